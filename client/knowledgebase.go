@@ -263,6 +263,18 @@ type SearchParams struct {
 	MatchCount           int     `json:"match_count"`
 	DisableKeywordsMatch bool    `json:"disable_keywords_match"`
 	DisableVectorMatch   bool    `json:"disable_vector_match"`
+	// Structured filter pushed down to retrieval
+	Filter *SearchFilter `json:"filter,omitempty"`
+}
+
+// SearchFilter restricts search results to knowledge matching structured conditions
+type SearchFilter struct {
+	TagIDs        []string          `json:"tag_ids,omitempty"`        // Tag IDs, sub-tags are included
+	FileTypes     []string          `json:"file_types,omitempty"`     // File types, "url" and "manual" match knowledge of that type
+	CreatedAfter  *time.Time        `json:"created_after,omitempty"`  // Only knowledge created at or after this time
+	CreatedBefore *time.Time        `json:"created_before,omitempty"` // Only knowledge created at or before this time
+	Metadata      map[string]string `json:"metadata,omitempty"`       // Knowledge metadata fields that must equal the values
+	SourceDomains []string          `json:"source_domains,omitempty"` // Source URL domains, subdomains are included
 }
 
 // HybridSearch performs hybrid search
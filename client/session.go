@@ -189,6 +189,8 @@ type KnowledgeQARequest struct {
 	WebSearchEnabled bool     `json:"web_search_enabled"` // Whether web search is enabled for this request
 	SummaryModelID   string   `json:"summary_model_id"`   // Optional summary model ID (overrides session default)
 	DisableTitle     bool     `json:"disable_title"`      // Whether to disable auto title generation
+	// Optional structured retrieval filter
+	Filter *SearchFilter `json:"filter,omitempty"`
 }
 
 // LLMToolCall represents a function/tool call from the LLM
@@ -409,6 +411,8 @@ type SearchKnowledgeRequest struct {
 	KnowledgeBaseID  string   `json:"knowledge_base_id,omitempty"`  // Single knowledge base ID (for backward compatibility)
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"` // Knowledge base IDs (multi-KB support)
 	KnowledgeIDs     []string `json:"knowledge_ids,omitempty"`      // Specific knowledge (file) IDs
+	// Optional structured retrieval filter
+	Filter *SearchFilter `json:"filter,omitempty"`
 }
 
 // SearchKnowledgeResponse search results response
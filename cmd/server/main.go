@@ -29,13 +29,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
 
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/container"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/router"
 	"github.com/Tencent/WeKnora/internal/runtime"
 	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -52,54 +53,84 @@ func main() {
 	// Build dependency injection container
 	c := container.BuildContainer(runtime.GetContainer())
 
+	// Restore a tenant backup and exit, see restore.go
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		os.Exit(runRestore(c, os.Args[2:]))
+	}
+
+	// Start background task workers and periodic tasks
+	if err := c.Invoke(router.RunAsynqServer); err != nil {
+		logger.Fatalf(context.Background(), "Failed to start task server: %v", err)
+	}
+	if err := c.Invoke(router.RunAsynqScheduler); err != nil {
+		logger.Fatalf(context.Background(), "Failed to start task scheduler: %v", err)
+	}
+
 	// Run application
 	err := c.Invoke(func(
 		cfg *config.Config,
-		router *gin.Engine,
+		engine *gin.Engine,
+		taskServer *asynq.Server,
+		healthService interfaces.HealthService,
 		tracer *tracing.Tracer,
 		resourceCleaner interfaces.ResourceCleaner,
 	) error {
-		// Create context for resource cleanup
-		shutdownTimeout := cfg.Server.ShutdownTimeout
-		if shutdownTimeout == 0 {
-			shutdownTimeout = 30 * time.Second
-		}
-		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), shutdownTimeout)
-		defer cleanupCancel()
-
-		// Register tracer cleanup function to resource cleaner
-		resourceCleaner.RegisterWithName("Tracer", func() error {
-			return tracer.Cleanup(cleanupCtx)
-		})
+		shutdownTimeout := cfg.Server.GetShutdownTimeout()
 
 		// Create HTTP server
 		server := &http.Server{
 			Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-			Handler: router,
+			Handler: engine,
+		}
+
+		// Prometheus metrics are served on their own listener when configured, see router.NewMetricsServer
+		metricsServer := router.NewMetricsServer(cfg)
+		if metricsServer != nil {
+			go func() {
+				logger.Infof(context.Background(), "Metrics server is running at %s", metricsServer.Addr)
+				if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logger.Errorf(context.Background(), "Metrics server stopped: %v", err)
+				}
+			}()
 		}
 
 		ctx, done := context.WithCancel(context.Background())
 		signals := make(chan os.Signal, 1)
-		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		// SIGHUP reloads the config file, see config.Watcher
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 		go func() {
 			sig := <-signals
 			logger.Infof(context.Background(), "Received signal: %v, starting server shutdown...", sig)
 
-			// Create a context with timeout for server shutdown
-			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer shutdownCancel()
+			// Fail readiness so orchestrators stop routing here, and stop taking new work: background tasks stay queued for the next start while requests drain
+			healthService.SetShuttingDown()
+			taskServer.Stop()
 
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer shutdownCancel()
 			if err := server.Shutdown(shutdownCtx); err != nil {
-				logger.Fatalf(context.Background(), "Server forced to shutdown: %v", err)
+				logger.Errorf(context.Background(), "Server forced to shutdown: %v", err)
+			}
+			if metricsServer != nil {
+				if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+					logger.Errorf(context.Background(), "Metrics server forced to shutdown: %v", err)
+				}
 			}
 
-			// Clean up all registered resources
+			// Clean up all registered resources, running background tasks are drained first
 			logger.Info(context.Background(), "Cleaning up resources...")
+			cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 2*shutdownTimeout)
+			defer cleanupCancel()
 			errs := resourceCleaner.Cleanup(cleanupCtx)
 			if len(errs) > 0 {
 				logger.Errorf(context.Background(), "Errors occurred during resource cleanup: %v", errs)
 			}
 
+			// Traces are flushed last so the spans of drained tasks are exported
+			if err := tracer.Cleanup(cleanupCtx); err != nil {
+				logger.Errorf(context.Background(), "Failed to flush traces: %v", err)
+			}
+
 			logger.Info(context.Background(), "Server has exited")
 			done()
 		}()
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/dig"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// runRestore restores a tenant backup archive into this deployment and prints the report as JSON.
+// It returns the exit code of the command.
+//
+//	WeKnora restore -file backup.zip [-sha256 <checksum>] [-verify-only]
+func runRestore(c *dig.Container, args []string) int {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	file := flags.String("file", "", "path of the backup archive")
+	checksum := flags.String("sha256", "", "expected SHA-256 checksum of the archive, as returned when downloading it")
+	verifyOnly := flags.Bool("verify-only", false, "check the archive without restoring it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "restore: -file is required")
+		flags.Usage()
+		return 2
+	}
+
+	var report *types.TenantRestoreReport
+	err := c.Invoke(func(service interfaces.TenantBackupService) error {
+		var err error
+		report, err = service.RestoreBackup(context.Background(), *file, types.TenantRestoreOptions{
+			SHA256:     *checksum,
+			VerifyOnly: *verifyOnly,
+		})
+		return err
+	})
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -1,15 +1,19 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/Tencent/WeKnora/docreader/proto"
+	"github.com/Tencent/WeKnora/internal/models/health"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/resolver"
 )
 
@@ -39,14 +43,22 @@ type ImageInfo struct {
 
 // Client represents a DocReader service client
 type Client struct {
-	conn *grpc.ClientConn
+	pool *connPool
 	proto.DocReaderClient
 	debug bool
 }
 
 // NewClient creates a new DocReader client with the specified address
 func NewClient(addr string) (*Client, error) {
-	Logger.Printf("INFO: Creating new DocReader client connecting to %s", addr)
+	return NewClientWithOptions(addr, DefaultOptions())
+}
+
+// NewClientWithOptions creates a new DocReader client with the specified address, spreading calls over
+// options.Connections connections and capping the calls in flight
+func NewClientWithOptions(addr string, options Options) (*Client, error) {
+	options = options.withDefaults()
+	Logger.Printf("INFO: Creating new DocReader client connecting to %s with %d connections, %d calls in flight",
+		addr, options.Connections, options.MaxInFlight)
 
 	// 设置消息大小限制 (configurable via GRPC_MAX_MESSAGE_SIZE_MB)
 	maxMsgSize := getMaxMessageSize()
@@ -57,28 +69,110 @@ func NewClient(addr string) (*Client, error) {
 			grpc.MaxCallRecvMsgSize(maxMsgSize),
 			grpc.MaxCallSendMsgSize(maxMsgSize),
 		),
+		grpc.WithUnaryInterceptor(tracingInterceptor),
 	}
 	resolver.SetDefaultScheme("dns")
 
 	startTime := time.Now()
-	conn, err := grpc.Dial("dns:///"+addr, opts...)
-	if err != nil {
-		Logger.Printf("ERROR: Failed to connect to DocReader service: %v", err)
-		return nil, err
+	pool := &connPool{
+		slots:        make(chan struct{}, options.MaxInFlight),
+		queueTimeout: options.QueueTimeout,
+		breaker:      health.NewRegistry(options.FailureThreshold, options.OpenDuration),
+	}
+	for i := 0; i < options.Connections; i++ {
+		conn, err := grpc.Dial("dns:///"+addr, opts...)
+		if err != nil {
+			Logger.Printf("ERROR: Failed to connect to DocReader service: %v", err)
+			_ = pool.Close()
+			return nil, err
+		}
+		pool.conns = append(pool.conns, conn)
 	}
 	Logger.Printf("INFO: Successfully connected to DocReader service in %v", time.Since(startTime))
 
 	return &Client{
-		conn:            conn,
-		DocReaderClient: proto.NewDocReaderClient(conn),
+		pool:            pool,
+		DocReaderClient: proto.NewDocReaderClient(pool),
 		debug:           false,
 	}, nil
 }
 
+// Ping checks the DocReader service is serving, using the standard gRPC health service it registers
+func (c *Client) Ping(ctx context.Context) error {
+	// The probe bypasses the pool so it reports DocReader itself, not a full queue or an open circuit
+	resp, err := healthpb.NewHealthClient(c.pool.pick()).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("docreader is %s", resp.GetStatus())
+	}
+	return nil
+}
+
+// ParserPluginFileTypes returns the file types DocReader parses with its parser plugins
+func (c *Client) ParserPluginFileTypes(ctx context.Context) ([]string, error) {
+	// Like Ping it bypasses the pool, a periodic lookup must not queue behind parses or trip the circuit
+	resp, err := proto.NewDocReaderClient(c.pool.pick()).ListParserPlugins(ctx, &proto.ListParserPluginsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var fileTypes []string
+	for _, plugin := range resp.GetPlugins() {
+		fileTypes = append(fileTypes, plugin.GetExtensions()...)
+	}
+	return fileTypes, nil
+}
+
+// ReadProgress returns the progress DocReader reported for the ReadFromFile call running with progressID.
+// Like Ping it bypasses the pool, polling must not queue behind the parse it follows.
+func (c *Client) ReadProgress(ctx context.Context, progressID string) (*proto.ReadProgress, error) {
+	return proto.NewDocReaderClient(c.pool.pick()).GetReadProgress(ctx, &proto.GetReadProgressRequest{
+		ProgressId: progressID,
+	})
+}
+
+// WatchParserPluginFileTypes passes the file types of DocReader's parser plugins to update now and then every
+// interval, so plugins added to DocReader are accepted without configuring them here. Lookups that fail keep the
+// previous file types. The returned function stops watching.
+func (c *Client) WatchParserPluginFileTypes(interval time.Duration, update func([]string)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last []string
+		listed, failing := false, false
+		for {
+			lookupCtx, lookupCancel := context.WithTimeout(ctx, 10*time.Second)
+			fileTypes, err := c.ParserPluginFileTypes(lookupCtx)
+			lookupCancel()
+			if err != nil {
+				// Logged once per outage, DocReader may start after this service
+				if !failing && ctx.Err() == nil {
+					Logger.Printf("WARN: Failed to list DocReader parser plugins: %v", err)
+				}
+				failing = true
+			} else {
+				if !listed || !slices.Equal(fileTypes, last) {
+					Logger.Printf("INFO: DocReader parser plugin file types: %v", fileTypes)
+				}
+				last, listed, failing = fileTypes, true, false
+				update(fileTypes)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
+
 // Close closes the client connection
 func (c *Client) Close() error {
-	Logger.Printf("INFO: Closing DocReader client connection")
-	return c.conn.Close()
+	Logger.Printf("INFO: Closing DocReader client connections")
+	return c.pool.Close()
 }
 
 // SetDebug enables or disables debug logging
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/models/health"
+	"github.com/Tencent/WeKnora/internal/slowlog"
+)
+
+// requestIDMetadataKey carries the request ID of a call in its metadata, for the requests that do not set it
+const requestIDMetadataKey = "x-request-id"
+
+// breakerKey is the key of the DocReader circuit in the breaker registry of a client
+const breakerKey = "docreader"
+
+// Options tunes how a client spreads calls over connections and protects DocReader under load
+type Options struct {
+	// Connections is the number of gRPC connections calls are spread over, 2 when unset
+	Connections int
+	// MaxInFlight caps the calls served at once, further calls wait for a slot; 16 when unset
+	MaxInFlight int
+	// QueueTimeout bounds the wait for a slot, calls waiting longer fail with ResourceExhausted; 2 minutes when unset
+	QueueTimeout time.Duration
+	// FailureThreshold is the number of consecutive failures of DocReader that opens the circuit, 5 when unset
+	FailureThreshold int
+	// OpenDuration is how long an open circuit fails calls fast before letting a probe through, 30 seconds when unset
+	OpenDuration time.Duration
+}
+
+// DefaultOptions returns the options used by NewClient
+func DefaultOptions() Options {
+	return Options{
+		Connections:      2,
+		MaxInFlight:      16,
+		QueueTimeout:     2 * time.Minute,
+		FailureThreshold: 5,
+		OpenDuration:     30 * time.Second,
+	}
+}
+
+// withDefaults fills the unset options
+func (o Options) withDefaults() Options {
+	d := DefaultOptions()
+	if o.Connections <= 0 {
+		o.Connections = d.Connections
+	}
+	if o.MaxInFlight <= 0 {
+		o.MaxInFlight = d.MaxInFlight
+	}
+	if o.QueueTimeout <= 0 {
+		o.QueueTimeout = d.QueueTimeout
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = d.FailureThreshold
+	}
+	if o.OpenDuration <= 0 {
+		o.OpenDuration = d.OpenDuration
+	}
+	return o
+}
+
+// connPool spreads calls over a few long-lived connections, caps the calls in flight and trips a circuit breaker
+// when DocReader keeps failing, so that a big batch ingest queues for DocReader instead of piling parse goroutines
+// on a service that is down
+type connPool struct {
+	conns        []*grpc.ClientConn
+	next         atomic.Uint64
+	slots        chan struct{}
+	queueTimeout time.Duration
+	breaker      *health.Registry
+}
+
+// pick returns the connection of the next call
+func (p *connPool) pick() *grpc.ClientConn {
+	return p.conns[p.next.Add(1)%uint64(len(p.conns))]
+}
+
+// Invoke makes a unary call once a slot is free and the circuit lets it through
+func (p *connPool) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	if p.circuitOpen() {
+		metrics.DocReaderCalls.WithLabelValues(method, metrics.ResultRejected).Inc()
+		return status.Error(codes.Unavailable, "docreader circuit is open after repeated failures")
+	}
+
+	release, err := p.acquire(ctx)
+	if err != nil {
+		metrics.DocReaderCalls.WithLabelValues(method, metrics.ResultRejected).Inc()
+		return err
+	}
+	defer release()
+
+	// The circuit may have opened while the call was queued
+	if !p.breaker.Allow(breakerKey) {
+		metrics.DocReaderCalls.WithLabelValues(method, metrics.ResultRejected).Inc()
+		return status.Error(codes.Unavailable, "docreader circuit is open after repeated failures")
+	}
+	start := time.Now()
+	err = p.pick().Invoke(withRequestID(ctx), method, args, reply, opts...)
+	slowlog.Observe(ctx, slowlog.KindDocReader, callDescription(method, args), start, err)
+	p.record(err)
+	metrics.DocReaderCalls.WithLabelValues(method, metrics.Result(err)).Inc()
+	return err
+}
+
+// NewStream opens a stream on the next connection, streams are not limited
+func (p *connPool) NewStream(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	method string,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	return p.pick().NewStream(withRequestID(ctx), desc, method, opts...)
+}
+
+// callDescription names a call in the slow log with the file or URL it parses
+func callDescription(method string, args any) string {
+	switch req := args.(type) {
+	case interface{ GetFileName() string }:
+		return method + " " + req.GetFileName()
+	case interface{ GetUrl() string }:
+		return method + " " + req.GetUrl()
+	}
+	return method
+}
+
+// withRequestID adds the request ID of ctx to the outgoing metadata, so that DocReader logs the call under it
+func withRequestID(ctx context.Context) context.Context {
+	if requestID := logger.GetRequestID(ctx); requestID != "" {
+		return metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, requestID)
+	}
+	return ctx
+}
+
+// acquire waits for an in-flight slot, up to the queue timeout
+func (p *connPool) acquire(ctx context.Context) (func(), error) {
+	release := func() {
+		<-p.slots
+		metrics.DocReaderInFlight.Dec()
+	}
+	start := time.Now()
+	select {
+	case p.slots <- struct{}{}:
+		metrics.DocReaderInFlight.Inc()
+		metrics.DocReaderQueueWait.Observe(0)
+		return release, nil
+	default:
+	}
+
+	metrics.DocReaderQueued.Inc()
+	defer metrics.DocReaderQueued.Dec()
+	timer := time.NewTimer(p.queueTimeout)
+	defer timer.Stop()
+	select {
+	case p.slots <- struct{}{}:
+		metrics.DocReaderInFlight.Inc()
+		metrics.DocReaderQueueWait.Observe(time.Since(start).Seconds())
+		return release, nil
+	case <-timer.C:
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"docreader is busy, no call slot freed within %v", p.queueTimeout)
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// circuitOpen reports whether the circuit rejects calls for now, without taking the probe of a half-open circuit
+func (p *connPool) circuitOpen() bool {
+	s := p.breaker.Status(breakerKey)
+	return s.State == health.StateOpen && s.OpenUntil != nil && time.Now().Before(*s.OpenUntil)
+}
+
+// record feeds the outcome of a call to the circuit. Only failures of DocReader itself count against it, a
+// document it cannot parse shows the service is up.
+func (p *connPool) record(err error) {
+	defer func() {
+		open := 0.0
+		if p.breaker.Status(breakerKey).State != health.StateClosed {
+			open = 1
+		}
+		metrics.DocReaderCircuitOpen.Set(open)
+	}()
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		p.breaker.RecordFailure(breakerKey, err)
+	case codes.Canceled:
+		// The caller went away, which says nothing about DocReader, but a half-open probe must end
+		if p.breaker.Status(breakerKey).State == health.StateHalfOpen {
+			p.breaker.RecordFailure(breakerKey, err)
+		}
+	default:
+		p.breaker.RecordSuccess(breakerKey)
+	}
+}
+
+// Close closes the connections of the pool
+func (p *connPool) Close() error {
+	var errs []error
+	for _, conn := range p.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tencent/WeKnora/internal/models/health"
+)
+
+func TestConnPoolQueueTimeout(t *testing.T) {
+	pool := &connPool{slots: make(chan struct{}, 1), queueTimeout: 20 * time.Millisecond}
+
+	release, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	if _, err := pool.acquire(context.Background()); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("acquire() on a full pool = %v, want ResourceExhausted", err)
+	}
+	release()
+	release, err = pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() after release error = %v", err)
+	}
+	release()
+}
+
+func TestConnPoolCircuit(t *testing.T) {
+	pool := &connPool{breaker: health.NewRegistry(2, time.Minute)}
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	pool.record(status.Error(codes.InvalidArgument, "unsupported file"))
+	pool.record(unavailable)
+	if pool.circuitOpen() {
+		t.Fatal("circuit open after one failure, want closed")
+	}
+	pool.record(status.Error(codes.Canceled, "canceled"))
+	pool.record(unavailable)
+	if !pool.circuitOpen() {
+		t.Fatal("circuit closed after two consecutive failures, want open")
+	}
+}
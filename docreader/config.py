@@ -1,3 +1,4 @@
+import json
 import logging
 import os
 from dataclasses import dataclass
@@ -45,6 +46,21 @@ def _mask_secret(v: str) -> str:
     return f"{v[:2]}***{v[-2:]}"
 
 
+def _mask_parser_plugins(v: str) -> str:
+    """Mask the header values of inline parser plugins, they usually hold
+    credentials. A file path is shown as is, unparsable JSON is masked whole."""
+    if not v.strip().startswith("["):
+        return v
+    try:
+        plugins = json.loads(v)
+        for plugin in plugins:
+            headers = plugin.get("headers") or {}
+            plugin["headers"] = {k: _mask_secret(str(h)) for k, h in headers.items()}
+    except (AttributeError, TypeError, ValueError):
+        return _mask_secret(v)
+    return json.dumps(plugins, ensure_ascii=False)
+
+
 @dataclass(frozen=True)
 class DocReaderConfig:
     # gRPC
@@ -55,6 +71,13 @@ class DocReaderConfig:
     # Image processing
     image_max_concurrent: int
 
+    # External converters (LibreOffice)
+    convert_max_workers: int
+    convert_max_queue: int
+    convert_queue_timeout: int
+    convert_timeout: int
+    convert_max_output_mb: int
+
     # Proxy
     external_http_proxy: str
     external_https_proxy: str
@@ -94,6 +117,7 @@ class DocReaderConfig:
 
     # Other
     mineru_endpoint: str
+    parser_plugins: str
 
 
 def load_config() -> DocReaderConfig:
@@ -117,6 +141,13 @@ def load_config() -> DocReaderConfig:
         ["DOCREADER_IMAGE_MAX_CONCURRENT", "IMAGE_MAX_CONCURRENT"], 1
     )
 
+    # External converters
+    convert_max_workers = _get_int(["DOCREADER_CONVERT_MAX_WORKERS"], 2)
+    convert_max_queue = _get_int(["DOCREADER_CONVERT_MAX_QUEUE"], 16)
+    convert_queue_timeout = _get_int(["DOCREADER_CONVERT_QUEUE_TIMEOUT"], 300)
+    convert_timeout = _get_int(["DOCREADER_CONVERT_TIMEOUT"], 120)
+    convert_max_output_mb = _get_int(["DOCREADER_CONVERT_MAX_OUTPUT_MB"], 200)
+
     # Proxies
     external_http_proxy = _get_str(
         ["DOCREADER_EXTERNAL_HTTP_PROXY", "EXTERNAL_HTTP_PROXY"], ""
@@ -181,12 +212,19 @@ def load_config() -> DocReaderConfig:
 
     # Other
     mineru_endpoint = _get_str(["DOCREADER_MINERU_ENDPOINT", "MINERU_ENDPOINT"], "")
+    # JSON list of parser plugins, or the path of a JSON file with the list
+    parser_plugins = _get_str(["DOCREADER_PARSER_PLUGINS"], "")
 
     return DocReaderConfig(
         grpc_max_workers=grpc_max_workers,
         grpc_max_file_size_mb=grpc_max_file_size_mb,
         grpc_port=grpc_port,
         image_max_concurrent=image_max_concurrent,
+        convert_max_workers=convert_max_workers,
+        convert_max_queue=convert_max_queue,
+        convert_queue_timeout=convert_queue_timeout,
+        convert_timeout=convert_timeout,
+        convert_max_output_mb=convert_max_output_mb,
         external_http_proxy=external_http_proxy,
         external_https_proxy=external_https_proxy,
         ocr_backend=ocr_backend,
@@ -214,6 +252,7 @@ def load_config() -> DocReaderConfig:
         minio_use_ssl=minio_use_ssl,
         local_storage_base_dir=local_storage_base_dir,
         mineru_endpoint=mineru_endpoint,
+        parser_plugins=parser_plugins,
     )
 
 
@@ -229,6 +268,12 @@ def dump_config(mask_secrets: bool = True) -> Dict[str, Any]:
         "DOCREADER_GRPC_PORT": cfg.grpc_port,
         # Image processing
         "DOCREADER_IMAGE_MAX_CONCURRENT": cfg.image_max_concurrent,
+        # External converters
+        "DOCREADER_CONVERT_MAX_WORKERS": cfg.convert_max_workers,
+        "DOCREADER_CONVERT_MAX_QUEUE": cfg.convert_max_queue,
+        "DOCREADER_CONVERT_QUEUE_TIMEOUT": cfg.convert_queue_timeout,
+        "DOCREADER_CONVERT_TIMEOUT": cfg.convert_timeout,
+        "DOCREADER_CONVERT_MAX_OUTPUT_MB": cfg.convert_max_output_mb,
         # Proxy
         "DOCREADER_EXTERNAL_HTTP_PROXY": cfg.external_http_proxy,
         "DOCREADER_EXTERNAL_HTTPS_PROXY": cfg.external_https_proxy,
@@ -273,6 +318,9 @@ def dump_config(mask_secrets: bool = True) -> Dict[str, Any]:
         "DOCREADER_LOCAL_STORAGE_BASE_DIR": cfg.local_storage_base_dir,
         # Other
         "DOCREADER_MINERU_ENDPOINT": cfg.mineru_endpoint,
+        "DOCREADER_PARSER_PLUGINS": _mask_parser_plugins(cfg.parser_plugins)
+        if mask_secrets
+        else cfg.parser_plugins,
     }
     return d
 
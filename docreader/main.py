@@ -1,3 +1,4 @@
+import json
 import logging
 import os
 import re
@@ -19,12 +20,18 @@ from docreader.proto import docreader_pb2_grpc
 from docreader.proto.docreader_pb2 import (
     Chunk,
     Image,
+    GetReadProgressRequest,
+    ListParserPluginsRequest,
+    ListParserPluginsResponse,
+    ParserPlugin,
     ReadConfig,
     ReadFromFileRequest,
     ReadFromURLRequest,
+    ReadProgress,
     ReadResponse,
     StorageProvider,
 )
+from docreader.utils.progress import get_progress, progress_context
 from docreader.utils.request import init_logging_request_id, request_id_context
 
 # Surrogate range U+D800..U+DFFF are invalid Unicode scalar values
@@ -108,14 +115,29 @@ def create_chunking_config(read_config: ReadConfig):
         "base_url": read_config.vlm_config.base_url,
         "api_key": read_config.vlm_config.api_key,
         "interface_type": read_config.vlm_config.interface_type or "openai",
+        "prompt": read_config.vlm_config.prompt,
+        "max_images": read_config.vlm_config.max_images,
     }
 
     logger.info(
         f"Using VLM config: model={vlm_config['model_name']}, "
         f"base_url={vlm_config['base_url']}, "
-        f"interface_type={vlm_config['interface_type']}"
+        f"interface_type={vlm_config['interface_type']}, "
+        f"custom_prompt={bool(vlm_config['prompt'])}, "
+        f"max_images={vlm_config['max_images'] or 'unlimited'}"
     )
 
+    # Extract OCR config, an empty engine falls back to the service default
+    ocr_config = {
+        "engine": read_config.ocr_config.engine,
+        "languages": list(read_config.ocr_config.languages),
+    }
+    if ocr_config["engine"] or ocr_config["languages"]:
+        logger.info(
+            f"Using OCR config: engine={ocr_config['engine'] or 'default'}, "
+            f"languages={ocr_config['languages']}"
+        )
+
     # Create and return ChunkingConfig
     return ChunkingConfig(
         chunk_size=chunk_size,
@@ -124,9 +146,20 @@ def create_chunking_config(read_config: ReadConfig):
         enable_multimodal=enable_multimodal,
         storage_config=storage_config,
         vlm_config=vlm_config,
+        ocr_config=ocr_config,
     )
 
 
+def _request_id(request, context) -> str:
+    """Request ID of a call: the request field, else the x-request-id metadata, else a new one"""
+    if getattr(request, "request_id", ""):
+        return request.request_id
+    for key, value in context.invocation_metadata() or ():
+        if key == "x-request-id" and value:
+            return value
+    return str(uuid.uuid4())
+
+
 class DocReaderServicer(docreader_pb2_grpc.DocReaderServicer):
     def __init__(self):
         super().__init__()
@@ -134,14 +167,10 @@ class DocReaderServicer(docreader_pb2_grpc.DocReaderServicer):
 
     def ReadFromFile(self, request: ReadFromFileRequest, context):
         # Get or generate request ID
-        request_id = (
-            request.request_id
-            if hasattr(request, "request_id") and request.request_id
-            else str(uuid.uuid4())
-        )
+        request_id = _request_id(request, context)
 
-        # Use request ID context
-        with request_id_context(request_id):
+        # Use request ID context, progress is recorded while the file is read
+        with request_id_context(request_id), progress_context(request.progress_id):
             try:
                 # Get file type
                 file_type = (
@@ -192,11 +221,7 @@ class DocReaderServicer(docreader_pb2_grpc.DocReaderServicer):
 
     def ReadFromURL(self, request: ReadFromURLRequest, context):
         # Get or generate request ID
-        request_id = (
-            request.request_id
-            if hasattr(request, "request_id") and request.request_id
-            else str(uuid.uuid4())
-        )
+        request_id = _request_id(request, context)
 
         # Use request ID context
         with request_id_context(request_id):
@@ -206,10 +231,38 @@ class DocReaderServicer(docreader_pb2_grpc.DocReaderServicer):
                 # Create chunking config
                 chunking_config = create_chunking_config(request.read_config)
 
+                # Extraction mode and the model of the llm mode
+                llm_config = {
+                    "model_name": request.llm_config.model_name,
+                    "base_url": request.llm_config.base_url,
+                    "api_key": request.llm_config.api_key,
+                    "interface_type": request.llm_config.interface_type or "openai",
+                }
+                logger.info(
+                    f"Using extraction mode: {request.extraction_mode or 'readability'}, "
+                    f"cleanup model: {llm_config['model_name'] or 'none'}"
+                )
+
+                # User-Agent, headers and cookies the site needs, values are secrets
+                fetch_config = {
+                    "user_agent": request.fetch_config.user_agent,
+                    "headers": {h.name: h.value for h in request.fetch_config.headers},
+                    "cookies": {c.name: c.value for c in request.fetch_config.cookies},
+                }
+                logger.info(
+                    f"Fetching with {len(fetch_config['headers'])} extra headers "
+                    f"and {len(fetch_config['cookies'])} cookies"
+                )
+
                 # Parse URL
                 logger.info("Starting URL parsing process")
                 result = self.parser.parse_url(
-                    request.url, request.title, chunking_config
+                    request.url,
+                    request.title,
+                    chunking_config,
+                    extraction_mode=request.extraction_mode,
+                    llm_config=llm_config,
+                    fetch_config=fetch_config,
                 )
                 if not result:
                     error_msg = "Failed to parse URL"
@@ -239,6 +292,26 @@ class DocReaderServicer(docreader_pb2_grpc.DocReaderServicer):
                 context.set_details(str(e))
                 return ReadResponse(error=str(e))
 
+    def ListParserPlugins(self, request: ListParserPluginsRequest, context):
+        """The parser plugins and their file types, the main service accepts
+        uploads of these types"""
+        return ListParserPluginsResponse(
+            plugins=[
+                ParserPlugin(name=plugin.name, extensions=plugin.extensions)
+                for plugin in self.parser.plugins
+            ]
+        )
+
+    def GetReadProgress(self, request: GetReadProgressRequest, context):
+        """Progress of the ReadFromFile call running with the progress ID, an
+        empty stage when it reported none or is not running here"""
+        progress = get_progress(request.progress_id)
+        if progress is None:
+            return ReadProgress()
+        return ReadProgress(
+            stage=progress.stage, current=progress.current, total=progress.total
+        )
+
     def _convert_chunk_to_proto(self, chunk):
         """Convert internal Chunk object to protobuf Chunk message
         Ensures all string fields are valid UTF-8 for protobuf (no lone surrogates).
@@ -252,6 +325,8 @@ class DocReaderServicer(docreader_pb2_grpc.DocReaderServicer):
             start=getattr(chunk, "start", 0),
             end=getattr(chunk, "end", 0),
         )
+        if getattr(chunk, "metadata", None):
+            proto_chunk.metadata = _c(json.dumps(chunk.metadata, ensure_ascii=False))
 
         # If chunk has images attribute and is not empty, add image info
         if hasattr(chunk, "images") and chunk.images:
@@ -267,6 +342,7 @@ class DocReaderServicer(docreader_pb2_grpc.DocReaderServicer):
                     original_url=_c(img_info.get("original_url", "")),
                     start=int(img_info.get("start", 0) or 0),
                     end=int(img_info.get("end", 0) or 0),
+                    ocr_engine=_c(img_info.get("ocr_engine", "")),
                 )
                 proto_chunk.images.append(proto_image)
 
@@ -23,5 +23,8 @@ class ChunkingConfig:
     # Preferred field name going forward
     storage_config: dict[str, str] = field(default_factory=dict)
 
-    # VLM configuration for image captioning
-    vlm_config: dict[str, str] = field(default_factory=dict)
+    # VLM configuration for image captioning: endpoint, prompt and max_images
+    vlm_config: dict = field(default_factory=dict)
+
+    # OCR configuration: engine name and ISO 639-1 languages
+    ocr_config: dict = field(default_factory=dict)
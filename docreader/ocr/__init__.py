@@ -1,37 +1,76 @@
+import hashlib
 import logging
 import threading
-from typing import Dict
+from collections import OrderedDict
+from typing import Dict, List, Optional
 
 from docreader.ocr.base import DummyOCRBackend, OCRBackend
 from docreader.ocr.paddle import PaddleOCRBackend
+from docreader.ocr.tesseract import TesseractOCRBackend
 from docreader.ocr.vlm import VLMOCRBackend
 
 logger = logging.getLogger(__name__)
 
 
+# Most cached OCR backends, the least recently used one is dropped beyond it.
+# Requests naming their own VLM endpoint and key each get a backend.
+MAX_OCR_INSTANCES = 16
+
+
 class OCREngine:
     """OCR Engine factory class for managing different OCR backend instances"""
 
-    _instances: Dict[str, OCRBackend] = {}
+    _instances: "OrderedDict[str, OCRBackend]" = OrderedDict()
     _lock = threading.Lock()
 
     @classmethod
-    def get_instance(cls, backend_type: str) -> OCRBackend:
+    def get_instance(
+        cls,
+        backend_type: str,
+        languages: Optional[List[str]] = None,
+        vlm_config: Optional[Dict[str, str]] = None,
+    ) -> OCRBackend:
+        """Get a cached OCR backend instance
+
+        Args:
+            backend_type: OCR engine type, one of "paddle", "tesseract" or "vlm"
+            languages: ISO 639-1 language codes, empty for the engine default
+            vlm_config: VLM settings of the request, only used by the vlm engine
+
+        Returns:
+            OCR backend instance, shared by all requests with the same settings
+        """
         backend_type = (backend_type or "dummy").lower()
+        languages = [lang.lower() for lang in languages or [] if lang]
+        key = backend_type
+        if languages:
+            key += ":" + "+".join(languages)
+        if backend_type == "vlm" and vlm_config and vlm_config.get("model_name"):
+            key += f":{vlm_config.get('base_url')}:{vlm_config['model_name']}"
+            key += f":{vlm_config.get('interface_type') or 'openai'}"
+            # Requests with another API key must not reuse a client holding this one
+            api_key = vlm_config.get("api_key") or ""
+            key += ":" + hashlib.sha256(api_key.encode()).hexdigest()[:16]
 
         with cls._lock:
-            inst = cls._instances.get(backend_type)
+            inst = cls._instances.get(key)
             if inst is not None:
+                cls._instances.move_to_end(key)
                 return inst
 
-            logger.info(f"Creating OCR engine instance for backend: {backend_type}")
+            logger.info(f"Creating OCR engine instance for backend: {key}")
 
             if backend_type == "paddle":
-                inst = PaddleOCRBackend()
+                inst = PaddleOCRBackend(languages=languages)
+            elif backend_type == "tesseract":
+                inst = TesseractOCRBackend(languages=languages)
             elif backend_type == "vlm":
-                inst = VLMOCRBackend()
+                inst = VLMOCRBackend(languages=languages, vlm_config=vlm_config)
             else:
                 inst = DummyOCRBackend()
 
-            cls._instances[backend_type] = inst
+            cls._instances[key] = inst
+            while len(cls._instances) > MAX_OCR_INSTANCES:
+                evicted, _ = cls._instances.popitem(last=False)
+                logger.info(f"Dropped least recently used OCR engine instance {evicted}")
             return inst
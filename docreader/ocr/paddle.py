@@ -3,7 +3,7 @@ import logging
 import os
 import platform
 import subprocess
-from typing import Union
+from typing import List, Optional, Union
 
 import numpy as np
 from PIL import Image
@@ -12,13 +12,44 @@ from docreader.ocr.base import OCRBackend
 
 logger = logging.getLogger(__name__)
 
+# ISO 639-1 codes to PaddleOCR language names
+# ISO 639-1 语言代码到 PaddleOCR 语言名称的映射
+PADDLE_LANGUAGES = {
+    "zh": "ch",
+    "zh-cn": "ch",
+    "zh-hans": "ch",
+    "zh-tw": "chinese_cht",
+    "zh-hant": "chinese_cht",
+    "en": "en",
+    "ja": "japan",
+    "ko": "korean",
+    "fr": "fr",
+    "de": "german",
+    "es": "es",
+    "pt": "pt",
+    "it": "it",
+    "ru": "ru",
+    "ar": "ar",
+    "hi": "hi",
+    "vi": "vi",
+}
+
 
 class PaddleOCRBackend(OCRBackend):
     """PaddleOCR backend implementation"""
 
-    def __init__(self):
-        """Initialize PaddleOCR backend"""
+    def __init__(self, languages: Optional[List[str]] = None):
+        """Initialize PaddleOCR backend
+
+        Args:
+            languages: ISO 639-1 language codes. PaddleOCR recognizes one language
+                per model, so only the first one is used; defaults to Chinese,
+                which also covers English.
+        """
         self.ocr = None
+        self.lang = "ch"
+        if languages:
+            self.lang = PADDLE_LANGUAGES.get(languages[0].lower(), languages[0])
         try:
             import paddle
 
@@ -83,14 +114,26 @@ class PaddleOCRBackend(OCRBackend):
                 "text_det_unclip_ratio": 1.5,
                 "text_rec_score_thresh": 0.0,
                 "ocr_version": "PP-OCRv4",
-                "lang": "ch",
+                "lang": self.lang,
                 "show_log": False,
                 "use_dilation": True,  # improves accuracy
                 "det_db_score_mode": "slow",  # improves accuracy
             }
+            if self.lang != "ch":
+                # The server models are Chinese only, let PaddleOCR pick the
+                # default models of the language
+                # 服务端模型仅支持中文，其他语言使用 PaddleOCR 的默认模型
+                for key in (
+                    "text_recognition_model_name",
+                    "text_detection_model_name",
+                    "ocr_version",
+                ):
+                    ocr_config.pop(key)
 
             self.ocr = PaddleOCR(**ocr_config)
-            logger.info("PaddleOCR engine initialized successfully")
+            logger.info(
+                f"PaddleOCR engine initialized successfully, lang: {self.lang}"
+            )
 
         except ImportError as e:
             logger.error(
@@ -0,0 +1,113 @@
+import io
+import logging
+import shutil
+import subprocess
+from typing import List, Optional, Union
+
+from PIL import Image
+
+from docreader.ocr.base import OCRBackend
+
+logger = logging.getLogger(__name__)
+
+# ISO 639-1 codes to Tesseract traineddata names
+# ISO 639-1 语言代码到 Tesseract 语言包名称的映射
+TESSERACT_LANGUAGES = {
+    "zh": "chi_sim",
+    "zh-cn": "chi_sim",
+    "zh-hans": "chi_sim",
+    "zh-tw": "chi_tra",
+    "zh-hant": "chi_tra",
+    "en": "eng",
+    "ja": "jpn",
+    "ko": "kor",
+    "fr": "fra",
+    "de": "deu",
+    "es": "spa",
+    "pt": "por",
+    "it": "ita",
+    "ru": "rus",
+    "ar": "ara",
+    "hi": "hin",
+    "th": "tha",
+    "vi": "vie",
+}
+
+
+class TesseractOCRBackend(OCRBackend):
+    """Tesseract OCR backend implementation using the tesseract command line"""
+
+    def __init__(self, languages: Optional[List[str]] = None, timeout: int = 60):
+        """Initialize Tesseract OCR backend
+
+        Args:
+            languages: ISO 639-1 language codes, defaults to Chinese and English
+            timeout: Timeout of a single recognition in seconds
+        """
+        self.binary = shutil.which("tesseract")
+        self.timeout = timeout
+        langs = []
+        for lang in languages or ["zh", "en"]:
+            name = TESSERACT_LANGUAGES.get(lang.lower(), lang)
+            if name not in langs:
+                langs.append(name)
+        self.lang = "+".join(langs)
+
+        if self.binary is None:
+            logger.error(
+                "tesseract executable not found, "
+                "please install it with 'apt-get install tesseract-ocr'"
+            )
+        else:
+            logger.info(f"Tesseract OCR engine initialized, languages: {self.lang}")
+
+    def predict(self, image: Union[str, bytes, Image.Image]) -> str:
+        """Extract text from an image
+
+        Args:
+            image: Image file path, bytes, or PIL Image object
+
+        Returns:
+            Extracted text
+        """
+        if self.binary is None:
+            logger.error("Tesseract OCR engine not initialized")
+            return ""
+
+        if isinstance(image, str):
+            image = Image.open(image)
+        elif isinstance(image, bytes):
+            image = Image.open(io.BytesIO(image))
+
+        if not isinstance(image, Image.Image):
+            raise TypeError("image must be a string, bytes, or PIL Image object")
+
+        try:
+            if image.mode != "RGB":
+                image = image.convert("RGB")
+            buf = io.BytesIO()
+            image.save(buf, format="PNG")
+
+            result = subprocess.run(
+                [self.binary, "stdin", "stdout", "-l", self.lang],
+                input=buf.getvalue(),
+                capture_output=True,
+                timeout=self.timeout,
+            )
+            if result.returncode != 0:
+                logger.error(
+                    f"Tesseract OCR failed: {result.stderr.decode(errors='ignore')}"
+                )
+                return ""
+
+            lines = result.stdout.decode("utf-8", errors="ignore").splitlines()
+            ocr_text = " ".join(line.strip() for line in lines if line.strip())
+            logger.info(f"OCR extracted {len(ocr_text)} characters")
+            return ocr_text
+
+        except subprocess.TimeoutExpired:
+            logger.error(f"Tesseract OCR timed out after {self.timeout} seconds")
+            return ""
+        except Exception as e:
+            logger.error(f"OCR recognition error: {str(e)}")
+            return ""
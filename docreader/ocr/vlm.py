@@ -1,5 +1,5 @@
 import logging
-from typing import Union
+from typing import Dict, List, Optional, Union
 
 from openai import OpenAI
 from PIL import Image
@@ -14,18 +14,35 @@ logger = logging.getLogger(__name__)
 class VLMOCRBackend(OCRBackend):
     """VLM OCR backend implementation using OpenAI API format"""
 
-    def __init__(self):
+    def __init__(
+        self,
+        languages: Optional[List[str]] = None,
+        vlm_config: Optional[Dict[str, str]] = None,
+    ):
         """Initialize VLM OCR backend
 
         Args:
-            api_key: API key for OpenAI API
-            base_url: Base URL for OpenAI API
-            model: Model name
+            languages: ISO 639-1 codes of the expected document languages
+            vlm_config: VLM settings of the request; the OCR settings of the
+                service are used unless it is a complete OpenAI-compatible config
         """
-        self.model = CONFIG.ocr_model
+        model = CONFIG.ocr_model
+        api_key = CONFIG.ocr_api_key
+        base_url = CONFIG.ocr_api_base_url
+        vlm_config = vlm_config or {}
+        if (
+            vlm_config.get("model_name")
+            and vlm_config.get("base_url")
+            and vlm_config.get("interface_type", "openai") == "openai"
+        ):
+            model = vlm_config["model_name"]
+            api_key = vlm_config.get("api_key") or ""
+            base_url = vlm_config["base_url"]
+
+        self.model = model
         self.client = OpenAI(
-            api_key=CONFIG.ocr_api_key,
-            base_url=CONFIG.ocr_api_base_url,
+            api_key=api_key,
+            base_url=base_url,
             timeout=30,
         )
         self.temperature = 0.0
@@ -37,6 +54,8 @@ class VLMOCRBackend(OCRBackend):
         "表格用html格式表达，"
         "文档中公式用latex格式表示，"
         "按照阅读顺序组织进行解析。"
+        if languages:
+            self.prompt += f"文档语言可能为：{', '.join(languages)}。"
 
     def predict(self, image: Union[str, bytes, Image.Image]) -> str:
         """Extract text from an image using VLM OCR
@@ -5,6 +5,7 @@ import ipaddress
 import logging
 import os
 import re
+import threading
 import time
 from abc import ABC, abstractmethod
 from typing import Dict, List, Optional, Tuple
@@ -19,6 +20,7 @@ from docreader.models.read_config import ChunkingConfig
 from docreader.ocr import OCREngine
 from docreader.parser.caption import Caption
 from docreader.parser.storage import create_storage
+from docreader.splitter.anchors import attach_source_anchors
 from docreader.splitter.splitter import TextSplitter
 from docreader.utils import endecode
 
@@ -33,6 +35,10 @@ class BaseParser(ABC):
     _ocr_engine = None
     _ocr_engine_failed = False
 
+    # Process images in multimodal mode regardless of the file extension,
+    # for parsers without one such as web pages
+    always_process_images = False
+
     @staticmethod
     def _is_safe_url(url: str) -> bool:
         """Validate URL to prevent SSRF attacks
@@ -181,6 +187,11 @@ class BaseParser(ABC):
         self.caption_parser = (
             Caption(vlm_config=vlm_config) if self.enable_multimodal else None
         )
+        # Number of images of this document the VLM may still describe,
+        # None means no limit
+        max_images = int((vlm_config or {}).get("max_images") or 0)
+        self._vlm_images_left = max_images if max_images > 0 else None
+        self._vlm_images_lock = threading.Lock()
 
     @abstractmethod
     def parse_into_text(self, content: bytes) -> Document:
@@ -210,7 +221,13 @@ class BaseParser(ABC):
         resized_image = self._resize_image_if_needed(image)
 
         # Get OCR engine
-        ocr_engine = OCREngine.get_instance(self.ocr_backend)
+        ocr_engine = OCREngine.get_instance(
+            self.ocr_backend,
+            languages=self.ocr_config.get("languages"),
+            vlm_config=(
+                self.chunking_config.vlm_config if self.chunking_config else None
+            ),
+        )
 
         # Execute OCR prediction
         logger.info(f"Executing OCR prediction (using {self.ocr_backend} engine)")
@@ -262,21 +279,32 @@ class BaseParser(ABC):
 
         # Resize image
         resized_image = self._resize_image_if_needed(image)
+        # Images beyond the VLM image limit are not described, nor OCRed by a VLM
+        use_vlm = self._take_vlm_image()
         try:
             # Perform OCR recognition
             loop = asyncio.get_event_loop()
-            try:
-                # Add timeout mechanism to avoid infinite blocking (30 seconds timeout)
-                ocr_task = loop.run_in_executor(None, self.perform_ocr, resized_image)
-                ocr_text = await asyncio.wait_for(ocr_task, timeout=30.0)
-            except Exception as e:
-                logger.error(f"OCR processing error, skipping this image: {str(e)}")
-                ocr_text = ""
+            ocr_text = ""
+            if use_vlm or self.ocr_backend != "vlm":
+                try:
+                    # Add timeout mechanism to avoid infinite blocking (30 seconds timeout)
+                    ocr_task = loop.run_in_executor(
+                        None, self.perform_ocr, resized_image
+                    )
+                    ocr_text = await asyncio.wait_for(ocr_task, timeout=30.0)
+                except Exception as e:
+                    logger.error(
+                        f"OCR processing error, skipping this image: {str(e)}"
+                    )
 
             logger.info(f"Successfully obtained image ocr: {ocr_text}")
-            img_base64 = endecode.decode_image(resized_image)
-            caption = self.get_image_caption(img_base64)
-            logger.info(f"Successfully obtained image caption: {caption}")
+            caption = ""
+            if use_vlm:
+                img_base64 = endecode.decode_image(resized_image)
+                caption = self.get_image_caption(img_base64)
+                logger.info(f"Successfully obtained image caption: {caption}")
+            else:
+                logger.info("VLM image limit of the document reached, skip caption")
             return ocr_text, caption, image_url
         finally:
             resized_image.close()
@@ -355,6 +383,20 @@ class BaseParser(ABC):
         )
         return results
 
+    def _take_vlm_image(self) -> bool:
+        """Reserve one image of the document's VLM image limit
+
+        Returns:
+            False when the limit is used up
+        """
+        with self._vlm_images_lock:
+            if self._vlm_images_left is None:
+                return True
+            if self._vlm_images_left <= 0:
+                return False
+            self._vlm_images_left -= 1
+            return True
+
     def get_image_caption(self, image_data: str) -> str:
         """Get image description
 
@@ -410,6 +452,14 @@ class BaseParser(ABC):
         chunk_str = splitter.split_text(document.content)
         chunks = self._str_to_chunk(chunk_str)
         logger.info(f"Created {len(chunks)} chunks from document")
+        # Record pages, heading paths and URL fragments so answers can cite
+        # where a chunk comes from
+        attach_source_anchors(
+            document.content,
+            chunks,
+            document.metadata.get("heading_ids"),
+            document.metadata.get("page_markers"),
+        )
 
         # Limit the number of returned chunks
         if len(chunks) > self.max_chunks:
@@ -435,6 +485,7 @@ class BaseParser(ABC):
                 ".markdown",
                 ".doc",
                 ".docx",
+                ".ipynb",
                 # Image files
                 ".jpg",
                 ".jpeg",
@@ -445,7 +496,7 @@ class BaseParser(ABC):
                 ".webp",
             ]
 
-            if file_ext in allowed_types:
+            if file_ext in allowed_types or self.always_process_images:
                 logger.info(
                     f"Processing images in each chunk for file type: {file_ext}"
                 )
@@ -907,6 +958,7 @@ class BaseParser(ABC):
             for orig_url, info in url_to_info_map.items():
                 if info.get("cos_url") == img_url:
                     info["ocr_text"] = ocr_text if ocr_text else ""
+                    info["ocr_engine"] = self.ocr_backend if ocr_text else ""
                     info["caption"] = caption if caption else ""
 
                     if ocr_text:
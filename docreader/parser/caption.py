@@ -176,14 +176,24 @@ class Caption:
     Uses an external API to process images and return textual descriptions.
     """
 
-    def __init__(self, vlm_config: Optional[Dict[str, str]] = None):
+    def __init__(self, vlm_config: Optional[Dict] = None):
         """
         Initialize the Caption service with configuration
         from parameters or environment variables.
         """
         logger.info("Initializing Caption service")
-        # Default prompt for image captioning in Chinese: "Briefly describe the main content of the image"
-        self.prompt = """简单凝炼的描述图片的主要内容"""
+        # Prompt for image captioning in Chinese. Descriptions are indexed for
+        # retrieval, so charts and diagrams are described by their content
+        # rather than their appearance
+        self.prompt = (
+            "用一段话描述图片，用于检索。先说明图片类型（照片、图表、流程图、架构图、"
+            "表格、截图等）和主题；图表需说明坐标轴、数据系列、关键数值和趋势；"
+            "流程图或架构图需说明主要组成部分及其关系；截图需说明界面及关键信息。"
+            "不要逐字抄录图片中的全部文字，不超过200字。"
+        )
+        # A knowledge base can replace the default prompt
+        if vlm_config and vlm_config.get("prompt"):
+            self.prompt = vlm_config["prompt"]
         # API request timeout in seconds
         self.timeout = 30
 
@@ -251,10 +261,9 @@ class Caption:
             logger.info(f"Calling Ollama API with model: {self.model}")
 
             # Call Ollama API with base64 encoded image
-            # Prompt: "Briefly describe the main content of the image"
             response = client.generate(
                 model=self.model,
-                prompt="简单凝炼的描述图片的主要内容",
+                prompt=self.prompt,
                 images=[image_base64],  # Pass base64 encoded image data
                 options={
                     "temperature": 0.1
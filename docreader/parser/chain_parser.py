@@ -7,7 +7,7 @@ This module provides two chain-of-responsibility pattern implementations for doc
 """
 
 import logging
-from typing import Dict, List, Tuple, Type
+from typing import Any, Dict, List, Tuple, Type
 
 from docreader.models.document import Document
 from docreader.parser.base_parser import BaseParser
@@ -123,17 +123,19 @@ class PipelineParser(BaseParser):
         """Parse content through a pipeline of parsers.
 
         Each parser in the pipeline processes the output of the previous parser.
-        Images from all parsers are accumulated and merged into the final document.
+        Images and metadata from all parsers are accumulated and merged into the
+        final document.
 
         Args:
             content: Raw bytes content to be parsed
 
         Returns:
             Document: Final document after processing through all parsers,
-                     with accumulated images from all stages
+                     with accumulated images and metadata from all stages
         """
-        # Accumulate images from all parsers
+        # Accumulate images and metadata from all parsers
         images: Dict[str, str] = {}
+        metadata: Dict[str, Any] = {}
         document = Document()
         for p in self._parsers:
             logger.info(f"PipelineParser: using parser {p.__class__.__name__}")
@@ -143,8 +145,10 @@ class PipelineParser(BaseParser):
             content = endecode.encode_bytes(document.content)
             # Accumulate images from this parser
             images.update(document.images)
-        # Merge all accumulated images into final document
+            metadata.update(document.metadata)
+        # Merge all accumulated images and metadata into final document
         document.images.update(images)
+        document.metadata = metadata
         return document
 
     @classmethod
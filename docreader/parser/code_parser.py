@@ -0,0 +1,413 @@
+import logging
+import os
+import re
+from typing import Dict, List, Optional, Pattern, Tuple
+
+from docreader.models.document import Chunk, Document
+from docreader.parser.base_parser import BaseParser
+from docreader.utils import endecode
+
+logger = logging.getLogger(__name__)
+
+# Source file extensions and the language they are indexed as
+CODE_LANGUAGES: Dict[str, str] = {
+    "go": "go",
+    "py": "python",
+    "js": "javascript",
+    "jsx": "javascript",
+    "ts": "typescript",
+    "tsx": "typescript",
+    "java": "java",
+    "kt": "kotlin",
+    "scala": "scala",
+    "c": "c",
+    "h": "c",
+    "cpp": "cpp",
+    "cc": "cpp",
+    "hpp": "cpp",
+    "cs": "csharp",
+    "rb": "ruby",
+    "php": "php",
+    "rs": "rust",
+    "swift": "swift",
+    "sh": "shell",
+    "sql": "sql",
+}
+
+_JS_DEFINITIONS = [
+    (
+        r"(?:export\s+)?(?:default\s+)?(?:async\s+)?function\*?\s+([\w$]+)",
+        "function",
+    ),
+    (r"(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([\w$]+)", "class"),
+    (
+        r"(?:export\s+)?(?:const|let|var)\s+([\w$]+)\s*=\s*(?:async\s+)?"
+        r"(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[\w$]+\s*=>)",
+        "function",
+    ),
+]
+
+_TS_DEFINITIONS = _JS_DEFINITIONS + [
+    (r"(?:export\s+)?(?:declare\s+)?interface\s+([\w$]+)", "interface"),
+    (r"(?:export\s+)?(?:declare\s+)?type\s+([\w$]+)\s*(?:<[^>]*>)?\s*=", "type"),
+    (r"(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+([\w$]+)", "enum"),
+]
+
+_JVM_MODIFIERS = (
+    r"(?:(?:public|private|protected|internal|static|final|abstract|sealed|"
+    r"partial|open|data|override|virtual|async|synchronized|readonly|unsafe)\s+)*"
+)
+
+_C_FUNCTION = (
+    r"(?!(?:if|for|while|switch|return|else|do|case|sizeof|delete|new)\b)"
+    r"(?:[\w:*&<>,~]+\s+)+[*&]*([\w:~]+)\s*\([^;]*$",
+    "function",
+)
+
+# Regular expressions matching the first line of a definition. Each pattern is
+# matched against the stripped line and captures the symbol name in group 1.
+_DEFINITIONS: Dict[str, List[Tuple[str, str]]] = {
+    "go": [
+        (r"func\s+\([^)]*\)\s*(\w+)", "method"),
+        (r"func\s+(\w+)", "function"),
+        (r"type\s+(\w+)\s+(?:struct|interface)\b", "type"),
+        (r"type\s+(\w+)", "type"),
+    ],
+    "python": [
+        (r"(?:async\s+)?def\s+(\w+)", "function"),
+        (r"class\s+(\w+)", "class"),
+    ],
+    "javascript": _JS_DEFINITIONS,
+    "typescript": _TS_DEFINITIONS,
+    "java": [
+        (_JVM_MODIFIERS + r"(?:class|interface|enum|record|@interface)\s+(\w+)", "class"),
+        (
+            _JVM_MODIFIERS
+            + r"(?:<[^>]*>\s*)?(?!(?:return|new|else|throw)\b)[\w<>\[\],.?\s]+\s+(\w+)\s*\([^;]*$",
+            "method",
+        ),
+    ],
+    "csharp": [
+        (
+            _JVM_MODIFIERS + r"(?:class|interface|enum|struct|record|namespace)\s+([\w.]+)",
+            "class",
+        ),
+        (
+            _JVM_MODIFIERS
+            + r"(?!(?:return|new|else|throw|await)\b)[\w<>\[\],.?\s]+\s+(\w+)\s*(?:<[^>]*>)?\s*\([^;]*$",
+            "method",
+        ),
+    ],
+    "kotlin": [
+        (_JVM_MODIFIERS + r"(?:enum\s+)?(?:class|interface|object)\s+(\w+)", "class"),
+        (_JVM_MODIFIERS + r"fun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?(\w+)", "function"),
+    ],
+    "scala": [
+        (
+            r"(?:(?:private|protected|final|sealed|abstract|implicit|case)\s+)*"
+            r"(?:class|object|trait)\s+(\w+)",
+            "class",
+        ),
+        (r"(?:(?:private|protected|override|final|implicit)\s+)*def\s+(\w+)", "function"),
+    ],
+    "c": [
+        (r"(?:typedef\s+)?(?:struct|enum|union)\s+(\w+)\s*\{?\s*$", "type"),
+        _C_FUNCTION,
+    ],
+    "cpp": [
+        (r"namespace\s+(\w+)", "namespace"),
+        (r"(?:template\s*<[^>]*>\s*)?(?:class|struct|enum(?:\s+class)?|union)\s+(\w+)\s*(?:final\s*)?(?::[^;{]*)?\{?\s*$", "class"),
+        _C_FUNCTION,
+    ],
+    "ruby": [
+        (r"def\s+((?:self\.)?[\w?!=]+)", "function"),
+        (r"(?:class|module)\s+([\w:]+)", "class"),
+    ],
+    "php": [
+        (r"(?:(?:abstract|final)\s+)?(?:class|interface|trait|enum)\s+(\w+)", "class"),
+        (
+            r"(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+&?(\w+)",
+            "function",
+        ),
+    ],
+    "rust": [
+        (
+            r"(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?"
+            r"(?:extern\s+\"[^\"]*\"\s+)?fn\s+(\w+)",
+            "function",
+        ),
+        (r"(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|union|trait|type)\s+(\w+)", "type"),
+        (r"(?:unsafe\s+)?impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?([\w:]+)", "impl"),
+        (r"(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)", "module"),
+    ],
+    "swift": [
+        (
+            r"(?:(?:public|private|fileprivate|internal|open|final)\s+)*"
+            r"(?:class|struct|enum|protocol|extension|actor)\s+(\w+)",
+            "class",
+        ),
+        (
+            r"(?:(?:public|private|fileprivate|internal|open|static|class|override|"
+            r"mutating|final)\s+)*func\s+(\w+)",
+            "function",
+        ),
+    ],
+    "shell": [
+        (r"function\s+([\w-]+)", "function"),
+        (r"([\w-]+)\s*\(\)\s*\{?\s*$", "function"),
+    ],
+    "sql": [
+        (
+            r"(?i)create\s+(?:or\s+replace\s+)?(?:temporary\s+|temp\s+|unique\s+|materialized\s+)?"
+            r"(?:table|view|function|procedure|index|trigger|type|sequence)\s+"
+            r"(?:if\s+not\s+exists\s+)?([\w.\"`]+)",
+            "statement",
+        ),
+    ],
+}
+
+_COMPILED: Dict[str, List[Tuple[Pattern[str], str]]] = {
+    language: [(re.compile(pattern), kind) for pattern, kind in patterns]
+    for language, patterns in _DEFINITIONS.items()
+}
+
+# Line prefixes of comments, decorators and annotations that belong to the
+# definition following them
+_LEADING_PREFIXES = ("//", "#", "/*", "*", "--", "@", "///", "[")
+
+
+def _indent_width(line: str) -> int:
+    width = 0
+    for ch in line:
+        if ch == " ":
+            width += 1
+        elif ch == "\t":
+            width += 4
+        else:
+            break
+    return width
+
+
+class _Segment:
+    """A run of source lines, optionally holding a single definition"""
+
+    def __init__(self, start: int, end: int, symbol: Optional[Dict] = None):
+        # 0-based line indexes, end exclusive
+        self.start = start
+        self.end = end
+        self.symbols: List[Dict] = [symbol] if symbol else []
+
+
+class CodeParser(BaseParser):
+    """
+    Parser for source code files.
+
+    The file content is kept as-is and split at definition boundaries
+    (functions, classes, types ...) found with per-language heuristics, so a
+    chunk never starts in the middle of a function unless the function alone
+    exceeds the chunk size. Small neighbouring definitions are packed into the
+    same chunk. Each chunk records the language, the symbols it contains and
+    its 1-based line range in metadata.
+    """
+
+    def language(self) -> str:
+        ext = self.file_type or os.path.splitext(self.file_name or "")[1]
+        return CODE_LANGUAGES.get(ext.lower().lstrip("."), "")
+
+    def parse_into_text(self, content: bytes) -> Document:
+        text = endecode.decode_bytes(content)
+        language = self.language()
+        lines = text.splitlines(keepends=True)
+        logger.info(f"Parsing {language or 'unknown'} source with {len(lines)} lines")
+        if not lines:
+            return Document(content=text)
+
+        segments = self._split_segments(lines, 0, len(lines), language, depth=0)
+        chunks = self._pack_segments(lines, segments, language)
+        if len(chunks) > self.max_chunks:
+            logger.warning(
+                f"Limiting chunks from {len(chunks)} to maximum {self.max_chunks}"
+            )
+            chunks = chunks[: self.max_chunks]
+        logger.info(f"Created {len(chunks)} chunks from source file")
+        return Document(content=text, chunks=chunks)
+
+    def _match_definition(self, line: str, language: str) -> Optional[Tuple[str, str]]:
+        stripped = line.strip()
+        if not stripped or stripped.startswith(_LEADING_PREFIXES):
+            return None
+        for pattern, kind in _COMPILED.get(language, []):
+            m = pattern.match(stripped)
+            if m:
+                return m.group(1).strip("\"`"), kind
+        return None
+
+    def _split_segments(
+        self,
+        lines: List[str],
+        start: int,
+        end: int,
+        language: str,
+        depth: int,
+        header: int = -1,
+    ) -> List[_Segment]:
+        """Split lines[start:end] at definitions of the shallowest indentation"""
+        found: List[Tuple[int, str, str]] = []
+        # When splitting an oversized definition, its own header line and
+        # anything before it is not a boundary
+        for i in range(max(start, header + 1), end):
+            match = self._match_definition(lines[i], language)
+            if match:
+                found.append((i, match[0], match[1]))
+
+        # Nested definitions (methods) are only boundaries when the enclosing
+        # definition is split further, so only keep the outermost level here
+        if found:
+            indent = min(_indent_width(lines[i]) for i, _, _ in found)
+            found = [f for f in found if _indent_width(lines[f[0]]) == indent]
+        if not found:
+            return [_Segment(start, end)]
+
+        boundaries = [self._leading_start(lines, i, start) for i, _, _ in found]
+        segments: List[_Segment] = []
+        if boundaries[0] > start:
+            segments.append(_Segment(start, boundaries[0]))
+        for n, (line_no, name, kind) in enumerate(found):
+            seg_start = boundaries[n]
+            seg_end = boundaries[n + 1] if n + 1 < len(found) else end
+            if depth > 0 and kind == "function":
+                kind = "method"
+            symbol = {
+                "name": name,
+                "kind": kind,
+                "start_line": line_no + 1,
+                "end_line": self._last_code_line(lines, seg_start, seg_end) + 1,
+            }
+            segment = _Segment(seg_start, seg_end, symbol)
+            if depth < 2 and self._size(lines, seg_start, seg_end) > self.chunk_size:
+                # Oversized definition: split at its members, keeping the
+                # definition itself as the symbol of the first piece
+                inner = self._split_segments(
+                    lines, seg_start, seg_end, language, depth + 1, header=line_no
+                )
+                if len(inner) > 1:
+                    inner[0].symbols.insert(0, symbol)
+                    segments.extend(inner)
+                    continue
+            segments.append(segment)
+        return segments
+
+    def _leading_start(self, lines: List[str], line_no: int, floor: int) -> int:
+        """Move a boundary up over the comments and decorators of a definition"""
+        indent = _indent_width(lines[line_no])
+        i = line_no
+        while i - 1 >= floor:
+            prev = lines[i - 1]
+            stripped = prev.strip()
+            if not stripped or _indent_width(prev) != indent:
+                break
+            if not stripped.startswith(_LEADING_PREFIXES) and not stripped.startswith(
+                ('"""', "'''")
+            ):
+                break
+            i -= 1
+        return i
+
+    def _last_code_line(self, lines: List[str], start: int, end: int) -> int:
+        for i in range(end - 1, start - 1, -1):
+            if lines[i].strip():
+                return i
+        return start
+
+    def _size(self, lines: List[str], start: int, end: int) -> int:
+        return sum(len(line) for line in lines[start:end])
+
+    def _pack_segments(
+        self, lines: List[str], segments: List[_Segment], language: str
+    ) -> List[Chunk]:
+        """Merge neighbouring segments up to chunk_size, splitting huge ones by lines"""
+        offsets = [0]
+        for line in lines:
+            offsets.append(offsets[-1] + len(line))
+
+        chunks: List[Chunk] = []
+        pending: List[_Segment] = []
+
+        def flush():
+            if not pending:
+                return
+            start, end = pending[0].start, pending[-1].end
+            self._emit(chunks, lines, offsets, start, end, pending, language)
+            pending.clear()
+
+        for segment in segments:
+            size = self._size(lines, segment.start, segment.end)
+            if size > self.chunk_size:
+                flush()
+                for start, end in self._split_lines(lines, segment.start, segment.end):
+                    self._emit(chunks, lines, offsets, start, end, [segment], language)
+                continue
+            pending_size = (
+                self._size(lines, pending[0].start, pending[-1].end) if pending else 0
+            )
+            if pending and pending_size + size > self.chunk_size:
+                flush()
+            pending.append(segment)
+        flush()
+        return chunks
+
+    def _split_lines(self, lines: List[str], start: int, end: int) -> List[Tuple[int, int]]:
+        """Split an oversized segment into line ranges, preferring blank lines"""
+        ranges: List[Tuple[int, int]] = []
+        begin, size, last_blank = start, 0, -1
+        for i in range(start, end):
+            size += len(lines[i])
+            if not lines[i].strip():
+                last_blank = i
+            if size > self.chunk_size and i > begin:
+                cut = last_blank + 1 if last_blank > begin else i
+                ranges.append((begin, cut))
+                begin = cut
+                size = self._size(lines, begin, i + 1)
+                last_blank = -1
+        if begin < end:
+            ranges.append((begin, end))
+        return ranges
+
+    def _emit(
+        self,
+        chunks: List[Chunk],
+        lines: List[str],
+        offsets: List[int],
+        start: int,
+        end: int,
+        segments: List[_Segment],
+        language: str,
+    ):
+        content = "".join(lines[start:end])
+        if not content.strip():
+            return
+        first = start
+        while first < end - 1 and not lines[first].strip():
+            first += 1
+        last = self._last_code_line(lines, start, end)
+        symbols = [
+            symbol
+            for segment in segments
+            for symbol in segment.symbols
+            if symbol["start_line"] <= last + 1 and symbol["end_line"] >= first + 1
+        ]
+        chunks.append(
+            Chunk(
+                content=content,
+                seq=len(chunks),
+                start=offsets[start],
+                end=offsets[end],
+                metadata={
+                    "language": language,
+                    "symbols": symbols,
+                    "line_start": first + 1,
+                    "line_end": last + 1,
+                },
+            )
+        )
@@ -2,17 +2,18 @@
 CSV Parser Module
 
 This module provides a parser for CSV (Comma-Separated Values) files.
-It converts CSV data into a Document with structured chunks, where each row
-becomes a separate chunk with key-value pairs.
+It converts CSV data into a Document with structured chunks of consecutive
+rows, where every value is kept next to its column name.
 """
+import csv
+import io
 import logging
-from io import BytesIO
 from typing import List
 
-import pandas as pd
-
-from docreader.models.document import Chunk, Document
+from docreader.models.document import Document
 from docreader.parser.base_parser import BaseParser
+from docreader.parser.table_schema import build_table_chunks
+from docreader.utils import endecode
 
 logger = logging.getLogger(__name__)
 
@@ -21,12 +22,11 @@ class CSVParser(BaseParser):
     """
     Parser for CSV files that converts tabular data into structured text.
     
-    This parser reads CSV content and transforms each row into a formatted string
-    with column-value pairs. Each row is stored as a separate Chunk in the Document,
-    allowing for granular access to individual records.
-    
-    The output format for each row is:
-        "column1: value1, column2: value2, column3: value3\n"
+    The header row is detected (title rows above it are skipped) and column
+    types are inferred, see table_schema. Rows are grouped into chunks up to
+    the chunk size, each row formatted as:
+        "column1: value1,column2: value2,column3: value3\n"
+    The rows of each chunk are also stored in metadata["table"] for cell lookups.
     
     Usage:
         parser = CSVParser()
@@ -37,51 +37,31 @@ class CSVParser(BaseParser):
     def parse_into_text(self, content: bytes) -> Document:
         """Parse CSV content into a Document with structured chunks.
         
-        Each row in the CSV is converted into a formatted string and stored as
-        a separate Chunk. The chunks maintain sequential order and track their
-        position in the overall document.
-        
         Args:
             content: Raw bytes content of the CSV file
             
         Returns:
             Document: A Document object containing:
                 - content: Full text with all rows concatenated
-                - chunks: List of Chunk objects, one per CSV row
+                - chunks: List of Chunk objects, each holding consecutive rows
                 
         Note:
-            Bad lines in the CSV are automatically skipped using pandas'
-            on_bad_lines="skip" parameter.
+            The delimiter is sniffed from the first lines, rows with a
+            different number of fields are padded instead of skipped.
         """
-        chunks: List[Chunk] = []
-        text: List[str] = []
-        start, end = 0, 0
-
-        # Read CSV content into a pandas DataFrame, skipping malformed lines
-        df = pd.read_csv(BytesIO(content), on_bad_lines="skip")
-
-        # Process each row in the DataFrame
-        for i, (idx, row) in enumerate(df.iterrows()):
-            # Format row as "column: value" pairs separated by commas
-            content_row = (
-                ",".join(
-                    f"{col.strip()}: {str(row[col]).strip()}" for col in df.columns
-                )
-                + "\n"
-            )
-            # Update end position for this chunk
-            end += len(content_row)
-            text.append(content_row)
-            
-            # Create a chunk for this row with position tracking
-            chunks.append(Chunk(content=content_row, seq=i, start=start, end=end))
-            # Update start position for next chunk
-            start = end
+        text = endecode.decode_bytes(content)
+        try:
+            dialect = csv.Sniffer().sniff(text[:8192], delimiters=",;\t|")
+        except csv.Error:
+            dialect = csv.excel
 
-        return Document(
-            content="".join(text),
-            chunks=chunks,
-        )
+        rows: List[List[str]] = [
+            [cell.strip() for cell in row]
+            for row in csv.reader(io.StringIO(text, newline=""), dialect)
+        ]
+        chunks, document_text = build_table_chunks(rows, self.chunk_size)
+        logger.info(f"Parsed CSV with {len(rows)} lines into {len(chunks)} chunks")
+        return Document(content=document_text, chunks=chunks)
 
 
 if __name__ == "__main__":
@@ -8,7 +8,9 @@ import textract
 from docreader.config import CONFIG
 from docreader.models.document import Document
 from docreader.parser.docx2_parser import Docx2Parser
-from docreader.utils.tempfile import TempDirContext, TempFileContext
+from docreader.utils.converter import CONVERSION_POOL, ConversionError
+from docreader.utils.progress import STAGE_CONVERTED, report_progress
+from docreader.utils.tempfile import TempFileContext
 
 logger = logging.getLogger(__name__)
 
@@ -170,7 +172,8 @@ class DocParser(Docx2Parser):
     def _try_convert_doc_to_docx(self, doc_path: str) -> Optional[bytes]:
         """Convert DOC file to DOCX format
 
-        Uses LibreOffice/OpenOffice for conversion
+        Uses LibreOffice/OpenOffice for conversion, run in the shared
+        conversion pool
 
         Args:
             doc_path: DOC file path
@@ -185,48 +188,29 @@ class DocParser(Docx2Parser):
         if not soffice_path:
             return None
 
-        # Execute conversion command
         logger.info(f"Using {soffice_path} to convert DOC to DOCX")
-
-        # Create a temporary directory to store the converted file
-        with TempDirContext() as temp_dir:
-            cmd = [
-                soffice_path,
-                "--headless",
-                "--convert-to",
-                "docx",
-                "--outdir",
-                temp_dir,
+        try:
+            docx_content = CONVERSION_POOL.convert(
+                "soffice",
                 doc_path,
-            ]
-            logger.info(f"Running command in sandbox: {' '.join(cmd)}")
-
-            # Execute in sandbox with proxy configuration
-            stdout, stderr, returncode = self.sandbox_executor.execute_in_sandbox(cmd)
-
-            if returncode != 0:
-                logger.warning(
-                    f"Error converting DOC to DOCX: {stderr.decode('utf-8')}"
-                )
-                return None
-
-            # Find the converted file
-            docx_file = [
-                file for file in os.listdir(temp_dir) if file.endswith(".docx")
-            ]
-            logger.info(f"Found {len(docx_file)} DOCX file(s) in temporary directory")
-            for file in docx_file:
-                converted_file = os.path.join(temp_dir, file)
-                logger.info(f"Found converted file: {converted_file}")
-
-                # Read the converted file content
-                with open(converted_file, "rb") as f:
-                    docx_content = f.read()
-                    logger.info(
-                        f"Successfully read DOCX file, size: {len(docx_content)}"
-                    )
-                    return docx_content
-        return None
+                ".docx",
+                lambda job: [
+                    soffice_path,
+                    f"-env:UserInstallation={job.profile_uri}",
+                    "--headless",
+                    "--convert-to",
+                    "docx",
+                    "--outdir",
+                    job.output_dir,
+                    job.input_path,
+                ],
+            )
+        except ConversionError as e:
+            logger.warning(f"Error converting DOC to DOCX: {e}")
+            return None
+        logger.info(f"Successfully converted DOC to DOCX, size: {len(docx_content)}")
+        report_progress(STAGE_CONVERTED)
+        return docx_content
 
     def _try_find_executable_path(
         self,
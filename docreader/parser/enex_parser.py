@@ -0,0 +1,251 @@
+import base64
+import hashlib
+import logging
+import os
+import xml.etree.ElementTree as ET
+from dataclasses import dataclass
+from datetime import datetime, timezone
+from typing import Dict, List, Optional, Set, Tuple
+
+from bs4 import BeautifulSoup
+from markdownify import markdownify
+
+from docreader.models.document import Document
+from docreader.parser.base_parser import BaseParser
+
+logger = logging.getLogger(__name__)
+
+# File extensions of resource MIME types, used when a resource has no file name
+RESOURCE_MIME_TYPES = {
+    "image/png": ".png",
+    "image/jpeg": ".jpg",
+    "image/gif": ".gif",
+    "image/bmp": ".bmp",
+    "image/webp": ".webp",
+    "image/tiff": ".tiff",
+    "application/pdf": ".pdf",
+    "application/msword": ".doc",
+    "application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+    "application/vnd.ms-excel": ".xls",
+    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": ".xlsx",
+    "text/plain": ".txt",
+    "text/markdown": ".md",
+    "text/csv": ".csv",
+}
+
+# Attachment types parsed into the note, images are handled as images
+ATTACHMENT_TYPES = ("pdf", "doc", "docx", "xls", "xlsx", "csv", "txt", "md")
+
+
+@dataclass
+class _Resource:
+    """An image or file embedded in a note"""
+
+    data: bytes
+    mime: str
+    file_name: str
+
+    @property
+    def ext(self) -> str:
+        ext = os.path.splitext(self.file_name)[1].lower()
+        return ext or RESOURCE_MIME_TYPES.get(self.mime, "")
+
+    @property
+    def is_image(self) -> bool:
+        return self.mime.startswith("image/")
+
+
+def _enex_time(value: str) -> str:
+    """Format a date of an Evernote export such as 20240115T083000Z"""
+    try:
+        t = datetime.strptime(value.strip(), "%Y%m%dT%H%M%SZ")
+    except ValueError:
+        return value.strip()
+    return t.replace(tzinfo=timezone.utc).strftime("%Y-%m-%d %H:%M:%S UTC")
+
+
+class EnexParser(BaseParser):
+    """
+    Parser for Evernote exports (.enex).
+
+    The server stores every note of an uploaded export as an export of its
+    own, exports with several notes are still rendered note after note.
+    Each note becomes a markdown section with its dates, tags, author and
+    source URL, followed by its ENML content converted to markdown:
+
+    - images (<en-media> of an image resource) are uploaded to storage and
+      referenced as markdown images, so they go through OCR and captioning
+      when multimodal is enabled
+    - PDF, Office and text attachments are parsed with the parser of their
+      file type and appended under an attachment heading
+    - checkboxes become [ ] / [x], encrypted blocks are only marked
+    """
+
+    # Images are uploaded by the parser, the .enex extension is not an image type
+    always_process_images = True
+
+    def parse_into_text(self, content: bytes) -> Document:
+        root = ET.fromstring(content)
+        notes = root.findall("note") if root.tag == "en-export" else [root]
+        logger.info(f"Parsing Evernote export with {len(notes)} notes")
+
+        images: Dict[str, str] = {}
+        sections = [self._render_note(note, images) for note in notes]
+        content = "\n\n".join(s for s in sections if s)
+        return Document(content=content, images=images)
+
+    def _render_note(self, note: ET.Element, images: Dict[str, str]) -> str:
+        title = (note.findtext("title") or "").strip()
+        header = [f"# {title}"] if title else []
+        created = note.findtext("created")
+        if created:
+            header.append(f"创建时间: {_enex_time(created)}")
+        updated = note.findtext("updated")
+        if updated:
+            header.append(f"更新时间: {_enex_time(updated)}")
+        tags = [t.text.strip() for t in note.findall("tag") if (t.text or "").strip()]
+        if tags:
+            header.append("标签: " + "，".join(tags))
+        author = note.findtext("note-attributes/author")
+        if author and author.strip():
+            header.append(f"作者: {author.strip()}")
+        source_url = note.findtext("note-attributes/source-url")
+        if source_url and source_url.strip():
+            header.append(f"来源: {source_url.strip()}")
+
+        resources = self._resources(note)
+        body, used = self._render_content(
+            note.findtext("content") or "", resources, images
+        )
+
+        # Attachments, including resources the content does not reference
+        attachments: List[str] = []
+        for digest, resource in resources.items():
+            if resource.is_image:
+                if digest in used:
+                    continue
+                image_url = self._upload_image(resource, images)
+                if image_url:
+                    attachments.append(f"![{resource.file_name}]({image_url})")
+                continue
+            text = self._parse_attachment(resource, images)
+            if text:
+                attachments.append(f"## 附件: {resource.file_name}\n\n{text}")
+
+        parts = ["\n".join(header), body] + attachments
+        return "\n\n".join(p for p in parts if p)
+
+    def _resources(self, note: ET.Element) -> Dict[str, _Resource]:
+        """Resources of a note by the MD5 of their data, as <en-media> refers to them"""
+        resources: Dict[str, _Resource] = {}
+        for i, element in enumerate(note.findall("resource")):
+            encoded = "".join((element.findtext("data") or "").split())
+            try:
+                data = base64.b64decode(encoded)
+            except ValueError as e:
+                logger.warning(f"Skipping resource with malformed data: {e}")
+                continue
+            if not data:
+                continue
+            mime = (element.findtext("mime") or "").strip().lower()
+            file_name = element.findtext("resource-attributes/file-name") or ""
+            file_name = file_name.strip() or (
+                f"resource-{i + 1}{RESOURCE_MIME_TYPES.get(mime, '')}"
+            )
+            resources[hashlib.md5(data).hexdigest()] = _Resource(data, mime, file_name)
+        return resources
+
+    def _render_content(
+        self, enml: str, resources: Dict[str, _Resource], images: Dict[str, str]
+    ) -> Tuple[str, Set[str]]:
+        """Convert the ENML content of a note to markdown, returns it with the
+        hashes of the resources it shows inline"""
+        used: Set[str] = set()
+        if not enml.strip():
+            return "", used
+        soup = BeautifulSoup(enml, "html.parser")
+        for media in soup.find_all("en-media"):
+            digest = (media.get("hash") or "").lower()
+            resource = resources.get(digest)
+            if resource is None:
+                media.decompose()
+                continue
+            if resource.is_image:
+                used.add(digest)
+                image_url = self._upload_image(resource, images)
+                if image_url:
+                    img = soup.new_tag("img", src=image_url, alt=resource.file_name)
+                    media.replace_with(img)
+                else:
+                    media.replace_with(f"[图片: {resource.file_name}]")
+            else:
+                media.replace_with(f"[附件: {resource.file_name}]")
+        for todo in soup.find_all("en-todo"):
+            checked = (todo.get("checked") or "").lower() == "true"
+            todo.replace_with("[x] " if checked else "[ ] ")
+        for crypt in soup.find_all("en-crypt"):
+            crypt.replace_with("[加密内容]")
+
+        note = soup.find("en-note") or soup
+        text = markdownify(str(note), heading_style="ATX")
+        lines = [line.rstrip() for line in text.splitlines()]
+        # Collapse the blank lines left by empty <div>s
+        collapsed: List[str] = []
+        for line in lines:
+            if not line and (not collapsed or not collapsed[-1]):
+                continue
+            collapsed.append(line)
+        return "\n".join(collapsed).strip(), used
+
+    def _upload_image(self, resource: _Resource, images: Dict[str, str]) -> str:
+        """Upload an image resource, returns its storage URL or ''"""
+        if not self.enable_multimodal:
+            return ""
+        try:
+            image_url = self.storage.upload_bytes(
+                resource.data, file_ext=resource.ext or ".png"
+            )
+        except Exception as e:
+            logger.error(f"Failed to upload note image {resource.file_name}: {e}")
+            return ""
+        if not image_url:
+            return ""
+        images[image_url] = base64.b64encode(resource.data).decode()
+        return image_url
+
+    def _parse_attachment(
+        self, resource: _Resource, images: Dict[str, str]
+    ) -> Optional[str]:
+        """Parse a file attachment with the parser of its type, None when the
+        type is not supported or parsing fails"""
+        file_type = resource.ext.lstrip(".")
+        if file_type not in ATTACHMENT_TYPES:
+            logger.info(f"Skipping attachment {resource.file_name} of type {file_type}")
+            return None
+        # Imported here, the parser registry imports this module
+        from docreader.parser.parser import Parser
+
+        cls = Parser().get_parser(file_type)
+        parser = cls(
+            file_name=resource.file_name,
+            file_type=file_type,
+            enable_multimodal=self.enable_multimodal,
+            chunk_size=self.chunk_size,
+            chunk_overlap=self.chunk_overlap,
+            separators=self.separators,
+            ocr_backend=self.ocr_backend,
+            ocr_config=self.ocr_config,
+            max_image_size=self.max_image_size,
+            max_concurrent_tasks=self.max_concurrent_tasks,
+            chunking_config=self.chunking_config,
+        )
+        try:
+            document = parser.parse_into_text(resource.data)
+        except Exception as e:
+            logger.error(f"Failed to parse attachment {resource.file_name}: {e}")
+            return None
+        images.update(document.images)
+        logger.info(
+            f"Parsed attachment {resource.file_name}: {len(document.content)} characters"
+        )
+        return document.content.strip() or None
@@ -13,6 +13,7 @@ import pandas as pd
 
 from docreader.models.document import Chunk, Document
 from docreader.parser.base_parser import BaseParser
+from docreader.parser.table_schema import build_table_chunks, cell_text
 
 logger = logging.getLogger(__name__)
 
@@ -21,14 +22,15 @@ class ExcelParser(BaseParser):
     """Parser for Excel files (.xlsx, .xls).
     
     This parser extracts text content from Excel files by processing all sheets
-    and converting each row into a structured text format. Each row becomes a
-    separate chunk with key-value pairs.
+    and converting each row into a structured text format. Consecutive rows
+    are grouped into chunks with key-value pairs.
     
     Features:
         - Supports multiple sheets in a single Excel file
-        - Automatically removes completely empty rows
-        - Converts each row to "column: value" format
-        - Creates individual chunks for each row for better granularity
+        - Detects the header row and skips title rows above it
+        - Infers column types (integer, number, date, boolean, text)
+        - Converts each row to "column: value" format, prefixed by the sheet name
+        - Stores the rows of each chunk in metadata["table"] for cell lookups
         
     Example:
         >>> parser = ExcelParser()
@@ -36,6 +38,7 @@ class ExcelParser(BaseParser):
         ...     content = f.read()
         ...     document = parser.parse_into_text(content)
         >>> print(document.content)
+        工作表: Sheet1
         Name: John,Age: 30,City: NYC
         Name: Jane,Age: 25,City: LA
     """
@@ -49,49 +52,35 @@ class ExcelParser(BaseParser):
         Returns:
             Document: Parsed document containing:
                 - content: Full text with all rows from all sheets
-                - chunks: List of Chunk objects, one per row
+                - chunks: List of Chunk objects, each holding consecutive rows
                 
         Note:
-            - Empty rows (all NaN values) are automatically skipped
-            - Each row is formatted as: "col1: val1,col2: val2,..."
+            - Empty rows and empty cells are skipped
             - Chunks maintain sequential ordering across all sheets
         """
         chunks: List[Chunk] = []
         text: List[str] = []
-        start, end = 0, 0
+        offset = 0
 
         # Load Excel file from bytes into pandas ExcelFile object
         excel_file = pd.ExcelFile(BytesIO(content))
         
         # Process each sheet in the Excel file
         for excel_sheet_name in excel_file.sheet_names:
-            # Parse the sheet into a DataFrame
-            df = excel_file.parse(sheet_name=excel_sheet_name)
-            # Remove rows where all values are NaN (completely empty rows)
-            df.dropna(how="all", inplace=True)
+            # Read raw cells, the header row is detected by build_table_chunks
+            df = excel_file.parse(sheet_name=excel_sheet_name, header=None, dtype=object)
+            rows = [[cell_text(v) for v in row] for row in df.itertuples(index=False)]
 
-            # Process each row in the DataFrame
-            for _, row in df.iterrows():
-                page_content = []
-                # Build key-value pairs for non-null values
-                for k, v in row.items():
-                    if pd.notna(v):  # Skip NaN/null values
-                        page_content.append(f"{k}: {v}")
-                
-                # Skip rows with no valid content
-                if not page_content:
-                    continue
-                
-                # Format row as comma-separated key-value pairs
-                content_row = ",".join(page_content) + "\n"
-                end += len(content_row)
-                text.append(content_row)
-                
-                # Create a chunk for this row with position tracking
-                chunks.append(
-                    Chunk(content=content_row, seq=len(chunks), start=start, end=end)
-                )
-                start = end
+            sheet_chunks, sheet_text = build_table_chunks(
+                rows,
+                self.chunk_size,
+                seq_start=len(chunks),
+                offset=offset,
+                sheet=str(excel_sheet_name),
+            )
+            chunks.extend(sheet_chunks)
+            text.append(sheet_text)
+            offset += len(sheet_text)
 
         # Combine all text and return as Document
         return Document(content="".join(text), chunks=chunks)
@@ -0,0 +1,652 @@
+import io
+import logging
+import posixpath
+import re
+import zipfile
+from typing import Callable, Dict, List, Optional, Tuple
+
+from docreader.models.document import Document
+from docreader.parser.base_parser import BaseParser
+from docreader.utils import endecode
+
+logger = logging.getLogger(__name__)
+
+# Depth of nested \input / \include resolved, deeper files are left out
+MAX_INPUT_DEPTH = 10
+# Total size of the .tex files read from a project archive, and of the
+# source once its includes are expanded
+MAX_ARCHIVE_TEX_BYTES = 20 * 1024 * 1024
+
+# Sectioning commands, outermost first
+SECTION_COMMANDS = (
+    "part",
+    "chapter",
+    "section",
+    "subsection",
+    "subsubsection",
+    "paragraph",
+    "subparagraph",
+)
+
+# Math environments rendered as display math, the value is the environment
+# the body is wrapped in inside $$ ... $$ to keep its alignment
+DISPLAY_MATH_ENVS = {
+    "equation": "",
+    "equation*": "",
+    "displaymath": "",
+    "math": "",
+    "align": "aligned",
+    "align*": "aligned",
+    "alignat": "aligned",
+    "alignat*": "aligned",
+    "flalign": "aligned",
+    "flalign*": "aligned",
+    "eqnarray": "aligned",
+    "eqnarray*": "aligned",
+    "gather": "gathered",
+    "gather*": "gathered",
+    "multline": "gathered",
+    "multline*": "gathered",
+}
+
+# Environments kept verbatim as code blocks
+VERBATIM_ENVS = ("verbatim", "Verbatim", "lstlisting", "minted", "alltt")
+
+THEOREM_ENVS = {
+    "theorem": "定理",
+    "lemma": "引理",
+    "proposition": "命题",
+    "corollary": "推论",
+    "definition": "定义",
+    "remark": "注",
+    "example": "例",
+    "proof": "证明",
+}
+
+# Commands dropped with their arguments, they only affect the layout
+DROPPED_COMMANDS = {
+    "label": 1,
+    "vspace": 1,
+    "hspace": 1,
+    "bibliographystyle": 1,
+    "bibliography": 1,
+    "pagestyle": 1,
+    "thispagestyle": 1,
+    "pagenumbering": 1,
+    "setlength": 2,
+    "setcounter": 2,
+    "addtolength": 2,
+    "addcontentsline": 3,
+    "includegraphics": 1,
+    "newcommand": 2,
+    "renewcommand": 2,
+    "providecommand": 2,
+    "DeclareMathOperator": 2,
+    "newtheorem": 2,
+    "usepackage": 1,
+    "graphicspath": 1,
+    "input": 1,
+    "include": 1,
+}
+
+CITE_COMMANDS = (
+    "cite",
+    "citep",
+    "citet",
+    "citealp",
+    "parencite",
+    "textcite",
+    "autocite",
+)
+REF_COMMANDS = ("ref", "eqref", "autoref", "cref", "Cref", "pageref")
+
+# Commands whose argument is rendered with markdown emphasis
+INLINE_FORMATS = {
+    "textbf": "**",
+    "bf": "**",
+    "emph": "*",
+    "textit": "*",
+    "textsl": "*",
+    "texttt": "`",
+}
+
+_COMMENT = re.compile(r"(?<!\\)%.*")
+_BEGIN_DOCUMENT = re.compile(r"\\begin\s*\{document\}")
+_END_DOCUMENT = re.compile(r"\\end\s*\{document\}")
+_INCLUDE = re.compile(
+    r"\\(?:input|include|subfile)\s*\{([^{}]+)\}"
+    r"|\\(?:sub)?import\*?\s*\{([^{}]*)\}\s*\{([^{}]+)\}"
+)
+_NEWCOMMAND = re.compile(
+    r"\\(?:re)?newcommand\*?\s*\{?\\([A-Za-z]+)\}?\s*\{"
+    r"|\\def\s*\\([A-Za-z]+)\s*\{"
+)
+_MATH_OPERATOR = re.compile(r"\\DeclareMathOperator\*?\s*\{\\([A-Za-z]+)\}\s*\{")
+_PLACEHOLDER = "\x00{}\x00"
+_PLACEHOLDER_RE = re.compile("\x00(\\d+)\x00")
+_TABULAR = r"\\begin\{tabular\*?\}.*?\\end\{tabular\*?\}"
+_RULES = re.compile(
+    r"\\(?:hline|toprule|midrule|bottomrule)"
+    r"|\\c(?:line|midrule)(?:\([^)]*\))?\{[^}]*\}"
+)
+_BLOCK_LINE = re.compile(r"(#{1,6} |[-*>] |\d+\. |\|)")
+_LIST_ITEM = re.compile(r"([-*] |\d+\. )")
+_CJK = re.compile(r"[\u3000-\u9fff\uff00-\uffef]")
+
+
+def _read_group(text: str, i: int, open_: str = "{", close: str = "}") -> Tuple[str, int]:
+    """Read a balanced group starting at text[i] == open_, returns its content
+    and the index after it, ('', i) when there is no group at i"""
+    if i >= len(text) or text[i] != open_:
+        return "", i
+    depth = 0
+    for j in range(i, len(text)):
+        c = text[j]
+        if c == "\\":
+            continue
+        if c == open_ and (j == 0 or text[j - 1] != "\\"):
+            depth += 1
+        elif c == close and text[j - 1] != "\\":
+            depth -= 1
+            if depth == 0:
+                return text[i + 1 : j], j + 1
+    return text[i + 1 :], len(text)
+
+
+def _skip_spaces(text: str, i: int) -> int:
+    while i < len(text) and text[i] in " \t\n":
+        i += 1
+    return i
+
+
+def _replace_command(
+    text: str, name: str, nargs: int, render: Callable[[List[str]], str]
+) -> str:
+    """Replace \\name[*][opt]{arg}... with render(args), args being the
+    required arguments"""
+    pattern = re.compile(r"\\" + re.escape(name) + r"(?![A-Za-z])\*?")
+    out: List[str] = []
+    pos = 0
+    while True:
+        m = pattern.search(text, pos)
+        if m is None:
+            break
+        i = _skip_spaces(text, m.end())
+        while i < len(text) and text[i] == "[":
+            _, i = _read_group(text, i, "[", "]")
+            i = _skip_spaces(text, i)
+        args = []
+        for _ in range(nargs):
+            i = _skip_spaces(text, i)
+            arg, j = _read_group(text, i)
+            if j == i:
+                break
+            args.append(arg)
+            i = j
+        if len(args) < nargs:
+            out.append(text[pos : m.end()])
+            pos = m.end()
+            continue
+        out.append(text[pos : m.start()])
+        out.append(render(args))
+        pos = i
+    out.append(text[pos:])
+    return "".join(out)
+
+
+def _strip_comments(text: str) -> str:
+    return "\n".join(_COMMENT.sub("", line) for line in text.splitlines())
+
+
+def _utf8_len(text: str) -> int:
+    return len(text.encode("utf-8"))
+
+
+class LatexParser(BaseParser):
+    """
+    Parser for LaTeX documents (.tex) and LaTeX projects uploaded as a zip
+    archive.
+
+    In an archive the main file is the one with \\documentclass, \\input,
+    \\include, \\subfile and \\import are resolved against the other .tex
+    files of the archive. The preamble is dropped except the title, author
+    and date, and the simple macros it defines, which are expanded inside
+    math so formulas keep their meaning. The body is converted to markdown:
+
+    - math is kept as LaTeX, inline as $...$ and display math (equation,
+      align, gather, \\[...\\], $$...$$) as $$...$$ blocks, alignment
+      environments becoming aligned / gathered
+    - sections become headings, lists, tables, quotes and verbatim blocks
+      their markdown counterparts, theorems and proofs bold labels
+    - figures keep only their captions, references and citations their keys
+    - layout commands are dropped and other commands replaced by their text
+    """
+
+    def parse_into_text(self, content: bytes) -> Document:
+        files = self._read_files(content)
+        main = self._main_file(files)
+        if main is None:
+            logger.warning("No LaTeX file found")
+            return Document()
+        logger.info(f"Parsing LaTeX project with {len(files)} files, main: {main}")
+
+        root = posixpath.dirname(main)
+        budget = [MAX_ARCHIVE_TEX_BYTES - _utf8_len(files[main])]
+        source = self._resolve_includes(files[main], files, root, [main], budget)
+        begin = _BEGIN_DOCUMENT.search(source)
+        if begin:
+            preamble, body = source[: begin.start()], source[begin.end() :]
+            end = _END_DOCUMENT.search(body)
+            if end:
+                body = body[: end.start()]
+        else:
+            preamble, body = "", source
+
+        macros = self._macros(preamble)
+        header = self._title_block(preamble + body)
+        # Sections start below the title heading
+        first_level = 2 if header.startswith("# ") else 1
+        markdown = self._convert(body, macros, first_level)
+        content = "\n\n".join(p for p in (header, markdown) if p)
+        return Document(content=content)
+
+    def _read_files(self, content: bytes) -> Dict[str, str]:
+        """The .tex files of an archive by path, or the single uploaded file"""
+        if not zipfile.is_zipfile(io.BytesIO(content)):
+            text = _strip_comments(endecode.decode_bytes(content))
+            return {self.file_name or "main.tex": text}
+        files: Dict[str, str] = {}
+        total = 0
+        with zipfile.ZipFile(io.BytesIO(content)) as archive:
+            for info in archive.infolist():
+                name = posixpath.normpath(info.filename)
+                if info.is_dir() or not name.lower().endswith(".tex"):
+                    continue
+                # Resource forks and hidden files of macOS archives
+                if name.startswith("__MACOSX/") or "/." in "/" + name:
+                    continue
+                total += info.file_size
+                if total > MAX_ARCHIVE_TEX_BYTES:
+                    logger.warning("LaTeX archive too large, skipping remaining files")
+                    break
+                files[name] = _strip_comments(
+                    endecode.decode_bytes(archive.read(info))
+                )
+        return files
+
+    @staticmethod
+    def _main_file(files: Dict[str, str]) -> Optional[str]:
+        """The file with \\documentclass, the shallowest one when there are
+        several, else the only file"""
+        mains = [name for name, text in files.items() if "\\documentclass" in text]
+        if mains:
+            return min(
+                mains, key=lambda name: (name.count("/"), name != "main.tex", name)
+            )
+        return next(iter(files), None) if len(files) == 1 else None
+
+    def _resolve_includes(
+        self,
+        text: str,
+        files: Dict[str, str],
+        root: str,
+        stack: List[str],
+        budget: List[int],
+    ) -> str:
+        """Expand \\input and \\include. budget holds the bytes the expanded
+        source may still grow by, a file included many times counts every
+        time, and includes are dropped once it is used up"""
+
+        def include(m: re.Match) -> str:
+            if m.group(1) is not None:
+                directory, name = "", m.group(1).strip()
+            else:
+                directory, name = m.group(2).strip(), m.group(3).strip()
+            current = posixpath.dirname(stack[-1])
+            path = self._find_file(files, root, current, directory, name)
+            if path is None:
+                logger.warning(f"Included LaTeX file not found: {name}")
+                return ""
+            if path in stack or len(stack) > MAX_INPUT_DEPTH:
+                logger.warning(f"Skipping recursive or too deep include: {path}")
+                return ""
+            size = _utf8_len(files[path])
+            if size > budget[0]:
+                if budget[0] >= 0:
+                    logger.warning(
+                        "LaTeX source too large, skipping remaining includes"
+                    )
+                    budget[0] = -1
+                return ""
+            budget[0] -= size
+            text = self._resolve_includes(
+                files[path], files, root, stack + [path], budget
+            )
+            return "\n" + text + "\n"
+
+        return _INCLUDE.sub(include, text)
+
+    @staticmethod
+    def _find_file(
+        files: Dict[str, str], root: str, current: str, directory: str, name: str
+    ) -> Optional[str]:
+        names = [name] if name.lower().endswith(".tex") else [name + ".tex", name]
+        # LaTeX resolves paths from the main file, \import and subfiles from
+        # the including file
+        for base in (root, current):
+            for candidate in names:
+                path = posixpath.normpath(posixpath.join(base, directory, candidate))
+                if path in files:
+                    return path
+        return None
+
+    @staticmethod
+    def _macros(preamble: str) -> Dict[str, str]:
+        """Macros without arguments defined in the preamble, by name"""
+        macros: Dict[str, str] = {}
+        # \newcommand{\foo}[1]{...} takes arguments and does not match
+        for m in _NEWCOMMAND.finditer(preamble):
+            definition, _ = _read_group(preamble, m.end() - 1)
+            macros[m.group(1) or m.group(2)] = definition.strip()
+        for m in _MATH_OPERATOR.finditer(preamble):
+            definition, _ = _read_group(preamble, m.end() - 1)
+            macros[m.group(1)] = "\\operatorname{" + definition.strip() + "}"
+        return macros
+
+    def _title_block(self, source: str) -> str:
+        fields = {}
+        for name in ("title", "author", "date"):
+            m = re.search(r"\\" + name + r"\s*(?:\[[^\]]*\])?\s*\{", source)
+            if m:
+                value, _ = _read_group(source, m.end() - 1)
+                value = _replace_command(value, "thanks", 1, lambda a: "")
+                value = re.sub(r"\s*(?:\\\\|\\and\b)\s*", ", ", value)
+                fields[name] = self._inline(value).strip(" ,")
+        lines = []
+        if fields.get("title"):
+            lines.append(f"# {fields['title']}")
+        if fields.get("author"):
+            lines.append(f"作者: {fields['author']}")
+        if fields.get("date") and "\\today" not in fields["date"]:
+            lines.append(f"日期: {fields['date']}")
+        return "\n".join(lines)
+
+    def _convert(self, body: str, macros: Dict[str, str], first_level: int) -> str:
+        saved: List[str] = []
+
+        def save(text: str) -> str:
+            saved.append(text)
+            return _PLACEHOLDER.format(len(saved) - 1)
+
+        # Verbatim first, its content is not LaTeX
+        for env in VERBATIM_ENVS:
+            body = re.sub(
+                r"\\begin\{" + env + r"\}(?:\[[^\]]*\])?(?:\{[^}]*\})?"
+                r"(.*?)\\end\{" + env + r"\}",
+                lambda m: "\n\n" + save(f"```\n{m.group(1).strip(chr(10))}\n```") + "\n\n",
+                body,
+                flags=re.S,
+            )
+        body = re.sub(
+            r"\\verb([^A-Za-z\s])(.*?)\1", lambda m: save("`" + m.group(2) + "`"), body
+        )
+
+        body = self._protect_math(body, macros, save)
+        body = self._convert_environments(body, save)
+        body = self._convert_sections(body, first_level)
+        body = self._inline(body)
+        body = self._join_lines(body)
+
+        def restore(m: re.Match) -> str:
+            return _PLACEHOLDER_RE.sub(restore, saved[int(m.group(1))])
+
+        return _PLACEHOLDER_RE.sub(restore, body).strip()
+
+    def _protect_math(
+        self, body: str, macros: Dict[str, str], save: Callable[[str], str]
+    ) -> str:
+        def clean(math: str) -> str:
+            math = _replace_command(math, "label", 1, lambda a: "")
+            math = re.sub(r"\\(?:nonumber|notag)\b", "", math)
+            for name, definition in macros.items():
+                math = re.sub(
+                    r"\\" + name + r"(?![A-Za-z])", lambda _: definition, math
+                )
+            return math.strip()
+
+        def display(math: str, wrapper: str = "") -> str:
+            math = clean(math)
+            if wrapper:
+                math = f"\\begin{{{wrapper}}}\n{math}\n\\end{{{wrapper}}}"
+            return "\n\n" + save(f"$$\n{math}\n$$") + "\n\n"
+
+        envs = "|".join(re.escape(env) for env in DISPLAY_MATH_ENVS)
+        body = re.sub(
+            r"\\begin\{(" + envs + r")\}(?:\{\d+\})?(.*?)\\end\{\1\}",
+            lambda m: display(m.group(2), DISPLAY_MATH_ENVS[m.group(1)]),
+            body,
+            flags=re.S,
+        )
+        body = re.sub(r"\$\$(.+?)\$\$", lambda m: display(m.group(1)), body, flags=re.S)
+        body = re.sub(r"\\\[(.+?)\\\]", lambda m: display(m.group(1)), body, flags=re.S)
+        body = re.sub(
+            r"\\\((.+?)\\\)",
+            lambda m: save("$" + clean(m.group(1)) + "$"),
+            body,
+            flags=re.S,
+        )
+        # Inline math does not span paragraphs
+        body = re.sub(
+            r"(?<!\\)\$((?:[^$\\\n]|\\.|\n(?!\s*\n))+?)\$",
+            lambda m: save("$" + clean(m.group(1)) + "$"),
+            body,
+        )
+        return body
+
+    def _convert_environments(self, body: str, save: Callable[[str], str]) -> str:
+        # Figures and tables keep their captions and tabulars
+        def float_env(m: re.Match) -> str:
+            label = "图" if m.group(1).startswith("figure") else "表"
+            inner = m.group(2)
+            captions: List[str] = []
+
+            def caption(args: List[str]) -> str:
+                captions.append(args[0])
+                return ""
+
+            inner = _replace_command(inner, "caption", 1, caption)
+            tables = re.findall(_TABULAR, inner, flags=re.S)
+            parts = [f"{label}: {c.strip()}" for c in captions]
+            parts.extend(save(self._tabular(t)) for t in tables)
+            return "\n\n" + "\n\n".join(parts) + "\n\n"
+
+        body = re.sub(
+            r"\\begin\{(figure\*?|table\*?|wrapfigure)\}(.*?)\\end\{\1\}",
+            float_env,
+            body,
+            flags=re.S,
+        )
+        body = re.sub(
+            _TABULAR,
+            lambda m: "\n\n" + save(self._tabular(m.group(0))) + "\n\n",
+            body,
+            flags=re.S,
+        )
+        body = self._convert_lists(body)
+
+        body = re.sub(
+            r"\\begin\{abstract\}(.*?)\\end\{abstract\}",
+            lambda m: "\n\n## 摘要\n\n" + m.group(1).strip() + "\n\n",
+            body,
+            flags=re.S,
+        )
+        for env, label in THEOREM_ENVS.items():
+            body = re.sub(
+                r"\\begin\{" + env + r"\*?\}(?:\[([^\]]*)\])?"
+                r"(.*?)\\end\{" + env + r"\*?\}",
+                lambda m, label=label: "\n\n**"
+                + label
+                + (f"（{m.group(1)}）" if m.group(1) else "")
+                + "** "
+                + m.group(2).strip()
+                + "\n\n",
+                body,
+                flags=re.S,
+            )
+        body = re.sub(
+            r"\\begin\{(quote|quotation)\}(.*?)\\end\{\1\}",
+            lambda m: "\n\n"
+            + "\n".join("> " + ln.strip() for ln in m.group(2).strip().splitlines())
+            + "\n\n",
+            body,
+            flags=re.S,
+        )
+        body = re.sub(
+            r"\\begin\{thebibliography\}\{[^}]*\}(.*?)\\end\{thebibliography\}",
+            lambda m: "\n\n## 参考文献\n\n"
+            + _replace_command(m.group(1), "bibitem", 1, lambda a: f"\n- [{a[0]}] "),
+            body,
+            flags=re.S,
+        )
+        # Other environments only lay out their content
+        return re.sub(r"\\(?:begin|end)\s*\{[^}]*\}(?:\[[^\]]*\])?", "\n", body)
+
+    def _convert_lists(self, body: str) -> str:
+        """Convert lists innermost first, nested items get indented"""
+        pattern = re.compile(
+            r"\\begin\{(itemize|enumerate|description)\}(?:\[[^\]]*\])?"
+            r"((?:(?!\\begin\{(?:itemize|enumerate|description)\}).)*?)"
+            r"\\end\{\1\}",
+            re.S,
+        )
+
+        def convert(m: re.Match) -> str:
+            kind = m.group(1)
+            items = re.split(r"\\item(?![A-Za-z])", m.group(2))[1:]
+            lines: List[str] = []
+            for n, item in enumerate(items, 1):
+                term = ""
+                item = item.lstrip()
+                if item.startswith("["):
+                    term, end = _read_group(item, 0, "[", "]")
+                    item = item[end:]
+                marker = f"{n}. " if kind == "enumerate" else "- "
+                if term:
+                    marker += f"**{term.strip()}** "
+                text = [line.strip() for line in item.strip().splitlines()]
+                lines.append(marker + (text[0] if text else ""))
+                indent = " " * len(f"{n}. " if kind == "enumerate" else "- ")
+                lines.extend(indent + line if line else "" for line in text[1:])
+            return "\n\n" + "\n".join(lines) + "\n\n"
+
+        while True:
+            body, count = pattern.subn(convert, body)
+            if count == 0:
+                return body
+
+    def _tabular(self, tabular: str) -> str:
+        m = re.match(r"\\begin\{tabular(\*?)\}", tabular)
+        i = _skip_spaces(tabular, m.end())
+        if i < len(tabular) and tabular[i] == "[":
+            _, i = _read_group(tabular, i, "[", "]")
+        if m.group(1):
+            # The width of tabular*
+            _, i = _read_group(tabular, _skip_spaces(tabular, i))
+        # The column specification, possibly with nested braces
+        _, i = _read_group(tabular, _skip_spaces(tabular, i))
+        inner = re.sub(r"\\end\{tabular\*?\}$", "", tabular[i:])
+        inner = _RULES.sub("", inner)
+        inner = _replace_command(inner, "multicolumn", 3, lambda a: a[2])
+        inner = _replace_command(inner, "multirow", 3, lambda a: a[2])
+        rows = []
+        for row in re.split(r"\\\\(?:\[[^\]]*\])?", inner):
+            if not row.strip():
+                continue
+            cells = [
+                self._inline(cell).replace("\n", " ").replace("|", "\\|").strip()
+                for cell in re.split(r"(?<!\\)&", row)
+            ]
+            rows.append(cells)
+        if not rows:
+            return ""
+        width = max(len(row) for row in rows)
+        rows = [row + [""] * (width - len(row)) for row in rows]
+        lines = ["| " + " | ".join(rows[0]) + " |", "|" + " --- |" * width]
+        lines.extend("| " + " | ".join(row) + " |" for row in rows[1:])
+        return "\n".join(lines)
+
+    def _convert_sections(self, body: str, first_level: int) -> str:
+        """Sections become headings, the outermost level used first_level"""
+        used = [
+            c for c in SECTION_COMMANDS if re.search(r"\\" + c + r"\*?\s*[\[{]", body)
+        ]
+        for depth, command in enumerate(used):
+            prefix = "#" * min(first_level + depth, 6)
+            body = _replace_command(
+                body,
+                command,
+                1,
+                lambda a, prefix=prefix: f"\n\n{prefix} {' '.join(a[0].split())}\n\n",
+            )
+        return body
+
+    def _inline(self, text: str) -> str:
+        """Convert inline commands and special characters"""
+        for name, nargs in DROPPED_COMMANDS.items():
+            text = _replace_command(text, name, nargs, lambda a: "")
+        for name, mark in INLINE_FORMATS.items():
+            text = _replace_command(
+                text, name, 1, lambda a, mark=mark: f"{mark}{a[0].strip()}{mark}"
+            )
+        text = _replace_command(text, "href", 2, lambda a: f"[{a[1]}]({a[0]})")
+        text = _replace_command(text, "url", 1, lambda a: a[0])
+        text = _replace_command(
+            text, "footnote", 1, lambda a: f"（注：{a[0].strip()}）"
+        )
+        for name in CITE_COMMANDS:
+            text = _replace_command(
+                text,
+                name,
+                1,
+                lambda a: "[" + ", ".join(k.strip() for k in a[0].split(",")) + "]",
+            )
+        for name in REF_COMMANDS:
+            text = _replace_command(text, name, 1, lambda a: f"[{a[0].strip()}]")
+
+        text = re.sub(r"\\\\(?:\[[^\]]*\])?", "\n", text)
+        for escaped in "&%$#_{}":
+            text = text.replace("\\" + escaped, "\x01" + escaped)
+        text = re.sub(r"\\[,;:! ]", " ", text)
+        # Accents such as \'e and \"{o}, the letter is kept
+        text = re.sub(r"\\['`^\"~=.]\{?([A-Za-z])\}?", r"\1", text)
+        text = re.sub(r"\\(?:ldots|dots)\b", "…", text)
+        text = re.sub(r"\\(?:LaTeX)\b", "LaTeX", text)
+        text = re.sub(r"\\(?:TeX)\b", "TeX", text)
+        # Any other command: its arguments stay as text, the command goes
+        text = re.sub(r"\\[A-Za-z]+\*?(?:\[[^\]]*\])?", "", text)
+        text = text.replace("{", "").replace("}", "")
+        text = text.replace("~", " ").replace("---", "—").replace("--", "–")
+        text = text.replace("``", "“").replace("''", "”")
+        return text.replace("\x01", "")
+
+    @staticmethod
+    def _join_lines(text: str) -> str:
+        """Join the hard-wrapped lines of paragraphs, keeping markdown blocks
+        and the indentation of nested list items"""
+        out: List[str] = []
+        for raw in text.splitlines():
+            line = re.sub(r"[ \t]+", " ", raw).strip()
+            if not line:
+                if out and out[-1]:
+                    out.append("")
+                continue
+            previous = out[-1] if out else ""
+            if _BLOCK_LINE.match(line):
+                indent = raw[: len(raw) - len(raw.lstrip())]
+                out.append(indent + line if _LIST_ITEM.match(line) else line)
+            elif previous and not previous.lstrip().startswith(("#", "|")):
+                cjk = _CJK.match(line[0]) and _CJK.match(previous[-1])
+                out[-1] = previous + ("" if cjk else " ") + line
+            else:
+                out.append(line)
+        return "\n".join(out)
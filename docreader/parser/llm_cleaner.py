@@ -0,0 +1,119 @@
+import logging
+from typing import Dict, List, Optional
+
+import ollama
+import requests
+
+logger = logging.getLogger(__name__)
+
+
+class LLMCleaner:
+    """Clean up the Markdown extracted from a web page with a large language model.
+
+    Readability extraction keeps the main article of a page, but cookie notices,
+    share buttons, "related articles" lists and inconsistent heading levels often
+    remain. The model removes such boilerplate and normalizes the headings without
+    rewriting the content. Long pages are cleaned in segments split on paragraph
+    boundaries.
+    """
+
+    # Instruction given to the model, in Chinese like the other prompts of DocReader
+    prompt = (
+        "下面是从网页抽取的 Markdown 内容。请删除与正文无关的部分，例如导航、"
+        "页眉页脚、Cookie 提示、分享按钮、广告、评论区和相关文章列表；"
+        "规范标题层级，使其从一级或二级标题开始并逐级递增。"
+        "不要改写、总结或翻译正文，保留正文中的链接、图片、表格和代码块。"
+        "只输出清理后的 Markdown，不要添加任何说明。"
+    )
+
+    # Characters of Markdown sent to the model at once
+    max_segment_chars = 12000
+
+    def __init__(self, llm_config: Optional[Dict] = None):
+        llm_config = llm_config or {}
+        self.base_url = (llm_config.get("base_url") or "").rstrip("/")
+        self.model = llm_config.get("model_name", "")
+        self.api_key = llm_config.get("api_key", "")
+        self.interface_type = (llm_config.get("interface_type") or "openai").lower()
+        # Seconds a segment may take, cleanup echoes the whole segment back
+        self.timeout = 120
+
+    def is_configured(self) -> bool:
+        """Whether a model is configured"""
+        return bool(self.base_url and self.model)
+
+    def clean(self, markdown: str) -> str:
+        """Clean the Markdown of a page.
+
+        Returns:
+            The cleaned Markdown. A segment the model fails on, or answers with
+            nothing, is kept as extracted.
+        """
+        if not self.is_configured():
+            logger.warning("LLM cleanup requested without a model, keeping extraction")
+            return markdown
+
+        cleaned: List[str] = []
+        segments = self._split(markdown)
+        logger.info(
+            f"Cleaning {len(markdown)} characters of Markdown in {len(segments)} "
+            f"segments with model {self.model}"
+        )
+        for segment in segments:
+            try:
+                result = self._complete(segment)
+            except Exception as e:
+                logger.error(f"LLM cleanup failed, keeping segment as extracted: {e}")
+                result = ""
+            cleaned.append(result.strip() or segment)
+        return "\n\n".join(cleaned)
+
+    def _split(self, markdown: str) -> List[str]:
+        """Split Markdown on blank lines into segments of at most max_segment_chars"""
+        segments: List[str] = []
+        current = ""
+        for paragraph in markdown.split("\n\n"):
+            if current and len(current) + len(paragraph) + 2 > self.max_segment_chars:
+                segments.append(current)
+                current = ""
+            current = f"{current}\n\n{paragraph}" if current else paragraph
+        if current:
+            segments.append(current)
+        return segments
+
+    def _complete(self, segment: str) -> str:
+        """Ask the model to clean one segment"""
+        if self.interface_type == "ollama":
+            host = self.base_url.replace("/v1", "")
+            client = ollama.Client(host=host, timeout=self.timeout)
+            response = client.chat(
+                model=self.model,
+                messages=[
+                    {"role": "system", "content": self.prompt},
+                    {"role": "user", "content": segment},
+                ],
+                options={"temperature": 0},
+            )
+            return response.message.content or ""
+
+        headers = {"Content-Type": "application/json"}
+        if self.api_key:
+            headers["Authorization"] = f"Bearer {self.api_key}"
+        response = requests.post(
+            self.base_url + "/chat/completions",
+            json={
+                "model": self.model,
+                "temperature": 0,
+                "messages": [
+                    {"role": "system", "content": self.prompt},
+                    {"role": "user", "content": segment},
+                ],
+            },
+            headers=headers,
+            timeout=self.timeout,
+        )
+        response.raise_for_status()
+        choices = response.json().get("choices") or []
+        if not choices:
+            return ""
+        return (choices[0].get("message") or {}).get("content") or ""
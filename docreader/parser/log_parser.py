@@ -0,0 +1,273 @@
+import logging
+import re
+from collections import Counter
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional
+
+from docreader.models.document import Document
+from docreader.parser.base_parser import BaseParser
+from docreader.utils import endecode
+
+logger = logging.getLogger(__name__)
+
+# Patterns listed in the overview, most frequent first
+MAX_PATTERNS = 200
+# Verbatim samples kept for each error or warning pattern
+MAX_SAMPLES_PER_PATTERN = 3
+# Continuation lines (e.g. stack traces) kept per sample
+MAX_CONTINUATION_LINES = 30
+# Runs of an already listed pattern shown again in the timeline from this length
+MIN_BURST_RUN = 20
+
+_TIMESTAMP = re.compile(
+    # 2024-01-02T03:04:05.678Z, 2024-01-02 03:04:05,678 +0800, 2024/01/02 03:04:05
+    r"\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:\s?Z|\s?[+-]\d{2}:?\d{2})?"
+    # 02/Jan/2024:03:04:05 +0000 (access logs)
+    r"|\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2}(?: [+-]\d{4})?"
+    # Jan  2 03:04:05 (syslog)
+    r"|[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2}"
+    # 0102 03:04:05.678901 (glog)
+    r"|\b[IWEF]?\d{4} \d{2}:\d{2}:\d{2}\.\d+"
+)
+_LEVEL = re.compile(
+    r"\b(TRACE|DEBUG|INFO|NOTICE|WARN(?:ING)?|ERROR|ERR|SEVERE|CRITICAL|CRIT|FATAL|PANIC|EMERG|ALERT)\b",
+    re.IGNORECASE,
+)
+_ERROR_HINT = re.compile(
+    r"Traceback \(most recent call last\)|\bException\b|\bpanic:|\bfailed\b|\berror\b",
+    re.IGNORECASE,
+)
+_LEVELS = {
+    "trace": "DEBUG",
+    "debug": "DEBUG",
+    "info": "INFO",
+    "notice": "INFO",
+    "warn": "WARN",
+    "warning": "WARN",
+    "error": "ERROR",
+    "err": "ERROR",
+    "severe": "ERROR",
+    "critical": "ERROR",
+    "crit": "ERROR",
+    "fatal": "ERROR",
+    "panic": "ERROR",
+    "emerg": "ERROR",
+    "alert": "ERROR",
+}
+
+# Variable parts of a message replaced by <*> when building its pattern,
+# applied in order so that longer tokens are replaced first
+_VARIABLES = [
+    re.compile(r"\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b"),
+    re.compile(r"\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b"),
+    re.compile(r"\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{16,}\b"),
+    re.compile(r"\"[^\"]*\"|'[^']*'"),
+    re.compile(r"(?<![A-Za-z])[-+]?\d+(?:\.\d+)?(?:ms|s|us|ns|kb|mb|gb|%)?(?![A-Za-z])", re.IGNORECASE),
+]
+
+
+@dataclass
+class _Entry:
+    """A log record: its first line and the continuation lines below it"""
+
+    line_no: int
+    text: str
+    timestamp: str
+    level: str
+    pattern: str
+    continuation: List[str] = field(default_factory=list)
+
+
+@dataclass
+class _Pattern:
+    pattern: str
+    level: str
+    count: int = 0
+    first: Optional[_Entry] = None
+    last: Optional[_Entry] = None
+    samples: List[_Entry] = field(default_factory=list)
+
+
+def _message_pattern(text: str) -> str:
+    text = _TIMESTAMP.sub("", text, count=1)
+    for variable in _VARIABLES:
+        text = variable.sub("<*>", text)
+    return re.sub(r"\s+", " ", text).strip()
+
+
+def _entry_level(text: str) -> str:
+    m = _LEVEL.search(text[:200])
+    if m:
+        return _LEVELS[m.group(1).lower()]
+    if _ERROR_HINT.search(text):
+        return "ERROR"
+    return "INFO"
+
+
+class LogParser(BaseParser):
+    """
+    Parser for log files (.log).
+
+    Log files repeat the same few messages thousands of times, so instead of
+    indexing them verbatim the parser groups records by message pattern
+    (timestamps, numbers, IDs and quoted values replaced by <*>) and renders:
+
+    - an overview: line and record counts, time range and level counts
+    - the patterns with their counts and first/last timestamps
+    - up to MAX_SAMPLES_PER_PATTERN verbatim samples of every error and
+      warning pattern, including stack traces
+    - a timeline keeping the first record of each pattern and later bursts,
+      with runs of the same pattern collapsed into one line
+
+    Lines that do not start with a timestamp or a level continue the record
+    above them, so multi-line stack traces stay with their error.
+    """
+
+    def parse_into_text(self, content: bytes) -> Document:
+        text = endecode.decode_bytes(content)
+        lines = text.splitlines()
+        entries = self._entries(lines)
+        logger.info(f"Parsed log with {len(lines)} lines into {len(entries)} records")
+
+        patterns: Dict[str, _Pattern] = {}
+        for entry in entries:
+            key = entry.level + " " + entry.pattern
+            p = patterns.get(key)
+            if p is None:
+                p = patterns[key] = _Pattern(pattern=entry.pattern, level=entry.level)
+                p.first = entry
+            p.count += 1
+            p.last = entry
+            if entry.level != "INFO" and entry.level != "DEBUG":
+                if len(p.samples) < MAX_SAMPLES_PER_PATTERN:
+                    p.samples.append(entry)
+
+        sections = [
+            self._overview(lines, entries, patterns),
+            self._patterns_section(patterns),
+            self._issues_section(patterns),
+            self._timeline_section(entries, patterns),
+        ]
+        document = "\n\n".join(s for s in sections if s)
+        logger.info(
+            f"Summarized log into {len(document)} characters, "
+            f"{len(patterns)} patterns"
+        )
+        return Document(content=document)
+
+    def _entries(self, lines: List[str]) -> List[_Entry]:
+        entries: List[_Entry] = []
+        for i, line in enumerate(lines):
+            if not line.strip():
+                continue
+            head = line[:64]
+            timestamp = _TIMESTAMP.search(head)
+            starts_record = (timestamp is not None and timestamp.start() < 32) or (
+                _LEVEL.match(line.lstrip("[ ")) is not None
+            )
+            # In timestamped logs a line without a timestamp continues the
+            # record above, otherwise only indented lines do
+            if entries and not starts_record and (
+                line[:1].isspace() or (entries[-1].timestamp and timestamp is None)
+            ):
+                if len(entries[-1].continuation) < MAX_CONTINUATION_LINES:
+                    entries[-1].continuation.append(line)
+                # A stack trace below an info line still marks an error
+                if entries[-1].level in ("INFO", "DEBUG") and _ERROR_HINT.search(line):
+                    entries[-1].level = "ERROR"
+                continue
+            entries.append(
+                _Entry(
+                    line_no=i + 1,
+                    text=line.rstrip(),
+                    timestamp=timestamp.group(0) if timestamp else "",
+                    level=_entry_level(line),
+                    pattern=_message_pattern(line),
+                )
+            )
+        return entries
+
+    def _overview(
+        self, lines: List[str], entries: List[_Entry], patterns: Dict[str, _Pattern]
+    ) -> str:
+        levels = Counter(entry.level for entry in entries)
+        timestamps = [entry.timestamp for entry in entries if entry.timestamp]
+        parts = [
+            "# 日志概览",
+            f"文件名: {self.file_name}" if self.file_name else "",
+            f"共 {len(lines)} 行，{len(entries)} 条记录，{len(patterns)} 种消息模式",
+        ]
+        if timestamps:
+            parts.append(f"时间范围: {timestamps[0]} ~ {timestamps[-1]}")
+        parts.append(
+            "级别统计: "
+            + "，".join(
+                f"{level} {levels[level]}"
+                for level in ("ERROR", "WARN", "INFO", "DEBUG")
+                if levels[level]
+            )
+        )
+        return "\n".join(p for p in parts if p)
+
+    def _patterns_section(self, patterns: Dict[str, _Pattern]) -> str:
+        ranked = sorted(patterns.values(), key=lambda p: -p.count)
+        lines = ["# 消息模式"]
+        for p in ranked[:MAX_PATTERNS]:
+            line = f"- [{p.level}] ×{p.count} {p.pattern}"
+            if p.first and p.first.timestamp:
+                line += f"（{self._time_range(p.first, p.last, p.count)}）"
+            lines.append(line)
+        if len(ranked) > MAX_PATTERNS:
+            rest = sum(p.count for p in ranked[MAX_PATTERNS:])
+            lines.append(f"- 其余 {len(ranked) - MAX_PATTERNS} 种模式共 {rest} 条记录")
+        return "\n".join(lines)
+
+    def _issues_section(self, patterns: Dict[str, _Pattern]) -> str:
+        issues = [p for p in patterns.values() if p.samples]
+        if not issues:
+            return ""
+        # Errors before warnings, each in order of first occurrence
+        issues.sort(key=lambda p: (p.level != "ERROR", p.first.line_no))
+        blocks = ["# 错误与警告"]
+        for p in issues:
+            block = [f"## [{p.level}] {p.pattern}", f"共 {p.count} 次"]
+            if p.first.timestamp:
+                block[-1] += f"，{self._time_range(p.first, p.last, p.count)}"
+            for sample in p.samples:
+                block.append(f"第 {sample.line_no} 行:")
+                block.append("\n".join([sample.text] + sample.continuation))
+            blocks.append("\n".join(block))
+        return "\n\n".join(blocks)
+
+    def _timeline_section(
+        self, entries: List[_Entry], patterns: Dict[str, _Pattern]
+    ) -> str:
+        """First record of every pattern in order, runs collapsed"""
+        lines = ["# 时间线"]
+        seen = set()
+        i = 0
+        while i < len(entries):
+            entry = entries[i]
+            key = entry.level + " " + entry.pattern
+            j = i
+            while j + 1 < len(entries) and (
+                entries[j + 1].level + " " + entries[j + 1].pattern == key
+            ):
+                j += 1
+            run = j - i + 1
+            # Later runs of a known pattern only matter when they are bursts
+            if key not in seen or run >= MIN_BURST_RUN:
+                line = entry.text
+                if run > 1:
+                    end = entries[j].timestamp or f"第 {entries[j].line_no} 行"
+                    line += f"\n  ↳ 连续重复 {run} 次，至 {end}"
+                lines.append(line)
+            seen.add(key)
+            i = j + 1
+        return "\n".join(lines)
+
+    @staticmethod
+    def _time_range(first: _Entry, last: _Entry, count: int) -> str:
+        if count == 1 or first.timestamp == last.timestamp:
+            return f"{first.timestamp}"
+        return f"{first.timestamp} ~ {last.timestamp}"
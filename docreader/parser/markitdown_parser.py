@@ -1,16 +1,29 @@
 import io
 import logging
+from typing import Iterator, Tuple
 
 from markitdown import MarkItDown
+from pdfminer.high_level import extract_pages
+from pdfminer.layout import LTTextContainer
 
 from docreader.models.document import Document
 from docreader.parser.base_parser import BaseParser
 from docreader.parser.chain_parser import PipelineParser
 from docreader.parser.markdown_parser import MarkdownParser
+from docreader.splitter.anchors import page_markers
 
 logger = logging.getLogger(__name__)
 
 
+def pdf_text_blocks(content: bytes) -> Iterator[Tuple[int, str]]:
+    """Text blocks of a PDF as (page, text), pages counted from 1, laid out
+    by pdfminer like the text markitdown extracts"""
+    for page_number, page in enumerate(extract_pages(io.BytesIO(content)), 1):
+        for element in page:
+            if isinstance(element, LTTextContainer):
+                yield page_number, element.get_text()
+
+
 class StdMarkitdownParser(BaseParser):
     """
     Standard MarkItDown Parser Wrapper
@@ -39,7 +52,14 @@ class StdMarkitdownParser(BaseParser):
             file_extension=ext,
             keep_data_uris=True
         )
-        return Document(content=result.text_content)
+        if (ext or "").lower() != ".pdf":
+            return Document(content=result.text_content)
+        # Page markers anchor chunks to their pages, whatever the Markdown
+        # pipeline does to the form feeds between pages
+        return Document(
+            content=result.text_content,
+            metadata={"page_markers": page_markers(pdf_text_blocks(content))},
+        )
 
 
 class MarkitdownParser(PipelineParser):
@@ -0,0 +1,157 @@
+import base64
+import email
+import io
+import logging
+from email import policy
+from email.message import Message
+from typing import Dict, Optional, Tuple
+from urllib.parse import urljoin
+
+from bs4 import BeautifulSoup
+from PIL import Image
+
+from docreader.models.document import Document
+from docreader.parser.base_parser import BaseParser
+from docreader.parser.web_parser import StdWebParser
+from docreader.utils import endecode
+
+logger = logging.getLogger(__name__)
+
+# File extensions of the image types kept as they are when they cannot be
+# opened to make a thumbnail
+IMAGE_MIME_TYPES = {
+    "image/png": ".png",
+    "image/jpeg": ".jpg",
+    "image/gif": ".gif",
+    "image/webp": ".webp",
+    "image/bmp": ".bmp",
+}
+
+
+def _part_bytes(part: Message) -> bytes:
+    return part.get_payload(decode=True) or b""
+
+
+def _part_html(part: Message) -> str:
+    """Decode an HTML part with its charset, falling back to detection"""
+    data = _part_bytes(part)
+    charset = part.get_content_charset()
+    if charset:
+        try:
+            return data.decode(charset)
+        except (LookupError, UnicodeDecodeError):
+            logger.warning(f"Failed to decode HTML part as {charset}")
+    return endecode.decode_bytes(data)
+
+
+class MhtmlParser(BaseParser):
+    """
+    Parser for web archives (.mht, .mhtml) saved by browsers and Word.
+
+    The archive is a MIME multipart message: the first HTML part is the page,
+    the other parts are its images, styles and frames, found by their
+    Content-Location or, for cid: references, their Content-ID. Embedded
+    images are reduced to thumbnails of at most max_image_size pixels,
+    uploaded to storage and the image references of the page rewritten to
+    them before the page is converted to markdown, so they go through OCR
+    and captioning when multimodal is enabled. Without multimodal, images
+    are replaced by their alt text.
+    """
+
+    # Images are uploaded by the parser, .mht is not an image file type
+    always_process_images = True
+
+    def parse_into_text(self, content: bytes) -> Document:
+        message = email.message_from_bytes(content, policy=policy.compat32)
+        page: Optional[Message] = None
+        resources: Dict[str, Message] = {}
+        for part in message.walk():
+            if part.is_multipart():
+                continue
+            if page is None and part.get_content_type() == "text/html":
+                page = part
+                continue
+            location = part.get("Content-Location")
+            if location:
+                resources[location.strip()] = part
+            content_id = part.get("Content-ID")
+            if content_id:
+                resources["cid:" + content_id.strip().strip("<>")] = part
+        if page is None:
+            logger.warning("Web archive has no HTML part")
+            return Document()
+
+        html = _part_html(page)
+        base = (page.get("Content-Location") or "").strip()
+        logger.info(
+            f"Parsing web archive {base or self.file_name}: "
+            f"{len(html)} characters of HTML, {len(resources)} resources"
+        )
+
+        images: Dict[str, str] = {}
+        soup = BeautifulSoup(html, "lxml")
+        uploaded: Dict[int, str] = {}
+        for img in soup.find_all("img"):
+            src = (img.get("src") or "").strip()
+            part = resources.get(src)
+            if part is None and base:
+                part = resources.get(urljoin(base, src))
+            if part is None or not part.get_content_type().startswith("image/"):
+                # Not archived, left to the image download of the base parser
+                continue
+            image_url = uploaded.get(id(part))
+            if image_url is None:
+                image_url = uploaded[id(part)] = self._upload_thumbnail(part, images)
+            if image_url:
+                img["src"] = image_url
+            else:
+                img.replace_with(img.get("alt") or "")
+
+        md_text = StdWebParser.convert_full_page(str(soup))
+        title = soup.title.get_text(strip=True) if soup.title else ""
+        if title and not md_text.startswith("#"):
+            md_text = f"# {title}\n\n{md_text}"
+        logger.info(f"Converted web archive with {len(images)} images")
+        return Document(content=md_text, images=images)
+
+    def _upload_thumbnail(self, part: Message, images: Dict[str, str]) -> str:
+        """Upload the thumbnail of an image part, returns its storage URL or ''"""
+        if not self.enable_multimodal:
+            return ""
+        data = _part_bytes(part)
+        if not data:
+            return ""
+        thumbnail = self._thumbnail(data)
+        if thumbnail is None:
+            ext = IMAGE_MIME_TYPES.get(part.get_content_type())
+            if ext is None:
+                logger.info(f"Skipping image of type {part.get_content_type()}")
+                return ""
+            thumbnail = (data, ext)
+        data, ext = thumbnail
+        try:
+            image_url = self.storage.upload_bytes(data, file_ext=ext)
+        except Exception as e:
+            logger.error(f"Failed to upload web archive image: {e}")
+            return ""
+        if not image_url:
+            return ""
+        images[image_url] = base64.b64encode(data).decode()
+        return image_url
+
+    def _thumbnail(self, data: bytes) -> Optional[Tuple[bytes, str]]:
+        """Shrink an image to max_image_size pixels, PNG when it has
+        transparency and JPEG otherwise, None when it cannot be opened"""
+        try:
+            image = Image.open(io.BytesIO(data))
+            image.load()
+        except Exception as e:
+            logger.warning(f"Failed to open web archive image: {e}")
+            return None
+        image.thumbnail((self.max_image_size, self.max_image_size))
+        out = io.BytesIO()
+        if image.mode in ("RGBA", "LA", "P"):
+            image.save(out, format="PNG")
+            return out.getvalue(), ".png"
+        image.convert("RGB").save(out, format="JPEG", quality=85)
+        return out.getvalue(), ".jpg"
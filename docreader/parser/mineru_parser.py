@@ -1,7 +1,8 @@
+import json
 import logging
 import re
 import time
-from typing import Dict, Optional
+from typing import Any, Dict, List, Optional
 
 import markdownify
 import requests
@@ -11,11 +12,29 @@ from docreader.models.document import Document
 from docreader.parser.base_parser import BaseParser
 from docreader.parser.chain_parser import PipelineParser
 from docreader.parser.markdown_parser import MarkdownImageUtil, MarkdownTableFormatter
+from docreader.splitter.anchors import page_markers
 from docreader.utils import endecode
 
 logger = logging.getLogger(__name__)
 
 
+def content_list_page_markers(content_list: Any) -> List[List]:
+    """Page markers of a document from the content list of MinerU, whose text
+    blocks carry the index of their page; the list may come JSON encoded"""
+    if isinstance(content_list, str):
+        try:
+            content_list = json.loads(content_list)
+        except ValueError:
+            return []
+    if not isinstance(content_list, list):
+        return []
+    return page_markers(
+        (int(block.get("page_idx") or 0) + 1, block.get("text") or "")
+        for block in content_list
+        if isinstance(block, dict) and block.get("type") == "text"
+    )
+
+
 class StdMinerUParser(BaseParser):
     """
     Standard MinerU Parser for document parsing.
@@ -88,6 +107,7 @@ class StdMinerUParser(BaseParser):
         logger.info(f"Parsing scanned PDF via MinerU API (size: {len(content)} bytes)")
         md_content: str = ""
         images_b64: Dict[str, str] = {}
+        markers: List[List] = []
         try:
             # Call MinerU API to parse document
             response = requests.post(
@@ -105,7 +125,7 @@ class StdMinerUParser(BaseParser):
                     "response_format_zip": False,  # Return JSON instead of ZIP
                     "return_middle_json": False,  # Don't return intermediate JSON
                     "return_model_output": False,  # Don't return model output
-                    "return_content_list": False,  # Don't return content list
+                    "return_content_list": True,  # Pages of the blocks, for page anchors
                 },
                 files={"files": content},
                 timeout=1000,
@@ -114,6 +134,7 @@ class StdMinerUParser(BaseParser):
             result = response.json()["results"]["files"]
             md_content = result["md_content"]
             images_b64 = result.get("images", {})
+            markers = content_list_page_markers(result.get("content_list"))
         except Exception as e:
             logger.error(f"MinerU parsing failed: {e}", exc_info=True)
             return Document()
@@ -162,9 +183,12 @@ class StdMinerUParser(BaseParser):
         text = self.image_helper.replace_path(md_content, image_replace)
 
         logger.info(
-            f"Successfully parsed PDF, text: {len(text)}, images: {len(images)}"
+            f"Successfully parsed PDF, text: {len(text)}, images: {len(images)}, "
+            f"pages located: {len(markers)}"
+        )
+        return Document(
+            content=text, images=images, metadata={"page_markers": markers}
         )
-        return Document(content=text, images=images)
 
 
 # Added: 新增 MinerUCloudParser 类，支持异步任务提交
@@ -256,6 +280,7 @@ class MinerUCloudParser(StdMinerUParser):
 
             md_content = result_data.get("md_content", "")
             images_b64 = result_data.get("images", {})
+            markers = content_list_page_markers(result_data.get("content_list"))
 
             # 使用父类的方法处理图片和Markdown转换 (复用现有逻辑)
 
@@ -284,7 +309,9 @@ class MinerUCloudParser(StdMinerUParser):
             if image_replace:
                 md_content = self.image_helper.replace_path(md_content, image_replace)
 
-            return Document(content=md_content, images=images)
+            return Document(
+                content=md_content, images=images, metadata={"page_markers": markers}
+            )
 
         except Exception as e:
             logger.error(f"Cloud MinerU parsing failed: {e}", exc_info=True)
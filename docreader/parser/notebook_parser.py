@@ -0,0 +1,165 @@
+import base64
+import json
+import logging
+import re
+from typing import Any, Dict, List, Tuple
+
+from docreader.models.document import Document
+from docreader.parser.base_parser import BaseParser
+
+logger = logging.getLogger(__name__)
+
+# Maximum characters of a single text output kept in the document
+# 单个文本输出保留的最大字符数
+MAX_OUTPUT_CHARS = 2000
+
+# Image output MIME types and their file extensions
+IMAGE_MIME_TYPES = {
+    "image/png": ".png",
+    "image/jpeg": ".jpg",
+    "image/gif": ".gif",
+}
+
+# ANSI escape sequences used by tracebacks and colored outputs
+ANSI_ESCAPE = re.compile(r"\x1b\[[0-9;]*[A-Za-z]")
+
+
+def _join_source(source: Any) -> str:
+    """Notebook text fields are either a string or a list of lines"""
+    if isinstance(source, list):
+        return "".join(str(s) for s in source)
+    return str(source or "")
+
+
+class NotebookParser(BaseParser):
+    """
+    Parser for Jupyter notebooks (.ipynb).
+
+    Cells are rendered in order: markdown and raw cells as-is, code cells as
+    fenced code blocks followed by their text outputs. Image outputs and
+    markdown attachments are uploaded to storage and referenced as markdown
+    images so they go through OCR and captioning when multimodal is enabled.
+    Each chunk records the cells it covers in metadata["notebook_cells"].
+    """
+
+    def __init__(self, *args, **kwargs):
+        super().__init__(*args, **kwargs)
+        # (start, end, cell index, cell type) of each rendered cell
+        self._cell_spans: List[Tuple[int, int, int, str]] = []
+
+    def parse_into_text(self, content: bytes) -> Document:
+        notebook = json.loads(content.decode("utf-8", errors="ignore"))
+        cells = notebook.get("cells")
+        if cells is None:
+            # nbformat 3 keeps cells in worksheets
+            cells = [
+                cell
+                for worksheet in notebook.get("worksheets", [])
+                for cell in worksheet.get("cells", [])
+            ]
+        metadata = notebook.get("metadata", {})
+        language = (
+            metadata.get("language_info", {}).get("name")
+            or metadata.get("kernelspec", {}).get("language")
+            or ""
+        )
+        logger.info(f"Parsing notebook with {len(cells)} cells, language: {language}")
+
+        images: Dict[str, str] = {}
+        parts: List[str] = []
+        self._cell_spans = []
+        offset = 0
+        for index, cell in enumerate(cells):
+            cell_type = cell.get("cell_type", "")
+            if cell_type == "code":
+                text = self._render_code_cell(cell, language, images)
+            elif cell_type == "markdown":
+                text = self._render_markdown_cell(cell, images)
+            else:
+                text = _join_source(cell.get("source")).strip()
+            if not text:
+                continue
+
+            if parts:
+                offset += 2  # the "\n\n" separator
+            self._cell_spans.append((offset, offset + len(text), index, cell_type))
+            parts.append(text)
+            offset += len(text)
+
+        return Document(content="\n\n".join(parts), images=images)
+
+    def parse(self, content: bytes) -> Document:
+        document = super().parse(content)
+        for chunk in document.chunks:
+            chunk.metadata["notebook_cells"] = [
+                {"index": index, "type": cell_type}
+                for start, end, index, cell_type in self._cell_spans
+                if start < chunk.end and end > chunk.start
+            ]
+        return document
+
+    def _render_code_cell(
+        self, cell: Dict[str, Any], language: str, images: Dict[str, str]
+    ) -> str:
+        source = _join_source(cell.get("source") or cell.get("input")).strip()
+        parts = [f"```{language}\n{source}\n```"] if source else []
+
+        for output in cell.get("outputs", []):
+            output_type = output.get("output_type", "")
+            data = output.get("data", {})
+            text = ""
+            if output_type == "stream":
+                text = _join_source(output.get("text"))
+            elif output_type == "error":
+                text = "\n".join(output.get("traceback") or []) or (
+                    f"{output.get('ename', '')}: {output.get('evalue', '')}"
+                )
+            else:
+                image_url = self._upload_image(data, images)
+                if image_url:
+                    parts.append(f"![output]({image_url})")
+                    continue
+                if "text/markdown" in data:
+                    parts.append(_join_source(data["text/markdown"]).strip())
+                    continue
+                text = _join_source(data.get("text/plain") or output.get("text"))
+
+            text = ANSI_ESCAPE.sub("", text).strip()
+            if not text:
+                continue
+            if len(text) > MAX_OUTPUT_CHARS:
+                text = text[:MAX_OUTPUT_CHARS] + "\n..."
+            parts.append(f"```\n{text}\n```")
+
+        return "\n\n".join(p for p in parts if p)
+
+    def _render_markdown_cell(
+        self, cell: Dict[str, Any], images: Dict[str, str]
+    ) -> str:
+        text = _join_source(cell.get("source")).strip()
+        for name, data in (cell.get("attachments") or {}).items():
+            image_url = self._upload_image(data, images)
+            if image_url:
+                text = text.replace(f"attachment:{name}", image_url)
+        return text
+
+    def _upload_image(self, data: Dict[str, Any], images: Dict[str, str]) -> str:
+        """Upload the first image of a MIME bundle, returns its storage URL or ''"""
+        if not self.enable_multimodal:
+            return ""
+        for mime, ext in IMAGE_MIME_TYPES.items():
+            if mime not in data:
+                continue
+            b64 = _join_source(data[mime]).replace("\n", "")
+            try:
+                image_url = self.storage.upload_bytes(
+                    base64.b64decode(b64), file_ext=ext
+                )
+            except Exception as e:
+                logger.error(f"Failed to upload notebook image output: {e}")
+                return ""
+            if not image_url:
+                return ""
+            images[image_url] = b64
+            return image_url
+        return ""
@@ -1,17 +1,28 @@
 import logging
-from typing import Dict, Type
+from typing import Dict, List, Optional, Type
 
 from docreader.config import CONFIG
 from docreader.models.document import Document
 from docreader.models.read_config import ChunkingConfig
 from docreader.parser.base_parser import BaseParser
+from docreader.parser.code_parser import CODE_LANGUAGES, CodeParser
 from docreader.parser.csv_parser import CSVParser
 from docreader.parser.doc_parser import DocParser
+from docreader.parser.enex_parser import EnexParser
 from docreader.parser.docx2_parser import Docx2Parser
 from docreader.parser.excel_parser import ExcelParser
 from docreader.parser.image_parser import ImageParser
+from docreader.parser.latex_parser import LatexParser
+from docreader.parser.log_parser import LogParser
 from docreader.parser.markdown_parser import MarkdownParser
+from docreader.parser.mhtml_parser import MhtmlParser
+from docreader.parser.notebook_parser import NotebookParser
 from docreader.parser.pdf_parser import PDFParser
+from docreader.parser.plugin_parser import (
+    ParserPlugin,
+    PluginParser,
+    load_parser_plugins,
+)
 from docreader.parser.text_parser import TextParser
 from docreader.parser.web_parser import WebParser
 
@@ -47,13 +58,53 @@ class Parser:
             "csv": CSVParser,
             "xlsx": ExcelParser,
             "xls": ExcelParser,
+            # Jupyter notebooks
+            "ipynb": NotebookParser,
+            # Log files, summarized by message pattern
+            "log": LogParser,
+            # Evernote exports, with their images and attachments
+            "enex": EnexParser,
+            # Web archives saved by browsers and Word, with their images
+            "mht": MhtmlParser,
+            "mhtml": MhtmlParser,
+            # LaTeX documents, and LaTeX projects uploaded as zip archives
+            "tex": LatexParser,
+            "latex": LatexParser,
+            "zip": LatexParser,
         }
+        # Source code files, split at function and class boundaries
+        self.parsers.update({ext: CodeParser for ext in CODE_LANGUAGES})
+        # External parser plugins, configured with DOCREADER_PARSER_PLUGINS
+        self.plugins: List[ParserPlugin] = []
+        for plugin in load_parser_plugins(CONFIG.parser_plugins):
+            self.register_plugin(plugin)
         logger.info(
             "Parser initialized with %d parsers: %s",
             len(self.parsers),
             ", ".join(self.parsers.keys()),
         )
 
+    def register_plugin(self, plugin: ParserPlugin) -> None:
+        """
+        Parse the file types of a plugin with it, replacing built-in parsers.
+
+        Args:
+            plugin: External parser and the file extensions it serves
+        """
+        cls = PluginParser.for_plugin(plugin)
+        for ext in plugin.extensions:
+            if ext in self.parsers:
+                logger.warning(
+                    f"Parser plugin {plugin.name} replaces "
+                    f"{self.parsers[ext].__name__} for .{ext} files"
+                )
+            self.parsers[ext] = cls
+        self.plugins.append(plugin)
+        logger.info(
+            f"Registered parser plugin {plugin.name} ({plugin.protocol} "
+            f"{plugin.endpoint}) for {', '.join(plugin.extensions)}"
+        )
+
     def get_parser(self, file_type: str) -> Type[BaseParser]:
         """
         Get parser class for the specified file type.
@@ -112,7 +163,8 @@ class Parser:
             max_image_size=1920,  # Limit image size to 1920px for performance
             chunking_config=config,  # Pass the entire chunking config for advanced options
             max_concurrent_tasks=CONFIG.image_max_concurrent,
-            ocr_backend=CONFIG.ocr_backend,
+            ocr_backend=config.ocr_config.get("engine") or CONFIG.ocr_backend,
+            ocr_config=config.ocr_config,
         )
 
         logger.info(f"Starting to parse file content, size: {len(content)} bytes")
@@ -130,7 +182,15 @@ class Parser:
         logger.info(f"Parsed file {file_name}, with {len(result.chunks)} chunks")
         return result
 
-    def parse_url(self, url: str, title: str, config: ChunkingConfig) -> Document:
+    def parse_url(
+        self,
+        url: str,
+        title: str,
+        config: ChunkingConfig,
+        extraction_mode: str = "",
+        llm_config: Optional[dict] = None,
+        fetch_config: Optional[dict] = None,
+    ) -> Document:
         """
         Parse content from a URL using the WebParser.
 
@@ -138,6 +198,9 @@ class Parser:
             url: URL to parse
             title: Title of the webpage (for metadata)
             config: Configuration for chunking process
+            extraction_mode: full, readability or llm; empty for readability
+            llm_config: Model cleaning the extraction in llm mode
+            fetch_config: User-Agent, headers and cookies sent to the site
 
         Returns:
             ParseResult containing chunks and metadata, or None if parsing failed
@@ -159,7 +222,11 @@ class Parser:
             max_image_size=1920,  # Limit image size to 1920px for performance
             chunking_config=config,  # Pass the entire chunking config
             max_concurrent_tasks=CONFIG.image_max_concurrent,
-            ocr_backend=CONFIG.ocr_backend,
+            ocr_backend=config.ocr_config.get("engine") or CONFIG.ocr_backend,
+            ocr_config=config.ocr_config,
+            extraction_mode=extraction_mode or "readability",
+            llm_config=llm_config,
+            fetch_config=fetch_config,
         )
 
         logger.info("Starting to parse URL content")
@@ -1,6 +1,7 @@
 from docreader.parser.chain_parser import FirstParser
 from docreader.parser.markitdown_parser import MarkitdownParser
 from docreader.parser.mineru_parser import MinerUParser
+from docreader.parser.scanned_pdf_parser import ScannedPDFParser
 
 
 class PDFParser(FirstParser):
@@ -8,9 +9,11 @@ class PDFParser(FirstParser):
     
     Attempts to parse PDF files using multiple parser backends in order:
     1. MinerUParser - Primary parser for PDF documents
-    2. MarkitdownParser - Fallback parser if MinerU fails
+    2. ScannedPDFParser - OCR for pages without a text layer, skipped when
+       every page has extractable text
+    3. MarkitdownParser - Fallback parser for text PDFs
     
     The first successful parser result will be returned.
     """
     # Parser classes to try in order (chain of responsibility pattern)
-    _parser_cls = (MinerUParser, MarkitdownParser)
+    _parser_cls = (MinerUParser, ScannedPDFParser, MarkitdownParser)
@@ -0,0 +1,213 @@
+import base64
+import json
+import logging
+import os
+from dataclasses import dataclass, field
+from typing import Dict, List, Type
+
+import grpc
+import requests
+
+from docreader.config import CONFIG
+from docreader.models.document import Document
+from docreader.parser.base_parser import BaseParser
+from docreader.proto import docreader_pb2_grpc
+from docreader.proto.docreader_pb2 import ReadConfig, ReadFromFileRequest
+
+logger = logging.getLogger(__name__)
+
+PLUGIN_PROTOCOLS = ("http", "grpc")
+
+
+@dataclass(frozen=True)
+class ParserPlugin:
+    """An external parser serving file types over HTTP or gRPC"""
+
+    name: str
+    extensions: List[str]
+    endpoint: str
+    protocol: str = "http"
+    # Seconds to wait for the plugin to parse a file
+    timeout: int = 300
+    # Extra HTTP headers, e.g. for authentication
+    headers: Dict[str, str] = field(default_factory=dict)
+
+    @classmethod
+    def from_dict(cls, data: dict) -> "ParserPlugin":
+        name = str(data.get("name") or "").strip()
+        endpoint = str(data.get("endpoint") or "").strip()
+        protocol = str(data.get("protocol") or "http").strip().lower()
+        extensions = [
+            str(ext).strip().lstrip(".").lower()
+            for ext in data.get("extensions") or []
+            if str(ext).strip().lstrip(".")
+        ]
+        if not name or not endpoint or not extensions:
+            raise ValueError("parser plugin needs a name, an endpoint and extensions")
+        if protocol not in PLUGIN_PROTOCOLS:
+            raise ValueError(f"parser plugin {name}: unknown protocol {protocol}")
+        return cls(
+            name=name,
+            extensions=extensions,
+            endpoint=endpoint,
+            protocol=protocol,
+            timeout=int(data.get("timeout") or 300),
+            headers={str(k): str(v) for k, v in (data.get("headers") or {}).items()},
+        )
+
+
+def load_parser_plugins(value: str) -> List[ParserPlugin]:
+    """Load parser plugins from a JSON list, or from the JSON file it names.
+    Invalid entries are skipped with an error so one bad plugin does not keep
+    the service from starting."""
+    value = value.strip()
+    if not value:
+        return []
+    try:
+        if not value.startswith("["):
+            with open(value, "r", encoding="utf-8") as f:
+                value = f.read()
+        entries = json.loads(value)
+    except (OSError, ValueError) as e:
+        logger.error(f"Failed to load parser plugins: {e}")
+        return []
+    if not isinstance(entries, list):
+        logger.error("Parser plugins must be a JSON list")
+        return []
+
+    plugins: List[ParserPlugin] = []
+    for entry in entries:
+        try:
+            plugins.append(ParserPlugin.from_dict(entry))
+        except (AttributeError, TypeError, ValueError) as e:
+            logger.error(f"Skipping parser plugin {entry}: {e}")
+    return plugins
+
+
+class PluginParser(BaseParser):
+    """
+    Parser delegating to an external parser plugin.
+
+    HTTP plugins receive a POST of {"file_name", "file_type", "content"
+    (base64), "enable_multimodal"} and answer {"content": markdown, "images":
+    {name: base64}, "metadata": {...}}; the images are uploaded to storage and
+    their names in the markdown replaced with the storage URLs, so they go
+    through OCR and captioning like the images of built-in parsers. A non-2xx
+    answer or an "error" field fails the parse.
+
+    gRPC plugins implement DocReader.ReadFromFile of docreader.proto; the
+    contents of the chunks they return, in order, make up the document.
+
+    Either way the document is chunked here with the knowledge base settings.
+    Use PluginParser.for_plugin to get the parser class of a plugin.
+    """
+
+    plugin: ParserPlugin
+
+    # Images are uploaded by the parser, plugin file types are not image types
+    always_process_images = True
+
+    @classmethod
+    def for_plugin(cls, plugin: ParserPlugin) -> Type["PluginParser"]:
+        """Parser class bound to a plugin, for the parser registry"""
+        return type(f"PluginParser[{plugin.name}]", (cls,), {"plugin": plugin})
+
+    def parse_into_text(self, content: bytes) -> Document:
+        logger.info(
+            f"Parsing {self.file_name} with parser plugin {self.plugin.name} "
+            f"over {self.plugin.protocol}, {len(content)} bytes"
+        )
+        if self.plugin.protocol == "grpc":
+            document = self._parse_grpc(content)
+        else:
+            document = self._parse_http(content)
+        logger.info(
+            f"Parser plugin {self.plugin.name} returned "
+            f"{len(document.content)} characters, {len(document.images)} images"
+        )
+        return document
+
+    def _parse_http(self, content: bytes) -> Document:
+        response = requests.post(
+            self.plugin.endpoint,
+            json={
+                "file_name": self.file_name,
+                "file_type": self.file_type,
+                "content": base64.b64encode(content).decode(),
+                "enable_multimodal": self.enable_multimodal,
+            },
+            headers=self.plugin.headers,
+            timeout=self.plugin.timeout,
+        )
+        try:
+            result = response.json()
+        except ValueError:
+            result = {}
+        if not response.ok or result.get("error"):
+            raise RuntimeError(
+                f"parser plugin {self.plugin.name} failed with "
+                f"{response.status_code}: {result.get('error') or response.text[:200]}"
+            )
+
+        text = result.get("content") or ""
+        images: Dict[str, str] = {}
+        for name, data in (result.get("images") or {}).items():
+            image_url = self._upload_image(name, data, images)
+            if image_url:
+                text = text.replace(f"]({name})", f"]({image_url})")
+        metadata = result.get("metadata")
+        return Document(
+            content=text,
+            images=images,
+            metadata=metadata if isinstance(metadata, dict) else {},
+        )
+
+    def _parse_grpc(self, content: bytes) -> Document:
+        with grpc.insecure_channel(
+            self.plugin.endpoint,
+            options=[
+                ("grpc.max_send_message_length", CONFIG.grpc_max_file_size_mb),
+                ("grpc.max_receive_message_length", CONFIG.grpc_max_file_size_mb),
+            ],
+        ) as channel:
+            stub = docreader_pb2_grpc.DocReaderStub(channel)
+            response = stub.ReadFromFile(
+                ReadFromFileRequest(
+                    file_content=content,
+                    file_name=self.file_name,
+                    file_type=self.file_type,
+                    read_config=ReadConfig(
+                        chunk_size=self.chunk_size,
+                        # Chunks are joined back, they must not overlap
+                        chunk_overlap=0,
+                        separators=self.separators,
+                        enable_multimodal=self.enable_multimodal,
+                    ),
+                ),
+                timeout=self.plugin.timeout,
+            )
+        if response.error:
+            raise RuntimeError(
+                f"parser plugin {self.plugin.name} failed: {response.error}"
+            )
+        chunks = sorted(response.chunks, key=lambda chunk: chunk.seq)
+        return Document(content="\n\n".join(chunk.content for chunk in chunks))
+
+    def _upload_image(self, name: str, data: str, images: Dict[str, str]) -> str:
+        """Upload an image returned by a plugin, returns its storage URL or ''"""
+        if not self.enable_multimodal:
+            return ""
+        if data.startswith("data:"):
+            data = data.split(",", 1)[-1]
+        try:
+            image_bytes = base64.b64decode(data)
+            image_url = self.storage.upload_bytes(
+                image_bytes, file_ext=os.path.splitext(name)[1] or ".png"
+            )
+        except Exception as e:
+            logger.error(f"Failed to upload image {name} of parser plugin: {e}")
+            return ""
+        if not image_url:
+            return ""
+        images[image_url] = data
+        return image_url
@@ -0,0 +1,92 @@
+import io
+import logging
+from typing import List
+
+import pdfplumber
+
+from docreader.models.document import Document
+from docreader.parser.base_parser import BaseParser
+from docreader.splitter.anchors import page_markers
+from docreader.utils.progress import STAGE_PARSING, report_progress
+
+logger = logging.getLogger(__name__)
+
+# Pages with fewer extractable characters are treated as scanned
+# 可提取字符数少于该值的页面视为扫描页
+MIN_PAGE_TEXT_CHARS = 20
+
+# Resolution used to render scanned pages for OCR
+# 扫描页渲染为图片进行 OCR 时使用的分辨率
+OCR_PAGE_RESOLUTION = 200
+
+# OCR backends that do not recognize any text
+NO_OCR_BACKENDS = ("", "no_ocr", "dummy")
+
+
+class ScannedPDFParser(BaseParser):
+    """
+    Parser for PDFs whose pages lack a text layer.
+
+    Every page is read from its text layer first; pages with no extractable
+    text are rendered to images and run through the OCR engine, and the OCR
+    text is merged with whatever native text the page has. PDFs where all
+    pages have a text layer return an empty Document so the next parser in
+    the chain handles them.
+    """
+
+    def parse_into_text(self, content: bytes) -> Document:
+        if (self.ocr_backend or "").lower() in NO_OCR_BACKENDS:
+            logger.debug("OCR backend is disabled, skip scanned PDF detection")
+            return Document()
+
+        with pdfplumber.open(io.BytesIO(content)) as pdf:
+            native_texts: List[str] = [
+                (page.extract_text() or "").strip() for page in pdf.pages
+            ]
+            scanned = [
+                i
+                for i, text in enumerate(native_texts)
+                if len(text) < MIN_PAGE_TEXT_CHARS
+            ]
+            if not scanned:
+                logger.info("All PDF pages have a text layer, skip OCR routing")
+                return Document()
+
+            logger.info(
+                f"Detected {len(scanned)}/{len(pdf.pages)} PDF pages without text, "
+                f"routing them through OCR ({self.ocr_backend})"
+            )
+            pages = list(native_texts)
+            ocr_pages = []
+            # Pages with a text layer are done, the scanned ones count as they are recognized
+            text_pages = len(pdf.pages) - len(scanned)
+            for done, i in enumerate(scanned):
+                report_progress(STAGE_PARSING, text_pages + done, len(pdf.pages))
+                try:
+                    image = pdf.pages[i].to_image(resolution=OCR_PAGE_RESOLUTION)
+                    ocr_text = (self.perform_ocr(image.original) or "").strip()
+                except Exception as e:
+                    logger.error(f"Failed to OCR PDF page {i + 1}: {e}")
+                    continue
+                if not ocr_text:
+                    continue
+                # Keep the native text of the page, e.g. headers, before the OCR text
+                pages[i] = f"{pages[i]}\n{ocr_text}" if pages[i] else ocr_text
+                ocr_pages.append(i + 1)
+            report_progress(STAGE_PARSING, len(pdf.pages), len(pdf.pages))
+
+        if not ocr_pages:
+            logger.warning("OCR extracted no text from scanned PDF pages")
+            return Document()
+
+        logger.info(f"Merged OCR text of PDF pages {ocr_pages}")
+        # Pages are separated by form feeds, like text extracted from a text layer;
+        # the page markers anchor chunks to their pages even if those are lost
+        return Document(
+            content="\n\f".join(pages),
+            metadata={
+                "ocr_pages": ocr_pages,
+                "ocr_engine": self.ocr_backend,
+                "page_markers": page_markers(enumerate(pages, 1)),
+            },
+        )
@@ -0,0 +1,204 @@
+"""
+Spreadsheet schema detection shared by the CSV and Excel parsers.
+
+Rows are detected below the real header row (title and note rows above it are
+skipped), column types are inferred from the values, and rows are packed into
+chunks that keep the column names next to every value. Each chunk carries the
+rows as a structured table in metadata["table"] so the server can look up cell
+values without re-parsing the text.
+"""
+
+import logging
+import math
+import re
+from datetime import date, datetime
+from typing import Any, Dict, List, Optional, Tuple
+
+from docreader.models.document import Chunk
+
+logger = logging.getLogger(__name__)
+
+# Number of leading rows searched for the header row
+HEADER_SCAN_ROWS = 10
+
+_INTEGER = re.compile(r"^[+-]?\d{1,3}(,\d{3})*$|^[+-]?\d+$")
+_NUMBER = re.compile(r"^[+-]?(\d{1,3}(,\d{3})*|\d+)?(\.\d+)?([eE][+-]?\d+)?%?$")
+_DATE = re.compile(
+    r"^\d{4}[-/.]\d{1,2}[-/.]\d{1,2}([ T]\d{1,2}:\d{2}(:\d{2}(\.\d+)?)?)?$"
+    r"|^\d{4}年\d{1,2}月\d{1,2}日$"
+)
+_BOOLEANS = {"true", "false", "yes", "no", "y", "n", "是", "否", "√", "×"}
+
+
+def cell_text(value: Any) -> str:
+    """Render a cell value as text, empty for missing values"""
+    if value is None:
+        return ""
+    if isinstance(value, float):
+        if math.isnan(value):
+            return ""
+        if value.is_integer():
+            return str(int(value))
+    if isinstance(value, datetime):
+        if (value.hour, value.minute, value.second) == (0, 0, 0):
+            return value.date().isoformat()
+        return value.isoformat(sep=" ")
+    if isinstance(value, date):
+        return value.isoformat()
+    text = str(value).strip()
+    # pandas renders missing values of object columns as NaT / nan
+    return "" if text in ("nan", "NaT") else text
+
+
+def _is_number(value: str) -> bool:
+    return bool(value) and _NUMBER.match(value) is not None and any(
+        c.isdigit() for c in value
+    )
+
+
+def infer_column_type(values: List[str]) -> str:
+    """Infer the type of a column: integer, number, boolean, date or text"""
+    values = [v for v in values if v]
+    if not values:
+        return "text"
+    if all(_INTEGER.match(v) for v in values):
+        return "integer"
+    if all(_is_number(v) for v in values):
+        return "number"
+    if all(_DATE.match(v) for v in values):
+        return "date"
+    if all(v.lower() in _BOOLEANS for v in values):
+        return "boolean"
+    return "text"
+
+
+def detect_header_row(rows: List[List[str]]) -> Optional[int]:
+    """Return the index of the header row, None if the table has no header.
+
+    The header is the first row that fills most of the table width with
+    distinct, non-numeric labels and is followed by at least one data row.
+    Title rows above it usually fill a single cell and are skipped.
+    """
+    sample = rows[:HEADER_SCAN_ROWS]
+    width = max((sum(1 for c in row if c) for row in sample), default=0)
+    if width == 0:
+        return None
+    for i, row in enumerate(sample):
+        if i + 1 >= len(rows):
+            break
+        labels = [c for c in row if c]
+        if len(labels) * 2 < width or len(labels) < min(2, width):
+            continue
+        if any(_is_number(c) or _DATE.match(c) for c in labels):
+            continue
+        if len(set(labels)) != len(labels):
+            continue
+        return i
+    return None
+
+
+def _column_names(header: List[str]) -> List[str]:
+    names: List[str] = []
+    seen: Dict[str, int] = {}
+    for i, name in enumerate(header):
+        name = name.strip() or f"列{i + 1}"
+        if name in seen:
+            seen[name] += 1
+            name = f"{name}_{seen[name]}"
+        else:
+            seen[name] = 1
+        names.append(name)
+    return names
+
+
+def build_table_chunks(
+    rows: List[List[str]],
+    chunk_size: int,
+    seq_start: int = 0,
+    offset: int = 0,
+    sheet: str = "",
+) -> Tuple[List[Chunk], str]:
+    """Split a sheet into chunks of consecutive rows.
+
+    Args:
+        rows: Cell texts of every row in the sheet, in sheet order
+        chunk_size: Target chunk size in characters, a row is never split
+        seq_start: Sequence number of the first chunk
+        offset: Position of the first chunk in the document content
+        sheet: Sheet name of Excel workbooks, prepended to every chunk
+
+    Returns:
+        The chunks and the text they cover
+    """
+    # 1-based row numbers as shown by spreadsheet applications
+    numbered = [(n + 1, row) for n, row in enumerate(rows) if any(row)]
+    if not numbered:
+        return [], ""
+    width = max(len(row) for _, row in numbered)
+    numbered = [(n, row + [""] * (width - len(row))) for n, row in numbered]
+
+    header_index = detect_header_row([row for _, row in numbered])
+    header_row = 0
+    if header_index is not None:
+        header_row, header = numbered[header_index]
+        data = numbered[header_index + 1 :]
+    else:
+        header = [""] * width
+        data = numbered
+
+    # Drop columns that have neither a header nor any value
+    keep = [i for i in range(width) if header[i] or any(row[i] for _, row in data)]
+    header = _column_names([header[i] for i in keep])
+    data = [(n, [row[i] for i in keep]) for n, row in data]
+    column_types = [
+        infer_column_type([row[i] for _, row in data]) for i in range(len(header))
+    ]
+    logger.info(
+        f"Detected table {sheet or ''} header row {header_row}, "
+        f"{len(data)} rows, columns: {list(zip(header, column_types))}"
+    )
+
+    prefix = f"工作表: {sheet}\n" if sheet else ""
+    chunks: List[Chunk] = []
+    texts: List[str] = []
+    group: List[Tuple[int, List[str]]] = []
+    group_text = prefix
+
+    def flush():
+        nonlocal group, group_text, offset
+        if not group:
+            return
+        chunks.append(
+            Chunk(
+                content=group_text,
+                seq=seq_start + len(chunks),
+                start=offset,
+                end=offset + len(group_text),
+                metadata={
+                    "table": {
+                        "header": header,
+                        "rows": [row for _, row in group],
+                        "sheet": sheet,
+                        "header_row": header_row,
+                        "row_start": group[0][0],
+                        "row_end": group[-1][0],
+                        "column_types": column_types,
+                    }
+                },
+            )
+        )
+        texts.append(group_text)
+        offset += len(group_text)
+        group, group_text = [], prefix
+
+    for number, row in data:
+        line = (
+            ",".join(f"{name}: {value}" for name, value in zip(header, row) if value)
+            + "\n"
+        )
+        if group and len(group_text) + len(line) > chunk_size:
+            flush()
+        group.append((number, row))
+        group_text += line
+    flush()
+    return chunks, "".join(texts)
@@ -1,6 +1,11 @@
 import asyncio
 import logging
 
+from typing import Dict, Optional
+from urllib.parse import urlsplit
+
+from bs4 import BeautifulSoup
+from markdownify import markdownify
 from playwright.async_api import async_playwright
 from trafilatura import extract
 
@@ -8,11 +13,39 @@ from docreader.config import CONFIG
 from docreader.models.document import Document
 from docreader.parser.base_parser import BaseParser
 from docreader.parser.chain_parser import PipelineParser
+from docreader.parser.llm_cleaner import LLMCleaner
 from docreader.parser.markdown_parser import MarkdownParser
+from docreader.splitter.anchors import heading_key
 from docreader.utils import endecode
 
 logger = logging.getLogger(__name__)
 
+# Extraction modes of web pages
+# full: the whole page converted to markdown, navigation and footers included
+EXTRACTION_MODE_FULL = "full"
+# readability: the main article only, the default
+EXTRACTION_MODE_READABILITY = "readability"
+# llm: the main article, cleaned of boilerplate with normalized headings by a model
+EXTRACTION_MODE_LLM = "llm"
+EXTRACTION_MODES = (
+    EXTRACTION_MODE_FULL,
+    EXTRACTION_MODE_READABILITY,
+    EXTRACTION_MODE_LLM,
+)
+
+# Elements of a page that never hold readable content
+_NON_CONTENT_TAGS = ("script", "style", "noscript", "template", "svg", "iframe")
+
+_HEADING_TAGS = ["h1", "h2", "h3", "h4", "h5", "h6"]
+# Elements whose id identifies the section opened by their first heading
+_SECTION_TAGS = ("section", "div", "article")
+
+
+def _origin(url: str) -> str:
+    """Scheme, host and port of a URL"""
+    parts = urlsplit(url)
+    return f"{parts.scheme}://{parts.netloc}".lower()
+
 
 class StdWebParser(BaseParser):
     """Standard web page parser using Playwright and Trafilatura.
@@ -22,18 +55,39 @@ class StdWebParser(BaseParser):
     converts HTML content to markdown format.
     """
 
-    def __init__(self, title: str, **kwargs):
+    def __init__(
+        self,
+        title: str,
+        extraction_mode: str = EXTRACTION_MODE_READABILITY,
+        llm_config: Optional[Dict] = None,
+        fetch_config: Optional[Dict] = None,
+        **kwargs,
+    ):
         """Initialize the web parser.
 
         Args:
             title: Title of the web page to be used as file name
+            extraction_mode: full, readability or llm, see EXTRACTION_MODES
+            llm_config: Model cleaning the extraction in llm mode
+            fetch_config: user_agent, headers and cookies the site needs
             **kwargs: Additional arguments passed to BaseParser
         """
         self.title = title
+        if extraction_mode not in EXTRACTION_MODES:
+            logger.warning(
+                f"Unknown extraction mode {extraction_mode!r}, using readability"
+            )
+            extraction_mode = EXTRACTION_MODE_READABILITY
+        self.extraction_mode = extraction_mode
+        self.llm_config = llm_config or {}
+        self.fetch_config = fetch_config or {}
         # Get proxy configuration from config if available
         self.proxy = CONFIG.external_https_proxy
         super().__init__(file_name=title, **kwargs)
-        logger.info(f"Initialized WebParser with title: {title}")
+        logger.info(
+            f"Initialized WebParser with title: {title}, "
+            f"extraction mode: {self.extraction_mode}"
+        )
 
     async def scrape(self, url: str) -> str:
         """Scrape web page content using Playwright.
@@ -53,7 +107,34 @@ class StdWebParser(BaseParser):
                     kwargs["proxy"] = {"server": self.proxy}
                 logger.info("Launching WebKit browser")
                 browser = await p.webkit.launch(**kwargs)
-                page = await browser.new_page()
+                context_kwargs = {}
+                if self.fetch_config.get("user_agent"):
+                    context_kwargs["user_agent"] = self.fetch_config["user_agent"]
+                browser_context = await browser.new_context(**context_kwargs)
+                # Headers and cookies often carry credentials, they are only sent to
+                # the site of the URL, not to the other sites the page loads from
+                headers = self.fetch_config.get("headers") or {}
+                if headers:
+                    origin = _origin(url)
+
+                    async def add_headers(route):
+                        if _origin(route.request.url) != origin:
+                            await route.continue_()
+                            return
+                        await route.continue_(
+                            headers={**route.request.headers, **headers}
+                        )
+
+                    await browser_context.route("**/*", add_headers)
+                cookies = self.fetch_config.get("cookies") or {}
+                if cookies:
+                    await browser_context.add_cookies(
+                        [
+                            {"name": name, "value": value, "url": url}
+                            for name, value in cookies.items()
+                        ]
+                    )
+                page = await browser_context.new_page()
 
                 logger.info(f"Navigating to URL: {url}")
                 try:
@@ -97,20 +178,91 @@ class StdWebParser(BaseParser):
         logger.info(f"Scraping web page: {url}")
         # Run async scraping in sync context
         chtml = asyncio.run(self.scrape(url))
-        # Extract clean content from HTML using Trafilatura
-        # Convert to markdown format with metadata, images, tables, and links
-        md_text = extract(
-            chtml,
+        if self.extraction_mode == EXTRACTION_MODE_FULL:
+            md_text = self.convert_full_page(chtml)
+        else:
+            md_text = self.extract_article(chtml)
+            if md_text and self.extraction_mode == EXTRACTION_MODE_LLM:
+                md_text = LLMCleaner(self.llm_config).clean(md_text)
+        if not md_text:
+            logger.error("Failed to parse web page")
+            return Document(content=f"Error parsing web page: {url}")
+        # Chunks are anchored to the ids of their headings, so citations can
+        # link to the section of the page
+        return Document(
+            content=md_text, metadata={"heading_ids": self.heading_ids(chtml)}
+        )
+
+    @staticmethod
+    def heading_ids(html: str) -> Dict[str, str]:
+        """Fragment identifiers of the headings of a page by their heading_key.
+
+        The id of a heading is its own, the one of an anchor inside it (an id,
+        a name or a permalink to a fragment) or the one of the section it opens.
+        Headings with the same text keep the first id.
+        """
+        ids: Dict[str, str] = {}
+        if not html:
+            return ids
+        soup = BeautifulSoup(html, "lxml")
+        for heading in soup.find_all(_HEADING_TAGS):
+            key = heading_key(heading.get_text(" ", strip=True))
+            if not key or key in ids:
+                continue
+            fragment = heading.get("id")
+            if not fragment:
+                for anchor in heading.find_all(["a", "span"]):
+                    href = anchor.get("href") or ""
+                    fragment = (
+                        anchor.get("id")
+                        or anchor.get("name")
+                        or (href[1:] if href.startswith("#") else "")
+                    )
+                    if fragment:
+                        break
+            parent = heading.parent
+            if not fragment and parent is not None and parent.name in _SECTION_TAGS:
+                if parent.find(_HEADING_TAGS) is heading:
+                    fragment = parent.get("id")
+            if fragment:
+                ids[key] = fragment
+        return ids
+
+    @staticmethod
+    def extract_article(html: str) -> Optional[str]:
+        """Extract the main article of a page as markdown using Trafilatura.
+
+        Metadata, images, tables and links of the article are kept.
+        """
+        return extract(
+            html,
             output_format="markdown",
             with_metadata=True,
             include_images=True,
             include_tables=True,
             include_links=True,
         )
-        if not md_text:
-            logger.error("Failed to parse web page")
-            return Document(content=f"Error parsing web page: {url}")
-        return Document(content=md_text)
+
+    @staticmethod
+    def convert_full_page(html: str) -> str:
+        """Convert the whole body of a page to markdown, boilerplate included.
+
+        Scripts, styles and other elements without readable content are dropped.
+        """
+        if not html:
+            return ""
+        soup = BeautifulSoup(html, "lxml")
+        for tag in soup(_NON_CONTENT_TAGS):
+            tag.decompose()
+        body = soup.body or soup
+        md_text = markdownify(str(body), heading_style="ATX")
+        # Collapse the runs of blank lines left by layout elements
+        lines = [line.rstrip() for line in md_text.splitlines()]
+        collapsed = []
+        for line in lines:
+            if line or (collapsed and collapsed[-1]):
+                collapsed.append(line)
+        return "\n".join(collapsed).strip()
 
 
 class WebParser(PipelineParser):
@@ -124,6 +276,9 @@ class WebParser(PipelineParser):
     # Parser classes to be executed in sequence
     _parser_cls = (StdWebParser, MarkdownParser)
 
+    # Web pages have no file extension, caption and OCR their images too
+    always_process_images = True
+
 
 if __name__ == "__main__":
     # Configure logging for debugging
@@ -171,6 +171,8 @@ type VLMConfig struct {
 	BaseUrl       string                 `protobuf:"bytes,2,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`                   // VLM Base URL
 	ApiKey        string                 `protobuf:"bytes,3,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`                      // VLM API Key
 	InterfaceType string                 `protobuf:"bytes,4,opt,name=interface_type,json=interfaceType,proto3" json:"interface_type,omitempty"` // VLM Interface Type: "ollama" or "openai"
+	Prompt        string                 `protobuf:"bytes,5,opt,name=prompt,proto3" json:"prompt,omitempty"`                                    // 图片描述提示词，为空时使用默认提示词
+	MaxImages     int32                  `protobuf:"varint,6,opt,name=max_images,json=maxImages,proto3" json:"max_images,omitempty"`            // 每个文档最多交给 VLM 处理的图片数，0 表示不限制
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -233,6 +235,256 @@ func (x *VLMConfig) GetInterfaceType() string {
 	return ""
 }
 
+func (x *VLMConfig) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *VLMConfig) GetMaxImages() int32 {
+	if x != nil {
+		return x.MaxImages
+	}
+	return 0
+}
+
+// OCR 配置
+type OCRConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Engine        string                 `protobuf:"bytes,1,opt,name=engine,proto3" json:"engine,omitempty"`       // OCR 引擎: "paddle", "tesseract" 或 "vlm"，为空时使用服务默认引擎
+	Languages     []string               `protobuf:"bytes,2,rep,name=languages,proto3" json:"languages,omitempty"` // 识别语言（ISO 639-1，如 zh、en、ja），为空时使用引擎默认语言
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OCRConfig) Reset() {
+	*x = OCRConfig{}
+	mi := &file_docreader_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OCRConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OCRConfig) ProtoMessage() {}
+
+func (x *OCRConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OCRConfig.ProtoReflect.Descriptor instead.
+func (*OCRConfig) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OCRConfig) GetEngine() string {
+	if x != nil {
+		return x.Engine
+	}
+	return ""
+}
+
+func (x *OCRConfig) GetLanguages() []string {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+// 大模型配置（网页正文清理使用）
+type LLMConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelName     string                 `protobuf:"bytes,1,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`             // 模型名称
+	BaseUrl       string                 `protobuf:"bytes,2,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`                   // 模型 Base URL
+	ApiKey        string                 `protobuf:"bytes,3,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`                      // 模型 API Key
+	InterfaceType string                 `protobuf:"bytes,4,opt,name=interface_type,json=interfaceType,proto3" json:"interface_type,omitempty"` // 接口类型: "ollama" 或 "openai"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LLMConfig) Reset() {
+	*x = LLMConfig{}
+	mi := &file_docreader_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LLMConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LLMConfig) ProtoMessage() {}
+
+func (x *LLMConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LLMConfig.ProtoReflect.Descriptor instead.
+func (*LLMConfig) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LLMConfig) GetModelName() string {
+	if x != nil {
+		return x.ModelName
+	}
+	return ""
+}
+
+func (x *LLMConfig) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *LLMConfig) GetApiKey() string {
+	if x != nil {
+		return x.ApiKey
+	}
+	return ""
+}
+
+func (x *LLMConfig) GetInterfaceType() string {
+	if x != nil {
+		return x.InterfaceType
+	}
+	return ""
+}
+
+// 名称和值，用于请求头和 Cookie
+type NameValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`   // 名称
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"` // 值
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameValue) Reset() {
+	*x = NameValue{}
+	mi := &file_docreader_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameValue) ProtoMessage() {}
+
+func (x *NameValue) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameValue.ProtoReflect.Descriptor instead.
+func (*NameValue) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *NameValue) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *NameValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// 网页请求配置（抓取需要登录或拦截爬虫的网站时使用）
+type FetchConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserAgent     string                 `protobuf:"bytes,1,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"` // User-Agent，为空时使用浏览器默认值
+	Headers       []*NameValue           `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty"`                      // 附加请求头
+	Cookies       []*NameValue           `protobuf:"bytes,3,rep,name=cookies,proto3" json:"cookies,omitempty"`                      // 附加 Cookie，仅发送到抓取的网址
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchConfig) Reset() {
+	*x = FetchConfig{}
+	mi := &file_docreader_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchConfig) ProtoMessage() {}
+
+func (x *FetchConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchConfig.ProtoReflect.Descriptor instead.
+func (*FetchConfig) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FetchConfig) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *FetchConfig) GetHeaders() []*NameValue {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *FetchConfig) GetCookies() []*NameValue {
+	if x != nil {
+		return x.Cookies
+	}
+	return nil
+}
+
 type ReadConfig struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	ChunkSize        int32                  `protobuf:"varint,1,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`                      // 分块大小
@@ -241,13 +493,14 @@ type ReadConfig struct {
 	EnableMultimodal bool                   `protobuf:"varint,4,opt,name=enable_multimodal,json=enableMultimodal,proto3" json:"enable_multimodal,omitempty"` // 多模态处理
 	StorageConfig    *StorageConfig         `protobuf:"bytes,5,opt,name=storage_config,json=storageConfig,proto3" json:"storage_config,omitempty"`           // 对象存储配置（通用）
 	VlmConfig        *VLMConfig             `protobuf:"bytes,6,opt,name=vlm_config,json=vlmConfig,proto3" json:"vlm_config,omitempty"`                       // VLM 配置
+	OcrConfig        *OCRConfig             `protobuf:"bytes,7,opt,name=ocr_config,json=ocrConfig,proto3" json:"ocr_config,omitempty"`                       // OCR 配置
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
 
 func (x *ReadConfig) Reset() {
 	*x = ReadConfig{}
-	mi := &file_docreader_proto_msgTypes[2]
+	mi := &file_docreader_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -259,7 +512,7 @@ func (x *ReadConfig) String() string {
 func (*ReadConfig) ProtoMessage() {}
 
 func (x *ReadConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_docreader_proto_msgTypes[2]
+	mi := &file_docreader_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -272,7 +525,7 @@ func (x *ReadConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadConfig.ProtoReflect.Descriptor instead.
 func (*ReadConfig) Descriptor() ([]byte, []int) {
-	return file_docreader_proto_rawDescGZIP(), []int{2}
+	return file_docreader_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ReadConfig) GetChunkSize() int32 {
@@ -317,6 +570,13 @@ func (x *ReadConfig) GetVlmConfig() *VLMConfig {
 	return nil
 }
 
+func (x *ReadConfig) GetOcrConfig() *OCRConfig {
+	if x != nil {
+		return x.OcrConfig
+	}
+	return nil
+}
+
 // 从文件读取文档请求
 type ReadFromFileRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -325,13 +585,14 @@ type ReadFromFileRequest struct {
 	FileType      string                 `protobuf:"bytes,3,opt,name=file_type,json=fileType,proto3" json:"file_type,omitempty"`          // 文件类型
 	ReadConfig    *ReadConfig            `protobuf:"bytes,4,opt,name=read_config,json=readConfig,proto3" json:"read_config,omitempty"`
 	RequestId     string                 `protobuf:"bytes,5,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ProgressId    string                 `protobuf:"bytes,6,opt,name=progress_id,json=progressId,proto3" json:"progress_id,omitempty"` // 进度标识，非空时可在解析期间通过 GetReadProgress 查询进度
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReadFromFileRequest) Reset() {
 	*x = ReadFromFileRequest{}
-	mi := &file_docreader_proto_msgTypes[3]
+	mi := &file_docreader_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -343,7 +604,7 @@ func (x *ReadFromFileRequest) String() string {
 func (*ReadFromFileRequest) ProtoMessage() {}
 
 func (x *ReadFromFileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_docreader_proto_msgTypes[3]
+	mi := &file_docreader_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -356,7 +617,7 @@ func (x *ReadFromFileRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadFromFileRequest.ProtoReflect.Descriptor instead.
 func (*ReadFromFileRequest) Descriptor() ([]byte, []int) {
-	return file_docreader_proto_rawDescGZIP(), []int{3}
+	return file_docreader_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ReadFromFileRequest) GetFileContent() []byte {
@@ -394,20 +655,30 @@ func (x *ReadFromFileRequest) GetRequestId() string {
 	return ""
 }
 
+func (x *ReadFromFileRequest) GetProgressId() string {
+	if x != nil {
+		return x.ProgressId
+	}
+	return ""
+}
+
 // 从URL读取文档请求
 type ReadFromURLRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`     // 文档URL
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"` // 标题
-	ReadConfig    *ReadConfig            `protobuf:"bytes,3,opt,name=read_config,json=readConfig,proto3" json:"read_config,omitempty"`
-	RequestId     string                 `protobuf:"bytes,4,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Url            string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`     // 文档URL
+	Title          string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"` // 标题
+	ReadConfig     *ReadConfig            `protobuf:"bytes,3,opt,name=read_config,json=readConfig,proto3" json:"read_config,omitempty"`
+	RequestId      string                 `protobuf:"bytes,4,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ExtractionMode string                 `protobuf:"bytes,5,opt,name=extraction_mode,json=extractionMode,proto3" json:"extraction_mode,omitempty"` // 网页抽取模式: "full"（整页转换）、"readability"（正文模式）或 "llm"（正文模式后由大模型清理），为空时使用正文模式
+	LlmConfig      *LLMConfig             `protobuf:"bytes,6,opt,name=llm_config,json=llmConfig,proto3" json:"llm_config,omitempty"`                // 大模型配置，llm 模式使用
+	FetchConfig    *FetchConfig           `protobuf:"bytes,7,opt,name=fetch_config,json=fetchConfig,proto3" json:"fetch_config,omitempty"`          // 网页请求配置
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ReadFromURLRequest) Reset() {
 	*x = ReadFromURLRequest{}
-	mi := &file_docreader_proto_msgTypes[4]
+	mi := &file_docreader_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -419,7 +690,7 @@ func (x *ReadFromURLRequest) String() string {
 func (*ReadFromURLRequest) ProtoMessage() {}
 
 func (x *ReadFromURLRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_docreader_proto_msgTypes[4]
+	mi := &file_docreader_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -432,7 +703,7 @@ func (x *ReadFromURLRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadFromURLRequest.ProtoReflect.Descriptor instead.
 func (*ReadFromURLRequest) Descriptor() ([]byte, []int) {
-	return file_docreader_proto_rawDescGZIP(), []int{4}
+	return file_docreader_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ReadFromURLRequest) GetUrl() string {
@@ -463,6 +734,27 @@ func (x *ReadFromURLRequest) GetRequestId() string {
 	return ""
 }
 
+func (x *ReadFromURLRequest) GetExtractionMode() string {
+	if x != nil {
+		return x.ExtractionMode
+	}
+	return ""
+}
+
+func (x *ReadFromURLRequest) GetLlmConfig() *LLMConfig {
+	if x != nil {
+		return x.LlmConfig
+	}
+	return nil
+}
+
+func (x *ReadFromURLRequest) GetFetchConfig() *FetchConfig {
+	if x != nil {
+		return x.FetchConfig
+	}
+	return nil
+}
+
 // 图片信息
 type Image struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -472,13 +764,14 @@ type Image struct {
 	OriginalUrl   string                 `protobuf:"bytes,4,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"` // 原始图片URL
 	Start         int32                  `protobuf:"varint,5,opt,name=start,proto3" json:"start,omitempty"`                               // 图片在文本中的开始位置
 	End           int32                  `protobuf:"varint,6,opt,name=end,proto3" json:"end,omitempty"`                                   // 图片在文本中的结束位置
+	OcrEngine     string                 `protobuf:"bytes,7,opt,name=ocr_engine,json=ocrEngine,proto3" json:"ocr_engine,omitempty"`       // 生成OCR文本的引擎
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Image) Reset() {
 	*x = Image{}
-	mi := &file_docreader_proto_msgTypes[5]
+	mi := &file_docreader_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -490,7 +783,7 @@ func (x *Image) String() string {
 func (*Image) ProtoMessage() {}
 
 func (x *Image) ProtoReflect() protoreflect.Message {
-	mi := &file_docreader_proto_msgTypes[5]
+	mi := &file_docreader_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -503,7 +796,7 @@ func (x *Image) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Image.ProtoReflect.Descriptor instead.
 func (*Image) Descriptor() ([]byte, []int) {
-	return file_docreader_proto_rawDescGZIP(), []int{5}
+	return file_docreader_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *Image) GetUrl() string {
@@ -548,20 +841,28 @@ func (x *Image) GetEnd() int32 {
 	return 0
 }
 
+func (x *Image) GetOcrEngine() string {
+	if x != nil {
+		return x.OcrEngine
+	}
+	return ""
+}
+
 type Chunk struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"` // 块内容
-	Seq           int32                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`        // 块在文档中的次序
-	Start         int32                  `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`    // 块在文档中的起始位置
-	End           int32                  `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"`        // 块在文档中的结束位置
-	Images        []*Image               `protobuf:"bytes,5,rep,name=images,proto3" json:"images,omitempty"`   // 块中包含的图片信息
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`   // 块内容
+	Seq           int32                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`          // 块在文档中的次序
+	Start         int32                  `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`      // 块在文档中的起始位置
+	End           int32                  `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"`          // 块在文档中的结束位置
+	Images        []*Image               `protobuf:"bytes,5,rep,name=images,proto3" json:"images,omitempty"`     // 块中包含的图片信息
+	Metadata      string                 `protobuf:"bytes,6,opt,name=metadata,proto3" json:"metadata,omitempty"` // 块的扩展元数据（JSON 格式），如笔记本单元格信息
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Chunk) Reset() {
 	*x = Chunk{}
-	mi := &file_docreader_proto_msgTypes[6]
+	mi := &file_docreader_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -573,7 +874,7 @@ func (x *Chunk) String() string {
 func (*Chunk) ProtoMessage() {}
 
 func (x *Chunk) ProtoReflect() protoreflect.Message {
-	mi := &file_docreader_proto_msgTypes[6]
+	mi := &file_docreader_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -586,7 +887,7 @@ func (x *Chunk) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return file_docreader_proto_rawDescGZIP(), []int{6}
+	return file_docreader_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *Chunk) GetContent() string {
@@ -624,6 +925,13 @@ func (x *Chunk) GetImages() []*Image {
 	return nil
 }
 
+func (x *Chunk) GetMetadata() string {
+	if x != nil {
+		return x.Metadata
+	}
+	return ""
+}
+
 // 从URL读取文档响应
 type ReadResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -635,7 +943,7 @@ type ReadResponse struct {
 
 func (x *ReadResponse) Reset() {
 	*x = ReadResponse{}
-	mi := &file_docreader_proto_msgTypes[7]
+	mi := &file_docreader_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -647,7 +955,7 @@ func (x *ReadResponse) String() string {
 func (*ReadResponse) ProtoMessage() {}
 
 func (x *ReadResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_docreader_proto_msgTypes[7]
+	mi := &file_docreader_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -660,7 +968,7 @@ func (x *ReadResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadResponse.ProtoReflect.Descriptor instead.
 func (*ReadResponse) Descriptor() ([]byte, []int) {
-	return file_docreader_proto_rawDescGZIP(), []int{7}
+	return file_docreader_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ReadResponse) GetChunks() []*Chunk {
@@ -677,6 +985,247 @@ func (x *ReadResponse) GetError() string {
 	return ""
 }
 
+// 列出解析插件请求
+type ListParserPluginsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListParserPluginsRequest) Reset() {
+	*x = ListParserPluginsRequest{}
+	mi := &file_docreader_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListParserPluginsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListParserPluginsRequest) ProtoMessage() {}
+
+func (x *ListParserPluginsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListParserPluginsRequest.ProtoReflect.Descriptor instead.
+func (*ListParserPluginsRequest) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{12}
+}
+
+// 解析插件
+type ParserPlugin struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`             // 插件名称
+	Extensions    []string               `protobuf:"bytes,2,rep,name=extensions,proto3" json:"extensions,omitempty"` // 处理的文件扩展名（小写，不含点）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParserPlugin) Reset() {
+	*x = ParserPlugin{}
+	mi := &file_docreader_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParserPlugin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParserPlugin) ProtoMessage() {}
+
+func (x *ParserPlugin) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParserPlugin.ProtoReflect.Descriptor instead.
+func (*ParserPlugin) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ParserPlugin) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ParserPlugin) GetExtensions() []string {
+	if x != nil {
+		return x.Extensions
+	}
+	return nil
+}
+
+// 列出解析插件响应
+type ListParserPluginsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plugins       []*ParserPlugin        `protobuf:"bytes,1,rep,name=plugins,proto3" json:"plugins,omitempty"` // 已加载的解析插件
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListParserPluginsResponse) Reset() {
+	*x = ListParserPluginsResponse{}
+	mi := &file_docreader_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListParserPluginsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListParserPluginsResponse) ProtoMessage() {}
+
+func (x *ListParserPluginsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListParserPluginsResponse.ProtoReflect.Descriptor instead.
+func (*ListParserPluginsResponse) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListParserPluginsResponse) GetPlugins() []*ParserPlugin {
+	if x != nil {
+		return x.Plugins
+	}
+	return nil
+}
+
+// 查询解析进度请求
+type GetReadProgressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProgressId    string                 `protobuf:"bytes,1,opt,name=progress_id,json=progressId,proto3" json:"progress_id,omitempty"` // ReadFromFileRequest 中的进度标识
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReadProgressRequest) Reset() {
+	*x = GetReadProgressRequest{}
+	mi := &file_docreader_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReadProgressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReadProgressRequest) ProtoMessage() {}
+
+func (x *GetReadProgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReadProgressRequest.ProtoReflect.Descriptor instead.
+func (*GetReadProgressRequest) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetReadProgressRequest) GetProgressId() string {
+	if x != nil {
+		return x.ProgressId
+	}
+	return ""
+}
+
+// 解析进度
+type ReadProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stage         string                 `protobuf:"bytes,1,opt,name=stage,proto3" json:"stage,omitempty"`      // 阶段: "converted"（已转换格式）或 "parsing"（逐页解析中），为空表示暂无进度
+	Current       int32                  `protobuf:"varint,2,opt,name=current,proto3" json:"current,omitempty"` // 已解析页数，不适用时为 0
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`     // 总页数，不适用时为 0
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadProgress) Reset() {
+	*x = ReadProgress{}
+	mi := &file_docreader_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadProgress) ProtoMessage() {}
+
+func (x *ReadProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_docreader_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadProgress.ProtoReflect.Descriptor instead.
+func (*ReadProgress) Descriptor() ([]byte, []int) {
+	return file_docreader_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReadProgress) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *ReadProgress) GetCurrent() int32 {
+	if x != nil {
+		return x.Current
+	}
+	return 0
+}
+
+func (x *ReadProgress) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
 var File_docreader_proto protoreflect.FileDescriptor
 
 const file_docreader_proto_rawDesc = "" +
@@ -691,13 +1240,33 @@ const file_docreader_proto_rawDesc = "" +
 	"\x11secret_access_key\x18\x05 \x01(\tR\x0fsecretAccessKey\x12\x15\n" +
 	"\x06app_id\x18\x06 \x01(\tR\x05appId\x12\x1f\n" +
 	"\vpath_prefix\x18\a \x01(\tR\n" +
-	"pathPrefix\"\x85\x01\n" +
+	"pathPrefix\"\xbc\x01\n" +
 	"\tVLMConfig\x12\x1d\n" +
 	"\n" +
 	"model_name\x18\x01 \x01(\tR\tmodelName\x12\x19\n" +
 	"\bbase_url\x18\x02 \x01(\tR\abaseUrl\x12\x17\n" +
 	"\aapi_key\x18\x03 \x01(\tR\x06apiKey\x12%\n" +
-	"\x0einterface_type\x18\x04 \x01(\tR\rinterfaceType\"\x93\x02\n" +
+	"\x0einterface_type\x18\x04 \x01(\tR\rinterfaceType\x12\x16\n" +
+	"\x06prompt\x18\x05 \x01(\tR\x06prompt\x12\x1d\n" +
+	"\n" +
+	"max_images\x18\x06 \x01(\x05R\tmaxImages\"A\n" +
+	"\tOCRConfig\x12\x16\n" +
+	"\x06engine\x18\x01 \x01(\tR\x06engine\x12\x1c\n" +
+	"\tlanguages\x18\x02 \x03(\tR\tlanguages\"\x85\x01\n" +
+	"\tLLMConfig\x12\x1d\n" +
+	"\n" +
+	"model_name\x18\x01 \x01(\tR\tmodelName\x12\x19\n" +
+	"\bbase_url\x18\x02 \x01(\tR\abaseUrl\x12\x17\n" +
+	"\aapi_key\x18\x03 \x01(\tR\x06apiKey\x12%\n" +
+	"\x0einterface_type\x18\x04 \x01(\tR\rinterfaceType\"5\n" +
+	"\tNameValue\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"\x8c\x01\n" +
+	"\vFetchConfig\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x01 \x01(\tR\tuserAgent\x12.\n" +
+	"\aheaders\x18\x02 \x03(\v2\x14.docreader.NameValueR\aheaders\x12.\n" +
+	"\acookies\x18\x03 \x03(\v2\x14.docreader.NameValueR\acookies\"\xc8\x02\n" +
 	"\n" +
 	"ReadConfig\x12\x1d\n" +
 	"\n" +
@@ -709,7 +1278,9 @@ const file_docreader_proto_rawDesc = "" +
 	"\x11enable_multimodal\x18\x04 \x01(\bR\x10enableMultimodal\x12?\n" +
 	"\x0estorage_config\x18\x05 \x01(\v2\x18.docreader.StorageConfigR\rstorageConfig\x123\n" +
 	"\n" +
-	"vlm_config\x18\x06 \x01(\v2\x14.docreader.VLMConfigR\tvlmConfig\"\xc9\x01\n" +
+	"vlm_config\x18\x06 \x01(\v2\x14.docreader.VLMConfigR\tvlmConfig\x123\n" +
+	"\n" +
+	"ocr_config\x18\a \x01(\v2\x14.docreader.OCRConfigR\tocrConfig\"\xea\x01\n" +
 	"\x13ReadFromFileRequest\x12!\n" +
 	"\ffile_content\x18\x01 \x01(\fR\vfileContent\x12\x1b\n" +
 	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x1b\n" +
@@ -717,37 +1288,63 @@ const file_docreader_proto_rawDesc = "" +
 	"\vread_config\x18\x04 \x01(\v2\x15.docreader.ReadConfigR\n" +
 	"readConfig\x12\x1d\n" +
 	"\n" +
-	"request_id\x18\x05 \x01(\tR\trequestId\"\x93\x01\n" +
+	"request_id\x18\x05 \x01(\tR\trequestId\x12\x1f\n" +
+	"\vprogress_id\x18\x06 \x01(\tR\n" +
+	"progressId\"\xac\x02\n" +
 	"\x12ReadFromURLRequest\x12\x10\n" +
 	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x126\n" +
 	"\vread_config\x18\x03 \x01(\v2\x15.docreader.ReadConfigR\n" +
 	"readConfig\x12\x1d\n" +
 	"\n" +
-	"request_id\x18\x04 \x01(\tR\trequestId\"\x99\x01\n" +
+	"request_id\x18\x04 \x01(\tR\trequestId\x12'\n" +
+	"\x0fextraction_mode\x18\x05 \x01(\tR\x0eextractionMode\x123\n" +
+	"\n" +
+	"llm_config\x18\x06 \x01(\v2\x14.docreader.LLMConfigR\tllmConfig\x129\n" +
+	"\ffetch_config\x18\a \x01(\v2\x16.docreader.FetchConfigR\vfetchConfig\"\xb8\x01\n" +
 	"\x05Image\x12\x10\n" +
 	"\x03url\x18\x01 \x01(\tR\x03url\x12\x18\n" +
 	"\acaption\x18\x02 \x01(\tR\acaption\x12\x19\n" +
 	"\bocr_text\x18\x03 \x01(\tR\aocrText\x12!\n" +
 	"\foriginal_url\x18\x04 \x01(\tR\voriginalUrl\x12\x14\n" +
 	"\x05start\x18\x05 \x01(\x05R\x05start\x12\x10\n" +
-	"\x03end\x18\x06 \x01(\x05R\x03end\"\x85\x01\n" +
+	"\x03end\x18\x06 \x01(\x05R\x03end\x12\x1d\n" +
+	"\n" +
+	"ocr_engine\x18\a \x01(\tR\tocrEngine\"\xa1\x01\n" +
 	"\x05Chunk\x12\x18\n" +
 	"\acontent\x18\x01 \x01(\tR\acontent\x12\x10\n" +
 	"\x03seq\x18\x02 \x01(\x05R\x03seq\x12\x14\n" +
 	"\x05start\x18\x03 \x01(\x05R\x05start\x12\x10\n" +
 	"\x03end\x18\x04 \x01(\x05R\x03end\x12(\n" +
-	"\x06images\x18\x05 \x03(\v2\x10.docreader.ImageR\x06images\"N\n" +
+	"\x06images\x18\x05 \x03(\v2\x10.docreader.ImageR\x06images\x12\x1a\n" +
+	"\bmetadata\x18\x06 \x01(\tR\bmetadata\"N\n" +
 	"\fReadResponse\x12(\n" +
 	"\x06chunks\x18\x01 \x03(\v2\x10.docreader.ChunkR\x06chunks\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error*G\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\x1a\n" +
+	"\x18ListParserPluginsRequest\"B\n" +
+	"\fParserPlugin\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1e\n" +
+	"\n" +
+	"extensions\x18\x02 \x03(\tR\n" +
+	"extensions\"N\n" +
+	"\x19ListParserPluginsResponse\x121\n" +
+	"\aplugins\x18\x01 \x03(\v2\x17.docreader.ParserPluginR\aplugins\"9\n" +
+	"\x16GetReadProgressRequest\x12\x1f\n" +
+	"\vprogress_id\x18\x01 \x01(\tR\n" +
+	"progressId\"T\n" +
+	"\fReadProgress\x12\x14\n" +
+	"\x05stage\x18\x01 \x01(\tR\x05stage\x12\x18\n" +
+	"\acurrent\x18\x02 \x01(\x05R\acurrent\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total*G\n" +
 	"\x0fStorageProvider\x12 \n" +
 	"\x1cSTORAGE_PROVIDER_UNSPECIFIED\x10\x00\x12\a\n" +
 	"\x03COS\x10\x01\x12\t\n" +
-	"\x05MINIO\x10\x022\x9f\x01\n" +
+	"\x05MINIO\x10\x022\xd2\x02\n" +
 	"\tDocReader\x12I\n" +
 	"\fReadFromFile\x12\x1e.docreader.ReadFromFileRequest\x1a\x17.docreader.ReadResponse\"\x00\x12G\n" +
-	"\vReadFromURL\x12\x1d.docreader.ReadFromURLRequest\x1a\x17.docreader.ReadResponse\"\x00B5Z3github.com/Tencent/WeKnora/internal/docreader/protob\x06proto3"
+	"\vReadFromURL\x12\x1d.docreader.ReadFromURLRequest\x1a\x17.docreader.ReadResponse\"\x00\x12`\n" +
+	"\x11ListParserPlugins\x12#.docreader.ListParserPluginsRequest\x1a$.docreader.ListParserPluginsResponse\"\x00\x12O\n" +
+	"\x0fGetReadProgress\x12!.docreader.GetReadProgressRequest\x1a\x17.docreader.ReadProgress\"\x00B5Z3github.com/Tencent/WeKnora/internal/docreader/protob\x06proto3"
 
 var (
 	file_docreader_proto_rawDescOnce sync.Once
@@ -762,35 +1359,54 @@ func file_docreader_proto_rawDescGZIP() []byte {
 }
 
 var file_docreader_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_docreader_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_docreader_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_docreader_proto_goTypes = []any{
-	(StorageProvider)(0),        // 0: docreader.StorageProvider
-	(*StorageConfig)(nil),       // 1: docreader.StorageConfig
-	(*VLMConfig)(nil),           // 2: docreader.VLMConfig
-	(*ReadConfig)(nil),          // 3: docreader.ReadConfig
-	(*ReadFromFileRequest)(nil), // 4: docreader.ReadFromFileRequest
-	(*ReadFromURLRequest)(nil),  // 5: docreader.ReadFromURLRequest
-	(*Image)(nil),               // 6: docreader.Image
-	(*Chunk)(nil),               // 7: docreader.Chunk
-	(*ReadResponse)(nil),        // 8: docreader.ReadResponse
+	(StorageProvider)(0),              // 0: docreader.StorageProvider
+	(*StorageConfig)(nil),             // 1: docreader.StorageConfig
+	(*VLMConfig)(nil),                 // 2: docreader.VLMConfig
+	(*OCRConfig)(nil),                 // 3: docreader.OCRConfig
+	(*LLMConfig)(nil),                 // 4: docreader.LLMConfig
+	(*NameValue)(nil),                 // 5: docreader.NameValue
+	(*FetchConfig)(nil),               // 6: docreader.FetchConfig
+	(*ReadConfig)(nil),                // 7: docreader.ReadConfig
+	(*ReadFromFileRequest)(nil),       // 8: docreader.ReadFromFileRequest
+	(*ReadFromURLRequest)(nil),        // 9: docreader.ReadFromURLRequest
+	(*Image)(nil),                     // 10: docreader.Image
+	(*Chunk)(nil),                     // 11: docreader.Chunk
+	(*ReadResponse)(nil),              // 12: docreader.ReadResponse
+	(*ListParserPluginsRequest)(nil),  // 13: docreader.ListParserPluginsRequest
+	(*ParserPlugin)(nil),              // 14: docreader.ParserPlugin
+	(*ListParserPluginsResponse)(nil), // 15: docreader.ListParserPluginsResponse
+	(*GetReadProgressRequest)(nil),    // 16: docreader.GetReadProgressRequest
+	(*ReadProgress)(nil),              // 17: docreader.ReadProgress
 }
 var file_docreader_proto_depIdxs = []int32{
-	0, // 0: docreader.StorageConfig.provider:type_name -> docreader.StorageProvider
-	1, // 1: docreader.ReadConfig.storage_config:type_name -> docreader.StorageConfig
-	2, // 2: docreader.ReadConfig.vlm_config:type_name -> docreader.VLMConfig
-	3, // 3: docreader.ReadFromFileRequest.read_config:type_name -> docreader.ReadConfig
-	3, // 4: docreader.ReadFromURLRequest.read_config:type_name -> docreader.ReadConfig
-	6, // 5: docreader.Chunk.images:type_name -> docreader.Image
-	7, // 6: docreader.ReadResponse.chunks:type_name -> docreader.Chunk
-	4, // 7: docreader.DocReader.ReadFromFile:input_type -> docreader.ReadFromFileRequest
-	5, // 8: docreader.DocReader.ReadFromURL:input_type -> docreader.ReadFromURLRequest
-	8, // 9: docreader.DocReader.ReadFromFile:output_type -> docreader.ReadResponse
-	8, // 10: docreader.DocReader.ReadFromURL:output_type -> docreader.ReadResponse
-	9, // [9:11] is the sub-list for method output_type
-	7, // [7:9] is the sub-list for method input_type
-	7, // [7:7] is the sub-list for extension type_name
-	7, // [7:7] is the sub-list for extension extendee
-	0, // [0:7] is the sub-list for field type_name
+	0,  // 0: docreader.StorageConfig.provider:type_name -> docreader.StorageProvider
+	5,  // 1: docreader.FetchConfig.headers:type_name -> docreader.NameValue
+	5,  // 2: docreader.FetchConfig.cookies:type_name -> docreader.NameValue
+	1,  // 3: docreader.ReadConfig.storage_config:type_name -> docreader.StorageConfig
+	2,  // 4: docreader.ReadConfig.vlm_config:type_name -> docreader.VLMConfig
+	3,  // 5: docreader.ReadConfig.ocr_config:type_name -> docreader.OCRConfig
+	7,  // 6: docreader.ReadFromFileRequest.read_config:type_name -> docreader.ReadConfig
+	7,  // 7: docreader.ReadFromURLRequest.read_config:type_name -> docreader.ReadConfig
+	4,  // 8: docreader.ReadFromURLRequest.llm_config:type_name -> docreader.LLMConfig
+	6,  // 9: docreader.ReadFromURLRequest.fetch_config:type_name -> docreader.FetchConfig
+	10, // 10: docreader.Chunk.images:type_name -> docreader.Image
+	11, // 11: docreader.ReadResponse.chunks:type_name -> docreader.Chunk
+	14, // 12: docreader.ListParserPluginsResponse.plugins:type_name -> docreader.ParserPlugin
+	8,  // 13: docreader.DocReader.ReadFromFile:input_type -> docreader.ReadFromFileRequest
+	9,  // 14: docreader.DocReader.ReadFromURL:input_type -> docreader.ReadFromURLRequest
+	13, // 15: docreader.DocReader.ListParserPlugins:input_type -> docreader.ListParserPluginsRequest
+	16, // 16: docreader.DocReader.GetReadProgress:input_type -> docreader.GetReadProgressRequest
+	12, // 17: docreader.DocReader.ReadFromFile:output_type -> docreader.ReadResponse
+	12, // 18: docreader.DocReader.ReadFromURL:output_type -> docreader.ReadResponse
+	15, // 19: docreader.DocReader.ListParserPlugins:output_type -> docreader.ListParserPluginsResponse
+	17, // 20: docreader.DocReader.GetReadProgress:output_type -> docreader.ReadProgress
+	17, // [17:21] is the sub-list for method output_type
+	13, // [13:17] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_docreader_proto_init() }
@@ -804,7 +1420,7 @@ func file_docreader_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_docreader_proto_rawDesc), len(file_docreader_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   8,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
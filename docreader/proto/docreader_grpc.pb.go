@@ -19,8 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DocReader_ReadFromFile_FullMethodName = "/docreader.DocReader/ReadFromFile"
-	DocReader_ReadFromURL_FullMethodName  = "/docreader.DocReader/ReadFromURL"
+	DocReader_ReadFromFile_FullMethodName      = "/docreader.DocReader/ReadFromFile"
+	DocReader_ReadFromURL_FullMethodName       = "/docreader.DocReader/ReadFromURL"
+	DocReader_ListParserPlugins_FullMethodName = "/docreader.DocReader/ListParserPlugins"
+	DocReader_GetReadProgress_FullMethodName   = "/docreader.DocReader/GetReadProgress"
 )
 
 // DocReaderClient is the client API for DocReader service.
@@ -33,6 +35,10 @@ type DocReaderClient interface {
 	ReadFromFile(ctx context.Context, in *ReadFromFileRequest, opts ...grpc.CallOption) (*ReadResponse, error)
 	// 从URL读取文档
 	ReadFromURL(ctx context.Context, in *ReadFromURLRequest, opts ...grpc.CallOption) (*ReadResponse, error)
+	// 列出解析插件及其处理的文件扩展名
+	ListParserPlugins(ctx context.Context, in *ListParserPluginsRequest, opts ...grpc.CallOption) (*ListParserPluginsResponse, error)
+	// 查询文件解析进度
+	GetReadProgress(ctx context.Context, in *GetReadProgressRequest, opts ...grpc.CallOption) (*ReadProgress, error)
 }
 
 type docReaderClient struct {
@@ -63,6 +69,26 @@ func (c *docReaderClient) ReadFromURL(ctx context.Context, in *ReadFromURLReques
 	return out, nil
 }
 
+func (c *docReaderClient) ListParserPlugins(ctx context.Context, in *ListParserPluginsRequest, opts ...grpc.CallOption) (*ListParserPluginsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListParserPluginsResponse)
+	err := c.cc.Invoke(ctx, DocReader_ListParserPlugins_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *docReaderClient) GetReadProgress(ctx context.Context, in *GetReadProgressRequest, opts ...grpc.CallOption) (*ReadProgress, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadProgress)
+	err := c.cc.Invoke(ctx, DocReader_GetReadProgress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DocReaderServer is the server API for DocReader service.
 // All implementations must embed UnimplementedDocReaderServer
 // for forward compatibility.
@@ -73,6 +99,10 @@ type DocReaderServer interface {
 	ReadFromFile(context.Context, *ReadFromFileRequest) (*ReadResponse, error)
 	// 从URL读取文档
 	ReadFromURL(context.Context, *ReadFromURLRequest) (*ReadResponse, error)
+	// 列出解析插件及其处理的文件扩展名
+	ListParserPlugins(context.Context, *ListParserPluginsRequest) (*ListParserPluginsResponse, error)
+	// 查询文件解析进度
+	GetReadProgress(context.Context, *GetReadProgressRequest) (*ReadProgress, error)
 	mustEmbedUnimplementedDocReaderServer()
 }
 
@@ -89,6 +119,12 @@ func (UnimplementedDocReaderServer) ReadFromFile(context.Context, *ReadFromFileR
 func (UnimplementedDocReaderServer) ReadFromURL(context.Context, *ReadFromURLRequest) (*ReadResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReadFromURL not implemented")
 }
+func (UnimplementedDocReaderServer) ListParserPlugins(context.Context, *ListParserPluginsRequest) (*ListParserPluginsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListParserPlugins not implemented")
+}
+func (UnimplementedDocReaderServer) GetReadProgress(context.Context, *GetReadProgressRequest) (*ReadProgress, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReadProgress not implemented")
+}
 func (UnimplementedDocReaderServer) mustEmbedUnimplementedDocReaderServer() {}
 func (UnimplementedDocReaderServer) testEmbeddedByValue()                   {}
 
@@ -146,6 +182,42 @@ func _DocReader_ReadFromURL_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DocReader_ListParserPlugins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListParserPluginsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DocReaderServer).ListParserPlugins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DocReader_ListParserPlugins_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DocReaderServer).ListParserPlugins(ctx, req.(*ListParserPluginsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DocReader_GetReadProgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReadProgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DocReaderServer).GetReadProgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DocReader_GetReadProgress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DocReaderServer).GetReadProgress(ctx, req.(*GetReadProgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DocReader_ServiceDesc is the grpc.ServiceDesc for DocReader service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -161,6 +233,14 @@ var DocReader_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ReadFromURL",
 			Handler:    _DocReader_ReadFromURL_Handler,
 		},
+		{
+			MethodName: "ListParserPlugins",
+			Handler:    _DocReader_ListParserPlugins_Handler,
+		},
+		{
+			MethodName: "GetReadProgress",
+			Handler:    _DocReader_GetReadProgress_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "docreader.proto",
@@ -24,7 +24,7 @@ _sym_db = _symbol_database.Default()
 
 
 
-DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0f\x64ocreader.proto\x12\tdocreader\"\xb9\x01\n\rStorageConfig\x12,\n\x08provider\x18\x01 \x01(\x0e\x32\x1a.docreader.StorageProvider\x12\x0e\n\x06region\x18\x02 \x01(\t\x12\x13\n\x0b\x62ucket_name\x18\x03 \x01(\t\x12\x15\n\raccess_key_id\x18\x04 \x01(\t\x12\x19\n\x11secret_access_key\x18\x05 \x01(\t\x12\x0e\n\x06\x61pp_id\x18\x06 \x01(\t\x12\x13\n\x0bpath_prefix\x18\x07 \x01(\t\"Z\n\tVLMConfig\x12\x12\n\nmodel_name\x18\x01 \x01(\t\x12\x10\n\x08\x62\x61se_url\x18\x02 \x01(\t\x12\x0f\n\x07\x61pi_key\x18\x03 \x01(\t\x12\x16\n\x0einterface_type\x18\x04 \x01(\t\"\xc2\x01\n\nReadConfig\x12\x12\n\nchunk_size\x18\x01 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x02 \x01(\x05\x12\x12\n\nseparators\x18\x03 \x03(\t\x12\x19\n\x11\x65nable_multimodal\x18\x04 \x01(\x08\x12\x30\n\x0estorage_config\x18\x05 \x01(\x0b\x32\x18.docreader.StorageConfig\x12(\n\nvlm_config\x18\x06 \x01(\x0b\x32\x14.docreader.VLMConfig\"\x91\x01\n\x13ReadFromFileRequest\x12\x14\n\x0c\x66ile_content\x18\x01 \x01(\x0c\x12\x11\n\tfile_name\x18\x02 \x01(\t\x12\x11\n\tfile_type\x18\x03 \x01(\t\x12*\n\x0bread_config\x18\x04 \x01(\x0b\x32\x15.docreader.ReadConfig\x12\x12\n\nrequest_id\x18\x05 \x01(\t\"p\n\x12ReadFromURLRequest\x12\x0b\n\x03url\x18\x01 \x01(\t\x12\r\n\x05title\x18\x02 \x01(\t\x12*\n\x0bread_config\x18\x03 \x01(\x0b\x32\x15.docreader.ReadConfig\x12\x12\n\nrequest_id\x18\x04 \x01(\t\"i\n\x05Image\x12\x0b\n\x03url\x18\x01 \x01(\t\x12\x0f\n\x07\x63\x61ption\x18\x02 \x01(\t\x12\x10\n\x08ocr_text\x18\x03 \x01(\t\x12\x14\n\x0coriginal_url\x18\x04 \x01(\t\x12\r\n\x05start\x18\x05 \x01(\x05\x12\x0b\n\x03\x65nd\x18\x06 \x01(\x05\"c\n\x05\x43hunk\x12\x0f\n\x07\x63ontent\x18\x01 \x01(\t\x12\x0b\n\x03seq\x18\x02 \x01(\x05\x12\r\n\x05start\x18\x03 \x01(\x05\x12\x0b\n\x03\x65nd\x18\x04 \x01(\x05\x12 \n\x06images\x18\x05 \x03(\x0b\x32\x10.docreader.Image\"?\n\x0cReadResponse\x12 \n\x06\x63hunks\x18\x01 \x03(\x0b\x32\x10.docreader.Chunk\x12\r\n\x05\x65rror\x18\x02 \x01(\t*G\n\x0fStorageProvider\x12 \n\x1cSTORAGE_PROVIDER_UNSPECIFIED\x10\x00\x12\x07\n\x03\x43OS\x10\x01\x12\t\n\x05MINIO\x10\x02\x32\x9f\x01\n\tDocReader\x12I\n\x0cReadFromFile\x12\x1e.docreader.ReadFromFileRequest\x1a\x17.docreader.ReadResponse\"\x00\x12G\n\x0bReadFromURL\x12\x1d.docreader.ReadFromURLRequest\x1a\x17.docreader.ReadResponse\"\x00\x42\x35Z3github.com/Tencent/WeKnora/internal/docreader/protob\x06proto3')
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0f\x64ocreader.proto\x12\tdocreader\"\xb9\x01\n\rStorageConfig\x12,\n\x08provider\x18\x01 \x01(\x0e\x32\x1a.docreader.StorageProvider\x12\x0e\n\x06region\x18\x02 \x01(\t\x12\x13\n\x0b\x62ucket_name\x18\x03 \x01(\t\x12\x15\n\raccess_key_id\x18\x04 \x01(\t\x12\x19\n\x11secret_access_key\x18\x05 \x01(\t\x12\x0e\n\x06\x61pp_id\x18\x06 \x01(\t\x12\x13\n\x0bpath_prefix\x18\x07 \x01(\t\"~\n\tVLMConfig\x12\x12\n\nmodel_name\x18\x01 \x01(\t\x12\x10\n\x08\x62\x61se_url\x18\x02 \x01(\t\x12\x0f\n\x07\x61pi_key\x18\x03 \x01(\t\x12\x16\n\x0einterface_type\x18\x04 \x01(\t\x12\x0e\n\x06prompt\x18\x05 \x01(\t\x12\x12\n\nmax_images\x18\x06 \x01(\x05\".\n\tOCRConfig\x12\x0e\n\x06\x65ngine\x18\x01 \x01(\t\x12\x11\n\tlanguages\x18\x02 \x03(\t\"Z\n\tLLMConfig\x12\x12\n\nmodel_name\x18\x01 \x01(\t\x12\x10\n\x08\x62\x61se_url\x18\x02 \x01(\t\x12\x0f\n\x07\x61pi_key\x18\x03 \x01(\t\x12\x16\n\x0einterface_type\x18\x04 \x01(\t\"(\n\tNameValue\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t\"o\n\x0b\x46\x65tchConfig\x12\x12\n\nuser_agent\x18\x01 \x01(\t\x12%\n\x07headers\x18\x02 \x03(\x0b\x32\x14.docreader.NameValue\x12%\n\x07\x63ookies\x18\x03 \x03(\x0b\x32\x14.docreader.NameValue\"\xec\x01\n\nReadConfig\x12\x12\n\nchunk_size\x18\x01 \x01(\x05\x12\x15\n\rchunk_overlap\x18\x02 \x01(\x05\x12\x12\n\nseparators\x18\x03 \x03(\t\x12\x19\n\x11\x65nable_multimodal\x18\x04 \x01(\x08\x12\x30\n\x0estorage_config\x18\x05 \x01(\x0b\x32\x18.docreader.StorageConfig\x12(\n\nvlm_config\x18\x06 \x01(\x0b\x32\x14.docreader.VLMConfig\x12(\n\nocr_config\x18\x07 \x01(\x0b\x32\x14.docreader.OCRConfig\"\xa6\x01\n\x13ReadFromFileRequest\x12\x14\n\x0c\x66ile_content\x18\x01 \x01(\x0c\x12\x11\n\tfile_name\x18\x02 \x01(\t\x12\x11\n\tfile_type\x18\x03 \x01(\t\x12*\n\x0bread_config\x18\x04 \x01(\x0b\x32\x15.docreader.ReadConfig\x12\x12\n\nrequest_id\x18\x05 \x01(\t\x12\x13\n\x0bprogress_id\x18\x06 \x01(\t\"\xe1\x01\n\x12ReadFromURLRequest\x12\x0b\n\x03url\x18\x01 \x01(\t\x12\r\n\x05title\x18\x02 \x01(\t\x12*\n\x0bread_config\x18\x03 \x01(\x0b\x32\x15.docreader.ReadConfig\x12\x12\n\nrequest_id\x18\x04 \x01(\t\x12\x17\n\x0f\x65xtraction_mode\x18\x05 \x01(\t\x12(\n\nllm_config\x18\x06 \x01(\x0b\x32\x14.docreader.LLMConfig\x12,\n\x0c\x66\x65tch_config\x18\x07 \x01(\x0b\x32\x16.docreader.FetchConfig\"}\n\x05Image\x12\x0b\n\x03url\x18\x01 \x01(\t\x12\x0f\n\x07\x63\x61ption\x18\x02 \x01(\t\x12\x10\n\x08ocr_text\x18\x03 \x01(\t\x12\x14\n\x0coriginal_url\x18\x04 \x01(\t\x12\r\n\x05start\x18\x05 \x01(\x05\x12\x0b\n\x03\x65nd\x18\x06 \x01(\x05\x12\x12\n\nocr_engine\x18\x07 \x01(\t\"u\n\x05\x43hunk\x12\x0f\n\x07\x63ontent\x18\x01 \x01(\t\x12\x0b\n\x03seq\x18\x02 \x01(\x05\x12\r\n\x05start\x18\x03 \x01(\x05\x12\x0b\n\x03\x65nd\x18\x04 \x01(\x05\x12 \n\x06images\x18\x05 \x03(\x0b\x32\x10.docreader.Image\x12\x10\n\x08metadata\x18\x06 \x01(\t\"?\n\x0cReadResponse\x12 \n\x06\x63hunks\x18\x01 \x03(\x0b\x32\x10.docreader.Chunk\x12\r\n\x05\x65rror\x18\x02 \x01(\t\"\x1a\n\x18ListParserPluginsRequest\"0\n\x0cParserPlugin\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x12\n\nextensions\x18\x02 \x03(\t\"E\n\x19ListParserPluginsResponse\x12(\n\x07plugins\x18\x01 \x03(\x0b\x32\x17.docreader.ParserPlugin\"-\n\x16GetReadProgressRequest\x12\x13\n\x0bprogress_id\x18\x01 \x01(\t\"=\n\x0cReadProgress\x12\r\n\x05stage\x18\x01 \x01(\t\x12\x0f\n\x07\x63urrent\x18\x02 \x01(\x05\x12\r\n\x05total\x18\x03 \x01(\x05*G\n\x0fStorageProvider\x12 \n\x1cSTORAGE_PROVIDER_UNSPECIFIED\x10\x00\x12\x07\n\x03\x43OS\x10\x01\x12\t\n\x05MINIO\x10\x02\x32\xd2\x02\n\tDocReader\x12I\n\x0cReadFromFile\x12\x1e.docreader.ReadFromFileRequest\x1a\x17.docreader.ReadResponse\"\x00\x12G\n\x0bReadFromURL\x12\x1d.docreader.ReadFromURLRequest\x1a\x17.docreader.ReadResponse\"\x00\x12`\n\x11ListParserPlugins\x12#.docreader.ListParserPluginsRequest\x1a$.docreader.ListParserPluginsResponse\"\x00\x12O\n\x0fGetReadProgress\x12!.docreader.GetReadProgressRequest\x1a\x17.docreader.ReadProgress\"\x00\x42\x35Z3github.com/Tencent/WeKnora/internal/docreader/protob\x06proto3')
 
 _globals = globals()
 _builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
@@ -32,24 +32,42 @@ _builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, 'docreader_pb2', _globals)
 if not _descriptor._USE_C_DESCRIPTORS:
   _globals['DESCRIPTOR']._loaded_options = None
   _globals['DESCRIPTOR']._serialized_options = b'Z3github.com/Tencent/WeKnora/internal/docreader/proto'
-  _globals['_STORAGEPROVIDER']._serialized_start=1042
-  _globals['_STORAGEPROVIDER']._serialized_end=1113
+  _globals['_STORAGEPROVIDER']._serialized_start=1847
+  _globals['_STORAGEPROVIDER']._serialized_end=1918
   _globals['_STORAGECONFIG']._serialized_start=31
   _globals['_STORAGECONFIG']._serialized_end=216
   _globals['_VLMCONFIG']._serialized_start=218
-  _globals['_VLMCONFIG']._serialized_end=308
-  _globals['_READCONFIG']._serialized_start=311
-  _globals['_READCONFIG']._serialized_end=505
-  _globals['_READFROMFILEREQUEST']._serialized_start=508
-  _globals['_READFROMFILEREQUEST']._serialized_end=653
-  _globals['_READFROMURLREQUEST']._serialized_start=655
-  _globals['_READFROMURLREQUEST']._serialized_end=767
-  _globals['_IMAGE']._serialized_start=769
-  _globals['_IMAGE']._serialized_end=874
-  _globals['_CHUNK']._serialized_start=876
-  _globals['_CHUNK']._serialized_end=975
-  _globals['_READRESPONSE']._serialized_start=977
-  _globals['_READRESPONSE']._serialized_end=1040
-  _globals['_DOCREADER']._serialized_start=1116
-  _globals['_DOCREADER']._serialized_end=1275
+  _globals['_VLMCONFIG']._serialized_end=344
+  _globals['_OCRCONFIG']._serialized_start=346
+  _globals['_OCRCONFIG']._serialized_end=392
+  _globals['_LLMCONFIG']._serialized_start=394
+  _globals['_LLMCONFIG']._serialized_end=484
+  _globals['_NAMEVALUE']._serialized_start=486
+  _globals['_NAMEVALUE']._serialized_end=526
+  _globals['_FETCHCONFIG']._serialized_start=528
+  _globals['_FETCHCONFIG']._serialized_end=639
+  _globals['_READCONFIG']._serialized_start=642
+  _globals['_READCONFIG']._serialized_end=878
+  _globals['_READFROMFILEREQUEST']._serialized_start=881
+  _globals['_READFROMFILEREQUEST']._serialized_end=1047
+  _globals['_READFROMURLREQUEST']._serialized_start=1050
+  _globals['_READFROMURLREQUEST']._serialized_end=1275
+  _globals['_IMAGE']._serialized_start=1277
+  _globals['_IMAGE']._serialized_end=1402
+  _globals['_CHUNK']._serialized_start=1404
+  _globals['_CHUNK']._serialized_end=1521
+  _globals['_READRESPONSE']._serialized_start=1523
+  _globals['_READRESPONSE']._serialized_end=1586
+  _globals['_LISTPARSERPLUGINSREQUEST']._serialized_start=1588
+  _globals['_LISTPARSERPLUGINSREQUEST']._serialized_end=1614
+  _globals['_PARSERPLUGIN']._serialized_start=1616
+  _globals['_PARSERPLUGIN']._serialized_end=1664
+  _globals['_LISTPARSERPLUGINSRESPONSE']._serialized_start=1666
+  _globals['_LISTPARSERPLUGINSRESPONSE']._serialized_end=1735
+  _globals['_GETREADPROGRESSREQUEST']._serialized_start=1737
+  _globals['_GETREADPROGRESSREQUEST']._serialized_end=1782
+  _globals['_READPROGRESS']._serialized_start=1784
+  _globals['_READPROGRESS']._serialized_end=1845
+  _globals['_DOCREADER']._serialized_start=1921
+  _globals['_DOCREADER']._serialized_end=2259
 # @@protoc_insertion_point(module_scope)
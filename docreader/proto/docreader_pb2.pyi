@@ -35,6 +35,30 @@ class StorageConfig(_message.Message):
     def __init__(self, provider: _Optional[_Union[StorageProvider, str]] = ..., region: _Optional[str] = ..., bucket_name: _Optional[str] = ..., access_key_id: _Optional[str] = ..., secret_access_key: _Optional[str] = ..., app_id: _Optional[str] = ..., path_prefix: _Optional[str] = ...) -> None: ...
 
 class VLMConfig(_message.Message):
+    __slots__ = ("model_name", "base_url", "api_key", "interface_type", "prompt", "max_images")
+    MODEL_NAME_FIELD_NUMBER: _ClassVar[int]
+    BASE_URL_FIELD_NUMBER: _ClassVar[int]
+    API_KEY_FIELD_NUMBER: _ClassVar[int]
+    INTERFACE_TYPE_FIELD_NUMBER: _ClassVar[int]
+    PROMPT_FIELD_NUMBER: _ClassVar[int]
+    MAX_IMAGES_FIELD_NUMBER: _ClassVar[int]
+    model_name: str
+    base_url: str
+    api_key: str
+    interface_type: str
+    prompt: str
+    max_images: int
+    def __init__(self, model_name: _Optional[str] = ..., base_url: _Optional[str] = ..., api_key: _Optional[str] = ..., interface_type: _Optional[str] = ..., prompt: _Optional[str] = ..., max_images: _Optional[int] = ...) -> None: ...
+
+class OCRConfig(_message.Message):
+    __slots__ = ("engine", "languages")
+    ENGINE_FIELD_NUMBER: _ClassVar[int]
+    LANGUAGES_FIELD_NUMBER: _ClassVar[int]
+    engine: str
+    languages: _containers.RepeatedScalarFieldContainer[str]
+    def __init__(self, engine: _Optional[str] = ..., languages: _Optional[_Iterable[str]] = ...) -> None: ...
+
+class LLMConfig(_message.Message):
     __slots__ = ("model_name", "base_url", "api_key", "interface_type")
     MODEL_NAME_FIELD_NUMBER: _ClassVar[int]
     BASE_URL_FIELD_NUMBER: _ClassVar[int]
@@ -46,77 +70,109 @@ class VLMConfig(_message.Message):
     interface_type: str
     def __init__(self, model_name: _Optional[str] = ..., base_url: _Optional[str] = ..., api_key: _Optional[str] = ..., interface_type: _Optional[str] = ...) -> None: ...
 
+class NameValue(_message.Message):
+    __slots__ = ("name", "value")
+    NAME_FIELD_NUMBER: _ClassVar[int]
+    VALUE_FIELD_NUMBER: _ClassVar[int]
+    name: str
+    value: str
+    def __init__(self, name: _Optional[str] = ..., value: _Optional[str] = ...) -> None: ...
+
+class FetchConfig(_message.Message):
+    __slots__ = ("user_agent", "headers", "cookies")
+    USER_AGENT_FIELD_NUMBER: _ClassVar[int]
+    HEADERS_FIELD_NUMBER: _ClassVar[int]
+    COOKIES_FIELD_NUMBER: _ClassVar[int]
+    user_agent: str
+    headers: _containers.RepeatedCompositeFieldContainer[NameValue]
+    cookies: _containers.RepeatedCompositeFieldContainer[NameValue]
+    def __init__(self, user_agent: _Optional[str] = ..., headers: _Optional[_Iterable[_Union[NameValue, _Mapping]]] = ..., cookies: _Optional[_Iterable[_Union[NameValue, _Mapping]]] = ...) -> None: ...
+
 class ReadConfig(_message.Message):
-    __slots__ = ("chunk_size", "chunk_overlap", "separators", "enable_multimodal", "storage_config", "vlm_config")
+    __slots__ = ("chunk_size", "chunk_overlap", "separators", "enable_multimodal", "storage_config", "vlm_config", "ocr_config")
     CHUNK_SIZE_FIELD_NUMBER: _ClassVar[int]
     CHUNK_OVERLAP_FIELD_NUMBER: _ClassVar[int]
     SEPARATORS_FIELD_NUMBER: _ClassVar[int]
     ENABLE_MULTIMODAL_FIELD_NUMBER: _ClassVar[int]
     STORAGE_CONFIG_FIELD_NUMBER: _ClassVar[int]
     VLM_CONFIG_FIELD_NUMBER: _ClassVar[int]
+    OCR_CONFIG_FIELD_NUMBER: _ClassVar[int]
     chunk_size: int
     chunk_overlap: int
     separators: _containers.RepeatedScalarFieldContainer[str]
     enable_multimodal: bool
     storage_config: StorageConfig
     vlm_config: VLMConfig
-    def __init__(self, chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., separators: _Optional[_Iterable[str]] = ..., enable_multimodal: bool = ..., storage_config: _Optional[_Union[StorageConfig, _Mapping]] = ..., vlm_config: _Optional[_Union[VLMConfig, _Mapping]] = ...) -> None: ...
+    ocr_config: OCRConfig
+    def __init__(self, chunk_size: _Optional[int] = ..., chunk_overlap: _Optional[int] = ..., separators: _Optional[_Iterable[str]] = ..., enable_multimodal: bool = ..., storage_config: _Optional[_Union[StorageConfig, _Mapping]] = ..., vlm_config: _Optional[_Union[VLMConfig, _Mapping]] = ..., ocr_config: _Optional[_Union[OCRConfig, _Mapping]] = ...) -> None: ...
 
 class ReadFromFileRequest(_message.Message):
-    __slots__ = ("file_content", "file_name", "file_type", "read_config", "request_id")
+    __slots__ = ("file_content", "file_name", "file_type", "read_config", "request_id", "progress_id")
     FILE_CONTENT_FIELD_NUMBER: _ClassVar[int]
     FILE_NAME_FIELD_NUMBER: _ClassVar[int]
     FILE_TYPE_FIELD_NUMBER: _ClassVar[int]
     READ_CONFIG_FIELD_NUMBER: _ClassVar[int]
     REQUEST_ID_FIELD_NUMBER: _ClassVar[int]
+    PROGRESS_ID_FIELD_NUMBER: _ClassVar[int]
     file_content: bytes
     file_name: str
     file_type: str
     read_config: ReadConfig
     request_id: str
-    def __init__(self, file_content: _Optional[bytes] = ..., file_name: _Optional[str] = ..., file_type: _Optional[str] = ..., read_config: _Optional[_Union[ReadConfig, _Mapping]] = ..., request_id: _Optional[str] = ...) -> None: ...
+    progress_id: str
+    def __init__(self, file_content: _Optional[bytes] = ..., file_name: _Optional[str] = ..., file_type: _Optional[str] = ..., read_config: _Optional[_Union[ReadConfig, _Mapping]] = ..., request_id: _Optional[str] = ..., progress_id: _Optional[str] = ...) -> None: ...
 
 class ReadFromURLRequest(_message.Message):
-    __slots__ = ("url", "title", "read_config", "request_id")
+    __slots__ = ("url", "title", "read_config", "request_id", "extraction_mode", "llm_config", "fetch_config")
     URL_FIELD_NUMBER: _ClassVar[int]
     TITLE_FIELD_NUMBER: _ClassVar[int]
     READ_CONFIG_FIELD_NUMBER: _ClassVar[int]
     REQUEST_ID_FIELD_NUMBER: _ClassVar[int]
+    EXTRACTION_MODE_FIELD_NUMBER: _ClassVar[int]
+    LLM_CONFIG_FIELD_NUMBER: _ClassVar[int]
+    FETCH_CONFIG_FIELD_NUMBER: _ClassVar[int]
     url: str
     title: str
     read_config: ReadConfig
     request_id: str
-    def __init__(self, url: _Optional[str] = ..., title: _Optional[str] = ..., read_config: _Optional[_Union[ReadConfig, _Mapping]] = ..., request_id: _Optional[str] = ...) -> None: ...
+    extraction_mode: str
+    llm_config: LLMConfig
+    fetch_config: FetchConfig
+    def __init__(self, url: _Optional[str] = ..., title: _Optional[str] = ..., read_config: _Optional[_Union[ReadConfig, _Mapping]] = ..., request_id: _Optional[str] = ..., extraction_mode: _Optional[str] = ..., llm_config: _Optional[_Union[LLMConfig, _Mapping]] = ..., fetch_config: _Optional[_Union[FetchConfig, _Mapping]] = ...) -> None: ...
 
 class Image(_message.Message):
-    __slots__ = ("url", "caption", "ocr_text", "original_url", "start", "end")
+    __slots__ = ("url", "caption", "ocr_text", "original_url", "start", "end", "ocr_engine")
     URL_FIELD_NUMBER: _ClassVar[int]
     CAPTION_FIELD_NUMBER: _ClassVar[int]
     OCR_TEXT_FIELD_NUMBER: _ClassVar[int]
     ORIGINAL_URL_FIELD_NUMBER: _ClassVar[int]
     START_FIELD_NUMBER: _ClassVar[int]
     END_FIELD_NUMBER: _ClassVar[int]
+    OCR_ENGINE_FIELD_NUMBER: _ClassVar[int]
     url: str
     caption: str
     ocr_text: str
     original_url: str
     start: int
     end: int
-    def __init__(self, url: _Optional[str] = ..., caption: _Optional[str] = ..., ocr_text: _Optional[str] = ..., original_url: _Optional[str] = ..., start: _Optional[int] = ..., end: _Optional[int] = ...) -> None: ...
+    ocr_engine: str
+    def __init__(self, url: _Optional[str] = ..., caption: _Optional[str] = ..., ocr_text: _Optional[str] = ..., original_url: _Optional[str] = ..., start: _Optional[int] = ..., end: _Optional[int] = ..., ocr_engine: _Optional[str] = ...) -> None: ...
 
 class Chunk(_message.Message):
-    __slots__ = ("content", "seq", "start", "end", "images")
+    __slots__ = ("content", "seq", "start", "end", "images", "metadata")
     CONTENT_FIELD_NUMBER: _ClassVar[int]
     SEQ_FIELD_NUMBER: _ClassVar[int]
     START_FIELD_NUMBER: _ClassVar[int]
     END_FIELD_NUMBER: _ClassVar[int]
     IMAGES_FIELD_NUMBER: _ClassVar[int]
+    METADATA_FIELD_NUMBER: _ClassVar[int]
     content: str
     seq: int
     start: int
     end: int
     images: _containers.RepeatedCompositeFieldContainer[Image]
-    def __init__(self, content: _Optional[str] = ..., seq: _Optional[int] = ..., start: _Optional[int] = ..., end: _Optional[int] = ..., images: _Optional[_Iterable[_Union[Image, _Mapping]]] = ...) -> None: ...
+    metadata: str
+    def __init__(self, content: _Optional[str] = ..., seq: _Optional[int] = ..., start: _Optional[int] = ..., end: _Optional[int] = ..., images: _Optional[_Iterable[_Union[Image, _Mapping]]] = ..., metadata: _Optional[str] = ...) -> None: ...
 
 class ReadResponse(_message.Message):
     __slots__ = ("chunks", "error")
@@ -125,3 +181,37 @@ class ReadResponse(_message.Message):
     chunks: _containers.RepeatedCompositeFieldContainer[Chunk]
     error: str
     def __init__(self, chunks: _Optional[_Iterable[_Union[Chunk, _Mapping]]] = ..., error: _Optional[str] = ...) -> None: ...
+
+class ListParserPluginsRequest(_message.Message):
+    __slots__ = ()
+    def __init__(self) -> None: ...
+
+class ParserPlugin(_message.Message):
+    __slots__ = ("name", "extensions")
+    NAME_FIELD_NUMBER: _ClassVar[int]
+    EXTENSIONS_FIELD_NUMBER: _ClassVar[int]
+    name: str
+    extensions: _containers.RepeatedScalarFieldContainer[str]
+    def __init__(self, name: _Optional[str] = ..., extensions: _Optional[_Iterable[str]] = ...) -> None: ...
+
+class ListParserPluginsResponse(_message.Message):
+    __slots__ = ("plugins",)
+    PLUGINS_FIELD_NUMBER: _ClassVar[int]
+    plugins: _containers.RepeatedCompositeFieldContainer[ParserPlugin]
+    def __init__(self, plugins: _Optional[_Iterable[_Union[ParserPlugin, _Mapping]]] = ...) -> None: ...
+
+class GetReadProgressRequest(_message.Message):
+    __slots__ = ("progress_id",)
+    PROGRESS_ID_FIELD_NUMBER: _ClassVar[int]
+    progress_id: str
+    def __init__(self, progress_id: _Optional[str] = ...) -> None: ...
+
+class ReadProgress(_message.Message):
+    __slots__ = ("stage", "current", "total")
+    STAGE_FIELD_NUMBER: _ClassVar[int]
+    CURRENT_FIELD_NUMBER: _ClassVar[int]
+    TOTAL_FIELD_NUMBER: _ClassVar[int]
+    stage: str
+    current: int
+    total: int
+    def __init__(self, stage: _Optional[str] = ..., current: _Optional[int] = ..., total: _Optional[int] = ...) -> None: ...
@@ -45,6 +45,16 @@ class DocReaderStub(object):
                 request_serializer=docreader__pb2.ReadFromURLRequest.SerializeToString,
                 response_deserializer=docreader__pb2.ReadResponse.FromString,
                 _registered_method=True)
+        self.ListParserPlugins = channel.unary_unary(
+                '/docreader.DocReader/ListParserPlugins',
+                request_serializer=docreader__pb2.ListParserPluginsRequest.SerializeToString,
+                response_deserializer=docreader__pb2.ListParserPluginsResponse.FromString,
+                _registered_method=True)
+        self.GetReadProgress = channel.unary_unary(
+                '/docreader.DocReader/GetReadProgress',
+                request_serializer=docreader__pb2.GetReadProgressRequest.SerializeToString,
+                response_deserializer=docreader__pb2.ReadProgress.FromString,
+                _registered_method=True)
 
 
 class DocReaderServicer(object):
@@ -65,6 +75,20 @@ class DocReaderServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def ListParserPlugins(self, request, context):
+        """列出解析插件及其处理的文件扩展名
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def GetReadProgress(self, request, context):
+        """查询文件解析进度
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_DocReaderServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -78,6 +102,16 @@ def add_DocReaderServicer_to_server(servicer, server):
                     request_deserializer=docreader__pb2.ReadFromURLRequest.FromString,
                     response_serializer=docreader__pb2.ReadResponse.SerializeToString,
             ),
+            'ListParserPlugins': grpc.unary_unary_rpc_method_handler(
+                    servicer.ListParserPlugins,
+                    request_deserializer=docreader__pb2.ListParserPluginsRequest.FromString,
+                    response_serializer=docreader__pb2.ListParserPluginsResponse.SerializeToString,
+            ),
+            'GetReadProgress': grpc.unary_unary_rpc_method_handler(
+                    servicer.GetReadProgress,
+                    request_deserializer=docreader__pb2.GetReadProgressRequest.FromString,
+                    response_serializer=docreader__pb2.ReadProgress.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'docreader.DocReader', rpc_method_handlers)
@@ -143,3 +177,57 @@ class DocReader(object):
             timeout,
             metadata,
             _registered_method=True)
+
+    @staticmethod
+    def ListParserPlugins(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/docreader.DocReader/ListParserPlugins',
+            docreader__pb2.ListParserPluginsRequest.SerializeToString,
+            docreader__pb2.ListParserPluginsResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def GetReadProgress(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/docreader.DocReader/GetReadProgress',
+            docreader__pb2.GetReadProgressRequest.SerializeToString,
+            docreader__pb2.ReadProgress.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
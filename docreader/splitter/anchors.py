@@ -0,0 +1,162 @@
+"""
+Source anchors of chunks: the pages, the heading path and, for web pages, the
+URL fragment a chunk comes from, so answers can cite the exact location of a
+passage.
+"""
+
+import re
+from bisect import bisect_right
+from typing import Dict, Iterable, List, Optional, Sequence, Tuple
+
+from docreader.models.document import Chunk
+
+# Form feed separating pages in text extracted from PDFs
+PAGE_BREAK = "\f"
+
+# ATX Markdown heading, e.g. "## Install"
+HEADING_PATTERN = re.compile(r"^ {0,3}(#{1,6})[ \t]+(.+?)(?:[ \t]+#+)?[ \t]*$")
+
+# Opening or closing line of a fenced code block
+FENCE_PATTERN = re.compile(r"^ {0,3}(```|~~~)")
+
+# Markdown link or image in a heading, kept as its text
+LINK_PATTERN = re.compile(r"!?\[([^\]]*)\]\([^)]*\)")
+
+# Characters Markdown conversions escape or rewrite, a page marker stops before them
+MARKER_STOP_PATTERN = re.compile(r"[\\`*_\[\]<>#|$]")
+
+# Longest and shortest text of a page marker
+MARKER_MAX_LEN = 40
+MARKER_MIN_LEN = 4
+
+
+def heading_text(title: str) -> str:
+    """Heading text without Markdown formatting and permalink markers"""
+    title = LINK_PATTERN.sub(r"\1", title)
+    title = re.sub(r"[*_`\\]", "", title)
+    # Permalink markers such as Sphinx's ¶
+    title = title.strip().rstrip("¶§#").strip()
+    return " ".join(title.split())
+
+
+def heading_key(title: str) -> str:
+    """Key matching the headings of a Markdown conversion with the headings of
+    its HTML source"""
+    return heading_text(title).lower()
+
+
+def _heading_paths(text: str) -> Tuple[List[int], List[List[str]]]:
+    """Offsets of the headings in text and the heading path in effect from each of them"""
+    offsets: List[int] = []
+    paths: List[List[str]] = []
+    stack: List[Tuple[int, str]] = []
+    fence = ""
+    offset = 0
+    for line in text.splitlines(keepends=True):
+        fence_match = FENCE_PATTERN.match(line)
+        if fence_match:
+            if not fence:
+                fence = fence_match.group(1)
+            elif fence_match.group(1) == fence:
+                fence = ""
+        elif not fence:
+            match = HEADING_PATTERN.match(line.rstrip("\r\n"))
+            if match:
+                level = len(match.group(1))
+                while stack and stack[-1][0] >= level:
+                    stack.pop()
+                stack.append((level, heading_text(match.group(2)) or match.group(2)))
+                offsets.append(offset)
+                paths.append([title for _, title in stack])
+        offset += len(line)
+    return offsets, paths
+
+
+def page_marker(block: str) -> str:
+    """Marker finding a block of text in the document, the beginning of its
+    first line up to what a Markdown conversion may rewrite; "" when too short"""
+    line = block.strip().split("\n", 1)[0]
+    marker = MARKER_STOP_PATTERN.split(line, 1)[0][:MARKER_MAX_LEN].strip()
+    return marker if len(marker) >= MARKER_MIN_LEN else ""
+
+
+def page_markers(blocks: Iterable[Tuple[int, str]]) -> List[List]:
+    """Page markers of a document from its text blocks in reading order, given
+    as (page, text) with pages counted from 1: the marker of the first block of
+    each page that has one. Parsers put them in the "page_markers" metadata of
+    the document, they outlive the rewrites of the text after parsing."""
+    markers: List[List] = []
+    for page, block in blocks:
+        if markers and page <= markers[-1][0]:
+            continue
+        marker = page_marker(block)
+        if marker:
+            markers.append([page, marker])
+    return markers
+
+
+def _page_starts_from_markers(text: str, markers: Sequence[Sequence]) -> List[int]:
+    """Offsets in text where each page starts, from page markers. Pages whose
+    marker is not found start with the next page found, so their text counts
+    towards the page before them."""
+    found: Dict[int, int] = {}
+    cursor = 0
+    for page, marker in markers:
+        index = text.find(marker, cursor)
+        if index < 0:
+            continue
+        # The page starts at the line of its first block, e.g. before "# "
+        found[int(page)] = max(text.rfind("\n", 0, index) + 1, cursor)
+        cursor = index + len(marker)
+    if not found:
+        return [0]
+    last_page = max(found)
+    page_starts = [0] * last_page
+    next_start = len(text)
+    for page in range(last_page, 1, -1):
+        next_start = found.get(page, next_start)
+        page_starts[page - 1] = next_start
+    return page_starts
+
+
+def attach_source_anchors(
+    text: str,
+    chunks: List[Chunk],
+    heading_ids: Optional[Dict[str, str]] = None,
+    markers: Optional[Sequence[Sequence]] = None,
+) -> None:
+    """Record page_start/page_end and heading_path of each chunk in its metadata
+
+    Pages come from the page markers of the parser, see page_markers, else
+    from form feeds in text; headings from Markdown headings. Chunk offsets
+    must refer to text. heading_ids maps the heading_key of the headings of a
+    web page to their element ids; a chunk under such a heading gets it as
+    url_fragment.
+    """
+    page_starts = [0]
+    if markers:
+        page_starts = _page_starts_from_markers(text, markers)
+    elif PAGE_BREAK in text:
+        page_starts += [i + 1 for i, c in enumerate(text) if c == PAGE_BREAK]
+    heading_offsets, heading_paths = _heading_paths(text)
+
+    for chunk in chunks:
+        content = chunk.content
+        # Anchor on the first and last visible characters, not on separators
+        first = chunk.start + len(content) - len(content.lstrip())
+        last = max(first, chunk.start + len(content.rstrip()) - 1)
+
+        if len(page_starts) > 1:
+            chunk.metadata["page_start"] = bisect_right(page_starts, first)
+            chunk.metadata["page_end"] = bisect_right(page_starts, last)
+
+        index = bisect_right(heading_offsets, first) - 1
+        if index >= 0:
+            chunk.metadata["heading_path"] = heading_paths[index]
+            # The nearest heading with an id, e.g. the section when the
+            # subsection has none
+            for title in reversed(heading_paths[index] if heading_ids else []):
+                fragment = heading_ids.get(heading_key(title))
+                if fragment:
+                    chunk.metadata["url_fragment"] = fragment
+                    break
@@ -0,0 +1,252 @@
+import logging
+import os
+import shutil
+import signal
+import subprocess
+import threading
+import time
+from dataclasses import asdict, dataclass
+from typing import Callable, Dict, List, Optional
+
+from docreader.config import CONFIG
+from docreader.utils.tempfile import TempDirContext
+
+logger = logging.getLogger(__name__)
+
+
+class ConversionError(RuntimeError):
+    """A conversion failed or produced no usable output"""
+
+
+class ConversionRejected(ConversionError):
+    """The conversion queue is full or the job waited too long for a worker"""
+
+
+class ConversionTimeout(ConversionError):
+    """The converter ran longer than the per-job timeout and was killed"""
+
+
+@dataclass
+class ConversionJob:
+    """Sandbox directory of a conversion: the converter reads input_path,
+    writes into output_dir and uses work_dir as its home and temp directory"""
+
+    work_dir: str
+    input_path: str
+    output_dir: str
+
+    @property
+    def profile_uri(self) -> str:
+        """URI of a private LibreOffice profile, concurrent soffice processes
+        sharing the default profile block each other"""
+        return "file://" + os.path.join(self.work_dir, "profile")
+
+
+@dataclass
+class ConversionStats:
+    submitted: int = 0
+    rejected: int = 0
+    completed: int = 0
+    failed: int = 0
+    timed_out: int = 0
+    waiting: int = 0
+    running: int = 0
+    wait_seconds_total: float = 0.0
+    wait_seconds_max: float = 0.0
+    run_seconds_total: float = 0.0
+
+
+class ConversionPool:
+    """
+    Bounded pool for external converter processes such as LibreOffice.
+
+    At most max_workers conversions run at once, up to max_queue more wait
+    for a worker and further jobs are rejected right away, as are jobs that
+    wait longer than queue_timeout seconds. Every job runs in a temporary
+    directory of its own, in a new process group killed as a whole after
+    timeout seconds, with outbound traffic sent to the configured proxy.
+    The output file must be non-empty and at most max_output_size bytes;
+    when prlimit is available the converter cannot write larger files.
+    """
+
+    def __init__(
+        self,
+        max_workers: int,
+        max_queue: int,
+        queue_timeout: float,
+        timeout: float,
+        max_output_size: int,
+        proxy: Optional[str] = None,
+    ):
+        self.max_workers = max(1, max_workers)
+        self.max_queue = max(0, max_queue)
+        self.queue_timeout = queue_timeout
+        self.timeout = timeout
+        self.max_output_size = max_output_size
+        # Same default as SandboxExecutor: block network access without proxy
+        self.proxy = proxy or CONFIG.external_https_proxy or "http://128.0.0.1:1"
+        self._slots = threading.Semaphore(self.max_workers)
+        self._lock = threading.Lock()
+        self._stats = ConversionStats()
+        self._prlimit = shutil.which("prlimit")
+
+    def stats(self) -> Dict[str, float]:
+        """Snapshot of the queue counters"""
+        with self._lock:
+            return asdict(self._stats)
+
+    def convert(
+        self,
+        name: str,
+        input_path: str,
+        output_ext: str,
+        build_cmd: Callable[[ConversionJob], List[str]],
+    ) -> bytes:
+        """Run a conversion and return the content of its output file
+
+        Args:
+            name: Converter name, for logs
+            input_path: File to convert, copied into the sandbox
+            output_ext: Extension of the expected output file, e.g. ".docx"
+            build_cmd: Builds the command line from the sandbox paths
+
+        Raises:
+            ConversionRejected: The queue is full or the wait timed out
+            ConversionTimeout: The converter was killed after the timeout
+            ConversionError: The converter failed or its output is unusable
+        """
+        with self._lock:
+            self._stats.submitted += 1
+            if self._stats.waiting >= self.max_queue:
+                self._stats.rejected += 1
+                raise ConversionRejected(
+                    f"Conversion queue is full ({self._stats.waiting} waiting)"
+                )
+            self._stats.waiting += 1
+
+        queued_at = time.monotonic()
+        acquired = self._slots.acquire(timeout=self.queue_timeout)
+        waited = time.monotonic() - queued_at
+        with self._lock:
+            self._stats.waiting -= 1
+            self._stats.wait_seconds_total += waited
+            self._stats.wait_seconds_max = max(self._stats.wait_seconds_max, waited)
+            if acquired:
+                self._stats.running += 1
+            else:
+                self._stats.rejected += 1
+        if not acquired:
+            raise ConversionRejected(
+                f"No conversion worker available after {waited:.0f} seconds"
+            )
+
+        started_at = time.monotonic()
+        outcome = "failed"
+        try:
+            content = self._run(name, input_path, output_ext, build_cmd)
+            outcome = "completed"
+            return content
+        except ConversionTimeout:
+            outcome = "timed_out"
+            raise
+        finally:
+            self._slots.release()
+            elapsed = time.monotonic() - started_at
+            with self._lock:
+                self._stats.running -= 1
+                self._stats.run_seconds_total += elapsed
+                setattr(self._stats, outcome, getattr(self._stats, outcome) + 1)
+                running, waiting = self._stats.running, self._stats.waiting
+            logger.info(
+                f"Conversion with {name} {outcome} in {elapsed:.1f}s after "
+                f"{waited:.1f}s in queue, {running} running, {waiting} waiting"
+            )
+            logger.debug(f"Conversion pool stats: {self.stats()}")
+
+    def _run(
+        self,
+        name: str,
+        input_path: str,
+        output_ext: str,
+        build_cmd: Callable[[ConversionJob], List[str]],
+    ) -> bytes:
+        with TempDirContext() as work_dir:
+            job = ConversionJob(
+                work_dir=work_dir,
+                input_path=os.path.join(
+                    work_dir, "input" + os.path.splitext(input_path)[1]
+                ),
+                output_dir=os.path.join(work_dir, "output"),
+            )
+            os.makedirs(job.output_dir)
+            os.makedirs(os.path.join(work_dir, "tmp"))
+            shutil.copyfile(input_path, job.input_path)
+
+            cmd = build_cmd(job)
+            if self._prlimit:
+                cmd = [self._prlimit, f"--fsize={self.max_output_size}", "--"] + cmd
+            logger.info(f"Running {name} in sandbox {work_dir}")
+            process = subprocess.Popen(
+                cmd,
+                stdout=subprocess.PIPE,
+                stderr=subprocess.PIPE,
+                cwd=work_dir,
+                env=self._env(work_dir),
+                start_new_session=True,
+            )
+            try:
+                _, stderr = process.communicate(timeout=self.timeout)
+            except subprocess.TimeoutExpired:
+                self._kill(process)
+                raise ConversionTimeout(f"{name} killed after {self.timeout} seconds")
+
+            if process.returncode != 0:
+                raise ConversionError(
+                    f"{name} exited with {process.returncode}: "
+                    f"{stderr.decode('utf-8', errors='ignore')[-500:]}"
+                )
+            return self._read_output(name, job.output_dir, output_ext)
+
+    def _read_output(self, name: str, output_dir: str, output_ext: str) -> bytes:
+        outputs = [
+            f for f in os.listdir(output_dir) if f.lower().endswith(output_ext)
+        ]
+        if not outputs:
+            raise ConversionError(f"{name} produced no {output_ext} file")
+        path = os.path.join(output_dir, outputs[0])
+        size = os.path.getsize(path)
+        if size == 0:
+            raise ConversionError(f"{name} produced an empty {output_ext} file")
+        if size > self.max_output_size:
+            raise ConversionError(
+                f"{name} output of {size} bytes exceeds {self.max_output_size}"
+            )
+        with open(path, "rb") as f:
+            return f.read()
+
+    def _env(self, work_dir: str) -> Dict[str, str]:
+        env = os.environ.copy()
+        env["HOME"] = work_dir
+        env["TMPDIR"] = os.path.join(work_dir, "tmp")
+        for key in ("http_proxy", "https_proxy", "HTTP_PROXY", "HTTPS_PROXY"):
+            env[key] = self.proxy
+        return env
+
+    @staticmethod
+    def _kill(process: subprocess.Popen) -> None:
+        """Kill the process group, converters start helper processes"""
+        try:
+            os.killpg(process.pid, signal.SIGKILL)
+        except (ProcessLookupError, PermissionError):
+            process.kill()
+        process.communicate()
+
+
+# Shared by all parsers, so the limits hold across concurrent requests
+CONVERSION_POOL = ConversionPool(
+    max_workers=CONFIG.convert_max_workers,
+    max_queue=CONFIG.convert_max_queue,
+    queue_timeout=CONFIG.convert_queue_timeout,
+    timeout=CONFIG.convert_timeout,
+    max_output_size=CONFIG.convert_max_output_mb * 1024 * 1024,
+)
@@ -0,0 +1,53 @@
+import contextlib
+import threading
+from contextvars import ContextVar
+from dataclasses import dataclass
+from typing import Dict, Optional
+
+# Stages reported while a file is read
+STAGE_CONVERTED = "converted"
+STAGE_PARSING = "parsing"
+
+_progress_id_var = ContextVar("progress_id", default=None)
+_lock = threading.Lock()
+_progress: Dict[str, "ReadProgress"] = {}
+
+
+@dataclass(frozen=True)
+class ReadProgress:
+    """Progress of a file read, current and total count pages, 0 when not applicable"""
+
+    stage: str
+    current: int = 0
+    total: int = 0
+
+
+@contextlib.contextmanager
+def progress_context(progress_id: str):
+    """Context manager recording the progress reported in it under progress_id,
+    the progress is dropped when the context ends. An empty ID records nothing."""
+    if not progress_id:
+        yield
+        return
+    token = _progress_id_var.set(progress_id)
+    try:
+        yield
+    finally:
+        _progress_id_var.reset(token)
+        with _lock:
+            _progress.pop(progress_id, None)
+
+
+def report_progress(stage: str, current: int = 0, total: int = 0) -> None:
+    """Record the progress of the read running in the current context"""
+    progress_id = _progress_id_var.get()
+    if not progress_id:
+        return
+    with _lock:
+        _progress[progress_id] = ReadProgress(stage, current, total)
+
+
+def get_progress(progress_id: str) -> Optional[ReadProgress]:
+    """Last progress reported for progress_id, None if there is none"""
+    with _lock:
+        return _progress.get(progress_id)
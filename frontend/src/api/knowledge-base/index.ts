@@ -52,7 +52,9 @@ export function uploadKnowledgeFile(kbId: string, data: { file: File; tag_id?: s
   Object.keys(data).forEach(key => {
     if (data[key] !== undefined) formData.append(key, data[key]);
   });
-  return postUpload(`/api/v1/knowledge-bases/${kbId}/knowledge/file`, formData, onProgress);
+  // 印象笔记导出文件按笔记拆分为多条知识
+  const endpoint = data.file.name.toLowerCase().endsWith('.enex') ? 'enex' : 'file';
+  return postUpload(`/api/v1/knowledge-bases/${kbId}/knowledge/${endpoint}`, formData, onProgress);
 }
 
 // 从URL创建知识
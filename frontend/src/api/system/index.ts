@@ -9,6 +9,7 @@ export interface SystemInfo {
   vector_store_engine?: string
   graph_database_engine?: string
   minio_enabled?: boolean
+  parser_plugin_file_types?: string[]
 }
 
 export interface ToolDefinition {
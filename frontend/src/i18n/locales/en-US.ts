@@ -1489,6 +1489,11 @@ export default {
     deepThoughtAlt: 'Deep thinking finished',
     referencesTitle: 'Referenced {count} related item(s)',
     referenceIconAlt: 'Reference materials icon',
+    referencePage: 'p. {page}',
+    referencePages: 'pp. {start}-{end}',
+    referenceLine: 'line {line}',
+    referenceLines: 'lines {start}-{end}',
+    referenceOpenSection: 'Open the section in the original page',
     chunkIdLabel: 'Chunk ID:',
     documentIdLabel: 'Document ID:',
     noPlanSteps: 'No detailed steps provided',
@@ -1515,6 +1520,7 @@ export default {
     errorMessageLabel: 'Error message',
     summaryLabel: 'Summary',
     rawTextLabel: 'Raw text',
+    extractionQualityLabel: 'Extraction quality {score}/100, the content may be incomplete, try a screenshot with OCR',
     collapseRaw: 'Collapse original',
     expandRaw: 'Expand original',
     noWebContent: 'No web content fetched',
@@ -234,6 +234,11 @@ export default {
     deepThoughtAlt: "심층 분석 완료",
     referencesTitle: "{count}개의 관련 내용 참조",
     referenceIconAlt: "참조 내용 아이콘",
+    referencePage: "{page}쪽",
+    referencePages: "{start}-{end}쪽",
+    referenceLine: "{line}행",
+    referenceLines: "{start}-{end}행",
+    referenceOpenSection: "원문의 해당 섹션 열기",
     chunkIdLabel: "청크 ID:",
     documentIdLabel: "문서 ID:",
     noPlanSteps: "구체적인 단계가 제공되지 않았습니다",
@@ -260,6 +265,7 @@ export default {
     errorMessageLabel: "오류 메시지",
     summaryLabel: "요약",
     rawTextLabel: "원본 텍스트",
+    extractionQualityLabel: "추출 품질 {score}/100, 내용이 불완전할 수 있습니다. 스크린샷 OCR을 사용해 보세요",
     collapseRaw: "원문 접기",
     expandRaw: "원문 펼치기",
     noWebContent: "웹 콘텐츠를 가져올 수 없습니다",
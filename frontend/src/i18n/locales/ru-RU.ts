@@ -1319,6 +1319,11 @@ export default {
     summaryInProgress: 'Идёт подготовка ответа…',
     referencesTitle: 'Использовано {count} связанного материала',
     referenceIconAlt: 'Иконка ссылок на материалы',
+    referencePage: 'с. {page}',
+    referencePages: 'с. {start}–{end}',
+    referenceLine: 'строка {line}',
+    referenceLines: 'строки {start}–{end}',
+    referenceOpenSection: 'Открыть раздел на исходной странице',
     chunkIdLabel: 'ID фрагмента:',
     documentIdLabel: 'ID документа:',
     noPlanSteps: 'Подробные шаги не предоставлены',
@@ -1345,6 +1350,7 @@ export default {
     errorMessageLabel: 'Сообщение об ошибке',
     summaryLabel: 'Сводка',
     rawTextLabel: 'Исходный текст',
+    extractionQualityLabel: 'Качество извлечения {score}/100, содержимое может быть неполным, попробуйте OCR снимка экрана',
     collapseRaw: 'Свернуть оригинал',
     expandRaw: 'Развернуть оригинал',
     noWebContent: 'Содержимое страницы не получено',
@@ -258,6 +258,11 @@ export default {
     deepThoughtAlt: "深度思考完成",
     referencesTitle: "参考了{count}个相关内容",
     referenceIconAlt: "参考内容图标",
+    referencePage: "第 {page} 页",
+    referencePages: "第 {start}-{end} 页",
+    referenceLine: "第 {line} 行",
+    referenceLines: "第 {start}-{end} 行",
+    referenceOpenSection: "打开原文对应章节",
     chunkIdLabel: "片段ID:",
     documentIdLabel: "文档ID:",
     noPlanSteps: "未提供具体步骤",
@@ -284,6 +289,7 @@ export default {
     errorMessageLabel: "错误信息",
     summaryLabel: "总结",
     rawTextLabel: "原始文本",
+    extractionQualityLabel: "抽取质量 {score}/100，内容可能不完整，可尝试截图识别",
     collapseRaw: "收起原文",
     expandRaw: "展开原文",
     noWebContent: "未获取到网页内容",
@@ -183,6 +183,8 @@ export interface WebFetchResultItem {
     raw_content?: string;
     content_length?: number;
     method?: string;
+    quality_score?: number;
+    quality_warnings?: string[];
     error?: string;
 }
 
@@ -1,4 +1,5 @@
 import { MessagePlugin } from "tdesign-vue-next";
+import { computed, ref } from "vue";
 
 // 声明全局运行时配置类型
 declare global {
@@ -39,8 +40,15 @@ export function formatStringDate(date: any) {
     year + "-" + month + "-" + day + " " + hour + ":" + minute + ":" + second
   );
 }
+// Source code file types, split at function and class boundaries when parsed
+export const codeFileTypes = ["go", "py", "js", "jsx", "ts", "tsx", "java", "kt", "scala", "c", "h", "cpp", "cc", "hpp", "cs", "rb", "php", "rs", "swift", "sh", "sql"];
+// File types parsed by DocReader parser plugins, loaded from the system info
+export const parserPluginFileTypes = ref<string[]>([]);
+const kbFileTypes = computed(() => ["pdf", "txt", "md", "docx", "doc", "jpg", "jpeg", "png", "csv", "xlsx", "xls", "ipynb", "log", "enex", "mht", "mhtml", "tex", "latex", "zip", ...codeFileTypes, ...parserPluginFileTypes.value]);
+// accept attribute of the knowledge file inputs
+export const kbFileAccept = computed(() => kbFileTypes.value.map((type) => "." + type).join(","));
 export function kbFileTypeVerification(file: any, silent = false) {
-  let validTypes = ["pdf", "txt", "md", "docx", "doc", "jpg", "jpeg", "png", "csv", "xlsx", "xls"];
+  let validTypes = kbFileTypes.value;
   let type = file.name.substring(file.name.lastIndexOf(".") + 1);
   if (!validTypes.includes(type)) {
     if (!silent) {
@@ -57,9 +65,15 @@ export function kbFileTypeVerification(file: any, silent = false) {
     }
     return true;
   }
-  if ((type == "txt" || type == "md") && file.size > MAX_FILE_SIZE_BYTES) {
+  if ((type == "txt" || type == "md" || type == "log") && file.size > MAX_FILE_SIZE_BYTES) {
     if (!silent) {
-      MessagePlugin.error(`txt/md文件不能超过${MAX_FILE_SIZE_MB}M！`);
+      MessagePlugin.error(`txt/md/log文件不能超过${MAX_FILE_SIZE_MB}M！`);
+    }
+    return true;
+  }
+  if (codeFileTypes.includes(type) && file.size > MAX_FILE_SIZE_BYTES) {
+    if (!silent) {
+      MessagePlugin.error(`代码文件不能超过${MAX_FILE_SIZE_MB}M！`);
     }
     return true;
   }
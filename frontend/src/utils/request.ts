@@ -41,6 +41,8 @@ instance.interceptors.request.use(
       }
     }
     
+    // 按界面语言返回本地化的提示消息
+    config.headers["Accept-Language"] = localStorage.getItem('locale') || 'zh-CN';
     config.headers["X-Request-ID"] = `${generateRandomString(12)}`;
     return config;
   },
@@ -0,0 +1,348 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// maxCalculatorExpression caps the length of an expression accepted by the calculator
+const maxCalculatorExpression = 1000
+
+var calculatorTool = BaseTool{
+	name: ToolCalculator,
+	description: `Evaluate an arithmetic expression exactly instead of computing it in your head.
+
+## Usage
+- Operators: + - * / % ^ (power) and parentheses
+- Functions: sqrt, abs, round, floor, ceil, ln, log10, log2, exp, sin, cos, tan, pow(x, y), min(...), max(...)
+- Constants: pi, e
+- Percentages must be written as division, e.g. 15% of 80 is 80 * 15 / 100 (% is the modulo operator)
+- Write numbers without thousands separators
+
+## When to Use
+- Any calculation on numbers taken from retrieved documents or web results (sums, ratios, growth rates, unit conversions)
+- Always use it before stating a computed number in the answer`,
+	schema: utils.GenerateSchema[CalculatorInput](),
+}
+
+// CalculatorInput defines the input parameters for the calculator tool
+type CalculatorInput struct {
+	Expression string `json:"expression" jsonschema:"要计算的算术表达式，例如 (1200 - 950) / 950 * 100"`
+}
+
+// CalculatorTool evaluates arithmetic expressions
+type CalculatorTool struct {
+	BaseTool
+}
+
+// NewCalculatorTool creates a new calculator tool instance
+func NewCalculatorTool() *CalculatorTool {
+	return &CalculatorTool{BaseTool: calculatorTool}
+}
+
+// Execute evaluates the expression
+func (t *CalculatorTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input CalculatorInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		logger.Errorf(ctx, "[Tool][Calculator] Failed to parse args: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, nil
+	}
+	expression := strings.TrimSpace(input.Expression)
+	if expression == "" {
+		return &types.ToolResult{Success: false, Error: "expression is required"}, nil
+	}
+	if len(expression) > maxCalculatorExpression {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("expression is longer than %d characters", maxCalculatorExpression),
+		}, nil
+	}
+
+	value, err := evaluateExpression(expression)
+	if err != nil {
+		return &types.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+	result := strconv.FormatFloat(value, 'g', 15, 64)
+	return &types.ToolResult{
+		Success: true,
+		Output:  fmt.Sprintf("%s = %s", expression, result),
+		Data: map[string]interface{}{
+			"expression": expression,
+			"result":     value,
+		},
+	}, nil
+}
+
+// evaluateExpression evaluates an arithmetic expression with the operators, functions and
+// constants supported by the calculator tool
+func evaluateExpression(expression string) (float64, error) {
+	p := &exprParser{input: []rune(expression)}
+	value, err := p.parseSum()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos+1)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("result is not a finite number")
+	}
+	return value, nil
+}
+
+// exprParser is a recursive descent parser over sum, product, power and unary levels
+type exprParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// consume advances past r when it is the next non-space rune
+func (p *exprParser) consume(r rune) bool {
+	p.skipSpaces()
+	if p.pos < len(p.input) && p.input[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseSum() (float64, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.consume('+'):
+			right, err := p.parseProduct()
+			if err != nil {
+				return 0, err
+			}
+			left += right
+		case p.consume('-'):
+			right, err := p.parseProduct()
+			if err != nil {
+				return 0, err
+			}
+			left -= right
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *exprParser) parseProduct() (float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.consume('*'):
+			right, err := p.parseUnary()
+			if err != nil {
+				return 0, err
+			}
+			left *= right
+		case p.consume('/'):
+			right, err := p.parseUnary()
+			if err != nil {
+				return 0, err
+			}
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		case p.consume('%'):
+			right, err := p.parseUnary()
+			if err != nil {
+				return 0, err
+			}
+			if right == 0 {
+				return 0, fmt.Errorf("modulo by zero")
+			}
+			left = math.Mod(left, right)
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *exprParser) parseUnary() (float64, error) {
+	switch {
+	case p.consume('-'):
+		value, err := p.parseUnary()
+		return -value, err
+	case p.consume('+'):
+		return p.parseUnary()
+	}
+	return p.parsePower()
+}
+
+// parsePower parses right-associative exponentiation, binding tighter than unary minus on its left
+func (p *exprParser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+	if p.consume('^') {
+		exponent, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(base, exponent), nil
+	}
+	return base, nil
+}
+
+func (p *exprParser) parsePrimary() (float64, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	r := p.input[p.pos]
+	switch {
+	case r == '(':
+		p.pos++
+		value, err := p.parseSum()
+		if err != nil {
+			return 0, err
+		}
+		if !p.consume(')') {
+			return 0, fmt.Errorf("missing closing parenthesis")
+		}
+		return value, nil
+	case unicode.IsDigit(r) || r == '.':
+		return p.parseNumber()
+	case unicode.IsLetter(r):
+		return p.parseIdentifier()
+	}
+	return 0, fmt.Errorf("unexpected %q at position %d", r, p.pos+1)
+}
+
+func (p *exprParser) parseNumber() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	// Scientific notation, e.g. 1.5e-3
+	if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+		next := p.pos + 1
+		if next < len(p.input) && (p.input[next] == '+' || p.input[next] == '-') {
+			next++
+		}
+		if next < len(p.input) && unicode.IsDigit(p.input[next]) {
+			p.pos = next
+			for p.pos < len(p.input) && unicode.IsDigit(p.input[p.pos]) {
+				p.pos++
+			}
+		}
+	}
+	value, err := strconv.ParseFloat(string(p.input[start:p.pos]), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", string(p.input[start:p.pos]))
+	}
+	return value, nil
+}
+
+func (p *exprParser) parseIdentifier() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsLetter(p.input[p.pos]) || unicode.IsDigit(p.input[p.pos])) {
+		p.pos++
+	}
+	name := strings.ToLower(string(p.input[start:p.pos]))
+	switch name {
+	case "pi":
+		return math.Pi, nil
+	case "e":
+		return math.E, nil
+	}
+
+	if !p.consume('(') {
+		return 0, fmt.Errorf("unknown constant %q", name)
+	}
+	var args []float64
+	if !p.consume(')') {
+		for {
+			value, err := p.parseSum()
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, value)
+			if p.consume(')') {
+				break
+			}
+			if !p.consume(',') {
+				return 0, fmt.Errorf("missing closing parenthesis after %s arguments", name)
+			}
+		}
+	}
+	return callFunction(name, args)
+}
+
+// calculatorFunctions are the single-argument functions supported by the calculator
+var calculatorFunctions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"round": math.Round,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"ln":    math.Log,
+	"log":   math.Log10,
+	"log10": math.Log10,
+	"log2":  math.Log2,
+	"exp":   math.Exp,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+}
+
+func callFunction(name string, args []float64) (float64, error) {
+	switch name {
+	case "min", "max":
+		if len(args) == 0 {
+			return 0, fmt.Errorf("%s needs at least one argument", name)
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			if name == "min" {
+				result = math.Min(result, arg)
+			} else {
+				result = math.Max(result, arg)
+			}
+		}
+		return result, nil
+	case "pow":
+		if len(args) != 2 {
+			return 0, fmt.Errorf("pow needs two arguments")
+		}
+		return math.Pow(args[0], args[1]), nil
+	}
+	fn, ok := calculatorFunctions[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown function %q", name)
+	}
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%s needs one argument", name)
+	}
+	return fn(args[0]), nil
+}
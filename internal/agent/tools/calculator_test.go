@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1200 - 950) / 950 * 100", 26.315789473684212},
+		{"-2^2", -4},
+		{"2^3^2", 512},
+		{"2^-1", 0.5},
+		{"10 % 4", 2},
+		{"sqrt(16) + abs(-3)", 7},
+		{"max(1, 5, 3) - min(4, 2)", 3},
+		{"pow(2, 10)", 1024},
+		{"round(pi * 100) / 100", 3.14},
+		{"1.5e3 + .5", 1500.5},
+	}
+	for _, tt := range tests {
+		got, err := evaluateExpression(tt.expression)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.expression, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+
+	for _, expression := range []string{"1 / 0", "2 +", "(1 + 2", "foo(1)", "x", "1 2", "sqrt(1, 2)", "os.Exit(1)"} {
+		if _, err := evaluateExpression(expression); err == nil {
+			t.Errorf("%s: expected an error", expression)
+		}
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, location)
+	end := time.Date(2026, 4, 1, 0, 0, 0, 0, location)
+	if days := daysBetween(start, end); days != 31 {
+		t.Errorf("got %d days across daylight saving, want 31", days)
+	}
+	if days := daysBetween(end, start); days != -31 {
+		t.Errorf("got %d days backwards, want -31", days)
+	}
+}
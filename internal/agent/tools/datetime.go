@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// defaultDateTimeZone is used when the model does not name a timezone
+const defaultDateTimeZone = "Asia/Shanghai"
+
+var dateTimeTool = BaseTool{
+	name: ToolDateTime,
+	description: `Get the current date and time, or do date arithmetic.
+
+## Operations
+- now: current date, time and weekday in the given timezone
+- add: the date that is "days" days after "date" (negative days go back)
+- diff: the number of days from "date" to "end_date"
+
+## When to Use
+- Questions about today, deadlines, ages, durations or "how long ago"
+- Never guess the current date; your training data is out of date`,
+	schema: utils.GenerateSchema[DateTimeInput](),
+}
+
+// DateTimeInput defines the input parameters for the datetime tool
+type DateTimeInput struct {
+	Operation string `json:"operation" jsonschema:"操作：now、add 或 diff，默认 now"`
+	Timezone  string `json:"timezone,omitempty" jsonschema:"IANA 时区，例如 Asia/Shanghai，默认 Asia/Shanghai"`
+	Date      string `json:"date,omitempty" jsonschema:"add 和 diff 的起始日期，格式 YYYY-MM-DD，默认今天"`
+	EndDate   string `json:"end_date,omitempty" jsonschema:"diff 的结束日期，格式 YYYY-MM-DD"`
+	Days      int    `json:"days,omitempty" jsonschema:"add 增加的天数，可为负数"`
+}
+
+// DateTimeTool answers questions about the current date and date arithmetic
+type DateTimeTool struct {
+	BaseTool
+	now func() time.Time
+}
+
+// NewDateTimeTool creates a new datetime tool instance
+func NewDateTimeTool() *DateTimeTool {
+	return &DateTimeTool{BaseTool: dateTimeTool, now: time.Now}
+}
+
+// Execute runs the requested date operation
+func (t *DateTimeTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input DateTimeInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		logger.Errorf(ctx, "[Tool][DateTime] Failed to parse args: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, nil
+	}
+	timezone := strings.TrimSpace(input.Timezone)
+	if timezone == "" {
+		timezone = defaultDateTimeZone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return &types.ToolResult{Success: false, Error: fmt.Sprintf("unknown timezone %q", timezone)}, nil
+	}
+	now := t.now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	parseDate := func(value string) (time.Time, error) {
+		if strings.TrimSpace(value) == "" {
+			return today, nil
+		}
+		date, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(value), location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+		}
+		return date, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(input.Operation)) {
+	case "", "now":
+		return &types.ToolResult{
+			Success: true,
+			Output: fmt.Sprintf("Current time (%s): %s, %s", timezone,
+				now.Format("2006-01-02 15:04:05 -07:00"), now.Weekday()),
+			Data: map[string]interface{}{
+				"timezone": timezone,
+				"datetime": now.Format(time.RFC3339),
+				"date":     now.Format(time.DateOnly),
+				"weekday":  now.Weekday().String(),
+			},
+		}, nil
+	case "add":
+		start, err := parseDate(input.Date)
+		if err != nil {
+			return &types.ToolResult{Success: false, Error: err.Error()}, nil
+		}
+		result := start.AddDate(0, 0, input.Days)
+		return &types.ToolResult{
+			Success: true,
+			Output: fmt.Sprintf("%s %+d days = %s, %s", start.Format(time.DateOnly), input.Days,
+				result.Format(time.DateOnly), result.Weekday()),
+			Data: map[string]interface{}{
+				"date":    result.Format(time.DateOnly),
+				"weekday": result.Weekday().String(),
+			},
+		}, nil
+	case "diff":
+		start, err := parseDate(input.Date)
+		if err != nil {
+			return &types.ToolResult{Success: false, Error: err.Error()}, nil
+		}
+		if strings.TrimSpace(input.EndDate) == "" {
+			return &types.ToolResult{Success: false, Error: "end_date is required for diff"}, nil
+		}
+		end, err := parseDate(input.EndDate)
+		if err != nil {
+			return &types.ToolResult{Success: false, Error: err.Error()}, nil
+		}
+		days := daysBetween(start, end)
+		return &types.ToolResult{
+			Success: true,
+			Output: fmt.Sprintf("From %s to %s: %d days", start.Format(time.DateOnly),
+				end.Format(time.DateOnly), days),
+			Data: map[string]interface{}{"days": days},
+		}, nil
+	}
+	return &types.ToolResult{
+		Success: false,
+		Error:   fmt.Sprintf("unknown operation %q, expected now, add or diff", input.Operation),
+	}, nil
+}
+
+// daysBetween returns the calendar days from start to end, ignoring daylight saving shifts
+func daysBetween(start, end time.Time) int {
+	startUTC := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	endUTC := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return int(endUTC.Sub(startUTC).Hours() / 24)
+}
@@ -14,6 +14,8 @@ const (
 	ToolDataSchema          = "data_schema"
 	ToolWebSearch           = "web_search"
 	ToolWebFetch            = "web_fetch"
+	ToolCalculator          = "calculator"
+	ToolDateTime            = "datetime"
 	// Skills-related tools (only available when skills are enabled)
 	ToolExecuteSkillScript = "execute_skill_script"
 	ToolReadSkill          = "read_skill"
@@ -40,6 +42,9 @@ func AvailableToolDefinitions() []AvailableTool {
 		{Name: ToolDatabaseQuery, Label: "查询数据库", Description: "查询数据库中的信息"},
 		{Name: ToolDataAnalysis, Label: "数据分析", Description: "理解数据文件并进行数据分析"},
 		{Name: ToolDataSchema, Label: "查看数据元信息", Description: "获取表格文件的元信息"},
+		{Name: ToolWebFetch, Label: "网页读取", Description: "抓取网页内容并按需分析，开启网络搜索时自动启用"},
+		{Name: ToolCalculator, Label: "计算器", Description: "精确计算算术表达式"},
+		{Name: ToolDateTime, Label: "日期时间", Description: "获取当前日期时间并进行日期推算"},
 		{Name: ToolReadSkill, Label: "读取技能", Description: "按需读取技能内容以学习专业能力"},
 		{Name: ToolExecuteSkillScript, Label: "执行技能脚本", Description: "在沙箱环境中执行技能脚本"},
 	}
@@ -58,5 +63,16 @@ func DefaultAllowedTools() []string {
 		ToolDatabaseQuery,
 		ToolDataAnalysis,
 		ToolDataSchema,
+		ToolCalculator,
+		ToolDateTime,
 	}
 }
+
+// TenantConfigurableTools returns the tools a tenant can disable, including the web tools
+// that are enabled through web search rather than the allowed tools list.
+func TenantConfigurableTools() []AvailableTool {
+	available := AvailableToolDefinitions()
+	return append(available,
+		AvailableTool{Name: ToolWebSearch, Label: "网络搜索", Description: "搜索互联网获取实时信息"},
+	)
+}
@@ -136,6 +136,14 @@ func (t *GetDocumentInfoTool) Execute(ctx context.Context, args json.RawMessage)
 				mu.Unlock()
 				return
 			}
+			if t.searchTargets.IsHidden(knowledge.KnowledgeBaseID, knowledge.ID) {
+				mu.Lock()
+				results[id] = &docInfo{
+					err: fmt.Errorf("文档 %s 不可访问", id),
+				}
+				mu.Unlock()
+				return
+			}
 
 			// Use knowledge's actual tenant_id for chunk query (supports cross-tenant shared KB)
 			_, total, err := t.chunkService.GetRepository().
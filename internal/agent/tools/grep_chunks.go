@@ -314,6 +314,15 @@ func (t *GrepChunksTool) searchChunks(
 		}
 	}
 
+	// Exclude documents a document permission hides from the user
+	var hiddenKnowledgeIDs []string
+	for _, target := range t.searchTargets {
+		hiddenKnowledgeIDs = append(hiddenKnowledgeIDs, target.HiddenKnowledgeIDs...)
+	}
+	if len(hiddenKnowledgeIDs) > 0 {
+		query = query.Where("chunks.knowledge_id NOT IN ?", hiddenKnowledgeIDs)
+	}
+
 	// Apply pattern matching (case-insensitive fixed string matching, OR logic for multiple patterns)
 	if len(patterns) == 1 {
 		query = query.Where("chunks.content ILIKE ?", "%"+patterns[0]+"%")
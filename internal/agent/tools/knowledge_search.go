@@ -242,14 +242,17 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args json.RawMessage)
 	}
 
 	// Fallback to global config if not set
-	if topK == 0 && t.config != nil {
-		topK = t.config.Conversation.EmbeddingTopK
-	}
-	if vectorThreshold == 0 && t.config != nil {
-		vectorThreshold = t.config.Conversation.VectorThreshold
-	}
-	if keywordThreshold == 0 && t.config != nil {
-		keywordThreshold = t.config.Conversation.KeywordThreshold
+	if t.config != nil {
+		conversation := t.config.Current().Conversation
+		if topK == 0 {
+			topK = conversation.EmbeddingTopK
+		}
+		if vectorThreshold == 0 {
+			vectorThreshold = conversation.VectorThreshold
+		}
+		if keywordThreshold == 0 {
+			keywordThreshold = conversation.KeywordThreshold
+		}
 	}
 
 	// Final fallback to hardcoded defaults if config is not available
@@ -1084,6 +1087,17 @@ func (t *KnowledgeSearchTool) formatOutput(
 			result.ChunkIndex,
 			result.Content,
 		)
+		// Where the chunk is in its document, so answers can point to it
+		location := ""
+		if faqMeta == nil && len(result.ChunkMetadata) > 0 {
+			var docMeta types.DocumentChunkMetadata
+			if err := json.Unmarshal(result.ChunkMetadata, &docMeta); err == nil {
+				location = docMeta.SourceLocation()
+			}
+		}
+		if location != "" {
+			output += fmt.Sprintf("  Location: %s\n", location)
+		}
 
 		// 解析并输出关联的图片信息
 		if result.ImageInfo != "" {
@@ -1135,6 +1149,9 @@ func (t *KnowledgeSearchTool) formatOutput(
 		})
 
 		last := formattedResults[len(formattedResults)-1]
+		if location != "" {
+			last["location"] = location
+		}
 
 		// 添加图片信息到结构化数据
 		if result.ImageInfo != "" {
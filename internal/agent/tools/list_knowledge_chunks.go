@@ -114,13 +114,19 @@ func (t *ListKnowledgeChunksTool) Execute(ctx context.Context, args json.RawMess
 		}, err
 	}
 
-	// Verify the knowledge's KB is in searchTargets (permission check)
+	// Verify the knowledge's KB is in searchTargets and the document is not hidden (permission check)
 	if !t.searchTargets.ContainsKB(knowledge.KnowledgeBaseID) {
 		return &types.ToolResult{
 			Success: false,
 			Error:   fmt.Sprintf("Knowledge base %s is not accessible", knowledge.KnowledgeBaseID),
 		}, fmt.Errorf("knowledge base not in search targets")
 	}
+	if t.searchTargets.IsHidden(knowledge.KnowledgeBaseID, knowledge.ID) {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Knowledge %s is not accessible", knowledge.ID),
+		}, fmt.Errorf("knowledge hidden by document permission")
+	}
 
 	// Use the knowledge's actual tenant_id for chunk query (supports cross-tenant shared KB)
 	effectiveTenantID := knowledge.TenantID
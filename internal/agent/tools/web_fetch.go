@@ -4,27 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/slowlog"
+	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/utils"
 )
 
 const (
-	webFetchTimeout  = 60 * time.Second // timeout for web fetch
-	webFetchMaxChars = 100000           // maximum number of characters to fetch
+	webFetchTimeout      = 60 * time.Second // timeout for web fetch
+	webFetchMaxChars     = 100000           // default maximum number of Markdown characters kept from a page
+	webFetchMaxHTMLBytes = 5 << 20          // default maximum number of HTML bytes read from a page
 )
 
 var webFetchTool = BaseTool{
@@ -82,10 +87,11 @@ type WebFetchTool struct {
 	BaseTool
 	client    *http.Client
 	chatModel chat.Chat
+	config    *config.Config // Global config for the page size caps
 }
 
 // NewWebFetchTool creates a new web_fetch tool instance
-func NewWebFetchTool(chatModel chat.Chat) *WebFetchTool {
+func NewWebFetchTool(chatModel chat.Chat, cfg *config.Config) *WebFetchTool {
 	// Use SSRF-safe HTTP client to prevent redirect-based SSRF attacks
 	ssrfConfig := utils.DefaultSSRFSafeHTTPClientConfig()
 	ssrfConfig.Timeout = webFetchTimeout
@@ -94,9 +100,27 @@ func NewWebFetchTool(chatModel chat.Chat) *WebFetchTool {
 		BaseTool:  webFetchTool,
 		client:    utils.NewSSRFSafeHTTPClient(ssrfConfig),
 		chatModel: chatModel,
+		config:    cfg,
 	}
 }
 
+// limits returns the most HTML bytes read from a page and the most Markdown characters kept from it
+func (t *WebFetchTool) limits() (int64, int) {
+	maxHTMLBytes, maxChars := int64(webFetchMaxHTMLBytes), webFetchMaxChars
+	if t.config == nil {
+		return maxHTMLBytes, maxChars
+	}
+	if webFetch := t.config.Current().WebFetch; webFetch != nil {
+		if webFetch.MaxHTMLBytes > 0 {
+			maxHTMLBytes = webFetch.MaxHTMLBytes
+		}
+		if webFetch.MaxMarkdownChars > 0 {
+			maxChars = webFetch.MaxMarkdownChars
+		}
+	}
+	return maxHTMLBytes, maxChars
+}
+
 // Execute 执行 web_fetch 工具
 func (t *WebFetchTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
 	logger.Infof(ctx, "[Tool][WebFetch] Execute started")
@@ -314,7 +338,7 @@ func (t *WebFetchTool) executeFetch(
 ) (string, map[string]interface{}, error) {
 	logger.Infof(ctx, "[Tool][WebFetch] Fetching URL: %s", displayURL)
 
-	htmlContent, method, err := t.fetchHTMLContent(ctx, vp)
+	page, method, err := t.fetchPageMarkdown(ctx, vp)
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][WebFetch] 获取页面失败 url=%s err=%v", vp.URL, err)
 		return fmt.Sprintf("URL: %s\n错误: %v\n", displayURL, err),
@@ -325,14 +349,30 @@ func (t *WebFetchTool) executeFetch(
 			}, err
 	}
 
-	textContent := t.convertHTMLToText(htmlContent)
+	textContent := page.Content
+	quality := assessPageQuality(page)
 
 	resultData := map[string]interface{}{
-		"url":            displayURL,
-		"prompt":         vp.Prompt,
-		"raw_content":    textContent,
-		"content_length": len(textContent),
-		"method":         method,
+		"url":              displayURL,
+		"prompt":           vp.Prompt,
+		"raw_content":      textContent,
+		"content_length":   len(textContent),
+		"method":           method,
+		"sections":         page.Sections,
+		"html_bytes":       page.HTMLBytes,
+		"truncated":        page.Truncated(),
+		"quality_score":    quality.Score,
+		"quality_warnings": quality.Warnings,
+	}
+	if len(quality.Warnings) > 0 {
+		logger.Infof(ctx, "[Tool][WebFetch] 页面抽取质量较低 url=%s score=%d text_chars=%d text_ratio=%.2f "+
+			"headings=%d repeated_ratio=%.2f", displayURL, quality.Score, quality.TextChars, quality.TextRatio,
+			quality.Headings, quality.RepeatedRatio)
+	}
+	if page.Truncated() {
+		resultData["truncated_by"] = page.TruncatedBy
+		logger.Infof(ctx, "[Tool][WebFetch] 页面内容已截断 url=%s reason=%s html_bytes=%d",
+			displayURL, page.TruncatedBy, page.HTMLBytes)
 	}
 	params := webFetchParams{URL: displayURL, Prompt: vp.Prompt}
 	var summary string
@@ -344,7 +384,7 @@ func (t *WebFetchTool) executeFetch(
 		resultData["summary"] = summary
 	}
 
-	output := t.buildOutputText(params, textContent, summary, summaryErr)
+	output := t.buildOutputText(params, page, quality, summary, summaryErr)
 
 	return output, resultData, summaryErr
 }
@@ -394,10 +434,24 @@ func (t *WebFetchTool) processWithLLM(ctx context.Context, params webFetchParams
 }
 
 // buildOutputText builds the output text for a web fetch item
-func (t *WebFetchTool) buildOutputText(params webFetchParams, content string, summary string, summaryErr error) string {
+func (t *WebFetchTool) buildOutputText(
+	params webFetchParams,
+	page *pageMarkdown,
+	quality *pageQuality,
+	summary string,
+	summaryErr error,
+) string {
 	var builder strings.Builder
 	builder.WriteString(fmt.Sprintf("URL: %s\n", params.URL))
 	builder.WriteString(fmt.Sprintf("Prompt: %s\n", params.Prompt))
+	if page.Truncated() {
+		builder.WriteString(fmt.Sprintf("Note: page too large, only the first %d sections were read (%s limit)\n",
+			page.Sections, page.TruncatedBy))
+	}
+	if len(quality.Warnings) > 0 {
+		builder.WriteString(fmt.Sprintf("Note: extraction quality %d/100, %s\n",
+			quality.Score, strings.Join(quality.Warnings, "; ")))
+	}
 
 	if summaryErr == nil && summary != "" {
 		builder.WriteString("Summary:\n")
@@ -405,38 +459,70 @@ func (t *WebFetchTool) buildOutputText(params webFetchParams, content string, su
 		builder.WriteString("\n")
 	} else {
 		builder.WriteString("Content Preview:\n")
-		builder.WriteString(content)
+		builder.WriteString(page.Content)
 		builder.WriteString("\n")
 	}
 
 	return builder.String()
 }
 
-// fetchHTMLContent fetches the HTML content for a web fetch item using pinned IP (DNS pinning).
-func (t *WebFetchTool) fetchHTMLContent(ctx context.Context, vp *validatedParams) (string, string, error) {
-	html, err := t.fetchWithChromedp(ctx, vp)
-	if err == nil && strings.TrimSpace(html) != "" {
-		return html, "chromedp", nil
+// fetchPageMarkdown fetches a page and converts it to Markdown using pinned IP (DNS pinning).
+func (t *WebFetchTool) fetchPageMarkdown(ctx context.Context, vp *validatedParams) (*pageMarkdown, string, error) {
+	maxHTMLBytes, maxChars := t.limits()
+
+	html, cut, err := t.fetchWithChromedp(ctx, vp, maxHTMLBytes)
+	if err == nil {
+		page := htmlToMarkdown(strings.NewReader(html), maxHTMLBytes, maxChars)
+		if cut && !page.Truncated() {
+			page.TruncatedBy = truncatedByHTMLSize
+		}
+		if page.Content != "" {
+			return page, "chromedp", nil
+		}
 	}
 
 	if err != nil {
 		logger.Debugf(ctx, "[Tool][WebFetch] Chromedp 抓取失败 url=%s err=%v，尝试直接请求", vp.URL, err)
 	}
 
-	html, httpErr := t.fetchWithHTTP(ctx, vp)
+	page, httpErr := t.fetchWithHTTP(ctx, vp, maxHTMLBytes, maxChars)
 	if httpErr != nil {
 		if err != nil {
-			return "", "", fmt.Errorf("chromedp error: %v; http error: %w", err, httpErr)
+			return nil, "", fmt.Errorf("chromedp error: %v; http error: %w", err, httpErr)
 		}
-		return "", "", httpErr
+		return nil, "", httpErr
 	}
 
-	return html, "http", nil
+	return page, "http", nil
 }
 
+// chromedpPageScript returns the HTML of the page cut to %d bytes of UTF-8 at a character boundary, and its full
+// size in bytes. JavaScript string lengths count UTF-16 code units, so the HTML is encoded before it is cut.
+const chromedpPageScript = `(() => {
+	const bytes = new TextEncoder().encode(document.documentElement.outerHTML);
+	let end = Math.min(bytes.length, %d);
+	while (end > 0 && end < bytes.length && (bytes[end] & 0xc0) === 0x80) end--;
+	return {html: new TextDecoder().decode(bytes.subarray(0, end)), length: bytes.length};
+})()`
+
 // fetchWithChromedp fetches the HTML content with Chromedp. Uses host-resolver-rules to pin host to vp.PinnedIP (DNS rebinding protection).
-func (t *WebFetchTool) fetchWithChromedp(ctx context.Context, vp *validatedParams) (string, error) {
+// The HTML is cut to maxHTMLBytes bytes in the browser, so a multi-MB page never crosses the DevTools
+// connection whole; cut reports whether it was.
+func (t *WebFetchTool) fetchWithChromedp(
+	ctx context.Context,
+	vp *validatedParams,
+	maxHTMLBytes int64,
+) (html string, cut bool, err error) {
 	logger.Debugf(ctx, "[Tool][WebFetch] Chromedp 抓取开始 url=%s", vp.URL)
+	ctx, span := tracing.ContextWithSpan(ctx, "WebFetchTool.fetchWithChromedp",
+		trace.WithAttributes(attribute.String("url.host", vp.Host)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
 
 	// DNS pinning: force Chrome to use the IP we resolved at validation time, not a second resolution.
 	hostRule := fmt.Sprintf("MAP %s %s", vp.Host, vp.PinnedIP.String())
@@ -456,6 +542,7 @@ func (t *WebFetchTool) fetchWithChromedp(ctx context.Context, vp *validatedParam
 
 	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
 	defer cancel()
+	defer trackBrowser(cancel)()
 
 	ctx, cancel = chromedp.NewContext(allocCtx)
 	defer cancel()
@@ -463,39 +550,84 @@ func (t *WebFetchTool) fetchWithChromedp(ctx context.Context, vp *validatedParam
 	ctx, cancel = context.WithTimeout(ctx, webFetchTimeout)
 	defer cancel()
 
-	var html string
-	err := chromedp.Run(ctx,
+	var page struct {
+		HTML   string `json:"html"`
+		Length int64  `json:"length"`
+	}
+	metrics.BrowserSessionsActive.Inc()
+	start := time.Now()
+	err = chromedp.Run(ctx,
 		chromedp.Navigate(vp.URL),
 		chromedp.WaitReady("body", chromedp.ByQuery),
-		chromedp.OuterHTML("html", &html),
+		chromedp.Evaluate(fmt.Sprintf(chromedpPageScript, maxHTMLBytes), &page),
 	)
+	metrics.BrowserSessionsActive.Dec()
+	metrics.BrowserSessions.WithLabelValues(metrics.Result(err)).Inc()
+	slowlog.Observe(ctx, slowlog.KindBrowser, "navigate "+vp.URL, start, err)
 	if err != nil {
-		return "", fmt.Errorf("chromedp run failed: %w", err)
+		return "", false, fmt.Errorf("chromedp run failed: %w", err)
 	}
 
-	logger.Debugf(ctx, "[Tool][WebFetch] Chromedp 抓取成功 url=%s", vp.URL)
-	return html, nil
+	logger.Debugf(ctx, "[Tool][WebFetch] Chromedp 抓取成功 url=%s length=%d", vp.URL, page.Length)
+	return page.HTML, page.Length > maxHTMLBytes, nil
+}
+
+// openBrowsers holds the cancel functions of the headless browsers in use, so shutdown can close them
+var openBrowsers = struct {
+	sync.Mutex
+	next    uint64
+	cancels map[uint64]context.CancelFunc
+}{cancels: make(map[uint64]context.CancelFunc)}
+
+// trackBrowser records a browser as open until the returned function is called
+func trackBrowser(cancel context.CancelFunc) func() {
+	openBrowsers.Lock()
+	defer openBrowsers.Unlock()
+	id := openBrowsers.next
+	openBrowsers.next++
+	openBrowsers.cancels[id] = cancel
+	return func() {
+		openBrowsers.Lock()
+		defer openBrowsers.Unlock()
+		delete(openBrowsers.cancels, id)
+	}
 }
 
-// fetchWithHTTP fetches the HTML content with HTTP using pinned IP (same as chromedp path).
-func (t *WebFetchTool) fetchWithHTTP(ctx context.Context, vp *validatedParams) (string, error) {
+// CloseBrowserSessions closes the headless browsers still open, waiting for their processes to exit,
+// and returns how many were closed
+func CloseBrowserSessions() int {
+	openBrowsers.Lock()
+	cancels := make([]context.CancelFunc, 0, len(openBrowsers.cancels))
+	for _, cancel := range openBrowsers.cancels {
+		cancels = append(cancels, cancel)
+	}
+	openBrowsers.Unlock()
+	// Cancelling an allocator kills its browser and waits for the process to exit
+	for _, cancel := range cancels {
+		cancel()
+	}
+	return len(cancels)
+}
+
+// fetchWithHTTP fetches the page with HTTP using pinned IP (same as chromedp path), converting the body to Markdown
+// while it is read.
+func (t *WebFetchTool) fetchWithHTTP(
+	ctx context.Context,
+	vp *validatedParams,
+	maxHTMLBytes int64,
+	maxChars int,
+) (*pageMarkdown, error) {
 	resp, err := t.fetchWithTimeout(ctx, vp)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status %d %s", resp.StatusCode, resp.Status)
-	}
-
-	limitedReader := io.LimitReader(resp.Body, webFetchMaxChars*2)
-	htmlBytes, err := io.ReadAll(limitedReader)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("request failed with status %d %s", resp.StatusCode, resp.Status)
 	}
 
-	return string(htmlBytes), nil
+	return htmlToMarkdown(resp.Body, maxHTMLBytes, maxChars), nil
 }
 
 // fetchWithTimeout fetches the HTML content with a timeout. Uses pinned IP and original Host header (DNS pinning).
@@ -522,140 +654,3 @@ func (t *WebFetchTool) fetchWithTimeout(ctx context.Context, vp *validatedParams
 
 	return t.client.Do(req)
 }
-
-// convertHTMLToText converts the HTML content to text
-func (t *WebFetchTool) convertHTMLToText(html string) string {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
-	if err != nil {
-		return t.basicTextExtraction(html)
-	}
-
-	doc.Find("script, style, nav, footer, header").Remove()
-
-	var markdown strings.Builder
-	doc.Find("body").Each(func(i int, body *goquery.Selection) {
-		t.processNode(body, &markdown)
-	})
-
-	result := markdown.String()
-	result = regexp.MustCompile(`\n{3,}`).ReplaceAllString(result, "\n\n")
-	return strings.TrimSpace(result)
-}
-
-// processNode processes a node in the HTML content
-func (t *WebFetchTool) processNode(s *goquery.Selection, markdown *strings.Builder) {
-	s.Contents().Each(func(i int, node *goquery.Selection) {
-		nodeName := goquery.NodeName(node)
-
-		switch nodeName {
-		case "h1", "h2", "h3", "h4", "h5", "h6":
-			headerLevel := int(nodeName[1] - '0')
-			markdown.WriteString("\n")
-			markdown.WriteString(strings.Repeat("#", headerLevel))
-			markdown.WriteString(" ")
-			markdown.WriteString(strings.TrimSpace(node.Text()))
-			markdown.WriteString("\n\n")
-		case "p":
-			t.processNode(node, markdown)
-			markdown.WriteString("\n\n")
-		case "a":
-			href, exists := node.Attr("href")
-			text := strings.TrimSpace(node.Text())
-			if exists && text != "" {
-				markdown.WriteString("[")
-				markdown.WriteString(text)
-				markdown.WriteString("](")
-				markdown.WriteString(href)
-				markdown.WriteString(")")
-			} else if text != "" {
-				markdown.WriteString(text)
-			}
-		case "img":
-			src, _ := node.Attr("src")
-			alt, _ := node.Attr("alt")
-			if src != "" {
-				markdown.WriteString("![")
-				markdown.WriteString(alt)
-				markdown.WriteString("](")
-				markdown.WriteString(src)
-				markdown.WriteString(")\n\n")
-			}
-		case "ul", "ol":
-			markdown.WriteString("\n")
-			isOrdered := nodeName == "ol"
-			node.Find("li").Each(func(idx int, li *goquery.Selection) {
-				if isOrdered {
-					fmt.Fprintf(markdown, "%d. ", idx+1)
-				} else {
-					markdown.WriteString("- ")
-				}
-				markdown.WriteString(strings.TrimSpace(li.Text()))
-				markdown.WriteString("\n")
-			})
-			markdown.WriteString("\n")
-		case "br":
-			markdown.WriteString("\n")
-		case "code":
-			parent := node.Parent()
-			if goquery.NodeName(parent) == "pre" {
-				markdown.WriteString("\n```\n")
-				markdown.WriteString(node.Text())
-				markdown.WriteString("\n```\n\n")
-			} else {
-				markdown.WriteString("`")
-				markdown.WriteString(node.Text())
-				markdown.WriteString("`")
-			}
-		case "blockquote":
-			lines := strings.Split(strings.TrimSpace(node.Text()), "\n")
-			for _, line := range lines {
-				markdown.WriteString("> ")
-				markdown.WriteString(strings.TrimSpace(line))
-				markdown.WriteString("\n")
-			}
-			markdown.WriteString("\n")
-		case "strong", "b":
-			markdown.WriteString("**")
-			markdown.WriteString(strings.TrimSpace(node.Text()))
-			markdown.WriteString("**")
-		case "em", "i":
-			markdown.WriteString("*")
-			markdown.WriteString(strings.TrimSpace(node.Text()))
-			markdown.WriteString("*")
-		case "hr":
-			markdown.WriteString("\n---\n\n")
-		case "table":
-			markdown.WriteString("\n")
-			node.Find("tr").Each(func(idx int, tr *goquery.Selection) {
-				tr.Find("th, td").Each(func(i int, cell *goquery.Selection) {
-					markdown.WriteString("| ")
-					markdown.WriteString(strings.TrimSpace(cell.Text()))
-					markdown.WriteString(" ")
-				})
-				markdown.WriteString("|\n")
-				if idx == 0 {
-					tr.Find("th").Each(func(i int, _ *goquery.Selection) {
-						markdown.WriteString("|---")
-					})
-					markdown.WriteString("|\n")
-				}
-			})
-			markdown.WriteString("\n")
-		case "#text":
-			text := node.Text()
-			if strings.TrimSpace(text) != "" {
-				markdown.WriteString(text)
-			}
-		default:
-			t.processNode(node, markdown)
-		}
-	})
-}
-
-// basicTextExtraction extracts the text from the HTML content
-func (t *WebFetchTool) basicTextExtraction(html string) string {
-	re := regexp.MustCompile(`<[^>]*>`)
-	text := re.ReplaceAllString(html, " ")
-	text = regexp.MustCompile(`\s+`).ReplaceAllString(text, " ")
-	return strings.TrimSpace(text)
-}
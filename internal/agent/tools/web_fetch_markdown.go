@@ -0,0 +1,399 @@
+package tools
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// truncatedByHTMLSize means the page had more HTML than the tool reads
+	truncatedByHTMLSize = "html_size"
+	// truncatedByMarkdownSize means the converted Markdown reached the character cap
+	truncatedByMarkdownSize = "markdown_size"
+)
+
+// htmlSkippedElements are dropped with everything inside them
+var htmlSkippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+	"svg": true, "iframe": true, "nav": true, "footer": true, "header": true,
+}
+
+// htmlHeadElements may appear in the head, any other element ends a head left open
+var htmlHeadElements = map[string]bool{
+	"head": true, "title": true, "meta": true, "link": true, "base": true,
+	"style": true, "script": true, "noscript": true, "template": true,
+}
+
+var (
+	markdownBlankLines = regexp.MustCompile(`\n{3,}`)
+	htmlWhitespace     = regexp.MustCompile(`\s+`)
+)
+
+// pageMarkdown is a web page converted to Markdown
+type pageMarkdown struct {
+	Content string
+	// Sections is the number of sections converted, a section starts at each heading
+	Sections int
+	// HTMLBytes is the amount of HTML read
+	HTMLBytes int64
+	// TruncatedBy is why the page was cut short, empty when it was converted whole
+	TruncatedBy string
+}
+
+// Truncated reports whether part of the page was left out
+func (p *pageMarkdown) Truncated() bool {
+	return p.TruncatedBy != ""
+}
+
+// htmlToMarkdown converts the HTML read from r to Markdown while it is read, without building a DOM. The page is
+// converted section by section, each heading starting a section, and conversion stops once maxHTMLBytes of HTML
+// were read or maxChars characters of Markdown were produced, so a multi-MB page costs no more than the caps.
+func htmlToMarkdown(r io.Reader, maxHTMLBytes int64, maxChars int) *pageMarkdown {
+	limited := &io.LimitedReader{R: r, N: maxHTMLBytes}
+	c := &markdownConverter{maxChars: maxChars, heading: -1}
+	z := html.NewTokenizer(limited)
+	for !c.done() {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		c.token(tt, z)
+	}
+	c.flush()
+
+	page := &pageMarkdown{
+		Content:   strings.TrimSpace(c.out.String()),
+		Sections:  c.sections,
+		HTMLBytes: maxHTMLBytes - limited.N,
+	}
+	switch {
+	case c.truncated:
+		page.TruncatedBy = truncatedByMarkdownSize
+	case limited.N == 0:
+		// The cap was reached, the page was cut short if anything is left
+		if n, _ := io.ReadFull(r, make([]byte, 1)); n > 0 {
+			page.TruncatedBy = truncatedByHTMLSize
+		}
+	}
+	return page
+}
+
+// markdownList is an open ul or ol
+type markdownList struct {
+	ordered bool
+	items   int
+}
+
+// markdownLink is an open a, its text is rewritten as a link when it closes
+type markdownLink struct {
+	href  string
+	start int
+}
+
+// markdownConverter holds the state of a conversion. The current section is kept apart from the output so open
+// headings, links and quotes can be rewritten in place, and is moved to the output when the next section starts.
+type markdownConverter struct {
+	maxChars  int
+	out       strings.Builder
+	outChars  int
+	sections  int
+	truncated bool
+
+	section      []byte
+	sectionChars int
+
+	skipTag   string
+	skipDepth int
+	preDepth  int
+	lists     []markdownList
+	links     []markdownLink
+	quotes    []int
+	heading   int // start of the open heading in the section, -1 when none
+	headLevel int
+	tableRow  int
+	rowCells  int
+	rowHeader bool
+}
+
+// done reports whether the character cap was reached
+func (c *markdownConverter) done() bool {
+	return c.truncated
+}
+
+// write appends s to the current section
+func (c *markdownConverter) write(s string) {
+	c.section = append(c.section, s...)
+	c.sectionChars += utf8.RuneCountInString(s)
+}
+
+// rewrite replaces the section from start on with s
+func (c *markdownConverter) rewrite(start int, s string) {
+	c.sectionChars -= utf8.RuneCount(c.section[start:])
+	c.section = append(c.section[:start], s...)
+	c.sectionChars += utf8.RuneCountInString(s)
+}
+
+// token converts one token of the page
+func (c *markdownConverter) token(tt html.TokenType, z *html.Tokenizer) {
+	switch tt {
+	case html.TextToken:
+		if c.skipDepth == 0 {
+			c.text(string(z.Text()))
+		}
+	case html.StartTagToken, html.SelfClosingTagToken:
+		name, hasAttr := z.TagName()
+		tag := string(name)
+		if c.skipDepth > 0 && c.skipTag == "head" && !htmlHeadElements[tag] {
+			// A head without an end tag ends at the body or its first element, as browsers parse it
+			c.skipTag, c.skipDepth = "", 0
+		}
+		if c.skipDepth > 0 {
+			if tag == c.skipTag && tt == html.StartTagToken {
+				c.skipDepth++
+			}
+			return
+		}
+		if htmlSkippedElements[tag] {
+			if tt == html.StartTagToken {
+				c.skipTag, c.skipDepth = tag, 1
+			}
+			return
+		}
+		var attrs map[string]string
+		if hasAttr {
+			attrs = make(map[string]string)
+			for {
+				key, val, more := z.TagAttr()
+				attrs[string(key)] = string(val)
+				if !more {
+					break
+				}
+			}
+		}
+		c.open(tag, attrs, tt == html.SelfClosingTagToken)
+	case html.EndTagToken:
+		name, _ := z.TagName()
+		tag := string(name)
+		if c.skipDepth > 0 {
+			if tag == c.skipTag {
+				c.skipDepth--
+			}
+			return
+		}
+		c.close(tag)
+	}
+
+	if c.outChars+c.sectionChars > c.maxChars {
+		c.flush()
+	}
+}
+
+// text writes a text node, collapsing whitespace outside pre
+func (c *markdownConverter) text(text string) {
+	if c.preDepth > 0 {
+		c.write(text)
+		return
+	}
+	text = htmlWhitespace.ReplaceAllString(text, " ")
+	if text == " " {
+		if n := len(c.section); n == 0 || c.section[n-1] == ' ' || c.section[n-1] == '\n' {
+			return
+		}
+	}
+	c.write(text)
+}
+
+// open converts a start tag
+func (c *markdownConverter) open(tag string, attrs map[string]string, selfClosing bool) {
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.flush()
+		c.headLevel = int(tag[1] - '0')
+		c.heading = len(c.section)
+	case "p", "div", "section", "article", "main":
+		c.write("\n\n")
+	case "br":
+		c.write("\n")
+	case "hr":
+		c.write("\n---\n\n")
+	case "img":
+		if src := attrs["src"]; src != "" {
+			c.write("![" + attrs["alt"] + "](" + src + ")\n\n")
+		}
+	case "a":
+		if !selfClosing {
+			c.links = append(c.links, markdownLink{href: attrs["href"], start: len(c.section)})
+		}
+	case "ul", "ol":
+		c.lists = append(c.lists, markdownList{ordered: tag == "ol"})
+		c.write("\n")
+	case "li":
+		c.write("\n")
+		if n := len(c.lists); n > 0 {
+			list := &c.lists[n-1]
+			list.items++
+			c.write(strings.Repeat("  ", n-1))
+			if list.ordered {
+				c.write(strconv.Itoa(list.items) + ". ")
+			} else {
+				c.write("- ")
+			}
+		} else {
+			c.write("- ")
+		}
+	case "pre":
+		c.preDepth++
+		c.write("\n```\n")
+	case "code":
+		if c.preDepth == 0 {
+			c.write("`")
+		}
+	case "blockquote":
+		c.write("\n")
+		c.quotes = append(c.quotes, len(c.section))
+	case "strong", "b":
+		c.write("**")
+	case "em", "i":
+		c.write("*")
+	case "table":
+		c.tableRow = 0
+		c.write("\n")
+	case "tr":
+		c.rowCells, c.rowHeader = 0, false
+	case "th", "td":
+		c.rowCells++
+		c.rowHeader = c.rowHeader || tag == "th"
+		c.write("| ")
+	}
+}
+
+// close converts an end tag
+func (c *markdownConverter) close(tag string) {
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if c.heading < 0 {
+			return
+		}
+		text := strings.TrimSpace(htmlWhitespace.ReplaceAllString(string(c.section[c.heading:]), " "))
+		if text == "" {
+			c.rewrite(c.heading, "")
+		} else {
+			c.rewrite(c.heading, "\n"+strings.Repeat("#", c.headLevel)+" "+text+"\n\n")
+		}
+		c.heading = -1
+	case "p", "div", "section", "article", "main":
+		c.write("\n\n")
+	case "a":
+		n := len(c.links)
+		if n == 0 {
+			return
+		}
+		link := c.links[n-1]
+		c.links = c.links[:n-1]
+		text := strings.TrimSpace(string(c.section[link.start:]))
+		if link.href != "" && text != "" && !strings.HasPrefix(link.href, "javascript:") {
+			c.rewrite(link.start, "["+text+"]("+link.href+")")
+		}
+	case "ul", "ol":
+		if n := len(c.lists); n > 0 {
+			c.lists = c.lists[:n-1]
+		}
+		c.write("\n\n")
+	case "pre":
+		if c.preDepth > 0 {
+			c.preDepth--
+		}
+		c.write("\n```\n\n")
+	case "code":
+		if c.preDepth == 0 {
+			c.write("`")
+		}
+	case "blockquote":
+		n := len(c.quotes)
+		if n == 0 {
+			return
+		}
+		start := c.quotes[n-1]
+		c.quotes = c.quotes[:n-1]
+		lines := strings.Split(strings.TrimSpace(string(c.section[start:])), "\n")
+		var quoted strings.Builder
+		for _, line := range lines {
+			quoted.WriteString("> ")
+			quoted.WriteString(strings.TrimSpace(line))
+			quoted.WriteString("\n")
+		}
+		quoted.WriteString("\n")
+		c.rewrite(start, quoted.String())
+	case "strong", "b":
+		c.write("**")
+	case "em", "i":
+		c.write("*")
+	case "th", "td":
+		c.write(" ")
+	case "tr":
+		c.write("|\n")
+		if c.tableRow == 0 && c.rowHeader {
+			c.write(strings.Repeat("|---", c.rowCells) + "|\n")
+		}
+		c.tableRow++
+	case "table":
+		c.write("\n")
+	}
+}
+
+// flush moves the current section to the output, cutting it at the character cap. Open headings, links and quotes
+// are left as plain text, their start is no longer in the section.
+func (c *markdownConverter) flush() {
+	c.heading = -1
+	c.links = c.links[:0]
+	c.quotes = c.quotes[:0]
+	if len(c.section) == 0 {
+		return
+	}
+
+	section := strings.TrimSpace(markdownBlankLines.ReplaceAllString(string(c.section), "\n\n"))
+	c.section, c.sectionChars = c.section[:0], 0
+	if section == "" {
+		return
+	}
+	if c.sections > 0 {
+		section = "\n\n" + section
+	}
+
+	chars := utf8.RuneCountInString(section)
+	if remaining := c.maxChars - c.outChars; chars > remaining {
+		section = truncateRunes(section, remaining)
+		chars = utf8.RuneCountInString(section)
+		c.truncated = true
+		if chars == 0 {
+			return
+		}
+	}
+	c.out.WriteString(section)
+	c.outChars += chars
+	c.sections++
+}
+
+// truncateRunes returns the first n runes of s, cut at the last line break when there is one in the second half
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	i := 0
+	for pos := range s {
+		if i == n {
+			s = s[:pos]
+			break
+		}
+		i++
+	}
+	if cut := strings.LastIndexByte(s, '\n'); cut > len(s)/2 {
+		s = s[:cut]
+	}
+	return s
+}
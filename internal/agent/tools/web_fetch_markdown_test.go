@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	page := htmlToMarkdown(strings.NewReader(`<html><head><title>t</title><script>var x = 1;</script></head>
+<body><nav>menu</nav>
+<h1>Guide</h1><p>Read the <a href="/docs">docs</a> and <strong>this</strong>.</p>
+<h2>Steps</h2><ol><li>one</li><li>two</li></ol>
+<pre><code>go build ./...</code></pre>
+<table><tr><th>k</th><th>v</th></tr><tr><td>a</td><td>1</td></tr></table>
+</body></html>`), 1<<20, 10000)
+
+	want := "# Guide\n\nRead the [docs](/docs) and **this**.\n\n## Steps\n\n1. one\n2. two\n\n```\ngo build ./...\n```\n\n" +
+		"| k | v |\n|---|---|\n| a | 1 |"
+	if page.Content != want {
+		t.Errorf("Content = %q, want %q", page.Content, want)
+	}
+	if page.Sections != 2 {
+		t.Errorf("Sections = %d, want 2", page.Sections)
+	}
+	if page.Truncated() {
+		t.Errorf("TruncatedBy = %q, want none", page.TruncatedBy)
+	}
+}
+
+func TestHTMLToMarkdownUnclosedHead(t *testing.T) {
+	cases := map[string]string{
+		"body":          `<html><head><title>x</title><body><h1>Hi</h1>`,
+		"first element": `<html><head><title>x</title><script>var x;</script><h1>Hi</h1>`,
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			page := htmlToMarkdown(strings.NewReader(input), 1<<20, 10000)
+			if page.Content != "# Hi" {
+				t.Errorf("Content = %q, want %q", page.Content, "# Hi")
+			}
+		})
+	}
+}
+
+func TestHTMLToMarkdownTruncates(t *testing.T) {
+	var html strings.Builder
+	for i := 0; i < 100; i++ {
+		html.WriteString("<h2>Section</h2><p>")
+		html.WriteString(strings.Repeat("文档内容 ", 20))
+		html.WriteString("</p>")
+	}
+
+	page := htmlToMarkdown(strings.NewReader(html.String()), 1<<20, 500)
+	if page.TruncatedBy != truncatedByMarkdownSize {
+		t.Errorf("TruncatedBy = %q, want %q", page.TruncatedBy, truncatedByMarkdownSize)
+	}
+	if n := len([]rune(page.Content)); n > 500 || n == 0 {
+		t.Errorf("content length = %d runes, want 1-500", n)
+	}
+
+	page = htmlToMarkdown(strings.NewReader(html.String()), 1000, 1<<20)
+	if page.TruncatedBy != truncatedByHTMLSize || page.HTMLBytes != 1000 {
+		t.Errorf("TruncatedBy = %q, HTMLBytes = %d, want %q after 1000 bytes",
+			page.TruncatedBy, page.HTMLBytes, truncatedByHTMLSize)
+	}
+
+	page = htmlToMarkdown(strings.NewReader("<p>short</p>"), 12, 100)
+	if page.Truncated() || page.Content != "short" {
+		t.Errorf("page = %+v, want short converted whole", page)
+	}
+}
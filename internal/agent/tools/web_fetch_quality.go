@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// pageQualityMinTextChars is the prose below which a page is too thin to be the content that was looked for
+	pageQualityMinTextChars = 200
+	// pageQualityMinTextRatio is the share of the Markdown that must be prose, below it links and markup dominate
+	pageQualityMinTextRatio = 0.3
+	// pageQualityNoHeadingChars is the prose above which a page without any heading is suspicious
+	pageQualityNoHeadingChars = 3000
+	// pageQualityMaxRepeatedRatio is the share of lines that may repeat an earlier line
+	pageQualityMaxRepeatedRatio = 0.3
+	// pageQualityMinRepeatLines is the number of lines below which repetition is not measured
+	pageQualityMinRepeatLines = 10
+	// pageQualityLargeHTMLBytes is the page size above which little text points at content the converter cannot see
+	pageQualityLargeHTMLBytes = 50 << 10
+	// pageQualityMinTextPerHTML is the prose per byte of HTML expected from a large page
+	pageQualityMinTextPerHTML = 0.01
+)
+
+var (
+	markdownImage    = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	markdownLinkText = regexp.MustCompile(`\[[^\]]*\]\([^)]*\)`)
+	markdownHeading  = regexp.MustCompile(`(?m)^#{1,6} \S`)
+	markdownSyntax   = regexp.MustCompile("[#*`|>_~-]+")
+)
+
+// pageQuality tells how likely the Markdown of a page is its content rather than navigation, boilerplate or an
+// empty shell whose content is rendered in images or by scripts, in which case a screenshot with OCR does better
+type pageQuality struct {
+	// Score goes from 0, nothing usable, to 100, nothing suspicious
+	Score    int
+	Warnings []string
+	// TextChars is the prose of the page, link text and Markdown syntax left out
+	TextChars int
+	// TextRatio is the share of the Markdown that is prose
+	TextRatio float64
+	Headings  int
+	// RepeatedRatio is the share of lines repeating an earlier line
+	RepeatedRatio float64
+}
+
+// assessPageQuality scores the Markdown converted from a page from its text-to-markup ratio, headings, repeated
+// lines and length against the size of the HTML
+func assessPageQuality(page *pageMarkdown) *pageQuality {
+	q := &pageQuality{Score: 100, Warnings: []string{}}
+	contentChars := utf8.RuneCountInString(page.Content)
+	if contentChars == 0 {
+		q.Score = 0
+		q.Warnings = append(q.Warnings, "no text was extracted, the page may be rendered in images or by scripts")
+		return q
+	}
+
+	text := markdownImage.ReplaceAllString(page.Content, "")
+	text = markdownLinkText.ReplaceAllString(text, "")
+	text = markdownSyntax.ReplaceAllString(text, "")
+	q.TextChars = utf8.RuneCountInString(strings.Join(strings.Fields(text), " "))
+	q.TextRatio = float64(q.TextChars) / float64(contentChars)
+	q.Headings = len(markdownHeading.FindAllStringIndex(page.Content, -1))
+	q.RepeatedRatio = repeatedLineRatio(page.Content)
+
+	if q.TextChars < pageQualityMinTextChars {
+		q.Score -= 40
+		q.Warnings = append(q.Warnings, "page has very little text, it may be a login, cookie or error page")
+	}
+	if q.TextRatio < pageQualityMinTextRatio {
+		q.Score -= 35
+		q.Warnings = append(q.Warnings, "page may be mostly navigation, most of the text is links")
+	}
+	if q.RepeatedRatio > pageQualityMaxRepeatedRatio {
+		q.Score -= 20
+		q.Warnings = append(q.Warnings, "page repeats the same lines, it may be mostly boilerplate")
+	}
+	if q.Headings == 0 && q.TextChars > pageQualityNoHeadingChars {
+		q.Score -= 10
+		q.Warnings = append(q.Warnings, "page has no headings, its structure may have been lost")
+	}
+	// A page cut at the character cap is long enough, its HTML may still hold far more than what was converted
+	if page.TruncatedBy != truncatedByMarkdownSize && page.HTMLBytes > pageQualityLargeHTMLBytes &&
+		float64(q.TextChars) < float64(page.HTMLBytes)*pageQualityMinTextPerHTML {
+		q.Score -= 30
+		q.Warnings = append(q.Warnings,
+			"little text was extracted from a large page, the content may be rendered in images or by scripts")
+	}
+	q.Score = max(q.Score, 0)
+	return q
+}
+
+// repeatedLineRatio returns the share of the non-empty lines of content that repeat an earlier line, 0 for content
+// too short to tell
+func repeatedLineRatio(content string) float64 {
+	seen := make(map[string]bool)
+	lines, repeated := 0, 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "---" || strings.HasPrefix(line, "|---") || strings.HasPrefix(line, "```") {
+			continue
+		}
+		lines++
+		if seen[line] {
+			repeated++
+		}
+		seen[line] = true
+	}
+	if lines < pageQualityMinRepeatLines {
+		return 0
+	}
+	return float64(repeated) / float64(lines)
+}
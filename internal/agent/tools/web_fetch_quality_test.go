@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssessPageQuality(t *testing.T) {
+	var article strings.Builder
+	article.WriteString("# Guide\n\n")
+	for i := 0; i < 20; i++ {
+		article.WriteString("This paragraph explains one step of the setup in plain words, paragraph ")
+		article.WriteString(strings.Repeat("x", i+1))
+		article.WriteString(".\n\n")
+	}
+	q := assessPageQuality(&pageMarkdown{Content: article.String(), HTMLBytes: 8 << 10})
+	if q.Score != 100 || len(q.Warnings) != 0 {
+		t.Errorf("article: score %d, warnings %v, want 100 and none", q.Score, q.Warnings)
+	}
+
+	var nav strings.Builder
+	for i := 0; i < 40; i++ {
+		nav.WriteString("- [Products and services overview](https://example.com/products/overview)\n")
+	}
+	q = assessPageQuality(&pageMarkdown{Content: nav.String(), HTMLBytes: 200 << 10})
+	if q.Score >= 50 {
+		t.Errorf("navigation: score %d, want below 50", q.Score)
+	}
+	if !containsWarning(q.Warnings, "mostly navigation") || !containsWarning(q.Warnings, "repeats") {
+		t.Errorf("navigation: warnings %v, want navigation and repetition", q.Warnings)
+	}
+
+	q = assessPageQuality(&pageMarkdown{Content: "", HTMLBytes: 300 << 10})
+	if q.Score != 0 || len(q.Warnings) != 1 {
+		t.Errorf("empty: score %d, warnings %v, want 0 and one", q.Score, q.Warnings)
+	}
+
+	q = assessPageQuality(&pageMarkdown{Content: "Loading the application, please wait.", HTMLBytes: 300 << 10})
+	if !containsWarning(q.Warnings, "large page") {
+		t.Errorf("script shell: warnings %v, want large page", q.Warnings)
+	}
+}
+
+func containsWarning(warnings []string, part string) bool {
+	for _, w := range warnings {
+		if strings.Contains(w, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+var ErrAnswerFeedbackNotFound = errors.New("answer feedback not found")
+
+// answerFeedbackRepository implements the AnswerFeedbackRepository interface
+type answerFeedbackRepository struct {
+	db *gorm.DB
+}
+
+// NewAnswerFeedbackRepository creates a new answer feedback repository
+func NewAnswerFeedbackRepository(db *gorm.DB) interfaces.AnswerFeedbackRepository {
+	return &answerFeedbackRepository{db: db}
+}
+
+// ListByMessage returns the feedback rows of a message, one per knowledge base
+func (r *answerFeedbackRepository) ListByMessage(ctx context.Context,
+	messageID string,
+) ([]*types.AnswerFeedback, error) {
+	var feedback []*types.AnswerFeedback
+	if err := r.db.WithContext(ctx).Where("message_id = ?", messageID).
+		Order("knowledge_base_id").Find(&feedback).Error; err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// SaveMessageFeedback replaces the feedback rows of a message
+func (r *answerFeedbackRepository) SaveMessageFeedback(ctx context.Context,
+	messageID string, feedback []*types.AnswerFeedback,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", messageID).Delete(&types.AnswerFeedback{}).Error; err != nil {
+			return err
+		}
+		if len(feedback) == 0 {
+			return nil
+		}
+		return tx.Create(feedback).Error
+	})
+}
+
+// Get returns a feedback of a knowledge base
+func (r *answerFeedbackRepository) Get(ctx context.Context,
+	knowledgeBaseID, id string,
+) (*types.AnswerFeedback, error) {
+	var feedback types.AnswerFeedback
+	if err := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND id = ?", knowledgeBaseID, id).
+		First(&feedback).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAnswerFeedbackNotFound
+		}
+		return nil, err
+	}
+	return &feedback, nil
+}
+
+// Update saves a feedback
+func (r *answerFeedbackRepository) Update(ctx context.Context, feedback *types.AnswerFeedback) error {
+	return r.db.WithContext(ctx).Save(feedback).Error
+}
+
+// List returns a page of the feedback of a knowledge base, newest first
+func (r *answerFeedbackRepository) List(ctx context.Context, knowledgeBaseID string,
+	filter *types.AnswerFeedbackFilter,
+) ([]*types.AnswerFeedback, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.AnswerFeedback{}).
+		Where("knowledge_base_id = ? AND status = ?", knowledgeBaseID, filter.Status)
+	if filter.Rating != "all" {
+		query = query.Where("rating = ?", filter.Rating)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var feedback []*types.AnswerFeedback
+	if err := query.Order("created_at DESC").
+		Offset(filter.Offset()).Limit(filter.Limit()).
+		Find(&feedback).Error; err != nil {
+		return nil, 0, err
+	}
+	return feedback, total, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// ErrAPIKeyNotFound is returned when a scoped API key does not exist
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// apiKeyRepository implements APIKeyRepository interface
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) interfaces.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// CreateAPIKey creates a key
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *types.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetAPIKeyByID gets a key of a tenant by ID
+func (r *apiKeyRepository) GetAPIKeyByID(ctx context.Context, tenantID uint64, id string) (*types.APIKey, error) {
+	var key types.APIKey
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetAPIKeyByHash gets a key by the hash of its secret
+func (r *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*types.APIKey, error) {
+	var key types.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys lists the keys of a tenant, newest first
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context, tenantID uint64) ([]*types.APIKey, error) {
+	var keys []*types.APIKey
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// UpdateAPIKey updates a key
+func (r *apiKeyRepository) UpdateAPIKey(ctx context.Context, key *types.APIKey) error {
+	return r.db.WithContext(ctx).Save(key).Error
+}
+
+// TouchAPIKey records the last time a key was used, without changing its update time
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&types.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", time.Now()).Error
+}
+
+// DeleteAPIKey deletes a key of a tenant
+func (r *apiKeyRepository) DeleteAPIKey(ctx context.Context, tenantID uint64, id string) error {
+	result := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// ListAPIKeysByServiceAccount lists the keys of a service account, newest first
+func (r *apiKeyRepository) ListAPIKeysByServiceAccount(ctx context.Context,
+	tenantID uint64, serviceAccountID string,
+) ([]*types.APIKey, error) {
+	var keys []*types.APIKey
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND service_account_id = ?", tenantID, serviceAccountID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// DeleteAPIKeysByServiceAccount deletes the keys of a service account
+func (r *apiKeyRepository) DeleteAPIKeysByServiceAccount(ctx context.Context,
+	tenantID uint64, serviceAccountID string,
+) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND service_account_id = ?", tenantID, serviceAccountID).
+		Delete(&types.APIKey{}).Error
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/tenantguard"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"gorm.io/gorm"
@@ -47,7 +49,7 @@ func (r *chunkRepository) GetChunkByID(ctx context.Context, tenantID uint64, id
 // GetChunkByIDOnly retrieves a chunk by ID without tenant filter (for permission resolution).
 func (r *chunkRepository) GetChunkByIDOnly(ctx context.Context, id string) (*types.Chunk, error) {
 	var chunk types.Chunk
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&chunk).Error; err != nil {
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Where("id = ?", id).First(&chunk).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("chunk not found")
 		}
@@ -87,7 +89,7 @@ func (r *chunkRepository) ListChunksByIDOnly(ctx context.Context, ids []string)
 		return nil, nil
 	}
 	var chunks []*types.Chunk
-	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&chunks).Error; err != nil {
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Where("id IN ?", ids).Find(&chunks).Error; err != nil {
 		return nil, err
 	}
 	return chunks, nil
@@ -373,6 +375,60 @@ func (r *chunkRepository) DeleteChunksByKnowledgeID(ctx context.Context, tenantI
 	).Delete(&types.Chunk{}).Error
 }
 
+// UpdateChunkEnabledByKnowledgeID sets is_enabled for all chunks of a knowledge.
+// Returns the IDs of chunks whose status actually changed, for index sync.
+func (r *chunkRepository) UpdateChunkEnabledByKnowledgeID(
+	ctx context.Context,
+	tenantID uint64,
+	knowledgeID string,
+	isEnabled bool,
+) ([]string, error) {
+	var affectedIDs []string
+	if err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND knowledge_id = ? AND is_enabled != ?", tenantID, knowledgeID, isEnabled).
+		Pluck("id", &affectedIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(affectedIDs) == 0 {
+		return affectedIDs, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND id IN ?", tenantID, affectedIDs).
+		Updates(map[string]interface{}{"is_enabled": isEnabled, "updated_at": time.Now()}).Error; err != nil {
+		return nil, err
+	}
+	return affectedIDs, nil
+}
+
+// UpdateChunkEnabledByIDs sets is_enabled for the chunks of a knowledge among ids.
+// Returns the IDs of chunks whose status changed.
+func (r *chunkRepository) UpdateChunkEnabledByIDs(
+	ctx context.Context,
+	tenantID uint64,
+	knowledgeID string,
+	ids []string,
+	isEnabled bool,
+) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var affectedIDs []string
+	if err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND knowledge_id = ? AND id IN ? AND is_enabled != ?", tenantID, knowledgeID, ids, isEnabled).
+		Pluck("id", &affectedIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(affectedIDs) == 0 {
+		return affectedIDs, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND id IN ?", tenantID, affectedIDs).
+		Updates(map[string]interface{}{"is_enabled": isEnabled, "updated_at": time.Now()}).Error; err != nil {
+		return nil, err
+	}
+	return affectedIDs, nil
+}
+
 // DeleteByKnowledgeList deletes all chunks for a knowledge list
 func (r *chunkRepository) DeleteByKnowledgeList(ctx context.Context, tenantID uint64, knowledgeIDs []string) error {
 	return r.db.WithContext(ctx).Where(
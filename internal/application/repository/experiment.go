@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/tenantguard"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+var ErrExperimentNotFound = errors.New("experiment not found")
+
+// experimentRepository implements the ExperimentRepository interface
+type experimentRepository struct {
+	db *gorm.DB
+}
+
+// NewExperimentRepository creates a new experiment repository
+func NewExperimentRepository(db *gorm.DB) interfaces.ExperimentRepository {
+	return &experimentRepository{db: db}
+}
+
+// CreateExperiment creates an experiment
+func (r *experimentRepository) CreateExperiment(ctx context.Context, experiment *types.KBExperiment) error {
+	return r.db.WithContext(ctx).Create(experiment).Error
+}
+
+// GetExperiment gets an experiment by id within a tenant
+func (r *experimentRepository) GetExperiment(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.KBExperiment, error) {
+	var experiment types.KBExperiment
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&experiment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExperimentNotFound
+		}
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// ListExperiments lists the experiments of a knowledge base, newest first
+func (r *experimentRepository) ListExperiments(ctx context.Context,
+	tenantID uint64, knowledgeBaseID string,
+) ([]*types.KBExperiment, error) {
+	var experiments []*types.KBExperiment
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		Order("created_at DESC").
+		Find(&experiments).Error; err != nil {
+		return nil, err
+	}
+	return experiments, nil
+}
+
+// UpdateExperiment saves an experiment
+func (r *experimentRepository) UpdateExperiment(ctx context.Context, experiment *types.KBExperiment) error {
+	return r.db.WithContext(ctx).Save(experiment).Error
+}
+
+// DeleteExperiment deletes an experiment
+func (r *experimentRepository) DeleteExperiment(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&types.KBExperiment{}).Error
+}
+
+// GetLiveExperiment returns the running experiment of a knowledge base, or else its latest promoted one
+func (r *experimentRepository) GetLiveExperiment(ctx context.Context,
+	knowledgeBaseID string,
+) (*types.KBExperiment, error) {
+	var experiment types.KBExperiment
+	err := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND status IN ?", knowledgeBaseID,
+			[]types.ExperimentStatus{types.ExperimentStatusRunning, types.ExperimentStatusPromoted}).
+		// A running experiment takes over from the promoted one until it is stopped or promoted itself
+		Order("CASE WHEN status = 'running' THEN 0 ELSE 1 END, promoted_at DESC").
+		First(&experiment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// ListRunningExperiments lists the running experiments of all tenants
+func (r *experimentRepository) ListRunningExperiments(ctx context.Context) ([]*types.KBExperiment, error) {
+	var experiments []*types.KBExperiment
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).
+		Where("status = ?", types.ExperimentStatusRunning).
+		Find(&experiments).Error; err != nil {
+		return nil, err
+	}
+	return experiments, nil
+}
+
+// PromoteExperiment saves a promoted experiment and stops the other promoted experiments of its knowledge base
+func (r *experimentRepository) PromoteExperiment(ctx context.Context, experiment *types.KBExperiment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&types.KBExperiment{}).
+			Where("knowledge_base_id = ? AND status = ? AND id <> ?",
+				experiment.KnowledgeBaseID, types.ExperimentStatusPromoted, experiment.ID).
+			Updates(map[string]interface{}{
+				"status":     types.ExperimentStatusStopped,
+				"stopped_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Save(experiment).Error
+	})
+}
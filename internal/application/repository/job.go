@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/tenantguard"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrJobNotFound is returned when a job does not exist
+var ErrJobNotFound = errors.New("job not found")
+
+// jobRepository implements JobRepository interface
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *gorm.DB) interfaces.JobRepository {
+	return &jobRepository{db: db}
+}
+
+// CreateJob records a queued job, jobs already recorded are kept
+func (r *jobRepository) CreateJob(ctx context.Context, job *types.Job) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(job).Error
+}
+
+// SaveJobState saves the status, attempts, error and timestamps of a job, recording it if needed.
+// A worker may pick up a job before the enqueuer has recorded it, so the state is upserted.
+func (r *jobRepository) SaveJobState(ctx context.Context, job *types.Job) error {
+	job.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"status", "attempts", "last_error", "started_at", "finished_at", "updated_at",
+		}),
+	}).Create(job).Error
+}
+
+// GetJob gets a job of a tenant by ID
+func (r *jobRepository) GetJob(ctx context.Context, tenantID uint64, id string) (*types.Job, error) {
+	var job types.Job
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobByID gets a job of any tenant by ID
+func (r *jobRepository) GetJobByID(ctx context.Context, id string) (*types.Job, error) {
+	var job types.Job
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs lists the jobs of a tenant matching a filter, newest first
+func (r *jobRepository) ListJobs(ctx context.Context,
+	tenantID uint64, filter *types.JobFilter, page *types.Pagination,
+) ([]*types.Job, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.Job{}).Where("tenant_id = ?", tenantID)
+	if filter != nil {
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+		if filter.Type != "" {
+			query = query.Where("type = ?", filter.Type)
+		}
+		if filter.KnowledgeBaseID != "" {
+			query = query.Where("knowledge_base_id = ?", filter.KnowledgeBaseID)
+		}
+		if filter.KnowledgeID != "" {
+			query = query.Where("knowledge_id = ?", filter.KnowledgeID)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var jobs []*types.Job
+	if err := query.Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// DeleteFinishedJobs deletes the jobs of all tenants that finished before a time and returns how many were deleted
+func (r *jobRepository) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).
+		Where("finished_at IS NOT NULL AND finished_at < ?", before).
+		Delete(&types.Job{})
+	return result.RowsAffected, result.Error
+}
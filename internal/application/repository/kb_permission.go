@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrKBMemberNotFound            = errors.New("knowledge base member not found")
+	ErrKnowledgePermissionNotFound = errors.New("knowledge permission not found")
+	ErrKBInviteNotFound            = errors.New("knowledge base invite not found")
+	ErrKBInviteUsedUp              = errors.New("knowledge base invite has no uses left")
+)
+
+// kbPermissionRepository implements KBPermissionRepository interface
+type kbPermissionRepository struct {
+	db *gorm.DB
+}
+
+// NewKBPermissionRepository creates a new knowledge base permission repository
+func NewKBPermissionRepository(db *gorm.DB) interfaces.KBPermissionRepository {
+	return &kbPermissionRepository{db: db}
+}
+
+// GetMember gets the role granted to a user on a knowledge base
+func (r *kbPermissionRepository) GetMember(ctx context.Context, kbID string, userID string) (*types.KBMember, error) {
+	var member types.KBMember
+	err := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND user_id = ?", kbID, userID).
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKBMemberNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListMembers lists the roles granted on a knowledge base with their users
+func (r *kbPermissionRepository) ListMembers(ctx context.Context, kbID string) ([]*types.KBMember, error) {
+	var members []*types.KBMember
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("knowledge_base_id = ?", kbID).
+		Order("created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// SaveMember creates or updates the role granted to a user on a knowledge base
+func (r *kbPermissionRepository) SaveMember(ctx context.Context, member *types.KBMember) error {
+	existing, err := r.GetMember(ctx, member.KnowledgeBaseID, member.UserID)
+	if errors.Is(err, ErrKBMemberNotFound) {
+		return r.db.WithContext(ctx).Create(member).Error
+	}
+	if err != nil {
+		return err
+	}
+	member.ID = existing.ID
+	member.CreatedAt = existing.CreatedAt
+	return r.db.WithContext(ctx).Model(&types.KBMember{}).
+		Where("id = ?", existing.ID).
+		Updates(map[string]interface{}{
+			"role":       member.Role,
+			"granted_by": member.GrantedBy,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// ListMembershipsByUser lists the roles granted to a user on knowledge bases
+func (r *kbPermissionRepository) ListMembershipsByUser(ctx context.Context, userID string) ([]*types.KBMember, error) {
+	var members []*types.KBMember
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// DeleteMember removes the role granted to a user on a knowledge base
+func (r *kbPermissionRepository) DeleteMember(ctx context.Context, kbID string, userID string) error {
+	result := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND user_id = ?", kbID, userID).
+		Delete(&types.KBMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKBMemberNotFound
+	}
+	return nil
+}
+
+// GetKnowledgePermission gets the permission set for a user on a document
+func (r *kbPermissionRepository) GetKnowledgePermission(ctx context.Context,
+	knowledgeID string, userID string,
+) (*types.KnowledgePermission, error) {
+	var permission types.KnowledgePermission
+	err := r.db.WithContext(ctx).
+		Where("knowledge_id = ? AND user_id = ?", knowledgeID, userID).
+		First(&permission).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKnowledgePermissionNotFound
+		}
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// ListKnowledgePermissions lists the permissions set on a document with their users
+func (r *kbPermissionRepository) ListKnowledgePermissions(ctx context.Context,
+	knowledgeID string,
+) ([]*types.KnowledgePermission, error) {
+	var permissions []*types.KnowledgePermission
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("knowledge_id = ?", knowledgeID).
+		Order("created_at ASC").
+		Find(&permissions).Error
+	return permissions, err
+}
+
+// SaveKnowledgePermission creates or updates the permission of a user on a document
+func (r *kbPermissionRepository) SaveKnowledgePermission(ctx context.Context,
+	permission *types.KnowledgePermission,
+) error {
+	existing, err := r.GetKnowledgePermission(ctx, permission.KnowledgeID, permission.UserID)
+	if errors.Is(err, ErrKnowledgePermissionNotFound) {
+		return r.db.WithContext(ctx).Create(permission).Error
+	}
+	if err != nil {
+		return err
+	}
+	permission.ID = existing.ID
+	permission.CreatedAt = existing.CreatedAt
+	return r.db.WithContext(ctx).Model(&types.KnowledgePermission{}).
+		Where("id = ?", existing.ID).
+		Updates(map[string]interface{}{
+			"role":       permission.Role,
+			"granted_by": permission.GrantedBy,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// DeleteKnowledgePermission removes the permission of a user on a document
+func (r *kbPermissionRepository) DeleteKnowledgePermission(ctx context.Context, knowledgeID string, userID string) error {
+	result := r.db.WithContext(ctx).
+		Where("knowledge_id = ? AND user_id = ?", knowledgeID, userID).
+		Delete(&types.KnowledgePermission{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKnowledgePermissionNotFound
+	}
+	return nil
+}
+
+// ListHiddenKnowledgeIDs returns the documents of a knowledge base hidden from a user
+func (r *kbPermissionRepository) ListHiddenKnowledgeIDs(ctx context.Context, kbID string, userID string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&types.KnowledgePermission{}).
+		Where("knowledge_base_id = ? AND user_id = ? AND role = ?", kbID, userID, types.KBRoleNone).
+		Pluck("knowledge_id", &ids).Error
+	return ids, err
+}
+
+// CreateInvite creates an invite link to a knowledge base
+func (r *kbPermissionRepository) CreateInvite(ctx context.Context, invite *types.KBInvite) error {
+	return r.db.WithContext(ctx).Create(invite).Error
+}
+
+// GetInvite gets an invite that has not been revoked
+func (r *kbPermissionRepository) GetInvite(ctx context.Context, id string) (*types.KBInvite, error) {
+	var invite types.KBInvite
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKBInviteNotFound
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ListInvites lists the invites of a knowledge base that have not been revoked, newest first
+func (r *kbPermissionRepository) ListInvites(ctx context.Context, kbID string) ([]*types.KBInvite, error) {
+	var invites []*types.KBInvite
+	err := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ?", kbID).
+		Order("created_at DESC").
+		Find(&invites).Error
+	return invites, err
+}
+
+// DeleteInvite revokes an invite of a knowledge base
+func (r *kbPermissionRepository) DeleteInvite(ctx context.Context, kbID string, id string) error {
+	result := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND id = ?", kbID, id).
+		Delete(&types.KBInvite{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKBInviteNotFound
+	}
+	return nil
+}
+
+// UseInvite counts one use of an invite, unless it has no uses left
+func (r *kbPermissionRepository) UseInvite(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Model(&types.KBInvite{}).
+		Where("id = ? AND (max_uses <= 0 OR uses < max_uses)", id).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKBInviteUsedUp
+	}
+	return nil
+}
@@ -3,8 +3,11 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/Tencent/WeKnora/internal/tenantguard"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"gorm.io/gorm"
@@ -27,6 +30,9 @@ func NewKnowledgeRepository(db *gorm.DB) interfaces.KnowledgeRepository {
 
 // CreateKnowledge creates knowledge
 func (r *knowledgeRepository) CreateKnowledge(ctx context.Context, knowledge *types.Knowledge) error {
+	if knowledge.CreatedBy == "" {
+		knowledge.CreatedBy = types.ActorIDFromContext(ctx)
+	}
 	err := r.db.WithContext(ctx).Create(knowledge).Error
 	return err
 }
@@ -50,7 +56,7 @@ func (r *knowledgeRepository) GetKnowledgeByID(
 // GetKnowledgeByIDOnly returns knowledge by ID without tenant filter (for permission resolution).
 func (r *knowledgeRepository) GetKnowledgeByIDOnly(ctx context.Context, id string) (*types.Knowledge, error) {
 	var knowledge types.Knowledge
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&knowledge).Error; err != nil {
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Where("id = ?", id).First(&knowledge).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrKnowledgeNotFound
 		}
@@ -84,57 +90,94 @@ func (r *knowledgeRepository) ListPagedKnowledgeByKnowledgeBaseID(
 	var knowledges []*types.Knowledge
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
-		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
-	if tagID != "" {
-		query = query.Where("tag_id = ?", tagID)
+	// Query total count first
+	query := r.listKnowledgeQuery(ctx, tenantID, kbID, tagID, keyword, fileType)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	if keyword != "" {
-		query = query.Where("file_name LIKE ?", "%"+keyword+"%")
+
+	// Then query paginated data
+	if err := r.listKnowledgeQuery(ctx, tenantID, kbID, tagID, keyword, fileType).
+		Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&knowledges).Error; err != nil {
+		return nil, 0, err
 	}
-	if fileType != "" {
-		if fileType == "manual" {
-			query = query.Where("type = ?", "manual")
-		} else if fileType == "url" {
-			query = query.Where("type = ?", "url")
-		} else {
-			query = query.Where("file_type = ?", fileType)
+
+	return knowledges, total, nil
+}
+
+// ListKnowledgePageByKnowledgeBaseID lists a page of the knowledge in a knowledge base by the sort order of the
+// query, with one more row than the limit when more follow. The total is nil when the query does not count it.
+func (r *knowledgeRepository) ListKnowledgePageByKnowledgeBaseID(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	listQuery *types.ListQuery,
+	tagID string,
+	keyword string,
+	fileType string,
+) ([]*types.Knowledge, *int64, error) {
+	var total *int64
+	if listQuery.CountTotal {
+		var count int64
+		if err := r.listKnowledgeQuery(ctx, tenantID, kbID, tagID, keyword, fileType).
+			Count(&count).Error; err != nil {
+			return nil, nil, err
 		}
+		total = &count
 	}
 
-	// Query total count first
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	query, err := applyListQuery(r.listKnowledgeQuery(ctx, tenantID, kbID, tagID, keyword, fileType), listQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	var knowledges []*types.Knowledge
+	if err := query.Find(&knowledges).Error; err != nil {
+		return nil, nil, err
 	}
+	return knowledges, total, nil
+}
 
-	// Then query paginated data
-	dataQuery := r.db.WithContext(ctx).
-		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+// ListKnowledgeIDsByFilter lists the IDs of the knowledge in a knowledge base matched by a bulk filter
+func (r *knowledgeRepository) ListKnowledgeIDsByFilter(
+	ctx context.Context, tenantID uint64, kbID string, filter *types.KnowledgeBulkFilter, limit int,
+) ([]string, error) {
+	query := r.listKnowledgeQuery(ctx, tenantID, kbID, filter.TagID, filter.Keyword, filter.FileType)
+	if filter.ParseStatus != "" {
+		query = query.Where("parse_status = ?", filter.ParseStatus)
+	}
+	var ids []string
+	if err := query.Order("created_at ASC").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// listKnowledgeQuery selects the knowledge of a knowledge base listed to the user, by tag, file name and type
+func (r *knowledgeRepository) listKnowledgeQuery(
+	ctx context.Context, tenantID uint64, kbID string, tagID string, keyword string, fileType string,
+) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND trashed_at IS NULL", tenantID, kbID)
+	query = r.whereNotHidden(ctx, query, kbID)
 	if tagID != "" {
-		dataQuery = dataQuery.Where("tag_id = ?", tagID)
+		query = r.whereInTagTree(query, tenantID, []string{tagID})
 	}
 	if keyword != "" {
-		dataQuery = dataQuery.Where("file_name LIKE ?", "%"+keyword+"%")
+		query = query.Where("file_name LIKE ?", "%"+keyword+"%")
 	}
 	if fileType != "" {
 		if fileType == "manual" {
-			dataQuery = dataQuery.Where("type = ?", "manual")
+			query = query.Where("type = ?", "manual")
 		} else if fileType == "url" {
-			dataQuery = dataQuery.Where("type = ?", "url")
+			query = query.Where("type = ?", "url")
 		} else {
-			dataQuery = dataQuery.Where("file_type = ?", fileType)
+			query = query.Where("file_type = ?", fileType)
 		}
 	}
-
-	if err := dataQuery.
-		Order("created_at DESC").
-		Offset(page.Offset()).
-		Limit(page.Limit()).
-		Find(&knowledges).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return knowledges, total, nil
+	return query
 }
 
 // UpdateKnowledge updates knowledge
@@ -153,12 +196,18 @@ func (r *knowledgeRepository) UpdateKnowledgeBatch(ctx context.Context, knowledg
 
 // DeleteKnowledge deletes knowledge
 func (r *knowledgeRepository) DeleteKnowledge(ctx context.Context, tenantID uint64, id string) error {
-	return r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.Knowledge{}).Error
+	return r.DeleteKnowledgeList(ctx, tenantID, []string{id})
 }
 
-// DeleteKnowledge deletes knowledge
+// DeleteKnowledge deletes knowledge and its tag assignments
 func (r *knowledgeRepository) DeleteKnowledgeList(ctx context.Context, tenantID uint64, ids []string) error {
-	return r.db.WithContext(ctx).Where("tenant_id = ? AND id in ?", tenantID, ids).Delete(&types.Knowledge{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND knowledge_id IN ?", tenantID, ids).
+			Delete(&types.KnowledgeTagRelation{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("tenant_id = ? AND id in ?", tenantID, ids).Delete(&types.Knowledge{}).Error
+	})
 }
 
 // GetKnowledgeBatch gets knowledge in batch
@@ -182,7 +231,8 @@ func (r *knowledgeRepository) CheckKnowledgeExists(
 	params *types.KnowledgeCheckParams,
 ) (bool, *types.Knowledge, error) {
 	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
-		Where("tenant_id = ? AND knowledge_base_id = ? AND parse_status <> ?", tenantID, kbID, "failed")
+		Where("tenant_id = ? AND knowledge_base_id = ? AND parse_status NOT IN ?", tenantID, kbID,
+			[]string{types.ParseStatusFailed, types.ParseStatusFailedPermanent})
 
 	switch params.Type {
 	case "file":
@@ -309,6 +359,25 @@ func (r *knowledgeRepository) CountKnowledgeByStatus(
 	return count, nil
 }
 
+// CountKnowledgeByTenant counts the knowledge items of a tenant, optionally of a type and with one of the parse statuses
+func (r *knowledgeRepository) CountKnowledgeByTenant(
+	ctx context.Context,
+	tenantID uint64,
+	knowledgeType string,
+	parseStatuses []string,
+) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).Where("tenant_id = ?", tenantID)
+	if knowledgeType != "" {
+		query = query.Where("type = ?", knowledgeType)
+	}
+	if len(parseStatuses) > 0 {
+		query = query.Where("parse_status IN ?", parseStatuses)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
 // SearchKnowledge searches knowledge items by keyword across the tenant
 // If keyword is empty, returns recent files
 // Only returns documents from document-type knowledge bases (excludes FAQ)
@@ -333,7 +402,7 @@ func (r *knowledgeRepository) SearchKnowledge(
 		Joins("JOIN knowledge_bases ON knowledge_bases.id = knowledges.knowledge_base_id").
 		Where("knowledges.tenant_id = ?", tenantID).
 		Where("knowledge_bases.type = ?", types.KnowledgeBaseTypeDocument).
-		Where("knowledges.deleted_at IS NULL")
+		Where("knowledges.deleted_at IS NULL AND knowledges.trashed_at IS NULL")
 
 	// If keyword is provided, filter by file_name or title
 	if keyword != "" {
@@ -519,6 +588,364 @@ func (r *knowledgeRepository) SearchKnowledgeInScopes(
 	return knowledges, hasMore, nil
 }
 
+// ListKnowledgeBaseIDsWithExpiry returns IDs of knowledge bases that contain
+// unarchived knowledge with an explicit expiration time
+func (r *knowledgeRepository) ListKnowledgeBaseIDsWithExpiry(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Model(&types.Knowledge{}).
+		Where("expires_at IS NOT NULL AND archived_at IS NULL AND trashed_at IS NULL").
+		Distinct("knowledge_base_id").
+		Pluck("knowledge_base_id", &ids).Error
+	return ids, err
+}
+
+// ListDueKnowledge lists unarchived knowledge in a knowledge base that is due for expiry.
+// Knowledge is due when its explicit expires_at is before dueBefore, or, when it has no explicit
+// expiration and createdBefore is not nil, when it was created before createdBefore.
+// When unnotifiedOnly is true, knowledge that has already been notified is skipped.
+func (r *knowledgeRepository) ListDueKnowledge(
+	ctx context.Context,
+	kbID string,
+	dueBefore time.Time,
+	createdBefore *time.Time,
+	unnotifiedOnly bool,
+	limit int,
+) ([]*types.Knowledge, error) {
+	query := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).
+		Where("knowledge_base_id = ? AND archived_at IS NULL AND trashed_at IS NULL AND parse_status != ?",
+			kbID, types.ParseStatusDeleting)
+	if createdBefore != nil {
+		query = query.Where("((expires_at IS NOT NULL AND expires_at <= ?) OR (expires_at IS NULL AND created_at <= ?))",
+			dueBefore, *createdBefore)
+	} else {
+		query = query.Where("expires_at IS NOT NULL AND expires_at <= ?", dueBefore)
+	}
+	if unnotifiedOnly {
+		query = query.Where("expiry_notified_at IS NULL")
+	}
+	var knowledges []*types.Knowledge
+	if err := query.Order("created_at ASC").Limit(limit).Find(&knowledges).Error; err != nil {
+		return nil, err
+	}
+	return knowledges, nil
+}
+
+// ListDeadLetterKnowledge lists knowledge whose parsing failed permanently, most recent first.
+// An empty kbID lists all knowledge bases of the tenant.
+func (r *knowledgeRepository) ListDeadLetterKnowledge(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	page *types.Pagination,
+) ([]*types.Knowledge, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND parse_status = ?", tenantID, types.ParseStatusFailedPermanent)
+	if kbID != "" {
+		query = query.Where("knowledge_base_id = ?", kbID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var knowledges []*types.Knowledge
+	if err := query.Order("updated_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&knowledges).Error; err != nil {
+		return nil, 0, err
+	}
+	return knowledges, total, nil
+}
+
+// ListTrashedKnowledge lists trashed knowledge in a knowledge base with pagination
+func (r *knowledgeRepository) ListTrashedKnowledge(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	page *types.Pagination,
+) ([]*types.Knowledge, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND trashed_at IS NOT NULL", tenantID, kbID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var knowledges []*types.Knowledge
+	if err := query.Order("trashed_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&knowledges).Error; err != nil {
+		return nil, 0, err
+	}
+	return knowledges, total, nil
+}
+
+// ListTrashedBefore lists knowledge of all tenants trashed before the given time
+func (r *knowledgeRepository) ListTrashedBefore(
+	ctx context.Context,
+	before time.Time,
+	limit int,
+) ([]*types.Knowledge, error) {
+	var knowledges []*types.Knowledge
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).
+		Where("trashed_at IS NOT NULL AND trashed_at <= ? AND parse_status != ?", before, types.ParseStatusDeleting).
+		Order("trashed_at ASC").
+		Limit(limit).
+		Find(&knowledges).Error; err != nil {
+		return nil, err
+	}
+	return knowledges, nil
+}
+
+// ListFingerprintedKnowledge lists the content fingerprints of live knowledge in a knowledge base,
+// oldest first, for duplicate detection
+func (r *knowledgeRepository) ListFingerprintedKnowledge(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	excludeID string,
+) ([]*types.Knowledge, error) {
+	var knowledges []*types.Knowledge
+	if err := r.db.WithContext(ctx).
+		Select("id, title, content_hash, sim_hash, duplicate_of, created_at").
+		Where("tenant_id = ? AND knowledge_base_id = ? AND id <> ? AND content_hash <> '' AND trashed_at IS NULL",
+			tenantID, kbID, excludeID).
+		Order("created_at ASC").
+		Find(&knowledges).Error; err != nil {
+		return nil, err
+	}
+	return knowledges, nil
+}
+
+// ListDuplicateKnowledge lists live knowledge in a knowledge base that was detected as a duplicate
+func (r *knowledgeRepository) ListDuplicateKnowledge(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+) ([]*types.Knowledge, error) {
+	var knowledges []*types.Knowledge
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND duplicate_of <> '' AND trashed_at IS NULL", tenantID, kbID).
+		Order("created_at ASC").
+		Find(&knowledges).Error; err != nil {
+		return nil, err
+	}
+	return knowledges, nil
+}
+
+// whereSourceURL restricts the query to live knowledge imported from a web page or a document at a URL
+func whereSourceURL(query *gorm.DB) *gorm.DB {
+	return query.Where("type IN ? AND (source LIKE ? OR source LIKE ?) AND trashed_at IS NULL AND parse_status != ?",
+		[]string{"url", "file"}, "http://%", "https://%", types.ParseStatusDeleting)
+}
+
+// ListKnowledgeBaseIDsWithSourceURL lists the knowledge bases of all tenants holding knowledge imported from a URL
+func (r *knowledgeRepository) ListKnowledgeBaseIDsWithSourceURL(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := whereSourceURL(r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Model(&types.Knowledge{})).
+		Distinct("knowledge_base_id").
+		Pluck("knowledge_base_id", &ids).Error
+	return ids, err
+}
+
+// ListLinkCheckDueKnowledge lists knowledge of a knowledge base imported from a URL whose source was never checked
+// or last checked before checkedBefore, never checked ones first
+func (r *knowledgeRepository) ListLinkCheckDueKnowledge(
+	ctx context.Context,
+	kbID string,
+	checkedBefore time.Time,
+	limit int,
+) ([]*types.Knowledge, error) {
+	var knowledges []*types.Knowledge
+	if err := whereSourceURL(r.db.WithContext(tenantguard.WithoutTenantScope(ctx))).
+		Where("knowledge_base_id = ? AND (link_checked_at IS NULL OR link_checked_at < ?)", kbID, checkedBefore).
+		Order("link_checked_at IS NOT NULL, link_checked_at ASC").
+		Limit(limit).
+		Find(&knowledges).Error; err != nil {
+		return nil, err
+	}
+	return knowledges, nil
+}
+
+// UpdateKnowledgeLinkCheck records the result of a check of the source URL of a knowledge of any tenant
+func (r *knowledgeRepository) UpdateKnowledgeLinkCheck(ctx context.Context, id string, check *types.KnowledgeLinkCheck) error {
+	return r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Model(&types.Knowledge{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"link_status":     check.Status,
+		"link_checked_at": check.CheckedAt,
+		"link_check":      check,
+	}).Error
+}
+
+// CountKnowledgeByLinkStatus counts the knowledge of a knowledge base imported from a URL by the status of their
+// source, knowledge not checked yet under an empty status
+func (r *knowledgeRepository) CountKnowledgeByLinkStatus(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+) (map[types.KnowledgeLinkStatus]int64, error) {
+	var rows []struct {
+		LinkStatus types.KnowledgeLinkStatus
+		Count      int64
+	}
+	if err := whereSourceURL(r.db.WithContext(ctx).Model(&types.Knowledge{})).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Select("COALESCE(link_status, '') AS link_status, COUNT(*) AS count").
+		Group("COALESCE(link_status, '')").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[types.KnowledgeLinkStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.LinkStatus] += row.Count
+	}
+	return counts, nil
+}
+
+// ListKnowledgeByLinkStatus lists knowledge of a knowledge base imported from a URL whose source had one of the
+// statuses at its last check, the most recently checked first
+func (r *knowledgeRepository) ListKnowledgeByLinkStatus(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	statuses []types.KnowledgeLinkStatus,
+	limit int,
+) ([]*types.Knowledge, error) {
+	var knowledges []*types.Knowledge
+	if err := whereSourceURL(r.db.WithContext(ctx)).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND link_status IN ?", tenantID, kbID, statuses).
+		Order("link_checked_at DESC").
+		Limit(limit).
+		Find(&knowledges).Error; err != nil {
+		return nil, err
+	}
+	return knowledges, nil
+}
+
+// whereNotHidden excludes the knowledge a document permission hides from the user of the request
+func (r *knowledgeRepository) whereNotHidden(ctx context.Context, query *gorm.DB, kbID string) *gorm.DB {
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+	if userID == "" {
+		return query
+	}
+	return query.Where(
+		"id NOT IN (SELECT knowledge_id FROM knowledge_permissions WHERE knowledge_base_id = ? AND user_id = ? AND role = ?)",
+		kbID, userID, types.KBRoleNone,
+	)
+}
+
+// whereInTagTree restricts the query to knowledge tagged (as primary or additional tag)
+// with any of the given tags or their descendants.
+func (r *knowledgeRepository) whereInTagTree(query *gorm.DB, tenantID uint64, tagIDs []string) *gorm.DB {
+	tagTree := r.db.Raw(tagTreeSQL, tenantID, tagIDs, tenantID)
+	return query.Where(
+		"(tag_id IN (?) OR id IN (SELECT knowledge_id FROM knowledge_tag_relations WHERE tenant_id = ? AND tag_id IN (?)))",
+		tagTree, tenantID, tagTree,
+	)
+}
+
+// ListIDsByTagTree returns the IDs of knowledge tagged with any of the given tags or their descendants
+func (r *knowledgeRepository) ListIDsByTagTree(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	tagIDs []string,
+) ([]string, error) {
+	if len(tagIDs) == 0 {
+		return []string{}, nil
+	}
+	var ids []string
+	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+	err := r.whereInTagTree(query, tenantID, tagIDs).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ListIDsByFilter returns the IDs of knowledge matching the non-tag conditions of a search filter
+func (r *knowledgeRepository) ListIDsByFilter(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	filter *types.SearchFilter,
+) ([]string, error) {
+	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+	if filter != nil {
+		query = r.whereSearchFilter(query, filter)
+	}
+	var ids []string
+	err := query.Pluck("id", &ids).Error
+	return ids, err
+}
+
+// whereSearchFilter applies the file type, language, date, metadata and source domain conditions of a filter
+func (r *knowledgeRepository) whereSearchFilter(query *gorm.DB, filter *types.SearchFilter) *gorm.DB {
+	if len(filter.FileTypes) > 0 {
+		var fileTypes, knowledgeTypes []string
+		for _, fileType := range filter.FileTypes {
+			fileType = strings.ToLower(strings.TrimSpace(fileType))
+			switch fileType {
+			case "":
+			case "url", "manual":
+				knowledgeTypes = append(knowledgeTypes, fileType)
+			default:
+				fileTypes = append(fileTypes, fileType)
+			}
+		}
+		switch {
+		case len(fileTypes) > 0 && len(knowledgeTypes) > 0:
+			query = query.Where("(LOWER(file_type) IN (?) OR type IN (?))", fileTypes, knowledgeTypes)
+		case len(fileTypes) > 0:
+			query = query.Where("LOWER(file_type) IN (?)", fileTypes)
+		case len(knowledgeTypes) > 0:
+			query = query.Where("type IN (?)", knowledgeTypes)
+		}
+	}
+	if len(filter.Languages) > 0 {
+		var fileTypes []string
+		for _, language := range filter.Languages {
+			fileTypes = append(fileTypes, types.CodeLanguageFileTypes(language)...)
+		}
+		// Validate rejects languages without file types, fileTypes is never empty
+		query = query.Where("LOWER(file_type) IN (?)", fileTypes)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedBefore)
+	}
+
+	// 根据数据库类型使用不同的 JSON 查询语法，键名已由 SearchFilter.Validate 校验
+	isPostgres := r.db.Dialector.Name() == "postgres"
+	for key, value := range filter.Metadata {
+		if isPostgres {
+			query = query.Where("metadata->>CAST(? AS TEXT) = ?", key, value)
+		} else {
+			query = query.Where("JSON_UNQUOTE(JSON_EXTRACT(metadata, ?)) = ?", fmt.Sprintf(`$."%s"`, key), value)
+		}
+	}
+
+	if len(filter.SourceDomains) > 0 {
+		var conditions []string
+		var args []interface{}
+		for _, domain := range filter.SourceDomains {
+			domain = strings.ToLower(strings.TrimSpace(domain))
+			// Match the host exactly, with a port, or as a subdomain
+			for _, pattern := range []string{"%://" + domain, "%://" + domain + "/%", "%://" + domain + ":%",
+				"%." + domain, "%." + domain + "/%", "%." + domain + ":%"} {
+				conditions = append(conditions, "LOWER(source) LIKE ?")
+				args = append(args, pattern)
+			}
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	return query
+}
+
 // ListIDsByTagID returns all knowledge IDs that have the specified tag ID
 func (r *knowledgeRepository) ListIDsByTagID(
 	ctx context.Context,
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// ErrKnowledgeFileLinkNotFound is returned when a signed link to a knowledge file does not exist
+var ErrKnowledgeFileLinkNotFound = errors.New("knowledge file link not found")
+
+// knowledgeFileLinkRepository implements KnowledgeFileLinkRepository interface
+type knowledgeFileLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewKnowledgeFileLinkRepository creates a new knowledge file link repository
+func NewKnowledgeFileLinkRepository(db *gorm.DB) interfaces.KnowledgeFileLinkRepository {
+	return &knowledgeFileLinkRepository{db: db}
+}
+
+// CreateLink creates a link
+func (r *knowledgeFileLinkRepository) CreateLink(ctx context.Context, link *types.KnowledgeFileLink) error {
+	return r.db.WithContext(ctx).Create(link).Error
+}
+
+// GetLink gets a link of a tenant by ID
+func (r *knowledgeFileLinkRepository) GetLink(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.KnowledgeFileLink, error) {
+	var link types.KnowledgeFileLink
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKnowledgeFileLinkNotFound
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListLinks lists the links to a knowledge of a tenant, newest first
+func (r *knowledgeFileLinkRepository) ListLinks(ctx context.Context,
+	tenantID uint64, knowledgeID string,
+) ([]*types.KnowledgeFileLink, error) {
+	var links []*types.KnowledgeFileLink
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID).
+		Order("created_at DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// RevokeLink revokes a link to a knowledge of a tenant, revoking it again keeps the first revocation time
+func (r *knowledgeFileLinkRepository) RevokeLink(ctx context.Context,
+	tenantID uint64, knowledgeID string, id string,
+) error {
+	result := r.db.WithContext(ctx).Model(&types.KnowledgeFileLink{}).
+		Where("tenant_id = ? AND knowledge_id = ? AND id = ?", tenantID, knowledgeID, id).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKnowledgeFileLinkNotFound
+	}
+	return nil
+}
+
+// ConsumeDownload counts a fetch through a link. It reports false, without counting, when the link is revoked,
+// expired or out of downloads; concurrent fetches cannot exceed the limit.
+func (r *knowledgeFileLinkRepository) ConsumeDownload(ctx context.Context, tenantID uint64, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&types.KnowledgeFileLink{}).
+		Where("tenant_id = ? AND id = ? AND revoked_at IS NULL AND expires_at > ?", tenantID, id, time.Now()).
+		Where("max_downloads = 0 OR download_count < max_downloads").
+		UpdateColumn("download_count", gorm.Expr("download_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
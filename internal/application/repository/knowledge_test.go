@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestWhereSearchFilter(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &knowledgeRepository{db: db}
+
+	cases := []struct {
+		name   string
+		filter *types.SearchFilter
+		want   string
+	}{
+		{"file types", &types.SearchFilter{FileTypes: []string{"PDF", " docx "}},
+			`LOWER(file_type) IN ("pdf","docx")`},
+		{"knowledge types", &types.SearchFilter{FileTypes: []string{"url"}},
+			`type IN ("url")`},
+		{"both", &types.SearchFilter{FileTypes: []string{"pdf", "manual"}},
+			`(LOWER(file_type) IN ("pdf") OR type IN ("manual"))`},
+		{"languages", &types.SearchFilter{Languages: []string{"go"}},
+			`LOWER(file_type) IN ("go")`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return r.whereSearchFilter(tx, tc.filter).Find(&[]types.Knowledge{})
+			})
+			if !strings.Contains(sql, tc.want) {
+				t.Errorf("SQL = %s, want it to contain %s", sql, tc.want)
+			}
+			if strings.Contains(sql, `""`) {
+				t.Errorf("SQL = %s, matches empty file types", sql)
+			}
+		})
+	}
+}
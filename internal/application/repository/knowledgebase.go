@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/Tencent/WeKnora/internal/tenantguard"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"gorm.io/gorm"
@@ -29,7 +30,7 @@ func (r *knowledgeBaseRepository) CreateKnowledgeBase(ctx context.Context, kb *t
 // GetKnowledgeBaseByID gets a knowledge base by id (no tenant scope; caller must enforce isolation where needed)
 func (r *knowledgeBaseRepository) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
 	var kb types.KnowledgeBase
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&kb).Error; err != nil {
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Where("id = ?", id).First(&kb).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrKnowledgeBaseNotFound
 		}
@@ -50,13 +51,13 @@ func (r *knowledgeBaseRepository) GetKnowledgeBaseByIDAndTenant(ctx context.Cont
 	return &kb, nil
 }
 
-// GetKnowledgeBaseByIDs gets knowledge bases by multiple ids
+// GetKnowledgeBaseByIDs gets knowledge bases of any tenant by multiple ids (no tenant scope; caller must enforce isolation)
 func (r *knowledgeBaseRepository) GetKnowledgeBaseByIDs(ctx context.Context, ids []string) ([]*types.KnowledgeBase, error) {
 	if len(ids) == 0 {
 		return []*types.KnowledgeBase{}, nil
 	}
 	var kbs []*types.KnowledgeBase
-	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&kbs).Error; err != nil {
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Where("id IN ?", ids).Find(&kbs).Error; err != nil {
 		return nil, err
 	}
 	return kbs, nil
@@ -83,12 +84,46 @@ func (r *knowledgeBaseRepository) ListKnowledgeBasesByTenantID(
 	return kbs, nil
 }
 
+// CountKnowledgeBasesByTenantID counts the knowledge bases of a tenant, excluding temporary ones
+func (r *knowledgeBaseRepository) CountKnowledgeBasesByTenantID(ctx context.Context, tenantID uint64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&types.KnowledgeBase{}).
+		Where("tenant_id = ? AND is_temporary = ?", tenantID, false).
+		Count(&count).Error
+	return count, err
+}
+
 // UpdateKnowledgeBase updates a knowledge base
 func (r *knowledgeBaseRepository) UpdateKnowledgeBase(ctx context.Context, kb *types.KnowledgeBase) error {
 	return r.db.WithContext(ctx).Save(kb).Error
 }
 
-// DeleteKnowledgeBase deletes a knowledge base
+// SwitchEmbeddingModel points a knowledge base and all of its knowledge at a new embedding model in one transaction
+func (r *knowledgeBaseRepository) SwitchEmbeddingModel(
+	ctx context.Context, tenantID uint64, kbID string, modelID string,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&types.KnowledgeBase{}).
+			Where("id = ? AND tenant_id = ?", kbID, tenantID).
+			Update("embedding_model_id", modelID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrKnowledgeBaseNotFound
+		}
+		return tx.Model(&types.Knowledge{}).
+			Where("knowledge_base_id = ? AND tenant_id = ?", kbID, tenantID).
+			Update("embedding_model_id", modelID).Error
+	})
+}
+
+// DeleteKnowledgeBase deletes a knowledge base and the tag assignments of its knowledge
 func (r *knowledgeBaseRepository) DeleteKnowledgeBase(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&types.KnowledgeBase{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("knowledge_base_id = ?", id).Delete(&types.KnowledgeTagRelation{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&types.KnowledgeBase{}).Error
+	})
 }
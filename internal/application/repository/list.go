@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+)
+
+// applyListQuery sorts a listing by the field of the query then id, and restricts it to the page of the query with
+// one more row than the limit, which tells whether more rows follow. Pages after a cursor are found by comparing
+// the sort key, so they cost the same at any depth.
+func applyListQuery(db *gorm.DB, listQuery *types.ListQuery) (*gorm.DB, error) {
+	expr := listQuery.Field.SQL()
+	op, direction := ">", "ASC"
+	if listQuery.Desc {
+		op, direction = "<", "DESC"
+	}
+	if listQuery.After != nil {
+		value, err := listQuery.CursorValue(listQuery.After)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", expr, op), value, listQuery.After.ID)
+	} else {
+		db = db.Offset(listQuery.Offset)
+	}
+	return db.Order(fmt.Sprintf("%s %s, id %s", expr, direction, direction)).Limit(listQuery.Limit + 1), nil
+}
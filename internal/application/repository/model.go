@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/Tencent/WeKnora/internal/tenantguard"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"gorm.io/gorm"
@@ -67,7 +68,22 @@ func (r *modelRepository) Update(ctx context.Context, m *types.Model) error {
 	// Use Select to explicitly update all fields, including zero values like false
 	return r.db.WithContext(ctx).Debug().Model(&types.Model{}).Where(
 		"id = ? AND tenant_id = ?", m.ID, m.TenantID,
-	).Select("*").Updates(m).Error
+	).Select("*").Omit("last_probe").Updates(m).Error
+}
+
+// ListActive lists the active models of all tenants
+func (r *modelRepository) ListActive(ctx context.Context) ([]*types.Model, error) {
+	var models []*types.Model
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Where("status = ?", types.ModelStatusActive).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+// UpdateProbeResult saves the result of the health probe of a model of any tenant without touching its other fields
+func (r *modelRepository) UpdateProbeResult(ctx context.Context, id string, result *types.ModelProbeResult) error {
+	return r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Model(&types.Model{}).Where("id = ?", id).
+		UpdateColumn("last_probe", result).Error
 }
 
 // Delete deletes a model
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+var ErrModerationEventNotFound = errors.New("moderation event not found")
+
+// moderationRepository implements the ModerationRepository interface
+type moderationRepository struct {
+	db *gorm.DB
+}
+
+// NewModerationRepository creates a new moderation repository
+func NewModerationRepository(db *gorm.DB) interfaces.ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+// Create inserts a moderated event
+func (r *moderationRepository) Create(ctx context.Context, moderationEvent *types.ModerationEvent) error {
+	return r.db.WithContext(ctx).Create(moderationEvent).Error
+}
+
+// Get returns a moderated event of a tenant
+func (r *moderationRepository) Get(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.ModerationEvent, error) {
+	var moderationEvent types.ModerationEvent
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&moderationEvent).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrModerationEventNotFound
+		}
+		return nil, err
+	}
+	return &moderationEvent, nil
+}
+
+// Update saves a moderated event
+func (r *moderationRepository) Update(ctx context.Context, moderationEvent *types.ModerationEvent) error {
+	return r.db.WithContext(ctx).Save(moderationEvent).Error
+}
+
+// List returns a page of the moderated events of a tenant, newest first
+func (r *moderationRepository) List(ctx context.Context, tenantID uint64,
+	filter *types.ModerationEventFilter,
+) ([]*types.ModerationEvent, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.ModerationEvent{}).Where("tenant_id = ?", tenantID)
+	if filter.Stage != "" {
+		query = query.Where("stage = ?", filter.Stage)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ReviewStatus != "" {
+		query = query.Where("review_status = ?", filter.ReviewStatus)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []*types.ModerationEvent
+	if err := query.Order("created_at DESC").
+		Offset(filter.Offset()).Limit(filter.Limit()).
+		Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
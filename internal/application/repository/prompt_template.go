@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+var ErrPromptTemplateNotFound = errors.New("prompt template not found")
+
+// promptTemplateRepository implements the PromptTemplateRepository interface
+type promptTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewPromptTemplateRepository creates a new prompt template repository
+func NewPromptTemplateRepository(db *gorm.DB) interfaces.PromptTemplateRepository {
+	return &promptTemplateRepository{db: db}
+}
+
+// Create creates a template as the next version of its knowledge base
+func (r *promptTemplateRepository) Create(ctx context.Context, template *types.KBPromptTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&types.KBPromptTemplate{}).
+			Where("knowledge_base_id = ?", template.KnowledgeBaseID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+		template.Version = latest + 1
+		return tx.Create(template).Error
+	})
+}
+
+// Get gets a template by id within a tenant
+func (r *promptTemplateRepository) Get(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.KBPromptTemplate, error) {
+	var template types.KBPromptTemplate
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromptTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// List lists the templates of a knowledge base, newest version first
+func (r *promptTemplateRepository) List(ctx context.Context,
+	tenantID uint64, knowledgeBaseID string,
+) ([]*types.KBPromptTemplate, error) {
+	var templates []*types.KBPromptTemplate
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		Order("version DESC").
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Update saves a template
+func (r *promptTemplateRepository) Update(ctx context.Context, template *types.KBPromptTemplate) error {
+	return r.db.WithContext(ctx).Save(template).Error
+}
+
+// Delete deletes a template
+func (r *promptTemplateRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&types.KBPromptTemplate{}).Error
+}
+
+// GetPublished returns the published version of a knowledge base, nil when there is none
+func (r *promptTemplateRepository) GetPublished(ctx context.Context,
+	knowledgeBaseID string,
+) (*types.KBPromptTemplate, error) {
+	var template types.KBPromptTemplate
+	err := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND status = ?", knowledgeBaseID, types.PromptTemplatePublished).
+		Order("published_at DESC").
+		First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Publish publishes a version and archives the other published versions of its knowledge base
+func (r *promptTemplateRepository) Publish(ctx context.Context, template *types.KBPromptTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&types.KBPromptTemplate{}).
+			Where("knowledge_base_id = ? AND status = ? AND id <> ?",
+				template.KnowledgeBaseID, types.PromptTemplatePublished, template.ID).
+			Update("status", types.PromptTemplateArchived).Error; err != nil {
+			return err
+		}
+		return tx.Save(template).Error
+	})
+}
+
+// ArchivePublished archives the published version of a knowledge base
+func (r *promptTemplateRepository) ArchivePublished(ctx context.Context, knowledgeBaseID string) error {
+	return r.db.WithContext(ctx).Model(&types.KBPromptTemplate{}).
+		Where("knowledge_base_id = ? AND status = ?", knowledgeBaseID, types.PromptTemplatePublished).
+		Update("status", types.PromptTemplateArchived).Error
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRetrievalEvalSetNotFound = errors.New("retrieval eval set not found")
+	ErrRetrievalEvalRunNotFound = errors.New("retrieval eval run not found")
+)
+
+// retrievalEvalRepository implements the RetrievalEvalRepository interface
+type retrievalEvalRepository struct {
+	db *gorm.DB
+}
+
+// NewRetrievalEvalRepository creates a new retrieval evaluation repository
+func NewRetrievalEvalRepository(db *gorm.DB) interfaces.RetrievalEvalRepository {
+	return &retrievalEvalRepository{db: db}
+}
+
+// CreateEvalSet creates a golden question set
+func (r *retrievalEvalRepository) CreateEvalSet(ctx context.Context, set *types.RetrievalEvalSet) error {
+	return r.db.WithContext(ctx).Create(set).Error
+}
+
+// GetEvalSet gets a golden question set by id within a tenant
+func (r *retrievalEvalRepository) GetEvalSet(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.RetrievalEvalSet, error) {
+	var set types.RetrievalEvalSet
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&set).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRetrievalEvalSetNotFound
+		}
+		return nil, err
+	}
+	return &set, nil
+}
+
+// ListEvalSets lists the golden question sets of a knowledge base, newest first
+func (r *retrievalEvalRepository) ListEvalSets(ctx context.Context,
+	tenantID uint64, knowledgeBaseID string,
+) ([]*types.RetrievalEvalSet, error) {
+	var sets []*types.RetrievalEvalSet
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		Order("created_at DESC").
+		Find(&sets).Error; err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+// UpdateEvalSet saves a golden question set
+func (r *retrievalEvalRepository) UpdateEvalSet(ctx context.Context, set *types.RetrievalEvalSet) error {
+	return r.db.WithContext(ctx).Save(set).Error
+}
+
+// DeleteEvalSet deletes a golden question set together with its runs
+func (r *retrievalEvalRepository) DeleteEvalSet(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND eval_set_id = ?", tenantID, id).
+			Delete(&types.RetrievalEvalRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.RetrievalEvalSet{}).Error
+	})
+}
+
+// CreateEvalRun creates an evaluation run
+func (r *retrievalEvalRepository) CreateEvalRun(ctx context.Context, run *types.RetrievalEvalRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// GetEvalRun gets an evaluation run by id within a tenant
+func (r *retrievalEvalRepository) GetEvalRun(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.RetrievalEvalRun, error) {
+	var run types.RetrievalEvalRun
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRetrievalEvalRunNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListEvalRuns lists the runs of a golden question set newest first, without per case results
+func (r *retrievalEvalRepository) ListEvalRuns(ctx context.Context,
+	tenantID uint64, setID string,
+) ([]*types.RetrievalEvalRun, error) {
+	var runs []*types.RetrievalEvalRun
+	if err := r.db.WithContext(ctx).
+		Omit("results").
+		Where("tenant_id = ? AND eval_set_id = ?", tenantID, setID).
+		Order("created_at DESC").
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// UpdateEvalRun saves an evaluation run
+func (r *retrievalEvalRepository) UpdateEvalRun(ctx context.Context, run *types.RetrievalEvalRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
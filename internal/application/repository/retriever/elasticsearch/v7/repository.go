@@ -1296,3 +1296,16 @@ func (e *elasticsearchRepository) BatchUpdateChunkTagID(
 	log.Infof("[ElasticsearchV7] Successfully batch updated chunk tag ID")
 	return nil
 }
+
+// Ping checks the Elasticsearch cluster answers
+func (e *elasticsearchRepository) Ping(ctx context.Context) error {
+	res, err := e.client.Ping(e.client.Ping.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch ping failed: %s", res.Status())
+	}
+	return nil
+}
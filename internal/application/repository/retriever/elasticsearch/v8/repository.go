@@ -742,3 +742,15 @@ func (e *elasticsearchRepository) BatchUpdateChunkTagID(
 	log.Infof("[Elasticsearch] Successfully batch updated chunk tag ID")
 	return nil
 }
+
+// Ping checks the Elasticsearch cluster answers
+func (e *elasticsearchRepository) Ping(ctx context.Context) error {
+	ok, err := e.client.Ping().Do(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("elasticsearch ping failed")
+	}
+	return nil
+}
@@ -146,6 +146,48 @@ func (g *pgRepository) DeleteByKnowledgeIDList(ctx context.Context, knowledgeIDL
 	return nil
 }
 
+// SeparatesDimensions reports that vectors keep their dimension in a column of their own
+func (g *pgRepository) SeparatesDimensions() bool {
+	return true
+}
+
+// DeleteByKnowledgeIDListInDimension deletes the indices of the knowledge stored with the given dimension
+func (g *pgRepository) DeleteByKnowledgeIDListInDimension(ctx context.Context,
+	knowledgeIDList []string, dimension int,
+) error {
+	if len(knowledgeIDList) == 0 {
+		return nil
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Deleting indices by knowledge IDs in dimension %d, count: %d",
+		dimension, len(knowledgeIDList))
+	result := g.db.WithContext(ctx).
+		Where("knowledge_id IN ? AND dimension = ?", knowledgeIDList, dimension).
+		Delete(&pgVector{})
+	if result.Error != nil {
+		logger.GetLogger(ctx).Errorf("[Postgres] Failed to delete indices by knowledge IDs in dimension %d: %v",
+			dimension, result.Error)
+		return result.Error
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Successfully deleted %d indices by knowledge IDs in dimension %d",
+		result.RowsAffected, dimension)
+	return nil
+}
+
+// GetEmbeddingsByKnowledgeID returns the stored vectors of a knowledge keyed by source ID
+func (g *pgRepository) GetEmbeddingsByKnowledgeID(ctx context.Context, knowledgeID string) (map[string][]float32, error) {
+	var rows []pgVector
+	if err := g.db.WithContext(ctx).Select("source_id", "embedding").
+		Where("knowledge_id = ?", knowledgeID).Find(&rows).Error; err != nil {
+		logger.GetLogger(ctx).Errorf("[Postgres] Failed to read embeddings of knowledge %s: %v", knowledgeID, err)
+		return nil, err
+	}
+	embeddings := make(map[string][]float32, len(rows))
+	for _, row := range rows {
+		embeddings[row.SourceID] = row.Embedding.Slice()
+	}
+	return embeddings, nil
+}
+
 // Retrieve handles retrieval requests and routes to appropriate method
 func (g *pgRepository) Retrieve(ctx context.Context, params types.RetrieveParams) ([]*types.RetrieveResult, error) {
 	logger.GetLogger(ctx).Debugf("[Postgres] Processing retrieval request of type: %s", params.RetrieverType)
@@ -185,6 +227,12 @@ func (g *pgRepository) KeywordsRetrieve(ctx context.Context,
 			Values: common.ToInterfaceSlice(params.KnowledgeIDs),
 		})
 	}
+	if len(params.ExcludeKnowledgeIDs) > 0 {
+		conds = append(conds, clause.Expr{
+			SQL:  "knowledge_id NOT IN ?",
+			Vars: []interface{}{params.ExcludeKnowledgeIDs},
+		})
+	}
 	// Filter by tag IDs if specified
 	if len(params.TagIDs) > 0 {
 		logger.GetLogger(ctx).Debugf("[Postgres] Filtering by tag IDs: %v", params.TagIDs)
@@ -194,8 +242,8 @@ func (g *pgRepository) KeywordsRetrieve(ctx context.Context,
 		})
 	}
 	conds = append(conds, clause.Expr{
-		SQL:  "id @@@ paradedb.match(field => 'content', value => ?, distance => 1)",
-		Vars: []interface{}{params.Query},
+		SQL:  "id @@@ paradedb.match(field => ?, value => ?, distance => 1)",
+		Vars: []interface{}{keywordSearchField(params.KeywordAnalyzer), params.Query},
 	})
 	// Filter by is_enabled = true or NULL (NULL means enabled for historical data)
 	conds = append(conds, clause.Expr{
@@ -248,6 +296,21 @@ func (g *pgRepository) KeywordsRetrieve(ctx context.Context,
 	}, nil
 }
 
+// keywordSearchField returns the field of the BM25 index that content is tokenized into by
+// the analyzer, the index holds one field per analyzer over the same content column
+func keywordSearchField(analyzer types.KeywordAnalyzer) string {
+	switch analyzer {
+	case types.KeywordAnalyzerChinese:
+		return "content_jieba"
+	case types.KeywordAnalyzerEnglish:
+		return "content_english"
+	case types.KeywordAnalyzerCode:
+		return "content_code"
+	default:
+		return "content"
+	}
+}
+
 // VectorRetrieve performs vector similarity search using pgvector
 // Optimized to use HNSW index efficiently and avoid recalculating vector distance
 func (g *pgRepository) VectorRetrieve(ctx context.Context,
@@ -302,6 +365,16 @@ func (g *pgRepository) VectorRetrieve(ctx context.Context,
 		whereParts = append(whereParts, fmt.Sprintf("knowledge_id IN (%s)",
 			strings.Join(placeholders, ", ")))
 	}
+	if len(params.ExcludeKnowledgeIDs) > 0 {
+		placeholders := make([]string, len(params.ExcludeKnowledgeIDs))
+		paramStart := len(allVars) + 1
+		for i := range params.ExcludeKnowledgeIDs {
+			placeholders[i] = fmt.Sprintf("$%d", paramStart+i)
+			allVars = append(allVars, params.ExcludeKnowledgeIDs[i])
+		}
+		whereParts = append(whereParts, fmt.Sprintf("knowledge_id NOT IN (%s)",
+			strings.Join(placeholders, ", ")))
+	}
 	// Filter by tag IDs if specified
 	if len(params.TagIDs) > 0 {
 		logger.GetLogger(ctx).Debugf(
@@ -617,3 +690,8 @@ func (g *pgRepository) BatchUpdateChunkTagID(ctx context.Context, chunkTagMap ma
 	logger.GetLogger(ctx).Infof("[Postgres] Successfully batch updated chunk tag ID")
 	return nil
 }
+
+// Ping checks the database answers
+func (r *pgRepository) Ping(ctx context.Context) error {
+	return r.db.WithContext(ctx).Exec("SELECT 1").Error
+}
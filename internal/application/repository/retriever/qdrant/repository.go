@@ -322,6 +322,18 @@ func (q *qdrantRepository) DeleteByKnowledgeIDList(ctx context.Context,
 	return nil
 }
 
+// SeparatesDimensions reports that every dimension has a collection of its own
+func (q *qdrantRepository) SeparatesDimensions() bool {
+	return true
+}
+
+// DeleteByKnowledgeIDListInDimension removes the points of the knowledge from the collection of the dimension
+func (q *qdrantRepository) DeleteByKnowledgeIDListInDimension(ctx context.Context,
+	knowledgeIDList []string, dimension int,
+) error {
+	return q.DeleteByKnowledgeIDList(ctx, knowledgeIDList, dimension, "")
+}
+
 // DeleteBySourceIDList removes points from the collection based on source IDs
 func (q *qdrantRepository) DeleteBySourceIDList(ctx context.Context,
 	sourceIDList []string, dimension int, knowledgeType string,
@@ -978,3 +990,9 @@ func tokenizeQuery(query string) []string {
 
 	return result
 }
+
+// Ping checks the Qdrant server answers
+func (q *qdrantRepository) Ping(ctx context.Context) error {
+	_, err := q.client.HealthCheck(ctx)
+	return err
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+var ErrSearchLogNotFound = errors.New("search log not found")
+
+// searchLogRepository implements the SearchLogRepository interface
+type searchLogRepository struct {
+	db *gorm.DB
+}
+
+// NewSearchLogRepository creates a new search log repository
+func NewSearchLogRepository(db *gorm.DB) interfaces.SearchLogRepository {
+	return &searchLogRepository{db: db}
+}
+
+// CreateSearchLogs inserts search logs
+func (r *searchLogRepository) CreateSearchLogs(ctx context.Context, logs []*types.SearchLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(logs).Error
+}
+
+// GetSearchLogByMessage returns the search log of a knowledge base answered by messageID
+func (r *searchLogRepository) GetSearchLogByMessage(ctx context.Context,
+	knowledgeBaseID, messageID string,
+) (*types.SearchLog, error) {
+	var log types.SearchLog
+	if err := r.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND message_id = ?", knowledgeBaseID, messageID).
+		First(&log).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSearchLogNotFound
+		}
+		return nil, err
+	}
+	return &log, nil
+}
+
+// UpdateSearchLog saves a search log
+func (r *searchLogRepository) UpdateSearchLog(ctx context.Context, log *types.SearchLog) error {
+	return r.db.WithContext(ctx).Save(log).Error
+}
+
+// GetSearchSummary aggregates the searches of a knowledge base since the given time
+func (r *searchLogRepository) GetSearchSummary(ctx context.Context, knowledgeBaseID string, since time.Time,
+	lowConfidenceThreshold float64,
+) (*types.SearchSummary, error) {
+	var summary types.SearchSummary
+	if err := r.db.WithContext(ctx).Model(&types.SearchLog{}).
+		Select(`COUNT(*) AS total_searches,
+			COALESCE(SUM(CASE WHEN hit_count = 0 THEN 1 ELSE 0 END), 0) AS zero_result_searches,
+			COALESCE(SUM(CASE WHEN hit_count > 0 AND top_score < ? THEN 1 ELSE 0 END), 0) AS low_confidence_searches,
+			COALESCE(SUM(click_count), 0) AS clicks,
+			COALESCE(SUM(CASE WHEN feedback = ? THEN 1 ELSE 0 END), 0) AS positive_feedback,
+			COALESCE(SUM(CASE WHEN feedback = ? THEN 1 ELSE 0 END), 0) AS negative_feedback`,
+			lowConfidenceThreshold, types.SearchFeedbackPositive, types.SearchFeedbackNegative).
+		Where("knowledge_base_id = ? AND created_at >= ?", knowledgeBaseID, since).
+		Scan(&summary).Error; err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// ListQueryStats aggregates the searches of a knowledge base by query, most frequent first
+func (r *searchLogRepository) ListQueryStats(ctx context.Context, knowledgeBaseID string, since time.Time,
+	kind types.SearchQueryKind, lowConfidenceThreshold float64, limit int,
+) ([]*types.SearchQueryStat, error) {
+	query := r.db.WithContext(ctx).Model(&types.SearchLog{}).
+		Select(`query_hash, MAX(query) AS query, COUNT(*) AS count,
+			AVG(hit_count) AS avg_hit_count, AVG(top_score) AS avg_top_score,
+			COALESCE(SUM(click_count), 0) AS clicks,
+			COALESCE(SUM(CASE WHEN feedback = ? THEN 1 ELSE 0 END), 0) AS positive_feedback,
+			COALESCE(SUM(CASE WHEN feedback = ? THEN 1 ELSE 0 END), 0) AS negative_feedback,
+			MAX(created_at) AS last_searched_at`,
+			types.SearchFeedbackPositive, types.SearchFeedbackNegative).
+		Where("knowledge_base_id = ? AND created_at >= ?", knowledgeBaseID, since)
+	switch kind {
+	case types.SearchQueryKindZeroResult:
+		query = query.Where("hit_count = 0")
+	case types.SearchQueryKindLowConfidence:
+		query = query.Where("hit_count > 0 AND top_score < ?", lowConfidenceThreshold)
+	}
+
+	var stats []*types.SearchQueryStat
+	if err := query.Group("query_hash").
+		Order("count DESC, last_searched_at DESC").
+		Limit(limit).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetExperimentStats aggregates the searches attributed to an experiment by variant
+func (r *searchLogRepository) GetExperimentStats(ctx context.Context,
+	knowledgeBaseID, experimentID string,
+) ([]*types.ExperimentVariantStats, error) {
+	var stats []*types.ExperimentVariantStats
+	if err := r.db.WithContext(ctx).Model(&types.SearchLog{}).
+		Select(`variant, COUNT(*) AS searches,
+			COALESCE(SUM(CASE WHEN hit_count = 0 THEN 1 ELSE 0 END), 0) AS zero_result_searches,
+			COALESCE(AVG(latency_ms), 0) AS avg_latency_ms,
+			COALESCE(SUM(click_count), 0) AS clicks,
+			COALESCE(SUM(CASE WHEN click_count > 0 THEN 1 ELSE 0 END), 0) AS clicked_searches,
+			COALESCE(SUM(CASE WHEN feedback = ? THEN 1 ELSE 0 END), 0) AS positive_feedback,
+			COALESCE(SUM(CASE WHEN feedback = ? THEN 1 ELSE 0 END), 0) AS negative_feedback`,
+			types.SearchFeedbackPositive, types.SearchFeedbackNegative).
+		Where("knowledge_base_id = ? AND experiment_id = ?", knowledgeBaseID, experimentID).
+		Group("variant").
+		Order("variant").
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// ErrServiceAccountNotFound is returned when a service account does not exist
+var ErrServiceAccountNotFound = errors.New("service account not found")
+
+// serviceAccountRepository implements ServiceAccountRepository interface
+type serviceAccountRepository struct {
+	db *gorm.DB
+}
+
+// NewServiceAccountRepository creates a new service account repository
+func NewServiceAccountRepository(db *gorm.DB) interfaces.ServiceAccountRepository {
+	return &serviceAccountRepository{db: db}
+}
+
+// CreateServiceAccount creates a service account
+func (r *serviceAccountRepository) CreateServiceAccount(ctx context.Context, account *types.ServiceAccount) error {
+	return r.db.WithContext(ctx).Create(account).Error
+}
+
+// GetServiceAccountByID gets a service account of a tenant by ID
+func (r *serviceAccountRepository) GetServiceAccountByID(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.ServiceAccount, error) {
+	var account types.ServiceAccount
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListServiceAccounts lists the service accounts of a tenant, newest first
+func (r *serviceAccountRepository) ListServiceAccounts(ctx context.Context,
+	tenantID uint64,
+) ([]*types.ServiceAccount, error) {
+	var accounts []*types.ServiceAccount
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// UpdateServiceAccount updates a service account
+func (r *serviceAccountRepository) UpdateServiceAccount(ctx context.Context, account *types.ServiceAccount) error {
+	return r.db.WithContext(ctx).Save(account).Error
+}
+
+// DeleteServiceAccount deletes a service account of a tenant
+func (r *serviceAccountRepository) DeleteServiceAccount(ctx context.Context, tenantID uint64, id string) error {
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&types.ServiceAccount{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrServiceAccountNotFound
+	}
+	return nil
+}
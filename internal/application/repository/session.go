@@ -77,6 +77,32 @@ func (r *sessionRepository) GetPagedByTenantID(
 	return sessions, total, nil
 }
 
+// ListPageByTenantID lists a page of the sessions of a tenant by the sort order of the query, with one more row than
+// the limit when more follow. The total is nil when the query does not count it.
+func (r *sessionRepository) ListPageByTenantID(
+	ctx context.Context, tenantID uint64, listQuery *types.ListQuery,
+) ([]*types.Session, *int64, error) {
+	var total *int64
+	if listQuery.CountTotal {
+		var count int64
+		err := r.db.WithContext(ctx).Model(&types.Session{}).Where("tenant_id = ?", tenantID).Count(&count).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		total = &count
+	}
+
+	query, err := applyListQuery(r.db.WithContext(ctx).Where("tenant_id = ?", tenantID), listQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	var sessions []*types.Session
+	if err := query.Find(&sessions).Error; err != nil {
+		return nil, nil, err
+	}
+	return sessions, total, nil
+}
+
 // Update updates a session
 func (r *sessionRepository) Update(ctx context.Context, session *types.Session) error {
 	session.UpdatedAt = time.Now()
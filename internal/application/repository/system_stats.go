@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/tenantguard"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// tenantStatsQuery aggregates the documents, storage, usage, sessions and parsing of each tenant. Parses are
+// counted on the knowledges that reached a final status in the period.
+const tenantStatsQuery = `SELECT t.id AS tenant_id, t.name, t.status, t.storage_used, t.storage_quota,
+	COALESCE(k.documents, 0) AS documents,
+	COALESCE(k.parse_completed, 0) AS parse_completed,
+	COALESCE(k.parse_failed, 0) AS parse_failed,
+	COALESCE(u.requests, 0) AS requests,
+	COALESCE(u.total_tokens, 0) AS total_tokens,
+	COALESCE(u.cost, 0) AS cost,
+	COALESCE(s.active_sessions, 0) AS active_sessions
+FROM tenants t
+LEFT JOIN (
+	SELECT tenant_id,
+		COUNT(*) FILTER (WHERE trashed_at IS NULL) AS documents,
+		COUNT(*) FILTER (WHERE parse_status = 'completed'
+			AND updated_at >= @from AND updated_at < @to) AS parse_completed,
+		COUNT(*) FILTER (WHERE parse_status IN ('failed', 'failed_permanent')
+			AND updated_at >= @from AND updated_at < @to) AS parse_failed
+	FROM knowledges WHERE deleted_at IS NULL GROUP BY tenant_id
+) k ON k.tenant_id = t.id
+LEFT JOIN (
+	SELECT tenant_id, COUNT(*) AS requests, SUM(total_tokens) AS total_tokens, SUM(cost) AS cost
+	FROM token_usage_records WHERE created_at >= @from AND created_at < @to GROUP BY tenant_id
+) u ON u.tenant_id = t.id
+LEFT JOIN (
+	SELECT ss.tenant_id, COUNT(DISTINCT m.session_id) AS active_sessions
+	FROM messages m JOIN sessions ss ON ss.id = m.session_id
+	WHERE m.created_at >= @from AND m.created_at < @to AND m.deleted_at IS NULL
+	GROUP BY ss.tenant_id
+) s ON s.tenant_id = t.id
+WHERE t.deleted_at IS NULL`
+
+// tenantStatsOrder maps the columns tenants can be ranked by to their SQL ordering
+var tenantStatsOrder = map[types.TenantStatsSort]string{
+	types.TenantStatsSortDocuments:      "documents DESC",
+	types.TenantStatsSortStorage:        "storage_used DESC",
+	types.TenantStatsSortTokens:         "total_tokens DESC",
+	types.TenantStatsSortActiveSessions: "active_sessions DESC",
+	types.TenantStatsSortParseFailed:    "parse_failed DESC",
+}
+
+// systemStatsRepository implements the SystemStatsRepository interface, its statistics span all tenants
+type systemStatsRepository struct {
+	db *gorm.DB
+}
+
+// NewSystemStatsRepository creates a new system statistics repository
+func NewSystemStatsRepository(db *gorm.DB) interfaces.SystemStatsRepository {
+	return &systemStatsRepository{db: db}
+}
+
+// statsPeriodArgs returns the named arguments of the period of a query
+func statsPeriodArgs(query *types.SystemStatsQuery) map[string]interface{} {
+	return map[string]interface{}{"from": query.From, "to": query.To}
+}
+
+// GetOverview sums the statistics of every tenant over a period
+func (r *systemStatsRepository) GetOverview(ctx context.Context,
+	query *types.SystemStatsQuery,
+) (*types.SystemStatsOverview, error) {
+	overview := &types.SystemStatsOverview{From: query.From, To: query.To}
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Raw(`SELECT COUNT(*) AS tenants,
+		COALESCE(SUM(documents), 0) AS documents,
+		COALESCE(SUM(storage_used), 0) AS storage_used,
+		COALESCE(SUM(parse_completed), 0) AS parse_completed,
+		COALESCE(SUM(parse_failed), 0) AS parse_failed,
+		COALESCE(SUM(requests), 0) AS requests,
+		COALESCE(SUM(total_tokens), 0) AS total_tokens,
+		COALESCE(SUM(cost), 0) AS cost,
+		COALESCE(SUM(active_sessions), 0) AS active_sessions
+		FROM (`+tenantStatsQuery+`) stats`, statsPeriodArgs(query)).
+		Scan(overview).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Model(&types.Job{}).
+		Where("status = ? AND updated_at >= ? AND updated_at < ?", types.JobStatusFailed, query.From, query.To).
+		Count(&overview.FailedJobs).Error; err != nil {
+		return nil, err
+	}
+	return overview, nil
+}
+
+// ListTenantStats lists the statistics of each tenant over a period, ranked by a column
+func (r *systemStatsRepository) ListTenantStats(ctx context.Context,
+	query *types.SystemStatsQuery, sort types.TenantStatsSort, page *types.Pagination,
+) ([]*types.TenantStats, int64, error) {
+	var total int64
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Model(&types.Tenant{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := tenantStatsOrder[sort]
+	if !ok {
+		order = tenantStatsOrder[types.TenantStatsSortDocuments]
+	}
+	args := statsPeriodArgs(query)
+	args["limit"] = page.Limit()
+	args["offset"] = page.Offset()
+
+	var stats []*types.TenantStats
+	if err := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).
+		Raw(tenantStatsQuery+" ORDER BY "+order+", t.id LIMIT @limit OFFSET @offset", args).
+		Scan(&stats).Error; err != nil {
+		return nil, 0, err
+	}
+	return stats, total, nil
+}
+
+// ListJobErrors counts the jobs that failed or are being retried in a period by task type and error message
+func (r *systemStatsRepository) ListJobErrors(ctx context.Context,
+	query *types.SystemStatsQuery, tenantID uint64, limit int,
+) ([]*types.ErrorStat, error) {
+	db := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Model(&types.Job{}).
+		Select(`type AS task_type, last_error AS error, COUNT(*) AS count,
+			COUNT(DISTINCT tenant_id) AS tenants, MAX(updated_at) AS last_seen_at`).
+		Where("status IN ? AND last_error <> '' AND updated_at >= ? AND updated_at < ?",
+			[]types.JobStatus{types.JobStatusFailed, types.JobStatusRetrying}, query.From, query.To)
+	if tenantID != 0 {
+		db = db.Where("tenant_id = ?", tenantID)
+	}
+
+	var stats []*types.ErrorStat
+	if err := db.Group("type, last_error").Order("count DESC").Limit(limit).Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -132,11 +133,108 @@ func (r *knowledgeTagRepository) ListByKB(
 	return tags, total, nil
 }
 
-// Delete deletes a knowledge tag
+// ListAllByKB lists all tags of a knowledge base without pagination, used to build the tag tree.
+func (r *knowledgeTagRepository) ListAllByKB(ctx context.Context, tenantID uint64, kbID string) ([]*types.KnowledgeTag, error) {
+	var tags []*types.KnowledgeTag
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Order("sort_order ASC, created_at DESC").
+		Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// tagTreeSQL selects the given tags and all of their descendants.
+const tagTreeSQL = `WITH RECURSIVE tag_tree AS (
+	SELECT id FROM knowledge_tags WHERE tenant_id = ? AND id IN (?)
+	UNION
+	SELECT t.id FROM knowledge_tags t JOIN tag_tree ON t.parent_id = tag_tree.id WHERE t.tenant_id = ?
+) SELECT id FROM tag_tree`
+
+// ListDescendantIDs returns the IDs of the given tags together with all of their descendants.
+func (r *knowledgeTagRepository) ListDescendantIDs(ctx context.Context, tenantID uint64, tagIDs []string) ([]string, error) {
+	if len(tagIDs) == 0 {
+		return []string{}, nil
+	}
+	var ids []string
+	if err := r.db.WithContext(ctx).Raw(tagTreeSQL, tenantID, tagIDs, tenantID).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ReparentChildren moves all direct children of a tag to a new parent.
+func (r *knowledgeTagRepository) ReparentChildren(ctx context.Context, tenantID uint64, tagID string, newParentID string) error {
+	return r.db.WithContext(ctx).Model(&types.KnowledgeTag{}).
+		Where("tenant_id = ? AND parent_id = ?", tenantID, tagID).
+		Update("parent_id", newParentID).Error
+}
+
+// Delete deletes a knowledge tag and its knowledge assignments
 func (r *knowledgeTagRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
-	return r.db.WithContext(ctx).
-		Where("tenant_id = ? AND id = ?", tenantID, id).
-		Delete(&types.KnowledgeTag{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND tag_id = ?", tenantID, id).
+			Delete(&types.KnowledgeTagRelation{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("tenant_id = ? AND id = ?", tenantID, id).
+			Delete(&types.KnowledgeTag{}).Error
+	})
+}
+
+// SetKnowledgeTags replaces all tags assigned to a knowledge entry.
+func (r *knowledgeTagRepository) SetKnowledgeTags(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	knowledgeID string,
+	tagIDs []string,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID).
+			Delete(&types.KnowledgeTagRelation{}).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		now := time.Now()
+		relations := make([]*types.KnowledgeTagRelation, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			relations = append(relations, &types.KnowledgeTagRelation{
+				KnowledgeID:     knowledgeID,
+				TagID:           tagID,
+				TenantID:        tenantID,
+				KnowledgeBaseID: kbID,
+				CreatedAt:       now,
+			})
+		}
+		return tx.Create(&relations).Error
+	})
+}
+
+// ListKnowledgeTagIDs returns the assigned tag IDs for each of the given knowledge entries.
+func (r *knowledgeTagRepository) ListKnowledgeTagIDs(
+	ctx context.Context,
+	tenantID uint64,
+	knowledgeIDs []string,
+) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(knowledgeIDs) == 0 {
+		return result, nil
+	}
+	var relations []*types.KnowledgeTagRelation
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_id IN (?)", tenantID, knowledgeIDs).
+		Order("created_at ASC").
+		Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	for _, rel := range relations {
+		result[rel.KnowledgeID] = append(result[rel.KnowledgeID], rel.TagID)
+	}
+	return result, nil
 }
 
 // CountReferences returns the number of knowledges and chunks that reference this tag
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// ErrTenantBackupNotFound is returned when a tenant backup does not exist
+var ErrTenantBackupNotFound = errors.New("tenant backup not found")
+
+// tenantBackupRepository implements TenantBackupRepository interface
+type tenantBackupRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantBackupRepository creates a new tenant backup repository
+func NewTenantBackupRepository(db *gorm.DB) interfaces.TenantBackupRepository {
+	return &tenantBackupRepository{db: db}
+}
+
+// CreateBackup records a backup
+func (r *tenantBackupRepository) CreateBackup(ctx context.Context, backup *types.TenantBackup) error {
+	return r.db.WithContext(ctx).Create(backup).Error
+}
+
+// UpdateBackup saves the status and result of a backup
+func (r *tenantBackupRepository) UpdateBackup(ctx context.Context, backup *types.TenantBackup) error {
+	return r.db.WithContext(ctx).Save(backup).Error
+}
+
+// GetBackup gets a backup by ID
+func (r *tenantBackupRepository) GetBackup(ctx context.Context, id string) (*types.TenantBackup, error) {
+	var backup types.TenantBackup
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&backup).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantBackupNotFound
+		}
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// ListBackups lists the backups of a tenant, or of every tenant when tenantID is 0, newest first
+func (r *tenantBackupRepository) ListBackups(ctx context.Context,
+	tenantID uint64, page *types.Pagination,
+) ([]*types.TenantBackup, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.TenantBackup{})
+	if tenantID != 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var backups []*types.TenantBackup
+	if err := query.Order("created_at DESC").
+		Offset(page.Offset()).Limit(page.Limit()).
+		Find(&backups).Error; err != nil {
+		return nil, 0, err
+	}
+	return backups, total, nil
+}
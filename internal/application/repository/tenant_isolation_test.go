@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/tenantguard"
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// TestCrossTenantAccess runs the repository calls behind the API endpoints for a request of tenant 1. Reaching the
+// rows of tenant 2 must trip the tenant isolation audit, except on the paths meant to work across tenants.
+func TestCrossTenantAccess(t *testing.T) {
+	defer tenantguard.SetMode(tenantguard.ModeOff)
+	tenantguard.SetMode(tenantguard.ModePanic)
+
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tenantguard.Register(db); err != nil {
+		t.Fatal(err)
+	}
+	knowledgeRepo := NewKnowledgeRepository(db)
+	kbRepo := NewKnowledgeBaseRepository(db)
+	chunkRepo := NewChunkRepository(db)
+	linkRepo := NewKnowledgeFileLinkRepository(db)
+	experimentRepo := NewExperimentRepository(db)
+	statsRepo := NewSystemStatsRepository(db)
+
+	const own, other = uint64(1), uint64(2)
+	cases := []struct {
+		name   string
+		run    func(ctx context.Context)
+		panics bool
+	}{
+		{"get own knowledge", func(ctx context.Context) {
+			_, _ = knowledgeRepo.GetKnowledgeByID(ctx, own, "k")
+		}, false},
+		{"get knowledge of other tenant", func(ctx context.Context) {
+			_, _ = knowledgeRepo.GetKnowledgeByID(ctx, other, "k")
+		}, true},
+		{"delete knowledge of other tenant", func(ctx context.Context) {
+			_ = knowledgeRepo.DeleteKnowledge(ctx, other, "k")
+		}, true},
+		{"list knowledge of other tenant", func(ctx context.Context) {
+			_, _ = knowledgeRepo.GetKnowledgeBatch(ctx, other, []string{"k"})
+		}, true},
+		{"get knowledge base of other tenant", func(ctx context.Context) {
+			_, _ = kbRepo.GetKnowledgeBaseByIDAndTenant(ctx, "kb", other)
+		}, true},
+		{"get chunk of other tenant", func(ctx context.Context) {
+			_, _ = chunkRepo.GetChunkByID(ctx, other, "c")
+		}, true},
+		{"get file link of other tenant", func(ctx context.Context) {
+			_, _ = linkRepo.GetLink(ctx, other, "l")
+		}, true},
+		{"create knowledge for other tenant", func(ctx context.Context) {
+			_ = knowledgeRepo.CreateKnowledge(ctx, &types.Knowledge{ID: "k", TenantID: other})
+		}, true},
+		{"resolve shared knowledge base", func(ctx context.Context) {
+			_, _ = kbRepo.GetKnowledgeBaseByID(ctx, "kb")
+		}, false},
+		{"resolve shared knowledge", func(ctx context.Context) {
+			_, _ = knowledgeRepo.GetKnowledgeByIDOnly(ctx, "k")
+		}, false},
+		{"purge trash job", func(ctx context.Context) {
+			_, _ = knowledgeRepo.ListTrashedBefore(ctx, time.Now(), 10)
+		}, false},
+		{"link check job", func(ctx context.Context) {
+			_ = knowledgeRepo.UpdateKnowledgeLinkCheck(ctx, "k", &types.KnowledgeLinkCheck{})
+		}, false},
+		{"experiment job", func(ctx context.Context) {
+			_, _ = experimentRepo.ListRunningExperiments(ctx)
+		}, false},
+		{"admin stats", func(ctx context.Context) {
+			query := &types.SystemStatsQuery{From: time.Now().Add(-time.Hour), To: time.Now()}
+			_, _ = statsRepo.GetOverview(ctx, query)
+			_, _ = statsRepo.ListJobErrors(ctx, query, other, 10)
+		}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tc.panics {
+					t.Errorf("panicked = %v, want %v", r, tc.panics)
+				}
+			}()
+			tc.run(context.WithValue(context.Background(), types.TenantIDContextKey, own))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// tokenUsageStatColumns aggregates usage records into a TokenUsageStat
+const tokenUsageStatColumns = `COUNT(*) AS requests,
+	COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+	COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+	COALESCE(SUM(total_tokens), 0) AS total_tokens,
+	COALESCE(SUM(CASE WHEN estimated THEN 1 ELSE 0 END), 0) AS estimated_requests,
+	COALESCE(SUM(cost), 0) AS cost`
+
+// tokenUsageRepository implements the TokenUsageRepository interface
+type tokenUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewTokenUsageRepository creates a new token usage repository
+func NewTokenUsageRepository(db *gorm.DB) interfaces.TokenUsageRepository {
+	return &tokenUsageRepository{db: db}
+}
+
+// Create inserts a usage record
+func (r *tokenUsageRepository) Create(ctx context.Context, record *types.TokenUsageRecord) error {
+	return r.db.WithContext(ctx).Create(record).Error
+}
+
+// SumTotalTokens sums the tokens used by a tenant since the given time
+func (r *tokenUsageRepository) SumTotalTokens(ctx context.Context, tenantID uint64, since time.Time) (int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&types.TokenUsageRecord{}).
+		Select("COALESCE(SUM(total_tokens), 0)").
+		Where("tenant_id = ? AND created_at >= ?", tenantID, since).
+		Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetTotals aggregates the usage of a tenant over a period
+func (r *tokenUsageRepository) GetTotals(ctx context.Context,
+	tenantID uint64, from, to time.Time,
+) (*types.TokenUsageStat, error) {
+	var stat types.TokenUsageStat
+	if err := r.db.WithContext(ctx).Model(&types.TokenUsageRecord{}).
+		Select(tokenUsageStatColumns).
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, from, to).
+		Scan(&stat).Error; err != nil {
+		return nil, err
+	}
+	return &stat, nil
+}
+
+// ListStats aggregates the usage of a tenant over a period by the dimension of the query
+func (r *tokenUsageRepository) ListStats(ctx context.Context,
+	tenantID uint64, query *types.TokenUsageQuery,
+) ([]*types.TokenUsageStat, error) {
+	var key, name, order string
+	switch query.GroupBy {
+	case types.TokenUsageGroupByKnowledgeBase:
+		key, name, order = "knowledge_base_id", "''", "total_tokens DESC"
+	case types.TokenUsageGroupByDay:
+		key, name, order = "to_char(created_at, 'YYYY-MM-DD')", "''", "key"
+	default:
+		key, name, order = "model_id", "MAX(model_name)", "total_tokens DESC"
+	}
+
+	var stats []*types.TokenUsageStat
+	if err := r.db.WithContext(ctx).Model(&types.TokenUsageRecord{}).
+		Select(key+" AS key, "+name+" AS name, "+tokenUsageStatColumns).
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, query.From, query.To).
+		Group(key).
+		Order(order).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -13,6 +14,7 @@ var (
 	ErrUserNotFound      = errors.New("user not found")
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrTokenNotFound     = errors.New("token not found")
+	ErrSessionNotFound   = errors.New("login session not found")
 )
 
 // userRepository implements user repository interface
@@ -66,6 +68,31 @@ func (r *userRepository) GetUserByUsername(ctx context.Context, username string)
 	return &user, nil
 }
 
+// GetUserByExternalID gets a user by the subject it has at an identity provider
+func (r *userRepository) GetUserByExternalID(ctx context.Context,
+	provider, externalID string,
+) (*types.User, error) {
+	var user types.User
+	if err := r.db.WithContext(ctx).
+		Where("auth_provider = ? AND external_id = ?", provider, externalID).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsersByAuthProvider lists the users signing in with an identity provider
+func (r *userRepository) ListUsersByAuthProvider(ctx context.Context, provider string) ([]*types.User, error) {
+	var users []*types.User
+	if err := r.db.WithContext(ctx).Where("auth_provider = ?", provider).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // UpdateUser updates a user
 func (r *userRepository) UpdateUser(ctx context.Context, user *types.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
@@ -172,3 +199,106 @@ func (r *authTokenRepository) DeleteExpiredTokens(ctx context.Context) error {
 func (r *authTokenRepository) RevokeTokensByUserID(ctx context.Context, userID string) error {
 	return r.db.WithContext(ctx).Model(&types.AuthToken{}).Where("user_id = ?", userID).Update("is_revoked", true).Error
 }
+
+// GetTokenByID gets a token by ID
+func (r *authTokenRepository) GetTokenByID(ctx context.Context, id string) (*types.AuthToken, error) {
+	var token types.AuthToken
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListActiveTokens lists the tokens of a user that are neither revoked nor expired, newest first
+func (r *authTokenRepository) ListActiveTokens(ctx context.Context, userID string) ([]*types.AuthToken, error) {
+	var tokens []*types.AuthToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND is_revoked = ? AND expires_at > NOW()", userID, false).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// TouchToken records the use of a token and the activity of its session, without changing their update times
+func (r *authTokenRepository) TouchToken(ctx context.Context, token *types.AuthToken) error {
+	now := time.Now()
+	if err := r.db.WithContext(ctx).Model(&types.AuthToken{}).
+		Where("id = ?", token.ID).
+		UpdateColumn("last_used_at", now).Error; err != nil {
+		return err
+	}
+	if token.SessionID == "" {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&types.LoginSession{}).
+		Where("id = ?", token.SessionID).
+		UpdateColumn("last_active_at", now).Error
+}
+
+// CreateSession creates a login session
+func (r *authTokenRepository) CreateSession(ctx context.Context, session *types.LoginSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// GetSession gets a login session by ID
+func (r *authTokenRepository) GetSession(ctx context.Context, id string) (*types.LoginSession, error) {
+	var session types.LoginSession
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// UpdateSession updates a login session
+func (r *authTokenRepository) UpdateSession(ctx context.Context, session *types.LoginSession) error {
+	return r.db.WithContext(ctx).Save(session).Error
+}
+
+// ListActiveSessions lists the login sessions of a user that are neither revoked nor expired, most recently active first
+func (r *authTokenRepository) ListActiveSessions(ctx context.Context, userID string) ([]*types.LoginSession, error) {
+	var sessions []*types.LoginSession
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", userID).
+		Order("last_active_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession revokes a login session and its tokens
+func (r *authTokenRepository) RevokeSession(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&types.LoginSession{}).
+			Where("id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSessionNotFound
+		}
+		return tx.Model(&types.AuthToken{}).Where("session_id = ?", id).Update("is_revoked", true).Error
+	})
+}
+
+// RevokeSessionsByUserID revokes the login sessions and tokens of a user, except the session to keep if any.
+// Tokens issued before sessions were recorded are revoked too.
+func (r *authTokenRepository) RevokeSessionsByUserID(ctx context.Context, userID string, keepSessionID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sessions := tx.Model(&types.LoginSession{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		tokens := tx.Model(&types.AuthToken{}).Where("user_id = ? AND is_revoked = ?", userID, false)
+		if keepSessionID != "" {
+			sessions = sessions.Where("id <> ?", keepSessionID)
+			tokens = tokens.Where("session_id IS NULL OR session_id <> ?", keepSessionID)
+		}
+		if err := sessions.Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tokens.Update("is_revoked", true).Error
+	})
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/tenantguard"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWebhookNotFound is returned when a webhook does not exist
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookDeliveryNotFound is returned when a webhook delivery does not exist
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// webhookRepository implements WebhookRepository interface
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) interfaces.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateWebhook creates a webhook
+func (r *webhookRepository) CreateWebhook(ctx context.Context, webhook *types.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+// GetWebhook gets a webhook of a tenant by ID
+func (r *webhookRepository) GetWebhook(ctx context.Context, tenantID uint64, id string) (*types.Webhook, error) {
+	var webhook types.Webhook
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks lists the webhooks of a tenant, newest first
+func (r *webhookRepository) ListWebhooks(ctx context.Context, tenantID uint64) ([]*types.Webhook, error) {
+	var webhooks []*types.Webhook
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// ListEnabledWebhooks lists the enabled webhooks of a tenant
+func (r *webhookRepository) ListEnabledWebhooks(ctx context.Context, tenantID uint64) ([]*types.Webhook, error) {
+	var webhooks []*types.Webhook
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND enabled = ?", tenantID, true).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// UpdateWebhook updates a webhook
+func (r *webhookRepository) UpdateWebhook(ctx context.Context, webhook *types.Webhook) error {
+	return r.db.WithContext(ctx).Save(webhook).Error
+}
+
+// DeleteWebhook deletes a webhook of a tenant
+func (r *webhookRepository) DeleteWebhook(ctx context.Context, tenantID uint64, id string) error {
+	result := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// CreateDelivery records a delivery
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// GetDelivery gets a delivery by ID
+func (r *webhookRepository) GetDelivery(ctx context.Context, id string) (*types.WebhookDelivery, error) {
+	var delivery types.WebhookDelivery
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery saves the status and outcome of a delivery
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Model(delivery).Select(
+		"status", "attempts", "response_status", "last_error", "delivered_at", "updated_at",
+	).Updates(delivery).Error
+}
+
+// ListDeliveries lists the deliveries of a webhook of a tenant, newest first
+func (r *webhookRepository) ListDeliveries(ctx context.Context,
+	tenantID uint64, webhookID string, page *types.Pagination,
+) ([]*types.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.WebhookDelivery{}).
+		Where("tenant_id = ? AND webhook_id = ?", tenantID, webhookID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []*types.WebhookDelivery
+	if err := query.Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// DeleteDeliveriesBefore deletes the deliveries of all tenants created before a time and returns how many were deleted
+func (r *webhookRepository) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(tenantguard.WithoutTenantScope(ctx)).Where("created_at < ?", before).Delete(&types.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
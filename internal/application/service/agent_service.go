@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 
 	"github.com/Tencent/WeKnora/internal/agent"
@@ -345,7 +346,21 @@ func (s *agentService) registerTools(
 	// If web search is enabled, add web_search to allowedTools
 	if config.WebSearchEnabled {
 		allowedTools = append(allowedTools, tools.ToolWebSearch)
-		allowedTools = append(allowedTools, tools.ToolWebFetch)
+		if !slices.Contains(allowedTools, tools.ToolWebFetch) {
+			allowedTools = append(allowedTools, tools.ToolWebFetch)
+		}
+	}
+
+	// Drop the tools disabled for the whole tenant
+	if tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant); ok && tenant.AgentToolPolicy != nil {
+		enabledTools := make([]string, 0, len(allowedTools))
+		for _, toolName := range allowedTools {
+			if tenant.AgentToolPolicy.Allows(toolName) {
+				enabledTools = append(enabledTools, toolName)
+			}
+		}
+		allowedTools = enabledTools
+		logger.Infof(ctx, "Tenant tool policy applied, disabled: %v", tenant.AgentToolPolicy.DisabledTools)
 	}
 	logger.Infof(ctx, "Registering tools: %v, webSearchEnabled: %v", allowedTools, config.WebSearchEnabled)
 
@@ -391,7 +406,7 @@ func (s *agentService) registerTools(
 			logger.Infof(ctx, "Registered web_search tool for session: %s, maxResults: %d", sessionID, config.WebSearchMaxResults)
 
 		case tools.ToolWebFetch:
-			toolToRegister = tools.NewWebFetchTool(chatModel)
+			toolToRegister = tools.NewWebFetchTool(chatModel, s.cfg)
 			logger.Infof(ctx, "Registered web_fetch tool for session: %s", sessionID)
 
 		case tools.ToolDataAnalysis:
@@ -402,6 +417,12 @@ func (s *agentService) registerTools(
 			toolToRegister = tools.NewDataSchemaTool(s.knowledgeService, s.chunkService.GetRepository())
 			logger.Infof(ctx, "Registered data_schema tool")
 
+		case tools.ToolCalculator:
+			toolToRegister = tools.NewCalculatorTool()
+
+		case tools.ToolDateTime:
+			toolToRegister = tools.NewDateTimeTool()
+
 		default:
 			logger.Warnf(ctx, "Unknown tool: %s", toolName)
 		}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrFeedbackNotAnswer is returned when feedback is given to a message that is not a completed answer
+var ErrFeedbackNotAnswer = errors.New("feedback can only be given to completed assistant messages")
+
+// answerFeedbackService implements interfaces.AnswerFeedbackService
+type answerFeedbackService struct {
+	repo        interfaces.AnswerFeedbackRepository
+	sessionRepo interfaces.SessionRepository
+	messageRepo interfaces.MessageRepository
+	analytics   interfaces.SearchAnalyticsService
+	webhooks    interfaces.WebhookService
+}
+
+// NewAnswerFeedbackService creates a new answer feedback service
+func NewAnswerFeedbackService(
+	repo interfaces.AnswerFeedbackRepository,
+	sessionRepo interfaces.SessionRepository,
+	messageRepo interfaces.MessageRepository,
+	analytics interfaces.SearchAnalyticsService,
+	webhooks interfaces.WebhookService,
+) interfaces.AnswerFeedbackService {
+	return &answerFeedbackService{
+		repo:        repo,
+		sessionRepo: sessionRepo,
+		messageRepo: messageRepo,
+		analytics:   analytics,
+		webhooks:    webhooks,
+	}
+}
+
+// SubmitFeedback rates an assistant message of the current tenant, replacing earlier feedback on it.
+// The feedback is recorded once per knowledge base the answer retrieved from, with its chunks.
+func (s *answerFeedbackService) SubmitFeedback(ctx context.Context,
+	sessionID, messageID string, req *types.AnswerFeedbackRequest,
+) (*types.AnswerFeedback, error) {
+	if !req.Rating.IsValid() {
+		return nil, fmt.Errorf("rating must be %s or %s", types.AnswerFeedbackUp, types.AnswerFeedbackDown)
+	}
+	message, err := s.getAnswer(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.Role != "assistant" || !message.IsCompleted {
+		return nil, ErrFeedbackNotAnswer
+	}
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+	base := types.AnswerFeedback{
+		TenantID:  tenantID,
+		SessionID: sessionID,
+		MessageID: messageID,
+		UserID:    userID,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
+		Query:     s.findQuery(ctx, message),
+		Answer:    types.StripThinking(message.Content),
+		Status:    types.AnswerFeedbackOpen,
+	}
+	// Positive feedback needs no curation
+	if req.Rating == types.AnswerFeedbackUp {
+		base.Status = types.AnswerFeedbackResolved
+	}
+
+	chunks := types.FeedbackChunksByKnowledgeBase(message)
+	knowledgeBaseIDs := make([]string, 0, len(chunks))
+	for knowledgeBaseID := range chunks {
+		knowledgeBaseIDs = append(knowledgeBaseIDs, knowledgeBaseID)
+	}
+	sort.Strings(knowledgeBaseIDs)
+	if len(knowledgeBaseIDs) == 0 {
+		knowledgeBaseIDs = []string{""}
+	}
+	feedback := make([]*types.AnswerFeedback, 0, len(knowledgeBaseIDs))
+	for _, knowledgeBaseID := range knowledgeBaseIDs {
+		row := base
+		row.ID = uuid.New().String()
+		row.KnowledgeBaseID = knowledgeBaseID
+		row.Chunks = chunks[knowledgeBaseID]
+		feedback = append(feedback, &row)
+	}
+	if err := s.repo.SaveMessageFeedback(ctx, messageID, feedback); err != nil {
+		logger.Errorf(ctx, "Failed to save feedback of message %s: %v", messageID, err)
+		return nil, err
+	}
+
+	// Keep search analytics feedback counts in step, answers without search logs are skipped
+	for _, knowledgeBaseID := range knowledgeBaseIDs {
+		if knowledgeBaseID == "" {
+			continue
+		}
+		err := s.analytics.RecordFeedback(ctx, knowledgeBaseID, messageID, req.Rating.SearchFeedback())
+		if err != nil && !errors.Is(err, repository.ErrSearchLogNotFound) {
+			logger.Warnf(ctx, "Failed to record search feedback of message %s: %v", messageID, err)
+		}
+	}
+	s.webhooks.Publish(ctx, tenantID, types.WebhookEventChatFeedback, &types.WebhookFeedbackData{
+		SessionID:        sessionID,
+		MessageID:        messageID,
+		UserID:           userID,
+		Rating:           req.Rating,
+		Comment:          req.Comment,
+		Query:            base.Query,
+		KnowledgeBaseIDs: slices.DeleteFunc(slices.Clone(knowledgeBaseIDs), func(id string) bool { return id == "" }),
+	})
+	logger.Infof(ctx, "Recorded %s feedback on message %s for %d knowledge bases",
+		req.Rating, messageID, len(feedback))
+	return feedback[0], nil
+}
+
+// GetMessageFeedback returns the feedback on an assistant message, nil when it has not been rated
+func (s *answerFeedbackService) GetMessageFeedback(ctx context.Context,
+	sessionID, messageID string,
+) (*types.AnswerFeedback, error) {
+	if _, err := s.getAnswer(ctx, sessionID, messageID); err != nil {
+		return nil, err
+	}
+	feedback, err := s.repo.ListByMessage(ctx, messageID)
+	if err != nil || len(feedback) == 0 {
+		return nil, err
+	}
+	return feedback[0], nil
+}
+
+// ListCurationQueue lists the feedback on answers retrieved from a knowledge base, newest first
+func (s *answerFeedbackService) ListCurationQueue(ctx context.Context,
+	knowledgeBaseID string, filter *types.AnswerFeedbackFilter,
+) (*types.PageResult, error) {
+	if filter.Status == "" {
+		filter.Status = types.AnswerFeedbackOpen
+	}
+	if !filter.Status.IsValid() {
+		return nil, fmt.Errorf("invalid status: %s", filter.Status)
+	}
+	if filter.Rating == "" {
+		filter.Rating = string(types.AnswerFeedbackDown)
+	}
+	if filter.Rating != "all" && !types.AnswerFeedbackRating(filter.Rating).IsValid() {
+		return nil, fmt.Errorf("invalid rating: %s", filter.Rating)
+	}
+
+	feedback, total, err := s.repo.List(ctx, knowledgeBaseID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, &filter.Pagination, feedback), nil
+}
+
+// UpdateFeedbackStatus changes the curation status of a feedback of a knowledge base
+func (s *answerFeedbackService) UpdateFeedbackStatus(ctx context.Context,
+	knowledgeBaseID, id string, req *types.UpdateAnswerFeedbackRequest,
+) (*types.AnswerFeedback, error) {
+	if !req.Status.IsValid() {
+		return nil, fmt.Errorf("invalid status: %s", req.Status)
+	}
+	feedback, err := s.repo.Get(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback.Status = req.Status
+	feedback.ResolutionNote = req.ResolutionNote
+	if req.Status == types.AnswerFeedbackOpen {
+		feedback.ResolvedBy = ""
+		feedback.ResolvedAt = nil
+	} else {
+		now := time.Now()
+		feedback.ResolvedBy, _ = ctx.Value(types.UserIDContextKey).(string)
+		feedback.ResolvedAt = &now
+	}
+	if err := s.repo.Update(ctx, feedback); err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// getAnswer gets a message of a session of the current tenant
+func (s *answerFeedbackService) getAnswer(ctx context.Context,
+	sessionID, messageID string,
+) (*types.Message, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.sessionRepo.Get(ctx, tenantID, sessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return s.messageRepo.GetMessage(ctx, sessionID, messageID)
+}
+
+// findQuery returns the user question an answer replied to, the user message of the same request
+// or else the last user message before it
+func (s *answerFeedbackService) findQuery(ctx context.Context, answer *types.Message) string {
+	// The question and the answer are created together and may share a timestamp
+	messages, err := s.messageRepo.GetMessagesBySessionBeforeTime(ctx,
+		answer.SessionID, answer.CreatedAt.Add(time.Millisecond), 4)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to find the question of message %s: %v", answer.ID, err)
+		return ""
+	}
+	var query string
+	for _, message := range messages {
+		if message.Role != "user" {
+			continue
+		}
+		if answer.RequestID != "" && message.RequestID == answer.RequestID {
+			return message.Content
+		}
+		query = message.Content
+	}
+	return query
+}
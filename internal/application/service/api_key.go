@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+const (
+	// apiKeyPrefixLength is how much of a key is kept to tell keys apart
+	apiKeyPrefixLength = 12
+	// apiKeyTouchInterval limits how often the last use of a key is written
+	apiKeyTouchInterval = time.Minute
+)
+
+// apiKeyService implements APIKeyService interface
+type apiKeyService struct {
+	repo               interfaces.APIKeyRepository
+	kbRepo             interfaces.KnowledgeBaseRepository
+	serviceAccountRepo interfaces.ServiceAccountRepository
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(
+	repo interfaces.APIKeyRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	serviceAccountRepo interfaces.ServiceAccountRepository,
+) interfaces.APIKeyService {
+	return &apiKeyService{repo: repo, kbRepo: kbRepo, serviceAccountRepo: serviceAccountRepo}
+}
+
+// hashAPIKey returns the hash a key is stored and looked up by
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey creates a scoped API key for the tenant in the context
+func (s *apiKeyService) CreateAPIKey(ctx context.Context,
+	req *types.CreateAPIKeyRequest,
+) (*types.APIKeyWithSecret, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if !req.Scope.IsValid() {
+		return nil, werrors.NewBadRequestError("Scope must be one of retrieval, ingest, admin")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, werrors.NewBadRequestError("Expiry must be in the future")
+	}
+	if err := s.checkKnowledgeBases(ctx, tenantID, req.KnowledgeBaseIDs); err != nil {
+		return nil, err
+	}
+	if req.ServiceAccountID != "" {
+		account, err := s.serviceAccountRepo.GetServiceAccountByID(ctx, tenantID, req.ServiceAccountID)
+		if err != nil {
+			if errors.Is(err, repository.ErrServiceAccountNotFound) {
+				return nil, werrors.NewBadRequestError("Service account not found")
+			}
+			return nil, err
+		}
+		if !account.AllowsScope(req.Scope) {
+			return nil, werrors.NewBadRequestError("The service account is not allowed the scope " + string(req.Scope))
+		}
+	}
+
+	createdBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	secret := generateApiKey(tenantID)
+	key := &types.APIKey{
+		ID:               uuid.New().String(),
+		TenantID:         tenantID,
+		Name:             req.Name,
+		KeyPrefix:        secret[:apiKeyPrefixLength],
+		KeyHash:          hashAPIKey(secret),
+		Scope:            req.Scope,
+		ServiceAccountID: req.ServiceAccountID,
+		KnowledgeBaseIDs: req.KnowledgeBaseIDs,
+		ExpiresAt:        req.ExpiresAt,
+		CreatedBy:        createdBy,
+	}
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+	logger.Infof(ctx, "Created API key %s with scope %s for tenant %d", key.ID, key.Scope, tenantID)
+	return &types.APIKeyWithSecret{APIKey: key, Key: secret}, nil
+}
+
+// ListAPIKeys lists the scoped API keys of the tenant in the context
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]*types.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx, ctx.Value(types.TenantIDContextKey).(uint64))
+}
+
+// UpdateAPIKey updates the name, knowledge base restrictions or expiry of a key
+func (s *apiKeyService) UpdateAPIKey(ctx context.Context,
+	id string, req *types.UpdateAPIKeyRequest,
+) (*types.APIKey, error) {
+	key, err := s.getAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, werrors.NewBadRequestError("Name cannot be empty")
+		}
+		key.Name = *req.Name
+	}
+	if req.KnowledgeBaseIDs != nil {
+		if err := s.checkKnowledgeBases(ctx, key.TenantID, *req.KnowledgeBaseIDs); err != nil {
+			return nil, err
+		}
+		key.KnowledgeBaseIDs = *req.KnowledgeBaseIDs
+	}
+	if req.ClearExpiry {
+		key.ExpiresAt = nil
+	} else if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, werrors.NewBadRequestError("Expiry must be in the future")
+		}
+		key.ExpiresAt = req.ExpiresAt
+	}
+	if err := s.repo.UpdateAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// RotateAPIKey replaces the key of a scoped API key, the previous key stops working immediately
+func (s *apiKeyService) RotateAPIKey(ctx context.Context, id string) (*types.APIKeyWithSecret, error) {
+	key, err := s.getAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	secret := generateApiKey(key.TenantID)
+	key.KeyPrefix = secret[:apiKeyPrefixLength]
+	key.KeyHash = hashAPIKey(secret)
+	if err := s.repo.UpdateAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Rotated API key %s of tenant %d", key.ID, key.TenantID)
+	return &types.APIKeyWithSecret{APIKey: key, Key: secret}, nil
+}
+
+// RevokeAPIKey deletes a scoped API key
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := s.repo.DeleteAPIKey(ctx, tenantID, id); err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return werrors.NewNotFoundError("API key not found")
+		}
+		return err
+	}
+	logger.Infof(ctx, "Revoked API key %s of tenant %d", id, tenantID)
+	return nil
+}
+
+// Authenticate returns the scoped API key of a tenant matching the key, if it has not expired.
+// Keys of a service account also need the account to be enabled and still allowed their scope.
+func (s *apiKeyService) Authenticate(ctx context.Context, tenantID uint64, secret string) (*types.APIKey, error) {
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key.TenantID != tenantID {
+		return nil, repository.ErrAPIKeyNotFound
+	}
+	if key.IsExpired(now) {
+		return nil, werrors.NewUnauthorizedError("API key has expired")
+	}
+	if key.ServiceAccountID != "" {
+		account, err := s.serviceAccountRepo.GetServiceAccountByID(ctx, tenantID, key.ServiceAccountID)
+		if err != nil {
+			return nil, err
+		}
+		if account.Disabled || !account.AllowsScope(key.Scope) {
+			return nil, werrors.NewUnauthorizedError("Service account is disabled or no longer has the scope of the key")
+		}
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+			logger.Warnf(ctx, "Failed to record use of API key %s: %v", key.ID, err)
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// getAPIKey gets a key of the tenant in the context
+func (s *apiKeyService) getAPIKey(ctx context.Context, id string) (*types.APIKey, error) {
+	key, err := s.repo.GetAPIKeyByID(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, werrors.NewNotFoundError("API key not found")
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// checkKnowledgeBases rejects knowledge base restrictions naming knowledge bases of other tenants
+func (s *apiKeyService) checkKnowledgeBases(ctx context.Context, tenantID uint64, kbIDs []string) error {
+	for _, kbID := range kbIDs {
+		if _, err := s.kbRepo.GetKnowledgeBaseByIDAndTenant(ctx, kbID, tenantID); err != nil {
+			return werrors.NewBadRequestError("Knowledge base not found: " + kbID)
+		}
+	}
+	return nil
+}
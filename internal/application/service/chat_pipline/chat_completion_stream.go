@@ -106,6 +106,9 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 		var finalContent string
 		var thinkingStarted bool
 		var thinkingEnded bool
+		// Answer text without thinking, scanned for citation markers
+		var answerContent string
+		citations := chatManage.Citations.Scanner()
 
 		for response := range responseChan {
 			// Handle error responses from the stream
@@ -174,6 +177,19 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 					}
 				}
 				finalContent += response.Content
+				answerContent += response.Content
+				newlyCited := citations.Write(response.Content)
+				// The full citation list is sent before the final answer event completes the message
+				if response.Done && len(chatManage.Citations) > 0 {
+					emitCitations(ctx, eventBus, chatManage.SessionID, answerID, citations.Cited(), true)
+				}
+				// Follow-up questions are suggested from the passages the answer was grounded in,
+				// they are sent before the answer completes so they are stored with the message
+				if response.Done && chatManage.EnableFollowUps && len(chatManage.MergeResult) > 0 {
+					if questions := generateFollowUps(ctx, chatModel, chatManage, answerContent); len(questions) > 0 {
+						emitFollowUps(ctx, eventBus, chatManage.SessionID, answerID, questions)
+					}
+				}
 				if err := eventBus.Emit(ctx, types.Event{
 					ID:        answerID,
 					Type:      types.EventType(event.EventAgentFinalAnswer),
@@ -185,6 +201,10 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 				}); err != nil {
 					logger.Errorf(ctx, "Failed to emit answer event: %v", err)
 				}
+				// Citations whose markers appeared in this chunk
+				if !response.Done && len(newlyCited) > 0 {
+					emitCitations(ctx, eventBus, chatManage.SessionID, answerID, newlyCited, false)
+				}
 			}
 		}
 
@@ -195,3 +215,23 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 
 	return next()
 }
+
+// emitCitations emits the citations of the answer
+func emitCitations(ctx context.Context, eventBus types.EventBusInterface,
+	sessionID string, answerID string, citations types.Citations, done bool,
+) {
+	if citations == nil {
+		citations = types.Citations{}
+	}
+	if err := eventBus.Emit(ctx, types.Event{
+		ID:        answerID + "-citations",
+		Type:      types.EventType(event.EventAgentCitations),
+		SessionID: sessionID,
+		Data: event.AgentCitationsData{
+			Citations: citations,
+			Done:      done,
+		},
+	}); err != nil {
+		logger.Errorf(ctx, "Failed to emit citations event: %v", err)
+	}
+}
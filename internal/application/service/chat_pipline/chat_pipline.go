@@ -122,6 +122,11 @@ var (
 		Description: "Failed to get conversation history",
 		ErrorType:   "get_history_failed",
 	}
+	// ErrSemanticCacheHit stops the pipeline after the answer was served from the semantic cache
+	ErrSemanticCacheHit = &PluginError{
+		Description: "Answered from semantic cache",
+		ErrorType:   "semantic_cache_hit",
+	}
 )
 
 // clone creates a copy of the PluginError
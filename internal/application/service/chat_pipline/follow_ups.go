@@ -0,0 +1,133 @@
+package chatpipline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// maxFollowUps is the number of follow-up questions suggested after an answer
+	maxFollowUps = 3
+	// followUpPassageLimit caps the runes of each retrieved passage shown to the model
+	followUpPassageLimit = 300
+	// followUpPassageCount caps the retrieved passages shown to the model
+	followUpPassageCount = 8
+)
+
+// followUpPrompt asks the model for follow-up questions the retrieved passages can answer
+const followUpPrompt = `你是一个知识库问答助手。请根据用户的问题、已给出的回答和参考资料，推荐 3 个用户接下来可能想问的追问问题，只输出一个 JSON 对象，不要输出任何解释。
+
+JSON 格式：
+{"questions": ["...", "...", "..."]}
+
+要求：
+- 追问必须能由参考资料回答，不要推荐资料中没有涉及的问题
+- 不要重复用户已经问过或回答中已经完整解答的内容
+- 每个问题简短具体，不超过 30 个字，以用户的口吻提问
+- 使用与用户问题相同的语言`
+
+// followUpOutput is the JSON object returned by the model
+type followUpOutput struct {
+	Questions []string `json:"questions"`
+}
+
+// generateFollowUps asks the chat model for follow-up questions grounded in the retrieved passages.
+// Failures only skip the suggestions.
+func generateFollowUps(ctx context.Context, chatModel chat.Chat,
+	chatManage *types.ChatManage, answer string,
+) []string {
+	var passages strings.Builder
+	for i, result := range chatManage.MergeResult {
+		if i >= followUpPassageCount {
+			break
+		}
+		content := []rune(result.Content)
+		if len(content) > followUpPassageLimit {
+			content = content[:followUpPassageLimit]
+		}
+		passages.WriteString(fmt.Sprintf("[%d] %s\n", i+1, string(content)))
+	}
+	input := fmt.Sprintf("用户问题：%s\n\n回答：%s\n\n参考资料：\n%s", chatManage.Query, answer, passages.String())
+
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: followUpPrompt},
+		{Role: "user", Content: input},
+	}, &chat.ChatOptions{
+		Temperature:         0.5,
+		MaxCompletionTokens: 300,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineError(ctx, "FollowUps", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return nil
+	}
+
+	questions, ok := parseFollowUps(response.Content, chatManage.Query)
+	if !ok {
+		pipelineWarn(ctx, "FollowUps", "parse", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"content":    response.Content,
+		})
+		return nil
+	}
+	pipelineInfo(ctx, "FollowUps", "output", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"questions":  questions,
+	})
+	return questions
+}
+
+// parseFollowUps extracts the questions from a model response, tolerating thinking content and
+// code fences around the JSON object. Questions are trimmed, de-duplicated and capped at maxFollowUps.
+func parseFollowUps(content string, query string) ([]string, bool) {
+	content = reg.ReplaceAllString(content, "")
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, false
+	}
+	var output followUpOutput
+	if err := json.Unmarshal([]byte(content[start:end+1]), &output); err != nil {
+		return nil, false
+	}
+	seen := map[string]struct{}{strings.ToLower(strings.TrimSpace(query)): {}}
+	questions := make([]string, 0, maxFollowUps)
+	for _, question := range output.Questions {
+		question = strings.TrimSpace(question)
+		key := strings.ToLower(question)
+		if _, ok := seen[key]; ok || question == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		questions = append(questions, question)
+		if len(questions) >= maxFollowUps {
+			break
+		}
+	}
+	return questions, len(questions) > 0
+}
+
+// emitFollowUps emits the follow-up questions suggested after the answer
+func emitFollowUps(ctx context.Context, eventBus types.EventBusInterface,
+	sessionID string, answerID string, questions []string,
+) {
+	if err := eventBus.Emit(ctx, types.Event{
+		ID:        answerID + "-follow-ups",
+		Type:      types.EventType(event.EventAgentFollowUps),
+		SessionID: sessionID,
+		Data:      event.AgentFollowUpsData{Questions: questions},
+	}); err != nil {
+		logger.Errorf(ctx, "Failed to emit follow-up questions event: %v", err)
+	}
+}
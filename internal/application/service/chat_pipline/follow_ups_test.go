@@ -0,0 +1,27 @@
+package chatpipline
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFollowUps(t *testing.T) {
+	content := "<think>reasoning</think>```json\n" +
+		`{"questions": [" 彗尾为什么背向太阳？", "彗尾的形状", "彗尾为什么背向太阳？", "彗发如何形成？", "", "轨道周期多长？", "第五个问题"]}` +
+		"\n```"
+	questions, ok := parseFollowUps(content, "彗尾的形状")
+	if !ok {
+		t.Fatalf("expected questions to be parsed")
+	}
+	want := []string{"彗尾为什么背向太阳？", "彗发如何形成？", "轨道周期多长？"}
+	if !slices.Equal(questions, want) {
+		t.Errorf("unexpected questions: %v", questions)
+	}
+
+	if _, ok := parseFollowUps("no json here", "q"); ok {
+		t.Errorf("expected parse failure without a JSON object")
+	}
+	if _, ok := parseFollowUps(`{"questions": ["q"]}`, "q"); ok {
+		t.Errorf("expected failure when only the query is suggested")
+	}
+}
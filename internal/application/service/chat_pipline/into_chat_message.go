@@ -78,6 +78,7 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 	weekdayName := []string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"}
 
 	var contextsBuilder strings.Builder
+	citations := make(types.Citations, 0, len(chatManage.MergeResult))
 
 	// Build contexts string based on FAQ priority strategy
 	if chatManage.FAQPriorityEnabled && len(faqResults) > 0 {
@@ -85,7 +86,8 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 		contextsBuilder.WriteString("### 资料来源 1：标准问答库 (FAQ)\n")
 		contextsBuilder.WriteString("【高置信度 - 请优先参考】\n")
 		for i, result := range faqResults {
-			passage := getEnrichedPassageForChat(ctx, result)
+			passage := getEnrichedPassageForChat(ctx, result, safeQuery)
+			citations = append(citations, types.NewCitation(fmt.Sprintf("FAQ-%d", i+1), result))
 			if hasHighConfidenceFAQ && i == 0 {
 				contextsBuilder.WriteString(fmt.Sprintf("[FAQ-%d] ⭐ 精准匹配: %s\n", i+1, passage))
 			} else {
@@ -97,7 +99,8 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 			contextsBuilder.WriteString("\n### 资料来源 2：参考文档\n")
 			contextsBuilder.WriteString("【补充资料 - 仅在FAQ无法解答时参考】\n")
 			for i, result := range docResults {
-				passage := getEnrichedPassageForChat(ctx, result)
+				passage := getEnrichedPassageForChat(ctx, result, safeQuery)
+				citations = append(citations, types.NewCitation(fmt.Sprintf("DOC-%d", i+1), result))
 				contextsBuilder.WriteString(fmt.Sprintf("[DOC-%d] %s\n", i+1, passage))
 			}
 		}
@@ -105,7 +108,8 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 		// Original behavior: simple numbered list
 		passages := make([]string, len(chatManage.MergeResult))
 		for i, result := range chatManage.MergeResult {
-			passages[i] = getEnrichedPassageForChat(ctx, result)
+			passages[i] = getEnrichedPassageForChat(ctx, result, safeQuery)
+			citations = append(citations, types.NewCitation(fmt.Sprintf("%d", i+1), result))
 		}
 		for i, passage := range passages {
 			if i > 0 {
@@ -121,19 +125,34 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 	userContent = strings.ReplaceAll(userContent, "{{contexts}}", contextsBuilder.String())
 	userContent = strings.ReplaceAll(userContent, "{{current_time}}", time.Now().Format("2006-01-02 15:04:05"))
 	userContent = strings.ReplaceAll(userContent, "{{current_week}}", weekdayName[time.Now().Weekday()])
+	if len(citations) > 0 && chatManage.CitationStyle != types.CitationStyleNone {
+		userContent += citationInstruction
+	}
 
 	// Set formatted content back to chat management
 	chatManage.UserContent = userContent
+	chatManage.Citations = citations
 	pipelineInfo(ctx, "IntoChatMessage", "output", map[string]interface{}{
 		"session_id":       chatManage.SessionID,
 		"user_content_len": len(chatManage.UserContent),
 		"faq_priority":     chatManage.FAQPriorityEnabled,
+		"citation_cnt":     len(citations),
 	})
 	return next()
 }
 
+// citationInstruction 要求模型以资料编号标注引用，流式输出时据此解析引用的知识片段
+const citationInstruction = "\n\n引用要求：回答中使用了参考资料的内容时，请在对应句子末尾用资料编号标注来源，" +
+	"如 [1] 或 [FAQ-1]，引用多条资料时写作 [1, 3]；不要编造不存在的编号。"
+
 // getEnrichedPassageForChat 合并Content和ImageInfo的文本内容，为聊天消息准备
-func getEnrichedPassageForChat(ctx context.Context, result *types.SearchResult) string {
+func getEnrichedPassageForChat(ctx context.Context, result *types.SearchResult, query string) string {
+	// CSV 和 Excel 文档的文本 Chunk 同样附带结构化的表格行
+	if result.ChunkType == string(types.ChunkTypeTable) ||
+		(result.ChunkType == string(types.ChunkTypeText) && isDataFile(result.KnowledgeFilename)) {
+		return getTablePassageForChat(ctx, result, query)
+	}
+
 	// 如果没有图片信息，直接返回内容
 	if result.Content == "" && result.ImageInfo == "" {
 		return ""
@@ -148,6 +167,51 @@ func getEnrichedPassageForChat(ctx context.Context, result *types.SearchResult)
 	return enrichContentWithImageInfo(ctx, result.Content, result.ImageInfo)
 }
 
+// maxTableLookupRows 表格单元格查找时最多返回的命中行数
+const maxTableLookupRows = 5
+
+// getTablePassageForChat 基于结构化表格数据回答单元格查找类问题：
+// 命中查询的单元格和行以"列名: 值"的形式置于表格前，便于模型准确定位单元格
+func getTablePassageForChat(ctx context.Context, result *types.SearchResult, query string) string {
+	chunk := &types.Chunk{Metadata: result.ChunkMetadata}
+	meta, err := chunk.TableMetadata()
+	if err != nil || meta == nil || meta.Table == nil {
+		if err != nil {
+			pipelineWarn(ctx, "IntoChatMessage", "table_metadata_parse", map[string]interface{}{
+				"chunk_id": result.ID,
+				"error":    err.Error(),
+			})
+		}
+		return result.Content
+	}
+
+	rows := meta.Table.LookupRows(query, maxTableLookupRows)
+	if len(rows) == 0 {
+		return result.Content
+	}
+	var b strings.Builder
+	// 问题同时提到了行的取值和列名时，直接给出对应的单元格
+	if cells := meta.Table.LookupCells(query, maxTableLookupRows); len(cells) > 0 {
+		b.WriteString("表格中与问题匹配的单元格：\n")
+		for _, cell := range cells {
+			fmt.Fprintf(&b, "- %s 的 %s: %s\n", cell.Key, cell.Column, cell.Value)
+		}
+	}
+	b.WriteString("表格中与问题匹配的行：\n")
+	for _, row := range rows {
+		b.WriteString("- ")
+		b.WriteString(meta.Table.RowText(row))
+		b.WriteString("\n")
+	}
+	if result.ChunkType == string(types.ChunkTypeTable) {
+		b.WriteString("完整表格：\n")
+	} else {
+		b.WriteString("原文：\n")
+	}
+	b.WriteString(result.Content)
+	return b.String()
+}
+
 // 正则表达式用于匹配Markdown图片链接
 var markdownImageRegex = regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
 
@@ -48,7 +48,7 @@ func (p *PluginLoadHistory) OnEvent(ctx context.Context,
 	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
 ) *PluginError {
 	// Determine max rounds from config or request
-	maxRounds := p.config.Conversation.MaxRounds
+	maxRounds := p.config.Current().Conversation.MaxRounds
 	if chatManage.MaxRounds > 0 {
 		maxRounds = chatManage.MaxRounds
 	}
@@ -58,6 +58,16 @@ func (p *PluginLoadHistory) OnEvent(ctx context.Context,
 		"max_rounds": maxRounds,
 	})
 
+	// History supplied by the caller with the request is used as is
+	if chatManage.ExternalHistory {
+		pipelineInfo(ctx, "LoadHistory", "skip", map[string]interface{}{
+			"session_id":     chatManage.SessionID,
+			"reason":         "external_history",
+			"history_rounds": len(chatManage.History),
+		})
+		return next()
+	}
+
 	// Get conversation history (fetch more to account for incomplete pairs)
 	history, err := p.messageService.GetRecentMessagesBySession(ctx, chatManage.SessionID, maxRounds*2+10)
 	if err != nil {
@@ -0,0 +1,159 @@
+package chatpipline
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// maxLLMExpansions caps the number of keyword expansions searched for a query
+const maxLLMExpansions = 5
+
+// queryExpansionPrompt asks the model for keyword expansions and a hypothetical answer in one call
+const queryExpansionPrompt = `你是一个检索查询优化助手。请基于用户问题生成用于知识库检索的内容，只输出一个 JSON 对象，不要输出任何解释。
+
+JSON 格式：
+{"expansions": ["..."], "hypothetical_document": "..."}
+
+要求：
+- expansions：最多 5 条简短的检索关键词组合，可使用同义词、别称、缩写全称或相关术语，不要简单重复原问题
+- hypothetical_document：{{hyde_instruction}}
+- 使用与用户问题相同的语言`
+
+const (
+	hydeInstruction   = "写一段 100 到 200 字、像是出自知识库文档的段落来直接回答该问题，即使不确定也给出最可能的表述"
+	noHydeInstruction = "留空字符串"
+)
+
+// PluginQueryExpansion generates LLM keyword expansions and a HyDE pseudo-document for retrieval
+// It runs after PluginRewrite so follow-up questions are expanded from the standalone query
+type PluginQueryExpansion struct {
+	modelService interfaces.ModelService // Model service for calling large language models
+}
+
+// NewPluginQueryExpansion creates a new query expansion plugin instance
+// Also registers the plugin with the event manager
+func NewPluginQueryExpansion(eventManager *EventManager, modelService interfaces.ModelService) *PluginQueryExpansion {
+	res := &PluginQueryExpansion{modelService: modelService}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to
+func (p *PluginQueryExpansion) ActivationEvents() []types.EventType {
+	return []types.EventType{types.REWRITE_QUERY}
+}
+
+// queryExpansionOutput is the JSON object returned by the model
+type queryExpansionOutput struct {
+	Expansions           []string `json:"expansions"`
+	HypotheticalDocument string   `json:"hypothetical_document"`
+}
+
+// OnEvent generates expansions for the rewritten query; failures only skip the step
+func (p *PluginQueryExpansion) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	chatManage.QueryExpansions = nil
+	chatManage.HyDEDocument = ""
+	if !chatManage.EnableLLMExpansion && !chatManage.EnableHyDE {
+		return next()
+	}
+
+	query := chatManage.RewriteQuery
+	if query == "" {
+		query = chatManage.Query
+	}
+	model, err := p.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	if err != nil {
+		pipelineError(ctx, "QueryExpansion", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": chatManage.ChatModelID,
+			"error":         err.Error(),
+		})
+		return next()
+	}
+
+	instruction := noHydeInstruction
+	maxTokens := 200
+	if chatManage.EnableHyDE {
+		instruction = hydeInstruction
+		maxTokens = 600
+	}
+	thinking := false
+	response, err := model.Chat(ctx, []chat.Message{
+		{Role: "system", Content: strings.ReplaceAll(queryExpansionPrompt, "{{hyde_instruction}}", instruction)},
+		{Role: "user", Content: query},
+	}, &chat.ChatOptions{
+		Temperature:         0.3,
+		MaxCompletionTokens: maxTokens,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineError(ctx, "QueryExpansion", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	output, ok := parseQueryExpansionOutput(response.Content)
+	if !ok {
+		pipelineWarn(ctx, "QueryExpansion", "parse", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"content":    response.Content,
+		})
+		return next()
+	}
+	if chatManage.EnableLLMExpansion {
+		chatManage.QueryExpansions = cleanExpansions(output.Expansions, query)
+	}
+	if chatManage.EnableHyDE {
+		chatManage.HyDEDocument = strings.TrimSpace(output.HypotheticalDocument)
+	}
+	pipelineInfo(ctx, "QueryExpansion", "output", map[string]interface{}{
+		"session_id":    chatManage.SessionID,
+		"expansions":    chatManage.QueryExpansions,
+		"hyde_document": chatManage.HyDEDocument != "",
+	})
+	return next()
+}
+
+// parseQueryExpansionOutput extracts the JSON object from a model response,
+// tolerating thinking content and markdown code fences around it
+func parseQueryExpansionOutput(content string) (*queryExpansionOutput, bool) {
+	content = reg.ReplaceAllString(content, "")
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, false
+	}
+	var output queryExpansionOutput
+	if err := json.Unmarshal([]byte(content[start:end+1]), &output); err != nil {
+		return nil, false
+	}
+	return &output, true
+}
+
+// cleanExpansions trims and de-duplicates expansions, dropping ones equal to the query
+func cleanExpansions(expansions []string, query string) []string {
+	seen := map[string]struct{}{strings.ToLower(strings.TrimSpace(query)): {}}
+	result := make([]string, 0, len(expansions))
+	for _, expansion := range expansions {
+		expansion = strings.TrimSpace(expansion)
+		key := strings.ToLower(expansion)
+		if _, ok := seen[key]; ok || expansion == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, expansion)
+		if len(result) >= maxLLMExpansions {
+			break
+		}
+	}
+	return result
+}
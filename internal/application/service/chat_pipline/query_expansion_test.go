@@ -0,0 +1,27 @@
+package chatpipline
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseQueryExpansionOutput(t *testing.T) {
+	content := "<think>reasoning</think>```json\n" +
+		`{"expansions": ["向量数据库", " Milvus ", "向量数据库", "如何部署"], "hypothetical_document": " 部署步骤 "}` +
+		"\n```"
+	output, ok := parseQueryExpansionOutput(content)
+	if !ok {
+		t.Fatalf("expected output to be parsed")
+	}
+	expansions := cleanExpansions(output.Expansions, "如何部署")
+	if !slices.Equal(expansions, []string{"向量数据库", "Milvus"}) {
+		t.Errorf("unexpected expansions: %v", expansions)
+	}
+	if output.HypotheticalDocument != " 部署步骤 " {
+		t.Errorf("unexpected hypothetical document: %q", output.HypotheticalDocument)
+	}
+
+	if _, ok := parseQueryExpansionOutput("no json here"); ok {
+		t.Errorf("expected parse failure without a JSON object")
+	}
+}
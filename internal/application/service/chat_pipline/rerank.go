@@ -3,10 +3,13 @@ package chatpipline
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
+	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/rerank"
 	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -15,18 +18,33 @@ import (
 
 // PluginRerank implements reranking functionality for chat pipeline
 type PluginRerank struct {
-	modelService interfaces.ModelService // Service to access rerank models
+	modelService      interfaces.ModelService            // Service to access rerank models
+	knowledgeBaseRepo interfaces.KnowledgeBaseRepository // Loads per knowledge base rerank settings
 }
 
 // NewPluginRerank creates a new rerank plugin instance
-func NewPluginRerank(eventManager *EventManager, modelService interfaces.ModelService) *PluginRerank {
+func NewPluginRerank(eventManager *EventManager, modelService interfaces.ModelService,
+	knowledgeBaseRepo interfaces.KnowledgeBaseRepository,
+) *PluginRerank {
 	res := &PluginRerank{
-		modelService: modelService,
+		modelService:      modelService,
+		knowledgeBaseRepo: knowledgeBaseRepo,
 	}
 	eventManager.Register(res)
 	return res
 }
 
+// rerankGroup is a set of candidates reranked with the same settings
+type rerankGroup struct {
+	name       string          // Knowledge base ID, or "session" for the session settings
+	reranker   rerank.Reranker // nil keeps the retrieval scores
+	threshold  float64         // Minimum model score kept
+	timeout    time.Duration   // Latency budget, 0 means no budget
+	fallback   bool            // Keep retrieval scores when the rerank call fails or times out
+	passages   []string
+	candidates []*types.SearchResult
+}
+
 // ActivationEvents returns the event types this plugin handles
 func (p *PluginRerank) ActivationEvents() []types.EventType {
 	return []types.EventType{types.CHUNK_RERANK}
@@ -49,27 +67,20 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 		})
 		return next()
 	}
-	if chatManage.RerankModelID == "" {
+
+	kbConfigs := p.loadRerankConfigs(ctx, chatManage.SearchResult)
+	if chatManage.RerankModelID == "" && len(kbConfigs) == 0 {
 		pipelineWarn(ctx, "Rerank", "skip", map[string]interface{}{
 			"reason": "empty_model_id",
 		})
 		return next()
 	}
 
-	// Get rerank model from service
-	rerankModel, err := p.modelService.GetRerankModel(ctx, chatManage.RerankModelID)
-	if err != nil {
-		pipelineError(ctx, "Rerank", "get_model", map[string]interface{}{
-			"model_id": chatManage.RerankModelID,
-			"error":    err.Error(),
-		})
-		return ErrGetRerankModel.WithError(err)
-	}
-
-	// Prepare passages for reranking (excluding DirectLoad results)
-	var passages []string
-	var candidatesToRerank []*types.SearchResult
+	// Split candidates into groups by the rerank settings of their knowledge base (excluding DirectLoad results)
+	groups := make(map[string]*rerankGroup)
+	var groupOrder []*rerankGroup
 	var directLoadResults []*types.SearchResult
+	candidateCnt := 0
 
 	for _, result := range chatManage.SearchResult {
 		if result.MatchType == types.MatchTypeDirectLoad {
@@ -79,41 +90,35 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 			})
 			continue
 		}
+		groupName := "session"
+		if _, ok := kbConfigs[result.KnowledgeBaseID]; ok {
+			groupName = result.KnowledgeBaseID
+		}
+		group, ok := groups[groupName]
+		if !ok {
+			group, ok = p.newRerankGroup(ctx, chatManage, groupName, kbConfigs[result.KnowledgeBaseID])
+			if !ok {
+				return ErrGetRerankModel
+			}
+			groups[groupName] = group
+			groupOrder = append(groupOrder, group)
+		}
 		// 合并Content和ImageInfo的文本内容
-		passage := getEnrichedPassage(ctx, result)
-		passages = append(passages, passage)
-		candidatesToRerank = append(candidatesToRerank, result)
+		group.passages = append(group.passages, getEnrichedPassage(ctx, result))
+		group.candidates = append(group.candidates, result)
+		candidateCnt++
 	}
 
 	pipelineInfo(ctx, "Rerank", "build_passages", map[string]interface{}{
 		"total_cnt":     len(chatManage.SearchResult),
-		"candidate_cnt": len(candidatesToRerank),
+		"candidate_cnt": candidateCnt,
 		"direct_cnt":    len(directLoadResults),
+		"group_cnt":     len(groupOrder),
 	})
 
-	var rerankResp []rerank.RankResult
-
-	// Only call rerank model if there are candidates
-	if len(candidatesToRerank) > 0 {
-		// Single rerank call with RewriteQuery, use threshold degradation if no results
-		originalThreshold := chatManage.RerankThreshold
-		rerankResp = p.rerank(ctx, chatManage, rerankModel, chatManage.RewriteQuery, passages, candidatesToRerank)
-
-		// If no results and threshold is high enough, try with lower threshold
-		if len(rerankResp) == 0 && originalThreshold > 0.3 {
-			degradedThreshold := originalThreshold * 0.7
-			if degradedThreshold < 0.3 {
-				degradedThreshold = 0.3
-			}
-			pipelineInfo(ctx, "Rerank", "threshold_degrade", map[string]interface{}{
-				"original": originalThreshold,
-				"degraded": degradedThreshold,
-			})
-			chatManage.RerankThreshold = degradedThreshold
-			rerankResp = p.rerank(ctx, chatManage, rerankModel, chatManage.RewriteQuery, passages, candidatesToRerank)
-			// Restore original threshold
-			chatManage.RerankThreshold = originalThreshold
-		}
+	var rerankResp []rerankedCandidate
+	for _, group := range groupOrder {
+		rerankResp = append(rerankResp, p.rerankGroup(ctx, chatManage, group)...)
 	}
 
 	pipelineInfo(ctx, "Rerank", "model_response", map[string]interface{}{
@@ -137,13 +142,10 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 
 	// Process reranked results
 	for _, rr := range rerankResp {
-		if rr.Index >= len(candidatesToRerank) {
-			continue
-		}
-		sr := candidatesToRerank[rr.Index]
+		sr := rr.result
 		base := sr.Score
 		sr.Metadata["base_score"] = fmt.Sprintf("%.4f", base)
-		modelScore := rr.RelevanceScore
+		modelScore := rr.modelScore
 		sr.Score = compositeScore(sr, modelScore, base)
 
 		// Apply FAQ score boost if enabled
@@ -214,11 +216,164 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 	return next()
 }
 
-// rerank performs the actual reranking operation with given query and passages
-func (p *PluginRerank) rerank(ctx context.Context,
-	chatManage *types.ChatManage, rerankModel rerank.Reranker, query string, passages []string,
-	candidates []*types.SearchResult,
-) []rerank.RankResult {
+// rerankedCandidate is a candidate kept by the rerank stage with its model score
+type rerankedCandidate struct {
+	result     *types.SearchResult
+	modelScore float64
+}
+
+// loadRerankConfigs returns the rerank settings of the knowledge bases in the search results,
+// keyed by knowledge base ID; knowledge bases without settings are left out
+func (p *PluginRerank) loadRerankConfigs(ctx context.Context,
+	results []*types.SearchResult,
+) map[string]*types.RerankConfig {
+	kbIDSet := make(map[string]struct{})
+	var kbIDs []string
+	for _, result := range results {
+		if result.KnowledgeBaseID == "" {
+			continue
+		}
+		if _, ok := kbIDSet[result.KnowledgeBaseID]; !ok {
+			kbIDSet[result.KnowledgeBaseID] = struct{}{}
+			kbIDs = append(kbIDs, result.KnowledgeBaseID)
+		}
+	}
+	configs := make(map[string]*types.RerankConfig)
+	if len(kbIDs) == 0 {
+		return configs
+	}
+	kbs, err := p.knowledgeBaseRepo.GetKnowledgeBaseByIDs(ctx, kbIDs)
+	if err != nil {
+		pipelineWarn(ctx, "Rerank", "load_kb_config", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return configs
+	}
+	for _, kb := range kbs {
+		if kb.RerankConfig != nil && kb.RerankConfig.Type != "" {
+			configs[kb.ID] = kb.RerankConfig
+		}
+	}
+	return configs
+}
+
+// newRerankGroup resolves the reranker, threshold and latency budget of a group.
+// A nil config uses the session settings, where failing to load the model aborts the
+// pipeline as before; a knowledge base whose model cannot be loaded keeps its retrieval scores.
+func (p *PluginRerank) newRerankGroup(ctx context.Context, chatManage *types.ChatManage,
+	name string, config *types.RerankConfig,
+) (*rerankGroup, bool) {
+	group := &rerankGroup{name: name, threshold: chatManage.RerankThreshold}
+	if config == nil {
+		if chatManage.RerankModelID == "" {
+			return group, true
+		}
+		model, err := p.modelService.GetRerankModel(ctx, chatManage.RerankModelID)
+		if err != nil {
+			pipelineError(ctx, "Rerank", "get_model", map[string]interface{}{
+				"model_id": chatManage.RerankModelID,
+				"error":    err.Error(),
+			})
+			return nil, false
+		}
+		group.reranker = model
+		return group, true
+	}
+
+	group.fallback = true
+	if config.Threshold > 0 {
+		group.threshold = config.Threshold
+	}
+	if config.TimeoutMs > 0 {
+		group.timeout = time.Duration(config.TimeoutMs) * time.Millisecond
+	}
+	var err error
+	switch config.Type {
+	case types.RerankTypeModel:
+		group.reranker, err = p.modelService.GetRerankModel(ctx, config.ModelID)
+	case types.RerankTypeLLM:
+		var chatModel chat.Chat
+		chatModel, err = p.modelService.GetChatModel(ctx, config.ModelID)
+		if err == nil {
+			group.reranker = rerank.NewLLMReranker(chatModel)
+		}
+	}
+	if err != nil {
+		pipelineWarn(ctx, "Rerank", "get_kb_model", map[string]interface{}{
+			"knowledge_base_id": name,
+			"model_id":          config.ModelID,
+			"error":             err.Error(),
+		})
+	}
+	return group, true
+}
+
+// rerankGroup reranks the candidates of a group, lowering the threshold once when nothing passes.
+// Groups without a reranker, and knowledge base groups whose call fails or exceeds the latency
+// budget, keep all candidates with their retrieval scores.
+func (p *PluginRerank) rerankGroup(ctx context.Context,
+	chatManage *types.ChatManage, group *rerankGroup,
+) []rerankedCandidate {
+	keepBaseScores := func(reason string) []rerankedCandidate {
+		pipelineInfo(ctx, "Rerank", "keep_base_scores", map[string]interface{}{
+			"group":  group.name,
+			"reason": reason,
+		})
+		kept := make([]rerankedCandidate, 0, len(group.candidates))
+		for _, sr := range group.candidates {
+			kept = append(kept, rerankedCandidate{result: sr, modelScore: sr.Score})
+		}
+		return kept
+	}
+	if group.reranker == nil {
+		return keepBaseScores("no_reranker")
+	}
+
+	callCtx := ctx
+	if group.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, group.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	rerankResp, err := p.callReranker(callCtx, group.reranker, chatManage.RewriteQuery, group.passages)
+	pipelineInfo(ctx, "Rerank", "group_call", map[string]interface{}{
+		"group":      group.name,
+		"model":      group.reranker.GetModelName(),
+		"passages":   len(group.passages),
+		"latency_ms": time.Since(start).Milliseconds(),
+	})
+	if err != nil {
+		if group.fallback {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return keepBaseScores("timeout")
+			}
+			return keepBaseScores("model_error")
+		}
+		return nil
+	}
+
+	// Single rerank call with RewriteQuery, use threshold degradation if no results
+	kept := filterRankResults(ctx, rerankResp, group.candidates, group.threshold)
+	if len(kept) == 0 && group.threshold > 0.3 {
+		degradedThreshold := group.threshold * 0.7
+		if degradedThreshold < 0.3 {
+			degradedThreshold = 0.3
+		}
+		pipelineInfo(ctx, "Rerank", "threshold_degrade", map[string]interface{}{
+			"group":    group.name,
+			"original": group.threshold,
+			"degraded": degradedThreshold,
+		})
+		kept = filterRankResults(ctx, rerankResp, group.candidates, degradedThreshold)
+	}
+	return kept
+}
+
+// callReranker performs the actual reranking operation with given query and passages
+func (p *PluginRerank) callReranker(ctx context.Context,
+	rerankModel rerank.Reranker, query string, passages []string,
+) ([]rerank.RankResult, error) {
 	pipelineInfo(ctx, "Rerank", "model_call", map[string]interface{}{
 		"query_variant": query,
 		"passages":      len(passages),
@@ -229,12 +384,18 @@ func (p *PluginRerank) rerank(ctx context.Context,
 			"query_variant": query,
 			"error":         err.Error(),
 		})
-		return nil
+		return nil, err
 	}
+	return rerankResp, nil
+}
 
+// filterRankResults keeps the results scored above the threshold, with special handling for history matches
+func filterRankResults(ctx context.Context,
+	rerankResp []rerank.RankResult, candidates []*types.SearchResult, threshold float64,
+) []rerankedCandidate {
 	// Log top scores for debugging
 	pipelineInfo(ctx, "Rerank", "threshold", map[string]interface{}{
-		"threshold": chatManage.RerankThreshold,
+		"threshold": threshold,
 	})
 	for i := range min(5, len(rerankResp)) {
 		if rerankResp[i].Index < len(candidates) {
@@ -249,19 +410,21 @@ func (p *PluginRerank) rerank(ctx context.Context,
 		}
 	}
 
-	// Filter results based on threshold with special handling for history matches
-	rankFilter := []rerank.RankResult{}
+	rankFilter := []rerankedCandidate{}
 	for _, result := range rerankResp {
-		if result.Index >= len(candidates) {
+		if result.Index < 0 || result.Index >= len(candidates) {
 			continue
 		}
-		th := chatManage.RerankThreshold
+		th := threshold
 		matchType := candidates[result.Index].MatchType
 		if matchType == types.MatchTypeHistory {
 			th = math.Max(th-0.1, 0.5) // Lower threshold for history matches
 		}
 		if result.RelevanceScore > th {
-			rankFilter = append(rankFilter, result)
+			rankFilter = append(rankFilter, rerankedCandidate{
+				result:     candidates[result.Index],
+				modelScore: result.RelevanceScore,
+			})
 		}
 	}
 	return rankFilter
@@ -275,6 +438,13 @@ func ensureMetadata(m map[string]string) map[string]string {
 	return m
 }
 
+const (
+	// pinnedChunkBoost is the score multiplier applied to chunks pinned by curators
+	pinnedChunkBoost = 1.2
+	// questionMatchBoost is the score multiplier applied to chunks matched via a generated question
+	questionMatchBoost = 1.1
+)
+
 // compositeScore calculates the composite score for a search result
 func compositeScore(sr *types.SearchResult, modelScore, baseScore float64) float64 {
 	sourceWeight := 1.0
@@ -290,6 +460,13 @@ func compositeScore(sr *types.SearchResult, modelScore, baseScore float64) float
 	}
 	composite := 0.6*modelScore + 0.3*baseScore + 0.1*sourceWeight
 	composite *= positionPrior
+	if sr.Pinned {
+		composite *= pinnedChunkBoost
+	}
+	// A hit on a generated question means the query is phrased like an FAQ the chunk answers
+	if sr.MatchedQuestion != nil {
+		composite *= questionMatchBoost
+	}
 	if composite < 0 {
 		composite = 0
 	}
@@ -421,6 +598,9 @@ func getEnrichedPassage(ctx context.Context, result *types.SearchResult) string
 			enrichments = append(enrichments, fmt.Sprintf("相关问题: %s", strings.Join(questionStrings, "; ")))
 		}
 	}
+	if q := result.MatchedQuestion; q != nil && q.Answer != "" {
+		enrichments = append(enrichments, fmt.Sprintf("匹配问答: 问：%s 答：%s", q.Question, q.Answer))
+	}
 
 	if len(enrichments) == 0 {
 		return combinedText
@@ -71,61 +71,17 @@ func (p *PluginRewrite) OnEvent(ctx context.Context,
 		"enable_rewrite": chatManage.EnableRewrite,
 	})
 
-	// Get conversation history
-	history, err := p.messageService.GetRecentMessagesBySession(ctx, chatManage.SessionID, 20)
-	if err != nil {
-		pipelineWarn(ctx, "Rewrite", "history_fetch", map[string]interface{}{
-			"session_id": chatManage.SessionID,
-			"error":      err.Error(),
-		})
-	}
-
-	// Convert historical messages to conversation history structure
-	historyMap := make(map[string]*types.History)
-
-	// Process historical messages, grouped by requestID
-	for _, message := range history {
-		history, ok := historyMap[message.RequestID]
-		if !ok {
-			history = &types.History{}
-		}
-		if message.Role == "user" {
-			// User message as query
-			history.Query = message.Content
-			history.CreateAt = message.CreatedAt
-		} else {
-			// System message as answer, while removing thinking process
-			history.Answer = reg.ReplaceAllString(message.Content, "")
-			history.KnowledgeReferences = message.KnowledgeReferences
-		}
-		historyMap[message.RequestID] = history
-	}
-
-	// Convert to list and filter incomplete conversations
-	historyList := make([]*types.History, 0)
-	for _, history := range historyMap {
-		if history.Answer != "" && history.Query != "" {
-			historyList = append(historyList, history)
-		}
-	}
-
-	// Sort by time, keep the most recent conversations
-	sort.Slice(historyList, func(i, j int) bool {
-		return historyList[i].CreateAt.After(historyList[j].CreateAt)
-	})
-
-	// Limit the number of historical records
-	maxRounds := p.config.Conversation.MaxRounds
+	// Get conversation history, unless the caller supplied it with the request
+	conversation := p.config.Current().Conversation
+	maxRounds := conversation.MaxRounds
 	if chatManage.MaxRounds > 0 {
 		maxRounds = chatManage.MaxRounds
 	}
-	if len(historyList) > maxRounds {
-		historyList = historyList[:maxRounds]
+	historyList := chatManage.History
+	if !chatManage.ExternalHistory {
+		historyList = p.loadHistory(ctx, chatManage, maxRounds)
+		chatManage.History = historyList
 	}
-
-	// Reverse to chronological order
-	slices.Reverse(historyList)
-	chatManage.History = historyList
 	if len(historyList) == 0 {
 		pipelineInfo(ctx, "Rewrite", "skip", map[string]interface{}{
 			"session_id": chatManage.SessionID,
@@ -139,11 +95,11 @@ func (p *PluginRewrite) OnEvent(ctx context.Context,
 		"max_rounds":     maxRounds,
 	})
 
-	userPrompt := p.config.Conversation.RewritePromptUser
+	userPrompt := conversation.RewritePromptUser
 	if chatManage.RewritePromptUser != "" {
 		userPrompt = chatManage.RewritePromptUser
 	}
-	systemPrompt := p.config.Conversation.RewritePromptSystem
+	systemPrompt := conversation.RewritePromptSystem
 	if chatManage.RewritePromptSystem != "" {
 		systemPrompt = chatManage.RewritePromptSystem
 	}
@@ -209,6 +165,62 @@ func (p *PluginRewrite) OnEvent(ctx context.Context,
 	return next()
 }
 
+// loadHistory loads the most recent complete conversation rounds of the session in chronological order
+func (p *PluginRewrite) loadHistory(ctx context.Context,
+	chatManage *types.ChatManage, maxRounds int,
+) []*types.History {
+	history, err := p.messageService.GetRecentMessagesBySession(ctx, chatManage.SessionID, 20)
+	if err != nil {
+		pipelineWarn(ctx, "Rewrite", "history_fetch", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+	}
+
+	// Convert historical messages to conversation history structure
+	historyMap := make(map[string]*types.History)
+
+	// Process historical messages, grouped by requestID
+	for _, message := range history {
+		history, ok := historyMap[message.RequestID]
+		if !ok {
+			history = &types.History{}
+		}
+		if message.Role == "user" {
+			// User message as query
+			history.Query = message.Content
+			history.CreateAt = message.CreatedAt
+		} else {
+			// System message as answer, while removing thinking process
+			history.Answer = reg.ReplaceAllString(message.Content, "")
+			history.KnowledgeReferences = message.KnowledgeReferences
+		}
+		historyMap[message.RequestID] = history
+	}
+
+	// Convert to list and filter incomplete conversations
+	historyList := make([]*types.History, 0)
+	for _, history := range historyMap {
+		if history.Answer != "" && history.Query != "" {
+			historyList = append(historyList, history)
+		}
+	}
+
+	// Sort by time, keep the most recent conversations
+	sort.Slice(historyList, func(i, j int) bool {
+		return historyList[i].CreateAt.After(historyList[j].CreateAt)
+	})
+
+	// Limit the number of historical records
+	if len(historyList) > maxRounds {
+		historyList = historyList[:maxRounds]
+	}
+
+	// Reverse to chronological order
+	slices.Reverse(historyList)
+	return historyList
+}
+
 // formatConversationHistory formats conversation history for prompt template
 func formatConversationHistory(historyList []*types.History) string {
 	if len(historyList) == 0 {
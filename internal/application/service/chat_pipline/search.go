@@ -125,6 +125,23 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 		})
 	}
 
+	// LLM expansions are searched by keywords and the HyDE document by vectors,
+	// both generated by PluginQueryExpansion before retrieval
+	if len(chatManage.QueryExpansions) > 0 {
+		pipelineInfo(ctx, "Search", "llm_expansion_start", map[string]interface{}{
+			"variants": len(chatManage.QueryExpansions),
+		})
+		chatManage.SearchResult = append(chatManage.SearchResult,
+			p.searchVariants(ctx, chatManage, chatManage.QueryExpansions, false)...)
+	}
+	if chatManage.HyDEDocument != "" {
+		pipelineInfo(ctx, "Search", "hyde_start", map[string]interface{}{
+			"document_len": len(chatManage.HyDEDocument),
+		})
+		chatManage.SearchResult = append(chatManage.SearchResult,
+			p.searchVariants(ctx, chatManage, []string{chatManage.HyDEDocument}, true)...)
+	}
+
 	// If recall is low, attempt query expansion with keyword-focused search
 	if chatManage.EnableQueryExpansion && len(chatManage.SearchResult) < max(1, chatManage.EmbeddingTopK/2) {
 		pipelineInfo(ctx, "Search", "recall_low", map[string]interface{}{
@@ -136,73 +153,8 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 			pipelineInfo(ctx, "Search", "expansion_start", map[string]interface{}{
 				"variants": len(expansions),
 			})
-			expTopK := max(chatManage.EmbeddingTopK*2, chatManage.RerankTopK*2)
-			expKwTh := chatManage.KeywordThreshold * 0.8
-			// Concurrent expansion retrieval across queries and search targets
-			expResults := make([]*types.SearchResult, 0, expTopK*len(expansions))
-			var muExp sync.Mutex
-			var wgExp sync.WaitGroup
-			jobs := len(expansions) * len(chatManage.SearchTargets)
-			capSem := 16
-			if jobs < capSem {
-				capSem = jobs
-			}
-			if capSem <= 0 {
-				capSem = 1
-			}
-			sem := make(chan struct{}, capSem)
-			pipelineInfo(ctx, "Search", "expansion_concurrency", map[string]interface{}{
-				"jobs": jobs,
-				"cap":  capSem,
-			})
-			for _, q := range expansions {
-				for _, target := range chatManage.SearchTargets {
-					wgExp.Add(1)
-					go func(q string, t *types.SearchTarget) {
-						defer wgExp.Done()
-						sem <- struct{}{}
-						defer func() { <-sem }()
-						paramsExp := types.SearchParams{
-							QueryText:            q,
-							VectorThreshold:      chatManage.VectorThreshold,
-							KeywordThreshold:     expKwTh,
-							MatchCount:           expTopK,
-							DisableVectorMatch:   true,
-							DisableKeywordsMatch: false,
-						}
-						// Apply knowledge ID filter if this is a partial KB search
-						if t.Type == types.SearchTargetTypeKnowledge {
-							paramsExp.KnowledgeIDs = t.KnowledgeIDs
-						}
-						res, err := p.knowledgeBaseService.HybridSearch(ctx, t.KnowledgeBaseID, paramsExp)
-						if err != nil {
-							pipelineWarn(ctx, "Search", "expansion_error", map[string]interface{}{
-								"kb_id": t.KnowledgeBaseID,
-								"error": err.Error(),
-							})
-							return
-						}
-						if len(res) > 0 {
-							pipelineInfo(ctx, "Search", "expansion_hits", map[string]interface{}{
-								"kb_id": t.KnowledgeBaseID,
-								"query": q,
-								"hits":  len(res),
-							})
-							muExp.Lock()
-							expResults = append(expResults, res...)
-							muExp.Unlock()
-						}
-					}(q, target)
-				}
-			}
-			wgExp.Wait()
-			if len(expResults) > 0 {
-				// Scores already normalized in HybridSearch
-				pipelineInfo(ctx, "Search", "expansion_done", map[string]interface{}{
-					"added": len(expResults),
-				})
-				chatManage.SearchResult = append(chatManage.SearchResult, expResults...)
-			}
+			chatManage.SearchResult = append(chatManage.SearchResult,
+				p.searchVariants(ctx, chatManage, expansions, false)...)
 		}
 	}
 
@@ -333,8 +285,9 @@ func (p *PluginSearch) searchByTargets(
 			// Default to all IDs in the target
 			searchKnowledgeIDs := t.KnowledgeIDs
 
-			// Try direct loading for specific knowledge targets
-			if t.Type == types.SearchTargetTypeKnowledge {
+			// Try direct loading for specific knowledge targets; filtered requests go through
+			// HybridSearch so that the filter also applies to the selected files
+			if t.Type == types.SearchTargetTypeKnowledge && chatManage.SearchFilter.IsEmpty() {
 				directResults, skippedIDs := p.tryDirectChunkLoading(ctx, chatManage.TenantID, t.KnowledgeIDs)
 
 				if len(directResults) > 0 {
@@ -368,6 +321,7 @@ func (p *PluginSearch) searchByTargets(
 				VectorThreshold:  chatManage.VectorThreshold,
 				KeywordThreshold: chatManage.KeywordThreshold,
 				MatchCount:       chatManage.EmbeddingTopK,
+				Filter:           chatManage.SearchFilter,
 			}
 			// Apply knowledge ID filter if this is a partial KB search
 			if t.Type == types.SearchTargetTypeKnowledge {
@@ -462,18 +416,19 @@ func (p *PluginSearch) tryDirectChunkLoading(ctx context.Context, tenantID uint6
 	var results []*types.SearchResult
 	for _, chunk := range allChunks {
 		res := &types.SearchResult{
-			ID:            chunk.ID,
-			Content:       chunk.Content,
-			Score:         1.0, // Maximum score for direct matches
-			KnowledgeID:   chunk.KnowledgeID,
-			ChunkIndex:    chunk.ChunkIndex,
-			MatchType:     types.MatchTypeDirectLoad,
-			ChunkType:     string(chunk.ChunkType),
-			ParentChunkID: chunk.ParentChunkID,
-			ImageInfo:     chunk.ImageInfo,
-			ChunkMetadata: chunk.Metadata,
-			StartAt:       chunk.StartAt,
-			EndAt:         chunk.EndAt,
+			ID:              chunk.ID,
+			Content:         chunk.Content,
+			Score:           1.0, // Maximum score for direct matches
+			KnowledgeID:     chunk.KnowledgeID,
+			KnowledgeBaseID: chunk.KnowledgeBaseID,
+			ChunkIndex:      chunk.ChunkIndex,
+			MatchType:       types.MatchTypeDirectLoad,
+			ChunkType:       string(chunk.ChunkType),
+			ParentChunkID:   chunk.ParentChunkID,
+			ImageInfo:       chunk.ImageInfo,
+			ChunkMetadata:   chunk.Metadata,
+			StartAt:         chunk.StartAt,
+			EndAt:           chunk.EndAt,
 		}
 
 		if k, ok := knowledgeMap[chunk.KnowledgeID]; ok {
@@ -538,6 +493,86 @@ func (p *PluginSearch) searchWebIfEnabled(ctx context.Context, chatManage *types
 	return res
 }
 
+// searchVariants searches query variants across all search targets concurrently.
+// Keyword variants use a relaxed keyword threshold without vector matching,
+// vector variants (HyDE documents) are matched by embeddings only.
+func (p *PluginSearch) searchVariants(ctx context.Context,
+	chatManage *types.ChatManage, queries []string, vectorOnly bool,
+) []*types.SearchResult {
+	expTopK := max(chatManage.EmbeddingTopK*2, chatManage.RerankTopK*2)
+	expKwTh := chatManage.KeywordThreshold * 0.8
+	if vectorOnly {
+		expTopK = chatManage.EmbeddingTopK
+	}
+	// Concurrent expansion retrieval across queries and search targets
+	expResults := make([]*types.SearchResult, 0, expTopK*len(queries))
+	var muExp sync.Mutex
+	var wgExp sync.WaitGroup
+	jobs := len(queries) * len(chatManage.SearchTargets)
+	capSem := 16
+	if jobs < capSem {
+		capSem = jobs
+	}
+	if capSem <= 0 {
+		capSem = 1
+	}
+	sem := make(chan struct{}, capSem)
+	pipelineInfo(ctx, "Search", "expansion_concurrency", map[string]interface{}{
+		"jobs":        jobs,
+		"cap":         capSem,
+		"vector_only": vectorOnly,
+	})
+	for _, q := range queries {
+		for _, target := range chatManage.SearchTargets {
+			wgExp.Add(1)
+			go func(q string, t *types.SearchTarget) {
+				defer wgExp.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				paramsExp := types.SearchParams{
+					QueryText:            q,
+					VectorThreshold:      chatManage.VectorThreshold,
+					KeywordThreshold:     expKwTh,
+					MatchCount:           expTopK,
+					DisableVectorMatch:   !vectorOnly,
+					DisableKeywordsMatch: vectorOnly,
+					Filter:               chatManage.SearchFilter,
+				}
+				// Apply knowledge ID filter if this is a partial KB search
+				if t.Type == types.SearchTargetTypeKnowledge {
+					paramsExp.KnowledgeIDs = t.KnowledgeIDs
+				}
+				res, err := p.knowledgeBaseService.HybridSearch(ctx, t.KnowledgeBaseID, paramsExp)
+				if err != nil {
+					pipelineWarn(ctx, "Search", "expansion_error", map[string]interface{}{
+						"kb_id": t.KnowledgeBaseID,
+						"error": err.Error(),
+					})
+					return
+				}
+				if len(res) > 0 {
+					pipelineInfo(ctx, "Search", "expansion_hits", map[string]interface{}{
+						"kb_id": t.KnowledgeBaseID,
+						"query": q,
+						"hits":  len(res),
+					})
+					muExp.Lock()
+					expResults = append(expResults, res...)
+					muExp.Unlock()
+				}
+			}(q, target)
+		}
+	}
+	wgExp.Wait()
+	if len(expResults) > 0 {
+		// Scores already normalized in HybridSearch
+		pipelineInfo(ctx, "Search", "expansion_done", map[string]interface{}{
+			"added": len(expResults),
+		})
+	}
+	return expResults
+}
+
 // expandQueries generates query variants locally without LLM to improve keyword recall
 // Uses simple techniques: word reordering, stopword removal, key phrase extraction
 func (p *PluginSearch) expandQueries(ctx context.Context, chatManage *types.ChatManage) []string {
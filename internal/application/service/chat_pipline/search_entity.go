@@ -208,6 +208,7 @@ func chunk2SearchResult(chunk *types.Chunk, knowledge *types.Knowledge) *types.S
 		ID:                chunk.ID,
 		Content:           chunk.Content,
 		KnowledgeID:       chunk.KnowledgeID,
+		KnowledgeBaseID:   chunk.KnowledgeBaseID,
 		ChunkIndex:        chunk.ChunkIndex,
 		KnowledgeTitle:    knowledge.Title,
 		StartAt:           chunk.StartAt,
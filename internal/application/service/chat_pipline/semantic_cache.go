@@ -0,0 +1,189 @@
+package chatpipline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// PluginSemanticCache answers questions from the semantic cache of a knowledge base
+// and caches new answers once they are fully streamed. It runs after PluginRewrite so
+// follow-up questions are matched by their standalone form.
+type PluginSemanticCache struct {
+	modelService      interfaces.ModelService
+	knowledgeBaseRepo interfaces.KnowledgeBaseRepository
+	semanticCache     interfaces.SemanticCache
+	kbPermissionRepo  interfaces.KBPermissionRepository
+}
+
+// NewPluginSemanticCache creates a new semantic cache plugin instance
+// Also registers the plugin with the event manager
+func NewPluginSemanticCache(eventManager *EventManager,
+	modelService interfaces.ModelService,
+	knowledgeBaseRepo interfaces.KnowledgeBaseRepository,
+	semanticCache interfaces.SemanticCache,
+	kbPermissionRepo interfaces.KBPermissionRepository,
+) *PluginSemanticCache {
+	res := &PluginSemanticCache{
+		modelService:      modelService,
+		knowledgeBaseRepo: knowledgeBaseRepo,
+		semanticCache:     semanticCache,
+		kbPermissionRepo:  kbPermissionRepo,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to
+func (p *PluginSemanticCache) ActivationEvents() []types.EventType {
+	return []types.EventType{types.REWRITE_QUERY}
+}
+
+// OnEvent looks the query up in the cache; on a hit the cached answer is emitted and the
+// pipeline stops with ErrSemanticCacheHit, on a miss the answer is cached when streamed
+func (p *PluginSemanticCache) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	// Only plain questions against one whole knowledge base are cached, anything
+	// narrowing or widening retrieval would make cached answers wrong. Experiment
+	// traffic bypasses the cache so each variant is measured on its own answers.
+	if chatManage.EventBus == nil || chatManage.WebSearchEnabled || chatManage.Experiment != nil ||
+		len(chatManage.SearchTargets) != 1 ||
+		chatManage.SearchTargets[0].Type != types.SearchTargetTypeKnowledgeBase || !chatManage.SearchFilter.IsEmpty() {
+		return next()
+	}
+	kb, err := p.knowledgeBaseRepo.GetKnowledgeBaseByID(ctx, chatManage.SearchTargets[0].KnowledgeBaseID)
+	if err != nil || !kb.SemanticCacheConfig.IsEnabled() {
+		return next()
+	}
+	// Cached answers may cite documents hidden from the user, and its own answers
+	// lack documents others can see, so such users never share the cache
+	if p.hasHiddenKnowledge(ctx, kb.ID) {
+		return next()
+	}
+
+	query := chatManage.RewriteQuery
+	if query == "" {
+		query = chatManage.Query
+	}
+	embedder, err := p.modelService.GetEmbeddingModelForTenant(ctx, kb.EmbeddingModelID, kb.TenantID)
+	if err != nil {
+		pipelineWarn(ctx, "SemanticCache", "get_model", map[string]interface{}{
+			"kb_id": kb.ID,
+			"error": err.Error(),
+		})
+		return next()
+	}
+	embedding, err := embedder.Embed(ctx, query)
+	if err != nil {
+		pipelineWarn(ctx, "SemanticCache", "embed", map[string]interface{}{
+			"kb_id": kb.ID,
+			"error": err.Error(),
+		})
+		return next()
+	}
+
+	entry, score, err := p.semanticCache.Lookup(ctx, kb.ID, kb.EmbeddingModelID,
+		embedding, kb.SemanticCacheConfig.GetThreshold())
+	if err != nil {
+		pipelineWarn(ctx, "SemanticCache", "lookup", map[string]interface{}{
+			"kb_id": kb.ID,
+			"error": err.Error(),
+		})
+	}
+	if entry != nil {
+		pipelineInfo(ctx, "SemanticCache", "hit", map[string]interface{}{
+			"session_id":   chatManage.SessionID,
+			"kb_id":        kb.ID,
+			"cached_query": entry.Query,
+			"score":        fmt.Sprintf("%.4f", score),
+		})
+		chatManage.MergeResult = entry.References
+		chatManage.ChatResponse = &types.ChatResponse{Content: entry.Answer}
+		if err := chatManage.EventBus.Emit(ctx, types.Event{
+			ID:        fmt.Sprintf("%s-answer", uuid.New().String()[:8]),
+			Type:      types.EventType(event.EventAgentFinalAnswer),
+			SessionID: chatManage.SessionID,
+			Data: event.AgentFinalAnswerData{
+				Content: entry.Answer,
+				Done:    true,
+			},
+		}); err != nil {
+			pipelineWarn(ctx, "SemanticCache", "emit", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return ErrSemanticCacheHit
+	}
+
+	p.cacheAnswer(ctx, chatManage, kb, query, embedding)
+	return next()
+}
+
+// hasHiddenKnowledge checks if documents of the knowledge base are hidden from the current user
+func (p *PluginSemanticCache) hasHiddenKnowledge(ctx context.Context, kbID string) bool {
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+	if userID == "" {
+		return false
+	}
+	hidden, err := p.kbPermissionRepo.ListHiddenKnowledgeIDs(ctx, kbID, userID)
+	if err != nil {
+		pipelineWarn(ctx, "SemanticCache", "list_hidden", map[string]interface{}{
+			"kb_id": kbID,
+			"error": err.Error(),
+		})
+		return true
+	}
+	return len(hidden) > 0
+}
+
+// cacheAnswer collects the streamed answer and caches it once done, as long as the
+// answer was built from retrieved references rather than the fallback response
+func (p *PluginSemanticCache) cacheAnswer(ctx context.Context, chatManage *types.ChatManage,
+	kb *types.KnowledgeBase, query string, embedding []float32,
+) {
+	var answer strings.Builder
+	stored := false
+	chatManage.EventBus.On(types.EventType(event.EventAgentFinalAnswer), func(_ context.Context, evt types.Event) error {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok || stored {
+			return nil
+		}
+		answer.WriteString(data.Content)
+		if !data.Done {
+			return nil
+		}
+		stored = true
+		content := strings.TrimSpace(reg.ReplaceAllString(answer.String(), ""))
+		if content == "" || len(chatManage.MergeResult) == 0 {
+			return nil
+		}
+		knowledgeIDs := make([]string, 0, len(chatManage.MergeResult))
+		seen := make(map[string]struct{})
+		for _, ref := range chatManage.MergeResult {
+			if _, ok := seen[ref.KnowledgeID]; !ok {
+				seen[ref.KnowledgeID] = struct{}{}
+				knowledgeIDs = append(knowledgeIDs, ref.KnowledgeID)
+			}
+		}
+		if err := p.semanticCache.Store(context.WithoutCancel(ctx), kb.ID, &types.SemanticCacheEntry{
+			Query:            query,
+			Embedding:        embedding,
+			EmbeddingModelID: kb.EmbeddingModelID,
+			Answer:           content,
+			References:       chatManage.MergeResult,
+			KnowledgeIDs:     knowledgeIDs,
+		}, kb.SemanticCacheConfig.GetTTL()); err != nil {
+			pipelineWarn(ctx, "SemanticCache", "store", map[string]interface{}{
+				"kb_id": kb.ID,
+				"error": err.Error(),
+			})
+		}
+		return nil
+	})
+}
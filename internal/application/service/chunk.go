@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -21,6 +22,7 @@ type chunkService struct {
 	kbRepository    interfaces.KnowledgeBaseRepository
 	modelService    interfaces.ModelService
 	retrieveEngine  interfaces.RetrieveEngineRegistry
+	semanticCache   interfaces.SemanticCache
 }
 
 // NewChunkService creates a new chunk service
@@ -35,12 +37,14 @@ func NewChunkService(
 	kbRepository interfaces.KnowledgeBaseRepository,
 	modelService interfaces.ModelService,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
+	semanticCache interfaces.SemanticCache,
 ) interfaces.ChunkService {
 	return &chunkService{
 		chunkRepository: chunkRepository,
 		kbRepository:    kbRepository,
 		modelService:    modelService,
 		retrieveEngine:  retrieveEngine,
+		semanticCache:   semanticCache,
 	}
 }
 
@@ -451,3 +455,118 @@ func (s *chunkService) DeleteGeneratedQuestion(ctx context.Context, chunkID stri
 	logger.Infof(ctx, "Successfully deleted generated question %s from chunk %s", questionID, chunkID)
 	return nil
 }
+
+// UpdateChunkContent replaces the text of a document chunk and re-embeds only that chunk.
+// Vectors of generated questions are keyed by their own source IDs and are left untouched.
+func (s *chunkService) UpdateChunkContent(ctx context.Context, chunk *types.Chunk, content string) error {
+	if chunk.ChunkType == types.ChunkTypeFAQ {
+		return werrors.NewBadRequestError("FAQ 条目请通过 FAQ 接口编辑")
+	}
+	if content == "" {
+		return werrors.NewBadRequestError("分块内容不能为空")
+	}
+	if content == chunk.Content {
+		return nil
+	}
+	logger.Infof(ctx, "Updating chunk content, ID: %s, knowledge ID: %s", chunk.ID, chunk.KnowledgeID)
+
+	kb, err := s.kbRepository.GetKnowledgeBaseByID(ctx, chunk.KnowledgeBaseID)
+	if err != nil {
+		return fmt.Errorf("failed to get knowledge base: %w", err)
+	}
+	embeddingModel, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		return fmt.Errorf("failed to get embedding model: %w", err)
+	}
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return fmt.Errorf("failed to create retrieve engine: %w", err)
+	}
+
+	// Persist the new text first so a failed embedding leaves the chunk visibly pending
+	chunk.Content = content
+	chunk.Status = int(types.ChunkStatusStored)
+	if err := s.chunkRepository.UpdateChunk(ctx, chunk); err != nil {
+		return fmt.Errorf("failed to update chunk: %w", err)
+	}
+
+	if err := retrieveEngine.DeleteBySourceIDList(ctx, []string{chunk.ID}, embeddingModel.GetDimensions(), kb.Type); err != nil {
+		return fmt.Errorf("failed to delete chunk vector: %w", err)
+	}
+	if err := retrieveEngine.BatchIndex(ctx, embeddingModel, []*types.IndexInfo{{
+		Content:         chunk.Content,
+		SourceID:        chunk.ID,
+		SourceType:      types.ChunkSourceType,
+		ChunkID:         chunk.ID,
+		KnowledgeID:     chunk.KnowledgeID,
+		KnowledgeBaseID: chunk.KnowledgeBaseID,
+		TagID:           chunk.TagID,
+	}}); err != nil {
+		return fmt.Errorf("failed to index chunk: %w", err)
+	}
+	// New vectors are enabled by default; keep a disabled chunk out of retrieval
+	if !chunk.IsEnabled {
+		if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, map[string]bool{chunk.ID: false}); err != nil {
+			return fmt.Errorf("failed to update chunk index status: %w", err)
+		}
+	}
+
+	chunk.Status = int(types.ChunkStatusIndexed)
+	if err := s.chunkRepository.UpdateChunk(ctx, chunk); err != nil {
+		return fmt.Errorf("failed to update chunk: %w", err)
+	}
+	s.invalidateSemanticCache(ctx, chunk)
+	logger.Infof(ctx, "Chunk content updated and re-embedded, ID: %s", chunk.ID)
+	return nil
+}
+
+// invalidateSemanticCache drops the cached answers of the knowledge base of a chunk whose content or status
+// changed, any answer may have been built from it or would now be built from it. Failures are only logged since
+// stale entries still expire with their TTL.
+func (s *chunkService) invalidateSemanticCache(ctx context.Context, chunk *types.Chunk) {
+	if err := s.semanticCache.InvalidateKnowledgeBase(ctx, chunk.KnowledgeBaseID); err != nil {
+		logger.Warnf(ctx, "Failed to invalidate semantic cache of knowledge base %s: %v", chunk.KnowledgeBaseID, err)
+	}
+}
+
+// SetChunkEnabled enables or disables a single chunk for retrieval without touching its document
+func (s *chunkService) SetChunkEnabled(ctx context.Context, chunk *types.Chunk, enabled bool) error {
+	if chunk.IsEnabled == enabled {
+		return nil
+	}
+	logger.Infof(ctx, "Setting chunk enabled status, ID: %s, enabled: %v", chunk.ID, enabled)
+
+	chunk.IsEnabled = enabled
+	if err := s.chunkRepository.UpdateChunk(ctx, chunk); err != nil {
+		return fmt.Errorf("failed to update chunk: %w", err)
+	}
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return fmt.Errorf("failed to create retrieve engine: %w", err)
+	}
+	if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, map[string]bool{chunk.ID: enabled}); err != nil {
+		return fmt.Errorf("failed to update chunk index status: %w", err)
+	}
+	s.invalidateSemanticCache(ctx, chunk)
+	return nil
+}
+
+// SetChunkPinned pins or unpins a chunk; pinned chunks get a ranking boost at rerank time
+func (s *chunkService) SetChunkPinned(ctx context.Context, chunk *types.Chunk, pinned bool) error {
+	if chunk.IsPinned() == pinned {
+		return nil
+	}
+	logger.Infof(ctx, "Setting chunk pinned status, ID: %s, pinned: %v", chunk.ID, pinned)
+
+	var setFlags, clearFlags map[string]types.ChunkFlags
+	if pinned {
+		setFlags = map[string]types.ChunkFlags{chunk.ID: types.ChunkFlagPinned}
+		chunk.Flags = chunk.Flags.SetFlag(types.ChunkFlagPinned)
+	} else {
+		clearFlags = map[string]types.ChunkFlags{chunk.ID: types.ChunkFlagPinned}
+		chunk.Flags = chunk.Flags.ClearFlag(types.ChunkFlagPinned)
+	}
+	return s.chunkRepository.UpdateChunkFlagsBatch(ctx, chunk.TenantID, chunk.KnowledgeBaseID, setFlags, clearFlags)
+}
@@ -257,6 +257,7 @@ func (e *EvaluationService) Evaluation(ctx context.Context,
 	logger.Infof(ctx, "Generated task ID: %s", taskID)
 
 	// Prepare evaluation detail with all parameters
+	conversation := e.config.Current().Conversation
 	detail := &types.EvaluationDetail{
 		Task: &types.EvaluationTask{
 			ID:        taskID,
@@ -266,31 +267,31 @@ func (e *EvaluationService) Evaluation(ctx context.Context,
 			StartTime: time.Now(),
 		},
 		Params: &types.ChatManage{
-			VectorThreshold:  e.config.Conversation.VectorThreshold,
-			KeywordThreshold: e.config.Conversation.KeywordThreshold,
-			EmbeddingTopK:    e.config.Conversation.EmbeddingTopK,
-			MaxRounds:        e.config.Conversation.MaxRounds,
+			VectorThreshold:  conversation.VectorThreshold,
+			KeywordThreshold: conversation.KeywordThreshold,
+			EmbeddingTopK:    conversation.EmbeddingTopK,
+			MaxRounds:        conversation.MaxRounds,
 			RerankModelID:    rerankModelID,
-			RerankTopK:       e.config.Conversation.RerankTopK,
-			RerankThreshold:  e.config.Conversation.RerankThreshold,
+			RerankTopK:       conversation.RerankTopK,
+			RerankThreshold:  conversation.RerankThreshold,
 			ChatModelID:      chatModelID,
 			SummaryConfig: types.SummaryConfig{
-				MaxTokens:           e.config.Conversation.Summary.MaxTokens,
-				RepeatPenalty:       e.config.Conversation.Summary.RepeatPenalty,
-				TopK:                e.config.Conversation.Summary.TopK,
-				TopP:                e.config.Conversation.Summary.TopP,
-				Prompt:              e.config.Conversation.Summary.Prompt,
-				ContextTemplate:     e.config.Conversation.Summary.ContextTemplate,
-				FrequencyPenalty:    e.config.Conversation.Summary.FrequencyPenalty,
-				PresencePenalty:     e.config.Conversation.Summary.PresencePenalty,
-				NoMatchPrefix:       e.config.Conversation.Summary.NoMatchPrefix,
-				Temperature:         e.config.Conversation.Summary.Temperature,
-				Seed:                e.config.Conversation.Summary.Seed,
-				MaxCompletionTokens: e.config.Conversation.Summary.MaxCompletionTokens,
+				MaxTokens:           conversation.Summary.MaxTokens,
+				RepeatPenalty:       conversation.Summary.RepeatPenalty,
+				TopK:                conversation.Summary.TopK,
+				TopP:                conversation.Summary.TopP,
+				Prompt:              conversation.Summary.Prompt,
+				ContextTemplate:     conversation.Summary.ContextTemplate,
+				FrequencyPenalty:    conversation.Summary.FrequencyPenalty,
+				PresencePenalty:     conversation.Summary.PresencePenalty,
+				NoMatchPrefix:       conversation.Summary.NoMatchPrefix,
+				Temperature:         conversation.Summary.Temperature,
+				Seed:                conversation.Summary.Seed,
+				MaxCompletionTokens: conversation.Summary.MaxCompletionTokens,
 			},
-			FallbackResponse:    e.config.Conversation.FallbackResponse,
-			RewritePromptSystem: e.config.Conversation.RewritePromptSystem,
-			RewritePromptUser:   e.config.Conversation.RewritePromptUser,
+			FallbackResponse:    conversation.FallbackResponse,
+			RewritePromptSystem: conversation.RewritePromptSystem,
+			RewritePromptUser:   conversation.RewritePromptUser,
 		},
 	}
 
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// experimentService implements interfaces.ExperimentService
+type experimentService struct {
+	repo          interfaces.ExperimentRepository
+	searchLogRepo interfaces.SearchLogRepository
+}
+
+// NewExperimentService creates a new experiment service
+func NewExperimentService(repo interfaces.ExperimentRepository,
+	searchLogRepo interfaces.SearchLogRepository,
+) interfaces.ExperimentService {
+	return &experimentService{repo: repo, searchLogRepo: searchLogRepo}
+}
+
+// CreateExperiment creates and starts an experiment, a knowledge base runs at most one at a time
+func (s *experimentService) CreateExperiment(ctx context.Context,
+	experiment *types.KBExperiment,
+) (*types.KBExperiment, error) {
+	live, err := s.repo.GetLiveExperiment(ctx, experiment.KnowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	if live != nil && live.Status == types.ExperimentStatusRunning {
+		return nil, werrors.NewConflictError("该知识库已有正在运行的实验，请先停止或晋升")
+	}
+
+	experiment.ID = uuid.New().String()
+	experiment.TenantID = ctx.Value(types.TenantIDContextKey).(uint64)
+	experiment.Status = types.ExperimentStatusRunning
+	experiment.Winner = ""
+	if err := s.repo.CreateExperiment(ctx, experiment); err != nil {
+		logger.Errorf(ctx, "Failed to create experiment: %v", err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Experiment started, ID: %s, knowledge base ID: %s, traffic to B: %d%%",
+		experiment.ID, experiment.KnowledgeBaseID, experiment.TrafficPercent)
+	return experiment, nil
+}
+
+// GetExperiment returns an experiment of a knowledge base
+func (s *experimentService) GetExperiment(ctx context.Context,
+	knowledgeBaseID, id string,
+) (*types.KBExperiment, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	experiment, err := s.repo.GetExperiment(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if experiment.KnowledgeBaseID != knowledgeBaseID {
+		return nil, repository.ErrExperimentNotFound
+	}
+	return experiment, nil
+}
+
+// ListExperiments lists the experiments of a knowledge base, newest first
+func (s *experimentService) ListExperiments(ctx context.Context,
+	knowledgeBaseID string,
+) ([]*types.KBExperiment, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	return s.repo.ListExperiments(ctx, tenantID, knowledgeBaseID)
+}
+
+// UpdateExperiment updates the name, description, traffic split and promotion settings of an
+// experiment. Variants cannot change once traffic was routed to them, that would mix the results
+// of two configurations.
+func (s *experimentService) UpdateExperiment(ctx context.Context,
+	experiment *types.KBExperiment,
+) (*types.KBExperiment, error) {
+	existing, err := s.GetExperiment(ctx, experiment.KnowledgeBaseID, experiment.ID)
+	if err != nil {
+		return nil, err
+	}
+	existing.Name = experiment.Name
+	existing.Description = experiment.Description
+	existing.TrafficPercent = experiment.TrafficPercent
+	existing.AutoPromote = experiment.AutoPromote
+	if err := s.repo.UpdateExperiment(ctx, existing); err != nil {
+		logger.Errorf(ctx, "Failed to update experiment %s: %v", existing.ID, err)
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteExperiment deletes an experiment, its search logs are kept
+func (s *experimentService) DeleteExperiment(ctx context.Context, knowledgeBaseID, id string) error {
+	experiment, err := s.GetExperiment(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteExperiment(ctx, experiment.TenantID, experiment.ID)
+}
+
+// StopExperiment stops routing chat traffic to an experiment
+func (s *experimentService) StopExperiment(ctx context.Context,
+	knowledgeBaseID, id string,
+) (*types.KBExperiment, error) {
+	experiment, err := s.GetExperiment(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !experiment.IsLive() {
+		return experiment, nil
+	}
+	now := time.Now()
+	experiment.Status = types.ExperimentStatusStopped
+	experiment.StoppedAt = &now
+	if err := s.repo.UpdateExperiment(ctx, experiment); err != nil {
+		logger.Errorf(ctx, "Failed to stop experiment %s: %v", experiment.ID, err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Experiment %s stopped", experiment.ID)
+	return experiment, nil
+}
+
+// PromoteExperiment routes all chat traffic of the knowledge base to a variant
+func (s *experimentService) PromoteExperiment(ctx context.Context, knowledgeBaseID, id string,
+	variant types.ExperimentVariant,
+) (*types.KBExperiment, error) {
+	experiment, err := s.GetExperiment(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return nil, err
+	}
+	if experiment.Status == types.ExperimentStatusStopped {
+		return nil, werrors.NewConflictError("实验已停止，无法晋升")
+	}
+	if err := s.promote(ctx, experiment, variant); err != nil {
+		return nil, err
+	}
+	return experiment, nil
+}
+
+// promote marks a variant as the winner of an experiment
+func (s *experimentService) promote(ctx context.Context,
+	experiment *types.KBExperiment, variant types.ExperimentVariant,
+) error {
+	now := time.Now()
+	experiment.Status = types.ExperimentStatusPromoted
+	experiment.Winner = variant
+	experiment.PromotedAt = &now
+	if err := s.repo.PromoteExperiment(ctx, experiment); err != nil {
+		logger.Errorf(ctx, "Failed to promote experiment %s: %v", experiment.ID, err)
+		return err
+	}
+	logger.Infof(ctx, "Experiment %s promoted variant %s, knowledge base ID: %s",
+		experiment.ID, variant, experiment.KnowledgeBaseID)
+	return nil
+}
+
+// GetExperimentResults compares the variants of an experiment
+func (s *experimentService) GetExperimentResults(ctx context.Context,
+	knowledgeBaseID, id string,
+) (*types.ExperimentResults, error) {
+	experiment, err := s.GetExperiment(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.compare(ctx, experiment)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get results of experiment %s: %v", experiment.ID, err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// compare aggregates the search logs of both variants and finds the leader on the promotion metric
+func (s *experimentService) compare(ctx context.Context,
+	experiment *types.KBExperiment,
+) (*types.ExperimentResults, error) {
+	stats, err := s.searchLogRepo.GetExperimentStats(ctx, experiment.KnowledgeBaseID, experiment.ID)
+	if err != nil {
+		return nil, err
+	}
+	variants := map[types.ExperimentVariant]*types.ExperimentVariantStats{
+		types.ExperimentVariantA: {Variant: types.ExperimentVariantA},
+		types.ExperimentVariantB: {Variant: types.ExperimentVariantB},
+	}
+	for _, stat := range stats {
+		if _, ok := variants[stat.Variant]; ok {
+			variants[stat.Variant] = stat
+		}
+	}
+	a, b := variants[types.ExperimentVariantA], variants[types.ExperimentVariantB]
+	fillExperimentRates(a)
+	fillExperimentRates(b)
+
+	results := &types.ExperimentResults{
+		Experiment: experiment,
+		Variants:   []*types.ExperimentVariantStats{a, b},
+	}
+	results.Leader, results.Lift = experimentLeader(&experiment.AutoPromote, a, b)
+	return results, nil
+}
+
+// AssignVariant returns the variant of the live experiment of a knowledge base a chat session is routed to.
+// Promoted experiments only return the winner's configuration, their traffic is no longer measured.
+func (s *experimentService) AssignVariant(ctx context.Context, knowledgeBaseID, sessionID string) (
+	*types.ExperimentAssignment, *types.ExperimentVariantConfig, error,
+) {
+	experiment, err := s.repo.GetLiveExperiment(ctx, knowledgeBaseID)
+	if err != nil || experiment == nil {
+		return nil, nil, err
+	}
+	variant := experiment.AssignVariant(sessionID)
+	if experiment.Status == types.ExperimentStatusPromoted {
+		return nil, experiment.VariantConfig(variant), nil
+	}
+	return &types.ExperimentAssignment{ExperimentID: experiment.ID, Variant: variant},
+		experiment.VariantConfig(variant), nil
+}
+
+// ProcessExperimentPromotion promotes the winners of running experiments with automatic promotion
+func (s *experimentService) ProcessExperimentPromotion(ctx context.Context, t *asynq.Task) error {
+	experiments, err := s.repo.ListRunningExperiments(ctx)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list running experiments: %v", err)
+		return err
+	}
+	for _, experiment := range experiments {
+		if !experiment.AutoPromote.Enabled {
+			continue
+		}
+		results, err := s.compare(ctx, experiment)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to compare variants of experiment %s: %v", experiment.ID, err)
+			continue
+		}
+		if results.Leader == "" || results.Lift < experiment.AutoPromote.GetMinLift() {
+			continue
+		}
+		logger.Infof(ctx, "Experiment %s variant %s leads on %s by %.1f%%, promoting",
+			experiment.ID, results.Leader, experiment.AutoPromote.GetMetric(), results.Lift*100)
+		// promote logs its own failure, the remaining experiments are still checked
+		_ = s.promote(ctx, experiment, results.Leader)
+	}
+	return nil
+}
+
+// fillExperimentRates derives the click-through and satisfaction rates of a variant
+func fillExperimentRates(stats *types.ExperimentVariantStats) {
+	if stats.Searches > 0 {
+		stats.ClickThroughRate = float64(stats.ClickedSearches) / float64(stats.Searches)
+	}
+	if rated := stats.PositiveFeedback + stats.NegativeFeedback; rated > 0 {
+		stats.SatisfactionRate = float64(stats.PositiveFeedback) / float64(rated)
+	}
+}
+
+// experimentLeader returns the variant ahead on the promotion metric and its relative lift over
+// the other variant, no leader while either variant lacks samples or both are even
+func experimentLeader(cfg *types.ExperimentAutoPromote, a, b *types.ExperimentVariantStats) (
+	types.ExperimentVariant, float64,
+) {
+	metric := cfg.GetMetric()
+	samples := func(stats *types.ExperimentVariantStats) int64 {
+		if metric == types.ExperimentMetricFeedback {
+			return stats.PositiveFeedback + stats.NegativeFeedback
+		}
+		return stats.Searches
+	}
+	minSamples := int64(cfg.GetMinSamples())
+	if samples(a) < minSamples || samples(b) < minSamples {
+		return "", 0
+	}
+
+	var valueA, valueB float64
+	switch metric {
+	case types.ExperimentMetricClicks:
+		valueA, valueB = a.ClickThroughRate, b.ClickThroughRate
+	case types.ExperimentMetricLatency:
+		// Lower latency wins, compare the time saved relative to the slower variant
+		if a.AvgLatencyMs == b.AvgLatencyMs {
+			return "", 0
+		}
+		if a.AvgLatencyMs < b.AvgLatencyMs {
+			return types.ExperimentVariantA, (b.AvgLatencyMs - a.AvgLatencyMs) / b.AvgLatencyMs
+		}
+		return types.ExperimentVariantB, (a.AvgLatencyMs - b.AvgLatencyMs) / a.AvgLatencyMs
+	default:
+		valueA, valueB = a.SatisfactionRate, b.SatisfactionRate
+	}
+
+	leader, high, low := types.ExperimentVariantA, valueA, valueB
+	if valueB > valueA {
+		leader, high, low = types.ExperimentVariantB, valueB, valueA
+	}
+	if high == low {
+		return "", 0
+	}
+	if low == 0 {
+		return leader, 1
+	}
+	return leader, (high - low) / low
+}
+
+// applyExperiment routes a chat request against a single knowledge base to the variant of its
+// live experiment, on failure the request keeps its own configuration
+func (s *sessionService) applyExperiment(ctx context.Context, sessionID string, chatManage *types.ChatManage) {
+	knowledgeBaseIDs := chatManage.SearchTargets.GetAllKnowledgeBaseIDs()
+	if len(knowledgeBaseIDs) != 1 {
+		return
+	}
+	assignment, variantConfig, err := s.experiments.AssignVariant(ctx, knowledgeBaseIDs[0], sessionID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to assign experiment variant of knowledge base %s: %v", knowledgeBaseIDs[0], err)
+		return
+	}
+	if variantConfig == nil {
+		return
+	}
+	variantConfig.Apply(chatManage)
+	chatManage.Experiment = assignment
+	if assignment != nil {
+		logger.Infof(ctx, "Session %s routed to variant %s of experiment %s",
+			sessionID, assignment.Variant, assignment.ExperimentID)
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestExperimentLeader(t *testing.T) {
+	stats := func(searches, clicked, positive, negative int64, latency float64) *types.ExperimentVariantStats {
+		s := &types.ExperimentVariantStats{
+			Searches:         searches,
+			ClickedSearches:  clicked,
+			PositiveFeedback: positive,
+			NegativeFeedback: negative,
+			AvgLatencyMs:     latency,
+		}
+		fillExperimentRates(s)
+		return s
+	}
+	cases := []struct {
+		name   string
+		cfg    types.ExperimentAutoPromote
+		a, b   *types.ExperimentVariantStats
+		leader types.ExperimentVariant
+		lift   float64
+	}{
+		{
+			name:   "feedback",
+			cfg:    types.ExperimentAutoPromote{MinSamples: 10},
+			a:      stats(100, 0, 6, 4, 0),
+			b:      stats(100, 0, 9, 1, 0),
+			leader: types.ExperimentVariantB,
+			lift:   0.5,
+		},
+		{
+			name: "too few ratings",
+			cfg:  types.ExperimentAutoPromote{MinSamples: 10},
+			a:    stats(100, 0, 6, 4, 0),
+			b:    stats(100, 0, 5, 0, 0),
+		},
+		{
+			name:   "clicks",
+			cfg:    types.ExperimentAutoPromote{Metric: types.ExperimentMetricClicks, MinSamples: 50},
+			a:      stats(100, 40, 0, 0, 0),
+			b:      stats(50, 10, 0, 0, 0),
+			leader: types.ExperimentVariantA,
+			lift:   1,
+		},
+		{
+			name:   "latency",
+			cfg:    types.ExperimentAutoPromote{Metric: types.ExperimentMetricLatency, MinSamples: 1},
+			a:      stats(10, 0, 0, 0, 2000),
+			b:      stats(10, 0, 0, 0, 1500),
+			leader: types.ExperimentVariantB,
+			lift:   0.25,
+		},
+		{
+			name: "even",
+			cfg:  types.ExperimentAutoPromote{Metric: types.ExperimentMetricClicks, MinSamples: 1},
+			a:    stats(10, 5, 0, 0, 0),
+			b:    stats(20, 10, 0, 0, 0),
+		},
+		{
+			name:   "other variant without clicks",
+			cfg:    types.ExperimentAutoPromote{Metric: types.ExperimentMetricClicks, MinSamples: 1},
+			a:      stats(10, 0, 0, 0, 0),
+			b:      stats(10, 2, 0, 0, 0),
+			leader: types.ExperimentVariantB,
+			lift:   1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			leader, lift := experimentLeader(&c.cfg, c.a, c.b)
+			if leader != c.leader || math.Abs(lift-c.lift) > 1e-9 {
+				t.Errorf("experimentLeader = (%q, %v), want (%q, %v)", leader, lift, c.leader, c.lift)
+			}
+		})
+	}
+}
+
+func TestExperimentAssignVariant(t *testing.T) {
+	experiment := &types.KBExperiment{ID: "exp", Status: types.ExperimentStatusRunning, TrafficPercent: 30}
+	routedToB := 0
+	for i := 0; i < 1000; i++ {
+		sessionID := string(rune('a'+i%26)) + string(rune('a'+i/26%26)) + string(rune('a'+i/676))
+		variant := experiment.AssignVariant(sessionID)
+		if variant != experiment.AssignVariant(sessionID) {
+			t.Fatalf("session %s is not routed consistently", sessionID)
+		}
+		if variant == types.ExperimentVariantB {
+			routedToB++
+		}
+	}
+	if routedToB < 200 || routedToB > 400 {
+		t.Errorf("%d of 1000 sessions routed to B, want about 300", routedToB)
+	}
+
+	experiment.Status = types.ExperimentStatusPromoted
+	experiment.Winner = types.ExperimentVariantB
+	if got := experiment.AssignVariant("any"); got != types.ExperimentVariantB {
+		t.Errorf("promoted experiment routed to %q, want b", got)
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// externalIdentity is a user as asserted by an external identity provider such as OIDC or LDAP
+type externalIdentity struct {
+	AuthProvider string
+	ExternalID   string
+	Email        string
+	// Username is the preferred username, the local part of the email is used when empty
+	Username string
+	Avatar   string
+	// TenantID is the tenant of a new user, 0 creates a personal workspace as registration does
+	TenantID uint64
+	// LinkExisting lets the identity take over the local account with the same email
+	LinkExisting bool
+	// AutoProvision creates the user when no account matches
+	AutoProvision bool
+}
+
+// externalUserProvisioner finds, links or creates the local users of external identities
+type externalUserProvisioner struct {
+	userRepo      interfaces.UserRepository
+	tenantService interfaces.TenantService
+}
+
+// findOrCreate returns the user of an identity, linking or creating the account as allowed.
+// The user is returned whether active or not, callers decide how to treat disabled accounts.
+func (p *externalUserProvisioner) findOrCreate(ctx context.Context, identity *externalIdentity) (*types.User, error) {
+	user, err := p.userRepo.GetUserByExternalID(ctx, identity.AuthProvider, identity.ExternalID)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
+	if user != nil {
+		return user, nil
+	}
+
+	existing, err := p.userRepo.GetUserByEmail(ctx, identity.Email)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		if !identity.LinkExisting || (existing.AuthProvider != "" && existing.AuthProvider != identity.AuthProvider) {
+			return nil, werrors.NewConflictError("An account with this email already exists")
+		}
+		existing.AuthProvider = identity.AuthProvider
+		existing.ExternalID = identity.ExternalID
+		existing.UpdatedAt = time.Now()
+		if err := p.userRepo.UpdateUser(ctx, existing); err != nil {
+			return nil, err
+		}
+		logger.Infof(ctx, "Linked user %s to identity provider %s", existing.ID, identity.AuthProvider)
+		return existing, nil
+	}
+
+	if !identity.AutoProvision {
+		return nil, werrors.NewForbiddenError("No account exists for this user, ask an administrator to create one")
+	}
+	return p.create(ctx, identity)
+}
+
+// create provisions the user of an identity into its tenant
+func (p *externalUserProvisioner) create(ctx context.Context, identity *externalIdentity) (*types.User, error) {
+	username, err := p.uniqueUsername(ctx, identity.Username, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := identity.TenantID
+	if tenantID == 0 {
+		tenant, err := p.tenantService.CreateTenant(ctx, &types.Tenant{
+			Name:        fmt.Sprintf("%s's Workspace", secutils.SanitizeForLog(username)),
+			Description: "Default workspace",
+			Status:      "active",
+		})
+		if err != nil {
+			logger.Errorf(ctx, "Failed to create workspace for user of %s: %v", identity.AuthProvider, err)
+			return nil, errors.New("failed to create workspace")
+		}
+		tenantID = tenant.ID
+	} else if _, err := p.tenantService.GetTenantByID(ctx, tenantID); err != nil {
+		logger.Errorf(ctx, "Tenant %d mapped by %s not found: %v", tenantID, identity.AuthProvider, err)
+		return nil, fmt.Errorf("tenant %d mapped by %s not found", tenantID, identity.AuthProvider)
+	}
+
+	// Users of an identity provider get a password nobody knows, so password login stays closed to them
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(randomURLString(32)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user := &types.User{
+		ID:           uuid.New().String(),
+		Username:     username,
+		Email:        identity.Email,
+		PasswordHash: string(passwordHash),
+		Avatar:       identity.Avatar,
+		TenantID:     tenantID,
+		IsActive:     true,
+		AuthProvider: identity.AuthProvider,
+		ExternalID:   identity.ExternalID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := p.userRepo.CreateUser(ctx, user); err != nil {
+		logger.Errorf(ctx, "Failed to create user of %s: %v", identity.AuthProvider, err)
+		return nil, errors.New("failed to create user")
+	}
+	logger.Infof(ctx, "Provisioned user %s from %s into tenant %d", user.ID, identity.AuthProvider, tenantID)
+	return user, nil
+}
+
+// uniqueUsername picks a free username from the preferred one, or the local part of the email
+func (p *externalUserProvisioner) uniqueUsername(ctx context.Context, preferred, email string) (string, error) {
+	base := strings.TrimSpace(preferred)
+	if base == "" {
+		base, _, _ = strings.Cut(email, "@")
+	}
+	if len(base) > 80 {
+		base = base[:80]
+	}
+	username := base
+	for i := 0; i < 5; i++ {
+		existing, err := p.userRepo.GetUserByUsername(ctx, username)
+		if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+			return "", err
+		}
+		if existing == nil {
+			return username, nil
+		}
+		suffix := make([]byte, 3)
+		_, _ = rand.Read(suffix)
+		username = base + "-" + hex.EncodeToString(suffix)
+	}
+	return "", errors.New("failed to pick a unique username")
+}
@@ -143,6 +143,20 @@ func (s *cosFileService) SaveBytes(ctx context.Context, data []byte, tenantID ui
 	return fmt.Sprintf("%s%s", s.bucketURL, objectName), nil
 }
 
+// SaveReader uploads the content of r to the main COS bucket and returns the file path
+func (s *cosFileService) SaveReader(ctx context.Context,
+	r io.Reader, size int64, tenantID uint64, fileName string,
+) (string, error) {
+	objectName := fmt.Sprintf("%s/%d/exports/%s%s", s.cosPathPrefix, tenantID, uuid.New().String(), filepath.Ext(fileName))
+	_, err := s.client.Object.Put(ctx, objectName, r, &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{ContentLength: size},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload stream to COS: %w", err)
+	}
+	return fmt.Sprintf("%s%s", s.bucketURL, objectName), nil
+}
+
 // GetFileURL returns a presigned download URL for the file
 func (s *cosFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
 	// 判断文件属于哪个桶
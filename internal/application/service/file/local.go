@@ -110,6 +110,35 @@ func (s *localFileService) DeleteFile(ctx context.Context, filePath string) erro
 	return nil
 }
 
+// SaveReader copies the content of r to a file next to the files of SaveBytes and returns the file path
+func (s *localFileService) SaveReader(ctx context.Context,
+	r io.Reader, size int64, tenantID uint64, fileName string,
+) (string, error) {
+	logger.Infof(ctx, "Saving stream: fileName=%s, size=%d, tenantID=%d", fileName, size, tenantID)
+
+	dir := filepath.Join(s.baseDir, fmt.Sprintf("%d", tenantID), "exports")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	ext := filepath.Ext(fileName)
+	filePath := filepath.Join(dir, fmt.Sprintf("%s_%d%s", fileName[:len(fileName)-len(ext)], time.Now().UnixNano(), ext))
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		dst.Close()
+		_ = os.Remove(filePath)
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(filePath)
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	return filePath, nil
+}
+
 // SaveBytes saves bytes data to a file and returns the file path
 // temp parameter is ignored for local storage (no auto-expiration support)
 func (s *localFileService) SaveBytes(ctx context.Context, data []byte, tenantID uint64, fileName string, temp bool) (string, error) {
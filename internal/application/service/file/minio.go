@@ -146,6 +146,20 @@ func (s *minioFileService) SaveBytes(ctx context.Context, data []byte, tenantID
 	return fmt.Sprintf("minio://%s/%s", s.bucketName, objectName), nil
 }
 
+// SaveReader uploads the content of r to MinIO and returns the file path
+func (s *minioFileService) SaveReader(ctx context.Context,
+	r io.Reader, size int64, tenantID uint64, fileName string,
+) (string, error) {
+	objectName := fmt.Sprintf("%d/exports/%s%s", tenantID, uuid.New().String(), filepath.Ext(fileName))
+	_, err := s.client.PutObject(ctx, s.bucketName, objectName, r, size, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload stream to MinIO: %w", err)
+	}
+	return fmt.Sprintf("minio://%s/%s", s.bucketName, objectName), nil
+}
+
 // GetFileURL returns a presigned download URL for the file
 func (s *minioFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
 	// Parse MinIO path
@@ -101,7 +101,7 @@ func (b *graphBuilder) extractEntities(ctx context.Context, chunk *types.Chunk)
 	messages := []chat.Message{
 		{
 			Role:    "system",
-			Content: b.config.Conversation.ExtractEntitiesPrompt,
+			Content: b.config.Current().Conversation.ExtractEntitiesPrompt,
 		},
 		{
 			Role:    "user",
@@ -212,7 +212,7 @@ func (b *graphBuilder) extractRelationships(ctx context.Context,
 	messages := []chat.Message{
 		{
 			Role:    "system",
-			Content: b.config.Conversation.ExtractRelationshipsPrompt,
+			Content: b.config.Current().Conversation.ExtractRelationshipsPrompt,
 		},
 		{
 			Role:    "user",
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tencent/WeKnora/docreader/client"
+	"github.com/Tencent/WeKnora/internal/models/health"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// healthProbeTimeout bounds each dependency probe, a dependency slower than this is reported down
+	healthProbeTimeout = 3 * time.Second
+	// healthCacheTTL is how long a report is reused, so frequent probes do not load the dependencies
+	healthCacheTTL = 5 * time.Second
+)
+
+// healthProbe checks one dependency
+type healthProbe struct {
+	name     string
+	required bool
+	check    func(ctx context.Context) error
+}
+
+// healthService implements HealthService interface
+type healthService struct {
+	db             *gorm.DB
+	redisClient    *redis.Client
+	engineRegistry interfaces.RetrieveEngineRegistry
+	docReader      *client.Client
+	neo4jDriver    neo4j.Driver
+
+	shuttingDown atomic.Bool
+	mu           sync.Mutex
+	last         *types.HealthReport
+}
+
+// NewHealthService creates a new health service
+func NewHealthService(
+	db *gorm.DB,
+	redisClient *redis.Client,
+	engineRegistry interfaces.RetrieveEngineRegistry,
+	docReader *client.Client,
+	neo4jDriver neo4j.Driver,
+) interfaces.HealthService {
+	return &healthService{
+		db:             db,
+		redisClient:    redisClient,
+		engineRegistry: engineRegistry,
+		docReader:      docReader,
+		neo4jDriver:    neo4jDriver,
+	}
+}
+
+// SetShuttingDown marks the service as not ready, so orchestrators stop routing to it while it drains
+func (s *healthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+// Check probes the dependencies concurrently, results are reused for a few seconds
+func (s *healthService) Check(ctx context.Context) *types.HealthReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shuttingDown := s.shuttingDown.Load()
+	if s.last != nil && time.Since(s.last.CheckedAt) < healthCacheTTL {
+		return types.NewHealthReport(s.last.Dependencies, shuttingDown, s.last.CheckedAt)
+	}
+
+	probes := s.probes()
+	deps := make([]*types.DependencyHealth, len(probes))
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deps[i] = runHealthProbe(ctx, probe)
+		}()
+	}
+	wg.Wait()
+	deps = append(deps, modelEndpointsHealth())
+
+	s.last = types.NewHealthReport(deps, shuttingDown, time.Now())
+	return s.last
+}
+
+// probes lists the dependencies to probe. Postgres, Redis and the vector stores are required,
+// without them nothing can be served; documents can still be searched while DocReader is down.
+func (s *healthService) probes() []healthProbe {
+	probes := []healthProbe{
+		{name: "postgres", required: true, check: func(ctx context.Context) error {
+			sqlDB, err := s.db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}},
+		{name: "redis", required: true, check: func(ctx context.Context) error {
+			return s.redisClient.Ping(ctx).Err()
+		}},
+	}
+	if s.engineRegistry != nil {
+		for _, engine := range s.engineRegistry.GetAllRetrieveEngineServices() {
+			checker, ok := engine.(interfaces.HealthChecker)
+			if !ok {
+				continue
+			}
+			probes = append(probes, healthProbe{
+				name:     fmt.Sprintf("vector_store:%s", engine.EngineType()),
+				required: true,
+				check:    checker.Ping,
+			})
+		}
+	}
+	if s.docReader != nil {
+		probes = append(probes, healthProbe{name: "docreader", check: s.docReader.Ping})
+	}
+	if s.neo4jDriver != nil {
+		probes = append(probes, healthProbe{name: "neo4j", check: s.neo4jDriver.VerifyConnectivity})
+	}
+	return probes
+}
+
+// runHealthProbe runs a probe with a timeout and measures its latency
+func runHealthProbe(ctx context.Context, probe healthProbe) *types.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe.check(ctx)
+	dep := &types.DependencyHealth{
+		Name:     probe.name,
+		Status:   types.HealthStatusUp,
+		Required: probe.required,
+		Latency:  time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		dep.Status = types.HealthStatusDown
+		dep.Error = err.Error()
+	}
+	return dep
+}
+
+// modelEndpointsHealth reports the model providers from their circuit breakers, which the periodic
+// model health probe and real calls keep current. Calling every model here would cost tokens.
+func modelEndpointsHealth() *types.DependencyHealth {
+	total, open := health.DefaultRegistry().Summary()
+	dep := &types.DependencyHealth{
+		Name:    "model_providers",
+		Status:  types.HealthStatusUp,
+		Details: map[string]any{"endpoints": total, "open_circuits": open},
+	}
+	if open > 0 {
+		dep.Status = types.HealthStatusDegraded
+		dep.Error = fmt.Sprintf("%d of %d model endpoints are failing", open, total)
+	}
+	return dep
+}
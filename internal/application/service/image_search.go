@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// imageIndexSuffix derives the knowledge base and knowledge IDs visual vectors are stored under.
+// Keeping them apart from the text vectors means text retrieval, re-indexing and text vector
+// cleanup never see them, even when both models have the same dimensions.
+const imageIndexSuffix = ":image"
+
+// defaultImageSearchTopK is the number of knowledge entries returned when top_k is not set
+const defaultImageSearchTopK = 10
+
+// imageIndexID returns the ID used for a knowledge base or knowledge in the image index
+func imageIndexID(id string) string {
+	return id + imageIndexSuffix
+}
+
+// imageDataURI encodes image bytes as a data URI accepted by multimodal embedding APIs
+func imageDataURI(content []byte) string {
+	return fmt.Sprintf("data:%s;base64,%s",
+		http.DetectContentType(content), base64.StdEncoding.EncodeToString(content))
+}
+
+// getImageEmbedder returns the embedding model of a knowledge base's image embedding config,
+// failing when the model cannot embed images
+func getImageEmbedder(ctx context.Context, modelService interfaces.ModelService,
+	modelID string, tenantID uint64,
+) (embedding.Embedder, embedding.ImageEmbedder, error) {
+	embedder, err := modelService.GetEmbeddingModelForTenant(ctx, modelID, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	imageEmbedder, ok := embedder.(embedding.ImageEmbedder)
+	if !ok {
+		return nil, nil, fmt.Errorf("embedding model %s does not support image input", embedder.GetModelName())
+	}
+	return embedder, imageEmbedder, nil
+}
+
+// deleteImageVectors removes the visual vectors of the given knowledge
+func deleteImageVectors(ctx context.Context, retrieveEngine *retriever.CompositeRetrieveEngine,
+	modelService interfaces.ModelService, modelID string, tenantID uint64, knowledgeIDs []string,
+) error {
+	if len(knowledgeIDs) == 0 {
+		return nil
+	}
+	embedder, err := modelService.GetEmbeddingModelForTenant(ctx, modelID, tenantID)
+	if err != nil {
+		return err
+	}
+	imageIDs := make([]string, 0, len(knowledgeIDs))
+	for _, id := range knowledgeIDs {
+		imageIDs = append(imageIDs, imageIndexID(id))
+	}
+	return retrieveEngine.DeleteByKnowledgeIDList(ctx, imageIDs, embedder.GetDimensions(), "")
+}
+
+// indexImageEmbedding embeds the original file of an image knowledge and stores the visual vector
+func (s *knowledgeService) indexImageEmbedding(ctx context.Context,
+	kb *types.KnowledgeBase, knowledge *types.Knowledge, content []byte,
+) error {
+	embedder, imageEmbedder, err := getImageEmbedder(ctx, s.modelService, kb.ImageEmbeddingConfig.ModelID, kb.TenantID)
+	if err != nil {
+		return err
+	}
+	vectors, err := imageEmbedder.EmbedImages(ctx, []string{imageDataURI(content)})
+	if err != nil {
+		return err
+	}
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return errors.New("no image embedding returned")
+	}
+
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return err
+	}
+	sourceID := imageIndexID(knowledge.ID)
+	// Re-parsing embeds the image again, drop the previous vector first
+	if err := retrieveEngine.DeleteBySourceIDList(ctx, []string{sourceID}, embedder.GetDimensions(), ""); err != nil {
+		return err
+	}
+	indexInfo := &types.IndexInfo{
+		Content:         knowledge.Title,
+		SourceID:        sourceID,
+		SourceType:      types.ImageSourceType,
+		ChunkID:         sourceID,
+		KnowledgeID:     sourceID,
+		KnowledgeBaseID: imageIndexID(kb.ID),
+	}
+	if err := retrieveEngine.Index(ctx, &precomputedEmbedder{
+		Embedder: embedder,
+		vectors:  map[string][]float32{indexInfo.Content: vectors[0]},
+	}, indexInfo); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Indexed image embedding of knowledge %s with model %s, dimensions: %d",
+		knowledge.ID, embedder.GetModelName(), len(vectors[0]))
+	return nil
+}
+
+// deleteImageEmbeddings removes the visual vectors of image knowledge in a knowledge base
+// with image embedding configured; failures are only logged like other best-effort cleanup
+func (s *knowledgeService) deleteImageEmbeddings(ctx context.Context,
+	retrieveEngine *retriever.CompositeRetrieveEngine, knowledgeList []*types.Knowledge,
+) {
+	byKB := make(map[string][]string)
+	for _, knowledge := range knowledgeList {
+		if IsImageType(knowledge.FileType) {
+			byKB[knowledge.KnowledgeBaseID] = append(byKB[knowledge.KnowledgeBaseID], knowledge.ID)
+		}
+	}
+	for kbID, knowledgeIDs := range byKB {
+		kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil || kb.ImageEmbeddingConfig == nil || kb.ImageEmbeddingConfig.ModelID == "" {
+			continue
+		}
+		if err := deleteImageVectors(ctx, retrieveEngine, s.modelService,
+			kb.ImageEmbeddingConfig.ModelID, kb.TenantID, knowledgeIDs); err != nil {
+			logger.Warnf(ctx, "Failed to delete image embeddings of knowledge base %s: %v", kbID, err)
+		}
+	}
+}
+
+// imageSearchable reports whether a knowledge may be returned by image search: it is neither trashed nor archived,
+// and has not expired under the retention policy of its knowledge base
+func imageSearchable(knowledge *types.Knowledge, policy *types.RetentionConfig, now time.Time) bool {
+	if knowledge.TrashedAt != nil || knowledge.ArchivedAt != nil {
+		return false
+	}
+	expiresAt := knowledgeExpiryTime(knowledge, policy)
+	return expiresAt.IsZero() || expiresAt.After(now)
+}
+
+// ImageSearch finds knowledge whose image is visually similar to the query image
+func (s *knowledgeBaseService) ImageSearch(ctx context.Context,
+	id string, image []byte, params types.ImageSearchParams,
+) ([]*types.ImageSearchResult, error) {
+	kb, err := s.repo.GetKnowledgeBaseByID(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": id,
+		})
+		return nil, err
+	}
+	if !kb.ImageEmbeddingConfig.IsEnabled() {
+		return nil, errors.New("image embedding is not enabled for this knowledge base")
+	}
+	if params.TopK <= 0 {
+		params.TopK = defaultImageSearchTopK
+	}
+
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		logger.Errorf(ctx, "Failed to create retrieval engine: %v", err)
+		return nil, err
+	}
+	if !retrieveEngine.SupportRetriever(types.VectorRetrieverType) {
+		return nil, errors.New("vector retrieval is not available")
+	}
+
+	// Shared knowledge bases are searched with the owner's model, like HybridSearch
+	_, imageEmbedder, err := getImageEmbedder(ctx, s.modelService, kb.ImageEmbeddingConfig.ModelID, kb.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get image embedding model %s: %v", kb.ImageEmbeddingConfig.ModelID, err)
+		return nil, err
+	}
+	vectors, err := imageEmbedder.EmbedImages(ctx, []string{imageDataURI(image)})
+	if err != nil {
+		logger.Errorf(ctx, "Failed to embed query image: %v", err)
+		return nil, err
+	}
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return nil, errors.New("no image embedding returned")
+	}
+
+	retrieveResults, err := retrieveEngine.Retrieve(ctx, []types.RetrieveParams{{
+		Embedding:        vectors[0],
+		KnowledgeBaseIDs: []string{imageIndexID(id)},
+		TopK:             params.TopK,
+		Threshold:        params.Threshold,
+		RetrieverType:    types.VectorRetrieverType,
+	}})
+	if err != nil {
+		logger.Errorf(ctx, "Failed to retrieve similar images: %v", err)
+		return nil, err
+	}
+
+	// Documents hidden from the user by a document permission are left out, like in HybridSearch
+	var hiddenKnowledgeIDs []string
+	if userID, _ := ctx.Value(types.UserIDContextKey).(string); userID != "" && s.kbPermissionRepo != nil {
+		hiddenKnowledgeIDs, err = s.kbPermissionRepo.ListHiddenKnowledgeIDs(ctx, id, userID)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to load hidden knowledge of knowledge base %s: %v", id, err)
+			return nil, err
+		}
+	}
+
+	// Each image knowledge has one vector, but several engines may return it
+	scores := make(map[string]float64)
+	for _, result := range retrieveResults {
+		for _, index := range result.Results {
+			knowledgeID := strings.TrimSuffix(index.KnowledgeID, imageIndexSuffix)
+			if slices.Contains(hiddenKnowledgeIDs, knowledgeID) {
+				continue
+			}
+			if score, ok := scores[knowledgeID]; !ok || index.Score > score {
+				scores[knowledgeID] = index.Score
+			}
+		}
+	}
+	if len(scores) == 0 {
+		return []*types.ImageSearchResult{}, nil
+	}
+
+	knowledgeIDs := make([]string, 0, len(scores))
+	for knowledgeID := range scores {
+		knowledgeIDs = append(knowledgeIDs, knowledgeID)
+	}
+	knowledgeList, err := s.kgRepo.GetKnowledgeBatch(ctx, kb.TenantID, knowledgeIDs)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to load knowledge of similar images: %v", err)
+		return nil, err
+	}
+
+	// Trashed, archived and expired knowledge is left out, as text retrieval leaves out its disabled chunks
+	var policy *types.RetentionConfig
+	if kb.RetentionConfig != nil && kb.RetentionConfig.Enabled {
+		policy = kb.RetentionConfig
+	}
+	now := time.Now()
+	results := make([]*types.ImageSearchResult, 0, len(knowledgeList))
+	for _, knowledge := range knowledgeList {
+		if !imageSearchable(knowledge, policy, now) {
+			continue
+		}
+		results = append(results, &types.ImageSearchResult{Knowledge: knowledge, Score: scores[knowledge.ID]})
+	}
+	slices.SortFunc(results, func(a, b *types.ImageSearchResult) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		default:
+			return 0
+		}
+	})
+	if len(results) > params.TopK {
+		results = results[:params.TopK]
+	}
+	logger.Infof(ctx, "Image search completed, knowledge base ID: %s, result count: %d", id, len(results))
+	return results, nil
+}
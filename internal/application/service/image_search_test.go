@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestImageSearchable(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	policy := &types.RetentionConfig{Enabled: true, MaxAgeDays: 30}
+
+	cases := []struct {
+		name      string
+		knowledge *types.Knowledge
+		policy    *types.RetentionConfig
+		want      bool
+	}{
+		{"active", &types.Knowledge{CreatedAt: now}, nil, true},
+		{"trashed", &types.Knowledge{CreatedAt: now, TrashedAt: &past}, nil, false},
+		{"archived", &types.Knowledge{CreatedAt: now, ArchivedAt: &past}, nil, false},
+		{"expired", &types.Knowledge{CreatedAt: now, ExpiresAt: &past}, nil, false},
+		{"expires later", &types.Knowledge{CreatedAt: now, ExpiresAt: &future}, nil, true},
+		{"past max age", &types.Knowledge{CreatedAt: now.AddDate(0, 0, -31)}, policy, false},
+		{"within max age", &types.Knowledge{CreatedAt: now.AddDate(0, 0, -1)}, policy, true},
+	}
+	for _, tc := range cases {
+		if got := imageSearchable(tc.knowledge, tc.policy, now); got != tc.want {
+			t.Errorf("%s: imageSearchable = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+)
+
+// defaultJobHistoryRetentionDays is how long finished jobs are kept when JOB_HISTORY_RETENTION_DAYS is not set
+const defaultJobHistoryRetentionDays = 30
+
+// jobService implements JobService interface
+type jobService struct {
+	repo          interfaces.JobRepository
+	knowledgeRepo interfaces.KnowledgeRepository
+	inspector     *asynq.Inspector
+}
+
+// NewJobService creates a new job service
+func NewJobService(
+	repo interfaces.JobRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	inspector *asynq.Inspector,
+) interfaces.JobService {
+	return &jobService{repo: repo, knowledgeRepo: knowledgeRepo, inspector: inspector}
+}
+
+// ListJobs lists the jobs of the tenant in the context, newest first
+func (s *jobService) ListJobs(ctx context.Context,
+	filter *types.JobFilter, page *types.Pagination,
+) (*types.PageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	jobs, total, err := s.repo.ListJobs(ctx, tenantID, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, jobs), nil
+}
+
+// GetJob gets a job of the tenant in the context
+func (s *jobService) GetJob(ctx context.Context, id string) (*types.Job, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	job, err := s.repo.GetJob(ctx, tenantID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrJobNotFound) {
+			return nil, werrors.NewNotFoundError("Job not found")
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// CancelJob cancels a job of the tenant in the context that has not finished.
+// Queued and retrying jobs are taken off the queue, running jobs have their context cancelled
+// and are not retried.
+func (s *jobService) CancelJob(ctx context.Context, id string) (*types.Job, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.IsFinished() {
+		return nil, werrors.NewConflictError(fmt.Sprintf("Job has already %s", job.Status))
+	}
+	wasRunning := job.Status == types.JobStatusRunning
+	now := time.Now()
+	job.Status = types.JobStatusCancelled
+	job.FinishedAt = &now
+	if err := s.repo.SaveJobState(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// The job is marked first so a worker picking it up meanwhile skips it, errors of the queue only
+	// mean the task already left the state it was in
+	if s.inspector != nil {
+		if wasRunning {
+			err = s.inspector.CancelProcessing(job.ID)
+		} else {
+			err = s.inspector.DeleteTask(job.Queue, job.ID)
+		}
+		if err != nil {
+			logger.Warnf(ctx, "Failed to remove cancelled job %s from queue %s: %v", job.ID, job.Queue, err)
+		}
+	}
+	// Running jobs release their document once the task has returned, see Track
+	if !wasRunning {
+		s.releaseDocument(ctx, job)
+	}
+	logger.Infof(ctx, "Cancelled job %s of type %s", job.ID, job.Type)
+	return job, nil
+}
+
+// releaseDocument marks the document of a cancelled parse job as failed, so it can be parsed again
+func (s *jobService) releaseDocument(ctx context.Context, job *types.Job) {
+	if job.Type != types.TypeDocumentProcess || job.KnowledgeID == "" {
+		return
+	}
+	knowledge, err := s.knowledgeRepo.GetKnowledgeByID(ctx, job.TenantID, job.KnowledgeID)
+	if err != nil || knowledge == nil {
+		return
+	}
+	if knowledge.ParseStatus != types.ParseStatusPending && knowledge.ParseStatus != types.ParseStatusProcessing {
+		return
+	}
+	knowledge.ParseStatus = types.ParseStatusFailed
+	knowledge.ErrorMessage = "Parsing was cancelled"
+	knowledge.UpdatedAt = time.Now()
+	if err := s.knowledgeRepo.UpdateKnowledge(ctx, knowledge); err != nil {
+		logger.Warnf(ctx, "Failed to update knowledge %s of cancelled job %s: %v", knowledge.ID, job.ID, err)
+	}
+}
+
+// Track is task middleware recording when jobs start, retry and finish, and skipping cancelled jobs.
+// Tasks of no tenant pass through untracked.
+func (s *jobService) Track(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		id, ok := asynq.GetTaskID(ctx)
+		if !ok {
+			return next.ProcessTask(ctx, t)
+		}
+		job, err := s.repo.GetJobByID(ctx, id)
+		if err != nil && !errors.Is(err, repository.ErrJobNotFound) {
+			logger.Warnf(ctx, "Failed to get job %s, running it untracked: %v", id, err)
+			return next.ProcessTask(ctx, t)
+		}
+		if job == nil {
+			queue, _ := asynq.GetQueueName(ctx)
+			maxRetry, _ := asynq.GetMaxRetry(ctx)
+			if job = types.NewJob(id, t.Type(), queue, maxRetry, t.Payload()); job == nil {
+				return next.ProcessTask(ctx, t)
+			}
+		}
+		if job.Status == types.JobStatusCancelled {
+			logger.Infof(ctx, "Skipping cancelled job %s of type %s", id, t.Type())
+			return nil
+		}
+
+		retried, _ := asynq.GetRetryCount(ctx)
+		if maxRetry, ok := asynq.GetMaxRetry(ctx); ok {
+			job.MaxRetry = maxRetry
+		}
+		startedAt := time.Now()
+		job.Status = types.JobStatusRunning
+		job.Attempts = retried + 1
+		job.StartedAt = &startedAt
+		job.FinishedAt = nil
+		if err := s.repo.SaveJobState(ctx, job); err != nil {
+			logger.Warnf(ctx, "Failed to record start of job %s: %v", id, err)
+		}
+
+		taskErr := next.ProcessTask(ctx, t)
+
+		// The task context is cancelled along with the job, the outcome is saved regardless
+		saveCtx := context.WithoutCancel(ctx)
+		if latest, err := s.repo.GetJobByID(saveCtx, id); err == nil && latest.Status == types.JobStatusCancelled {
+			s.releaseDocument(saveCtx, latest)
+			if taskErr != nil {
+				return fmt.Errorf("job cancelled: %v: %w", taskErr, asynq.SkipRetry)
+			}
+			return nil
+		}
+		finishedAt := time.Now()
+		switch {
+		case taskErr != nil && errors.Is(context.Cause(ctx), types.ErrTaskInterrupted):
+			// Shutdown requeued the task, it runs again after restart
+			job.Status = types.JobStatusQueued
+			job.LastError = taskErr.Error()
+		case taskErr == nil:
+			job.Status = types.JobStatusSucceeded
+			job.LastError = ""
+			job.FinishedAt = &finishedAt
+		case retried >= job.MaxRetry || errors.Is(taskErr, asynq.SkipRetry):
+			job.Status = types.JobStatusFailed
+			job.LastError = taskErr.Error()
+			job.FinishedAt = &finishedAt
+		default:
+			job.Status = types.JobStatusRetrying
+			job.LastError = taskErr.Error()
+		}
+		if err := s.repo.SaveJobState(saveCtx, job); err != nil {
+			logger.Warnf(ctx, "Failed to record outcome of job %s: %v", id, err)
+		}
+		return taskErr
+	})
+}
+
+// ProcessJobHistoryPurge handles the periodic purge of jobs finished longer than the retention window
+func (s *jobService) ProcessJobHistoryPurge(ctx context.Context, t *asynq.Task) error {
+	cutoff := time.Now().AddDate(0, 0, -jobHistoryRetentionDays())
+	deleted, err := s.repo.DeleteFinishedJobs(ctx, cutoff)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to purge job history: %v", err)
+		return err
+	}
+	if deleted > 0 {
+		logger.Infof(ctx, "Purged %d jobs finished before %s", deleted, cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// jobHistoryRetentionDays returns how many days finished jobs are kept
+func jobHistoryRetentionDays() int {
+	if v := os.Getenv("JOB_HISTORY_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			return days
+		}
+	}
+	return defaultJobHistoryRetentionDays
+}
+
+// jobEnqueuer records the tasks of tenants as jobs when they are enqueued
+type jobEnqueuer struct {
+	client *asynq.Client
+	repo   interfaces.JobRepository
+}
+
+// NewJobEnqueuer creates the task enqueuer services use, recording the tasks of tenants as jobs
+func NewJobEnqueuer(client *asynq.Client, repo interfaces.JobRepository) interfaces.TaskEnqueuer {
+	return &jobEnqueuer{client: client, repo: repo}
+}
+
+// Enqueue enqueues a task and records it as a queued job. Failing to record the job does not fail
+// the enqueue, the worker records it when it starts.
+func (e *jobEnqueuer) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	info, err := e.client.Enqueue(task, opts...)
+	if err != nil {
+		return nil, err
+	}
+	job := types.NewJob(info.ID, info.Type, info.Queue, info.MaxRetry, info.Payload)
+	if job == nil {
+		return info, nil
+	}
+	ctx := context.Background()
+	if err := e.repo.CreateJob(ctx, job); err != nil {
+		logger.Warnf(ctx, "Failed to record job %s of type %s: %v", info.ID, info.Type, err)
+	}
+	return info, nil
+}
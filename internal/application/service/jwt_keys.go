@@ -0,0 +1,107 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtKey is a secret signing tokens, named in the kid header of the tokens it signs by its version
+type jwtKey struct {
+	version string
+	secret  []byte
+}
+
+// jwtKeySet signs tokens with the current secret and accepts the tokens of the previous ones, so that the secret can
+// be rotated without invalidating the login sessions, share links and file links signed before
+type jwtKeySet struct {
+	current  jwtKey
+	previous []jwtKey
+}
+
+var (
+	jwtKeysOnce sync.Once
+	jwtKeys     *jwtKeySet
+)
+
+// errUnknownJwtKey is returned for tokens signed by a secret that is neither current nor previous
+var errUnknownJwtKey = errors.New("token signed by an unknown key version")
+
+// getJwtKeys returns the signing keys: JWT_SECRET, and the comma separated JWT_PREVIOUS_SECRETS still accepted
+func getJwtKeys() *jwtKeySet {
+	jwtKeysOnce.Do(func() {
+		var previous []string
+		for _, secret := range strings.Split(os.Getenv("JWT_PREVIOUS_SECRETS"), ",") {
+			if secret = strings.TrimSpace(secret); secret != "" {
+				previous = append(previous, secret)
+			}
+		}
+		jwtKeys = newJwtKeySet(getJwtSecret(), previous)
+	})
+	return jwtKeys
+}
+
+// newJwtKeySet creates the key set of a current secret and the previous secrets still accepted
+func newJwtKeySet(current string, previous []string) *jwtKeySet {
+	keys := &jwtKeySet{current: newJwtKey(current)}
+	for _, secret := range previous {
+		if secret != current {
+			keys.previous = append(keys.previous, newJwtKey(secret))
+		}
+	}
+	return keys
+}
+
+// newJwtKey names a secret by a fingerprint, which tells the secrets apart without revealing them
+func newJwtKey(secret string) jwtKey {
+	sum := sha256.Sum256([]byte(secret))
+	return jwtKey{version: "k" + hex.EncodeToString(sum[:4]), secret: []byte(secret)}
+}
+
+// sign signs claims with the current secret, naming its version in the kid header
+func (k *jwtKeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = k.current.version
+	return token.SignedString(k.current.secret)
+}
+
+// keyFunc returns the secret verifying a token: the one of its version, or any secret for the tokens signed before
+// versions were added
+func (k *jwtKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	version, _ := token.Header["kid"].(string)
+	if version == "" {
+		set := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{k.current.secret}}
+		for _, key := range k.previous {
+			set.Keys = append(set.Keys, key.secret)
+		}
+		return set, nil
+	}
+	if version == k.current.version {
+		return k.current.secret, nil
+	}
+	for _, key := range k.previous {
+		if version == key.version {
+			return key.secret, nil
+		}
+	}
+	return nil, errUnknownJwtKey
+}
+
+// signJwt signs claims with the current secret
+func signJwt(claims jwt.Claims) (string, error) {
+	return getJwtKeys().sign(claims)
+}
+
+// jwtKeyFunc verifies tokens signed with the current or a previous secret
+func jwtKeyFunc(token *jwt.Token) (interface{}, error) {
+	return getJwtKeys().keyFunc(token)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJwtKeySetRotation(t *testing.T) {
+	claims := jwt.MapClaims{"type": "test", "exp": 4102444800}
+	old := newJwtKeySet("old-secret", nil)
+	signedOld, err := old.sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("old-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newJwtKeySet("new-secret", []string{"old-secret"})
+	signedNew, err := rotated.sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, token := range map[string]string{"new": signedNew, "previous": signedOld, "unversioned": legacy} {
+		if parsed, err := jwt.Parse(token, rotated.keyFunc); err != nil || !parsed.Valid {
+			t.Errorf("%s token rejected after rotation: %v", name, err)
+		}
+	}
+
+	retired := newJwtKeySet("new-secret", nil)
+	for name, token := range map[string]string{"previous": signedOld, "unversioned": legacy} {
+		if _, err := jwt.Parse(token, retired.keyFunc); err == nil {
+			t.Errorf("%s token accepted once its secret was retired", name)
+		}
+	}
+}
@@ -0,0 +1,892 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	kbImportProgressKeyPrefix = "kb_import_progress:"
+	kbImportProgressTTL       = 24 * time.Hour
+	// Uploaded bundles are stored under this directory until their import task has run
+	kbImportBundleDir = "kb_imports"
+	// Chunks are read, created and indexed in pages of this size
+	kbBundleChunkPageSize = 100
+	// Decompressed size caps of a bundle entry and of all entries read, against zip bombs
+	kbBundleMaxEntrySize = 512 << 20
+	kbBundleMaxTotalSize = 4 * types.KBBundleMaxSize
+)
+
+// getKBImportProgressKey returns the Redis key for storing KB import progress
+func getKBImportProgressKey(taskID string) string {
+	return kbImportProgressKeyPrefix + taskID
+}
+
+// ExportKnowledgeBase writes a document knowledge base as a zip bundle to w.
+// Only parsed knowledge outside the trash is exported. Vectors are included on request
+// when the vector store can read them back.
+func (s *knowledgeService) ExportKnowledgeBase(
+	ctx context.Context, kbID string, includeEmbeddings bool, w io.Writer,
+) error {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return werrors.NewBadRequestError("FAQ 知识库请使用 FAQ 导出功能")
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+
+	allKnowledge, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kb.ID)
+	if err != nil {
+		return err
+	}
+	knowledgeList := make([]*types.Knowledge, 0, len(allKnowledge))
+	for _, knowledge := range allKnowledge {
+		if knowledge.ParseStatus == types.ParseStatusCompleted && knowledge.TrashedAt == nil {
+			knowledgeList = append(knowledgeList, knowledge)
+		}
+	}
+	// Oldest first, so originals are imported before their duplicates
+	slices.Reverse(knowledgeList)
+	if err := s.fillKnowledgeTagIDs(ctx, tenantID, knowledgeList); err != nil {
+		return err
+	}
+	tags, err := s.tagRepo.ListAllByKB(ctx, tenantID, kb.ID)
+	if err != nil {
+		return err
+	}
+
+	manifest := &types.KBBundleManifest{
+		FormatVersion: types.KBBundleFormatVersion,
+		ExportedAt:    time.Now(),
+		KnowledgeBase: types.KBBundleKnowledgeBase{
+			Name:                     kb.Name,
+			Description:              kb.Description,
+			Type:                     kb.Type,
+			ChunkingConfig:           kb.ChunkingConfig,
+			ImageProcessingConfig:    kb.ImageProcessingConfig,
+			QuestionGenerationConfig: kb.QuestionGenerationConfig,
+			RetentionConfig:          kb.RetentionConfig,
+			OCRConfig:                kb.OCRConfig,
+			WebExtractionConfig:      kb.WebExtractionConfig,
+			URLFetchConfig:           kb.URLFetchConfig,
+		},
+		Tags:      tags,
+		Knowledge: knowledgeList,
+	}
+
+	var retrieveEngine *retriever.CompositeRetrieveEngine
+	if includeEmbeddings {
+		embedder, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+		if err != nil {
+			return err
+		}
+		tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+		retrieveEngine, err = retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+		if err != nil {
+			return err
+		}
+		manifest.Embedding = &types.KBBundleEmbedding{
+			ModelName:  embedder.GetModelName(),
+			Dimensions: embedder.GetDimensions(),
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeBundleJSON(zw, types.KBBundleManifestPath, manifest); err != nil {
+		return err
+	}
+	for _, knowledge := range knowledgeList {
+		if err := s.exportBundleKnowledge(ctx, zw, knowledge, retrieveEngine); err != nil {
+			return fmt.Errorf("failed to export knowledge %s: %w", knowledge.ID, err)
+		}
+	}
+	logger.Infof(ctx, "Exported knowledge base %s with %d knowledge, embeddings: %v",
+		kb.ID, len(knowledgeList), includeEmbeddings)
+	return zw.Close()
+}
+
+// exportBundleKnowledge writes the chunks, extracted text, original file and vectors of one knowledge.
+// A missing original file is logged and skipped, the knowledge is still importable from its chunks.
+func (s *knowledgeService) exportBundleKnowledge(ctx context.Context,
+	zw *zip.Writer, knowledge *types.Knowledge, retrieveEngine *retriever.CompositeRetrieveEngine,
+) error {
+	var chunks []*types.Chunk
+	for page := 1; ; page++ {
+		pageChunks, _, err := s.chunkRepo.ListPagedChunksByKnowledgeID(ctx,
+			knowledge.TenantID,
+			knowledge.ID,
+			&types.Pagination{
+				Page:     page,
+				PageSize: kbBundleChunkPageSize,
+			},
+			indexedChunkTypes,
+			"",
+			"",
+			"",
+			"",
+			"",
+		)
+		if err != nil {
+			return err
+		}
+		if len(pageChunks) == 0 {
+			break
+		}
+		chunks = append(chunks, pageChunks...)
+	}
+	if err := writeBundleJSON(zw, types.KBBundleChunksPath(knowledge.ID), chunks); err != nil {
+		return err
+	}
+
+	texts := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.ChunkType == types.ChunkTypeText {
+			texts = append(texts, chunk.Content)
+		}
+	}
+	markdown, err := zw.Create(types.KBBundleMarkdownPath(knowledge.ID))
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(markdown, strings.Join(texts, "\n\n")); err != nil {
+		return err
+	}
+
+	if knowledge.FilePath != "" {
+		if err := s.exportBundleFile(ctx, zw, knowledge); err != nil {
+			logger.Warnf(ctx, "Failed to export original file of knowledge %s: %v", knowledge.ID, err)
+		}
+	}
+
+	if retrieveEngine != nil {
+		embeddings, err := retrieveEngine.GetEmbeddingsByKnowledgeID(ctx, knowledge.ID)
+		if err != nil {
+			return err
+		}
+		if len(embeddings) > 0 {
+			if err := writeBundleJSON(zw, types.KBBundleEmbeddingsPath(knowledge.ID), embeddings); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportBundleFile copies the original file of a knowledge into the bundle
+func (s *knowledgeService) exportBundleFile(ctx context.Context, zw *zip.Writer, knowledge *types.Knowledge) error {
+	reader, err := s.fileSvc.GetFile(ctx, knowledge.FilePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	fileName := knowledge.FileName
+	if fileName == "" {
+		fileName = knowledge.Title
+	}
+	entry, err := zw.Create(types.KBBundleFilePath(knowledge.ID, fileName))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, reader)
+	return err
+}
+
+// writeBundleJSON writes v as a JSON entry of the bundle
+func writeBundleJSON(zw *zip.Writer, name string, v any) error {
+	entry, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(entry).Encode(v)
+}
+
+// readBundleManifest opens a bundle and validates its manifest
+func readBundleManifest(r io.ReaderAt, size int64) (*bundleReader, *types.KBBundleManifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, werrors.NewBadRequestError("导入文件不是有效的 zip 压缩包")
+	}
+	br := newBundleReader(zr, kbBundleMaxEntrySize, kbBundleMaxTotalSize)
+	var manifest types.KBBundleManifest
+	found, err := br.readJSON(types.KBBundleManifestPath, &manifest)
+	if err != nil {
+		return nil, nil, werrors.NewBadRequestError("知识库导出包清单无法解析").WithDetails(err.Error())
+	}
+	if !found {
+		return nil, nil, werrors.NewBadRequestError("导入文件缺少 manifest.json，不是知识库导出包")
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > types.KBBundleFormatVersion {
+		return nil, nil, werrors.NewBadRequestError(
+			fmt.Sprintf("不支持的知识库导出包版本: %d", manifest.FormatVersion))
+	}
+	if manifest.KnowledgeBase.Type == types.KnowledgeBaseTypeFAQ {
+		return nil, nil, werrors.NewBadRequestError("FAQ 知识库请使用 FAQ 导入功能")
+	}
+	return br, &manifest, nil
+}
+
+// errBundleTooLarge is returned when an entry decompresses beyond the caps of its archive
+var errBundleTooLarge = errors.New("archive entry exceeds the decompressed size limit")
+
+// bundleReader reads entries of an uploaded zip archive with caps on their decompressed size, per entry
+// and across all entries read, so that a small archive cannot expand into gigabytes of memory
+type bundleReader struct {
+	zr           *zip.Reader
+	maxEntrySize int64
+
+	mu sync.Mutex
+	// Decompressed bytes still allowed across all entries
+	remaining int64
+}
+
+// newBundleReader wraps a zip reader with the given decompressed size caps
+func newBundleReader(zr *zip.Reader, maxEntrySize, maxTotalSize int64) *bundleReader {
+	return &bundleReader{zr: zr, maxEntrySize: maxEntrySize, remaining: maxTotalSize}
+}
+
+// readJSON decodes a JSON entry of the archive, reporting whether the entry exists
+func (b *bundleReader) readJSON(name string, v any) (bool, error) {
+	data, found, err := b.readEntry(name)
+	if err != nil || !found {
+		return found, err
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// readEntry reads an entry of the archive, reporting whether the entry exists.
+// The declared size is checked before reading and the actual size while reading, as the header may lie.
+func (b *bundleReader) readEntry(name string) ([]byte, bool, error) {
+	file, err := b.zr.Open(name)
+	if err != nil {
+		return nil, false, nil
+	}
+	defer file.Close()
+	limit, err := b.reserve(file)
+	if err != nil {
+		return nil, true, fmt.Errorf("%s: %w", name, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	b.release(limit - int64(len(data)))
+	if err != nil {
+		return nil, true, err
+	}
+	if int64(len(data)) > limit {
+		return nil, true, fmt.Errorf("%s: %w", name, errBundleTooLarge)
+	}
+	return data, true, nil
+}
+
+// reserve takes the largest size the entry may decompress to out of the remaining total
+func (b *bundleReader) reserve(file fs.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	limit := min(b.maxEntrySize, b.remaining)
+	if info.Size() > limit {
+		return 0, errBundleTooLarge
+	}
+	b.remaining -= limit
+	return limit, nil
+}
+
+// release gives back the part of a reservation an entry did not use
+func (b *bundleReader) release(unused int64) {
+	if unused <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.remaining += unused
+	b.mu.Unlock()
+}
+
+// StartKBImport creates a knowledge base from an uploaded bundle and enqueues the import of its content.
+// The knowledge base is returned right away and fills up as the task progresses.
+func (s *knowledgeService) StartKBImport(
+	ctx context.Context, file *multipart.FileHeader, req *types.KBImportRequest,
+) (*types.KnowledgeBase, *types.KBImportProgress, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer src.Close()
+	_, manifest, err := readBundleManifest(src, file.Size)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := manifest.KnowledgeBase.OCRConfig.Validate(); err != nil {
+		return nil, nil, werrors.NewBadRequestError(err.Error())
+	}
+	if err := manifest.KnowledgeBase.WebExtractionConfig.Validate(); err != nil {
+		return nil, nil, werrors.NewBadRequestError(err.Error())
+	}
+	manifest.KnowledgeBase.URLFetchConfig.DropMasked()
+	if err := manifest.KnowledgeBase.URLFetchConfig.Validate(); err != nil {
+		return nil, nil, werrors.NewBadRequestError(err.Error())
+	}
+	if err := s.quotaService.CheckKnowledgeCountQuota(ctx, int64(len(manifest.Knowledge))); err != nil {
+		return nil, nil, err
+	}
+
+	model, err := s.modelService.GetModelByID(ctx, req.EmbeddingModelID)
+	if err != nil || model == nil {
+		return nil, nil, werrors.NewBadRequestError("向量模型不存在")
+	}
+	if model.Type != types.ModelTypeEmbedding {
+		return nil, nil, werrors.NewBadRequestError("所选模型不是向量模型")
+	}
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	bundlePath, err := s.fileSvc.SaveFile(ctx, file, tenantID, kbImportBundleDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to store bundle: %w", err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = manifest.KnowledgeBase.Name
+	}
+	kb, err := s.kbService.CreateKnowledgeBase(ctx, &types.KnowledgeBase{
+		Name:                     name,
+		Description:              manifest.KnowledgeBase.Description,
+		Type:                     types.KnowledgeBaseTypeDocument,
+		ChunkingConfig:           manifest.KnowledgeBase.ChunkingConfig,
+		ImageProcessingConfig:    manifest.KnowledgeBase.ImageProcessingConfig,
+		QuestionGenerationConfig: manifest.KnowledgeBase.QuestionGenerationConfig,
+		RetentionConfig:          manifest.KnowledgeBase.RetentionConfig,
+		OCRConfig:                manifest.KnowledgeBase.OCRConfig,
+		WebExtractionConfig:      manifest.KnowledgeBase.WebExtractionConfig,
+		URLFetchConfig:           manifest.KnowledgeBase.URLFetchConfig,
+		EmbeddingModelID:         req.EmbeddingModelID,
+		SummaryModelID:           req.SummaryModelID,
+	})
+	if err != nil {
+		_ = s.fileSvc.DeleteFile(ctx, bundlePath)
+		return nil, nil, err
+	}
+
+	taskID := utils.GenerateTaskID("kb_import", tenantID, kb.ID)
+	payload := types.KBImportPayload{
+		TenantID:   tenantID,
+		TaskID:     taskID,
+		KBID:       kb.ID,
+		BundlePath: bundlePath,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return kb, nil, fmt.Errorf("failed to marshal import payload: %w", err)
+	}
+	// A partial import cannot be resumed; the user deletes the knowledge base and imports again
+	task := asynq.NewTask(types.TypeKBImport, payloadBytes,
+		asynq.TaskID(taskID), asynq.Queue("default"), asynq.MaxRetry(0))
+	if _, err := s.task.Enqueue(task); err != nil {
+		return kb, nil, fmt.Errorf("failed to enqueue import task: %w", err)
+	}
+
+	now := time.Now().Unix()
+	progress := &types.KBImportProgress{
+		TaskID:    taskID,
+		KBID:      kb.ID,
+		Status:    types.KBImportStatusPending,
+		Total:     len(manifest.Knowledge),
+		Message:   "Task queued, waiting to start...",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.saveKBImportProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save initial KB import progress: %v", err)
+	}
+	logger.Infof(ctx, "KB import task enqueued: %s, kb: %s, knowledge: %d", taskID, kb.ID, len(manifest.Knowledge))
+	return kb, progress, nil
+}
+
+// spoolBundle copies a stored bundle into a temporary file for random access, at most KBBundleMaxSize bytes.
+// The caller closes and removes the file.
+func (s *knowledgeService) spoolBundle(ctx context.Context, bundlePath string) (*os.File, int64, error) {
+	reader, err := s.fileSvc.GetFile(ctx, bundlePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer reader.Close()
+	tmp, err := os.CreateTemp("", "kb-import-*.zip")
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := io.Copy(tmp, io.LimitReader(reader, types.KBBundleMaxSize+1))
+	if err == nil && size > types.KBBundleMaxSize {
+		err = fmt.Errorf("bundle exceeds %d bytes", int64(types.KBBundleMaxSize))
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, 0, err
+	}
+	return tmp, size, nil
+}
+
+// kbImportRun carries the state of one import across knowledge
+type kbImportRun struct {
+	kb             *types.KnowledgeBase
+	zr             *bundleReader
+	embedder       embedding.Embedder
+	retrieveEngine *retriever.CompositeRetrieveEngine
+	progress       *types.KBImportProgress
+	// Bundled vectors are reused only when computed by the same model
+	reuseEmbeddings bool
+	// bundle ID -> new ID
+	tagIDMap       map[string]string
+	knowledgeIDMap map[string]string
+}
+
+// ProcessKBImport handles Asynq knowledge base import tasks.
+//
+// Tags, knowledge and chunks are recreated with new IDs and the chunks are indexed with the
+// knowledge base's embedding model. Bundled vectors are used instead of embedding again
+// when they were computed by a model with the same name and dimensions.
+func (s *knowledgeService) ProcessKBImport(ctx context.Context, t *asynq.Task) error {
+	var payload types.KBImportPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal KB import payload: %w", err)
+	}
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant info: %v", err)
+		return fmt.Errorf("failed to get tenant info: %w", err)
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	logger.Infof(ctx, "Processing KB import task: %s, kb: %s", payload.TaskID, payload.KBID)
+
+	progress := &types.KBImportProgress{
+		TaskID:  payload.TaskID,
+		KBID:    payload.KBID,
+		Status:  types.KBImportStatusProcessing,
+		Message: "Starting import...",
+	}
+	if existing, err := s.GetKBImportProgress(ctx, payload.TaskID); err == nil {
+		progress.CreatedAt = existing.CreatedAt
+	}
+	_ = s.saveKBImportProgress(ctx, progress)
+	defer func() {
+		if err := s.fileSvc.DeleteFile(ctx, payload.BundlePath); err != nil {
+			logger.Warnf(ctx, "Failed to delete import bundle %s: %v", payload.BundlePath, err)
+		}
+	}()
+
+	handleError := func(err error, message string) error {
+		logger.Errorf(ctx, "KB import task %s: %s: %v", payload.TaskID, message, err)
+		progress.Status = types.KBImportStatusFailed
+		progress.Error = err.Error()
+		progress.Message = message
+		_ = s.saveKBImportProgress(ctx, progress)
+		return err
+	}
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.KBID)
+	if err != nil {
+		return handleError(err, "Failed to get knowledge base")
+	}
+	bundle, size, err := s.spoolBundle(ctx, payload.BundlePath)
+	if err != nil {
+		return handleError(err, "Failed to read bundle")
+	}
+	defer func() {
+		bundle.Close()
+		os.Remove(bundle.Name())
+	}()
+	zr, manifest, err := readBundleManifest(bundle, size)
+	if err != nil {
+		return handleError(err, "Invalid bundle")
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		return handleError(err, "Failed to get embedding model")
+	}
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return handleError(err, "Failed to init retrieve engine")
+	}
+
+	run := &kbImportRun{
+		kb:             kb,
+		zr:             zr,
+		embedder:       embedder,
+		retrieveEngine: retrieveEngine,
+		progress:       progress,
+		reuseEmbeddings: manifest.Embedding != nil &&
+			manifest.Embedding.ModelName == embedder.GetModelName() &&
+			manifest.Embedding.Dimensions == embedder.GetDimensions(),
+		knowledgeIDMap: make(map[string]string, len(manifest.Knowledge)),
+	}
+	if manifest.Embedding != nil && !run.reuseEmbeddings {
+		logger.Infof(ctx, "Bundle vectors were computed by %s (%d), re-embedding with %s (%d)",
+			manifest.Embedding.ModelName, manifest.Embedding.Dimensions,
+			embedder.GetModelName(), embedder.GetDimensions())
+	}
+
+	run.tagIDMap, err = s.importBundleTags(ctx, kb, manifest.Tags)
+	if err != nil {
+		return handleError(err, "Failed to import tags")
+	}
+
+	progress.Total = len(manifest.Knowledge)
+	for _, src := range manifest.Knowledge {
+		// Documents added to the tenant since the upload count too
+		if err := s.quotaService.CheckKnowledgeQuota(ctx); err != nil {
+			return handleError(err, "Knowledge quota reached")
+		}
+		if err := s.importBundleKnowledge(ctx, run, src); err != nil {
+			return handleError(err, fmt.Sprintf("Failed to import knowledge %s", src.Title))
+		}
+		progress.Processed++
+		progress.Progress = progress.Processed * 100 / progress.Total
+		progress.Message = fmt.Sprintf("Imported %d/%d knowledge", progress.Processed, progress.Total)
+		_ = s.saveKBImportProgress(ctx, progress)
+	}
+
+	progress.Status = types.KBImportStatusCompleted
+	progress.Progress = 100
+	progress.Message = fmt.Sprintf("Imported %d knowledge, reused %d vectors",
+		progress.Processed, progress.ReusedEmbeddings)
+	_ = s.saveKBImportProgress(ctx, progress)
+	logger.Infof(ctx, "KB import task completed: %s, kb: %s, knowledge: %d",
+		payload.TaskID, kb.ID, progress.Processed)
+	return nil
+}
+
+// importBundleTags recreates the tag tree of the bundle, parents before children.
+// Tags whose parent is missing from the bundle become root tags.
+func (s *knowledgeService) importBundleTags(
+	ctx context.Context, kb *types.KnowledgeBase, tags []*types.KnowledgeTag,
+) (map[string]string, error) {
+	tagIDMap := make(map[string]string, len(tags))
+	bundleTagIDs := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		bundleTagIDs[tag.ID] = true
+	}
+	pending := tags
+	for len(pending) > 0 {
+		var deferred []*types.KnowledgeTag
+		for _, tag := range pending {
+			parentID := ""
+			if tag.ParentID != "" && bundleTagIDs[tag.ParentID] {
+				mapped, ok := tagIDMap[tag.ParentID]
+				if !ok {
+					deferred = append(deferred, tag)
+					continue
+				}
+				parentID = mapped
+			}
+			now := time.Now()
+			newTag := &types.KnowledgeTag{
+				ID:              uuid.New().String(),
+				TenantID:        kb.TenantID,
+				KnowledgeBaseID: kb.ID,
+				ParentID:        parentID,
+				Name:            tag.Name,
+				Color:           tag.Color,
+				SortOrder:       tag.SortOrder,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			}
+			if err := s.tagRepo.Create(ctx, newTag); err != nil {
+				return nil, err
+			}
+			tagIDMap[tag.ID] = newTag.ID
+		}
+		// A parent cycle in the bundle: break it by importing the rest as root tags
+		if len(deferred) == len(pending) {
+			for _, tag := range deferred {
+				delete(bundleTagIDs, tag.ParentID)
+			}
+		}
+		pending = deferred
+	}
+	return tagIDMap, nil
+}
+
+// importBundleKnowledge recreates one knowledge with its file and chunks and indexes the chunks.
+// On failure the knowledge is kept and marked as failed.
+func (s *knowledgeService) importBundleKnowledge(
+	ctx context.Context, run *kbImportRun, src *types.Knowledge,
+) (err error) {
+	var chunks []*types.Chunk
+	found, err := run.zr.readJSON(types.KBBundleChunksPath(src.ID), &chunks)
+	if err != nil {
+		return fmt.Errorf("invalid chunks: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("chunks of knowledge %s missing from bundle", src.ID)
+	}
+
+	dst := &types.Knowledge{
+		ID:               uuid.New().String(),
+		TenantID:         run.kb.TenantID,
+		KnowledgeBaseID:  run.kb.ID,
+		TagID:            run.tagIDMap[src.TagID],
+		Type:             src.Type,
+		Title:            src.Title,
+		Description:      src.Description,
+		Source:           src.Source,
+		ParseStatus:      types.ParseStatusProcessing,
+		EnableStatus:     "disabled",
+		EmbeddingModelID: run.kb.EmbeddingModelID,
+		FileName:         src.FileName,
+		FileType:         src.FileType,
+		FileSize:         src.FileSize,
+		FileHash:         src.FileHash,
+		ContentHash:      src.ContentHash,
+		DuplicateType:    src.DuplicateType,
+		Metadata:         src.Metadata,
+		Capture:          src.Capture,
+		ExpiresAt:        src.ExpiresAt,
+	}
+	// Duplicates point at their original, which was imported earlier
+	if originalID, ok := run.knowledgeIDMap[src.DuplicateOf]; ok {
+		dst.DuplicateOf = originalID
+	} else {
+		dst.DuplicateType = ""
+	}
+	if markdown, found, err := run.zr.readEntry(types.KBBundleMarkdownPath(src.ID)); err == nil && found {
+		dst.SimHash = int64(types.SimHash(string(markdown)))
+	}
+	if src.FileName != "" {
+		fileData, found, err := run.zr.readEntry(types.KBBundleFilePath(src.ID, src.FileName))
+		if err != nil {
+			return fmt.Errorf("failed to read original file: %w", err)
+		}
+		if found {
+			dst.FilePath, err = s.fileSvc.SaveBytes(ctx, fileData, run.kb.TenantID, src.FileName, false)
+			if err != nil {
+				return fmt.Errorf("failed to store original file: %w", err)
+			}
+			dst.StorageSize = int64(len(fileData))
+		}
+	}
+
+	if err := s.repo.CreateKnowledge(ctx, dst); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.ParseStatus = types.ParseStatusFailed
+			dst.ErrorMessage = err.Error()
+		} else {
+			now := time.Now()
+			dst.ParseStatus = types.ParseStatusCompleted
+			dst.EnableStatus = src.EnableStatus
+			dst.ProcessedAt = &now
+		}
+		_ = s.repo.UpdateKnowledge(ctx, dst)
+	}()
+	run.knowledgeIDMap[src.ID] = dst.ID
+
+	if dst.StorageSize > 0 {
+		tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+		tenantInfo.StorageUsed += dst.StorageSize
+		if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, dst.StorageSize); err != nil {
+			logger.Warnf(ctx, "Failed to update tenant storage used: %v", err)
+		}
+	}
+	if len(src.TagIDs) > 0 {
+		tagIDs := make([]string, 0, len(src.TagIDs))
+		for _, tagID := range src.TagIDs {
+			if mapped, ok := run.tagIDMap[tagID]; ok {
+				tagIDs = append(tagIDs, mapped)
+			}
+		}
+		if err := s.tagRepo.SetKnowledgeTags(ctx, dst.TenantID, run.kb.ID, dst.ID, tagIDs); err != nil {
+			return err
+		}
+	}
+
+	return s.importBundleChunks(ctx, run, src, dst, chunks)
+}
+
+// importBundleChunks creates the chunks of an imported knowledge with new IDs and indexes them
+func (s *knowledgeService) importBundleChunks(ctx context.Context,
+	run *kbImportRun, src, dst *types.Knowledge, srcChunks []*types.Chunk,
+) error {
+	var bundleVectors map[string][]float32
+	if run.reuseEmbeddings {
+		if _, err := run.zr.readJSON(types.KBBundleEmbeddingsPath(src.ID), &bundleVectors); err != nil {
+			return fmt.Errorf("invalid embeddings: %w", err)
+		}
+	}
+
+	now := time.Now()
+	chunkIDMap := make(map[string]string, len(srcChunks))
+	dstChunks := make([]*types.Chunk, 0, len(srcChunks))
+	// Vectors are looked up by content since source IDs change on import
+	contentVectors := make(map[string][]float32)
+	for _, srcChunk := range srcChunks {
+		dstChunk := &types.Chunk{
+			ID:              uuid.New().String(),
+			TenantID:        dst.TenantID,
+			KnowledgeID:     dst.ID,
+			KnowledgeBaseID: dst.KnowledgeBaseID,
+			TagID:           run.tagIDMap[srcChunk.TagID],
+			Content:         srcChunk.Content,
+			ChunkIndex:      srcChunk.ChunkIndex,
+			IsEnabled:       srcChunk.IsEnabled,
+			Flags:           srcChunk.Flags,
+			Status:          srcChunk.Status,
+			StartAt:         srcChunk.StartAt,
+			EndAt:           srcChunk.EndAt,
+			PreChunkID:      srcChunk.PreChunkID,
+			NextChunkID:     srcChunk.NextChunkID,
+			ChunkType:       srcChunk.ChunkType,
+			ParentChunkID:   srcChunk.ParentChunkID,
+			Metadata:        srcChunk.Metadata,
+			ContentHash:     srcChunk.ContentHash,
+			ImageInfo:       srcChunk.ImageInfo,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		dstChunks = append(dstChunks, dstChunk)
+		chunkIDMap[srcChunk.ID] = dstChunk.ID
+		for _, info := range chunkIndexInfos(srcChunk, src.ID, "") {
+			if vector, ok := bundleVectors[info.SourceID]; ok {
+				contentVectors[info.Content] = vector
+			}
+		}
+	}
+	for _, dstChunk := range dstChunks {
+		dstChunk.PreChunkID = chunkIDMap[dstChunk.PreChunkID]
+		dstChunk.NextChunkID = chunkIDMap[dstChunk.NextChunkID]
+		dstChunk.ParentChunkID = chunkIDMap[dstChunk.ParentChunkID]
+	}
+
+	embedder := run.embedder
+	if len(contentVectors) > 0 {
+		embedder = &precomputedEmbedder{Embedder: run.embedder, vectors: contentVectors}
+	}
+	disabledChunks := make(map[string]bool)
+	for batch := range slices.Chunk(dstChunks, kbBundleChunkPageSize) {
+		if err := s.chunkRepo.CreateChunks(ctx, batch); err != nil {
+			return err
+		}
+		indexInfoList := make([]*types.IndexInfo, 0, len(batch))
+		for _, chunk := range batch {
+			infos := chunkIndexInfos(chunk, dst.ID, dst.KnowledgeBaseID)
+			for _, info := range infos {
+				if _, ok := contentVectors[info.Content]; ok {
+					run.progress.ReusedEmbeddings++
+				}
+			}
+			indexInfoList = append(indexInfoList, infos...)
+			if !chunk.IsEnabled {
+				disabledChunks[chunk.ID] = false
+			}
+		}
+		if err := run.retrieveEngine.BatchIndex(ctx, embedder, indexInfoList); err != nil {
+			return err
+		}
+	}
+	if len(disabledChunks) > 0 {
+		if err := run.retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, disabledChunks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// precomputedEmbedder serves vectors shipped in an import bundle and only embeds texts it has no vector for
+type precomputedEmbedder struct {
+	embedding.Embedder
+	// content -> vector
+	vectors map[string][]float32
+}
+
+// Embed returns the bundled vector of the text or embeds it with the underlying model
+func (e *precomputedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if vector, ok := e.vectors[text]; ok {
+		return vector, nil
+	}
+	return e.Embedder.Embed(ctx, text)
+}
+
+// BatchEmbedWithPool returns the bundled vectors and embeds the remaining texts with the underlying model
+func (e *precomputedEmbedder) BatchEmbedWithPool(
+	ctx context.Context, _ embedding.Embedder, texts []string,
+) ([][]float32, error) {
+	result := make([][]float32, len(texts))
+	var missing []string
+	var missingIdx []int
+	for i, text := range texts {
+		if vector, ok := e.vectors[text]; ok {
+			result[i] = vector
+			continue
+		}
+		missing = append(missing, text)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	vectors, err := e.Embedder.BatchEmbedWithPool(ctx, e.Embedder, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(missing) {
+		return nil, fmt.Errorf("embedding count mismatch: expected %d, got %d", len(missing), len(vectors))
+	}
+	for i, vector := range vectors {
+		result[missingIdx[i]] = vector
+	}
+	return result, nil
+}
+
+// saveKBImportProgress saves the KB import progress to Redis
+func (s *knowledgeService) saveKBImportProgress(ctx context.Context, progress *types.KBImportProgress) error {
+	progress.UpdatedAt = time.Now().Unix()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	return s.redisClient.Set(ctx, getKBImportProgressKey(progress.TaskID), data, kbImportProgressTTL).Err()
+}
+
+// GetKBImportProgress retrieves the progress of a knowledge base import task
+func (s *knowledgeService) GetKBImportProgress(ctx context.Context, taskID string) (*types.KBImportProgress, error) {
+	data, err := s.redisClient.Get(ctx, getKBImportProgressKey(taskID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, werrors.NewNotFoundError("KB import task not found")
+		}
+		return nil, fmt.Errorf("failed to get progress from Redis: %w", err)
+	}
+
+	var progress types.KBImportProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+	}
+	return &progress, nil
+}
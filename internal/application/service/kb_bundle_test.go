@@ -0,0 +1,60 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newTestBundle(t *testing.T, entries map[string][]byte) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestBundleReaderLimits(t *testing.T) {
+	zr := newTestBundle(t, map[string][]byte{
+		"small.txt": []byte("hello"),
+		"bomb.txt":  bytes.Repeat([]byte{0}, 1<<20),
+		"a.txt":     bytes.Repeat([]byte("a"), 600),
+		"b.txt":     bytes.Repeat([]byte("b"), 600),
+	})
+
+	br := newBundleReader(zr, 1000, 1000)
+	data, found, err := br.readEntry("small.txt")
+	if err != nil || !found || string(data) != "hello" {
+		t.Fatalf("small entry: %q, %v, %v", data, found, err)
+	}
+	if _, found, err := br.readEntry("missing.txt"); err != nil || found {
+		t.Fatalf("missing entry: %v, %v", found, err)
+	}
+	if _, _, err := br.readEntry("bomb.txt"); !errors.Is(err, errBundleTooLarge) {
+		t.Fatalf("bomb entry: got %v, want errBundleTooLarge", err)
+	}
+
+	// Entries within the per-entry cap still add up to the total cap
+	if _, _, err := br.readEntry("a.txt"); err != nil {
+		t.Fatalf("first entry: %v", err)
+	}
+	if _, _, err := br.readEntry("b.txt"); !errors.Is(err, errBundleTooLarge) {
+		t.Fatalf("second entry: got %v, want errBundleTooLarge", err)
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// kbInviteTokenType is the type claim of invite link tokens, distinguishing them from login and share tokens
+	kbInviteTokenType = "kb_invite"
+	// DefaultKBInviteTTL is how long invite links stay valid when no expiry is requested
+	DefaultKBInviteTTL = 72 * time.Hour
+	// MaxKBInviteTTL is the longest an invite link may stay valid
+	MaxKBInviteTTL = 30 * 24 * time.Hour
+)
+
+// ListGrantedKnowledgeBases lists the knowledge bases of other tenants a user was granted a role on
+func (s *kbPermissionService) ListGrantedKnowledgeBases(ctx context.Context,
+	userID string, currentTenantID uint64,
+) ([]*types.SharedKnowledgeBaseInfo, error) {
+	if userID == "" {
+		return nil, nil
+	}
+	members, err := s.repo.ListMembershipsByUser(ctx, userID)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	kbIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		kbIDs = append(kbIDs, member.KnowledgeBaseID)
+	}
+	kbs, err := s.kbRepo.GetKnowledgeBaseByIDs(ctx, kbIDs)
+	if err != nil {
+		return nil, err
+	}
+	kbByID := make(map[string]*types.KnowledgeBase, len(kbs))
+	for _, kb := range kbs {
+		kbByID[kb.ID] = kb
+	}
+
+	result := make([]*types.SharedKnowledgeBaseInfo, 0, len(members))
+	for _, member := range members {
+		kb := kbByID[member.KnowledgeBaseID]
+		// Grants within the user's own tenant only adjust its role there, they do not share anything
+		if kb == nil || kb.TenantID == currentTenantID || kb.IsTemporary {
+			continue
+		}
+		switch kb.Type {
+		case types.KnowledgeBaseTypeDocument:
+			if count, err := s.kgRepo.CountKnowledgeByKnowledgeBaseID(ctx, kb.TenantID, kb.ID); err == nil {
+				kb.KnowledgeCount = count
+			}
+		case types.KnowledgeBaseTypeFAQ:
+			if count, err := s.chunkRepo.CountChunksByKnowledgeBaseID(ctx, kb.TenantID, kb.ID); err == nil {
+				kb.ChunkCount = count
+			}
+		}
+		result = append(result, &types.SharedKnowledgeBaseInfo{
+			KnowledgeBase:  kb,
+			Permission:     member.Role.OrgRole(),
+			SourceTenantID: kb.TenantID,
+			SharedAt:       member.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// CreateInvite creates an expiring invite link granting a role on a knowledge base
+func (s *kbPermissionService) CreateInvite(ctx context.Context,
+	kbID string, req *types.CreateKBInviteRequest,
+) (*types.KBInviteLink, error) {
+	if !req.Role.IsValidForInvite() {
+		return nil, werrors.NewBadRequestError("Role must be one of editor, contributor, viewer")
+	}
+	if req.MaxUses < 0 {
+		return nil, werrors.NewBadRequestError("Max uses cannot be negative")
+	}
+	ttl := time.Duration(req.ExpiresInHours) * time.Hour
+	if ttl <= 0 {
+		ttl = DefaultKBInviteTTL
+	}
+	if ttl > MaxKBInviteTTL {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("Invite expiry cannot exceed %d hours", int(MaxKBInviteTTL.Hours())))
+	}
+
+	now := time.Now()
+	createdBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	invite := &types.KBInvite{
+		ID:              uuid.New().String(),
+		KnowledgeBaseID: kbID,
+		Role:            req.Role,
+		MaxUses:         req.MaxUses,
+		ExpiresAt:       now.Add(ttl),
+		CreatedBy:       createdBy,
+	}
+	// Invite links are signed like login tokens, the type claim keeps them from being accepted as one.
+	// The invite record lets links be revoked and their uses counted.
+	claims := jwt.MapClaims{
+		"invite_id":         invite.ID,
+		"knowledge_base_id": kbID,
+		"exp":               invite.ExpiresAt.Unix(),
+		"iat":               now.Unix(),
+		"type":              kbInviteTokenType,
+	}
+	token, err := signJwt(claims)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateInvite(ctx, invite); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Created invite %s with role %s to knowledge base %s until %s",
+		invite.ID, invite.Role, kbID, invite.ExpiresAt.Format(time.RFC3339))
+	return &types.KBInviteLink{KBInvite: invite, Token: token}, nil
+}
+
+// ListInvites lists the invites of a knowledge base that have not been revoked
+func (s *kbPermissionService) ListInvites(ctx context.Context, kbID string) ([]*types.KBInvite, error) {
+	return s.repo.ListInvites(ctx, kbID)
+}
+
+// RevokeInvite revokes an invite, the roles already granted through it are kept
+func (s *kbPermissionService) RevokeInvite(ctx context.Context, kbID string, id string) error {
+	if err := s.repo.DeleteInvite(ctx, kbID, id); err != nil {
+		if errors.Is(err, repository.ErrKBInviteNotFound) {
+			return werrors.NewNotFoundError("Invite not found")
+		}
+		return err
+	}
+	logger.Infof(ctx, "Revoked invite %s to knowledge base %s", id, kbID)
+	return nil
+}
+
+// AcceptInvite grants the role of an invite link to the user in ctx.
+// Users who already have the role or a higher one keep theirs and do not use up the invite.
+func (s *kbPermissionService) AcceptInvite(ctx context.Context, token string) (*types.KBMember, error) {
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+	if userID == "" {
+		return nil, werrors.NewForbiddenError("Invites can only be accepted by a logged in user")
+	}
+	inviteID, err := parseKBInviteToken(token)
+	if err != nil {
+		logger.Warnf(ctx, "Rejected invite link: %v", err)
+		return nil, werrors.NewBadRequestError("Invalid or expired invite link")
+	}
+	invite, err := s.repo.GetInvite(ctx, inviteID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKBInviteNotFound) {
+			return nil, werrors.NewBadRequestError("The invite link has been revoked")
+		}
+		return nil, err
+	}
+	if !invite.IsUsable(time.Now()) {
+		return nil, werrors.NewBadRequestError("The invite link has expired or has no uses left")
+	}
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, invite.KnowledgeBaseID)
+	if err != nil || kb == nil {
+		return nil, werrors.NewNotFoundError("Knowledge base not found")
+	}
+
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	role, _, err := s.ResolveKBRole(ctx, kb, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role.HasPermission(invite.Role) {
+		return &types.KBMember{KnowledgeBaseID: kb.ID, UserID: userID, Role: role}, nil
+	}
+
+	if err := s.repo.UseInvite(ctx, invite.ID); err != nil {
+		if errors.Is(err, repository.ErrKBInviteUsedUp) {
+			return nil, werrors.NewBadRequestError("The invite link has expired or has no uses left")
+		}
+		return nil, err
+	}
+	member := &types.KBMember{
+		ID:              uuid.New().String(),
+		KnowledgeBaseID: kb.ID,
+		UserID:          userID,
+		Role:            invite.Role,
+		GrantedBy:       invite.CreatedBy,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if err := s.repo.SaveMember(ctx, member); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "User %s accepted invite %s, granted role %s on knowledge base %s",
+		userID, invite.ID, invite.Role, kb.ID)
+	return member, nil
+}
+
+// parseKBInviteToken checks the signature and expiry of an invite link and returns its invite ID
+func parseKBInviteToken(token string) (string, error) {
+	parsed, err := jwt.Parse(token, jwtKeyFunc)
+	if err != nil || !parsed.Valid {
+		return "", fmt.Errorf("invalid invite token: %w", err)
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["type"] != kbInviteTokenType {
+		return "", errors.New("not an invite token")
+	}
+	inviteID, _ := claims["invite_id"].(string)
+	if inviteID == "" {
+		return "", errors.New("invite token without invite")
+	}
+	return inviteID, nil
+}
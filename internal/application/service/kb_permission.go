@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// kbPermissionService implements KBPermissionService interface
+type kbPermissionService struct {
+	repo           interfaces.KBPermissionRepository
+	kbShareService interfaces.KBShareService
+	userRepo       interfaces.UserRepository
+	kbRepo         interfaces.KnowledgeBaseRepository
+	kgRepo         interfaces.KnowledgeRepository
+	chunkRepo      interfaces.ChunkRepository
+}
+
+// NewKBPermissionService creates a new knowledge base permission service
+func NewKBPermissionService(
+	repo interfaces.KBPermissionRepository,
+	kbShareService interfaces.KBShareService,
+	userRepo interfaces.UserRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	kgRepo interfaces.KnowledgeRepository,
+	chunkRepo interfaces.ChunkRepository,
+) interfaces.KBPermissionService {
+	return &kbPermissionService{
+		repo:           repo,
+		kbShareService: kbShareService,
+		userRepo:       userRepo,
+		kbRepo:         kbRepo,
+		kgRepo:         kgRepo,
+		chunkRepo:      chunkRepo,
+	}
+}
+
+// ResolveKBRole returns the role of a user on a knowledge base and the tenant its data is read with
+func (s *kbPermissionService) ResolveKBRole(ctx context.Context,
+	kb *types.KnowledgeBase, tenantID uint64, userID string,
+) (types.KBRole, uint64, error) {
+	// Scoped API keys act within their tenant, capped by their scope and knowledge base restrictions
+	if apiKey := types.APIKeyFromContext(ctx); apiKey != nil {
+		if kb.TenantID != tenantID || !apiKey.AllowsKnowledgeBase(kb.ID) {
+			return "", 0, nil
+		}
+		return apiKey.Scope.KBRole(), tenantID, nil
+	}
+
+	// A role granted on the knowledge base is the most specific, it may raise or restrict access
+	if userID != "" {
+		member, err := s.repo.GetMember(ctx, kb.ID, userID)
+		if err == nil {
+			return member.Role, kb.TenantID, nil
+		}
+		if !errors.Is(err, repository.ErrKBMemberNotFound) {
+			return "", 0, err
+		}
+	}
+
+	// Members of the tenant the knowledge base belongs to, and API keys of that tenant, own it
+	if kb.TenantID == tenantID {
+		return types.KBRoleOwner, tenantID, nil
+	}
+
+	if userID != "" && s.kbShareService != nil {
+		permission, isShared, err := s.kbShareService.CheckUserKBPermission(ctx, kb.ID, userID)
+		if err != nil {
+			return "", 0, err
+		}
+		if isShared {
+			return types.KBRoleFromOrgRole(permission), kb.TenantID, nil
+		}
+	}
+	return "", 0, nil
+}
+
+// ResolveKnowledgeRole applies the document permission and authorship of a user to its role on the knowledge base
+func (s *kbPermissionService) ResolveKnowledgeRole(ctx context.Context,
+	knowledge *types.Knowledge, kbRole types.KBRole, userID string,
+) (types.KBRole, error) {
+	if kbRole == "" {
+		return kbRole, nil
+	}
+	if userID == "" {
+		// Service accounts have no document permissions, but may edit what they added like users do
+		if apiKey := types.APIKeyFromContext(ctx); apiKey != nil && apiKey.ServiceAccountID != "" {
+			return types.EffectiveKnowledgeRole(kbRole, "", knowledge.CreatedBy == apiKey.ServiceAccountID), nil
+		}
+		return kbRole, nil
+	}
+	var override types.KBRole
+	permission, err := s.repo.GetKnowledgePermission(ctx, knowledge.ID, userID)
+	if err == nil {
+		override = permission.Role
+	} else if !errors.Is(err, repository.ErrKnowledgePermissionNotFound) {
+		return "", err
+	}
+	return types.EffectiveKnowledgeRole(kbRole, override, knowledge.CreatedBy == userID), nil
+}
+
+// ListMembers lists the roles granted on a knowledge base
+func (s *kbPermissionService) ListMembers(ctx context.Context, kbID string) ([]*types.KBMember, error) {
+	return s.repo.ListMembers(ctx, kbID)
+}
+
+// SetMemberRole grants a role on a knowledge base to a user
+func (s *kbPermissionService) SetMemberRole(ctx context.Context,
+	kbID string, userID string, role types.KBRole,
+) (*types.KBMember, error) {
+	if !role.IsValid() {
+		return nil, werrors.NewBadRequestError("Role must be one of owner, editor, contributor, viewer")
+	}
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGrantee(ctx, kb.TenantID, userID); err != nil {
+		return nil, err
+	}
+	grantedBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	member := &types.KBMember{
+		ID:              uuid.New().String(),
+		KnowledgeBaseID: kbID,
+		UserID:          userID,
+		Role:            role,
+		GrantedBy:       grantedBy,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if err := s.repo.SaveMember(ctx, member); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Granted role %s on knowledge base %s to user %s", role, kbID, userID)
+	return member, nil
+}
+
+// RemoveMember revokes the role granted to a user on a knowledge base
+func (s *kbPermissionService) RemoveMember(ctx context.Context, kbID string, userID string) error {
+	if err := s.repo.DeleteMember(ctx, kbID, userID); err != nil {
+		if errors.Is(err, repository.ErrKBMemberNotFound) {
+			return werrors.NewNotFoundError("The user has no role granted on this knowledge base")
+		}
+		return err
+	}
+	logger.Infof(ctx, "Revoked role on knowledge base %s of user %s", kbID, userID)
+	return nil
+}
+
+// ListKnowledgePermissions lists the permissions set on a document
+func (s *kbPermissionService) ListKnowledgePermissions(ctx context.Context,
+	knowledgeID string,
+) ([]*types.KnowledgePermission, error) {
+	return s.repo.ListKnowledgePermissions(ctx, knowledgeID)
+}
+
+// SetKnowledgePermission overrides the role of a user on a document
+func (s *kbPermissionService) SetKnowledgePermission(ctx context.Context,
+	knowledge *types.Knowledge, userID string, role types.KBRole,
+) (*types.KnowledgePermission, error) {
+	if !role.IsValidForKnowledge() {
+		return nil, werrors.NewBadRequestError("Role must be one of editor, viewer, none")
+	}
+	if err := s.checkGrantee(ctx, knowledge.TenantID, userID); err != nil {
+		return nil, err
+	}
+	grantedBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	permission := &types.KnowledgePermission{
+		ID:              uuid.New().String(),
+		KnowledgeID:     knowledge.ID,
+		KnowledgeBaseID: knowledge.KnowledgeBaseID,
+		UserID:          userID,
+		Role:            role,
+		GrantedBy:       grantedBy,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if err := s.repo.SaveKnowledgePermission(ctx, permission); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Set role %s on knowledge %s for user %s", role, knowledge.ID, userID)
+	return permission, nil
+}
+
+// RemoveKnowledgePermission removes the document permission of a user
+func (s *kbPermissionService) RemoveKnowledgePermission(ctx context.Context, knowledgeID string, userID string) error {
+	if err := s.repo.DeleteKnowledgePermission(ctx, knowledgeID, userID); err != nil {
+		if errors.Is(err, repository.ErrKnowledgePermissionNotFound) {
+			return werrors.NewNotFoundError("The user has no permission set on this knowledge")
+		}
+		return err
+	}
+	logger.Infof(ctx, "Removed permission on knowledge %s of user %s", knowledgeID, userID)
+	return nil
+}
+
+// ListHiddenKnowledgeIDs returns the documents of a knowledge base hidden from a user
+func (s *kbPermissionService) ListHiddenKnowledgeIDs(ctx context.Context, kbID string, userID string) ([]string, error) {
+	return s.repo.ListHiddenKnowledgeIDs(ctx, kbID, userID)
+}
+
+// checkGrantee rejects changes to the caller's own roles, which could lock the caller out, and users outside
+// the tenant owning the knowledge base. Those get access through shares, and are reported like unknown users
+// so that user IDs of other tenants cannot be probed.
+func (s *kbPermissionService) checkGrantee(ctx context.Context, tenantID uint64, userID string) error {
+	if caller, _ := ctx.Value(types.UserIDContextKey).(string); caller != "" && caller == userID {
+		return werrors.NewBadRequestError("You cannot change your own role")
+	}
+	if user, err := s.userRepo.GetUserByID(ctx, userID); err != nil || user == nil || user.TenantID != tenantID {
+		return werrors.NewNotFoundError("User not found in this tenant, share the knowledge base to give access to other tenants")
+	}
+	return nil
+}
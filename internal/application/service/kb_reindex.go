@@ -0,0 +1,555 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	kbReindexProgressKeyPrefix = "kb_reindex_progress:"
+	kbReindexRunningKeyPrefix  = "kb_reindex_running:"
+	kbReindexProgressTTL       = 24 * time.Hour
+	// Chunks are paged through the chunk repository, whose page size is capped at 100
+	kbReindexDefaultBatchSize = 50
+	kbReindexMaxBatchSize     = 100
+	// Knowledge added while a pass runs is picked up by the next pass, up to this many passes
+	kbReindexMaxPasses = 3
+	// New vectors are written under this prefix until the switch, so retrieval never sees them early
+	kbReindexShadowPrefix = "reindex-"
+)
+
+// indexedChunkTypes are the chunk types that carry their own vectors in document knowledge bases
+var indexedChunkTypes = []types.ChunkType{
+	types.ChunkTypeText, types.ChunkTypeSummary,
+	types.ChunkTypeImageCaption, types.ChunkTypeImageOCR,
+	types.ChunkTypeTable, types.ChunkTypeTableSummary, types.ChunkTypeTableColumn,
+}
+
+// getKBReindexProgressKey returns the Redis key for storing KB re-index progress
+func getKBReindexProgressKey(taskID string) string {
+	return kbReindexProgressKeyPrefix + taskID
+}
+
+// getKBReindexRunningKey returns the Redis key holding the running re-index task of a KB
+func getKBReindexRunningKey(kbID string) string {
+	return kbReindexRunningKeyPrefix + kbID
+}
+
+// StartKBEmbeddingReindex validates the target model and enqueues a re-index task for the knowledge base.
+// Only one re-index task may run per knowledge base at a time.
+func (s *knowledgeService) StartKBEmbeddingReindex(
+	ctx context.Context, kb *types.KnowledgeBase, req *types.KBReindexRequest,
+) (*types.KBReindexProgress, error) {
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return nil, werrors.NewBadRequestError("FAQ 知识库暂不支持重建向量索引")
+	}
+	if req.EmbeddingModelID == kb.EmbeddingModelID {
+		return nil, werrors.NewBadRequestError("目标向量模型与当前模型相同")
+	}
+	if req.BatchesPerMinute < 0 {
+		return nil, werrors.NewBadRequestError("batches_per_minute 不能为负数")
+	}
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = kbReindexDefaultBatchSize
+	}
+	if batchSize > kbReindexMaxBatchSize {
+		batchSize = kbReindexMaxBatchSize
+	}
+
+	model, err := s.modelService.GetModelByID(ctx, req.EmbeddingModelID)
+	if err != nil || model == nil {
+		return nil, werrors.NewBadRequestError("目标向量模型不存在")
+	}
+	if model.Type != types.ModelTypeEmbedding {
+		return nil, werrors.NewBadRequestError("目标模型不是向量模型")
+	}
+	oldEmbedder, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current embedding model: %w", err)
+	}
+	newEmbedder, err := s.modelService.GetEmbeddingModel(ctx, req.EmbeddingModelID)
+	if err != nil {
+		return nil, werrors.NewBadRequestError("目标向量模型不可用")
+	}
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return nil, fmt.Errorf("failed to init retrieve engine: %w", err)
+	}
+	if err := checkKBReindexSwitchable(
+		oldEmbedder.GetDimensions(), newEmbedder.GetDimensions(), retrieveEngine,
+	); err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+
+	// Documents still being parsed would be embedded with the old model behind the job's back
+	if err := s.kbService.FillKnowledgeBaseCounts(ctx, kb); err != nil {
+		return nil, fmt.Errorf("failed to count knowledge base content: %w", err)
+	}
+	if kb.ProcessingCount > 0 {
+		return nil, werrors.NewConflictError("知识库中有文档正在解析，请稍后再试")
+	}
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	taskID := utils.GenerateTaskID("kb_reindex", tenantID, kb.ID)
+	locked, err := s.redisClient.SetNX(ctx, getKBReindexRunningKey(kb.ID), taskID, kbReindexProgressTTL).Result()
+	metrics.ObserveLock("kb_reindex", locked, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire re-index lock: %w", err)
+	}
+	if !locked {
+		return nil, werrors.NewConflictError("该知识库已有重建索引任务在运行")
+	}
+
+	payload := types.KBReindexPayload{
+		TenantID:         tenantID,
+		TaskID:           taskID,
+		KBID:             kb.ID,
+		ToModelID:        req.EmbeddingModelID,
+		BatchSize:        batchSize,
+		BatchesPerMinute: req.BatchesPerMinute,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		_ = s.redisClient.Del(ctx, getKBReindexRunningKey(kb.ID)).Err()
+		return nil, fmt.Errorf("failed to marshal re-index payload: %w", err)
+	}
+	task := asynq.NewTask(types.TypeKBEmbeddingReindex, payloadBytes,
+		asynq.TaskID(taskID), asynq.Queue("default"), asynq.MaxRetry(3))
+	if _, err := s.task.Enqueue(task); err != nil {
+		_ = s.redisClient.Del(ctx, getKBReindexRunningKey(kb.ID)).Err()
+		return nil, fmt.Errorf("failed to enqueue re-index task: %w", err)
+	}
+
+	now := time.Now().Unix()
+	progress := &types.KBReindexProgress{
+		TaskID:      taskID,
+		KBID:        kb.ID,
+		FromModelID: kb.EmbeddingModelID,
+		ToModelID:   req.EmbeddingModelID,
+		Status:      types.KBReindexStatusPending,
+		Message:     "Task queued, waiting to start...",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.saveKBReindexProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save initial KB re-index progress: %v", err)
+	}
+	logger.Infof(ctx, "KB re-index task enqueued: %s, kb: %s, from model: %s, to model: %s",
+		taskID, kb.ID, kb.EmbeddingModelID, req.EmbeddingModelID)
+	return progress, nil
+}
+
+// checkKBReindexSwitchable reports why the vectors of a KB cannot be switched between the two dimensions, if so.
+// The old vectors are dropped after the switch by their dimension, so both must differ and the engines
+// must store dimensions apart.
+func checkKBReindexSwitchable(oldDimension, newDimension int, engine *retriever.CompositeRetrieveEngine) error {
+	if oldDimension == newDimension {
+		return fmt.Errorf("目标向量模型与当前模型维度相同（%d），无法与旧向量并存切换", newDimension)
+	}
+	if !engine.SeparatesDimensions() {
+		return errors.New("当前检索引擎不按维度区分向量，不支持重建向量索引")
+	}
+	return nil
+}
+
+// ProcessKBEmbeddingReindex handles Asynq knowledge base embedding re-index tasks.
+//
+// All chunks are re-embedded with the target model into a shadow namespace that retrieval
+// never queries. Once every batch is indexed, the shadow vectors are copied next to the old ones,
+// the knowledge base is switched to the new model in one transaction and only then are the old
+// vectors and the shadow dropped. The knowledge base keeps serving throughout.
+func (s *knowledgeService) ProcessKBEmbeddingReindex(ctx context.Context, t *asynq.Task) error {
+	var payload types.KBReindexPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal KB re-index payload: %w", err)
+	}
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant info: %v", err)
+		return fmt.Errorf("failed to get tenant info: %w", err)
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	isLastRetry := retryCount >= maxRetry
+
+	logger.Infof(ctx, "Processing KB re-index task: %s, kb: %s, to model: %s, retry: %d/%d",
+		payload.TaskID, payload.KBID, payload.ToModelID, retryCount, maxRetry)
+
+	progress := &types.KBReindexProgress{
+		TaskID:    payload.TaskID,
+		KBID:      payload.KBID,
+		ToModelID: payload.ToModelID,
+		Status:    types.KBReindexStatusProcessing,
+		Message:   "Starting re-index...",
+	}
+	if existing, err := s.GetKBReindexProgress(ctx, payload.TaskID); err == nil {
+		progress.CreatedAt = existing.CreatedAt
+	}
+
+	// Only mark as failed and release the lock on the last retry
+	handleError := func(err error, message string) error {
+		logger.Errorf(ctx, "KB re-index task %s: %s: %v", payload.TaskID, message, err)
+		if isLastRetry {
+			progress.Status = types.KBReindexStatusFailed
+			progress.Error = err.Error()
+			progress.Message = message
+			_ = s.saveKBReindexProgress(ctx, progress)
+			_ = s.redisClient.Del(ctx, getKBReindexRunningKey(payload.KBID)).Err()
+		}
+		return err
+	}
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.KBID)
+	if err != nil {
+		return handleError(err, "Failed to get knowledge base")
+	}
+	progress.FromModelID = kb.EmbeddingModelID
+	_ = s.saveKBReindexProgress(ctx, progress)
+
+	oldEmbedder, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		return handleError(err, "Failed to get current embedding model")
+	}
+	newEmbedder, err := s.modelService.GetEmbeddingModel(ctx, payload.ToModelID)
+	if err != nil {
+		return handleError(err, "Failed to get target embedding model")
+	}
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return handleError(err, "Failed to init retrieve engine")
+	}
+
+	if err := checkKBReindexSwitchable(oldEmbedder.GetDimensions(), newEmbedder.GetDimensions(), retrieveEngine); err != nil {
+		return handleError(err, "Cannot switch the knowledge base to the target model")
+	}
+
+	// A previous attempt may have left a partial shadow or partially copied vectors behind
+	knowledgeList, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, payload.TenantID, kb.ID)
+	if err != nil {
+		return handleError(err, "Failed to list knowledge")
+	}
+	if len(knowledgeList) > 0 {
+		knowledgeIDs := make([]string, 0, len(knowledgeList))
+		shadowIDs := make([]string, 0, len(knowledgeList))
+		for _, knowledge := range knowledgeList {
+			knowledgeIDs = append(knowledgeIDs, knowledge.ID)
+			shadowIDs = append(shadowIDs, kbReindexShadowPrefix+knowledge.ID)
+		}
+		if err := retrieveEngine.DeleteByKnowledgeIDList(ctx, shadowIDs, newEmbedder.GetDimensions(), kb.Type); err != nil {
+			return handleError(err, "Failed to clean up previous shadow index")
+		}
+		if err := retrieveEngine.DeleteByKnowledgeIDListInDimension(
+			ctx, knowledgeIDs, newEmbedder.GetDimensions(),
+		); err != nil {
+			return handleError(err, "Failed to clean up previously copied vectors")
+		}
+	}
+
+	if total, err := s.chunkRepo.CountChunksByKnowledgeBaseID(ctx, payload.TenantID, kb.ID); err == nil {
+		progress.Total = int(total)
+	}
+
+	var ticker *time.Ticker
+	if payload.BatchesPerMinute > 0 {
+		ticker = time.NewTicker(time.Minute / time.Duration(payload.BatchesPerMinute))
+		defer ticker.Stop()
+	}
+
+	run := &kbReindexRun{
+		payload:        payload,
+		kb:             kb,
+		embedder:       newEmbedder,
+		retrieveEngine: retrieveEngine,
+		progress:       progress,
+		ticker:         ticker,
+		shadowKBID:     kbReindexShadowPrefix + payload.TaskID,
+		knowledgeIDMap: make(map[string]string),
+		chunkIDMap:     make(map[string]string),
+		disabledChunks: make(map[string]bool),
+		chunkTags:      make(map[string]string),
+	}
+
+	// Knowledge finished while a pass runs is caught up by the next one
+	for pass := 0; pass < kbReindexMaxPasses; pass++ {
+		if pass > 0 {
+			knowledgeList, err = s.repo.ListKnowledgeByKnowledgeBaseID(ctx, payload.TenantID, kb.ID)
+			if err != nil {
+				return handleError(err, "Failed to list knowledge")
+			}
+		}
+		pending := make([]*types.Knowledge, 0, len(knowledgeList))
+		for _, knowledge := range knowledgeList {
+			shadowID := kbReindexShadowPrefix + knowledge.ID
+			if _, done := run.knowledgeIDMap[shadowID]; done || knowledge.ParseStatus != types.ParseStatusCompleted {
+				continue
+			}
+			pending = append(pending, knowledge)
+		}
+		if len(pending) == 0 {
+			break
+		}
+		for _, knowledge := range pending {
+			if err := s.reindexKnowledge(ctx, run, knowledge); err != nil {
+				return handleError(err, fmt.Sprintf("Failed to re-index knowledge %s", knowledge.ID))
+			}
+		}
+	}
+
+	if err := s.switchKBEmbeddingModel(ctx, run, oldEmbedder, knowledgeList); err != nil {
+		return handleError(err, "Failed to switch to the new index, the vector index may be incomplete; please start the re-index again")
+	}
+
+	progress.Status = types.KBReindexStatusCompleted
+	progress.Progress = 100
+	progress.Message = fmt.Sprintf("Re-indexed %d chunks of %d knowledge", progress.Processed, len(run.knowledgeIDMap))
+	_ = s.saveKBReindexProgress(ctx, progress)
+	_ = s.redisClient.Del(ctx, getKBReindexRunningKey(payload.KBID)).Err()
+	logger.Infof(ctx, "KB re-index task completed: %s, kb: %s, chunks: %d",
+		payload.TaskID, payload.KBID, progress.Processed)
+	return nil
+}
+
+// kbReindexRun carries the state of one re-index attempt across knowledge and batches
+type kbReindexRun struct {
+	payload        types.KBReindexPayload
+	kb             *types.KnowledgeBase
+	embedder       embedding.Embedder
+	retrieveEngine *retriever.CompositeRetrieveEngine
+	progress       *types.KBReindexProgress
+	ticker         *time.Ticker
+	batches        int
+
+	shadowKBID string
+	// shadow knowledge ID -> real knowledge ID
+	knowledgeIDMap map[string]string
+	// Chunk IDs are kept as is; the identity map is what CopyIndices expects
+	chunkIDMap map[string]string
+	// Vector status is not carried over by CopyIndices and is reapplied after the switch
+	disabledChunks map[string]bool
+	chunkTags      map[string]string
+}
+
+// reindexKnowledge embeds all chunks of one knowledge into the shadow namespace, batch by batch
+func (s *knowledgeService) reindexKnowledge(ctx context.Context, run *kbReindexRun, knowledge *types.Knowledge) error {
+	shadowKnowledgeID := kbReindexShadowPrefix + knowledge.ID
+	for page := 1; ; page++ {
+		chunks, _, err := s.chunkRepo.ListPagedChunksByKnowledgeID(ctx,
+			run.payload.TenantID,
+			knowledge.ID,
+			&types.Pagination{
+				Page:     page,
+				PageSize: run.payload.BatchSize,
+			},
+			indexedChunkTypes,
+			"",
+			"",
+			"",
+			"",
+			"",
+		)
+		if err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			break
+		}
+
+		indexInfoList := make([]*types.IndexInfo, 0, len(chunks))
+		for _, chunk := range chunks {
+			indexInfoList = append(indexInfoList, chunkIndexInfos(chunk, shadowKnowledgeID, run.shadowKBID)...)
+			run.chunkIDMap[chunk.ID] = chunk.ID
+			if !chunk.IsEnabled {
+				run.disabledChunks[chunk.ID] = false
+			}
+			if chunk.TagID != "" {
+				run.chunkTags[chunk.ID] = chunk.TagID
+			}
+		}
+
+		if err := run.wait(ctx); err != nil {
+			return err
+		}
+		if err := run.retrieveEngine.BatchIndex(ctx, run.embedder, indexInfoList); err != nil {
+			return err
+		}
+
+		run.progress.Processed += len(chunks)
+		if run.progress.Total < run.progress.Processed {
+			run.progress.Total = run.progress.Processed
+		}
+		if run.progress.Total > 0 {
+			// 100 is reserved for after the switch
+			run.progress.Progress = min(run.progress.Processed*100/run.progress.Total, 99)
+		}
+		run.progress.Message = fmt.Sprintf("Re-embedded %d/%d chunks", run.progress.Processed, run.progress.Total)
+		_ = s.saveKBReindexProgress(ctx, run.progress)
+	}
+	run.knowledgeIDMap[shadowKnowledgeID] = knowledge.ID
+	return nil
+}
+
+// chunkIndexInfos returns the index entries of a chunk under the given knowledge and knowledge base IDs.
+// Generated questions are indexed next to their chunk.
+func chunkIndexInfos(chunk *types.Chunk, knowledgeID, kbID string) []*types.IndexInfo {
+	infos := []*types.IndexInfo{{
+		Content:         chunk.Content,
+		SourceID:        chunk.ID,
+		SourceType:      types.ChunkSourceType,
+		ChunkID:         chunk.ID,
+		KnowledgeID:     knowledgeID,
+		KnowledgeBaseID: kbID,
+		TagID:           chunk.TagID,
+	}}
+	if chunk.ChunkType == types.ChunkTypeText {
+		if meta, err := chunk.DocumentMetadata(); err == nil && meta != nil {
+			for _, gq := range meta.GeneratedQuestions {
+				infos = append(infos, &types.IndexInfo{
+					Content:         gq.Question,
+					SourceID:        fmt.Sprintf("%s-%s", chunk.ID, gq.ID),
+					SourceType:      types.ChunkSourceType,
+					ChunkID:         chunk.ID,
+					KnowledgeID:     knowledgeID,
+					KnowledgeBaseID: kbID,
+					TagID:           chunk.TagID,
+				})
+			}
+		}
+	}
+	return infos
+}
+
+// wait blocks until the rate limit allows the next batch
+func (run *kbReindexRun) wait(ctx context.Context) error {
+	run.batches++
+	if run.ticker == nil || run.batches == 1 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-run.ticker.C:
+		return nil
+	}
+}
+
+// switchKBEmbeddingModel copies the shadow vectors in, points the KB at the new model and only then drops the old vectors.
+//
+// Old and new vectors share their knowledge and chunk IDs and are told apart by their dimension alone, which is
+// why re-indexing needs a model of another dimension and engines that store dimensions apart. Until the switch
+// retrieval embeds queries with the old model and only matches the old vectors, after it only the new ones.
+// A failed copy or switch removes the copied vectors again and leaves the old ones untouched.
+func (s *knowledgeService) switchKBEmbeddingModel(
+	ctx context.Context, run *kbReindexRun, oldEmbedder embedding.Embedder, knowledgeList []*types.Knowledge,
+) error {
+	run.progress.Status = types.KBReindexStatusSwitching
+	run.progress.Message = "Switching to the new index..."
+	_ = s.saveKBReindexProgress(ctx, run.progress)
+
+	newDimension := run.embedder.GetDimensions()
+	knowledgeIDs := make([]string, 0, len(knowledgeList))
+	for _, knowledge := range knowledgeList {
+		knowledgeIDs = append(knowledgeIDs, knowledge.ID)
+	}
+	rollback := func() {
+		if len(knowledgeIDs) == 0 {
+			return
+		}
+		if err := run.retrieveEngine.DeleteByKnowledgeIDListInDimension(ctx, knowledgeIDs, newDimension); err != nil {
+			logger.Warnf(ctx, "Failed to remove copied vectors of re-index task %s: %v", run.payload.TaskID, err)
+		}
+	}
+
+	if len(run.chunkIDMap) > 0 {
+		if err := run.retrieveEngine.CopyIndices(ctx, run.shadowKBID, run.kb.ID,
+			run.knowledgeIDMap, run.chunkIDMap, newDimension, run.kb.Type,
+		); err != nil {
+			rollback()
+			return fmt.Errorf("failed to copy shadow vectors: %w", err)
+		}
+		if len(run.disabledChunks) > 0 {
+			if err := run.retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, run.disabledChunks); err != nil {
+				rollback()
+				return fmt.Errorf("failed to restore chunk enabled status: %w", err)
+			}
+		}
+		if len(run.chunkTags) > 0 {
+			if err := run.retrieveEngine.BatchUpdateChunkTagID(ctx, run.chunkTags); err != nil {
+				rollback()
+				return fmt.Errorf("failed to restore chunk tags: %w", err)
+			}
+		}
+	}
+
+	if err := s.kbService.GetRepository().SwitchEmbeddingModel(
+		ctx, run.payload.TenantID, run.kb.ID, run.payload.ToModelID,
+	); err != nil {
+		rollback()
+		return fmt.Errorf("failed to switch embedding model: %w", err)
+	}
+
+	// The switch has happened; leftovers of the old model or the shadow are invisible to retrieval
+	// and only cost storage
+	if len(knowledgeIDs) > 0 {
+		if err := run.retrieveEngine.DeleteByKnowledgeIDListInDimension(
+			ctx, knowledgeIDs, oldEmbedder.GetDimensions(),
+		); err != nil {
+			logger.Warnf(ctx, "Failed to delete old vectors of re-index task %s: %v", run.payload.TaskID, err)
+		}
+	}
+	if len(run.knowledgeIDMap) > 0 {
+		shadowIDs := make([]string, 0, len(run.knowledgeIDMap))
+		for shadowID := range run.knowledgeIDMap {
+			shadowIDs = append(shadowIDs, shadowID)
+		}
+		if err := run.retrieveEngine.DeleteByKnowledgeIDList(ctx, shadowIDs, newDimension, run.kb.Type); err != nil {
+			logger.Warnf(ctx, "Failed to delete shadow vectors of re-index task %s: %v", run.payload.TaskID, err)
+		}
+	}
+	return nil
+}
+
+// saveKBReindexProgress saves the KB re-index progress to Redis
+func (s *knowledgeService) saveKBReindexProgress(ctx context.Context, progress *types.KBReindexProgress) error {
+	progress.UpdatedAt = time.Now().Unix()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	return s.redisClient.Set(ctx, getKBReindexProgressKey(progress.TaskID), data, kbReindexProgressTTL).Err()
+}
+
+// GetKBReindexProgress retrieves the progress of a knowledge base re-index task
+func (s *knowledgeService) GetKBReindexProgress(ctx context.Context, taskID string) (*types.KBReindexProgress, error) {
+	data, err := s.redisClient.Get(ctx, getKBReindexProgressKey(taskID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, werrors.NewNotFoundError("KB re-index task not found")
+		}
+		return nil, fmt.Errorf("failed to get progress from Redis: %w", err)
+	}
+
+	var progress types.KBReindexProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+	}
+	return &progress, nil
+}
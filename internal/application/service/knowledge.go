@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/url"
 	"regexp"
 	"runtime"
 	"slices"
@@ -20,11 +21,16 @@ import (
 	"github.com/Tencent/WeKnora/docreader/client"
 	"github.com/Tencent/WeKnora/docreader/proto"
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	"github.com/Tencent/WeKnora/internal/cluster"
+	"github.com/Tencent/WeKnora/internal/common"
 	"github.com/Tencent/WeKnora/internal/config"
 	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/slowlog"
 	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -33,6 +39,8 @@ import (
 	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -67,10 +75,18 @@ type knowledgeService struct {
 	tagService      interfaces.KnowledgeTagService
 	fileSvc         interfaces.FileService
 	modelService    interfaces.ModelService
-	task            *asynq.Client
+	task            interfaces.TaskEnqueuer
 	graphEngine     interfaces.RetrieveGraphRepository
 	redisClient     *redis.Client
 	kbShareService  interfaces.KBShareService
+	semanticCache   interfaces.SemanticCache
+	quotaService    interfaces.QuotaService
+	fileCache       *knowledgeFileCache
+	// Resolves access to documents of other tenants, through shares and granted roles
+	kbPermissionService interfaces.KBPermissionService
+	webhookService      interfaces.WebhookService
+	// Claims the documents parsed by this replica, so that a task enqueued twice is not parsed twice at once
+	replica *cluster.Replica
 }
 
 const (
@@ -92,11 +108,16 @@ func NewKnowledgeService(
 	tagService interfaces.KnowledgeTagService,
 	fileSvc interfaces.FileService,
 	modelService interfaces.ModelService,
-	task *asynq.Client,
+	task interfaces.TaskEnqueuer,
 	graphEngine interfaces.RetrieveGraphRepository,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
 	redisClient *redis.Client,
 	kbShareService interfaces.KBShareService,
+	semanticCache interfaces.SemanticCache,
+	quotaService interfaces.QuotaService,
+	kbPermissionService interfaces.KBPermissionService,
+	webhookService interfaces.WebhookService,
+	replica *cluster.Replica,
 ) (interfaces.KnowledgeService, error) {
 	return &knowledgeService{
 		config:          config,
@@ -115,6 +136,13 @@ func NewKnowledgeService(
 		retrieveEngine:  retrieveEngine,
 		redisClient:     redisClient,
 		kbShareService:  kbShareService,
+		semanticCache:   semanticCache,
+		fileCache:       newKnowledgeFileCache(redisClient, config),
+		quotaService:    quotaService,
+
+		kbPermissionService: kbPermissionService,
+		webhookService:      webhookService,
+		replica:             replica,
 	}, nil
 }
 
@@ -128,6 +156,14 @@ func (s *knowledgeService) GetRepository() interfaces.KnowledgeRepository {
 	return s.repo
 }
 
+// checkKnowledgeQuota checks the tenant can add another document and start parsing it
+func (s *knowledgeService) checkKnowledgeQuota(ctx context.Context, knowledgeType string) error {
+	if err := s.quotaService.CheckKnowledgeQuota(ctx); err != nil {
+		return err
+	}
+	return s.quotaService.CheckParseJobQuota(ctx, knowledgeType)
+}
+
 // isKnowledgeDeleting checks if a knowledge entry is being deleted.
 // This is used to prevent async tasks from conflicting with deletion operations.
 func (s *knowledgeService) isKnowledgeDeleting(ctx context.Context, tenantID uint64, knowledgeID string) bool {
@@ -167,33 +203,8 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 	}
 
 	// 检查多模态配置完整性 - 只在图片文件时校验
-	// 检查是否为图片文件
-	if !IsImageType(getFileType(fileName)) {
-		logger.Info(ctx, "Non-image file with multimodal enabled, skipping COS/VLM validation")
-	} else {
-		// 检查COS配置
-		switch kb.StorageConfig.Provider {
-		case "cos":
-			if kb.StorageConfig.SecretID == "" || kb.StorageConfig.SecretKey == "" ||
-				kb.StorageConfig.Region == "" || kb.StorageConfig.BucketName == "" ||
-				kb.StorageConfig.AppID == "" {
-				logger.Error(ctx, "COS configuration incomplete for image multimodal processing")
-				return nil, werrors.NewBadRequestError("上传图片文件需要完整的对象存储配置信息, 请前往系统设置页面进行补全")
-			}
-		case "minio":
-			if kb.StorageConfig.BucketName == "" {
-				logger.Error(ctx, "MinIO configuration incomplete for image multimodal processing")
-				return nil, werrors.NewBadRequestError("上传图片文件需要完整的对象存储配置信息, 请前往系统设置页面进行补全")
-			}
-		}
-
-		// 检查VLM配置
-		if !kb.VLMConfig.Enabled || kb.VLMConfig.ModelID == "" {
-			logger.Error(ctx, "VLM model is not configured")
-			return nil, werrors.NewBadRequestError("上传图片文件需要设置VLM模型")
-		}
-
-		logger.Info(ctx, "Image multimodal configuration validation passed")
+	if err := checkImageFileConfig(ctx, kb, fileName); err != nil {
+		return nil, err
 	}
 
 	// Validate file type
@@ -203,6 +214,19 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		return nil, ErrInvalidFileType
 	}
 
+	// Archives are only accepted as LaTeX projects
+	if strings.EqualFold(getFileType(fileName), "zip") {
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = checkLatexArchive(f, file.Size)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Calculate file hash for deduplication
 	logger.Info(ctx, "Calculating file hash")
 	hash, err := calculateFileHash(file)
@@ -234,11 +258,9 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		return existingKnowledge, types.NewDuplicateFileError(existingKnowledge)
 	}
 
-	// Check storage quota
-	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
-	if tenantInfo.StorageQuota > 0 && tenantInfo.StorageUsed >= tenantInfo.StorageQuota {
-		logger.Error(ctx, "Storage quota exceeded")
-		return nil, types.NewStorageQuotaExceededError()
+	// Check storage, document and parse job quotas
+	if err := s.checkKnowledgeQuota(ctx, ""); err != nil {
+		return nil, err
 	}
 
 	// Convert metadata to JSON format if provided
@@ -278,6 +300,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		EmbeddingModelID: kb.EmbeddingModelID,
 		Metadata:         metadataJSON,
 	}
+	s.detectUploadDuplicate(ctx, knowledge, file)
 	// Save knowledge record to database
 	logger.Info(ctx, "Saving knowledge record to database")
 	if err := s.repo.CreateKnowledge(ctx, knowledge); err != nil {
@@ -299,6 +322,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		logger.Errorf(ctx, "Failed to update knowledge with file path, ID: %s, error: %v", knowledge.ID, err)
 		return nil, err
 	}
+	s.webhookService.Publish(ctx, tenantID, types.WebhookEventKnowledgeCreated, types.NewWebhookKnowledgeData(knowledge))
 
 	// Enqueue document processing task to Asynq
 	logger.Info(ctx, "Enqueuing document processing task to Asynq")
@@ -329,6 +353,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		EnableMultimodel:         enableMultimodelValue,
 		EnableQuestionGeneration: enableQuestionGeneration,
 		QuestionCount:            questionCount,
+		TraceContext:             tracing.Inject(ctx),
 	}
 
 	payloadBytes, err := json.Marshal(taskPayload)
@@ -338,7 +363,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		return knowledge, nil
 	}
 
-	task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"))
+	task := newDocumentProcessTask(payloadBytes)
 	info, err := s.task.Enqueue(task)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to enqueue document process task: %v", err)
@@ -361,10 +386,45 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 	return knowledge, nil
 }
 
+// checkImageFileConfig checks that a knowledge base has the storage and VLM model an image file needs, other
+// files need neither
+func checkImageFileConfig(ctx context.Context, kb *types.KnowledgeBase, fileName string) error {
+	// 检查是否为图片文件
+	if !IsImageType(getFileType(fileName)) {
+		logger.Info(ctx, "Non-image file with multimodal enabled, skipping COS/VLM validation")
+	} else {
+		// 检查COS配置
+		switch kb.StorageConfig.Provider {
+		case "cos":
+			if kb.StorageConfig.SecretID == "" || kb.StorageConfig.SecretKey == "" ||
+				kb.StorageConfig.Region == "" || kb.StorageConfig.BucketName == "" ||
+				kb.StorageConfig.AppID == "" {
+				logger.Error(ctx, "COS configuration incomplete for image multimodal processing")
+				return werrors.NewBadRequestError("上传图片文件需要完整的对象存储配置信息, 请前往系统设置页面进行补全")
+			}
+		case "minio":
+			if kb.StorageConfig.BucketName == "" {
+				logger.Error(ctx, "MinIO configuration incomplete for image multimodal processing")
+				return werrors.NewBadRequestError("上传图片文件需要完整的对象存储配置信息, 请前往系统设置页面进行补全")
+			}
+		}
+
+		// 检查VLM配置
+		if !kb.VLMConfig.Enabled || kb.VLMConfig.ModelID == "" {
+			logger.Error(ctx, "VLM model is not configured")
+			return werrors.NewBadRequestError("上传图片文件需要设置VLM模型")
+		}
+
+		logger.Info(ctx, "Image multimodal configuration validation passed")
+	}
+	return nil
+}
+
 // CreateKnowledgeFromURL creates a knowledge entry from a URL source
 // tagID is optional - when provided, the knowledge will be assigned to the specified tag/category.
+// extractionMode is optional - when provided, it overrides the web extraction mode of the knowledge base.
 func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
-	kbID string, url string, enableMultimodel *bool, title string, tagID string,
+	kbID string, url string, enableMultimodel *bool, title string, tagID string, extractionMode string,
 ) (*types.Knowledge, error) {
 	logger.Info(ctx, "Start creating knowledge from URL")
 	logger.Infof(ctx, "Knowledge base ID: %s, URL: %s", kbID, url)
@@ -415,11 +475,9 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		return existingKnowledge, types.NewDuplicateURLError(existingKnowledge)
 	}
 
-	// Check storage quota
-	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
-	if tenantInfo.StorageQuota > 0 && tenantInfo.StorageUsed >= tenantInfo.StorageQuota {
-		logger.Error(ctx, "Storage quota exceeded")
-		return nil, types.NewStorageQuotaExceededError()
+	// Check storage, document, parse job and browser session quotas
+	if err := s.checkKnowledgeQuota(ctx, types.KnowledgeTypeURL); err != nil {
+		return nil, err
 	}
 
 	// Create knowledge record
@@ -446,6 +504,7 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		logger.Errorf(ctx, "Failed to create knowledge record: %v", err)
 		return nil, err
 	}
+	s.webhookService.Publish(ctx, tenantID, types.WebhookEventKnowledgeCreated, types.NewWebhookKnowledgeData(knowledge))
 
 	// Enqueue URL processing task to Asynq
 	logger.Info(ctx, "Enqueuing URL processing task to Asynq")
@@ -474,6 +533,8 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		EnableMultimodel:         enableMultimodelValue,
 		EnableQuestionGeneration: enableQuestionGeneration,
 		QuestionCount:            questionCount,
+		ExtractionMode:           extractionMode,
+		TraceContext:             tracing.Inject(ctx),
 	}
 
 	payloadBytes, err := json.Marshal(taskPayload)
@@ -482,7 +543,7 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		return knowledge, nil
 	}
 
-	task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"))
+	task := newDocumentProcessTask(payloadBytes)
 	info, err := s.task.Enqueue(task)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to enqueue URL process task: %v", err)
@@ -545,6 +606,15 @@ func (s *knowledgeService) CreateKnowledgeFromManual(ctx context.Context,
 		return nil, err
 	}
 
+	if err := s.quotaService.CheckKnowledgeQuota(ctx); err != nil {
+		return nil, err
+	}
+	if status == types.ManualKnowledgeStatusPublish {
+		if err := s.quotaService.CheckParseJobQuota(ctx, types.KnowledgeTypeManual); err != nil {
+			return nil, err
+		}
+	}
+
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	now := time.Now()
 	title := safeTitle
@@ -580,11 +650,13 @@ func (s *knowledgeService) CreateKnowledgeFromManual(ctx context.Context,
 	if status == types.ManualKnowledgeStatusPublish {
 		knowledge.ParseStatus = "pending"
 	}
+	s.detectDuplicateKnowledge(ctx, knowledge, cleanContent)
 
 	if err := s.repo.CreateKnowledge(ctx, knowledge); err != nil {
 		logger.Errorf(ctx, "Failed to create manual knowledge record: %v", err)
 		return nil, err
 	}
+	s.webhookService.Publish(ctx, tenantID, types.WebhookEventKnowledgeCreated, types.NewWebhookKnowledgeData(knowledge))
 
 	if status == types.ManualKnowledgeStatusPublish {
 		logger.Infof(ctx, "Manual knowledge created, scheduling indexing, ID: %s", knowledge.ID)
@@ -649,11 +721,14 @@ func (s *knowledgeService) createKnowledgeFromPassageInternal(ctx context.Contex
 		logger.Errorf(ctx, "Failed to create knowledge record: %v", err)
 		return nil, err
 	}
+	s.webhookService.Publish(ctx, knowledge.TenantID,
+		types.WebhookEventKnowledgeCreated, types.NewWebhookKnowledgeData(knowledge))
 
 	// Process passages
 	if syncMode {
 		logger.Info(ctx, "Processing passage synchronously")
 		s.processDocumentFromPassage(ctx, kb, knowledge, safePassages)
+		s.publishParseOutcome(ctx, knowledge)
 		logger.Infof(ctx, "Knowledge from passage created successfully (sync), ID: %s", knowledge.ID)
 	} else {
 		// Enqueue passage processing task to Asynq
@@ -678,6 +753,7 @@ func (s *knowledgeService) createKnowledgeFromPassageInternal(ctx context.Contex
 			EnableMultimodel:         false, // 文本段落不支持多模态
 			EnableQuestionGeneration: enableQuestionGeneration,
 			QuestionCount:            questionCount,
+			TraceContext:             tracing.Inject(ctx),
 		}
 
 		payloadBytes, err := json.Marshal(taskPayload)
@@ -687,7 +763,7 @@ func (s *knowledgeService) createKnowledgeFromPassageInternal(ctx context.Contex
 			return knowledge, nil
 		}
 
-		task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"))
+		task := newDocumentProcessTask(payloadBytes)
 		info, err := s.task.Enqueue(task)
 		if err != nil {
 			logger.Errorf(ctx, "Failed to enqueue passage process task: %v", err)
@@ -732,15 +808,40 @@ func (s *knowledgeService) ListKnowledgeByKnowledgeBaseID(ctx context.Context,
 func (s *knowledgeService) ListPagedKnowledgeByKnowledgeBaseID(ctx context.Context,
 	kbID string, page *types.Pagination, tagID string, keyword string, fileType string,
 ) (*types.PageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	knowledges, total, err := s.repo.ListPagedKnowledgeByKnowledgeBaseID(ctx,
-		ctx.Value(types.TenantIDContextKey).(uint64), kbID, page, tagID, keyword, fileType)
+		tenantID, kbID, page, tagID, keyword, fileType)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.fillKnowledgeTagIDs(ctx, tenantID, knowledges); err != nil {
+		logger.Warnf(ctx, "Failed to load tags for knowledge list of KB %s: %v", kbID, err)
+	}
+
 	return types.NewPageResult(total, page, knowledges), nil
 }
 
+// ListKnowledgePageByKnowledgeBaseID returns a page of the knowledge in a knowledge base by page number or cursor
+func (s *knowledgeService) ListKnowledgePageByKnowledgeBaseID(ctx context.Context,
+	kbID string, query *types.ListQuery, tagID string, keyword string, fileType string,
+) (*types.CursorPageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledges, total, err := s.repo.ListKnowledgePageByKnowledgeBaseID(ctx,
+		tenantID, kbID, query, tagID, keyword, fileType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := types.NewCursorPageResult(knowledges, total, query)
+	if page, ok := result.Data.([]*types.Knowledge); ok {
+		if err := s.fillKnowledgeTagIDs(ctx, tenantID, page); err != nil {
+			logger.Warnf(ctx, "Failed to load tags for knowledge list of KB %s: %v", kbID, err)
+		}
+	}
+	return result, nil
+}
+
 // DeleteKnowledge deletes a knowledge entry and all related resources
 func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error {
 	// Get the knowledge entry
@@ -782,6 +883,7 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 			logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge delete knowledge embedding failed")
 			return err
 		}
+		s.deleteImageEmbeddings(ctx, retrieveEngine, []*types.Knowledge{knowledge})
 		return nil
 	})
 
@@ -800,6 +902,7 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 			if err := s.fileSvc.DeleteFile(ctx, knowledge.FilePath); err != nil {
 				logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge delete file failed")
 			}
+			s.fileCache.invalidate(ctx, knowledge.ID)
 		}
 		tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 		tenantInfo.StorageUsed -= knowledge.StorageSize
@@ -822,6 +925,7 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 	if err = wg.Wait(); err != nil {
 		return err
 	}
+	s.invalidateSemanticCache(ctx, []*types.Knowledge{knowledge})
 	// Delete the knowledge entry itself from the database
 	return s.repo.DeleteKnowledge(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
 }
@@ -885,6 +989,7 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 				return err
 			}
 		}
+		s.deleteImageEmbeddings(ctx, retrieveEngine, knowledgeList)
 		return nil
 	})
 
@@ -908,6 +1013,7 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 			}
 			storageAdjust -= knowledge.StorageSize
 		}
+		s.fileCache.invalidate(ctx, ids...)
 		tenantInfo.StorageUsed += storageAdjust
 		if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, storageAdjust); err != nil {
 			logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge update tenant storage used failed")
@@ -934,6 +1040,7 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 	if err = wg.Wait(); err != nil {
 		return err
 	}
+	s.invalidateSemanticCache(ctx, knowledgeList)
 	// 5. Delete the knowledge entry itself from the database
 	return s.repo.DeleteKnowledgeList(ctx, tenantInfo.ID, ids)
 }
@@ -963,9 +1070,12 @@ func (s *knowledgeService) cloneKnowledge(
 		FileType:         src.FileType,
 		FileSize:         src.FileSize,
 		FileHash:         src.FileHash,
+		ContentHash:      src.ContentHash,
+		SimHash:          src.SimHash,
 		FilePath:         src.FilePath,
 		StorageSize:      src.StorageSize,
 		Metadata:         src.Metadata,
+		Capture:          src.Capture,
 	}
 	defer func() {
 		if err != nil {
@@ -1040,7 +1150,7 @@ type ProcessChunksOptions struct {
 func (s *knowledgeService) processChunks(ctx context.Context,
 	kb *types.KnowledgeBase, knowledge *types.Knowledge, chunks []*proto.Chunk,
 	opts ...ProcessChunksOptions,
-) {
+) error {
 	// Get options
 	var options ProcessChunksOptions
 	if len(opts) > 0 {
@@ -1049,6 +1159,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 
 	ctx, span := tracing.ContextWithSpan(ctx, "knowledgeService.processChunks")
 	defer span.End()
+	chunkStart := time.Now()
 	span.SetAttributes(
 		attribute.Int("tenant_id", int(knowledge.TenantID)),
 		attribute.String("knowledge_base_id", knowledge.KnowledgeBaseID),
@@ -1061,7 +1172,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	if s.isKnowledgeDeleting(ctx, knowledge.TenantID, knowledge.ID) {
 		logger.Infof(ctx, "Knowledge is being deleted, aborting chunk processing: %s", knowledge.ID)
 		span.AddEvent("aborted: knowledge is being deleted")
-		return
+		return nil
 	}
 
 	// Get embedding model for vectorization
@@ -1069,7 +1180,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	if err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks get embedding model failed")
 		span.RecordError(err)
-		return
+		return err
 	}
 
 	// 幂等性处理：清理旧的chunks和索引数据，避免重复数据
@@ -1189,9 +1300,42 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			EndAt:           int(chunkData.End),
 			ChunkType:       types.ChunkTypeText,
 		}
+		if chunkData.Metadata != "" {
+			var meta types.DocumentChunkMetadata
+			if err := json.Unmarshal([]byte(chunkData.Metadata), &meta); err != nil {
+				logger.GetLogger(ctx).WithField("error", err).Warnf("Failed to parse metadata of chunk #%d", chunkData.Seq)
+			} else if err := textChunk.SetDocumentMetadata(&meta); err != nil {
+				logger.GetLogger(ctx).WithField("error", err).Warnf("Failed to set metadata of chunk #%d", chunkData.Seq)
+			}
+		}
 		var chunkImages []types.ImageInfo
 		insertChunks = append(insertChunks, textChunk)
 
+		// 抽取 Markdown 表格为结构化表格 Chunk（原文仍保留在文本 Chunk 中）
+		for i, table := range types.ParseMarkdownTables(chunkData.Content) {
+			tableChunk := &types.Chunk{
+				ID:              uuid.New().String(),
+				TenantID:        knowledge.TenantID,
+				KnowledgeID:     knowledge.ID,
+				KnowledgeBaseID: knowledge.KnowledgeBaseID,
+				Content:         table.Markdown(),
+				ChunkIndex:      maxSeq + i*100 + 3, // 使用不冲突的索引方式
+				IsEnabled:       true,
+				CreatedAt:       time.Now(),
+				UpdatedAt:       time.Now(),
+				StartAt:         int(chunkData.Start),
+				EndAt:           int(chunkData.End),
+				ChunkType:       types.ChunkTypeTable,
+				ParentChunkID:   textChunk.ID,
+			}
+			if err := tableChunk.SetTableMetadata(&types.TableChunkMetadata{Table: table}); err != nil {
+				logger.GetLogger(ctx).WithField("error", err).Errorf("Failed to set table metadata")
+				continue
+			}
+			insertChunks = append(insertChunks, tableChunk)
+			logger.GetLogger(ctx).Infof("Created table chunk %d (%d rows) in chunk #%d", i, len(table.Rows), chunkData.Seq)
+		}
+
 		// 处理图片信息
 		if len(chunkData.Images) > 0 {
 			logger.GetLogger(ctx).Infof("Processing %d images in chunk #%d", len(chunkData.Images), chunkData.Seq)
@@ -1204,6 +1348,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 					StartPos:    int(img.Start),
 					EndPos:      int(img.End),
 					OCRText:     img.OcrText,
+					OCREngine:   img.OcrEngine,
 					Caption:     img.Caption,
 				}
 				chunkImages = append(chunkImages, imageInfo)
@@ -1320,7 +1465,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			knowledge.UpdatedAt = time.Now()
 			s.repo.UpdateKnowledge(ctx, knowledge)
 			span.RecordError(err)
-			return
+			return err
 		}
 		// Check if there's enough storage quota available
 		if tenantInfo.StorageUsed+totalStorageSize > tenantInfo.StorageQuota {
@@ -1329,7 +1474,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			knowledge.UpdatedAt = time.Now()
 			s.repo.UpdateKnowledge(ctx, knowledge)
 			span.RecordError(errors.New("storage quota exceeded"))
-			return
+			return nil
 		}
 	}
 
@@ -1337,20 +1482,24 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	if s.isKnowledgeDeleting(ctx, knowledge.TenantID, knowledge.ID) {
 		logger.Infof(ctx, "Knowledge is being deleted, aborting before saving chunks: %s", knowledge.ID)
 		span.AddEvent("aborted: knowledge is being deleted before saving")
-		return
+		return nil
 	}
 
 	// Save chunks to database
 	span.AddEvent("create chunks")
-	if err := s.chunkService.CreateChunks(ctx, insertChunks); err != nil {
+	err = s.chunkService.CreateChunks(ctx, insertChunks)
+	metrics.ObserveParseStage(metrics.StageChunk, chunkStart, err)
+	if err != nil {
 		knowledge.ParseStatus = types.ParseStatusFailed
 		knowledge.ErrorMessage = err.Error()
 		knowledge.UpdatedAt = time.Now()
 		s.repo.UpdateKnowledge(ctx, knowledge)
 		span.RecordError(err)
-		return
+		return err
 	}
 
+	s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageChunked, len(insertChunks), len(insertChunks), "")
+
 	// Check again before batch indexing (this is a heavy operation)
 	if s.isKnowledgeDeleting(ctx, knowledge.TenantID, knowledge.ID) {
 		logger.Infof(ctx, "Knowledge is being deleted, cleaning up and aborting before indexing: %s", knowledge.ID)
@@ -1359,11 +1508,22 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			logger.Warnf(ctx, "Failed to cleanup chunks after deletion detected: %v", err)
 		}
 		span.AddEvent("aborted: knowledge is being deleted before indexing")
-		return
+		return nil
 	}
 
 	span.AddEvent("batch index")
-	err = retrieveEngine.BatchIndex(ctx, embeddingModel, indexInfoList)
+	// Index in batches to report embedding progress
+	embedded := 0
+	embedStart := time.Now()
+	s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageEmbedding, 0, len(indexInfoList), "")
+	for batch := range slices.Chunk(indexInfoList, embeddingProgressBatchSize) {
+		if err = retrieveEngine.BatchIndex(ctx, embeddingModel, batch); err != nil {
+			break
+		}
+		embedded += len(batch)
+		s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageEmbedding, embedded, len(indexInfoList), "")
+	}
+	metrics.ObserveParseStage(metrics.StageEmbed, embedStart, err)
 	if err != nil {
 		knowledge.ParseStatus = types.ParseStatusFailed
 		knowledge.ErrorMessage = err.Error()
@@ -1382,7 +1542,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			logger.Errorf(ctx, "Delete index failed: %v", err)
 		}
 		span.RecordError(err)
-		return
+		return err
 	}
 	logger.GetLogger(ctx).Infof("processChunks batch index successfully, with %d index", len(indexInfoList))
 
@@ -1408,7 +1568,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			logger.Warnf(ctx, "Failed to cleanup index after deletion detected: %v", err)
 		}
 		span.AddEvent("aborted: knowledge was deleted during processing")
-		return
+		return nil
 	}
 
 	// Update knowledge status to completed
@@ -1426,9 +1586,22 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		knowledge.SummaryStatus = types.SummaryStatusNone
 	}
 
+	// Fingerprint the extracted text to flag duplicates of existing knowledge
+	textParts := make([]string, 0, len(textChunks))
+	for _, chunk := range textChunks {
+		textParts = append(textParts, chunk.Content)
+	}
+	s.detectDuplicateKnowledge(ctx, knowledge, strings.Join(textParts, "\n"))
+	if knowledge.Capture != nil {
+		knowledge.Capture.ContentHash = knowledge.ContentHash
+	}
+	completedMessage := knowledge.DuplicateWarning()
+
 	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks update knowledge failed")
 	}
+	s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageCompleted,
+		len(indexInfoList), len(indexInfoList), completedMessage)
 
 	// Enqueue question generation task if enabled (async, non-blocking)
 	if options.EnableQuestionGeneration && len(textChunks) > 0 {
@@ -1453,6 +1626,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks update tenant storage used failed")
 	}
 	logger.GetLogger(ctx).Infof("processChunks successfully")
+	return nil
 }
 
 // GetSummary generates a summary for knowledge content using an AI model
@@ -1540,7 +1714,7 @@ func (s *knowledgeService) getSummary(ctx context.Context,
 	summary, err := summaryModel.Chat(ctx, []chat.Message{
 		{
 			Role:    "system",
-			Content: s.config.Conversation.GenerateSummaryPrompt,
+			Content: s.config.Current().Conversation.GenerateSummaryPrompt,
 		},
 		{
 			Role:    "user",
@@ -1877,6 +2051,8 @@ func (s *knowledgeService) ProcessQuestionGeneration(ctx context.Context, t *asy
 	if questionCount > 10 {
 		questionCount = 10
 	}
+	// Q&A pairs store a grounded answer next to each question for FAQ-style retrieval
+	generateAnswers := kb.QuestionGenerationConfig != nil && kb.QuestionGenerationConfig.GenerateAnswers
 
 	// Generate questions for each chunk with context
 	var indexInfoList []*types.IndexInfo
@@ -1898,28 +2074,39 @@ func (s *knowledgeService) ProcessQuestionGeneration(ctx context.Context, t *asy
 			}
 		}
 
-		questions, err := s.generateQuestionsWithContext(ctx, chatModel, chunk.Content, prevContent, nextContent, knowledge.Title, questionCount)
+		var generatedQuestions []types.GeneratedQuestion
+		if generateAnswers {
+			generatedQuestions, err = s.generateQAPairsWithContext(
+				ctx, chatModel, chunk.Content, prevContent, nextContent, knowledge.Title, questionCount,
+			)
+		} else {
+			var questions []string
+			questions, err = s.generateQuestionsWithContext(
+				ctx, chatModel, chunk.Content, prevContent, nextContent, knowledge.Title, questionCount,
+			)
+			for _, question := range questions {
+				generatedQuestions = append(generatedQuestions, types.GeneratedQuestion{Question: question})
+			}
+		}
 		if err != nil {
 			logger.Warnf(ctx, "Failed to generate questions for chunk %s: %v", chunk.ID, err)
 			continue
 		}
 
-		if len(questions) == 0 {
+		if len(generatedQuestions) == 0 {
 			continue
 		}
 
 		// Update chunk metadata with unique IDs for each question
-		generatedQuestions := make([]types.GeneratedQuestion, len(questions))
-		for j, question := range questions {
-			questionID := fmt.Sprintf("q%d", time.Now().UnixNano()+int64(j))
-			generatedQuestions[j] = types.GeneratedQuestion{
-				ID:       questionID,
-				Question: question,
-			}
+		for j := range generatedQuestions {
+			generatedQuestions[j].ID = fmt.Sprintf("q%d", time.Now().UnixNano()+int64(j))
 		}
-		meta := &types.DocumentChunkMetadata{
-			GeneratedQuestions: generatedQuestions,
+		// Keep metadata recorded at parse time, e.g. notebook cells
+		meta, _ := chunk.DocumentMetadata()
+		if meta == nil {
+			meta = &types.DocumentChunkMetadata{}
 		}
+		meta.GeneratedQuestions = generatedQuestions
 		if err := chunk.SetDocumentMetadata(meta); err != nil {
 			logger.Warnf(ctx, "Failed to set document metadata for chunk %s: %v", chunk.ID, err)
 			continue
@@ -1943,7 +2130,7 @@ func (s *knowledgeService) ProcessQuestionGeneration(ctx context.Context, t *asy
 				KnowledgeBaseID: knowledge.KnowledgeBaseID,
 			})
 		}
-		logger.Debugf(ctx, "Generated %d questions for chunk %s", len(questions), chunk.ID)
+		logger.Debugf(ctx, "Generated %d questions for chunk %s", len(generatedQuestions), chunk.ID)
 	}
 
 	// Index generated questions
@@ -1967,7 +2154,7 @@ func (s *knowledgeService) generateQuestionsWithContext(ctx context.Context,
 	}
 
 	// Build prompt with context
-	prompt := s.config.Conversation.GenerateQuestionsPrompt
+	prompt := s.config.Current().Conversation.GenerateQuestionsPrompt
 	if prompt == "" {
 		prompt = defaultQuestionGenerationPrompt
 	}
@@ -2027,6 +2214,91 @@ func (s *knowledgeService) generateQuestionsWithContext(ctx context.Context,
 	return questions, nil
 }
 
+// generateQAPairsWithContext generates question/answer pairs for a chunk with surrounding context.
+// Answers must be grounded in the chunk content; pairs with an empty answer are dropped.
+func (s *knowledgeService) generateQAPairsWithContext(ctx context.Context,
+	chatModel chat.Chat, content, prevContent, nextContent, docName string, pairCount int,
+) ([]types.GeneratedQuestion, error) {
+	if content == "" || pairCount <= 0 {
+		return nil, nil
+	}
+
+	prompt := s.config.Current().Conversation.GenerateQAPairsPrompt
+	if prompt == "" {
+		prompt = defaultQAPairGenerationPrompt
+	}
+
+	var contextSection string
+	if prevContent != "" || nextContent != "" {
+		contextSection = "## 上下文信息（仅供参考，帮助理解主要内容）\n"
+		if prevContent != "" {
+			contextSection += fmt.Sprintf("【前文】%s\n", prevContent)
+		}
+		if nextContent != "" {
+			contextSection += fmt.Sprintf("【后文】%s\n", nextContent)
+		}
+		contextSection += "\n"
+	}
+
+	prompt = strings.ReplaceAll(prompt, "{{question_count}}", fmt.Sprintf("%d", pairCount))
+	prompt = strings.ReplaceAll(prompt, "{{content}}", content)
+	prompt = strings.ReplaceAll(prompt, "{{context}}", contextSection)
+	prompt = strings.ReplaceAll(prompt, "{{doc_name}}", docName)
+
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}, &chat.ChatOptions{
+		Temperature: 0.3,
+		MaxTokens:   2048,
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate qa pairs: %w", err)
+	}
+
+	var pairs []types.GeneratedQuestion
+	if err := common.ParseLLMJsonResponse(response.Content, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to parse qa pairs: %w", err)
+	}
+	result := make([]types.GeneratedQuestion, 0, min(len(pairs), pairCount))
+	for _, pair := range pairs {
+		pair.Question = strings.TrimSpace(pair.Question)
+		pair.Answer = strings.TrimSpace(pair.Answer)
+		if pair.Question == "" || pair.Answer == "" {
+			continue
+		}
+		result = append(result, types.GeneratedQuestion{Question: pair.Question, Answer: pair.Answer})
+		if len(result) >= pairCount {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Default prompt for question/answer pair generation with context support
+const defaultQAPairGenerationPrompt = `你是一个专业的问答对生成助手。你的任务是根据给定的【主要内容】生成用户可能会问的问题，并给出基于内容的答案。
+
+{{context}}
+## 主要内容（请基于此内容生成问答对）
+文档名称：{{doc_name}}
+文档内容：
+{{content}}
+
+## 核心要求
+- 问题必须与【主要内容】直接相关，禁止使用代词或指代词（如"它"、"这个"、"该文档"、"本文"等），必须用具体名称替代
+- 问题必须完整独立，脱离上下文也能被理解，长度控制在30字以内
+- 答案必须完全依据【主要内容】，不得编造内容中没有的信息，长度控制在200字以内
+- 问题应多样化，覆盖内容的不同方面
+- 生成的问答对数量为 {{question_count}} 个
+
+## 输出格式
+仅输出 JSON 数组，不要输出其他内容：
+[{"question": "问题", "answer": "答案"}]`
+
 // Default prompt for question generation with context support
 const defaultQuestionGenerationPrompt = `你是一个专业的问题生成助手。你的任务是根据给定的【主要内容】生成用户可能会问的相关问题。
 
@@ -2064,8 +2336,10 @@ func (s *knowledgeService) GetKnowledgeFile(ctx context.Context, id string) (io.
 		return nil, "", err
 	}
 
-	// Get the file from storage
-	file, err := s.fileSvc.GetFile(ctx, knowledge.FilePath)
+	// Get the file from the cache, or from storage on a miss
+	file, err := s.fileCache.open(ctx, knowledge, func() (io.ReadCloser, error) {
+		return s.fileSvc.GetFile(ctx, knowledge.FilePath)
+	})
 	if err != nil {
 		return nil, "", err
 	}
@@ -2218,6 +2492,19 @@ func (s *knowledgeService) ReparseKnowledge(ctx context.Context, knowledgeID str
 		return nil, err
 	}
 
+	if err := s.quotaService.CheckParseJobQuota(ctx, existing.Type); err != nil {
+		return nil, err
+	}
+	return s.reparseKnowledge(ctx, existing, kb)
+}
+
+// reparseKnowledge cleans up the content of existing and schedules parsing it again in kb,
+// callers check the parse job quota first
+func (s *knowledgeService) reparseKnowledge(ctx context.Context,
+	existing *types.Knowledge, kb *types.KnowledgeBase,
+) (*types.Knowledge, error) {
+	knowledgeID := existing.ID
+
 	// Step 1: Clean up existing resources (chunks, embeddings, graph data)
 	logger.Infof(ctx, "Cleaning up existing resources for knowledge: %s", knowledgeID)
 	if err := s.cleanupKnowledgeResources(ctx, existing); err != nil {
@@ -2280,6 +2567,7 @@ func (s *knowledgeService) ReparseKnowledge(ctx context.Context, knowledgeID str
 			EnableMultimodel:         enableMultimodel,
 			EnableQuestionGeneration: enableQuestionGeneration,
 			QuestionCount:            questionCount,
+			TraceContext:             tracing.Inject(ctx),
 		}
 
 		payloadBytes, err := json.Marshal(taskPayload)
@@ -2288,7 +2576,7 @@ func (s *knowledgeService) ReparseKnowledge(ctx context.Context, knowledgeID str
 			return existing, nil
 		}
 
-		task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"))
+		task := newDocumentProcessTask(payloadBytes)
 		info, err := s.task.Enqueue(task)
 		if err != nil {
 			logger.Errorf(ctx, "Failed to enqueue reparse task: %v", err)
@@ -2328,6 +2616,8 @@ func (s *knowledgeService) ReparseKnowledge(ctx context.Context, knowledgeID str
 			EnableMultimodel:         enableMultimodel,
 			EnableQuestionGeneration: enableQuestionGeneration,
 			QuestionCount:            questionCount,
+			ExtractionMode:           previousExtractionMode(existing),
+			TraceContext:             tracing.Inject(ctx),
 		}
 
 		payloadBytes, err := json.Marshal(taskPayload)
@@ -2336,7 +2626,7 @@ func (s *knowledgeService) ReparseKnowledge(ctx context.Context, knowledgeID str
 			return existing, nil
 		}
 
-		task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes, asynq.Queue("default"))
+		task := newDocumentProcessTask(payloadBytes)
 		info, err := s.task.Enqueue(task)
 		if err != nil {
 			logger.Errorf(ctx, "Failed to enqueue URL reparse task: %v", err)
@@ -2353,11 +2643,12 @@ func (s *knowledgeService) ReparseKnowledge(ctx context.Context, knowledgeID str
 
 // isValidFileType checks if a file type is supported
 func isValidFileType(filename string) bool {
-	switch strings.ToLower(getFileType(filename)) {
-	case "pdf", "txt", "docx", "doc", "md", "markdown", "png", "jpg", "jpeg", "gif", "csv", "xlsx", "xls":
+	fileType := strings.ToLower(getFileType(filename))
+	switch fileType {
+	case "pdf", "txt", "docx", "doc", "md", "markdown", "png", "jpg", "jpeg", "gif", "csv", "xlsx", "xls", "ipynb", "log", "enex", "mht", "mhtml", "tex", "latex", "zip":
 		return true
 	default:
-		return false
+		return types.IsCodeFileType(fileType) || types.IsParserPluginFileType(fileType)
 	}
 }
 
@@ -2423,8 +2714,17 @@ func (s *knowledgeService) GetKnowledgeBatchWithSharedAccess(ctx context.Context
 		if err != nil || k == nil || k.KnowledgeBaseID == "" {
 			continue
 		}
-		hasPermission, err := s.kbShareService.HasKBPermission(ctx, k.KnowledgeBaseID, userID, types.OrgRoleViewer)
-		if err != nil || !hasPermission {
+		// Shares and granted roles, including those from invite links, give access; hidden documents stay hidden
+		kb, err := s.kbService.GetKnowledgeBaseByIDOnly(ctx, k.KnowledgeBaseID)
+		if err != nil || kb == nil {
+			continue
+		}
+		kbRole, _, err := s.kbPermissionService.ResolveKBRole(ctx, kb, tenantID, userID)
+		if err != nil {
+			continue
+		}
+		role, err := s.kbPermissionService.ResolveKnowledgeRole(ctx, k, kbRole, userID)
+		if err != nil || !role.HasPermission(types.KBRoleViewer) {
 			continue
 		}
 		foundSet[k.ID] = true
@@ -2762,6 +3062,7 @@ func (s *knowledgeService) CloneChunk(ctx context.Context, src, dst *types.Knowl
 	chunkType := []types.ChunkType{
 		types.ChunkTypeText, types.ChunkTypeSummary,
 		types.ChunkTypeImageCaption, types.ChunkTypeImageOCR,
+		types.ChunkTypeTable,
 	}
 	for {
 		sourceChunks, _, err := s.chunkRepo.ListPagedChunksByKnowledgeID(ctx,
@@ -4784,8 +5085,16 @@ func (s *knowledgeService) UpdateKnowledgeTag(ctx context.Context, knowledgeID s
 		resolvedTagID = tag.ID
 	}
 
-	knowledge.TagID = resolvedTagID
-	return s.repo.UpdateKnowledge(ctx, knowledge)
+	existing, err := s.tagRepo.ListKnowledgeTagIDs(ctx, tenantID, []string{knowledge.ID})
+	if err != nil {
+		return err
+	}
+	tagIDs := replacePrimaryTag(existing[knowledge.ID], knowledge.TagID, resolvedTagID)
+	knowledge.TagID = firstTagID(tagIDs)
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return err
+	}
+	return s.tagRepo.SetKnowledgeTags(ctx, tenantID, knowledge.KnowledgeBaseID, knowledge.ID, tagIDs)
 }
 
 // UpdateKnowledgeTagBatch updates tags for document knowledge items in batch.
@@ -4836,8 +5145,14 @@ func (s *knowledgeService) UpdateKnowledgeTagBatch(ctx context.Context, updates
 		}
 	}
 
+	existingTags, err := s.tagRepo.ListKnowledgeTagIDs(ctx, tenantID, knowledgeIDs)
+	if err != nil {
+		return err
+	}
+
 	// Update knowledge items
 	knowledgeToUpdate := make([]*types.Knowledge, 0)
+	nextTags := make(map[string][]string, len(knowledgeList))
 	for _, knowledge := range knowledgeList {
 		tagID, exists := updates[knowledge.ID]
 		if !exists {
@@ -4856,14 +5171,222 @@ func (s *knowledgeService) UpdateKnowledgeTagBatch(ctx context.Context, updates
 			resolvedTagID = tag.ID
 		}
 
-		knowledge.TagID = resolvedTagID
+		nextTags[knowledge.ID] = replacePrimaryTag(existingTags[knowledge.ID], knowledge.TagID, resolvedTagID)
+		knowledge.TagID = firstTagID(nextTags[knowledge.ID])
 		knowledgeToUpdate = append(knowledgeToUpdate, knowledge)
 	}
 
 	if len(knowledgeToUpdate) > 0 {
-		return s.repo.UpdateKnowledgeBatch(ctx, knowledgeToUpdate)
+		if err := s.repo.UpdateKnowledgeBatch(ctx, knowledgeToUpdate); err != nil {
+			return err
+		}
+		for _, knowledge := range knowledgeToUpdate {
+			if err := s.tagRepo.SetKnowledgeTags(ctx, tenantID,
+				knowledge.KnowledgeBaseID, knowledge.ID, nextTags[knowledge.ID]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// replacePrimaryTag swaps the primary tag in the assigned tags of a knowledge entry and keeps the others.
+// The new primary tag comes first; when it is empty the next assigned tag becomes primary.
+func replacePrimaryTag(assigned []string, oldPrimary, newPrimary string) []string {
+	tagIDs := nonEmptyTagIDs(newPrimary)
+	for _, tagID := range assigned {
+		if tagID != oldPrimary && !slices.Contains(tagIDs, tagID) {
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+	return tagIDs
+}
+
+// firstTagID returns the primary tag of a tag list, or "" when it is empty.
+func firstTagID(tagIDs []string) string {
+	if len(tagIDs) == 0 {
+		return ""
+	}
+	return tagIDs[0]
+}
+
+// nonEmptyTagIDs returns a single-element tag list, or nil when tagID is empty.
+func nonEmptyTagIDs(tagID string) []string {
+	if tagID == "" {
+		return nil
 	}
+	return []string{tagID}
+}
 
+// fillKnowledgeTagIDs populates TagIDs of the given knowledge with all their assigned tags.
+func (s *knowledgeService) fillKnowledgeTagIDs(ctx context.Context, tenantID uint64, knowledges []*types.Knowledge) error {
+	if len(knowledges) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(knowledges))
+	for _, k := range knowledges {
+		ids = append(ids, k.ID)
+	}
+	tagMap, err := s.tagRepo.ListKnowledgeTagIDs(ctx, tenantID, ids)
+	if err != nil {
+		return err
+	}
+	for _, k := range knowledges {
+		k.TagIDs = tagMap[k.ID]
+		if k.TagID != "" && !slices.Contains(k.TagIDs, k.TagID) {
+			k.TagIDs = append([]string{k.TagID}, k.TagIDs...)
+		}
+	}
+	return nil
+}
+
+// SetKnowledgeTags replaces all tags of a knowledge entry. The first tag becomes the primary tag.
+func (s *knowledgeService) SetKnowledgeTags(ctx context.Context, knowledgeID string, tagIDs []string) (*types.Knowledge, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := s.resolveKnowledgeTags(ctx, tenantID, knowledge.KnowledgeBaseID, tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyKnowledgeTags(ctx, tenantID, knowledge, resolved); err != nil {
+		return nil, err
+	}
+	return knowledge, nil
+}
+
+// RetagKnowledgeBatch moves or retags multiple knowledge entries in one call.
+func (s *knowledgeService) RetagKnowledgeBatch(ctx context.Context, req *types.KnowledgeRetagRequest) error {
+	if req == nil || len(req.KnowledgeIDs) == 0 {
+		return werrors.NewBadRequestError("知识ID列表不能为空")
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = types.KnowledgeRetagModeReplace
+	}
+	if mode != types.KnowledgeRetagModeReplace && len(req.TagIDs) == 0 {
+		return werrors.NewBadRequestError("标签ID列表不能为空")
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+
+	knowledgeList, err := s.repo.GetKnowledgeBatch(ctx, tenantID, req.KnowledgeIDs)
+	if err != nil {
+		return err
+	}
+	if len(knowledgeList) == 0 {
+		return werrors.NewNotFoundError("知识不存在")
+	}
+	existing, err := s.tagRepo.ListKnowledgeTagIDs(ctx, tenantID, req.KnowledgeIDs)
+	if err != nil {
+		return err
+	}
+
+	// Tags are validated once per knowledge base
+	resolvedByKB := make(map[string][]string)
+	for _, knowledge := range knowledgeList {
+		resolved, ok := resolvedByKB[knowledge.KnowledgeBaseID]
+		if !ok {
+			resolved, err = s.resolveKnowledgeTags(ctx, tenantID, knowledge.KnowledgeBaseID, req.TagIDs)
+			if err != nil {
+				return err
+			}
+			resolvedByKB[knowledge.KnowledgeBaseID] = resolved
+		}
+
+		current := existing[knowledge.ID]
+		if knowledge.TagID != "" && !slices.Contains(current, knowledge.TagID) {
+			current = append([]string{knowledge.TagID}, current...)
+		}
+
+		var next []string
+		switch mode {
+		case types.KnowledgeRetagModeReplace:
+			next = resolved
+		case types.KnowledgeRetagModeAdd:
+			next = append([]string{}, current...)
+			for _, tagID := range resolved {
+				if !slices.Contains(next, tagID) {
+					next = append(next, tagID)
+				}
+			}
+		case types.KnowledgeRetagModeRemove:
+			next = make([]string, 0, len(current))
+			for _, tagID := range current {
+				if !slices.Contains(resolved, tagID) {
+					next = append(next, tagID)
+				}
+			}
+		default:
+			return werrors.NewBadRequestError(fmt.Sprintf("不支持的操作模式: %s", mode))
+		}
+
+		if err := s.applyKnowledgeTags(ctx, tenantID, knowledge, next); err != nil {
+			return err
+		}
+	}
+	logger.Infof(ctx, "Retagged %d knowledge entries, mode: %s, tags: %v", len(knowledgeList), mode, req.TagIDs)
+	return nil
+}
+
+// resolveKnowledgeTags validates that all tags belong to the knowledge base and removes duplicates.
+func (s *knowledgeService) resolveKnowledgeTags(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	tagIDs []string,
+) ([]string, error) {
+	if len(tagIDs) == 0 {
+		return []string{}, nil
+	}
+	tags, err := s.tagRepo.GetByIDs(ctx, tenantID, tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	tagMap := make(map[string]*types.KnowledgeTag, len(tags))
+	for _, tag := range tags {
+		tagMap[tag.ID] = tag
+	}
+	resolved := make([]string, 0, len(tagIDs))
+	for _, tagID := range tagIDs {
+		tag, ok := tagMap[tagID]
+		if !ok {
+			return nil, werrors.NewBadRequestError(fmt.Sprintf("标签 %s 不存在", tagID))
+		}
+		if tag.KnowledgeBaseID != kbID {
+			return nil, werrors.NewBadRequestError(fmt.Sprintf("标签 %s 不属于知识库 %s", tagID, kbID))
+		}
+		if !slices.Contains(resolved, tagID) {
+			resolved = append(resolved, tagID)
+		}
+	}
+	return resolved, nil
+}
+
+// applyKnowledgeTags persists the tag list of a knowledge entry, keeping the first tag as primary.
+func (s *knowledgeService) applyKnowledgeTags(
+	ctx context.Context,
+	tenantID uint64,
+	knowledge *types.Knowledge,
+	tagIDs []string,
+) error {
+	primary := ""
+	if len(tagIDs) > 0 {
+		primary = tagIDs[0]
+	}
+	if knowledge.TagID != primary {
+		knowledge.TagID = primary
+		knowledge.UpdatedAt = time.Now()
+		if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+			return err
+		}
+	}
+	if err := s.tagRepo.SetKnowledgeTags(ctx, tenantID, knowledge.KnowledgeBaseID, knowledge.ID, tagIDs); err != nil {
+		return err
+	}
+	knowledge.TagIDs = tagIDs
 	return nil
 }
 
@@ -6354,6 +6877,7 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 			knowledge.ErrorMessage = cfgErr.Error()
 			knowledge.UpdatedAt = time.Now()
 			s.repo.UpdateKnowledge(ctx, knowledge)
+			s.publishParseOutcome(ctx, knowledge)
 			return
 		}
 		if cfg == nil {
@@ -6385,6 +6909,7 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 				PathPrefix:      kb.StorageConfig.PathPrefix,
 			},
 			VlmConfig: vlmConfig,
+			OcrConfig: ocrProtoConfig(kb),
 		},
 		RequestId: ctx.Value(types.RequestIDContextKey).(string),
 	})
@@ -6395,16 +6920,36 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 		knowledge.ErrorMessage = err.Error()
 		knowledge.UpdatedAt = time.Now()
 		s.repo.UpdateKnowledge(ctx, knowledge)
+		s.publishParseOutcome(ctx, knowledge)
 		return
 	}
 
 	if sync {
 		s.processChunks(ctx, kb, knowledge, resp.Chunks)
+		s.publishParseOutcome(ctx, knowledge)
 		return
 	}
 
 	newCtx := logger.CloneContext(ctx)
-	go s.processChunks(newCtx, kb, knowledge, resp.Chunks)
+	go func() {
+		s.processChunks(newCtx, kb, knowledge, resp.Chunks)
+		s.publishParseOutcome(newCtx, knowledge)
+	}()
+}
+
+// publishParseOutcome notifies webhooks of the status parsing left a knowledge in. Web pages added by URL also
+// raise crawl.completed once fetched and indexed.
+func (s *knowledgeService) publishParseOutcome(ctx context.Context, knowledge *types.Knowledge) {
+	data := types.NewWebhookKnowledgeData(knowledge)
+	switch knowledge.ParseStatus {
+	case types.ParseStatusCompleted:
+		s.webhookService.Publish(ctx, knowledge.TenantID, types.WebhookEventParseCompleted, data)
+		if knowledge.Type == types.KnowledgeTypeURL {
+			s.webhookService.Publish(ctx, knowledge.TenantID, types.WebhookEventCrawlCompleted, data)
+		}
+	case types.ParseStatusFailed, types.ParseStatusFailedPermanent:
+		s.webhookService.Publish(ctx, knowledge.TenantID, types.WebhookEventParseFailed, data)
+	}
 }
 
 func (s *knowledgeService) cleanupKnowledgeResources(ctx context.Context, knowledge *types.Knowledge) error {
@@ -6417,6 +6962,9 @@ func (s *knowledgeService) cleanupKnowledgeResources(ctx context.Context, knowle
 		return nil
 	}
 
+	// Answers built from the old content must not be served after re-parsing
+	s.invalidateSemanticCache(ctx, []*types.Knowledge{knowledge})
+
 	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 	if knowledge.EmbeddingModelID != "" {
 		retrieveEngine, err := retriever.NewCompositeRetrieveEngine(
@@ -6477,6 +7025,8 @@ func (s *knowledgeService) getVLMProtoConfig(ctx context.Context, kb *types.Know
 			BaseUrl:       kb.VLMConfig.BaseURL,
 			ApiKey:        kb.VLMConfig.APIKey,
 			InterfaceType: kb.VLMConfig.InterfaceType,
+			Prompt:        kb.VLMConfig.Prompt,
+			MaxImages:     int32(kb.VLMConfig.MaxImagesPerDocument),
 		}, nil
 	}
 
@@ -6490,19 +7040,88 @@ func (s *knowledgeService) getVLMProtoConfig(ctx context.Context, kb *types.Know
 		return nil, err
 	}
 
-	interfaceType := model.Parameters.InterfaceType
-	if interfaceType == "" {
-		interfaceType = "openai"
-	}
-
 	return &proto.VLMConfig{
 		ModelName:     model.Name,
 		BaseUrl:       model.Parameters.BaseURL,
 		ApiKey:        model.Parameters.APIKey,
-		InterfaceType: interfaceType,
+		InterfaceType: docReaderInterfaceType(model),
+		Prompt:        kb.VLMConfig.Prompt,
+		MaxImages:     int32(kb.VLMConfig.MaxImagesPerDocument),
+	}, nil
+}
+
+// getLLMCleanupProtoConfig returns the summary model of a knowledge base, which cleans up web pages extracted in
+// llm mode; nil when the knowledge base has none
+func (s *knowledgeService) getLLMCleanupProtoConfig(ctx context.Context,
+	kb *types.KnowledgeBase,
+) (*proto.LLMConfig, error) {
+	if kb == nil || kb.SummaryModelID == "" {
+		return nil, nil
+	}
+	model, err := s.modelService.GetModelByID(ctx, kb.SummaryModelID)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.LLMConfig{
+		ModelName:     model.Name,
+		BaseUrl:       model.Parameters.BaseURL,
+		ApiKey:        model.Parameters.APIKey,
+		InterfaceType: docReaderInterfaceType(model),
 	}, nil
 }
 
+// docReaderInterfaceType returns the API DocReader calls a model with: ollama or openai
+func docReaderInterfaceType(model *types.Model) string {
+	interfaceType := model.Parameters.InterfaceType
+	if interfaceType == "" && model.Parameters.Provider == string(provider.ProviderOllama) {
+		interfaceType = "ollama"
+	}
+	if interfaceType == "" {
+		interfaceType = "openai"
+	}
+	return interfaceType
+}
+
+// ocrProtoConfig converts the OCR settings of a knowledge base, nil lets DocReader use its default engine
+func ocrProtoConfig(kb *types.KnowledgeBase) *proto.OCRConfig {
+	if kb == nil || kb.OCRConfig == nil {
+		return nil
+	}
+	return &proto.OCRConfig{
+		Engine:    kb.OCRConfig.Engine,
+		Languages: kb.OCRConfig.Languages,
+	}
+}
+
+// fetchProtoConfig converts the URL fetch settings of a knowledge base for fetching rawURL, nil lets DocReader use
+// its browser defaults. Headers and cookies are left out unless the config lists the host of the URL, DocReader
+// then sends them to the site of the URL only.
+func fetchProtoConfig(kb *types.KnowledgeBase, rawURL string) *proto.FetchConfig {
+	if kb == nil || kb.URLFetchConfig == nil {
+		return nil
+	}
+	config := &proto.FetchConfig{UserAgent: kb.URLFetchConfig.UserAgent}
+	if parsed, err := url.Parse(rawURL); err == nil && kb.URLFetchConfig.SendsTo(parsed.Hostname()) {
+		config.Headers = protoNameValues(kb.URLFetchConfig.Headers)
+		config.Cookies = protoNameValues(kb.URLFetchConfig.Cookies)
+	}
+	return config
+}
+
+// protoNameValues converts a map to name-value pairs sorted by name
+func protoNameValues(values map[string]string) []*proto.NameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]*proto.NameValue, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, &proto.NameValue{Name: name, Value: values[name]})
+	}
+	return pairs
+}
+
 func IsImageType(fileType string) bool {
 	switch fileType {
 	case "jpg", "jpeg", "png", "gif", "webp", "bmp", "svg", "tiff":
@@ -6521,13 +7140,27 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	}
 
 	ctx = logger.WithRequestID(ctx, payload.RequestId)
-	ctx = logger.WithField(ctx, "document_process", payload.KnowledgeID)
+	ctx = logger.WithField(ctx, "tenant_id", payload.TenantID)
+	ctx = logger.WithField(ctx, "knowledge_id", payload.KnowledgeID)
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	ctx = slowlog.WithKnowledgeBase(ctx, payload.KnowledgeBaseID)
+	if payload.RequestId != "" {
+		ctx = context.WithValue(ctx, types.RequestIDContextKey, payload.RequestId)
+	}
+
+	ctx, span := tracing.ContextWithSpan(ctx, "knowledgeService.ProcessDocument")
+	defer span.End()
+	span.SetAttributes(
+		attribute.Int("tenant_id", int(payload.TenantID)),
+		attribute.String("knowledge_base_id", payload.KnowledgeBaseID),
+		attribute.String("knowledge_id", payload.KnowledgeID),
+		attribute.String("file_type", payload.FileType),
+		attribute.Bool("url", payload.URL != ""),
+	)
 
 	// 获取任务重试信息，用于判断是否是最后一次重试
 	retryCount, _ := asynq.GetRetryCount(ctx)
 	maxRetry, _ := asynq.GetMaxRetry(ctx)
-	isLastRetry := retryCount >= maxRetry
 
 	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
 	if err != nil {
@@ -6539,6 +7172,19 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	logger.Infof(ctx, "Processing document task: knowledge_id=%s, file_path=%s, retry=%d/%d",
 		payload.KnowledgeID, payload.FilePath, retryCount, maxRetry)
 
+	// A knowledge is parsed by one task at a time across the replicas. A duplicate is retried later, when it finds
+	// the knowledge parsed; a task recovered from a replica that died waits for the claim of that replica to expire.
+	claim, err := s.replica.Acquire(ctx, cluster.DocumentProcessKey(payload.KnowledgeID))
+	if errors.Is(err, cluster.ErrClaimed) {
+		logger.Infof(ctx, "Knowledge %s is being parsed by another task, retrying later", payload.KnowledgeID)
+		return fmt.Errorf("knowledge %s is being parsed by another task", payload.KnowledgeID)
+	}
+	if err != nil {
+		logger.Warnf(ctx, "Failed to claim knowledge %s, parsing without a claim: %v", payload.KnowledgeID, err)
+	} else {
+		defer claim.Release()
+	}
+
 	// 幂等性检查：获取knowledge记录
 	knowledge, err := s.repo.GetKnowledgeByID(ctx, payload.TenantID, payload.KnowledgeID)
 	if err != nil {
@@ -6598,6 +7244,20 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		logger.Errorf(ctx, "failed to update knowledge status to processing: %v", err)
 		return nil
 	}
+	// The whole pipeline is measured, and webhooks notified, by the status it leaves the knowledge in, documents
+	// deleted midway are not
+	pipelineStart := time.Now()
+	defer func() {
+		switch knowledge.ParseStatus {
+		case types.ParseStatusCompleted:
+			metrics.ParseStageDuration.WithLabelValues(metrics.StageTotal, metrics.ResultSuccess).
+				Observe(time.Since(pipelineStart).Seconds())
+		case types.ParseStatusFailed, types.ParseStatusFailedPermanent:
+			metrics.ParseStageDuration.WithLabelValues(metrics.StageTotal, metrics.ResultError).
+				Observe(time.Since(pipelineStart).Seconds())
+		}
+		s.publishParseOutcome(ctx, knowledge)
+	}()
 
 	// 构建VLM配置（如果需要）
 	var vlmConfig *proto.VLMConfig
@@ -6626,7 +7286,31 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 
 	// 处理不同类型的导入：文件、URL、文本段落
 	var chunks []*proto.Chunk
-	if payload.URL != "" {
+	// 图片文件的原始内容，用于生成图像向量
+	var imageContent []byte
+	var recapture *knowledgeRecapture
+	if payload.RecapturePreviewID != "" {
+		recapture, err = s.loadKnowledgeRecapture(ctx, knowledge.ID, payload.RecapturePreviewID)
+		if err != nil {
+			s.markDocumentProcessFailed(ctx, knowledge, err, retryCount, maxRetry)
+			return err
+		}
+		if recapture == nil {
+			// Fetching the page again would index content nobody previewed
+			logger.Errorf(ctx, "Re-capture %s of knowledge %s is missing, not capturing the page again",
+				payload.RecapturePreviewID, knowledge.ID)
+			knowledge.ParseStatus = types.ParseStatusFailed
+			knowledge.ErrorMessage = errRecapturePreviewMissing.Error()
+			knowledge.UpdatedAt = time.Now()
+			s.repo.UpdateKnowledge(ctx, knowledge)
+			return nil
+		}
+	}
+	if recapture != nil {
+		// 确认的重新抓取：使用预览时抓取的内容，不再重新请求页面
+		chunks = recapture.Chunks
+		s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageParsed, len(chunks), len(chunks), "")
+	} else if payload.URL != "" {
 		// URL导入 - 再次进行 SSRF 验证（防止 DNS 重绑定攻击）
 		if safe, reason := secutils.IsSSRFSafeURL(payload.URL); !safe {
 			logger.Errorf(ctx, "URL rejected for SSRF protection in ProcessDocument: %s, reason: %s", payload.URL, reason)
@@ -6637,40 +7321,20 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 			return nil
 		}
 
-		urlResp, err := s.docReaderClient.ReadFromURL(ctx, &proto.ReadFromURLRequest{
-			Url:   payload.URL,
-			Title: knowledge.Title,
-			ReadConfig: &proto.ReadConfig{
-				ChunkSize:        int32(kb.ChunkingConfig.ChunkSize),
-				ChunkOverlap:     int32(kb.ChunkingConfig.ChunkOverlap),
-				Separators:       kb.ChunkingConfig.Separators,
-				EnableMultimodal: payload.EnableMultimodel,
-				StorageConfig: &proto.StorageConfig{
-					Provider: proto.StorageProvider(
-						proto.StorageProvider_value[strings.ToUpper(kb.StorageConfig.Provider)],
-					),
-					Region:          kb.StorageConfig.Region,
-					BucketName:      kb.StorageConfig.BucketName,
-					AccessKeyId:     kb.StorageConfig.SecretID,
-					SecretAccessKey: kb.StorageConfig.SecretKey,
-					AppId:           kb.StorageConfig.AppID,
-					PathPrefix:      kb.StorageConfig.PathPrefix,
-				},
-				VlmConfig: vlmConfig,
-			},
-			RequestId: payload.RequestId,
-		})
+		s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageParsing, 0, 0, "fetching and parsing URL")
+		parseStart := time.Now()
+		extractionMode := kb.WebExtractionMode(payload.ExtractionMode)
+		urlResp, err := s.readFromURL(ctx, kb, payload.URL, knowledge.Title, extractionMode,
+			payload.EnableMultimodel, vlmConfig, payload.RequestId)
+		metrics.ObserveParseStage(metrics.StageParse, parseStart, err)
 		if err != nil {
-			// 如果是最后一次重试，更新状态为失败
-			if isLastRetry {
-				knowledge.ParseStatus = "failed"
-				knowledge.ErrorMessage = err.Error()
-				knowledge.UpdatedAt = time.Now()
-				s.repo.UpdateKnowledge(ctx, knowledge)
-			}
+			s.markDocumentProcessFailed(ctx, knowledge, err, retryCount, maxRetry)
 			return fmt.Errorf("failed to read from URL: %w", err)
 		}
 		chunks = urlResp.Chunks
+		knowledge.Capture = types.NewKnowledgeCapture(payload.URL, types.KnowledgeCaptureMethodText, knowledge.CreatedBy)
+		knowledge.Capture.ExtractionMode = extractionMode
+		s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageParsed, len(chunks), len(chunks), "")
 	} else if len(payload.Passages) > 0 {
 		// 文本段落导入
 		chunks := make([]*proto.Chunk, 0, len(payload.Passages))
@@ -6690,39 +7354,39 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 			chunks = append(chunks, chunk)
 		}
 		// 直接处理chunks，不需要调用docReader
-		s.processChunks(ctx, kb, knowledge, chunks)
+		s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageParsed, len(chunks), len(chunks), "")
+		if err := s.processChunks(ctx, kb, knowledge, chunks); err != nil {
+			s.markDocumentProcessFailed(ctx, knowledge, err, retryCount, maxRetry)
+			return fmt.Errorf("failed to process chunks: %w", err)
+		}
 		return nil
 	} else {
 		// 文件导入
+		downloadStart := time.Now()
 		fileReader, err := s.fileSvc.GetFile(ctx, payload.FilePath)
 		if err != nil {
+			metrics.ObserveParseStage(metrics.StageDownload, downloadStart, err)
 			logger.GetLogger(ctx).WithField("knowledge_id", knowledge.ID).
 				WithField("error", err).Errorf("processDocument get file failed")
-			// 如果是最后一次重试，更新状态为失败
-			if isLastRetry {
-				knowledge.ParseStatus = "failed"
-				knowledge.ErrorMessage = err.Error()
-				knowledge.UpdatedAt = time.Now()
-				s.repo.UpdateKnowledge(ctx, knowledge)
-			}
+			s.markDocumentProcessFailed(ctx, knowledge, err, retryCount, maxRetry)
 			return fmt.Errorf("failed to get file: %w", err)
 		}
 		defer fileReader.Close()
 
 		// 读取文件内容
 		contentBytes, err := io.ReadAll(fileReader)
+		metrics.ObserveParseStage(metrics.StageDownload, downloadStart, err)
 		if err != nil {
-			// 如果是最后一次重试，更新状态为失败
-			if isLastRetry {
-				knowledge.ParseStatus = "failed"
-				knowledge.ErrorMessage = err.Error()
-				knowledge.UpdatedAt = time.Now()
-				s.repo.UpdateKnowledge(ctx, knowledge)
-			}
+			s.markDocumentProcessFailed(ctx, knowledge, err, retryCount, maxRetry)
 			return fmt.Errorf("failed to read file: %w", err)
 		}
+		s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageDownloaded, 0, 0,
+			fmt.Sprintf("%d bytes", len(contentBytes)))
 
 		// 调用docReader处理文件
+		s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageParsing, 0, 0, "")
+		parseStart := time.Now()
+		stopWatching := s.watchReadProgress(ctx, knowledge.ID)
 		fileResp, err := s.docReaderClient.ReadFromFile(ctx, &proto.ReadFromFileRequest{
 			FileContent: contentBytes,
 			FileName:    payload.FileName,
@@ -6742,33 +7406,139 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 					PathPrefix:      kb.StorageConfig.PathPrefix,
 				},
 				VlmConfig: vlmConfig,
+				OcrConfig: ocrProtoConfig(kb),
 			},
-			RequestId: payload.RequestId,
+			RequestId:  payload.RequestId,
+			ProgressId: knowledge.ID,
 		})
+		stopWatching()
+		metrics.ObserveParseStage(metrics.StageParse, parseStart, err)
 		if err != nil {
 			logger.GetLogger(ctx).WithField("knowledge_id", knowledge.ID).
 				WithField("error", err).Errorf("processDocument read file failed")
-			// 如果是最后一次重试，更新状态为失败
-			if isLastRetry {
-				knowledge.ParseStatus = "failed"
-				knowledge.ErrorMessage = err.Error()
-				knowledge.UpdatedAt = time.Now()
-				s.repo.UpdateKnowledge(ctx, knowledge)
-			}
+			s.markDocumentProcessFailed(ctx, knowledge, err, retryCount, maxRetry)
 			return fmt.Errorf("failed to read file from docreader: %w", err)
 		}
 		chunks = fileResp.Chunks
+		s.reportParseProgress(ctx, knowledge.ID, types.KnowledgeParseStageParsed, len(chunks), len(chunks), "")
+		if IsImageType(payload.FileType) && kb.ImageEmbeddingConfig.IsEnabled() {
+			imageContent = contentBytes
+		}
 	}
 
 	// 处理chunks（这会更新状态为completed）
-	s.processChunks(ctx, kb, knowledge, chunks, ProcessChunksOptions{
+	if err := s.processChunks(ctx, kb, knowledge, chunks, ProcessChunksOptions{
 		EnableQuestionGeneration: payload.EnableQuestionGeneration,
 		QuestionCount:            payload.QuestionCount,
-	})
+	}); err != nil {
+		// Transient failures (embedding model, vector store, database) are retried with backoff
+		s.markDocumentProcessFailed(ctx, knowledge, err, retryCount, maxRetry)
+		return fmt.Errorf("failed to process chunks: %w", err)
+	}
+	if recapture != nil {
+		s.deleteKnowledgeRecapture(ctx, knowledge.ID, payload.RecapturePreviewID)
+	}
+
+	// 图像向量是文本向量之外的补充，失败不影响知识的解析状态
+	if len(imageContent) > 0 {
+		if err := s.indexImageEmbedding(ctx, kb, knowledge, imageContent); err != nil {
+			logger.GetLogger(ctx).WithField("knowledge_id", knowledge.ID).
+				WithField("error", err).Warn("processDocument index image embedding failed")
+		}
+	}
 
 	return nil
 }
 
+// readFromURL fetches a page through docreader, extracts it in the given web extraction mode and splits its content
+// into chunks with the settings of kb
+func (s *knowledgeService) readFromURL(ctx context.Context, kb *types.KnowledgeBase,
+	url string, title string, extractionMode string,
+	enableMultimodal bool, vlmConfig *proto.VLMConfig, requestID string,
+) (*proto.ReadResponse, error) {
+	var llmConfig *proto.LLMConfig
+	if extractionMode == types.WebExtractionModeLLM {
+		var err error
+		if llmConfig, err = s.getLLMCleanupProtoConfig(ctx, kb); err != nil {
+			logger.Warnf(ctx, "Failed to get the cleanup model of knowledge base %s: %v", kb.ID, err)
+		}
+		if llmConfig == nil {
+			logger.Warnf(ctx, "Knowledge base %s has no summary model, web page is extracted without cleanup", kb.ID)
+		}
+	}
+	return s.docReaderClient.ReadFromURL(ctx, &proto.ReadFromURLRequest{
+		Url:            url,
+		Title:          title,
+		ExtractionMode: extractionMode,
+		LlmConfig:      llmConfig,
+		FetchConfig:    fetchProtoConfig(kb, url),
+		ReadConfig: &proto.ReadConfig{
+			ChunkSize:        int32(kb.ChunkingConfig.ChunkSize),
+			ChunkOverlap:     int32(kb.ChunkingConfig.ChunkOverlap),
+			Separators:       kb.ChunkingConfig.Separators,
+			EnableMultimodal: enableMultimodal,
+			StorageConfig: &proto.StorageConfig{
+				Provider: proto.StorageProvider(
+					proto.StorageProvider_value[strings.ToUpper(kb.StorageConfig.Provider)],
+				),
+				Region:          kb.StorageConfig.Region,
+				BucketName:      kb.StorageConfig.BucketName,
+				AccessKeyId:     kb.StorageConfig.SecretID,
+				SecretAccessKey: kb.StorageConfig.SecretKey,
+				AppId:           kb.StorageConfig.AppID,
+				PathPrefix:      kb.StorageConfig.PathPrefix,
+			},
+			VlmConfig: vlmConfig,
+			OcrConfig: ocrProtoConfig(kb),
+		},
+		RequestId: requestID,
+	})
+}
+
+// newDocumentProcessTask creates a document process task that is retried with exponential backoff
+// (see router.NewAsynqServer) and dead-lettered after the configured number of retries.
+func newDocumentProcessTask(payload []byte) *asynq.Task {
+	return asynq.NewTask(types.TypeDocumentProcess, payload,
+		asynq.Queue("default"), asynq.MaxRetry(secutils.GetDocumentParseMaxRetry()))
+}
+
+// markDocumentProcessFailed records a failed document process attempt. After the last retry the
+// knowledge is dead-lettered with ParseStatusFailedPermanent, otherwise it stays failed until the
+// next automatic retry. Attempts interrupted by shutdown are requeued, the knowledge goes back to pending
+// and is parsed again after restart.
+func (s *knowledgeService) markDocumentProcessFailed(
+	ctx context.Context,
+	knowledge *types.Knowledge,
+	cause error,
+	retryCount, maxRetry int,
+) {
+	if errors.Is(context.Cause(ctx), types.ErrTaskInterrupted) {
+		knowledge.ParseStatus = types.ParseStatusPending
+		knowledge.ErrorMessage = ""
+		knowledge.UpdatedAt = time.Now()
+		if err := s.repo.UpdateKnowledge(context.WithoutCancel(ctx), knowledge); err != nil {
+			logger.Errorf(ctx, "Failed to checkpoint knowledge %s interrupted by shutdown: %v", knowledge.ID, err)
+		}
+		logger.Infof(ctx, "Document processing interrupted by shutdown, requeued: knowledge_id=%s", knowledge.ID)
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(cause)
+	span.SetStatus(codes.Error, cause.Error())
+	if retryCount >= maxRetry {
+		knowledge.ParseStatus = types.ParseStatusFailedPermanent
+		knowledge.ErrorMessage = fmt.Sprintf("%v (gave up after %d attempts)", cause, retryCount+1)
+		logger.Errorf(ctx, "Document processing dead-lettered: knowledge_id=%s, error: %v", knowledge.ID, cause)
+	} else {
+		knowledge.ParseStatus = types.ParseStatusFailed
+		knowledge.ErrorMessage = fmt.Sprintf("%v (attempt %d/%d, retrying)", cause, retryCount+1, maxRetry+1)
+	}
+	knowledge.UpdatedAt = time.Now()
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		logger.Errorf(ctx, "Failed to update knowledge %s after processing failure: %v", knowledge.ID, err)
+	}
+}
+
 // ProcessFAQImport handles Asynq FAQ import tasks (including dry run mode)
 func (s *knowledgeService) ProcessFAQImport(ctx context.Context, t *asynq.Task) error {
 	var payload types.FAQImportPayload
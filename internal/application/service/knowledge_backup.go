@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ExportKnowledgeVectors returns the vectors of a knowledge keyed by source ID, for tenant backups.
+// An empty result means the vector store of the tenant cannot read vectors back.
+func (s *knowledgeService) ExportKnowledgeVectors(
+	ctx context.Context, knowledge *types.Knowledge,
+) (map[string][]float32, error) {
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return nil, err
+	}
+	return retrieveEngine.GetEmbeddingsByKnowledgeID(ctx, knowledge.ID)
+}
+
+// RestoreKnowledgeVectors indexes the chunks of a restored knowledge with the embedding model of its knowledge base.
+// Chunks keep their IDs on restore, so backed up vectors are matched by source ID and only chunks without one are
+// embedded again. It returns how many vectors were reused.
+func (s *knowledgeService) RestoreKnowledgeVectors(
+	ctx context.Context, knowledge *types.Knowledge, vectors map[string][]float32,
+) (int, error) {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return 0, err
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		return 0, err
+	}
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return 0, err
+	}
+
+	chunkTypes := indexedChunkTypes
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		chunkTypes = []types.ChunkType{types.ChunkTypeFAQ}
+	}
+	var indexInfoList []*types.IndexInfo
+	disabledChunks := make(map[string]bool)
+	for page := 1; ; page++ {
+		chunks, _, err := s.chunkRepo.ListPagedChunksByKnowledgeID(ctx,
+			knowledge.TenantID,
+			knowledge.ID,
+			&types.Pagination{
+				Page:     page,
+				PageSize: kbBundleChunkPageSize,
+			},
+			chunkTypes,
+			"",
+			"",
+			"",
+			"",
+			"",
+		)
+		if err != nil {
+			return 0, err
+		}
+		if len(chunks) == 0 {
+			break
+		}
+		for _, chunk := range chunks {
+			infos := chunkIndexInfos(chunk, knowledge.ID, knowledge.KnowledgeBaseID)
+			if kb.Type == types.KnowledgeBaseTypeFAQ {
+				if infos, err = s.buildFAQIndexInfoList(ctx, kb, chunk); err != nil {
+					return 0, err
+				}
+			}
+			indexInfoList = append(indexInfoList, infos...)
+			if !chunk.IsEnabled {
+				disabledChunks[chunk.ID] = false
+			}
+		}
+	}
+
+	// Vectors are looked up by content, as the embedder is given texts
+	contentVectors := make(map[string][]float32)
+	for _, info := range indexInfoList {
+		if vector, ok := vectors[info.SourceID]; ok && len(vector) == embedder.GetDimensions() {
+			contentVectors[info.Content] = vector
+		}
+	}
+	reused := 0
+	for _, info := range indexInfoList {
+		if _, ok := contentVectors[info.Content]; ok {
+			reused++
+		}
+	}
+
+	indexEmbedder := embedder
+	if len(contentVectors) > 0 {
+		indexEmbedder = &precomputedEmbedder{Embedder: embedder, vectors: contentVectors}
+	}
+	for batch := range slices.Chunk(indexInfoList, kbBundleChunkPageSize) {
+		if err := retrieveEngine.BatchIndex(ctx, indexEmbedder, batch); err != nil {
+			return reused, err
+		}
+	}
+	if len(disabledChunks) > 0 {
+		if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, disabledChunks); err != nil {
+			return reused, err
+		}
+	}
+	return reused, nil
+}
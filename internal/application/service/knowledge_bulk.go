@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	knowledgeBulkProgressKeyPrefix = "knowledge_bulk_progress:"
+	knowledgeBulkProgressTTL       = 24 * time.Hour
+	// Knowledge is loaded and its progress saved this many entries at a time
+	knowledgeBulkBatchSize = 50
+)
+
+// getKnowledgeBulkProgressKey returns the Redis key for storing knowledge bulk operation progress
+func getKnowledgeBulkProgressKey(taskID string) string {
+	return knowledgeBulkProgressKeyPrefix + taskID
+}
+
+// StartKnowledgeBulkOperation resolves the knowledge a bulk request applies to and enqueues the operation.
+// A filter is resolved to knowledge IDs now, so knowledge added meanwhile is not affected.
+func (s *knowledgeService) StartKnowledgeBulkOperation(
+	ctx context.Context, kb *types.KnowledgeBase, role types.KBRole, req *types.KnowledgeBulkRequest,
+) (*types.KnowledgeBulkProgress, error) {
+	if err := req.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+
+	if req.Operation == types.KnowledgeBulkRetag {
+		// Unknown tags fail the request instead of every entry
+		if _, err := s.resolveKnowledgeTags(ctx, tenantID, kb.ID, req.TagIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	knowledgeIDs := req.KnowledgeIDs
+	if req.Filter != nil {
+		ids, err := s.repo.ListKnowledgeIDsByFilter(ctx, tenantID, kb.ID, req.Filter, types.MaxKnowledgeBulkItems+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve bulk filter: %w", err)
+		}
+		if len(ids) > types.MaxKnowledgeBulkItems {
+			return nil, werrors.NewBadRequestError(
+				fmt.Sprintf("筛选条件匹配的知识超过%d条，请缩小范围", types.MaxKnowledgeBulkItems))
+		}
+		knowledgeIDs = ids
+	}
+
+	userID, _ := ctx.Value(types.UserIDContextKey).(string)
+	taskID := utils.GenerateTaskID("knowledge_bulk", tenantID, kb.ID)
+	now := time.Now().Unix()
+	progress := &types.KnowledgeBulkProgress{
+		TaskID:    taskID,
+		KBID:      kb.ID,
+		Operation: req.Operation,
+		Status:    types.KnowledgeBulkStatusPending,
+		Total:     len(knowledgeIDs),
+		Failures:  []types.KnowledgeBulkFailure{},
+		Message:   "Task queued, waiting to start...",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if len(knowledgeIDs) == 0 {
+		// Nothing matched the filter, there is no work to queue
+		progress.Status = types.KnowledgeBulkStatusCompleted
+		progress.Progress = 100
+		progress.Message = "No knowledge matched"
+		if err := s.saveKnowledgeBulkProgress(ctx, progress); err != nil {
+			logger.Warnf(ctx, "Failed to save knowledge bulk progress: %v", err)
+		}
+		return progress, nil
+	}
+
+	payload := types.KnowledgeBulkPayload{
+		TenantID:     tenantID,
+		TaskID:       taskID,
+		KBID:         kb.ID,
+		UserID:       userID,
+		KBRole:       role,
+		Operation:    req.Operation,
+		KnowledgeIDs: knowledgeIDs,
+		Permanent:    req.Permanent,
+		TagIDs:       req.TagIDs,
+		Mode:         req.Mode,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal knowledge bulk payload: %w", err)
+	}
+	// Saved before enqueueing, the task resumes from the saved progress
+	if err := s.saveKnowledgeBulkProgress(ctx, progress); err != nil {
+		return nil, fmt.Errorf("failed to save knowledge bulk progress: %w", err)
+	}
+	task := asynq.NewTask(types.TypeKnowledgeBulk, payloadBytes,
+		asynq.TaskID(taskID), asynq.Queue("default"), asynq.MaxRetry(3))
+	if _, err := s.task.Enqueue(task); err != nil {
+		_ = s.redisClient.Del(ctx, getKnowledgeBulkProgressKey(taskID)).Err()
+		return nil, fmt.Errorf("failed to enqueue knowledge bulk task: %w", err)
+	}
+
+	logger.Infof(ctx, "Knowledge bulk task enqueued: %s, kb: %s, operation: %s, knowledge: %d",
+		taskID, kb.ID, req.Operation, len(knowledgeIDs))
+	return progress, nil
+}
+
+// ProcessKnowledgeBulkOperation handles Asynq knowledge bulk operation tasks.
+//
+// Knowledge is processed in batches and the progress saved after each, a retried task resumes after the last saved
+// batch. Entries that are gone, outside the knowledge base or not editable by the caller are counted as failed,
+// as are entries the operation fails on; the task itself only fails when the knowledge cannot be loaded.
+func (s *knowledgeService) ProcessKnowledgeBulkOperation(ctx context.Context, t *asynq.Task) error {
+	var payload types.KnowledgeBulkPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal knowledge bulk payload: %w", err)
+	}
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant info: %v", err)
+		return fmt.Errorf("failed to get tenant info: %w", err)
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+	if payload.UserID != "" {
+		ctx = context.WithValue(ctx, types.UserIDContextKey, payload.UserID)
+	}
+
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	isLastRetry := retryCount >= maxRetry
+
+	logger.Infof(ctx, "Processing knowledge bulk task: %s, kb: %s, operation: %s, retry: %d/%d",
+		payload.TaskID, payload.KBID, payload.Operation, retryCount, maxRetry)
+
+	progress, err := s.GetKnowledgeBulkProgress(ctx, payload.TaskID)
+	if err != nil {
+		progress = &types.KnowledgeBulkProgress{
+			TaskID:    payload.TaskID,
+			KBID:      payload.KBID,
+			Operation: payload.Operation,
+			Total:     len(payload.KnowledgeIDs),
+			Failures:  []types.KnowledgeBulkFailure{},
+			CreatedAt: time.Now().Unix(),
+		}
+	}
+	progress.Status = types.KnowledgeBulkStatusProcessing
+	progress.Message = "Processing knowledge..."
+	_ = s.saveKnowledgeBulkProgress(ctx, progress)
+
+	for start := progress.Processed; start < len(payload.KnowledgeIDs); start += knowledgeBulkBatchSize {
+		end := min(start+knowledgeBulkBatchSize, len(payload.KnowledgeIDs))
+		if err := s.applyKnowledgeBulkBatch(ctx, &payload, payload.KnowledgeIDs[start:end], progress); err != nil {
+			logger.Errorf(ctx, "Knowledge bulk task %s: failed to process batch: %v", payload.TaskID, err)
+			if isLastRetry {
+				progress.Status = types.KnowledgeBulkStatusFailed
+				progress.Error = err.Error()
+				progress.Message = "Failed to process knowledge"
+				_ = s.saveKnowledgeBulkProgress(ctx, progress)
+			}
+			return err
+		}
+		progress.Message = fmt.Sprintf("Processed %d/%d knowledge", progress.Processed, progress.Total)
+		_ = s.saveKnowledgeBulkProgress(ctx, progress)
+	}
+
+	progress.Status = types.KnowledgeBulkStatusCompleted
+	progress.Progress = 100
+	progress.Message = fmt.Sprintf("Completed, %d succeeded, %d failed", progress.Succeeded, progress.Failed)
+	_ = s.saveKnowledgeBulkProgress(ctx, progress)
+
+	logger.Infof(ctx, "Knowledge bulk task %s completed, succeeded: %d, failed: %d",
+		payload.TaskID, progress.Succeeded, progress.Failed)
+	return nil
+}
+
+// applyKnowledgeBulkBatch applies the operation of a bulk task to a batch of knowledge and records the outcome of
+// every entry in the progress
+func (s *knowledgeService) applyKnowledgeBulkBatch(ctx context.Context,
+	payload *types.KnowledgeBulkPayload, ids []string, progress *types.KnowledgeBulkProgress,
+) error {
+	knowledgeList, err := s.repo.GetKnowledgeBatch(ctx, payload.TenantID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load knowledge: %w", err)
+	}
+	found := make(map[string]*types.Knowledge, len(knowledgeList))
+	for _, knowledge := range knowledgeList {
+		if knowledge.KnowledgeBaseID == payload.KBID {
+			found[knowledge.ID] = knowledge
+		}
+	}
+
+	editable := make([]string, 0, len(ids))
+	for _, id := range ids {
+		knowledge, ok := found[id]
+		if !ok {
+			progress.RecordFailure(id, werrors.NewNotFoundError("知识不存在"))
+			continue
+		}
+		role, err := s.kbPermissionService.ResolveKnowledgeRole(ctx, knowledge, payload.KBRole, payload.UserID)
+		if err != nil {
+			progress.RecordFailure(id, err)
+			continue
+		}
+		if !role.HasPermission(types.KBRoleEditor) {
+			progress.RecordFailure(id, werrors.NewForbiddenError("Permission denied to access this knowledge"))
+			continue
+		}
+		editable = append(editable, id)
+	}
+	if len(editable) == 0 {
+		return nil
+	}
+
+	switch {
+	case payload.Operation == types.KnowledgeBulkDelete && payload.Permanent:
+		s.recordKnowledgeBulkBatch(progress, editable, s.DeleteKnowledgeList(ctx, editable))
+	case payload.Operation == types.KnowledgeBulkDelete:
+		for _, id := range editable {
+			s.recordKnowledgeBulkEntry(progress, id, s.TrashKnowledge(ctx, id))
+		}
+	case payload.Operation == types.KnowledgeBulkRetag:
+		err := s.RetagKnowledgeBatch(ctx, &types.KnowledgeRetagRequest{
+			KnowledgeIDs: editable,
+			TagIDs:       payload.TagIDs,
+			Mode:         payload.Mode,
+		})
+		s.recordKnowledgeBulkBatch(progress, editable, err)
+	case payload.Operation == types.KnowledgeBulkReparse:
+		for _, id := range editable {
+			_, err := s.ReparseKnowledge(ctx, id)
+			s.recordKnowledgeBulkEntry(progress, id, err)
+		}
+	default:
+		for _, id := range editable {
+			progress.RecordFailure(id, fmt.Errorf("unsupported operation %q", payload.Operation))
+		}
+	}
+	return nil
+}
+
+// recordKnowledgeBulkEntry records the outcome of an operation on one entry
+func (s *knowledgeService) recordKnowledgeBulkEntry(progress *types.KnowledgeBulkProgress, id string, err error) {
+	if err != nil {
+		progress.RecordFailure(id, err)
+		return
+	}
+	progress.RecordSuccess(1)
+}
+
+// recordKnowledgeBulkBatch records the outcome of an operation applied to a batch as a whole
+func (s *knowledgeService) recordKnowledgeBulkBatch(progress *types.KnowledgeBulkProgress, ids []string, err error) {
+	if err == nil {
+		progress.RecordSuccess(len(ids))
+		return
+	}
+	for _, id := range ids {
+		progress.RecordFailure(id, err)
+	}
+}
+
+// saveKnowledgeBulkProgress saves the knowledge bulk operation progress to Redis
+func (s *knowledgeService) saveKnowledgeBulkProgress(ctx context.Context, progress *types.KnowledgeBulkProgress) error {
+	progress.UpdatedAt = time.Now().Unix()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	return s.redisClient.Set(ctx, getKnowledgeBulkProgressKey(progress.TaskID), data, knowledgeBulkProgressTTL).Err()
+}
+
+// GetKnowledgeBulkProgress retrieves the progress of a knowledge bulk operation task
+func (s *knowledgeService) GetKnowledgeBulkProgress(
+	ctx context.Context, taskID string,
+) (*types.KnowledgeBulkProgress, error) {
+	data, err := s.redisClient.Get(ctx, getKnowledgeBulkProgressKey(taskID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, werrors.NewNotFoundError("Knowledge bulk task not found")
+		}
+		return nil, fmt.Errorf("failed to get progress from Redis: %w", err)
+	}
+
+	var progress types.KnowledgeBulkProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+	}
+	return &progress, nil
+}
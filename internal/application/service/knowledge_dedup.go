@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// uploadDuplicateMaxSize is the largest text file fingerprinted at upload, larger ones are only checked after parsing
+const uploadDuplicateMaxSize = 10 << 20
+
+// isPlainTextFileType reports whether the bytes of a file type are its text, so it can be fingerprinted before parsing
+func isPlainTextFileType(fileType string) bool {
+	switch strings.ToLower(fileType) {
+	case "txt", "md", "markdown", "csv", "log":
+		return true
+	default:
+		return types.IsCodeFileType(fileType)
+	}
+}
+
+// detectUploadDuplicate fingerprints an uploaded text file before it is parsed, so the upload response can warn
+// about a duplicate. Other files are checked once parsed. Parsing fingerprints the extracted text again and its
+// result replaces this one.
+func (s *knowledgeService) detectUploadDuplicate(ctx context.Context,
+	knowledge *types.Knowledge, file *multipart.FileHeader,
+) {
+	if !isPlainTextFileType(knowledge.FileType) || file.Size > uploadDuplicateMaxSize {
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		logger.Warnf(ctx, "Failed to open upload %s for duplicate detection: %v", knowledge.FileName, err)
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, uploadDuplicateMaxSize))
+	if err != nil {
+		logger.Warnf(ctx, "Failed to read upload %s for duplicate detection: %v", knowledge.FileName, err)
+		return
+	}
+	if !utf8.Valid(data) {
+		return
+	}
+	s.detectDuplicateKnowledge(ctx, knowledge, string(data))
+}
+
+// detectDuplicateKnowledge fingerprints the extracted text of a knowledge entry and marks it as a
+// duplicate of the oldest live knowledge in the same knowledge base with identical content or,
+// failing that, the closest SimHash within types.NearDuplicateMaxDistance.
+// Only the fields are set; the caller persists the knowledge.
+func (s *knowledgeService) detectDuplicateKnowledge(ctx context.Context, knowledge *types.Knowledge, text string) {
+	knowledge.ContentHash, knowledge.SimHash = "", 0
+	knowledge.DuplicateOf, knowledge.DuplicateType = "", ""
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	knowledge.ContentHash = types.ContentHash(text)
+	knowledge.SimHash = int64(types.SimHash(text))
+
+	candidates, err := s.repo.ListFingerprintedKnowledge(ctx, knowledge.TenantID, knowledge.KnowledgeBaseID, knowledge.ID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to load fingerprints for duplicate detection of knowledge %s: %v", knowledge.ID, err)
+		return
+	}
+
+	var nearest *types.Knowledge
+	nearestDistance := types.NearDuplicateMaxDistance + 1
+	for _, candidate := range candidates {
+		if candidate.ContentHash == knowledge.ContentHash {
+			markKnowledgeDuplicate(knowledge, candidate, types.KnowledgeDuplicateExact)
+			break
+		}
+		if knowledge.SimHash == 0 || candidate.SimHash == 0 {
+			continue
+		}
+		if d := types.HammingDistance(uint64(knowledge.SimHash), uint64(candidate.SimHash)); d < nearestDistance {
+			nearest, nearestDistance = candidate, d
+		}
+	}
+	if knowledge.DuplicateOf == "" && nearest != nil {
+		markKnowledgeDuplicate(knowledge, nearest, types.KnowledgeDuplicateNear)
+	}
+	if knowledge.DuplicateOf != "" {
+		logger.Infof(ctx, "Knowledge %s (%s) detected as %s duplicate of %s",
+			knowledge.ID, knowledge.Title, knowledge.DuplicateType, knowledge.DuplicateOf)
+	}
+}
+
+// markKnowledgeDuplicate points knowledge at the original of match, keeping clusters one level deep
+func markKnowledgeDuplicate(knowledge, match *types.Knowledge, duplicateType string) {
+	knowledge.DuplicateOf = match.ID
+	if match.DuplicateOf != "" {
+		knowledge.DuplicateOf = match.DuplicateOf
+	}
+	knowledge.DuplicateType = duplicateType
+}
+
+// ListDuplicateClusters lists the duplicates of a knowledge base grouped by their original.
+// Duplicates whose original has been deleted or trashed are no longer reported.
+func (s *knowledgeService) ListDuplicateClusters(ctx context.Context, kbID string) ([]*types.DuplicateCluster, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	duplicates, err := s.repo.ListDuplicateKnowledge(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if len(duplicates) == 0 {
+		return []*types.DuplicateCluster{}, nil
+	}
+
+	originalIDs := make([]string, 0, len(duplicates))
+	for _, duplicate := range duplicates {
+		if !slices.Contains(originalIDs, duplicate.DuplicateOf) {
+			originalIDs = append(originalIDs, duplicate.DuplicateOf)
+		}
+	}
+	originals, err := s.repo.GetKnowledgeBatch(ctx, tenantID, originalIDs)
+	if err != nil {
+		return nil, err
+	}
+	clusterByOriginal := make(map[string]*types.DuplicateCluster, len(originals))
+	for _, original := range originals {
+		if original.TrashedAt != nil {
+			continue
+		}
+		clusterByOriginal[original.ID] = &types.DuplicateCluster{Original: original}
+	}
+
+	clusters := make([]*types.DuplicateCluster, 0, len(clusterByOriginal))
+	for _, duplicate := range duplicates {
+		cluster, ok := clusterByOriginal[duplicate.DuplicateOf]
+		if !ok {
+			continue
+		}
+		if len(cluster.Duplicates) == 0 {
+			clusters = append(clusters, cluster)
+		}
+		cluster.Duplicates = append(cluster.Duplicates, duplicate)
+	}
+	return clusters, nil
+}
+
+// DedupKnowledge moves detected duplicates to trash and keeps their originals, so the
+// cleanup can be undone from the trash. Duplicates that cannot be trashed are skipped.
+func (s *knowledgeService) DedupKnowledge(ctx context.Context, kbID string, knowledgeIDs []string) (int, error) {
+	clusters, err := s.ListDuplicateClusters(ctx, kbID)
+	if err != nil {
+		return 0, err
+	}
+	trashed := 0
+	for _, cluster := range clusters {
+		for _, duplicate := range cluster.Duplicates {
+			if len(knowledgeIDs) > 0 && !slices.Contains(knowledgeIDs, duplicate.ID) {
+				continue
+			}
+			if err := s.TrashKnowledge(ctx, duplicate.ID); err != nil {
+				logger.Warnf(ctx, "Failed to trash duplicate knowledge %s: %v", duplicate.ID, err)
+				continue
+			}
+			trashed++
+		}
+	}
+	logger.Infof(ctx, "Dedup of knowledge base %s moved %d duplicates to trash", kbID, trashed)
+	return trashed, nil
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+const (
+	// enexTimeLayout is the layout of the dates of an Evernote export, always UTC
+	enexTimeLayout = "20060102T150405Z"
+	// enexUntitledNote names the file of a note without a title
+	enexUntitledNote = "未命名笔记"
+	// enexMaxTitleRunes bounds the file name of a note, titles of web clips can be very long
+	enexMaxTitleRunes = 120
+)
+
+// errNotEnex is returned for a file that is not an Evernote export
+var errNotEnex = errors.New("not an Evernote export")
+
+// enexNote is a note of an Evernote export. Only the fields the import needs are decoded, the note is stored as it
+// was exported, with its content and resources, and parsed by the docreader.
+type enexNote struct {
+	Title     string   `xml:"title"`
+	Created   string   `xml:"created"`
+	Updated   string   `xml:"updated"`
+	Tags      []string `xml:"tag"`
+	Author    string   `xml:"note-attributes>author"`
+	SourceURL string   `xml:"note-attributes>source-url"`
+	// Raw is the note element as it appears in the export
+	Raw []byte `xml:"-"`
+}
+
+// splitEnexNotes reads the notes of an Evernote export. Each note keeps its raw XML so it can be stored as an
+// export of its own.
+func splitEnexNotes(data []byte) ([]*enexNote, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var notes []*enexNote
+	isExport := false
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch element.Name.Local {
+		case "en-export":
+			isExport = true
+		case "note":
+			if !isExport {
+				return nil, errNotEnex
+			}
+			note := &enexNote{}
+			if err := decoder.DecodeElement(note, &element); err != nil {
+				return nil, err
+			}
+			note.Raw = data[start:decoder.InputOffset()]
+			notes = append(notes, note)
+		}
+	}
+	if !isExport {
+		return nil, errNotEnex
+	}
+	return notes, nil
+}
+
+// exportFile is the note as an Evernote export of its own, the file the knowledge of the note is parsed from
+func (n *enexNote) exportFile() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<en-export>\n")
+	buf.Write(n.Raw)
+	buf.WriteString("\n</en-export>\n")
+	return buf.Bytes()
+}
+
+// fileName is the file name of the knowledge of the note, its title without path separators
+func (n *enexNote) fileName() string {
+	title := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 32 {
+			return ' '
+		}
+		return r
+	}, n.Title)
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		title = enexUntitledNote
+	}
+	if runes := []rune(title); len(runes) > enexMaxTitleRunes {
+		title = string(runes[:enexMaxTitleRunes])
+	}
+	return title + ".enex"
+}
+
+// metadata is the knowledge metadata of the note, dates in RFC 3339
+func (n *enexNote) metadata(notebook string) map[string]string {
+	metadata := map[string]string{types.EnexMetadataNotebook: notebook}
+	if created := parseEnexTime(n.Created); !created.IsZero() {
+		metadata[types.EnexMetadataNoteCreated] = created.Format(time.RFC3339)
+	}
+	if updated := parseEnexTime(n.Updated); !updated.IsZero() {
+		metadata[types.EnexMetadataNoteUpdated] = updated.Format(time.RFC3339)
+	}
+	if author := strings.TrimSpace(n.Author); author != "" {
+		metadata[types.EnexMetadataAuthor] = author
+	}
+	if sourceURL := strings.TrimSpace(n.SourceURL); sourceURL != "" {
+		metadata[types.EnexMetadataSourceURL] = sourceURL
+	}
+	if tags := n.tagNames(); len(tags) > 0 {
+		metadata[types.EnexMetadataTags] = strings.Join(tags, ",")
+	}
+	return metadata
+}
+
+// tagNames are the tags of the note, trimmed and without duplicates
+func (n *enexNote) tagNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, tag := range n.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		names = append(names, tag)
+	}
+	return names
+}
+
+// parseEnexTime parses a date of an Evernote export such as 20240115T083000Z, the zero time when it is malformed
+func parseEnexTime(value string) time.Time {
+	t, err := time.Parse(enexTimeLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ImportEnex imports an Evernote export, one knowledge per note. Each note is stored as an export of its own and
+// parsed by the docreader with its images and attachments. The notes are tagged with their notebook, named after the
+// export file unless notebook is given, and with their Evernote tags, after tagID when it is given. Creation and
+// update dates, author and source URL of the notes are kept in the knowledge metadata. Notes imported before are
+// reported as duplicates, notes that cannot be imported as failed, neither stops the import.
+func (s *knowledgeService) ImportEnex(ctx context.Context,
+	kbID string, file *multipart.FileHeader, metadata map[string]string, enableMultimodel *bool,
+	notebook string, tagID string,
+) (*types.EnexImportResult, error) {
+	logger.Infof(ctx, "Start importing Evernote export, knowledge base ID: %s", kbID)
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base: %v", err)
+		return nil, err
+	}
+	if getFileType(file.Filename) != "enex" {
+		return nil, werrors.NewBadRequestError("请上传 .enex 格式的印象笔记导出文件")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	notes, err := splitEnexNotes(data)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to read Evernote export %s: %v", secutils.SanitizeForLog(file.Filename), err)
+		return nil, werrors.NewBadRequestError("无法解析印象笔记导出文件").WithDetails(err.Error())
+	}
+
+	notebook = strings.TrimSpace(notebook)
+	if notebook == "" {
+		notebook = strings.TrimSpace(strings.TrimSuffix(filepath.Base(file.Filename), filepath.Ext(file.Filename)))
+	}
+	result := &types.EnexImportResult{
+		Notebook:   notebook,
+		Knowledge:  []*types.Knowledge{},
+		Duplicates: []*types.Knowledge{},
+		Failed:     []types.EnexNoteError{},
+	}
+	// Tags are looked up once per name, notes of a notebook share most of them
+	tagIDs := make(map[string]string)
+	resolveTag := func(name string) (string, error) {
+		if id, ok := tagIDs[name]; ok {
+			return id, nil
+		}
+		tag, err := s.tagService.FindOrCreateTagByName(ctx, kbID, name)
+		if err != nil {
+			return "", err
+		}
+		tagIDs[name] = tag.ID
+		return tag.ID, nil
+	}
+
+	for _, note := range notes {
+		knowledge, err := s.importEnexNote(ctx, kb, note, notebook, metadata, enableMultimodel, tagID, resolveTag)
+		if err != nil {
+			var duplicate *types.DuplicateKnowledgeError
+			if errors.As(err, &duplicate) {
+				result.Duplicates = append(result.Duplicates, knowledge)
+				continue
+			}
+			logger.Warnf(ctx, "Failed to import note %s: %v", secutils.SanitizeForLog(note.Title), err)
+			result.Failed = append(result.Failed, types.EnexNoteError{Title: note.Title, Error: err.Error()})
+			continue
+		}
+		result.Knowledge = append(result.Knowledge, knowledge)
+	}
+	logger.Infof(ctx, "Imported Evernote notebook %s: %d notes, %d created, %d duplicates, %d failed",
+		secutils.SanitizeForLog(notebook), len(notes), len(result.Knowledge), len(result.Duplicates), len(result.Failed))
+	return result, nil
+}
+
+// importEnexNote creates the knowledge of a note of an Evernote export and queues its parsing
+func (s *knowledgeService) importEnexNote(ctx context.Context,
+	kb *types.KnowledgeBase, note *enexNote, notebook string, metadata map[string]string, enableMultimodel *bool,
+	tagID string, resolveTag func(name string) (string, error),
+) (*types.Knowledge, error) {
+	safeFilename, isValid := secutils.ValidateInput(note.fileName())
+	if !isValid {
+		return nil, werrors.NewValidationError("笔记标题包含非法字符")
+	}
+	data := note.exportFile()
+	sum := md5.Sum(data)
+	hash := hex.EncodeToString(sum[:])
+	fileSize := int64(len(data))
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	exists, existingKnowledge, err := s.repo.CheckKnowledgeExists(ctx, tenantID, kb.ID, &types.KnowledgeCheckParams{
+		Type:     "file",
+		FileName: safeFilename,
+		FileSize: fileSize,
+		FileHash: hash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return existingKnowledge, types.NewDuplicateFileError(existingKnowledge)
+	}
+	if err := s.checkKnowledgeQuota(ctx, ""); err != nil {
+		return nil, err
+	}
+
+	// Metadata of the upload first, the note's own fields win
+	noteMetadata := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		noteMetadata[key] = value
+	}
+	for key, value := range note.metadata(notebook) {
+		noteMetadata[key] = value
+	}
+	metadataBytes, err := json.Marshal(noteMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	if tagID != "" {
+		tags = append(tags, tagID)
+	}
+	for _, name := range append([]string{notebook}, note.tagNames()...) {
+		if name == "" {
+			continue
+		}
+		id, err := resolveTag(name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, id)
+	}
+
+	knowledge := &types.Knowledge{
+		TenantID:         tenantID,
+		KnowledgeBaseID:  kb.ID,
+		Type:             "file",
+		Title:            strings.TrimSuffix(safeFilename, ".enex"),
+		Source:           strings.TrimSpace(note.SourceURL),
+		FileName:         safeFilename,
+		FileType:         "enex",
+		FileSize:         fileSize,
+		FileHash:         hash,
+		ParseStatus:      "pending",
+		EnableStatus:     "disabled",
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+		EmbeddingModelID: kb.EmbeddingModelID,
+		Metadata:         types.JSON(metadataBytes),
+	}
+	if len(tags) > 0 {
+		knowledge.TagID = tags[0]
+	}
+	if err := s.repo.CreateKnowledge(ctx, knowledge); err != nil {
+		logger.Errorf(ctx, "Failed to create knowledge record: %v", err)
+		return nil, err
+	}
+	if len(tags) > 1 {
+		if _, err := s.SetKnowledgeTags(ctx, knowledge.ID, tags); err != nil {
+			logger.Warnf(ctx, "Failed to tag knowledge %s of note: %v", knowledge.ID, err)
+		} else {
+			knowledge.TagIDs = tags
+		}
+	}
+	filePath, err := s.fileSvc.SaveBytes(ctx, data, tenantID, safeFilename, false)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to save note file, knowledge ID: %s, error: %v", knowledge.ID, err)
+		return nil, err
+	}
+	knowledge.FilePath = filePath
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		logger.Errorf(ctx, "Failed to update knowledge with file path, ID: %s, error: %v", knowledge.ID, err)
+		return nil, err
+	}
+	s.webhookService.Publish(ctx, tenantID, types.WebhookEventKnowledgeCreated, types.NewWebhookKnowledgeData(knowledge))
+
+	s.enqueueKnowledgeFileProcessing(ctx, kb, knowledge, enableMultimodel)
+	return knowledge, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+const testEnex = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export4.dtd">
+<en-export export-date="20240301T120000Z" application="Evernote" version="10.70.2">
+  <note>
+    <title>周会纪要 2024/01/15</title>
+    <created>20240115T083000Z</created>
+    <updated>20240116T020304Z</updated>
+    <tag>会议</tag>
+    <tag> 会议 </tag>
+    <tag>项目A</tag>
+    <note-attributes>
+      <author>张三</author>
+      <source-url>https://example.com/wiki/weekly</source-url>
+    </note-attributes>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div>进度正常</div><en-media hash="4f3a" type="image/png"/></en-note>]]></content>
+    <resource>
+      <data encoding="base64">iVBORw0KGgo=</data>
+      <mime>image/png</mime>
+      <resource-attributes><file-name>board.png</file-name></resource-attributes>
+    </resource>
+  </note>
+  <note>
+    <title></title>
+    <created>not a date</created>
+    <content><![CDATA[<en-note>随手记</en-note>]]></content>
+  </note>
+</en-export>
+`
+
+func TestSplitEnexNotes(t *testing.T) {
+	notes, err := splitEnexNotes([]byte(testEnex))
+	if err != nil {
+		t.Fatalf("splitEnexNotes() error = %v", err)
+	}
+	if len(notes) != 2 {
+		t.Fatalf("got %d notes, want 2", len(notes))
+	}
+
+	first := notes[0]
+	if got := first.fileName(); got != "周会纪要 2024 01 15.enex" {
+		t.Errorf("fileName() = %q", got)
+	}
+	raw := string(first.Raw)
+	if !strings.HasPrefix(raw, "<note>") || !strings.HasSuffix(raw, "</note>") {
+		t.Errorf("Raw is not the note element: %q", raw)
+	}
+	if !strings.Contains(raw, "board.png") || !strings.Contains(raw, "<![CDATA[") {
+		t.Errorf("Raw lost the content or resources: %q", raw)
+	}
+	reparsed, err := splitEnexNotes(first.exportFile())
+	if err != nil || len(reparsed) != 1 || reparsed[0].Title != first.Title {
+		t.Errorf("exportFile() is not a single note export: %v, %v", reparsed, err)
+	}
+
+	metadata := first.metadata("工作")
+	want := map[string]string{
+		"notebook":      "工作",
+		"note_created":  "2024-01-15T08:30:00Z",
+		"note_updated":  "2024-01-16T02:03:04Z",
+		"author":        "张三",
+		"source_url":    "https://example.com/wiki/weekly",
+		"evernote_tags": "会议,项目A",
+	}
+	if len(metadata) != len(want) {
+		t.Errorf("metadata() = %v, want %v", metadata, want)
+	}
+	for key, value := range want {
+		if metadata[key] != value {
+			t.Errorf("metadata()[%q] = %q, want %q", key, metadata[key], value)
+		}
+	}
+
+	second := notes[1]
+	if got := second.fileName(); got != enexUntitledNote+".enex" {
+		t.Errorf("fileName() of untitled note = %q", got)
+	}
+	if _, ok := second.metadata("工作")["note_created"]; ok {
+		t.Error("malformed creation date should be left out of the metadata")
+	}
+}
+
+func TestSplitEnexNotesRejectsOtherXML(t *testing.T) {
+	for _, data := range []string{
+		`<html><body><note>x</note></body></html>`,
+		`not xml at all <`,
+	} {
+		if _, err := splitEnexNotes([]byte(data)); err == nil {
+			t.Errorf("splitEnexNotes(%q) should fail", data)
+		}
+	}
+}
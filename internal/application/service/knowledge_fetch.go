@@ -0,0 +1,341 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+const (
+	// knowledgeFetchTimeout bounds the download of a document, large PDFs on slow sites take a while
+	knowledgeFetchTimeout = 2 * time.Minute
+	// knowledgeFetchDefaultName names a fetched file whose URL and headers give no file name
+	knowledgeFetchDefaultName = "document"
+)
+
+// pdfMagic starts every PDF document
+var pdfMagic = []byte("%PDF-")
+
+// CreateKnowledgeFromFetchedURL downloads the document served at a URL, such as a PDF or an Office file sent as an
+// attachment, and imports it like an uploaded file, so it goes through the pipeline of its file type rather than the
+// web page one. The URL is kept as the source of the knowledge.
+func (s *knowledgeService) CreateKnowledgeFromFetchedURL(ctx context.Context,
+	kbID string, fileURL string, enableMultimodel *bool, title string, tagID string,
+) (*types.Knowledge, error) {
+	logger.Infof(ctx, "Start creating knowledge from fetched URL, knowledge base ID: %s", kbID)
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base: %v", err)
+		return nil, err
+	}
+	if !isValidURL(fileURL) || !secutils.IsValidURL(fileURL) {
+		logger.Error(ctx, "Invalid or unsafe URL format")
+		return nil, werrors.NewBadRequestError(ErrInvalidURL.Error())
+	}
+	if safe, reason := secutils.IsSSRFSafeURL(fileURL); !safe {
+		logger.Errorf(ctx, "URL rejected for SSRF protection: %s, reason: %s", fileURL, reason)
+		return nil, werrors.NewBadRequestError(ErrInvalidURL.Error())
+	}
+
+	data, fileName, err := fetchDocument(ctx, fileURL, kb.URLFetchConfig)
+	if err != nil {
+		return nil, err
+	}
+	if title != "" {
+		fileName = withExtension(title, getFileType(fileName))
+	}
+	if err := checkImageFileConfig(ctx, kb, fileName); err != nil {
+		return nil, err
+	}
+	safeFilename, isValid := secutils.ValidateInput(fileName)
+	if !isValid {
+		logger.Errorf(ctx, "Invalid filename: %s", fileName)
+		return nil, werrors.NewValidationError("文件名包含非法字符")
+	}
+	sum := md5.Sum(data)
+	hash := hex.EncodeToString(sum[:])
+	fileSize := int64(len(data))
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	exists, existingKnowledge, err := s.repo.CheckKnowledgeExists(ctx, tenantID, kbID, &types.KnowledgeCheckParams{
+		Type:     "file",
+		FileName: safeFilename,
+		FileSize: fileSize,
+		FileHash: hash,
+	})
+	if err != nil {
+		logger.Errorf(ctx, "Failed to check knowledge existence: %v", err)
+		return nil, err
+	}
+	if exists {
+		logger.Infof(ctx, "Fetched file already exists: %s", safeFilename)
+		if err := s.repo.UpdateKnowledgeColumn(ctx, existingKnowledge.ID, "created_at", time.Now()); err != nil {
+			logger.Errorf(ctx, "Failed to update existing knowledge: %v", err)
+			return nil, err
+		}
+		return existingKnowledge, types.NewDuplicateFileError(existingKnowledge)
+	}
+
+	if err := s.checkKnowledgeQuota(ctx, ""); err != nil {
+		return nil, err
+	}
+
+	captureMethod := types.KnowledgeCaptureMethodFile
+	if getFileType(safeFilename) == "pdf" {
+		captureMethod = types.KnowledgeCaptureMethodPDF
+	}
+	capturedBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	knowledge := &types.Knowledge{
+		TenantID:         tenantID,
+		KnowledgeBaseID:  kbID,
+		TagID:            tagID,
+		Type:             "file",
+		Title:            safeFilename,
+		Source:           fileURL,
+		FileName:         safeFilename,
+		FileType:         getFileType(safeFilename),
+		FileSize:         fileSize,
+		FileHash:         hash,
+		ParseStatus:      "pending",
+		EnableStatus:     "disabled",
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+		EmbeddingModelID: kb.EmbeddingModelID,
+		Capture:          types.NewKnowledgeCapture(fileURL, captureMethod, capturedBy),
+	}
+	if err := s.repo.CreateKnowledge(ctx, knowledge); err != nil {
+		logger.Errorf(ctx, "Failed to create knowledge record: %v", err)
+		return nil, err
+	}
+	filePath, err := s.fileSvc.SaveBytes(ctx, data, tenantID, safeFilename, false)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to save fetched file, knowledge ID: %s, error: %v", knowledge.ID, err)
+		return nil, err
+	}
+	knowledge.FilePath = filePath
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		logger.Errorf(ctx, "Failed to update knowledge with file path, ID: %s, error: %v", knowledge.ID, err)
+		return nil, err
+	}
+	s.webhookService.Publish(ctx, tenantID, types.WebhookEventKnowledgeCreated, types.NewWebhookKnowledgeData(knowledge))
+
+	s.enqueueKnowledgeFileProcessing(ctx, kb, knowledge, enableMultimodel)
+	return knowledge, nil
+}
+
+// enqueueKnowledgeFileProcessing queues the parsing of a knowledge file saved to storage, plus the summary of data
+// tables. Failures are only logged, the knowledge stays pending and can be reparsed.
+func (s *knowledgeService) enqueueKnowledgeFileProcessing(ctx context.Context,
+	kb *types.KnowledgeBase, knowledge *types.Knowledge, enableMultimodel *bool,
+) {
+	enableMultimodelValue := kb.IsMultimodalEnabled()
+	if enableMultimodel != nil {
+		enableMultimodelValue = *enableMultimodel
+	}
+	enableQuestionGeneration := false
+	questionCount := 3
+	if kb.QuestionGenerationConfig != nil && kb.QuestionGenerationConfig.Enabled {
+		enableQuestionGeneration = true
+		if kb.QuestionGenerationConfig.QuestionCount > 0 {
+			questionCount = kb.QuestionGenerationConfig.QuestionCount
+		}
+	}
+	payloadBytes, err := json.Marshal(types.DocumentProcessPayload{
+		TenantID:                 knowledge.TenantID,
+		KnowledgeID:              knowledge.ID,
+		KnowledgeBaseID:          knowledge.KnowledgeBaseID,
+		FilePath:                 knowledge.FilePath,
+		FileName:                 knowledge.FileName,
+		FileType:                 knowledge.FileType,
+		EnableMultimodel:         enableMultimodelValue,
+		EnableQuestionGeneration: enableQuestionGeneration,
+		QuestionCount:            questionCount,
+		TraceContext:             tracing.Inject(ctx),
+	})
+	if err != nil {
+		logger.Errorf(ctx, "Failed to marshal document process task payload: %v", err)
+		return
+	}
+	info, err := s.task.Enqueue(newDocumentProcessTask(payloadBytes))
+	if err != nil {
+		logger.Errorf(ctx, "Failed to enqueue document process task: %v", err)
+		return
+	}
+	logger.Infof(ctx, "Enqueued document process task: id=%s queue=%s knowledge_id=%s size=%d",
+		info.ID, info.Queue, knowledge.ID, knowledge.FileSize)
+
+	if slices.Contains([]string{"csv", "xlsx", "xls"}, knowledge.FileType) {
+		NewDataTableSummaryTask(ctx, s.task, knowledge.TenantID, knowledge.ID, kb.SummaryModelID, kb.EmbeddingModelID)
+	}
+}
+
+// fetchDocument downloads the document at fileURL, up to the maximum upload size, and returns it with its file
+// name. Redirects and the connection are checked against SSRF like the URL itself. The headers and cookies of
+// fetchConfig are only sent to the hosts it lists, they are dropped on redirects to other hosts.
+func fetchDocument(ctx context.Context, fileURL string, fetchConfig *types.URLFetchConfig) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, "", werrors.NewBadRequestError(ErrInvalidURL.Error())
+	}
+	client := newFetchClient(req, fetchConfig, knowledgeFetchTimeout)
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to fetch %s: %v", fileURL, err)
+		return nil, "", werrors.NewBadRequestError("failed to fetch the URL").WithDetails(err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", werrors.NewBadRequestError(fmt.Sprintf("failed to fetch the URL: status %d", resp.StatusCode))
+	}
+
+	// The final URL names the file when the link redirected to it
+	fileName, isPage := fetchedFileName(resp.Request.URL, resp.Header.Get("Content-Disposition"),
+		resp.Header.Get("Content-Type"))
+	if isPage {
+		return nil, "", werrors.NewBadRequestError(
+			"the URL serves a web page, import web pages with POST /knowledge-bases/:id/knowledge/url")
+	}
+
+	maxSize := secutils.GetMaxFileSize()
+	tooLarge := werrors.NewBadRequestError(fmt.Sprintf("文件大小不能超过%dMB", secutils.GetMaxFileSizeMB()))
+	if resp.ContentLength > maxSize {
+		return nil, "", tooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		logger.Errorf(ctx, "Failed to read %s: %v", fileURL, err)
+		return nil, "", werrors.NewBadRequestError("failed to fetch the URL").WithDetails(err.Error())
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", tooLarge
+	}
+	// Servers often send PDFs as application/octet-stream under a name without extension, the content tells better
+	if !isValidFileType(fileName) && bytes.HasPrefix(data, pdfMagic) {
+		fileName = withExtension(fileName, "pdf")
+	}
+	if !isValidFileType(fileName) {
+		return nil, "", werrors.NewBadRequestError(
+			fmt.Sprintf("unsupported file type of %s, supported types are those of file uploads", fileName))
+	}
+	if strings.EqualFold(getFileType(fileName), "zip") {
+		if err := checkLatexArchive(bytes.NewReader(data), int64(len(data))); err != nil {
+			return nil, "", err
+		}
+	}
+	return data, fileName, nil
+}
+
+// newFetchClient returns an SSRF-safe client for req, setting the default User-Agent and the fetch config of a
+// knowledge base on req. The headers and cookies of the config are dropped on redirects to another host, or to a
+// host the config does not list.
+func newFetchClient(req *http.Request, fetchConfig *types.URLFetchConfig, timeout time.Duration) *http.Client {
+	clientConfig := secutils.DefaultSSRFSafeHTTPClientConfig()
+	clientConfig.Timeout = timeout
+	client := secutils.NewSSRFSafeHTTPClient(clientConfig)
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; WeKnora/1.0)")
+	if fetchConfig == nil {
+		return client
+	}
+	setFetchHeaders(req, fetchConfig)
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if next.URL.Host != req.URL.Host || !fetchConfig.SendsTo(next.URL.Hostname()) {
+			for name := range fetchConfig.Headers {
+				next.Header.Del(name)
+			}
+			next.Header.Del("Cookie")
+		}
+		return checkRedirect(next, via)
+	}
+	return client
+}
+
+// setFetchHeaders sets the User-Agent of a knowledge base URL fetch config on a request, and its headers and
+// cookies when the config lists the host of the request
+func setFetchHeaders(req *http.Request, fetchConfig *types.URLFetchConfig) {
+	if fetchConfig.UserAgent != "" {
+		req.Header.Set("User-Agent", fetchConfig.UserAgent)
+	}
+	if !fetchConfig.SendsTo(req.URL.Hostname()) {
+		return
+	}
+	for name, value := range fetchConfig.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range fetchConfig.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+}
+
+// fetchedFileMediaTypes maps the media types of documents to the file type they are imported as, for files whose
+// name has no extension
+var fetchedFileMediaTypes = map[string]string{
+	"application/pdf":    "pdf",
+	"application/msword": "doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+	"application/vnd.ms-excel": "xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": "xlsx",
+	"text/csv":      "csv",
+	"text/markdown": "md",
+	"text/plain":    "txt",
+	"image/png":     "png",
+	"image/jpeg":    "jpg",
+	"image/gif":     "gif",
+}
+
+// fetchedFileName names a fetched file after the file name suggested by its Content-Disposition, else after the last
+// segment of its URL, adding the extension of its Content-Type when the name has none. isPage reports an HTML page
+// served inline, which is a web page rather than a file to import.
+func fetchedFileName(u *neturl.URL, contentDisposition string, contentType string) (name string, isPage bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	disposition, params, err := mime.ParseMediaType(contentDisposition)
+	attachment := err == nil && disposition == "attachment"
+	if mediaType == "text/html" && !attachment {
+		return "", true
+	}
+
+	if err == nil && params["filename"] != "" {
+		name = path.Base(strings.ReplaceAll(params["filename"], "\\", "/"))
+	} else if base := path.Base(u.Path); base != "." && base != "/" {
+		name = base
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = knowledgeFetchDefaultName
+	}
+	if fileType, ok := fetchedFileMediaTypes[mediaType]; ok && !isValidFileType(name) {
+		name = withExtension(name, fileType)
+	}
+	return name, false
+}
+
+// withExtension appends .ext to a file name that does not end with it
+func withExtension(name string, ext string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = knowledgeFetchDefaultName
+	}
+	if !strings.HasSuffix(strings.ToLower(name), "."+ext) {
+		name += "." + ext
+	}
+	return name
+}
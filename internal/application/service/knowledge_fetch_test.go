@@ -0,0 +1,75 @@
+package service
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestFetchedFileName(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		disposition string
+		contentType string
+		want        string
+		wantPage    bool
+	}{
+		{
+			name:        "attachment filename",
+			url:         "https://intranet.example.com/download?id=42",
+			disposition: `attachment; filename="Q3 report.docx"`,
+			contentType: "application/octet-stream",
+			want:        "Q3 report.docx",
+		},
+		{
+			name:        "RFC 5987 filename",
+			url:         "https://intranet.example.com/download?id=43",
+			disposition: `attachment; filename*=UTF-8''%E9%A2%84%E7%AE%97.xlsx`,
+			contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			want:        "预算.xlsx",
+		},
+		{
+			name:        "path in filename",
+			url:         "https://intranet.example.com/download",
+			disposition: `attachment; filename="C:\\reports\\plan.docx"`,
+			want:        "plan.docx",
+		},
+		{
+			name:        "extension from media type",
+			url:         "https://intranet.example.com/files/7f3a",
+			contentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			want:        "7f3a.docx",
+		},
+		{
+			name:        "URL path",
+			url:         "https://arxiv.org/pdf/1706.03762.pdf",
+			contentType: "application/pdf",
+			want:        "1706.03762.pdf",
+		},
+		{
+			name:        "inline web page",
+			url:         "https://example.com/docs",
+			contentType: "text/html; charset=utf-8",
+			wantPage:    true,
+		},
+		{
+			name:        "HTML attachment",
+			url:         "https://example.com/export",
+			disposition: `attachment; filename="export.html"`,
+			contentType: "text/html",
+			want:        "export.html",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, isPage := fetchedFileName(u, tt.disposition, tt.contentType)
+			if got != tt.want || isPage != tt.wantPage {
+				t.Errorf("fetchedFileName() = %q, %v, want %q, %v", got, isPage, tt.want, tt.wantPage)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// knowledgeFileCacheKeyPrefix prefixes the Redis hash caching the file of a knowledge
+const knowledgeFileCacheKeyPrefix = "knowledge_file:"
+
+const (
+	defaultKnowledgeFileCacheMaxBytes = 4 << 20
+	defaultKnowledgeFileCacheTTL      = time.Hour
+)
+
+// knowledgeFileCache is a read-through cache of knowledge files in Redis, so that previews and downloads of the same
+// file are served without going back to the storage backend. The file of a knowledge is kept in one hash together
+// with the UpdatedAt of the knowledge it was read for; an entry of another version is a miss and gets replaced.
+type knowledgeFileCache struct {
+	redisClient *redis.Client
+	cfg         *config.Config
+}
+
+// newKnowledgeFileCache creates the file cache of the knowledge service, it is disabled without Redis
+func newKnowledgeFileCache(redisClient *redis.Client, cfg *config.Config) *knowledgeFileCache {
+	return &knowledgeFileCache{redisClient: redisClient, cfg: cfg}
+}
+
+// settings returns whether the cache is enabled, the largest file it keeps and how long it keeps it
+func (c *knowledgeFileCache) settings() (bool, int64, time.Duration) {
+	if c.redisClient == nil || c.cfg == nil {
+		return false, 0, 0
+	}
+	kb := c.cfg.Current().KnowledgeBase
+	if kb == nil || kb.FileCache == nil {
+		return false, 0, 0
+	}
+	settings := kb.FileCache
+	maxBytes, ttl := settings.MaxFileBytes, settings.TTL
+	if maxBytes <= 0 {
+		maxBytes = defaultKnowledgeFileCacheMaxBytes
+	}
+	if ttl <= 0 {
+		ttl = defaultKnowledgeFileCacheTTL
+	}
+	return settings.Enabled, maxBytes, ttl
+}
+
+func (c *knowledgeFileCache) key(knowledgeID string) string {
+	return knowledgeFileCacheKeyPrefix + knowledgeID
+}
+
+// knowledgeFileVersion is the version of the file of a knowledge, any update of the knowledge moves it
+func knowledgeFileVersion(knowledge *types.Knowledge) string {
+	return strconv.FormatInt(knowledge.UpdatedAt.UnixNano(), 10)
+}
+
+// open returns the file of the knowledge from the cache. On a miss the file is read with load and cached when it
+// is small enough; larger files are streamed from storage as they are.
+func (c *knowledgeFileCache) open(ctx context.Context,
+	knowledge *types.Knowledge, load func() (io.ReadCloser, error),
+) (io.ReadCloser, error) {
+	enabled, maxBytes, ttl := c.settings()
+	if !enabled || knowledge.FileSize > maxBytes {
+		metrics.KnowledgeFileCache.WithLabelValues(metrics.CacheBypass).Inc()
+		return load()
+	}
+
+	key, version := c.key(knowledge.ID), knowledgeFileVersion(knowledge)
+	values, err := c.redisClient.HMGet(ctx, key, "version", "data").Result()
+	if err != nil {
+		logger.Warnf(ctx, "Failed to read cached file of knowledge %s: %v", knowledge.ID, err)
+	} else if cached, ok := values[0].(string); ok && cached == version {
+		if data, ok := values[1].(string); ok {
+			metrics.KnowledgeFileCache.WithLabelValues(metrics.CacheHit).Inc()
+			return io.NopCloser(strings.NewReader(data)), nil
+		}
+	}
+	metrics.KnowledgeFileCache.WithLabelValues(metrics.CacheMiss).Inc()
+
+	file, err := load()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		// The recorded size was short of the file, send what was read and stream the rest
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), file), file}, nil
+	}
+	file.Close()
+
+	pipe := c.redisClient.TxPipeline()
+	pipe.HSet(ctx, key, "version", version, "data", data)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warnf(ctx, "Failed to cache file of knowledge %s: %v", knowledge.ID, err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// invalidate drops the cached files of the knowledge, for files deleted or replaced in storage
+func (c *knowledgeFileCache) invalidate(ctx context.Context, knowledgeIDs ...string) {
+	if c.redisClient == nil || len(knowledgeIDs) == 0 {
+		return
+	}
+	keys := make([]string, len(knowledgeIDs))
+	for i, id := range knowledgeIDs {
+		keys[i] = c.key(id)
+	}
+	if err := c.redisClient.Del(ctx, keys...).Err(); err != nil {
+		logger.Warnf(ctx, "Failed to invalidate cached files of %d knowledge: %v", len(knowledgeIDs), err)
+	}
+}
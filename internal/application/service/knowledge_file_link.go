@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// knowledgeFileLinkTokenType is the type claim of file link tokens, distinguishing them from login tokens and
+	// share links
+	knowledgeFileLinkTokenType = "knowledge_file_link"
+	// KnowledgeFileLinkPath is the API path serving the file of a link, followed by its token
+	KnowledgeFileLinkPath = "/api/v1/shared/files/"
+	// DefaultKnowledgeFileLinkTTL is how long file links stay valid when no expiry is requested
+	DefaultKnowledgeFileLinkTTL = 24 * time.Hour
+	// MaxKnowledgeFileLinkTTL is the longest a file link may stay valid
+	MaxKnowledgeFileLinkTTL = 30 * 24 * time.Hour
+)
+
+// invalidFileLinkError is returned for file links that are malformed, tampered with, expired, revoked or used up
+func invalidFileLinkError() error {
+	return werrors.NewUnauthorizedError("invalid, expired or revoked file link")
+}
+
+// knowledgeFileLinkService implements KnowledgeFileLinkService interface
+type knowledgeFileLinkService struct {
+	repo      interfaces.KnowledgeFileLinkRepository
+	kgService interfaces.KnowledgeService
+	kgRepo    interfaces.KnowledgeRepository
+}
+
+// NewKnowledgeFileLinkService creates a new knowledge file link service
+func NewKnowledgeFileLinkService(
+	repo interfaces.KnowledgeFileLinkRepository,
+	kgService interfaces.KnowledgeService,
+	kgRepo interfaces.KnowledgeRepository,
+) interfaces.KnowledgeFileLinkService {
+	return &knowledgeFileLinkService{repo: repo, kgService: kgService, kgRepo: kgRepo}
+}
+
+// CreateLink signs a link to the file of a knowledge of the tenant in the context
+func (s *knowledgeFileLinkService) CreateLink(ctx context.Context,
+	knowledgeID string, req *types.CreateKnowledgeFileLinkRequest,
+) (*types.KnowledgeFileLinkWithURL, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	scope := req.Scope
+	if scope == "" {
+		scope = types.KnowledgeFileLinkScopeDownload
+	}
+	if !scope.IsValid() {
+		return nil, werrors.NewBadRequestError("scope must be view or download")
+	}
+	if req.ExpiresInHours < 0 || req.MaxDownloads < 0 {
+		return nil, werrors.NewBadRequestError("expires_in_hours and max_downloads must not be negative")
+	}
+	ttl := time.Duration(req.ExpiresInHours) * time.Hour
+	if ttl == 0 {
+		ttl = DefaultKnowledgeFileLinkTTL
+	}
+	if ttl > MaxKnowledgeFileLinkTTL {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("file link expiry cannot exceed %d hours", int(MaxKnowledgeFileLinkTTL.Hours())))
+	}
+
+	knowledge, err := s.kgRepo.GetKnowledgeByID(ctx, tenantID, knowledgeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeNotFound) {
+			return nil, werrors.NewNotFoundError("Knowledge not found")
+		}
+		return nil, err
+	}
+	if knowledge.FilePath == "" {
+		return nil, werrors.NewBadRequestError("knowledge has no file")
+	}
+
+	createdBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	link := &types.KnowledgeFileLink{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		KnowledgeID:  knowledgeID,
+		Scope:        scope,
+		MaxDownloads: req.MaxDownloads,
+		ExpiresAt:    time.Now().Add(ttl),
+		CreatedBy:    createdBy,
+	}
+	if err := s.repo.CreateLink(ctx, link); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+
+	// The token only names the link, its scope, downloads and revocation are read from the link on every fetch
+	claims := jwt.MapClaims{
+		"link_id":   link.ID,
+		"tenant_id": tenantID,
+		"exp":       link.ExpiresAt.Unix(),
+		"iat":       link.CreatedAt.Unix(),
+		"type":      knowledgeFileLinkTokenType,
+	}
+	token, err := signJwt(claims)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Created %s link %s to knowledge %s until %s",
+		scope, link.ID, knowledgeID, link.ExpiresAt.Format(time.RFC3339))
+	return &types.KnowledgeFileLinkWithURL{
+		KnowledgeFileLink: link,
+		Token:             token,
+		Path:              KnowledgeFileLinkPath + token,
+	}, nil
+}
+
+// ListLinks lists the links to the file of a knowledge of the tenant in the context
+func (s *knowledgeFileLinkService) ListLinks(ctx context.Context,
+	knowledgeID string,
+) ([]*types.KnowledgeFileLink, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	return s.repo.ListLinks(ctx, tenantID, knowledgeID)
+}
+
+// RevokeLink revokes a link to the file of a knowledge of the tenant in the context
+func (s *knowledgeFileLinkService) RevokeLink(ctx context.Context, knowledgeID string, id string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := s.repo.RevokeLink(ctx, tenantID, knowledgeID, id); err != nil {
+		if errors.Is(err, repository.ErrKnowledgeFileLinkNotFound) {
+			return werrors.NewNotFoundError(err.Error())
+		}
+		return err
+	}
+	logger.Infof(ctx, "Revoked link %s to knowledge %s", id, knowledgeID)
+	return nil
+}
+
+// OpenLink checks a link token, opens the file of its knowledge and counts the fetch. Links to trashed or
+// archived knowledge are rejected.
+func (s *knowledgeFileLinkService) OpenLink(ctx context.Context,
+	token string,
+) (*types.KnowledgeFileLink, io.ReadCloser, string, error) {
+	parsed, err := jwt.Parse(token, jwtKeyFunc)
+	if err != nil || !parsed.Valid {
+		logger.Warnf(ctx, "Rejected file link: %v", err)
+		return nil, nil, "", invalidFileLinkError()
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["type"] != knowledgeFileLinkTokenType {
+		return nil, nil, "", invalidFileLinkError()
+	}
+	linkID, _ := claims["link_id"].(string)
+	tenantID, _ := claims["tenant_id"].(float64)
+	if linkID == "" || tenantID <= 0 {
+		return nil, nil, "", invalidFileLinkError()
+	}
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, uint64(tenantID))
+	link, err := s.repo.GetLink(ctx, uint64(tenantID), linkID)
+	if errors.Is(err, repository.ErrKnowledgeFileLinkNotFound) {
+		return nil, nil, "", invalidFileLinkError()
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !link.IsUsable(time.Now()) {
+		logger.Warnf(ctx, "Rejected file link %s: revoked, expired or used up", link.ID)
+		return nil, nil, "", invalidFileLinkError()
+	}
+	// Links stop serving while their knowledge is in the trash or archived
+	knowledge, err := s.kgRepo.GetKnowledgeByID(ctx, link.TenantID, link.KnowledgeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeNotFound) {
+			return nil, nil, "", werrors.NewNotFoundError("Knowledge not found")
+		}
+		return nil, nil, "", err
+	}
+	if knowledge.TrashedAt != nil || knowledge.ArchivedAt != nil {
+		logger.Warnf(ctx, "Rejected file link %s: knowledge %s is trashed or archived", link.ID, knowledge.ID)
+		return nil, nil, "", werrors.NewNotFoundError("Knowledge not found")
+	}
+
+	// Opened before counting, so a fetch failing on storage does not use up a download
+	file, filename, err := s.kgService.GetKnowledgeFile(ctx, link.KnowledgeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeNotFound) {
+			return nil, nil, "", werrors.NewNotFoundError("Knowledge not found")
+		}
+		return nil, nil, "", err
+	}
+	// Counted atomically, a concurrent fetch may have used the last download
+	consumed, err := s.repo.ConsumeDownload(ctx, link.TenantID, link.ID)
+	if err != nil {
+		file.Close()
+		return nil, nil, "", err
+	}
+	if !consumed {
+		file.Close()
+		logger.Warnf(ctx, "Rejected file link %s: revoked, expired or used up", link.ID)
+		return nil, nil, "", invalidFileLinkError()
+	}
+	logger.Infof(ctx, "Serving knowledge %s through link %s", link.KnowledgeID, link.ID)
+	return link, file, filename, nil
+}
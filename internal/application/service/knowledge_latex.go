@@ -0,0 +1,45 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"path"
+	"strings"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+)
+
+// latexMainScanBytes is how much of each .tex file of an archive is searched for \documentclass
+const latexMainScanBytes = 64 * 1024
+
+// latexDocumentClass starts the main file of a LaTeX project
+var latexDocumentClass = []byte(`\documentclass`)
+
+// errNotLatexArchive is returned for a zip archive without a LaTeX project
+var errNotLatexArchive = werrors.NewBadRequestError(
+	"仅支持上传 LaTeX 项目的 zip 压缩包（需包含带 \\documentclass 的 .tex 主文件）")
+
+// checkLatexArchive accepts a zip archive only when it holds a LaTeX project, a .tex file with \documentclass: it is
+// the only kind of archive the docreader parses, \input and \include being resolved across the archive.
+func checkLatexArchive(r io.ReaderAt, size int64) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return werrors.NewBadRequestError("无法读取 zip 压缩包").WithDetails(err.Error())
+	}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(f.Name), ".tex") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		head, _ := io.ReadAll(io.LimitReader(rc, latexMainScanBytes))
+		rc.Close()
+		if bytes.Contains(head, latexDocumentClass) {
+			return nil
+		}
+	}
+	return errNotLatexArchive
+}
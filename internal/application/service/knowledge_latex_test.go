@@ -0,0 +1,65 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func testZip(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestCheckLatexArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr bool
+	}{
+		{
+			name: "project",
+			files: map[string]string{
+				"paper/main.tex":         "% main\n\\documentclass{article}\n\\begin{document}\\input{intro}\\end{document}",
+				"paper/intro.tex":        "\\section{Intro}",
+				"paper/figures/plot.png": "png",
+				"paper/references.bib":   "@article{}",
+			},
+		},
+		{
+			name:    "sections only",
+			files:   map[string]string{"intro.tex": "\\section{Intro}"},
+			wantErr: true,
+		},
+		{
+			name:    "other archive",
+			files:   map[string]string{"report.pdf": "%PDF-1.4", "notes.txt": "\\documentclass"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testZip(t, tt.files)
+			err := checkLatexArchive(r, r.Size())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkLatexArchive() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := checkLatexArchive(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Error("checkLatexArchive() should reject data that is not a zip archive")
+	}
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// lifecycleBatchSize limits how many knowledge items are handled per knowledge base in one run
+const lifecycleBatchSize = 200
+
+// SetKnowledgeExpiry sets or clears the explicit expiration time of a knowledge entry.
+func (s *knowledgeService) SetKnowledgeExpiry(
+	ctx context.Context,
+	id string,
+	expiresAt *time.Time,
+) (*types.Knowledge, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return nil, werrors.NewBadRequestError("过期时间不能早于当前时间")
+	}
+	knowledge.ExpiresAt = expiresAt
+	// A new expiration date needs a new notification
+	knowledge.ExpiryNotifiedAt = nil
+	knowledge.UpdatedAt = time.Now()
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return nil, err
+	}
+	return knowledge, nil
+}
+
+// ArchiveKnowledge archives a knowledge entry, excluding all of its chunks from retrieval.
+func (s *knowledgeService) ArchiveKnowledge(ctx context.Context, id string) (*types.Knowledge, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if knowledge.TrashedAt != nil {
+		return nil, werrors.NewBadRequestError("知识已在回收站中")
+	}
+	if knowledge.ArchivedAt != nil {
+		return knowledge, nil
+	}
+	if err := s.setKnowledgeArchived(ctx, knowledge, true); err != nil {
+		return nil, err
+	}
+	return knowledge, nil
+}
+
+// UnarchiveKnowledge restores an archived knowledge entry to retrieval.
+// The chunks that were enabled when it was archived are enabled again.
+func (s *knowledgeService) UnarchiveKnowledge(ctx context.Context, id string) (*types.Knowledge, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if knowledge.TrashedAt != nil {
+		return nil, werrors.NewBadRequestError("知识已在回收站中")
+	}
+	if knowledge.ArchivedAt == nil {
+		return knowledge, nil
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	// Unarchiving expired knowledge starts a new retention period, otherwise the next run archives it again
+	now := time.Now()
+	if knowledge.ExpiresAt != nil && knowledge.ExpiresAt.Before(now) {
+		knowledge.ExpiresAt = nil
+		knowledge.ExpiryNotifiedAt = nil
+	}
+	if knowledge.ExpiresAt == nil && kb.RetentionConfig != nil && kb.RetentionConfig.Enabled &&
+		kb.RetentionConfig.MaxAgeDays > 0 {
+		if expiry := knowledgeExpiryTime(knowledge, kb.RetentionConfig); !expiry.After(now) {
+			expiresAt := now.AddDate(0, 0, kb.RetentionConfig.MaxAgeDays)
+			knowledge.ExpiresAt = &expiresAt
+			knowledge.ExpiryNotifiedAt = nil
+		}
+	}
+	if err := s.setKnowledgeArchived(ctx, knowledge, false); err != nil {
+		return nil, err
+	}
+	return knowledge, nil
+}
+
+// setKnowledgeArchived toggles chunk availability and persists the archive state.
+func (s *knowledgeService) setKnowledgeArchived(ctx context.Context, knowledge *types.Knowledge, archived bool) error {
+	var changed int
+	var err error
+	if archived {
+		changed, err = s.suspendKnowledgeChunks(ctx, knowledge)
+	} else {
+		changed, err = s.resumeKnowledgeChunks(ctx, knowledge)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if archived {
+		knowledge.ArchivedAt = &now
+	} else {
+		knowledge.ArchivedAt = nil
+	}
+	knowledge.UpdatedAt = now
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return err
+	}
+	if archived {
+		s.invalidateSemanticCache(ctx, []*types.Knowledge{knowledge})
+	} else {
+		s.invalidateKnowledgeBaseSemanticCache(ctx, knowledge.KnowledgeBaseID)
+	}
+	logger.Infof(ctx, "Knowledge %s archived=%v, %d chunks updated", knowledge.ID, archived, changed)
+	return nil
+}
+
+// suspendKnowledgeChunks disables the chunks of a knowledge being archived or trashed and records the ones that
+// were enabled in the knowledge, for resumeKnowledgeChunks. The caller saves the knowledge.
+// Returns the number of chunks whose status changed.
+func (s *knowledgeService) suspendKnowledgeChunks(ctx context.Context, knowledge *types.Knowledge) (int, error) {
+	changedIDs, err := s.setKnowledgeChunksEnabled(ctx, knowledge, nil, false)
+	if err != nil {
+		return 0, err
+	}
+	suspended := make(types.StringArray, 0, len(knowledge.SuspendedChunkIDs)+len(changedIDs))
+	suspended = append(suspended, knowledge.SuspendedChunkIDs...)
+	for _, id := range changedIDs {
+		if !slices.Contains(knowledge.SuspendedChunkIDs, id) {
+			suspended = append(suspended, id)
+		}
+	}
+	knowledge.SuspendedChunkIDs = suspended
+	return len(changedIDs), nil
+}
+
+// resumeKnowledgeChunks enables again the chunks recorded by suspendKnowledgeChunks, chunks disabled before are
+// left disabled. Knowledge suspended before chunks were recorded gets all of its chunks enabled. The caller saves
+// the knowledge.
+// Returns the number of chunks whose status changed.
+func (s *knowledgeService) resumeKnowledgeChunks(ctx context.Context, knowledge *types.Knowledge) (int, error) {
+	ids := []string(knowledge.SuspendedChunkIDs)
+	if ids == nil {
+		logger.Warnf(ctx, "Knowledge %s has no record of its suspended chunks, enabling all", knowledge.ID)
+	} else if len(ids) == 0 {
+		return 0, nil
+	}
+	changedIDs, err := s.setKnowledgeChunksEnabled(ctx, knowledge, ids, true)
+	if err != nil {
+		return 0, err
+	}
+	knowledge.SuspendedChunkIDs = nil
+	return len(changedIDs), nil
+}
+
+// setKnowledgeChunksEnabled enables or disables the chunks of a knowledge among ids, or all of its chunks when
+// ids is nil, in DB and retrieval engines.
+// Returns the IDs of chunks whose status changed.
+func (s *knowledgeService) setKnowledgeChunksEnabled(
+	ctx context.Context,
+	knowledge *types.Knowledge,
+	ids []string,
+	enabled bool,
+) ([]string, error) {
+	var changedIDs []string
+	var err error
+	if ids == nil {
+		changedIDs, err = s.chunkRepo.UpdateChunkEnabledByKnowledgeID(ctx, knowledge.TenantID, knowledge.ID, enabled)
+	} else {
+		changedIDs, err = s.chunkRepo.UpdateChunkEnabledByIDs(ctx, knowledge.TenantID, knowledge.ID, ids, enabled)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(changedIDs) == 0 {
+		return nil, nil
+	}
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return nil, err
+	}
+	statusMap := make(map[string]bool, len(changedIDs))
+	for _, chunkID := range changedIDs {
+		statusMap[chunkID] = enabled
+	}
+	if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, statusMap); err != nil {
+		return nil, err
+	}
+	return changedIDs, nil
+}
+
+// ProcessKnowledgeLifecycle enforces retention policies: it sends expiring notifications to webhooks and
+// archives or deletes expired knowledge. Scheduled periodically.
+func (s *knowledgeService) ProcessKnowledgeLifecycle(ctx context.Context, t *asynq.Task) error {
+	var payload types.KnowledgeLifecyclePayload
+	if len(t.Payload()) > 0 {
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			logger.Errorf(ctx, "Failed to unmarshal knowledge lifecycle payload: %v", err)
+			return err
+		}
+	}
+
+	kbs, err := s.listLifecycleKnowledgeBases(ctx, payload.KnowledgeBaseID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list knowledge bases for lifecycle: %v", err)
+		return err
+	}
+	logger.Infof(ctx, "Processing knowledge lifecycle for %d knowledge bases", len(kbs))
+
+	tenants := make(map[uint64]*types.Tenant)
+	now := time.Now()
+	for _, kb := range kbs {
+		tenant, ok := tenants[kb.TenantID]
+		if !ok {
+			tenant, err = s.tenantRepo.GetTenantByID(ctx, kb.TenantID)
+			if err != nil {
+				logger.Warnf(ctx, "Failed to get tenant %d for lifecycle: %v", kb.TenantID, err)
+				continue
+			}
+			tenants[kb.TenantID] = tenant
+		}
+		kbCtx := context.WithValue(ctx, types.TenantIDContextKey, kb.TenantID)
+		kbCtx = context.WithValue(kbCtx, types.TenantInfoContextKey, tenant)
+		if err := s.enforceRetention(kbCtx, kb, now); err != nil {
+			// Keep going with other knowledge bases
+			logger.Errorf(kbCtx, "Failed to enforce retention for KB %s: %v", kb.ID, err)
+		}
+	}
+	return nil
+}
+
+// listLifecycleKnowledgeBases returns knowledge bases with an enabled retention policy or
+// with knowledge carrying an explicit expiration time.
+func (s *knowledgeService) listLifecycleKnowledgeBases(ctx context.Context, kbID string) ([]*types.KnowledgeBase, error) {
+	kbRepo := s.kbService.GetRepository()
+	if kbID != "" {
+		kb, err := kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			return nil, err
+		}
+		return []*types.KnowledgeBase{kb}, nil
+	}
+
+	expiryKBIDs, err := s.repo.ListKnowledgeBaseIDsWithExpiry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]struct{}, len(expiryKBIDs))
+	for _, id := range expiryKBIDs {
+		selected[id] = struct{}{}
+	}
+
+	all, err := kbRepo.ListKnowledgeBases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.KnowledgeBase, 0)
+	for _, kb := range all {
+		_, hasExpiry := selected[kb.ID]
+		if hasExpiry || (kb.RetentionConfig != nil && kb.RetentionConfig.Enabled) {
+			result = append(result, kb)
+		}
+	}
+	return result, nil
+}
+
+// enforceRetention applies the retention policy of a single knowledge base.
+// Without an enabled policy, only explicit expiration times are honored and expired knowledge is archived.
+func (s *knowledgeService) enforceRetention(ctx context.Context, kb *types.KnowledgeBase, now time.Time) error {
+	var policy *types.RetentionConfig
+	if kb.RetentionConfig != nil && kb.RetentionConfig.Enabled {
+		policy = kb.RetentionConfig
+	}
+	action := policy.GetAction()
+
+	maxAgeCutoff := func(at time.Time) *time.Time {
+		if policy == nil || policy.MaxAgeDays <= 0 {
+			return nil
+		}
+		cutoff := at.AddDate(0, 0, -policy.MaxAgeDays)
+		return &cutoff
+	}
+
+	// Notify before expiry
+	if policy != nil && policy.NotifyBeforeDays > 0 {
+		notifyAt := now.AddDate(0, 0, policy.NotifyBeforeDays)
+		expiring, err := s.repo.ListDueKnowledge(ctx, kb.ID, notifyAt, maxAgeCutoff(notifyAt), true, lifecycleBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, knowledge := range expiring {
+			s.notifyKnowledgeExpiring(ctx, kb, knowledge, policy, action, now)
+		}
+	}
+
+	expired, err := s.repo.ListDueKnowledge(ctx, kb.ID, now, maxAgeCutoff(now), false, lifecycleBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	logger.Infof(ctx, "KB %s: %d knowledge expired, action: %s", kb.ID, len(expired), action)
+
+	handled := expired
+	if action == types.RetentionActionDelete {
+		ids := make([]string, 0, len(expired))
+		for _, knowledge := range expired {
+			ids = append(ids, knowledge.ID)
+		}
+		if err := s.DeleteKnowledgeList(ctx, ids); err != nil {
+			return err
+		}
+	} else {
+		handled = make([]*types.Knowledge, 0, len(expired))
+		for _, knowledge := range expired {
+			if err := s.setKnowledgeArchived(ctx, knowledge, true); err != nil {
+				logger.Errorf(ctx, "Failed to archive expired knowledge %s: %v", knowledge.ID, err)
+				continue
+			}
+			handled = append(handled, knowledge)
+		}
+	}
+
+	for _, knowledge := range handled {
+		s.webhookService.Publish(ctx, knowledge.TenantID, types.WebhookEventKnowledgeExpired,
+			types.KnowledgeExpiryNotification{
+				TenantID:        knowledge.TenantID,
+				KnowledgeBaseID: kb.ID,
+				KnowledgeID:     knowledge.ID,
+				Title:           knowledge.Title,
+				ExpiresAt:       knowledgeExpiryTime(knowledge, policy),
+				Action:          action,
+			})
+	}
+	return nil
+}
+
+// notifyKnowledgeExpiring sends the expiring notification to the webhooks of the tenant once per expiration date.
+func (s *knowledgeService) notifyKnowledgeExpiring(
+	ctx context.Context,
+	kb *types.KnowledgeBase,
+	knowledge *types.Knowledge,
+	policy *types.RetentionConfig,
+	action string,
+	now time.Time,
+) {
+	notification := types.KnowledgeExpiryNotification{
+		TenantID:        knowledge.TenantID,
+		KnowledgeBaseID: kb.ID,
+		KnowledgeID:     knowledge.ID,
+		Title:           knowledge.Title,
+		ExpiresAt:       knowledgeExpiryTime(knowledge, policy),
+		Action:          action,
+	}
+	logger.Infof(ctx, "Knowledge %s in KB %s expires at %s (action: %s)",
+		knowledge.ID, kb.ID, notification.ExpiresAt.Format(time.RFC3339), action)
+	s.webhookService.Publish(ctx, knowledge.TenantID, types.WebhookEventKnowledgeExpiring, notification)
+	if err := s.repo.UpdateKnowledgeColumn(ctx, knowledge.ID, "expiry_notified_at", now); err != nil {
+		logger.Warnf(ctx, "Failed to mark knowledge %s as notified: %v", knowledge.ID, err)
+	}
+}
+
+// knowledgeExpiryTime returns the effective expiration time of a knowledge under a policy.
+func knowledgeExpiryTime(knowledge *types.Knowledge, policy *types.RetentionConfig) time.Time {
+	if knowledge.ExpiresAt != nil {
+		return *knowledge.ExpiresAt
+	}
+	if policy != nil && policy.MaxAgeDays > 0 {
+		return knowledge.CreatedAt.AddDate(0, 0, policy.MaxAgeDays)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/hibiken/asynq"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// linkCheckBatchSize limits how many sources are checked per knowledge base in one run, the rest are checked in
+	// the next runs
+	linkCheckBatchSize = 50
+	// linkCheckConcurrency is the number of sources of a knowledge base checked at once
+	linkCheckConcurrency = 4
+	// linkReportMaxItems bounds the knowledge listed by a link report
+	linkReportMaxItems = 500
+	// linkTitleMinSimilarity is the similarity to the first title seen below which a page is considered replaced
+	linkTitleMinSimilarity = 0.5
+	// linkFileMaxSizeRatio is the factor by which a document may grow or shrink before it is considered replaced
+	linkFileMaxSizeRatio = 2.0
+)
+
+// defaultLinkReportStatuses are the statuses a link report lists when none are requested
+var defaultLinkReportStatuses = []types.KnowledgeLinkStatus{
+	types.KnowledgeLinkDead, types.KnowledgeLinkChanged, types.KnowledgeLinkUnreachable,
+}
+
+// ProcessKnowledgeLinkCheck checks the source URL of the knowledge imported from the web that was not checked for
+// the link check interval, and records which sources are dead or now serve something else. Scheduled periodically.
+func (s *knowledgeService) ProcessKnowledgeLinkCheck(ctx context.Context, t *asynq.Task) error {
+	interval := secutils.GetKnowledgeLinkCheckInterval()
+	if interval <= 0 {
+		return nil
+	}
+	var payload types.KnowledgeLinkCheckPayload
+	if len(t.Payload()) > 0 {
+		if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+			logger.Errorf(ctx, "Failed to unmarshal knowledge link check payload: %v", err)
+			return err
+		}
+	}
+
+	kbIDs := []string{payload.KnowledgeBaseID}
+	if payload.KnowledgeBaseID == "" {
+		var err error
+		if kbIDs, err = s.repo.ListKnowledgeBaseIDsWithSourceURL(ctx); err != nil {
+			logger.Errorf(ctx, "Failed to list knowledge bases for link check: %v", err)
+			return err
+		}
+	}
+	logger.Infof(ctx, "Checking source links of %d knowledge bases", len(kbIDs))
+
+	checkedBefore := time.Now().Add(-interval)
+	for _, kbID := range kbIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		kb, err := s.kbService.GetRepository().GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to get knowledge base %s for link check: %v", kbID, err)
+			continue
+		}
+		due, err := s.repo.ListLinkCheckDueKnowledge(ctx, kbID, checkedBefore, linkCheckBatchSize)
+		if err != nil {
+			// Keep going with other knowledge bases
+			logger.Errorf(ctx, "Failed to list knowledge to link check in KB %s: %v", kbID, err)
+			continue
+		}
+		s.checkKnowledgeLinks(ctx, kb, due)
+	}
+	return nil
+}
+
+// checkKnowledgeLinks analyzes the source URL of each knowledge, a few at a time, with the fetch settings of their
+// knowledge base, and records the results
+func (s *knowledgeService) checkKnowledgeLinks(ctx context.Context, kb *types.KnowledgeBase,
+	knowledges []*types.Knowledge,
+) {
+	var g errgroup.Group
+	g.SetLimit(linkCheckConcurrency)
+	for _, knowledge := range knowledges {
+		g.Go(func() error {
+			result := analyzeURL(ctx, knowledge.Source, kb.URLFetchConfig)
+			check := linkCheckFromAnalysis(knowledge, result, time.Now())
+			if err := s.repo.UpdateKnowledgeLinkCheck(ctx, knowledge.ID, check); err != nil {
+				logger.Warnf(ctx, "Failed to record link check of knowledge %s: %v", knowledge.ID, err)
+				return nil
+			}
+			if check.Status != types.KnowledgeLinkOK && check.Status != knowledge.LinkStatus {
+				logger.Infof(ctx, "Source of knowledge %s in KB %s is %s: %s", knowledge.ID, kb.ID, check.Status,
+					strings.Join(check.Reasons, "; "))
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	logger.Infof(ctx, "Checked the source links of %d knowledge in KB %s", len(knowledges), kb.ID)
+}
+
+// linkCheckFromAnalysis judges the analysis of the source URL of a knowledge against what was imported from it.
+// A 404 or 410 marks the source dead, other failures unreachable. A reachable source is changed when it serves
+// another kind of content, redirects to another site or to the home page, its page title no longer resembles the
+// first title seen, or its document is far larger or smaller than the imported file.
+func linkCheckFromAnalysis(knowledge *types.Knowledge, result *types.AnalyzeURLResult,
+	now time.Time,
+) *types.KnowledgeLinkCheck {
+	check := &types.KnowledgeLinkCheck{
+		CheckedAt:  now.UTC(),
+		StatusCode: result.StatusCode,
+		FinalURL:   result.FinalURL,
+		Title:      result.Title,
+	}
+	previous := knowledge.LinkCheck
+	if previous != nil {
+		check.BaselineTitle = previous.BaselineTitle
+	}
+
+	if result.StatusCode == http.StatusNotFound || result.StatusCode == http.StatusGone {
+		check.Status = types.KnowledgeLinkDead
+		check.Reasons = []string{fmt.Sprintf("the source answered %d %s", result.StatusCode,
+			http.StatusText(result.StatusCode))}
+		return check
+	}
+	if !result.Reachable {
+		check.Status = types.KnowledgeLinkUnreachable
+		check.Reasons = []string{result.Error}
+		check.FailedChecks = 1
+		if previous != nil {
+			check.FailedChecks = previous.FailedChecks + 1
+		}
+		return check
+	}
+
+	if check.BaselineTitle == "" {
+		check.BaselineTitle = result.Title
+	}
+	check.Reasons = linkChangeReasons(knowledge, result, check.BaselineTitle)
+	check.Status = types.KnowledgeLinkOK
+	if len(check.Reasons) > 0 {
+		check.Status = types.KnowledgeLinkChanged
+	}
+	return check
+}
+
+// linkChangeReasons returns why a reachable source no longer serves what was imported from it, nil when it still
+// does
+func linkChangeReasons(knowledge *types.Knowledge, result *types.AnalyzeURLResult, baselineTitle string) []string {
+	var reasons []string
+	switch {
+	case knowledge.Type == "url" && result.Kind != types.URLKindPage:
+		reasons = append(reasons, "the source no longer serves a web page")
+	case knowledge.Type == "file" && result.Kind == types.URLKindPage:
+		reasons = append(reasons, "the source serves a web page instead of the document")
+	}
+
+	source, sourceErr := neturl.Parse(knowledge.Source)
+	final, finalErr := neturl.Parse(result.FinalURL)
+	if sourceErr == nil && finalErr == nil && result.FinalURL != "" {
+		switch {
+		case siteHost(source) != siteHost(final):
+			reasons = append(reasons, fmt.Sprintf("the source redirects to another site: %s", final.Hostname()))
+		case strings.Trim(final.Path, "/") == "" && strings.Trim(source.Path, "/") != "":
+			reasons = append(reasons, "the source redirects to the home page of the site")
+		}
+	}
+
+	if result.Title != "" && baselineTitle != "" &&
+		titleSimilarity(result.Title, baselineTitle) < linkTitleMinSimilarity {
+		reasons = append(reasons, fmt.Sprintf("the page title changed from %q to %q", baselineTitle, result.Title))
+	}
+	if knowledge.Type == "file" && knowledge.FileSize > 0 && result.ContentLength > 0 {
+		ratio := float64(result.ContentLength) / float64(knowledge.FileSize)
+		if ratio > linkFileMaxSizeRatio || ratio < 1/linkFileMaxSizeRatio {
+			reasons = append(reasons, fmt.Sprintf("the document size changed from %d to %d bytes",
+				knowledge.FileSize, result.ContentLength))
+		}
+	}
+	return reasons
+}
+
+// siteHost returns the host name of a URL without its www. prefix
+func siteHost(u *neturl.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// titleSimilarity returns the Jaccard similarity of the character bigrams of two titles, which works for titles
+// with and without spaces between words alike
+func titleSimilarity(a, b string) float64 {
+	bigrams := func(s string) map[string]bool {
+		runes := []rune(strings.ToLower(strings.Join(strings.Fields(s), "")))
+		set := make(map[string]bool, len(runes))
+		if len(runes) == 1 {
+			set[string(runes)] = true
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			set[string(runes[i:i+2])] = true
+		}
+		return set
+	}
+	setA, setB := bigrams(a), bigrams(b)
+	if len(setA) == 0 && len(setB) == 0 {
+		return 1
+	}
+	common := 0
+	for bigram := range setA {
+		if setB[bigram] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(setA)+len(setB)-common)
+}
+
+// GetKnowledgeLinkReport counts the knowledge of a knowledge base imported from the web by the status of their
+// source, and lists those with the given statuses, dead, changed and unreachable ones by default
+func (s *knowledgeService) GetKnowledgeLinkReport(ctx context.Context, kbID string,
+	statuses []types.KnowledgeLinkStatus,
+) (*types.KnowledgeLinkReport, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if len(statuses) == 0 {
+		statuses = defaultLinkReportStatuses
+	}
+	counts, err := s.repo.CountKnowledgeByLinkStatus(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListKnowledgeByLinkStatus(ctx, tenantID, kbID, statuses, linkReportMaxItems)
+	if err != nil {
+		return nil, err
+	}
+	report := &types.KnowledgeLinkReport{Counts: make(map[string]int64, len(counts)), Items: items}
+	for status, count := range counts {
+		name := string(status)
+		if name == "" {
+			name = "unchecked"
+		}
+		report.Counts[name] += count
+	}
+	return report, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestLinkCheckFromAnalysis(t *testing.T) {
+	now := time.Now()
+	page := &types.Knowledge{Type: "url", Source: "https://example.com/docs/release-2.3"}
+	checkedPage := &types.Knowledge{
+		Type:   "url",
+		Source: "https://example.com/docs/release-2.3",
+		LinkCheck: &types.KnowledgeLinkCheck{
+			Status: types.KnowledgeLinkOK, BaselineTitle: "Release notes 2.3 - Example Docs",
+		},
+	}
+	file := &types.Knowledge{Type: "file", Source: "https://example.com/files/handbook.pdf", FileSize: 1000}
+
+	tests := []struct {
+		name      string
+		knowledge *types.Knowledge
+		result    *types.AnalyzeURLResult
+		want      types.KnowledgeLinkStatus
+	}{
+		{"gone", page, &types.AnalyzeURLResult{StatusCode: 410, Error: "status 410"}, types.KnowledgeLinkDead},
+		{"timeout", page, &types.AnalyzeURLResult{Error: "timeout"}, types.KnowledgeLinkUnreachable},
+		{"server error", file, &types.AnalyzeURLResult{StatusCode: 503, Error: "status 503"},
+			types.KnowledgeLinkUnreachable},
+		{"same page", checkedPage, &types.AnalyzeURLResult{
+			Reachable: true, StatusCode: 200, Kind: types.URLKindPage,
+			FinalURL: "https://www.example.com/docs/release-2.3/", Title: "Release notes 2.3 – Example Docs",
+		}, types.KnowledgeLinkOK},
+		{"new title", checkedPage, &types.AnalyzeURLResult{
+			Reachable: true, StatusCode: 200, Kind: types.URLKindPage,
+			FinalURL: "https://example.com/docs/release-2.3", Title: "Domain for sale",
+		}, types.KnowledgeLinkChanged},
+		{"home page redirect", page, &types.AnalyzeURLResult{
+			Reachable: true, StatusCode: 200, Kind: types.URLKindPage, FinalURL: "https://example.com/",
+		}, types.KnowledgeLinkChanged},
+		{"other site", page, &types.AnalyzeURLResult{
+			Reachable: true, StatusCode: 200, Kind: types.URLKindPage, FinalURL: "https://parking.example.net/a",
+		}, types.KnowledgeLinkChanged},
+		{"file became page", file, &types.AnalyzeURLResult{
+			Reachable: true, StatusCode: 200, Kind: types.URLKindPage,
+			FinalURL: "https://example.com/files/handbook.pdf",
+		}, types.KnowledgeLinkChanged},
+		{"file shrank", file, &types.AnalyzeURLResult{
+			Reachable: true, StatusCode: 200, Kind: types.URLKindFile, ContentLength: 300,
+			FinalURL: "https://example.com/files/handbook.pdf",
+		}, types.KnowledgeLinkChanged},
+		{"file updated", file, &types.AnalyzeURLResult{
+			Reachable: true, StatusCode: 200, Kind: types.URLKindFile, ContentLength: 1200,
+			FinalURL: "https://example.com/files/handbook.pdf",
+		}, types.KnowledgeLinkOK},
+	}
+	for _, tt := range tests {
+		check := linkCheckFromAnalysis(tt.knowledge, tt.result, now)
+		if check.Status != tt.want {
+			t.Errorf("%s: status = %s (%v), want %s", tt.name, check.Status, check.Reasons, tt.want)
+		}
+		if check.Status != types.KnowledgeLinkOK && len(check.Reasons) == 0 {
+			t.Errorf("%s: status %s without reasons", tt.name, check.Status)
+		}
+	}
+}
+
+func TestLinkCheckKeepsBaseline(t *testing.T) {
+	knowledge := &types.Knowledge{Type: "url", Source: "https://example.com/a"}
+	first := linkCheckFromAnalysis(knowledge, &types.AnalyzeURLResult{
+		Reachable: true, StatusCode: 200, Kind: types.URLKindPage, FinalURL: "https://example.com/a", Title: "A",
+	}, time.Now())
+	if first.BaselineTitle != "A" {
+		t.Fatalf("BaselineTitle = %q, want the first title", first.BaselineTitle)
+	}
+	knowledge.LinkCheck = first
+	failed := linkCheckFromAnalysis(knowledge, &types.AnalyzeURLResult{Error: "timeout"}, time.Now())
+	knowledge.LinkCheck = failed
+	failed = linkCheckFromAnalysis(knowledge, &types.AnalyzeURLResult{Error: "timeout"}, time.Now())
+	if failed.BaselineTitle != "A" || failed.FailedChecks != 2 {
+		t.Errorf("after failures = %+v, want baseline A and 2 failed checks", failed)
+	}
+}
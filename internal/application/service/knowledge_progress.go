@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/docreader/proto"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	knowledgeParseProgressKeyPrefix = "knowledge_parse_progress:"
+	knowledgeParseProgressTTL       = 24 * time.Hour
+	// embeddingProgressBatchSize is the number of index entries embedded between two progress updates
+	embeddingProgressBatchSize = 200
+	// readProgressPollInterval is how often the progress of a file parse is read from docreader
+	readProgressPollInterval = 2 * time.Second
+)
+
+// getKnowledgeParseProgressKey returns the Redis key for storing parse progress
+func getKnowledgeParseProgressKey(knowledgeID string) string {
+	return knowledgeParseProgressKeyPrefix + knowledgeID
+}
+
+// reportParseProgress stores the current parse stage of a knowledge entry.
+// Progress is best effort, failures are only logged.
+func (s *knowledgeService) reportParseProgress(
+	ctx context.Context,
+	knowledgeID string,
+	stage types.KnowledgeParseStage,
+	current, total int,
+	message string,
+) {
+	if s.redisClient == nil {
+		return
+	}
+	progress := &types.KnowledgeParseProgress{
+		KnowledgeID: knowledgeID,
+		Stage:       stage,
+		Current:     current,
+		Total:       total,
+		Message:     message,
+		UpdatedAt:   time.Now().UnixMilli(),
+	}
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return
+	}
+	key := getKnowledgeParseProgressKey(knowledgeID)
+	if err := s.redisClient.Set(ctx, key, data, knowledgeParseProgressTTL).Err(); err != nil {
+		logger.Warnf(ctx, "Failed to save parse progress of knowledge %s: %v", knowledgeID, err)
+	}
+}
+
+// watchReadProgress copies the progress docreader reports for the file parse running with the knowledge ID as
+// its progress ID, the converted stage and the pages parsed, until the returned function is called
+func (s *knowledgeService) watchReadProgress(ctx context.Context, knowledgeID string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(readProgressPollInterval)
+		defer ticker.Stop()
+		var last *proto.ReadProgress
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			progress, err := s.docReaderClient.ReadProgress(ctx, knowledgeID)
+			if err != nil || progress.GetStage() == "" {
+				continue
+			}
+			if last != nil && progress.GetStage() == last.GetStage() && progress.GetCurrent() == last.GetCurrent() &&
+				progress.GetTotal() == last.GetTotal() {
+				continue
+			}
+			last = progress
+			switch progress.GetStage() {
+			case string(types.KnowledgeParseStageConverted):
+				s.reportParseProgress(ctx, knowledgeID, types.KnowledgeParseStageConverted, 0, 0, "")
+			case string(types.KnowledgeParseStageParsing):
+				s.reportParseProgress(ctx, knowledgeID, types.KnowledgeParseStageParsing,
+					int(progress.GetCurrent()), int(progress.GetTotal()), "pages")
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// ListDeadLetterKnowledge lists knowledge of the current tenant whose parsing failed after all automatic retries.
+func (s *knowledgeService) ListDeadLetterKnowledge(
+	ctx context.Context,
+	kbID string,
+	page *types.Pagination,
+) (*types.PageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledges, total, err := s.repo.ListDeadLetterKnowledge(ctx, tenantID, kbID, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, knowledges), nil
+}
+
+// GetKnowledgeParseProgress returns the parse progress of a knowledge entry.
+// The final state always follows the parse status stored in the database, so failures
+// anywhere in the pipeline are reported even if no progress was recorded for them.
+func (s *knowledgeService) GetKnowledgeParseProgress(
+	ctx context.Context,
+	id string,
+) (*types.KnowledgeParseProgress, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &types.KnowledgeParseProgress{
+		KnowledgeID: knowledge.ID,
+		Stage:       types.KnowledgeParseStageQueued,
+		UpdatedAt:   knowledge.UpdatedAt.UnixMilli(),
+	}
+	if s.redisClient != nil {
+		data, err := s.redisClient.Get(ctx, getKnowledgeParseProgressKey(id)).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("failed to get progress from Redis: %w", err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, progress); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+			}
+		}
+	}
+
+	switch knowledge.ParseStatus {
+	case types.ParseStatusCompleted:
+		progress.Stage = types.KnowledgeParseStageCompleted
+		progress.Current = progress.Total
+	case types.ParseStatusFailed, types.ParseStatusFailedPermanent:
+		progress.Stage = types.KnowledgeParseStageFailed
+		progress.Error = knowledge.ErrorMessage
+	default:
+		// Left over from a previous run, the knowledge is being re-parsed
+		if progress.Done() {
+			progress.Stage = types.KnowledgeParseStageQueued
+			progress.Current, progress.Total = 0, 0
+			progress.Message, progress.Error = "", ""
+		}
+	}
+	return progress, nil
+}
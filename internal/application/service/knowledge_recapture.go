@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/docreader/proto"
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	knowledgeRecaptureKeyPrefix = "knowledge_recapture:"
+	// knowledgeRecapturePreviewTTL is how long a previewed capture can be confirmed
+	knowledgeRecapturePreviewTTL = 30 * time.Minute
+	// knowledgeRecaptureApplyTTL keeps a confirmed capture until the document process task, retries included, has
+	// indexed it
+	knowledgeRecaptureApplyTTL = 24 * time.Hour
+	// knowledgeRecaptureDiffContext is the number of unchanged lines shown around each change of a preview
+	knowledgeRecaptureDiffContext = 3
+)
+
+// errRecapturePreviewMissing is the failure of a re-capture whose preview is gone, e.g. expired before it was applied
+var errRecapturePreviewMissing = errors.New("re-capture preview not found or expired, preview the page again")
+
+// knowledgeRecapture is a captured page kept from its preview until it replaces the content of the knowledge
+type knowledgeRecapture struct {
+	PreviewID string                  `json:"preview_id"`
+	Capture   *types.KnowledgeCapture `json:"capture"`
+	Chunks    []*proto.Chunk          `json:"chunks"`
+}
+
+// getKnowledgeRecaptureKey returns the Redis key of a previewed capture of a knowledge
+func getKnowledgeRecaptureKey(knowledgeID string, previewID string) string {
+	return knowledgeRecaptureKeyPrefix + knowledgeID + ":" + previewID
+}
+
+// PreviewKnowledgeRecapture captures the page of a URL knowledge again and compares it with the current content,
+// without changing the knowledge. The capture is kept for ConfirmKnowledgeRecapture to apply. The page is extracted
+// in the mode of the last capture, unless extractionMode is given.
+func (s *knowledgeService) PreviewKnowledgeRecapture(ctx context.Context,
+	knowledgeID string, extractionMode string,
+) (*types.KnowledgeRecapturePreview, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.getRecapturableKnowledge(ctx, tenantID, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	enableMultimodel := kb.IsMultimodalEnabled()
+	var vlmConfig *proto.VLMConfig
+	if enableMultimodel {
+		if vlmConfig, err = s.getVLMProtoConfig(ctx, kb); err != nil {
+			logger.Warnf(ctx, "Failed to build VLM config for re-capture of knowledge %s: %v", knowledge.ID, err)
+		}
+	}
+	if extractionMode == "" {
+		extractionMode = previousExtractionMode(knowledge)
+	}
+	extractionMode = kb.WebExtractionMode(extractionMode)
+	requestID, _ := ctx.Value(types.RequestIDContextKey).(string)
+	resp, err := s.readFromURL(ctx, kb, knowledge.Source, knowledge.Title, extractionMode,
+		enableMultimodel, vlmConfig, requestID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to capture %s for knowledge %s: %v", knowledge.Source, knowledge.ID, err)
+		return nil, werrors.NewBadRequestError("failed to capture the page").WithDetails(err.Error())
+	}
+
+	current, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, knowledge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current content: %w", err)
+	}
+	currentParts := make([]string, 0, len(current))
+	for _, chunk := range current {
+		currentParts = append(currentParts, chunk.Content)
+	}
+	capturedParts := make([]string, 0, len(resp.Chunks))
+	for _, chunk := range resp.Chunks {
+		capturedParts = append(capturedParts, chunk.Content)
+	}
+	currentText, capturedText := strings.Join(currentParts, "\n"), strings.Join(capturedParts, "\n")
+
+	capturedBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	recapture := &knowledgeRecapture{
+		PreviewID: uuid.New().String(),
+		Capture:   types.NewKnowledgeCapture(knowledge.Source, types.KnowledgeCaptureMethodText, capturedBy),
+		Chunks:    resp.Chunks,
+	}
+	recapture.Capture.ExtractionMode = extractionMode
+	if strings.TrimSpace(capturedText) != "" {
+		recapture.Capture.ContentHash = types.ContentHash(capturedText)
+	}
+	data, err := json.Marshal(recapture)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal re-capture: %w", err)
+	}
+	key := getKnowledgeRecaptureKey(knowledge.ID, recapture.PreviewID)
+	if err := s.redisClient.Set(ctx, key, data, knowledgeRecapturePreviewTTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to save re-capture: %w", err)
+	}
+
+	diff, added, removed := types.DiffLines(
+		strings.Split(currentText, "\n"), strings.Split(capturedText, "\n"), knowledgeRecaptureDiffContext)
+	logger.Infof(ctx, "Previewed re-capture %s of knowledge %s: %d lines added, %d removed",
+		recapture.PreviewID, knowledge.ID, added, removed)
+	return &types.KnowledgeRecapturePreview{
+		PreviewID:    recapture.PreviewID,
+		KnowledgeID:  knowledge.ID,
+		Capture:      recapture.Capture,
+		Changed:      added+removed > 0,
+		AddedLines:   added,
+		RemovedLines: removed,
+		Warnings:     recaptureWarnings(currentText, capturedText),
+		Diff:         diff,
+		ExpiresAt:    recapture.Capture.CapturedAt.Add(knowledgeRecapturePreviewTTL),
+	}, nil
+}
+
+// ConfirmKnowledgeRecapture replaces the content of a URL knowledge with a previewed capture and indexes it
+// asynchronously, the page is not fetched again
+func (s *knowledgeService) ConfirmKnowledgeRecapture(ctx context.Context,
+	knowledgeID string, previewID string,
+) (*types.Knowledge, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.getRecapturableKnowledge(ctx, tenantID, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+	recapture, err := s.loadKnowledgeRecapture(ctx, knowledge.ID, previewID)
+	if err != nil {
+		return nil, err
+	}
+	if recapture == nil {
+		return nil, werrors.NewNotFoundError(errRecapturePreviewMissing.Error())
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quotaService.CheckParseJobQuota(ctx, knowledge.Type); err != nil {
+		return nil, err
+	}
+	// The capture must outlive the preview window until the task has indexed it
+	key := getKnowledgeRecaptureKey(knowledge.ID, previewID)
+	if err := s.redisClient.Expire(ctx, key, knowledgeRecaptureApplyTTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to keep re-capture: %w", err)
+	}
+
+	if err := s.cleanupKnowledgeResources(ctx, knowledge); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": knowledge.ID,
+		})
+		return nil, err
+	}
+	knowledge.ParseStatus = types.ParseStatusPending
+	knowledge.EnableStatus = "disabled"
+	knowledge.Description = ""
+	knowledge.ProcessedAt = nil
+	knowledge.EmbeddingModelID = kb.EmbeddingModelID
+	knowledge.Capture = recapture.Capture
+	// The source is checked again against the new capture
+	knowledge.LinkStatus = ""
+	knowledge.LinkCheckedAt = nil
+	knowledge.LinkCheck = nil
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return nil, err
+	}
+
+	enableQuestionGeneration := false
+	questionCount := 3
+	if kb.QuestionGenerationConfig != nil && kb.QuestionGenerationConfig.Enabled {
+		enableQuestionGeneration = true
+		if kb.QuestionGenerationConfig.QuestionCount > 0 {
+			questionCount = kb.QuestionGenerationConfig.QuestionCount
+		}
+	}
+	payloadBytes, err := json.Marshal(types.DocumentProcessPayload{
+		TenantID:                 tenantID,
+		KnowledgeID:              knowledge.ID,
+		KnowledgeBaseID:          knowledge.KnowledgeBaseID,
+		URL:                      knowledge.Source,
+		EnableMultimodel:         kb.IsMultimodalEnabled(),
+		EnableQuestionGeneration: enableQuestionGeneration,
+		QuestionCount:            questionCount,
+		ExtractionMode:           recapture.Capture.ExtractionMode,
+		RecapturePreviewID:       previewID,
+		TraceContext:             tracing.Inject(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal re-capture task payload: %w", err)
+	}
+	info, err := s.task.Enqueue(newDocumentProcessTask(payloadBytes))
+	if err != nil {
+		logger.Errorf(ctx, "Failed to enqueue re-capture task: %v", err)
+		return knowledge, nil
+	}
+	logger.Infof(ctx, "Enqueued re-capture task: id=%s queue=%s knowledge_id=%s preview_id=%s",
+		info.ID, info.Queue, knowledge.ID, previewID)
+	return knowledge, nil
+}
+
+// getRecapturableKnowledge gets a knowledge of the tenant whose page can be captured again
+func (s *knowledgeService) getRecapturableKnowledge(ctx context.Context,
+	tenantID uint64, knowledgeID string,
+) (*types.Knowledge, error) {
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, knowledgeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeNotFound) {
+			return nil, werrors.NewNotFoundError("Knowledge not found")
+		}
+		return nil, err
+	}
+	if knowledge.Type != types.KnowledgeTypeURL || knowledge.Source == "" {
+		return nil, werrors.NewBadRequestError("only knowledge imported from a URL can be re-captured")
+	}
+	if safe, reason := secutils.IsSSRFSafeURL(knowledge.Source); !safe {
+		logger.Errorf(ctx, "URL rejected for SSRF protection: %s, reason: %s", knowledge.Source, reason)
+		return nil, ErrInvalidURL
+	}
+	return knowledge, nil
+}
+
+// previousExtractionMode returns the web extraction mode of the last capture of a knowledge, empty when unknown
+func previousExtractionMode(knowledge *types.Knowledge) string {
+	if knowledge.Capture == nil {
+		return ""
+	}
+	return knowledge.Capture.ExtractionMode
+}
+
+// loadKnowledgeRecapture loads a previewed capture of a knowledge, nil when it does not exist, has expired or
+// cannot be read back
+func (s *knowledgeService) loadKnowledgeRecapture(ctx context.Context,
+	knowledgeID string, previewID string,
+) (*knowledgeRecapture, error) {
+	data, err := s.redisClient.Get(ctx, getKnowledgeRecaptureKey(knowledgeID, previewID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load re-capture: %w", err)
+	}
+	var recapture knowledgeRecapture
+	if err := json.Unmarshal(data, &recapture); err != nil {
+		logger.Warnf(ctx, "Failed to unmarshal re-capture %s of knowledge %s: %v", previewID, knowledgeID, err)
+		return nil, nil
+	}
+	return &recapture, nil
+}
+
+// deleteKnowledgeRecapture drops a capture once it has been indexed, it cannot be applied twice
+func (s *knowledgeService) deleteKnowledgeRecapture(ctx context.Context, knowledgeID string, previewID string) {
+	if err := s.redisClient.Del(ctx, getKnowledgeRecaptureKey(knowledgeID, previewID)).Err(); err != nil {
+		logger.Warnf(ctx, "Failed to delete re-capture %s of knowledge %s: %v", previewID, knowledgeID, err)
+	}
+}
+
+// recaptureWarnings flags captures that look like error or placeholder pages: empty, or much shorter than the
+// content they would replace
+func recaptureWarnings(currentText, capturedText string) []string {
+	warnings := []string{}
+	currentLen, capturedLen := len([]rune(strings.TrimSpace(currentText))), len([]rune(strings.TrimSpace(capturedText)))
+	switch {
+	case capturedLen == 0:
+		warnings = append(warnings, "the captured page has no content")
+	case currentLen > 0 && capturedLen*2 < currentLen:
+		warnings = append(warnings, fmt.Sprintf(
+			"the captured page is %d%% shorter than the current content", 100-capturedLen*100/currentLen))
+	}
+	return warnings
+}
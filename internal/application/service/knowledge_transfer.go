@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"io"
+	"slices"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// CopyKnowledgeToKB copies a knowledge entry (file, metadata and tags) to another knowledge base
+// of the same tenant. Chunks and embeddings are reused when requested and both knowledge bases use
+// the same embedding model, otherwise the copy is re-parsed in the target knowledge base.
+func (s *knowledgeService) CopyKnowledgeToKB(
+	ctx context.Context,
+	id string,
+	req *types.KnowledgeTransferRequest,
+) (*types.Knowledge, error) {
+	src, targetKB, err := s.prepareKnowledgeTransfer(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.copyKnowledgeTo(ctx, src, targetKB, req.ShouldReuseChunks())
+}
+
+// MoveKnowledgeToKB moves a knowledge entry to another knowledge base of the same tenant.
+// The entry is copied to the target and then deleted from the source, so it gets a new ID.
+func (s *knowledgeService) MoveKnowledgeToKB(
+	ctx context.Context,
+	id string,
+	req *types.KnowledgeTransferRequest,
+) (*types.Knowledge, error) {
+	src, targetKB, err := s.prepareKnowledgeTransfer(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := s.copyKnowledgeTo(ctx, src, targetKB, req.ShouldReuseChunks())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DeleteKnowledge(ctx, src.ID); err != nil {
+		// Drop the copy so that a retry of the move does not leave another one in the target
+		logger.Errorf(ctx, "Knowledge %s copied to %s but failed to delete source: %v", src.ID, dst.ID, err)
+		s.discardKnowledgeCopy(ctx, dst)
+		return nil, err
+	}
+	logger.Infof(ctx, "Knowledge %s moved to KB %s as %s", src.ID, targetKB.ID, dst.ID)
+	return dst, nil
+}
+
+// prepareKnowledgeTransfer loads and validates the source knowledge and the target knowledge base
+func (s *knowledgeService) prepareKnowledgeTransfer(
+	ctx context.Context,
+	id string,
+	req *types.KnowledgeTransferRequest,
+) (*types.Knowledge, *types.KnowledgeBase, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	src, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if src.KnowledgeBaseID == req.TargetKBID {
+		return nil, nil, werrors.NewBadRequestError("目标知识库与当前知识库相同")
+	}
+	if src.TrashedAt != nil {
+		return nil, nil, werrors.NewBadRequestError("知识已在回收站中")
+	}
+	if src.ParseStatus == types.ParseStatusPending || src.ParseStatus == types.ParseStatusProcessing ||
+		src.ParseStatus == types.ParseStatusDeleting {
+		return nil, nil, werrors.NewBadRequestError("知识正在处理中，请稍后重试")
+	}
+
+	targetKB, err := s.kbService.GetKnowledgeBaseByID(ctx, req.TargetKBID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if targetKB.TenantID != src.TenantID {
+		return nil, nil, werrors.NewForbiddenError("只能移动或复制到同一租户下的知识库")
+	}
+	if targetKB.Type == types.KnowledgeBaseTypeFAQ {
+		return nil, nil, werrors.NewBadRequestError("不能移动或复制到FAQ知识库")
+	}
+	return src, targetKB, nil
+}
+
+// copyKnowledgeTo creates a copy of src in targetKB
+func (s *knowledgeService) copyKnowledgeTo(
+	ctx context.Context,
+	src *types.Knowledge,
+	targetKB *types.KnowledgeBase,
+	reuseChunks bool,
+) (*types.Knowledge, error) {
+	reuse := reuseChunks &&
+		src.ParseStatus == types.ParseStatusCompleted &&
+		src.EmbeddingModelID != "" &&
+		src.EmbeddingModelID == targetKB.EmbeddingModelID
+
+	// The copy adds a document, and a parse job unless the chunks are reused
+	if err := s.quotaService.CheckKnowledgeQuota(ctx); err != nil {
+		return nil, err
+	}
+	if !reuse {
+		if err := s.quotaService.CheckParseJobQuota(ctx, src.Type); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	dst := &types.Knowledge{
+		ID:               uuid.New().String(),
+		TenantID:         targetKB.TenantID,
+		KnowledgeBaseID:  targetKB.ID,
+		Type:             src.Type,
+		Title:            src.Title,
+		Description:      src.Description,
+		Source:           src.Source,
+		ParseStatus:      types.ParseStatusPending,
+		EnableStatus:     "disabled",
+		EmbeddingModelID: targetKB.EmbeddingModelID,
+		FileName:         src.FileName,
+		FileType:         src.FileType,
+		FileSize:         src.FileSize,
+		FileHash:         src.FileHash,
+		Metadata:         src.Metadata,
+		Capture:          src.Capture,
+		ExpiresAt:        src.ExpiresAt,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	// The copy gets its own file, so deleting either entry keeps the other intact
+	if src.FilePath != "" {
+		filePath, err := s.copyKnowledgeFile(ctx, src, targetKB.TenantID)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to copy file of knowledge %s: %v", src.ID, err)
+			return nil, err
+		}
+		dst.FilePath = filePath
+	}
+
+	if err := s.repo.CreateKnowledge(ctx, dst); err != nil {
+		if dst.FilePath != "" {
+			if delErr := s.fileSvc.DeleteFile(ctx, dst.FilePath); delErr != nil {
+				logger.Warnf(ctx, "Failed to delete copied file %s: %v", dst.FilePath, delErr)
+			}
+		}
+		return nil, err
+	}
+	s.copyKnowledgeTags(ctx, src, dst)
+
+	if !reuse {
+		// The quota was checked above, the pending copy itself must not count against it
+		logger.Infof(ctx, "Copied knowledge %s to %s, reparsing in KB %s", src.ID, dst.ID, targetKB.ID)
+		reparsed, err := s.reparseKnowledge(ctx, dst, targetKB)
+		if err != nil {
+			s.discardKnowledgeCopy(ctx, dst)
+			return nil, err
+		}
+		return reparsed, nil
+	}
+
+	if err := s.CloneChunk(ctx, src, dst); err != nil {
+		logger.Errorf(ctx, "Failed to clone chunks of knowledge %s: %v", src.ID, err)
+		s.discardKnowledgeCopy(ctx, dst)
+		return nil, err
+	}
+	dst.ParseStatus = types.ParseStatusCompleted
+	dst.EnableStatus = "enabled"
+	dst.StorageSize = src.StorageSize
+	dst.ProcessedAt = &now
+	if err := s.repo.UpdateKnowledge(ctx, dst); err != nil {
+		dst.StorageSize = 0
+		s.discardKnowledgeCopy(ctx, dst)
+		return nil, err
+	}
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	tenantInfo.StorageUsed += dst.StorageSize
+	if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, dst.StorageSize); err != nil {
+		logger.Warnf(ctx, "Failed to update tenant storage used after copying knowledge: %v", err)
+	}
+	logger.Infof(ctx, "Copied knowledge %s to %s with existing chunks", src.ID, dst.ID)
+	return dst, nil
+}
+
+// discardKnowledgeCopy deletes a copy that could not be completed, with its file, chunks and tags
+func (s *knowledgeService) discardKnowledgeCopy(ctx context.Context, dst *types.Knowledge) {
+	if err := s.DeleteKnowledge(ctx, dst.ID); err != nil {
+		logger.Errorf(ctx, "Failed to discard copied knowledge %s: %v", dst.ID, err)
+	}
+}
+
+// copyKnowledgeFile stores a copy of the knowledge file and returns the new path,
+// streaming it when the file service supports it and the size is known
+func (s *knowledgeService) copyKnowledgeFile(ctx context.Context, src *types.Knowledge, tenantID uint64) (string, error) {
+	reader, err := s.fileSvc.GetFile(ctx, src.FilePath)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	if saver, ok := s.fileSvc.(interfaces.FileStreamSaver); ok && src.FileSize > 0 {
+		return saver.SaveReader(ctx, reader, src.FileSize, tenantID, src.FileName)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return s.fileSvc.SaveBytes(ctx, data, tenantID, src.FileName, false)
+}
+
+// copyKnowledgeTags assigns tags with the same names in the target knowledge base, creating them if needed
+func (s *knowledgeService) copyKnowledgeTags(ctx context.Context, src, dst *types.Knowledge) {
+	tagIDsMap, err := s.tagRepo.ListKnowledgeTagIDs(ctx, src.TenantID, []string{src.ID})
+	if err != nil {
+		logger.Warnf(ctx, "Failed to load tags of knowledge %s: %v", src.ID, err)
+		return
+	}
+	// Primary tag first, it stays the primary tag of the copy
+	srcTagIDs := append(nonEmptyTagIDs(src.TagID), tagIDsMap[src.ID]...)
+	if len(srcTagIDs) == 0 {
+		return
+	}
+	mapping := map[string]string{}
+	dstTagIDs := make([]string, 0, len(srcTagIDs))
+	for _, tagID := range srcTagIDs {
+		mapped := s.getOrCreateTagInTarget(ctx, src.TenantID, dst.TenantID, dst.KnowledgeBaseID, tagID, mapping)
+		if mapped != "" && !slices.Contains(dstTagIDs, mapped) {
+			dstTagIDs = append(dstTagIDs, mapped)
+		}
+	}
+	if err := s.applyKnowledgeTags(ctx, dst.TenantID, dst, dstTagIDs); err != nil {
+		logger.Warnf(ctx, "Failed to assign tags to copied knowledge %s: %v", dst.ID, err)
+	}
+}
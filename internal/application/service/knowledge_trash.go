@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// defaultTrashRetentionDays is used when knowledge_base.trash_retention_days is not configured
+	defaultTrashRetentionDays = 30
+	// trashPurgeBatchSize limits how many trashed knowledge items are purged in one run
+	trashPurgeBatchSize = 500
+)
+
+// TrashKnowledge moves a knowledge entry to trash. Its chunks are disabled so that it no longer
+// takes part in retrieval, but data, vectors and files are kept until restored or purged.
+func (s *knowledgeService) TrashKnowledge(ctx context.Context, id string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if knowledge.TrashedAt != nil {
+		return nil
+	}
+	if knowledge.ParseStatus == types.ParseStatusPending || knowledge.ParseStatus == types.ParseStatusProcessing {
+		return werrors.NewBadRequestError("知识正在解析中，无法移入回收站，请稍后重试或彻底删除")
+	}
+
+	changed, err := s.suspendKnowledgeChunks(ctx, knowledge)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	knowledge.TrashedAt = &now
+	knowledge.UpdatedAt = now
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return err
+	}
+	s.invalidateSemanticCache(ctx, []*types.Knowledge{knowledge})
+	logger.Infof(ctx, "Knowledge %s moved to trash, %d chunks disabled", knowledge.ID, changed)
+	return nil
+}
+
+// RestoreKnowledge restores a trashed knowledge entry. Parsed knowledge gets the chunks that were enabled when it
+// was trashed re-enabled (unless it is archived), knowledge that never finished parsing is re-parsed.
+func (s *knowledgeService) RestoreKnowledge(ctx context.Context, id string) (*types.Knowledge, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if knowledge.TrashedAt == nil {
+		return knowledge, nil
+	}
+	if knowledge.ParseStatus == types.ParseStatusDeleting {
+		return nil, werrors.NewBadRequestError("知识正在被删除，无法恢复")
+	}
+
+	knowledge.TrashedAt = nil
+	knowledge.UpdatedAt = time.Now()
+	if knowledge.ParseStatus != types.ParseStatusCompleted {
+		// Parsing builds the chunks anew
+		if knowledge.ArchivedAt == nil {
+			knowledge.SuspendedChunkIDs = nil
+		}
+		if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+			return nil, err
+		}
+		logger.Infof(ctx, "Knowledge %s restored from trash, reparsing (status: %s)", knowledge.ID, knowledge.ParseStatus)
+		return s.ReparseKnowledge(ctx, knowledge.ID)
+	}
+
+	if knowledge.ArchivedAt == nil {
+		if _, err := s.resumeKnowledgeChunks(ctx, knowledge); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return nil, err
+	}
+	if knowledge.ArchivedAt == nil {
+		s.invalidateKnowledgeBaseSemanticCache(ctx, knowledge.KnowledgeBaseID)
+	}
+	logger.Infof(ctx, "Knowledge %s restored from trash", knowledge.ID)
+	return knowledge, nil
+}
+
+// ListTrashedKnowledge lists trashed knowledge of a knowledge base, most recently trashed first.
+func (s *knowledgeService) ListTrashedKnowledge(
+	ctx context.Context,
+	kbID string,
+	page *types.Pagination,
+) (*types.PageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledges, total, err := s.repo.ListTrashedKnowledge(ctx, tenantID, kbID, page)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillKnowledgeTagIDs(ctx, tenantID, knowledges); err != nil {
+		logger.Warnf(ctx, "Failed to load tags for trashed knowledge of KB %s: %v", kbID, err)
+	}
+	return types.NewPageResult(total, page, knowledges), nil
+}
+
+// ProcessKnowledgeTrashPurge permanently deletes knowledge that has been in trash
+// longer than the configured retention window. Scheduled periodically.
+func (s *knowledgeService) ProcessKnowledgeTrashPurge(ctx context.Context, t *asynq.Task) error {
+	cutoff := time.Now().AddDate(0, 0, -s.trashRetentionDays())
+	expired, err := s.repo.ListTrashedBefore(ctx, cutoff, trashPurgeBatchSize)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list trashed knowledge: %v", err)
+		return err
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	byTenant := make(map[uint64][]string)
+	for _, knowledge := range expired {
+		byTenant[knowledge.TenantID] = append(byTenant[knowledge.TenantID], knowledge.ID)
+	}
+	for tenantID, ids := range byTenant {
+		tenant, err := s.tenantRepo.GetTenantByID(ctx, tenantID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to get tenant %d for trash purge: %v", tenantID, err)
+			continue
+		}
+		tenantCtx := context.WithValue(ctx, types.TenantIDContextKey, tenantID)
+		tenantCtx = context.WithValue(tenantCtx, types.TenantInfoContextKey, tenant)
+		if err := s.DeleteKnowledgeList(tenantCtx, ids); err != nil {
+			logger.Errorf(tenantCtx, "Failed to purge %d trashed knowledge of tenant %d: %v", len(ids), tenantID, err)
+			continue
+		}
+		logger.Infof(tenantCtx, "Purged %d trashed knowledge of tenant %d", len(ids), tenantID)
+	}
+	return nil
+}
+
+// trashRetentionDays returns the configured trash retention window in days
+func (s *knowledgeService) trashRetentionDays() int {
+	if s.config == nil {
+		return defaultTrashRetentionDays
+	}
+	if kb := s.config.Current().KnowledgeBase; kb != nil && kb.TrashRetentionDays > 0 {
+		return kb.TrashRetentionDays
+	}
+	return defaultTrashRetentionDays
+}
@@ -1,14 +1,17 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -20,11 +23,16 @@ import (
 // ErrInvalidTenantID represents an error for invalid tenant ID
 var ErrInvalidTenantID = errors.New("invalid tenant ID")
 
+// rrfK is the RRF constant used to fuse vector and keyword results in HybridSearch,
+// k=60 is a common choice that works well in practice
+const rrfK = 60
+
 // knowledgeBaseService implements the knowledge base service interface
 type knowledgeBaseService struct {
 	repo           interfaces.KnowledgeBaseRepository
 	kgRepo         interfaces.KnowledgeRepository
 	chunkRepo      interfaces.ChunkRepository
+	tagRepo        interfaces.KnowledgeTagRepository
 	shareRepo      interfaces.KBShareRepository
 	kbShareService interfaces.KBShareService
 	modelService   interfaces.ModelService
@@ -32,13 +40,18 @@ type knowledgeBaseService struct {
 	tenantRepo     interfaces.TenantRepository
 	fileSvc        interfaces.FileService
 	graphEngine    interfaces.RetrieveGraphRepository
-	asynqClient    *asynq.Client
+	asynqClient    interfaces.TaskEnqueuer
+	semanticCache  interfaces.SemanticCache
+	// Provides the documents hidden from users, excluded from retrieval
+	kbPermissionRepo interfaces.KBPermissionRepository
+	quotaService     interfaces.QuotaService
 }
 
 // NewKnowledgeBaseService creates a new knowledge base service
 func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 	kgRepo interfaces.KnowledgeRepository,
 	chunkRepo interfaces.ChunkRepository,
+	tagRepo interfaces.KnowledgeTagRepository,
 	shareRepo interfaces.KBShareRepository,
 	kbShareService interfaces.KBShareService,
 	modelService interfaces.ModelService,
@@ -46,20 +59,27 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 	tenantRepo interfaces.TenantRepository,
 	fileSvc interfaces.FileService,
 	graphEngine interfaces.RetrieveGraphRepository,
-	asynqClient *asynq.Client,
+	asynqClient interfaces.TaskEnqueuer,
+	semanticCache interfaces.SemanticCache,
+	kbPermissionRepo interfaces.KBPermissionRepository,
+	quotaService interfaces.QuotaService,
 ) interfaces.KnowledgeBaseService {
 	return &knowledgeBaseService{
-		repo:           repo,
-		kgRepo:         kgRepo,
-		chunkRepo:      chunkRepo,
-		shareRepo:      shareRepo,
-		kbShareService: kbShareService,
-		modelService:   modelService,
-		retrieveEngine: retrieveEngine,
-		tenantRepo:     tenantRepo,
-		fileSvc:        fileSvc,
-		graphEngine:    graphEngine,
-		asynqClient:    asynqClient,
+		repo:             repo,
+		kgRepo:           kgRepo,
+		chunkRepo:        chunkRepo,
+		tagRepo:          tagRepo,
+		shareRepo:        shareRepo,
+		kbShareService:   kbShareService,
+		modelService:     modelService,
+		retrieveEngine:   retrieveEngine,
+		tenantRepo:       tenantRepo,
+		fileSvc:          fileSvc,
+		graphEngine:      graphEngine,
+		asynqClient:      asynqClient,
+		semanticCache:    semanticCache,
+		kbPermissionRepo: kbPermissionRepo,
+		quotaService:     quotaService,
 	}
 }
 
@@ -86,6 +106,12 @@ func (s *knowledgeBaseService) CreateKnowledgeBase(ctx context.Context,
 	kb.UpdatedAt = time.Now()
 	kb.EnsureDefaults()
 
+	if !kb.IsTemporary {
+		if err := s.quotaService.CheckKnowledgeBaseQuota(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	logger.Infof(ctx, "Creating knowledge base, ID: %s, tenant ID: %d, name: %s", kb.ID, kb.TenantID, kb.Name)
 
 	if err := s.repo.CreateKnowledgeBase(ctx, kb); err != nil {
@@ -297,6 +323,52 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 	if config.FAQConfig != nil {
 		kb.FAQConfig = config.FAQConfig
 	}
+	// Update retention policy if provided
+	if config.RetentionConfig != nil {
+		if config.RetentionConfig.MaxAgeDays < 0 || config.RetentionConfig.NotifyBeforeDays < 0 {
+			return nil, errors.New("retention days cannot be negative")
+		}
+		kb.RetentionConfig = config.RetentionConfig
+	}
+	// Update OCR settings if provided
+	if config.OCRConfig != nil {
+		kb.OCRConfig = config.OCRConfig
+	}
+	// Update web extraction settings if provided
+	if config.WebExtractionConfig != nil {
+		kb.WebExtractionConfig = config.WebExtractionConfig
+	}
+	// Update URL fetch settings if provided, masked credentials keep their stored values
+	if config.URLFetchConfig != nil {
+		config.URLFetchConfig.MergeMasked(kb.URLFetchConfig)
+		kb.URLFetchConfig = config.URLFetchConfig
+	}
+	// Update VLM settings if provided
+	if config.VLMConfig != nil {
+		if err := s.checkVLMModel(ctx, config.VLMConfig); err != nil {
+			return nil, err
+		}
+		kb.VLMConfig = *config.VLMConfig
+		if !kb.VLMConfig.Enabled {
+			kb.VLMConfig.ModelID = ""
+		}
+	}
+	// Update image embedding settings if provided
+	if config.ImageEmbeddingConfig != nil {
+		kb.ImageEmbeddingConfig = config.ImageEmbeddingConfig
+	}
+	// Update rerank settings if provided
+	if config.RerankConfig != nil {
+		kb.RerankConfig = config.RerankConfig
+	}
+	// Update semantic cache settings if provided
+	if config.SemanticCacheConfig != nil {
+		kb.SemanticCacheConfig = config.SemanticCacheConfig
+	}
+	// Update keyword analyzer and fusion weights if provided
+	if config.KeywordSearchConfig != nil {
+		kb.KeywordSearchConfig = config.KeywordSearchConfig
+	}
 	kb.UpdatedAt = time.Now()
 	kb.EnsureDefaults()
 
@@ -308,6 +380,13 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 		return nil, err
 	}
 
+	// Cached answers are dropped once the cache is turned off so re-enabling starts fresh
+	if config.SemanticCacheConfig != nil && !config.SemanticCacheConfig.Enabled {
+		if err := s.semanticCache.InvalidateKnowledgeBase(ctx, kb.ID); err != nil {
+			logger.Warnf(ctx, "Failed to clear semantic cache of knowledge base %s: %v", kb.ID, err)
+		}
+	}
+
 	logger.Infof(ctx, "Knowledge base updated successfully, ID: %s, name: %s", kb.ID, kb.Name)
 	return kb, nil
 }
@@ -327,6 +406,12 @@ func (s *knowledgeBaseService) DeleteKnowledgeBase(ctx context.Context, id strin
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 
+	// The image embedding model is needed by the cleanup task to find the visual vectors
+	var imageEmbeddingModelID string
+	if kb, err := s.repo.GetKnowledgeBaseByID(ctx, id); err == nil && kb.ImageEmbeddingConfig != nil {
+		imageEmbeddingModelID = kb.ImageEmbeddingConfig.ModelID
+	}
+
 	// Step 1: Delete the knowledge base record first (mark as deleted)
 	logger.Infof(ctx, "Deleting knowledge base from database")
 	err := s.repo.DeleteKnowledgeBase(ctx, id)
@@ -342,11 +427,17 @@ func (s *knowledgeBaseService) DeleteKnowledgeBase(ctx context.Context, id strin
 		logger.Warnf(ctx, "Failed to delete KB shares for knowledge base %s: %v", id, delErr)
 	}
 
+	// Step 1c: Drop the cached answers of the knowledge base
+	if cacheErr := s.semanticCache.InvalidateKnowledgeBase(ctx, id); cacheErr != nil {
+		logger.Warnf(ctx, "Failed to drop semantic cache for knowledge base %s: %v", id, cacheErr)
+	}
+
 	// Step 2: Enqueue async task for heavy cleanup operations
 	payload := types.KBDeletePayload{
-		TenantID:         tenantID,
-		KnowledgeBaseID:  id,
-		EffectiveEngines: tenantInfo.GetEffectiveEngines(),
+		TenantID:              tenantID,
+		KnowledgeBaseID:       id,
+		EffectiveEngines:      tenantInfo.GetEffectiveEngines(),
+		ImageEmbeddingModelID: imageEmbeddingModelID,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -436,6 +527,19 @@ func (s *knowledgeBaseService) ProcessKBDelete(ctx context.Context, t *asynq.Tas
 					logger.Warnf(ctx, "Failed to delete embeddings for model %s: %v", key.EmbeddingModelID, err)
 				}
 			}
+
+			if payload.ImageEmbeddingModelID != "" {
+				var imageKnowledgeIDs []string
+				for _, knowledge := range knowledgeList {
+					if IsImageType(knowledge.FileType) {
+						imageKnowledgeIDs = append(imageKnowledgeIDs, knowledge.ID)
+					}
+				}
+				if err := deleteImageVectors(ctx, retrieveEngine, s.modelService,
+					payload.ImageEmbeddingModelID, tenantID, imageKnowledgeIDs); err != nil {
+					logger.Warnf(ctx, "Failed to delete image embeddings: %v", err)
+				}
+			}
 		}
 
 		// Delete all chunks
@@ -515,6 +619,14 @@ func (s *knowledgeBaseService) SetEmbeddingModel(ctx context.Context, id string,
 		return err
 	}
 
+	model, err := s.modelService.GetModelByID(ctx, modelID)
+	if err != nil {
+		return err
+	}
+	if err := s.CheckEmbeddingDimension(ctx, kb, model.Parameters.EmbeddingParameters.Dimension); err != nil {
+		return err
+	}
+
 	// Update the knowledge base's embedding model
 	kb.EmbeddingModelID = modelID
 	kb.UpdatedAt = time.Now()
@@ -586,6 +698,46 @@ func (s *knowledgeBaseService) CopyKnowledgeBase(ctx context.Context,
 	return sourceKB, targetKB, nil
 }
 
+// splitCodeKeywordParams splits a keyword retrieval into one over the source code files, matched with the code
+// analyzer, and one over the other files with the analyzer of params. Legs without files are dropped.
+func splitCodeKeywordParams(params types.RetrieveParams, codeKnowledgeIDs []string) []types.RetrieveParams {
+	if len(params.KnowledgeIDs) > 0 {
+		selected := make(map[string]struct{}, len(params.KnowledgeIDs))
+		for _, kid := range params.KnowledgeIDs {
+			selected[kid] = struct{}{}
+		}
+		codeKnowledgeIDs = slices.DeleteFunc(slices.Clone(codeKnowledgeIDs), func(kid string) bool {
+			_, ok := selected[kid]
+			return !ok
+		})
+	}
+	if len(codeKnowledgeIDs) == 0 {
+		return []types.RetrieveParams{params}
+	}
+
+	codeParams := params
+	codeParams.KnowledgeIDs = codeKnowledgeIDs
+	codeParams.KeywordAnalyzer = types.KeywordAnalyzerCode
+
+	textParams := params
+	if len(params.KnowledgeIDs) > 0 {
+		code := make(map[string]struct{}, len(codeKnowledgeIDs))
+		for _, kid := range codeKnowledgeIDs {
+			code[kid] = struct{}{}
+		}
+		textParams.KnowledgeIDs = slices.DeleteFunc(slices.Clone(params.KnowledgeIDs), func(kid string) bool {
+			_, ok := code[kid]
+			return ok
+		})
+		if len(textParams.KnowledgeIDs) == 0 {
+			return []types.RetrieveParams{codeParams}
+		}
+	} else {
+		textParams.ExcludeKnowledgeIDs = append(slices.Clone(params.ExcludeKnowledgeIDs), codeKnowledgeIDs...)
+	}
+	return []types.RetrieveParams{textParams, codeParams}
+}
+
 // HybridSearch performs hybrid search, including vector retrieval and keyword retrieval
 func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	id string,
@@ -593,6 +745,10 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 ) ([]*types.SearchResult, error) {
 	logger.Infof(ctx, "Hybrid search parameters, knowledge base ID: %s, query text: %s", id, params.QueryText)
 
+	if apiKey := types.APIKeyFromContext(ctx); apiKey != nil && !apiKey.AllowsKnowledgeBase(id) {
+		return nil, werrors.NewForbiddenError("API key is not allowed to access this knowledge base")
+	}
+
 	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 	currentTenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 
@@ -604,6 +760,8 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	}
 
 	var retrieveParams []types.RetrieveParams
+	// Whether the keyword results come from separate retrievals of code and other files
+	var splitKeywordResults bool
 	var embeddingModel embedding.Embedder
 	var kb *types.KnowledgeBase
 
@@ -615,6 +773,74 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		return nil, err
 	}
 
+	// Structured filters are resolved to knowledge IDs, which every retrieval engine applies
+	// inside the vector / keyword query instead of filtering the results afterwards
+	if params.Filter != nil {
+		params.TagIDs = append(params.TagIDs, params.Filter.TagIDs...)
+		if params.Filter.HasKnowledgeConditions() {
+			knowledgeIDs, err := s.kgRepo.ListIDsByFilter(ctx, kb.TenantID, id, params.Filter)
+			if err != nil {
+				logger.Errorf(ctx, "Failed to resolve search filter: %v", err)
+				return nil, err
+			}
+			if len(params.KnowledgeIDs) > 0 {
+				allowed := make(map[string]struct{}, len(params.KnowledgeIDs))
+				for _, kid := range params.KnowledgeIDs {
+					allowed[kid] = struct{}{}
+				}
+				knowledgeIDs = slices.DeleteFunc(knowledgeIDs, func(kid string) bool {
+					_, ok := allowed[kid]
+					return !ok
+				})
+			}
+			if len(knowledgeIDs) == 0 {
+				logger.Infof(ctx, "No knowledge matches search filter of knowledge base %s", id)
+				return []*types.SearchResult{}, nil
+			}
+			params.KnowledgeIDs = knowledgeIDs
+		}
+	}
+
+	// Tag filters also cover sub-tags. For document KBs they are resolved to knowledge IDs,
+	// so that additional (non-primary) tags assigned to knowledge are honored as well.
+	if len(params.TagIDs) > 0 {
+		if kb.Type == types.KnowledgeBaseTypeFAQ {
+			tagIDs, err := s.tagRepo.ListDescendantIDs(ctx, kb.TenantID, params.TagIDs)
+			if err != nil {
+				logger.Errorf(ctx, "Failed to expand tag filter: %v", err)
+				return nil, err
+			}
+			params.TagIDs = tagIDs
+		} else {
+			knowledgeIDs, err := s.kgRepo.ListIDsByTagTree(ctx, kb.TenantID, id, params.TagIDs)
+			if err != nil {
+				logger.Errorf(ctx, "Failed to resolve tag filter: %v", err)
+				return nil, err
+			}
+			if len(params.KnowledgeIDs) > 0 {
+				knowledgeIDs = slices.DeleteFunc(knowledgeIDs, func(kid string) bool {
+					return !slices.Contains(params.KnowledgeIDs, kid)
+				})
+			}
+			if len(knowledgeIDs) == 0 {
+				logger.Infof(ctx, "No knowledge matches tag filter %v", params.TagIDs)
+				return []*types.SearchResult{}, nil
+			}
+			params.KnowledgeIDs = knowledgeIDs
+			params.TagIDs = nil
+		}
+	}
+
+	// Documents hidden from the user by a document permission are excluded inside the retrieval engines
+	var hiddenKnowledgeIDs []string
+	if userID, _ := ctx.Value(types.UserIDContextKey).(string); userID != "" && s.kbPermissionRepo != nil {
+		hiddenKnowledgeIDs, err = s.kbPermissionRepo.ListHiddenKnowledgeIDs(ctx, id, userID)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to load hidden knowledge of knowledge base %s: %v", id, err)
+			return nil, err
+		}
+	}
+
 	matchCount := params.MatchCount * 3
 
 	// Add vector retrieval params if supported
@@ -648,14 +874,15 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		logger.Infof(ctx, "Query embedding generated successfully, embedding vector length: %d", len(queryEmbedding))
 
 		vectorParams := types.RetrieveParams{
-			Query:            params.QueryText,
-			Embedding:        queryEmbedding,
-			KnowledgeBaseIDs: []string{id},
-			TopK:             matchCount,
-			Threshold:        params.VectorThreshold,
-			RetrieverType:    types.VectorRetrieverType,
-			KnowledgeIDs:     params.KnowledgeIDs,
-			TagIDs:           params.TagIDs,
+			Query:               params.QueryText,
+			Embedding:           queryEmbedding,
+			KnowledgeBaseIDs:    []string{id},
+			TopK:                matchCount,
+			Threshold:           params.VectorThreshold,
+			RetrieverType:       types.VectorRetrieverType,
+			KnowledgeIDs:        params.KnowledgeIDs,
+			TagIDs:              params.TagIDs,
+			ExcludeKnowledgeIDs: hiddenKnowledgeIDs,
 		}
 
 		// For FAQ knowledge base, use FAQ index
@@ -671,15 +898,32 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	if retrieveEngine.SupportRetriever(types.KeywordsRetrieverType) && !params.DisableKeywordsMatch &&
 		kb.Type != types.KnowledgeBaseTypeFAQ {
 		logger.Info(ctx, "Keyword retrieval supported, preparing keyword retrieval parameters")
-		retrieveParams = append(retrieveParams, types.RetrieveParams{
-			Query:            params.QueryText,
-			KnowledgeBaseIDs: []string{id},
-			TopK:             matchCount,
-			Threshold:        params.KeywordThreshold,
-			RetrieverType:    types.KeywordsRetrieverType,
-			KnowledgeIDs:     params.KnowledgeIDs,
-			TagIDs:           params.TagIDs,
-		})
+		keywordParams := types.RetrieveParams{
+			Query:               params.QueryText,
+			KnowledgeBaseIDs:    []string{id},
+			TopK:                matchCount,
+			Threshold:           params.KeywordThreshold,
+			RetrieverType:       types.KeywordsRetrieverType,
+			KnowledgeIDs:        params.KnowledgeIDs,
+			TagIDs:              params.TagIDs,
+			KeywordAnalyzer:     kb.KeywordSearchConfig.GetAnalyzer(),
+			ExcludeKnowledgeIDs: hiddenKnowledgeIDs,
+		}
+		// Source code files are matched with the code analyzer whatever the analyzer of the knowledge base
+		if keywordParams.KeywordAnalyzer != types.KeywordAnalyzerCode &&
+			types.SupportsKeywordAnalyzers(tenantInfo.GetEffectiveEngines()) {
+			codeKnowledgeIDs, err := s.kgRepo.ListIDsByFilter(ctx, kb.TenantID, id,
+				&types.SearchFilter{FileTypes: types.CodeFileTypes()})
+			if err != nil {
+				logger.Errorf(ctx, "Failed to list source code files of knowledge base %s: %v", id, err)
+				return nil, err
+			}
+			keywordLegs := splitCodeKeywordParams(keywordParams, codeKnowledgeIDs)
+			splitKeywordResults = len(keywordLegs) > 1
+			retrieveParams = append(retrieveParams, keywordLegs...)
+		} else {
+			retrieveParams = append(retrieveParams, keywordParams)
+		}
 		logger.Info(ctx, "Keyword retrieval parameters setup completed")
 	}
 
@@ -718,6 +962,13 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		}
 	}
 
+	// BM25 scores of the code and text analyzers are ranked together
+	if splitKeywordResults {
+		slices.SortStableFunc(keywordResults, func(a, b *types.IndexWithScore) int {
+			return cmp.Compare(b.Score, a.Score)
+		})
+	}
+
 	// Early return if no results
 	if len(vectorResults) == 0 && len(keywordResults) == 0 {
 		logger.Info(ctx, "No search results found")
@@ -725,6 +976,14 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	}
 	logger.Infof(ctx, "Result count before fusion: vector=%d, keyword=%d", len(vectorResults), len(keywordResults))
 
+	// Snapshot retriever hits for debug requests, fusion below overwrites their scores
+	var searchTrace *types.HybridSearchTrace
+	if trace := types.RetrievalTraceFromContext(ctx); trace != nil {
+		searchTrace = newHybridSearchTrace(id, params.QueryText, vectorResults, keywordResults)
+		searchTrace.KeywordAnalyzer = kb.KeywordSearchConfig.GetAnalyzer()
+		defer trace.AddSearch(searchTrace)
+	}
+
 	var deduplicatedChunks []*types.IndexWithScore
 
 	// If only vector results (no keyword results), keep original embedding scores
@@ -753,10 +1012,8 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		logger.Infof(ctx, "Result count after deduplication: %d", len(deduplicatedChunks))
 	} else {
 		// Use RRF (Reciprocal Rank Fusion) to merge results from multiple retrievers
-		// RRF score = sum(1 / (k + rank)) for each retriever where the chunk appears
-		// k=60 is a common choice that works well in practice
-		const rrfK = 60
-
+		// RRF score = sum(weight / (k + rank)) for each retriever where the chunk appears,
+		// the weights are knowledge base settings and default to 1
 		// Build rank maps for each retriever (already sorted by score from retriever)
 		vectorRanks := make(map[string]int)
 		for i, r := range vectorResults {
@@ -790,13 +1047,15 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		}
 
 		// Compute RRF scores
+		vectorWeight := kb.KeywordSearchConfig.GetVectorWeight()
+		keywordWeight := kb.KeywordSearchConfig.GetKeywordWeight()
 		for chunkID := range chunkInfoMap {
 			rrfScore := 0.0
 			if rank, ok := vectorRanks[chunkID]; ok {
-				rrfScore += 1.0 / float64(rrfK+rank)
+				rrfScore += vectorWeight / float64(rrfK+rank)
 			}
 			if rank, ok := keywordRanks[chunkID]; ok {
-				rrfScore += 1.0 / float64(rrfK+rank)
+				rrfScore += keywordWeight / float64(rrfK+rank)
 			}
 			rrfScores[chunkID] = rrfScore
 		}
@@ -856,6 +1115,9 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	if len(deduplicatedChunks) > params.MatchCount {
 		deduplicatedChunks = deduplicatedChunks[:params.MatchCount]
 	}
+	if searchTrace != nil {
+		setFusedHits(searchTrace, deduplicatedChunks, len(keywordResults) == 0, kb.KeywordSearchConfig)
+	}
 
 	return s.processSearchResults(ctx, deduplicatedChunks)
 }
@@ -1112,6 +1374,7 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 	chunkScores := make(map[string]float64)
 	chunkMatchTypes := make(map[string]types.MatchType)
 	chunkMatchedContents := make(map[string]string)
+	chunkSourceIDs := make(map[string]string)
 	processedKnowledgeIDs := make(map[string]bool)
 
 	// Collect all knowledge and chunk IDs
@@ -1125,6 +1388,7 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 		chunkScores[chunk.ChunkID] = chunk.Score
 		chunkMatchTypes[chunk.ChunkID] = chunk.MatchType
 		chunkMatchedContents[chunk.ChunkID] = chunk.Content
+		chunkSourceIDs[chunk.ChunkID] = chunk.SourceID
 	}
 
 	// Batch fetch knowledge data (include shared KB so cross-tenant retrieval works)
@@ -1226,7 +1490,9 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 		if knowledge, ok := knowledgeMap[chunk.KnowledgeID]; ok {
 			matchType := chunkMatchTypes[chunk.ID]
 			matchedContent := chunkMatchedContents[chunk.ID]
-			searchResults = append(searchResults, s.buildSearchResult(chunk, knowledge, score, matchType, matchedContent))
+			result := s.buildSearchResult(chunk, knowledge, score, matchType, matchedContent)
+			result.MatchedQuestion = matchedGeneratedQuestion(chunk, chunkSourceIDs[chunk.ID])
+			searchResults = append(searchResults, result)
 			addedChunkIDs[chunk.ID] = true
 		} else {
 			logger.Warnf(ctx, "Knowledge not found for chunk: %s, knowledge_id: %s", chunk.ID, chunk.KnowledgeID)
@@ -1260,6 +1526,19 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 	return searchResults, nil
 }
 
+// matchedGeneratedQuestion returns the generated question of a document chunk that the
+// search hit came from, or nil when the hit matched the chunk content itself.
+func matchedGeneratedQuestion(chunk *types.Chunk, sourceID string) *types.GeneratedQuestion {
+	if chunk.ChunkType != types.ChunkTypeText || sourceID == "" || sourceID == chunk.ID {
+		return nil
+	}
+	meta, err := chunk.DocumentMetadata()
+	if err != nil {
+		return nil
+	}
+	return meta.QuestionBySourceID(chunk.ID, sourceID)
+}
+
 // collectRelatedChunkIDs extracts related chunk IDs from a chunk
 func (s *knowledgeBaseService) collectRelatedChunkIDs(chunk *types.Chunk, processedIDs map[string]bool) []string {
 	var relatedIDs []string
@@ -1289,6 +1568,7 @@ func (s *knowledgeBaseService) buildSearchResult(chunk *types.Chunk,
 		ID:                chunk.ID,
 		Content:           chunk.Content,
 		KnowledgeID:       chunk.KnowledgeID,
+		KnowledgeBaseID:   chunk.KnowledgeBaseID,
 		ChunkIndex:        chunk.ChunkIndex,
 		KnowledgeTitle:    knowledge.Title,
 		StartAt:           chunk.StartAt,
@@ -1304,6 +1584,7 @@ func (s *knowledgeBaseService) buildSearchResult(chunk *types.Chunk,
 		KnowledgeSource:   knowledge.Source,
 		ChunkMetadata:     chunk.Metadata,
 		MatchedContent:    matchedContent,
+		Pinned:            chunk.IsPinned(),
 	}
 }
 
@@ -1312,7 +1593,7 @@ func (s *knowledgeBaseService) isValidTextChunk(chunk *types.Chunk) bool {
 	return slices.Contains([]types.ChunkType{
 		types.ChunkTypeText, types.ChunkTypeSummary,
 		types.ChunkTypeTableColumn, types.ChunkTypeTableSummary,
-		types.ChunkTypeFAQ,
+		types.ChunkTypeFAQ, types.ChunkTypeTable,
 	}, chunk.ChunkType)
 }
 
@@ -1470,3 +1751,56 @@ func (s *knowledgeBaseService) listChunksByIDWithShared(ctx context.Context,
 	logger.Infof(ctx, "[listChunksByIDWithShared] After shared lookup, total chunks: %d", len(chunks))
 	return chunks, nil
 }
+
+// CheckEmbeddingDimension rejects binding a knowledge base to embeddings of another dimension than
+// the vectors it already holds, which would make them unsearchable. The dimension of the stored
+// vectors is the one configured on the current embedding model of the knowledge base.
+func (s *knowledgeBaseService) CheckEmbeddingDimension(ctx context.Context,
+	kb *types.KnowledgeBase, dimension int,
+) error {
+	if dimension <= 0 || kb.EmbeddingModelID == "" {
+		return nil
+	}
+	current, err := s.modelService.GetModelByID(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		// The current model is gone, there is nothing to compare against
+		if errors.Is(err, ErrModelNotFound) {
+			return nil
+		}
+		return err
+	}
+	existing := current.Parameters.EmbeddingParameters.Dimension
+	if existing <= 0 || existing == dimension {
+		return nil
+	}
+	hasVectors, err := kbHasVectors(ctx, s.chunkRepo, kb)
+	if err != nil {
+		return err
+	}
+	if hasVectors {
+		return fmt.Errorf("%w: knowledge base %q holds %d-dimension vectors, the model produces %d, "+
+			"re-index the knowledge base instead", ErrEmbeddingDimensionMismatch, kb.Name, existing, dimension)
+	}
+	return nil
+}
+
+// checkVLMModel checks that an enabled VLM config points at a vision model of the tenant
+func (s *knowledgeBaseService) checkVLMModel(ctx context.Context, config *types.VLMConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.ModelID == "" {
+		return werrors.NewBadRequestError("VLM model is required when image understanding is enabled")
+	}
+	model, err := s.modelService.GetModelByID(ctx, config.ModelID)
+	if err != nil {
+		if errors.Is(err, ErrModelNotFound) {
+			return werrors.NewBadRequestError("VLM model not found")
+		}
+		return err
+	}
+	if model.Type != types.ModelTypeVLLM {
+		return werrors.NewBadRequestError("VLM model must be a VLLM model")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestSplitCodeKeywordParams(t *testing.T) {
+	base := types.RetrieveParams{
+		Query:               "parseConfig",
+		KeywordAnalyzer:     types.KeywordAnalyzerEnglish,
+		ExcludeKnowledgeIDs: []string{"hidden"},
+	}
+	withIDs := func(p types.RetrieveParams, ids ...string) types.RetrieveParams {
+		p.KnowledgeIDs = ids
+		return p
+	}
+	text := func(p types.RetrieveParams, exclude ...string) types.RetrieveParams {
+		p.ExcludeKnowledgeIDs = exclude
+		return p
+	}
+	code := func(ids ...string) types.RetrieveParams {
+		p := base
+		p.KnowledgeIDs = ids
+		p.KeywordAnalyzer = types.KeywordAnalyzerCode
+		return p
+	}
+
+	tests := []struct {
+		name   string
+		params types.RetrieveParams
+		code   []string
+		want   []types.RetrieveParams
+	}{
+		{"no code files", base, nil, []types.RetrieveParams{base}},
+		{"code files", base, []string{"a.go", "b.py"},
+			[]types.RetrieveParams{text(base, "hidden", "a.go", "b.py"), code("a.go", "b.py")}},
+		{"selected text only", withIDs(base, "doc"), []string{"a.go"},
+			[]types.RetrieveParams{withIDs(base, "doc")}},
+		{"selected code only", withIDs(base, "a.go"), []string{"a.go", "b.py"},
+			[]types.RetrieveParams{code("a.go")}},
+		{"selected mixed", withIDs(base, "doc", "a.go"), []string{"a.go", "b.py"},
+			[]types.RetrieveParams{withIDs(base, "doc"), code("a.go")}},
+	}
+	for _, tt := range tests {
+		if got := splitCodeKeywordParams(tt.params, tt.code); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+	if !reflect.DeepEqual(base.ExcludeKnowledgeIDs, []string{"hidden"}) {
+		t.Errorf("params modified: %v", base.ExcludeKnowledgeIDs)
+	}
+}
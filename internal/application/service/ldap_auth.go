@@ -0,0 +1,537 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/hibiken/asynq"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// LDAPAuthProvider is the auth provider stored on users signing in with LDAP
+const LDAPAuthProvider = "ldap"
+
+const (
+	defaultLDAPUserFilter  = "(&(objectClass=user)(|(sAMAccountName={username})(userPrincipalName={username})(mail={username})))"
+	defaultLDAPGroupFilter = "(member={dn})"
+	// adAccountDisabled is the ACCOUNTDISABLE flag of Active Directory's userAccountControl
+	adAccountDisabled = 0x2
+	// defaultLDAPTimeout bounds dialing and each directory operation when no timeout is configured
+	defaultLDAPTimeout = 10 * time.Second
+)
+
+// ErrLDAPUserNotFound is returned when the directory has no user with the login name
+var ErrLDAPUserNotFound = errors.New("ldap user not found")
+
+// ldapService authenticates users with simple binds and maps their directory groups to tenants and shared spaces
+type ldapService struct {
+	cfg         *config.Config
+	userRepo    interfaces.UserRepository
+	tokenRepo   interfaces.AuthTokenRepository
+	userService interfaces.UserService
+	tenants     interfaces.TenantService
+	orgRepo     interfaces.OrganizationRepository
+	orgService  interfaces.OrganizationService
+	users       *externalUserProvisioner
+}
+
+// NewLDAPService creates the LDAP login and group sync service
+func NewLDAPService(
+	cfg *config.Config,
+	userRepo interfaces.UserRepository,
+	tokenRepo interfaces.AuthTokenRepository,
+	userService interfaces.UserService,
+	tenantService interfaces.TenantService,
+	orgRepo interfaces.OrganizationRepository,
+	orgService interfaces.OrganizationService,
+) interfaces.LDAPService {
+	return &ldapService{
+		cfg:         cfg,
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		userService: userService,
+		tenants:     tenantService,
+		orgRepo:     orgRepo,
+		orgService:  orgService,
+		users:       &externalUserProvisioner{userRepo: userRepo, tenantService: tenantService},
+	}
+}
+
+// Enabled reports whether LDAP login is configured
+func (s *ldapService) Enabled() bool {
+	return s.config() != nil
+}
+
+func (s *ldapService) config() *config.LDAPConfig {
+	if s.cfg.Auth == nil || s.cfg.Auth.LDAP == nil || !s.cfg.Auth.LDAP.Enabled || s.cfg.Auth.LDAP.URL == "" {
+		return nil
+	}
+	return s.cfg.Auth.LDAP
+}
+
+// Login authenticates a directory user and issues login tokens, wrong credentials give an unsuccessful response
+func (s *ldapService) Login(ctx context.Context, username, password string) (*types.LoginResponse, error) {
+	cfg := s.config()
+	if cfg == nil {
+		return nil, ErrLDAPUserNotFound
+	}
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return &types.LoginResponse{Success: false, Message: i18n.T(ctx, i18n.MsgInvalidCredentials)}, nil
+	}
+
+	conn, err := s.dial(cfg)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to connect to LDAP server: %v", err)
+		return nil, werrors.NewInternalServerError("Directory server unavailable").WithDetails(err.Error())
+	}
+	defer conn.Close()
+
+	entry, err := s.findUser(conn, cfg, username)
+	if err != nil {
+		if errors.Is(err, ErrLDAPUserNotFound) {
+			return nil, err
+		}
+		logger.Errorf(ctx, "Failed to look up LDAP user: %v", err)
+		return nil, werrors.NewInternalServerError("Directory lookup failed").WithDetails(err.Error())
+	}
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.ErrorEmptyPassword) ||
+			ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			logger.Warnf(ctx, "LDAP password verification failed for %s", secutils.SanitizeForLog(username))
+			return &types.LoginResponse{Success: false, Message: i18n.T(ctx, i18n.MsgInvalidCredentials)}, nil
+		}
+		return nil, werrors.NewInternalServerError("Directory login failed").WithDetails(err.Error())
+	}
+	if ldapAccountDisabled(entry) {
+		return &types.LoginResponse{Success: false, Message: i18n.T(ctx, i18n.MsgAccountDisabled)}, nil
+	}
+
+	// Groups are read with the service account, users may not be allowed to read them
+	if err := bindServiceAccount(conn, cfg); err != nil {
+		return nil, werrors.NewInternalServerError("Directory lookup failed").WithDetails(err.Error())
+	}
+	groups, err := s.userGroups(conn, cfg, entry, username)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to read groups of LDAP user: %v", err)
+		return nil, werrors.NewInternalServerError("Directory lookup failed").WithDetails(err.Error())
+	}
+	if len(cfg.RequiredGroups) > 0 && !matchesAnyGroup(groups, cfg.RequiredGroups) {
+		return nil, werrors.NewForbiddenError("The account is not in a group allowed to sign in")
+	}
+
+	email := strings.TrimSpace(entry.GetEqualFoldAttributeValue(defaultString(cfg.EmailAttribute, "mail")))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, werrors.NewForbiddenError("The directory entry has no email address")
+	}
+	user, err := s.users.findOrCreate(ctx, &externalIdentity{
+		AuthProvider:  LDAPAuthProvider,
+		ExternalID:    ldapExternalID(cfg, entry),
+		Email:         email,
+		Username:      entry.GetEqualFoldAttributeValue(defaultString(cfg.UsernameAttribute, "sAMAccountName")),
+		TenantID:      resolveLDAPTenant(cfg, groups),
+		LinkExisting:  cfg.LinkExistingUsers,
+		AutoProvision: cfg.AutoProvision,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Users disabled by an administrator, or by a sync, stay disabled until an administrator enables them
+	if !user.IsActive {
+		return &types.LoginResponse{Success: false, Message: i18n.T(ctx, i18n.MsgAccountDisabled)}, nil
+	}
+	if err := s.applyGroups(ctx, cfg, user, groups); err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, err := s.userService.GenerateTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := s.tenants.GetTenantByID(ctx, user.TenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get tenant %d of LDAP user: %v", user.TenantID, err)
+	}
+	logger.Infof(ctx, "User %s signed in with LDAP", user.ID)
+	return &types.LoginResponse{
+		Success:      true,
+		Message:      i18n.T(ctx, i18n.MsgLoginSucceeded),
+		User:         user,
+		Tenant:       tenant,
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// ProcessLDAPSync re-reads every directory user, disabling those removed, disabled or out of the required
+// groups and bringing their shared space roles and cross-tenant access in line with their groups
+func (s *ldapService) ProcessLDAPSync(ctx context.Context, t *asynq.Task) error {
+	cfg := s.config()
+	if cfg == nil {
+		return nil
+	}
+	users, err := s.userRepo.ListUsersByAuthProvider(ctx, LDAPAuthProvider)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	conn, err := s.dial(cfg)
+	if err != nil {
+		return fmt.Errorf("connect to ldap server: %w", err)
+	}
+	defer conn.Close()
+
+	disabled, failed := 0, 0
+	for _, user := range users {
+		entry, err := s.lookupUser(conn, cfg, user.ExternalID)
+		if err != nil && !errors.Is(err, ErrLDAPUserNotFound) {
+			// A lookup error says nothing about the user, keep it as it is
+			failed++
+			logger.Warnf(ctx, "Failed to look up LDAP user %s: %v", user.ID, err)
+			continue
+		}
+
+		var groups []string
+		active := err == nil && !ldapAccountDisabled(entry)
+		if active {
+			username := entry.GetEqualFoldAttributeValue(defaultString(cfg.UsernameAttribute, "sAMAccountName"))
+			groups, err = s.userGroups(conn, cfg, entry, username)
+			if err != nil {
+				failed++
+				logger.Warnf(ctx, "Failed to read groups of LDAP user %s: %v", user.ID, err)
+				continue
+			}
+			active = len(cfg.RequiredGroups) == 0 || matchesAnyGroup(groups, cfg.RequiredGroups)
+		}
+
+		if !active {
+			if user.IsActive {
+				disabled++
+				s.disableUser(ctx, user)
+			}
+			continue
+		}
+		if user.IsActive {
+			if err := s.applyGroups(ctx, cfg, user, groups); err != nil {
+				failed++
+				logger.Warnf(ctx, "Failed to sync groups of LDAP user %s: %v", user.ID, err)
+			}
+		}
+	}
+
+	logger.Infof(ctx, "LDAP sync completed, users: %d, disabled: %d, failed: %d", len(users), disabled, failed)
+	return nil
+}
+
+// disableUser deactivates a user gone from the directory and revokes its login sessions and tokens
+func (s *ldapService) disableUser(ctx context.Context, user *types.User) {
+	user.IsActive = false
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		logger.Warnf(ctx, "Failed to disable LDAP user %s: %v", user.ID, err)
+		return
+	}
+	if err := s.tokenRepo.RevokeSessionsByUserID(ctx, user.ID, ""); err != nil {
+		logger.Warnf(ctx, "Failed to revoke tokens of LDAP user %s: %v", user.ID, err)
+	}
+	logger.Infof(ctx, "Disabled LDAP user %s", user.ID)
+}
+
+// applyGroups brings the shared space memberships and cross-tenant access of a user in line with its groups.
+// Only the spaces named in the mappings are managed, and owners keep their role.
+func (s *ldapService) applyGroups(ctx context.Context, cfg *config.LDAPConfig, user *types.User, groups []string) error {
+	desired := make(map[string]types.OrgMemberRole)
+	managed := make(map[string]bool)
+	managesCrossTenant, crossTenant := false, false
+	for _, mapping := range cfg.GroupMappings {
+		if mapping.CrossTenantAccess {
+			managesCrossTenant = true
+		}
+		if mapping.OrganizationID != "" {
+			managed[mapping.OrganizationID] = true
+		}
+		if !matchesAnyGroup(groups, []string{mapping.Group}) {
+			continue
+		}
+		crossTenant = crossTenant || mapping.CrossTenantAccess
+		if mapping.OrganizationID == "" {
+			continue
+		}
+		role := types.OrgMemberRole(defaultString(mapping.Role, string(types.OrgRoleViewer)))
+		if !role.IsValid() {
+			logger.Warnf(ctx, "Ignoring LDAP group mapping of %s with invalid role %q", mapping.Group, mapping.Role)
+			continue
+		}
+		if current, ok := desired[mapping.OrganizationID]; !ok || role.HasPermission(current) {
+			desired[mapping.OrganizationID] = role
+		}
+	}
+
+	if managesCrossTenant {
+		user.CanAccessAllTenants = crossTenant
+	}
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	for orgID := range managed {
+		if err := s.syncMembership(ctx, orgID, user, desired[orgID]); err != nil {
+			logger.Warnf(ctx, "Failed to sync membership of user %s in organization %s: %v", user.ID, orgID, err)
+		}
+	}
+	return nil
+}
+
+// syncMembership adds, updates or, with an empty role, removes the membership of a user in a shared space
+func (s *ldapService) syncMembership(ctx context.Context, orgID string, user *types.User, role types.OrgMemberRole) error {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if org.OwnerID == user.ID {
+		return nil
+	}
+	member, err := s.orgRepo.GetMember(ctx, orgID, user.ID)
+	if err != nil && !errors.Is(err, repository.ErrOrgMemberNotFound) {
+		return err
+	}
+	switch {
+	case role == "" && member != nil:
+		logger.Infof(ctx, "Removing LDAP user %s from organization %s", user.ID, orgID)
+		return s.orgRepo.RemoveMember(ctx, orgID, user.ID)
+	case role != "" && member == nil:
+		logger.Infof(ctx, "Adding LDAP user %s to organization %s as %s", user.ID, orgID, role)
+		return s.orgService.AddMember(ctx, orgID, user.ID, user.TenantID, role)
+	case role != "" && member.Role != role:
+		logger.Infof(ctx, "Changing role of LDAP user %s in organization %s to %s", user.ID, orgID, role)
+		return s.orgRepo.UpdateMemberRole(ctx, orgID, user.ID, role)
+	}
+	return nil
+}
+
+// dial connects, upgrading ldap:// connections with StartTLS when configured, and binds with the service account
+func (s *ldapService) dial(cfg *config.LDAPConfig) (*ldap.Conn, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap url: %w", err)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultLDAPTimeout
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	conn, err := ldap.DialURL(cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+	if cfg.StartTLS && strings.EqualFold(u.Scheme, "ldap") {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := bindServiceAccount(conn, cfg); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// bindServiceAccount binds with the service account, anonymously when no bind DN is configured
+func bindServiceAccount(conn *ldap.Conn, cfg *config.LDAPConfig) error {
+	if cfg.BindDN == "" && cfg.BindPassword == "" {
+		return conn.UnauthenticatedBind("")
+	}
+	return conn.Bind(cfg.BindDN, cfg.BindPassword)
+}
+
+// ldapSearch runs a search and returns its entries, the entries sent along with a size limit error are kept
+func ldapSearch(conn *ldap.Conn, cfg *config.LDAPConfig,
+	baseDN string, scope int, filter string, attributes []string, sizeLimit int,
+) ([]*ldap.Entry, error) {
+	timeLimit := int(cfg.Timeout / time.Second)
+	if timeLimit <= 0 {
+		timeLimit = int(defaultLDAPTimeout / time.Second)
+	}
+	result, err := conn.Search(ldap.NewSearchRequest(baseDN, scope, ldap.DerefAlways,
+		sizeLimit, timeLimit, false, filter, attributes, nil))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) && result != nil && len(result.Entries) > 0 {
+			return result.Entries, nil
+		}
+		return nil, err
+	}
+	return result.Entries, nil
+}
+
+// userAttributes are the attributes read from user entries
+func userAttributes(cfg *config.LDAPConfig) []string {
+	attributes := []string{
+		defaultString(cfg.UsernameAttribute, "sAMAccountName"),
+		defaultString(cfg.EmailAttribute, "mail"),
+		defaultString(cfg.GroupAttribute, "memberOf"),
+		"userAccountControl",
+	}
+	if cfg.IDAttribute != "" {
+		attributes = append(attributes, cfg.IDAttribute)
+	}
+	return attributes
+}
+
+// findUser searches the user with a login name, which must match exactly one entry
+func (s *ldapService) findUser(conn *ldap.Conn, cfg *config.LDAPConfig, username string) (*ldap.Entry, error) {
+	filter := strings.ReplaceAll(defaultString(cfg.UserFilter, defaultLDAPUserFilter), "{username}", ldap.EscapeFilter(username))
+	entries, err := ldapSearch(conn, cfg, cfg.UserBaseDN, ldap.ScopeWholeSubtree, filter, userAttributes(cfg), 2)
+	if err != nil {
+		return nil, err
+	}
+	switch len(entries) {
+	case 0:
+		return nil, ErrLDAPUserNotFound
+	case 1:
+		return entries[0], nil
+	}
+	return nil, fmt.Errorf("login name matches more than one directory entry")
+}
+
+// lookupUser reads the entry of a user by the external ID stored at provisioning
+func (s *ldapService) lookupUser(conn *ldap.Conn, cfg *config.LDAPConfig, externalID string) (*ldap.Entry, error) {
+	baseDN, scope, filter := externalID, ldap.ScopeBaseObject, "(objectClass=*)"
+	if cfg.IDAttribute != "" {
+		raw, err := hex.DecodeString(externalID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid external id: %w", err)
+		}
+		baseDN, scope = cfg.UserBaseDN, ldap.ScopeWholeSubtree
+		filter = "(" + cfg.IDAttribute + "=" + escapeFilterBytes(raw) + ")"
+	}
+	entries, err := ldapSearch(conn, cfg, baseDN, scope, filter, userAttributes(cfg), 2)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+		return nil, ErrLDAPUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != 1 {
+		return nil, ErrLDAPUserNotFound
+	}
+	return entries[0], nil
+}
+
+// userGroups returns the DNs of the groups of a user, from its group attribute or a group search
+func (s *ldapService) userGroups(conn *ldap.Conn, cfg *config.LDAPConfig,
+	entry *ldap.Entry, username string,
+) ([]string, error) {
+	if cfg.GroupBaseDN == "" {
+		return entry.GetEqualFoldAttributeValues(defaultString(cfg.GroupAttribute, "memberOf")), nil
+	}
+	filter := strings.NewReplacer(
+		"{dn}", ldap.EscapeFilter(entry.DN),
+		"{username}", ldap.EscapeFilter(username),
+	).Replace(defaultString(cfg.GroupFilter, defaultLDAPGroupFilter))
+	entries, err := ldapSearch(conn, cfg, cfg.GroupBaseDN, ldap.ScopeWholeSubtree, filter, []string{"cn"}, 0)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]string, 0, len(entries))
+	for _, group := range entries {
+		groups = append(groups, group.DN)
+	}
+	return groups, nil
+}
+
+// ldapExternalID returns the identifier stored for a directory user: the hex encoded ID attribute, or the DN
+func ldapExternalID(cfg *config.LDAPConfig, entry *ldap.Entry) string {
+	if cfg.IDAttribute != "" {
+		if values := entry.GetEqualFoldRawAttributeValues(cfg.IDAttribute); len(values) > 0 {
+			return hex.EncodeToString(values[0])
+		}
+	}
+	return entry.DN
+}
+
+// ldapAccountDisabled reports whether Active Directory marks the account as disabled
+func ldapAccountDisabled(entry *ldap.Entry) bool {
+	flags, err := strconv.ParseInt(entry.GetEqualFoldAttributeValue("userAccountControl"), 10, 64)
+	return err == nil && flags&adAccountDisabled != 0
+}
+
+// resolveLDAPTenant returns the tenant of the first mapping whose group the user is in, or the default tenant
+func resolveLDAPTenant(cfg *config.LDAPConfig, groups []string) uint64 {
+	for _, mapping := range cfg.GroupMappings {
+		if mapping.TenantID != 0 && matchesAnyGroup(groups, []string{mapping.Group}) {
+			return mapping.TenantID
+		}
+	}
+	return cfg.DefaultTenantID
+}
+
+// matchesAnyGroup reports whether any group DN matches a wanted group, given by DN or common name
+func matchesAnyGroup(groups []string, wanted []string) bool {
+	for _, group := range groups {
+		cn := groupCommonName(group)
+		for _, w := range wanted {
+			if strings.EqualFold(group, w) || (cn != "" && strings.EqualFold(cn, w)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// groupCommonName returns the value of the first RDN of a DN, such as Engineering for CN=Engineering,OU=Groups
+func groupCommonName(dn string) string {
+	for i := 0; i < len(dn); i++ {
+		if dn[i] == '\\' {
+			i++
+			continue
+		}
+		if dn[i] == ',' {
+			dn = dn[:i]
+			break
+		}
+	}
+	_, value, found := strings.Cut(dn, "=")
+	if !found {
+		return ""
+	}
+	return strings.ReplaceAll(strings.TrimSpace(value), "\\", "")
+}
+
+// escapeFilterBytes escapes every byte of a binary value for a search filter
+func escapeFilterBytes(raw []byte) string {
+	var b strings.Builder
+	for _, c := range raw {
+		fmt.Fprintf(&b, "\\%02x", c)
+	}
+	return b.String()
+}
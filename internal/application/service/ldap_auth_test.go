@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestLDAPGroupMatching(t *testing.T) {
+	groups := []string{"CN=Engineering,OU=Groups,DC=example,DC=com", "CN=Sales\\, EMEA,OU=Groups,DC=example,DC=com"}
+	tests := []struct {
+		wanted string
+		want   bool
+	}{
+		{"engineering", true},
+		{"cn=engineering,ou=groups,dc=example,dc=com", true},
+		{"Sales, EMEA", true},
+		{"Sales", false},
+		{"Groups", false},
+	}
+	for _, tt := range tests {
+		if got := matchesAnyGroup(groups, []string{tt.wanted}); got != tt.want {
+			t.Errorf("matchesAnyGroup(%q) = %v, want %v", tt.wanted, got, tt.want)
+		}
+	}
+
+	cfg := &config.LDAPConfig{
+		DefaultTenantID: 1,
+		GroupMappings: []config.LDAPGroupMapping{
+			{Group: "Admins", CrossTenantAccess: true},
+			{Group: "Engineering", TenantID: 10},
+			{Group: "Sales, EMEA", TenantID: 20},
+		},
+	}
+	if got := resolveLDAPTenant(cfg, groups); got != 10 {
+		t.Errorf("resolveLDAPTenant = %d, want 10", got)
+	}
+	if got := resolveLDAPTenant(cfg, nil); got != 1 {
+		t.Errorf("resolveLDAPTenant without groups = %d, want 1", got)
+	}
+}
+
+func TestEscapeFilterBytes(t *testing.T) {
+	if got := escapeFilterBytes([]byte{0x01, 0xab, '('}); got != "\\01\\ab\\28" {
+		t.Errorf("escapeFilterBytes = %q", got)
+	}
+}
+
+func TestLDAPEntryAttributes(t *testing.T) {
+	entry := ldap.NewEntry("CN=Alice,OU=Users,DC=example,DC=com", map[string][]string{
+		"objectGUID":         {"\x01\xab"},
+		"userAccountControl": {"514"},
+	})
+	if got := ldapExternalID(&config.LDAPConfig{}, entry); got != entry.DN {
+		t.Errorf("external id without id attribute = %q", got)
+	}
+	if got := ldapExternalID(&config.LDAPConfig{IDAttribute: "objectguid"}, entry); got != "01ab" {
+		t.Errorf("external id = %q, want 01ab", got)
+	}
+	if !ldapAccountDisabled(entry) {
+		t.Error("userAccountControl 514 should be disabled")
+	}
+	if ldapAccountDisabled(ldap.NewEntry(entry.DN, map[string][]string{"userAccountControl": {"512"}})) {
+		t.Error("userAccountControl 512 should be enabled")
+	}
+}
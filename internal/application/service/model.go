@@ -3,10 +3,12 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/models/health"
 	"github.com/Tencent/WeKnora/internal/models/rerank"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -16,19 +18,35 @@ import (
 // ErrModelNotFound is returned when a model cannot be found in the repository
 var ErrModelNotFound = errors.New("model not found")
 
+// ErrInvalidModelFallback is returned when the fallback models of a model cannot be used
+var ErrInvalidModelFallback = errors.New("invalid fallback models")
+
+// ErrEmbeddingDimensionMismatch is returned when an embedding model's dimension differs from the
+// vectors a knowledge base already holds
+var ErrEmbeddingDimensionMismatch = errors.New("embedding dimension does not match the existing vectors")
+
 // modelService implements the model service interface
 type modelService struct {
 	repo          interfaces.ModelRepository
 	ollamaService *ollama.OllamaService
 	pooler        embedding.EmbedderPooler
+	tokenUsage    interfaces.TokenUsageService
+	kbRepo        interfaces.KnowledgeBaseRepository
+	chunkRepo     interfaces.ChunkRepository
 }
 
 // NewModelService creates a new model service instance
-func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.OllamaService, pooler embedding.EmbedderPooler) interfaces.ModelService {
+func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.OllamaService,
+	pooler embedding.EmbedderPooler, tokenUsage interfaces.TokenUsageService,
+	kbRepo interfaces.KnowledgeBaseRepository, chunkRepo interfaces.ChunkRepository,
+) interfaces.ModelService {
 	return &modelService{
 		repo:          repo,
 		ollamaService: ollamaService,
 		pooler:        pooler,
+		tokenUsage:    tokenUsage,
+		kbRepo:        kbRepo,
+		chunkRepo:     chunkRepo,
 	}
 }
 
@@ -38,6 +56,14 @@ func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.Olla
 func (s *modelService) CreateModel(ctx context.Context, model *types.Model) error {
 	logger.Infof(ctx, "Creating model: %s, type: %s, source: %s", model.Name, model.Type, model.Source)
 
+	if err := s.validateFallbacks(ctx, model.TenantID, model); err != nil {
+		return err
+	}
+	if err := model.Parameters.Capabilities.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidModelCapabilities, err)
+	}
+	applyLocalProviderEndpoint(ctx, model)
+
 	// Handle remote models (e.g., OpenAI, Azure)
 	if model.Source == types.ModelSourceRemote {
 		logger.Info(ctx, "Remote model detected, setting status to active")
@@ -181,6 +207,18 @@ func (s *modelService) UpdateModel(ctx context.Context, model *types.Model) erro
 		logger.Warnf(ctx, "Attempted to update builtin model: %s", model.ID)
 		return errors.New("builtin models cannot be updated")
 	}
+	if err := s.validateFallbacks(ctx, tenantID, model); err != nil {
+		return err
+	}
+	if err := model.Parameters.Capabilities.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidModelCapabilities, err)
+	}
+	if existingModel != nil {
+		if err := s.checkDimensionChange(ctx, tenantID, existingModel, model); err != nil {
+			return err
+		}
+	}
+	applyLocalProviderEndpoint(ctx, model)
 
 	// Update model in repository
 	err = s.repo.Update(ctx, model)
@@ -246,16 +284,7 @@ func (s *modelService) GetEmbeddingModel(ctx context.Context, modelId string) (e
 	logger.Infof(ctx, "Getting embedding model: %s, source: %s", model.Name, model.Source)
 
 	// Initialize the embedder with model configuration
-	embedder, err := embedding.NewEmbedder(embedding.Config{
-		Source:               model.Source,
-		BaseURL:              model.Parameters.BaseURL,
-		APIKey:               model.Parameters.APIKey,
-		ModelID:              model.ID,
-		ModelName:            model.Name,
-		Dimensions:           model.Parameters.EmbeddingParameters.Dimension,
-		TruncatePromptTokens: model.Parameters.EmbeddingParameters.TruncatePromptTokens,
-		Provider:             model.Parameters.Provider,
-	}, s.pooler, s.ollamaService)
+	embedder, err := s.newEmbeddingChain(ctx, ctx.Value(types.TenantIDContextKey).(uint64), model)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"model_id":   model.ID,
@@ -301,16 +330,7 @@ func (s *modelService) GetEmbeddingModelForTenant(ctx context.Context, modelId s
 	logger.Infof(ctx, "Getting cross-tenant embedding model: %s, source: %s, tenant: %d", model.Name, model.Source, tenantID)
 
 	// Initialize the embedder with model configuration
-	embedder, err := embedding.NewEmbedder(embedding.Config{
-		Source:               model.Source,
-		BaseURL:              model.Parameters.BaseURL,
-		APIKey:               model.Parameters.APIKey,
-		ModelID:              model.ID,
-		ModelName:            model.Name,
-		Dimensions:           model.Parameters.EmbeddingParameters.Dimension,
-		TruncatePromptTokens: model.Parameters.EmbeddingParameters.TruncatePromptTokens,
-		Provider:             model.Parameters.Provider,
-	}, s.pooler, s.ollamaService)
+	embedder, err := s.newEmbeddingChain(ctx, tenantID, model)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"model_id":   model.ID,
@@ -339,13 +359,7 @@ func (s *modelService) GetRerankModel(ctx context.Context, modelId string) (rera
 	logger.Infof(ctx, "Getting rerank model: %s, source: %s", model.Name, model.Source)
 
 	// Initialize the reranker with model configuration
-	reranker, err := rerank.NewReranker(&rerank.RerankerConfig{
-		ModelID:   model.ID,
-		APIKey:    model.Parameters.APIKey,
-		BaseURL:   model.Parameters.BaseURL,
-		ModelName: model.Name,
-		Source:    model.Source,
-	})
+	reranker, err := newReranker(model)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"model_id":   model.ID,
@@ -369,6 +383,11 @@ func (s *modelService) GetChatModel(ctx context.Context, modelId string) (chat.C
 
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 
+	// Tenants that used up their monthly token budget cannot call chat models until next month
+	if err := s.tokenUsage.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+
 	// Get the model directly from repository to avoid status checks
 	model, err := s.repo.GetByID(ctx, tenantID, modelId)
 	if err != nil {
@@ -387,22 +406,215 @@ func (s *modelService) GetChatModel(ctx context.Context, modelId string) (chat.C
 	logger.Infof(ctx, "Getting chat model: %s, source: %s", model.Name, model.Source)
 
 	// Initialize the chat model with model configuration
-	chatModel, err := chat.NewChat(&chat.ChatConfig{
+	chatModel, err := s.newChatChain(ctx, tenantID, model)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"model_id":   model.ID,
+			"model_name": model.Name,
+		})
+		return nil, err
+	}
+
+	return chatModel, nil
+}
+
+// newChat initializes a chat model that reports its token usage
+func (s *modelService) newChat(model *types.Model) (chat.Chat, error) {
+	chatModel, err := s.newChatClient(model)
+	if err != nil {
+		return nil, err
+	}
+	return chat.NewUsageTrackingChat(chatModel, model, s.tokenUsage), nil
+}
+
+// newChatClient initializes a chat model without usage tracking
+func (s *modelService) newChatClient(model *types.Model) (chat.Chat, error) {
+	return chat.NewChat(&chat.ChatConfig{
 		ModelID:   model.ID,
 		APIKey:    model.Parameters.APIKey,
 		BaseURL:   model.Parameters.BaseURL,
 		ModelName: model.Name,
 		Source:    model.Source,
+		Provider:  model.Parameters.Provider,
+		Extra:     chatExtra(model.Parameters.ExtraConfig),
 	}, s.ollamaService)
+}
+
+// newReranker initializes a reranker
+func newReranker(model *types.Model) (rerank.Reranker, error) {
+	return rerank.NewReranker(&rerank.RerankerConfig{
+		ModelID:   model.ID,
+		APIKey:    model.Parameters.APIKey,
+		BaseURL:   model.Parameters.BaseURL,
+		ModelName: model.Name,
+		Source:    model.Source,
+	})
+}
+
+// newChatChain initializes a chat model that fails over to the fallback models of model
+func (s *modelService) newChatChain(ctx context.Context, tenantID uint64, model *types.Model) (chat.Chat, error) {
+	primary, err := s.newChat(model)
 	if err != nil {
-		logger.ErrorWithFields(ctx, err, map[string]interface{}{
-			"model_id":   model.ID,
-			"model_name": model.Name,
-		})
 		return nil, err
 	}
+	chain := []chat.Chat{primary}
+	for _, fallback := range s.fallbackModels(ctx, tenantID, model) {
+		chatModel, err := s.newChat(fallback)
+		if err != nil {
+			logger.Warnf(ctx, "Skipping fallback model %s of %s: %v", fallback.ID, model.ID, err)
+			continue
+		}
+		chain = append(chain, chatModel)
+	}
+	return chat.NewFallbackChat(chain, health.DefaultRegistry()), nil
+}
 
-	return chatModel, nil
+// chatExtra converts the provider-specific settings of a model to the chat configuration format
+func chatExtra(extraConfig map[string]string) map[string]any {
+	if len(extraConfig) == 0 {
+		return nil
+	}
+	extra := make(map[string]any, len(extraConfig))
+	for key, value := range extraConfig {
+		extra[key] = value
+	}
+	return extra
+}
+
+// newEmbedder initializes an embedder
+func (s *modelService) newEmbedder(model *types.Model) (embedding.Embedder, error) {
+	return embedding.NewEmbedder(embedding.Config{
+		Source:               model.Source,
+		BaseURL:              model.Parameters.BaseURL,
+		APIKey:               model.Parameters.APIKey,
+		ModelID:              model.ID,
+		ModelName:            model.Name,
+		Dimensions:           model.Parameters.EmbeddingParameters.Dimension,
+		TruncatePromptTokens: model.Parameters.EmbeddingParameters.TruncatePromptTokens,
+		Provider:             model.Parameters.Provider,
+		Extra:                model.Parameters.ExtraConfig,
+	}, s.pooler, s.ollamaService)
+}
+
+// newEmbeddingChain initializes an embedder that fails over to the fallback models of model
+func (s *modelService) newEmbeddingChain(ctx context.Context,
+	tenantID uint64, model *types.Model,
+) (embedding.Embedder, error) {
+	primary, err := s.newEmbedder(model)
+	if err != nil {
+		return nil, err
+	}
+	chain := []embedding.Embedder{primary}
+	for _, fallback := range s.fallbackModels(ctx, tenantID, model) {
+		embedder, err := s.newEmbedder(fallback)
+		if err != nil {
+			logger.Warnf(ctx, "Skipping fallback model %s of %s: %v", fallback.ID, model.ID, err)
+			continue
+		}
+		chain = append(chain, embedder)
+	}
+	return embedding.NewFallbackEmbedder(chain, health.DefaultRegistry()), nil
+}
+
+// fallbackModels loads the usable fallback models of a model, skipping the ones that were deleted,
+// are not active or no longer match the model
+func (s *modelService) fallbackModels(ctx context.Context, tenantID uint64, model *types.Model) []*types.Model {
+	var fallbacks []*types.Model
+	for _, id := range model.Parameters.FallbackModelIDs {
+		fallback, err := s.repo.GetByID(ctx, tenantID, id)
+		if err != nil || fallback == nil {
+			logger.Warnf(ctx, "Skipping fallback model %s of %s: not found", id, model.ID)
+			continue
+		}
+		if fallback.Status != types.ModelStatusActive {
+			logger.Warnf(ctx, "Skipping fallback model %s of %s: status %s", id, model.ID, fallback.Status)
+			continue
+		}
+		if err := model.CheckFallback(fallback); err != nil {
+			logger.Warnf(ctx, "Skipping fallback model %s of %s: %v", id, model.ID, err)
+			continue
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+	return fallbacks
+}
+
+// applyLocalProviderEndpoint points ollama and vllm models without a base URL at the tenant's
+// configured server
+func applyLocalProviderEndpoint(ctx context.Context, model *types.Model) {
+	if model.Parameters.BaseURL != "" {
+		return
+	}
+	tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if !ok || tenant == nil {
+		return
+	}
+	if endpoint := tenant.LocalProviderConfig.Endpoint(model.Parameters.Provider); endpoint != nil {
+		model.Parameters.BaseURL = endpoint.BaseURL
+		if model.Parameters.APIKey == "" {
+			model.Parameters.APIKey = endpoint.APIKey
+		}
+	}
+}
+
+// validateFallbacks checks the fallback chain of a model before it is saved
+func (s *modelService) validateFallbacks(ctx context.Context, tenantID uint64, model *types.Model) error {
+	ids := model.Parameters.FallbackModelIDs
+	if len(ids) == 0 {
+		return nil
+	}
+	if !model.Type.SupportsFallback() {
+		return fmt.Errorf("%w: %s models do not support fallbacks", ErrInvalidModelFallback, model.Type)
+	}
+	if len(ids) > types.MaxModelFallbacks {
+		return fmt.Errorf("%w: at most %d fallback models are allowed", ErrInvalidModelFallback, types.MaxModelFallbacks)
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("%w: fallback model %s is listed twice", ErrInvalidModelFallback, id)
+		}
+		seen[id] = true
+		fallback, err := s.repo.GetByID(ctx, tenantID, id)
+		if err != nil {
+			return err
+		}
+		if fallback == nil {
+			return fmt.Errorf("%w: fallback model %s not found", ErrInvalidModelFallback, id)
+		}
+		if err := model.CheckFallback(fallback); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidModelFallback, err)
+		}
+	}
+	return nil
+}
+
+// GetModelHealth returns the health of the tenant's models that were called since startup
+func (s *modelService) GetModelHealth(ctx context.Context) ([]health.Status, error) {
+	models, err := s.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]health.Status, 0, len(models))
+	for _, model := range models {
+		statuses = append(statuses, health.DefaultRegistry().Status(model.ID))
+	}
+	return statuses, nil
+}
+
+// GetEmbeddingStats returns the embedding throughput of the tenant's embedding models
+func (s *modelService) GetEmbeddingStats(ctx context.Context) ([]embedding.Stats, error) {
+	models, err := s.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]embedding.Stats, 0, len(models))
+	for _, model := range models {
+		if model.Type == types.ModelTypeEmbedding {
+			stats = append(stats, embedding.GetStats(model.ID))
+		}
+	}
+	return stats, nil
 }
 
 // Note: default model selection logic has been removed; models no longer
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	ollamaapi "github.com/ollama/ollama/api"
+)
+
+const (
+	// providerRequestTimeout bounds the connection tests and model listings of model servers
+	providerRequestTimeout = 10 * time.Second
+	// modelPullTimeout bounds a model download
+	modelPullTimeout = 12 * time.Hour
+	// modelPullRetention is how long finished downloads stay visible
+	modelPullRetention = 24 * time.Hour
+)
+
+var (
+	// ErrUnsupportedModelProvider is returned for providers other than the self-hosted ones
+	ErrUnsupportedModelProvider = errors.New("provider must be ollama or vllm")
+	// ErrModelPullTaskNotFound is returned when a download does not exist for the tenant
+	ErrModelPullTaskNotFound = errors.New("model pull task not found")
+)
+
+// modelProviderService talks to the self-hosted Ollama and vLLM servers of tenants
+type modelProviderService struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	pulls map[string]*types.ModelPullTask
+}
+
+// NewModelProviderService creates the service of self-hosted model servers
+func NewModelProviderService() interfaces.ModelProviderService {
+	return &modelProviderService{
+		client: &http.Client{Timeout: providerRequestTimeout, Transport: utils.OutboundTransport()},
+		pulls:  make(map[string]*types.ModelPullTask),
+	}
+}
+
+// ResolveEndpoint returns the server to use for a provider: the given endpoint when it has a base
+// URL, else the tenant's configured server, else the provider's default address
+func (s *modelProviderService) ResolveEndpoint(ctx context.Context,
+	providerName string, endpoint *types.ProviderEndpoint,
+) (*types.ProviderEndpoint, error) {
+	if providerName != types.LocalProviderOllama && providerName != types.LocalProviderVLLM {
+		return nil, ErrUnsupportedModelProvider
+	}
+	if endpoint != nil && endpoint.BaseURL != "" {
+		return endpoint, nil
+	}
+	if tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant); ok && tenant != nil {
+		if configured := tenant.LocalProviderConfig.Endpoint(providerName); configured != nil {
+			return configured, nil
+		}
+	}
+	if providerName == types.LocalProviderOllama {
+		baseURL := os.Getenv("OLLAMA_BASE_URL")
+		if baseURL == "" {
+			baseURL = provider.OllamaBaseURL
+		}
+		return &types.ProviderEndpoint{BaseURL: baseURL}, nil
+	}
+	return &types.ProviderEndpoint{BaseURL: provider.VLLMBaseURL}, nil
+}
+
+// TestConnection checks that the server answers, and that modelName is served when it is set
+func (s *modelProviderService) TestConnection(ctx context.Context,
+	providerName string, endpoint *types.ProviderEndpoint, modelName string,
+) (*types.ProviderConnectionStatus, error) {
+	endpoint, err := s.ResolveEndpoint(ctx, providerName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	status := &types.ProviderConnectionStatus{
+		Provider: providerName,
+		BaseURL:  endpoint.BaseURL,
+		Models:   []types.ProviderModel{},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, providerRequestTimeout)
+	defer cancel()
+	start := time.Now()
+	if providerName == types.LocalProviderOllama {
+		status.Version, status.Models, err = s.probeOllama(ctx, endpoint)
+	} else {
+		status.Version, status.Models, err = s.probeVLLM(ctx, endpoint)
+	}
+	status.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		logger.Warnf(ctx, "Model server %s at %s is unavailable: %v", providerName, endpoint.BaseURL, err)
+		status.Error = err.Error()
+		return status, nil
+	}
+	status.Available = true
+	if modelName != "" {
+		available := hasProviderModel(providerName, status.Models, modelName)
+		status.ModelAvailable = &available
+	}
+	return status, nil
+}
+
+// ListModels lists the models served by the server
+func (s *modelProviderService) ListModels(ctx context.Context,
+	providerName string, endpoint *types.ProviderEndpoint,
+) ([]types.ProviderModel, error) {
+	endpoint, err := s.ResolveEndpoint(ctx, providerName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, providerRequestTimeout)
+	defer cancel()
+	var models []types.ProviderModel
+	if providerName == types.LocalProviderOllama {
+		_, models, err = s.probeOllama(ctx, endpoint)
+	} else {
+		_, models, err = s.probeVLLM(ctx, endpoint)
+	}
+	return models, err
+}
+
+// probeOllama returns the version and models of an Ollama server
+func (s *modelProviderService) probeOllama(ctx context.Context,
+	endpoint *types.ProviderEndpoint,
+) (string, []types.ProviderModel, error) {
+	service, err := ollama.GetOllamaServiceForURL(endpoint.BaseURL)
+	if err != nil {
+		return "", nil, err
+	}
+	version, err := service.GetClient().Version(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to reach Ollama: %w", err)
+	}
+	installed, err := service.ListModelsDetailed(ctx)
+	if err != nil {
+		return version, nil, err
+	}
+	models := make([]types.ProviderModel, 0, len(installed))
+	for _, model := range installed {
+		modifiedAt := model.ModifiedAt
+		models = append(models, types.ProviderModel{
+			Name:              model.Name,
+			Size:              model.Size,
+			ModifiedAt:        &modifiedAt,
+			Family:            model.Family,
+			ParameterSize:     model.ParameterSize,
+			QuantizationLevel: model.QuantizationLevel,
+		})
+	}
+	return version, models, nil
+}
+
+// probeVLLM returns the version and models of a vLLM or other OpenAI-compatible server
+func (s *modelProviderService) probeVLLM(ctx context.Context,
+	endpoint *types.ProviderEndpoint,
+) (string, []types.ProviderModel, error) {
+	baseURL := strings.TrimRight(endpoint.BaseURL, "/")
+
+	var list struct {
+		Data []struct {
+			ID          string `json:"id"`
+			MaxModelLen int    `json:"max_model_len"`
+		} `json:"data"`
+	}
+	if err := s.getJSON(ctx, baseURL+"/models", endpoint.APIKey, &list); err != nil {
+		return "", nil, err
+	}
+	models := make([]types.ProviderModel, 0, len(list.Data))
+	for _, model := range list.Data {
+		models = append(models, types.ProviderModel{Name: model.ID, MaxModelLen: model.MaxModelLen})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+
+	// vLLM serves its version next to the OpenAI routes; other compatible servers may not
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := s.getJSON(ctx, strings.TrimSuffix(baseURL, "/v1")+"/version", endpoint.APIKey, &version); err != nil {
+		logger.Debugf(ctx, "Model server at %s reports no version: %v", baseURL, err)
+	}
+	return version.Version, models, nil
+}
+
+// getJSON fetches and decodes a JSON document
+func (s *modelProviderService) getJSON(ctx context.Context, url, apiKey string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// hasProviderModel reports whether a model is among the served ones. Ollama names without a tag
+// refer to the latest tag.
+func hasProviderModel(providerName string, models []types.ProviderModel, name string) bool {
+	if providerName == types.LocalProviderOllama && !strings.Contains(name, ":") {
+		name += ":latest"
+	}
+	for _, model := range models {
+		if model.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PullModel starts downloading a model on the tenant's Ollama server. A download of the same model
+// on the same server that is still running is returned instead of starting another one.
+func (s *modelProviderService) PullModel(ctx context.Context,
+	endpoint *types.ProviderEndpoint, modelName string,
+) (*types.ModelPullTask, error) {
+	if !ollama.IsValidModelName(modelName) {
+		return nil, fmt.Errorf("invalid model name: %s", modelName)
+	}
+	endpoint, err := s.ResolveEndpoint(ctx, types.LocalProviderOllama, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	service, err := ollama.GetOllamaServiceForURL(endpoint.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+
+	s.mu.Lock()
+	s.pruneLocked()
+	for _, task := range s.pulls {
+		if task.TenantID == tenantID && task.BaseURL == service.BaseURL() &&
+			task.ModelName == modelName && task.IsRunning() {
+			copied := *task
+			s.mu.Unlock()
+			return &copied, nil
+		}
+	}
+	task := &types.ModelPullTask{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		BaseURL:   service.BaseURL(),
+		ModelName: modelName,
+		Status:    types.ModelPullPending,
+		StartedAt: time.Now(),
+	}
+	s.pulls[task.ID] = task
+	copied := *task
+	s.mu.Unlock()
+
+	pullCtx, cancel := context.WithTimeout(logger.CloneContext(ctx), modelPullTimeout)
+	go func() {
+		defer cancel()
+		s.runPull(pullCtx, service, task.ID, modelName)
+	}()
+	logger.Infof(ctx, "Started pulling model %s on %s, task: %s", modelName, service.BaseURL(), task.ID)
+	return &copied, nil
+}
+
+// runPull downloads a model, recording its progress on the task
+func (s *modelProviderService) runPull(ctx context.Context,
+	service *ollama.OllamaService, taskID, modelName string,
+) {
+	s.updatePull(taskID, types.ModelPullDownloading, 0, "starting download")
+	err := service.GetClient().Pull(ctx, &ollamaapi.PullRequest{Model: modelName},
+		func(progress ollamaapi.ProgressResponse) error {
+			percent := 0.0
+			if progress.Total > 0 {
+				percent = float64(progress.Completed) / float64(progress.Total) * 100
+			}
+			s.updatePull(taskID, types.ModelPullDownloading, percent, progress.Status)
+			return nil
+		})
+	if err != nil {
+		logger.Errorf(ctx, "Failed to pull model %s on %s: %v", modelName, service.BaseURL(), err)
+		s.updatePull(taskID, types.ModelPullFailed, 0, err.Error())
+		return
+	}
+	logger.Infof(ctx, "Pulled model %s on %s", modelName, service.BaseURL())
+	s.updatePull(taskID, types.ModelPullCompleted, 100, "success")
+}
+
+// updatePull records the progress of a download
+func (s *modelProviderService) updatePull(taskID string,
+	status types.ModelPullStatus, progress float64, message string,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.pulls[taskID]
+	if !ok {
+		return
+	}
+	task.Status = status
+	// Ollama reports the progress of each layer, keep the overall progress from going backwards
+	if progress > task.Progress || status != types.ModelPullDownloading {
+		task.Progress = progress
+	}
+	task.Message = message
+	if !task.IsRunning() {
+		now := time.Now()
+		task.FinishedAt = &now
+	}
+}
+
+// pruneLocked forgets downloads that finished long ago
+func (s *modelProviderService) pruneLocked() {
+	for id, task := range s.pulls {
+		if task.FinishedAt != nil && time.Since(*task.FinishedAt) > modelPullRetention {
+			delete(s.pulls, id)
+		}
+	}
+}
+
+// GetPullTask returns a download of the tenant
+func (s *modelProviderService) GetPullTask(ctx context.Context, id string) (*types.ModelPullTask, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.pulls[id]
+	if !ok || task.TenantID != tenantID {
+		return nil, ErrModelPullTaskNotFound
+	}
+	copied := *task
+	return &copied, nil
+}
+
+// ListPullTasks returns the downloads of the tenant, most recent first
+func (s *modelProviderService) ListPullTasks(ctx context.Context) ([]*types.ModelPullTask, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+	tasks := make([]*types.ModelPullTask, 0)
+	for _, task := range s.pulls {
+		if task.TenantID == tenantID {
+			copied := *task
+			tasks = append(tasks, &copied)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartedAt.After(tasks[j].StartedAt) })
+	return tasks, nil
+}
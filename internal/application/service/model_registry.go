@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/health"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// modelProbeTimeout bounds a single health probe
+	modelProbeTimeout = 30 * time.Second
+	// modelProbeConcurrency is the number of models probed at once by the probe task
+	modelProbeConcurrency = 4
+	// modelProbeMaxTokens keeps the completion of chat probes short
+	modelProbeMaxTokens = 8
+)
+
+// ErrInvalidModelCapabilities is returned when the capabilities of a model are invalid
+var ErrInvalidModelCapabilities = errors.New("invalid model capabilities")
+
+// GetModelRegistry lists the tenant's models with their capabilities, last probe and circuit state
+func (s *modelService) GetModelRegistry(ctx context.Context) ([]*types.ModelRegistryEntry, error) {
+	models, err := s.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*types.ModelRegistryEntry, 0, len(models))
+	for _, model := range models {
+		entry := &types.ModelRegistryEntry{
+			ID:           model.ID,
+			Name:         model.Name,
+			Type:         model.Type,
+			Source:       model.Source,
+			Provider:     model.Parameters.Provider,
+			Status:       model.Status,
+			IsBuiltin:    model.IsBuiltin,
+			Capabilities: model.GetCapabilities(),
+			LastProbe:    model.LastProbe,
+			CircuitState: string(health.DefaultRegistry().Status(model.ID).State),
+		}
+		if model.Type == types.ModelTypeEmbedding {
+			entry.EmbeddingDimension = model.Parameters.EmbeddingParameters.Dimension
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ProbeModel runs a health probe of one of the tenant's models and saves the result
+func (s *modelService) ProbeModel(ctx context.Context, id string) (*types.ModelProbeResult, error) {
+	model, err := s.GetModelByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	result := s.probe(ctx, model)
+	if err := s.repo.UpdateProbeResult(ctx, model.ID, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ProcessModelHealthProbe probes the active models of all tenants. Successful probes close the
+// circuit of a recovered endpoint and failed ones count against it, so fallback routing reacts
+// before user traffic hits a broken model.
+func (s *modelService) ProcessModelHealthProbe(ctx context.Context, t *asynq.Task) error {
+	models, err := s.repo.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	unhealthy := 0
+	sem := make(chan struct{}, modelProbeConcurrency)
+	for _, model := range models {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(model *types.Model) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := s.probe(ctx, model)
+			if !result.Healthy {
+				mu.Lock()
+				unhealthy++
+				mu.Unlock()
+				logger.Warnf(ctx, "Model %s (%s) failed health probe: %s", model.ID, model.Name, result.Error)
+			}
+			if err := s.repo.UpdateProbeResult(ctx, model.ID, result); err != nil {
+				logger.Warnf(ctx, "Failed to save health probe of model %s: %v", model.ID, err)
+			}
+		}(model)
+	}
+	wg.Wait()
+
+	logger.Infof(ctx, "Model health probe completed, models: %d, unhealthy: %d", len(models), unhealthy)
+	return nil
+}
+
+// probe sends a minimal request to a model and reports whether it answered. Probes bypass token
+// usage tracking so they are not billed to the tenant.
+func (s *modelService) probe(ctx context.Context, model *types.Model) *types.ModelProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, modelProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result := &types.ModelProbeResult{CheckedAt: start}
+	var err error
+	switch model.Type {
+	case types.ModelTypeEmbedding:
+		err = s.probeEmbedding(ctx, model, result)
+	case types.ModelTypeRerank:
+		err = probeRerank(ctx, model)
+	case types.ModelTypeKnowledgeQA, types.ModelTypeVLLM:
+		err = s.probeChat(ctx, model)
+	default:
+		err = fmt.Errorf("unsupported model type: %s", model.Type)
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		result.Error = err.Error()
+		if health.IsFailoverError(err) {
+			health.DefaultRegistry().RecordFailure(model.ID, err)
+		}
+		return result
+	}
+	result.Healthy = true
+	health.DefaultRegistry().RecordSuccess(model.ID)
+	return result
+}
+
+// probeEmbedding embeds a short text and checks the vector length against the configured dimension
+func (s *modelService) probeEmbedding(ctx context.Context, model *types.Model, result *types.ModelProbeResult) error {
+	embedder, err := s.newEmbedder(model)
+	if err != nil {
+		return err
+	}
+	vector, err := embedder.Embed(ctx, "health check")
+	if err != nil {
+		return err
+	}
+	result.DetectedDimension = len(vector)
+	if configured := model.Parameters.EmbeddingParameters.Dimension; configured > 0 && configured != len(vector) {
+		return fmt.Errorf("the model returned %d-dimension vectors, %d are configured", len(vector), configured)
+	}
+	return nil
+}
+
+// probeChat asks for a few tokens of completion
+func (s *modelService) probeChat(ctx context.Context, model *types.Model) error {
+	chatModel, err := s.newChatClient(model)
+	if err != nil {
+		return err
+	}
+	_, err = chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: "ping"}},
+		&chat.ChatOptions{MaxTokens: modelProbeMaxTokens})
+	return err
+}
+
+// probeRerank ranks a single document
+func probeRerank(ctx context.Context, model *types.Model) error {
+	reranker, err := newReranker(model)
+	if err != nil {
+		return err
+	}
+	_, err = reranker.Rerank(ctx, "health check", []string{"health check"})
+	return err
+}
+
+// checkDimensionChange rejects changing the dimension of an embedding model while knowledge bases
+// of the tenant hold vectors it produced
+func (s *modelService) checkDimensionChange(ctx context.Context, tenantID uint64, existing, updated *types.Model) error {
+	if existing.Type != types.ModelTypeEmbedding {
+		return nil
+	}
+	oldDimension := existing.Parameters.EmbeddingParameters.Dimension
+	if oldDimension <= 0 || oldDimension == updated.Parameters.EmbeddingParameters.Dimension {
+		return nil
+	}
+	kbs, err := s.kbRepo.ListKnowledgeBasesByTenantID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	for _, kb := range kbs {
+		if kb.EmbeddingModelID != existing.ID {
+			continue
+		}
+		hasVectors, err := kbHasVectors(ctx, s.chunkRepo, kb)
+		if err != nil {
+			return err
+		}
+		if hasVectors {
+			return fmt.Errorf("%w: knowledge base %q holds %d-dimension vectors of this model, "+
+				"re-index it with a new model instead", ErrEmbeddingDimensionMismatch, kb.Name, oldDimension)
+		}
+	}
+	return nil
+}
+
+// kbHasVectors reports whether a knowledge base holds chunks, whose vectors fix its embedding dimension
+func kbHasVectors(ctx context.Context, chunkRepo interfaces.ChunkRepository, kb *types.KnowledgeBase) (bool, error) {
+	count, err := chunkRepo.CountChunksByKnowledgeBaseID(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
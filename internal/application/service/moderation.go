@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+const (
+	// moderationContentLimit caps the text kept with a moderated event, in runes
+	moderationContentLimit = 5000
+	// answerModerationHoldLimit is the most answer text held back while waiting for a sentence end, in runes
+	answerModerationHoldLimit = 500
+)
+
+// ErrModerationEventNotFlagged is returned when reviewing a blocked or masked event, which need no review
+var ErrModerationEventNotFlagged = errors.New("only flagged moderation events can be reviewed")
+
+// defaultModerationCategories are looked for by the moderation model when the tenant lists none
+var defaultModerationCategories = []string{
+	"违法犯罪", "色情低俗", "暴力恐怖", "仇恨歧视", "自残自杀", "个人隐私泄露",
+}
+
+// moderationModelPrompt asks the moderation model for a verdict on a text
+const moderationModelPrompt = `你是一个内容安全审核员。请判断用户提供的文本是否包含以下类别的不当内容：%s。
+只输出一个 JSON 对象，不要输出任何解释：
+{"flagged": true 或 false, "categories": ["命中的类别"]}`
+
+// moderationModelOutput is the JSON object returned by the moderation model
+type moderationModelOutput struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories"`
+}
+
+// moderationService implements interfaces.ModerationService
+type moderationService struct {
+	repo         interfaces.ModerationRepository
+	modelService interfaces.ModelService
+}
+
+// NewModerationService creates a new moderation service
+func NewModerationService(
+	repo interfaces.ModerationRepository,
+	modelService interfaces.ModelService,
+) interfaces.ModerationService {
+	return &moderationService{repo: repo, modelService: modelService}
+}
+
+// moderationConfig returns the moderation configuration of the tenant of ctx
+func moderationConfig(ctx context.Context) *types.ModerationConfig {
+	tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if !ok || tenant == nil {
+		return nil
+	}
+	return tenant.ModerationConfig
+}
+
+// ModerateQuery checks a question against the rules and moderation model of the tenant.
+// A failed model call lets the question through.
+func (s *moderationService) ModerateQuery(ctx context.Context, sessionID, query string) (string, error) {
+	cfg := moderationConfig(ctx)
+	if !cfg.IsActiveFor(types.ModerationStageQuery) {
+		return query, nil
+	}
+	matcher, err := types.NewModerationMatcher(cfg, types.ModerationStageQuery)
+	if err != nil {
+		logger.Warnf(ctx, "Invalid moderation rules, skipping rule checks: %v", err)
+		matcher = &types.ModerationMatcher{}
+	}
+	result := matcher.Check(query)
+	if result.Action != types.ModerationBlock && cfg.Model.AppliesTo(types.ModerationStageQuery) {
+		if match := s.classify(ctx, cfg.Model, query); match != nil {
+			result.Add(*match)
+		}
+	}
+	if len(result.Matches) == 0 {
+		return query, nil
+	}
+
+	s.recordEvent(ctx, &types.ModerationEvent{
+		SessionID: sessionID,
+		Stage:     types.ModerationStageQuery,
+		Action:    result.Action,
+		Matches:   result.Matches,
+		Content:   query,
+	})
+	logger.Infof(ctx, "Question of session %s moderated: action=%s, matches=%d",
+		sessionID, result.Action, len(result.Matches))
+	if result.Action == types.ModerationBlock {
+		return "", apperrors.NewContentBlockedError(cfg.GetBlockMessage())
+	}
+	return result.Text, nil
+}
+
+// GuardAnswerStream moderates the answer chunks emitted on the event bus. Chunks are held back until
+// a sentence ends so that matches are not split across chunks; after a block match the rest of the
+// answer is replaced with the block message. The moderation model classifies the complete answer
+// and can only flag it, since the answer has already been streamed.
+func (s *moderationService) GuardAnswerStream(ctx context.Context,
+	sessionID, messageID string, eventBus *event.EventBus,
+) {
+	cfg := moderationConfig(ctx)
+	if !cfg.IsActiveFor(types.ModerationStageAnswer) {
+		return
+	}
+	matcher, err := types.NewModerationMatcher(cfg, types.ModerationStageAnswer)
+	if err != nil {
+		logger.Warnf(ctx, "Invalid moderation rules, skipping rule checks: %v", err)
+		matcher = &types.ModerationMatcher{}
+	}
+	moderator := &answerModerator{matcher: matcher, blockMessage: cfg.GetBlockMessage()}
+	bgCtx := context.WithoutCancel(ctx)
+
+	eventBus.Intercept(event.EventAgentFinalAnswer, func(_ context.Context, evt event.Event) (event.Event, bool) {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok {
+			return evt, true
+		}
+		content, finished := moderator.process(data.Content, data.Done)
+		if content == "" && !data.Done {
+			return evt, false
+		}
+		evt.Data = event.AgentFinalAnswerData{Content: content, Done: data.Done}
+		if finished {
+			result, answer := moderator.outcome()
+			go s.finishAnswer(bgCtx, cfg, sessionID, messageID, answer, result)
+		}
+		return evt, true
+	})
+}
+
+// finishAnswer classifies a complete answer with the moderation model and records the moderated event
+func (s *moderationService) finishAnswer(ctx context.Context, cfg *types.ModerationConfig,
+	sessionID, messageID, answer string, result *types.ModerationResult,
+) {
+	if cfg.Model.AppliesTo(types.ModerationStageAnswer) && strings.TrimSpace(answer) != "" {
+		if match := s.classify(ctx, cfg.Model, types.StripThinking(answer)); match != nil {
+			match.Action = types.ModerationFlag
+			result.Add(*match)
+		}
+	}
+	if len(result.Matches) == 0 {
+		return
+	}
+	s.recordEvent(ctx, &types.ModerationEvent{
+		SessionID: sessionID,
+		MessageID: messageID,
+		Stage:     types.ModerationStageAnswer,
+		Action:    result.Action,
+		Matches:   result.Matches,
+		Content:   answer,
+	})
+	logger.Infof(ctx, "Answer %s moderated: action=%s, matches=%d", messageID, result.Action, len(result.Matches))
+}
+
+// classify asks the moderation model whether a text is inappropriate, returning nil when it is not
+// or when the model fails
+func (s *moderationService) classify(ctx context.Context,
+	modelCfg *types.ModerationModelConfig, text string,
+) *types.ModerationMatch {
+	chatModel, err := s.modelService.GetChatModel(ctx, modelCfg.ModelID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get moderation model %s: %v", modelCfg.ModelID, err)
+		return nil
+	}
+	categories := modelCfg.Categories
+	if len(categories) == 0 {
+		categories = defaultModerationCategories
+	}
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: fmt.Sprintf(moderationModelPrompt, strings.Join(categories, "、"))},
+		{Role: "user", Content: text},
+	}, &chat.ChatOptions{Temperature: 0, MaxCompletionTokens: 100, Thinking: &thinking})
+	if err != nil {
+		logger.Warnf(ctx, "Moderation model call failed: %v", err)
+		return nil
+	}
+
+	content := types.StripThinking(response.Content)
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		logger.Warnf(ctx, "Unexpected moderation model output: %s", response.Content)
+		return nil
+	}
+	var output moderationModelOutput
+	if err := json.Unmarshal([]byte(content[start:end+1]), &output); err != nil {
+		logger.Warnf(ctx, "Failed to parse moderation model output: %v", err)
+		return nil
+	}
+	if !output.Flagged {
+		return nil
+	}
+	return &types.ModerationMatch{
+		Rule:   "model",
+		Source: types.ModerationSourceModel,
+		Action: modelCfg.Action,
+		Text:   strings.Join(output.Categories, ","),
+	}
+}
+
+// recordEvent writes a moderated event to the audit trail, failures are only logged
+func (s *moderationService) recordEvent(ctx context.Context, moderationEvent *types.ModerationEvent) {
+	moderationEvent.ID = uuid.New().String()
+	moderationEvent.TenantID, _ = ctx.Value(types.TenantIDContextKey).(uint64)
+	moderationEvent.UserID = types.ActorIDFromContext(ctx)
+	if content := []rune(moderationEvent.Content); len(content) > moderationContentLimit {
+		moderationEvent.Content = string(content[:moderationContentLimit])
+	}
+	moderationEvent.ReviewStatus = types.ModerationReviewNone
+	if moderationEvent.Action == types.ModerationFlag {
+		moderationEvent.ReviewStatus = types.ModerationReviewPending
+	}
+	if err := s.repo.Create(ctx, moderationEvent); err != nil {
+		logger.Warnf(ctx, "Failed to record moderation event of session %s: %v", moderationEvent.SessionID, err)
+	}
+}
+
+// ListEvents lists the moderated events of the tenant, newest first
+func (s *moderationService) ListEvents(ctx context.Context,
+	filter *types.ModerationEventFilter,
+) (*types.PageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	events, total, err := s.repo.List(ctx, tenantID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, &filter.Pagination, events), nil
+}
+
+// ReviewEvent records the review of a flagged event, setting it back to pending clears the review
+func (s *moderationService) ReviewEvent(ctx context.Context,
+	id string, req *types.ReviewModerationEventRequest,
+) (*types.ModerationEvent, error) {
+	switch req.Status {
+	case types.ModerationReviewPending, types.ModerationReviewApproved, types.ModerationReviewRejected:
+	default:
+		return nil, fmt.Errorf("invalid review status: %s", req.Status)
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	moderationEvent, err := s.repo.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if moderationEvent.Action != types.ModerationFlag {
+		return nil, ErrModerationEventNotFlagged
+	}
+
+	moderationEvent.ReviewStatus = req.Status
+	moderationEvent.ReviewNote = req.Note
+	if req.Status == types.ModerationReviewPending {
+		moderationEvent.ReviewedBy = ""
+		moderationEvent.ReviewedAt = nil
+	} else {
+		now := time.Now()
+		moderationEvent.ReviewedBy, _ = ctx.Value(types.UserIDContextKey).(string)
+		moderationEvent.ReviewedAt = &now
+	}
+	if err := s.repo.Update(ctx, moderationEvent); err != nil {
+		return nil, err
+	}
+	return moderationEvent, nil
+}
+
+// answerModerator holds back streamed answer text until a sentence ends and checks each sentence
+type answerModerator struct {
+	mu           sync.Mutex
+	matcher      *types.ModerationMatcher
+	blockMessage string
+	pending      []rune
+	answer       strings.Builder
+	emitted      bool
+	blocked      bool
+	finished     bool
+	result       types.ModerationResult
+}
+
+// process takes the next answer chunk and returns the text to emit, and whether the answer just finished
+func (m *answerModerator) process(content string, done bool) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.finished {
+		return content, false
+	}
+	m.answer.WriteString(content)
+	if m.blocked {
+		m.finished = done
+		return "", done
+	}
+	m.pending = append(m.pending, []rune(content)...)
+
+	cut := len(m.pending)
+	if !done {
+		cut = lastSentenceEnd(m.pending)
+		if cut == 0 && len(m.pending) >= answerModerationHoldLimit {
+			cut = len(m.pending)
+		}
+	}
+	segment := string(m.pending[:cut])
+	m.pending = m.pending[cut:]
+	m.finished = done
+
+	var out string
+	if segment != "" {
+		checked := m.matcher.Check(segment)
+		for _, match := range checked.Matches {
+			m.result.Add(match)
+		}
+		if checked.Action == types.ModerationBlock {
+			m.blocked = true
+			m.pending = nil
+			out = m.blockMessage
+			if m.emitted {
+				out = "\n\n" + m.blockMessage
+			}
+		} else {
+			out = checked.Text
+		}
+	}
+	if out != "" {
+		m.emitted = true
+	}
+	return out, done
+}
+
+// outcome returns the matches and the original text of the answer
+func (m *answerModerator) outcome() (*types.ModerationResult, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := m.result
+	return &result, m.answer.String()
+}
+
+// lastSentenceEnd returns the index just after the last sentence-ending rune, 0 when there is none
+func lastSentenceEnd(text []rune) int {
+	for i := len(text) - 1; i >= 0; i-- {
+		switch text[i] {
+		case '。', '！', '？', '；', '.', '!', '?', ';', '\n':
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestAnswerModeratorHoldsBackUntilSentenceEnd(t *testing.T) {
+	matcher, err := types.NewModerationMatcher(&types.ModerationConfig{
+		Enabled: true,
+		Rules: []types.ModerationRule{
+			{Name: "code", Type: types.ModerationRuleKeyword, Patterns: []string{"secret"}, Action: types.ModerationMask},
+			{Name: "ban", Type: types.ModerationRuleKeyword, Patterns: []string{"forbidden"}, Action: types.ModerationBlock},
+		},
+	}, types.ModerationStageAnswer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &answerModerator{matcher: matcher, blockMessage: "blocked"}
+
+	steps := []struct {
+		chunk string
+		done  bool
+		want  string
+	}{
+		{"The sec", false, ""},
+		{"ret is here. Next", false, "The ****** is here."},
+		{" part", false, ""},
+		{" is forbidden. More", false, "\n\nblocked"},
+		{" text.", false, ""},
+		{"", true, ""},
+	}
+	for i, step := range steps {
+		got, finished := m.process(step.chunk, step.done)
+		if got != step.want || finished != step.done {
+			t.Errorf("step %d: got %q, %v, want %q, %v", i, got, finished, step.want, step.done)
+		}
+	}
+	result, answer := m.outcome()
+	if result.Action != types.ModerationBlock || len(result.Matches) != 2 {
+		t.Errorf("got result %+v", result)
+	}
+	if answer != "The secret is here. Next part is forbidden. More text." {
+		t.Errorf("got answer %q", answer)
+	}
+}
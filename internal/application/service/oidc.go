@@ -0,0 +1,636 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+const (
+	// oidcStateTokenType marks the signed login state so it is never accepted as a login token
+	oidcStateTokenType = "oidc_state"
+	// OIDCStateTTL is how long a user has to complete the login at the provider
+	OIDCStateTTL = 10 * time.Minute
+	// OIDCAuthProviderPrefix prefixes the provider name stored on users signing in with OIDC
+	OIDCAuthProviderPrefix = "oidc:"
+
+	oidcDiscoveryTTL = time.Hour
+	// oidcJWKSRefreshInterval bounds how often an unknown key ID triggers a key set refetch
+	oidcJWKSRefreshInterval = time.Minute
+	oidcHTTPTimeout         = 15 * time.Second
+	maxOIDCResponseSize     = 1 << 20
+)
+
+// ErrOIDCProviderNotFound is returned for a provider name that is not configured
+var ErrOIDCProviderNotFound = errors.New("oidc provider not found")
+
+// oidcSigningMethods are the ID token algorithms accepted, symmetric ones are never trusted
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// oidcMetadata is the part of a provider's discovery document and key set used for logins
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	fetchedAt     time.Time
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// oidcService signs users in with OpenID Connect providers using the authorization code flow with PKCE
+type oidcService struct {
+	cfg           *config.Config
+	userService   interfaces.UserService
+	tenantService interfaces.TenantService
+	users         *externalUserProvisioner
+	client        *http.Client
+
+	mu       sync.Mutex
+	metadata map[string]*oidcMetadata
+}
+
+// NewOIDCService creates the single sign-on service for the providers in the auth config
+func NewOIDCService(
+	cfg *config.Config,
+	userRepo interfaces.UserRepository,
+	userService interfaces.UserService,
+	tenantService interfaces.TenantService,
+) interfaces.OIDCService {
+	return &oidcService{
+		cfg:           cfg,
+		userService:   userService,
+		tenantService: tenantService,
+		users:         &externalUserProvisioner{userRepo: userRepo, tenantService: tenantService},
+		client:        &http.Client{Timeout: oidcHTTPTimeout, Transport: utils.OutboundTransport()},
+		metadata:      make(map[string]*oidcMetadata),
+	}
+}
+
+// ListProviders lists the configured providers
+func (s *oidcService) ListProviders() []*types.OIDCProviderInfo {
+	if s.cfg.Auth == nil {
+		return []*types.OIDCProviderInfo{}
+	}
+	providers := make([]*types.OIDCProviderInfo, 0, len(s.cfg.Auth.OIDC))
+	for _, p := range s.cfg.Auth.OIDC {
+		displayName := p.DisplayName
+		if displayName == "" {
+			displayName = p.Name
+		}
+		providers = append(providers, &types.OIDCProviderInfo{Name: p.Name, DisplayName: displayName})
+	}
+	return providers
+}
+
+// BeginLogin returns the authorization URL of the provider and the signed state the callback must present
+func (s *oidcService) BeginLogin(ctx context.Context, provider string) (string, string, error) {
+	p, err := s.provider(provider)
+	if err != nil {
+		return "", "", err
+	}
+	metadata, err := s.getMetadata(ctx, p)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, nonce, verifier := randomURLString(24), randomURLString(24), randomURLString(32)
+	now := time.Now()
+	signedState, err := signJwt(jwt.MapClaims{
+		"type":          oidcStateTokenType,
+		"provider":      p.Name,
+		"state":         state,
+		"nonce":         nonce,
+		"code_verifier": verifier,
+		"exp":           now.Add(OIDCStateTTL).Unix(),
+		"iat":           now.Unix(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(oidcScopes(p.Scopes), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {pkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	authURL := metadata.AuthorizationEndpoint
+	if strings.Contains(authURL, "?") {
+		authURL += "&" + query.Encode()
+	} else {
+		authURL += "?" + query.Encode()
+	}
+	logger.Infof(ctx, "Starting OIDC login with provider %s", p.Name)
+	return authURL, signedState, nil
+}
+
+// CompleteLogin exchanges the authorization code, signs in or provisions the user and issues login tokens
+func (s *oidcService) CompleteLogin(ctx context.Context,
+	provider, code, state, signedState string,
+) (*types.LoginResponse, error) {
+	p, err := s.provider(provider)
+	if err != nil {
+		return nil, err
+	}
+	nonce, verifier, err := verifyOIDCState(signedState, p.Name, state)
+	if err != nil {
+		logger.Warnf(ctx, "Rejected OIDC callback for provider %s: %v", p.Name, err)
+		return nil, werrors.NewUnauthorizedError("Invalid or expired login state, please sign in again")
+	}
+	if code == "" {
+		return nil, werrors.NewBadRequestError("Authorization code is required")
+	}
+	metadata, err := s.getMetadata(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := s.exchangeCode(ctx, p, metadata, code, verifier)
+	if err != nil {
+		logger.Errorf(ctx, "OIDC code exchange with provider %s failed: %v", p.Name, err)
+		return nil, werrors.NewUnauthorizedError("Sign-in with the identity provider failed").WithDetails(err.Error())
+	}
+	claims, err := s.verifyIDToken(ctx, p, metadata, idToken, nonce)
+	if err != nil {
+		logger.Warnf(ctx, "Rejected ID token from provider %s: %v", p.Name, err)
+		return nil, werrors.NewUnauthorizedError("Invalid ID token").WithDetails(err.Error())
+	}
+
+	user, err := s.provisionUser(ctx, p, claims)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, refreshToken, err := s.userService.GenerateTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := s.tenantService.GetTenantByID(ctx, user.TenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get tenant %d of OIDC user: %v", user.TenantID, err)
+	}
+	logger.Infof(ctx, "User %s signed in with OIDC provider %s", user.ID, p.Name)
+	return &types.LoginResponse{
+		Success:      true,
+		Message:      i18n.T(ctx, i18n.MsgLoginSucceeded),
+		User:         user,
+		Tenant:       tenant,
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// provider returns the config of a provider by name
+func (s *oidcService) provider(name string) (*config.OIDCProviderConfig, error) {
+	if s.cfg.Auth != nil {
+		for i := range s.cfg.Auth.OIDC {
+			if s.cfg.Auth.OIDC[i].Name == name {
+				return &s.cfg.Auth.OIDC[i], nil
+			}
+		}
+	}
+	return nil, ErrOIDCProviderNotFound
+}
+
+// getMetadata returns the discovery document of a provider, fetching it when missing or stale
+func (s *oidcService) getMetadata(ctx context.Context, p *config.OIDCProviderConfig) (*oidcMetadata, error) {
+	s.mu.Lock()
+	cached := s.metadata[p.Name]
+	s.mu.Unlock()
+	if cached != nil && time.Since(cached.fetchedAt) < oidcDiscoveryTTL {
+		return cached, nil
+	}
+
+	issuer := strings.TrimSuffix(p.Issuer, "/")
+	metadata := &oidcMetadata{}
+	if err := s.getJSON(ctx, issuer+"/.well-known/openid-configuration", metadata); err != nil {
+		if cached != nil {
+			logger.Warnf(ctx, "Failed to refresh OIDC discovery of provider %s, using cached: %v", p.Name, err)
+			return cached, nil
+		}
+		return nil, fmt.Errorf("discover oidc provider %s: %w", p.Name, err)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc provider %s reports issuer %q, expected %q", p.Name, metadata.Issuer, p.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc provider %s discovery document is incomplete", p.Name)
+	}
+	metadata.fetchedAt = time.Now()
+	if cached != nil && cached.JWKSURI == metadata.JWKSURI {
+		metadata.keys, metadata.keysFetchedAt = cached.keys, cached.keysFetchedAt
+	}
+
+	s.mu.Lock()
+	s.metadata[p.Name] = metadata
+	s.mu.Unlock()
+	return metadata, nil
+}
+
+// exchangeCode redeems an authorization code at the token endpoint and returns the ID token
+func (s *oidcService) exchangeCode(ctx context.Context,
+	p *config.OIDCProviderConfig, metadata *oidcMetadata, code, verifier string,
+) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.ClientSecret != "" {
+		form.Set("client_secret", p.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOIDCResponseSize))
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("token endpoint returned HTTP %d: %w", resp.StatusCode, err)
+	}
+	if result.Error != "" {
+		return "", fmt.Errorf("token endpoint returned %s: %s", result.Error, result.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
+	}
+	if result.IDToken == "" {
+		return "", errors.New("token endpoint returned no id_token, is the openid scope granted?")
+	}
+	return result.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token and returns its claims
+func (s *oidcService) verifyIDToken(ctx context.Context,
+	p *config.OIDCProviderConfig, metadata *oidcMetadata, idToken, nonce string,
+) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.signingKey(ctx, metadata, kid)
+	},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if tokenNonce, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, errors.New("nonce mismatch")
+	}
+	if subject, _ := claims["sub"].(string); subject == "" {
+		return nil, errors.New("missing sub claim")
+	}
+	return claims, nil
+}
+
+// signingKey returns the provider key with the given ID, refetching the key set once when it is unknown
+func (s *oidcService) signingKey(ctx context.Context, metadata *oidcMetadata, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	keys, fetchedAt := metadata.keys, metadata.keysFetchedAt
+	s.mu.Unlock()
+	if key := lookupJWK(keys, kid); key != nil {
+		return key, nil
+	}
+	if keys != nil && time.Since(fetchedAt) < oidcJWKSRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := s.getJSON(ctx, metadata.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch signing keys: %w", err)
+	}
+	keys = make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, raw := range set.Keys {
+		id, key, err := parseJWK(raw)
+		if err != nil {
+			// Keys of unsupported types or uses are skipped, others may still verify the token
+			continue
+		}
+		keys[id] = key
+	}
+	s.mu.Lock()
+	metadata.keys, metadata.keysFetchedAt = keys, time.Now()
+	s.mu.Unlock()
+
+	if key := lookupJWK(keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// provisionUser finds the user of an ID token, linking or creating the account as the provider allows
+func (s *oidcService) provisionUser(ctx context.Context,
+	p *config.OIDCProviderConfig, claims jwt.MapClaims,
+) (*types.User, error) {
+	email := strings.TrimSpace(claimString(claims, defaultString(p.EmailClaim, "email")))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, werrors.NewForbiddenError("The identity provider did not return an email address")
+	}
+	if verified, ok := claims["email_verified"]; ok && verified != true && verified != "true" {
+		return nil, werrors.NewForbiddenError("The email address is not verified by the identity provider")
+	}
+	if !emailDomainAllowed(email, p.AllowedDomains) {
+		return nil, werrors.NewForbiddenError("The email domain is not allowed to sign in")
+	}
+
+	identity := &externalIdentity{
+		AuthProvider:  OIDCAuthProviderPrefix + p.Name,
+		ExternalID:    claimString(claims, "sub"),
+		Email:         email,
+		Username:      claimString(claims, defaultString(p.UsernameClaim, "preferred_username")),
+		TenantID:      resolveOIDCTenant(p, claims),
+		LinkExisting:  p.LinkExistingUsers && oidcEmailVerified(claims),
+		AutoProvision: p.AutoProvision,
+	}
+	if picture := claimString(claims, "picture"); strings.HasPrefix(picture, "https://") && len(picture) <= 500 {
+		identity.Avatar = picture
+	}
+	user, err := s.users.findOrCreate(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, werrors.NewForbiddenError("Account is disabled")
+	}
+	return user, nil
+}
+
+// oidcEmailVerified reports whether the provider asserted the email is verified. Only a boolean true counts, an
+// existing account is taken over by its email address only then.
+func oidcEmailVerified(claims jwt.MapClaims) bool {
+	verified, ok := claims["email_verified"].(bool)
+	return ok && verified
+}
+
+// getJSON fetches a JSON document from a provider
+func (s *oidcService) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponseSize)).Decode(out)
+}
+
+// verifyOIDCState checks the signed state of a login against the callback and returns its nonce and code verifier
+func verifyOIDCState(signedState, provider, state string) (nonce, verifier string, err error) {
+	if signedState == "" {
+		return "", "", errors.New("missing login state")
+	}
+	parsed, err := jwt.Parse(signedState, jwtKeyFunc)
+	if err != nil || !parsed.Valid {
+		return "", "", fmt.Errorf("invalid login state: %v", err)
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["type"] != oidcStateTokenType || claims["provider"] != provider {
+		return "", "", errors.New("login state belongs to another provider")
+	}
+	expected, _ := claims["state"].(string)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(state)) != 1 {
+		return "", "", errors.New("state mismatch")
+	}
+	nonce, _ = claims["nonce"].(string)
+	verifier, _ = claims["code_verifier"].(string)
+	if nonce == "" || verifier == "" {
+		return "", "", errors.New("incomplete login state")
+	}
+	return nonce, verifier, nil
+}
+
+// pkceChallenge derives the S256 code challenge of a code verifier (RFC 7636)
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomURLString returns n random bytes encoded as URL-safe base64
+func randomURLString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate random string: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// oidcScopes returns the scopes to request, always including openid
+func oidcScopes(scopes []string) []string {
+	if len(scopes) == 0 {
+		return []string{"openid", "profile", "email"}
+	}
+	if slices.Contains(scopes, "openid") {
+		return scopes
+	}
+	return append([]string{"openid"}, scopes...)
+}
+
+// resolveOIDCTenant returns the tenant of the first mapping matching the tenant claim, or the default tenant
+func resolveOIDCTenant(p *config.OIDCProviderConfig, claims jwt.MapClaims) uint64 {
+	if p.TenantClaim != "" {
+		values := claimStrings(claims, p.TenantClaim)
+		for _, mapping := range p.TenantMapping {
+			if slices.Contains(values, mapping.Value) {
+				return mapping.TenantID
+			}
+		}
+	}
+	return p.DefaultTenantID
+}
+
+// emailDomainAllowed reports whether the domain of an email is in the allowed list, an empty list allows all
+func emailDomainAllowed(email string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	for _, d := range allowed {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(d), "@"), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupClaim returns a claim by name, falling back to a dotted path into nested claims such as realm_access.roles
+func lookupClaim(claims map[string]interface{}, name string) interface{} {
+	if value, ok := claims[name]; ok {
+		return value
+	}
+	head, rest, found := strings.Cut(name, ".")
+	if !found {
+		return nil
+	}
+	nested, ok := claims[head].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return lookupClaim(nested, rest)
+}
+
+// claimString returns a string claim, empty when missing or of another type
+func claimString(claims jwt.MapClaims, name string) string {
+	value, _ := lookupClaim(claims, name).(string)
+	return value
+}
+
+// claimStrings returns a string or string list claim as a list
+func claimStrings(claims jwt.MapClaims, name string) []string {
+	switch value := lookupClaim(claims, name).(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// defaultString returns value, or fallback when value is empty
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// lookupJWK returns the key with the given ID, or the only key when the token names none
+func lookupJWK(keys map[string]crypto.PublicKey, kid string) crypto.PublicKey {
+	if key, ok := keys[kid]; ok {
+		return key
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// parseJWK parses an RSA or EC signing key of a JSON web key set
+func parseJWK(raw json.RawMessage) (string, crypto.PublicKey, error) {
+	var jwk struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &jwk); err != nil {
+		return "", nil, err
+	}
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return "", nil, fmt.Errorf("key %s is not a signing key", jwk.Kid)
+	}
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return "", nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return "", nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return "", nil, fmt.Errorf("key %s has an invalid exponent", jwk.Kid)
+		}
+		return jwk.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return "", nil, fmt.Errorf("key %s uses unsupported curve %s", jwk.Kid, jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return "", nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return "", nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return "", nil, fmt.Errorf("key %s is not on curve %s", jwk.Kid, jwk.Crv)
+		}
+		return jwk.Kid, key, nil
+	}
+	return "", nil, fmt.Errorf("key %s has unsupported type %s", jwk.Kid, jwk.Kty)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Tencent/WeKnora/internal/config"
+)
+
+func TestPKCEChallenge(t *testing.T) {
+	// S256 is the unpadded base64url SHA-256 of the verifier
+	got := pkceChallenge("weknora-pkce-code-verifier-0123456789abcdefghij")
+	if want := "t89Anvx8VWu6tSJLuycVpE2Wlj22njdZUB4tjBn5cdI"; got != want {
+		t.Errorf("pkceChallenge = %q, want %q", got, want)
+	}
+}
+
+func TestResolveOIDCTenant(t *testing.T) {
+	p := &config.OIDCProviderConfig{
+		TenantClaim: "realm_access.roles",
+		TenantMapping: []config.OIDCTenantMapping{
+			{Value: "engineering", TenantID: 10},
+			{Value: "sales", TenantID: 20},
+		},
+		DefaultTenantID: 5,
+	}
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   uint64
+	}{
+		{"nested list", jwt.MapClaims{"realm_access": map[string]interface{}{
+			"roles": []interface{}{"sales", "engineering"},
+		}}, 10},
+		{"no match", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []interface{}{"hr"}}}, 5},
+		{"missing claim", jwt.MapClaims{}, 5},
+	}
+	for _, tt := range tests {
+		if got := resolveOIDCTenant(p, tt.claims); got != tt.want {
+			t.Errorf("%s: resolveOIDCTenant = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	p.TenantClaim = "tid"
+	if got := resolveOIDCTenant(p, jwt.MapClaims{"tid": "sales"}); got != 20 {
+		t.Errorf("string claim: resolveOIDCTenant = %d, want 20", got)
+	}
+}
+
+func TestVerifyOIDCState(t *testing.T) {
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"type": oidcStateTokenType, "provider": "keycloak", "state": "abc",
+		"nonce": "n", "code_verifier": "v", "exp": 4102444800,
+	}).SignedString([]byte(getJwtSecret()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, verifier, err := verifyOIDCState(signed, "keycloak", "abc")
+	if err != nil || nonce != "n" || verifier != "v" {
+		t.Errorf("verifyOIDCState = %q, %q, %v", nonce, verifier, err)
+	}
+	if _, _, err := verifyOIDCState(signed, "keycloak", "other"); err == nil {
+		t.Error("expected state mismatch to be rejected")
+	}
+	if _, _, err := verifyOIDCState(signed, "google", "abc"); err == nil {
+		t.Error("expected state of another provider to be rejected")
+	}
+}
+
+func TestEmailDomainAllowed(t *testing.T) {
+	if !emailDomainAllowed("a@example.com", nil) {
+		t.Error("empty list should allow all domains")
+	}
+	if !emailDomainAllowed("a@Example.com", []string{"@example.com"}) {
+		t.Error("domain match should ignore case and a leading @")
+	}
+	if emailDomainAllowed("a@evil.com", []string{"example.com"}) {
+		t.Error("unlisted domain should be rejected")
+	}
+}
+
+func TestOIDCEmailVerified(t *testing.T) {
+	tests := []struct {
+		claims jwt.MapClaims
+		want   bool
+	}{
+		{jwt.MapClaims{"email_verified": true}, true},
+		{jwt.MapClaims{"email_verified": "true"}, false},
+		{jwt.MapClaims{"email_verified": false}, false},
+		{jwt.MapClaims{}, false},
+	}
+	for _, tt := range tests {
+		if got := oidcEmailVerified(tt.claims); got != tt.want {
+			t.Errorf("oidcEmailVerified(%v) = %v, want %v", tt.claims, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// ErrPromptTemplateNotDraft is returned when editing or deleting a version that was published
+var ErrPromptTemplateNotDraft = errors.New("only draft prompt templates can be changed")
+
+// promptPreviewEvents are the pipeline stages run for each sample question, the rag_stream
+// retrieval stages followed by non-streaming answer generation
+var promptPreviewEvents = []types.EventType{
+	types.REWRITE_QUERY,
+	types.CHUNK_SEARCH,
+	types.CHUNK_RERANK,
+	types.CHUNK_MERGE,
+	types.FILTER_TOP_K,
+	types.INTO_CHAT_MESSAGE,
+	types.CHAT_COMPLETION,
+}
+
+// promptTemplateService implements interfaces.PromptTemplateService
+type promptTemplateService struct {
+	cfg                  *config.Config
+	repo                 interfaces.PromptTemplateRepository
+	knowledgeBaseService interfaces.KnowledgeBaseService
+	sessionService       interfaces.SessionService
+	modelService         interfaces.ModelService
+}
+
+// NewPromptTemplateService creates a new prompt template service
+func NewPromptTemplateService(
+	cfg *config.Config,
+	repo interfaces.PromptTemplateRepository,
+	knowledgeBaseService interfaces.KnowledgeBaseService,
+	sessionService interfaces.SessionService,
+	modelService interfaces.ModelService,
+) interfaces.PromptTemplateService {
+	return &promptTemplateService{
+		cfg:                  cfg,
+		repo:                 repo,
+		knowledgeBaseService: knowledgeBaseService,
+		sessionService:       sessionService,
+		modelService:         modelService,
+	}
+}
+
+// ListTemplates lists the template versions of a knowledge base, newest first
+func (s *promptTemplateService) ListTemplates(ctx context.Context,
+	knowledgeBaseID string,
+) ([]*types.KBPromptTemplate, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	return s.repo.List(ctx, tenantID, knowledgeBaseID)
+}
+
+// GetTemplate returns a template version of a knowledge base
+func (s *promptTemplateService) GetTemplate(ctx context.Context,
+	knowledgeBaseID, id string,
+) (*types.KBPromptTemplate, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	template, err := s.repo.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if template.KnowledgeBaseID != knowledgeBaseID {
+		return nil, repository.ErrPromptTemplateNotFound
+	}
+	return template, nil
+}
+
+// CreateTemplate creates a draft as the next version of the knowledge base
+func (s *promptTemplateService) CreateTemplate(ctx context.Context,
+	knowledgeBaseID string, req *types.PromptTemplateRequest,
+) (*types.KBPromptTemplate, error) {
+	template := &types.KBPromptTemplate{
+		ID:              uuid.New().String(),
+		TenantID:        ctx.Value(types.TenantIDContextKey).(uint64),
+		KnowledgeBaseID: knowledgeBaseID,
+		Status:          types.PromptTemplateDraft,
+	}
+	template.CreatedBy, _ = ctx.Value(types.UserIDContextKey).(string)
+	setPromptTemplate(template, req)
+	if err := template.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError("Invalid prompt template").WithDetails(err.Error())
+	}
+	if err := s.repo.Create(ctx, template); err != nil {
+		logger.Errorf(ctx, "Failed to create prompt template: %v", err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Prompt template version %d drafted, ID: %s, knowledge base ID: %s",
+		template.Version, template.ID, knowledgeBaseID)
+	return template, nil
+}
+
+// UpdateTemplate edits a draft, published and archived versions are immutable so that
+// rolling back restores exactly what was answered with before
+func (s *promptTemplateService) UpdateTemplate(ctx context.Context,
+	knowledgeBaseID, id string, req *types.PromptTemplateRequest,
+) (*types.KBPromptTemplate, error) {
+	template, err := s.GetTemplate(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return nil, err
+	}
+	if template.Status != types.PromptTemplateDraft {
+		return nil, ErrPromptTemplateNotDraft
+	}
+	setPromptTemplate(template, req)
+	if err := template.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError("Invalid prompt template").WithDetails(err.Error())
+	}
+	if err := s.repo.Update(ctx, template); err != nil {
+		logger.Errorf(ctx, "Failed to update prompt template %s: %v", id, err)
+		return nil, err
+	}
+	return template, nil
+}
+
+// DeleteTemplate deletes a draft
+func (s *promptTemplateService) DeleteTemplate(ctx context.Context, knowledgeBaseID, id string) error {
+	template, err := s.GetTemplate(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return err
+	}
+	if template.Status != types.PromptTemplateDraft {
+		return ErrPromptTemplateNotDraft
+	}
+	return s.repo.Delete(ctx, template.TenantID, id)
+}
+
+// PublishTemplate applies a version to answers of the knowledge base, archiving the published one.
+// Publishing an archived version rolls back to it.
+func (s *promptTemplateService) PublishTemplate(ctx context.Context,
+	knowledgeBaseID, id string,
+) (*types.KBPromptTemplate, error) {
+	template, err := s.GetTemplate(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return nil, err
+	}
+	if template.Status == types.PromptTemplatePublished {
+		return template, nil
+	}
+	now := time.Now()
+	template.Status = types.PromptTemplatePublished
+	template.PublishedAt = &now
+	if err := s.repo.Publish(ctx, template); err != nil {
+		logger.Errorf(ctx, "Failed to publish prompt template %s: %v", id, err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Prompt template version %d published, knowledge base ID: %s",
+		template.Version, knowledgeBaseID)
+	return template, nil
+}
+
+// UnpublishTemplate archives the published version, answers fall back to the agent's prompt
+func (s *promptTemplateService) UnpublishTemplate(ctx context.Context, knowledgeBaseID string) error {
+	if err := s.repo.ArchivePublished(ctx, knowledgeBaseID); err != nil {
+		logger.Errorf(ctx, "Failed to unpublish prompt template of knowledge base %s: %v", knowledgeBaseID, err)
+		return err
+	}
+	logger.Infof(ctx, "Prompt template of knowledge base %s unpublished", knowledgeBaseID)
+	return nil
+}
+
+// PreviewTemplate answers sample questions with a template version without publishing it
+func (s *promptTemplateService) PreviewTemplate(ctx context.Context,
+	knowledgeBaseID, id string, req *types.PromptPreviewRequest,
+) ([]*types.PromptPreviewResult, error) {
+	var questions []string
+	for _, question := range req.Questions {
+		if question = strings.TrimSpace(question); question != "" {
+			questions = append(questions, question)
+		}
+	}
+	if len(questions) == 0 {
+		return nil, werrors.NewBadRequestError("at least one question is required")
+	}
+	if len(questions) > types.MaxPromptPreviewQuestions {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("at most %d questions can be previewed at a time", types.MaxPromptPreviewQuestions))
+	}
+
+	template, err := s.GetTemplate(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := s.knowledgeBaseService.GetKnowledgeBaseByID(ctx, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	rerankModelID, chatModelID, err := s.previewModels(ctx, kb, req)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.PromptPreviewResult, 0, len(questions))
+	for _, question := range questions {
+		results = append(results, s.previewQuestion(ctx, kb, template, question,
+			rerankModelID, chatModelID, req.Generate))
+	}
+	logger.Infof(ctx, "Previewed prompt template version %d with %d questions, knowledge base ID: %s",
+		template.Version, len(questions), knowledgeBaseID)
+	return results, nil
+}
+
+// previewModels resolves the rerank and chat models of a preview, the knowledge base's summary
+// model or else the first available models of the tenant
+func (s *promptTemplateService) previewModels(ctx context.Context,
+	kb *types.KnowledgeBase, req *types.PromptPreviewRequest,
+) (string, string, error) {
+	chatModelID := req.ChatModelID
+	if chatModelID == "" {
+		chatModelID = kb.SummaryModelID
+	}
+	models, err := s.modelService.ListModels(ctx)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get models: %v", err)
+		return "", "", err
+	}
+	var rerankModelID string
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		if model.Type == types.ModelTypeRerank && rerankModelID == "" {
+			rerankModelID = model.ID
+		}
+		if model.Type == types.ModelTypeKnowledgeQA && chatModelID == "" {
+			chatModelID = model.ID
+		}
+	}
+	if req.Generate && chatModelID == "" {
+		return "", "", werrors.NewBadRequestError("no chat model available to generate answers")
+	}
+	return rerankModelID, chatModelID, nil
+}
+
+// previewQuestion runs the pipeline for one sample question with the template applied
+func (s *promptTemplateService) previewQuestion(ctx context.Context, kb *types.KnowledgeBase,
+	template *types.KBPromptTemplate, question, rerankModelID, chatModelID string, generate bool,
+) *types.PromptPreviewResult {
+	result := &types.PromptPreviewResult{Question: question}
+	conversation := s.cfg.Current().Conversation
+	chatManage := &types.ChatManage{
+		Query:            question,
+		RewriteQuery:     question,
+		KnowledgeBaseIDs: []string{kb.ID},
+		SearchTargets: types.SearchTargets{&types.SearchTarget{
+			Type:            types.SearchTargetTypeKnowledgeBase,
+			KnowledgeBaseID: kb.ID,
+			TenantID:        kb.TenantID,
+		}},
+		VectorThreshold:  conversation.VectorThreshold,
+		KeywordThreshold: conversation.KeywordThreshold,
+		EmbeddingTopK:    conversation.EmbeddingTopK,
+		RerankModelID:    rerankModelID,
+		RerankTopK:       conversation.RerankTopK,
+		RerankThreshold:  conversation.RerankThreshold,
+		ChatModelID:      chatModelID,
+		TenantID:         kb.TenantID,
+		FallbackStrategy: types.FallbackStrategyFixed,
+		FallbackResponse: conversation.FallbackResponse,
+		SummaryConfig: types.SummaryConfig{
+			Prompt:              conversation.Summary.Prompt,
+			ContextTemplate:     conversation.Summary.ContextTemplate,
+			Temperature:         conversation.Summary.Temperature,
+			NoMatchPrefix:       conversation.Summary.NoMatchPrefix,
+			MaxCompletionTokens: conversation.Summary.MaxCompletionTokens,
+		},
+	}
+	template.Apply(chatManage)
+	result.SystemPrompt = chatManage.SummaryConfig.Prompt
+
+	events := promptPreviewEvents
+	if !generate {
+		// Stop once the prompt is rendered
+		events = promptPreviewEvents[:len(promptPreviewEvents)-1]
+	}
+	if err := s.sessionService.KnowledgeQAByEvent(ctx, chatManage, events); err != nil {
+		logger.Warnf(ctx, "Prompt template preview failed, question: %s, error: %v", question, err)
+		result.Error = err.Error()
+		return result
+	}
+
+	result.UserContent = chatManage.UserContent
+	result.References = chatManage.MergeResult
+	if chatManage.ChatResponse != nil {
+		result.Answer = chatManage.ChatResponse.Content
+	}
+	// Nothing relevant was retrieved, the pipeline replied with the fallback response
+	result.Fallback = len(chatManage.MergeResult) == 0 && chatManage.ChatResponse != nil
+	return result
+}
+
+// setPromptTemplate copies the editable fields of a request to a template
+func setPromptTemplate(template *types.KBPromptTemplate, req *types.PromptTemplateRequest) {
+	template.SystemPrompt = req.SystemPrompt
+	template.ContextTemplate = req.ContextTemplate
+	template.Language = strings.TrimSpace(req.Language)
+	template.Tone = strings.TrimSpace(req.Tone)
+	template.CitationStyle = req.CitationStyle
+	template.RefusalPolicy = req.RefusalPolicy
+	template.RefusalMessage = req.RefusalMessage
+	template.Note = req.Note
+}
+
+// applyPromptTemplate applies the published prompt template of the knowledge base a chat request
+// searches alone, requests across several knowledge bases keep the agent's prompt
+func (s *sessionService) applyPromptTemplate(ctx context.Context, chatManage *types.ChatManage) {
+	knowledgeBaseIDs := chatManage.SearchTargets.GetAllKnowledgeBaseIDs()
+	if len(knowledgeBaseIDs) != 1 {
+		return
+	}
+	template, err := s.promptTemplates.GetPublished(ctx, knowledgeBaseIDs[0])
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get prompt template of knowledge base %s: %v", knowledgeBaseIDs[0], err)
+		return
+	}
+	if template == nil {
+		return
+	}
+	template.Apply(chatManage)
+	logger.Infof(ctx, "Applied prompt template version %d of knowledge base %s",
+		template.Version, knowledgeBaseIDs[0])
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// activeParseStatuses are the statuses of documents holding a parse job
+var activeParseStatuses = []string{types.ParseStatusPending, types.ParseStatusProcessing}
+
+// quotaService implements interfaces.QuotaService
+type quotaService struct {
+	config        *config.Config
+	kbRepo        interfaces.KnowledgeBaseRepository
+	knowledgeRepo interfaces.KnowledgeRepository
+}
+
+// NewQuotaService creates a new quota service
+func NewQuotaService(
+	config *config.Config,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+) interfaces.QuotaService {
+	return &quotaService{config: config, kbRepo: kbRepo, knowledgeRepo: knowledgeRepo}
+}
+
+// quotaOf returns the tenant in ctx and its effective caps
+func (s *quotaService) quotaOf(ctx context.Context) (*types.Tenant, types.TenantQuota, bool) {
+	tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if !ok || tenant == nil {
+		return nil, types.TenantQuota{}, false
+	}
+	var defaults *types.TenantQuota
+	if s.config != nil {
+		if tenant := s.config.Current().Tenant; tenant != nil {
+			defaults = tenant.DefaultQuota
+		}
+	}
+	return tenant, tenant.Quota.Effective(defaults), true
+}
+
+// GetQuotaStatus returns the usage of the tenant in ctx against its quotas
+func (s *quotaService) GetQuotaStatus(ctx context.Context) (*types.TenantQuotaStatus, error) {
+	tenant, quota, ok := s.quotaOf(ctx)
+	if !ok {
+		return nil, apperrors.NewUnauthorizedError("Tenant not found")
+	}
+	kbCount, err := s.kbRepo.CountKnowledgeBasesByTenantID(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+	knowledgeCount, err := s.knowledgeRepo.CountKnowledgeByTenant(ctx, tenant.ID, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	parseJobs, err := s.knowledgeRepo.CountKnowledgeByTenant(ctx, tenant.ID, "", activeParseStatuses)
+	if err != nil {
+		return nil, err
+	}
+	browserSessions, err := s.knowledgeRepo.CountKnowledgeByTenant(ctx, tenant.ID,
+		types.KnowledgeTypeURL, activeParseStatuses)
+	if err != nil {
+		return nil, err
+	}
+	return &types.TenantQuotaStatus{
+		KnowledgeBases:  types.NewQuotaUsage(quota.MaxKnowledgeBases, kbCount),
+		Knowledge:       types.NewQuotaUsage(quota.MaxKnowledge, knowledgeCount),
+		ParseJobs:       types.NewQuotaUsage(quota.MaxConcurrentParseJobs, parseJobs),
+		BrowserSessions: types.NewQuotaUsage(quota.MaxBrowserSessions, browserSessions),
+		Storage:         types.NewQuotaUsage(max(tenant.StorageQuota, 0), tenant.StorageUsed),
+	}, nil
+}
+
+// CheckKnowledgeBaseQuota returns an error when the tenant cannot create another knowledge base
+func (s *quotaService) CheckKnowledgeBaseQuota(ctx context.Context) error {
+	tenant, quota, ok := s.quotaOf(ctx)
+	if !ok || quota.MaxKnowledgeBases == 0 {
+		return nil
+	}
+	count, err := s.kbRepo.CountKnowledgeBasesByTenantID(ctx, tenant.ID)
+	return s.check(ctx, tenant, err, types.NewQuotaUsage(quota.MaxKnowledgeBases, count),
+		apperrors.ErrKnowledgeBaseQuotaExceeded, "知识库数量已达上限")
+}
+
+// CheckKnowledgeQuota returns an error when the tenant cannot add another document, by count or storage
+func (s *quotaService) CheckKnowledgeQuota(ctx context.Context) error {
+	return s.CheckKnowledgeCountQuota(ctx, 1)
+}
+
+// CheckKnowledgeCountQuota returns an error when the tenant cannot add count more documents, by count or storage
+func (s *quotaService) CheckKnowledgeCountQuota(ctx context.Context, count int64) error {
+	tenant, quota, ok := s.quotaOf(ctx)
+	if !ok {
+		return nil
+	}
+	if usage := types.NewQuotaUsage(max(tenant.StorageQuota, 0), tenant.StorageUsed); usage.Exceeded {
+		return s.check(ctx, tenant, nil, usage, apperrors.ErrStorageQuotaExceeded, "存储空间已达上限")
+	}
+	if quota.MaxKnowledge == 0 {
+		return nil
+	}
+	used, err := s.knowledgeRepo.CountKnowledgeByTenant(ctx, tenant.ID, "", nil)
+	usage := types.NewQuotaUsage(quota.MaxKnowledge, used)
+	usage.Exceeded = used+count > quota.MaxKnowledge
+	return s.check(ctx, tenant, err, usage, apperrors.ErrKnowledgeQuotaExceeded, "文档数量已达上限")
+}
+
+// CheckParseJobQuota returns an error when the tenant cannot start parsing another document of the type
+func (s *quotaService) CheckParseJobQuota(ctx context.Context, knowledgeType string) error {
+	tenant, quota, ok := s.quotaOf(ctx)
+	if !ok {
+		return nil
+	}
+	if quota.MaxConcurrentParseJobs > 0 {
+		count, err := s.knowledgeRepo.CountKnowledgeByTenant(ctx, tenant.ID, "", activeParseStatuses)
+		if err := s.check(ctx, tenant, err, types.NewQuotaUsage(quota.MaxConcurrentParseJobs, count),
+			apperrors.ErrParseJobQuotaExceeded, "正在解析的文档数量已达上限，请稍后再试"); err != nil {
+			return err
+		}
+	}
+	if knowledgeType == types.KnowledgeTypeURL && quota.MaxBrowserSessions > 0 {
+		count, err := s.knowledgeRepo.CountKnowledgeByTenant(ctx, tenant.ID,
+			types.KnowledgeTypeURL, activeParseStatuses)
+		return s.check(ctx, tenant, err, types.NewQuotaUsage(quota.MaxBrowserSessions, count),
+			apperrors.ErrBrowserSessionQuotaExceeded, "正在抓取的网页数量已达上限，请稍后再试")
+	}
+	return nil
+}
+
+// check turns a reached quota into an error with the usage as details; a failed count lets the request through
+func (s *quotaService) check(ctx context.Context, tenant *types.Tenant, countErr error,
+	usage types.QuotaUsage, code apperrors.ErrorCode, message string,
+) error {
+	if countErr != nil {
+		logger.Warnf(ctx, "Failed to check quota %d of tenant %d: %v", code, tenant.ID, countErr)
+		return nil
+	}
+	if !usage.Exceeded {
+		return nil
+	}
+	logger.Warnf(ctx, "Tenant %d reached quota %d: %d/%d", tenant.ID, code, usage.Used, usage.Limit)
+	return apperrors.NewQuotaExceededError(code, message).WithDetails(usage)
+}
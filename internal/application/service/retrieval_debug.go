@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// retrievalDebugEvents are the pipeline stages run by a retrieval debug request,
+// the rag_stream pipeline up to the assembled context without answer generation
+var retrievalDebugEvents = []types.EventType{
+	types.REWRITE_QUERY,
+	types.CHUNK_SEARCH,
+	types.CHUNK_RERANK,
+	types.CHUNK_MERGE,
+	types.FILTER_TOP_K,
+	types.INTO_CHAT_MESSAGE,
+}
+
+// newHybridSearchTrace snapshots the vector and keyword hits of a HybridSearch call
+func newHybridSearchTrace(knowledgeBaseID, queryText string,
+	vectorResults, keywordResults []*types.IndexWithScore,
+) *types.HybridSearchTrace {
+	trace := &types.HybridSearchTrace{
+		KnowledgeBaseID: knowledgeBaseID,
+		QueryText:       queryText,
+		VectorHits:      make([]*types.RetrievalHit, 0, len(vectorResults)),
+		KeywordHits:     make([]*types.RetrievalHit, 0, len(keywordResults)),
+	}
+	for i, r := range vectorResults {
+		hit := newRetrievalHit(r)
+		hit.VectorRank = i + 1
+		trace.VectorHits = append(trace.VectorHits, hit)
+	}
+	for i, r := range keywordResults {
+		hit := newRetrievalHit(r)
+		hit.KeywordRank = i + 1
+		trace.KeywordHits = append(trace.KeywordHits, hit)
+	}
+	return trace
+}
+
+// setFusedHits records the fused HybridSearch results with the ranks they had in each retriever
+func setFusedHits(trace *types.HybridSearchTrace, fused []*types.IndexWithScore, vectorOnly bool,
+	cfg *types.KeywordSearchConfig,
+) {
+	trace.Fusion = &types.FusionInfo{
+		Method:        types.FusionMethodRRF,
+		RRFK:          rrfK,
+		VectorWeight:  cfg.GetVectorWeight(),
+		KeywordWeight: cfg.GetKeywordWeight(),
+	}
+	if vectorOnly {
+		trace.Fusion = &types.FusionInfo{Method: types.FusionMethodVectorOnly, VectorWeight: 1}
+	}
+	vectorRanks := firstRanks(trace.VectorHits)
+	keywordRanks := firstRanks(trace.KeywordHits)
+	trace.FusedHits = make([]*types.RetrievalHit, 0, len(fused))
+	for _, r := range fused {
+		hit := newRetrievalHit(r)
+		hit.VectorRank = vectorRanks[r.ChunkID]
+		hit.KeywordRank = keywordRanks[r.ChunkID]
+		trace.FusedHits = append(trace.FusedHits, hit)
+	}
+}
+
+// newRetrievalHit converts a retriever hit for the debug output
+func newRetrievalHit(r *types.IndexWithScore) *types.RetrievalHit {
+	return &types.RetrievalHit{
+		ChunkID:     r.ChunkID,
+		KnowledgeID: r.KnowledgeID,
+		Content:     r.Content,
+		Score:       r.Score,
+		MatchType:   r.MatchType,
+	}
+}
+
+// firstRanks maps chunk IDs to the 1-based rank of their first hit
+func firstRanks(hits []*types.RetrievalHit) map[string]int {
+	ranks := make(map[string]int, len(hits))
+	for i, hit := range hits {
+		if _, ok := ranks[hit.ChunkID]; !ok {
+			ranks[hit.ChunkID] = i + 1
+		}
+	}
+	return ranks
+}
+
+// snapshotResults copies search results so later stages rescoring them in place
+// do not change what an earlier stage reported
+func snapshotResults(results []*types.SearchResult) []*types.SearchResult {
+	snapshot := make([]*types.SearchResult, 0, len(results))
+	for _, r := range results {
+		copied := *r
+		snapshot = append(snapshot, &copied)
+	}
+	return snapshot
+}
+
+// DebugRetrieval runs the retrieval stages of the chat pipeline for a query against a
+// knowledge base and reports each stage's output. sessionID is optional and enables
+// query rewriting with that session's history; modelID optionally selects the chat
+// model used for rewriting and LLM query expansion.
+func (s *sessionService) DebugRetrieval(ctx context.Context,
+	knowledgeBaseID, query, sessionID, modelID string,
+) (*types.RetrievalDebugResult, error) {
+	tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64)
+	if !ok {
+		logger.Error(ctx, "Failed to get tenant ID from context")
+		return nil, errors.New("tenant ID not found in context")
+	}
+	searchTargets, err := s.buildSearchTargets(ctx, tenantID, []string{knowledgeBaseID}, nil)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to build search targets: %v", err)
+		return nil, err
+	}
+	// Knowledge bases the tenant cannot access yield no search target
+	if len(searchTargets) == 0 {
+		return nil, repository.ErrKnowledgeBaseNotFound
+	}
+
+	conversation := s.cfg.Current().Conversation
+	chatManage := &types.ChatManage{
+		SessionID:          sessionID,
+		Query:              query,
+		RewriteQuery:       query,
+		KnowledgeBaseIDs:   []string{knowledgeBaseID},
+		SearchTargets:      searchTargets,
+		VectorThreshold:    conversation.VectorThreshold,
+		KeywordThreshold:   conversation.KeywordThreshold,
+		EmbeddingTopK:      conversation.EmbeddingTopK,
+		RerankTopK:         conversation.RerankTopK,
+		RerankThreshold:    conversation.RerankThreshold,
+		MaxRounds:          conversation.MaxRounds,
+		ChatModelID:        modelID,
+		EnableRewrite:      conversation.EnableRewrite && sessionID != "",
+		EnableLLMExpansion: conversation.EnableLLMExpansion,
+		EnableHyDE:         conversation.EnableHyDE,
+		TenantID:           tenantID,
+		SummaryConfig: types.SummaryConfig{
+			ContextTemplate: conversation.Summary.ContextTemplate,
+		},
+	}
+
+	models, err := s.modelService.ListModels(ctx)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get models: %v", err)
+		return nil, err
+	}
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		if model.Type == types.ModelTypeRerank && chatManage.RerankModelID == "" {
+			chatManage.RerankModelID = model.ID
+		}
+		if model.Type == types.ModelTypeKnowledgeQA && chatManage.ChatModelID == "" {
+			chatManage.ChatModelID = model.ID
+		}
+	}
+
+	trace := &types.RetrievalTrace{}
+	ctx = context.WithValue(ctx, types.RetrievalTraceContextKey, trace)
+	result := &types.RetrievalDebugResult{Query: query, RerankModelID: chatManage.RerankModelID}
+	for _, eventType := range retrievalDebugEvents {
+		pluginErr := s.eventManager.Trigger(ctx, eventType, chatManage)
+		if pluginErr == chatpipline.ErrSearchNothing {
+			result.StoppedAt = eventType
+			break
+		}
+		if pluginErr != nil {
+			logger.Errorf(ctx, "Retrieval debug event %v failed: %s, error: %v",
+				eventType, pluginErr.Description, pluginErr.Err)
+			if pluginErr.Err != nil {
+				return nil, pluginErr.Err
+			}
+			return nil, errors.New(pluginErr.Description)
+		}
+		switch eventType {
+		case types.REWRITE_QUERY:
+			result.RewriteQuery = chatManage.RewriteQuery
+			result.QueryExpansions = chatManage.QueryExpansions
+			result.HyDEDocument = chatManage.HyDEDocument
+		case types.CHUNK_SEARCH:
+			result.SearchResults = snapshotResults(chatManage.SearchResult)
+		case types.CHUNK_RERANK:
+			result.RerankResults = snapshotResults(chatManage.RerankResult)
+		case types.FILTER_TOP_K:
+			result.MergeResults = snapshotResults(chatManage.MergeResult)
+		case types.INTO_CHAT_MESSAGE:
+			result.Context = chatManage.UserContent
+		}
+	}
+	result.Searches = trace.Searches()
+
+	logger.Infof(ctx, "Retrieval debug completed, knowledge base ID: %s, searches: %d, final results: %d",
+		knowledgeBaseID, len(result.Searches), len(result.MergeResults))
+	return result, nil
+}
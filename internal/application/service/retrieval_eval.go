@@ -0,0 +1,573 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"golang.org/x/sync/errgroup"
+)
+
+// retrievalEvalWorkers is the number of golden cases evaluated concurrently
+const retrievalEvalWorkers = 4
+
+// retrievalEvalEvents are the pipeline stages run for each golden case, the
+// rag_stream retrieval stages followed by non-streaming answer generation
+var retrievalEvalEvents = []types.EventType{
+	types.REWRITE_QUERY,
+	types.CHUNK_SEARCH,
+	types.CHUNK_RERANK,
+	types.CHUNK_MERGE,
+	types.FILTER_TOP_K,
+	types.INTO_CHAT_MESSAGE,
+	types.CHAT_COMPLETION,
+}
+
+// faithfulnessJudgePrompt asks the judge model whether an answer is supported by the retrieved context
+const faithfulnessJudgePrompt = `你是一名严格的问答质量评审。请判断回答中的陈述是否都能由给定的参考资料支持，只输出一个 JSON 对象，不要输出任何解释。
+
+JSON 格式：
+{"score": 0.0, "reason": "..."}
+
+要求：
+- score：0 到 1 之间的小数，表示回答中能被参考资料支持的陈述所占比例；回答明确表示资料中没有答案时记为 1
+- reason：一句话说明主要的无依据陈述，全部有依据时简要说明
+- 只依据参考资料判断，不要使用你自己的知识`
+
+// retrievalEvalService implements interfaces.RetrievalEvalService
+type retrievalEvalService struct {
+	cfg                  *config.Config
+	repo                 interfaces.RetrievalEvalRepository
+	knowledgeBaseService interfaces.KnowledgeBaseService
+	sessionService       interfaces.SessionService
+	modelService         interfaces.ModelService
+	tenantRepo           interfaces.TenantRepository
+	task                 interfaces.TaskEnqueuer
+}
+
+// NewRetrievalEvalService creates a new retrieval evaluation service
+func NewRetrievalEvalService(
+	cfg *config.Config,
+	repo interfaces.RetrievalEvalRepository,
+	knowledgeBaseService interfaces.KnowledgeBaseService,
+	sessionService interfaces.SessionService,
+	modelService interfaces.ModelService,
+	tenantRepo interfaces.TenantRepository,
+	task interfaces.TaskEnqueuer,
+) interfaces.RetrievalEvalService {
+	return &retrievalEvalService{
+		cfg:                  cfg,
+		repo:                 repo,
+		knowledgeBaseService: knowledgeBaseService,
+		sessionService:       sessionService,
+		modelService:         modelService,
+		tenantRepo:           tenantRepo,
+		task:                 task,
+	}
+}
+
+// CreateEvalSet creates a golden question set
+func (s *retrievalEvalService) CreateEvalSet(ctx context.Context,
+	set *types.RetrievalEvalSet,
+) (*types.RetrievalEvalSet, error) {
+	set.ID = uuid.New().String()
+	set.TenantID = ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := s.repo.CreateEvalSet(ctx, set); err != nil {
+		logger.Errorf(ctx, "Failed to create retrieval eval set: %v", err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Retrieval eval set created, ID: %s, knowledge base ID: %s, cases: %d",
+		set.ID, set.KnowledgeBaseID, len(set.Cases))
+	return set, nil
+}
+
+// GetEvalSet returns a golden question set of a knowledge base
+func (s *retrievalEvalService) GetEvalSet(ctx context.Context,
+	knowledgeBaseID, id string,
+) (*types.RetrievalEvalSet, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	set, err := s.repo.GetEvalSet(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if set.KnowledgeBaseID != knowledgeBaseID {
+		return nil, repository.ErrRetrievalEvalSetNotFound
+	}
+	return set, nil
+}
+
+// ListEvalSets lists the golden question sets of a knowledge base
+func (s *retrievalEvalService) ListEvalSets(ctx context.Context,
+	knowledgeBaseID string,
+) ([]*types.RetrievalEvalSet, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	return s.repo.ListEvalSets(ctx, tenantID, knowledgeBaseID)
+}
+
+// UpdateEvalSet replaces the name, description and cases of a golden question set
+func (s *retrievalEvalService) UpdateEvalSet(ctx context.Context,
+	set *types.RetrievalEvalSet,
+) (*types.RetrievalEvalSet, error) {
+	existing, err := s.GetEvalSet(ctx, set.KnowledgeBaseID, set.ID)
+	if err != nil {
+		return nil, err
+	}
+	existing.Name = set.Name
+	existing.Description = set.Description
+	existing.Cases = set.Cases
+	if err := s.repo.UpdateEvalSet(ctx, existing); err != nil {
+		logger.Errorf(ctx, "Failed to update retrieval eval set %s: %v", set.ID, err)
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteEvalSet deletes a golden question set and its runs
+func (s *retrievalEvalService) DeleteEvalSet(ctx context.Context, knowledgeBaseID, id string) error {
+	set, err := s.GetEvalSet(ctx, knowledgeBaseID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteEvalSet(ctx, set.TenantID, set.ID)
+}
+
+// StartEvalRun enqueues a run of a golden question set, zero config values use the current configuration
+func (s *retrievalEvalService) StartEvalRun(ctx context.Context, knowledgeBaseID, setID string,
+	cfg *types.RetrievalEvalConfig,
+) (*types.RetrievalEvalRun, error) {
+	set, err := s.GetEvalSet(ctx, knowledgeBaseID, setID)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := s.knowledgeBaseService.GetKnowledgeBaseByID(ctx, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	effective, err := s.effectiveConfig(ctx, kb, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &types.RetrievalEvalRun{
+		ID:              uuid.New().String(),
+		TenantID:        set.TenantID,
+		EvalSetID:       set.ID,
+		KnowledgeBaseID: knowledgeBaseID,
+		Status:          types.RetrievalEvalStatusPending,
+		Config:          *effective,
+		Total:           len(set.Cases),
+	}
+	if err := s.repo.CreateEvalRun(ctx, run); err != nil {
+		logger.Errorf(ctx, "Failed to create retrieval eval run: %v", err)
+		return nil, err
+	}
+
+	payloadBytes, err := json.Marshal(types.RetrievalEvalPayload{TenantID: run.TenantID, RunID: run.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal retrieval eval payload: %w", err)
+	}
+	task := asynq.NewTask(types.TypeRetrievalEval, payloadBytes,
+		asynq.TaskID(run.ID), asynq.Queue("low"), asynq.MaxRetry(1))
+	if _, err := s.task.Enqueue(task); err != nil {
+		s.failRun(ctx, run, fmt.Errorf("failed to enqueue retrieval eval task: %w", err))
+		return nil, err
+	}
+	logger.Infof(ctx, "Retrieval eval run enqueued, ID: %s, set ID: %s, cases: %d", run.ID, set.ID, run.Total)
+	return run, nil
+}
+
+// effectiveConfig fills the unset values of a run request from the current configuration
+func (s *retrievalEvalService) effectiveConfig(ctx context.Context, kb *types.KnowledgeBase,
+	req *types.RetrievalEvalConfig,
+) (*types.RetrievalEvalConfig, error) {
+	conversation := s.cfg.Current().Conversation
+	cfg := *req
+	if cfg.VectorThreshold == 0 {
+		cfg.VectorThreshold = conversation.VectorThreshold
+	}
+	if cfg.KeywordThreshold == 0 {
+		cfg.KeywordThreshold = conversation.KeywordThreshold
+	}
+	if cfg.EmbeddingTopK == 0 {
+		cfg.EmbeddingTopK = conversation.EmbeddingTopK
+	}
+	if cfg.RerankTopK == 0 {
+		cfg.RerankTopK = conversation.RerankTopK
+	}
+	if cfg.RerankThreshold == 0 {
+		cfg.RerankThreshold = conversation.RerankThreshold
+	}
+	if cfg.EnableLLMExpansion == nil {
+		enabled := conversation.EnableLLMExpansion
+		cfg.EnableLLMExpansion = &enabled
+	}
+	if cfg.EnableHyDE == nil {
+		enabled := conversation.EnableHyDE
+		cfg.EnableHyDE = &enabled
+	}
+	if len(cfg.Ks) == 0 {
+		cfg.Ks = types.DefaultRetrievalEvalKs
+	}
+	cfg.Ks = slices.Clone(cfg.Ks)
+	slices.Sort(cfg.Ks)
+	cfg.Ks = slices.Compact(cfg.Ks)
+	judge := cfg.JudgeEnabled()
+	cfg.Judge = &judge
+	if cfg.ChatModelID == "" {
+		cfg.ChatModelID = kb.SummaryModelID
+	}
+
+	if cfg.RerankModelID == "" || cfg.ChatModelID == "" {
+		models, err := s.modelService.ListModels(ctx)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to get models: %v", err)
+			return nil, err
+		}
+		for _, model := range models {
+			if model == nil {
+				continue
+			}
+			if model.Type == types.ModelTypeRerank && cfg.RerankModelID == "" {
+				cfg.RerankModelID = model.ID
+			}
+			if model.Type == types.ModelTypeKnowledgeQA && cfg.ChatModelID == "" {
+				cfg.ChatModelID = model.ID
+			}
+		}
+	}
+	if judge && cfg.ChatModelID == "" {
+		return nil, errors.New("no chat model available to generate answers for faithfulness")
+	}
+	if cfg.JudgeModelID == "" {
+		cfg.JudgeModelID = cfg.ChatModelID
+	}
+	return &cfg, nil
+}
+
+// GetEvalRun returns a run with its per case results
+func (s *retrievalEvalService) GetEvalRun(ctx context.Context,
+	knowledgeBaseID, runID string,
+) (*types.RetrievalEvalRun, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	run, err := s.repo.GetEvalRun(ctx, tenantID, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.KnowledgeBaseID != knowledgeBaseID {
+		return nil, repository.ErrRetrievalEvalRunNotFound
+	}
+	return run, nil
+}
+
+// ListEvalRuns lists the runs of a golden question set without per case results, newest first
+func (s *retrievalEvalService) ListEvalRuns(ctx context.Context,
+	knowledgeBaseID, setID string,
+) ([]*types.RetrievalEvalRun, error) {
+	set, err := s.GetEvalSet(ctx, knowledgeBaseID, setID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListEvalRuns(ctx, set.TenantID, set.ID)
+}
+
+// ProcessRetrievalEval evaluates every golden case of a run and stores the metrics
+func (s *retrievalEvalService) ProcessRetrievalEval(ctx context.Context, t *asynq.Task) error {
+	var payload types.RetrievalEvalPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal retrieval eval payload: %w", err)
+	}
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	if _, ok := ctx.Value(types.RequestIDContextKey).(string); !ok {
+		ctx = context.WithValue(ctx, types.RequestIDContextKey, payload.RunID)
+	}
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant info: %v", err)
+		return fmt.Errorf("failed to get tenant info: %w", err)
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	run, err := s.repo.GetEvalRun(ctx, payload.TenantID, payload.RunID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get retrieval eval run %s: %v", payload.RunID, err)
+		return err
+	}
+	set, err := s.repo.GetEvalSet(ctx, payload.TenantID, run.EvalSetID)
+	if err != nil {
+		s.failRun(ctx, run, err)
+		return nil
+	}
+	kb, err := s.knowledgeBaseService.GetKnowledgeBaseByID(ctx, run.KnowledgeBaseID)
+	if err != nil {
+		s.failRun(ctx, run, err)
+		return nil
+	}
+
+	logger.Infof(ctx, "Processing retrieval eval run %s, set: %s, cases: %d", run.ID, set.ID, len(set.Cases))
+	run.Status = types.RetrievalEvalStatusRunning
+	run.Total = len(set.Cases)
+	run.Finished = 0
+	if err := s.repo.UpdateEvalRun(ctx, run); err != nil {
+		return err
+	}
+
+	results := make(types.RetrievalEvalCaseResults, len(set.Cases))
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(retrievalEvalWorkers)
+	for i := range set.Cases {
+		evalCase := set.Cases[i]
+		g.Go(func() error {
+			result := s.evalCase(ctx, kb, &run.Config, &evalCase)
+			mu.Lock()
+			defer mu.Unlock()
+			results[i] = result
+			run.Finished++
+			if err := s.repo.UpdateEvalRun(ctx, run); err != nil {
+				logger.Warnf(ctx, "Failed to update retrieval eval run progress: %v", err)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	now := time.Now()
+	run.Results = results
+	run.Metrics = aggregateRetrievalEval(results, run.Config.Ks)
+	run.Status = types.RetrievalEvalStatusCompleted
+	run.FinishedAt = &now
+	if err := s.repo.UpdateEvalRun(ctx, run); err != nil {
+		logger.Errorf(ctx, "Failed to save retrieval eval run %s: %v", run.ID, err)
+		return err
+	}
+	logger.Infof(ctx, "Retrieval eval run %s completed, MRR: %.4f, failed cases: %d",
+		run.ID, run.Metrics.MRR, run.Metrics.FailedCases)
+	return nil
+}
+
+// failRun marks a run as failed
+func (s *retrievalEvalService) failRun(ctx context.Context, run *types.RetrievalEvalRun, err error) {
+	logger.Errorf(ctx, "Retrieval eval run %s failed: %v", run.ID, err)
+	now := time.Now()
+	run.Status = types.RetrievalEvalStatusFailed
+	run.Error = err.Error()
+	run.FinishedAt = &now
+	if updateErr := s.repo.UpdateEvalRun(ctx, run); updateErr != nil {
+		logger.Errorf(ctx, "Failed to mark retrieval eval run %s as failed: %v", run.ID, updateErr)
+	}
+}
+
+// evalCase runs the pipeline for one golden case and scores its retrieval and answer
+func (s *retrievalEvalService) evalCase(ctx context.Context, kb *types.KnowledgeBase,
+	cfg *types.RetrievalEvalConfig, evalCase *types.RetrievalEvalCase,
+) *types.RetrievalEvalCaseResult {
+	result := &types.RetrievalEvalCaseResult{
+		Question:       evalCase.Question,
+		ExpectedAnswer: evalCase.ExpectedAnswer,
+	}
+	conversation := s.cfg.Current().Conversation
+	chatManage := &types.ChatManage{
+		Query:            evalCase.Question,
+		RewriteQuery:     evalCase.Question,
+		KnowledgeBaseIDs: []string{kb.ID},
+		SearchTargets: types.SearchTargets{&types.SearchTarget{
+			Type:            types.SearchTargetTypeKnowledgeBase,
+			KnowledgeBaseID: kb.ID,
+			TenantID:        kb.TenantID,
+		}},
+		VectorThreshold:    cfg.VectorThreshold,
+		KeywordThreshold:   cfg.KeywordThreshold,
+		EmbeddingTopK:      cfg.EmbeddingTopK,
+		RerankModelID:      cfg.RerankModelID,
+		RerankTopK:         cfg.RerankTopK,
+		RerankThreshold:    cfg.RerankThreshold,
+		ChatModelID:        cfg.ChatModelID,
+		EnableLLMExpansion: cfg.EnableLLMExpansion != nil && *cfg.EnableLLMExpansion,
+		EnableHyDE:         cfg.EnableHyDE != nil && *cfg.EnableHyDE,
+		TenantID:           kb.TenantID,
+		FallbackStrategy:   types.FallbackStrategyFixed,
+		FallbackResponse:   conversation.FallbackResponse,
+		SummaryConfig: types.SummaryConfig{
+			Prompt:              conversation.Summary.Prompt,
+			ContextTemplate:     conversation.Summary.ContextTemplate,
+			Temperature:         conversation.Summary.Temperature,
+			NoMatchPrefix:       conversation.Summary.NoMatchPrefix,
+			MaxCompletionTokens: conversation.Summary.MaxCompletionTokens,
+		},
+	}
+
+	events := retrievalEvalEvents
+	if !cfg.JudgeEnabled() {
+		// Without judging the answer is not needed, stop once the final results are known
+		events = retrievalEvalEvents[:len(retrievalEvalEvents)-2]
+	}
+	if err := s.sessionService.KnowledgeQAByEvent(ctx, chatManage, events); err != nil {
+		logger.Warnf(ctx, "Retrieval eval case failed, question: %s, error: %v", evalCase.Question, err)
+		result.Error = err.Error()
+		return result
+	}
+
+	expected, byChunk := evalCase.ExpectedSourceIDs()
+	ranked := make([]string, 0, len(chatManage.MergeResult))
+	for _, r := range chatManage.MergeResult {
+		sourceID := r.KnowledgeID
+		if byChunk {
+			sourceID = r.ID
+		}
+		ranked = append(ranked, sourceID)
+		result.Retrieved = append(result.Retrieved, &types.RetrievalEvalHit{
+			ChunkID:     r.ID,
+			KnowledgeID: r.KnowledgeID,
+			Score:       r.Score,
+			Relevant:    slices.Contains(expected, sourceID),
+		})
+	}
+	result.RecallAtK = make(map[int]float64, len(cfg.Ks))
+	for _, k := range cfg.Ks {
+		result.RecallAtK[k] = recallAtK(expected, ranked, k)
+	}
+	result.FirstRelevantRank = firstRelevantRank(expected, ranked)
+	if result.FirstRelevantRank > 0 {
+		result.ReciprocalRank = 1 / float64(result.FirstRelevantRank)
+	}
+
+	if !cfg.JudgeEnabled() || len(chatManage.MergeResult) == 0 || chatManage.ChatResponse == nil {
+		return result
+	}
+	result.Answer = chatManage.ChatResponse.Content
+	score, reason, err := s.judgeFaithfulness(ctx, cfg.JudgeModelID, chatManage.MergeResult, result.Answer)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to judge faithfulness, question: %s, error: %v", evalCase.Question, err)
+		result.JudgeReason = "judge failed: " + err.Error()
+		return result
+	}
+	result.Faithfulness = &score
+	result.JudgeReason = reason
+	return result
+}
+
+// faithfulnessVerdict is the JSON object returned by the judge model
+type faithfulnessVerdict struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// judgeFaithfulness asks the judge model how much of the answer is supported by the retrieved context
+func (s *retrievalEvalService) judgeFaithfulness(ctx context.Context, modelID string,
+	references []*types.SearchResult, answer string,
+) (float64, string, error) {
+	model, err := s.modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		return 0, "", err
+	}
+	var refs strings.Builder
+	for i, r := range references {
+		fmt.Fprintf(&refs, "[%d] %s\n\n", i+1, r.Content)
+	}
+	thinking := false
+	response, err := model.Chat(ctx, []chat.Message{
+		{Role: "system", Content: faithfulnessJudgePrompt},
+		{Role: "user", Content: fmt.Sprintf("参考资料：\n%s回答：\n%s", refs.String(), answer)},
+	}, &chat.ChatOptions{
+		Temperature:         0,
+		MaxCompletionTokens: 300,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	verdict, err := parseFaithfulnessVerdict(response.Content)
+	if err != nil {
+		return 0, "", err
+	}
+	return verdict.Score, verdict.Reason, nil
+}
+
+// parseFaithfulnessVerdict extracts the judge's JSON verdict, tolerating thinking
+// content and code fences around it, and clamps the score to [0, 1]
+func parseFaithfulnessVerdict(content string) (*faithfulnessVerdict, error) {
+	if end := strings.LastIndex(content, "</think>"); end >= 0 {
+		content = content[end+len("</think>"):]
+	}
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("no verdict in judge response: %q", content)
+	}
+	var verdict faithfulnessVerdict
+	if err := json.Unmarshal([]byte(content[start:end+1]), &verdict); err != nil {
+		return nil, fmt.Errorf("invalid judge verdict: %w", err)
+	}
+	verdict.Score = min(max(verdict.Score, 0), 1)
+	return &verdict, nil
+}
+
+// recallAtK returns the share of expected IDs found in the first k ranked IDs
+func recallAtK(expected, ranked []string, k int) float64 {
+	if len(expected) == 0 {
+		return 0
+	}
+	found := make(map[string]struct{})
+	for _, id := range ranked[:min(k, len(ranked))] {
+		if slices.Contains(expected, id) {
+			found[id] = struct{}{}
+		}
+	}
+	return float64(len(found)) / float64(len(expected))
+}
+
+// firstRelevantRank returns the 1-based rank of the first expected ID, 0 when none was ranked
+func firstRelevantRank(expected, ranked []string) int {
+	for i, id := range ranked {
+		if slices.Contains(expected, id) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// aggregateRetrievalEval averages the case metrics, failed cases are counted but not averaged
+func aggregateRetrievalEval(results types.RetrievalEvalCaseResults, ks []int) *types.RetrievalEvalMetrics {
+	metrics := &types.RetrievalEvalMetrics{RecallAtK: make(map[int]float64, len(ks))}
+	var faithfulness float64
+	for _, result := range results {
+		if result == nil || result.Error != "" {
+			metrics.FailedCases++
+			continue
+		}
+		metrics.EvaluatedCases++
+		for _, k := range ks {
+			metrics.RecallAtK[k] += result.RecallAtK[k]
+		}
+		metrics.MRR += result.ReciprocalRank
+		if result.Faithfulness != nil {
+			metrics.JudgedCases++
+			faithfulness += *result.Faithfulness
+		}
+	}
+	if metrics.EvaluatedCases > 0 {
+		for _, k := range ks {
+			metrics.RecallAtK[k] /= float64(metrics.EvaluatedCases)
+		}
+		metrics.MRR /= float64(metrics.EvaluatedCases)
+	}
+	if metrics.JudgedCases > 0 {
+		faithfulness /= float64(metrics.JudgedCases)
+		metrics.Faithfulness = &faithfulness
+	}
+	return metrics
+}
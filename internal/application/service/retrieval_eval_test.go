@@ -0,0 +1,92 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestRetrievalEvalRankMetrics(t *testing.T) {
+	expected := []string{"a", "b"}
+	ranked := []string{"x", "a", "a", "y", "b"}
+
+	recalls := map[int]float64{1: 0, 2: 0.5, 3: 0.5, 5: 1, 10: 1}
+	for k, want := range recalls {
+		if got := recallAtK(expected, ranked, k); math.Abs(got-want) > 1e-9 {
+			t.Errorf("recallAtK(k=%d) = %v, want %v", k, got, want)
+		}
+	}
+	if got := recallAtK(nil, ranked, 5); got != 0 {
+		t.Errorf("recallAtK without expected IDs = %v, want 0", got)
+	}
+	if got := firstRelevantRank(expected, ranked); got != 2 {
+		t.Errorf("firstRelevantRank = %d, want 2", got)
+	}
+	if got := firstRelevantRank(expected, []string{"x", "y"}); got != 0 {
+		t.Errorf("firstRelevantRank without hits = %d, want 0", got)
+	}
+}
+
+func TestAggregateRetrievalEval(t *testing.T) {
+	one, half := 1.0, 0.5
+	results := types.RetrievalEvalCaseResults{
+		{RecallAtK: map[int]float64{1: 1, 5: 1}, ReciprocalRank: 1, Faithfulness: &one},
+		{RecallAtK: map[int]float64{1: 0, 5: 0.5}, ReciprocalRank: 0.25, Faithfulness: &half},
+		{RecallAtK: map[int]float64{1: 0, 5: 0}},
+		{Error: "search failed"},
+	}
+	metrics := aggregateRetrievalEval(results, []int{1, 5})
+
+	if metrics.EvaluatedCases != 3 || metrics.FailedCases != 1 || metrics.JudgedCases != 2 {
+		t.Fatalf("counts = %d/%d/%d, want 3/1/2", metrics.EvaluatedCases, metrics.FailedCases, metrics.JudgedCases)
+	}
+	if got := metrics.RecallAtK[1]; math.Abs(got-1.0/3) > 1e-9 {
+		t.Errorf("recall@1 = %v, want 1/3", got)
+	}
+	if got := metrics.RecallAtK[5]; math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("recall@5 = %v, want 0.5", got)
+	}
+	if math.Abs(metrics.MRR-1.25/3) > 1e-9 {
+		t.Errorf("MRR = %v, want %v", metrics.MRR, 1.25/3)
+	}
+	if metrics.Faithfulness == nil || math.Abs(*metrics.Faithfulness-0.75) > 1e-9 {
+		t.Errorf("faithfulness = %v, want 0.75", metrics.Faithfulness)
+	}
+
+	if empty := aggregateRetrievalEval(nil, []int{1}); empty.Faithfulness != nil || empty.MRR != 0 {
+		t.Errorf("empty aggregate = %+v, want zero metrics", empty)
+	}
+}
+
+func TestParseFaithfulnessVerdict(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		score   float64
+		wantErr bool
+	}{
+		{"plain", `{"score": 0.8, "reason": "ok"}`, 0.8, false},
+		{"thinking and fence", "<think>{\"score\": 0}</think>\n```json\n{\"score\": 0.6, \"reason\": \"x\"}\n```", 0.6, false},
+		{"clamped", `{"score": 3}`, 1, false},
+		{"no json", "the answer is faithful", 0, true},
+		{"invalid json", `{"score": high}`, 0, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			verdict, err := parseFaithfulnessVerdict(c.content)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", verdict)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if verdict.Score != c.score {
+				t.Errorf("score = %v, want %v", verdict.Score, c.score)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package retriever
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	batchOpUpsert = "upsert"
+	batchOpDelete = "delete"
+)
+
+// BatchOptions sizes the requests an engine sends to its repository when saving or deleting many vectors
+type BatchOptions struct {
+	// UpsertBatchSize is the number of vectors saved per request, 40 when unset
+	UpsertBatchSize int
+	// DeleteBatchSize is the number of IDs deleted per request, 500 when unset
+	DeleteBatchSize int
+	// Parallelism caps the requests of one save or delete sent at once, 5 when unset
+	Parallelism int
+}
+
+// DefaultBatchOptions returns the options used by NewKVHybridRetrieveEngine
+func DefaultBatchOptions() BatchOptions {
+	return BatchOptions{
+		UpsertBatchSize: 40,
+		DeleteBatchSize: 500,
+		Parallelism:     5,
+	}
+}
+
+// withDefaults fills the unset options
+func (o BatchOptions) withDefaults() BatchOptions {
+	d := DefaultBatchOptions()
+	if o.UpsertBatchSize <= 0 {
+		o.UpsertBatchSize = d.UpsertBatchSize
+	}
+	if o.DeleteBatchSize <= 0 {
+		o.DeleteBatchSize = d.DeleteBatchSize
+	}
+	if o.Parallelism <= 0 {
+		o.Parallelism = d.Parallelism
+	}
+	return o
+}
+
+// runBatches calls fn on consecutive batches of items with the offset of each batch, at most parallelism at once.
+// It stops starting batches at the first error and returns it.
+func runBatches[T any](ctx context.Context, items []T, size int, parallelism int,
+	fn func(ctx context.Context, offset int, batch []T) error,
+) error {
+	if len(items) <= size {
+		if len(items) == 0 {
+			return nil
+		}
+		return fn(ctx, 0, items)
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+	for offset := 0; offset < len(items); offset += size {
+		batch := items[offset:min(offset+size, len(items))]
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fn(ctx, offset, batch)
+		})
+	}
+	return g.Wait()
+}
+
+// observeBatch records the latency of one batch request of an engine
+func observeBatch(engine string, op string, start time.Time, err error) {
+	metrics.VectorWriteDuration.WithLabelValues(engine, op, metrics.Result(err)).Observe(time.Since(start).Seconds())
+}
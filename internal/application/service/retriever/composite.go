@@ -300,6 +300,30 @@ func (c *CompositeRetrieveEngine) CopyIndices(
 	})
 }
 
+// GetEmbeddingsByKnowledgeID returns the vectors of a knowledge from the first engine that stores any.
+// An empty result means no registered engine can read vectors back.
+func (c *CompositeRetrieveEngine) GetEmbeddingsByKnowledgeID(ctx context.Context,
+	knowledgeID string,
+) (map[string][]float32, error) {
+	for _, engineInfo := range c.engineInfos {
+		if engineInfo == nil || !slices.Contains(engineInfo.retrieverType, types.VectorRetrieverType) {
+			continue
+		}
+		reader, ok := engineInfo.retrieveEngine.(interfaces.EmbeddingReader)
+		if !ok {
+			continue
+		}
+		embeddings, err := reader.GetEmbeddingsByKnowledgeID(ctx, knowledgeID)
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) > 0 {
+			return embeddings, nil
+		}
+	}
+	return nil, nil
+}
+
 // DeleteByKnowledgeIDList deletes vector embeddings by knowledge ID list from all registered repositories
 func (c *CompositeRetrieveEngine) DeleteByKnowledgeIDList(ctx context.Context,
 	knowledgeIDList []string, dimension int, knowledgeType string,
@@ -314,6 +338,39 @@ func (c *CompositeRetrieveEngine) DeleteByKnowledgeIDList(ctx context.Context,
 	})
 }
 
+// SeparatesDimensions reports whether every registered engine stores vectors of different dimensions apart
+func (c *CompositeRetrieveEngine) SeparatesDimensions() bool {
+	for _, engineInfo := range c.engineInfos {
+		if engineInfo == nil {
+			continue
+		}
+		deleter, ok := engineInfo.retrieveEngine.(interfaces.DimensionDeleter)
+		if !ok || !deleter.SeparatesDimensions() {
+			return false
+		}
+	}
+	return true
+}
+
+// DeleteByKnowledgeIDListInDimension deletes the vectors of the knowledge stored with the given dimension
+// from all registered repositories
+func (c *CompositeRetrieveEngine) DeleteByKnowledgeIDListInDimension(ctx context.Context,
+	knowledgeIDList []string, dimension int,
+) error {
+	return c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) error {
+		deleter, ok := engineInfo.retrieveEngine.(interfaces.DimensionDeleter)
+		if !ok {
+			return fmt.Errorf("retrieve engine %s does not store dimensions apart", engineInfo.retrieveEngine.EngineType())
+		}
+		if err := deleter.DeleteByKnowledgeIDListInDimension(ctx, knowledgeIDList, dimension); err != nil {
+			logger.GetLogger(ctx).Errorf("Repository %s failed to delete knowledge ID list in dimension %d: %v",
+				engineInfo.retrieveEngine.EngineType(), dimension, err)
+			return err
+		}
+		return nil
+	})
+}
+
 // EstimateStorageSize estimates the storage size required for the provided index information
 func (c *CompositeRetrieveEngine) EstimateStorageSize(ctx context.Context,
 	embedder embedding.Embedder, indexInfoList []*types.IndexInfo,
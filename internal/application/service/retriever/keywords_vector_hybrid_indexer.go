@@ -2,15 +2,17 @@ package retriever
 
 import (
 	"context"
+	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
-	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/slowlog"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
-	"golang.org/x/sync/errgroup"
 )
 
 // KeywordsVectorHybridRetrieveEngineService implements a hybrid retrieval engine
@@ -18,6 +20,7 @@ import (
 type KeywordsVectorHybridRetrieveEngineService struct {
 	indexRepository interfaces.RetrieveEngineRepository
 	engineType      types.RetrieverEngineType
+	batch           BatchOptions
 }
 
 // NewKVHybridRetrieveEngine creates a new instance of the hybrid retrieval engine
@@ -25,7 +28,19 @@ type KeywordsVectorHybridRetrieveEngineService struct {
 func NewKVHybridRetrieveEngine(indexRepository interfaces.RetrieveEngineRepository,
 	engineType types.RetrieverEngineType,
 ) interfaces.RetrieveEngineService {
-	return &KeywordsVectorHybridRetrieveEngineService{indexRepository: indexRepository, engineType: engineType}
+	return NewKVHybridRetrieveEngineWithOptions(indexRepository, engineType, DefaultBatchOptions())
+}
+
+// NewKVHybridRetrieveEngineWithOptions creates a hybrid retrieval engine that saves and deletes vectors
+// in batches sized by opts, unset options take their defaults
+func NewKVHybridRetrieveEngineWithOptions(indexRepository interfaces.RetrieveEngineRepository,
+	engineType types.RetrieverEngineType, opts BatchOptions,
+) interfaces.RetrieveEngineService {
+	return &KeywordsVectorHybridRetrieveEngineService{
+		indexRepository: indexRepository,
+		engineType:      engineType,
+		batch:           opts.withDefaults(),
+	}
 }
 
 // EngineType returns the type of the retrieval engine
@@ -37,7 +52,14 @@ func (v *KeywordsVectorHybridRetrieveEngineService) EngineType() types.Retriever
 func (v *KeywordsVectorHybridRetrieveEngineService) Retrieve(ctx context.Context,
 	params types.RetrieveParams,
 ) ([]*types.RetrieveResult, error) {
-	return v.indexRepository.Retrieve(ctx, params)
+	start := time.Now()
+	results, err := v.indexRepository.Retrieve(ctx, params)
+	metrics.VectorQueryDuration.WithLabelValues(string(v.engineType), string(params.RetrieverType), metrics.Result(err)).
+		Observe(time.Since(start).Seconds())
+	slowCtx := slowlog.WithKnowledgeBase(ctx, strings.Join(params.KnowledgeBaseIDs, ","))
+	slowlog.Observe(slowCtx, slowlog.KindVectorSearch,
+		fmt.Sprintf("%s %s search, top %d", v.engineType, params.RetrieverType, params.TopK), start, err)
+	return results, err
 }
 
 // Index creates embeddings for the content and saves it to the repository
@@ -59,7 +81,7 @@ func (v *KeywordsVectorHybridRetrieveEngineService) Index(ctx context.Context,
 }
 
 // BatchIndex creates embeddings for multiple content items and saves them to the repository
-// in batches for efficiency. Uses concurrent batch saving to improve performance.
+// in batches of the configured size, sending up to the configured number of batches at once.
 func (v *KeywordsVectorHybridRetrieveEngineService) BatchIndex(ctx context.Context,
 	embedder embedding.Embedder, indexInfoList []*types.IndexInfo, retrieverTypes []types.RetrieverType,
 ) error {
@@ -87,143 +109,72 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchIndex(ctx context.Conte
 			return err
 		}
 
-		batchSize := 40
-		chunks := utils.ChunkSlice(indexInfoList, batchSize)
-
-		// Use concurrent batch saving for better performance
-		// Limit concurrency to avoid overwhelming the backend
-		const maxConcurrency = 5
-		if len(chunks) <= maxConcurrency {
-			// For small number of batches, use simple concurrency
-			return v.concurrentBatchSave(ctx, chunks, embeddings, batchSize)
-		}
-
-		// For large number of batches, use bounded concurrency
-		return v.boundedConcurrentBatchSave(ctx, chunks, embeddings, batchSize, maxConcurrency)
+		return v.saveBatches(ctx, indexInfoList, embeddings)
 	}
-
-	// For non-vector retrieval, use concurrent batch saving as well
-	chunks := utils.ChunkSlice(indexInfoList, 10)
-	const maxConcurrency = 5
-	if len(chunks) <= maxConcurrency {
-		return v.concurrentBatchSaveNoEmbedding(ctx, chunks)
-	}
-	return v.boundedConcurrentBatchSaveNoEmbedding(ctx, chunks, maxConcurrency)
+	return v.saveBatches(ctx, indexInfoList, nil)
 }
 
-// concurrentBatchSave saves all batches concurrently without concurrency limit
-func (v *KeywordsVectorHybridRetrieveEngineService) concurrentBatchSave(
-	ctx context.Context,
-	chunks [][]*types.IndexInfo,
-	embeddings [][]float32,
-	batchSize int,
+// saveBatches saves the index entries in batches of the configured size, with bounded parallelism.
+// embeddings holds the vector of each entry, nil when the engine indexes keywords only.
+func (v *KeywordsVectorHybridRetrieveEngineService) saveBatches(ctx context.Context,
+	indexInfoList []*types.IndexInfo, embeddings [][]float32,
 ) error {
-	g, ctx := errgroup.WithContext(ctx)
-	for i, indexChunk := range chunks {
-		g.Go(func() error {
+	return runBatches(ctx, indexInfoList, v.batch.UpsertBatchSize, v.batch.Parallelism,
+		func(ctx context.Context, offset int, batch []*types.IndexInfo) error {
 			params := make(map[string]any)
-			embeddingMap := make(map[string][]float32)
-			for j, indexInfo := range indexChunk {
-				embeddingMap[indexInfo.SourceID] = embeddings[i*batchSize+j]
+			if embeddings != nil {
+				embeddingMap := make(map[string][]float32, len(batch))
+				for j, indexInfo := range batch {
+					embeddingMap[indexInfo.SourceID] = embeddings[offset+j]
+				}
+				params["embedding"] = embeddingMap
 			}
-			params["embedding"] = embeddingMap
-			return v.indexRepository.BatchSave(ctx, indexChunk, params)
-		})
-	}
-	return g.Wait()
-}
-
-// boundedConcurrentBatchSave saves batches with bounded concurrency using semaphore pattern
-func (v *KeywordsVectorHybridRetrieveEngineService) boundedConcurrentBatchSave(
-	ctx context.Context,
-	chunks [][]*types.IndexInfo,
-	embeddings [][]float32,
-	batchSize int,
-	maxConcurrency int,
-) error {
-	g, ctx := errgroup.WithContext(ctx)
-	sem := make(chan struct{}, maxConcurrency)
-
-	for i, indexChunk := range chunks {
-		g.Go(func() error {
-			select {
-			case sem <- struct{}{}:
-				defer func() { <-sem }()
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-
-			params := make(map[string]any)
-			embeddingMap := make(map[string][]float32)
-			for j, indexInfo := range indexChunk {
-				embeddingMap[indexInfo.SourceID] = embeddings[i*batchSize+j]
-			}
-			params["embedding"] = embeddingMap
-			return v.indexRepository.BatchSave(ctx, indexChunk, params)
-		})
-	}
-	return g.Wait()
-}
-
-// concurrentBatchSaveNoEmbedding saves all batches concurrently without embeddings
-func (v *KeywordsVectorHybridRetrieveEngineService) concurrentBatchSaveNoEmbedding(
-	ctx context.Context,
-	chunks [][]*types.IndexInfo,
-) error {
-	g, ctx := errgroup.WithContext(ctx)
-	for _, indexChunk := range chunks {
-		g.Go(func() error {
-			params := make(map[string]any)
-			return v.indexRepository.BatchSave(ctx, indexChunk, params)
+			start := time.Now()
+			err := v.indexRepository.BatchSave(ctx, batch, params)
+			observeBatch(string(v.engineType), batchOpUpsert, start, err)
+			return err
 		})
-	}
-	return g.Wait()
 }
 
-// boundedConcurrentBatchSaveNoEmbedding saves batches with bounded concurrency without embeddings
-func (v *KeywordsVectorHybridRetrieveEngineService) boundedConcurrentBatchSaveNoEmbedding(
-	ctx context.Context,
-	chunks [][]*types.IndexInfo,
-	maxConcurrency int,
+// deleteBatches deletes the IDs in batches of the configured size, with bounded parallelism, so that
+// reparsing a large document neither sends one huge request nor waits on the batches one after another
+func (v *KeywordsVectorHybridRetrieveEngineService) deleteBatches(ctx context.Context,
+	idList []string, del func(ctx context.Context, batch []string) error,
 ) error {
-	g, ctx := errgroup.WithContext(ctx)
-	sem := make(chan struct{}, maxConcurrency)
-
-	for _, indexChunk := range chunks {
-		g.Go(func() error {
-			select {
-			case sem <- struct{}{}:
-				defer func() { <-sem }()
-			case <-ctx.Done():
-				return ctx.Err()
-			}
-
-			params := make(map[string]any)
-			return v.indexRepository.BatchSave(ctx, indexChunk, params)
+	return runBatches(ctx, idList, v.batch.DeleteBatchSize, v.batch.Parallelism,
+		func(ctx context.Context, _ int, batch []string) error {
+			start := time.Now()
+			err := del(ctx, batch)
+			observeBatch(string(v.engineType), batchOpDelete, start, err)
+			return err
 		})
-	}
-	return g.Wait()
 }
 
 // DeleteByChunkIDList deletes vectors by their chunk IDs
 func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByChunkIDList(ctx context.Context,
 	indexIDList []string, dimension int, knowledgeType string,
 ) error {
-	return v.indexRepository.DeleteByChunkIDList(ctx, indexIDList, dimension, knowledgeType)
+	return v.deleteBatches(ctx, indexIDList, func(ctx context.Context, batch []string) error {
+		return v.indexRepository.DeleteByChunkIDList(ctx, batch, dimension, knowledgeType)
+	})
 }
 
 // DeleteBySourceIDList deletes vectors by their source IDs
 func (v *KeywordsVectorHybridRetrieveEngineService) DeleteBySourceIDList(ctx context.Context,
 	sourceIDList []string, dimension int, knowledgeType string,
 ) error {
-	return v.indexRepository.DeleteBySourceIDList(ctx, sourceIDList, dimension, knowledgeType)
+	return v.deleteBatches(ctx, sourceIDList, func(ctx context.Context, batch []string) error {
+		return v.indexRepository.DeleteBySourceIDList(ctx, batch, dimension, knowledgeType)
+	})
 }
 
 // DeleteByKnowledgeIDList deletes vectors by their knowledge IDs
 func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByKnowledgeIDList(ctx context.Context,
 	knowledgeIDList []string, dimension int, knowledgeType string,
 ) error {
-	return v.indexRepository.DeleteByKnowledgeIDList(ctx, knowledgeIDList, dimension, knowledgeType)
+	return v.deleteBatches(ctx, knowledgeIDList, func(ctx context.Context, batch []string) error {
+		return v.indexRepository.DeleteByKnowledgeIDList(ctx, batch, dimension, knowledgeType)
+	})
 }
 
 // Support returns the retriever types supported by this engine
@@ -283,3 +234,42 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchUpdateChunkTagID(
 ) error {
 	return v.indexRepository.BatchUpdateChunkTagID(ctx, chunkTagMap)
 }
+
+// GetEmbeddingsByKnowledgeID returns the stored vectors of a knowledge if the repository can read them back
+func (v *KeywordsVectorHybridRetrieveEngineService) GetEmbeddingsByKnowledgeID(
+	ctx context.Context,
+	knowledgeID string,
+) (map[string][]float32, error) {
+	reader, ok := v.indexRepository.(interfaces.EmbeddingReader)
+	if !ok {
+		return nil, nil
+	}
+	return reader.GetEmbeddingsByKnowledgeID(ctx, knowledgeID)
+}
+
+// SeparatesDimensions reports whether the repository stores vectors of different dimensions apart
+func (v *KeywordsVectorHybridRetrieveEngineService) SeparatesDimensions() bool {
+	deleter, ok := v.indexRepository.(interfaces.DimensionDeleter)
+	return ok && deleter.SeparatesDimensions()
+}
+
+// DeleteByKnowledgeIDListInDimension deletes the vectors of the knowledge stored with the given dimension
+func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByKnowledgeIDListInDimension(ctx context.Context,
+	knowledgeIDList []string, dimension int,
+) error {
+	deleter, ok := v.indexRepository.(interfaces.DimensionDeleter)
+	if !ok || !deleter.SeparatesDimensions() {
+		return fmt.Errorf("retrieve engine %s does not store dimensions apart", v.engineType)
+	}
+	return v.deleteBatches(ctx, knowledgeIDList, func(ctx context.Context, batch []string) error {
+		return deleter.DeleteByKnowledgeIDListInDimension(ctx, batch, dimension)
+	})
+}
+
+// Ping checks the backend of the engine answers, engines whose repository cannot be probed are assumed up
+func (v *KeywordsVectorHybridRetrieveEngineService) Ping(ctx context.Context) error {
+	if checker, ok := v.indexRepository.(interfaces.HealthChecker); ok {
+		return checker.Ping(ctx)
+	}
+	return nil
+}
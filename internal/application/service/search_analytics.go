@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// defaultLowConfidenceThreshold is used when knowledge_base.search_analytics.low_confidence_threshold is not set
+const defaultLowConfidenceThreshold = 0.5
+
+// searchAnalyticsService implements interfaces.SearchAnalyticsService
+type searchAnalyticsService struct {
+	cfg  *config.Config
+	repo interfaces.SearchLogRepository
+}
+
+// NewSearchAnalyticsService creates a new search analytics service
+func NewSearchAnalyticsService(cfg *config.Config, repo interfaces.SearchLogRepository) interfaces.SearchAnalyticsService {
+	return &searchAnalyticsService{cfg: cfg, repo: repo}
+}
+
+// analyticsConfig returns the search analytics configuration, nil when search logging is disabled
+func (s *searchAnalyticsService) analyticsConfig() *config.SearchAnalyticsConfig {
+	if s.cfg == nil {
+		return nil
+	}
+	kb := s.cfg.Current().KnowledgeBase
+	if kb == nil || kb.SearchAnalytics == nil || !kb.SearchAnalytics.Enabled {
+		return nil
+	}
+	return kb.SearchAnalytics
+}
+
+// lowConfidenceThreshold returns the configured low confidence threshold
+func (s *searchAnalyticsService) lowConfidenceThreshold() float64 {
+	if s.cfg == nil {
+		return defaultLowConfidenceThreshold
+	}
+	if kb := s.cfg.Current().KnowledgeBase; kb != nil && kb.SearchAnalytics != nil &&
+		kb.SearchAnalytics.LowConfidenceThreshold > 0 {
+		return kb.SearchAnalytics.LowConfidenceThreshold
+	}
+	return defaultLowConfidenceThreshold
+}
+
+// RecordSearch logs a search once per knowledge base it targeted, failures are only logged.
+// Searches routed to an experiment are logged even when search analytics is disabled,
+// without their query text, since experiment results are computed from them.
+func (s *searchAnalyticsService) RecordSearch(ctx context.Context, record *types.SearchRecord) {
+	cfg := s.analyticsConfig()
+	if (cfg == nil && record.Experiment == nil) || strings.TrimSpace(record.Query) == "" ||
+		len(record.KnowledgeBaseIDs) == 0 {
+		return
+	}
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	query := record.Query
+	queryHash := hashSearchQuery(query)
+	if cfg == nil || !cfg.RecordQueryText {
+		query = ""
+	}
+
+	logs := make([]*types.SearchLog, 0, len(record.KnowledgeBaseIDs))
+	seen := make(map[string]struct{}, len(record.KnowledgeBaseIDs))
+	for _, kbID := range record.KnowledgeBaseIDs {
+		if _, ok := seen[kbID]; ok || kbID == "" {
+			continue
+		}
+		seen[kbID] = struct{}{}
+		log := &types.SearchLog{
+			ID:              uuid.New().String(),
+			TenantID:        tenantID,
+			KnowledgeBaseID: kbID,
+			SessionID:       record.SessionID,
+			MessageID:       record.MessageID,
+			Source:          record.Source,
+			Query:           query,
+			QueryHash:       queryHash,
+			ClickedChunkIDs: types.StringArray{},
+			LatencyMs:       record.Latency.Milliseconds(),
+		}
+		if record.Experiment != nil {
+			log.ExperimentID = record.Experiment.ExperimentID
+			log.Variant = record.Experiment.Variant
+		}
+		for _, r := range record.Results {
+			if r.KnowledgeBaseID != kbID {
+				continue
+			}
+			log.HitCount++
+			if r.Score > log.TopScore {
+				log.TopScore = r.Score
+			}
+		}
+		logs = append(logs, log)
+	}
+	if err := s.repo.CreateSearchLogs(ctx, logs); err != nil {
+		logger.Warnf(ctx, "Failed to record search logs: %v", err)
+	}
+}
+
+// RecordClick records a click on a citation of the answer to messageID
+func (s *searchAnalyticsService) RecordClick(ctx context.Context, knowledgeBaseID, messageID, chunkID string) error {
+	log, err := s.repo.GetSearchLogByMessage(ctx, knowledgeBaseID, messageID)
+	if err != nil {
+		return err
+	}
+	log.ClickCount++
+	if !slices.Contains(log.ClickedChunkIDs, chunkID) {
+		log.ClickedChunkIDs = append(log.ClickedChunkIDs, chunkID)
+	}
+	return s.repo.UpdateSearchLog(ctx, log)
+}
+
+// RecordFeedback records the feedback given to the answer to messageID
+func (s *searchAnalyticsService) RecordFeedback(ctx context.Context, knowledgeBaseID, messageID, feedback string) error {
+	log, err := s.repo.GetSearchLogByMessage(ctx, knowledgeBaseID, messageID)
+	if err != nil {
+		return err
+	}
+	log.Feedback = feedback
+	return s.repo.UpdateSearchLog(ctx, log)
+}
+
+// GetSearchAnalytics reports the searches of the last days, listing up to limit queries per kind
+func (s *searchAnalyticsService) GetSearchAnalytics(ctx context.Context,
+	knowledgeBaseID string, days, limit int,
+) (*types.SearchAnalytics, error) {
+	since := time.Now().AddDate(0, 0, -days)
+	threshold := s.lowConfidenceThreshold()
+
+	summary, err := s.repo.GetSearchSummary(ctx, knowledgeBaseID, since, threshold)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get search summary of knowledge base %s: %v", knowledgeBaseID, err)
+		return nil, err
+	}
+	analytics := &types.SearchAnalytics{
+		KnowledgeBaseID:        knowledgeBaseID,
+		Days:                   days,
+		LowConfidenceThreshold: threshold,
+		SearchSummary:          *summary,
+	}
+	for kind, stats := range map[types.SearchQueryKind]*[]*types.SearchQueryStat{
+		types.SearchQueryKindTop:           &analytics.TopQueries,
+		types.SearchQueryKindZeroResult:    &analytics.ZeroResultQueries,
+		types.SearchQueryKindLowConfidence: &analytics.LowConfidenceQueries,
+	} {
+		*stats, err = s.repo.ListQueryStats(ctx, knowledgeBaseID, since, kind, threshold, limit)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to list %s queries of knowledge base %s: %v", kind, knowledgeBaseID, err)
+			return nil, err
+		}
+	}
+	return analytics, nil
+}
+
+// hashSearchQuery hashes a query after normalizing case and whitespace so repeats group together
+func hashSearchQuery(query string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
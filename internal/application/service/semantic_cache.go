@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// semanticCacheKeyPrefix prefixes the Redis hash holding the cached answers of a knowledge base
+const semanticCacheKeyPrefix = "semantic_cache:"
+
+// maxSemanticCacheEntries caps the answers cached per knowledge base, the oldest are evicted first
+const maxSemanticCacheEntries = 1000
+
+// semanticCache implements interfaces.SemanticCache with one Redis hash per knowledge base.
+// Lookups scan the hash, which is fine for the bounded number of entries kept per knowledge base.
+type semanticCache struct {
+	redisClient *redis.Client
+}
+
+// NewSemanticCache creates a Redis-backed semantic answer cache
+func NewSemanticCache(redisClient *redis.Client) interfaces.SemanticCache {
+	return &semanticCache{redisClient: redisClient}
+}
+
+func (c *semanticCache) key(knowledgeBaseID string) string {
+	return semanticCacheKeyPrefix + knowledgeBaseID
+}
+
+// loadEntries reads all entries of a knowledge base and removes the expired ones
+func (c *semanticCache) loadEntries(ctx context.Context, knowledgeBaseID string) ([]*types.SemanticCacheEntry, error) {
+	key := c.key(knowledgeBaseID)
+	values, err := c.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entries := make([]*types.SemanticCacheEntry, 0, len(values))
+	var expired []string
+	for id, value := range values {
+		var entry types.SemanticCacheEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil || now.After(entry.ExpiresAt) {
+			expired = append(expired, id)
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	if len(expired) > 0 {
+		if err := c.redisClient.HDel(ctx, key, expired...).Err(); err != nil {
+			logger.Warnf(ctx, "Failed to evict expired semantic cache entries of knowledge base %s: %v",
+				knowledgeBaseID, err)
+		}
+	}
+	return entries, nil
+}
+
+// Lookup returns the most similar unexpired entry scoring at least threshold
+func (c *semanticCache) Lookup(ctx context.Context, knowledgeBaseID string, embeddingModelID string,
+	embedding []float32, threshold float64,
+) (*types.SemanticCacheEntry, float64, error) {
+	entries, err := c.loadEntries(ctx, knowledgeBaseID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var best *types.SemanticCacheEntry
+	bestScore := threshold
+	for _, entry := range entries {
+		if entry.EmbeddingModelID != embeddingModelID {
+			continue
+		}
+		if score := cosineSimilarity(embedding, entry.Embedding); score >= bestScore {
+			best, bestScore = entry, score
+		}
+	}
+	if best == nil {
+		return nil, 0, nil
+	}
+	return best, bestScore, nil
+}
+
+// Store caches an answer for ttl, evicting the oldest entries beyond maxSemanticCacheEntries
+func (c *semanticCache) Store(ctx context.Context,
+	knowledgeBaseID string, entry *types.SemanticCacheEntry, ttl time.Duration,
+) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	entry.CreatedAt = time.Now()
+	entry.ExpiresAt = entry.CreatedAt.Add(ttl)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal semantic cache entry: %w", err)
+	}
+
+	key := c.key(knowledgeBaseID)
+	if err := c.redisClient.HSet(ctx, key, entry.ID, data).Err(); err != nil {
+		return err
+	}
+	// The hash lives as long as its newest entry
+	if err := c.redisClient.Expire(ctx, key, ttl).Err(); err != nil {
+		return err
+	}
+
+	count, err := c.redisClient.HLen(ctx, key).Result()
+	if err != nil || count <= maxSemanticCacheEntries {
+		return err
+	}
+	entries, err := c.loadEntries(ctx, knowledgeBaseID)
+	if err != nil || len(entries) <= maxSemanticCacheEntries {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	evicted := make([]string, 0, len(entries)-maxSemanticCacheEntries)
+	for _, e := range entries[:len(entries)-maxSemanticCacheEntries] {
+		evicted = append(evicted, e.ID)
+	}
+	return c.redisClient.HDel(ctx, key, evicted...).Err()
+}
+
+// InvalidateKnowledge drops cached answers built from any of the given knowledge
+func (c *semanticCache) InvalidateKnowledge(ctx context.Context, knowledgeBaseID string, knowledgeIDs []string) error {
+	if len(knowledgeIDs) == 0 {
+		return nil
+	}
+	entries, err := c.loadEntries(ctx, knowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for _, entry := range entries {
+		for _, id := range entry.KnowledgeIDs {
+			if slices.Contains(knowledgeIDs, id) {
+				stale = append(stale, entry.ID)
+				break
+			}
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	logger.Infof(ctx, "Invalidating %d semantic cache entries of knowledge base %s", len(stale), knowledgeBaseID)
+	return c.redisClient.HDel(ctx, c.key(knowledgeBaseID), stale...).Err()
+}
+
+// InvalidateKnowledgeBase drops all cached answers of a knowledge base
+func (c *semanticCache) InvalidateKnowledgeBase(ctx context.Context, knowledgeBaseID string) error {
+	return c.redisClient.Del(ctx, c.key(knowledgeBaseID)).Err()
+}
+
+// invalidateSemanticCache drops cached answers built from the given knowledge,
+// failures are only logged since stale entries still expire with their TTL
+func (s *knowledgeService) invalidateSemanticCache(ctx context.Context, knowledgeList []*types.Knowledge) {
+	byKB := make(map[string][]string)
+	for _, knowledge := range knowledgeList {
+		byKB[knowledge.KnowledgeBaseID] = append(byKB[knowledge.KnowledgeBaseID], knowledge.ID)
+	}
+	for kbID, knowledgeIDs := range byKB {
+		if err := s.semanticCache.InvalidateKnowledge(ctx, kbID, knowledgeIDs); err != nil {
+			logger.Warnf(ctx, "Failed to invalidate semantic cache of knowledge base %s: %v", kbID, err)
+		}
+	}
+}
+
+// invalidateKnowledgeBaseSemanticCache drops all cached answers of a knowledge base whose retrievable content grew,
+// failures are only logged since stale entries still expire with their TTL
+func (s *knowledgeService) invalidateKnowledgeBaseSemanticCache(ctx context.Context, kbID string) {
+	if err := s.semanticCache.InvalidateKnowledgeBase(ctx, kbID); err != nil {
+		logger.Warnf(ctx, "Failed to invalidate semantic cache of knowledge base %s: %v", kbID, err)
+	}
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, 0 when they are not comparable
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"opposite", []float32{1, 1}, []float32{-1, -1}, -1},
+		{"length mismatch", []float32{1, 2}, []float32{1, 2, 3}, 0},
+		{"zero vector", []float32{0, 0}, []float32{1, 1}, 0},
+	}
+	for _, c := range cases {
+		if got := cosineSimilarity(c.a, c.b); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("%s: cosineSimilarity = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
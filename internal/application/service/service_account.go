@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// serviceAccountService implements ServiceAccountService interface
+type serviceAccountService struct {
+	repo       interfaces.ServiceAccountRepository
+	apiKeyRepo interfaces.APIKeyRepository
+}
+
+// NewServiceAccountService creates a new service account service
+func NewServiceAccountService(
+	repo interfaces.ServiceAccountRepository,
+	apiKeyRepo interfaces.APIKeyRepository,
+) interfaces.ServiceAccountService {
+	return &serviceAccountService{repo: repo, apiKeyRepo: apiKeyRepo}
+}
+
+// newServiceAccountID generates the ID of a service account, short enough for the columns holding user IDs
+func newServiceAccountID() string {
+	return types.ServiceAccountIDPrefix + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// CreateServiceAccount creates a service account for the tenant in the context
+func (s *serviceAccountService) CreateServiceAccount(ctx context.Context,
+	req *types.CreateServiceAccountRequest,
+) (*types.ServiceAccount, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	scopes, err := serviceAccountScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	createdBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	account := &types.ServiceAccount{
+		ID:          newServiceAccountID(),
+		TenantID:    tenantID,
+		Name:        req.Name,
+		Description: req.Description,
+		Scopes:      scopes,
+		CreatedBy:   createdBy,
+	}
+	if err := s.repo.CreateServiceAccount(ctx, account); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+	logger.Infof(ctx, "Created service account %s with scopes %v for tenant %d", account.ID, scopes, tenantID)
+	return account, nil
+}
+
+// ListServiceAccounts lists the service accounts of the tenant in the context
+func (s *serviceAccountService) ListServiceAccounts(ctx context.Context) ([]*types.ServiceAccount, error) {
+	return s.repo.ListServiceAccounts(ctx, ctx.Value(types.TenantIDContextKey).(uint64))
+}
+
+// GetServiceAccount gets a service account with its API keys
+func (s *serviceAccountService) GetServiceAccount(ctx context.Context, id string) (*types.ServiceAccount, error) {
+	account, err := s.getServiceAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	account.Keys, err = s.apiKeyRepo.ListAPIKeysByServiceAccount(ctx, account.TenantID, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// UpdateServiceAccount updates the name, description, scopes or status of a service account
+func (s *serviceAccountService) UpdateServiceAccount(ctx context.Context,
+	id string, req *types.UpdateServiceAccountRequest,
+) (*types.ServiceAccount, error) {
+	account, err := s.getServiceAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, werrors.NewBadRequestError("Name cannot be empty")
+		}
+		account.Name = *req.Name
+	}
+	if req.Description != nil {
+		account.Description = *req.Description
+	}
+	if req.Scopes != nil {
+		// Keys with a scope the account no longer has stop authenticating, see apiKeyService.Authenticate
+		if account.Scopes, err = serviceAccountScopes(*req.Scopes); err != nil {
+			return nil, err
+		}
+	}
+	if req.Disabled != nil {
+		account.Disabled = *req.Disabled
+	}
+	if err := s.repo.UpdateServiceAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Updated service account %s of tenant %d, scopes %v, disabled %v",
+		account.ID, account.TenantID, account.Scopes, account.Disabled)
+	return account, nil
+}
+
+// DeleteServiceAccount deletes a service account and revokes its API keys
+func (s *serviceAccountService) DeleteServiceAccount(ctx context.Context, id string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := s.repo.DeleteServiceAccount(ctx, tenantID, id); err != nil {
+		if errors.Is(err, repository.ErrServiceAccountNotFound) {
+			return werrors.NewNotFoundError("Service account not found")
+		}
+		return err
+	}
+	if err := s.apiKeyRepo.DeleteAPIKeysByServiceAccount(ctx, tenantID, id); err != nil {
+		// The keys no longer authenticate without their account
+		logger.Warnf(ctx, "Failed to revoke API keys of deleted service account %s: %v", id, err)
+	}
+	logger.Infof(ctx, "Deleted service account %s of tenant %d", id, tenantID)
+	return nil
+}
+
+// getServiceAccount gets a service account of the tenant in the context
+func (s *serviceAccountService) getServiceAccount(ctx context.Context, id string) (*types.ServiceAccount, error) {
+	account, err := s.repo.GetServiceAccountByID(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrServiceAccountNotFound) {
+			return nil, werrors.NewNotFoundError("Service account not found")
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+// serviceAccountScopes validates the scopes granted to a service account and removes duplicates
+func serviceAccountScopes(scopes []types.APIKeyScope) (types.StringArray, error) {
+	if len(scopes) == 0 {
+		return nil, werrors.NewBadRequestError("At least one scope is required")
+	}
+	result := make(types.StringArray, 0, len(scopes))
+	for _, scope := range scopes {
+		if !scope.IsValidForServiceAccount() {
+			return nil, werrors.NewBadRequestError("Service account scopes must be retrieval or ingest")
+		}
+		if !slices.Contains(result, string(scope)) {
+			result = append(result, string(scope))
+		}
+	}
+	return result, nil
+}
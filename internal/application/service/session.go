@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/agent/tools"
 	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
@@ -44,6 +46,18 @@ type sessionService struct {
 	chunkService         interfaces.ChunkService          // Service for chunk operations
 	webSearchStateRepo   interfaces.WebSearchStateService // Service for web search state
 	kbShareService       interfaces.KBShareService        // Service for KB sharing operations
+
+	// searchAnalytics records searches for knowledge base search analytics
+	searchAnalytics interfaces.SearchAnalyticsService
+
+	// experiments routes chat requests to the variants of knowledge base A/B experiments
+	experiments interfaces.ExperimentService
+
+	// promptTemplates holds the published prompt templates of knowledge bases
+	promptTemplates interfaces.PromptTemplateRepository
+
+	// kbPermissionService resolves access to knowledge bases of other tenants, through shares and granted roles
+	kbPermissionService interfaces.KBPermissionService
 }
 
 // NewSessionService creates a new session service instance with all required dependencies
@@ -60,6 +74,10 @@ func NewSessionService(cfg *config.Config,
 	sessionStorage llmcontext.ContextStorage,
 	webSearchStateRepo interfaces.WebSearchStateService,
 	kbShareService interfaces.KBShareService,
+	searchAnalytics interfaces.SearchAnalyticsService,
+	experiments interfaces.ExperimentService,
+	promptTemplates interfaces.PromptTemplateRepository,
+	kbPermissionService interfaces.KBPermissionService,
 ) interfaces.SessionService {
 	return &sessionService{
 		cfg:                  cfg,
@@ -75,6 +93,10 @@ func NewSessionService(cfg *config.Config,
 		sessionStorage:       sessionStorage,
 		webSearchStateRepo:   webSearchStateRepo,
 		kbShareService:       kbShareService,
+		searchAnalytics:      searchAnalytics,
+		experiments:          experiments,
+		promptTemplates:      promptTemplates,
+		kbPermissionService:  kbPermissionService,
 	}
 }
 
@@ -169,6 +191,23 @@ func (s *sessionService) GetPagedSessionsByTenant(ctx context.Context,
 	return types.NewPageResult(total, pagination, sessions), nil
 }
 
+// ListSessionPageByTenant retrieves a page of the sessions of the current tenant by page number or cursor
+func (s *sessionService) ListSessionPageByTenant(ctx context.Context,
+	query *types.ListQuery,
+) (*types.CursorPageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	sessions, total, err := s.sessionRepo.ListPageByTenantID(ctx, tenantID, query)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"tenant_id": tenantID,
+			"sort":      query.Sort,
+		})
+		return nil, err
+	}
+
+	return types.NewCursorPageResult(sessions, total, query), nil
+}
+
 // UpdateSession updates an existing session's properties
 func (s *sessionService) UpdateSession(ctx context.Context, session *types.Session) error {
 	// Validate session ID
@@ -301,7 +340,7 @@ func (s *sessionService) GenerateTitle(ctx context.Context,
 	// Prepare messages for title generation
 	var chatMessages []chat.Message
 	chatMessages = append(chatMessages,
-		chat.Message{Role: "system", Content: s.cfg.Conversation.GenerateSessionTitlePrompt},
+		chat.Message{Role: "system", Content: s.cfg.Current().Conversation.GenerateSessionTitlePrompt},
 	)
 	chatMessages = append(chatMessages,
 		chat.Message{Role: "user", Content: message.Content},
@@ -406,6 +445,46 @@ func (s *sessionService) KnowledgeQA(
 	query string,
 	knowledgeBaseIDs []string,
 	knowledgeIDs []string,
+	searchFilter *types.SearchFilter,
+	assistantMessageID string,
+	summaryModelID string,
+	webSearchEnabled bool,
+	eventBus *event.EventBus,
+	customAgent *types.CustomAgent,
+) error {
+	return s.knowledgeQA(ctx, session, query, nil, knowledgeBaseIDs, knowledgeIDs, searchFilter,
+		assistantMessageID, summaryModelID, webSearchEnabled, eventBus, customAgent)
+}
+
+// KnowledgeQAWithHistory performs knowledge base question answering for a conversation kept by the caller
+// The history replaces the session's stored messages, so the session does not need to be persisted
+func (s *sessionService) KnowledgeQAWithHistory(
+	ctx context.Context,
+	session *types.Session,
+	query string,
+	history []*types.History,
+	knowledgeBaseIDs []string,
+	assistantMessageID string,
+	eventBus *event.EventBus,
+	customAgent *types.CustomAgent,
+) error {
+	if history == nil {
+		history = []*types.History{}
+	}
+	return s.knowledgeQA(ctx, session, query, history, knowledgeBaseIDs, nil, nil,
+		assistantMessageID, "", false, eventBus, customAgent)
+}
+
+// knowledgeQA runs knowledge base question answering
+// history is loaded from the session's messages when nil
+func (s *sessionService) knowledgeQA(
+	ctx context.Context,
+	session *types.Session,
+	query string,
+	history []*types.History,
+	knowledgeBaseIDs []string,
+	knowledgeIDs []string,
+	searchFilter *types.SearchFilter,
 	assistantMessageID string,
 	summaryModelID string,
 	webSearchEnabled bool,
@@ -446,28 +525,32 @@ func (s *sessionService) KnowledgeQA(
 	}
 
 	// Initialize default values from config.yaml
-	rewritePromptSystem := s.cfg.Conversation.RewritePromptSystem
-	rewritePromptUser := s.cfg.Conversation.RewritePromptUser
-	vectorThreshold := s.cfg.Conversation.VectorThreshold
-	keywordThreshold := s.cfg.Conversation.KeywordThreshold
-	embeddingTopK := s.cfg.Conversation.EmbeddingTopK
-	rerankTopK := s.cfg.Conversation.RerankTopK
-	rerankThreshold := s.cfg.Conversation.RerankThreshold
-	maxRounds := s.cfg.Conversation.MaxRounds
-	fallbackStrategy := types.FallbackStrategy(s.cfg.Conversation.FallbackStrategy)
-	fallbackResponse := s.cfg.Conversation.FallbackResponse
-	fallbackPrompt := s.cfg.Conversation.FallbackPrompt
-	enableRewrite := s.cfg.Conversation.EnableRewrite
-	enableQueryExpansion := s.cfg.Conversation.EnableQueryExpansion
+	conversation := s.cfg.Current().Conversation
+	rewritePromptSystem := conversation.RewritePromptSystem
+	rewritePromptUser := conversation.RewritePromptUser
+	vectorThreshold := conversation.VectorThreshold
+	keywordThreshold := conversation.KeywordThreshold
+	embeddingTopK := conversation.EmbeddingTopK
+	rerankTopK := conversation.RerankTopK
+	rerankThreshold := conversation.RerankThreshold
+	maxRounds := conversation.MaxRounds
+	fallbackStrategy := types.FallbackStrategy(conversation.FallbackStrategy)
+	fallbackResponse := conversation.FallbackResponse
+	fallbackPrompt := conversation.FallbackPrompt
+	enableRewrite := conversation.EnableRewrite
+	enableQueryExpansion := conversation.EnableQueryExpansion
+	enableLLMExpansion := conversation.EnableLLMExpansion
+	enableHyDE := conversation.EnableHyDE
+	enableFollowUps := conversation.EnableFollowUps
 	rerankModelID := ""
 
 	summaryConfig := types.SummaryConfig{
-		Prompt:              s.cfg.Conversation.Summary.Prompt,
-		ContextTemplate:     s.cfg.Conversation.Summary.ContextTemplate,
-		Temperature:         s.cfg.Conversation.Summary.Temperature,
-		NoMatchPrefix:       s.cfg.Conversation.Summary.NoMatchPrefix,
-		MaxCompletionTokens: s.cfg.Conversation.Summary.MaxCompletionTokens,
-		Thinking:            s.cfg.Conversation.Summary.Thinking,
+		Prompt:              conversation.Summary.Prompt,
+		ContextTemplate:     conversation.Summary.ContextTemplate,
+		Temperature:         conversation.Summary.Temperature,
+		NoMatchPrefix:       conversation.Summary.NoMatchPrefix,
+		MaxCompletionTokens: conversation.Summary.MaxCompletionTokens,
+		Thinking:            conversation.Summary.Thinking,
 	}
 
 	// Set default fallback strategy if not set
@@ -535,6 +618,9 @@ func (s *sessionService) KnowledgeQA(
 		// Override rewrite settings
 		enableRewrite = customAgent.Config.EnableRewrite
 		enableQueryExpansion = customAgent.Config.EnableQueryExpansion
+		enableLLMExpansion = customAgent.Config.EnableLLMExpansion
+		enableHyDE = customAgent.Config.EnableHyDE
+		enableFollowUps = customAgent.Config.EnableFollowUps
 		if customAgent.Config.RewritePromptSystem != "" {
 			rewritePromptSystem = customAgent.Config.RewritePromptSystem
 		}
@@ -562,6 +648,10 @@ func (s *sessionService) KnowledgeQA(
 			logger.Infof(ctx, "Multi-turn disabled by custom agent, clearing history")
 		}
 	}
+	// The request may ask for follow-up questions or turn them off regardless of the agent
+	if suggest, ok := ctx.Value(types.FollowUpsContextKey).(bool); ok {
+		enableFollowUps = suggest
+	}
 
 	// Extract FAQ strategy settings from custom agent
 	var faqPriorityEnabled bool
@@ -611,6 +701,7 @@ func (s *sessionService) KnowledgeQA(
 		MessageID:            assistantMessageID, // NEW: For event emission in pipeline
 		KnowledgeBaseIDs:     knowledgeBaseIDs,   // Multi-KB support
 		KnowledgeIDs:         knowledgeIDs,       // Specific knowledge (file) IDs
+		SearchFilter:         searchFilter,       // Structured retrieval filter
 		SearchTargets:        searchTargets,      // Pre-computed search targets
 		VectorThreshold:      vectorThreshold,
 		KeywordThreshold:     keywordThreshold,
@@ -631,11 +722,22 @@ func (s *sessionService) KnowledgeQA(
 		RewritePromptUser:    rewritePromptUser,
 		EnableRewrite:        enableRewrite,
 		EnableQueryExpansion: enableQueryExpansion,
+		EnableLLMExpansion:   enableLLMExpansion,
+		EnableHyDE:           enableHyDE,
+		EnableFollowUps:      enableFollowUps,
 		// FAQ Strategy Settings
 		FAQPriorityEnabled:       faqPriorityEnabled,
 		FAQDirectAnswerThreshold: faqDirectAnswerThreshold,
 		FAQScoreBoost:            faqScoreBoost,
 	}
+	if history != nil {
+		// Keep the most recent rounds of the caller's conversation
+		if len(history) > maxRounds {
+			history = history[len(history)-max(maxRounds, 0):]
+		}
+		chatManage.History = history
+		chatManage.ExternalHistory = true
+	}
 
 	// Determine pipeline based on knowledge bases availability and web search setting
 	// If no knowledge bases are selected AND web search is disabled, use pure chat pipeline
@@ -663,9 +765,18 @@ func (s *sessionService) KnowledgeQA(
 		pipeline = types.Pipline["rag_stream"]
 	}
 
+	// Questions against a single knowledge base are answered with its published prompt template,
+	// a live A/B experiment may override it in turn
+	s.applyPromptTemplate(ctx, chatManage)
+
+	// Route questions against a single knowledge base to its live A/B experiment
+	s.applyExperiment(ctx, session.ID, chatManage)
+
 	// Start knowledge QA event processing (set session tenant so pipeline session/message lookups use session owner)
 	ctx = context.WithValue(ctx, types.SessionTenantIDContextKey, session.TenantID)
+	ctx = withTokenUsageScope(ctx, session.ID, searchTargets.GetAllKnowledgeBaseIDs())
 	logger.Info(ctx, "Triggering question answering event")
+	start := time.Now()
 	err = s.KnowledgeQAByEvent(ctx, chatManage, pipeline)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
@@ -673,6 +784,18 @@ func (s *sessionService) KnowledgeQA(
 		})
 		return err
 	}
+	if len(searchTargets) > 0 {
+		s.searchAnalytics.RecordSearch(ctx, &types.SearchRecord{
+			Source:           types.SearchLogSourceChat,
+			SessionID:        session.ID,
+			MessageID:        assistantMessageID,
+			Query:            query,
+			KnowledgeBaseIDs: searchTargets.GetAllKnowledgeBaseIDs(),
+			Results:          chatManage.MergeResult,
+			Latency:          time.Since(start),
+			Experiment:       chatManage.Experiment,
+		})
+	}
 
 	// Emit references event if we have search results
 	if len(chatManage.MergeResult) > 0 {
@@ -864,6 +987,19 @@ func (s *sessionService) resolveKnowledgeBasesFromAgent(
 				}
 			}
 		}
+		// And the knowledge bases of other tenants the user was granted a role on
+		if userID, _ := userIDVal.(string); userID != "" && s.kbPermissionService != nil {
+			grantedList, err := s.kbPermissionService.ListGrantedKnowledgeBases(ctx, userID, tenantID)
+			if err != nil {
+				logger.Warnf(ctx, "Failed to list granted knowledge bases: %v", err)
+			}
+			for _, info := range grantedList {
+				if !kbIDSet[info.KnowledgeBase.ID] {
+					kbIDs = append(kbIDs, info.KnowledgeBase.ID)
+					kbIDSet[info.KnowledgeBase.ID] = true
+				}
+			}
+		}
 
 		logger.Infof(ctx, "KBSelectionMode=all: loaded %d knowledge bases (own + shared)", len(kbIDs))
 		return kbIDs
@@ -950,6 +1086,14 @@ func (s *sessionService) buildSearchTargets(
 ) (types.SearchTargets, error) {
 	var targets types.SearchTargets
 
+	// Scoped API keys restricted to some knowledge bases search only those, whichever ones the agent names
+	apiKey := types.APIKeyFromContext(ctx)
+	if apiKey != nil {
+		knowledgeBaseIDs = slices.DeleteFunc(slices.Clone(knowledgeBaseIDs), func(kbID string) bool {
+			return !apiKey.AllowsKnowledgeBase(kbID)
+		})
+	}
+
 	// Build a map from KB ID to TenantID for all KBs we need to process
 	kbTenantMap := make(map[string]uint64)
 
@@ -973,10 +1117,11 @@ func (s *sessionService) buildSearchTargets(
 				kbTenantMap[kbID] = tenantID
 			} else if kb.TenantID == tenantID {
 				kbTenantMap[kbID] = tenantID
-			} else if s.kbShareService != nil && userID != "" {
-				hasAccess, _ := s.kbShareService.HasKBPermission(ctx, kbID, userID, types.OrgRoleViewer)
-				if hasAccess {
-					kbTenantMap[kbID] = kb.TenantID
+			} else if s.kbPermissionService != nil && userID != "" {
+				// Shares and granted roles, including those from invite links, give access to other tenants' data
+				role, effectiveTenantID, _ := s.kbPermissionService.ResolveKBRole(ctx, kb, tenantID, userID)
+				if role.HasPermission(types.KBRoleViewer) {
+					kbTenantMap[kbID] = effectiveTenantID
 				} else {
 					kbTenantMap[kbID] = tenantID
 				}
@@ -1006,6 +1151,9 @@ func (s *sessionService) buildSearchTargets(
 			if k == nil || k.KnowledgeBaseID == "" {
 				continue
 			}
+			if apiKey != nil && !apiKey.AllowsKnowledgeBase(k.KnowledgeBaseID) {
+				continue
+			}
 			// Track KB -> TenantID mapping from knowledge items
 			if kbTenantMap[k.KnowledgeBaseID] == 0 {
 				kbTenantMap[k.KnowledgeBaseID] = k.TenantID
@@ -1032,6 +1180,28 @@ func (s *sessionService) buildSearchTargets(
 		}
 	}
 
+	// Documents hidden from the user by a document permission are excluded by the agent tools too
+	if userID, _ := ctx.Value(types.UserIDContextKey).(string); userID != "" && s.kbPermissionService != nil {
+		visible := targets[:0]
+		for _, target := range targets {
+			hidden, err := s.kbPermissionService.ListHiddenKnowledgeIDs(ctx, target.KnowledgeBaseID, userID)
+			if err != nil {
+				return nil, err
+			}
+			target.HiddenKnowledgeIDs = hidden
+			if target.Type == types.SearchTargetTypeKnowledge {
+				target.KnowledgeIDs = slices.DeleteFunc(target.KnowledgeIDs, func(id string) bool {
+					return slices.Contains(hidden, id)
+				})
+				if len(target.KnowledgeIDs) == 0 {
+					continue
+				}
+			}
+			visible = append(visible, target)
+		}
+		targets = visible
+	}
+
 	logger.Infof(ctx, "Built %d search targets: %d full KB, %d partial KB, kbTenantMap=%v",
 		len(targets), len(knowledgeBaseIDs), len(targets)-len(knowledgeBaseIDs), kbTenantMap)
 
@@ -1080,6 +1250,12 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 			return nil
 		}
 
+		// The answer was already emitted from the semantic cache
+		if err == chatpipline.ErrSemanticCacheHit {
+			logger.Infof(ctx, "Event %v answered from semantic cache", eventType)
+			return nil
+		}
+
 		// Handle other errors
 		if err != nil {
 			logger.Errorf(ctx, "Event triggering failed, event: %v, error type: %s, description: %s, error: %v",
@@ -1099,10 +1275,12 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 // SearchKnowledge performs knowledge base search without LLM summarization
 // knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 // knowledgeIDs: list of specific knowledge (file) IDs to search
+// searchFilter: optional structured filter applied to every knowledge base
 func (s *sessionService) SearchKnowledge(ctx context.Context,
-	knowledgeBaseIDs []string, knowledgeIDs []string, query string,
+	knowledgeBaseIDs []string, knowledgeIDs []string, searchFilter *types.SearchFilter, query string,
 ) ([]*types.SearchResult, error) {
 	logger.Info(ctx, "Start knowledge base search without LLM summary")
+	start := time.Now()
 	logger.Infof(ctx, "Knowledge base search parameters, knowledge base IDs: %v, knowledge IDs: %v, query: %s",
 		knowledgeBaseIDs, knowledgeIDs, query)
 
@@ -1125,18 +1303,20 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 	}
 
 	// Create default retrieval parameters
+	conversation := s.cfg.Current().Conversation
 	chatManage := &types.ChatManage{
 		Query:            query,
 		RewriteQuery:     query,
 		KnowledgeBaseIDs: knowledgeBaseIDs,
 		KnowledgeIDs:     knowledgeIDs,
+		SearchFilter:     searchFilter,
 		SearchTargets:    searchTargets,
-		VectorThreshold:  s.cfg.Conversation.VectorThreshold,  // Use default configuration
-		KeywordThreshold: s.cfg.Conversation.KeywordThreshold, // Use default configuration
-		EmbeddingTopK:    s.cfg.Conversation.EmbeddingTopK,    // Use default configuration
-		RerankTopK:       s.cfg.Conversation.RerankTopK,       // Use default configuration
-		RerankThreshold:  s.cfg.Conversation.RerankThreshold,  // Use default configuration
-		MaxRounds:        s.cfg.Conversation.MaxRounds,
+		VectorThreshold:  conversation.VectorThreshold,  // Use default configuration
+		KeywordThreshold: conversation.KeywordThreshold, // Use default configuration
+		EmbeddingTopK:    conversation.EmbeddingTopK,    // Use default configuration
+		RerankTopK:       conversation.RerankTopK,       // Use default configuration
+		RerankThreshold:  conversation.RerankThreshold,  // Use default configuration
+		MaxRounds:        conversation.MaxRounds,
 	}
 
 	// Get default models
@@ -1191,6 +1371,12 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 		// Handle case where search returns no results
 		if err == chatpipline.ErrSearchNothing {
 			logger.Warnf(ctx, "Event %v triggered, search result is empty", event)
+			s.searchAnalytics.RecordSearch(ctx, &types.SearchRecord{
+				Source:           types.SearchLogSourceSearch,
+				Query:            query,
+				KnowledgeBaseIDs: searchTargets.GetAllKnowledgeBaseIDs(),
+				Latency:          time.Since(start),
+			})
 			return []*types.SearchResult{}, nil
 		}
 
@@ -1207,6 +1393,13 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 	}
 
 	logger.Infof(ctx, "Knowledge base search completed, found %d results", len(chatManage.MergeResult))
+	s.searchAnalytics.RecordSearch(ctx, &types.SearchRecord{
+		Source:           types.SearchLogSourceSearch,
+		Query:            query,
+		KnowledgeBaseIDs: searchTargets.GetAllKnowledgeBaseIDs(),
+		Results:          chatManage.MergeResult,
+		Latency:          time.Since(start),
+	})
 	return chatManage.MergeResult, nil
 }
 
@@ -1445,6 +1638,7 @@ func (s *sessionService) AgentQA(
 	// Execute agent with streaming (asynchronously)
 	// Events will be emitted to EventBus and handled by the Handler layer
 	logger.Info(ctx, "Executing agent with streaming")
+	ctx = withTokenUsageScope(ctx, sessionID, searchTargets.GetAllKnowledgeBaseIDs())
 	if _, err := engine.Execute(ctx, sessionID, assistantMessageID, query, llmContext); err != nil {
 		logger.Errorf(ctx, "Agent execution failed: %v", err)
 		// Emit error event to the EventBus used by this agent
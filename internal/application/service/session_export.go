@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// sessionShareTokenType is the type claim of share link tokens, distinguishing them from login tokens
+	sessionShareTokenType = "session_share"
+	// exportMessagePageSize is the page size used to load the messages of an exported session
+	exportMessagePageSize = 200
+	// DefaultSessionShareTTL is how long share links stay valid when no expiry is requested
+	DefaultSessionShareTTL = 72 * time.Hour
+	// MaxSessionShareTTL is the longest a share link may stay valid
+	MaxSessionShareTTL = 30 * 24 * time.Hour
+)
+
+// ErrInvalidShareToken is returned for share links that are malformed, tampered with or expired
+var ErrInvalidShareToken = errors.New("invalid or expired share link")
+
+// ExportSession exports a session of the current tenant with the citations of its answers
+func (s *sessionService) ExportSession(ctx context.Context, id string) (*types.SessionExport, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	return s.exportSession(ctx, tenantID, id)
+}
+
+// ShareSession signs a read-only link to a session of the current tenant, valid for ttl
+func (s *sessionService) ShareSession(ctx context.Context,
+	id string, ttl time.Duration,
+) (*types.SessionShareLink, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.getTenantSession(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = DefaultSessionShareTTL
+	}
+	if ttl > MaxSessionShareTTL {
+		return nil, fmt.Errorf("share link expiry cannot exceed %d hours", int(MaxSessionShareTTL.Hours()))
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	// Share links are signed like login tokens, the type claim keeps them from being accepted as one
+	claims := jwt.MapClaims{
+		"session_id": id,
+		"tenant_id":  tenantID,
+		"exp":        expiresAt.Unix(),
+		"iat":        now.Unix(),
+		"type":       sessionShareTokenType,
+	}
+	token, err := signJwt(claims)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Session %s shared until %s", id, expiresAt.Format(time.RFC3339))
+	return &types.SessionShareLink{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// GetSharedSession exports the session a share link points to, no tenant context is needed
+func (s *sessionService) GetSharedSession(ctx context.Context, token string) (*types.SessionExport, error) {
+	parsed, err := jwt.Parse(token, jwtKeyFunc)
+	if err != nil || !parsed.Valid {
+		logger.Warnf(ctx, "Rejected share link: %v", err)
+		return nil, ErrInvalidShareToken
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["type"] != sessionShareTokenType {
+		return nil, ErrInvalidShareToken
+	}
+	sessionID, _ := claims["session_id"].(string)
+	tenantID, _ := claims["tenant_id"].(float64)
+	if sessionID == "" || tenantID <= 0 {
+		return nil, ErrInvalidShareToken
+	}
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, uint64(tenantID))
+	return s.exportSession(ctx, uint64(tenantID), sessionID)
+}
+
+// exportSession loads a session with all its messages
+func (s *sessionService) exportSession(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.SessionExport, error) {
+	session, err := s.getTenantSession(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*types.Message
+	for page := 1; ; page++ {
+		batch, err := s.messageRepo.GetMessagesBySession(ctx, id, page, exportMessagePageSize)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, batch...)
+		if len(batch) < exportMessagePageSize {
+			break
+		}
+	}
+	logger.Infof(ctx, "Exporting session %s with %d messages", id, len(messages))
+	return types.NewSessionExport(session, messages), nil
+}
+
+// getTenantSession gets a session of the tenant, reporting ErrSessionNotFound when it does not exist
+func (s *sessionService) getTenantSession(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.Session, error) {
+	session, err := s.sessionRepo.Get(ctx, tenantID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.ErrSessionNotFound
+	}
+	return session, err
+}
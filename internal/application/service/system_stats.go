@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// systemStatsErrorGroups is how many raw error messages are merged into the top errors. Messages differing only by
+// attempt counters or record IDs are grouped in the database separately, so more are read than returned.
+const systemStatsErrorGroups = 500
+
+// systemStatsService implements SystemStatsService interface
+type systemStatsService struct {
+	repo interfaces.SystemStatsRepository
+}
+
+// NewSystemStatsService creates a new system statistics service
+func NewSystemStatsService(repo interfaces.SystemStatsRepository) interfaces.SystemStatsService {
+	return &systemStatsService{repo: repo}
+}
+
+// GetOverview aggregates the statistics of every tenant over a period
+func (s *systemStatsService) GetOverview(ctx context.Context,
+	query *types.SystemStatsQuery,
+) (*types.SystemStatsOverview, error) {
+	overview, err := s.repo.GetOverview(ctx, query)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return nil, err
+	}
+	overview.ComputeFailureRate()
+	return overview, nil
+}
+
+// ListTenantStats lists the statistics of each tenant over a period, ranked by a column in descending order
+func (s *systemStatsService) ListTenantStats(ctx context.Context,
+	query *types.SystemStatsQuery, sort types.TenantStatsSort, page *types.Pagination,
+) (*types.PageResult, error) {
+	stats, total, err := s.repo.ListTenantStats(ctx, query, sort, page)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return nil, err
+	}
+	for _, stat := range stats {
+		stat.ComputeFailureRate()
+	}
+	return types.NewPageResult(total, page, stats), nil
+}
+
+// ListTopErrors lists the most frequent errors of background jobs over a period. Error messages are normalized
+// so retries and failures on different records of the same cause are counted together.
+func (s *systemStatsService) ListTopErrors(ctx context.Context,
+	query *types.SystemStatsQuery, tenantID uint64, limit int,
+) ([]*types.ErrorStat, error) {
+	raw, err := s.repo.ListJobErrors(ctx, query, tenantID, systemStatsErrorGroups)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return nil, err
+	}
+
+	type errorKey struct{ taskType, message string }
+	merged := make(map[errorKey]*types.ErrorStat)
+	for _, stat := range raw {
+		key := errorKey{stat.TaskType, types.NormalizeErrorMessage(stat.Error)}
+		existing, ok := merged[key]
+		if !ok {
+			stat.Error = key.message
+			merged[key] = stat
+			continue
+		}
+		existing.Count += stat.Count
+		// Tenants are counted per raw message, the largest count is a lower bound of the merged one
+		existing.Tenants = max(existing.Tenants, stat.Tenants)
+		if stat.LastSeenAt.After(existing.LastSeenAt) {
+			existing.LastSeenAt = stat.LastSeenAt
+		}
+	}
+
+	top := make([]*types.ErrorStat, 0, len(merged))
+	for _, stat := range merged {
+		top = append(top, stat)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].LastSeenAt.After(top[j].LastSeenAt)
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
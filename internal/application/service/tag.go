@@ -25,7 +25,7 @@ type knowledgeTagService struct {
 	chunkRepo      interfaces.ChunkRepository
 	retrieveEngine interfaces.RetrieveEngineRegistry
 	modelService   interfaces.ModelService
-	task           *asynq.Client
+	task           interfaces.TaskEnqueuer
 	kbShareService interfaces.KBShareService
 }
 
@@ -37,7 +37,7 @@ func NewKnowledgeTagService(
 	chunkRepo interfaces.ChunkRepository,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
 	modelService interfaces.ModelService,
-	task *asynq.Client,
+	task interfaces.TaskEnqueuer,
 	kbShareService interfaces.KBShareService,
 ) (interfaces.KnowledgeTagService, error) {
 	return &knowledgeTagService{
@@ -134,15 +134,126 @@ func (s *knowledgeTagService) ListTags(
 	return types.NewPageResult(total, page, results), nil
 }
 
+// GetTagTree returns all tags of a KB organized as a tree, with usage stats on every node.
+func (s *knowledgeTagService) GetTagTree(ctx context.Context, kbID string) ([]*types.KnowledgeTagNode, error) {
+	if kbID == "" {
+		return nil, werrors.NewBadRequestError("知识库ID不能为空")
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.repo.ListAllByKB(ctx, kb.TenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagIDs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	countsMap, err := s.repo.BatchCountReferences(ctx, kb.TenantID, kbID, tagIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*types.KnowledgeTagNode, len(tags))
+	for _, tag := range tags {
+		counts := countsMap[tag.ID]
+		nodes[tag.ID] = &types.KnowledgeTagNode{
+			KnowledgeTagWithStats: types.KnowledgeTagWithStats{
+				KnowledgeTag:   *tag,
+				KnowledgeCount: counts.KnowledgeCount,
+				ChunkCount:     counts.ChunkCount,
+			},
+			Children: []*types.KnowledgeTagNode{},
+		}
+	}
+
+	// Tags keep the repository ordering (sort_order, created_at) within each level.
+	// Tags whose parent no longer exists are treated as roots.
+	roots := make([]*types.KnowledgeTagNode, 0)
+	for _, tag := range tags {
+		node := nodes[tag.ID]
+		if parent, ok := nodes[tag.ParentID]; ok && tag.ParentID != tag.ID {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	return roots, nil
+}
+
+// validateParentTag checks that parentID can be used as the parent of tagID within kbID.
+// tagID is empty when creating a new tag. Returns the depth of the parent (1 for a root tag).
+func (s *knowledgeTagService) validateParentTag(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	tagID string,
+	parentID string,
+) (int, error) {
+	depth := 0
+	for current := parentID; current != ""; {
+		if current == tagID {
+			return 0, werrors.NewBadRequestError("不能将标签移动到其自身或子标签下")
+		}
+		parent, err := s.repo.GetByID(ctx, tenantID, current)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, werrors.NewNotFoundError("父标签不存在")
+			}
+			return 0, err
+		}
+		if parent.KnowledgeBaseID != kbID {
+			return 0, werrors.NewBadRequestError("父标签不属于当前知识库")
+		}
+		depth++
+		if depth > types.MaxTagDepth {
+			return 0, werrors.NewBadRequestError("标签层级过深")
+		}
+		current = parent.ParentID
+	}
+	return depth, nil
+}
+
+// subtreeHeight returns the height of the subtree rooted at tagID (1 for a leaf).
+func (s *knowledgeTagService) subtreeHeight(ctx context.Context, tenantID uint64, kbID string, tagID string) (int, error) {
+	tags, err := s.repo.ListAllByKB(ctx, tenantID, kbID)
+	if err != nil {
+		return 0, err
+	}
+	children := make(map[string][]string, len(tags))
+	for _, tag := range tags {
+		children[tag.ParentID] = append(children[tag.ParentID], tag.ID)
+	}
+	var height func(id string, level int) int
+	height = func(id string, level int) int {
+		if level > types.MaxTagDepth {
+			return level
+		}
+		maxHeight := 1
+		for _, child := range children[id] {
+			if h := 1 + height(child, level+1); h > maxHeight {
+				maxHeight = h
+			}
+		}
+		return maxHeight
+	}
+	return height(tagID, 1), nil
+}
+
 // CreateTag creates a new tag under a KB.
 func (s *knowledgeTagService) CreateTag(
 	ctx context.Context,
 	kbID string,
+	parentID string,
 	name string,
 	color string,
 	sortOrder int,
 ) (*types.KnowledgeTag, error) {
 	name = strings.TrimSpace(name)
+	parentID = strings.TrimSpace(parentID)
 	if kbID == "" || name == "" {
 		return nil, werrors.NewBadRequestError("知识库ID和标签名称不能为空")
 	}
@@ -151,6 +262,16 @@ func (s *knowledgeTagService) CreateTag(
 		return nil, err
 	}
 
+	if parentID != "" {
+		depth, err := s.validateParentTag(ctx, kb.TenantID, kbID, "", parentID)
+		if err != nil {
+			return nil, err
+		}
+		if depth >= types.MaxTagDepth {
+			return nil, werrors.NewBadRequestError("标签层级过深")
+		}
+	}
+
 	// Check if tag with same name already exists
 	existingTag, err := s.repo.GetByName(ctx, kb.TenantID, kbID, name)
 	if err == nil && existingTag != nil {
@@ -169,6 +290,7 @@ func (s *knowledgeTagService) CreateTag(
 		ID:              uuid.New().String(),
 		TenantID:        kb.TenantID,
 		KnowledgeBaseID: kb.ID,
+		ParentID:        parentID,
 		Name:            name,
 		Color:           strings.TrimSpace(color),
 		SortOrder:       sortOrder,
@@ -218,6 +340,41 @@ func (s *knowledgeTagService) UpdateTag(
 	return tag, nil
 }
 
+// MoveTag moves a tag (with its subtree) under a new parent tag, or to the root when parentID is empty.
+func (s *knowledgeTagService) MoveTag(ctx context.Context, id string, parentID string) (*types.KnowledgeTag, error) {
+	if id == "" {
+		return nil, werrors.NewBadRequestError("标签ID不能为空")
+	}
+	parentID = strings.TrimSpace(parentID)
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	tag, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if tag.ParentID == parentID {
+		return tag, nil
+	}
+
+	depth, err := s.validateParentTag(ctx, tenantID, tag.KnowledgeBaseID, tag.ID, parentID)
+	if err != nil {
+		return nil, err
+	}
+	height, err := s.subtreeHeight(ctx, tenantID, tag.KnowledgeBaseID, tag.ID)
+	if err != nil {
+		return nil, err
+	}
+	if depth+height > types.MaxTagDepth {
+		return nil, werrors.NewBadRequestError("标签层级过深")
+	}
+
+	tag.ParentID = parentID
+	tag.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
 // DeleteTag deletes a tag. When force=true, also deletes all chunks under this tag.
 // For document-type knowledge bases, also deletes all knowledge files under this tag.
 // When contentOnly=true, only deletes the content under the tag but keeps the tag itself.
@@ -336,6 +493,10 @@ func (s *knowledgeTagService) DeleteTag(ctx context.Context, id string, force bo
 	if len(excludeIDs) > 0 {
 		return nil
 	}
+	// Keep the subtree: children of the deleted tag move up one level
+	if err := s.repo.ReparentChildren(ctx, tenantID, tag.ID, tag.ParentID); err != nil {
+		return err
+	}
 	return s.repo.Delete(ctx, tenantID, id)
 }
 
@@ -393,23 +554,12 @@ func (s *knowledgeTagService) ProcessIndexDelete(ctx context.Context, t *asynq.T
 		return err
 	}
 
-	// Delete indices in batches to avoid overwhelming the backend
-	const batchSize = 100
-	chunkIDs := payload.ChunkIDs
-	dimension := embeddingModel.GetDimensions()
-
-	for i := 0; i < len(chunkIDs); i += batchSize {
-		end := i + batchSize
-		if end > len(chunkIDs) {
-			end = len(chunkIDs)
-		}
-		batch := chunkIDs[i:end]
-
-		if err := retrieveEngine.DeleteByChunkIDList(ctx, batch, dimension, payload.KBType); err != nil {
-			logger.Warnf(ctx, "Failed to delete indices for chunks batch [%d-%d]: %v", i, end, err)
-			return err
-		}
-		logger.Debugf(ctx, "Deleted indices batch [%d-%d] of %d chunks", i, end, len(chunkIDs))
+	// The engines split the IDs into batches sized by the vector_database config
+	if err := retrieveEngine.DeleteByChunkIDList(
+		ctx, payload.ChunkIDs, embeddingModel.GetDimensions(), payload.KBType,
+	); err != nil {
+		logger.Warnf(ctx, "Failed to delete indices for %d chunks: %v", len(payload.ChunkIDs), err)
+		return err
 	}
 
 	logger.Infof(ctx, "Successfully deleted indices for %d chunks", len(payload.ChunkIDs))
@@ -442,5 +592,5 @@ func (s *knowledgeTagService) FindOrCreateTagByName(ctx context.Context, kbID st
 	}
 
 	// 创建新标签
-	return s.CreateTag(ctx, kbID, name, "", 0)
+	return s.CreateTag(ctx, kbID, "", name, "", 0)
 }
@@ -13,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -52,7 +54,7 @@ func (s *tenantService) CreateTenant(ctx context.Context, tenant *types.Tenant)
 	logger.Infof(ctx, "Creating tenant, name: %s", tenant.Name)
 
 	// Create tenant with initial values
-	tenant.APIKey = s.generateApiKey(0)
+	tenant.APIKey = generateApiKey(0)
 	tenant.Status = "active"
 	tenant.CreatedAt = time.Now()
 	tenant.UpdatedAt = time.Now()
@@ -66,7 +68,7 @@ func (s *tenantService) CreateTenant(ctx context.Context, tenant *types.Tenant)
 	}
 
 	logger.Infof(ctx, "Tenant created successfully, ID: %d, generating official API Key", tenant.ID)
-	tenant.APIKey = s.generateApiKey(tenant.ID)
+	tenant.APIKey = generateApiKey(tenant.ID)
 	if err := s.repo.UpdateTenant(ctx, tenant); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"tenant_id":   tenant.ID,
@@ -118,10 +120,18 @@ func (s *tenantService) UpdateTenant(ctx context.Context, tenant *types.Tenant)
 
 	logger.Infof(ctx, "Updating tenant, ID: %d, name: %s", tenant.ID, tenant.Name)
 
+	if tenant.Locale != "" {
+		lang, ok := i18n.Parse(tenant.Locale)
+		if !ok {
+			return nil, apperrors.NewBadRequestError("不支持的语言: " + tenant.Locale)
+		}
+		tenant.Locale = string(lang)
+	}
+
 	// Generate new API key if empty
 	if tenant.APIKey == "" {
 		logger.Info(ctx, "API Key is empty, generating new API Key")
-		tenant.APIKey = s.generateApiKey(tenant.ID)
+		tenant.APIKey = generateApiKey(tenant.ID)
 	}
 
 	tenant.UpdatedAt = time.Now()
@@ -194,7 +204,7 @@ func (s *tenantService) UpdateAPIKey(ctx context.Context, id uint64) (string, er
 	}
 
 	logger.Infof(ctx, "Generating new API Key for tenant, ID: %d", id)
-	tenant.APIKey = s.generateApiKey(tenant.ID)
+	tenant.APIKey = generateApiKey(tenant.ID)
 
 	if err := s.repo.UpdateTenant(ctx, tenant); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
@@ -207,8 +217,8 @@ func (s *tenantService) UpdateAPIKey(ctx context.Context, id uint64) (string, er
 	return tenant.APIKey, nil
 }
 
-// generateApiKey generates a secure API key for tenant authentication
-func (r *tenantService) generateApiKey(tenantID uint64) string {
+// generateApiKey generates a secure API key for tenant authentication, which carries the encrypted tenant ID
+func generateApiKey(tenantID uint64) string {
 	// 1. Convert tenant_id to bytes
 	idBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(idBytes, uint64(tenantID))
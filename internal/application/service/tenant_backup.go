@@ -0,0 +1,759 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const (
+	// tenantBackupTimeout bounds a backup task, tenants with many files take a while to copy
+	tenantBackupTimeout = 4 * time.Hour
+	// tenantRestoreBatchSize is how many rows are inserted per statement on restore
+	tenantRestoreBatchSize = 500
+	// Entries read into memory on restore, files, vectors and the manifest, are capped at this size
+	tenantRestoreMaxEntrySize = 1 << 30
+	// tenantBackupMaxBufferedSize caps archives stored through a file service that cannot stream them
+	tenantBackupMaxBufferedSize = 512 << 20
+)
+
+// tenantBackupTable is a table holding rows of a tenant, where selects them with the @tenant argument
+type tenantBackupTable struct {
+	name  string
+	where string
+}
+
+// tenantBackupTables are the tables backed up, in restore order. Login sessions and tokens are left out so
+// users log in again, as are the job and webhook delivery history and the shared spaces, which span tenants.
+var tenantBackupTables = []tenantBackupTable{
+	{name: "tenants", where: "id = @tenant"},
+	{name: "users", where: "tenant_id = @tenant"},
+	{name: "service_accounts", where: "tenant_id = @tenant"},
+	{name: "api_keys", where: "tenant_id = @tenant"},
+	{name: "models", where: "tenant_id = @tenant"},
+	{name: "mcp_services", where: "tenant_id = @tenant"},
+	{name: "webhooks", where: "tenant_id = @tenant"},
+	{name: "knowledge_bases", where: "tenant_id = @tenant"},
+	{name: "kb_members", where: "knowledge_base_id IN (SELECT id FROM knowledge_bases WHERE tenant_id = @tenant)"},
+	{name: "kb_invites", where: "knowledge_base_id IN (SELECT id FROM knowledge_bases WHERE tenant_id = @tenant)"},
+	{name: "kb_prompt_templates", where: "tenant_id = @tenant"},
+	{name: "knowledge_tags", where: "tenant_id = @tenant"},
+	{name: "knowledges", where: "tenant_id = @tenant"},
+	{name: "knowledge_tag_relations", where: "tenant_id = @tenant"},
+	{name: "knowledge_permissions", where: "knowledge_base_id IN (SELECT id FROM knowledge_bases WHERE tenant_id = @tenant)"},
+	{name: "chunks", where: "tenant_id = @tenant"},
+	{name: "custom_agents", where: "tenant_id = @tenant"},
+	{name: "sessions", where: "tenant_id = @tenant"},
+	{name: "messages", where: "session_id IN (SELECT id FROM sessions WHERE tenant_id = @tenant)"},
+	{name: "answer_feedback", where: "tenant_id = @tenant"},
+	{name: "search_logs", where: "tenant_id = @tenant"},
+	{name: "retrieval_eval_sets", where: "tenant_id = @tenant"},
+	{name: "retrieval_eval_runs", where: "tenant_id = @tenant"},
+	{name: "kb_experiments", where: "tenant_id = @tenant"},
+	{name: "token_usage_records", where: "tenant_id = @tenant"},
+	{name: "moderation_events", where: "tenant_id = @tenant"},
+}
+
+// tenantBackupService implements TenantBackupService interface
+type tenantBackupService struct {
+	db               *gorm.DB
+	repo             interfaces.TenantBackupRepository
+	tenantRepo       interfaces.TenantRepository
+	knowledgeService interfaces.KnowledgeService
+	fileSvc          interfaces.FileService
+	task             interfaces.TaskEnqueuer
+}
+
+// NewTenantBackupService creates a new tenant backup service
+func NewTenantBackupService(
+	db *gorm.DB,
+	repo interfaces.TenantBackupRepository,
+	tenantRepo interfaces.TenantRepository,
+	knowledgeService interfaces.KnowledgeService,
+	fileSvc interfaces.FileService,
+	task interfaces.TaskEnqueuer,
+) interfaces.TenantBackupService {
+	return &tenantBackupService{
+		db:               db,
+		repo:             repo,
+		tenantRepo:       tenantRepo,
+		knowledgeService: knowledgeService,
+		fileSvc:          fileSvc,
+		task:             task,
+	}
+}
+
+// CreateBackup records a backup of a tenant and enqueues it
+func (s *tenantBackupService) CreateBackup(ctx context.Context, tenantID uint64) (*types.TenantBackup, error) {
+	if _, err := s.tenantRepo.GetTenantByID(ctx, tenantID); err != nil {
+		if errors.Is(err, repository.ErrTenantNotFound) {
+			return nil, werrors.NewNotFoundError("Tenant not found")
+		}
+		return nil, err
+	}
+	backup := &types.TenantBackup{
+		ID:            uuid.New().String(),
+		TenantID:      tenantID,
+		Status:        types.TenantBackupStatusPending,
+		FormatVersion: types.TenantBackupFormatVersion,
+		CreatedAt:     time.Now(),
+	}
+	if user, ok := ctx.Value(types.UserContextKey).(*types.User); ok && user != nil {
+		backup.CreatedBy = user.ID
+	}
+	if err := s.repo.CreateBackup(ctx, backup); err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(types.TenantBackupPayload{TenantID: tenantID, BackupID: backup.ID})
+	if err != nil {
+		return nil, err
+	}
+	task := asynq.NewTask(types.TypeTenantBackup, payload,
+		asynq.TaskID(backup.ID), asynq.Queue("low"), asynq.MaxRetry(2), asynq.Timeout(tenantBackupTimeout))
+	if _, err := s.task.Enqueue(task); err != nil {
+		backup.Status = types.TenantBackupStatusFailed
+		backup.Error = err.Error()
+		_ = s.repo.UpdateBackup(ctx, backup)
+		return nil, fmt.Errorf("failed to enqueue tenant backup: %w", err)
+	}
+	logger.Infof(ctx, "Tenant backup %s of tenant %d enqueued", backup.ID, tenantID)
+	return backup, nil
+}
+
+// ListBackups lists the backups of a tenant, or of every tenant when tenantID is 0, newest first
+func (s *tenantBackupService) ListBackups(ctx context.Context,
+	tenantID uint64, page *types.Pagination,
+) (*types.PageResult, error) {
+	backups, total, err := s.repo.ListBackups(ctx, tenantID, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, backups), nil
+}
+
+// GetBackup gets a backup by ID
+func (s *tenantBackupService) GetBackup(ctx context.Context, id string) (*types.TenantBackup, error) {
+	backup, err := s.repo.GetBackup(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrTenantBackupNotFound) {
+			return nil, werrors.NewNotFoundError("Backup not found")
+		}
+		return nil, err
+	}
+	return backup, nil
+}
+
+// OpenBackup opens the archive of a completed backup
+func (s *tenantBackupService) OpenBackup(ctx context.Context, id string) (*types.TenantBackup, io.ReadCloser, error) {
+	backup, err := s.GetBackup(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if backup.Status != types.TenantBackupStatusCompleted {
+		return nil, nil, werrors.NewConflictError(fmt.Sprintf("Backup is %s", backup.Status))
+	}
+	reader, err := s.fileSvc.GetFile(ctx, backup.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return backup, reader, nil
+}
+
+// ProcessTenantBackup handles Asynq tenant backup tasks. The archive is written to a temporary file,
+// then stored in object storage with its checksum recorded on the backup.
+func (s *tenantBackupService) ProcessTenantBackup(ctx context.Context, t *asynq.Task) error {
+	var payload types.TenantBackupPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal tenant backup payload: %w", err)
+	}
+	backup, err := s.repo.GetBackup(ctx, payload.BackupID)
+	if err != nil {
+		return err
+	}
+	tenant, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		return s.failBackup(ctx, backup, err)
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, tenant.ID)
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+
+	backup.Status = types.TenantBackupStatusRunning
+	backup.Error = ""
+	if err := s.repo.UpdateBackup(ctx, backup); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Backing up tenant %d to backup %s", tenant.ID, backup.ID)
+
+	archive, err := os.CreateTemp("", "tenant-backup-*.zip")
+	if err != nil {
+		return s.failBackup(ctx, backup, err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	// The checksum is computed while writing, so the archive is read once more only to store it
+	archiveHash := &hashingWriter{hash: sha256.New()}
+	manifest, err := s.writeBackup(ctx, tenant, io.MultiWriter(archive, archiveHash))
+	if err != nil {
+		return s.failBackup(ctx, backup, err)
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return s.failBackup(ctx, backup, err)
+	}
+	fileName := fmt.Sprintf("tenant-%d-backup-%s.zip", tenant.ID, time.Now().Format("20060102-150405"))
+	filePath, err := s.storeArchive(ctx, archive, archiveHash.size, tenant.ID, fileName)
+	if err != nil {
+		return s.failBackup(ctx, backup, fmt.Errorf("failed to store backup archive: %w", err))
+	}
+
+	now := time.Now()
+	backup.Status = types.TenantBackupStatusCompleted
+	backup.FilePath = filePath
+	backup.SchemaVersion = manifest.SchemaVersion
+	backup.Size = archiveHash.size
+	backup.SHA256 = hex.EncodeToString(archiveHash.hash.Sum(nil))
+	backup.Files = len(manifest.Files)
+	backup.Rows = 0
+	for _, table := range manifest.Tables {
+		backup.Rows += table.Rows
+	}
+	backup.CompletedAt = &now
+	if err := s.repo.UpdateBackup(ctx, backup); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Tenant backup %s completed: %d rows, %d files, %d bytes",
+		backup.ID, backup.Rows, backup.Files, backup.Size)
+	return nil
+}
+
+// storeArchive stores the archive streaming it when the file service supports it. Other file services take the
+// archive in memory, so archives larger than tenantBackupMaxBufferedSize are refused for them.
+func (s *tenantBackupService) storeArchive(ctx context.Context,
+	archive io.Reader, size int64, tenantID uint64, fileName string,
+) (string, error) {
+	if saver, ok := s.fileSvc.(interfaces.FileStreamSaver); ok {
+		return saver.SaveReader(ctx, archive, size, tenantID, fileName)
+	}
+	if size > tenantBackupMaxBufferedSize {
+		return "", fmt.Errorf("archive of %d bytes exceeds %d bytes, the limit of storage that cannot stream",
+			size, tenantBackupMaxBufferedSize)
+	}
+	data, err := io.ReadAll(archive)
+	if err != nil {
+		return "", err
+	}
+	return s.fileSvc.SaveBytes(ctx, data, tenantID, fileName, false)
+}
+
+// failBackup records the error of a backup and returns it
+func (s *tenantBackupService) failBackup(ctx context.Context, backup *types.TenantBackup, err error) error {
+	logger.Errorf(ctx, "Tenant backup %s failed: %v", backup.ID, err)
+	backup.Status = types.TenantBackupStatusFailed
+	backup.Error = err.Error()
+	if updateErr := s.repo.UpdateBackup(ctx, backup); updateErr != nil {
+		logger.Warnf(ctx, "Failed to record failure of tenant backup %s: %v", backup.ID, updateErr)
+	}
+	return err
+}
+
+// backupWriter writes entries to a backup archive, recording their size and checksum
+type backupWriter struct {
+	zw      *zip.Writer
+	entries map[string]types.TenantBackupEntry
+}
+
+// create writes an entry with write, recording its size and checksum
+func (w *backupWriter) create(name string, write func(io.Writer) error) error {
+	entry, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	counter := &hashingWriter{hash: sha256.New()}
+	if err := write(io.MultiWriter(entry, counter)); err != nil {
+		return err
+	}
+	w.entries[name] = types.TenantBackupEntry{Size: counter.size, SHA256: hex.EncodeToString(counter.hash.Sum(nil))}
+	return nil
+}
+
+// hashingWriter hashes and counts the bytes written to it
+type hashingWriter struct {
+	hash hash.Hash
+	size int64
+}
+
+// Write hashes p
+func (w *hashingWriter) Write(p []byte) (int, error) {
+	w.size += int64(len(p))
+	return w.hash.Write(p)
+}
+
+// writeBackup writes the rows, files and vectors of a tenant and then the manifest to out
+func (s *tenantBackupService) writeBackup(
+	ctx context.Context, tenant *types.Tenant, out io.Writer,
+) (*types.TenantBackupManifest, error) {
+	schemaVersion, err := s.schemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &types.TenantBackupManifest{
+		FormatVersion: types.TenantBackupFormatVersion,
+		SchemaVersion: schemaVersion,
+		TenantID:      tenant.ID,
+		TenantName:    tenant.Name,
+		CreatedAt:     time.Now(),
+	}
+	w := &backupWriter{zw: zip.NewWriter(out), entries: make(map[string]types.TenantBackupEntry)}
+
+	for _, table := range tenantBackupTables {
+		var count int64
+		if err := w.create(types.TenantBackupTablePath(table.name), func(entry io.Writer) error {
+			var exportErr error
+			count, exportErr = s.exportTable(ctx, table, tenant.ID, entry)
+			return exportErr
+		}); err != nil {
+			return nil, fmt.Errorf("failed to back up table %s: %w", table.name, err)
+		}
+		manifest.Tables = append(manifest.Tables, types.TenantBackupTable{Name: table.name, Rows: count})
+	}
+
+	var knowledgeList []*types.Knowledge
+	if err := s.db.WithContext(ctx).Where("tenant_id = ?", tenant.ID).Find(&knowledgeList).Error; err != nil {
+		return nil, err
+	}
+	for _, knowledge := range knowledgeList {
+		if knowledge.FilePath != "" {
+			file, err := s.backupFile(ctx, w, knowledge)
+			if err != nil {
+				logger.Warnf(ctx, "Failed to back up original file of knowledge %s: %v", knowledge.ID, err)
+				manifest.MissingFiles = append(manifest.MissingFiles, knowledge.ID)
+			} else {
+				manifest.Files = append(manifest.Files, *file)
+			}
+		}
+		if knowledge.ParseStatus != types.ParseStatusCompleted {
+			continue
+		}
+		vectors, err := s.knowledgeService.ExportKnowledgeVectors(ctx, knowledge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up vectors of knowledge %s: %w", knowledge.ID, err)
+		}
+		if len(vectors) == 0 {
+			continue
+		}
+		if err := w.create(types.TenantBackupVectorsPath(knowledge.ID), func(entry io.Writer) error {
+			return json.NewEncoder(entry).Encode(vectors)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest.Entries = w.entries
+	entry, err := w.zw.Create(types.TenantBackupManifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(entry).Encode(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, w.zw.Close()
+}
+
+// exportTable writes the rows of a tenant in a table as JSON lines, returning the number of rows
+func (s *tenantBackupService) exportTable(
+	ctx context.Context, table tenantBackupTable, tenantID uint64, out io.Writer,
+) (int64, error) {
+	rows, err := s.db.WithContext(ctx).
+		Raw(fmt.Sprintf("SELECT row_to_json(t)::text FROM %s t WHERE %s", table.name, table.where),
+			sql.Named("tenant", tenantID)).
+		Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var count int64
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			return count, err
+		}
+		if _, err := io.WriteString(out, row+"\n"); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// backupFile copies the original file of a knowledge into the backup
+func (s *tenantBackupService) backupFile(
+	ctx context.Context, w *backupWriter, knowledge *types.Knowledge,
+) (*types.TenantBackupFile, error) {
+	reader, err := s.fileSvc.GetFile(ctx, knowledge.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	fileName := knowledge.FileName
+	if fileName == "" {
+		fileName = knowledge.Title
+	}
+	name := types.TenantBackupFilePath(knowledge.ID, fileName)
+	if err := w.create(name, func(entry io.Writer) error {
+		_, err := io.Copy(entry, reader)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return &types.TenantBackupFile{KnowledgeID: knowledge.ID, SourcePath: knowledge.FilePath, Entry: name}, nil
+}
+
+// schemaVersion returns the database migration version of this deployment
+func (s *tenantBackupService) schemaVersion(ctx context.Context) (uint, error) {
+	var version uint
+	if err := s.db.WithContext(ctx).Raw("SELECT version FROM schema_migrations LIMIT 1").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// RestoreBackup recreates the tenant of a backup archive on this deployment. Every entry is checked against the
+// manifest before anything is written. Files are stored first and the rows inserted in one transaction keeping
+// their IDs, then the chunks are indexed again reusing the backed up vectors.
+func (s *tenantBackupService) RestoreBackup(ctx context.Context,
+	archivePath string, opts types.TenantRestoreOptions,
+) (*types.TenantRestoreReport, error) {
+	if opts.SHA256 != "" {
+		if err := verifyArchiveChecksum(archivePath, opts.SHA256); err != nil {
+			return nil, err
+		}
+	}
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup archive: %w", err)
+	}
+	defer zr.Close()
+
+	bundle := newBundleReader(&zr.Reader, tenantRestoreMaxEntrySize, math.MaxInt64)
+	manifest, err := readTenantBackupManifest(bundle)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyTenantBackup(&zr.Reader, manifest); err != nil {
+		return nil, err
+	}
+	report := &types.TenantRestoreReport{
+		TenantID:      manifest.TenantID,
+		TenantName:    manifest.TenantName,
+		SchemaVersion: manifest.SchemaVersion,
+		Tables:        manifest.Tables,
+		Files:         len(manifest.Files),
+		Verified:      true,
+	}
+	if opts.VerifyOnly {
+		return report, nil
+	}
+
+	schemaVersion, err := s.schemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.SchemaVersion > schemaVersion {
+		return nil, fmt.Errorf("backup was taken at schema version %d, upgrade this deployment from version %d first",
+			manifest.SchemaVersion, schemaVersion)
+	}
+	var existing int64
+	if err := s.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM tenants WHERE id = ?", manifest.TenantID).
+		Scan(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("tenant %d already exists on this deployment", manifest.TenantID)
+	}
+
+	filePaths, err := s.restoreFiles(ctx, bundle, manifest)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range manifest.Tables {
+			if err := restoreTable(tx, &zr.Reader, table.Name, filePaths); err != nil {
+				return fmt.Errorf("failed to restore table %s: %w", table.Name, err)
+			}
+		}
+		// Documents that were still being parsed have no chunks to index, they are parsed again
+		return tx.Model(&types.Knowledge{}).
+			Where("tenant_id = ? AND parse_status IN ?", manifest.TenantID,
+				[]string{types.ParseStatusPending, types.ParseStatusProcessing}).
+			Updates(map[string]any{
+				"parse_status":  types.ParseStatusFailed,
+				"error_message": "Parsing was interrupted by a backup, please parse again",
+			}).Error
+	})
+	if err != nil {
+		for _, filePath := range filePaths {
+			_ = s.fileSvc.DeleteFile(ctx, filePath)
+		}
+		return nil, err
+	}
+	logger.Infof(ctx, "Restored rows and %d files of tenant %d", len(filePaths), manifest.TenantID)
+
+	if err := s.restoreVectors(ctx, bundle, manifest.TenantID, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// restoreFiles stores the original files of the backup, returning their new path keyed by their source path
+func (s *tenantBackupService) restoreFiles(ctx context.Context,
+	bundle *bundleReader, manifest *types.TenantBackupManifest,
+) (map[string]string, error) {
+	filePaths := make(map[string]string, len(manifest.Files))
+	for _, file := range manifest.Files {
+		data, _, err := bundle.readEntry(file.Entry)
+		if err == nil {
+			var filePath string
+			filePath, err = s.fileSvc.SaveBytes(ctx, data, manifest.TenantID, path.Base(file.Entry), false)
+			filePaths[file.SourcePath] = filePath
+		}
+		if err != nil {
+			for _, filePath := range filePaths {
+				_ = s.fileSvc.DeleteFile(ctx, filePath)
+			}
+			return nil, fmt.Errorf("failed to restore file of knowledge %s: %w", file.KnowledgeID, err)
+		}
+	}
+	return filePaths, nil
+}
+
+// restoreVectors indexes the chunks of the completed knowledge of a restored tenant. Knowledge that cannot be
+// indexed, for example because its embedding model is unreachable, is marked failed to be parsed again.
+func (s *tenantBackupService) restoreVectors(ctx context.Context,
+	bundle *bundleReader, tenantID uint64, report *types.TenantRestoreReport,
+) error {
+	tenant, err := s.tenantRepo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, tenant.ID)
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+
+	var knowledgeList []*types.Knowledge
+	if err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND parse_status = ?", tenant.ID, types.ParseStatusCompleted).
+		Find(&knowledgeList).Error; err != nil {
+		return err
+	}
+	for _, knowledge := range knowledgeList {
+		var vectors map[string][]float32
+		if _, err := bundle.readJSON(types.TenantBackupVectorsPath(knowledge.ID), &vectors); err != nil {
+			return fmt.Errorf("invalid vectors of knowledge %s: %w", knowledge.ID, err)
+		}
+		reused, err := s.knowledgeService.RestoreKnowledgeVectors(ctx, knowledge, vectors)
+		report.ReusedVectors += reused
+		if err != nil {
+			logger.Warnf(ctx, "Failed to index restored knowledge %s: %v", knowledge.ID, err)
+			report.Failures = append(report.Failures, knowledge.ID)
+			if updateErr := s.db.WithContext(ctx).Model(&types.Knowledge{}).Where("id = ?", knowledge.ID).
+				Updates(map[string]any{
+					"parse_status":  types.ParseStatusFailed,
+					"error_message": fmt.Sprintf("Indexing after restore failed: %v", err),
+				}).Error; updateErr != nil {
+				return updateErr
+			}
+			continue
+		}
+		report.Indexed++
+	}
+	return nil
+}
+
+// restoreTable inserts the rows of a table file, keeping only the columns the table still has so backups of an
+// older schema restore with the defaults of newer columns. File paths of knowledge are rewritten to the restored
+// files.
+func restoreTable(tx *gorm.DB, zr *zip.Reader, table string, filePaths map[string]string) error {
+	var columns []string
+	if err := tx.Raw(`SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ? AND is_generated = 'NEVER'`, table).
+		Scan(&columns).Error; err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("table does not exist")
+	}
+
+	file, err := zr.Open(types.TenantBackupTablePath(table))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReaderSize(file, 1<<20)
+
+	var batch []map[string]json.RawMessage
+	var insertColumns []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		data, err := json.Marshal(batch)
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+		quoted := make([]string, len(insertColumns))
+		for i, column := range insertColumns {
+			quoted[i] = `"` + column + `"`
+		}
+		list := strings.Join(quoted, ", ")
+		return tx.Exec(fmt.Sprintf(`INSERT INTO "%s" (%s) SELECT %s FROM json_populate_recordset(NULL::"%s", ?::json)`,
+			table, list, list, table), string(data)).Error
+	}
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var row map[string]json.RawMessage
+			if err := json.Unmarshal(line, &row); err != nil {
+				return fmt.Errorf("invalid row: %w", err)
+			}
+			if insertColumns == nil {
+				for _, column := range columns {
+					if _, ok := row[column]; ok {
+						insertColumns = append(insertColumns, column)
+					}
+				}
+			}
+			if table == "knowledges" {
+				rewriteFilePath(row, filePaths)
+			}
+			batch = append(batch, row)
+			if len(batch) >= tenantRestoreBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	// Rows keep their IDs, serial sequences are moved past them
+	if slices.Contains(columns, "id") {
+		var sequence sql.NullString
+		if err := tx.Raw("SELECT pg_get_serial_sequence(?, 'id')", table).Scan(&sequence).Error; err != nil {
+			return err
+		}
+		if sequence.Valid && sequence.String != "" {
+			if err := tx.Exec(fmt.Sprintf(`SELECT setval(?, (SELECT COALESCE(MAX(id), 1) FROM "%s"))`, table),
+				sequence.String).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rewriteFilePath points the file_path of a knowledge row to its restored file
+func rewriteFilePath(row map[string]json.RawMessage, filePaths map[string]string) {
+	var source string
+	if err := json.Unmarshal(row["file_path"], &source); err != nil || source == "" {
+		return
+	}
+	if restored, ok := filePaths[source]; ok {
+		row["file_path"], _ = json.Marshal(restored)
+	}
+}
+
+// readTenantBackupManifest reads and validates the manifest of a backup archive
+func readTenantBackupManifest(bundle *bundleReader) (*types.TenantBackupManifest, error) {
+	var manifest types.TenantBackupManifest
+	found, err := bundle.readJSON(types.TenantBackupManifestPath, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("archive has no %s, it is not a tenant backup", types.TenantBackupManifestPath)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > types.TenantBackupFormatVersion {
+		return nil, fmt.Errorf("unsupported tenant backup format version: %d", manifest.FormatVersion)
+	}
+	for _, table := range manifest.Tables {
+		if !slices.ContainsFunc(tenantBackupTables, func(t tenantBackupTable) bool { return t.name == table.Name }) {
+			return nil, fmt.Errorf("backup contains unknown table %s", table.Name)
+		}
+	}
+	return &manifest, nil
+}
+
+// verifyTenantBackup checks every entry of the archive is listed in the manifest with its size and checksum
+func verifyTenantBackup(zr *zip.Reader, manifest *types.TenantBackupManifest) error {
+	seen := make(map[string]bool, len(manifest.Entries))
+	for _, file := range zr.File {
+		if file.Name == types.TenantBackupManifestPath {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = manifest.Verify(file.Name, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		seen[file.Name] = true
+	}
+	for name := range manifest.Entries {
+		if !seen[name] {
+			return fmt.Errorf("entry %s listed in the manifest is missing from the archive", name)
+		}
+	}
+	return nil
+}
+
+// verifyArchiveChecksum checks the SHA-256 of the archive file matches the one recorded when it was backed up
+func verifyArchiveChecksum(archivePath, expected string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	entry, err := types.NewTenantBackupEntry(file)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(entry.SHA256, expected) {
+		return fmt.Errorf("archive checksum mismatch: expected %s, got %s", expected, entry.SHA256)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/service/file"
+)
+
+// streamingFileService records what was streamed to it
+type streamingFileService struct {
+	file.DummyFileService
+	streamed []byte
+}
+
+func (s *streamingFileService) SaveReader(ctx context.Context,
+	r io.Reader, size int64, tenantID uint64, fileName string,
+) (string, error) {
+	data, err := io.ReadAll(r)
+	s.streamed = data
+	return "streamed/" + fileName, err
+}
+
+func TestStoreTenantBackupArchive(t *testing.T) {
+	ctx := context.Background()
+	archive := []byte("zip archive")
+
+	streaming := &streamingFileService{}
+	svc := &tenantBackupService{fileSvc: streaming}
+	path, err := svc.storeArchive(ctx, bytes.NewReader(archive), int64(len(archive)), 1, "backup.zip")
+	if err != nil || path != "streamed/backup.zip" || !bytes.Equal(streaming.streamed, archive) {
+		t.Fatalf("streaming store: %q, %v, streamed %q", path, err, streaming.streamed)
+	}
+
+	svc = &tenantBackupService{fileSvc: &file.DummyFileService{}}
+	if _, err := svc.storeArchive(ctx, bytes.NewReader(archive), int64(len(archive)), 1, "backup.zip"); err != nil {
+		t.Fatalf("buffered store: %v", err)
+	}
+	if _, err := svc.storeArchive(ctx, bytes.NewReader(archive), tenantBackupMaxBufferedSize+1, 1, "backup.zip"); err == nil {
+		t.Fatal("archives over the buffered limit should be refused by storage that cannot stream")
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// tokenUsageService implements interfaces.TokenUsageService
+type tokenUsageService struct {
+	repo interfaces.TokenUsageRepository
+}
+
+// NewTokenUsageService creates a new token usage service
+func NewTokenUsageService(repo interfaces.TokenUsageRepository) interfaces.TokenUsageService {
+	return &tokenUsageService{repo: repo}
+}
+
+// RecordTokenUsage records the usage of one LLM call, failures are only logged
+func (s *tokenUsageService) RecordTokenUsage(ctx context.Context, model *types.Model, usage types.TokenUsage) {
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	if tenantID == 0 {
+		return
+	}
+	record := &types.TokenUsageRecord{
+		ID:               uuid.New().String(),
+		TenantID:         tenantID,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		Estimated:        usage.Estimated,
+	}
+	if model != nil {
+		record.ModelID = model.ID
+		record.ModelName = model.Name
+		record.Cost = model.Parameters.Pricing.Cost(usage)
+	}
+	if scope, ok := ctx.Value(types.TokenUsageScopeContextKey).(*types.TokenUsageScope); ok && scope != nil {
+		record.SessionID = scope.SessionID
+		record.KnowledgeBaseID = scope.KnowledgeBaseID
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		logger.Warnf(ctx, "Failed to record token usage of model %s: %v", record.ModelID, err)
+	}
+}
+
+// GetBudgetStatus returns the current month's usage of the tenant against its budget
+func (s *tokenUsageService) GetBudgetStatus(ctx context.Context) (*types.TokenBudgetStatus, error) {
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	status := &types.TokenBudgetStatus{PeriodStart: types.MonthStart(time.Now())}
+	if tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant); ok && tenant != nil &&
+		tenant.MonthlyTokenBudget > 0 {
+		status.MonthlyBudget = tenant.MonthlyTokenBudget
+	}
+	used, err := s.repo.SumTotalTokens(ctx, tenantID, status.PeriodStart)
+	if err != nil {
+		return nil, err
+	}
+	status.Used = used
+	status.Exceeded = status.MonthlyBudget > 0 && used >= status.MonthlyBudget
+	return status, nil
+}
+
+// CheckBudget returns an error when the tenant has used up its monthly budget.
+// Tenants without a budget are not queried; a failed query lets the call through.
+func (s *tokenUsageService) CheckBudget(ctx context.Context) error {
+	tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if !ok || tenant == nil || tenant.MonthlyTokenBudget <= 0 {
+		return nil
+	}
+	status, err := s.GetBudgetStatus(ctx)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to check token budget of tenant %d: %v", tenant.ID, err)
+		return nil
+	}
+	if status.Exceeded {
+		logger.Warnf(ctx, "Tenant %d exceeded its monthly token budget: %d/%d",
+			tenant.ID, status.Used, status.MonthlyBudget)
+		return apperrors.NewTokenBudgetExceededError().WithDetails(status)
+	}
+	return nil
+}
+
+// GetUsageReport aggregates the usage of the tenant over a period
+func (s *tokenUsageService) GetUsageReport(ctx context.Context,
+	query *types.TokenUsageQuery,
+) (*types.TokenUsageReport, error) {
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	if !query.GroupBy.IsValid() {
+		query.GroupBy = types.TokenUsageGroupByModel
+	}
+	totals, err := s.repo.GetTotals(ctx, tenantID, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListStats(ctx, tenantID, query)
+	if err != nil {
+		return nil, err
+	}
+	budget, err := s.GetBudgetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &types.TokenUsageReport{
+		From:    query.From,
+		To:      query.To,
+		GroupBy: query.GroupBy,
+		Totals:  totals,
+		Items:   items,
+		Budget:  *budget,
+	}, nil
+}
+
+// withTokenUsageScope attributes the LLM calls made with ctx to a session, and to a knowledge base
+// when the request targets exactly one
+func withTokenUsageScope(ctx context.Context, sessionID string, knowledgeBaseIDs []string) context.Context {
+	scope := &types.TokenUsageScope{SessionID: sessionID}
+	if len(knowledgeBaseIDs) == 1 {
+		scope.KnowledgeBaseID = knowledgeBaseIDs[0]
+	}
+	return context.WithValue(ctx, types.TokenUsageScopeContextKey, scope)
+}
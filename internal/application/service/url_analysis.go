@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// urlAnalysisTimeout bounds the analysis of a single URL, a batch takes a few of them
+	urlAnalysisTimeout = 15 * time.Second
+	// urlAnalysisConcurrency is the number of URLs of a batch analyzed at once
+	urlAnalysisConcurrency = 8
+	// urlAnalysisPeekBytes is the start of a web page read for its title
+	urlAnalysisPeekBytes = 64 << 10
+	// urlAnalysisMaxTitleRunes bounds the title returned for a page
+	urlAnalysisMaxTitleRunes = 300
+)
+
+// htmlTitlePattern matches the title element of an HTML page
+var htmlTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// AnalyzeURLs analyzes the URLs of a pasted link list concurrently, a few at a time, and returns a result per URL in
+// the order given. URLs that fail are reported in their result rather than failing the batch.
+func (s *knowledgeService) AnalyzeURLs(ctx context.Context, urls []string) []*types.AnalyzeURLResult {
+	results := make([]*types.AnalyzeURLResult, len(urls))
+	var g errgroup.Group
+	g.SetLimit(urlAnalysisConcurrency)
+	for i, rawURL := range urls {
+		g.Go(func() error {
+			results[i] = analyzeURL(ctx, rawURL, nil)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	logger.Infof(ctx, "Analyzed %d URLs", len(urls))
+	return results
+}
+
+// analyzeURL fetches the start of what a URL serves, after the same validation and SSRF checks as an import, and
+// tells whether it is a web page or a document file and how it would be named
+func analyzeURL(ctx context.Context, rawURL string, fetchConfig *types.URLFetchConfig) *types.AnalyzeURLResult {
+	result := &types.AnalyzeURLResult{URL: rawURL}
+	rawURL = strings.TrimSpace(rawURL)
+	if !isValidURL(rawURL) || !secutils.IsValidURL(rawURL) {
+		result.Error = ErrInvalidURL.Error()
+		return result
+	}
+	if safe, reason := secutils.IsSSRFSafeURL(rawURL); !safe {
+		result.Error = fmt.Sprintf("URL is not allowed: %s", reason)
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, urlAnalysisTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.Error = ErrInvalidURL.Error()
+		return result
+	}
+	resp, err := newFetchClient(req, fetchConfig, urlAnalysisTimeout).Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.FinalURL = resp.Request.URL.String()
+	result.ContentType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	result.ContentLength = resp.ContentLength
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = fmt.Sprintf("status %d", resp.StatusCode)
+		return result
+	}
+	result.Reachable = true
+
+	fileName, isPage := fetchedFileName(resp.Request.URL, resp.Header.Get("Content-Disposition"),
+		resp.Header.Get("Content-Type"))
+	if isPage {
+		result.Kind = types.URLKindPage
+		head, _ := io.ReadAll(io.LimitReader(resp.Body, urlAnalysisPeekBytes))
+		result.Title = htmlTitle(head)
+		return result
+	}
+	// The content tells a PDF served under a name without extension, as on import
+	if !isValidFileType(fileName) {
+		head := make([]byte, len(pdfMagic))
+		n, _ := io.ReadFull(resp.Body, head)
+		if bytes.HasPrefix(head[:n], pdfMagic) {
+			fileName = withExtension(fileName, "pdf")
+		}
+	}
+	result.FileName = fileName
+	result.FileType = getFileType(fileName)
+	result.Kind = types.URLKindFile
+	if !isValidFileType(fileName) {
+		result.Kind = types.URLKindUnsupported
+	}
+	return result
+}
+
+// htmlTitle returns the title of an HTML page from its start, unescaped and on a single line
+func htmlTitle(head []byte) string {
+	match := htmlTitlePattern.FindSubmatch(head)
+	if match == nil {
+		return ""
+	}
+	title := strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+	if runes := []rune(title); len(runes) > urlAnalysisMaxTitleRunes {
+		title = string(runes[:urlAnalysisMaxTitleRunes])
+	}
+	return title
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHTMLTitle(t *testing.T) {
+	tests := []struct {
+		head string
+		want string
+	}{
+		{`<html><head><TITLE lang="en">WeKnora &amp; RAG
+			docs</TITLE></head>`, "WeKnora & RAG docs"},
+		{`<html><head><meta charset="utf-8"></head>`, ""},
+	}
+	for _, tt := range tests {
+		if got := htmlTitle([]byte(tt.head)); got != tt.want {
+			t.Errorf("htmlTitle(%q) = %q, want %q", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeURLRejectsUnsafeURLs(t *testing.T) {
+	for _, rawURL := range []string{"not a url", "ftp://example.com/a.pdf", "http://127.0.0.1/admin"} {
+		result := analyzeURL(context.Background(), rawURL, nil)
+		if result.Reachable || result.Error == "" || result.URL != rawURL {
+			t.Errorf("analyzeURL(%q) = %+v, want an error", rawURL, result)
+		}
+	}
+}
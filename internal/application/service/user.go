@@ -15,6 +15,9 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -26,6 +29,15 @@ var (
 	jwtSecret     string
 )
 
+const (
+	// accessTokenTTL is how long access tokens are valid
+	accessTokenTTL = 24 * time.Hour
+	// refreshTokenTTL is how long refresh tokens are valid, a login session lasts as long without refreshing
+	refreshTokenTTL = 7 * 24 * time.Hour
+	// tokenTouchInterval limits how often the last use of a token is written
+	tokenTouchInterval = time.Minute
+)
+
 // getJwtSecret retrieves the JWT secret from the environment, falling back to a securely generated random secret.
 func getJwtSecret() string {
 	jwtSecretOnce.Do(func() {
@@ -136,14 +148,14 @@ func (s *userService) Login(ctx context.Context, req *types.LoginRequest) (*type
 		logger.Errorf(ctx, "Failed to get user by email: %v", err)
 		return &types.LoginResponse{
 			Success: false,
-			Message: "Invalid email or password",
+			Message: i18n.T(ctx, i18n.MsgInvalidEmailOrPassword),
 		}, nil
 	}
 	if user == nil {
 		logger.Warn(ctx, "User not found for email")
 		return &types.LoginResponse{
 			Success: false,
-			Message: "Invalid email or password",
+			Message: i18n.T(ctx, i18n.MsgInvalidEmailOrPassword),
 		}, nil
 	}
 
@@ -152,7 +164,7 @@ func (s *userService) Login(ctx context.Context, req *types.LoginRequest) (*type
 		logger.Warn(ctx, "User account is disabled")
 		return &types.LoginResponse{
 			Success: false,
-			Message: "Account is disabled",
+			Message: i18n.T(ctx, i18n.MsgAccountDisabled),
 		}, nil
 	}
 
@@ -162,7 +174,7 @@ func (s *userService) Login(ctx context.Context, req *types.LoginRequest) (*type
 		logger.Warn(ctx, "Password verification failed")
 		return &types.LoginResponse{
 			Success: false,
-			Message: "Invalid email or password",
+			Message: i18n.T(ctx, i18n.MsgInvalidEmailOrPassword),
 		}, nil
 	}
 	logger.Info(ctx, "Password verification successful")
@@ -174,7 +186,7 @@ func (s *userService) Login(ctx context.Context, req *types.LoginRequest) (*type
 		logger.Errorf(ctx, "Failed to generate tokens: %v", err)
 		return &types.LoginResponse{
 			Success: false,
-			Message: "Login failed",
+			Message: i18n.T(ctx, i18n.MsgLoginFailed),
 		}, nil
 	}
 	logger.Info(ctx, "Tokens generated successfully")
@@ -190,7 +202,7 @@ func (s *userService) Login(ctx context.Context, req *types.LoginRequest) (*type
 	logger.Info(ctx, "User logged in successfully")
 	return &types.LoginResponse{
 		Success:      true,
-		Message:      "Login successful",
+		Message:      i18n.T(ctx, i18n.MsgLoginSucceeded),
 		User:         user,
 		Tenant:       tenant,
 		Token:        accessToken,
@@ -259,23 +271,46 @@ func (s *userService) ValidatePassword(ctx context.Context, userID string, passw
 	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 }
 
-// GenerateTokens generates access and refresh tokens for user
+// GenerateTokens generates access and refresh tokens for user, starting a new login session
 func (s *userService) GenerateTokens(
 	ctx context.Context,
 	user *types.User,
+) (accessToken, refreshToken string, err error) {
+	now := time.Now()
+	session := &types.LoginSession{
+		ID:           uuid.New().String(),
+		UserID:       user.ID,
+		LastActiveAt: now,
+		ExpiresAt:    now.Add(refreshTokenTTL),
+	}
+	if client := types.ClientInfoFromContext(ctx); client != nil {
+		session.IPAddress = client.IPAddress
+		session.UserAgent = client.UserAgent
+	}
+	if err := s.tokenRepo.CreateSession(ctx, session); err != nil {
+		return "", "", err
+	}
+	return s.issueTokens(ctx, user, session.ID)
+}
+
+// issueTokens generates access and refresh tokens for user within a login session
+func (s *userService) issueTokens(
+	ctx context.Context,
+	user *types.User,
+	sessionID string,
 ) (accessToken, refreshToken string, err error) {
 	// Generate access token (expires in 24 hours)
 	accessClaims := jwt.MapClaims{
 		"user_id":   user.ID,
 		"email":     user.Email,
 		"tenant_id": user.TenantID,
-		"exp":       time.Now().Add(24 * time.Hour).Unix(),
+		"sid":       sessionID,
+		"exp":       time.Now().Add(accessTokenTTL).Unix(),
 		"iat":       time.Now().Unix(),
 		"type":      "access",
 	}
 
-	accessTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = accessTokenObj.SignedString([]byte(getJwtSecret()))
+	accessToken, err = signJwt(accessClaims)
 	if err != nil {
 		return "", "", err
 	}
@@ -283,13 +318,13 @@ func (s *userService) GenerateTokens(
 	// Generate refresh token (expires in 7 days)
 	refreshClaims := jwt.MapClaims{
 		"user_id": user.ID,
-		"exp":     time.Now().Add(7 * 24 * time.Hour).Unix(),
+		"sid":     sessionID,
+		"exp":     time.Now().Add(refreshTokenTTL).Unix(),
 		"iat":     time.Now().Unix(),
 		"type":    "refresh",
 	}
 
-	refreshTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err = refreshTokenObj.SignedString([]byte(getJwtSecret()))
+	refreshToken, err = signJwt(refreshClaims)
 	if err != nil {
 		return "", "", err
 	}
@@ -300,7 +335,8 @@ func (s *userService) GenerateTokens(
 		UserID:    user.ID,
 		Token:     accessToken,
 		TokenType: "access_token",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		SessionID: sessionID,
+		ExpiresAt: time.Now().Add(accessTokenTTL),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -310,7 +346,8 @@ func (s *userService) GenerateTokens(
 		UserID:    user.ID,
 		Token:     refreshToken,
 		TokenType: "refresh_token",
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+		SessionID: sessionID,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -323,12 +360,7 @@ func (s *userService) GenerateTokens(
 
 // ValidateToken validates an access token
 func (s *userService) ValidateToken(ctx context.Context, tokenString string) (*types.User, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(getJwtSecret()), nil
-	})
+	token, err := jwt.Parse(tokenString, jwtKeyFunc)
 
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid token")
@@ -344,12 +376,18 @@ func (s *userService) ValidateToken(ctx context.Context, tokenString string) (*t
 		return nil, errors.New("invalid user ID in token")
 	}
 
-	// Check if token is revoked
+	// Check if token is revoked, revoking a session revokes all of its tokens
 	tokenRecord, err := s.tokenRepo.GetTokenByValue(ctx, tokenString)
 	if err != nil || tokenRecord == nil || tokenRecord.IsRevoked {
 		return nil, errors.New("token is revoked")
 	}
 
+	if now := time.Now(); tokenRecord.LastUsedAt == nil || now.Sub(*tokenRecord.LastUsedAt) >= tokenTouchInterval {
+		if err := s.tokenRepo.TouchToken(ctx, tokenRecord); err != nil {
+			logger.Warnf(ctx, "Failed to record use of token %s: %v", tokenRecord.ID, err)
+		}
+	}
+
 	return s.userRepo.GetUserByID(ctx, userID)
 }
 
@@ -358,12 +396,7 @@ func (s *userService) RefreshToken(
 	ctx context.Context,
 	refreshTokenString string,
 ) (accessToken, newRefreshToken string, err error) {
-	token, err := jwt.Parse(refreshTokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(getJwtSecret()), nil
-	})
+	token, err := jwt.Parse(refreshTokenString, jwtKeyFunc)
 
 	if err != nil || !token.Valid {
 		return "", "", errors.New("invalid refresh token")
@@ -400,16 +433,40 @@ func (s *userService) RefreshToken(
 	tokenRecord.IsRevoked = true
 	_ = s.tokenRepo.UpdateToken(ctx, tokenRecord)
 
-	// Generate new tokens
-	return s.GenerateTokens(ctx, user)
+	// Tokens issued before login sessions were recorded start a new session
+	if tokenRecord.SessionID == "" {
+		return s.GenerateTokens(ctx, user)
+	}
+	session, err := s.tokenRepo.GetSession(ctx, tokenRecord.SessionID)
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	if !session.IsActive(now) {
+		return "", "", errors.New("login session is revoked")
+	}
+	session.LastActiveAt = now
+	session.ExpiresAt = now.Add(refreshTokenTTL)
+	if err := s.tokenRepo.UpdateSession(ctx, session); err != nil {
+		return "", "", err
+	}
+
+	// Generate new tokens within the same session
+	return s.issueTokens(ctx, user, session.ID)
 }
 
-// RevokeToken revokes a token
+// RevokeToken revokes a token, and the login session it belongs to
 func (s *userService) RevokeToken(ctx context.Context, tokenString string) error {
 	tokenRecord, err := s.tokenRepo.GetTokenByValue(ctx, tokenString)
 	if err != nil {
 		return err
 	}
+	if tokenRecord.SessionID != "" {
+		// A session already revoked leaves only this token to revoke
+		if err := s.tokenRepo.RevokeSession(ctx, tokenRecord.SessionID); !errors.Is(err, repository.ErrSessionNotFound) {
+			return err
+		}
+	}
 
 	tokenRecord.IsRevoked = true
 	tokenRecord.UpdatedAt = time.Now()
@@ -434,3 +491,83 @@ func (s *userService) SearchUsers(ctx context.Context, query string, limit int)
 	}
 	return s.userRepo.SearchUsers(ctx, query, limit)
 }
+
+// ListSessions lists the active login sessions of a user with their usable tokens,
+// marking the session of the current token if it is one of them
+func (s *userService) ListSessions(ctx context.Context,
+	userID string, currentToken string,
+) ([]*types.LoginSession, error) {
+	sessions, err := s.tokenRepo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.tokenRepo.ListActiveTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*types.LoginSession, len(sessions))
+	for _, session := range sessions {
+		session.Tokens = []*types.IssuedToken{}
+		byID[session.ID] = session
+	}
+	for _, token := range tokens {
+		if session := byID[token.SessionID]; session != nil {
+			session.Tokens = append(session.Tokens, token.Issued())
+		}
+		if currentToken != "" && token.Token == currentToken {
+			if session := byID[token.SessionID]; session != nil {
+				session.Current = true
+			}
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes a login session of a user and all of its tokens
+func (s *userService) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	session, err := s.tokenRepo.GetSession(ctx, sessionID)
+	if err != nil || session.UserID != userID || session.RevokedAt != nil {
+		if err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+			return err
+		}
+		return werrors.NewNotFoundError("Login session not found")
+	}
+	if err := s.tokenRepo.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Revoked login session %s of user %s", sessionID, userID)
+	return nil
+}
+
+// RevokeAllSessions revokes all login sessions and tokens of a user, except the session of keepToken if given
+func (s *userService) RevokeAllSessions(ctx context.Context, userID string, keepToken string) error {
+	var keepSessionID string
+	if keepToken != "" {
+		if token, err := s.tokenRepo.GetTokenByValue(ctx, keepToken); err == nil && token.UserID == userID {
+			keepSessionID = token.SessionID
+		}
+	}
+	if err := s.tokenRepo.RevokeSessionsByUserID(ctx, userID, keepSessionID); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Revoked all login sessions of user %s, kept %q", userID, keepSessionID)
+	return nil
+}
+
+// RevokeIssuedToken revokes a single token of a user, its session stays usable with its other tokens
+func (s *userService) RevokeIssuedToken(ctx context.Context, userID string, tokenID string) error {
+	token, err := s.tokenRepo.GetTokenByID(ctx, tokenID)
+	if err != nil || token.UserID != userID || token.IsRevoked {
+		if err != nil && !errors.Is(err, repository.ErrTokenNotFound) {
+			return err
+		}
+		return werrors.NewNotFoundError("Token not found")
+	}
+	token.IsRevoked = true
+	token.UpdatedAt = time.Now()
+	if err := s.tokenRepo.UpdateToken(ctx, token); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Revoked %s %s of user %s", token.TokenType, token.ID, userID)
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/docreader/proto"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// PreviewImageUnderstanding runs the image understanding of a knowledge base on one image without
+// creating knowledge, so the vision model and prompt can be tried before documents are imported.
+// A non-empty prompt overrides the prompt saved in the knowledge base.
+func (s *knowledgeService) PreviewImageUnderstanding(ctx context.Context,
+	kb *types.KnowledgeBase, image []byte, fileName string, prompt string,
+) (*types.VLMPreviewResult, error) {
+	if s.docReaderClient == nil {
+		return nil, werrors.NewInternalServerError("DocReader service not configured")
+	}
+	vlmConfig, err := s.getVLMProtoConfig(ctx, kb)
+	if err != nil {
+		return nil, err
+	}
+	if vlmConfig == nil {
+		return nil, werrors.NewBadRequestError("VLM model is not configured for this knowledge base")
+	}
+	if prompt != "" {
+		vlmConfig.Prompt = prompt
+	}
+	// A single image never reaches the per document limit
+	vlmConfig.MaxImages = 0
+
+	requestID, _ := ctx.Value(types.RequestIDContextKey).(string)
+	start := time.Now()
+	response, err := s.docReaderClient.ReadFromFile(ctx, &proto.ReadFromFileRequest{
+		FileContent: image,
+		FileName:    fileName,
+		FileType:    strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), "."),
+		ReadConfig: &proto.ReadConfig{
+			ChunkSize:        int32(kb.ChunkingConfig.ChunkSize),
+			ChunkOverlap:     int32(kb.ChunkingConfig.ChunkOverlap),
+			Separators:       kb.ChunkingConfig.Separators,
+			EnableMultimodal: true,
+			StorageConfig: &proto.StorageConfig{
+				Provider: proto.StorageProvider(
+					proto.StorageProvider_value[strings.ToUpper(kb.StorageConfig.Provider)],
+				),
+				Region:          kb.StorageConfig.Region,
+				BucketName:      kb.StorageConfig.BucketName,
+				AccessKeyId:     kb.StorageConfig.SecretID,
+				SecretAccessKey: kb.StorageConfig.SecretKey,
+				AppId:           kb.StorageConfig.AppID,
+				PathPrefix:      kb.StorageConfig.PathPrefix,
+			},
+			VlmConfig: vlmConfig,
+			OcrConfig: ocrProtoConfig(kb),
+		},
+		RequestId: requestID,
+	})
+	if err != nil {
+		logger.Errorf(ctx, "VLM preview of knowledge base %s failed: %v", kb.ID, err)
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, werrors.NewBadRequestError("DocReader failed to process the image").WithDetails(response.Error)
+	}
+
+	var captions, ocrTexts []string
+	for _, chunk := range response.Chunks {
+		for _, img := range chunk.Images {
+			if img.Caption != "" {
+				captions = append(captions, img.Caption)
+			}
+			if img.OcrText != "" {
+				ocrTexts = append(ocrTexts, img.OcrText)
+			}
+		}
+	}
+	return &types.VLMPreviewResult{
+		Model:          vlmConfig.ModelName,
+		Prompt:         vlmConfig.Prompt,
+		Caption:        strings.Join(captions, "\n"),
+		OCRText:        strings.Join(ocrTexts, "\n"),
+		ProcessingTime: time.Since(start).Milliseconds(),
+	}, nil
+}
@@ -288,8 +288,8 @@ func (s *WebSearchService) Search(
 // NewWebSearchService creates a new web search service
 func NewWebSearchService(cfg *config.Config, registry *web_search.Registry) (interfaces.WebSearchService, error) {
 	timeout := 10 // default timeout
-	if cfg.WebSearch != nil && cfg.WebSearch.Timeout > 0 {
-		timeout = cfg.WebSearch.Timeout
+	if webSearch := cfg.Current().WebSearch; webSearch != nil && webSearch.Timeout > 0 {
+		timeout = webSearch.Timeout
 	}
 
 	// Create all registered providers
@@ -13,6 +13,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
 )
 
 const (
@@ -57,7 +58,8 @@ func NewBingProvider() (interfaces.WebSearchProvider, error) {
 		return nil, fmt.Errorf("BING_SEARCH_API_KEY is not set")
 	}
 	client := &http.Client{
-		Timeout: defaultBingTimeout,
+		Timeout:   defaultBingTimeout,
+		Transport: utils.OutboundTransport(),
 	}
 	return &BingProvider{
 		client:  client,
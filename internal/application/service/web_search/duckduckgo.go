@@ -26,7 +26,8 @@ type DuckDuckGoProvider struct {
 func NewDuckDuckGoProvider() (interfaces.WebSearchProvider, error) {
 	return &DuckDuckGoProvider{
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: secutils.OutboundTransport(),
 		},
 	}, nil
 }
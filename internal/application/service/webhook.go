@@ -0,0 +1,446 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// webhookSecretPrefix tells webhook secrets apart from other keys
+	webhookSecretPrefix = "whsec_"
+	// webhookMaxRetry is how many times a failed delivery is sent again, with the default asynq backoff the
+	// last retry is about three hours after the event
+	webhookMaxRetry = 8
+	// webhookRequestTimeout bounds each attempt to deliver an event
+	webhookRequestTimeout = 10 * time.Second
+	// webhookErrorBodyLimit is how much of an error response is kept on the delivery
+	webhookErrorBodyLimit = 512
+	// defaultWebhookDeliveryRetentionDays is how long deliveries are kept when WEBHOOK_DELIVERY_RETENTION_DAYS
+	// is not set
+	defaultWebhookDeliveryRetentionDays = 14
+)
+
+// webhookService implements WebhookService interface
+type webhookService struct {
+	repo   interfaces.WebhookRepository
+	task   interfaces.TaskEnqueuer
+	client *http.Client
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo interfaces.WebhookRepository, task interfaces.TaskEnqueuer) interfaces.WebhookService {
+	// Endpoints are given by tenants, so they are reached through the SSRF-safe client
+	clientConfig := utils.DefaultSSRFSafeHTTPClientConfig()
+	clientConfig.Timeout = webhookRequestTimeout
+	clientConfig.MaxRedirects = 3
+	return &webhookService{
+		repo:   repo,
+		task:   task,
+		client: utils.NewSSRFSafeHTTPClient(clientConfig),
+	}
+}
+
+// generateWebhookSecret generates a random secret to sign payloads with
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(buf), nil
+}
+
+// validateWebhookURL rejects URLs that are not http(s) or point at internal addresses
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return werrors.NewBadRequestError("URL must be an absolute http or https URL")
+	}
+	if safe, reason := utils.IsSSRFSafeURL(rawURL); !safe {
+		return werrors.NewBadRequestError("URL is not allowed: " + reason)
+	}
+	return nil
+}
+
+// validateWebhookEvents rejects unknown events and returns the events without duplicates
+func validateWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, werrors.NewBadRequestError("At least one event is required")
+	}
+	unique := make([]string, 0, len(events))
+	for _, event := range events {
+		if !types.WebhookEvent(event).IsValid() {
+			return nil, werrors.NewBadRequestError("Unknown event: " + event)
+		}
+		if !slices.Contains(unique, event) {
+			unique = append(unique, event)
+		}
+	}
+	return unique, nil
+}
+
+// CreateWebhook registers a webhook for the tenant in the context
+func (s *webhookService) CreateWebhook(ctx context.Context,
+	req *types.CreateWebhookRequest,
+) (*types.WebhookWithSecret, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := validateWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	createdBy, _ := ctx.Value(types.UserIDContextKey).(string)
+	webhook := &types.Webhook{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		Name:      req.Name,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    events,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedBy: createdBy,
+	}
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+	logger.Infof(ctx, "Created webhook %s for tenant %d with events %v", webhook.ID, tenantID, events)
+	return &types.WebhookWithSecret{Webhook: webhook, Secret: secret}, nil
+}
+
+// ListWebhooks lists the webhooks of the tenant in the context
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]*types.Webhook, error) {
+	return s.repo.ListWebhooks(ctx, ctx.Value(types.TenantIDContextKey).(uint64))
+}
+
+// GetWebhook gets a webhook of the tenant in the context
+func (s *webhookService) GetWebhook(ctx context.Context, id string) (*types.Webhook, error) {
+	webhook, err := s.repo.GetWebhook(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return nil, werrors.NewNotFoundError("Webhook not found")
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook updates the name, URL, events or state of a webhook
+func (s *webhookService) UpdateWebhook(ctx context.Context,
+	id string, req *types.UpdateWebhookRequest,
+) (*types.Webhook, error) {
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, werrors.NewBadRequestError("Name cannot be empty")
+		}
+		webhook.Name = *req.Name
+	}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		events, err := validateWebhookEvents(*req.Events)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Events = events
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	if err := s.repo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// RotateWebhookSecret replaces the secret of a webhook, payloads are signed with the new secret immediately
+func (s *webhookService) RotateWebhookSecret(ctx context.Context, id string) (*types.WebhookWithSecret, error) {
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	if err := s.repo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Rotated secret of webhook %s of tenant %d", webhook.ID, webhook.TenantID)
+	return &types.WebhookWithSecret{Webhook: webhook, Secret: secret}, nil
+}
+
+// DeleteWebhook deletes a webhook, deliveries still queued for it are dropped
+func (s *webhookService) DeleteWebhook(ctx context.Context, id string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := s.repo.DeleteWebhook(ctx, tenantID, id); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return werrors.NewNotFoundError("Webhook not found")
+		}
+		return err
+	}
+	logger.Infof(ctx, "Deleted webhook %s of tenant %d", id, tenantID)
+	return nil
+}
+
+// TestWebhook sends a ping event to a webhook once, without retries, and returns the outcome
+func (s *webhookService) TestWebhook(ctx context.Context, id string) (*types.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := s.newDelivery(webhook, types.WebhookEventPing, map[string]string{"webhook_id": webhook.ID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	if err := s.send(ctx, webhook, delivery); err != nil {
+		delivery.Status = types.WebhookDeliveryFailed
+	}
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ListDeliveries lists the deliveries of a webhook, newest first
+func (s *webhookService) ListDeliveries(ctx context.Context,
+	id string, page *types.Pagination,
+) (*types.PageResult, error) {
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, total, err := s.repo.ListDeliveries(ctx, webhook.TenantID, webhook.ID, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, deliveries), nil
+}
+
+// Publish delivers an event to the webhooks of a tenant subscribed to it. Each webhook gets its own delivery,
+// sent by a background task so slow endpoints do not hold up the operation that raised the event.
+func (s *webhookService) Publish(ctx context.Context, tenantID uint64, event types.WebhookEvent, data any) {
+	// The event happened, deliver it even if the request that raised it is cancelled
+	ctx = context.WithoutCancel(ctx)
+	webhooks, err := s.repo.ListEnabledWebhooks(ctx, tenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list webhooks of tenant %d for event %s: %v", tenantID, event, err)
+		return
+	}
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+		if err := s.enqueueDelivery(ctx, webhook, event, data); err != nil {
+			logger.Errorf(ctx, "Failed to deliver event %s to webhook %s: %v", event, webhook.ID, err)
+		}
+	}
+}
+
+// newDelivery builds the delivery of an event to a webhook with its payload
+func (s *webhookService) newDelivery(
+	webhook *types.Webhook, event types.WebhookEvent, data any,
+) (*types.WebhookDelivery, error) {
+	now := time.Now()
+	body := types.WebhookEventBody{
+		ID:        uuid.New().String(),
+		Event:     event,
+		TenantID:  webhook.TenantID,
+		CreatedAt: now,
+		Data:      data,
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	return &types.WebhookDelivery{
+		ID:        body.ID,
+		WebhookID: webhook.ID,
+		TenantID:  webhook.TenantID,
+		Event:     event,
+		Payload:   types.JSON(payload),
+		Status:    types.WebhookDeliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// enqueueDelivery records the delivery of an event to a webhook and enqueues the task sending it
+func (s *webhookService) enqueueDelivery(ctx context.Context,
+	webhook *types.Webhook, event types.WebhookEvent, data any,
+) error {
+	delivery, err := s.newDelivery(webhook, event, data)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(types.WebhookDeliveryPayload{DeliveryID: delivery.ID})
+	if err != nil {
+		return err
+	}
+	task := asynq.NewTask(types.TypeWebhookDelivery, payload,
+		asynq.TaskID(delivery.ID), asynq.Queue("default"), asynq.MaxRetry(webhookMaxRetry),
+		asynq.Timeout(2*webhookRequestTimeout))
+	if _, err := s.task.Enqueue(task); err != nil {
+		delivery.Status = types.WebhookDeliveryFailed
+		delivery.LastError = fmt.Sprintf("failed to enqueue delivery: %v", err)
+		_ = s.repo.UpdateDelivery(ctx, delivery)
+		return err
+	}
+	return nil
+}
+
+// ProcessWebhookDelivery handles Asynq webhook delivery tasks. Failed attempts return an error so the task is
+// retried with backoff, the delivery is marked failed after the last one.
+func (s *webhookService) ProcessWebhookDelivery(ctx context.Context, t *asynq.Task) error {
+	var payload types.WebhookDeliveryPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal webhook delivery payload: %w", err)
+	}
+	delivery, err := s.repo.GetDelivery(ctx, payload.DeliveryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+			logger.Warnf(ctx, "Webhook delivery %s no longer exists, skipping", payload.DeliveryID)
+			return nil
+		}
+		return err
+	}
+
+	webhook, err := s.repo.GetWebhook(ctx, delivery.TenantID, delivery.WebhookID)
+	if err != nil && !errors.Is(err, repository.ErrWebhookNotFound) {
+		return err
+	}
+	if webhook == nil || !webhook.Enabled {
+		delivery.Status = types.WebhookDeliveryFailed
+		delivery.LastError = "webhook was deleted or disabled before the event was delivered"
+		return s.repo.UpdateDelivery(ctx, delivery)
+	}
+
+	sendErr := s.send(ctx, webhook, delivery)
+	if sendErr != nil {
+		retryCount, _ := asynq.GetRetryCount(ctx)
+		maxRetry, _ := asynq.GetMaxRetry(ctx)
+		delivery.Status = types.WebhookDeliveryRetrying
+		if retryCount >= maxRetry {
+			delivery.Status = types.WebhookDeliveryFailed
+		}
+	}
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		logger.Errorf(ctx, "Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+	if sendErr != nil {
+		logger.Warnf(ctx, "Webhook delivery %s of event %s to webhook %s failed (attempt %d): %v",
+			delivery.ID, delivery.Event, webhook.ID, delivery.Attempts, sendErr)
+		return sendErr
+	}
+	return nil
+}
+
+// send posts a delivery to its webhook once, recording the attempt and its outcome on the delivery.
+// The payload is signed at send time, so retries carry a fresh timestamp.
+func (s *webhookService) send(ctx context.Context, webhook *types.Webhook, delivery *types.WebhookDelivery) error {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+
+	err := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "WeKnora-Webhook/1.0")
+		req.Header.Set(types.WebhookEventHeader, string(delivery.Event))
+		req.Header.Set(types.WebhookDeliveryHeader, delivery.ID)
+		req.Header.Set(types.WebhookSignatureHeader,
+			types.SignWebhookPayload(webhook.Secret, time.Now(), delivery.Payload))
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		delivery.ResponseStatus = resp.StatusCode
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorBodyLimit))
+			return fmt.Errorf("endpoint answered HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		}
+		return nil
+	}()
+
+	metrics.WebhookDeliveries.WithLabelValues(string(delivery.Event), metrics.Result(err)).Inc()
+	if err != nil {
+		delivery.LastError = err.Error()
+		return err
+	}
+	now := time.Now()
+	delivery.Status = types.WebhookDeliverySucceeded
+	delivery.LastError = ""
+	delivery.DeliveredAt = &now
+	return nil
+}
+
+// ProcessWebhookDeliveryPurge handles the periodic purge of deliveries older than the retention window
+func (s *webhookService) ProcessWebhookDeliveryPurge(ctx context.Context, t *asynq.Task) error {
+	cutoff := time.Now().AddDate(0, 0, -webhookDeliveryRetentionDays())
+	deleted, err := s.repo.DeleteDeliveriesBefore(ctx, cutoff)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to purge webhook deliveries: %v", err)
+		return err
+	}
+	if deleted > 0 {
+		logger.Infof(ctx, "Purged %d webhook deliveries created before %s", deleted, cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// webhookDeliveryRetentionDays returns how many days deliveries are kept
+func webhookDeliveryRetentionDays() int {
+	if v := os.Getenv("WEBHOOK_DELIVERY_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			return days
+		}
+	}
+	return defaultWebhookDeliveryRetentionDays
+}
@@ -0,0 +1,231 @@
+// Package cluster coordinates the replicas of the server through Redis: it claims the work that only one replica
+// may do at a time, such as parsing a document, and records which replica generates the answer of a session.
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// claimKeyPrefix prefixes the Redis keys of the claims
+const claimKeyPrefix = "claim:"
+
+// ErrClaimed is returned when another replica holds the claim
+var ErrClaimed = errors.New("claimed by another replica")
+
+// renewScript extends a claim still held by the caller
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript deletes a claim still held by the caller
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Options identifies the replica and sets how long its claims outlive it
+type Options struct {
+	// ReplicaID names the replica, REPLICA_ID or the host name followed by a random suffix when unset
+	ReplicaID string
+	// AdvertiseURL is the base URL other replicas reach this one at to forward requests, no forwarding when unset
+	AdvertiseURL string
+	// ClaimTTL is how long a claim lasts without being renewed, after the replica holding it died; 1 minute when
+	// unset. Claims are renewed every third of it.
+	ClaimTTL time.Duration
+}
+
+// DefaultOptions returns the options used by NewReplica
+func DefaultOptions() Options {
+	return Options{ClaimTTL: time.Minute}
+}
+
+// withDefaults fills the unset options
+func (o Options) withDefaults() Options {
+	d := DefaultOptions()
+	if o.ReplicaID == "" {
+		o.ReplicaID = defaultReplicaID()
+	}
+	if o.ClaimTTL <= 0 {
+		o.ClaimTTL = d.ClaimTTL
+	}
+	return o
+}
+
+// defaultReplicaID is REPLICA_ID, or the host name with a random suffix so that restarts get a new identity
+func defaultReplicaID() string {
+	if id := os.Getenv("REPLICA_ID"); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "replica"
+	}
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// SessionKey is the claim of the replica generating an answer in a session
+func SessionKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+// DocumentProcessKey is the claim of the replica parsing a knowledge
+func DocumentProcessKey(knowledgeID string) string {
+	return "document_process:" + knowledgeID
+}
+
+// Owner is the replica holding a claim
+type Owner struct {
+	ReplicaID string `json:"replica_id"`
+	URL       string `json:"url,omitempty"`
+}
+
+// claimValue is the value of a claim key, the token telling apart the claims of one replica
+type claimValue struct {
+	Owner
+	Token string `json:"token"`
+}
+
+// Replica is this replica in the cluster
+type Replica struct {
+	redisClient *redis.Client
+	opts        Options
+}
+
+// NewReplica creates the replica with the default options
+func NewReplica(redisClient *redis.Client) *Replica {
+	return NewReplicaWithOptions(redisClient, DefaultOptions())
+}
+
+// NewReplicaWithOptions creates the replica
+func NewReplicaWithOptions(redisClient *redis.Client, opts Options) *Replica {
+	return &Replica{redisClient: redisClient, opts: opts.withDefaults()}
+}
+
+// ID returns the ID of the replica
+func (r *Replica) ID() string {
+	return r.opts.ReplicaID
+}
+
+// Claim is work held by this replica until released, renewed in the background meanwhile
+type Claim struct {
+	replica *Replica
+	key     string
+	value   string
+	stop    chan struct{}
+	once    sync.Once
+	done    sync.WaitGroup
+}
+
+// newClaim creates a claim of key by this replica, not yet stored
+func (r *Replica) newClaim(key string) *Claim {
+	token := make([]byte, 8)
+	_, _ = rand.Read(token)
+	value, _ := json.Marshal(claimValue{
+		Owner: Owner{ReplicaID: r.opts.ReplicaID, URL: r.opts.AdvertiseURL},
+		Token: hex.EncodeToString(token),
+	})
+	return &Claim{replica: r, key: claimKeyPrefix + key, value: string(value), stop: make(chan struct{})}
+}
+
+// Acquire claims key for this replica, it fails with ErrClaimed while it is held, by another replica or by
+// another claim of this one
+func (r *Replica) Acquire(ctx context.Context, key string) (*Claim, error) {
+	claim := r.newClaim(key)
+	acquired, err := r.redisClient.SetNX(ctx, claim.key, claim.value, r.opts.ClaimTTL).Result()
+	metrics.ObserveLock("claim", acquired, err)
+	if err != nil {
+		return nil, fmt.Errorf("claim %s: %w", key, err)
+	}
+	if !acquired {
+		return nil, ErrClaimed
+	}
+	claim.hold()
+	return claim, nil
+}
+
+// Take claims key for this replica whoever held it, for work that moves to the replica that started it last
+func (r *Replica) Take(ctx context.Context, key string) (*Claim, error) {
+	claim := r.newClaim(key)
+	if err := r.redisClient.Set(ctx, claim.key, claim.value, r.opts.ClaimTTL).Err(); err != nil {
+		return nil, fmt.Errorf("claim %s: %w", key, err)
+	}
+	claim.hold()
+	return claim, nil
+}
+
+// hold renews the claim until it is released
+func (c *Claim) hold() {
+	c.done.Add(1)
+	go c.renew()
+}
+
+// Owner returns the replica holding key, false when nobody does
+func (r *Replica) Owner(ctx context.Context, key string) (*Owner, bool, error) {
+	value, err := r.redisClient.Get(ctx, claimKeyPrefix+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var claim claimValue
+	if err := json.Unmarshal([]byte(value), &claim); err != nil {
+		return nil, false, fmt.Errorf("invalid owner of claim %s: %w", key, err)
+	}
+	return &claim.Owner, true, nil
+}
+
+// renew extends the claim every third of its TTL until it is released
+func (c *Claim) renew() {
+	defer c.done.Done()
+	ttl := c.replica.opts.ClaimTTL
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+			renewed, err := renewScript.Run(ctx, c.replica.redisClient,
+				[]string{c.key}, c.value, ttl.Milliseconds()).Int()
+			cancel()
+			if err != nil {
+				logger.Warnf(context.Background(), "Failed to renew claim %s: %v", c.key, err)
+			} else if renewed == 0 {
+				logger.Warnf(context.Background(), "Claim %s was lost to another replica", c.key)
+				return
+			}
+		}
+	}
+}
+
+// Release gives the claim up, it may be called more than once
+func (c *Claim) Release() {
+	c.once.Do(func() {
+		close(c.stop)
+		c.done.Wait()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := releaseScript.Run(ctx, c.replica.redisClient, []string{c.key}, c.value).Err(); err != nil {
+			logger.Warnf(ctx, "Failed to release claim %s: %v", c.key, err)
+		}
+	})
+}
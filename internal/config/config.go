@@ -6,9 +6,13 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/slowlog"
+	"github.com/Tencent/WeKnora/internal/tenantguard"
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
@@ -26,15 +30,72 @@ type Config struct {
 	StreamManager   *StreamManagerConfig   `yaml:"stream_manager"   json:"stream_manager"`
 	ExtractManager  *ExtractManagerConfig  `yaml:"extract"          json:"extract"`
 	WebSearch       *WebSearchConfig       `yaml:"web_search"       json:"web_search"`
+	WebFetch        *WebFetchConfig        `yaml:"web_fetch"        json:"web_fetch"`
 	PromptTemplates *PromptTemplatesConfig `yaml:"prompt_templates" json:"prompt_templates"`
+	Auth            *AuthConfig            `yaml:"auth"             json:"auth"`
+	RateLimit       *RateLimitConfig       `yaml:"rate_limit"       json:"rate_limit"`
+	Idempotency     *IdempotencyConfig     `yaml:"idempotency"      json:"idempotency"`
+	Response        *ResponseConfig        `yaml:"response"         json:"response"`
+	MCPServer       *MCPServerConfig       `yaml:"mcp_server"       json:"mcp_server"`
+	Metrics         *MetricsConfig         `yaml:"metrics"          json:"metrics"`
+	Security        *SecurityConfig        `yaml:"security"         json:"security"`
+	OutboundHTTP    *OutboundHTTPConfig    `yaml:"outbound_http"    json:"outbound_http"`
+	SlowLog         *SlowLogConfig         `yaml:"slow_log"         json:"slow_log"`
+	Cluster         *ClusterConfig         `yaml:"cluster"          json:"cluster"`
+
+	// file is the config file the config was read from, watched for hot reload
+	file string
+	// current holds the running config, replaced whole when the config file is reloaded
+	current *atomic.Pointer[Config]
+}
+
+// Current returns the running config, with the reloadable sections of the last reload. Reloads never modify a
+// config in place, so read reloadable sections through Current, the config loaded at startup keeps its values.
+func (c *Config) Current() *Config {
+	if c.current == nil {
+		return c
+	}
+	return c.current.Load()
+}
+
+// setRunning makes c the running config that reloads replace
+func (c *Config) setRunning() {
+	c.current = new(atomic.Pointer[Config])
+	c.current.Store(c)
+}
+
+// CrossTenantAccessEnabled reports whether the running config lets users with cross-tenant permission reach other
+// tenants, c may be nil
+func (c *Config) CrossTenantAccessEnabled() bool {
+	if c == nil {
+		return false
+	}
+	tenant := c.Current().Tenant
+	return tenant != nil && tenant.EnableCrossTenantAccess
 }
 
 type DocReaderConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
+	// Connections is the number of gRPC connections to DocReader, 2 when unset
+	Connections int `yaml:"connections" json:"connections"`
+	// MaxInFlight caps the parse calls sent to DocReader at once, 16 when unset
+	MaxInFlight int `yaml:"max_in_flight" json:"max_in_flight"`
+	// QueueTimeout bounds how long a parse waits for an in-flight slot, 2 minutes when unset
+	QueueTimeout time.Duration `yaml:"queue_timeout" json:"queue_timeout"`
+	// FailureThreshold is the number of consecutive DocReader failures that open its circuit, 5 when unset
+	FailureThreshold int `yaml:"failure_threshold" json:"failure_threshold"`
+	// OpenDuration is how long an open circuit fails parses fast, 30 seconds when unset
+	OpenDuration time.Duration `yaml:"open_duration" json:"open_duration"`
 }
 
 type VectorDatabaseConfig struct {
 	Driver string `yaml:"driver" json:"driver"`
+	// UpsertBatchSize is the number of vectors saved per request to a retrieval engine, 40 when unset
+	UpsertBatchSize int `yaml:"upsert_batch_size" json:"upsert_batch_size"`
+	// DeleteBatchSize is the number of IDs deleted per request to a retrieval engine, 500 when unset
+	DeleteBatchSize int `yaml:"delete_batch_size" json:"delete_batch_size"`
+	// Parallelism caps the batch requests of one save or delete sent to an engine at once, 5 when unset
+	Parallelism int `yaml:"parallelism" json:"parallelism"`
 }
 
 // ConversationConfig 对话服务配置
@@ -50,7 +111,10 @@ type ConversationConfig struct {
 	FallbackPrompt             string         `yaml:"fallback_prompt"               json:"fallback_prompt"`
 	EnableRewrite              bool           `yaml:"enable_rewrite"                json:"enable_rewrite"`
 	EnableQueryExpansion       bool           `yaml:"enable_query_expansion"        json:"enable_query_expansion"`
+	EnableLLMExpansion         bool           `yaml:"enable_llm_expansion"          json:"enable_llm_expansion"`
+	EnableHyDE                 bool           `yaml:"enable_hyde"                   json:"enable_hyde"`
 	EnableRerank               bool           `yaml:"enable_rerank"                 json:"enable_rerank"`
+	EnableFollowUps            bool           `yaml:"enable_follow_ups"             json:"enable_follow_ups"`
 	Summary                    *SummaryConfig `yaml:"summary"                       json:"summary"`
 	GenerateSessionTitlePrompt string         `yaml:"generate_session_title_prompt" json:"generate_session_title_prompt"`
 	GenerateSummaryPrompt      string         `yaml:"generate_summary_prompt"       json:"generate_summary_prompt"`
@@ -62,6 +126,8 @@ type ConversationConfig struct {
 	ExtractRelationshipsPrompt string         `yaml:"extract_relationships_prompt"  json:"extract_relationships_prompt"`
 	// GenerateQuestionsPrompt is used to generate questions for document chunks to improve recall
 	GenerateQuestionsPrompt string `yaml:"generate_questions_prompt" json:"generate_questions_prompt"`
+	// GenerateQAPairsPrompt is used to generate question/answer pairs for document chunks
+	GenerateQAPairsPrompt string `yaml:"generate_qa_pairs_prompt" json:"generate_qa_pairs_prompt"`
 }
 
 // SummaryConfig 摘要配置
@@ -87,6 +153,20 @@ type ServerConfig struct {
 	Host            string        `yaml:"host"             json:"host"`
 	LogPath         string        `yaml:"log_path"         json:"log_path"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" json:"shutdown_timeout" default:"30s"`
+	// TrustedProxies are the CIDRs of the reverse proxies whose X-Forwarded-For header gives the client address,
+	// forwarded headers are ignored when empty
+	TrustedProxies []string `yaml:"trusted_proxies"  json:"trusted_proxies"`
+}
+
+// defaultShutdownTimeout is how long shutdown waits for in-flight requests and background tasks when not configured
+const defaultShutdownTimeout = 30 * time.Second
+
+// GetShutdownTimeout returns how long shutdown waits for in-flight requests, and then for background tasks
+func (c *ServerConfig) GetShutdownTimeout() time.Duration {
+	if c == nil || c.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return c.ShutdownTimeout
 }
 
 // KnowledgeBaseConfig 知识库配置
@@ -96,6 +176,23 @@ type KnowledgeBaseConfig struct {
 	SplitMarkers    []string               `yaml:"split_markers"    json:"split_markers"`
 	KeepSeparator   bool                   `yaml:"keep_separator"   json:"keep_separator"`
 	ImageProcessing *ImageProcessingConfig `yaml:"image_processing" json:"image_processing"`
+	// TrashRetentionDays is how long trashed knowledge is kept before being purged, 0 uses the default
+	TrashRetentionDays int `yaml:"trash_retention_days" json:"trash_retention_days"`
+	// SearchAnalytics configures the search logs behind the knowledge base search analytics
+	SearchAnalytics *SearchAnalyticsConfig `yaml:"search_analytics" json:"search_analytics"`
+	// MaxFileSizeMB caps uploaded and fetched files, 0 falls back to MAX_FILE_SIZE_MB or 50
+	MaxFileSizeMB int64 `yaml:"max_file_size_mb" json:"max_file_size_mb"`
+	// FileCache caches knowledge files read for previews and downloads in Redis
+	FileCache *FileCacheConfig `yaml:"file_cache" json:"file_cache"`
+}
+
+// FileCacheConfig 知识文件缓存配置
+type FileCacheConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxFileBytes is the largest file cached, larger files are always read from storage; 4MB when unset
+	MaxFileBytes int64 `yaml:"max_file_bytes" json:"max_file_bytes"`
+	// TTL is how long a cached file is kept, 1 hour when unset
+	TTL time.Duration `yaml:"ttl" json:"ttl"`
 }
 
 // ImageProcessingConfig 图像处理配置
@@ -103,6 +200,15 @@ type ImageProcessingConfig struct {
 	EnableMultimodal bool `yaml:"enable_multimodal" json:"enable_multimodal"`
 }
 
+// SearchAnalyticsConfig 检索分析配置
+type SearchAnalyticsConfig struct {
+	Enabled bool `yaml:"enabled"           json:"enabled"`
+	// RecordQueryText keeps query text in search logs, when false only a hash is kept to count repeats
+	RecordQueryText bool `yaml:"record_query_text" json:"record_query_text"`
+	// LowConfidenceThreshold reports searches whose best result scores below it as low confidence
+	LowConfidenceThreshold float64 `yaml:"low_confidence_threshold" json:"low_confidence_threshold"`
+}
+
 // TenantConfig 租户配置
 type TenantConfig struct {
 	DefaultSessionName        string `yaml:"default_session_name"        json:"default_session_name"`
@@ -110,6 +216,101 @@ type TenantConfig struct {
 	DefaultSessionDescription string `yaml:"default_session_description" json:"default_session_description"`
 	// EnableCrossTenantAccess enables cross-tenant access for users with permission
 	EnableCrossTenantAccess bool `yaml:"enable_cross_tenant_access" json:"enable_cross_tenant_access"`
+	// DefaultQuota caps the resources of tenants that do not set their own caps
+	DefaultQuota *types.TenantQuota `yaml:"default_quota" json:"default_quota"`
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	// OIDC lists the single sign-on providers users can log in with
+	OIDC []OIDCProviderConfig `yaml:"oidc" json:"oidc"`
+	// LDAP lets users log in with their LDAP or Active Directory account
+	LDAP *LDAPConfig `yaml:"ldap" json:"ldap"`
+	// FrontendRedirectURL is where the browser is sent with the issued tokens after a single sign-on login,
+	// when empty the callback answers with the login response as JSON
+	FrontendRedirectURL string `yaml:"frontend_redirect_url" json:"frontend_redirect_url"`
+}
+
+// OIDCProviderConfig 单点登录 OIDC 提供方配置
+type OIDCProviderConfig struct {
+	// Name identifies the provider in the login and callback URLs
+	Name         string   `yaml:"name"          json:"name"`
+	DisplayName  string   `yaml:"display_name"  json:"display_name"`
+	Issuer       string   `yaml:"issuer"        json:"issuer"`
+	ClientID     string   `yaml:"client_id"     json:"client_id"`
+	ClientSecret string   `yaml:"client_secret" json:"-"`
+	RedirectURL  string   `yaml:"redirect_url"  json:"redirect_url"`
+	Scopes       []string `yaml:"scopes"        json:"scopes"`
+	// EmailClaim and UsernameClaim name the ID token claims read for the user, default email and preferred_username
+	EmailClaim    string `yaml:"email_claim"    json:"email_claim"`
+	UsernameClaim string `yaml:"username_claim" json:"username_claim"`
+	// TenantClaim names a string or string list claim matched against TenantMapping to pick the tenant of new users
+	TenantClaim   string              `yaml:"tenant_claim"   json:"tenant_claim"`
+	TenantMapping []OIDCTenantMapping `yaml:"tenant_mapping" json:"tenant_mapping"`
+	// DefaultTenantID is used when no mapping matches, 0 creates a personal workspace as registration does
+	DefaultTenantID uint64 `yaml:"default_tenant_id" json:"default_tenant_id"`
+	// AllowedDomains restricts logins to these email domains, empty allows all
+	AllowedDomains []string `yaml:"allowed_domains" json:"allowed_domains"`
+	// AutoProvision creates unknown users on their first login
+	AutoProvision bool `yaml:"auto_provision" json:"auto_provision"`
+	// LinkExistingUsers lets a verified email log in to the local account with the same email
+	LinkExistingUsers bool `yaml:"link_existing_users" json:"link_existing_users"`
+}
+
+// OIDCTenantMapping maps a tenant claim value to a tenant
+type OIDCTenantMapping struct {
+	Value    string `yaml:"value"     json:"value"`
+	TenantID uint64 `yaml:"tenant_id" json:"tenant_id"`
+}
+
+// LDAPConfig LDAP/Active Directory 认证配置
+type LDAPConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// URL is ldap://host:389 or ldaps://host:636
+	URL                string        `yaml:"url"                  json:"url"`
+	StartTLS           bool          `yaml:"start_tls"            json:"start_tls"`
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+	Timeout            time.Duration `yaml:"timeout"              json:"timeout"`
+	// BindDN and BindPassword are the service account used to find users and read their groups, empty binds anonymously
+	BindDN       string `yaml:"bind_dn"       json:"bind_dn"`
+	BindPassword string `yaml:"bind_password" json:"-"`
+	UserBaseDN   string `yaml:"user_base_dn"  json:"user_base_dn"`
+	// UserFilter finds the user logging in, {username} is replaced with the escaped login name
+	UserFilter string `yaml:"user_filter" json:"user_filter"`
+	// IDAttribute holds a stable identifier of the user such as objectGUID or entryUUID, empty identifies users by DN
+	IDAttribute       string `yaml:"id_attribute"       json:"id_attribute"`
+	UsernameAttribute string `yaml:"username_attribute" json:"username_attribute"`
+	EmailAttribute    string `yaml:"email_attribute"    json:"email_attribute"`
+	// GroupAttribute lists the groups on the user entry, default memberOf
+	GroupAttribute string `yaml:"group_attribute" json:"group_attribute"`
+	// GroupBaseDN, when set, searches the groups with GroupFilter instead of reading GroupAttribute,
+	// {dn} and {username} in GroupFilter are replaced with the escaped user DN and login name
+	GroupBaseDN string `yaml:"group_base_dn" json:"group_base_dn"`
+	GroupFilter string `yaml:"group_filter"  json:"group_filter"`
+	// GroupMappings grant tenants, shared space roles and cross-tenant access to the members of directory groups
+	GroupMappings []LDAPGroupMapping `yaml:"group_mappings" json:"group_mappings"`
+	// RequiredGroups restricts logins to members of any of these groups, empty allows every user found
+	RequiredGroups []string `yaml:"required_groups" json:"required_groups"`
+	// DefaultTenantID is used for new users no mapping assigns a tenant, 0 creates a personal workspace
+	DefaultTenantID uint64 `yaml:"default_tenant_id" json:"default_tenant_id"`
+	// AutoProvision creates unknown users on their first login
+	AutoProvision bool `yaml:"auto_provision" json:"auto_provision"`
+	// LinkExistingUsers lets a directory user log in to the local account with the same email
+	LinkExistingUsers bool `yaml:"link_existing_users" json:"link_existing_users"`
+	// SyncInterval is how often the groups and status of directory users are synchronized, 0 disables
+	SyncInterval time.Duration `yaml:"sync_interval" json:"sync_interval"`
+}
+
+// LDAPGroupMapping maps a directory group, by DN or common name, to what its members are granted
+type LDAPGroupMapping struct {
+	Group string `yaml:"group" json:"group"`
+	// TenantID is the tenant new members are provisioned into
+	TenantID uint64 `yaml:"tenant_id" json:"tenant_id"`
+	// OrganizationID and Role make members part of a shared space, and so of the knowledge bases shared to it
+	OrganizationID string `yaml:"organization_id" json:"organization_id"`
+	Role           string `yaml:"role"            json:"role"`
+	// CrossTenantAccess grants members access to all tenants, when tenant.enable_cross_tenant_access is on
+	CrossTenantAccess bool `yaml:"cross_tenant_access" json:"cross_tenant_access"`
 }
 
 // PromptTemplate 提示词模板
@@ -147,6 +348,190 @@ type StreamManagerConfig struct {
 }
 
 // RedisConfig Redis配置
+// RateLimitConfig limits the request rate of tenants and scoped API keys with token buckets kept in Redis.
+// Limits are set per route class: chat, ingest, browser and default.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// KeyPrefix prefixes the Redis keys of the buckets
+	KeyPrefix string `yaml:"key_prefix" json:"key_prefix"`
+	// Classes are the limits every tenant gets, keyed by route class
+	Classes map[string]RateLimitRule `yaml:"classes" json:"classes"`
+	// Tenants overrides the limits of some tenants, keyed by tenant ID then route class
+	Tenants map[uint64]map[string]RateLimitRule `yaml:"tenants" json:"tenants"`
+	// APIKeys gives scoped API keys their own limits within their tenant's, keyed by API key ID then route class
+	APIKeys map[string]map[string]RateLimitRule `yaml:"api_keys" json:"api_keys"`
+}
+
+// IdempotencyConfig makes retries of mutating requests sending the same Idempotency-Key header replay the response
+// of the first request instead of running again. Responses are kept in Redis.
+type IdempotencyConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// KeyPrefix prefixes the Redis keys of the responses
+	KeyPrefix string `yaml:"key_prefix" json:"key_prefix"`
+	// TTL is how long responses are replayed for retries, 24 hours when unset
+	TTL time.Duration `yaml:"ttl" json:"ttl"`
+	// LockTimeout is how long a request holds its key while it runs, retries meanwhile are refused. It bounds how
+	// long a key stays taken when the server dies mid-request, 5 minutes when unset.
+	LockTimeout time.Duration `yaml:"lock_timeout" json:"lock_timeout"`
+	// MaxResponseBytes bounds the size of responses kept for replay, 1 MiB when unset. Requests with larger
+	// responses release their key, so retries run again.
+	MaxResponseBytes int `yaml:"max_response_bytes" json:"max_response_bytes"`
+	// MaxBodyBytes bounds the size of request bodies other than multipart uploads read to fingerprint a request,
+	// 10 MiB when unset. Larger requests with an idempotency key are rejected.
+	MaxBodyBytes int64 `yaml:"max_body_bytes" json:"max_body_bytes"`
+}
+
+// ResponseConfig controls the encoding and size of API responses
+type ResponseConfig struct {
+	Compression *CompressionConfig `yaml:"compression" json:"compression"`
+	// MaxPayloadBytes bounds the text of one response of the endpoints that can return megabytes of it, the chunk
+	// listing and search. Longer responses are cut between items and carry a cursor continuing after the last
+	// item, 4 MiB when unset.
+	MaxPayloadBytes int `yaml:"max_payload_bytes" json:"max_payload_bytes"`
+}
+
+// CompressionConfig compresses responses with the encodings the client accepts
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Encodings are the encodings offered in order of preference, zstd then gzip when unset
+	Encodings []string `yaml:"encodings" json:"encodings"`
+	// MinBytes is the size below which responses are sent as they are, 1 KiB when unset
+	MinBytes int `yaml:"min_bytes" json:"min_bytes"`
+}
+
+// MCPServerConfig serves knowledge base retrieval and document fetch as MCP tools on /api/v1/mcp, for agent
+// frameworks and IDE assistants. Callers authenticate like any API client and only see their own knowledge bases.
+type MCPServerConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// DefaultTopK is the number of search results when the caller asks for none, 5 when unset
+	DefaultTopK int `yaml:"default_top_k" json:"default_top_k"`
+	// MaxKnowledgeBases bounds the knowledge bases one search runs on, 10 when unset
+	MaxKnowledgeBases int `yaml:"max_knowledge_bases" json:"max_knowledge_bases"`
+	// MaxDocumentChars truncates fetched documents, 100000 when unset
+	MaxDocumentChars int `yaml:"max_document_chars" json:"max_document_chars"`
+}
+
+// MetricsConfig exposes the Prometheus metrics of the server on /metrics
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Listen is the address of a dedicated metrics listener, such as ":9464". When empty the metrics are served
+	// by the API listener, and only when a token is set.
+	Listen string `yaml:"listen" json:"listen"`
+	// Token is the bearer token scrapers must send, optional on the dedicated listener
+	Token string `yaml:"token" json:"token"`
+}
+
+// SecurityConfig 安全配置
+type SecurityConfig struct {
+	// SSRFAllowedHosts are hosts that URL imports and web fetches may reach although they resolve to private
+	// addresses, such as an intranet wiki. An entry starting with a dot matches the subdomains of the domain.
+	SSRFAllowedHosts []string `yaml:"ssrf_allowed_hosts" json:"ssrf_allowed_hosts"`
+	// TenantIsolationAudit checks that database statements on tenant tables are scoped to a tenant: off, log or
+	// panic; off when unset
+	TenantIsolationAudit string `yaml:"tenant_isolation_audit" json:"tenant_isolation_audit"`
+}
+
+// tenantGuardMode returns the mode of the tenant isolation audit
+func (c *SecurityConfig) tenantGuardMode() tenantguard.Mode {
+	if c == nil {
+		return tenantguard.ModeOff
+	}
+	return tenantguard.Mode(c.TenantIsolationAudit)
+}
+
+// OutboundHTTPConfig 出站 HTTP 配置，作用于调用模型、网络搜索、网页抓取、Webhook、OIDC 与 MCP 服务的客户端
+type OutboundHTTPConfig struct {
+	// DialTimeout bounds connecting to a host, 30 seconds when unset
+	DialTimeout time.Duration `yaml:"dial_timeout" json:"dial_timeout"`
+	// TLSHandshakeTimeout bounds the TLS handshake, 10 seconds when unset
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout" json:"tls_handshake_timeout"`
+	// ResponseHeaderTimeout bounds the wait for response headers, no limit when unset. Non-streaming model calls
+	// only send headers once the answer is complete, so keep it above the slowest answer.
+	ResponseHeaderTimeout time.Duration `yaml:"response_header_timeout" json:"response_header_timeout"`
+	// IdleConnTimeout closes connections idle for longer, 90 seconds when unset
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout" json:"idle_conn_timeout"`
+	// ProxyURL is the proxy of outbound requests, HTTP_PROXY and HTTPS_PROXY are used when unset
+	ProxyURL string `yaml:"proxy_url" json:"proxy_url"`
+	// NoProxy lists the hosts reached without the proxy in the NO_PROXY format, NO_PROXY is used when unset
+	NoProxy string `yaml:"no_proxy" json:"no_proxy"`
+	// ProxySSRFSafeClients sends web fetch, webhook and other SSRF-safe requests through the proxy too, their
+	// targets are then checked before the request instead of when connecting
+	ProxySSRFSafeClients bool `yaml:"proxy_ssrf_safe_clients" json:"proxy_ssrf_safe_clients"`
+	// CAFile is a PEM bundle of certificate authorities trusted besides the system ones
+	CAFile string `yaml:"ca_file" json:"ca_file"`
+	// InsecureSkipVerifyHosts are hosts whose certificates are not verified. An entry starting with a dot matches
+	// the subdomains of the domain.
+	InsecureSkipVerifyHosts []string `yaml:"insecure_skip_verify_hosts" json:"insecure_skip_verify_hosts"`
+}
+
+// settings converts the section to the settings of the outbound clients, the defaults apply when it is nil
+func (c *OutboundHTTPConfig) settings() utils.OutboundHTTPSettings {
+	if c == nil {
+		return utils.OutboundHTTPSettings{}
+	}
+	return utils.OutboundHTTPSettings{
+		DialTimeout:             c.DialTimeout,
+		TLSHandshakeTimeout:     c.TLSHandshakeTimeout,
+		ResponseHeaderTimeout:   c.ResponseHeaderTimeout,
+		IdleConnTimeout:         c.IdleConnTimeout,
+		ProxyURL:                c.ProxyURL,
+		NoProxy:                 c.NoProxy,
+		ProxySSRFSafeClients:    c.ProxySSRFSafeClients,
+		CAFile:                  c.CAFile,
+		InsecureSkipVerifyHosts: c.InsecureSkipVerifyHosts,
+	}
+}
+
+// SlowLogConfig 慢操作日志配置，耗时超过阈值的操作以 Warn 级别记录，并保留最近的记录供查询。
+// 阈值为 0 时使用默认值，为负数时不记录该类操作。
+type SlowLogConfig struct {
+	// DB is the threshold of database queries, 500 milliseconds when unset
+	DB time.Duration `yaml:"db" json:"db"`
+	// VectorSearch is the threshold of vector and keyword searches, 1 second when unset
+	VectorSearch time.Duration `yaml:"vector_search" json:"vector_search"`
+	// Model is the threshold of model calls until the response headers, 30 seconds when unset
+	Model time.Duration `yaml:"model" json:"model"`
+	// DocReader is the threshold of DocReader parses, 1 minute when unset
+	DocReader time.Duration `yaml:"docreader" json:"docreader"`
+	// Browser is the threshold of headless browser page loads, 15 seconds when unset
+	Browser time.Duration `yaml:"browser" json:"browser"`
+	// Recent is the number of slow operations kept for the endpoint, 200 when unset
+	Recent int `yaml:"recent" json:"recent"`
+}
+
+// settings converts the section to the settings of the slow log, the defaults apply when it is nil
+func (c *SlowLogConfig) settings() slowlog.Settings {
+	if c == nil {
+		return slowlog.Settings{}
+	}
+	return slowlog.Settings{
+		DB:           c.DB,
+		VectorSearch: c.VectorSearch,
+		Model:        c.Model,
+		DocReader:    c.DocReader,
+		Browser:      c.Browser,
+		Recent:       c.Recent,
+	}
+}
+
+// ClusterConfig 多副本部署配置，副本之间通过 Redis 协调会话与任务的归属
+type ClusterConfig struct {
+	// AdvertiseURL is the base URL other replicas reach this one at, such as http://10.0.0.12:8080. Requests about
+	// an answer generated on another replica are forwarded to it; without it they are rejected with the replica to
+	// route to.
+	AdvertiseURL string `yaml:"advertise_url" json:"advertise_url"`
+	// ClaimTTL is how long a replica that died keeps its claims on sessions and document parses, 1 minute when unset
+	ClaimTTL time.Duration `yaml:"claim_ttl" json:"claim_ttl"`
+}
+
+// RateLimitRule is a token bucket, a rate of zero means unlimited
+type RateLimitRule struct {
+	// Rate is the number of requests per second the bucket refills with
+	Rate float64 `yaml:"rate" json:"rate"`
+	// Burst is the size of the bucket, the number of requests allowed at once
+	Burst int `yaml:"burst" json:"burst"`
+}
+
 type RedisConfig struct {
 	Address  string        `yaml:"address"  json:"address"`  // Redis地址
 	Username string        `yaml:"username" json:"username"` // Redis用户名
@@ -178,17 +563,32 @@ func LoadConfig() (*Config, error) {
 	viper.AddConfigPath("$HOME/.appname") // 用户目录
 	viper.AddConfigPath("/etc/appname/")  // etc目录
 
-	// 启用环境变量替换
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-
-	// 读取配置文件
+	// 查找配置文件
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
+	cfg, err := readConfigFile(viper.ConfigFileUsed())
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Using configuration file: %s\n", cfg.file)
+	cfg.setRunning()
+	cfg.applyRuntimeSettings()
+	return cfg, nil
+}
+
+// readConfigFile 读取并解析配置文件，启动与热加载共用
+func readConfigFile(path string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	// 启用环境变量替换
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
 	// 替换配置中的环境变量引用
-	configFileContent, err := os.ReadFile(viper.ConfigFileUsed())
+	configFileContent, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("error reading config file content: %w", err)
 	}
@@ -206,19 +606,30 @@ func LoadConfig() (*Config, error) {
 	})
 
 	// 使用处理后的配置内容
-	viper.ReadConfig(strings.NewReader(result))
+	if err := v.ReadConfig(strings.NewReader(result)); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %w", err)
+	}
 
 	// 解析配置到结构体
 	var cfg Config
-	if err := viper.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) {
+	if err := v.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) {
 		dc.TagName = "yaml"
 	}); err != nil {
 		return nil, fmt.Errorf("unable to decode config into struct: %w", err)
 	}
-	fmt.Printf("Using configuration file: %s\n", viper.ConfigFileUsed())
+	cfg.file = path
+
+	if err := utils.CheckOutboundHTTPSettings(cfg.OutboundHTTP.settings()); err != nil {
+		return nil, fmt.Errorf("invalid outbound_http config: %w", err)
+	}
+	switch mode := cfg.Security.tenantGuardMode(); mode {
+	case "", tenantguard.ModeOff, tenantguard.ModeLog, tenantguard.ModePanic:
+	default:
+		return nil, fmt.Errorf("invalid security.tenant_isolation_audit %q: want off, log or panic", mode)
+	}
 
 	// 加载提示词模板（从目录或配置文件）
-	configDir := filepath.Dir(viper.ConfigFileUsed())
+	configDir := filepath.Dir(path)
 	promptTemplates, err := loadPromptTemplates(configDir)
 	if err != nil {
 		fmt.Printf("Warning: failed to load prompt templates from directory: %v\n", err)
@@ -282,3 +693,12 @@ func loadPromptTemplates(configDir string) (*PromptTemplatesConfig, error) {
 type WebSearchConfig struct {
 	Timeout int `yaml:"timeout" json:"timeout"` // 超时时间（秒）
 }
+
+// WebFetchConfig bounds the pages the web_fetch agent tool converts to Markdown. Pages are converted section by
+// section while they are read, conversion stops at either cap and the result reports the truncation.
+type WebFetchConfig struct {
+	// MaxHTMLBytes is the most HTML read from a page, 5MB when unset
+	MaxHTMLBytes int64 `yaml:"max_html_bytes" json:"max_html_bytes"`
+	// MaxMarkdownChars is the most Markdown kept from a page, 100000 when unset
+	MaxMarkdownChars int `yaml:"max_markdown_chars" json:"max_markdown_chars"`
+}
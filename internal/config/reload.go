@@ -0,0 +1,308 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/slowlog"
+	"github.com/Tencent/WeKnora/internal/tenantguard"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// defaultReloadInterval is how often the config file is checked for changes when CONFIG_RELOAD_INTERVAL is not set
+const defaultReloadInterval = 10 * time.Second
+
+// reloadableSections are the sections applied to the running server when the config file changes. They are read
+// on every use, the other sections open connections or pick backends at startup and need a restart.
+var reloadableSections = map[string]bool{
+	"conversation":     true,
+	"knowledge_base":   true,
+	"tenant":           true,
+	"web_search":       true,
+	"web_fetch":        true,
+	"prompt_templates": true,
+	"rate_limit":       true,
+	"idempotency":      true,
+	"response":         true,
+	"mcp_server":       true,
+	"security":         true,
+	"outbound_http":    true,
+	"slow_log":         true,
+}
+
+// redactedValue replaces secrets in the effective config
+const redactedValue = "******"
+
+// secretKeySuffixes are the suffixes of the keys whose values are secrets
+var secretKeySuffixes = []string{"password", "secret", "token", "api_key", "apikey", "access_key", "secret_key"}
+
+// applyRuntimeSettings pushes the settings kept outside the config, such as the upload size limit, to their packages
+func (c *Config) applyRuntimeSettings() {
+	var maxFileSizeMB int64
+	if c.KnowledgeBase != nil {
+		maxFileSizeMB = c.KnowledgeBase.MaxFileSizeMB
+	}
+	utils.SetMaxFileSizeMB(maxFileSizeMB)
+
+	var allowedHosts []string
+	if c.Security != nil {
+		allowedHosts = c.Security.SSRFAllowedHosts
+	}
+	utils.SetSSRFAllowedHosts(allowedHosts)
+
+	// The settings were checked when the file was read
+	if err := utils.SetOutboundHTTPSettings(c.OutboundHTTP.settings()); err != nil {
+		logger.Errorf(context.Background(), "Failed to apply outbound HTTP settings, keeping the previous ones: %v", err)
+	}
+
+	slowlog.SetSettings(c.SlowLog.settings())
+	tenantguard.SetMode(c.Security.tenantGuardMode())
+}
+
+// applyReloadable makes a copy of the running config with the reloadable sections of next that differ, and
+// stores it as the running config. The running config is never modified, so readers of Current see either the old
+// or the new config. It returns the reloadable sections applied and the changed sections that need a restart.
+func (c *Config) applyReloadable(next *Config) (applied, restartRequired []string) {
+	running := c.Current()
+	merged := *running
+	current := reflect.ValueOf(&merged).Elem()
+	updated := reflect.ValueOf(next).Elem()
+	for i := 0; i < current.NumField(); i++ {
+		field := current.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if !reloadableSections[name] {
+			restartRequired = append(restartRequired, name)
+			continue
+		}
+		current.Field(i).Set(updated.Field(i))
+		applied = append(applied, name)
+	}
+	if len(applied) > 0 {
+		if c.current == nil {
+			// Configs built without LoadConfig, as in tests
+			c.setRunning()
+		}
+		merged.current = c.current
+		c.current.Store(&merged)
+	}
+	return applied, restartRequired
+}
+
+// Redacted returns the effective config as JSON values, with secrets replaced
+func (c *Config) Redacted() (map[string]any, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	redactSecrets(values)
+	return values, nil
+}
+
+// redactSecrets replaces the non-empty values of secret keys in nested maps and lists, and the passwords of URLs
+// such as a proxy URL with credentials
+func redactSecrets(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if isSecretKey(key) {
+				if item != nil && item != "" {
+					v[key] = redactedValue
+				}
+				continue
+			}
+			if s, ok := item.(string); ok {
+				if u, err := url.Parse(s); err == nil && u.User != nil {
+					if _, hasPassword := u.User.Password(); hasPassword {
+						v[key] = u.Redacted()
+					}
+				}
+				continue
+			}
+			redactSecrets(item)
+		}
+	case []any:
+		for _, item := range v {
+			redactSecrets(item)
+		}
+	}
+}
+
+// isSecretKey reports whether a config key holds a secret, such as password, client_secret or api_key
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadStatus describes the config file being watched and the outcome of the last reload
+type ReloadStatus struct {
+	File     string `json:"file"`
+	Interval string `json:"interval"`
+	// ReloadableSections are applied without a restart, changes to other sections are reported in RestartRequired
+	ReloadableSections []string   `json:"reloadable_sections"`
+	LastReloadAt       *time.Time `json:"last_reload_at,omitempty"`
+	LastError          string     `json:"last_error,omitempty"`
+	// Applied are the sections the last reload changed
+	Applied []string `json:"applied,omitempty"`
+	// RestartRequired are the changed sections that only take effect after a restart
+	RestartRequired []string `json:"restart_required,omitempty"`
+}
+
+// Watcher reloads the config file when it changes, or on SIGHUP, and applies its reloadable sections
+type Watcher struct {
+	cfg      *Config
+	interval time.Duration
+
+	mu      sync.Mutex
+	modTime time.Time
+	status  ReloadStatus
+	stop    context.CancelFunc
+}
+
+// NewWatcher creates a watcher for the file cfg was loaded from. CONFIG_RELOAD_INTERVAL sets how often the
+// file is checked, 0 only reloads on SIGHUP.
+func NewWatcher(cfg *Config) *Watcher {
+	interval := defaultReloadInterval
+	if v := os.Getenv("CONFIG_RELOAD_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil {
+			interval = parsed
+		}
+	}
+	sections := make([]string, 0, len(reloadableSections))
+	for name := range reloadableSections {
+		sections = append(sections, name)
+	}
+	sort.Strings(sections)
+	w := &Watcher{
+		cfg:      cfg,
+		interval: interval,
+		status: ReloadStatus{
+			File:               cfg.file,
+			Interval:           interval.String(),
+			ReloadableSections: sections,
+		},
+	}
+	if info, err := os.Stat(cfg.file); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+// Start watches the config file until Stop is called
+func (w *Watcher) Start() {
+	if w.cfg.file == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.stop = cancel
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hangup)
+		var tick <-chan time.Time
+		if w.interval > 0 {
+			ticker := time.NewTicker(w.interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				logger.Info(ctx, "Received SIGHUP, reloading config")
+				_, _ = w.Reload(ctx)
+			case <-tick:
+				if w.changed() {
+					_, _ = w.Reload(ctx)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops watching the config file
+func (w *Watcher) Stop() {
+	if w.stop != nil {
+		w.stop()
+	}
+}
+
+// changed reports whether the config file was modified since it was last read
+func (w *Watcher) changed() bool {
+	info, err := os.Stat(w.cfg.file)
+	if err != nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !info.ModTime().Equal(w.modTime)
+}
+
+// Reload reads the config file and applies its reloadable sections. A file that fails to parse leaves the
+// running config unchanged.
+func (w *Watcher) Reload(ctx context.Context) (ReloadStatus, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if info, err := os.Stat(w.cfg.file); err == nil {
+		w.modTime = info.ModTime()
+	}
+	now := time.Now()
+	w.status.LastReloadAt = &now
+
+	next, err := readConfigFile(w.cfg.file)
+	if err != nil {
+		w.status.LastError = err.Error()
+		logger.Errorf(ctx, "Failed to reload config from %s, keeping the running config: %v", w.cfg.file, err)
+		return w.status, fmt.Errorf("reload config: %w", err)
+	}
+
+	applied, restartRequired := w.cfg.applyReloadable(next)
+	w.cfg.Current().applyRuntimeSettings()
+	w.status.LastError = ""
+	w.status.Applied = applied
+	w.status.RestartRequired = restartRequired
+	if len(applied) > 0 {
+		logger.Infof(ctx, "Reloaded config sections: %s", strings.Join(applied, ", "))
+	}
+	if len(restartRequired) > 0 {
+		logger.Warnf(ctx, "Config sections changed that need a restart to take effect: %s",
+			strings.Join(restartRequired, ", "))
+	}
+	return w.status, nil
+}
+
+// Status returns the watched file and the outcome of the last reload
+func (w *Watcher) Status() ReloadStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
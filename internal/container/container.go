@@ -26,6 +26,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Tencent/WeKnora/docreader/client"
+	"github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	elasticsearchRepoV7 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v7"
 	elasticsearchRepoV8 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v8"
@@ -38,6 +39,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/application/service/llmcontext"
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
 	"github.com/Tencent/WeKnora/internal/application/service/web_search"
+	"github.com/Tencent/WeKnora/internal/cluster"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/database"
 	"github.com/Tencent/WeKnora/internal/event"
@@ -48,7 +50,9 @@ import (
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/router"
+	"github.com/Tencent/WeKnora/internal/slowlog"
 	"github.com/Tencent/WeKnora/internal/stream"
+	"github.com/Tencent/WeKnora/internal/tenantguard"
 	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -72,15 +76,19 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	// Core infrastructure configuration
 	logger.Debugf(ctx, "[Container] Registering core infrastructure...")
 	must(container.Provide(config.LoadConfig))
+	must(container.Provide(config.NewWatcher))
 	must(container.Provide(initTracer))
 	must(container.Provide(initDatabase))
 	must(container.Provide(initFileService))
 	must(container.Provide(initRedisClient))
+	must(container.Provide(initReplica))
 	must(container.Provide(initAntsPool))
 	must(container.Provide(initContextStorage))
 
 	// Register goroutine pool cleanup handler
 	must(container.Invoke(registerPoolCleanup))
+	must(container.Invoke(registerBrowserCleanup))
+	must(container.Invoke(startConfigWatcher))
 
 	// Initialize retrieval engine registry for search capabilities
 	logger.Debugf(ctx, "[Container] Registering retrieval engine registry...")
@@ -89,6 +97,7 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	// External service clients
 	logger.Debugf(ctx, "[Container] Registering external service clients...")
 	must(container.Provide(initDocReaderClient))
+	must(container.Invoke(startParserPluginSync))
 	must(container.Provide(initOllamaService))
 	must(container.Provide(initNeo4jClient))
 	must(container.Provide(stream.NewStreamManager))
@@ -101,6 +110,7 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(repository.NewTenantRepository))
 	must(container.Provide(repository.NewKnowledgeBaseRepository))
 	must(container.Provide(repository.NewKnowledgeRepository))
+	must(container.Provide(repository.NewKnowledgeFileLinkRepository))
 	must(container.Provide(repository.NewChunkRepository))
 	must(container.Provide(repository.NewKnowledgeTagRepository))
 	must(container.Provide(repository.NewSessionRepository))
@@ -113,9 +123,24 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(repository.NewCustomAgentRepository))
 	must(container.Provide(repository.NewOrganizationRepository))
 	must(container.Provide(repository.NewKBShareRepository))
+	must(container.Provide(repository.NewKBPermissionRepository))
+	must(container.Provide(repository.NewAPIKeyRepository))
+	must(container.Provide(repository.NewServiceAccountRepository))
+	must(container.Provide(repository.NewJobRepository))
+	must(container.Provide(repository.NewTenantBackupRepository))
+	must(container.Provide(repository.NewWebhookRepository))
+	must(container.Provide(repository.NewSystemStatsRepository))
 	must(container.Provide(repository.NewAgentShareRepository))
 	must(container.Provide(repository.NewTenantDisabledSharedAgentRepository))
+	must(container.Provide(repository.NewSearchLogRepository))
+	must(container.Provide(repository.NewRetrievalEvalRepository))
+	must(container.Provide(repository.NewExperimentRepository))
+	must(container.Provide(repository.NewAnswerFeedbackRepository))
+	must(container.Provide(repository.NewPromptTemplateRepository))
+	must(container.Provide(repository.NewTokenUsageRepository))
+	must(container.Provide(repository.NewModerationRepository))
 	must(container.Provide(service.NewWebSearchStateService))
+	must(container.Provide(service.NewSemanticCache))
 
 	// MCP manager for managing MCP client connections
 	logger.Debugf(ctx, "[Container] Registering MCP manager...")
@@ -124,18 +149,38 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	// Business service layer
 	logger.Debugf(ctx, "[Container] Registering business services...")
 	must(container.Provide(service.NewTenantService))
+	must(container.Provide(service.NewQuotaService))
 	must(container.Provide(service.NewKnowledgeBaseService))
 	must(container.Provide(service.NewOrganizationService))
 	must(container.Provide(service.NewKBShareService)) // KBShareService must be registered before KnowledgeService and KnowledgeTagService
+	must(container.Provide(service.NewKBPermissionService))
+	must(container.Provide(service.NewAPIKeyService))
+	must(container.Provide(service.NewServiceAccountService))
+	must(container.Provide(service.NewJobService))
+	must(container.Provide(service.NewTenantBackupService))
+	must(container.Provide(service.NewWebhookService))
+	must(container.Provide(service.NewSystemStatsService))
+	must(container.Provide(service.NewHealthService))
 	must(container.Provide(service.NewAgentShareService))
 	must(container.Provide(service.NewKnowledgeService))
+	must(container.Provide(service.NewKnowledgeFileLinkService))
 	must(container.Provide(service.NewChunkService))
 	must(container.Provide(service.NewKnowledgeTagService))
 	must(container.Provide(embedding.NewBatchEmbedder))
+	must(container.Provide(service.NewTokenUsageService))
 	must(container.Provide(service.NewModelService))
+	must(container.Provide(service.NewModerationService))
+	must(container.Provide(service.NewModelProviderService))
 	must(container.Provide(service.NewDatasetService))
 	must(container.Provide(service.NewEvaluationService))
 	must(container.Provide(service.NewUserService))
+	must(container.Provide(service.NewOIDCService))
+	must(container.Provide(service.NewLDAPService))
+	must(container.Provide(service.NewSearchAnalyticsService))
+	must(container.Provide(service.NewAnswerFeedbackService))
+	must(container.Provide(service.NewExperimentService))
+	must(container.Provide(service.NewRetrievalEvalService))
+	must(container.Provide(service.NewPromptTemplateService))
 
 	// Extract services - register individual extracters with names
 	must(container.Provide(service.NewChunkExtractService, dig.Name("chunkExtractor")))
@@ -165,6 +210,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	logger.Debugf(ctx, "[Container] Registering asynq client and server...")
 	must(container.Provide(router.NewAsyncqClient))
 	must(container.Provide(router.NewAsynqServer))
+	must(container.Provide(router.NewAsynqInspector))
+	must(container.Provide(service.NewJobEnqueuer))
 
 	// Chat pipeline components for processing chat requests
 	logger.Debugf(ctx, "[Container] Registering chat pipeline plugins...")
@@ -180,6 +227,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Invoke(chatpipline.NewPluginStreamFilter))
 	must(container.Invoke(chatpipline.NewPluginFilterTopK))
 	must(container.Invoke(chatpipline.NewPluginRewrite))
+	must(container.Invoke(chatpipline.NewPluginSemanticCache))
+	must(container.Invoke(chatpipline.NewPluginQueryExpansion))
 	must(container.Invoke(chatpipline.NewPluginLoadHistory))
 	must(container.Invoke(chatpipline.NewPluginExtractEntity))
 	must(container.Invoke(chatpipline.NewPluginSearchEntity))
@@ -202,17 +251,27 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewAuthHandler))
 	must(container.Provide(handler.NewSystemHandler))
 	must(container.Provide(handler.NewMCPServiceHandler))
+	must(container.Provide(handler.NewMCPServerHandler))
 	must(container.Provide(handler.NewWebSearchHandler))
 	must(container.Provide(handler.NewCustomAgentHandler))
 	must(container.Provide(service.NewSkillService))
 	must(container.Provide(handler.NewSkillHandler))
 	must(container.Provide(handler.NewOrganizationHandler))
+	must(container.Provide(handler.NewUsageHandler))
+	must(container.Provide(handler.NewModerationHandler))
+	must(container.Provide(handler.NewAPIKeyHandler))
+	must(container.Provide(handler.NewServiceAccountHandler))
+	must(container.Provide(handler.NewJobHandler))
+	must(container.Provide(handler.NewTenantBackupHandler))
+	must(container.Provide(handler.NewWebhookHandler))
+	must(container.Provide(handler.NewSystemStatsHandler))
+	must(container.Provide(handler.NewHealthHandler))
+	must(container.Provide(handler.NewModelProviderHandler))
 	logger.Debugf(ctx, "[Container] HTTP handlers registered")
 
-	// Router configuration
-	logger.Debugf(ctx, "[Container] Registering router and starting asynq server...")
+	// Router configuration, the asynq server and scheduler are started by the serve command
+	logger.Debugf(ctx, "[Container] Registering router...")
 	must(container.Provide(router.NewRouter))
-	must(container.Invoke(router.RunAsynqServer))
 
 	logger.Infof(ctx, "[Container] Container initialization completed successfully")
 	return container
@@ -329,10 +388,13 @@ func initDatabase(cfg *config.Config) (*gorm.DB, error) {
 	default:
 		return nil, fmt.Errorf("unsupported database driver: %s", os.Getenv("DB_DRIVER"))
 	}
-	db, err := gorm.Open(dialector, &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: slowlog.GormLogger()})
 	if err != nil {
 		return nil, err
 	}
+	if err := tenantguard.Register(db); err != nil {
+		return nil, fmt.Errorf("register tenant isolation audit: %w", err)
+	}
 
 	// Run database migrations automatically (optional, can be disabled via env var)
 	// To disable auto-migration, set AUTO_MIGRATE=false
@@ -435,13 +497,21 @@ func initFileService(cfg *config.Config) (interfaces.FileService, error) {
 //   - Error if initialization fails
 func initRetrieveEngineRegistry(db *gorm.DB, cfg *config.Config) (interfaces.RetrieveEngineRegistry, error) {
 	registry := retriever.NewRetrieveEngineRegistry()
+	var batchOptions retriever.BatchOptions
+	if cfg.VectorDatabase != nil {
+		batchOptions = retriever.BatchOptions{
+			UpsertBatchSize: cfg.VectorDatabase.UpsertBatchSize,
+			DeleteBatchSize: cfg.VectorDatabase.DeleteBatchSize,
+			Parallelism:     cfg.VectorDatabase.Parallelism,
+		}
+	}
 	retrieveDriver := strings.Split(os.Getenv("RETRIEVE_DRIVER"), ",")
 	log := logger.GetLogger(context.Background())
 
 	if slices.Contains(retrieveDriver, "postgres") {
 		postgresRepo := postgresRepo.NewPostgresRetrieveEngineRepository(db)
 		if err := registry.Register(
-			retriever.NewKVHybridRetrieveEngine(postgresRepo, types.PostgresRetrieverEngineType),
+			retriever.NewKVHybridRetrieveEngineWithOptions(postgresRepo, types.PostgresRetrieverEngineType, batchOptions),
 		); err != nil {
 			log.Errorf("Register postgres retrieve engine failed: %v", err)
 		} else {
@@ -459,8 +529,8 @@ func initRetrieveEngineRegistry(db *gorm.DB, cfg *config.Config) (interfaces.Ret
 		} else {
 			elasticsearchRepo := elasticsearchRepoV8.NewElasticsearchEngineRepository(client, cfg)
 			if err := registry.Register(
-				retriever.NewKVHybridRetrieveEngine(
-					elasticsearchRepo, types.ElasticsearchRetrieverEngineType,
+				retriever.NewKVHybridRetrieveEngineWithOptions(
+					elasticsearchRepo, types.ElasticsearchRetrieverEngineType, batchOptions,
 				),
 			); err != nil {
 				log.Errorf("Register elasticsearch_v8 retrieve engine failed: %v", err)
@@ -481,8 +551,8 @@ func initRetrieveEngineRegistry(db *gorm.DB, cfg *config.Config) (interfaces.Ret
 		} else {
 			elasticsearchRepo := elasticsearchRepoV7.NewElasticsearchEngineRepository(client, cfg)
 			if err := registry.Register(
-				retriever.NewKVHybridRetrieveEngine(
-					elasticsearchRepo, types.ElasticsearchRetrieverEngineType,
+				retriever.NewKVHybridRetrieveEngineWithOptions(
+					elasticsearchRepo, types.ElasticsearchRetrieverEngineType, batchOptions,
 				),
 			); err != nil {
 				log.Errorf("Register elasticsearch_v7 retrieve engine failed: %v", err)
@@ -529,8 +599,8 @@ func initRetrieveEngineRegistry(db *gorm.DB, cfg *config.Config) (interfaces.Ret
 		} else {
 			qdrantRepository := qdrantRepo.NewQdrantRetrieveEngineRepository(client)
 			if err := registry.Register(
-				retriever.NewKVHybridRetrieveEngine(
-					qdrantRepository, types.QdrantRetrieverEngineType,
+				retriever.NewKVHybridRetrieveEngineWithOptions(
+					qdrantRepository, types.QdrantRetrieverEngineType, batchOptions,
 				),
 			); err != nil {
 				log.Errorf("Register qdrant retrieve engine failed: %v", err)
@@ -576,6 +646,41 @@ func registerPoolCleanup(pool *ants.Pool, cleaner interfaces.ResourceCleaner) {
 	})
 }
 
+// registerBrowserCleanup closes the headless browsers still open at shutdown, so their processes are not orphaned.
+// It is registered early, so it runs after background tasks have been drained.
+func registerBrowserCleanup(cleaner interfaces.ResourceCleaner) {
+	cleaner.RegisterWithName("BrowserSessions", func() error {
+		if n := tools.CloseBrowserSessions(); n > 0 {
+			logger.Infof(context.Background(), "Closed %d headless browser sessions", n)
+		}
+		return nil
+	})
+}
+
+// startConfigWatcher watches the config file and applies its reloadable sections while the server runs
+func startConfigWatcher(watcher *config.Watcher, cleaner interfaces.ResourceCleaner) {
+	watcher.Start()
+	cleaner.RegisterWithName("ConfigWatcher", func() error {
+		watcher.Stop()
+		return nil
+	})
+}
+
+// initReplica identifies this replica to the others, which coordinate session and document parse ownership with it
+// through Redis
+func initReplica(redisClient *redis.Client, cfg *config.Config) *cluster.Replica {
+	var options cluster.Options
+	if cfg.Cluster != nil {
+		options = cluster.Options{
+			AdvertiseURL: cfg.Cluster.AdvertiseURL,
+			ClaimTTL:     cfg.Cluster.ClaimTTL,
+		}
+	}
+	replica := cluster.NewReplicaWithOptions(redisClient, options)
+	logger.Infof(context.Background(), "Running as replica %s", replica.ID())
+	return replica
+}
+
 // initDocReaderClient initializes the document reader client
 // Creates a client for interacting with the document reader service
 // Parameters:
@@ -590,7 +695,26 @@ func initDocReaderClient(cfg *config.Config) (*client.Client, error) {
 	if docReaderURL == "" && cfg.DocReader != nil {
 		docReaderURL = cfg.DocReader.Addr
 	}
-	return client.NewClient(docReaderURL)
+	var options client.Options
+	if cfg.DocReader != nil {
+		options = client.Options{
+			Connections:      cfg.DocReader.Connections,
+			MaxInFlight:      cfg.DocReader.MaxInFlight,
+			QueueTimeout:     cfg.DocReader.QueueTimeout,
+			FailureThreshold: cfg.DocReader.FailureThreshold,
+			OpenDuration:     cfg.DocReader.OpenDuration,
+		}
+	}
+	return client.NewClientWithOptions(docReaderURL, options)
+}
+
+// startParserPluginSync keeps the file types accepted for upload in sync with the parser plugins DocReader reports
+func startParserPluginSync(docReader *client.Client, cleaner interfaces.ResourceCleaner) {
+	stop := docReader.WatchParserPluginFileTypes(time.Minute, types.SetParserPluginFileTypes)
+	cleaner.RegisterWithName("ParserPluginSync", func() error {
+		stop()
+		return nil
+	})
 }
 
 // initOllamaService initializes the Ollama service client
@@ -0,0 +1,165 @@
+package errors
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/i18n"
+)
+
+// CodeInfo describes an error code of the catalogue
+type CodeInfo struct {
+	// Reason is the stable machine-readable name of the code, clients can branch on it
+	Reason string
+	// HTTPCode is the HTTP status errors of the code answer with
+	HTTPCode int
+	// Messages are the default messages of the code by language
+	Messages map[i18n.Language]string
+}
+
+// catalogue holds every error code returned by the API. Codes and reasons are part of the API contract: add new
+// ones, never renumber or rename existing ones.
+var catalogue = map[ErrorCode]CodeInfo{
+	ErrBadRequest: {"bad_request", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "请求参数错误", i18n.English: "Invalid request",
+	}},
+	ErrUnauthorized: {"unauthorized", http.StatusUnauthorized, map[i18n.Language]string{
+		i18n.Chinese: "未登录或登录已失效", i18n.English: "Authentication required",
+	}},
+	ErrForbidden: {"forbidden", http.StatusForbidden, map[i18n.Language]string{
+		i18n.Chinese: "权限不足", i18n.English: "Insufficient permissions",
+	}},
+	ErrNotFound: {"not_found", http.StatusNotFound, map[i18n.Language]string{
+		i18n.Chinese: "资源不存在", i18n.English: "Resource not found",
+	}},
+	ErrMethodNotAllowed: {"method_not_allowed", http.StatusMethodNotAllowed, map[i18n.Language]string{
+		i18n.Chinese: "不支持的请求方法", i18n.English: "Method not allowed",
+	}},
+	ErrConflict: {"conflict", http.StatusConflict, map[i18n.Language]string{
+		i18n.Chinese: "资源冲突", i18n.English: "Resource conflict",
+	}},
+	ErrTooManyRequests: {"too_many_requests", http.StatusTooManyRequests, map[i18n.Language]string{
+		i18n.Chinese: "请求过于频繁，请稍后再试", i18n.English: "Too many requests, please retry later",
+	}},
+	ErrInternalServer: {"internal_error", http.StatusInternalServerError, map[i18n.Language]string{
+		i18n.Chinese: "服务器内部错误", i18n.English: "Internal server error",
+	}},
+	ErrServiceUnavailable: {"service_unavailable", http.StatusServiceUnavailable, map[i18n.Language]string{
+		i18n.Chinese: "服务暂不可用", i18n.English: "Service unavailable",
+	}},
+	ErrTimeout: {"timeout", http.StatusGatewayTimeout, map[i18n.Language]string{
+		i18n.Chinese: "请求超时", i18n.English: "Request timed out",
+	}},
+	ErrValidation: {"validation_failed", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "参数校验失败", i18n.English: "Validation failed",
+	}},
+	ErrInvalidAPIKey: {"invalid_api_key", http.StatusUnauthorized, map[i18n.Language]string{
+		i18n.Chinese: "API Key 无效或已过期", i18n.English: "Invalid or expired API key",
+	}},
+	ErrAPIKeyScopeDenied: {"api_key_scope_denied", http.StatusForbidden, map[i18n.Language]string{
+		i18n.Chinese: "API Key 的权限范围不允许该操作",
+		i18n.English: "The scope of the API key does not allow this operation",
+	}},
+	ErrIPNotAllowed: {"ip_not_allowed", http.StatusForbidden, map[i18n.Language]string{
+		i18n.Chinese: "当前 IP 地址不允许访问该租户", i18n.English: "Your IP address is not allowed for this tenant",
+	}},
+	ErrIdempotencyKeyBusy: {"idempotency_key_in_progress", http.StatusConflict, map[i18n.Language]string{
+		i18n.Chinese: "使用该幂等键的请求仍在处理中，请稍后重试",
+		i18n.English: "A request with this idempotency key is still in progress, please retry later",
+	}},
+	ErrIdempotencyKeyUsed: {"idempotency_key_reused", http.StatusUnprocessableEntity, map[i18n.Language]string{
+		i18n.Chinese: "该幂等键已用于其他请求",
+		i18n.English: "The idempotency key was already used for a different request",
+	}},
+
+	ErrTenantNotFound: {"tenant_not_found", http.StatusNotFound, map[i18n.Language]string{
+		i18n.Chinese: "租户不存在", i18n.English: "Tenant not found",
+	}},
+	ErrTenantAlreadyExists: {"tenant_already_exists", http.StatusConflict, map[i18n.Language]string{
+		i18n.Chinese: "租户已存在", i18n.English: "Tenant already exists",
+	}},
+	ErrTenantInactive: {"tenant_inactive", http.StatusForbidden, map[i18n.Language]string{
+		i18n.Chinese: "租户已停用", i18n.English: "Tenant is inactive",
+	}},
+	ErrTenantNameRequired: {"tenant_name_required", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "租户名称不能为空", i18n.English: "Tenant name is required",
+	}},
+	ErrTenantInvalidStatus: {"tenant_invalid_status", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "租户状态无效", i18n.English: "Invalid tenant status",
+	}},
+
+	ErrAgentMissingThinkingModel: {"agent_missing_thinking_model", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "启用Agent模式前，请先选择思考模型",
+		i18n.English: "Select a thinking model before enabling agent mode",
+	}},
+	ErrAgentMissingAllowedTools: {"agent_missing_allowed_tools", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "至少需要选择一个允许的工具", i18n.English: "Select at least one allowed tool",
+	}},
+	ErrAgentInvalidMaxIterations: {"agent_invalid_max_iterations", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "最大迭代次数必须在1-20之间", i18n.English: "Max iterations must be between 1 and 20",
+	}},
+	ErrAgentInvalidTemperature: {"agent_invalid_temperature", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "温度参数必须在0-2之间", i18n.English: "Temperature must be between 0 and 2",
+	}},
+
+	ErrTokenBudgetExceeded: {"token_budget_exceeded", http.StatusTooManyRequests, map[i18n.Language]string{
+		i18n.Chinese: "本月 Token 用量已达上限", i18n.English: "The monthly token budget is used up",
+	}},
+
+	ErrContentBlocked: {"content_blocked", http.StatusBadRequest, map[i18n.Language]string{
+		i18n.Chinese: "内容未通过审核", i18n.English: "The content was blocked by moderation",
+	}},
+
+	ErrKnowledgeBaseQuotaExceeded: {"knowledge_base_quota_exceeded", http.StatusForbidden, map[i18n.Language]string{
+		i18n.Chinese: "知识库数量已达上限", i18n.English: "The knowledge base quota is reached",
+	}},
+	ErrKnowledgeQuotaExceeded: {"knowledge_quota_exceeded", http.StatusForbidden, map[i18n.Language]string{
+		i18n.Chinese: "文档数量已达上限", i18n.English: "The document quota is reached",
+	}},
+	ErrParseJobQuotaExceeded: {"parse_job_quota_exceeded", http.StatusTooManyRequests, map[i18n.Language]string{
+		i18n.Chinese: "同时解析的文档数已达上限，请稍后再试",
+		i18n.English: "Too many documents are being parsed, please retry later",
+	}},
+	ErrBrowserSessionQuotaExceeded: {"browser_session_quota_exceeded", http.StatusTooManyRequests,
+		map[i18n.Language]string{
+			i18n.Chinese: "同时打开的浏览器会话数已达上限，请稍后再试",
+			i18n.English: "Too many browser sessions are open, please retry later",
+		}},
+	ErrStorageQuotaExceeded: {"storage_quota_exceeded", http.StatusForbidden, map[i18n.Language]string{
+		i18n.Chinese: "存储空间不足", i18n.English: "The storage quota is reached",
+	}},
+}
+
+// Lookup returns the catalogue entry of an error code
+func Lookup(code ErrorCode) (CodeInfo, bool) {
+	info, ok := catalogue[code]
+	return info, ok
+}
+
+// Catalogue returns every error code with its catalogue entry
+func Catalogue() map[ErrorCode]CodeInfo {
+	codes := make(map[ErrorCode]CodeInfo, len(catalogue))
+	for code, info := range catalogue {
+		codes[code] = info
+	}
+	return codes
+}
+
+// Reason returns the machine-readable name of an error code, internal_error for codes outside the catalogue
+func (c ErrorCode) Reason() string {
+	if info, ok := catalogue[c]; ok {
+		return info.Reason
+	}
+	return catalogue[ErrInternalServer].Reason
+}
+
+// DefaultMessage returns the default message of an error code in a language
+func (c ErrorCode) DefaultMessage(lang i18n.Language) string {
+	info, ok := catalogue[c]
+	if !ok {
+		info = catalogue[ErrInternalServer]
+	}
+	if msg, ok := info.Messages[lang]; ok {
+		return msg
+	}
+	return info.Messages[i18n.DefaultLanguage]
+}
@@ -3,6 +3,8 @@ package errors
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/i18n"
 )
 
 // ErrorCode defines the error code type
@@ -22,6 +24,11 @@ const (
 	ErrServiceUnavailable ErrorCode = 1008
 	ErrTimeout            ErrorCode = 1009
 	ErrValidation         ErrorCode = 1010
+	ErrInvalidAPIKey      ErrorCode = 1011
+	ErrAPIKeyScopeDenied  ErrorCode = 1012
+	ErrIPNotAllowed       ErrorCode = 1013
+	ErrIdempotencyKeyBusy ErrorCode = 1014
+	ErrIdempotencyKeyUsed ErrorCode = 1015
 
 	// Tenant related error codes (2000-2099)
 	ErrTenantNotFound      ErrorCode = 2000
@@ -36,7 +43,20 @@ const (
 	ErrAgentInvalidMaxIterations ErrorCode = 2102
 	ErrAgentInvalidTemperature   ErrorCode = 2103
 
-	// Add more error codes here
+	// Usage related error codes (2200-2299)
+	ErrTokenBudgetExceeded ErrorCode = 2200
+
+	// Moderation related error codes (2300-2399)
+	ErrContentBlocked ErrorCode = 2300
+
+	// Quota related error codes (2400-2499)
+	ErrKnowledgeBaseQuotaExceeded  ErrorCode = 2400
+	ErrKnowledgeQuotaExceeded      ErrorCode = 2401
+	ErrParseJobQuotaExceeded       ErrorCode = 2402
+	ErrBrowserSessionQuotaExceeded ErrorCode = 2403
+	ErrStorageQuotaExceeded        ErrorCode = 2404
+
+	// Add more error codes here, with their reason and messages in the catalogue
 )
 
 // AppError defines the application error structure
@@ -124,6 +144,60 @@ func NewValidationError(message string) *AppError {
 	}
 }
 
+// NewTooManyRequestsError creates a too many requests error
+func NewTooManyRequestsError(message string) *AppError {
+	return &AppError{
+		Code:     ErrTooManyRequests,
+		Message:  message,
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
+// NewInvalidAPIKeyError creates an error for a request authenticated with an unknown, revoked or expired API key
+func NewInvalidAPIKeyError(message string) *AppError {
+	return &AppError{
+		Code:     ErrInvalidAPIKey,
+		Message:  message,
+		HTTPCode: http.StatusUnauthorized,
+	}
+}
+
+// NewAPIKeyScopeDeniedError creates an error for a request the scope of its API key does not allow
+func NewAPIKeyScopeDeniedError() *AppError {
+	return &AppError{
+		Code:     ErrAPIKeyScopeDenied,
+		Message:  ErrAPIKeyScopeDenied.DefaultMessage(i18n.DefaultLanguage),
+		HTTPCode: http.StatusForbidden,
+	}
+}
+
+// NewIPNotAllowedError creates an error for a request from an address the tenant does not allow
+func NewIPNotAllowedError() *AppError {
+	return &AppError{
+		Code:     ErrIPNotAllowed,
+		Message:  ErrIPNotAllowed.DefaultMessage(i18n.DefaultLanguage),
+		HTTPCode: http.StatusForbidden,
+	}
+}
+
+// NewIdempotencyKeyBusyError creates an error for a retry sent while the request of its idempotency key still runs
+func NewIdempotencyKeyBusyError() *AppError {
+	return &AppError{
+		Code:     ErrIdempotencyKeyBusy,
+		Message:  ErrIdempotencyKeyBusy.DefaultMessage(i18n.DefaultLanguage),
+		HTTPCode: http.StatusConflict,
+	}
+}
+
+// NewIdempotencyKeyUsedError creates an error for an idempotency key reused for a different request
+func NewIdempotencyKeyUsedError() *AppError {
+	return &AppError{
+		Code:     ErrIdempotencyKeyUsed,
+		Message:  ErrIdempotencyKeyUsed.DefaultMessage(i18n.DefaultLanguage),
+		HTTPCode: http.StatusUnprocessableEntity,
+	}
+}
+
 // Tenant related errors
 func NewTenantNotFoundError() *AppError {
 	return &AppError{
@@ -184,6 +258,38 @@ func NewAgentInvalidTemperatureError() *AppError {
 	}
 }
 
+// NewTokenBudgetExceededError creates an error for a tenant that used up its monthly token budget
+func NewTokenBudgetExceededError() *AppError {
+	return &AppError{
+		Code:     ErrTokenBudgetExceeded,
+		Message:  "本月 Token 用量已达上限",
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
+// NewContentBlockedError creates an error for a question rejected by content moderation
+func NewContentBlockedError(message string) *AppError {
+	return &AppError{
+		Code:     ErrContentBlocked,
+		Message:  message,
+		HTTPCode: http.StatusBadRequest,
+	}
+}
+
+// NewQuotaExceededError creates an error for a tenant that reached one of its quotas.
+// Caps on concurrent work answer 429 since retrying later succeeds, caps on counts answer 403.
+func NewQuotaExceededError(code ErrorCode, message string) *AppError {
+	httpCode := http.StatusForbidden
+	if code == ErrParseJobQuotaExceeded || code == ErrBrowserSessionQuotaExceeded {
+		httpCode = http.StatusTooManyRequests
+	}
+	return &AppError{
+		Code:     code,
+		Message:  message,
+		HTTPCode: httpCode,
+	}
+}
+
 // IsAppError checks if the error is an AppError type
 func IsAppError(err error) (*AppError, bool) {
 	appErr, ok := err.(*AppError)
@@ -0,0 +1,138 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/i18n"
+)
+
+// ErrorBody is the error object of failed API responses:
+//
+//	{"success": false, "error": {"code": 1003, "reason": "not_found", "message": "...", "details": ...}}
+type ErrorBody struct {
+	// Code is the numeric code of the error catalogue
+	Code ErrorCode `json:"code"`
+	// Reason is the stable machine-readable name of the code
+	Reason string `json:"reason"`
+	// Message is readable by users, in the language of the request
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// Localize returns the response body of the error with its message in a language
+func (e *AppError) Localize(lang i18n.Language) ErrorBody {
+	return ErrorBody{
+		Code:    e.Code,
+		Reason:  e.Code.Reason(),
+		Message: localizeMessage(e.Code, e.Message, lang),
+		Details: e.Details,
+	}
+}
+
+// localizeMessage translates the message of an error. Messages are written in Chinese or English; Chinese messages
+// without a translation fall back to the default message of the code for other languages, since they are unreadable
+// to their users, while English ones are kept.
+func localizeMessage(code ErrorCode, msg string, lang i18n.Language) string {
+	if msg == "" {
+		return code.DefaultMessage(lang)
+	}
+	if info, ok := catalogue[code]; ok {
+		for _, defaultMsg := range info.Messages {
+			if msg == defaultMsg {
+				return code.DefaultMessage(lang)
+			}
+		}
+	}
+	if lang == i18n.Chinese {
+		return msg
+	}
+	if bundle, ok := messageBundles[lang]; ok {
+		if translated, ok := bundle.translate(msg); ok {
+			return translated
+		}
+	}
+	if containsHan(msg) {
+		return code.DefaultMessage(lang)
+	}
+	return msg
+}
+
+// containsHan reports whether a message contains Chinese characters
+func containsHan(msg string) bool {
+	return strings.IndexFunc(msg, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0
+}
+
+// messageBundle translates the Chinese messages of errors into a language
+type messageBundle struct {
+	exact    map[string]string
+	patterns []messagePattern
+}
+
+// messagePattern translates messages built from a format, the arguments are carried into the translation
+type messagePattern struct {
+	source *regexp.Regexp
+	target string
+}
+
+// formatVerb matches the verbs of the formats of messages
+var formatVerb = regexp.MustCompile(`%[sdv]`)
+
+// newMessageBundle compiles translations keyed by the message or its format. Formats may hold %s, %d or %v verbs,
+// their translations take the arguments in the same order as %s verbs. A key ending in ": " is a prefix, the rest
+// of the message, usually an underlying error, is kept as is.
+func newMessageBundle(translations map[string]string) *messageBundle {
+	bundle := &messageBundle{exact: make(map[string]string)}
+	for source, target := range translations {
+		isPrefix := strings.HasSuffix(source, ": ")
+		if !isPrefix && !formatVerb.MatchString(source) {
+			bundle.exact[source] = target
+			continue
+		}
+		parts := formatVerb.Split(source, -1)
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		expr := strings.Join(parts, "(.+?)")
+		if isPrefix {
+			expr += "(.*)"
+			target += "%s"
+		}
+		bundle.patterns = append(bundle.patterns, messagePattern{
+			source: regexp.MustCompile("^" + expr + "$"),
+			target: target,
+		})
+	}
+	// Longer formats are more specific, try them first
+	sort.Slice(bundle.patterns, func(i, j int) bool {
+		return len(bundle.patterns[i].source.String()) > len(bundle.patterns[j].source.String())
+	})
+	return bundle
+}
+
+// translate returns the translation of a message, if it has one
+func (b *messageBundle) translate(msg string) (string, bool) {
+	if target, ok := b.exact[msg]; ok {
+		return target, true
+	}
+	for _, pattern := range b.patterns {
+		match := pattern.source.FindStringSubmatch(msg)
+		if match == nil {
+			continue
+		}
+		args := make([]any, len(match)-1)
+		for i, arg := range match[1:] {
+			args[i] = arg
+		}
+		return fmt.Sprintf(pattern.target, args...), true
+	}
+	return "", false
+}
+
+// messageBundles holds the translations of error messages by language
+var messageBundles = map[i18n.Language]*messageBundle{
+	i18n.English: newMessageBundle(englishMessages),
+}
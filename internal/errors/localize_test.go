@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/i18n"
+)
+
+func TestCatalogue(t *testing.T) {
+	reasons := make(map[string]ErrorCode)
+	for code, info := range Catalogue() {
+		if other, ok := reasons[info.Reason]; ok {
+			t.Errorf("codes %d and %d share reason %q", code, other, info.Reason)
+		}
+		reasons[info.Reason] = code
+		for _, lang := range i18n.Languages {
+			if info.Messages[lang] == "" {
+				t.Errorf("code %d has no %s message", code, lang)
+			}
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	cases := []struct {
+		err  *AppError
+		lang i18n.Language
+		want string
+	}{
+		{NewTenantNotFoundError(), i18n.English, "Tenant not found"},
+		{NewTenantNotFoundError(), i18n.Chinese, "租户不存在"},
+		{NewInternalServerError(""), i18n.English, "Internal server error"},
+		{NewNotFoundError("知识库不存在"), i18n.English, "Knowledge base not found"},
+		{NewNotFoundError("标签 42 不存在"), i18n.English, "Tag 42 not found"},
+		{NewConflictError("标准问「如何退款」已存在"), i18n.English, "The standard question \"如何退款\" already exists"},
+		{NewInternalServerError("更新知识库失败: record not found"), i18n.English,
+			"Failed to update the knowledge base: record not found"},
+		// Untranslated Chinese messages fall back to the message of the code, English ones are kept
+		{NewBadRequestError("未翻译的错误"), i18n.English, "Invalid request"},
+		{NewBadRequestError("Invalid tenant_id"), i18n.English, "Invalid tenant_id"},
+		{NewBadRequestError("Invalid tenant_id"), i18n.Chinese, "Invalid tenant_id"},
+	}
+	for _, c := range cases {
+		body := c.err.Localize(c.lang)
+		if body.Message != c.want {
+			t.Errorf("Localize(%q, %s) = %q, want %q", c.err.Message, c.lang, body.Message, c.want)
+		}
+		if body.Code != c.err.Code || body.Reason != c.err.Code.Reason() {
+			t.Errorf("Localize(%q) changed the code to %d %q", c.err.Message, body.Code, body.Reason)
+		}
+	}
+}
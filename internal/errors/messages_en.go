@@ -0,0 +1,163 @@
+package errors
+
+// englishMessages translates the Chinese messages of errors into English, keyed by the message or its format.
+// Add the translation of a Chinese message when adding the message.
+var englishMessages = map[string]string{
+	// Requests
+	"请求参数不合法":                     "Invalid request parameters",
+	"请求体不能为空":                     "The request body is required",
+	"请求内容不能为空":                    "The request content is required",
+	"表单参数解析失败":                    "Failed to parse the form parameters",
+	"分页参数不合法":                     "Invalid pagination parameters",
+	"内容不能为空":                      "The content is required",
+	"内容长度超出限制（最多%d个字符）":           "The content is too long (at most %s characters)",
+	"过期时间不能早于当前时间":                "The expiry time must not be in the past",
+	"不支持的操作模式: %s":                "Unsupported operation mode: %s",
+	"不支持的语言: ":                    "Unsupported language: ",
+	"Idempotency-Key 长度不能超过%d个字符": "The Idempotency-Key must not exceed %s characters",
+	"任务ID不能为空":                    "The task ID is required",
+	"下载任务不存在":                     "Download task not found",
+
+	// Knowledge bases
+	"知识库不存在":                        "Knowledge base not found",
+	"知识库ID不能为空":                     "The knowledge base ID is required",
+	"知识库 ID 不能为空":                   "The knowledge base ID is required",
+	"无权访问该知识库":                      "No access to this knowledge base",
+	"获取知识库信息失败: ":                   "Failed to get the knowledge base: ",
+	"更新知识库失败: ":                     "Failed to update the knowledge base: ",
+	"更新知识库配置失败: ":                   "Failed to update the knowledge base configuration: ",
+	"知识库中已有文件，无法修改Embedding模型":      "The knowledge base has files, its embedding model cannot be changed",
+	"知识库中有文档正在解析，请稍后再试":             "Documents of the knowledge base are being parsed, please retry later",
+	"目标知识库与当前知识库相同":                 "The target knowledge base is the current one",
+	"知识库导出包大小不能超过1GB":               "The knowledge base bundle must not exceed 1GB",
+	"知识库导出包清单无法解析":                  "The manifest of the knowledge base bundle cannot be parsed",
+	"导入文件不是有效的 zip 压缩包":             "The imported file is not a valid zip archive",
+	"导入文件缺少 manifest.json，不是知识库导出包": "The imported file has no manifest.json, it is not a knowledge base bundle",
+	"该知识库已有导入任务正在进行中（任务ID: %s），请等待完成后再试": "An import into the knowledge base is running " +
+		"(task ID: %s), please wait for it to finish",
+	"该知识库已有重建索引任务在运行":       "The knowledge base is already being reindexed",
+	"该知识库已有正在运行的实验，请先停止或晋升": "The knowledge base has a running experiment, stop or promote it first",
+	"实验已停止，无法晋升":            "The experiment is stopped and cannot be promoted",
+
+	// Knowledge
+	"知识不存在":      "Knowledge not found",
+	"知识ID列表不能为空": "The knowledge ID list is required",
+	"筛选条件匹配的知识超过%d条，请缩小范围": "More than %s knowledge entries match the filter, narrow it down",
+	"知识已在回收站中":             "The knowledge is already in the trash",
+	"知识正在处理中，请稍后重试":        "The knowledge is being processed, please retry later",
+	"知识正在被删除，无法恢复":         "The knowledge is being deleted and cannot be restored",
+	"知识正在解析中，无法移入回收站，请稍后重试或彻底删除": "The knowledge is being parsed and cannot be moved to the trash, " +
+		"retry later or delete it permanently",
+	"仅支持手工知识的在线编辑":          "Only manual knowledge can be edited online",
+	"无法获取手工知识内容":            "Failed to get the content of the manual knowledge",
+	"只能移动或复制本租户的知识":         "Only knowledge of the current tenant can be moved or copied",
+	"只能移动或复制到同一租户下的知识库":     "Knowledge can only be moved or copied to knowledge bases of the same tenant",
+	"不能移动或复制到FAQ知识库":        "Knowledge cannot be moved or copied to a FAQ knowledge base",
+	"文件名包含非法字符":             "The file name contains invalid characters",
+	"文件大小不能超过%dMB":          "The file must not exceed %sMB",
+	"标题包含非法字符或超出长度限制":       "The title contains invalid characters or is too long",
+	"段落 %d 包含非法内容":          "Paragraph %s contains invalid content",
+	"状态仅支持 draft 或 publish": "The status must be draft or publish",
+	"分块内容不能为空":              "The chunk content is required",
+	"文本内容不能为空":              "The text content is required",
+	"文本内容长度不能超过5000字符":      "The text content must not exceed 5000 characters",
+	"只允许上传图片文件":             "Only image files can be uploaded",
+	"图片文件大小不能超过10MB":        "The image must not exceed 10MB",
+	"图片文件大小不能超过%dMB":        "The image must not exceed %sMB",
+	"读取图片文件失败":              "Failed to read the image file",
+	"获取上传图片失败":              "Failed to get the uploaded image",
+	"上传图片文件需要设置VLM模型":       "Uploading images requires a VLM model",
+	"上传图片文件需要完整的对象存储配置信息, 请前往系统设置页面进行补全": "Uploading images requires a complete object storage " +
+		"configuration, please complete it in the system settings",
+
+	// FAQ
+	"FAQ条目不存在":                             "FAQ entry not found",
+	"FAQ 条目不能为空":                           "The FAQ entries are required",
+	"FAQ 条目请通过 FAQ 接口编辑":                   "Edit FAQ entries through the FAQ API",
+	"FAQ 知识库暂不支持重建向量索引":                    "FAQ knowledge bases do not support reindexing yet",
+	"FAQ 知识库请使用 FAQ 导入功能":                  "Use the FAQ import for FAQ knowledge bases",
+	"FAQ 知识库请使用 FAQ 导出功能":                  "Use the FAQ export for FAQ knowledge bases",
+	"仅 FAQ 知识库支持该操作":                       "Only FAQ knowledge bases support this operation",
+	"仅支持更新 FAQ 条目":                         "Only FAQ entries can be updated",
+	"仅支持更新 FAQ 条目标签":                       "Only tags of FAQ entries can be updated",
+	"包含无效的 FAQ 条目":                         "Some FAQ entries are invalid",
+	"无权操作该 FAQ 条目":                         "No access to this FAQ entry",
+	"请选择需要删除的 FAQ 条目":                      "Select the FAQ entries to delete",
+	"获取 FAQ 元数据失败":                         "Failed to get the FAQ metadata",
+	"条目ID不能为空":                             "The entry ID is required",
+	"entry_id 必须是整数":                       "entry_id must be an integer",
+	"模式仅支持 append 或 replace":               "The mode must be append or replace",
+	"标准问不能为空":                              "The standard question is required",
+	"标准问「%s」已存在":                           "The standard question \"%s\" already exists",
+	"标准问「%s」与已有相似问重复":                      "The standard question \"%s\" duplicates an existing similar question",
+	"相似问列表不能为空":                            "The similar question list is required",
+	"相似问「%s」已存在":                           "The similar question \"%s\" already exists",
+	"相似问「%s」重复":                            "The similar question \"%s\" is duplicated",
+	"相似问「%s」不能与标准问相同":                      "The similar question \"%s\" must differ from the standard question",
+	"相似问「%s」与已有标准问重复":                      "The similar question \"%s\" duplicates an existing standard question",
+	"至少提供一个答案":                             "Provide at least one answer",
+	"answer_strategy 必须是 'all' 或 'random'": "answer_strategy must be 'all' or 'random'",
+	"batches_per_minute 不能为负数":             "batches_per_minute must not be negative",
+
+	// Tags
+	"标签不存在":               "Tag not found",
+	"标签 %d 不存在":           "Tag %s not found",
+	"标签 %s 不存在":           "Tag %s not found",
+	"标签不属于当前知识库":          "The tag does not belong to the knowledge base",
+	"标签 %d 不属于当前知识库":      "Tag %s does not belong to the knowledge base",
+	"标签 %s 不属于知识库 %s":     "Tag %s does not belong to knowledge base %s",
+	"标签ID不能为空":            "The tag ID is required",
+	"标签ID列表不能为空":          "The tag ID list is required",
+	"tag_id 必须是整数":        "tag_id must be an integer",
+	"标签名称不能为空":            "The tag name is required",
+	"标签名称已存在":             "The tag name already exists",
+	"知识库ID和标签名称不能为空":      "The knowledge base ID and tag name are required",
+	"标签层级过深":              "Tags are nested too deeply",
+	"父标签不存在":              "Parent tag not found",
+	"父标签不属于当前知识库":         "The parent tag does not belong to the knowledge base",
+	"不能将标签移动到其自身或子标签下":    "A tag cannot be moved under itself or its children",
+	"标签仍有知识或FAQ条目引用，无法删除": "The tag is still used by knowledge or FAQ entries and cannot be deleted",
+	"获取标签下的文档失败":          "Failed to get the documents of the tag",
+	"删除标签下的文档失败":          "Failed to delete the documents of the tag",
+	"删除标签下的数据失败":          "Failed to delete the data of the tag",
+
+	// Models
+	"LLM模型不存在":              "LLM model not found",
+	"Embedding模型不存在":        "Embedding model not found",
+	"向量模型不存在":               "Embedding model not found",
+	"所选模型不是向量模型":            "The selected model is not an embedding model",
+	"目标向量模型不存在":             "The target embedding model was not found",
+	"目标模型不是向量模型":            "The target model is not an embedding model",
+	"目标向量模型与当前模型相同":         "The target embedding model is the current one",
+	"模型名称和Base URL不能为空":     "The model name and base URL are required",
+	"VLM模型名称和Base URL不能为空":  "The VLM model name and base URL are required",
+	"创建模型失败: ":              "Failed to create the model: ",
+	"更新模型失败: ":              "Failed to update the model: ",
+	"获取模型列表失败: ":            "Failed to list the models: ",
+	"检查模型状态失败: ":            "Failed to check the model status: ",
+	"Ollama服务不可用: ":         "The Ollama service is unavailable: ",
+	"启用多模态时需要配置VLM信息":       "Multimodal parsing requires a VLM configuration",
+	"VLM配置不完整":              "The VLM configuration is incomplete",
+	"Rerank配置不完整":           "The rerank configuration is incomplete",
+	"Node Extractor配置不完整":   "The node extractor configuration is incomplete",
+	"COS配置不完整":              "The COS configuration is incomplete",
+	"COS配置信息不能为空":           "The COS configuration is required",
+	"MinIO配置不完整":            "The MinIO configuration is incomplete",
+	"MinIO配置信息不能为空":         "The MinIO configuration is required",
+	"无效的存储类型":               "Invalid storage type",
+	"请正确配置环境变量NEO4J_ENABLE": "Set the NEO4J_ENABLE environment variable correctly",
+	"文本关系提取失败: ":            "Failed to extract relations from the text: ",
+	"文本关系提取请求参数错误":          "Invalid relation extraction request",
+	"生成示例文本失败: ":            "Failed to generate the sample text: ",
+	"生成示例文本请求参数错误":          "Invalid sample text request",
+	"请先提取实体和关系":             "Extract entities and relations first",
+	"至少需要选择一个关系标签":          "Select at least one relation tag",
+
+	// Organizations
+	"该空间成员已满，无法加入":     "The space is full and cannot be joined",
+	"该空间成员已满，无法提交加入申请": "The space is full, join requests cannot be submitted",
+	"该空间成员已满，无法添加新成员":  "The space is full, members cannot be added",
+	"空间成员已满，无法通过该加入申请": "The space is full, the join request cannot be approved",
+	"当前成员数已超过新的上限，请先移除成员或设置更大的上限": "The space has more members than the new limit, " +
+		"remove members or set a larger limit",
+}
@@ -47,12 +47,14 @@ const (
 	EventAgentComplete EventType = "agent.complete" // Agent 完成
 
 	// Agent streaming events (for real-time feedback)
-	EventAgentThought     EventType = "thought"      // Agent 思考过程
-	EventAgentToolCall    EventType = "tool_call"    // 工具调用通知
-	EventAgentToolResult  EventType = "tool_result"  // 工具结果
-	EventAgentReflection  EventType = "reflection"   // Agent 反思
-	EventAgentReferences  EventType = "references"   // 知识引用
-	EventAgentFinalAnswer EventType = "final_answer" // 最终答案
+	EventAgentThought     EventType = "thought"             // Agent 思考过程
+	EventAgentToolCall    EventType = "tool_call"           // 工具调用通知
+	EventAgentToolResult  EventType = "tool_result"         // 工具结果
+	EventAgentReflection  EventType = "reflection"          // Agent 反思
+	EventAgentReferences  EventType = "references"          // 知识引用
+	EventAgentFinalAnswer EventType = "final_answer"        // 最终答案
+	EventAgentCitations   EventType = "citations"           // 答案引用的知识片段
+	EventAgentFollowUps   EventType = "follow_up_questions" // 推荐的追问问题
 
 	// Error events
 	EventError EventType = "error" // 错误事件
@@ -77,11 +79,15 @@ type Event struct {
 // EventHandler is a function that handles events
 type EventHandler func(ctx context.Context, event Event) error
 
+// Interceptor rewrites an event before its handlers run; returning false drops the event
+type Interceptor func(ctx context.Context, event Event) (Event, bool)
+
 // EventBus manages event publishing and subscription
 type EventBus struct {
-	mu        sync.RWMutex
-	handlers  map[EventType][]EventHandler
-	asyncMode bool // 是否异步处理事件
+	mu           sync.RWMutex
+	handlers     map[EventType][]EventHandler
+	interceptors map[EventType][]Interceptor
+	asyncMode    bool // 是否异步处理事件
 }
 
 // NewEventBus creates a new EventBus instance
@@ -109,6 +115,33 @@ func (eb *EventBus) On(eventType EventType, handler EventHandler) {
 	eb.handlers[eventType] = append(eb.handlers[eventType], handler)
 }
 
+// Intercept registers an interceptor that sees every event of a type before the handlers do.
+// Interceptors run in registration order, each receiving the event returned by the previous one.
+func (eb *EventBus) Intercept(eventType EventType, interceptor Interceptor) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	if eb.interceptors == nil {
+		eb.interceptors = make(map[EventType][]Interceptor)
+	}
+	eb.interceptors[eventType] = append(eb.interceptors[eventType], interceptor)
+}
+
+// intercept runs the interceptors of the event type, reporting false when one drops the event
+func (eb *EventBus) intercept(ctx context.Context, event Event) (Event, bool) {
+	eb.mu.RLock()
+	interceptors := eb.interceptors[event.Type]
+	eb.mu.RUnlock()
+
+	for _, interceptor := range interceptors {
+		var ok bool
+		if event, ok = interceptor(ctx, event); !ok {
+			return event, false
+		}
+	}
+	return event, true
+}
+
 // Off removes all handlers for a specific event type
 func (eb *EventBus) Off(eventType EventType) {
 	eb.mu.Lock()
@@ -125,6 +158,10 @@ func (eb *EventBus) Emit(ctx context.Context, event Event) error {
 	if event.ID == "" {
 		event.ID = uuid.New().String()
 	}
+	event, ok := eb.intercept(ctx, event)
+	if !ok {
+		return nil
+	}
 
 	eb.mu.RLock()
 	handlers, exists := eb.handlers[event.Type]
@@ -164,6 +201,10 @@ func (eb *EventBus) EmitAndWait(ctx context.Context, event Event) error {
 	if event.ID == "" {
 		event.ID = uuid.New().String()
 	}
+	event, ok := eb.intercept(ctx, event)
+	if !ok {
+		return nil
+	}
 
 	eb.mu.RLock()
 	handlers, exists := eb.handlers[event.Type]
@@ -221,10 +262,11 @@ func (eb *EventBus) GetHandlerCount(eventType EventType) int {
 	return 0
 }
 
-// Clear removes all event handlers
+// Clear removes all event handlers and interceptors
 func (eb *EventBus) Clear() {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
 	eb.handlers = make(map[EventType][]EventHandler)
+	eb.interceptors = nil
 }
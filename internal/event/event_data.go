@@ -181,6 +181,18 @@ type AgentReferencesData struct {
 	Iteration  int         `json:"iteration"`
 }
 
+// AgentCitationsData represents the passages cited by inline markers of the answer
+// Citations are sent as their markers appear in the stream, and once more in full before the answer is done
+type AgentCitationsData struct {
+	Citations interface{} `json:"citations"` // types.Citations
+	Done      bool        `json:"done"`
+}
+
+// AgentFollowUpsData represents the follow-up questions suggested after the answer, sent before it is done
+type AgentFollowUpsData struct {
+	Questions []string `json:"questions"`
+}
+
 // AgentFinalAnswerData represents final answer streaming data
 type AgentFinalAnswerData struct {
 	Content string `json:"content"`
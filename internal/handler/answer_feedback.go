@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SubmitFeedback godoc
+// @Summary      反馈回答
+// @Description  对助手回答点赞（up）或点踩（down）并附文字说明，反馈与回答检索到的分块关联，点踩的回答进入知识库审核队列；重复提交会覆盖之前的反馈
+// @Tags         消息
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string                       true  "会话ID"
+// @Param        id          path      string                       true  "消息ID"
+// @Param        request     body      types.AnswerFeedbackRequest  true  "反馈内容"
+// @Success      200         {object}  map[string]interface{}       "反馈结果"
+// @Failure      400         {object}  errors.AppError              "请求参数错误"
+// @Failure      404         {object}  errors.AppError              "消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /messages/{session_id}/{id}/feedback [post]
+func (h *MessageHandler) SubmitFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.AnswerFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	if !req.Rating.IsValid() {
+		c.Error(apperrors.NewBadRequestError("Rating must be up or down"))
+		return
+	}
+
+	feedback, err := h.FeedbackService.SubmitFeedback(ctx, sessionID, messageID, &req)
+	if err != nil {
+		c.Error(answerFeedbackError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feedback,
+	})
+}
+
+// GetFeedback godoc
+// @Summary      获取回答反馈
+// @Description  获取对助手回答的反馈，未反馈时 data 为 null
+// @Tags         消息
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Param        id          path      string  true  "消息ID"
+// @Success      200         {object}  map[string]interface{}  "反馈"
+// @Failure      404         {object}  errors.AppError         "消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /messages/{session_id}/{id}/feedback [get]
+func (h *MessageHandler) GetFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	feedback, err := h.FeedbackService.GetMessageFeedback(ctx, sessionID, messageID)
+	if err != nil {
+		c.Error(answerFeedbackError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feedback,
+	})
+}
+
+// ListAnswerFeedback godoc
+// @Summary      回答反馈审核队列
+// @Description  列出使用了该知识库的回答收到的反馈，默认为待处理的点踩反馈，每条反馈带有回答检索到的该知识库分块，便于定位需要修正的内容
+// @Tags         知识库
+// @Produce      json
+// @Param        id         path      string  true   "知识库ID"
+// @Param        status     query     string  false  "审核状态：open（默认）、resolved、dismissed"
+// @Param        rating     query     string  false  "评价：down（默认）、up、all"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Success      200        {object}  types.PageResult  "反馈列表"
+// @Failure      400        {object}  errors.AppError   "请求参数错误"
+// @Failure      403        {object}  errors.AppError   "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/answer-feedback [get]
+func (h *KnowledgeBaseHandler) ListAnswerFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, permission, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// Feedback contains questions and comments of all users, only administrators can read them
+	if !permission.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base administrators can view answer feedback"))
+		return
+	}
+
+	var filter types.AnswerFeedbackFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		logger.Error(ctx, "Failed to parse query parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid query parameters").WithDetails(err.Error()))
+		return
+	}
+
+	result, err := h.feedbackService.ListCurationQueue(ctx, id, &filter)
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// UpdateAnswerFeedback godoc
+// @Summary      处理回答反馈
+// @Description  将审核队列中的反馈标记为已解决（resolved）、无需处理（dismissed）或重新打开（open）
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                             true  "知识库ID"
+// @Param        feedback_id  path      string                             true  "反馈ID"
+// @Param        request      body      types.UpdateAnswerFeedbackRequest  true  "处理结果"
+// @Success      200          {object}  map[string]interface{}             "更新后的反馈"
+// @Failure      400          {object}  errors.AppError                    "请求参数错误"
+// @Failure      403          {object}  errors.AppError                    "无权限"
+// @Failure      404          {object}  errors.AppError                    "反馈不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/answer-feedback/{feedback_id} [put]
+func (h *KnowledgeBaseHandler) UpdateAnswerFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, permission, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !permission.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base administrators can curate answer feedback"))
+		return
+	}
+
+	var req types.UpdateAnswerFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	if !req.Status.IsValid() {
+		c.Error(apperrors.NewBadRequestError("Status must be open, resolved or dismissed"))
+		return
+	}
+
+	feedback, err := h.feedbackService.UpdateFeedbackStatus(ctx, id,
+		secutils.SanitizeForLog(c.Param("feedback_id")), &req)
+	if err != nil {
+		c.Error(answerFeedbackError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feedback,
+	})
+}
+
+// answerFeedbackError maps answer feedback failures to API errors
+func answerFeedbackError(ctx context.Context, err error) error {
+	switch {
+	case stderrors.Is(err, apperrors.ErrSessionNotFound):
+		return apperrors.NewNotFoundError(err.Error())
+	case stderrors.Is(err, gorm.ErrRecordNotFound):
+		return apperrors.NewNotFoundError("Message not found")
+	case stderrors.Is(err, repository.ErrAnswerFeedbackNotFound):
+		return apperrors.NewNotFoundError(err.Error())
+	case stderrors.Is(err, service.ErrFeedbackNotAnswer):
+		return apperrors.NewBadRequestError(err.Error())
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler handles the scoped API keys of the current tenant
+type APIKeyHandler struct {
+	service interfaces.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(service interfaces.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// ListAPIKeys godoc
+// @Summary      获取 API Key 列表
+// @Description  列出当前租户的限定范围 API Key，不返回密钥本身
+// @Tags         API Key
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "API Key 列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	keys, err := h.service.ListAPIKeys(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    keys,
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary      创建 API Key
+// @Description  创建限定范围的 API Key：retrieval 只读检索与问答，ingest 只能导入文档，admin 与租户 API Key 权限相同；可限定知识库和过期时间。密钥只在创建时返回一次
+// @Tags         API Key
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.CreateAPIKeyRequest  true  "API Key 配置"
+// @Success      201      {object}  map[string]interface{}     "创建的 API Key 及密钥"
+// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(ctx, &req)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// UpdateAPIKey godoc
+// @Summary      更新 API Key
+// @Description  修改 API Key 的名称、限定的知识库或过期时间，范围不可修改
+// @Tags         API Key
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                     true  "API Key ID"
+// @Param        request  body      types.UpdateAPIKeyRequest  true  "更新内容"
+// @Success      200      {object}  map[string]interface{}     "更新后的 API Key"
+// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Failure      404      {object}  errors.AppError            "API Key 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /api-keys/{id} [put]
+func (h *APIKeyHandler) UpdateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.UpdateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	key, err := h.service.UpdateAPIKey(ctx, secutils.SanitizeForLog(c.Param("id")), &req)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// RotateAPIKey godoc
+// @Summary      轮换 API Key
+// @Description  生成新的密钥并立即使原密钥失效，范围、知识库限定和过期时间保持不变。新密钥只返回一次
+// @Tags         API Key
+// @Produce      json
+// @Param        id   path      string                  true  "API Key ID"
+// @Success      200  {object}  map[string]interface{}  "API Key 及新密钥"
+// @Failure      404  {object}  errors.AppError         "API Key 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	key, err := h.service.RotateAPIKey(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary      吊销 API Key
+// @Description  删除 API Key，使用该密钥的请求立即失败
+// @Tags         API Key
+// @Produce      json
+// @Param        id   path      string                  true  "API Key ID"
+// @Success      200  {object}  map[string]interface{}  "吊销成功"
+// @Failure      404  {object}  errors.AppError         "API Key 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.service.RevokeAPIKey(ctx, secutils.SanitizeForLog(c.Param("id"))); err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// apiKeyError maps API key management failures to API errors
+func apiKeyError(ctx context.Context, err error) error {
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		return appErr
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
@@ -1,14 +1,18 @@
 package handler
 
 import (
+	stderrors "errors"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/Tencent/WeKnora/internal/application/service"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -21,21 +25,35 @@ import (
 type AuthHandler struct {
 	userService   interfaces.UserService
 	tenantService interfaces.TenantService
+	oidcService   interfaces.OIDCService
+	ldapService   interfaces.LDAPService
 	configInfo    *config.Config
 }
 
+const (
+	// oidcStateCookie carries the signed login state from the redirect to the provider back to the callback
+	oidcStateCookie     = "weknora_oidc_state"
+	oidcStateCookiePath = "/api/v1/auth/oidc/"
+)
+
 // NewAuthHandler creates a new auth handler instance with the provided services
 // Parameters:
 //   - userService: An implementation of the UserService interface for business logic
 //   - tenantService: An implementation of the TenantService interface for tenant management
+//   - oidcService: An implementation of the OIDCService interface for single sign-on
+//   - ldapService: An implementation of the LDAPService interface for directory logins
 //
 // Returns a pointer to the newly created AuthHandler
 func NewAuthHandler(configInfo *config.Config,
-	userService interfaces.UserService, tenantService interfaces.TenantService) *AuthHandler {
+	userService interfaces.UserService, tenantService interfaces.TenantService,
+	oidcService interfaces.OIDCService, ldapService interfaces.LDAPService,
+) *AuthHandler {
 	return &AuthHandler{
 		configInfo:    configInfo,
 		userService:   userService,
 		tenantService: tenantService,
+		oidcService:   oidcService,
+		ldapService:   ldapService,
 	}
 }
 
@@ -95,7 +113,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// Return success response
 	response := &types.RegisterResponse{
 		Success: true,
-		Message: "Registration successful",
+		Message: i18n.T(ctx, i18n.MsgRegistered),
 		User:    user,
 	}
 
@@ -114,7 +132,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Failure      401      {object}  errors.AppError  "认证失败"
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
-	ctx := c.Request.Context()
+	ctx := withClientInfo(c)
 
 	logger.Info(ctx, "Start user login")
 
@@ -135,6 +153,16 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Directory users sign in with their email too, local accounts keep their own password
+	if h.ldapService.Enabled() {
+		user, err := h.userService.GetUserByEmail(ctx, req.Email)
+		if err != nil || user == nil || user.AuthProvider == service.LDAPAuthProvider {
+			if h.ldapLogin(c, req.Email, req.Password) {
+				return
+			}
+		}
+	}
+
 	// Call service to authenticate user
 	response, err := h.userService.Login(ctx, &req)
 	if err != nil {
@@ -159,7 +187,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 // Logout godoc
 // @Summary      用户登出
-// @Description  撤销当前访问令牌并登出
+// @Description  撤销当前登录会话（访问令牌和刷新令牌）并登出
 // @Tags         认证
 // @Accept       json
 // @Produce      json
@@ -204,7 +232,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	logger.Info(ctx, "User logged out successfully")
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Logout successful",
+		"message": i18n.T(ctx, i18n.MsgLoggedOut),
 	})
 }
 
@@ -219,7 +247,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 // @Failure      401      {object}  errors.AppError              "令牌无效"
 // @Router       /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	ctx := c.Request.Context()
+	ctx := withClientInfo(c)
 
 	logger.Info(ctx, "Start token refresh")
 
@@ -246,7 +274,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	logger.Info(ctx, "Token refreshed successfully")
 	c.JSON(http.StatusOK, gin.H{
 		"success":       true,
-		"message":       "Token refreshed successfully",
+		"message":       i18n.T(ctx, i18n.MsgTokenRefreshed),
 		"access_token":  accessToken,
 		"refresh_token": newRefreshToken,
 	})
@@ -284,7 +312,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		}
 	}
 	userInfo := user.ToUserInfo()
-	userInfo.CanAccessAllTenants = user.CanAccessAllTenants && h.configInfo.Tenant.EnableCrossTenantAccess
+	userInfo.CanAccessAllTenants = user.CanAccessAllTenants && h.configInfo.CrossTenantAccessEnabled()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
@@ -343,7 +371,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	logger.Infof(ctx, "Password changed successfully for user: %s", user.Email)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Password changed successfully",
+		"message": i18n.T(ctx, i18n.MsgPasswordChanged),
 	})
 }
 
@@ -394,7 +422,189 @@ func (h *AuthHandler) ValidateToken(c *gin.Context) {
 	logger.Infof(ctx, "Token validated successfully for user: %s", user.Email)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Token is valid",
+		"message": i18n.T(ctx, i18n.MsgTokenValid),
 		"user":    user.ToUserInfo(),
 	})
 }
+
+// ListOIDCProviders godoc
+// @Summary      获取单点登录提供方
+// @Description  获取已配置的 OIDC 单点登录提供方及其登录地址
+// @Tags         认证
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "提供方列表"
+// @Router       /auth/oidc/providers [get]
+func (h *AuthHandler) ListOIDCProviders(c *gin.Context) {
+	providers := h.oidcService.ListProviders()
+	for _, p := range providers {
+		p.LoginURL = oidcStateCookiePath + url.PathEscape(p.Name) + "/login"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    providers,
+	})
+}
+
+// OIDCLogin godoc
+// @Summary      发起单点登录
+// @Description  跳转到 OIDC 提供方的授权页面（授权码 + PKCE）
+// @Tags         认证
+// @Param        provider  path  string  true  "提供方名称"
+// @Success      302
+// @Failure      404  {object}  errors.AppError  "提供方不存在"
+// @Router       /auth/oidc/{provider}/login [get]
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	ctx := c.Request.Context()
+	provider := c.Param("provider")
+
+	authURL, signedState, err := h.oidcService.BeginLogin(ctx, provider)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to start OIDC login with provider %s: %v", secutils.SanitizeForLog(provider), err)
+		c.Error(oidcError(err))
+		return
+	}
+
+	h.setOIDCStateCookie(c, signedState, int(service.OIDCStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback godoc
+// @Summary      单点登录回调
+// @Description  OIDC 提供方授权后的回调，校验 ID Token、按声明映射开通用户并签发登录令牌。
+// @Description  配置了 auth.frontend_redirect_url 时跳转到前端并在 URL 片段中携带令牌，否则直接返回登录结果
+// @Tags         认证
+// @Produce      json
+// @Param        provider  path   string  true   "提供方名称"
+// @Param        code      query  string  false  "授权码"
+// @Param        state     query  string  false  "登录状态"
+// @Success      200  {object}  types.LoginResponse
+// @Success      302
+// @Failure      401  {object}  errors.AppError  "认证失败"
+// @Failure      403  {object}  errors.AppError  "不允许登录"
+// @Router       /auth/oidc/{provider}/callback [get]
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	ctx := withClientInfo(c)
+	provider := c.Param("provider")
+
+	signedState, _ := c.Cookie(oidcStateCookie)
+	// The state is single use, whatever the outcome
+	h.setOIDCStateCookie(c, "", -1)
+
+	var response *types.LoginResponse
+	var err error
+	if providerError := c.Query("error"); providerError != "" {
+		logger.Warnf(ctx, "OIDC provider %s returned error: %s", secutils.SanitizeForLog(provider),
+			secutils.SanitizeForLog(providerError))
+		err = errors.NewUnauthorizedError("Sign-in was cancelled or denied by the identity provider").
+			WithDetails(c.Query("error_description"))
+	} else {
+		response, err = h.oidcService.CompleteLogin(ctx, provider, c.Query("code"), c.Query("state"), signedState)
+	}
+
+	redirectURL := ""
+	if h.configInfo.Auth != nil {
+		redirectURL = h.configInfo.Auth.FrontendRedirectURL
+	}
+	if err != nil {
+		logger.Errorf(ctx, "OIDC login with provider %s failed: %v", secutils.SanitizeForLog(provider), err)
+		appErr := oidcError(err)
+		if redirectURL == "" {
+			c.Error(appErr)
+			return
+		}
+		fragment := url.Values{"error": {appErr.Message}}
+		c.Redirect(http.StatusFound, redirectURL+"#"+fragment.Encode())
+		return
+	}
+
+	if redirectURL == "" {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	// Tokens travel in the fragment so they never reach server logs or the Referer header
+	fragment := url.Values{
+		"token":         {response.Token},
+		"refresh_token": {response.RefreshToken},
+	}
+	c.Redirect(http.StatusFound, redirectURL+"#"+fragment.Encode())
+}
+
+// setOIDCStateCookie sets or, with a negative max age, clears the login state cookie
+func (h *AuthHandler) setOIDCStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	// Lax lets the cookie ride along the top-level redirect back from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcStateCookiePath, "", secure, true)
+}
+
+// oidcError converts a single sign-on error to the error returned to the client
+func oidcError(err error) *errors.AppError {
+	if appErr, ok := errors.IsAppError(err); ok {
+		return appErr
+	}
+	if stderrors.Is(err, service.ErrOIDCProviderNotFound) {
+		return errors.NewNotFoundError("Single sign-on provider not found")
+	}
+	return errors.NewInternalServerError("Single sign-on failed").WithDetails(err.Error())
+}
+
+// LDAPLogin godoc
+// @Summary      LDAP 登录
+// @Description  使用 LDAP / Active Directory 账号（sAMAccountName、UPN 或邮箱）登录并获取访问令牌
+// @Tags         认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.LDAPLoginRequest  true  "登录请求参数"
+// @Success      200      {object}  types.LoginResponse
+// @Failure      401      {object}  errors.AppError  "认证失败"
+// @Failure      403      {object}  errors.AppError  "不允许登录"
+// @Failure      404      {object}  errors.AppError  "未启用 LDAP 登录"
+// @Router       /auth/ldap/login [post]
+func (h *AuthHandler) LDAPLogin(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if !h.ldapService.Enabled() {
+		c.Error(errors.NewNotFoundError("LDAP login is not enabled"))
+		return
+	}
+	var req types.LDAPLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse LDAP login request parameters", err)
+		c.Error(errors.NewValidationError("Invalid login parameters").WithDetails(err.Error()))
+		return
+	}
+	if !h.ldapLogin(c, req.Username, req.Password) {
+		c.JSON(http.StatusUnauthorized, &types.LoginResponse{
+			Success: false,
+			Message: i18n.T(ctx, i18n.MsgInvalidCredentials),
+		})
+	}
+}
+
+// ldapLogin signs a user in against the directory and writes the response,
+// it returns false without writing anything when the directory does not know the user
+func (h *AuthHandler) ldapLogin(c *gin.Context, username, password string) bool {
+	ctx := withClientInfo(c)
+
+	response, err := h.ldapService.Login(ctx, username, password)
+	if stderrors.Is(err, service.ErrLDAPUserNotFound) {
+		return false
+	}
+	if err != nil {
+		logger.Errorf(ctx, "LDAP login of %s failed: %v", secutils.SanitizeForLog(username), err)
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(errors.NewUnauthorizedError("Login failed").WithDetails(err.Error()))
+		}
+		return true
+	}
+	if !response.Success {
+		logger.Warnf(ctx, "LDAP login failed: %s", response.Message)
+		c.JSON(http.StatusUnauthorized, response)
+		return true
+	}
+	logger.Infof(ctx, "User logged in with LDAP, username: %s", secutils.SanitizeForLog(username))
+	c.JSON(http.StatusOK, response)
+	return true
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// withClientInfo returns the request context carrying the client IP and user agent, recorded on new login sessions
+func withClientInfo(c *gin.Context) context.Context {
+	return context.WithValue(c.Request.Context(), types.ClientInfoContextKey, &types.ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// bearerToken returns the token of the Authorization header, empty when there is none
+func bearerToken(c *gin.Context) string {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+// sessionOwner returns the user whose sessions a request manages: the current user,
+// or the user of the user_id query for administrators who can access all tenants
+func (h *AuthHandler) sessionOwner(c *gin.Context) (string, bool, error) {
+	user, ok := c.Request.Context().Value(types.UserContextKey).(*types.User)
+	if !ok || user == nil {
+		return "", false, errors.NewUnauthorizedError("Login sessions can only be managed by a logged in user")
+	}
+	target := secutils.SanitizeForLog(c.Query("user_id"))
+	if target == "" || target == user.ID {
+		return user.ID, true, nil
+	}
+	crossTenant := h.configInfo.CrossTenantAccessEnabled()
+	if !crossTenant || !user.CanAccessAllTenants {
+		logger.Warnf(c.Request.Context(), "User %s attempted to manage login sessions of user %s", user.ID, target)
+		return "", false, errors.NewForbiddenError("Insufficient permissions to manage login sessions of other users")
+	}
+	if other, err := h.userService.GetUserByID(c.Request.Context(), target); err != nil || other == nil {
+		return "", false, errors.NewNotFoundError("User not found")
+	}
+	return target, false, nil
+}
+
+// ListSessions godoc
+// @Summary      获取登录会话
+// @Description  列出当前用户未过期、未撤销的登录会话及其可用令牌（不含令牌本身），包括设备、IP 和最近活动时间，current 标记当前请求所在会话。可访问所有租户的管理员可通过 user_id 查看其他用户
+// @Tags         认证
+// @Produce      json
+// @Param        user_id  query     string                  false  "用户ID（仅管理员）"
+// @Success      200      {object}  map[string]interface{}  "登录会话列表"
+// @Failure      401      {object}  errors.AppError         "未授权"
+// @Failure      403      {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Router       /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, self, err := h.sessionOwner(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var currentToken string
+	if self {
+		currentToken = bearerToken(c)
+	}
+	sessions, err := h.userService.ListSessions(ctx, userID, currentToken)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sessions,
+	})
+}
+
+// RevokeSession godoc
+// @Summary      撤销登录会话
+// @Description  撤销一个登录会话，其访问令牌和刷新令牌立即失效
+// @Tags         认证
+// @Produce      json
+// @Param        id       path      string                  true   "会话ID"
+// @Param        user_id  query     string                  false  "用户ID（仅管理员）"
+// @Success      200      {object}  map[string]interface{}  "撤销成功"
+// @Failure      404      {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Router       /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, _, err := h.sessionOwner(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := h.userService.RevokeSession(ctx, userID, secutils.SanitizeForLog(c.Param("id"))); err != nil {
+		c.Error(loginSessionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// RevokeAllSessions godoc
+// @Summary      撤销全部登录会话
+// @Description  撤销用户的全部登录会话和令牌，例如怀疑账号泄露时。keep_current=true 时保留当前请求所在会话
+// @Tags         认证
+// @Produce      json
+// @Param        keep_current  query     bool                    false  "保留当前会话"
+// @Param        user_id       query     string                  false  "用户ID（仅管理员）"
+// @Success      200           {object}  map[string]interface{}  "撤销成功"
+// @Security     Bearer
+// @Router       /auth/sessions [delete]
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, self, err := h.sessionOwner(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var keepToken string
+	if self && c.Query("keep_current") == "true" {
+		keepToken = bearerToken(c)
+	}
+	if err := h.userService.RevokeAllSessions(ctx, userID, keepToken); err != nil {
+		c.Error(loginSessionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// RevokeIssuedToken godoc
+// @Summary      撤销令牌
+// @Description  撤销单个访问令牌或刷新令牌，所在会话的其他令牌不受影响
+// @Tags         认证
+// @Produce      json
+// @Param        id       path      string                  true   "令牌ID"
+// @Param        user_id  query     string                  false  "用户ID（仅管理员）"
+// @Success      200      {object}  map[string]interface{}  "撤销成功"
+// @Failure      404      {object}  errors.AppError         "令牌不存在"
+// @Security     Bearer
+// @Router       /auth/tokens/{id} [delete]
+func (h *AuthHandler) RevokeIssuedToken(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID, _, err := h.sessionOwner(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := h.userService.RevokeIssuedToken(ctx, userID, secutils.SanitizeForLog(c.Param("id"))); err != nil {
+		c.Error(loginSessionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// loginSessionError maps login session management failures to API errors
+func loginSessionError(ctx context.Context, err error) error {
+	if appErr, ok := errors.IsAppError(err); ok {
+		return appErr
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return errors.NewInternalServerError(err.Error())
+}
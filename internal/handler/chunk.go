@@ -5,7 +5,9 @@ import (
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -15,48 +17,51 @@ import (
 
 // ChunkHandler defines HTTP handlers for chunk operations
 type ChunkHandler struct {
-	service           interfaces.ChunkService
-	kgService         interfaces.KnowledgeService
-	kbShareService    interfaces.KBShareService
-	agentShareService interfaces.AgentShareService
+	service             interfaces.ChunkService
+	kgService           interfaces.KnowledgeService
+	agentShareService   interfaces.AgentShareService
+	kbPermissionService interfaces.KBPermissionService
+	config              *config.Config
 }
 
 // NewChunkHandler creates a new chunk handler
-func NewChunkHandler(service interfaces.ChunkService, kgService interfaces.KnowledgeService, kbShareService interfaces.KBShareService, agentShareService interfaces.AgentShareService) *ChunkHandler {
-	return &ChunkHandler{service: service, kgService: kgService, kbShareService: kbShareService, agentShareService: agentShareService}
+func NewChunkHandler(service interfaces.ChunkService, kgService interfaces.KnowledgeService, agentShareService interfaces.AgentShareService, kbPermissionService interfaces.KBPermissionService, config *config.Config) *ChunkHandler {
+	return &ChunkHandler{service: service, kgService: kgService, agentShareService: agentShareService, kbPermissionService: kbPermissionService, config: config}
 }
 
-// effectiveCtxForKnowledge resolves knowledge by ID, validates KB access (owner or shared with required role), and returns context with effectiveTenantID for downstream service calls.
-func (h *ChunkHandler) effectiveCtxForKnowledge(c *gin.Context, knowledgeID string, requiredPermission types.OrgMemberRole) (context.Context, error) {
+// effectiveCtxForKnowledge resolves knowledge by ID, validates the role of the caller on it (KB role with document permission applied), and returns context with effectiveTenantID for downstream service calls.
+func (h *ChunkHandler) effectiveCtxForKnowledge(c *gin.Context, knowledgeID string, requiredRole types.KBRole) (context.Context, error) {
 	ctx := c.Request.Context()
 	tenantID := c.GetUint64(types.TenantIDContextKey.String())
 	if tenantID == 0 {
 		return nil, errors.NewUnauthorizedError("Unauthorized")
 	}
-	userID, userExists := c.Get(types.UserIDContextKey.String())
+	userID := c.GetString(types.UserIDContextKey.String())
 
 	knowledge, err := h.kgService.GetKnowledgeByIDOnly(ctx, knowledgeID)
 	if err != nil {
 		return nil, errors.NewNotFoundError("Knowledge not found")
 	}
-	if knowledge.TenantID == tenantID {
-		return context.WithValue(ctx, types.TenantIDContextKey, tenantID), nil
-	}
-	if !userExists {
-		return nil, errors.NewForbiddenError("Permission denied to access this knowledge")
+	kbRef := &types.KnowledgeBase{ID: knowledge.KnowledgeBaseID, TenantID: knowledge.TenantID}
+	kbRole, effectiveTenantID, err := h.kbPermissionService.ResolveKBRole(ctx, kbRef, tenantID, userID)
+	if err != nil {
+		return nil, errors.NewInternalServerError(err.Error())
 	}
-	if h.kbShareService != nil {
-		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, knowledge.KnowledgeBaseID, userID.(string))
-		if permErr == nil && isShared {
-			if !permission.HasPermission(requiredPermission) {
-				return nil, errors.NewForbiddenError("Insufficient permission for this operation")
-			}
-			return context.WithValue(ctx, types.TenantIDContextKey, knowledge.TenantID), nil
+	if kbRole != "" {
+		role, err := h.kbPermissionService.ResolveKnowledgeRole(ctx, knowledge, kbRole, userID)
+		if err != nil {
+			return nil, errors.NewInternalServerError(err.Error())
+		}
+		if !role.HasPermission(requiredRole) {
+			return nil, errors.NewForbiddenError("Insufficient permission for this operation")
 		}
+		return context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID), nil
+	}
+	if userID == "" {
+		return nil, errors.NewForbiddenError("Permission denied to access this knowledge")
 	}
-	if requiredPermission == types.OrgRoleViewer && h.agentShareService != nil {
-		kbRef := &types.KnowledgeBase{ID: knowledge.KnowledgeBaseID, TenantID: knowledge.TenantID}
-		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID.(string), tenantID, kbRef)
+	if requiredRole == types.KBRoleViewer && h.agentShareService != nil {
+		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID, tenantID, kbRef)
 		if err == nil && can {
 			return context.WithValue(ctx, types.TenantIDContextKey, knowledge.TenantID), nil
 		}
@@ -101,7 +106,7 @@ func (h *ChunkHandler) GetChunkByIDOnly(c *gin.Context) {
 		return
 	}
 
-	_, err = h.effectiveCtxForKnowledge(c, chunk.KnowledgeID, types.OrgRoleViewer)
+	_, err = h.effectiveCtxForKnowledge(c, chunk.KnowledgeID, types.KBRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -127,6 +132,7 @@ func (h *ChunkHandler) GetChunkByIDOnly(c *gin.Context) {
 // @Param        knowledge_id  path      string  true   "知识ID"
 // @Param        page          query     int     false  "页码"  default(1)
 // @Param        page_size     query     int     false  "每页数量"  default(10)
+// @Param        cursor        query     string  false  "上一次响应返回的 next_cursor，不能与 page 同时使用"
 // @Success      200           {object}  map[string]interface{}  "分块列表"
 // @Failure      400           {object}  errors.AppError         "请求参数错误"
 // @Security     Bearer
@@ -143,7 +149,7 @@ func (h *ChunkHandler) ListKnowledgeChunks(c *gin.Context) {
 		return
 	}
 
-	effCtx, err := h.effectiveCtxForKnowledge(c, knowledgeID, types.OrgRoleViewer)
+	effCtx, err := h.effectiveCtxForKnowledge(c, knowledgeID, types.KBRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -165,6 +171,20 @@ func (h *ChunkHandler) ListKnowledgeChunks(c *gin.Context) {
 	if pagination.PageSize > 100 {
 		pagination.PageSize = 100
 	}
+	// A cursor continues after the last chunk of the previous response, which may have been cut at the payload cap
+	if cursorText := c.Query("cursor"); cursorText != "" {
+		if pagination.Page > 1 {
+			c.Error(errors.NewBadRequestError("cursor cannot be combined with page"))
+			return
+		}
+		cursor, err := types.DecodePayloadCursor(cursorText, knowledgeID)
+		if err != nil {
+			c.Error(errors.NewBadRequestError(err.Error()))
+			return
+		}
+		pagination.Start = cursor.Offset
+	}
+	start := pagination.Offset()
 
 	chunkType := []types.ChunkType{types.ChunkTypeText}
 
@@ -176,20 +196,55 @@ func (h *ChunkHandler) ListKnowledgeChunks(c *gin.Context) {
 		return
 	}
 
+	// Parse status of the document decides the embedding status of pipeline-created chunks
+	parseStatus := ""
+	if knowledge, err := h.kgService.GetKnowledgeByIDOnly(ctx, knowledgeID); err == nil {
+		parseStatus = knowledge.ParseStatus
+	}
+
 	// 对 chunk 内容进行安全清理
-	for _, chunk := range result.Data.([]*types.Chunk) {
+	chunks := result.Data.([]*types.Chunk)
+	count, truncated := types.FitPayload(len(chunks), maxPayloadBytes(h.config), func(i int) int {
+		return len(chunks[i].Content) + len(chunks[i].Metadata) + len(chunks[i].ImageInfo)
+	})
+	chunks = chunks[:count]
+	items := make([]*chunkListItem, 0, len(chunks))
+	for _, chunk := range chunks {
 		if chunk.Content != "" {
 			chunk.Content = secutils.SanitizeForDisplay(chunk.Content)
 		}
+		items = append(items, &chunkListItem{
+			Chunk:           chunk,
+			EmbeddingStatus: chunk.EmbeddingStatus(parseStatus),
+			Pinned:          chunk.IsPinned(),
+		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	next := start + len(chunks)
+	hasMore := int64(next) < result.Total
+	response := gin.H{
 		"success":   true,
-		"data":      result.Data,
+		"data":      items,
 		"total":     result.Total,
 		"page":      result.Page,
 		"page_size": result.PageSize,
-	})
+		"has_more":  hasMore,
+		"truncated": truncated,
+	}
+	if hasMore {
+		response["next_cursor"] = types.PayloadCursor{Scope: knowledgeID, Offset: next}.Encode()
+	}
+	if truncated {
+		logger.Infof(ctx, "Chunk list cut at the payload cap, knowledge ID: %s, chunks: %d", knowledgeID, len(chunks))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// chunkListItem is a chunk as returned by the list endpoint, with derived curation fields
+type chunkListItem struct {
+	*types.Chunk
+	EmbeddingStatus types.ChunkEmbeddingStatus `json:"embedding_status"`
+	Pinned          bool                       `json:"pinned"`
 }
 
 // UpdateChunkRequest defines the request structure for updating a chunk
@@ -220,7 +275,7 @@ func (h *ChunkHandler) validateAndGetChunk(c *gin.Context) (*types.Chunk, string
 		return nil, knowledgeID, nil, errors.NewBadRequestError("Chunk ID cannot be empty")
 	}
 
-	effCtx, err := h.effectiveCtxForKnowledge(c, knowledgeID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKnowledge(c, knowledgeID, types.KBRoleEditor)
 	if err != nil {
 		return nil, knowledgeID, nil, err
 	}
@@ -276,15 +331,16 @@ func (h *ChunkHandler) UpdateChunk(c *gin.Context) {
 		return
 	}
 
+	// Content edits re-embed just this chunk so retrieval sees the new text
 	if req.Content != "" {
-		chunk.Content = req.Content
+		if err := h.service.UpdateChunkContent(effCtx, chunk, req.Content); err != nil {
+			h.handleChunkUpdateError(c, err)
+			return
+		}
 	}
 
-	chunk.IsEnabled = req.IsEnabled
-
-	if err := h.service.UpdateChunk(effCtx, chunk); err != nil {
-		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError(err.Error()))
+	if err := h.service.SetChunkEnabled(effCtx, chunk, req.IsEnabled); err != nil {
+		h.handleChunkUpdateError(c, err)
 		return
 	}
 
@@ -296,6 +352,112 @@ func (h *ChunkHandler) UpdateChunk(c *gin.Context) {
 	})
 }
 
+// handleChunkUpdateError reports a chunk update failure, passing application errors through
+func (h *ChunkHandler) handleChunkUpdateError(c *gin.Context, err error) {
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	c.Error(errors.NewInternalServerError(err.Error()))
+}
+
+// chunkPinRequest defines the request body for pinning a chunk
+type chunkPinRequest struct {
+	Pinned *bool `json:"pinned" binding:"required"`
+}
+
+// PinChunk godoc
+// @Summary      置顶分块
+// @Description  置顶或取消置顶分块，置顶的分块在检索重排时获得加权
+// @Tags         分块管理
+// @Accept       json
+// @Produce      json
+// @Param        knowledge_id  path      string           true  "知识ID"
+// @Param        id            path      string           true  "分块ID"
+// @Param        request       body      chunkPinRequest  true  "置顶请求"
+// @Success      200           {object}  map[string]interface{}  "更新后的分块"
+// @Failure      400           {object}  errors.AppError         "请求参数错误"
+// @Failure      404           {object}  errors.AppError         "分块不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /chunks/{knowledge_id}/{id}/pin [put]
+func (h *ChunkHandler) PinChunk(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	chunk, _, effCtx, err := h.validateAndGetChunk(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req chunkPinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf(ctx, "Failed to parse request parameters: %s", secutils.SanitizeForLog(err.Error()))
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	if err := h.service.SetChunkPinned(effCtx, chunk, *req.Pinned); err != nil {
+		h.handleChunkUpdateError(c, err)
+		return
+	}
+
+	logger.Infof(ctx, "Chunk pinned status updated, chunk ID: %s, pinned: %v",
+		secutils.SanitizeForLog(chunk.ID), *req.Pinned)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    chunk,
+	})
+}
+
+// chunkEnabledRequest defines the request body for enabling or disabling a chunk
+type chunkEnabledRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// SetChunkEnabled godoc
+// @Summary      启用/禁用分块
+// @Description  启用或禁用单个分块的检索，不影响所属文档
+// @Tags         分块管理
+// @Accept       json
+// @Produce      json
+// @Param        knowledge_id  path      string               true  "知识ID"
+// @Param        id            path      string               true  "分块ID"
+// @Param        request       body      chunkEnabledRequest  true  "启用请求"
+// @Success      200           {object}  map[string]interface{}  "更新后的分块"
+// @Failure      400           {object}  errors.AppError         "请求参数错误"
+// @Failure      404           {object}  errors.AppError         "分块不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /chunks/{knowledge_id}/{id}/enabled [put]
+func (h *ChunkHandler) SetChunkEnabled(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	chunk, _, effCtx, err := h.validateAndGetChunk(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req chunkEnabledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf(ctx, "Failed to parse request parameters: %s", secutils.SanitizeForLog(err.Error()))
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	if err := h.service.SetChunkEnabled(effCtx, chunk, *req.Enabled); err != nil {
+		h.handleChunkUpdateError(c, err)
+		return
+	}
+
+	logger.Infof(ctx, "Chunk enabled status updated, chunk ID: %s, enabled: %v",
+		secutils.SanitizeForLog(chunk.ID), *req.Enabled)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    chunk,
+	})
+}
+
 // DeleteChunk godoc
 // @Summary      删除分块
 // @Description  删除指定的分块
@@ -328,7 +490,7 @@ func (h *ChunkHandler) DeleteChunk(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Chunk deleted",
+		"message": i18n.T(ctx, i18n.MsgChunkDeleted),
 	})
 }
 
@@ -355,7 +517,7 @@ func (h *ChunkHandler) DeleteChunksByKnowledgeID(c *gin.Context) {
 		return
 	}
 
-	effCtx, err := h.effectiveCtxForKnowledge(c, knowledgeID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKnowledge(c, knowledgeID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -370,7 +532,7 @@ func (h *ChunkHandler) DeleteChunksByKnowledgeID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "All chunks under knowledge deleted",
+		"message": i18n.T(ctx, i18n.MsgKnowledgeChunksDeleted),
 	})
 }
 
@@ -420,7 +582,7 @@ func (h *ChunkHandler) DeleteGeneratedQuestion(c *gin.Context) {
 		return
 	}
 
-	effCtx, err := h.effectiveCtxForKnowledge(c, chunk.KnowledgeID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKnowledge(c, chunk.KnowledgeID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -436,6 +598,6 @@ func (h *ChunkHandler) DeleteGeneratedQuestion(c *gin.Context) {
 		secutils.SanitizeForLog(chunkID), secutils.SanitizeForLog(req.QuestionID))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Generated question deleted",
+		"message": i18n.T(ctx, i18n.MsgGeneratedQuestionDeleted),
 	})
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/application/service"
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -296,7 +297,7 @@ func (h *CustomAgentHandler) DeleteAgent(c *gin.Context) {
 	logger.Infof(ctx, "Custom agent deleted successfully, ID: %s", secutils.SanitizeForLog(id))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Agent deleted successfully",
+		"message": i18n.T(ctx, i18n.MsgAgentDeleted),
 	})
 }
 
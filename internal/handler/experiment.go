@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ExperimentRequest creates an experiment; on update the variants are ignored
+type ExperimentRequest struct {
+	Name           string                        `json:"name"            binding:"required"`
+	Description    string                        `json:"description"`
+	TrafficPercent int                           `json:"traffic_percent" binding:"required"`
+	VariantA       types.ExperimentVariantConfig `json:"variant_a"`
+	VariantB       types.ExperimentVariantConfig `json:"variant_b"`
+	AutoPromote    types.ExperimentAutoPromote   `json:"auto_promote"`
+}
+
+// PromoteExperimentRequest selects the variant to promote
+type PromoteExperimentRequest struct {
+	Variant types.ExperimentVariant `json:"variant" binding:"required"`
+}
+
+// validateExperimentKnowledgeBase resolves the knowledge base of an experiment request
+func (h *KnowledgeBaseHandler) validateExperimentKnowledgeBase(c *gin.Context) (string, error) {
+	return h.validateOwnedKnowledgeBase(c, "Only the owner of the knowledge base can manage experiments")
+}
+
+// bindExperiment parses and validates an experiment request
+func bindExperiment(c *gin.Context, knowledgeBaseID string) (*types.KBExperiment, error) {
+	var req ExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(c.Request.Context(), "Failed to parse request parameters", err)
+		return nil, apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error())
+	}
+	experiment := &types.KBExperiment{
+		KnowledgeBaseID: knowledgeBaseID,
+		Name:            req.Name,
+		Description:     req.Description,
+		TrafficPercent:  req.TrafficPercent,
+		VariantA:        req.VariantA,
+		VariantB:        req.VariantB,
+		AutoPromote:     req.AutoPromote,
+	}
+	if err := experiment.Validate(); err != nil {
+		return nil, apperrors.NewBadRequestError("Invalid experiment").WithDetails(err.Error())
+	}
+	return experiment, nil
+}
+
+// CreateExperiment godoc
+// @Summary      创建 A/B 实验
+// @Description  为知识库定义两套检索/生成配置并立即开始分流，按会话将指定比例的对话路由到 B 配置
+// @Tags         A/B 实验
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true  "知识库ID"
+// @Param        request  body      ExperimentRequest  true  "实验配置"
+// @Success      201      {object}  map[string]interface{}  "创建的实验"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      409      {object}  errors.AppError         "已有正在运行的实验"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/experiments [post]
+func (h *KnowledgeBaseHandler) CreateExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateExperimentKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	experiment, err := bindExperiment(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	experiment, err = h.experimentService.CreateExperiment(ctx, experiment)
+	if err != nil {
+		c.Error(experimentError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    experiment,
+	})
+}
+
+// ListExperiments godoc
+// @Summary      获取 A/B 实验列表
+// @Description  列出知识库的全部实验，按创建时间倒序
+// @Tags         A/B 实验
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "实验列表"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/experiments [get]
+func (h *KnowledgeBaseHandler) ListExperiments(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateExperimentKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	experiments, err := h.experimentService.ListExperiments(ctx, id)
+	if err != nil {
+		c.Error(experimentError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiments,
+	})
+}
+
+// GetExperiment godoc
+// @Summary      获取 A/B 实验详情
+// @Description  获取实验的配置和状态
+// @Tags         A/B 实验
+// @Produce      json
+// @Param        id             path      string  true  "知识库ID"
+// @Param        experiment_id  path      string  true  "实验ID"
+// @Success      200            {object}  map[string]interface{}  "实验"
+// @Failure      404            {object}  errors.AppError         "实验不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/experiments/{experiment_id} [get]
+func (h *KnowledgeBaseHandler) GetExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateExperimentKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	experiment, err := h.experimentService.GetExperiment(ctx, id, secutils.SanitizeForLog(c.Param("experiment_id")))
+	if err != nil {
+		c.Error(experimentError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiment,
+	})
+}
+
+// UpdateExperiment godoc
+// @Summary      更新 A/B 实验
+// @Description  更新实验的名称、描述、分流比例和自动晋升设置，变体配置创建后不可修改
+// @Tags         A/B 实验
+// @Accept       json
+// @Produce      json
+// @Param        id             path      string             true  "知识库ID"
+// @Param        experiment_id  path      string             true  "实验ID"
+// @Param        request        body      ExperimentRequest  true  "实验配置"
+// @Success      200            {object}  map[string]interface{}  "更新后的实验"
+// @Failure      400            {object}  errors.AppError         "请求参数错误"
+// @Failure      404            {object}  errors.AppError         "实验不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/experiments/{experiment_id} [put]
+func (h *KnowledgeBaseHandler) UpdateExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateExperimentKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	experiment, err := bindExperiment(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	experiment.ID = secutils.SanitizeForLog(c.Param("experiment_id"))
+
+	experiment, err = h.experimentService.UpdateExperiment(ctx, experiment)
+	if err != nil {
+		c.Error(experimentError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiment,
+	})
+}
+
+// DeleteExperiment godoc
+// @Summary      删除 A/B 实验
+// @Description  删除实验，正在运行或已晋升的实验删除后不再影响对话
+// @Tags         A/B 实验
+// @Produce      json
+// @Param        id             path      string  true  "知识库ID"
+// @Param        experiment_id  path      string  true  "实验ID"
+// @Success      200            {object}  map[string]interface{}  "删除成功"
+// @Failure      404            {object}  errors.AppError         "实验不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/experiments/{experiment_id} [delete]
+func (h *KnowledgeBaseHandler) DeleteExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateExperimentKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.experimentService.DeleteExperiment(ctx, id, secutils.SanitizeForLog(c.Param("experiment_id"))); err != nil {
+		c.Error(experimentError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// StopExperiment godoc
+// @Summary      停止 A/B 实验
+// @Description  停止实验分流，所有对话恢复使用原有配置；已晋升的实验停止后也不再生效
+// @Tags         A/B 实验
+// @Produce      json
+// @Param        id             path      string  true  "知识库ID"
+// @Param        experiment_id  path      string  true  "实验ID"
+// @Success      200            {object}  map[string]interface{}  "停止后的实验"
+// @Failure      404            {object}  errors.AppError         "实验不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/experiments/{experiment_id}/stop [post]
+func (h *KnowledgeBaseHandler) StopExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateExperimentKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	experiment, err := h.experimentService.StopExperiment(ctx, id, secutils.SanitizeForLog(c.Param("experiment_id")))
+	if err != nil {
+		c.Error(experimentError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiment,
+	})
+}
+
+// PromoteExperiment godoc
+// @Summary      晋升 A/B 实验变体
+// @Description  将指定变体设为胜出配置，知识库的全部对话此后使用该配置，直至实验被停止或新实验开始
+// @Tags         A/B 实验
+// @Accept       json
+// @Produce      json
+// @Param        id             path      string                    true  "知识库ID"
+// @Param        experiment_id  path      string                    true  "实验ID"
+// @Param        request        body      PromoteExperimentRequest  true  "晋升的变体"
+// @Success      200            {object}  map[string]interface{}    "晋升后的实验"
+// @Failure      400            {object}  errors.AppError           "请求参数错误"
+// @Failure      404            {object}  errors.AppError           "实验不存在"
+// @Failure      409            {object}  errors.AppError           "实验已停止"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/experiments/{experiment_id}/promote [post]
+func (h *KnowledgeBaseHandler) PromoteExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateExperimentKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req PromoteExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	if req.Variant != types.ExperimentVariantA && req.Variant != types.ExperimentVariantB {
+		c.Error(apperrors.NewBadRequestError("Variant must be a or b"))
+		return
+	}
+
+	experiment, err := h.experimentService.PromoteExperiment(ctx, id,
+		secutils.SanitizeForLog(c.Param("experiment_id")), req.Variant)
+	if err != nil {
+		c.Error(experimentError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiment,
+	})
+}
+
+// GetExperimentResults godoc
+// @Summary      获取 A/B 实验结果
+// @Description  对比两个变体的检索次数、无结果检索、平均延迟、引用点击率和正向反馈率，并给出按晋升指标领先的变体
+// @Tags         A/B 实验
+// @Produce      json
+// @Param        id             path      string  true  "知识库ID"
+// @Param        experiment_id  path      string  true  "实验ID"
+// @Success      200            {object}  map[string]interface{}  "实验结果"
+// @Failure      404            {object}  errors.AppError         "实验不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/experiments/{experiment_id}/results [get]
+func (h *KnowledgeBaseHandler) GetExperimentResults(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateExperimentKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results, err := h.experimentService.GetExperimentResults(ctx, id, secutils.SanitizeForLog(c.Param("experiment_id")))
+	if err != nil {
+		c.Error(experimentError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// experimentError maps experiment failures to API errors
+func experimentError(ctx context.Context, err error) error {
+	var appErr *apperrors.AppError
+	switch {
+	case stderrors.Is(err, repository.ErrExperimentNotFound):
+		return apperrors.NewNotFoundError("Experiment not found")
+	case stderrors.As(err, &appErr):
+		return appErr
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
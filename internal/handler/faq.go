@@ -16,35 +16,35 @@ import (
 
 // FAQHandler handles FAQ knowledge base operations.
 type FAQHandler struct {
-	knowledgeService  interfaces.KnowledgeService
-	kbService         interfaces.KnowledgeBaseService
-	kbShareService    interfaces.KBShareService
-	agentShareService interfaces.AgentShareService
+	knowledgeService    interfaces.KnowledgeService
+	kbService           interfaces.KnowledgeBaseService
+	agentShareService   interfaces.AgentShareService
+	kbPermissionService interfaces.KBPermissionService
 }
 
 // NewFAQHandler creates a new FAQ handler
 func NewFAQHandler(
 	knowledgeService interfaces.KnowledgeService,
 	kbService interfaces.KnowledgeBaseService,
-	kbShareService interfaces.KBShareService,
 	agentShareService interfaces.AgentShareService,
+	kbPermissionService interfaces.KBPermissionService,
 ) *FAQHandler {
 	return &FAQHandler{
-		knowledgeService:  knowledgeService,
-		kbService:         kbService,
-		kbShareService:    kbShareService,
-		agentShareService: agentShareService,
+		knowledgeService:    knowledgeService,
+		kbService:           kbService,
+		agentShareService:   agentShareService,
+		kbPermissionService: kbPermissionService,
 	}
 }
 
-// effectiveCtxForKB validates KB access (owner, shared, or via shared agent when requiredPermission is Viewer) and returns context with effectiveTenantID.
-func (h *FAQHandler) effectiveCtxForKB(c *gin.Context, kbID string, requiredPermission types.OrgMemberRole) (context.Context, error) {
+// effectiveCtxForKB validates the role of the caller on the KB (granted, owner, shared, or via shared agent when requiredRole is Viewer) and returns context with effectiveTenantID.
+func (h *FAQHandler) effectiveCtxForKB(c *gin.Context, kbID string, requiredRole types.KBRole) (context.Context, error) {
 	ctx := c.Request.Context()
 	tenantID := c.GetUint64(types.TenantIDContextKey.String())
 	if tenantID == 0 {
 		return nil, errors.NewUnauthorizedError("Unauthorized")
 	}
-	userID, userExists := c.Get(types.UserIDContextKey.String())
+	userID := c.GetString(types.UserIDContextKey.String())
 	kbID = secutils.SanitizeForLog(kbID)
 	if kbID == "" {
 		return nil, errors.NewBadRequestError("Knowledge base ID cannot be empty")
@@ -54,24 +54,22 @@ func (h *FAQHandler) effectiveCtxForKB(c *gin.Context, kbID string, requiredPerm
 		logger.ErrorWithFields(ctx, err, nil)
 		return nil, errors.NewInternalServerError(err.Error())
 	}
-	if kb.TenantID == tenantID {
-		return context.WithValue(ctx, types.TenantIDContextKey, tenantID), nil
-	}
-	if userExists && h.kbShareService != nil {
-		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, kbID, userID.(string))
-		if permErr == nil && isShared && permission.HasPermission(requiredPermission) {
-			sourceTenantID, srcErr := h.kbShareService.GetKBSourceTenant(ctx, kbID)
-			if srcErr == nil {
-				logger.Infof(ctx, "User %s accessing shared KB %s with permission %s, source tenant: %d",
-					userID.(string), kbID, permission, sourceTenantID)
-				return context.WithValue(ctx, types.TenantIDContextKey, sourceTenantID), nil
-			}
+	role, effectiveTenantID, err := h.kbPermissionService.ResolveKBRole(ctx, kb, tenantID, userID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return nil, errors.NewInternalServerError(err.Error())
+	}
+	if role.HasPermission(requiredRole) {
+		if kb.TenantID != tenantID {
+			logger.Infof(ctx, "User %s accessing shared KB %s with role %s, source tenant: %d",
+				userID, kbID, role, effectiveTenantID)
 		}
+		return context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID), nil
 	}
-	if requiredPermission == types.OrgRoleViewer && userExists && h.agentShareService != nil {
-		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID.(string), tenantID, kb)
+	if role == "" && requiredRole == types.KBRoleViewer && userID != "" && h.agentShareService != nil {
+		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID, tenantID, kb)
 		if err == nil && can {
-			logger.Infof(ctx, "User %s accessing KB %s via some shared agent", userID.(string), kbID)
+			logger.Infof(ctx, "User %s accessing KB %s via some shared agent", userID, kbID)
 			return context.WithValue(ctx, types.TenantIDContextKey, kb.TenantID), nil
 		}
 	}
@@ -100,7 +98,7 @@ func (h *FAQHandler) effectiveCtxForKB(c *gin.Context, kbID string, requiredPerm
 func (h *FAQHandler) ListEntries(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleViewer)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -157,7 +155,7 @@ func (h *FAQHandler) ListEntries(c *gin.Context) {
 func (h *FAQHandler) UpsertEntries(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -201,7 +199,7 @@ func (h *FAQHandler) UpsertEntries(c *gin.Context) {
 func (h *FAQHandler) CreateEntry(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -243,7 +241,7 @@ func (h *FAQHandler) CreateEntry(c *gin.Context) {
 func (h *FAQHandler) UpdateEntry(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -291,7 +289,7 @@ func (h *FAQHandler) UpdateEntry(c *gin.Context) {
 func (h *FAQHandler) UpdateEntryTagBatch(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -329,7 +327,7 @@ func (h *FAQHandler) UpdateEntryTagBatch(c *gin.Context) {
 func (h *FAQHandler) UpdateEntryFieldsBatch(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -383,7 +381,7 @@ type addSimilarQuestionsRequest struct {
 func (h *FAQHandler) DeleteEntries(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -424,7 +422,7 @@ func (h *FAQHandler) DeleteEntries(c *gin.Context) {
 func (h *FAQHandler) SearchFAQ(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleViewer)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -470,7 +468,7 @@ func (h *FAQHandler) SearchFAQ(c *gin.Context) {
 func (h *FAQHandler) ExportEntries(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleViewer)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -508,7 +506,7 @@ func (h *FAQHandler) ExportEntries(c *gin.Context) {
 func (h *FAQHandler) GetEntry(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleViewer)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -583,7 +581,7 @@ type updateLastFAQImportResultDisplayStatusRequest struct {
 func (h *FAQHandler) UpdateLastImportResultDisplayStatus(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -625,7 +623,7 @@ func (h *FAQHandler) UpdateLastImportResultDisplayStatus(c *gin.Context) {
 func (h *FAQHandler) AddSimilarQuestions(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
-	effCtx, err := h.effectiveCtxForKB(c, kbID, types.OrgRoleEditor)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
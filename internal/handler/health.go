@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler serves the deep health and readiness endpoints
+type HealthHandler struct {
+	service interfaces.HealthService
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(service interfaces.HealthService) *HealthHandler {
+	return &HealthHandler{service: service}
+}
+
+// Healthz godoc
+// @Summary      依赖健康检查
+// @Description  探测 Postgres、Redis、向量数据库、DocReader、Neo4j 与模型服务，返回各依赖的状态与延迟。必需依赖不可用时返回 503
+// @Tags         系统
+// @Produce      json
+// @Success      200  {object}  types.HealthReport  "服务正常或部分降级"
+// @Failure      503  {object}  types.HealthReport  "必需依赖不可用"
+// @Router       /healthz [get]
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	report := h.service.Check(c.Request.Context())
+	status := http.StatusOK
+	if report.Status == types.HealthStatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// Readyz godoc
+// @Summary      就绪检查
+// @Description  必需依赖均可用且服务未在停机时返回 200，否则返回 503，供编排系统决定是否转发流量
+// @Tags         系统
+// @Produce      json
+// @Success      200  {object}  types.HealthReport  "可以接收流量"
+// @Failure      503  {object}  types.HealthReport  "不可接收流量"
+// @Router       /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.service.Check(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
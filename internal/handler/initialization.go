@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -15,9 +16,11 @@ import (
 
 	"github.com/Tencent/WeKnora/docreader/client"
 	"github.com/Tencent/WeKnora/docreader/proto"
+	"github.com/Tencent/WeKnora/internal/application/service"
 	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
@@ -129,8 +132,9 @@ type KBModelConfigRequest struct {
 
 	// 问题生成配置
 	QuestionGeneration struct {
-		Enabled       bool `json:"enabled"`
-		QuestionCount int  `json:"questionCount"`
+		Enabled         bool `json:"enabled"`
+		QuestionCount   int  `json:"questionCount"`
+		GenerateAnswers bool `json:"generateAnswers"`
 	} `json:"questionGeneration"`
 }
 
@@ -232,6 +236,10 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 		c.Error(errors.NewBadRequestError(err.Error()))
 		return
 	}
+	if err := req.VLMConfig.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	// 获取知识库信息
 	kb, err := h.kbService.GetKnowledgeBaseByID(ctx, kbIdStr)
@@ -284,6 +292,8 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 		} else {
 			kb.VLMConfig.Enabled = req.VLMConfig.Enabled
 			kb.VLMConfig.ModelID = req.VLMConfig.ModelID
+			kb.VLMConfig.Prompt = req.VLMConfig.Prompt
+			kb.VLMConfig.MaxImagesPerDocument = req.VLMConfig.MaxImagesPerDocument
 		}
 	}
 	if !kb.VLMConfig.Enabled {
@@ -370,8 +380,9 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 			questionCount = 10
 		}
 		kb.QuestionGenerationConfig = &types.QuestionGenerationConfig{
-			Enabled:       true,
-			QuestionCount: questionCount,
+			Enabled:         true,
+			QuestionCount:   questionCount,
+			GenerateAnswers: req.QuestionGeneration.GenerateAnswers,
 		}
 	} else {
 		kb.QuestionGenerationConfig = &types.QuestionGenerationConfig{Enabled: false}
@@ -386,7 +397,7 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "配置更新成功",
+		"message": i18n.T(ctx, i18n.MsgConfigUpdated),
 	})
 }
 
@@ -431,6 +442,16 @@ func (h *InitializationHandler) InitializeByKB(c *gin.Context) {
 		return
 	}
 
+	if err := h.kbService.CheckEmbeddingDimension(ctx, kb, req.Embedding.Dimension); err != nil {
+		if stderrors.Is(err, service.ErrEmbeddingDimensionMismatch) {
+			c.Error(errors.NewConflictError(err.Error()))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"kbId": utils.SanitizeForLog(kbIdStr)})
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
 	processedModels, err := h.processInitializationModels(ctx, kb, kbIdStr, req)
 	if err != nil {
 		c.Error(err)
@@ -447,7 +468,7 @@ func (h *InitializationHandler) InitializeByKB(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "知识库配置更新成功",
+		"message": i18n.T(ctx, i18n.MsgKnowledgeBaseConfigUpdated),
 		"data": gin.H{
 			"models":         processedModels,
 			"knowledge_base": kb,
@@ -719,9 +740,12 @@ func (h *InitializationHandler) applyKnowledgeBaseInitialization(
 	}
 
 	if req.Multimodal.Enabled {
+		// The prompt and image limit are not part of initialization, keep the ones already set
 		kb.VLMConfig = types.VLMConfig{
-			Enabled: req.Multimodal.Enabled,
-			ModelID: vlmModelID,
+			Enabled:              req.Multimodal.Enabled,
+			ModelID:              vlmModelID,
+			Prompt:               kb.VLMConfig.Prompt,
+			MaxImagesPerDocument: kb.VLMConfig.MaxImagesPerDocument,
 		}
 		switch req.Multimodal.StorageType {
 		case "cos":
@@ -953,7 +977,7 @@ func (h *InitializationHandler) DownloadOllamaModel(c *gin.Context) {
 	if available {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
-			"message": "模型已存在",
+			"message": i18n.T(ctx, i18n.MsgModelAlreadyExists),
 			"data": gin.H{
 				"modelName": req.ModelName,
 				"status":    "completed",
@@ -970,7 +994,7 @@ func (h *InitializationHandler) DownloadOllamaModel(c *gin.Context) {
 			tasksMutex.RUnlock()
 			c.JSON(http.StatusOK, gin.H{
 				"success": true,
-				"message": "模型下载任务已存在",
+				"message": i18n.T(ctx, i18n.MsgModelDownloadExists),
 				"data": gin.H{
 					"taskId":    task.ID,
 					"modelName": task.ModelName,
@@ -1008,7 +1032,7 @@ func (h *InitializationHandler) DownloadOllamaModel(c *gin.Context) {
 	logger.Infof(ctx, "Created download task for model, task ID: %s", taskID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "模型下载任务已创建",
+		"message": i18n.T(ctx, i18n.MsgModelDownloadCreated),
 		"data": gin.H{
 			"taskId":    taskID,
 			"modelName": req.ModelName,
@@ -1428,6 +1452,9 @@ type RemoteModelCheckRequest struct {
 	ModelName string `json:"modelName" binding:"required"`
 	BaseURL   string `json:"baseUrl"   binding:"required"`
 	APIKey    string `json:"apiKey"`
+	Provider  string `json:"provider"`
+	// ExtraConfig holds provider-specific settings, such as the Azure deployment or Bedrock credentials
+	ExtraConfig map[string]string `json:"extraConfig"`
 }
 
 // CheckRemoteModel godoc
@@ -1466,8 +1493,10 @@ func (h *InitializationHandler) CheckRemoteModel(c *gin.Context) {
 		Name:   req.ModelName,
 		Source: "remote",
 		Parameters: types.ModelParameters{
-			BaseURL: req.BaseURL,
-			APIKey:  req.APIKey,
+			BaseURL:     req.BaseURL,
+			APIKey:      req.APIKey,
+			Provider:    req.Provider,
+			ExtraConfig: req.ExtraConfig,
 		},
 		Type: "llm", // 默认类型，实际检查时不区分具体类型
 	}
@@ -1510,6 +1539,8 @@ func (h *InitializationHandler) TestEmbeddingModel(c *gin.Context) {
 		APIKey    string `json:"apiKey"`
 		Dimension int    `json:"dimension"`
 		Provider  string `json:"provider"`
+		// ExtraConfig holds provider-specific settings, such as the Azure deployment or Bedrock credentials
+		ExtraConfig map[string]string `json:"extraConfig"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -1527,7 +1558,7 @@ func (h *InitializationHandler) TestEmbeddingModel(c *gin.Context) {
 				"success": true,
 				"data": gin.H{
 					"available": false,
-					"message":   "阿里云多模态 Embedding 模型暂不支持，请使用纯文本 Embedding 模型（如 text-embedding-v4）",
+					"message":   i18n.T(ctx, i18n.MsgMultimodalEmbeddingUnsupported),
 					"dimension": 0,
 				},
 			})
@@ -1545,6 +1576,7 @@ func (h *InitializationHandler) TestEmbeddingModel(c *gin.Context) {
 		Dimensions:           req.Dimension,
 		ModelID:              "",
 		Provider:             req.Provider,
+		Extra:                req.ExtraConfig,
 	}
 
 	emb, err := embedding.NewEmbedder(cfg, h.pooler, h.ollamaService)
@@ -1588,6 +1620,13 @@ func (h *InitializationHandler) checkRemoteModelConnection(ctx context.Context,
 		ModelName: model.Name,
 		APIKey:    model.Parameters.APIKey,
 		ModelID:   model.Name,
+		Provider:  model.Parameters.Provider,
+	}
+	for key, value := range model.Parameters.ExtraConfig {
+		if chatConfig.Extra == nil {
+			chatConfig.Extra = make(map[string]any, len(model.Parameters.ExtraConfig))
+		}
+		chatConfig.Extra[key] = value
 	}
 
 	// 创建聊天实例
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler handles the background jobs of the current tenant
+type JobHandler struct {
+	service interfaces.JobService
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(service interfaces.JobService) *JobHandler {
+	return &JobHandler{service: service}
+}
+
+// ListJobs godoc
+// @Summary      获取后台任务列表
+// @Description  分页列出当前租户的后台任务（文档解析、重新解析、网页抓取、重建索引等）及其状态，按创建时间倒序
+// @Tags         后台任务
+// @Produce      json
+// @Param        status        query     string  false  "状态筛选：queued/running/retrying/succeeded/failed/cancelled"
+// @Param        type          query     string  false  "任务类型筛选，如 document:process"
+// @Param        kb_id         query     string  false  "知识库ID筛选"
+// @Param        knowledge_id  query     string  false  "知识ID筛选"
+// @Param        page          query     int     false  "页码"
+// @Param        page_size     query     int     false  "每页数量"
+// @Success      200           {object}  map[string]interface{}  "任务列表"
+// @Failure      400           {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.Error(ctx, "Failed to parse pagination parameters", err)
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	var filter types.JobFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		logger.Error(ctx, "Failed to parse filter parameters", err)
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	result, err := h.service.ListJobs(ctx, &filter, &pagination)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      result.Data,
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
+	})
+}
+
+// GetJob godoc
+// @Summary      获取后台任务详情
+// @Description  获取当前租户的一个后台任务的状态、尝试次数与最近一次错误
+// @Tags         后台任务
+// @Produce      json
+// @Param        id   path      string  true  "任务ID"
+// @Success      200  {object}  map[string]interface{}  "任务详情"
+// @Failure      404  {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /jobs/{id} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	job, err := h.service.GetJob(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// CancelJob godoc
+// @Summary      取消后台任务
+// @Description  取消尚未结束的后台任务：排队或等待重试的任务从队列移除，正在运行的任务被中断且不再重试。取消的文档解析任务会将文档标记为解析失败，可重新解析
+// @Tags         后台任务
+// @Produce      json
+// @Param        id   path      string  true  "任务ID"
+// @Success      200  {object}  map[string]interface{}  "已取消的任务"
+// @Failure      404  {object}  errors.AppError         "任务不存在"
+// @Failure      409  {object}  errors.AppError         "任务已结束"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /jobs/{id}/cancel [post]
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	job, err := h.service.CancelJob(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
@@ -0,0 +1,418 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyKBRole godoc
+// @Summary      获取我在知识库的角色
+// @Description  返回当前用户在知识库上的有效角色（owner、editor、contributor、viewer）
+// @Tags         知识库权限
+// @Produce      json
+// @Param        id   path      string                  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "当前角色"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/my-role [get]
+func (h *KnowledgeBaseHandler) GetMyKBRole(c *gin.Context) {
+	_, _, _, role, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"role": role},
+	})
+}
+
+// ListKBMembers godoc
+// @Summary      获取知识库成员角色
+// @Description  列出在知识库上单独授予的角色，仅知识库所有者可查看
+// @Tags         知识库权限
+// @Produce      json
+// @Param        id   path      string                  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "成员角色列表"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/members [get]
+func (h *KnowledgeBaseHandler) ListKBMembers(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, role, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !role.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owners can manage roles"))
+		return
+	}
+
+	members, err := h.kbPermissionService.ListMembers(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    members,
+	})
+}
+
+// SetKBMemberRole godoc
+// @Summary      设置知识库成员角色
+// @Description  授予或修改用户在知识库上的角色，该角色优先于租户与组织共享带来的权限，可用于提升或收窄访问；仅知识库所有者可操作
+// @Tags         知识库权限
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "知识库ID"
+// @Param        user_id  path      string                  true  "用户ID"
+// @Param        request  body      types.SetKBRoleRequest  true  "角色"
+// @Success      200      {object}  map[string]interface{}  "成员角色"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      403      {object}  errors.AppError         "无权限"
+// @Failure      404      {object}  errors.AppError         "用户不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/members/{user_id} [put]
+func (h *KnowledgeBaseHandler) SetKBMemberRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, role, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !role.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owners can manage roles"))
+		return
+	}
+
+	var req types.SetKBRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	member, err := h.kbPermissionService.SetMemberRole(ctx, id, secutils.SanitizeForLog(c.Param("user_id")), req.Role)
+	if err != nil {
+		c.Error(kbPermissionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    member,
+	})
+}
+
+// RemoveKBMember godoc
+// @Summary      移除知识库成员角色
+// @Description  撤销在知识库上单独授予的角色，用户恢复为租户或组织共享带来的权限；仅知识库所有者可操作
+// @Tags         知识库权限
+// @Produce      json
+// @Param        id       path      string                  true  "知识库ID"
+// @Param        user_id  path      string                  true  "用户ID"
+// @Success      200      {object}  map[string]interface{}  "移除成功"
+// @Failure      403      {object}  errors.AppError         "无权限"
+// @Failure      404      {object}  errors.AppError         "未授予角色"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/members/{user_id} [delete]
+func (h *KnowledgeBaseHandler) RemoveKBMember(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, role, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !role.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owners can manage roles"))
+		return
+	}
+
+	if err := h.kbPermissionService.RemoveMember(ctx, id, secutils.SanitizeForLog(c.Param("user_id"))); err != nil {
+		c.Error(kbPermissionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// ListKBInvites godoc
+// @Summary      获取知识库邀请链接
+// @Description  列出知识库未吊销的邀请链接（不含链接令牌），仅知识库所有者可查看
+// @Tags         知识库权限
+// @Produce      json
+// @Param        id   path      string                  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "邀请链接列表"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/invites [get]
+func (h *KnowledgeBaseHandler) ListKBInvites(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, role, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !role.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owners can manage invites"))
+		return
+	}
+
+	invites, err := h.kbPermissionService.ListInvites(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    invites,
+	})
+}
+
+// CreateKBInvite godoc
+// @Summary      创建知识库邀请链接
+// @Description  生成带签名、会过期的邀请链接，接受邀请的用户（可属于其他租户）获得指定角色（editor、contributor、viewer）；可限制使用次数。链接令牌只在创建时返回一次；仅知识库所有者可操作
+// @Tags         知识库权限
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                       true  "知识库ID"
+// @Param        request  body      types.CreateKBInviteRequest  true  "邀请设置"
+// @Success      201      {object}  map[string]interface{}       "邀请链接及令牌"
+// @Failure      400      {object}  errors.AppError              "请求参数错误"
+// @Failure      403      {object}  errors.AppError              "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/invites [post]
+func (h *KnowledgeBaseHandler) CreateKBInvite(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, role, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !role.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owners can manage invites"))
+		return
+	}
+
+	var req types.CreateKBInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	link, err := h.kbPermissionService.CreateInvite(ctx, id, &req)
+	if err != nil {
+		c.Error(kbPermissionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    link,
+	})
+}
+
+// RevokeKBInvite godoc
+// @Summary      吊销知识库邀请链接
+// @Description  吊销邀请链接，之后无法再接受；已通过该链接授予的角色保留，可在成员角色中移除；仅知识库所有者可操作
+// @Tags         知识库权限
+// @Produce      json
+// @Param        id         path      string                  true  "知识库ID"
+// @Param        invite_id  path      string                  true  "邀请ID"
+// @Success      200        {object}  map[string]interface{}  "吊销成功"
+// @Failure      403        {object}  errors.AppError         "无权限"
+// @Failure      404        {object}  errors.AppError         "邀请不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/invites/{invite_id} [delete]
+func (h *KnowledgeBaseHandler) RevokeKBInvite(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, role, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !role.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owners can manage invites"))
+		return
+	}
+
+	if err := h.kbPermissionService.RevokeInvite(ctx, id, secutils.SanitizeForLog(c.Param("invite_id"))); err != nil {
+		c.Error(kbPermissionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// AcceptKBInvite godoc
+// @Summary      接受知识库邀请
+// @Description  使用邀请链接令牌获得知识库上的角色，需以用户身份登录；已有相同或更高角色时保持不变且不占用使用次数
+// @Tags         知识库权限
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.AcceptKBInviteRequest  true  "邀请令牌"
+// @Success      200      {object}  map[string]interface{}       "获得的角色"
+// @Failure      400      {object}  errors.AppError              "链接无效、已过期、已吊销或次数已用完"
+// @Security     Bearer
+// @Router       /kb-invites/accept [post]
+func (h *KnowledgeBaseHandler) AcceptKBInvite(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.AcceptKBInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	member, err := h.kbPermissionService.AcceptInvite(ctx, req.Token)
+	if err != nil {
+		c.Error(kbPermissionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    member,
+	})
+}
+
+// ListKnowledgePermissions godoc
+// @Summary      获取文档权限
+// @Description  列出为文档单独设置的用户权限，仅知识库所有者可查看
+// @Tags         知识库权限
+// @Produce      json
+// @Param        id   path      string                  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "文档权限列表"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/permissions [get]
+func (h *KnowledgeHandler) ListKnowledgePermissions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	knowledge, _, err := h.resolveKnowledgeAndValidateKBAccess(c, secutils.SanitizeForLog(c.Param("id")), types.KBRoleOwner)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	permissions, err := h.kbPermissionService.ListKnowledgePermissions(ctx, knowledge.ID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    permissions,
+	})
+}
+
+// SetKnowledgePermission godoc
+// @Summary      设置文档权限
+// @Description  为用户单独设置文档权限（editor、viewer，或 none 对其隐藏文档，包括检索结果），覆盖其在知识库上的角色；仅知识库所有者可操作
+// @Tags         知识库权限
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "知识ID"
+// @Param        user_id  path      string                  true  "用户ID"
+// @Param        request  body      types.SetKBRoleRequest  true  "权限"
+// @Success      200      {object}  map[string]interface{}  "文档权限"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      403      {object}  errors.AppError         "无权限"
+// @Failure      404      {object}  errors.AppError         "用户不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/permissions/{user_id} [put]
+func (h *KnowledgeHandler) SetKnowledgePermission(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	knowledge, _, err := h.resolveKnowledgeAndValidateKBAccess(c, secutils.SanitizeForLog(c.Param("id")), types.KBRoleOwner)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req types.SetKBRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	permission, err := h.kbPermissionService.SetKnowledgePermission(ctx, knowledge,
+		secutils.SanitizeForLog(c.Param("user_id")), req.Role)
+	if err != nil {
+		c.Error(kbPermissionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    permission,
+	})
+}
+
+// RemoveKnowledgePermission godoc
+// @Summary      移除文档权限
+// @Description  移除为用户单独设置的文档权限，用户恢复为其在知识库上的角色；仅知识库所有者可操作
+// @Tags         知识库权限
+// @Produce      json
+// @Param        id       path      string                  true  "知识ID"
+// @Param        user_id  path      string                  true  "用户ID"
+// @Success      200      {object}  map[string]interface{}  "移除成功"
+// @Failure      403      {object}  errors.AppError         "无权限"
+// @Failure      404      {object}  errors.AppError         "未设置权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/permissions/{user_id} [delete]
+func (h *KnowledgeHandler) RemoveKnowledgePermission(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	knowledge, _, err := h.resolveKnowledgeAndValidateKBAccess(c, secutils.SanitizeForLog(c.Param("id")), types.KBRoleOwner)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.kbPermissionService.RemoveKnowledgePermission(ctx, knowledge.ID,
+		secutils.SanitizeForLog(c.Param("user_id"))); err != nil {
+		c.Error(kbPermissionError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// kbPermissionError maps role management failures to API errors
+func kbPermissionError(ctx context.Context, err error) error {
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		return appErr
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
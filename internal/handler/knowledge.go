@@ -8,11 +8,14 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	goerrors "errors"
 
 	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -22,175 +25,128 @@ import (
 
 // KnowledgeHandler processes HTTP requests related to knowledge resources
 type KnowledgeHandler struct {
-	kgService         interfaces.KnowledgeService
-	kbService         interfaces.KnowledgeBaseService
-	kbShareService    interfaces.KBShareService
-	agentShareService interfaces.AgentShareService
+	kgService           interfaces.KnowledgeService
+	kbService           interfaces.KnowledgeBaseService
+	agentShareService   interfaces.AgentShareService
+	kbPermissionService interfaces.KBPermissionService
+	fileLinkService     interfaces.KnowledgeFileLinkService
+	cfg                 *config.Config
 }
 
 // NewKnowledgeHandler creates a new knowledge handler instance
 func NewKnowledgeHandler(
 	kgService interfaces.KnowledgeService,
 	kbService interfaces.KnowledgeBaseService,
-	kbShareService interfaces.KBShareService,
 	agentShareService interfaces.AgentShareService,
+	kbPermissionService interfaces.KBPermissionService,
+	fileLinkService interfaces.KnowledgeFileLinkService,
+	cfg *config.Config,
 ) *KnowledgeHandler {
 	return &KnowledgeHandler{
-		kgService:         kgService,
-		kbService:         kbService,
-		kbShareService:    kbShareService,
-		agentShareService: agentShareService,
+		kgService:           kgService,
+		kbService:           kbService,
+		agentShareService:   agentShareService,
+		kbPermissionService: kbPermissionService,
+		fileLinkService:     fileLinkService,
+		cfg:                 cfg,
 	}
 }
 
 // validateKnowledgeBaseAccess validates access permissions to a knowledge base
-// Returns the knowledge base, the knowledge base ID, effective tenant ID, permission level, and any errors encountered
+// Returns the knowledge base, the knowledge base ID, effective tenant ID, role of the caller, and any errors encountered
 // For owned KBs, effectiveTenantID is the caller's tenant ID
 // For shared KBs, effectiveTenantID is the source tenant ID (owner's tenant)
-func (h *KnowledgeHandler) validateKnowledgeBaseAccess(c *gin.Context) (*types.KnowledgeBase, string, uint64, types.OrgMemberRole, error) {
-	ctx := c.Request.Context()
+func (h *KnowledgeHandler) validateKnowledgeBaseAccess(c *gin.Context) (*types.KnowledgeBase, string, uint64, types.KBRole, error) {
+	return h.validateKnowledgeBaseAccessWithKBID(c, c.Param("id"))
+}
 
-	// Get tenant ID from context
+// validateKnowledgeBaseAccessWithKBID validates access to the given knowledge base ID (e.g. from query or body).
+// Returns the knowledge base, kbID, effective tenant ID, role of the caller, and error.
+func (h *KnowledgeHandler) validateKnowledgeBaseAccessWithKBID(c *gin.Context, kbID string) (*types.KnowledgeBase, string, uint64, types.KBRole, error) {
+	ctx := c.Request.Context()
 	tenantID := c.GetUint64(types.TenantIDContextKey.String())
 	if tenantID == 0 {
 		logger.Error(ctx, "Failed to get tenant ID")
 		return nil, "", 0, "", errors.NewUnauthorizedError("Unauthorized")
 	}
-
-	// Get user ID from context (needed for shared KB permission check)
-	userID, userExists := c.Get(types.UserIDContextKey.String())
-
-	// Get knowledge base ID from URL path parameter
-	kbID := secutils.SanitizeForLog(c.Param("id"))
+	userID := c.GetString(types.UserIDContextKey.String())
+	kbID = secutils.SanitizeForLog(kbID)
 	if kbID == "" {
 		logger.Error(ctx, "Knowledge base ID is empty")
 		return nil, "", 0, "", errors.NewBadRequestError("Knowledge base ID cannot be empty")
 	}
-
-	// Get knowledge base details
 	kb, err := h.kbService.GetKnowledgeBaseByID(ctx, kbID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		return nil, kbID, 0, "", errors.NewInternalServerError(err.Error())
 	}
 
-	// Check 1: Verify tenant ownership (owner has full access)
-	if kb.TenantID == tenantID {
-		return kb, kbID, tenantID, types.OrgRoleAdmin, nil
-	}
-
-	// Check 2: If not owner, check organization shared access
-	if userExists && h.kbShareService != nil {
-		// Check if user has shared access through organization
-		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, kbID, userID.(string))
-		if permErr == nil && isShared {
-			// User has shared access, get the source tenant ID for queries
-			sourceTenantID, srcErr := h.kbShareService.GetKBSourceTenant(ctx, kbID)
-			if srcErr == nil {
-				logger.Infof(ctx, "User %s accessing shared KB %s with permission %s, source tenant: %d",
-					userID.(string), kbID, permission, sourceTenantID)
-				return kb, kbID, sourceTenantID, permission, nil
-			}
-		}
-	}
-
-	// Check 3: If not owner and no direct share, allow if user has any shared agent that can access this KB (e.g. opened from "通过智能体可见" list)
-	if userExists && h.agentShareService != nil {
-		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID.(string), tenantID, kb)
-		if err == nil && can {
-			logger.Infof(ctx, "User %s accessing KB %s via some shared agent", userID.(string), kbID)
-			return kb, kbID, kb.TenantID, types.OrgRoleViewer, nil
-		}
-	}
-
-	// No permission: not owner and no shared access
-	logger.Warnf(
-		ctx,
-		"Permission denied to access this knowledge base, tenant ID mismatch, "+
-			"requested tenant ID: %d, knowledge base tenant ID: %d",
-		tenantID,
-		kb.TenantID,
-	)
-	return nil, kbID, 0, "", errors.NewForbiddenError("Permission denied to access this knowledge base")
-}
-
-// validateKnowledgeBaseAccessWithKBID validates access to the given knowledge base ID (e.g. from query or body).
-// Returns the knowledge base, kbID, effective tenant ID, permission, and error.
-func (h *KnowledgeHandler) validateKnowledgeBaseAccessWithKBID(c *gin.Context, kbID string) (*types.KnowledgeBase, string, uint64, types.OrgMemberRole, error) {
-	ctx := c.Request.Context()
-	tenantID := c.GetUint64(types.TenantIDContextKey.String())
-	if tenantID == 0 {
-		logger.Error(ctx, "Failed to get tenant ID")
-		return nil, "", 0, "", errors.NewUnauthorizedError("Unauthorized")
-	}
-	userID, userExists := c.Get(types.UserIDContextKey.String())
-	kbID = secutils.SanitizeForLog(kbID)
-	if kbID == "" {
-		return nil, "", 0, "", errors.NewBadRequestError("Knowledge base ID cannot be empty")
-	}
-	kb, err := h.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	// Check 1: role granted on the knowledge base, tenant ownership or organization share
+	role, effectiveTenantID, err := h.kbPermissionService.ResolveKBRole(ctx, kb, tenantID, userID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		return nil, kbID, 0, "", errors.NewInternalServerError(err.Error())
 	}
-	if kb.TenantID == tenantID {
-		return kb, kbID, tenantID, types.OrgRoleAdmin, nil
-	}
-	if userExists && h.kbShareService != nil {
-		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, kbID, userID.(string))
-		if permErr == nil && isShared {
-			sourceTenantID, srcErr := h.kbShareService.GetKBSourceTenant(ctx, kbID)
-			if srcErr == nil {
-				logger.Infof(ctx, "User %s accessing shared KB %s with permission %s, source tenant: %d",
-					userID.(string), kbID, permission, sourceTenantID)
-				return kb, kbID, sourceTenantID, permission, nil
-			}
+	if role != "" {
+		if kb.TenantID != tenantID {
+			logger.Infof(ctx, "User %s accessing shared KB %s with role %s, source tenant: %d",
+				userID, kbID, role, effectiveTenantID)
 		}
+		return kb, kbID, effectiveTenantID, role, nil
 	}
-	if userExists && h.agentShareService != nil {
-		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID.(string), tenantID, kb)
+
+	// Check 2: allow read access if user has any shared agent that can access this KB (e.g. opened from "通过智能体可见" list)
+	if userID != "" && h.agentShareService != nil {
+		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID, tenantID, kb)
 		if err == nil && can {
-			logger.Infof(ctx, "User %s accessing KB %s via some shared agent", userID.(string), kbID)
-			return kb, kbID, kb.TenantID, types.OrgRoleViewer, nil
+			logger.Infof(ctx, "User %s accessing KB %s via some shared agent", userID, kbID)
+			return kb, kbID, kb.TenantID, types.KBRoleViewer, nil
 		}
 	}
 	logger.Warnf(ctx, "Permission denied to access KB %s, tenant ID: %d, KB tenant: %d", kbID, tenantID, kb.TenantID)
 	return nil, kbID, 0, "", errors.NewForbiddenError("Permission denied to access this knowledge base")
 }
 
-// resolveKnowledgeAndValidateKBAccess resolves knowledge by ID and validates KB access (owner or shared with required permission).
+// resolveKnowledgeAndValidateKBAccess resolves knowledge by ID and validates the role of the caller on it,
+// taking the permission set on the document and its author into account.
 // Returns the knowledge, context with effectiveTenantID set for downstream service calls, and error.
-func (h *KnowledgeHandler) resolveKnowledgeAndValidateKBAccess(c *gin.Context, knowledgeID string, requiredPermission types.OrgMemberRole) (*types.Knowledge, context.Context, error) {
+func (h *KnowledgeHandler) resolveKnowledgeAndValidateKBAccess(c *gin.Context, knowledgeID string, requiredRole types.KBRole) (*types.Knowledge, context.Context, error) {
 	ctx := c.Request.Context()
 	tenantID := c.GetUint64(types.TenantIDContextKey.String())
 	if tenantID == 0 {
 		return nil, ctx, errors.NewUnauthorizedError("Unauthorized")
 	}
-	userID, userExists := c.Get(types.UserIDContextKey.String())
+	userID := c.GetString(types.UserIDContextKey.String())
 
 	knowledge, err := h.kgService.GetKnowledgeByIDOnly(ctx, knowledgeID)
 	if err != nil {
 		return nil, ctx, errors.NewNotFoundError("Knowledge not found")
 	}
 
-	// Owner: knowledge belongs to caller's tenant
-	if knowledge.TenantID == tenantID {
-		return knowledge, context.WithValue(ctx, types.TenantIDContextKey, tenantID), nil
+	// Role on the knowledge base, narrowed or widened by the document permission
+	kbRef := &types.KnowledgeBase{ID: knowledge.KnowledgeBaseID, TenantID: knowledge.TenantID}
+	kbRole, effectiveTenantID, err := h.kbPermissionService.ResolveKBRole(ctx, kbRef, tenantID, userID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return nil, ctx, errors.NewInternalServerError(err.Error())
 	}
-
-	// Shared KB: check organization permission
-	if userExists && h.kbShareService != nil {
-		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, knowledge.KnowledgeBaseID, userID.(string))
-		if permErr == nil && isShared && permission.HasPermission(requiredPermission) {
-			effectiveTenantID := knowledge.TenantID
-			return knowledge, context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID), nil
+	if kbRole != "" {
+		role, err := h.kbPermissionService.ResolveKnowledgeRole(ctx, knowledge, kbRole, userID)
+		if err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			return nil, ctx, errors.NewInternalServerError(err.Error())
 		}
+		if !role.HasPermission(requiredRole) {
+			return nil, ctx, errors.NewForbiddenError("Permission denied to access this knowledge")
+		}
+		return knowledge, context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID), nil
 	}
+
 	// Shared agent: request passes agent_id, or user has any shared agent that can access this KB
-	if userExists && h.agentShareService != nil && requiredPermission == types.OrgRoleViewer {
+	if userID != "" && h.agentShareService != nil && requiredRole == types.KBRoleViewer {
 		agentID := c.Query("agent_id")
 		if agentID != "" {
-			agent, err := h.agentShareService.GetSharedAgentForUser(ctx, userID.(string), tenantID, agentID)
+			agent, err := h.agentShareService.GetSharedAgentForUser(ctx, userID, tenantID, agentID)
 			if err == nil && agent != nil {
 				if knowledge.TenantID != agent.TenantID {
 					return nil, ctx, errors.NewForbiddenError("Permission denied to access this knowledge")
@@ -212,8 +168,7 @@ func (h *KnowledgeHandler) resolveKnowledgeAndValidateKBAccess(c *gin.Context, k
 				}
 			}
 		} else {
-			kbRef := &types.KnowledgeBase{ID: knowledge.KnowledgeBaseID, TenantID: knowledge.TenantID}
-			can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID.(string), tenantID, kbRef)
+			can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID, tenantID, kbRef)
 			if err == nil && can {
 				return knowledge, context.WithValue(ctx, types.TenantIDContextKey, knowledge.TenantID), nil
 			}
@@ -271,7 +226,7 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
 
 	// Check write permission
-	if permission != types.OrgRoleAdmin && permission != types.OrgRoleEditor {
+	if !permission.HasPermission(types.KBRoleContributor) {
 		c.Error(errors.NewForbiddenError("No permission to create knowledge"))
 		return
 	}
@@ -358,9 +313,95 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 		secutils.SanitizeForLog(knowledge.ID),
 		secutils.SanitizeForLog(knowledge.Title),
 	)
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, knowledgeCreatedResponse(knowledge))
+}
+
+// knowledgeCreatedResponse is the response of a created knowledge, warning when its content duplicates another
+func knowledgeCreatedResponse(knowledge *types.Knowledge) gin.H {
+	resp := gin.H{
 		"success": true,
 		"data":    knowledge,
+	}
+	if warning := knowledge.DuplicateWarning(); warning != "" {
+		resp["warnings"] = []string{warning}
+	}
+	return resp
+}
+
+// ImportEnex godoc
+// @Summary      导入印象笔记导出文件
+// @Description  上传印象笔记导出的 .enex 文件，每条笔记创建一个知识，笔记中的图片经 OCR/图片描述处理，PDF 等附件一并解析；笔记本和笔记标签映射为知识库标签，笔记的创建、更新时间等记录在知识元数据中
+// @Tags         知识管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id                path      string  true   "知识库ID"
+// @Param        file              formData  file    true   "印象笔记导出文件（.enex）"
+// @Param        notebook          formData  string  false  "笔记本名称，默认取文件名"
+// @Param        metadata          formData  string  false  "元数据JSON"
+// @Param        enable_multimodel formData  bool    false  "启用多模态处理"
+// @Param        tag_id            formData  string  false  "标签ID"
+// @Success      200               {object}  types.EnexImportResult  "导入结果"
+// @Failure      400               {object}  errors.AppError         "不是有效的印象笔记导出文件"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/enex [post]
+func (h *KnowledgeHandler) ImportEnex(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, effectiveTenantID, permission, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
+	if !permission.HasPermission(types.KBRoleContributor) {
+		c.Error(errors.NewForbiddenError("No permission to create knowledge"))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "File upload failed", err)
+		c.Error(errors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+	if file.Size > secutils.GetMaxFileSize() {
+		c.Error(errors.NewBadRequestError(fmt.Sprintf("文件大小不能超过%dMB", secutils.GetMaxFileSizeMB())))
+		return
+	}
+
+	var metadata map[string]string
+	if metadataStr := c.PostForm("metadata"); metadataStr != "" {
+		if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
+			logger.Error(ctx, "Failed to parse metadata", err)
+			c.Error(errors.NewBadRequestError("Invalid metadata format").WithDetails(err.Error()))
+			return
+		}
+	}
+	var enableMultimodel *bool
+	if enableMultimodelForm := c.PostForm("enable_multimodel"); enableMultimodelForm != "" {
+		parseBool, err := strconv.ParseBool(enableMultimodelForm)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid enable_multimodel format").WithDetails(err.Error()))
+			return
+		}
+		enableMultimodel = &parseBool
+	}
+	tagID := c.PostForm("tag_id")
+	if tagID == "__untagged__" {
+		tagID = ""
+	}
+	logger.Infof(ctx, "Importing Evernote export, knowledge base ID: %s, filename: %s, size: %.2f KB",
+		secutils.SanitizeForLog(kbID), secutils.SanitizeForLog(file.Filename), float64(file.Size)/1024)
+
+	result, err := h.kgService.ImportEnex(ctx, kbID, file, metadata, enableMultimodel, c.PostForm("notebook"), tagID)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
 	})
 }
 
@@ -371,7 +412,7 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id       path      string  true  "知识库ID"
-// @Param        request  body      object{url=string,enable_multimodel=bool,title=string,tag_id=string}  true  "URL请求"
+// @Param        request  body      object{url=string,enable_multimodel=bool,title=string,tag_id=string,extraction_mode=string}  true  "URL请求"
 // @Success      201      {object}  map[string]interface{}  "创建的知识"
 // @Failure      400      {object}  errors.AppError         "请求参数错误"
 // @Failure      409      {object}  map[string]interface{}  "URL重复"
@@ -391,7 +432,7 @@ func (h *KnowledgeHandler) CreateKnowledgeFromURL(c *gin.Context) {
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
 
 	// Check write permission
-	if permission != types.OrgRoleAdmin && permission != types.OrgRoleEditor {
+	if !permission.HasPermission(types.KBRoleContributor) {
 		c.Error(errors.NewForbiddenError("No permission to create knowledge"))
 		return
 	}
@@ -402,12 +443,18 @@ func (h *KnowledgeHandler) CreateKnowledgeFromURL(c *gin.Context) {
 		EnableMultimodel *bool  `json:"enable_multimodel"`
 		Title            string `json:"title"`
 		TagID            string `json:"tag_id"`
+		// ExtractionMode overrides the web extraction mode of the knowledge base: full, readability or llm
+		ExtractionMode string `json:"extraction_mode"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "Failed to parse URL request", err)
 		c.Error(errors.NewBadRequestError(err.Error()))
 		return
 	}
+	if !types.ValidWebExtractionMode(req.ExtractionMode) {
+		c.Error(errors.NewBadRequestError("extraction_mode must be full, readability or llm"))
+		return
+	}
 
 	logger.Infof(ctx, "Received URL request: %s", secutils.SanitizeForLog(req.URL))
 	logger.Infof(
@@ -418,7 +465,8 @@ func (h *KnowledgeHandler) CreateKnowledgeFromURL(c *gin.Context) {
 	)
 
 	// Create knowledge entry from the URL
-	knowledge, err := h.kgService.CreateKnowledgeFromURL(ctx, kbID, req.URL, req.EnableMultimodel, req.Title, req.TagID)
+	knowledge, err := h.kgService.CreateKnowledgeFromURL(ctx, kbID, req.URL, req.EnableMultimodel, req.Title, req.TagID,
+		req.ExtractionMode)
 	// Check for duplicate knowledge error
 	if err != nil {
 		if h.handleDuplicateKnowledgeError(c, err, knowledge, "url") {
@@ -441,6 +489,96 @@ func (h *KnowledgeHandler) CreateKnowledgeFromURL(c *gin.Context) {
 	})
 }
 
+// CreateKnowledgeFromFetchedURL godoc
+// @Summary      下载 URL 指向的文件创建知识
+// @Description  服务端下载 URL 指向的 PDF、Office 等文档（大小和类型受上传限制约束），优先使用 Content-Disposition 建议的文件名，按上传文件的方式解析，知识的 source 记录该 URL
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "知识库ID"
+// @Param        request  body      object{url=string,enable_multimodel=bool,title=string,tag_id=string}  true  "URL请求"
+// @Success      201      {object}  map[string]interface{}  "创建的知识"
+// @Failure      400      {object}  errors.AppError         "URL 是网页、文件类型不支持、下载失败或文件过大"
+// @Failure      409      {object}  map[string]interface{}  "文件重复"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/fetch [post]
+func (h *KnowledgeHandler) CreateKnowledgeFromFetchedURL(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, effectiveTenantID, permission, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
+	if !permission.HasPermission(types.KBRoleContributor) {
+		c.Error(errors.NewForbiddenError("No permission to create knowledge"))
+		return
+	}
+
+	var req struct {
+		URL              string `json:"url" binding:"required"`
+		EnableMultimodel *bool  `json:"enable_multimodel"`
+		Title            string `json:"title"`
+		TagID            string `json:"tag_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse fetch request", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	logger.Infof(ctx, "Creating knowledge from fetched URL, knowledge base ID: %s, URL: %s",
+		secutils.SanitizeForLog(kbID), secutils.SanitizeForLog(req.URL))
+
+	knowledge, err := h.kgService.CreateKnowledgeFromFetchedURL(ctx, kbID, req.URL, req.EnableMultimodel,
+		req.Title, req.TagID)
+	if err != nil {
+		if h.handleDuplicateKnowledgeError(c, err, knowledge, "file") {
+			return
+		}
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
+
+// AnalyzeURLs godoc
+// @Summary      批量分析 URL
+// @Description  并发分析粘贴的链接列表（最多 50 个），逐个进行 SSRF 校验并请求，返回每个 URL 是否可访问、是网页还是文档文件、网页标题或导入后的文件名，结果顺序与请求一致
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.AnalyzeURLsRequest  true  "URL 列表"
+// @Success      200      {object}  map[string]interface{}    "每个 URL 的分析结果"
+// @Failure      400      {object}  errors.AppError           "URL 列表为空或过长"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/url/analyze-batch [post]
+func (h *KnowledgeHandler) AnalyzeURLs(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.AnalyzeURLsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	if len(req.URLs) == 0 || len(req.URLs) > types.MaxAnalyzeURLBatch {
+		c.Error(errors.NewBadRequestError(
+			fmt.Sprintf("urls must contain between 1 and %d URLs", types.MaxAnalyzeURLBatch)))
+		return
+	}
+
+	results := h.kgService.AnalyzeURLs(ctx, req.URLs)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
 // CreateManualKnowledge godoc
 // @Summary      手工创建知识
 // @Description  手工录入Markdown格式的知识内容
@@ -467,7 +605,7 @@ func (h *KnowledgeHandler) CreateManualKnowledge(c *gin.Context) {
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
 
 	// Check write permission
-	if permission != types.OrgRoleAdmin && permission != types.OrgRoleEditor {
+	if !permission.HasPermission(types.KBRoleContributor) {
 		c.Error(errors.NewForbiddenError("No permission to create knowledge"))
 		return
 	}
@@ -494,10 +632,7 @@ func (h *KnowledgeHandler) CreateManualKnowledge(c *gin.Context) {
 
 	logger.Infof(ctx, "Manual knowledge created successfully, knowledge ID: %s",
 		secutils.SanitizeForLog(knowledge.ID))
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    knowledge,
-	})
+	c.JSON(http.StatusOK, knowledgeCreatedResponse(knowledge))
 }
 
 // GetKnowledge godoc
@@ -526,7 +661,7 @@ func (h *KnowledgeHandler) GetKnowledge(c *gin.Context) {
 	}
 
 	// Resolve knowledge and validate KB access (at least viewer)
-	knowledge, _, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	knowledge, _, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -542,17 +677,21 @@ func (h *KnowledgeHandler) GetKnowledge(c *gin.Context) {
 
 // ListKnowledge godoc
 // @Summary      获取知识列表
-// @Description  获取知识库下的知识列表，支持分页和筛选
+// @Description  获取知识库下的知识列表，支持按页码或游标分页、排序和筛选
 // @Tags         知识管理
 // @Accept       json
 // @Produce      json
-// @Param        id         path      string  true   "知识库ID"
-// @Param        page       query     int     false  "页码"
-// @Param        page_size  query     int     false  "每页数量"
-// @Param        tag_id     query     string  false  "标签ID筛选"
-// @Param        keyword    query     string  false  "关键词搜索"
-// @Param        file_type  query     string  false  "文件类型筛选"
-// @Success      200        {object}  map[string]interface{}  "知识列表"
+// @Param        id          path      string  true   "知识库ID"
+// @Param        page        query     int     false  "页码，不能与 cursor 同时使用"
+// @Param        page_size   query     int     false  "每页数量，最大 100"
+// @Param        cursor      query     string  false  "上一页返回的 next_cursor"
+// @Param        sort        query     string  false  "排序字段：created_at、updated_at、size 或 parse_status，默认 created_at"
+// @Param        order       query     string  false  "排序方向：asc 或 desc，默认 desc"
+// @Param        skip_total  query     bool    false  "为 true 时不统计总数，适用于大型知识库"
+// @Param        tag_id      query     string  false  "标签ID筛选"
+// @Param        keyword     query     string  false  "关键词搜索"
+// @Param        file_type   query     string  false  "文件类型筛选"
+// @Success      200         {object}  map[string]interface{}  "知识列表"
 // @Failure      400        {object}  errors.AppError         "请求参数错误"
 // @Security     Bearer
 // @Security     ApiKeyAuth
@@ -572,13 +711,18 @@ func (h *KnowledgeHandler) ListKnowledge(c *gin.Context) {
 	// Update context with effective tenant ID for shared KB access
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
 
-	// Parse pagination parameters from query string
-	var pagination types.Pagination
-	if err := c.ShouldBindQuery(&pagination); err != nil {
+	// Parse pagination and sorting parameters from query string
+	var options types.ListOptions
+	if err := c.ShouldBindQuery(&options); err != nil {
 		logger.Error(ctx, "Failed to parse pagination parameters", err)
 		c.Error(errors.NewBadRequestError(err.Error()))
 		return
 	}
+	listQuery, err := options.Resolve(types.KnowledgeSortFields)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	tagID := c.Query("tag_id")
 	keyword := c.Query("keyword")
@@ -586,18 +730,20 @@ func (h *KnowledgeHandler) ListKnowledge(c *gin.Context) {
 
 	logger.Infof(
 		ctx,
-		"Retrieving knowledge list under knowledge base, knowledge base ID: %s, tag_id: %s, keyword: %s, file_type: %s, page: %d, page size: %d, effectiveTenantID: %d",
+		"Retrieving knowledge list under knowledge base, knowledge base ID: %s, tag_id: %s, keyword: %s, file_type: %s, page: %d, page size: %d, sort: %s, cursor: %v, effectiveTenantID: %d",
 		secutils.SanitizeForLog(kbID),
 		secutils.SanitizeForLog(tagID),
 		secutils.SanitizeForLog(keyword),
 		secutils.SanitizeForLog(fileType),
-		pagination.Page,
-		pagination.PageSize,
+		listQuery.Page,
+		listQuery.Limit,
+		listQuery.Sort,
+		listQuery.After != nil,
 		effectiveTenantID,
 	)
 
-	// Retrieve paginated knowledge entries
-	result, err := h.kgService.ListPagedKnowledgeByKnowledgeBaseID(ctx, kbID, &pagination, tagID, keyword, fileType)
+	// Retrieve a page of knowledge entries
+	result, err := h.kgService.ListKnowledgePageByKnowledgeBaseID(ctx, kbID, listQuery, tagID, keyword, fileType)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
@@ -606,101 +752,573 @@ func (h *KnowledgeHandler) ListKnowledge(c *gin.Context) {
 
 	logger.Infof(
 		ctx,
-		"Knowledge list retrieved successfully, knowledge base ID: %s, total: %d",
+		"Knowledge list retrieved successfully, knowledge base ID: %s, has more: %v",
 		secutils.SanitizeForLog(kbID),
-		result.Total,
+		result.HasMore,
 	)
-	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"data":      result.Data,
-		"total":     result.Total,
-		"page":      result.Page,
-		"page_size": result.PageSize,
-	})
+	response := gin.H{
+		"success":     true,
+		"data":        result.Data,
+		"total":       result.Total,
+		"page_size":   result.PageSize,
+		"has_more":    result.HasMore,
+		"next_cursor": result.NextCursor,
+	}
+	if result.Page > 0 {
+		response["page"] = result.Page
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-// DeleteKnowledge godoc
-// @Summary      删除知识
-// @Description  根据ID删除知识条目
+// ListTrashedKnowledge godoc
+// @Summary      获取回收站中的知识
+// @Description  分页获取知识库回收站中的知识，按移入回收站时间倒序
 // @Tags         知识管理
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "知识ID"
-// @Success      200  {object}  map[string]interface{}  "删除成功"
-// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Param        id         path      string  true   "知识库ID"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "回收站知识列表"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
 // @Security     Bearer
 // @Security     ApiKeyAuth
-// @Router       /knowledge/{id} [delete]
-func (h *KnowledgeHandler) DeleteKnowledge(c *gin.Context) {
+// @Router       /knowledge-bases/{id}/knowledge/trash [get]
+func (h *KnowledgeHandler) ListTrashedKnowledge(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	logger.Info(ctx, "Start deleting knowledge")
-
-	id := secutils.SanitizeForLog(c.Param("id"))
-	if id == "" {
-		logger.Error(ctx, "Knowledge ID is empty")
-		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+	_, kbID, effectiveTenantID, _, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
 
-	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
-	if err != nil {
-		c.Error(err)
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.Error(ctx, "Failed to parse pagination parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
 		return
 	}
-	logger.Infof(ctx, "Deleting knowledge, ID: %s", secutils.SanitizeForLog(id))
-	err = h.kgService.DeleteKnowledge(effCtx, id)
+
+	result, err := h.kgService.ListTrashedKnowledge(ctx, kbID, &pagination)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
 	}
 
-	logger.Infof(ctx, "Knowledge deleted successfully, ID: %s", secutils.SanitizeForLog(id))
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Deleted successfully",
+		"success":   true,
+		"data":      result.Data,
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
 	})
 }
 
-// DownloadKnowledgeFile godoc
-// @Summary      下载知识文件
-// @Description  下载知识条目关联的原始文件
+// DedupKnowledgeRequest is the request to clean up duplicates of a knowledge base
+type DedupKnowledgeRequest struct {
+	// Optional: only trash these duplicates; empty means all detected duplicates
+	KnowledgeIDs []string `json:"knowledge_ids"`
+}
+
+// ListDuplicateKnowledge godoc
+// @Summary      获取重复文档报告
+// @Description  列出知识库中检测到的重复文档，按原始文档分组（exact 为内容完全相同，near 为近似重复）
 // @Tags         知识管理
 // @Accept       json
-// @Produce      application/octet-stream
-// @Param        id   path      string  true  "知识ID"
-// @Success      200  {file}    file    "文件内容"
-// @Failure      400  {object}  errors.AppError  "请求参数错误"
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "重复文档分组"
+// @Failure      403  {object}  errors.AppError         "无权限"
 // @Security     Bearer
 // @Security     ApiKeyAuth
-// @Router       /knowledge/{id}/download [get]
-func (h *KnowledgeHandler) DownloadKnowledgeFile(c *gin.Context) {
+// @Router       /knowledge-bases/{id}/knowledge/duplicates [get]
+func (h *KnowledgeHandler) ListDuplicateKnowledge(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	logger.Info(ctx, "Start downloading knowledge file")
-
-	id := secutils.SanitizeForLog(c.Param("id"))
-	if id == "" {
-		logger.Error(ctx, "Knowledge ID is empty")
-		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
-		return
-	}
-
-	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	_, kbID, effectiveTenantID, permission, err := h.validateKnowledgeBaseAccess(c)
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	logger.Infof(ctx, "Retrieving knowledge file, ID: %s", secutils.SanitizeForLog(id))
+	if !permission.HasPermission(types.KBRoleOwner) {
+		c.Error(errors.NewForbiddenError("No permission to view duplicate report"))
+		return
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
 
-	file, filename, err := h.kgService.GetKnowledgeFile(effCtx, id)
+	clusters, err := h.kgService.ListDuplicateClusters(ctx, kbID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError("Failed to retrieve file").WithDetails(err.Error()))
+		c.Error(errors.NewInternalServerError(err.Error()))
 		return
 	}
-	defer file.Close()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    clusters,
+	})
+}
+
+// GetKnowledgeLinkReport godoc
+// @Summary      获取失效链接报告
+// @Description  统计知识库中从网页或 URL 文件导入的知识的来源链接状态，并列出指定状态（默认 dead、changed、unreachable）的知识，便于清理或重新抓取。链接由后台任务定期检查，需要知识库编辑权限
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string  true   "知识库ID"
+// @Param        status  query     string  false  "链接状态，逗号分隔：ok、dead、changed、unreachable"
+// @Success      200     {object}  types.KnowledgeLinkReport  "失效链接报告"
+// @Failure      400     {object}  errors.AppError            "状态无效"
+// @Failure      403     {object}  errors.AppError            "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/link-report [get]
+func (h *KnowledgeHandler) GetKnowledgeLinkReport(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, effectiveTenantID, permission, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !permission.HasPermission(types.KBRoleEditor) {
+		c.Error(errors.NewForbiddenError("No permission to view link report"))
+		return
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
+
+	var statuses []types.KnowledgeLinkStatus
+	for _, status := range strings.Split(c.Query("status"), ",") {
+		switch status := types.KnowledgeLinkStatus(strings.TrimSpace(status)); status {
+		case "":
+		case types.KnowledgeLinkOK, types.KnowledgeLinkDead, types.KnowledgeLinkChanged,
+			types.KnowledgeLinkUnreachable:
+			statuses = append(statuses, status)
+		default:
+			c.Error(errors.NewBadRequestError("status must be ok, dead, changed or unreachable"))
+			return
+		}
+	}
+
+	report, err := h.kgService.GetKnowledgeLinkReport(ctx, kbID, statuses)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// DedupKnowledge godoc
+// @Summary      一键去重
+// @Description  将检测到的重复文档移入回收站并保留原始文档，可从回收站恢复
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true   "知识库ID"
+// @Param        request  body      DedupKnowledgeRequest  false  "去重范围"
+// @Success      200      {object}  map[string]interface{}  "去重结果"
+// @Failure      403      {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/duplicates/dedup [post]
+func (h *KnowledgeHandler) DedupKnowledge(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, effectiveTenantID, permission, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !permission.HasPermission(types.KBRoleOwner) {
+		c.Error(errors.NewForbiddenError("No permission to dedup knowledge"))
+		return
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
+
+	var req DedupKnowledgeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Error(ctx, "Failed to parse request parameters", err)
+			c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+
+	trashed, err := h.kgService.DedupKnowledge(ctx, kbID, req.KnowledgeIDs)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Dedup completed, knowledge base ID: %s, trashed: %d", secutils.SanitizeForLog(kbID), trashed)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"trashed": trashed,
+		},
+	})
+}
+
+// RestoreKnowledge godoc
+// @Summary      从回收站恢复知识
+// @Description  恢复回收站中的知识，已解析的知识重新参与检索，未解析完成的知识重新解析
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "恢复后的知识"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/restore [post]
+func (h *KnowledgeHandler) RestoreKnowledge(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	knowledge, err := h.kgService.RestoreKnowledge(effCtx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
+
+// ListDeadLetterKnowledge godoc
+// @Summary      获取解析失败的知识（死信）
+// @Description  分页获取当前租户中自动重试耗尽后仍解析失败（failed_permanent）的知识及失败原因，可通过重新解析接口重试，仅可访问所有租户的管理员可用
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        kb_id      query     string  false  "知识库ID筛选"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "死信知识列表"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      403        {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/dead-letter [get]
+func (h *KnowledgeHandler) ListDeadLetterKnowledge(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Failure reasons may name internal services and models, only admins see them
+	user, ok := ctx.Value(types.UserContextKey).(*types.User)
+	if !ok || user == nil || !h.cfg.CrossTenantAccessEnabled() || !user.CanAccessAllTenants {
+		logger.Warnf(ctx, "Dead-letter access denied for request without cross-tenant access")
+		c.Error(errors.NewForbiddenError("Insufficient permissions to view dead-lettered knowledge"))
+		return
+	}
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.Error(ctx, "Failed to parse pagination parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	kbID := secutils.SanitizeForLog(c.Query("kb_id"))
+
+	result, err := h.kgService.ListDeadLetterKnowledge(ctx, kbID, &pagination)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      result.Data,
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
+	})
+}
+
+// knowledgeProgressPollInterval is how often parse progress is checked while streaming
+const knowledgeProgressPollInterval = 500 * time.Millisecond
+
+// GetKnowledgeProgress godoc
+// @Summary      获取知识解析进度
+// @Description  通过 SSE 推送知识解析的阶段进度（downloaded、parsing、parsed、chunked、embedding N/M），解析完成或失败后结束
+// @Tags         知识管理
+// @Produce      text/event-stream
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  types.KnowledgeParseProgress  "解析进度"
+// @Failure      400  {object}  errors.AppError                "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/progress [get]
+func (h *KnowledgeHandler) GetKnowledgeProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	progress, err := h.kgService.GetKnowledgeParseProgress(effCtx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("progress", progress)
+	c.Writer.Flush()
+	if progress.Done() {
+		return
+	}
+	last := *progress
+
+	ticker := time.NewTicker(knowledgeProgressPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug(ctx, "Client closed knowledge progress stream")
+			return
+		case <-ticker.C:
+			progress, err := h.kgService.GetKnowledgeParseProgress(effCtx, id)
+			if err != nil {
+				logger.Errorf(ctx, "Failed to get knowledge progress: %v", err)
+				return
+			}
+			if *progress == last {
+				continue
+			}
+			last = *progress
+			c.SSEvent("progress", progress)
+			c.Writer.Flush()
+			if progress.Done() {
+				return
+			}
+		}
+	}
+}
+
+// CopyKnowledge godoc
+// @Summary      复制知识到其他知识库
+// @Description  将知识（文件、元数据、标签）复制到同一租户下的其他知识库。两个知识库使用相同的嵌入模型且 reuse_chunks 不为 false 时复用已有分块和向量，否则在目标知识库中重新解析
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "知识ID"
+// @Param        request  body      types.KnowledgeTransferRequest  true  "目标知识库"
+// @Success      200      {object}  map[string]interface{}          "复制后的知识"
+// @Failure      400      {object}  errors.AppError                 "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/copy [post]
+func (h *KnowledgeHandler) CopyKnowledge(c *gin.Context) {
+	h.transferKnowledge(c, false)
+}
+
+// MoveKnowledge godoc
+// @Summary      移动知识到其他知识库
+// @Description  将知识移动到同一租户下的其他知识库，移动后知识ID会变化
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "知识ID"
+// @Param        request  body      types.KnowledgeTransferRequest  true  "目标知识库"
+// @Success      200      {object}  map[string]interface{}          "移动后的知识"
+// @Failure      400      {object}  errors.AppError                 "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/move [post]
+func (h *KnowledgeHandler) MoveKnowledge(c *gin.Context) {
+	h.transferKnowledge(c, true)
+}
+
+// transferKnowledge handles move and copy requests
+func (h *KnowledgeHandler) transferKnowledge(c *gin.Context, move bool) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// Only knowledge owned by the caller's tenant can be moved or copied
+	if knowledge.TenantID != c.GetUint64(types.TenantIDContextKey.String()) {
+		c.Error(errors.NewForbiddenError("只能移动或复制本租户的知识"))
+		return
+	}
+
+	var req types.KnowledgeTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse knowledge transfer request", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	req.TargetKBID = secutils.SanitizeForLog(req.TargetKBID)
+
+	var result *types.Knowledge
+	if move {
+		result, err = h.kgService.MoveKnowledgeToKB(effCtx, id, &req)
+	} else {
+		result, err = h.kgService.CopyKnowledgeToKB(effCtx, id, &req)
+	}
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+			"target_kb_id": req.TargetKBID,
+			"move":         move,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// DeleteKnowledge godoc
+// @Summary      删除知识
+// @Description  根据ID删除知识条目。默认移入回收站，permanent=true 或知识已在回收站中时彻底删除
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string  true   "知识ID"
+// @Param        permanent  query     bool    false  "是否彻底删除"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id} [delete]
+func (h *KnowledgeHandler) DeleteKnowledge(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	logger.Info(ctx, "Start deleting knowledge")
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Knowledge ID is empty")
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	permanent := c.Query("permanent") == "true" || knowledge.TrashedAt != nil
+	if !permanent {
+		logger.Infof(ctx, "Moving knowledge to trash, ID: %s", secutils.SanitizeForLog(id))
+		if err := h.kgService.TrashKnowledge(effCtx, id); err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": i18n.T(ctx, i18n.MsgKnowledgeMovedToTrash),
+		})
+		return
+	}
+
+	logger.Infof(ctx, "Deleting knowledge, ID: %s", secutils.SanitizeForLog(id))
+	err = h.kgService.DeleteKnowledge(effCtx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Knowledge deleted successfully, ID: %s", secutils.SanitizeForLog(id))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": i18n.T(ctx, i18n.MsgKnowledgeDeleted),
+	})
+}
+
+// DownloadKnowledgeFile godoc
+// @Summary      下载知识文件
+// @Description  下载知识条目关联的原始文件
+// @Tags         知识管理
+// @Accept       json
+// @Produce      application/octet-stream
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {file}    file    "文件内容"
+// @Failure      400  {object}  errors.AppError  "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/download [get]
+func (h *KnowledgeHandler) DownloadKnowledgeFile(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	logger.Info(ctx, "Start downloading knowledge file")
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Knowledge ID is empty")
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	logger.Infof(ctx, "Retrieving knowledge file, ID: %s", secutils.SanitizeForLog(id))
+
+	file, filename, err := h.kgService.GetKnowledgeFile(effCtx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError("Failed to retrieve file").WithDetails(err.Error()))
+		return
+	}
+	defer file.Close()
 
 	logger.Infof(
 		ctx,
@@ -797,7 +1415,7 @@ func (h *KnowledgeHandler) GetKnowledgeBatch(c *gin.Context) {
 
 	// Optional kb_id: validate KB access and use effective tenant for shared KB
 	if kbID := secutils.SanitizeForLog(req.KBID); kbID != "" {
-		_, _, effID, _, err := h.validateKnowledgeBaseAccessWithKBID(c, kbID)
+		_, _, effID, kbRole, err := h.validateKnowledgeBaseAccessWithKBID(c, kbID)
 		if err != nil {
 			c.Error(err)
 			return
@@ -809,6 +1427,9 @@ func (h *KnowledgeHandler) GetKnowledgeBatch(c *gin.Context) {
 			effectiveTenantID, len(req.IDs))
 
 		knowledges, err = h.kgService.GetKnowledgeBatch(ctx, effectiveTenantID, req.IDs)
+		if err == nil {
+			knowledges, err = h.filterVisibleKnowledge(c, knowledges, kbRole)
+		}
 	} else {
 		// No kb_id: use GetKnowledgeBatchWithSharedAccess (or effectiveTenantID may already be set by agent_id for shared agent)
 		logger.Infof(ctx, "Batch retrieving knowledge without kb_id, effective tenant ID: %d, IDs count: %d",
@@ -832,6 +1453,24 @@ func (h *KnowledgeHandler) GetKnowledgeBatch(c *gin.Context) {
 	})
 }
 
+// filterVisibleKnowledge drops the documents hidden from the caller by their permissions
+func (h *KnowledgeHandler) filterVisibleKnowledge(c *gin.Context,
+	knowledges []*types.Knowledge, kbRole types.KBRole,
+) ([]*types.Knowledge, error) {
+	userID := c.GetString(types.UserIDContextKey.String())
+	visible := make([]*types.Knowledge, 0, len(knowledges))
+	for _, knowledge := range knowledges {
+		role, err := h.kbPermissionService.ResolveKnowledgeRole(c.Request.Context(), knowledge, kbRole, userID)
+		if err != nil {
+			return nil, err
+		}
+		if role.HasPermission(types.KBRoleViewer) {
+			visible = append(visible, knowledge)
+		}
+	}
+	return visible, nil
+}
+
 // UpdateKnowledge godoc
 // @Summary      更新知识
 // @Description  更新知识条目信息
@@ -855,7 +1494,7 @@ func (h *KnowledgeHandler) UpdateKnowledge(c *gin.Context) {
 		return
 	}
 
-	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -878,7 +1517,7 @@ func (h *KnowledgeHandler) UpdateKnowledge(c *gin.Context) {
 	logger.Infof(ctx, "Knowledge updated successfully, knowledge ID: %s", id)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Knowledge chunk updated successfully",
+		"message": i18n.T(ctx, i18n.MsgKnowledgeChunkUpdated),
 	})
 }
 
@@ -906,7 +1545,7 @@ func (h *KnowledgeHandler) UpdateManualKnowledge(c *gin.Context) {
 		return
 	}
 
-	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -964,7 +1603,7 @@ func (h *KnowledgeHandler) ReparseKnowledge(c *gin.Context) {
 	}
 
 	// Validate KB access with editor permission (reparse requires write access)
-	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -987,7 +1626,7 @@ func (h *KnowledgeHandler) ReparseKnowledge(c *gin.Context) {
 	logger.Infof(ctx, "Knowledge reparse task submitted successfully, knowledge ID: %s", id)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Knowledge reparse task submitted",
+		"message": i18n.T(ctx, i18n.MsgKnowledgeReparseSubmitted),
 		"data":    knowledge,
 	})
 }
@@ -1033,7 +1672,7 @@ func (h *KnowledgeHandler) UpdateKnowledgeTagBatch(c *gin.Context) {
 			c.Error(err)
 			return
 		}
-		if permission != types.OrgRoleAdmin && permission != types.OrgRoleEditor {
+		if !permission.HasPermission(types.KBRoleEditor) {
 			c.Error(errors.NewForbiddenError("No permission to update knowledge tags"))
 			return
 		}
@@ -1046,7 +1685,7 @@ func (h *KnowledgeHandler) UpdateKnowledgeTagBatch(c *gin.Context) {
 			break
 		}
 		if firstKnowledgeID != "" {
-			_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, firstKnowledgeID, types.OrgRoleEditor)
+			_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, firstKnowledgeID, types.KBRoleEditor)
 			if err != nil {
 				c.Error(err)
 				return
@@ -1064,6 +1703,343 @@ func (h *KnowledgeHandler) UpdateKnowledgeTagBatch(c *gin.Context) {
 	})
 }
 
+type setKnowledgeTagsRequest struct {
+	TagIDs []string `json:"tag_ids"`
+}
+
+// SetKnowledgeTags godoc
+// @Summary      设置知识标签
+// @Description  替换知识条目的全部标签，第一个标签作为主标签
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "知识ID"
+// @Param        request  body      object{tag_ids=[]string}    true  "标签ID列表"
+// @Success      200      {object}  map[string]interface{}      "更新后的知识"
+// @Failure      400      {object}  errors.AppError             "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/tags [put]
+func (h *KnowledgeHandler) SetKnowledgeTags(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req setKnowledgeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse knowledge tags request", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	knowledge, err := h.kgService.SetKnowledgeTags(effCtx, id, req.TagIDs)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
+
+type knowledgeRetagBatchRequest struct {
+	types.KnowledgeRetagRequest
+	KBID string `json:"kb_id"` // Optional: scope to this KB (validates editor access and uses effective tenant for shared KB)
+}
+
+// RetagKnowledgeBatch godoc
+// @Summary      批量移动/重新打标签
+// @Description  批量修改知识条目的标签。mode=replace 替换全部标签（移动），add 追加标签，remove 移除标签
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object{knowledge_ids=[]string,tag_ids=[]string,mode=string,kb_id=string}  true  "批量标签请求"
+// @Success      200      {object}  map[string]interface{}  "更新成功"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/tags/batch [post]
+func (h *KnowledgeHandler) RetagKnowledgeBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req knowledgeRetagBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse knowledge retag request", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	if len(req.KnowledgeIDs) == 0 {
+		c.Error(errors.NewBadRequestError("knowledge_ids cannot be empty"))
+		return
+	}
+
+	var effCtx context.Context
+	if kbID := secutils.SanitizeForLog(req.KBID); kbID != "" {
+		_, _, effID, permission, err := h.validateKnowledgeBaseAccessWithKBID(c, kbID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !permission.HasPermission(types.KBRoleEditor) {
+			c.Error(errors.NewForbiddenError("No permission to update knowledge tags"))
+			return
+		}
+		effCtx = context.WithValue(ctx, types.TenantIDContextKey, effID)
+	} else {
+		_, kCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, req.KnowledgeIDs[0], types.KBRoleEditor)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		effCtx = kCtx
+	}
+
+	if err := h.kgService.RetagKnowledgeBatch(effCtx, &req.KnowledgeRetagRequest); err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// BulkKnowledgeOperation godoc
+// @Summary      批量操作知识
+// @Description  对知识库中按 ID 列表（最多1000条）或筛选条件选中的知识异步执行删除、改标签或重新解析，返回任务进度
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "知识库ID"
+// @Param        request  body      types.KnowledgeBulkRequest  true  "批量操作请求"
+// @Success      200      {object}  map[string]interface{}      "任务进度"
+// @Failure      400      {object}  errors.AppError             "请求参数错误"
+// @Failure      403      {object}  errors.AppError             "无编辑权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/bulk [post]
+func (h *KnowledgeHandler) BulkKnowledgeOperation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kb, kbID, effID, permission, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !permission.HasPermission(types.KBRoleEditor) {
+		c.Error(errors.NewForbiddenError("No permission to modify knowledge"))
+		return
+	}
+
+	var req types.KnowledgeBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse knowledge bulk request", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Starting knowledge bulk operation, KB ID: %s, operation: %s",
+		kbID, secutils.SanitizeForLog(string(req.Operation)))
+
+	effCtx := context.WithValue(ctx, types.TenantIDContextKey, effID)
+	progress, err := h.kgService.StartKnowledgeBulkOperation(effCtx, kb, permission, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// GetKnowledgeBulkProgress godoc
+// @Summary      获取知识批量操作进度
+// @Description  获取知识批量操作任务的进度，包括成功、失败数量及失败条目
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "知识库ID"
+// @Param        task_id  path      string  true  "任务ID"
+// @Success      200      {object}  map[string]interface{}  "进度信息"
+// @Failure      404      {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/bulk/{task_id} [get]
+func (h *KnowledgeHandler) GetKnowledgeBulkProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, _, _, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	taskID := c.Param("task_id")
+	if taskID == "" {
+		c.Error(errors.NewBadRequestError("任务ID不能为空"))
+		return
+	}
+
+	progress, err := h.kgService.GetKnowledgeBulkProgress(ctx, taskID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+	// Tasks of other knowledge bases are not visible through this one
+	if progress.KBID != kbID {
+		c.Error(errors.NewNotFoundError("Knowledge bulk task not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+type knowledgeExpiryRequest struct {
+	// ExpiresAt is the expiration time, null clears the explicit expiration
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// SetKnowledgeExpiry godoc
+// @Summary      设置知识过期时间
+// @Description  设置或清除知识的过期时间，过期后按知识库生命周期策略归档或删除（未配置策略时归档）
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "知识ID"
+// @Param        request  body      object{expires_at=string}   true  "过期时间(RFC3339)，null表示清除"
+// @Success      200      {object}  map[string]interface{}      "更新后的知识"
+// @Failure      400      {object}  errors.AppError             "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/expiry [put]
+func (h *KnowledgeHandler) SetKnowledgeExpiry(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req knowledgeExpiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse knowledge expiry request", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	knowledge, err := h.kgService.SetKnowledgeExpiry(effCtx, id, req.ExpiresAt)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
+
+// ArchiveKnowledge godoc
+// @Summary      归档知识
+// @Description  归档知识，归档后不再参与检索，数据保留
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "归档后的知识"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/archive [post]
+func (h *KnowledgeHandler) ArchiveKnowledge(c *gin.Context) {
+	h.setKnowledgeArchived(c, true)
+}
+
+// UnarchiveKnowledge godoc
+// @Summary      取消归档知识
+// @Description  恢复已归档的知识，重新参与检索
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "恢复后的知识"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/unarchive [post]
+func (h *KnowledgeHandler) UnarchiveKnowledge(c *gin.Context) {
+	h.setKnowledgeArchived(c, false)
+}
+
+// setKnowledgeArchived handles archive and unarchive requests
+func (h *KnowledgeHandler) setKnowledgeArchived(c *gin.Context, archived bool) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var knowledge *types.Knowledge
+	if archived {
+		knowledge, err = h.kgService.ArchiveKnowledge(effCtx, id)
+	} else {
+		knowledge, err = h.kgService.UnarchiveKnowledge(effCtx, id)
+	}
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+			"archived":     archived,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
+
 // UpdateImageInfo godoc
 // @Summary      更新图像信息
 // @Description  更新知识分块的图像信息
@@ -1095,7 +2071,7 @@ func (h *KnowledgeHandler) UpdateImageInfo(c *gin.Context) {
 		return
 	}
 
-	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -1122,7 +2098,7 @@ func (h *KnowledgeHandler) UpdateImageInfo(c *gin.Context) {
 	logger.Infof(ctx, "Knowledge chunk updated successfully, knowledge ID: %s, chunk ID: %s", id, chunkID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Knowledge chunk image updated successfully",
+		"message": i18n.T(ctx, i18n.MsgKnowledgeChunkImageUpdated),
 	})
 }
 
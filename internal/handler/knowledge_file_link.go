@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateKnowledgeFileLink godoc
+// @Summary      生成文件签名链接
+// @Description  为知识文件生成到期失效的签名链接，持有链接者无需 API Key 即可查看或下载文件，可限制下载次数并随时撤销；需要知识库编辑权限
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                true   "知识ID"
+// @Param        request  body      types.CreateKnowledgeFileLinkRequest  false  "链接设置"
+// @Success      201      {object}  types.KnowledgeFileLinkWithURL        "签名链接"
+// @Failure      400      {object}  errors.AppError                       "请求参数错误"
+// @Failure      403      {object}  errors.AppError                       "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/links [post]
+func (h *KnowledgeHandler) CreateKnowledgeFileLink(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.CreateKnowledgeFileLinkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c,
+		secutils.SanitizeForLog(c.Param("id")), types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	link, err := h.fileLinkService.CreateLink(effCtx, knowledge.ID, &req)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    link,
+	})
+}
+
+// ListKnowledgeFileLinks godoc
+// @Summary      获取文件签名链接列表
+// @Description  列出知识文件的签名链接及其下载次数，不返回令牌；需要知识库编辑权限
+// @Tags         知识管理
+// @Produce      json
+// @Param        id   path      string                  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "签名链接列表"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/links [get]
+func (h *KnowledgeHandler) ListKnowledgeFileLinks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c,
+		secutils.SanitizeForLog(c.Param("id")), types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	links, err := h.fileLinkService.ListLinks(effCtx, knowledge.ID)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    links,
+	})
+}
+
+// RevokeKnowledgeFileLink godoc
+// @Summary      撤销文件签名链接
+// @Description  撤销知识文件的签名链接，撤销后链接立即失效；需要知识库编辑权限
+// @Tags         知识管理
+// @Produce      json
+// @Param        id       path      string                  true  "知识ID"
+// @Param        link_id  path      string                  true  "链接ID"
+// @Success      200      {object}  map[string]interface{}  "撤销成功"
+// @Failure      404      {object}  errors.AppError         "链接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/links/{link_id} [delete]
+func (h *KnowledgeHandler) RevokeKnowledgeFileLink(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c,
+		secutils.SanitizeForLog(c.Param("id")), types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.fileLinkService.RevokeLink(effCtx, knowledge.ID,
+		secutils.SanitizeForLog(c.Param("link_id"))); err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// GetSharedKnowledgeFile godoc
+// @Summary      通过签名链接获取文件
+// @Description  无需认证，通过签名链接查看或下载知识文件；链接无效、过期、已撤销或下载次数用尽时返回 401
+// @Tags         知识管理
+// @Produce      octet-stream
+// @Param        token  path      string           true  "链接令牌"
+// @Success      200    {file}    file             "文件内容"
+// @Failure      401    {object}  errors.AppError  "链接无效或已失效"
+// @Failure      404    {object}  errors.AppError  "知识不存在"
+// @Router       /shared/files/{token} [get]
+func (h *KnowledgeHandler) GetSharedKnowledgeFile(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	link, file, filename, err := h.fileLinkService.OpenLink(ctx, c.Param("token"))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	defer file.Close()
+
+	disposition := "attachment"
+	contentType := "application/octet-stream"
+	if link.Scope == types.KnowledgeFileLinkScopeView {
+		disposition = "inline"
+		if detected := mime.TypeByExtension(filepath.Ext(filename)); detected != "" {
+			contentType = detected
+		}
+		// Files are served from the API origin, a sandbox keeps uploaded HTML from running scripts there
+		c.Header("Content-Security-Policy", "sandbox")
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%s", disposition, filename))
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, no-store")
+
+	c.Stream(func(w io.Writer) bool {
+		if _, err := io.Copy(w, file); err != nil {
+			logger.Errorf(ctx, "Failed to send file: %v", err)
+		}
+		return false
+	})
+}
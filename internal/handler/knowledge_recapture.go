@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewKnowledgeRecapture godoc
+// @Summary      预览重新抓取
+// @Description  重新抓取 URL 知识的页面，返回与当前内容的逐行差异，不修改知识；确认后才替换内容。需要知识库编辑权限
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                  true   "知识ID"
+// @Param        request  body      types.PreviewKnowledgeRecaptureRequest  false  "抽取模式"
+// @Success      200      {object}  types.KnowledgeRecapturePreview         "重新抓取预览"
+// @Failure      400      {object}  errors.AppError                         "不是 URL 知识或抓取失败"
+// @Failure      403      {object}  errors.AppError                         "权限不足"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/recapture/preview [post]
+func (h *KnowledgeHandler) PreviewKnowledgeRecapture(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.PreviewKnowledgeRecaptureRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+	if !types.ValidWebExtractionMode(req.ExtractionMode) {
+		c.Error(apperrors.NewBadRequestError("extraction_mode must be full, readability or llm"))
+		return
+	}
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c,
+		secutils.SanitizeForLog(c.Param("id")), types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	preview, err := h.kgService.PreviewKnowledgeRecapture(effCtx, knowledge.ID, req.ExtractionMode)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    preview,
+	})
+}
+
+// ConfirmKnowledgeRecapture godoc
+// @Summary      确认重新抓取
+// @Description  用预览时抓取的内容替换 URL 知识的内容并重新索引，不会再次请求页面；预览 30 分钟内有效。需要知识库编辑权限
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                  true  "知识ID"
+// @Param        request  body      types.ConfirmKnowledgeRecaptureRequest  true  "预览ID"
+// @Success      200      {object}  map[string]interface{}                  "替换任务已提交"
+// @Failure      404      {object}  errors.AppError                         "预览不存在或已过期"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/recapture [post]
+func (h *KnowledgeHandler) ConfirmKnowledgeRecapture(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.ConfirmKnowledgeRecaptureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c,
+		secutils.SanitizeForLog(c.Param("id")), types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	knowledge, err = h.kgService.ConfirmKnowledgeRecapture(effCtx, knowledge.ID,
+		secutils.SanitizeForLog(req.PreviewID))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
@@ -3,15 +3,21 @@ package handler
 import (
 	"encoding/json"
 	stderrors "errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
 	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/Tencent/WeKnora/internal/utils"
@@ -22,11 +28,18 @@ import (
 
 // KnowledgeBaseHandler defines the HTTP handler for knowledge base operations
 type KnowledgeBaseHandler struct {
-	service           interfaces.KnowledgeBaseService
-	knowledgeService  interfaces.KnowledgeService
-	kbShareService    interfaces.KBShareService
-	agentShareService interfaces.AgentShareService
-	asynqClient       *asynq.Client
+	service               interfaces.KnowledgeBaseService
+	knowledgeService      interfaces.KnowledgeService
+	kbShareService        interfaces.KBShareService
+	agentShareService     interfaces.AgentShareService
+	analyticsService      interfaces.SearchAnalyticsService
+	evalService           interfaces.RetrievalEvalService
+	experimentService     interfaces.ExperimentService
+	feedbackService       interfaces.AnswerFeedbackService
+	promptTemplateService interfaces.PromptTemplateService
+	kbPermissionService   interfaces.KBPermissionService
+	asynqClient           interfaces.TaskEnqueuer
+	config                *config.Config
 }
 
 // NewKnowledgeBaseHandler creates a new knowledge base handler instance
@@ -35,14 +48,28 @@ func NewKnowledgeBaseHandler(
 	knowledgeService interfaces.KnowledgeService,
 	kbShareService interfaces.KBShareService,
 	agentShareService interfaces.AgentShareService,
-	asynqClient *asynq.Client,
+	analyticsService interfaces.SearchAnalyticsService,
+	evalService interfaces.RetrievalEvalService,
+	experimentService interfaces.ExperimentService,
+	feedbackService interfaces.AnswerFeedbackService,
+	promptTemplateService interfaces.PromptTemplateService,
+	kbPermissionService interfaces.KBPermissionService,
+	asynqClient interfaces.TaskEnqueuer,
+	config *config.Config,
 ) *KnowledgeBaseHandler {
 	return &KnowledgeBaseHandler{
-		service:           service,
-		knowledgeService:  knowledgeService,
-		kbShareService:    kbShareService,
-		agentShareService: agentShareService,
-		asynqClient:       asynqClient,
+		service:               service,
+		knowledgeService:      knowledgeService,
+		kbShareService:        kbShareService,
+		agentShareService:     agentShareService,
+		analyticsService:      analyticsService,
+		evalService:           evalService,
+		experimentService:     experimentService,
+		feedbackService:       feedbackService,
+		promptTemplateService: promptTemplateService,
+		kbPermissionService:   kbPermissionService,
+		asynqClient:           asynqClient,
+		config:                config,
 	}
 }
 
@@ -78,6 +105,29 @@ func (h *KnowledgeBaseHandler) HybridSearch(c *gin.Context) {
 		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid search filter", err)
+		c.Error(apperrors.NewBadRequestError("Invalid search filter").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Highlight.Validate(); err != nil {
+		c.Error(apperrors.NewBadRequestError("Invalid highlight options").WithDetails(err.Error()))
+		return
+	}
+	// A cursor continues results cut at the payload cap, it is only valid for the same search
+	cursorText := req.Cursor
+	req.Cursor = ""
+	searchJSON, _ := json.Marshal(req)
+	scope := types.PayloadScope(id, string(searchJSON))
+	start := 0
+	if cursorText != "" {
+		cursor, err := types.DecodePayloadCursor(cursorText, scope)
+		if err != nil {
+			c.Error(apperrors.NewBadRequestError(err.Error()))
+			return
+		}
+		start = cursor.Offset
+	}
 
 	logger.Infof(ctx, "Executing hybrid search, knowledge base ID: %s, query: %s, effectiveTenantID: %d",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.QueryText), effectiveTenantID)
@@ -91,14 +141,198 @@ func (h *KnowledgeBaseHandler) HybridSearch(c *gin.Context) {
 		return
 	}
 
-	logger.Infof(ctx, "Hybrid search completed, knowledge base ID: %s, result count: %d",
-		secutils.SanitizeForLog(id), len(results))
+	start = min(start, len(results))
+	results = results[start:]
+	count, truncated := types.FitPayload(len(results), maxPayloadBytes(h.config), func(i int) int {
+		return len(results[i].Content) + len(results[i].ImageInfo)
+	})
+	results = results[:count]
+	searchutil.HighlightResults(req.QueryText, results, req.Highlight)
+
+	logger.Infof(ctx, "Hybrid search completed, knowledge base ID: %s, result count: %d, truncated: %v",
+		secutils.SanitizeForLog(id), len(results), truncated)
+	response := gin.H{
+		"success": true,
+		"data":    results,
+	}
+	if truncated {
+		response["truncated"] = true
+		response["next_cursor"] = types.PayloadCursor{Scope: scope, Offset: start + count}.Encode()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// maxImageSearchSize is the largest query image accepted by image search
+const maxImageSearchSize = 10 << 20
+
+// maxImageSearchTopK caps the number of results of an image search
+const maxImageSearchTopK = 100
+
+// ImageSearch godoc
+// @Summary      以图搜图
+// @Description  上传一张图片，返回知识库中视觉上相似的图片知识；需在知识库中启用图像向量
+// @Tags         知识库
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id         path      string   true   "知识库ID"
+// @Param        image      formData  file     true   "查询图片"
+// @Param        top_k      formData  int      false  "返回数量，默认10，最大100"
+// @Param        threshold  formData  number   false  "最低相似度"
+// @Success      200        {object}  map[string]interface{}  "相似知识及相似度"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/image-search [post]
+func (h *KnowledgeBaseHandler) ImageSearch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kb, id, _, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !kb.ImageEmbeddingConfig.IsEnabled() {
+		c.Error(apperrors.NewBadRequestError("Image embedding is not enabled for this knowledge base"))
+		return
+	}
+
+	var req types.ImageSearchParams
+	if err := c.ShouldBind(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	if req.TopK < 0 || req.TopK > maxImageSearchTopK {
+		c.Error(apperrors.NewBadRequestError(fmt.Sprintf("top_k must be between 1 and %d", maxImageSearchTopK)))
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		logger.Error(ctx, "Image upload failed", err)
+		c.Error(apperrors.NewBadRequestError("Image upload failed").WithDetails(err.Error()))
+		return
+	}
+	if file.Size > maxImageSearchSize {
+		c.Error(apperrors.NewBadRequestError("图片文件大小不能超过10MB"))
+		return
+	}
+	reader, err := file.Open()
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("Image upload failed").WithDetails(err.Error()))
+		return
+	}
+	defer reader.Close()
+	image, err := io.ReadAll(reader)
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("Image upload failed").WithDetails(err.Error()))
+		return
+	}
+	if !strings.HasPrefix(http.DetectContentType(image), "image/") {
+		c.Error(apperrors.NewBadRequestError("只允许上传图片文件"))
+		return
+	}
+
+	logger.Infof(ctx, "Executing image search, knowledge base ID: %s, image: %s, size: %d",
+		secutils.SanitizeForLog(id), secutils.SanitizeForLog(file.Filename), len(image))
+
+	results, err := h.service.ImageSearch(ctx, id, image, req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    results,
 	})
 }
 
+// maxVLMPreviewImageSize bounds the image uploaded to preview image understanding
+const maxVLMPreviewImageSize = 10 << 20
+
+// PreviewImageUnderstanding godoc
+// @Summary      预览图片理解效果
+// @Description  使用知识库的视觉模型和提示词处理一张图片并返回图片描述和 OCR 文本，不创建知识，可传入 prompt 试用新的提示词
+// @Tags         知识库
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id      path      string  true   "知识库ID"
+// @Param        image   formData  file    true   "预览图片，最大10MB"
+// @Param        prompt  formData  string  false  "临时使用的提示词，为空时使用知识库配置"
+// @Success      200     {object}  map[string]interface{}  "图片描述和OCR文本"
+// @Failure      400     {object}  errors.AppError         "请求参数错误或未配置视觉模型"
+// @Failure      403     {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/vlm/preview [post]
+func (h *KnowledgeBaseHandler) PreviewImageUnderstanding(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kb, id, _, permission, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// The preview calls the vision model, which is billed like document processing
+	if !permission.HasPermission(types.KBRoleEditor) {
+		c.Error(apperrors.NewForbiddenError("No permission to preview image understanding"))
+		return
+	}
+
+	prompt := strings.TrimSpace(c.PostForm("prompt"))
+	if err := (&types.VLMConfig{Prompt: prompt}).Validate(); err != nil {
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		logger.Error(ctx, "Image upload failed", err)
+		c.Error(apperrors.NewBadRequestError("Image upload failed").WithDetails(err.Error()))
+		return
+	}
+	if file.Size > maxVLMPreviewImageSize {
+		c.Error(apperrors.NewBadRequestError("图片文件大小不能超过10MB"))
+		return
+	}
+	reader, err := file.Open()
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("Image upload failed").WithDetails(err.Error()))
+		return
+	}
+	defer reader.Close()
+	image, err := io.ReadAll(reader)
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("Image upload failed").WithDetails(err.Error()))
+		return
+	}
+	if !strings.HasPrefix(http.DetectContentType(image), "image/") {
+		c.Error(apperrors.NewBadRequestError("只允许上传图片文件"))
+		return
+	}
+
+	logger.Infof(ctx, "Previewing image understanding, knowledge base ID: %s, image: %s, size: %d",
+		secutils.SanitizeForLog(id), secutils.SanitizeForLog(file.Filename), len(image))
+
+	result, err := h.knowledgeService.PreviewImageUnderstanding(ctx, kb, image, file.Filename, prompt)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
 // CreateKnowledgeBase godoc
 // @Summary      创建知识库
 // @Description  创建新的知识库
@@ -128,6 +362,54 @@ func (h *KnowledgeBaseHandler) CreateKnowledgeBase(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	if err := req.OCRConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid OCR configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid OCR configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.WebExtractionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid web extraction configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid web extraction configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.URLFetchConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid URL fetch configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid URL fetch configuration").WithDetails(err.Error()))
+		return
+	}
+	// A config copied from another knowledge base has its credentials masked, they are not copied
+	req.URLFetchConfig.DropMasked()
+	if err := req.VLMConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid VLM configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid VLM configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.ImageEmbeddingConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid image embedding configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid image embedding configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.RerankConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid rerank configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid rerank configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.SemanticCacheConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid semantic cache configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid semantic cache configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.KeywordSearchConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid keyword search configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid keyword search configuration").WithDetails(err.Error()))
+		return
+	}
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if err := req.KeywordSearchConfig.ValidateEngines(tenant.GetEffectiveEngines()); err != nil {
+		logger.Error(ctx, "Keyword analyzer not supported by the retrieval engines", err)
+		c.Error(apperrors.NewValidationError("Keyword analyzer not supported").WithDetails(err.Error()))
+		return
+	}
 
 	logger.Infof(ctx, "Creating knowledge base, name: %s", secutils.SanitizeForLog(req.Name))
 	// Create knowledge base using the service
@@ -147,10 +429,10 @@ func (h *KnowledgeBaseHandler) CreateKnowledgeBase(c *gin.Context) {
 }
 
 // validateAndGetKnowledgeBase validates request parameters and retrieves the knowledge base
-// Returns the knowledge base, knowledge base ID, effective tenant ID for embedding, permission level, and any errors encountered
+// Returns the knowledge base, knowledge base ID, effective tenant ID for embedding, role of the caller, and any errors encountered
 // For owned KBs, effectiveTenantID is the caller's tenant ID
 // For shared KBs, effectiveTenantID is the source tenant ID (owner's tenant)
-func (h *KnowledgeBaseHandler) validateAndGetKnowledgeBase(c *gin.Context) (*types.KnowledgeBase, string, uint64, types.OrgMemberRole, error) {
+func (h *KnowledgeBaseHandler) validateAndGetKnowledgeBase(c *gin.Context) (*types.KnowledgeBase, string, uint64, types.KBRole, error) {
 	ctx := c.Request.Context()
 
 	// Get tenant ID from context
@@ -160,7 +442,7 @@ func (h *KnowledgeBaseHandler) validateAndGetKnowledgeBase(c *gin.Context) (*typ
 		return nil, "", 0, "", apperrors.NewUnauthorizedError("Unauthorized")
 	}
 
-	// Get user ID from context (needed for shared KB permission check)
+	// Get user ID from context (needed for member and shared KB permission checks)
 	userID, userExists := c.Get(types.UserIDContextKey.String())
 
 	// Get knowledge base ID from URL parameter
@@ -177,27 +459,22 @@ func (h *KnowledgeBaseHandler) validateAndGetKnowledgeBase(c *gin.Context) (*typ
 		return nil, id, 0, "", apperrors.NewInternalServerError(err.Error())
 	}
 
-	// Check 1: Verify tenant ownership (owner has full access)
-	if kb.TenantID == tenantID.(uint64) {
-		return kb, id, tenantID.(uint64), types.OrgRoleAdmin, nil
+	// Check 1: role granted on the knowledge base, tenant ownership or organization share
+	callerID, _ := userID.(string)
+	role, effectiveTenantID, err := h.kbPermissionService.ResolveKBRole(ctx, kb, tenantID.(uint64), callerID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return nil, id, 0, "", apperrors.NewInternalServerError(err.Error())
 	}
-
-	// Check 2: If not owner, check organization shared access
-	if userExists && h.kbShareService != nil {
-		// Check if user has shared access through organization
-		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, id, userID.(string))
-		if permErr == nil && isShared {
-			// User has shared access, get the source tenant ID for embedding queries
-			sourceTenantID, srcErr := h.kbShareService.GetKBSourceTenant(ctx, id)
-			if srcErr == nil {
-				logger.Infof(ctx, "User %s accessing shared KB %s with permission %s, source tenant: %d",
-					userID.(string), id, permission, sourceTenantID)
-				return kb, id, sourceTenantID, permission, nil
-			}
+	if role != "" {
+		if kb.TenantID != tenantID.(uint64) {
+			logger.Infof(ctx, "User %s accessing shared KB %s with role %s, source tenant: %d",
+				callerID, id, role, effectiveTenantID)
 		}
+		return kb, id, effectiveTenantID, role, nil
 	}
 
-	// Check 3: Shared agent — allow if request has agent_id (and agent can access this KB) OR user has any shared agent that can access this KB (e.g. opened from "通过智能体可见" list without agent_id)
+	// Check 2: Shared agent — allow if request has agent_id (and agent can access this KB) OR user has any shared agent that can access this KB (e.g. opened from "通过智能体可见" list without agent_id)
 	if userExists && h.agentShareService != nil {
 		currentTenantID := tenantID.(uint64)
 		agentID := c.Query("agent_id")
@@ -212,12 +489,12 @@ func (h *KnowledgeBaseHandler) validateAndGetKnowledgeBase(c *gin.Context) (*typ
 						// no-op, fall through
 					} else if mode == "all" {
 						logger.Infof(ctx, "User %s accessing KB %s via shared agent %s (mode=all)", userID.(string), id, agentID)
-						return kb, id, kb.TenantID, types.OrgRoleViewer, nil
+						return kb, id, kb.TenantID, types.KBRoleViewer, nil
 					} else if mode == "selected" {
 						for _, allowedID := range agent.Config.KnowledgeBases {
 							if allowedID == id {
 								logger.Infof(ctx, "User %s accessing KB %s via shared agent %s (mode=selected)", userID.(string), id, agentID)
-								return kb, id, kb.TenantID, types.OrgRoleViewer, nil
+								return kb, id, kb.TenantID, types.KBRoleViewer, nil
 							}
 						}
 					}
@@ -228,7 +505,7 @@ func (h *KnowledgeBaseHandler) validateAndGetKnowledgeBase(c *gin.Context) (*typ
 			can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID.(string), currentTenantID, kb)
 			if err == nil && can {
 				logger.Infof(ctx, "User %s accessing KB %s via some shared agent (no agent_id in query)", userID.(string), id)
-				return kb, id, kb.TenantID, types.OrgRoleViewer, nil
+				return kb, id, kb.TenantID, types.KBRoleViewer, nil
 			}
 		}
 	}
@@ -270,15 +547,17 @@ func (h *KnowledgeBaseHandler) GetKnowledgeBase(c *gin.Context) {
 	}
 	tenantID := c.GetUint64(types.TenantIDContextKey.String())
 	data := interface{}(kb)
-	if kb.TenantID != tenantID && permission != "" {
-		// Include my_permission in data so frontend can show role (e.g. "只读") instead of "--" for agent-visible KBs
-		var dataMap map[string]interface{}
-		b, _ := json.Marshal(kb)
-		_ = json.Unmarshal(b, &dataMap)
-		if dataMap != nil {
-			dataMap["my_permission"] = permission
-			data = dataMap
+	// Include my_role in data so frontend can enable actions by role; my_permission keeps showing
+	// the role (e.g. "只读") instead of "--" for shared and agent-visible KBs
+	var dataMap map[string]interface{}
+	b, _ := json.Marshal(kb)
+	_ = json.Unmarshal(b, &dataMap)
+	if dataMap != nil {
+		dataMap["my_role"] = permission
+		if kb.TenantID != tenantID {
+			dataMap["my_permission"] = permission.OrgRole()
 		}
+		data = dataMap
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
 }
@@ -360,6 +639,16 @@ func (h *KnowledgeBaseHandler) ListKnowledgeBases(c *gin.Context) {
 		c.Error(apperrors.NewInternalServerError(err.Error()))
 		return
 	}
+	// API keys restricted to some knowledge bases only see those
+	if apiKey := types.APIKeyFromContext(ctx); apiKey != nil && len(apiKey.KnowledgeBaseIDs) > 0 {
+		allowed := make([]*types.KnowledgeBase, 0, len(kbs))
+		for _, kb := range kbs {
+			if apiKey.AllowsKnowledgeBase(kb.ID) {
+				allowed = append(allowed, kb)
+			}
+		}
+		kbs = allowed
+	}
 
 	// Get share counts for all knowledge bases
 	if len(kbs) > 0 && h.kbShareService != nil {
@@ -418,7 +707,7 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 	}
 
 	// Only admin/editor can update knowledge base
-	if permission != types.OrgRoleAdmin && permission != types.OrgRoleEditor {
+	if !permission.HasPermission(types.KBRoleOwner) {
 		c.Error(apperrors.NewForbiddenError("No permission to update knowledge base"))
 		return
 	}
@@ -430,6 +719,52 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Config.OCRConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid OCR configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid OCR configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.WebExtractionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid web extraction configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid web extraction configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.URLFetchConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid URL fetch configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid URL fetch configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.VLMConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid VLM configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid VLM configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.ImageEmbeddingConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid image embedding configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid image embedding configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.RerankConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid rerank configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid rerank configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.SemanticCacheConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid semantic cache configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid semantic cache configuration").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.KeywordSearchConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid keyword search configuration", err)
+		c.Error(apperrors.NewBadRequestError("Invalid keyword search configuration").WithDetails(err.Error()))
+		return
+	}
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if err := req.Config.KeywordSearchConfig.ValidateEngines(tenant.GetEffectiveEngines()); err != nil {
+		logger.Error(ctx, "Keyword analyzer not supported by the retrieval engines", err)
+		c.Error(apperrors.NewValidationError("Keyword analyzer not supported").WithDetails(err.Error()))
+		return
+	}
 
 	logger.Infof(ctx, "Updating knowledge base, ID: %s, name: %s",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.Name))
@@ -438,6 +773,10 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 	kb, err := h.service.UpdateKnowledgeBase(ctx, id, req.Name, req.Description, req.Config)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
 		c.Error(apperrors.NewInternalServerError(err.Error()))
 		return
 	}
@@ -475,7 +814,7 @@ func (h *KnowledgeBaseHandler) DeleteKnowledgeBase(c *gin.Context) {
 
 	// Only owner (admin with matching tenant) can delete knowledge base
 	tenantID, _ := c.Get(types.TenantIDContextKey.String())
-	if kb.TenantID != tenantID.(uint64) || permission != types.OrgRoleAdmin {
+	if kb.TenantID != tenantID.(uint64) || !permission.HasPermission(types.KBRoleOwner) {
 		c.Error(apperrors.NewForbiddenError("Only knowledge base owner can delete"))
 		return
 	}
@@ -494,7 +833,7 @@ func (h *KnowledgeBaseHandler) DeleteKnowledgeBase(c *gin.Context) {
 		secutils.SanitizeForLog(id))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Knowledge base deleted successfully",
+		"message": i18n.T(ctx, i18n.MsgKnowledgeBaseDeleted),
 	})
 }
 
@@ -676,6 +1015,229 @@ func (h *KnowledgeBaseHandler) GetKBCloneProgress(c *gin.Context) {
 	})
 }
 
+// ReindexKnowledgeBase godoc
+// @Summary      更换向量模型并重建索引
+// @Description  使用新的向量模型为知识库全量重建索引，完成后原子切换到新模型并清理旧向量
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "知识库ID"
+// @Param        request  body      types.KBReindexRequest  true  "重建参数"
+// @Success      200      {object}  map[string]interface{}  "任务信息"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      409      {object}  errors.AppError         "已有重建任务在运行"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/reindex [post]
+func (h *KnowledgeBaseHandler) ReindexKnowledgeBase(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kb, id, _, permission, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Only owner can replace the embedding model of a knowledge base
+	tenantID, _ := c.Get(types.TenantIDContextKey.String())
+	if kb.TenantID != tenantID.(uint64) || !permission.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owner can re-index"))
+		return
+	}
+
+	var req types.KBReindexRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Starting knowledge base re-index, ID: %s, target model: %s",
+		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.EmbeddingModelID))
+
+	progress, err := h.knowledgeService.StartKBEmbeddingReindex(ctx, kb, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// GetKBReindexProgress godoc
+// @Summary      获取知识库重建索引进度
+// @Description  获取知识库向量模型迁移任务的进度
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        task_id  path      string  true  "任务ID"
+// @Success      200      {object}  map[string]interface{}  "进度信息"
+// @Failure      404      {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/reindex/progress/{task_id} [get]
+func (h *KnowledgeBaseHandler) GetKBReindexProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	taskID := c.Param("task_id")
+	if taskID == "" {
+		logger.Error(ctx, "Task ID is empty")
+		c.Error(apperrors.NewBadRequestError("Task ID cannot be empty"))
+		return
+	}
+
+	progress, err := h.knowledgeService.GetKBReindexProgress(ctx, taskID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// ExportKnowledgeBase godoc
+// @Summary      导出知识库
+// @Description  将知识库的原始文件、解析文本、分块、标签及元数据导出为 zip 包，可选包含向量
+// @Tags         知识库
+// @Produce      application/zip
+// @Param        id                  path   string  true   "知识库ID"
+// @Param        include_embeddings  query  bool    false  "是否包含向量"
+// @Success      200  {file}    file             "知识库导出包"
+// @Failure      400  {object}  errors.AppError  "请求参数错误"
+// @Failure      403  {object}  errors.AppError  "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/export [get]
+func (h *KnowledgeBaseHandler) ExportKnowledgeBase(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kb, id, _, permission, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Only owner can export the full content of a knowledge base
+	tenantID, _ := c.Get(types.TenantIDContextKey.String())
+	if kb.TenantID != tenantID.(uint64) || !permission.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base owner can export"))
+		return
+	}
+
+	includeEmbeddings, _ := strconv.ParseBool(c.Query("include_embeddings"))
+	logger.Infof(ctx, "Exporting knowledge base, ID: %s, include embeddings: %v",
+		secutils.SanitizeForLog(id), includeEmbeddings)
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", "attachment; filename=kb_export_"+kb.ID+".zip")
+	if err := h.knowledgeService.ExportKnowledgeBase(ctx, kb.ID, includeEmbeddings, c.Writer); err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		// Once the archive has started streaming the status can no longer change
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "")
+			c.Header("Content-Disposition", "")
+			c.Error(err)
+		}
+		return
+	}
+}
+
+// ImportKnowledgeBase godoc
+// @Summary      导入知识库
+// @Description  从导出包创建新知识库，内容在后台任务中导入；向量模型一致时复用包内向量
+// @Tags         知识库
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file                formData  file    true   "知识库导出包"
+// @Param        embedding_model_id  formData  string  true   "向量模型ID"
+// @Param        summary_model_id    formData  string  false  "摘要模型ID"
+// @Param        name                formData  string  false  "知识库名称，默认使用导出包中的名称"
+// @Success      200  {object}  map[string]interface{}  "新知识库及任务信息"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/import [post]
+func (h *KnowledgeBaseHandler) ImportKnowledgeBase(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.KBImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "File upload failed", err)
+		c.Error(apperrors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+	if file.Size > types.KBBundleMaxSize {
+		c.Error(apperrors.NewBadRequestError("知识库导出包大小不能超过1GB"))
+		return
+	}
+
+	logger.Infof(ctx, "Importing knowledge base from bundle: %s", secutils.SanitizeForLog(file.Filename))
+
+	kb, progress, err := h.knowledgeService.StartKBImport(ctx, file, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"knowledge_base": kb,
+			"task":           progress,
+		},
+	})
+}
+
+// GetKBImportProgress godoc
+// @Summary      获取知识库导入进度
+// @Description  获取知识库导入任务的进度
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        task_id  path      string  true  "任务ID"
+// @Success      200      {object}  map[string]interface{}  "进度信息"
+// @Failure      404      {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/import/progress/{task_id} [get]
+func (h *KnowledgeBaseHandler) GetKBImportProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	taskID := c.Param("task_id")
+	if taskID == "" {
+		logger.Error(ctx, "Task ID is empty")
+		c.Error(apperrors.NewBadRequestError("Task ID cannot be empty"))
+		return
+	}
+
+	progress, err := h.knowledgeService.GetKBImportProgress(ctx, taskID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
 // validateExtractConfig validates the graph configuration parameters
 func validateExtractConfig(config *types.ExtractConfig) error {
 	if config == nil {
@@ -0,0 +1,475 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// MCPKnowledgeBasesHeader selects the knowledge bases an MCP client works with, as comma separated IDs
+	MCPKnowledgeBasesHeader = "X-Knowledge-Base-IDs"
+
+	defaultMCPTopK              = 5
+	maxMCPTopK                  = 50
+	defaultMCPMaxKnowledgeBases = 10
+	defaultMCPMaxDocumentChars  = 100000
+)
+
+// mcpCaller is the authenticated caller of an MCP request and the knowledge bases it selected
+type mcpCaller struct {
+	tenantID uint64
+	userID   string
+	// kbIDs are the knowledge bases selected for the connection, empty selects all the caller can read
+	kbIDs []string
+}
+
+type mcpCallerContextKey struct{}
+
+// mcpCallerFromContext returns the caller of the MCP request of a context
+func mcpCallerFromContext(ctx context.Context) (*mcpCaller, bool) {
+	caller, ok := ctx.Value(mcpCallerContextKey{}).(*mcpCaller)
+	return caller, ok && caller != nil && caller.tenantID != 0
+}
+
+// MCPServerHandler serves WeKnora retrieval and document fetch as MCP tools over Streamable HTTP, for agent
+// frameworks and IDE assistants
+type MCPServerHandler struct {
+	config              *config.Config
+	kbService           interfaces.KnowledgeBaseService
+	knowledgeService    interfaces.KnowledgeService
+	chunkService        interfaces.ChunkService
+	kbPermissionService interfaces.KBPermissionService
+	httpServer          *server.StreamableHTTPServer
+}
+
+// NewMCPServerHandler creates the MCP server handler
+func NewMCPServerHandler(
+	config *config.Config,
+	kbService interfaces.KnowledgeBaseService,
+	knowledgeService interfaces.KnowledgeService,
+	chunkService interfaces.ChunkService,
+	kbPermissionService interfaces.KBPermissionService,
+) *MCPServerHandler {
+	h := &MCPServerHandler{
+		config:              config,
+		kbService:           kbService,
+		knowledgeService:    knowledgeService,
+		chunkService:        chunkService,
+		kbPermissionService: kbPermissionService,
+	}
+
+	mcpServer := server.NewMCPServer("WeKnora", Version,
+		server.WithToolCapabilities(false),
+		server.WithRecovery(),
+		server.WithInstructions("Search the knowledge bases of WeKnora with search_knowledge and read whole "+
+			"documents with get_document. list_knowledge_bases lists the knowledge bases you can search."),
+	)
+	mcpServer.AddTool(mcp.NewTool("list_knowledge_bases",
+		mcp.WithDescription("List the knowledge bases available to search"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), h.listKnowledgeBases)
+	mcpServer.AddTool(mcp.NewTool("search_knowledge",
+		mcp.WithDescription("Hybrid (vector and keyword) search over knowledge bases, returns the most relevant "+
+			"passages with their document IDs"),
+		mcp.WithString("query", mcp.Required(), mcp.Description("The search query")),
+		mcp.WithArray("knowledge_base_ids", mcp.WithStringItems(),
+			mcp.Description("Knowledge bases to search, all available ones when omitted")),
+		mcp.WithNumber("top_k", mcp.Min(1), mcp.Max(maxMCPTopK),
+			mcp.Description("Number of passages to return")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), h.searchKnowledge)
+	mcpServer.AddTool(mcp.NewTool("get_document",
+		mcp.WithDescription("Fetch the parsed text of a document by the knowledge ID returned by search_knowledge"),
+		mcp.WithString("knowledge_id", mcp.Required(), mcp.Description("The knowledge ID of the document")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), h.getDocument)
+
+	// Stateless sessions keep nothing in memory between requests, so any replica serves any request
+	h.httpServer = server.NewStreamableHTTPServer(mcpServer, server.WithStateLess(true))
+	return h
+}
+
+// ServeMCP godoc
+// @Summary      MCP 服务
+// @Description  以 Streamable HTTP 协议提供 MCP 工具：list_knowledge_bases、search_knowledge、get_document。
+// @Description  可通过 X-Knowledge-Base-IDs 请求头或 kb_ids 查询参数限定可用的知识库
+// @Tags         MCP服务
+// @Accept       json
+// @Produce      json
+// @Param        kb_ids  query     string  false  "可用的知识库ID，逗号分隔"
+// @Success      200     {object}  map[string]interface{}  "JSON-RPC 响应，检索过程中升级为 SSE 推送进度"
+// @Failure      404     {object}  errors.AppError         "MCP 服务未启用"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /mcp [post]
+func (h *MCPServerHandler) ServeMCP(c *gin.Context) {
+	if settings := h.config.Current().MCPServer; settings == nil || !settings.Enabled {
+		c.Error(errors.NewNotFoundError("MCP server is not enabled"))
+		return
+	}
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if tenantID == 0 {
+		c.Error(errors.NewUnauthorizedError("Unauthorized"))
+		return
+	}
+
+	selection := c.GetHeader(MCPKnowledgeBasesHeader)
+	if selection == "" {
+		selection = c.Query("kb_ids")
+	}
+	caller := &mcpCaller{
+		tenantID: tenantID,
+		userID:   c.GetString(types.UserIDContextKey.String()),
+		kbIDs:    splitCommaList(selection),
+	}
+	ctx := context.WithValue(c.Request.Context(), mcpCallerContextKey{}, caller)
+	if caller.userID != "" {
+		ctx = context.WithValue(ctx, types.UserIDContextKey, caller.userID)
+	}
+	h.httpServer.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
+
+// splitCommaList splits a comma separated list, dropping empty items
+func splitCommaList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// mcpSettings returns the MCP server config with defaults
+func (h *MCPServerHandler) mcpSettings() (topK int, maxKBs int, maxChars int) {
+	topK, maxKBs, maxChars = defaultMCPTopK, defaultMCPMaxKnowledgeBases, defaultMCPMaxDocumentChars
+	if settings := h.config.Current().MCPServer; settings != nil {
+		if settings.DefaultTopK > 0 {
+			topK = settings.DefaultTopK
+		}
+		if settings.MaxKnowledgeBases > 0 {
+			maxKBs = settings.MaxKnowledgeBases
+		}
+		if settings.MaxDocumentChars > 0 {
+			maxChars = settings.MaxDocumentChars
+		}
+	}
+	return topK, maxKBs, maxChars
+}
+
+// readableKnowledgeBase returns a knowledge base the caller may read, and a context reading its data with the
+// tenant it belongs to
+func (h *MCPServerHandler) readableKnowledgeBase(
+	ctx context.Context, caller *mcpCaller, kbID string,
+) (*types.KnowledgeBase, context.Context, error) {
+	if len(caller.kbIDs) > 0 && !slices.Contains(caller.kbIDs, kbID) {
+		return nil, ctx, fmt.Errorf("knowledge base %s is not selected for this connection", kbID)
+	}
+	kb, err := h.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil {
+		return nil, ctx, fmt.Errorf("knowledge base %s not found", kbID)
+	}
+	role, effectiveTenantID, err := h.kbPermissionService.ResolveKBRole(ctx, kb, caller.tenantID, caller.userID)
+	if err != nil {
+		return nil, ctx, err
+	}
+	if !role.HasPermission(types.KBRoleViewer) {
+		return nil, ctx, fmt.Errorf("no access to knowledge base %s", kbID)
+	}
+	return kb, context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID), nil
+}
+
+// readableTenantKnowledgeBases returns the knowledge bases of the caller's tenant it may read, a role granted on
+// a knowledge base or the knowledge base restrictions of an API key can take access away
+func (h *MCPServerHandler) readableTenantKnowledgeBases(
+	ctx context.Context, caller *mcpCaller,
+) ([]*types.KnowledgeBase, error) {
+	all, err := h.kbService.ListKnowledgeBases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kbs := make([]*types.KnowledgeBase, 0, len(all))
+	for _, kb := range all {
+		role, _, err := h.kbPermissionService.ResolveKBRole(ctx, kb, caller.tenantID, caller.userID)
+		if err != nil {
+			return nil, err
+		}
+		if role.HasPermission(types.KBRoleViewer) {
+			kbs = append(kbs, kb)
+		}
+	}
+	return kbs, nil
+}
+
+// mcpKnowledgeBase is a knowledge base listed to MCP clients
+type mcpKnowledgeBase struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+}
+
+// listKnowledgeBases lists the knowledge bases of the tenant the caller can read, within the selection
+func (h *MCPServerHandler) listKnowledgeBases(
+	ctx context.Context, _ mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	caller, ok := mcpCallerFromContext(ctx)
+	if !ok {
+		return mcp.NewToolResultError("unauthorized"), nil
+	}
+
+	var kbs []*types.KnowledgeBase
+	if len(caller.kbIDs) > 0 {
+		// Selected knowledge bases may be shared from other tenants
+		for _, kbID := range caller.kbIDs {
+			kb, _, err := h.readableKnowledgeBase(ctx, caller, kbID)
+			if err != nil {
+				logger.Warnf(ctx, "MCP skips selected knowledge base %s: %v", kbID, err)
+				continue
+			}
+			kbs = append(kbs, kb)
+		}
+	} else {
+		var err error
+		kbs, err = h.readableTenantKnowledgeBases(ctx, caller)
+		if err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			return mcp.NewToolResultError("failed to list knowledge bases"), nil
+		}
+	}
+
+	listed := make([]mcpKnowledgeBase, 0, len(kbs))
+	for _, kb := range kbs {
+		listed = append(listed, mcpKnowledgeBase{ID: kb.ID, Name: kb.Name, Description: kb.Description, Type: kb.Type})
+	}
+	return mcpJSONResult(map[string]any{"knowledge_bases": listed})
+}
+
+// mcpSearchHit is a passage returned by search_knowledge
+type mcpSearchHit struct {
+	KnowledgeBaseID string  `json:"knowledge_base_id"`
+	KnowledgeID     string  `json:"knowledge_id"`
+	Title           string  `json:"title"`
+	ChunkIndex      int     `json:"chunk_index"`
+	Score           float64 `json:"score"`
+	Content         string  `json:"content"`
+}
+
+// searchKnowledge runs a hybrid search on each knowledge base in turn. When the client asked for progress, each
+// knowledge base searched is reported as it completes, so clients show results coming in.
+func (h *MCPServerHandler) searchKnowledge(
+	ctx context.Context, request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	caller, ok := mcpCallerFromContext(ctx)
+	if !ok {
+		return mcp.NewToolResultError("unauthorized"), nil
+	}
+	query, err := request.RequireString("query")
+	if err != nil || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("query is required"), nil
+	}
+	defaultTopK, maxKBs, _ := h.mcpSettings()
+	topK := min(max(request.GetInt("top_k", defaultTopK), 1), maxMCPTopK)
+
+	kbIDs := request.GetStringSlice("knowledge_base_ids", nil)
+	if len(kbIDs) == 0 {
+		kbIDs = caller.kbIDs
+	}
+	if len(kbIDs) == 0 {
+		readable, err := h.readableTenantKnowledgeBases(ctx, caller)
+		if err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			return mcp.NewToolResultError("failed to list knowledge bases"), nil
+		}
+		for _, kb := range readable {
+			kbIDs = append(kbIDs, kb.ID)
+		}
+	}
+	if len(kbIDs) > maxKBs {
+		return mcp.NewToolResultErrorf("at most %d knowledge bases can be searched at once, "+
+			"pass knowledge_base_ids", maxKBs), nil
+	}
+
+	vectorThreshold, keywordThreshold := 0.0, 0.0
+	if conversation := h.config.Current().Conversation; conversation != nil {
+		vectorThreshold, keywordThreshold = conversation.VectorThreshold, conversation.KeywordThreshold
+	}
+	progressToken := mcpProgressToken(request)
+
+	var hits []mcpSearchHit
+	var skipped []string
+	for i, kbID := range kbIDs {
+		kb, kbCtx, err := h.readableKnowledgeBase(ctx, caller, kbID)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		results, err := h.kbService.HybridSearch(kbCtx, kb.ID, types.SearchParams{
+			QueryText:        query,
+			MatchCount:       topK,
+			VectorThreshold:  vectorThreshold,
+			KeywordThreshold: keywordThreshold,
+		})
+		if err != nil {
+			logger.Warnf(ctx, "MCP search failed on knowledge base %s: %v", kb.ID, err)
+			skipped = append(skipped, fmt.Sprintf("search failed on knowledge base %s", kb.ID))
+			continue
+		}
+		for _, result := range results {
+			title := result.KnowledgeTitle
+			if title == "" {
+				title = result.KnowledgeFilename
+			}
+			hits = append(hits, mcpSearchHit{
+				KnowledgeBaseID: kb.ID,
+				KnowledgeID:     result.KnowledgeID,
+				Title:           title,
+				ChunkIndex:      result.ChunkIndex,
+				Score:           result.Score,
+				Content:         result.Content,
+			})
+		}
+		h.notifyProgress(ctx, progressToken, i+1, len(kbIDs),
+			fmt.Sprintf("Searched %s: %d passages", kb.Name, len(results)))
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > topK {
+		hits = hits[:topK]
+	}
+	response := map[string]any{"results": hits}
+	if len(skipped) > 0 {
+		response["skipped"] = skipped
+	}
+	return mcpJSONResult(response)
+}
+
+// getDocument returns the parsed text of a document the caller may read
+func (h *MCPServerHandler) getDocument(
+	ctx context.Context, request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	caller, ok := mcpCallerFromContext(ctx)
+	if !ok {
+		return mcp.NewToolResultError("unauthorized"), nil
+	}
+	knowledgeID, err := request.RequireString("knowledge_id")
+	if err != nil || knowledgeID == "" {
+		return mcp.NewToolResultError("knowledge_id is required"), nil
+	}
+
+	knowledge, err := h.knowledgeService.GetKnowledgeByIDOnly(ctx, knowledgeID)
+	// Trashed and archived documents are out of retrieval, they are not served either
+	if err != nil || knowledge == nil || knowledge.TrashedAt != nil || knowledge.ArchivedAt != nil {
+		return mcp.NewToolResultError("document not found"), nil
+	}
+	kb, kbCtx, err := h.readableKnowledgeBase(ctx, caller, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return mcp.NewToolResultError("document not found"), nil
+	}
+	// The document permission may hide a document of a readable knowledge base
+	kbRole, _, err := h.kbPermissionService.ResolveKBRole(ctx, kb, caller.tenantID, caller.userID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return mcp.NewToolResultError("failed to check document access"), nil
+	}
+	role, err := h.kbPermissionService.ResolveKnowledgeRole(ctx, knowledge, kbRole, caller.userID)
+	if err != nil || !role.HasPermission(types.KBRoleViewer) {
+		return mcp.NewToolResultError("document not found"), nil
+	}
+	if knowledge.ParseStatus != types.ParseStatusCompleted {
+		return mcp.NewToolResultErrorf("document is not parsed yet, parse status: %s", knowledge.ParseStatus), nil
+	}
+
+	chunks, err := h.chunkService.ListChunksByKnowledgeID(kbCtx, knowledge.ID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return mcp.NewToolResultError("failed to read document"), nil
+	}
+	_, _, maxChars := h.mcpSettings()
+	var content strings.Builder
+	truncated := false
+	for _, chunk := range chunks {
+		if content.Len()+len(chunk.Content) > maxChars {
+			truncated = true
+			break
+		}
+		if content.Len() > 0 {
+			content.WriteString("\n\n")
+		}
+		content.WriteString(chunk.Content)
+	}
+
+	title := knowledge.Title
+	if title == "" {
+		title = knowledge.FileName
+	}
+	return mcpJSONResult(map[string]any{
+		"knowledge_id":      knowledge.ID,
+		"knowledge_base_id": knowledge.KnowledgeBaseID,
+		"title":             title,
+		"source":            knowledge.Source,
+		"content":           content.String(),
+		"truncated":         truncated,
+	})
+}
+
+// mcpProgressToken returns the progress token of a tool call, nil when the client asked for no progress
+func mcpProgressToken(request mcp.CallToolRequest) mcp.ProgressToken {
+	if request.Params.Meta == nil {
+		return nil
+	}
+	return request.Params.Meta.ProgressToken
+}
+
+// notifyProgress reports the progress of a tool call to the client, which turns the response into an event stream
+func (h *MCPServerHandler) notifyProgress(ctx context.Context, token mcp.ProgressToken, done, total int,
+	message string,
+) {
+	if token == nil {
+		return
+	}
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return
+	}
+	err := mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+		"progressToken": token,
+		"progress":      done,
+		"total":         total,
+		"message":       message,
+	})
+	if err != nil {
+		logger.Warnf(ctx, "Failed to send MCP progress: %v", err)
+	}
+}
+
+// mcpJSONResult returns a tool result carrying a value as JSON text
+func mcpJSONResult(value any) (*mcp.CallToolResult, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// RejectMCPStream answers GET and DELETE requests of MCP clients: the server is stateless, it opens no stream
+// outside requests and has no sessions to end
+func (h *MCPServerHandler) RejectMCPStream(c *gin.Context) {
+	c.Header("Allow", http.MethodPost)
+	c.Status(http.StatusMethodNotAllowed)
+}
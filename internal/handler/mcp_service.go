@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -299,7 +300,7 @@ func (h *MCPServiceHandler) DeleteMCPService(c *gin.Context) {
 	logger.Infof(ctx, "MCP service deleted successfully: %s", secutils.SanitizeForLog(serviceID))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "MCP service deleted successfully",
+		"message": i18n.T(ctx, i18n.MsgMCPServiceDeleted),
 	})
 }
 
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
@@ -16,17 +17,23 @@ import (
 // MessageHandler handles HTTP requests related to messages within chat sessions
 // It provides endpoints for loading and managing message history
 type MessageHandler struct {
-	MessageService interfaces.MessageService // Service that implements message business logic
+	MessageService  interfaces.MessageService        // Service that implements message business logic
+	FeedbackService interfaces.AnswerFeedbackService // Service that records feedback on answers
 }
 
 // NewMessageHandler creates a new message handler instance with the required service
 // Parameters:
 //   - messageService: Service that implements message business logic
+//   - feedbackService: Service that records feedback on answers
 //
 // Returns a pointer to a new MessageHandler
-func NewMessageHandler(messageService interfaces.MessageService) *MessageHandler {
+func NewMessageHandler(
+	messageService interfaces.MessageService,
+	feedbackService interfaces.AnswerFeedbackService,
+) *MessageHandler {
 	return &MessageHandler{
-		MessageService: messageService,
+		MessageService:  messageService,
+		FeedbackService: feedbackService,
 	}
 }
 
@@ -153,6 +160,6 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context) {
 	logger.Infof(ctx, "Message deleted successfully, session ID: %s, message ID: %s", sessionID, messageID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Message deleted successfully",
+		"message": i18n.T(ctx, i18n.MsgMessageDeleted),
 	})
 }
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	stderrors "errors"
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/application/service"
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/provider"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -113,7 +115,7 @@ func (h *ModelHandler) CreateModel(c *gin.Context) {
 
 	if err := h.service.CreateModel(ctx, model); err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError(err.Error()))
+		c.Error(modelSaveError(err))
 		return
 	}
 
@@ -294,6 +296,14 @@ func (h *ModelHandler) UpdateModel(c *gin.Context) {
 	// Check if any Parameters field is set (can't use struct comparison due to map field)
 	if req.Parameters.BaseURL != "" || req.Parameters.APIKey != "" || req.Parameters.Provider != "" {
 		model.Parameters = req.Parameters
+	} else {
+		// The fallback chain and capabilities can be changed on their own
+		if req.Parameters.FallbackModelIDs != nil {
+			model.Parameters.FallbackModelIDs = req.Parameters.FallbackModelIDs
+		}
+		if req.Parameters.Capabilities != nil {
+			model.Parameters.Capabilities = req.Parameters.Capabilities
+		}
 	}
 	model.Source = req.Source
 	model.Type = req.Type
@@ -301,7 +311,7 @@ func (h *ModelHandler) UpdateModel(c *gin.Context) {
 	logger.Infof(ctx, "Updating model, ID: %s, Name: %s", id, model.Name)
 	if err := h.service.UpdateModel(ctx, model); err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError(err.Error()))
+		c.Error(modelSaveError(err))
 		return
 	}
 
@@ -355,7 +365,7 @@ func (h *ModelHandler) DeleteModel(c *gin.Context) {
 	logger.Infof(ctx, "Model deleted successfully, ID: %s", id)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Model deleted",
+		"message": i18n.T(ctx, i18n.MsgModelDeleted),
 	})
 }
 
@@ -366,6 +376,8 @@ type ModelProviderDTO struct {
 	Description string            `json:"description"` // 描述
 	DefaultURLs map[string]string `json:"defaultUrls"` // 按模型类型区分的默认 URL
 	ModelTypes  []string          `json:"modelTypes"`  // 支持的模型类型
+	// ExtraFields 需要额外填写到 extra_config 中的配置项，如 Azure 部署名称、Bedrock 区域
+	ExtraFields []provider.ExtraFieldConfig `json:"extraFields,omitempty"`
 }
 
 // modelTypeToFrontend 将后端 ModelType 转换为前端兼容的字符串
@@ -451,6 +463,7 @@ func (h *ModelHandler) ListModelProviders(c *gin.Context) {
 			Description: p.Description,
 			DefaultURLs: defaultURLs,
 			ModelTypes:  modelTypes,
+			ExtraFields: p.ExtraFields,
 		})
 	}
 
@@ -460,3 +473,117 @@ func (h *ModelHandler) ListModelProviders(c *gin.Context) {
 		"data":    result,
 	})
 }
+
+// modelSaveError maps the errors of creating or updating a model to API errors
+func modelSaveError(err error) error {
+	switch {
+	case stderrors.Is(err, service.ErrInvalidModelFallback), stderrors.Is(err, service.ErrInvalidModelCapabilities):
+		return errors.NewBadRequestError(err.Error())
+	case stderrors.Is(err, service.ErrEmbeddingDimensionMismatch):
+		return errors.NewConflictError(err.Error())
+	}
+	return errors.NewInternalServerError(err.Error())
+}
+
+// GetModelRegistry godoc
+// @Summary      获取模型注册表
+// @Description  获取当前租户所有模型的能力信息（最大上下文、是否支持视觉和工具调用、嵌入维度）、最近一次健康探测结果和熔断状态
+// @Tags         模型管理
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "模型注册表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/registry [get]
+func (h *ModelHandler) GetModelRegistry(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	entries, err := h.service.GetModelRegistry(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// ProbeModel godoc
+// @Summary      探测模型健康状态
+// @Description  立即向模型发送一次最小请求，记录可用性、延迟以及嵌入模型实际返回的向量维度
+// @Tags         模型管理
+// @Produce      json
+// @Param        id   path      string  true  "模型ID"
+// @Success      200  {object}  map[string]interface{}  "探测结果"
+// @Failure      404  {object}  errors.AppError         "模型不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/{id}/probe [post]
+func (h *ModelHandler) ProbeModel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	result, err := h.service.ProbeModel(ctx, id)
+	if err != nil {
+		if stderrors.Is(err, service.ErrModelNotFound) {
+			c.Error(errors.NewNotFoundError("Model not found"))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetEmbeddingStats godoc
+// @Summary      获取嵌入模型吞吐统计
+// @Description  获取当前租户各嵌入模型自服务启动以来的请求数、失败与限流次数、平均延迟和批量向量化吞吐
+// @Tags         模型管理
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "嵌入模型吞吐统计列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/embedding/stats [get]
+func (h *ModelHandler) GetEmbeddingStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	stats, err := h.service.GetEmbeddingStats(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+// GetModelHealth godoc
+// @Summary      获取模型健康状态
+// @Description  获取当前租户各模型端点的熔断状态，用于观察故障转移情况
+// @Tags         模型管理
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "模型健康状态列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/health [get]
+func (h *ModelHandler) GetModelHealth(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	statuses, err := h.service.GetModelHealth(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    statuses,
+	})
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/service"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ModelProviderHandler handles the connection tests, model listings and model downloads of the
+// tenant's self-hosted Ollama and vLLM servers
+type ModelProviderHandler struct {
+	service interfaces.ModelProviderService
+}
+
+// NewModelProviderHandler creates a new model provider handler
+func NewModelProviderHandler(service interfaces.ModelProviderService) *ModelProviderHandler {
+	return &ModelProviderHandler{service: service}
+}
+
+// TestProviderConnectionRequest is the server to test, the tenant's configured server when empty
+type TestProviderConnectionRequest struct {
+	BaseURL   string `json:"base_url"`
+	APIKey    string `json:"api_key"`
+	ModelName string `json:"model_name"`
+}
+
+// TestProviderConnection godoc
+// @Summary      测试本地模型服务连接
+// @Description  测试 Ollama 或 vLLM 服务是否可用，返回版本、延迟和已部署的模型；未指定 base_url 时使用租户配置的服务
+// @Tags         模型管理
+// @Accept       json
+// @Produce      json
+// @Param        provider  path      string                         true  "服务类型：ollama 或 vllm"
+// @Param        request   body      TestProviderConnectionRequest  false "服务地址和待检查的模型"
+// @Success      200       {object}  map[string]interface{}         "连接状态"
+// @Failure      400       {object}  errors.AppError                "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/providers/{provider}/test [post]
+func (h *ModelProviderHandler) TestProviderConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req TestProviderConnectionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Error(ctx, "Failed to parse request parameters", err)
+			c.Error(apperrors.NewBadRequestError(err.Error()))
+			return
+		}
+	}
+	endpoint := &types.ProviderEndpoint{BaseURL: req.BaseURL, APIKey: req.APIKey}
+	if endpoint.BaseURL != "" {
+		if err := endpoint.Validate(); err != nil {
+			c.Error(apperrors.NewBadRequestError(err.Error()))
+			return
+		}
+	}
+
+	status, err := h.service.TestConnection(ctx, c.Param("provider"), endpoint, req.ModelName)
+	if err != nil {
+		c.Error(modelProviderError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// ListProviderModels godoc
+// @Summary      获取本地模型服务的模型列表
+// @Description  列出租户配置的 Ollama 或 vLLM 服务上已部署的模型
+// @Tags         模型管理
+// @Produce      json
+// @Param        provider  path      string  true  "服务类型：ollama 或 vllm"
+// @Success      200       {object}  map[string]interface{}  "模型列表"
+// @Failure      400       {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/providers/{provider}/models [get]
+func (h *ModelProviderHandler) ListProviderModels(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	models, err := h.service.ListModels(ctx, c.Param("provider"), nil)
+	if err != nil {
+		c.Error(modelProviderError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    models,
+	})
+}
+
+// PullProviderModelRequest is the model to download, on the tenant's configured server when
+// base_url is empty
+type PullProviderModelRequest struct {
+	ModelName string `json:"model_name" binding:"required"`
+	BaseURL   string `json:"base_url"`
+}
+
+// PullProviderModel godoc
+// @Summary      拉取 Ollama 模型
+// @Description  在租户的 Ollama 服务上异步下载模型，返回下载任务；同一模型正在下载时返回已有任务
+// @Tags         模型管理
+// @Accept       json
+// @Produce      json
+// @Param        provider  path      string                    true  "服务类型：仅支持 ollama"
+// @Param        request   body      PullProviderModelRequest  true  "模型名称"
+// @Success      200       {object}  map[string]interface{}    "下载任务"
+// @Failure      400       {object}  errors.AppError           "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/providers/{provider}/pulls [post]
+func (h *ModelProviderHandler) PullProviderModel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if c.Param("provider") != types.LocalProviderOllama {
+		c.Error(apperrors.NewBadRequestError("Only ollama supports pulling models"))
+		return
+	}
+	var req PullProviderModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	endpoint := &types.ProviderEndpoint{BaseURL: req.BaseURL}
+	if endpoint.BaseURL != "" {
+		if err := endpoint.Validate(); err != nil {
+			c.Error(apperrors.NewBadRequestError(err.Error()))
+			return
+		}
+	}
+
+	logger.Infof(ctx, "Pulling Ollama model: %s", secutils.SanitizeForLog(req.ModelName))
+	task, err := h.service.PullModel(ctx, endpoint, req.ModelName)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+	})
+}
+
+// ListProviderPulls godoc
+// @Summary      获取 Ollama 模型下载任务列表
+// @Description  列出当前租户最近的模型下载任务，按开始时间倒序
+// @Tags         模型管理
+// @Produce      json
+// @Param        provider  path      string  true  "服务类型：仅支持 ollama"
+// @Success      200       {object}  map[string]interface{}  "下载任务列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/providers/{provider}/pulls [get]
+func (h *ModelProviderHandler) ListProviderPulls(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tasks, err := h.service.ListPullTasks(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tasks,
+	})
+}
+
+// GetProviderPull godoc
+// @Summary      获取 Ollama 模型下载进度
+// @Description  获取模型下载任务的状态和进度
+// @Tags         模型管理
+// @Produce      json
+// @Param        provider  path      string  true  "服务类型：仅支持 ollama"
+// @Param        task_id   path      string  true  "任务ID"
+// @Success      200       {object}  map[string]interface{}  "下载任务"
+// @Failure      404       {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /models/providers/{provider}/pulls/{task_id} [get]
+func (h *ModelProviderHandler) GetProviderPull(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	task, err := h.service.GetPullTask(ctx, secutils.SanitizeForLog(c.Param("task_id")))
+	if err != nil {
+		c.Error(modelProviderError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+	})
+}
+
+// modelProviderError maps the errors of the model provider service to API errors
+func modelProviderError(ctx context.Context, err error) error {
+	switch {
+	case stderrors.Is(err, service.ErrUnsupportedModelProvider):
+		return apperrors.NewBadRequestError(err.Error())
+	case stderrors.Is(err, service.ErrModelPullTaskNotFound):
+		return apperrors.NewNotFoundError(err.Error())
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
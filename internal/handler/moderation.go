@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ModerationHandler handles the audit trail and review queue of content moderation
+type ModerationHandler struct {
+	service interfaces.ModerationService
+}
+
+// NewModerationHandler creates a new moderation handler
+func NewModerationHandler(service interfaces.ModerationService) *ModerationHandler {
+	return &ModerationHandler{service: service}
+}
+
+// ListModerationEvents godoc
+// @Summary      获取内容审核记录
+// @Description  列出当前租户被内容审核规则拦截、打码或标记的问题和回答，按时间倒序；review_status=pending 即待复核队列
+// @Tags         内容审核
+// @Produce      json
+// @Param        stage          query     string  false  "阶段：query 或 answer"
+// @Param        action         query     string  false  "动作：block、mask 或 flag"
+// @Param        review_status  query     string  false  "复核状态：none、pending、approved 或 rejected"
+// @Param        page           query     int     false  "页码"
+// @Param        page_size      query     int     false  "每页数量"
+// @Success      200            {object}  map[string]interface{}  "审核记录"
+// @Failure      400            {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /moderation/events [get]
+func (h *ModerationHandler) ListModerationEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var filter types.ModerationEventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		logger.Error(ctx, "Failed to parse query parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid query parameters").WithDetails(err.Error()))
+		return
+	}
+	if filter.Stage != "" && !filter.Stage.IsValid() {
+		c.Error(apperrors.NewBadRequestError("Stage must be query or answer"))
+		return
+	}
+	if filter.Action != "" && !filter.Action.IsValid() {
+		c.Error(apperrors.NewBadRequestError("Action must be block, mask or flag"))
+		return
+	}
+
+	result, err := h.service.ListEvents(ctx, &filter)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ReviewModerationEvent godoc
+// @Summary      复核内容审核记录
+// @Description  将被标记（flag）的记录复核为合规（approved）、违规（rejected）或重新待复核（pending）
+// @Tags         内容审核
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                              true  "审核记录ID"
+// @Param        request  body      types.ReviewModerationEventRequest  true  "复核结果"
+// @Success      200      {object}  map[string]interface{}              "复核后的记录"
+// @Failure      400      {object}  errors.AppError                     "请求参数错误"
+// @Failure      404      {object}  errors.AppError                     "记录不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /moderation/events/{id}/review [put]
+func (h *ModerationHandler) ReviewModerationEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.ReviewModerationEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	switch req.Status {
+	case types.ModerationReviewPending, types.ModerationReviewApproved, types.ModerationReviewRejected:
+	default:
+		c.Error(apperrors.NewBadRequestError("Status must be pending, approved or rejected"))
+		return
+	}
+
+	moderationEvent, err := h.service.ReviewEvent(ctx, secutils.SanitizeForLog(c.Param("id")), &req)
+	if err != nil {
+		c.Error(moderationError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    moderationEvent,
+	})
+}
+
+// moderationError maps moderation event failures to API errors
+func moderationError(ctx context.Context, err error) error {
+	switch {
+	case stderrors.Is(err, repository.ErrModerationEventNotFound):
+		return apperrors.NewNotFoundError(err.Error())
+	case stderrors.Is(err, service.ErrModerationEventNotFlagged):
+		return apperrors.NewBadRequestError(err.Error())
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/application/service"
 	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -26,6 +27,8 @@ type OrganizationHandler struct {
 	kbService          interfaces.KnowledgeBaseService
 	knowledgeRepo      interfaces.KnowledgeRepository
 	chunkRepo          interfaces.ChunkRepository
+	// Lists the knowledge bases shared with users directly or through invite links
+	kbPermissionService interfaces.KBPermissionService
 }
 
 // NewOrganizationHandler creates a new organization handler
@@ -38,6 +41,7 @@ func NewOrganizationHandler(
 	kbService interfaces.KnowledgeBaseService,
 	knowledgeRepo interfaces.KnowledgeRepository,
 	chunkRepo interfaces.ChunkRepository,
+	kbPermissionService interfaces.KBPermissionService,
 ) *OrganizationHandler {
 	return &OrganizationHandler{
 		orgService:         orgService,
@@ -48,6 +52,8 @@ func NewOrganizationHandler(
 		kbService:          kbService,
 		knowledgeRepo:      knowledgeRepo,
 		chunkRepo:          chunkRepo,
+
+		kbPermissionService: kbPermissionService,
 	}
 }
 
@@ -337,7 +343,7 @@ func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Organization deleted successfully",
+		"message": i18n.T(ctx, i18n.MsgOrganizationDeleted),
 	})
 }
 
@@ -422,7 +428,7 @@ func (h *OrganizationHandler) UpdateMemberRole(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Member role updated successfully",
+		"message": i18n.T(ctx, i18n.MsgMemberRoleUpdated),
 	})
 }
 
@@ -451,7 +457,7 @@ func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Member removed successfully",
+		"message": i18n.T(ctx, i18n.MsgMemberRemoved),
 	})
 }
 
@@ -838,7 +844,7 @@ func (h *OrganizationHandler) LeaveOrganization(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Left organization successfully",
+		"message": i18n.T(ctx, i18n.MsgOrganizationLeft),
 	})
 }
 
@@ -966,7 +972,7 @@ func (h *OrganizationHandler) ReviewJoinRequest(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Review completed",
+		"message": i18n.T(ctx, i18n.MsgJoinRequestReviewed),
 	})
 }
 
@@ -1105,7 +1111,7 @@ func (h *OrganizationHandler) UpdateSharePermission(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Share permission updated successfully",
+		"message": i18n.T(ctx, i18n.MsgSharePermissionSaved),
 	})
 }
 
@@ -1133,7 +1139,7 @@ func (h *OrganizationHandler) RemoveShare(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Share removed successfully",
+		"message": i18n.T(ctx, i18n.MsgShareRemoved),
 	})
 }
 
@@ -1215,7 +1221,7 @@ func (h *OrganizationHandler) ListOrgShares(c *gin.Context) {
 
 // ListSharedKnowledgeBases lists all knowledge bases shared to the current user
 // @Summary      获取共享给我的知识库列表
-// @Description  获取通过组织共享给当前用户的所有知识库
+// @Description  获取通过组织共享、单独授予角色或邀请链接共享给当前用户的所有知识库，未通过组织共享的知识库 organization_id 为空
 // @Tags         知识库共享
 // @Produce      json
 // @Success      200  {object}  map[string]interface{}
@@ -1234,6 +1240,27 @@ func (h *OrganizationHandler) ListSharedKnowledgeBases(c *gin.Context) {
 		return
 	}
 
+	// Knowledge bases shared with the user directly or through invite links; a granted role
+	// takes precedence over organization shares of the same knowledge base
+	grantedKBs, err := h.kbPermissionService.ListGrantedKnowledgeBases(ctx, userID, tenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list granted knowledge bases: %v", err)
+		c.Error(apperrors.NewInternalServerError("Failed to list shared knowledge bases"))
+		return
+	}
+	if len(grantedKBs) > 0 {
+		granted := make(map[string]bool, len(grantedKBs))
+		for _, info := range grantedKBs {
+			granted[info.KnowledgeBase.ID] = true
+		}
+		for _, info := range sharedKBs {
+			if !granted[info.KnowledgeBase.ID] {
+				grantedKBs = append(grantedKBs, info)
+			}
+		}
+		sharedKBs = grantedKBs
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    sharedKBs,
@@ -1306,7 +1333,7 @@ func (h *OrganizationHandler) RemoveAgentShare(c *gin.Context) {
 		c.Error(apperrors.NewForbiddenError("Permission denied"))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Share removed successfully"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": i18n.T(ctx, i18n.MsgShareRemoved)})
 }
 
 // ListOrgAgentShares lists all agents shared to an organization
@@ -1804,6 +1831,6 @@ func (h *OrganizationHandler) InviteMember(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Member added successfully",
+		"message": i18n.T(ctx, i18n.MsgMemberAdded),
 	})
 }
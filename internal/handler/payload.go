@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// maxPayloadBytes returns the most text one response of the endpoints returning large text may carry
+func maxPayloadBytes(cfg *config.Config) int {
+	if cfg == nil {
+		return types.DefaultMaxPayloadBytes
+	}
+	if response := cfg.Current().Response; response != nil && response.MaxPayloadBytes > 0 {
+		return response.MaxPayloadBytes
+	}
+	return types.DefaultMaxPayloadBytes
+}
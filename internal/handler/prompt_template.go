@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// validatePromptTemplateKnowledgeBase resolves the knowledge base of a prompt template request
+func (h *KnowledgeBaseHandler) validatePromptTemplateKnowledgeBase(c *gin.Context) (string, error) {
+	return h.validateOwnedKnowledgeBase(c, "Only the owner of the knowledge base can manage prompt templates")
+}
+
+// bindPromptTemplate parses a prompt template request
+func bindPromptTemplate(c *gin.Context) (*types.PromptTemplateRequest, error) {
+	var req types.PromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(c.Request.Context(), "Failed to parse request parameters", err)
+		return nil, apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error())
+	}
+	return &req, nil
+}
+
+// ListPromptTemplates godoc
+// @Summary      获取提示词模板版本列表
+// @Description  列出知识库的全部提示词模板版本，按版本号倒序，其中至多一个为已发布（published）
+// @Tags         提示词模板
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "模板版本列表"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/prompt-templates [get]
+func (h *KnowledgeBaseHandler) ListPromptTemplates(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validatePromptTemplateKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	templates, err := h.promptTemplateService.ListTemplates(ctx, id)
+	if err != nil {
+		c.Error(promptTemplateError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+	})
+}
+
+// CreatePromptTemplate godoc
+// @Summary      创建提示词模板
+// @Description  以草稿创建知识库提示词模板的新版本，可设置系统提示词、回答语言、语气、引用样式和拒答策略，发布后才会用于回答
+// @Tags         提示词模板
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                       true  "知识库ID"
+// @Param        request  body      types.PromptTemplateRequest  true  "模板内容"
+// @Success      201      {object}  map[string]interface{}       "创建的模板"
+// @Failure      400      {object}  errors.AppError              "请求参数错误"
+// @Failure      403      {object}  errors.AppError              "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/prompt-templates [post]
+func (h *KnowledgeBaseHandler) CreatePromptTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validatePromptTemplateKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	req, err := bindPromptTemplate(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	template, err := h.promptTemplateService.CreateTemplate(ctx, id, req)
+	if err != nil {
+		c.Error(promptTemplateError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// GetPromptTemplate godoc
+// @Summary      获取提示词模板
+// @Description  获取知识库提示词模板的一个版本
+// @Tags         提示词模板
+// @Produce      json
+// @Param        id           path      string  true  "知识库ID"
+// @Param        template_id  path      string  true  "模板ID"
+// @Success      200          {object}  map[string]interface{}  "模板"
+// @Failure      404          {object}  errors.AppError         "模板不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/prompt-templates/{template_id} [get]
+func (h *KnowledgeBaseHandler) GetPromptTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validatePromptTemplateKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	template, err := h.promptTemplateService.GetTemplate(ctx, id, secutils.SanitizeForLog(c.Param("template_id")))
+	if err != nil {
+		c.Error(promptTemplateError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// UpdatePromptTemplate godoc
+// @Summary      更新提示词模板
+// @Description  修改草稿版本的模板内容，已发布或已归档的版本不可修改，需创建新版本
+// @Tags         提示词模板
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                       true  "知识库ID"
+// @Param        template_id  path      string                       true  "模板ID"
+// @Param        request      body      types.PromptTemplateRequest  true  "模板内容"
+// @Success      200          {object}  map[string]interface{}       "更新后的模板"
+// @Failure      400          {object}  errors.AppError              "请求参数错误"
+// @Failure      404          {object}  errors.AppError              "模板不存在"
+// @Failure      409          {object}  errors.AppError              "模板不是草稿"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/prompt-templates/{template_id} [put]
+func (h *KnowledgeBaseHandler) UpdatePromptTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validatePromptTemplateKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	req, err := bindPromptTemplate(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	template, err := h.promptTemplateService.UpdateTemplate(ctx, id,
+		secutils.SanitizeForLog(c.Param("template_id")), req)
+	if err != nil {
+		c.Error(promptTemplateError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// DeletePromptTemplate godoc
+// @Summary      删除提示词模板
+// @Description  删除草稿版本，已发布或已归档的版本保留以便回滚
+// @Tags         提示词模板
+// @Produce      json
+// @Param        id           path      string  true  "知识库ID"
+// @Param        template_id  path      string  true  "模板ID"
+// @Success      200          {object}  map[string]interface{}  "删除成功"
+// @Failure      404          {object}  errors.AppError         "模板不存在"
+// @Failure      409          {object}  errors.AppError         "模板不是草稿"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/prompt-templates/{template_id} [delete]
+func (h *KnowledgeBaseHandler) DeletePromptTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validatePromptTemplateKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.promptTemplateService.DeleteTemplate(ctx, id,
+		secutils.SanitizeForLog(c.Param("template_id"))); err != nil {
+		c.Error(promptTemplateError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// PublishPromptTemplate godoc
+// @Summary      发布提示词模板
+// @Description  将模板版本用于仅检索该知识库的对话回答，原已发布版本自动归档；发布已归档的版本即回滚到该版本
+// @Tags         提示词模板
+// @Produce      json
+// @Param        id           path      string  true  "知识库ID"
+// @Param        template_id  path      string  true  "模板ID"
+// @Success      200          {object}  map[string]interface{}  "发布的模板"
+// @Failure      404          {object}  errors.AppError         "模板不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/prompt-templates/{template_id}/publish [post]
+func (h *KnowledgeBaseHandler) PublishPromptTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validatePromptTemplateKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	template, err := h.promptTemplateService.PublishTemplate(ctx, id,
+		secutils.SanitizeForLog(c.Param("template_id")))
+	if err != nil {
+		c.Error(promptTemplateError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// UnpublishPromptTemplate godoc
+// @Summary      取消发布提示词模板
+// @Description  归档已发布的模板版本，知识库的对话恢复使用智能体或系统默认的提示词
+// @Tags         提示词模板
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "取消发布成功"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/prompt-templates/unpublish [post]
+func (h *KnowledgeBaseHandler) UnpublishPromptTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validatePromptTemplateKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.promptTemplateService.UnpublishTemplate(ctx, id); err != nil {
+		c.Error(promptTemplateError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// PreviewPromptTemplate godoc
+// @Summary      预览提示词模板
+// @Description  用模板版本回答示例问题而不发布，返回渲染后的提示词、检索到的分块，以及 generate 为 true 时生成的回答
+// @Tags         提示词模板
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                      true  "知识库ID"
+// @Param        template_id  path      string                      true  "模板ID"
+// @Param        request      body      types.PromptPreviewRequest  true  "示例问题"
+// @Success      200          {object}  map[string]interface{}      "每个问题的预览结果"
+// @Failure      400          {object}  errors.AppError             "请求参数错误"
+// @Failure      404          {object}  errors.AppError             "模板不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/prompt-templates/{template_id}/preview [post]
+func (h *KnowledgeBaseHandler) PreviewPromptTemplate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validatePromptTemplateKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var req types.PromptPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	results, err := h.promptTemplateService.PreviewTemplate(ctx, id,
+		secutils.SanitizeForLog(c.Param("template_id")), &req)
+	if err != nil {
+		c.Error(promptTemplateError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// promptTemplateError maps prompt template failures to API errors
+func promptTemplateError(ctx context.Context, err error) error {
+	var appErr *apperrors.AppError
+	switch {
+	case stderrors.Is(err, repository.ErrPromptTemplateNotFound):
+		return apperrors.NewNotFoundError("Prompt template not found")
+	case stderrors.Is(err, service.ErrPromptTemplateNotDraft):
+		return apperrors.NewConflictError(err.Error())
+	case stderrors.As(err, &appErr):
+		return appErr
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
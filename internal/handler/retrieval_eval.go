@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RetrievalEvalSetRequest creates or replaces a golden question set
+type RetrievalEvalSetRequest struct {
+	Name        string                   `json:"name"        binding:"required"`
+	Description string                   `json:"description"`
+	Cases       types.RetrievalEvalCases `json:"cases"       binding:"required"`
+}
+
+// validateOwnedKnowledgeBase resolves the knowledge base of a request reserved to the tenant
+// owning it, such as evaluations and experiments, where shared access is not enough
+func (h *KnowledgeBaseHandler) validateOwnedKnowledgeBase(c *gin.Context, forbidden string) (string, error) {
+	kb, id, _, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		return "", err
+	}
+	if kb.TenantID != c.GetUint64(types.TenantIDContextKey.String()) {
+		return "", apperrors.NewForbiddenError(forbidden)
+	}
+	return id, nil
+}
+
+// validateEvalKnowledgeBase resolves the knowledge base of a retrieval evaluation request
+func (h *KnowledgeBaseHandler) validateEvalKnowledgeBase(c *gin.Context) (string, error) {
+	return h.validateOwnedKnowledgeBase(c, "Only the owner of the knowledge base can run retrieval evaluations")
+}
+
+// bindEvalSet parses and validates a golden question set request
+func bindEvalSet(c *gin.Context, knowledgeBaseID string) (*types.RetrievalEvalSet, error) {
+	var req RetrievalEvalSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(c.Request.Context(), "Failed to parse request parameters", err)
+		return nil, apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error())
+	}
+	if err := req.Cases.Validate(); err != nil {
+		return nil, apperrors.NewBadRequestError("Invalid cases").WithDetails(err.Error())
+	}
+	return &types.RetrievalEvalSet{
+		KnowledgeBaseID: knowledgeBaseID,
+		Name:            req.Name,
+		Description:     req.Description,
+		Cases:           req.Cases,
+	}, nil
+}
+
+// CreateEvalSet godoc
+// @Summary      创建检索评测集
+// @Description  上传知识库的黄金问答集，每个用例包含问题以及期望命中的文档或分块
+// @Tags         检索评测
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                   true  "知识库ID"
+// @Param        request  body      RetrievalEvalSetRequest  true  "评测集"
+// @Success      201      {object}  map[string]interface{}   "创建的评测集"
+// @Failure      400      {object}  errors.AppError          "请求参数错误"
+// @Failure      403      {object}  errors.AppError          "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/eval-sets [post]
+func (h *KnowledgeBaseHandler) CreateEvalSet(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateEvalKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	set, err := bindEvalSet(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	set, err = h.evalService.CreateEvalSet(ctx, set)
+	if err != nil {
+		c.Error(retrievalEvalError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    set,
+	})
+}
+
+// ListEvalSets godoc
+// @Summary      获取检索评测集列表
+// @Description  列出知识库的黄金问答集
+// @Tags         检索评测
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "评测集列表"
+// @Failure      403  {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/eval-sets [get]
+func (h *KnowledgeBaseHandler) ListEvalSets(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateEvalKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	sets, err := h.evalService.ListEvalSets(ctx, id)
+	if err != nil {
+		c.Error(retrievalEvalError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sets,
+	})
+}
+
+// GetEvalSet godoc
+// @Summary      获取检索评测集详情
+// @Description  获取黄金问答集及其全部用例
+// @Tags         检索评测
+// @Produce      json
+// @Param        id      path      string  true  "知识库ID"
+// @Param        set_id  path      string  true  "评测集ID"
+// @Success      200     {object}  map[string]interface{}  "评测集"
+// @Failure      404     {object}  errors.AppError         "评测集不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/eval-sets/{set_id} [get]
+func (h *KnowledgeBaseHandler) GetEvalSet(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateEvalKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	set, err := h.evalService.GetEvalSet(ctx, id, secutils.SanitizeForLog(c.Param("set_id")))
+	if err != nil {
+		c.Error(retrievalEvalError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    set,
+	})
+}
+
+// UpdateEvalSet godoc
+// @Summary      更新检索评测集
+// @Description  替换黄金问答集的名称、描述和用例，已有的评测结果保持不变
+// @Tags         检索评测
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                   true  "知识库ID"
+// @Param        set_id   path      string                   true  "评测集ID"
+// @Param        request  body      RetrievalEvalSetRequest  true  "评测集"
+// @Success      200      {object}  map[string]interface{}   "更新后的评测集"
+// @Failure      400      {object}  errors.AppError          "请求参数错误"
+// @Failure      404      {object}  errors.AppError          "评测集不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/eval-sets/{set_id} [put]
+func (h *KnowledgeBaseHandler) UpdateEvalSet(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateEvalKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	set, err := bindEvalSet(c, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	set.ID = secutils.SanitizeForLog(c.Param("set_id"))
+
+	set, err = h.evalService.UpdateEvalSet(ctx, set)
+	if err != nil {
+		c.Error(retrievalEvalError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    set,
+	})
+}
+
+// DeleteEvalSet godoc
+// @Summary      删除检索评测集
+// @Description  删除黄金问答集及其全部评测结果
+// @Tags         检索评测
+// @Produce      json
+// @Param        id      path      string  true  "知识库ID"
+// @Param        set_id  path      string  true  "评测集ID"
+// @Success      200     {object}  map[string]interface{}  "删除成功"
+// @Failure      404     {object}  errors.AppError         "评测集不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/eval-sets/{set_id} [delete]
+func (h *KnowledgeBaseHandler) DeleteEvalSet(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateEvalKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.evalService.DeleteEvalSet(ctx, id, secutils.SanitizeForLog(c.Param("set_id"))); err != nil {
+		c.Error(retrievalEvalError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// StartEvalRun godoc
+// @Summary      运行检索评测
+// @Description  使用当前检索配置（可按需覆盖）异步运行评测集，完成后报告 recall@k、MRR 和 LLM 评判的忠实度
+// @Tags         检索评测
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                     true   "知识库ID"
+// @Param        set_id   path      string                     true   "评测集ID"
+// @Param        request  body      types.RetrievalEvalConfig  false  "检索配置覆盖项"
+// @Success      202      {object}  map[string]interface{}     "已创建的评测任务"
+// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Failure      404      {object}  errors.AppError            "评测集不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/eval-sets/{set_id}/runs [post]
+func (h *KnowledgeBaseHandler) StartEvalRun(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateEvalKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var cfg types.RetrievalEvalConfig
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&cfg); err != nil {
+			logger.Error(ctx, "Failed to parse request parameters", err)
+			c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		c.Error(apperrors.NewBadRequestError("Invalid evaluation config").WithDetails(err.Error()))
+		return
+	}
+
+	run, err := h.evalService.StartEvalRun(ctx, id, secutils.SanitizeForLog(c.Param("set_id")), &cfg)
+	if err != nil {
+		c.Error(retrievalEvalError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// ListEvalRuns godoc
+// @Summary      获取评测记录列表
+// @Description  列出评测集的历次评测及汇总指标（不含逐用例结果），按时间倒序
+// @Tags         检索评测
+// @Produce      json
+// @Param        id      path      string  true  "知识库ID"
+// @Param        set_id  path      string  true  "评测集ID"
+// @Success      200     {object}  map[string]interface{}  "评测记录列表"
+// @Failure      404     {object}  errors.AppError         "评测集不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/eval-sets/{set_id}/runs [get]
+func (h *KnowledgeBaseHandler) ListEvalRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateEvalKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	runs, err := h.evalService.ListEvalRuns(ctx, id, secutils.SanitizeForLog(c.Param("set_id")))
+	if err != nil {
+		c.Error(retrievalEvalError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runs,
+	})
+}
+
+// GetEvalRun godoc
+// @Summary      获取评测结果
+// @Description  获取一次评测的进度、汇总指标以及逐用例的检索结果和评判
+// @Tags         检索评测
+// @Produce      json
+// @Param        id      path      string  true  "知识库ID"
+// @Param        run_id  path      string  true  "评测记录ID"
+// @Success      200     {object}  map[string]interface{}  "评测结果"
+// @Failure      404     {object}  errors.AppError         "评测记录不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/eval-runs/{run_id} [get]
+func (h *KnowledgeBaseHandler) GetEvalRun(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := h.validateEvalKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	run, err := h.evalService.GetEvalRun(ctx, id, secutils.SanitizeForLog(c.Param("run_id")))
+	if err != nil {
+		c.Error(retrievalEvalError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// retrievalEvalError maps retrieval evaluation failures to API errors
+func retrievalEvalError(ctx context.Context, err error) error {
+	switch {
+	case stderrors.Is(err, repository.ErrRetrievalEvalSetNotFound):
+		return apperrors.NewNotFoundError("Evaluation set not found")
+	case stderrors.Is(err, repository.ErrRetrievalEvalRunNotFound):
+		return apperrors.NewNotFoundError("Evaluation run not found")
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+)
+
+// Search analytics query defaults and bounds
+const (
+	defaultSearchAnalyticsDays  = 30
+	maxSearchAnalyticsDays      = 365
+	defaultSearchAnalyticsLimit = 20
+	maxSearchAnalyticsLimit     = 100
+)
+
+// SearchAnalyticsQuery holds the query parameters of a search analytics request
+type SearchAnalyticsQuery struct {
+	Days  int `form:"days"`
+	Limit int `form:"limit"`
+}
+
+// SearchClickRequest records a click on a citation of an answer
+type SearchClickRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+	ChunkID   string `json:"chunk_id"   binding:"required"`
+}
+
+// SearchFeedbackRequest records the feedback given to an answer
+type SearchFeedbackRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+	Feedback  string `json:"feedback"   binding:"required"`
+}
+
+// GetSearchAnalytics godoc
+// @Summary      获取检索分析
+// @Description  统计知识库的检索次数、无结果检索、低置信度检索、引用点击和反馈，并列出高频问题、无结果问题和低置信度问题
+// @Tags         知识库
+// @Produce      json
+// @Param        id     path      string  true   "知识库ID"
+// @Param        days   query     int     false  "统计最近天数，默认30，最大365"
+// @Param        limit  query     int     false  "每类问题返回数量，默认20，最大100"
+// @Success      200    {object}  map[string]interface{}  "检索分析"
+// @Failure      400    {object}  errors.AppError         "请求参数错误"
+// @Failure      403    {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/search-analytics [get]
+func (h *KnowledgeBaseHandler) GetSearchAnalytics(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, permission, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// Search logs contain questions asked by all users, only administrators can read them
+	if !permission.HasPermission(types.KBRoleOwner) {
+		c.Error(apperrors.NewForbiddenError("Only knowledge base administrators can view search analytics"))
+		return
+	}
+
+	var query SearchAnalyticsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logger.Error(ctx, "Failed to parse query parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid query parameters").WithDetails(err.Error()))
+		return
+	}
+	if query.Days <= 0 {
+		query.Days = defaultSearchAnalyticsDays
+	}
+	if query.Days > maxSearchAnalyticsDays {
+		query.Days = maxSearchAnalyticsDays
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchAnalyticsLimit
+	}
+	if query.Limit > maxSearchAnalyticsLimit {
+		query.Limit = maxSearchAnalyticsLimit
+	}
+
+	analytics, err := h.analyticsService.GetSearchAnalytics(ctx, id, query.Days, query.Limit)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    analytics,
+	})
+}
+
+// RecordSearchClick godoc
+// @Summary      记录引用点击
+// @Description  记录用户点击了某条回答引用的分块，用于检索分析
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string              true  "知识库ID"
+// @Param        request  body      SearchClickRequest  true  "点击信息"
+// @Success      200      {object}  map[string]interface{}  "记录成功"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "检索记录不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/search-analytics/clicks [post]
+func (h *KnowledgeBaseHandler) RecordSearchClick(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req SearchClickRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	if err := h.analyticsService.RecordClick(ctx, id, req.MessageID, req.ChunkID); err != nil {
+		c.Error(searchLogError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// RecordSearchFeedback godoc
+// @Summary      记录回答反馈
+// @Description  记录用户对某条回答的反馈（positive 或 negative），用于检索分析
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true  "知识库ID"
+// @Param        request  body      SearchFeedbackRequest  true  "反馈信息"
+// @Success      200      {object}  map[string]interface{}  "记录成功"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "检索记录不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/search-analytics/feedback [post]
+func (h *KnowledgeBaseHandler) RecordSearchFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, id, _, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req SearchFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	if req.Feedback != types.SearchFeedbackPositive && req.Feedback != types.SearchFeedbackNegative {
+		c.Error(apperrors.NewBadRequestError("Feedback must be positive or negative"))
+		return
+	}
+
+	if err := h.analyticsService.RecordFeedback(ctx, id, req.MessageID, req.Feedback); err != nil {
+		c.Error(searchLogError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// searchLogError maps search log lookup failures to API errors
+func searchLogError(ctx context.Context, err error) error {
+	if stderrors.Is(err, repository.ErrSearchLogNotFound) {
+		return apperrors.NewNotFoundError("Search log not found for this message")
+	}
+	logger.ErrorWithFields(ctx, err, nil)
+	return apperrors.NewInternalServerError(err.Error())
+}
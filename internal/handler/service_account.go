@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"net/http"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceAccountHandler handles the service accounts of the current tenant
+type ServiceAccountHandler struct {
+	service interfaces.ServiceAccountService
+}
+
+// NewServiceAccountHandler creates a new service account handler
+func NewServiceAccountHandler(service interfaces.ServiceAccountService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{service: service}
+}
+
+// ListServiceAccounts godoc
+// @Summary      获取服务账号列表
+// @Description  列出当前租户的服务账号
+// @Tags         服务账号
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "服务账号列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /service-accounts [get]
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	accounts, err := h.service.ListServiceAccounts(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    accounts,
+	})
+}
+
+// CreateServiceAccount godoc
+// @Summary      创建服务账号
+// @Description  创建供 CI、爬虫等系统使用的服务账号。服务账号不能登录，只能通过自己的 API Key 访问；scopes 限定其 API Key 可用的范围，只能为 retrieval 或 ingest
+// @Tags         服务账号
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.CreateServiceAccountRequest  true  "服务账号配置"
+// @Success      201      {object}  map[string]interface{}             "创建的服务账号"
+// @Failure      400      {object}  errors.AppError                    "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /service-accounts [post]
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	account, err := h.service.CreateServiceAccount(ctx, &req)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    account,
+	})
+}
+
+// GetServiceAccount godoc
+// @Summary      获取服务账号详情
+// @Description  获取服务账号及其 API Key，不返回密钥本身
+// @Tags         服务账号
+// @Produce      json
+// @Param        id   path      string                  true  "服务账号ID"
+// @Success      200  {object}  map[string]interface{}  "服务账号详情"
+// @Failure      404  {object}  errors.AppError         "服务账号不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /service-accounts/{id} [get]
+func (h *ServiceAccountHandler) GetServiceAccount(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	account, err := h.service.GetServiceAccount(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    account,
+	})
+}
+
+// UpdateServiceAccount godoc
+// @Summary      更新服务账号
+// @Description  修改服务账号的名称、描述、范围或停用状态。停用后其 API Key 立即无法认证；收窄范围后，范围之外的 API Key 也不再可用
+// @Tags         服务账号
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                             true  "服务账号ID"
+// @Param        request  body      types.UpdateServiceAccountRequest  true  "更新内容"
+// @Success      200      {object}  map[string]interface{}             "更新后的服务账号"
+// @Failure      400      {object}  errors.AppError                    "请求参数错误"
+// @Failure      404      {object}  errors.AppError                    "服务账号不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /service-accounts/{id} [put]
+func (h *ServiceAccountHandler) UpdateServiceAccount(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.UpdateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	account, err := h.service.UpdateServiceAccount(ctx, secutils.SanitizeForLog(c.Param("id")), &req)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    account,
+	})
+}
+
+// DeleteServiceAccount godoc
+// @Summary      删除服务账号
+// @Description  删除服务账号并吊销其全部 API Key，已导入的文档保留原创建者记录
+// @Tags         服务账号
+// @Produce      json
+// @Param        id   path      string                  true  "服务账号ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "服务账号不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /service-accounts/{id} [delete]
+func (h *ServiceAccountHandler) DeleteServiceAccount(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.service.DeleteServiceAccount(ctx, secutils.SanitizeForLog(c.Param("id"))); err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
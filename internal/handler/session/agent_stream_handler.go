@@ -62,6 +62,8 @@ func (h *AgentStreamHandler) Subscribe() {
 	h.eventBus.On(event.EventAgentToolResult, h.handleToolResult)
 	h.eventBus.On(event.EventAgentReferences, h.handleReferences)
 	h.eventBus.On(event.EventAgentFinalAnswer, h.handleFinalAnswer)
+	h.eventBus.On(event.EventAgentCitations, h.handleCitations)
+	h.eventBus.On(event.EventAgentFollowUps, h.handleFollowUps)
 	h.eventBus.On(event.EventAgentReflection, h.handleReflection)
 	h.eventBus.On(event.EventError, h.handleError)
 	h.eventBus.On(event.EventSessionTitle, h.handleSessionTitle)
@@ -324,6 +326,68 @@ func (h *AgentStreamHandler) handleFinalAnswer(ctx context.Context, evt event.Ev
 	return nil
 }
 
+// handleCitations handles citations of the final answer
+func (h *AgentStreamHandler) handleCitations(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentCitationsData)
+	if !ok {
+		return nil
+	}
+	citations, ok := data.Citations.(types.Citations)
+	if !ok {
+		return nil
+	}
+
+	// The full list arrives before the answer is done, store it with the assistant message
+	if data.Done {
+		h.mu.Lock()
+		h.assistantMessage.Citations = citations
+		h.mu.Unlock()
+	}
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeCitations,
+		Content:   "",
+		Done:      data.Done,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"citations": citations,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append citations event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
+// handleFollowUps handles the follow-up questions suggested after the final answer
+func (h *AgentStreamHandler) handleFollowUps(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentFollowUpsData)
+	if !ok {
+		return nil
+	}
+
+	// The questions arrive before the answer is done, store them with the assistant message
+	h.mu.Lock()
+	h.assistantMessage.FollowUpQuestions = data.Questions
+	h.mu.Unlock()
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeFollowUps,
+		Content:   "",
+		Done:      true,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"questions": data.Questions,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append follow-up questions event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
 // handleReflection handles agent reflection events
 func (h *AgentStreamHandler) handleReflection(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.AgentReflectionData)
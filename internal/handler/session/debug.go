@@ -0,0 +1,70 @@
+package session
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// DebugRetrieval godoc
+// @Summary      检索调试
+// @Description  对指定知识库执行检索流水线（不生成回答），返回改写后的查询、向量与关键词命中、融合方式、重排分数以及最终拼装的上下文
+// @Tags         问答
+// @Produce      json
+// @Param        knowledge_base_id  query     string  true   "知识库ID"
+// @Param        query              query     string  true   "查询内容"
+// @Param        session_id         query     string  false  "会话ID，提供时使用会话历史改写查询"
+// @Param        model_id           query     string  false  "用于查询改写和扩展的对话模型ID"
+// @Success      200                {object}  map[string]interface{}  "各阶段输出"
+// @Failure      400                {object}  errors.AppError         "请求参数错误"
+// @Failure      404                {object}  errors.AppError         "知识库或会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /debug/retrieval [get]
+func (h *Handler) DebugRetrieval(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+
+	var request DebugRetrievalRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Error(ctx, "Failed to parse retrieval debug parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	if request.SessionID != "" {
+		if _, err := h.sessionService.GetSession(ctx, request.SessionID); err != nil {
+			if err == errors.ErrSessionNotFound {
+				c.Error(errors.NewNotFoundError(err.Error()))
+				return
+			}
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError(err.Error()))
+			return
+		}
+	}
+
+	logger.Infof(ctx, "Retrieval debug request, knowledge base ID: %s, query: %s",
+		secutils.SanitizeForLog(request.KnowledgeBaseID), secutils.SanitizeForLog(request.Query))
+
+	result, err := h.sessionService.DebugRetrieval(ctx,
+		request.KnowledgeBaseID, request.Query, request.SessionID, request.ModelID)
+	if err != nil {
+		if stderrors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			c.Error(errors.NewNotFoundError("Knowledge base not found"))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
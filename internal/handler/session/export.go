@@ -0,0 +1,169 @@
+package session
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// sharedSessionPath is the API path reading a shared conversation, followed by the share token
+const sharedSessionPath = "/api/v1/shared/sessions/"
+
+// ExportSession godoc
+// @Summary      导出会话
+// @Description  将会话及回答中的引用导出为 Markdown 或 JSON 文件
+// @Tags         会话
+// @Produce      json
+// @Produce      text/markdown
+// @Param        id          path      string               true   "会话ID"
+// @Param        format      query     string               false  "导出格式：markdown（默认）或 json"
+// @Success      200         {object}  types.SessionExport  "导出的会话"
+// @Failure      400         {object}  errors.AppError      "请求参数错误"
+// @Failure      404         {object}  errors.AppError      "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{id}/export [get]
+func (h *Handler) ExportSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+	format, ok := parseExportFormat(c.Query("format"))
+	if !ok {
+		c.Error(errors.NewBadRequestError("format must be markdown or json"))
+		return
+	}
+
+	export, err := h.sessionService.ExportSession(ctx, id)
+	if err != nil {
+		if err == errors.ErrSessionNotFound {
+			c.Error(errors.NewNotFoundError(err.Error()))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	writeSessionExport(c, export, format, true)
+}
+
+// ShareSession godoc
+// @Summary      分享会话
+// @Description  生成会话的只读分享链接，链接经过签名并在到期后失效
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string                  true   "会话ID"
+// @Param        request     body      ShareSessionRequest     false  "分享设置"
+// @Success      200         {object}  types.SessionShareLink  "分享链接"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
+// @Failure      404         {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/share [post]
+func (h *Handler) ShareSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("session_id"))
+
+	var request ShareSessionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(errors.NewBadRequestError(err.Error()))
+			return
+		}
+	}
+	if request.ExpiresInHours < 0 {
+		c.Error(errors.NewBadRequestError("expires_in_hours must not be negative"))
+		return
+	}
+
+	link, err := h.sessionService.ShareSession(ctx, id, time.Duration(request.ExpiresInHours)*time.Hour)
+	if err != nil {
+		if err == errors.ErrSessionNotFound {
+			c.Error(errors.NewNotFoundError(err.Error()))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	link.Path = sharedSessionPath + link.Token
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    link,
+	})
+}
+
+// GetSharedSession godoc
+// @Summary      查看分享的会话
+// @Description  通过分享链接只读查看会话，无需登录，链接到期后失效
+// @Tags         会话
+// @Produce      json
+// @Produce      text/markdown
+// @Param        token   path      string  true   "分享令牌"
+// @Param        format  query     string  false  "返回格式：json（默认）或 markdown"
+// @Success      200     {object}  types.SessionExport  "分享的会话"
+// @Failure      401     {object}  errors.AppError      "链接无效或已过期"
+// @Failure      404     {object}  errors.AppError      "会话不存在"
+// @Router       /shared/sessions/{token} [get]
+func (h *Handler) GetSharedSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	format := types.SessionExportFormatJSON
+	if c.Query("format") != "" {
+		var ok bool
+		if format, ok = parseExportFormat(c.Query("format")); !ok {
+			c.Error(errors.NewBadRequestError("format must be markdown or json"))
+			return
+		}
+	}
+
+	export, err := h.sessionService.GetSharedSession(ctx, c.Param("token"))
+	if err != nil {
+		switch {
+		case stderrors.Is(err, service.ErrInvalidShareToken):
+			c.Error(errors.NewUnauthorizedError(err.Error()))
+		case err == errors.ErrSessionNotFound:
+			c.Error(errors.NewNotFoundError(err.Error()))
+		default:
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+	writeSessionExport(c, export, format, false)
+}
+
+// parseExportFormat parses the format query parameter, Markdown when empty
+func parseExportFormat(format string) (types.SessionExportFormat, bool) {
+	switch types.SessionExportFormat(format) {
+	case "", types.SessionExportFormatMarkdown, "md":
+		return types.SessionExportFormatMarkdown, true
+	case types.SessionExportFormatJSON:
+		return types.SessionExportFormatJSON, true
+	}
+	return "", false
+}
+
+// writeSessionExport writes the exported session, as a file download when attachment is set
+func writeSessionExport(c *gin.Context,
+	export *types.SessionExport, format types.SessionExportFormat, attachment bool,
+) {
+	if format == types.SessionExportFormatJSON {
+		if attachment {
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=session_%s.json", export.SessionID))
+		}
+		c.JSON(http.StatusOK, export)
+		return
+	}
+	if attachment {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=session_%s.md", export.SessionID))
+	}
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(export.Markdown()))
+}
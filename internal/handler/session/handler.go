@@ -3,9 +3,12 @@ package session
 import (
 	"net/http"
 
+	"github.com/Tencent/WeKnora/internal/cluster"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/stream"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
@@ -22,6 +25,9 @@ type Handler struct {
 	customAgentService   interfaces.CustomAgentService   // Service for managing custom agents
 	tenantService        interfaces.TenantService        // Service for loading tenant (shared agent context)
 	agentShareService    interfaces.AgentShareService    // Service for resolving shared agents (KB scope in retrieval)
+	moderationService    interfaces.ModerationService    // Service for moderating questions and answers
+	replica              *cluster.Replica                // This replica, recorded as the one generating answers
+	localStreams         bool                            // Whether streams are kept in memory, only on their replica
 }
 
 // NewHandler creates a new instance of Handler with all necessary dependencies
@@ -34,7 +40,10 @@ func NewHandler(
 	customAgentService interfaces.CustomAgentService,
 	tenantService interfaces.TenantService,
 	agentShareService interfaces.AgentShareService,
+	moderationService interfaces.ModerationService,
+	replica *cluster.Replica,
 ) *Handler {
+	_, localStreams := streamManager.(*stream.MemoryStreamManager)
 	return &Handler{
 		sessionService:       sessionService,
 		messageService:       messageService,
@@ -44,6 +53,9 @@ func NewHandler(
 		customAgentService:   customAgentService,
 		tenantService:        tenantService,
 		agentShareService:    agentShareService,
+		moderationService:    moderationService,
+		replica:              replica,
+		localStreams:         localStreams,
 	}
 }
 
@@ -159,30 +171,39 @@ func (h *Handler) GetSession(c *gin.Context) {
 
 // GetSessionsByTenant godoc
 // @Summary      获取会话列表
-// @Description  获取当前租户的会话列表，支持分页
+// @Description  获取当前租户的会话列表，支持按页码或游标分页和排序
 // @Tags         会话
 // @Accept       json
 // @Produce      json
-// @Param        page       query     int  false  "页码"
-// @Param        page_size  query     int  false  "每页数量"
-// @Success      200        {object}  map[string]interface{}  "会话列表"
-// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Param        page        query     int     false  "页码，不能与 cursor 同时使用"
+// @Param        page_size   query     int     false  "每页数量，最大 100"
+// @Param        cursor      query     string  false  "上一页返回的 next_cursor"
+// @Param        sort        query     string  false  "排序字段：created_at 或 updated_at，默认 created_at"
+// @Param        order       query     string  false  "排序方向：asc 或 desc，默认 desc"
+// @Param        skip_total  query     bool    false  "为 true 时不统计总数"
+// @Success      200         {object}  map[string]interface{}  "会话列表"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
 // @Security     Bearer
 // @Security     ApiKeyAuth
 // @Router       /sessions [get]
 func (h *Handler) GetSessionsByTenant(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	// Parse pagination parameters from query
-	var pagination types.Pagination
-	if err := c.ShouldBindQuery(&pagination); err != nil {
+	// Parse pagination and sorting parameters from query
+	var options types.ListOptions
+	if err := c.ShouldBindQuery(&options); err != nil {
 		logger.Error(ctx, "Failed to parse pagination parameters", err)
 		c.Error(errors.NewBadRequestError(err.Error()))
 		return
 	}
+	listQuery, err := options.Resolve(types.SessionSortFields)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	// Use paginated query to get sessions
-	result, err := h.sessionService.GetPagedSessionsByTenant(ctx, &pagination)
+	result, err := h.sessionService.ListSessionPageByTenant(ctx, listQuery)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
@@ -190,13 +211,18 @@ func (h *Handler) GetSessionsByTenant(c *gin.Context) {
 	}
 
 	// Return sessions with pagination data
-	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"data":      result.Data,
-		"total":     result.Total,
-		"page":      result.Page,
-		"page_size": result.PageSize,
-	})
+	response := gin.H{
+		"success":     true,
+		"data":        result.Data,
+		"total":       result.Total,
+		"page_size":   result.PageSize,
+		"has_more":    result.HasMore,
+		"next_cursor": result.NextCursor,
+	}
+	if result.Page > 0 {
+		response["page"] = result.Page
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // UpdateSession godoc
@@ -300,6 +326,6 @@ func (h *Handler) DeleteSession(c *gin.Context) {
 	// Return success message
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Session deleted successfully",
+		"message": i18n.T(ctx, i18n.MsgSessionDeleted),
 	})
 }
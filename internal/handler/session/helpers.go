@@ -217,19 +217,20 @@ func (h *Handler) createDefaultSummaryConfig(ctx context.Context) *types.Summary
 	tenant, _ := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 
 	// Initialize with config.yaml defaults
+	summary := h.config.Current().Conversation.Summary
 	cfg := &types.SummaryConfig{
-		MaxTokens:           h.config.Conversation.Summary.MaxTokens,
-		TopP:                h.config.Conversation.Summary.TopP,
-		TopK:                h.config.Conversation.Summary.TopK,
-		FrequencyPenalty:    h.config.Conversation.Summary.FrequencyPenalty,
-		PresencePenalty:     h.config.Conversation.Summary.PresencePenalty,
-		RepeatPenalty:       h.config.Conversation.Summary.RepeatPenalty,
-		Prompt:              h.config.Conversation.Summary.Prompt,
-		ContextTemplate:     h.config.Conversation.Summary.ContextTemplate,
-		NoMatchPrefix:       h.config.Conversation.Summary.NoMatchPrefix,
-		Temperature:         h.config.Conversation.Summary.Temperature,
-		Seed:                h.config.Conversation.Summary.Seed,
-		MaxCompletionTokens: h.config.Conversation.Summary.MaxCompletionTokens,
+		MaxTokens:           summary.MaxTokens,
+		TopP:                summary.TopP,
+		TopK:                summary.TopK,
+		FrequencyPenalty:    summary.FrequencyPenalty,
+		PresencePenalty:     summary.PresencePenalty,
+		RepeatPenalty:       summary.RepeatPenalty,
+		Prompt:              summary.Prompt,
+		ContextTemplate:     summary.ContextTemplate,
+		NoMatchPrefix:       summary.NoMatchPrefix,
+		Temperature:         summary.Temperature,
+		Seed:                summary.Seed,
+		MaxCompletionTokens: summary.MaxCompletionTokens,
 	}
 
 	// Override with tenant-level conversation config if available
@@ -280,19 +281,20 @@ func (h *Handler) fillSummaryConfigDefaults(ctx context.Context, config *types.S
 	}
 
 	// Fall back to config.yaml if tenant config is empty
+	summary := h.config.Current().Conversation.Summary
 	if defaultPrompt == "" {
-		defaultPrompt = h.config.Conversation.Summary.Prompt
+		defaultPrompt = summary.Prompt
 	}
 	if defaultContextTemplate == "" {
-		defaultContextTemplate = h.config.Conversation.Summary.ContextTemplate
+		defaultContextTemplate = summary.ContextTemplate
 	}
 	if defaultTemperature == 0 {
-		defaultTemperature = h.config.Conversation.Summary.Temperature
+		defaultTemperature = summary.Temperature
 	}
 	if defaultMaxCompletionTokens == 0 {
-		defaultMaxCompletionTokens = h.config.Conversation.Summary.MaxCompletionTokens
+		defaultMaxCompletionTokens = summary.MaxCompletionTokens
 	}
-	defaultNoMatchPrefix = h.config.Conversation.Summary.NoMatchPrefix
+	defaultNoMatchPrefix = summary.NoMatchPrefix
 
 	// Fill missing fields
 	if config.Prompt == "" {
@@ -0,0 +1,408 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// openAIOwner is the owner reported for the models of the gateway
+const openAIOwner = "weknora"
+
+// openAIAnswerEvent is an answer chunk or failure forwarded from the QA event bus
+type openAIAnswerEvent struct {
+	content   string
+	done      bool
+	err       string
+	citations types.Citations
+	followUps []string
+}
+
+// openAIRequestError is a request failure reported in the OpenAI error format
+type openAIRequestError struct {
+	status  int
+	code    string
+	message string
+}
+
+// ChatCompletions godoc
+// @Summary      OpenAI 兼容的对话补全
+// @Description  以 OpenAI Chat Completions 协议进行知识库问答，model 为 kb:<知识库ID> 或 agent:<智能体ID>，stream 为 true 时以 SSE 流式返回
+// @Tags         OpenAI 兼容
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.OpenAIChatCompletionRequest  true  "对话补全请求"
+// @Success      200      {object}  types.OpenAIChatCompletion         "对话补全结果"
+// @Failure      400      {object}  types.OpenAIErrorResponse          "请求参数错误"
+// @Failure      404      {object}  types.OpenAIErrorResponse          "模型不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /openai/chat/completions [post]
+func (h *Handler) ChatCompletions(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+	logger.Info(ctx, "Start processing OpenAI-compatible chat completion request")
+
+	var request types.OpenAIChatCompletionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse request data", err)
+		writeOpenAIError(c, &openAIRequestError{status: http.StatusBadRequest, message: err.Error()})
+		return
+	}
+	query, history, err := request.Conversation()
+	if err != nil {
+		logger.Errorf(ctx, "Invalid chat completion messages: %v", err)
+		writeOpenAIError(c, &openAIRequestError{status: http.StatusBadRequest, message: err.Error()})
+		return
+	}
+	query, err = h.moderationService.ModerateQuery(ctx, "", query)
+	if err != nil {
+		logger.Warnf(ctx, "Chat completion question rejected by content moderation")
+		writeOpenAIError(c, &openAIRequestError{
+			status: http.StatusBadRequest, code: "content_blocked", message: err.Error(),
+		})
+		return
+	}
+	knowledgeBaseIDs, customAgent, reqErr := h.resolveOpenAIModel(ctx, request.Model)
+	if reqErr != nil {
+		logger.Warnf(ctx, "Failed to resolve model %s: %s", secutils.SanitizeForLog(request.Model), reqErr.message)
+		writeOpenAIError(c, reqErr)
+		return
+	}
+	logger.Infof(ctx, "Chat completion: model=%s, history_rounds=%d, stream=%v",
+		secutils.SanitizeForLog(request.Model), len(history), request.Stream)
+
+	// The conversation is kept by the client, so the session only scopes this request
+	session := &types.Session{
+		ID:       uuid.New().String(),
+		TenantID: c.GetUint64(types.TenantIDContextKey.String()),
+	}
+	completionID := "chatcmpl-" + uuid.New().String()
+	if request.SuggestFollowUps != nil {
+		ctx = context.WithValue(ctx, types.FollowUpsContextKey, *request.SuggestFollowUps)
+	}
+
+	asyncCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	answers := make(chan openAIAnswerEvent, 64)
+	send := func(evt openAIAnswerEvent) {
+		select {
+		case answers <- evt:
+		case <-asyncCtx.Done():
+		}
+	}
+
+	eventBus := event.NewEventBus()
+	h.moderationService.GuardAnswerStream(ctx, session.ID, completionID, eventBus)
+	eventBus.On(event.EventAgentFinalAnswer, func(_ context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.AgentFinalAnswerData); ok {
+			send(openAIAnswerEvent{content: data.Content, done: data.Done})
+		}
+		return nil
+	})
+	eventBus.On(event.EventAgentCitations, func(_ context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentCitationsData)
+		if !ok || !data.Done {
+			return nil
+		}
+		if citations, ok := data.Citations.(types.Citations); ok {
+			send(openAIAnswerEvent{citations: citations})
+		}
+		return nil
+	})
+	eventBus.On(event.EventAgentFollowUps, func(_ context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.AgentFollowUpsData); ok && len(data.Questions) > 0 {
+			send(openAIAnswerEvent{followUps: data.Questions})
+		}
+		return nil
+	})
+	eventBus.On(event.EventError, func(_ context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.ErrorData); ok {
+			send(openAIAnswerEvent{err: data.Error})
+		}
+		return nil
+	})
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				buf := make([]byte, 10240)
+				runtime.Stack(buf, true)
+				logger.Errorf(asyncCtx, "Chat completion panicked: %v\n%s", r, string(buf))
+				send(openAIAnswerEvent{err: "internal error"})
+			}
+		}()
+		err := h.sessionService.KnowledgeQAWithHistory(asyncCtx, session, query, history,
+			knowledgeBaseIDs, completionID, eventBus, customAgent)
+		if err != nil {
+			logger.ErrorWithFields(asyncCtx, err, nil)
+			send(openAIAnswerEvent{err: err.Error()})
+		}
+	}()
+
+	// Prompt usage covers the conversation sent by the client
+	prompt := []string{query}
+	for _, round := range history {
+		prompt = append(prompt, round.Query, round.Answer)
+	}
+	completion := &types.OpenAIChatCompletion{
+		ID:      completionID,
+		Created: time.Now().Unix(),
+		Model:   request.Model,
+	}
+	if request.Stream {
+		includeUsage := request.StreamOptions != nil && request.StreamOptions.IncludeUsage
+		streamChatCompletion(c, completion, answers, strings.Join(prompt, "\n"), includeUsage)
+		return
+	}
+	completeChatCompletion(c, completion, answers, strings.Join(prompt, "\n"))
+}
+
+// ListOpenAIModels godoc
+// @Summary      OpenAI 兼容的模型列表
+// @Description  列出可作为 model 使用的知识库（kb:<ID>）和智能体（agent:<ID>）
+// @Tags         OpenAI 兼容
+// @Produce      json
+// @Success      200  {object}  types.OpenAIModelList  "模型列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /openai/models [get]
+func (h *Handler) ListOpenAIModels(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+
+	knowledgeBases, err := h.knowledgebaseService.ListKnowledgeBases(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		writeOpenAIError(c, &openAIRequestError{status: http.StatusInternalServerError, message: err.Error()})
+		return
+	}
+	agents, err := h.customAgentService.ListAgents(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		writeOpenAIError(c, &openAIRequestError{status: http.StatusInternalServerError, message: err.Error()})
+		return
+	}
+
+	models := make([]*types.OpenAIModel, 0, len(knowledgeBases)+len(agents))
+	for _, kb := range knowledgeBases {
+		models = append(models, &types.OpenAIModel{
+			ID:      types.OpenAIModelPrefixKnowledgeBase + kb.ID,
+			Object:  types.OpenAIObjectModel,
+			Created: kb.CreatedAt.Unix(),
+			OwnedBy: openAIOwner,
+			Name:    kb.Name,
+		})
+	}
+	for _, agent := range agents {
+		// Agents in ReAct mode need the agent QA flow with tool calls
+		if agent.IsAgentMode() {
+			continue
+		}
+		models = append(models, &types.OpenAIModel{
+			ID:      types.OpenAIModelPrefixAgent + agent.ID,
+			Object:  types.OpenAIObjectModel,
+			Created: agent.CreatedAt.Unix(),
+			OwnedBy: openAIOwner,
+			Name:    agent.Name,
+		})
+	}
+	c.JSON(http.StatusOK, &types.OpenAIModelList{Object: types.OpenAIObjectList, Data: models})
+}
+
+// resolveOpenAIModel maps a model name to the knowledge bases or custom agent answering the request
+func (h *Handler) resolveOpenAIModel(ctx context.Context,
+	model string,
+) ([]string, *types.CustomAgent, *openAIRequestError) {
+	notFound := &openAIRequestError{
+		status:  http.StatusNotFound,
+		code:    "model_not_found",
+		message: fmt.Sprintf("The model %s does not exist", model),
+	}
+	switch {
+	case strings.HasPrefix(model, types.OpenAIModelPrefixKnowledgeBase):
+		kb, err := h.knowledgebaseService.GetKnowledgeBaseByID(ctx,
+			strings.TrimPrefix(model, types.OpenAIModelPrefixKnowledgeBase))
+		tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+		if err != nil || kb.TenantID != tenantID {
+			return nil, nil, notFound
+		}
+		return []string{kb.ID}, nil, nil
+	case strings.HasPrefix(model, types.OpenAIModelPrefixAgent):
+		agent, err := h.customAgentService.GetAgentByID(ctx, strings.TrimPrefix(model, types.OpenAIModelPrefixAgent))
+		if err != nil {
+			return nil, nil, notFound
+		}
+		if agent.IsAgentMode() {
+			return nil, nil, &openAIRequestError{
+				status:  http.StatusBadRequest,
+				code:    "model_not_supported",
+				message: fmt.Sprintf("The model %s runs in agent mode, which chat completions do not support", model),
+			}
+		}
+		return nil, agent, nil
+	}
+	return nil, nil, &openAIRequestError{
+		status:  http.StatusBadRequest,
+		code:    "model_not_found",
+		message: "The model must be kb:<knowledge base ID> or agent:<agent ID>",
+	}
+}
+
+// completeChatCompletion waits for the whole answer and writes it as a chat completion
+func completeChatCompletion(c *gin.Context,
+	completion *types.OpenAIChatCompletion, answers <-chan openAIAnswerEvent, prompt string,
+) {
+	var splitter types.OpenAIThinkingSplitter
+	var reasoning, content strings.Builder
+	for {
+		evt, ok := nextOpenAIAnswer(c, answers)
+		if !ok {
+			return
+		}
+		if evt.err != "" {
+			writeOpenAIError(c, &openAIRequestError{status: http.StatusInternalServerError, message: evt.err})
+			return
+		}
+		if evt.citations != nil {
+			completion.Citations = evt.citations
+			continue
+		}
+		if evt.followUps != nil {
+			completion.FollowUpQuestions = evt.followUps
+			continue
+		}
+		r, text := splitter.Split(evt.content)
+		reasoning.WriteString(r)
+		content.WriteString(text)
+		if evt.done {
+			break
+		}
+	}
+
+	finishReason := types.OpenAIFinishReasonStop
+	completion.Object = types.OpenAIObjectChatCompletion
+	completion.Choices = []types.OpenAIChoice{{
+		Message: &types.OpenAIResponseMessage{
+			Role:             "assistant",
+			Content:          content.String(),
+			ReasoningContent: reasoning.String(),
+		},
+		FinishReason: &finishReason,
+	}}
+	completion.Usage = types.NewOpenAIUsage(prompt, reasoning.String()+content.String())
+	c.JSON(http.StatusOK, completion)
+}
+
+// streamChatCompletion forwards the answer as chat completion chunks over SSE
+func streamChatCompletion(c *gin.Context,
+	completion *types.OpenAIChatCompletion, answers <-chan openAIAnswerEvent, prompt string, includeUsage bool,
+) {
+	setSSEHeaders(c)
+	completion.Object = types.OpenAIObjectChatCompletionChunk
+	writeOpenAIChunk(c, completion, &types.OpenAIResponseMessage{Role: "assistant"}, nil)
+
+	var splitter types.OpenAIThinkingSplitter
+	var generated strings.Builder
+	var citations types.Citations
+	var followUps []string
+	for {
+		evt, ok := nextOpenAIAnswer(c, answers)
+		if !ok {
+			return
+		}
+		if evt.err != "" {
+			writeOpenAIEvent(c, &types.OpenAIErrorResponse{Error: types.OpenAIError{
+				Message: evt.err,
+				Type:    "server_error",
+			}})
+			writeOpenAIEvent(c, nil)
+			return
+		}
+		if evt.citations != nil {
+			citations = evt.citations
+			continue
+		}
+		if evt.followUps != nil {
+			followUps = evt.followUps
+			continue
+		}
+		generated.WriteString(evt.content)
+		if reasoning, content := splitter.Split(evt.content); reasoning != "" || content != "" {
+			writeOpenAIChunk(c, completion,
+				&types.OpenAIResponseMessage{Content: content, ReasoningContent: reasoning}, nil)
+		}
+		if evt.done {
+			break
+		}
+	}
+
+	finishReason := types.OpenAIFinishReasonStop
+	last := *completion
+	last.Citations = citations
+	last.FollowUpQuestions = followUps
+	writeOpenAIChunk(c, &last, &types.OpenAIResponseMessage{}, &finishReason)
+	if includeUsage {
+		usage := *completion
+		usage.Choices = []types.OpenAIChoice{}
+		usage.Usage = types.NewOpenAIUsage(prompt, generated.String())
+		writeOpenAIEvent(c, &usage)
+	}
+	writeOpenAIEvent(c, nil)
+}
+
+// nextOpenAIAnswer waits for the next answer event, reporting false once the client has gone
+func nextOpenAIAnswer(c *gin.Context, answers <-chan openAIAnswerEvent) (openAIAnswerEvent, bool) {
+	select {
+	case evt := <-answers:
+		return evt, true
+	case <-c.Request.Context().Done():
+		logger.Warn(c.Request.Context(), "Client disconnected before the chat completion finished")
+		return openAIAnswerEvent{}, false
+	}
+}
+
+// writeOpenAIChunk writes a chunk with a single choice
+func writeOpenAIChunk(c *gin.Context,
+	completion *types.OpenAIChatCompletion, delta *types.OpenAIResponseMessage, finishReason *string,
+) {
+	chunk := *completion
+	chunk.Choices = []types.OpenAIChoice{{Delta: delta, FinishReason: finishReason}}
+	writeOpenAIEvent(c, &chunk)
+}
+
+// writeOpenAIEvent writes a data-only SSE event as OpenAI clients expect, nil marks the end of the stream
+func writeOpenAIEvent(c *gin.Context, payload interface{}) {
+	data := []byte("[DONE]")
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			logger.Errorf(c.Request.Context(), "Failed to marshal chat completion chunk: %v", err)
+			return
+		}
+	}
+	fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+	c.Writer.Flush()
+}
+
+// writeOpenAIError writes an error in the OpenAI format
+func writeOpenAIError(c *gin.Context, reqErr *openAIRequestError) {
+	errType := "invalid_request_error"
+	if reqErr.status >= http.StatusInternalServerError {
+		errType = "server_error"
+	}
+	c.JSON(reqErr.status, &types.OpenAIErrorResponse{Error: types.OpenAIError{
+		Message: reqErr.message,
+		Type:    errType,
+		Code:    reqErr.code,
+	}})
+}
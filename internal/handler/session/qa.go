@@ -11,6 +11,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"github.com/gin-gonic/gin"
@@ -28,6 +29,7 @@ type qaRequestContext struct {
 	assistantMessage  *types.Message
 	knowledgeBaseIDs  []string
 	knowledgeIDs      []string
+	searchFilter      *types.SearchFilter
 	summaryModelID    string
 	webSearchEnabled  bool
 	mentionedItems    types.MentionedItems
@@ -58,6 +60,18 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		logger.Error(ctx, "Query content is empty")
 		return nil, nil, errors.NewBadRequestError("Query content cannot be empty")
 	}
+	if err := request.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid search filter", err)
+		return nil, nil, errors.NewBadRequestError("Invalid search filter").WithDetails(err.Error())
+	}
+
+	// Apply content moderation before the question is stored or answered
+	query, err := h.moderationService.ModerateQuery(ctx, sessionID, request.Query)
+	if err != nil {
+		logger.Warnf(ctx, "Question of session %s rejected by content moderation", sessionID)
+		return nil, nil, err
+	}
+	request.Query = query
 
 	// Log request details
 	if requestJSON, err := json.Marshal(request); err == nil {
@@ -147,6 +161,10 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 	logger.Infof(ctx, "[%s] @mention merge: request.KnowledgeBaseIDs=%v, request.MentionedItems=%d, merged kbIDs=%v, merged knowledgeIDs=%v",
 		logPrefix, request.KnowledgeBaseIDs, len(request.MentionedItems), kbIDs, knowledgeIDs)
 
+	if request.SuggestFollowUps != nil {
+		ctx = context.WithValue(ctx, types.FollowUpsContextKey, *request.SuggestFollowUps)
+	}
+
 	// Build request context
 	reqCtx := &qaRequestContext{
 		ctx:         ctx,
@@ -164,6 +182,7 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		},
 		knowledgeBaseIDs:  secutils.SanitizeForLogArray(kbIDs),
 		knowledgeIDs:      secutils.SanitizeForLogArray(knowledgeIDs),
+		searchFilter:      request.Filter,
 		summaryModelID:    secutils.SanitizeForLog(request.SummaryModelID),
 		webSearchEnabled:  request.WebSearchEnabled,
 		mentionedItems:    convertMentionedItems(request.MentionedItems),
@@ -179,10 +198,14 @@ type sseStreamContext struct {
 	asyncCtx         context.Context
 	cancel           context.CancelFunc
 	assistantMessage *types.Message
+	releaseSession   func() // Gives up the claim of this replica on the session once the answer is generated
 }
 
 // setupSSEStream sets up the SSE streaming context
 func (h *Handler) setupSSEStream(reqCtx *qaRequestContext, generateTitle bool) *sseStreamContext {
+	// Record this replica as generating the answer, before the headers are sent
+	releaseSession := h.claimSession(reqCtx.ctx, reqCtx.c, reqCtx.sessionID)
+
 	// Set SSE headers
 	setSSEHeaders(reqCtx.c)
 
@@ -207,8 +230,12 @@ func (h *Handler) setupSSEStream(reqCtx *qaRequestContext, generateTitle bool) *
 		asyncCtx:         asyncCtx,
 		cancel:           cancel,
 		assistantMessage: reqCtx.assistantMessage,
+		releaseSession:   releaseSession,
 	}
 
+	// Moderate answer chunks before the stream handler and message persistence see them
+	h.moderationService.GuardAnswerStream(reqCtx.ctx, reqCtx.sessionID, reqCtx.assistantMessage.ID, eventBus)
+
 	// Setup stop event handler
 	h.setupStopEventHandler(eventBus, reqCtx.sessionID, reqCtx.session.TenantID, reqCtx.assistantMessage, cancel)
 
@@ -260,6 +287,15 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Query content cannot be empty"))
 		return
 	}
+	if err := request.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid search filter", err)
+		c.Error(errors.NewBadRequestError("Invalid search filter").WithDetails(err.Error()))
+		return
+	}
+	if err := request.Highlight.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError("Invalid highlight options").WithDetails(err.Error()))
+		return
+	}
 
 	// Merge single knowledge_base_id into knowledge_base_ids for backward compatibility
 	knowledgeBaseIDs := request.KnowledgeBaseIDs
@@ -292,13 +328,15 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 	)
 
 	// Directly call knowledge retrieval service without LLM summarization
-	searchResults, err := h.sessionService.SearchKnowledge(ctx, knowledgeBaseIDs, request.KnowledgeIDs, request.Query)
+	searchResults, err := h.sessionService.SearchKnowledge(ctx, knowledgeBaseIDs, request.KnowledgeIDs, request.Filter, request.Query)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
 	}
 
+	searchutil.HighlightResults(request.Query, searchResults, request.Highlight)
+
 	logger.Infof(ctx, "Knowledge search completed, found %d results", len(searchResults))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -429,6 +467,7 @@ func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bo
 
 	// Execute KnowledgeQA asynchronously
 	go func() {
+		defer streamCtx.releaseSession()
 		defer func() {
 			if r := recover(); r != nil {
 				buf := make([]byte, 10240)
@@ -444,6 +483,7 @@ func (h *Handler) executeNormalModeQA(reqCtx *qaRequestContext, generateTitle bo
 			reqCtx.query,
 			reqCtx.knowledgeBaseIDs,
 			reqCtx.knowledgeIDs,
+			reqCtx.searchFilter,
 			reqCtx.assistantMessage.ID,
 			reqCtx.summaryModelID,
 			reqCtx.webSearchEnabled,
@@ -511,6 +551,7 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 
 	// Execute AgentQA asynchronously
 	go func() {
+		defer streamCtx.releaseSession()
 		defer func() {
 			if r := recover(); r != nil {
 				buf := make([]byte, 1024)
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/Tencent/WeKnora/internal/cluster"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// replicaHeader names the replica generating the answer of a session, for load balancers to route the requests
+	// about the answer to it
+	replicaHeader = "X-WeKnora-Replica"
+	// forwardedHeader marks requests forwarded by another replica, which are not forwarded again
+	forwardedHeader = "X-WeKnora-Forwarded-By"
+)
+
+// claimSession records this replica as the one generating the answer of the session until the returned function is
+// called. It is a no-op when streams are shared through Redis, any replica can serve them then.
+func (h *Handler) claimSession(ctx context.Context, c *gin.Context, sessionID string) func() {
+	if h.replica == nil || !h.localStreams {
+		return func() {}
+	}
+	c.Header(replicaHeader, h.replica.ID())
+	claim, err := h.replica.Take(ctx, cluster.SessionKey(sessionID))
+	if err != nil {
+		logger.Warnf(ctx, "Failed to record the replica of session %s: %v", sessionID, err)
+		return func() {}
+	}
+	return claim.Release
+}
+
+// forwardToOwner sends a request about the answer of a session to the replica generating it, when its stream is
+// kept in the memory of that replica. Without the URL of that replica the request is rejected, naming the replica
+// to route it to. It reports whether the request was handled.
+func (h *Handler) forwardToOwner(c *gin.Context, sessionID string) bool {
+	if h.replica == nil || !h.localStreams || c.GetHeader(forwardedHeader) != "" {
+		return false
+	}
+	ctx := c.Request.Context()
+	owner, ok, err := h.replica.Owner(ctx, cluster.SessionKey(sessionID))
+	if err != nil {
+		logger.Warnf(ctx, "Failed to look up the replica of session %s: %v", sessionID, err)
+		return false
+	}
+	if !ok || owner.ReplicaID == h.replica.ID() {
+		return false
+	}
+
+	c.Header(replicaHeader, owner.ReplicaID)
+	target, err := url.Parse(owner.URL)
+	if owner.URL == "" || err != nil {
+		logger.Warnf(ctx, "Session %s is generating on replica %s, which cannot be reached", sessionID, owner.ReplicaID)
+		c.Error(errors.NewConflictError("The answer is being generated on replica " + owner.ReplicaID))
+		return true
+	}
+
+	logger.Infof(ctx, "Forwarding request of session %s to replica %s", sessionID, owner.ReplicaID)
+	c.Request.Header.Set(forwardedHeader, h.replica.ID())
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	// Server-sent events are passed on as they arrive
+	proxy.FlushInterval = -1
+	proxy.ServeHTTP(c.Writer, c.Request)
+	c.Abort()
+	return true
+}
@@ -3,11 +3,11 @@ package session
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -40,6 +40,9 @@ func (h *Handler) ContinueStream(c *gin.Context) {
 		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
 		return
 	}
+	if h.forwardToOwner(c, sessionID) {
+		return
+	}
 
 	// Get message ID from query parameter
 	messageID := secutils.SanitizeForLog(c.Query("message_id"))
@@ -74,10 +77,7 @@ func (h *Handler) ContinueStream(c *gin.Context) {
 
 	if message == nil {
 		logger.Warnf(ctx, "Incomplete message not found, session ID: %s, message ID: %s", sessionID, messageID)
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "Incomplete message not found",
-		})
+		c.Error(errors.NewNotFoundError("Incomplete message not found"))
 		return
 	}
 
@@ -91,10 +91,7 @@ func (h *Handler) ContinueStream(c *gin.Context) {
 
 	if len(events) == 0 {
 		logger.Warnf(ctx, "No events found in stream, session ID: %s, message ID: %s", sessionID, messageID)
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "No stream events found",
-		})
+		c.Error(errors.NewNotFoundError("No stream events found"))
 		return
 	}
 
@@ -193,7 +190,10 @@ func (h *Handler) StopSession(c *gin.Context) {
 	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
 
 	if sessionID == "" {
-		c.JSON(400, gin.H{"error": "Session ID is required"})
+		c.Error(errors.NewBadRequestError("Session ID is required"))
+		return
+	}
+	if h.forwardToOwner(c, sessionID) {
 		return
 	}
 
@@ -203,7 +203,7 @@ func (h *Handler) StopSession(c *gin.Context) {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id": sessionID,
 		})
-		c.JSON(400, gin.H{"error": "message_id is required"})
+		c.Error(errors.NewBadRequestError("message_id is required"))
 		return
 	}
 
@@ -214,7 +214,7 @@ func (h *Handler) StopSession(c *gin.Context) {
 	tenantID, exists := c.Get(types.TenantIDContextKey.String())
 	if !exists {
 		logger.Error(ctx, "Failed to get tenant ID")
-		c.JSON(401, gin.H{"error": "Unauthorized"})
+		c.Error(errors.NewUnauthorizedError("Unauthorized"))
 		return
 	}
 	tenantIDUint := tenantID.(uint64)
@@ -226,14 +226,14 @@ func (h *Handler) StopSession(c *gin.Context) {
 			"session_id": sessionID,
 			"message_id": assistantMessageID,
 		})
-		c.JSON(404, gin.H{"error": "Message not found"})
+		c.Error(errors.NewNotFoundError("Message not found"))
 		return
 	}
 
 	// Verify message belongs to this session (double check)
 	if message.SessionID != sessionID {
 		logger.Warnf(ctx, "Message %s does not belong to session %s", assistantMessageID, sessionID)
-		c.JSON(403, gin.H{"error": "Message does not belong to this session"})
+		c.Error(errors.NewForbiddenError("Message does not belong to this session"))
 		return
 	}
 
@@ -243,13 +243,13 @@ func (h *Handler) StopSession(c *gin.Context) {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id": sessionID,
 		})
-		c.JSON(404, gin.H{"error": "Session not found"})
+		c.Error(errors.NewNotFoundError("Session not found"))
 		return
 	}
 
 	if session.TenantID != tenantIDUint {
 		logger.Warnf(ctx, "Session %s does not belong to tenant %d", sessionID, tenantIDUint)
-		c.JSON(403, gin.H{"error": "Access denied"})
+		c.Error(errors.NewForbiddenError("Access denied"))
 		return
 	}
 
@@ -258,7 +258,7 @@ func (h *Handler) StopSession(c *gin.Context) {
 		logger.Infof(ctx, "Message %s is already completed, no need to stop", assistantMessageID)
 		c.JSON(200, gin.H{
 			"success": true,
-			"message": "Message already completed",
+			"message": i18n.T(ctx, i18n.MsgMessageAlreadyCompleted),
 		})
 		return
 	}
@@ -282,14 +282,14 @@ func (h *Handler) StopSession(c *gin.Context) {
 			"session_id": sessionID,
 			"message_id": assistantMessageID,
 		})
-		c.JSON(500, gin.H{"error": "Failed to write stop event"})
+		c.Error(errors.NewInternalServerError("Failed to write stop event"))
 		return
 	}
 
 	logger.Infof(ctx, "Stop event written successfully for session: %s, message: %s", sessionID, assistantMessageID)
 	c.JSON(200, gin.H{
 		"success": true,
-		"message": "Generation stopped",
+		"message": i18n.T(ctx, i18n.MsgGenerationStopped),
 	})
 }
 
@@ -19,6 +19,11 @@ type GenerateTitleRequest struct {
 	Messages []types.Message `json:"messages" binding:"required"` // Messages to use as context for title generation
 }
 
+// ShareSessionRequest defines the request structure for sharing a session
+type ShareSessionRequest struct {
+	ExpiresInHours int `json:"expires_in_hours"` // Hours the share link stays valid, 72 when zero and at most 720
+}
+
 // MentionedItemRequest represents a mentioned item in the request
 type MentionedItemRequest struct {
 	ID     string `json:"id"`
@@ -37,15 +42,29 @@ type CreateKnowledgeQARequest struct {
 	WebSearchEnabled bool                   `json:"web_search_enabled"`                    // Whether web search is enabled for this request
 	SummaryModelID   string                 `json:"summary_model_id"`                      // Optional summary model ID for this request (overrides session default)
 	MentionedItems   []MentionedItemRequest `json:"mentioned_items"`                       // @mentioned knowledge bases and files
+	Filter           *types.SearchFilter    `json:"filter"`                                // Structured retrieval filter (tags, file type, dates, metadata, source domain)
 	DisableTitle     bool                   `json:"disable_title"`                         // Whether to disable auto title generation
+	SuggestFollowUps *bool                  `json:"suggest_follow_ups"`                    // Whether to suggest follow-up questions after the answer (overrides agent config)
 }
 
 // SearchKnowledgeRequest defines the request structure for searching knowledge without LLM summarization
 type SearchKnowledgeRequest struct {
-	Query            string   `json:"query"              binding:"required"` // Query text to search for
-	KnowledgeBaseID  string   `json:"knowledge_base_id"`                     // Single knowledge base ID (for backward compatibility)
-	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`                    // IDs of knowledge bases to search (multi-KB support)
-	KnowledgeIDs     []string `json:"knowledge_ids"`                         // IDs of specific knowledge (files) to search
+	Query            string              `json:"query"              binding:"required"` // Query text to search for
+	KnowledgeBaseID  string              `json:"knowledge_base_id"`                     // Single knowledge base ID (for backward compatibility)
+	KnowledgeBaseIDs []string            `json:"knowledge_base_ids"`                    // IDs of knowledge bases to search (multi-KB support)
+	KnowledgeIDs     []string            `json:"knowledge_ids"`                         // IDs of specific knowledge (files) to search
+	Filter           *types.SearchFilter `json:"filter"`                                // Structured retrieval filter
+
+	// Highlighted snippets to add to the results
+	Highlight *types.HighlightOptions `json:"highlight"`
+}
+
+// DebugRetrievalRequest defines the query parameters of a retrieval debug request
+type DebugRetrievalRequest struct {
+	KnowledgeBaseID string `form:"knowledge_base_id" binding:"required"` // Knowledge base to search
+	Query           string `form:"query"             binding:"required"` // Query text to search for
+	SessionID       string `form:"session_id"`                           // Optional session whose history is used for query rewriting
+	ModelID         string `form:"model_id"`                             // Optional chat model for query rewriting and expansion
 }
 
 // StopSessionRequest represents the stop session request
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/Tencent/WeKnora/internal/config"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/gin-gonic/gin"
@@ -17,15 +18,17 @@ import (
 
 // SystemHandler handles system-related requests
 type SystemHandler struct {
-	cfg         *config.Config
-	neo4jDriver neo4j.Driver
+	cfg           *config.Config
+	neo4jDriver   neo4j.Driver
+	configWatcher *config.Watcher
 }
 
 // NewSystemHandler creates a new system handler
-func NewSystemHandler(cfg *config.Config, neo4jDriver neo4j.Driver) *SystemHandler {
+func NewSystemHandler(cfg *config.Config, neo4jDriver neo4j.Driver, configWatcher *config.Watcher) *SystemHandler {
 	return &SystemHandler{
-		cfg:         cfg,
-		neo4jDriver: neo4jDriver,
+		cfg:           cfg,
+		neo4jDriver:   neo4jDriver,
+		configWatcher: configWatcher,
 	}
 }
 
@@ -39,6 +42,8 @@ type GetSystemInfoResponse struct {
 	VectorStoreEngine   string `json:"vector_store_engine,omitempty"`
 	GraphDatabaseEngine string `json:"graph_database_engine,omitempty"`
 	MinioEnabled        bool   `json:"minio_enabled,omitempty"`
+	// ParserPluginFileTypes are the file types DocReader parses with parser plugins
+	ParserPluginFileTypes []string `json:"parser_plugin_file_types,omitempty"`
 }
 
 // 编译时注入的版本信息
@@ -73,14 +78,15 @@ func (h *SystemHandler) GetSystemInfo(c *gin.Context) {
 	minioEnabled := h.isMinioEnabled()
 
 	response := GetSystemInfoResponse{
-		Version:             Version,
-		CommitID:            CommitID,
-		BuildTime:           BuildTime,
-		GoVersion:           GoVersion,
-		KeywordIndexEngine:  keywordIndexEngine,
-		VectorStoreEngine:   vectorStoreEngine,
-		GraphDatabaseEngine: graphDatabaseEngine,
-		MinioEnabled:        minioEnabled,
+		Version:               Version,
+		CommitID:              CommitID,
+		BuildTime:             BuildTime,
+		GoVersion:             GoVersion,
+		KeywordIndexEngine:    keywordIndexEngine,
+		VectorStoreEngine:     vectorStoreEngine,
+		GraphDatabaseEngine:   graphDatabaseEngine,
+		MinioEnabled:          minioEnabled,
+		ParserPluginFileTypes: types.ParserPluginFileTypes(),
 	}
 
 	logger.Info(ctx, "System info retrieved successfully")
@@ -210,11 +216,7 @@ func (h *SystemHandler) ListMinioBuckets(c *gin.Context) {
 	// Check if MinIO is enabled
 	if !h.isMinioEnabled() {
 		logger.Warn(ctx, "MinIO is not enabled")
-		c.JSON(400, gin.H{
-			"code":    400,
-			"msg":     "MinIO is not enabled",
-			"success": false,
-		})
+		c.Error(apperrors.NewBadRequestError("MinIO is not enabled"))
 		return
 	}
 
@@ -231,11 +233,7 @@ func (h *SystemHandler) ListMinioBuckets(c *gin.Context) {
 	})
 	if err != nil {
 		logger.Error(ctx, "Failed to create MinIO client", "error", err)
-		c.JSON(500, gin.H{
-			"code":    500,
-			"msg":     "Failed to connect to MinIO",
-			"success": false,
-		})
+		c.Error(apperrors.NewInternalServerError("Failed to connect to MinIO"))
 		return
 	}
 
@@ -243,11 +241,7 @@ func (h *SystemHandler) ListMinioBuckets(c *gin.Context) {
 	buckets, err := minioClient.ListBuckets(context.Background())
 	if err != nil {
 		logger.Error(ctx, "Failed to list MinIO buckets", "error", err)
-		c.JSON(500, gin.H{
-			"code":    500,
-			"msg":     "Failed to list buckets",
-			"success": false,
-		})
+		c.Error(apperrors.NewInternalServerError("Failed to list buckets"))
 		return
 	}
 
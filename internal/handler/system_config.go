@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+)
+
+// requireSystemAdmin checks the request comes from a user who can access all tenants, the config is shared by them
+func (h *SystemHandler) requireSystemAdmin(c *gin.Context) bool {
+	user, ok := c.Request.Context().Value(types.UserContextKey).(*types.User)
+	crossTenant := h.cfg.CrossTenantAccessEnabled()
+	if !ok || user == nil || !crossTenant || !user.CanAccessAllTenants {
+		logger.Warnf(c.Request.Context(), "Config access denied for request without cross-tenant access")
+		c.Error(errors.NewForbiddenError("Insufficient permissions to access the system configuration"))
+		return false
+	}
+	return true
+}
+
+// GetEffectiveConfig godoc
+// @Summary      获取生效配置
+// @Description  返回当前生效的配置（密码、密钥、令牌等已脱敏）以及配置热加载状态，仅可访问所有租户的管理员可用
+// @Tags         系统
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "生效配置与热加载状态"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /system/config [get]
+func (h *SystemHandler) GetEffectiveConfig(c *gin.Context) {
+	if !h.requireSystemAdmin(c) {
+		return
+	}
+	values, err := h.cfg.Current().Redacted()
+	if err != nil {
+		logger.ErrorWithFields(c.Request.Context(), err, nil)
+		c.Error(errors.NewInternalServerError("Failed to render the configuration").WithDetails(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"config": values,
+			"reload": h.configWatcher.Status(),
+		},
+	})
+}
+
+// ReloadConfig godoc
+// @Summary      重新加载配置
+// @Description  立即重新读取配置文件并应用可热加载的配置段，返回已应用的配置段和需要重启才能生效的配置段。配置文件解析失败时保持当前配置不变
+// @Tags         系统
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "热加载结果"
+// @Failure      400  {object}  errors.AppError         "配置文件无效"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /system/config/reload [post]
+func (h *SystemHandler) ReloadConfig(c *gin.Context) {
+	if !h.requireSystemAdmin(c) {
+		return
+	}
+	status, err := h.configWatcher.Reload(c.Request.Context())
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Failed to reload the configuration").WithDetails(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
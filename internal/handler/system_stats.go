@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/slowlog"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultTopErrorsLimit is how many errors are returned when no limit is given
+	defaultTopErrorsLimit = 20
+	// maxTopErrorsLimit bounds how many errors are returned
+	maxTopErrorsLimit = 100
+	// defaultSlowOpsLimit is how many slow operations are returned when no limit is given
+	defaultSlowOpsLimit = 50
+	// maxSlowOpsLimit bounds how many slow operations are returned
+	maxSlowOpsLimit = 200
+)
+
+// SystemStatsHandler serves the statistics of every tenant for operations dashboards, available to users who can
+// access all tenants
+type SystemStatsHandler struct {
+	service interfaces.SystemStatsService
+	cfg     *config.Config
+}
+
+// NewSystemStatsHandler creates a new system statistics handler
+func NewSystemStatsHandler(service interfaces.SystemStatsService, cfg *config.Config) *SystemStatsHandler {
+	return &SystemStatsHandler{service: service, cfg: cfg}
+}
+
+// requireCrossTenantAccess checks the request comes from a user who can access all tenants
+func (h *SystemStatsHandler) requireCrossTenantAccess(c *gin.Context) bool {
+	user, ok := c.Request.Context().Value(types.UserContextKey).(*types.User)
+	crossTenant := h.cfg.CrossTenantAccessEnabled()
+	if !ok || user == nil || !crossTenant || !user.CanAccessAllTenants {
+		logger.Warnf(c.Request.Context(), "System statistics access denied for request without cross-tenant access")
+		c.Error(apperrors.NewForbiddenError("Insufficient permissions to view system statistics"))
+		return false
+	}
+	return true
+}
+
+// bindStatsQuery reads the window query parameter into the period of the statistics, ending now
+func (h *SystemStatsHandler) bindStatsQuery(c *gin.Context) (*types.SystemStatsQuery, bool) {
+	window, err := types.ParseSystemStatsWindow(c.Query("window"))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return nil, false
+	}
+	now := time.Now()
+	return &types.SystemStatsQuery{From: now.Add(-window), To: now}, true
+}
+
+// bindTenantAndLimit reads the tenant_id filter and the limit query parameters, the limit being capped at maxLimit
+func (h *SystemStatsHandler) bindTenantAndLimit(c *gin.Context, defaultLimit, maxLimit int) (uint64, int, bool) {
+	var tenantID uint64
+	if v := c.Query("tenant_id"); v != "" {
+		parsed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewBadRequestError("Invalid tenant_id"))
+			return 0, 0, false
+		}
+		tenantID = parsed
+	}
+	limit := defaultLimit
+	if v := c.Query("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			c.Error(apperrors.NewBadRequestError("Invalid limit"))
+			return 0, 0, false
+		}
+		limit = min(parsed, maxLimit)
+	}
+	return tenantID, limit, true
+}
+
+// GetOverview godoc
+// @Summary      获取系统统计概览
+// @Description  汇总所有租户的文档数、存储用量，以及时间窗口内的 Token 用量、活跃会话、解析失败率和失败任务数。仅可访问所有租户的管理员可用
+// @Tags         系统统计
+// @Produce      json
+// @Param        window  query     string  false  "时间窗口，如 1h、24h、7d、30d，最长 90d，默认 24h"
+// @Success      200     {object}  map[string]interface{}  "统计概览"
+// @Failure      400     {object}  errors.AppError         "请求参数错误"
+// @Failure      403     {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /system/stats/overview [get]
+func (h *SystemStatsHandler) GetOverview(c *gin.Context) {
+	if !h.requireCrossTenantAccess(c) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	query, ok := h.bindStatsQuery(c)
+	if !ok {
+		return
+	}
+	overview, err := h.service.GetOverview(ctx, query)
+	if err != nil {
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    overview,
+	})
+}
+
+// ListTenantStats godoc
+// @Summary      获取租户统计列表
+// @Description  分页列出每个租户的文档数、存储用量，以及时间窗口内的 Token 用量、活跃会话和解析失败率，按指定指标倒序排列
+// @Tags         系统统计
+// @Produce      json
+// @Param        window     query     string  false  "时间窗口，如 1h、24h、7d、30d，最长 90d，默认 24h"
+// @Param        sort       query     string  false  "排序指标：documents、storage、tokens、active_sessions 或 parse_failed，默认 documents"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "租户统计列表"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      403        {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /system/stats/tenants [get]
+func (h *SystemStatsHandler) ListTenantStats(c *gin.Context) {
+	if !h.requireCrossTenantAccess(c) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	query, ok := h.bindStatsQuery(c)
+	if !ok {
+		return
+	}
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.Error(ctx, "Failed to parse pagination parameters", err)
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	sort := types.TenantStatsSort(c.DefaultQuery("sort", string(types.TenantStatsSortDocuments)))
+	if !sort.IsValid() {
+		c.Error(apperrors.NewBadRequestError(
+			"sort must be documents, storage, tokens, active_sessions or parse_failed"))
+		return
+	}
+
+	result, err := h.service.ListTenantStats(ctx, query, sort, &pagination)
+	if err != nil {
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      result.Data,
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
+	})
+}
+
+// ListTopErrors godoc
+// @Summary      获取高频错误
+// @Description  列出时间窗口内失败或重试中的后台任务最常见的错误，按任务类型和归一化后的错误信息（去除重试次数与记录 ID）聚合
+// @Tags         系统统计
+// @Produce      json
+// @Param        window     query     string  false  "时间窗口，如 1h、24h、7d、30d，最长 90d，默认 24h"
+// @Param        tenant_id  query     int     false  "租户ID筛选"
+// @Param        limit      query     int     false  "返回数量，默认 20，最大 100"
+// @Success      200        {object}  map[string]interface{}  "错误列表"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      403        {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /system/stats/errors [get]
+func (h *SystemStatsHandler) ListTopErrors(c *gin.Context) {
+	if !h.requireCrossTenantAccess(c) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	query, ok := h.bindStatsQuery(c)
+	if !ok {
+		return
+	}
+	tenantID, limit, ok := h.bindTenantAndLimit(c, defaultTopErrorsLimit, maxTopErrorsLimit)
+	if !ok {
+		return
+	}
+
+	topErrors, err := h.service.ListTopErrors(ctx, query, tenantID, limit)
+	if err != nil {
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    topErrors,
+	})
+}
+
+// ListSlowOps godoc
+// @Summary      获取最近的慢操作
+// @Description  列出本实例最近耗时超过阈值的数据库查询、向量检索、模型调用、DocReader 解析与浏览器抓取，最新的在前。记录保存在内存中，各实例分别保留
+// @Tags         系统统计
+// @Produce      json
+// @Param        kind       query     string  false  "操作类型：db、vector_search、model、docreader 或 browser"
+// @Param        tenant_id  query     int     false  "租户ID筛选"
+// @Param        limit      query     int     false  "返回数量，默认 50，最大 200"
+// @Success      200        {object}  map[string]interface{}  "慢操作列表"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      403        {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /system/stats/slow-ops [get]
+func (h *SystemStatsHandler) ListSlowOps(c *gin.Context) {
+	if !h.requireCrossTenantAccess(c) {
+		return
+	}
+
+	kind := slowlog.Kind(c.Query("kind"))
+	switch kind {
+	case "", slowlog.KindDB, slowlog.KindVectorSearch, slowlog.KindModel, slowlog.KindDocReader, slowlog.KindBrowser:
+	default:
+		c.Error(apperrors.NewBadRequestError("kind must be db, vector_search, model, docreader or browser"))
+		return
+	}
+	tenantID, limit, ok := h.bindTenantAndLimit(c, defaultSlowOpsLimit, maxSlowOpsLimit)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    slowlog.Recent(slowlog.Filter{Kind: kind, TenantID: tenantID, Limit: limit}),
+	})
+}
@@ -16,12 +16,12 @@ import (
 
 // TagHandler handles knowledge base tag operations.
 type TagHandler struct {
-	tagService        interfaces.KnowledgeTagService
-	tagRepo           interfaces.KnowledgeTagRepository
-	chunkRepo         interfaces.ChunkRepository
-	kbService         interfaces.KnowledgeBaseService
-	kbShareService    interfaces.KBShareService
-	agentShareService interfaces.AgentShareService
+	tagService          interfaces.KnowledgeTagService
+	tagRepo             interfaces.KnowledgeTagRepository
+	chunkRepo           interfaces.ChunkRepository
+	kbService           interfaces.KnowledgeBaseService
+	agentShareService   interfaces.AgentShareService
+	kbPermissionService interfaces.KBPermissionService
 }
 
 // DeleteTagRequest represents the request body for deleting a tag
@@ -35,20 +35,20 @@ func NewTagHandler(
 	tagRepo interfaces.KnowledgeTagRepository,
 	chunkRepo interfaces.ChunkRepository,
 	kbService interfaces.KnowledgeBaseService,
-	kbShareService interfaces.KBShareService,
 	agentShareService interfaces.AgentShareService,
+	kbPermissionService interfaces.KBPermissionService,
 ) *TagHandler {
-	return &TagHandler{tagService: tagService, tagRepo: tagRepo, chunkRepo: chunkRepo, kbService: kbService, kbShareService: kbShareService, agentShareService: agentShareService}
+	return &TagHandler{tagService: tagService, tagRepo: tagRepo, chunkRepo: chunkRepo, kbService: kbService, agentShareService: agentShareService, kbPermissionService: kbPermissionService}
 }
 
-// effectiveCtxForKB validates KB access (owner or shared) and returns context with effectiveTenantID for downstream service calls.
-func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string) (context.Context, error) {
+// effectiveCtxForKB validates the role of the caller on the KB (granted, owner, shared, or via shared agent when requiredRole is Viewer) and returns context with effectiveTenantID for downstream service calls.
+func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string, requiredRole types.KBRole) (context.Context, error) {
 	ctx := c.Request.Context()
 	tenantID := c.GetUint64(types.TenantIDContextKey.String())
 	if tenantID == 0 {
 		return nil, errors.NewUnauthorizedError("Unauthorized")
 	}
-	userID, userExists := c.Get(types.UserIDContextKey.String())
+	userID := c.GetString(types.UserIDContextKey.String())
 	kbID = secutils.SanitizeForLog(kbID)
 	if kbID == "" {
 		return nil, errors.NewBadRequestError("Knowledge base ID cannot be empty")
@@ -58,24 +58,22 @@ func (h *TagHandler) effectiveCtxForKB(c *gin.Context, kbID string) (context.Con
 		logger.ErrorWithFields(ctx, err, nil)
 		return nil, errors.NewInternalServerError(err.Error())
 	}
-	if kb.TenantID == tenantID {
-		return context.WithValue(ctx, types.TenantIDContextKey, tenantID), nil
+	role, effectiveTenantID, err := h.kbPermissionService.ResolveKBRole(ctx, kb, tenantID, userID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		return nil, errors.NewInternalServerError(err.Error())
 	}
-	if userExists && h.kbShareService != nil {
-		permission, isShared, permErr := h.kbShareService.CheckUserKBPermission(ctx, kbID, userID.(string))
-		if permErr == nil && isShared {
-			sourceTenantID, srcErr := h.kbShareService.GetKBSourceTenant(ctx, kbID)
-			if srcErr == nil {
-				logger.Infof(ctx, "User %s accessing shared KB %s with permission %s, source tenant: %d",
-					userID.(string), kbID, permission, sourceTenantID)
-				return context.WithValue(ctx, types.TenantIDContextKey, sourceTenantID), nil
-			}
+	if role.HasPermission(requiredRole) {
+		if kb.TenantID != tenantID {
+			logger.Infof(ctx, "User %s accessing shared KB %s with role %s, source tenant: %d",
+				userID, kbID, role, effectiveTenantID)
 		}
+		return context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID), nil
 	}
-	if userExists && h.agentShareService != nil {
-		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID.(string), tenantID, kb)
+	if role == "" && requiredRole == types.KBRoleViewer && userID != "" && h.agentShareService != nil {
+		can, err := h.agentShareService.UserCanAccessKBViaSomeSharedAgent(ctx, userID, tenantID, kb)
 		if err == nil && can {
-			logger.Infof(ctx, "User %s accessing KB %s via some shared agent", userID.(string), kbID)
+			logger.Infof(ctx, "User %s accessing KB %s via some shared agent", userID, kbID)
 			return context.WithValue(ctx, types.TenantIDContextKey, kb.TenantID), nil
 		}
 	}
@@ -128,7 +126,7 @@ func (h *TagHandler) ListTags(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
 
-	effCtx, err := h.effectiveCtxForKB(c, kbID)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
@@ -157,6 +155,7 @@ func (h *TagHandler) ListTags(c *gin.Context) {
 }
 
 type createTagRequest struct {
+	ParentID  string `json:"parent_id"`
 	Name      string `json:"name"       binding:"required"`
 	Color     string `json:"color"`
 	SortOrder int    `json:"sort_order"`
@@ -169,7 +168,7 @@ type createTagRequest struct {
 // @Accept       json
 // @Produce      json
 // @Param        id       path      string  true  "知识库ID"
-// @Param        request  body      object{name=string,color=string,sort_order=int}  true  "标签信息"
+// @Param        request  body      object{parent_id=string,name=string,color=string,sort_order=int}  true  "标签信息"
 // @Success      200      {object}  map[string]interface{}  "创建的标签"
 // @Failure      400      {object}  errors.AppError         "请求参数错误"
 // @Security     Bearer
@@ -179,7 +178,7 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
 
-	effCtx, err := h.effectiveCtxForKB(c, kbID)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -192,7 +191,7 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 		return
 	}
 
-	tag, err := h.tagService.CreateTag(effCtx, kbID,
+	tag, err := h.tagService.CreateTag(effCtx, kbID, secutils.SanitizeForLog(req.ParentID),
 		secutils.SanitizeForLog(req.Name), secutils.SanitizeForLog(req.Color), req.SortOrder)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
@@ -232,7 +231,7 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
 
-	effCtx, err := h.effectiveCtxForKB(c, kbID)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -266,6 +265,99 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 	})
 }
 
+// GetTagTree godoc
+// @Summary      获取标签树
+// @Description  获取知识库下的全部标签（树形结构）及统计信息
+// @Tags         标签管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "标签树"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/tags/tree [get]
+func (h *TagHandler) GetTagTree(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tree, err := h.tagService.GetTagTree(effCtx, kbID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"kb_id": kbID,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tree,
+	})
+}
+
+type moveTagRequest struct {
+	ParentID string `json:"parent_id"`
+}
+
+// MoveTag godoc
+// @Summary      移动标签
+// @Description  将标签（及其子标签）移动到新的父标签下，parent_id 为空时移动到根级
+// @Tags         标签管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "知识库ID"
+// @Param        tag_id   path      string  true  "标签ID (UUID或seq_id)"
+// @Param        request  body      object{parent_id=string}  true  "目标父标签"
+// @Success      200      {object}  map[string]interface{}  "移动后的标签"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/tags/{tag_id}/move [put]
+func (h *TagHandler) MoveTag(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tagID, err := h.resolveTagIDWithCtx(c, effCtx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req moveTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind move tag payload", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	tag, err := h.tagService.MoveTag(effCtx, tagID, secutils.SanitizeForLog(req.ParentID))
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"tag_id": tagID,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tag,
+	})
+}
+
 // DeleteTag godoc
 // @Summary      删除标签
 // @Description  删除标签，可使用force=true强制删除被引用的标签，content_only=true仅删除标签下的内容而保留标签本身
@@ -286,7 +378,7 @@ func (h *TagHandler) DeleteTag(c *gin.Context) {
 	ctx := c.Request.Context()
 	kbID := secutils.SanitizeForLog(c.Param("id"))
 
-	effCtx, err := h.effectiveCtxForKB(c, kbID)
+	effCtx, err := h.effectiveCtxForKB(c, kbID, types.KBRoleEditor)
 	if err != nil {
 		c.Error(err)
 		return
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +11,7 @@ import (
 	agenttools "github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -232,7 +234,7 @@ func (h *TenantHandler) DeleteTenant(c *gin.Context) {
 	logger.Infof(ctx, "Tenant deleted successfully, ID: %d", id)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Tenant deleted successfully",
+		"message": i18n.T(ctx, i18n.MsgTenantDeleted),
 	})
 }
 
@@ -292,7 +294,7 @@ func (h *TenantHandler) ListAllTenants(c *gin.Context) {
 	}
 
 	// Check if cross-tenant access is enabled
-	if h.config == nil || h.config.Tenant == nil || !h.config.Tenant.EnableCrossTenantAccess {
+	if !h.config.CrossTenantAccessEnabled() {
 		logger.Warnf(ctx, "Cross-tenant access is disabled, user: %s", user.ID)
 		c.Error(errors.NewForbiddenError("Cross-tenant access is disabled"))
 		return
@@ -353,7 +355,7 @@ func (h *TenantHandler) SearchTenants(c *gin.Context) {
 	}
 
 	// Check if cross-tenant access is enabled
-	if h.config == nil || h.config.Tenant == nil || !h.config.Tenant.EnableCrossTenantAccess {
+	if !h.config.CrossTenantAccessEnabled() {
 		logger.Warnf(ctx, "Cross-tenant access is disabled, user: %s", user.ID)
 		c.Error(errors.NewForbiddenError("Cross-tenant access is disabled"))
 		return
@@ -566,7 +568,7 @@ func (h *TenantHandler) updateTenantAgentConfigInternal(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    agentConfig,
-		"message": "Agent configuration updated successfully",
+		"message": i18n.T(ctx, i18n.MsgAgentConfigUpdated),
 	})
 }
 
@@ -599,6 +601,21 @@ func (h *TenantHandler) GetTenantKV(c *gin.Context) {
 	case "prompt-templates":
 		h.GetPromptTemplates(c)
 		return
+	case "agent-tools":
+		h.GetTenantAgentToolPolicy(c)
+		return
+	case "moderation-config":
+		h.GetTenantModerationConfig(c)
+		return
+	case "local-providers":
+		h.GetTenantLocalProviderConfig(c)
+		return
+	case "ip-policy":
+		h.GetTenantIPPolicy(c)
+		return
+	case "locale":
+		h.GetTenantLocale(c)
+		return
 	default:
 		logger.Info(ctx, "KV key not supported", "key", key)
 		c.Error(errors.NewBadRequestError("unsupported key"))
@@ -633,6 +650,21 @@ func (h *TenantHandler) UpdateTenantKV(c *gin.Context) {
 	case "conversation-config":
 		h.updateTenantConversationInternal(c)
 		return
+	case "agent-tools":
+		h.updateTenantAgentToolPolicyInternal(c)
+		return
+	case "moderation-config":
+		h.updateTenantModerationConfigInternal(c)
+		return
+	case "local-providers":
+		h.updateTenantLocalProviderConfigInternal(c)
+		return
+	case "ip-policy":
+		h.updateTenantIPPolicyInternal(c)
+		return
+	case "locale":
+		h.updateTenantLocaleInternal(c)
+		return
 	default:
 		logger.Info(ctx, "KV key not supported", "key", key)
 		c.Error(errors.NewBadRequestError("unsupported key"))
@@ -680,7 +712,7 @@ func (h *TenantHandler) updateTenantWebSearchConfigInternal(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    updatedTenant.WebSearchConfig,
-		"message": "Web search configuration updated successfully",
+		"message": i18n.T(ctx, i18n.MsgWebSearchConfigUpdated),
 	})
 }
 
@@ -713,25 +745,411 @@ func (h *TenantHandler) GetTenantWebSearchConfig(c *gin.Context) {
 	})
 }
 
+// GetTenantAgentToolPolicy godoc
+// @Summary      获取租户智能体工具策略
+// @Description  获取租户可禁用的智能体工具及已禁用的工具，禁用的工具对租户下所有智能体生效
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "工具策略"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/kv/agent-tools [get]
+func (h *TenantHandler) GetTenantAgentToolPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	disabledTools := []string{}
+	if tenant.AgentToolPolicy != nil && tenant.AgentToolPolicy.DisabledTools != nil {
+		disabledTools = tenant.AgentToolPolicy.DisabledTools
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"disabled_tools":  disabledTools,
+			"available_tools": agenttools.TenantConfigurableTools(),
+		},
+	})
+}
+
+// updateTenantAgentToolPolicyInternal updates the agent tools disabled for the tenant
+func (h *TenantHandler) updateTenantAgentToolPolicyInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var policy types.AgentToolPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+
+	known := make(map[string]bool)
+	for _, t := range agenttools.TenantConfigurableTools() {
+		known[t.Name] = true
+	}
+	disabledTools := make([]string, 0, len(policy.DisabledTools))
+	for _, name := range policy.DisabledTools {
+		if !known[name] {
+			c.Error(errors.NewBadRequestError("unknown tool: " + secutils.SanitizeForLog(name)))
+			return
+		}
+		if !slices.Contains(disabledTools, name) {
+			disabledTools = append(disabledTools, name)
+		}
+	}
+	policy.DisabledTools = disabledTools
+
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.AgentToolPolicy = &policy
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			logger.Error(ctx, "Failed to update tenant: application error", appErr)
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update tenant agent tool policy").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Tenant agent tool policy updated, Tenant ID: %d, disabled: %v", tenant.ID, disabledTools)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.AgentToolPolicy,
+		"message": i18n.T(ctx, i18n.MsgAgentToolPolicyUpdated),
+	})
+}
+
+// GetTenantModerationConfig godoc
+// @Summary      获取租户内容审核配置
+// @Description  获取租户对用户问题和模型回答的内容审核规则
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "内容审核配置"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/kv/moderation-config [get]
+func (h *TenantHandler) GetTenantModerationConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	cfg := tenant.ModerationConfig
+	if cfg == nil {
+		cfg = &types.ModerationConfig{Rules: []types.ModerationRule{}}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cfg,
+	})
+}
+
+// updateTenantModerationConfigInternal updates the content moderation rules of the tenant
+func (h *TenantHandler) updateTenantModerationConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.ModerationConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		c.Error(errors.NewValidationError("Invalid moderation config").WithDetails(err.Error()))
+		return
+	}
+
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.ModerationConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			logger.Error(ctx, "Failed to update tenant: application error", appErr)
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update tenant moderation config").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Tenant moderation config updated, Tenant ID: %d, enabled: %v, rules: %d",
+		tenant.ID, cfg.Enabled, len(cfg.Rules))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.ModerationConfig,
+		"message": i18n.T(ctx, i18n.MsgModerationConfigUpdated),
+	})
+}
+
+// GetTenantLocalProviderConfig godoc
+// @Summary      获取租户本地模型服务配置
+// @Description  获取租户配置的 Ollama 和 vLLM 服务地址
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "本地模型服务配置"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/kv/local-providers [get]
+func (h *TenantHandler) GetTenantLocalProviderConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	cfg := tenant.LocalProviderConfig
+	if cfg == nil {
+		cfg = &types.LocalProviderConfig{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cfg,
+	})
+}
+
+// updateTenantLocalProviderConfigInternal updates the self-hosted model servers of the tenant
+func (h *TenantHandler) updateTenantLocalProviderConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.LocalProviderConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		c.Error(errors.NewValidationError("Invalid local provider config").WithDetails(err.Error()))
+		return
+	}
+
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.LocalProviderConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			logger.Error(ctx, "Failed to update tenant: application error", appErr)
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update tenant local provider config").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Tenant local provider config updated, Tenant ID: %d", tenant.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.LocalProviderConfig,
+		"message": i18n.T(ctx, i18n.MsgLocalProviderConfigUpdated),
+	})
+}
+
+// GetTenantIPPolicy godoc
+// @Summary      获取租户IP访问策略
+// @Description  获取租户 API 和内部服务回调的 IP 白名单与黑名单
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "IP访问策略"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/kv/ip-policy [get]
+func (h *TenantHandler) GetTenantIPPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	policy := tenant.IPPolicy
+	if policy == nil {
+		policy = &types.TenantIPPolicy{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// updateTenantIPPolicyInternal updates the IP allow and deny lists of the tenant
+func (h *TenantHandler) updateTenantIPPolicyInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var policy types.TenantIPPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		c.Error(errors.NewValidationError("Invalid IP policy").WithDetails(err.Error()))
+		return
+	}
+	// Refuse policies that would lock the caller out, it could not fix them afterwards
+	if !policy.AllowsAPI(c.ClientIP()) {
+		c.Error(errors.NewBadRequestError("The IP policy would block your current address " + c.ClientIP()))
+		return
+	}
+
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.IPPolicy = &policy
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			logger.Error(ctx, "Failed to update tenant: application error", appErr)
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update tenant IP policy").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Tenant IP policy updated, Tenant ID: %d, enabled: %v", tenant.ID, policy.Enabled)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.IPPolicy,
+		"message": i18n.T(ctx, i18n.MsgIPPolicyUpdated),
+	})
+}
+
+// GetTenantLocale godoc
+// @Summary      获取租户语言设置
+// @Description  获取请求未通过 Accept-Language 指定语言时，API 消息使用的语言，以及支持的语言列表
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "语言设置"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/kv/locale [get]
+func (h *TenantHandler) GetTenantLocale(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"locale":    tenant.Locale,
+			"languages": i18n.Languages,
+		},
+	})
+}
+
+// updateTenantLocaleInternal updates the language of API messages of the tenant
+func (h *TenantHandler) updateTenantLocaleInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req struct {
+		Locale string `json:"locale" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+
+	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.Locale = req.Locale
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			logger.Error(ctx, "Failed to update tenant: application error", appErr)
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update tenant locale").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Tenant locale updated, Tenant ID: %d, locale: %s", tenant.ID, updatedTenant.Locale)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"locale": updatedTenant.Locale},
+		"message": i18n.T(ctx, i18n.MsgLocaleUpdated),
+	})
+}
+
 func (h *TenantHandler) buildDefaultConversationConfig() *types.ConversationConfig {
+	conversation := h.config.Current().Conversation
 	return &types.ConversationConfig{
-		Prompt:               h.config.Conversation.Summary.Prompt,
-		ContextTemplate:      h.config.Conversation.Summary.ContextTemplate,
-		Temperature:          h.config.Conversation.Summary.Temperature,
-		MaxCompletionTokens:  h.config.Conversation.Summary.MaxCompletionTokens,
-		MaxRounds:            h.config.Conversation.MaxRounds,
-		EmbeddingTopK:        h.config.Conversation.EmbeddingTopK,
-		KeywordThreshold:     h.config.Conversation.KeywordThreshold,
-		VectorThreshold:      h.config.Conversation.VectorThreshold,
-		RerankTopK:           h.config.Conversation.RerankTopK,
-		RerankThreshold:      h.config.Conversation.RerankThreshold,
-		EnableRewrite:        h.config.Conversation.EnableRewrite,
-		EnableQueryExpansion: h.config.Conversation.EnableQueryExpansion,
-		FallbackStrategy:     h.config.Conversation.FallbackStrategy,
-		FallbackResponse:     h.config.Conversation.FallbackResponse,
-		FallbackPrompt:       h.config.Conversation.FallbackPrompt,
-		RewritePromptUser:    h.config.Conversation.RewritePromptUser,
-		RewritePromptSystem:  h.config.Conversation.RewritePromptSystem,
+		Prompt:               conversation.Summary.Prompt,
+		ContextTemplate:      conversation.Summary.ContextTemplate,
+		Temperature:          conversation.Summary.Temperature,
+		MaxCompletionTokens:  conversation.Summary.MaxCompletionTokens,
+		MaxRounds:            conversation.MaxRounds,
+		EmbeddingTopK:        conversation.EmbeddingTopK,
+		KeywordThreshold:     conversation.KeywordThreshold,
+		VectorThreshold:      conversation.VectorThreshold,
+		RerankTopK:           conversation.RerankTopK,
+		RerankThreshold:      conversation.RerankThreshold,
+		EnableRewrite:        conversation.EnableRewrite,
+		EnableQueryExpansion: conversation.EnableQueryExpansion,
+		EnableLLMExpansion:   conversation.EnableLLMExpansion,
+		EnableHyDE:           conversation.EnableHyDE,
+		FallbackStrategy:     conversation.FallbackStrategy,
+		FallbackResponse:     conversation.FallbackResponse,
+		FallbackPrompt:       conversation.FallbackPrompt,
+		RewritePromptUser:    conversation.RewritePromptUser,
+		RewritePromptSystem:  conversation.RewritePromptSystem,
 	}
 }
 
@@ -844,7 +1262,7 @@ func (h *TenantHandler) updateTenantConversationInternal(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    updatedTenant.ConversationConfig,
-		"message": "Conversation configuration updated successfully",
+		"message": i18n.T(ctx, i18n.MsgConversationConfigUpdated),
 	})
 }
 
@@ -861,7 +1279,7 @@ func (h *TenantHandler) updateTenantConversationInternal(c *gin.Context) {
 // @Router       /tenants/kv/prompt-templates [get]
 func (h *TenantHandler) GetPromptTemplates(c *gin.Context) {
 	// Return prompt templates from config.yaml
-	templates := h.config.PromptTemplates
+	templates := h.config.Current().PromptTemplates
 	if templates == nil {
 		templates = &config.PromptTemplatesConfig{}
 	}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantBackupHandler handles the backups of tenant data, available to users who can access all tenants
+type TenantBackupHandler struct {
+	service interfaces.TenantBackupService
+	cfg     *config.Config
+}
+
+// NewTenantBackupHandler creates a new tenant backup handler
+func NewTenantBackupHandler(service interfaces.TenantBackupService, cfg *config.Config) *TenantBackupHandler {
+	return &TenantBackupHandler{service: service, cfg: cfg}
+}
+
+// requireCrossTenantAccess checks the request comes from a user who can access all tenants, backups hold every
+// row of a tenant including credentials
+func (h *TenantBackupHandler) requireCrossTenantAccess(c *gin.Context) bool {
+	user, ok := c.Request.Context().Value(types.UserContextKey).(*types.User)
+	crossTenant := h.cfg.CrossTenantAccessEnabled()
+	if !ok || user == nil || !crossTenant || !user.CanAccessAllTenants {
+		logger.Warnf(c.Request.Context(), "Backup access denied for request without cross-tenant access")
+		c.Error(apperrors.NewForbiddenError("Insufficient permissions to manage tenant backups"))
+		return false
+	}
+	return true
+}
+
+// CreateBackup godoc
+// @Summary      创建租户备份
+// @Description  在后台任务中将租户的数据库记录、原始文件与向量打包写入对象存储，返回待执行的备份记录。仅可访问所有租户的管理员可用
+// @Tags         租户备份
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.TenantBackupRequest  true  "备份请求"
+// @Success      200      {object}  map[string]interface{}     "备份记录"
+// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Failure      403      {object}  errors.AppError            "权限不足"
+// @Failure      404      {object}  errors.AppError            "租户不存在"
+// @Security     Bearer
+// @Router       /system/backups [post]
+func (h *TenantBackupHandler) CreateBackup(c *gin.Context) {
+	if !h.requireCrossTenantAccess(c) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var req types.TenantBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse backup request", err)
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	backup, err := h.service.CreateBackup(ctx, req.TenantID)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    backup,
+	})
+}
+
+// ListBackups godoc
+// @Summary      获取租户备份列表
+// @Description  分页列出租户备份，按创建时间倒序；不指定租户时列出所有租户的备份
+// @Tags         租户备份
+// @Produce      json
+// @Param        tenant_id  query     int  false  "租户ID筛选"
+// @Param        page       query     int  false  "页码"
+// @Param        page_size  query     int  false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "备份列表"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      403        {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Router       /system/backups [get]
+func (h *TenantBackupHandler) ListBackups(c *gin.Context) {
+	if !h.requireCrossTenantAccess(c) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.Error(ctx, "Failed to parse pagination parameters", err)
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+	var tenantID uint64
+	if v := c.Query("tenant_id"); v != "" {
+		parsed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.Error(apperrors.NewBadRequestError("Invalid tenant_id"))
+			return
+		}
+		tenantID = parsed
+	}
+
+	result, err := h.service.ListBackups(ctx, tenantID, &pagination)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      result.Data,
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
+	})
+}
+
+// GetBackup godoc
+// @Summary      获取租户备份详情
+// @Description  获取一个租户备份的状态、大小、校验和与记录数
+// @Tags         租户备份
+// @Produce      json
+// @Param        id   path      string  true  "备份ID"
+// @Success      200  {object}  map[string]interface{}  "备份记录"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Failure      404  {object}  errors.AppError         "备份不存在"
+// @Security     Bearer
+// @Router       /system/backups/{id} [get]
+func (h *TenantBackupHandler) GetBackup(c *gin.Context) {
+	if !h.requireCrossTenantAccess(c) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	backup, err := h.service.GetBackup(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    backup,
+	})
+}
+
+// DownloadBackup godoc
+// @Summary      下载租户备份
+// @Description  下载已完成的备份压缩包，响应头 X-Checksum-SHA256 为压缩包的 SHA-256 校验和，可传给恢复命令校验下载是否完整
+// @Tags         租户备份
+// @Produce      application/zip
+// @Param        id   path      string  true  "备份ID"
+// @Success      200  {file}    file             "备份压缩包"
+// @Failure      403  {object}  errors.AppError  "权限不足"
+// @Failure      404  {object}  errors.AppError  "备份不存在"
+// @Failure      409  {object}  errors.AppError  "备份尚未完成"
+// @Security     Bearer
+// @Router       /system/backups/{id}/download [get]
+func (h *TenantBackupHandler) DownloadBackup(c *gin.Context) {
+	if !h.requireCrossTenantAccess(c) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	backup, reader, err := h.service.OpenBackup(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition",
+		fmt.Sprintf("attachment; filename=tenant_%d_backup_%s.zip", backup.TenantID, backup.ID))
+	c.Header("Content-Length", strconv.FormatInt(backup.Size, 10))
+	c.Header("X-Checksum-SHA256", backup.SHA256)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		logger.Errorf(ctx, "Failed to stream backup %s: %v", backup.ID, err)
+	}
+}
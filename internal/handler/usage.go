@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// maxTokenUsageReportDays bounds the period of a token usage report
+const maxTokenUsageReportDays = 366
+
+// UsageHandler handles the token usage, budget and quota API of the current tenant
+type UsageHandler struct {
+	service      interfaces.TokenUsageService
+	quotaService interfaces.QuotaService
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(service interfaces.TokenUsageService, quotaService interfaces.QuotaService) *UsageHandler {
+	return &UsageHandler{service: service, quotaService: quotaService}
+}
+
+// TokenUsageReportQuery holds the query parameters of a token usage report request
+type TokenUsageReportQuery struct {
+	From    string `form:"from"`
+	To      string `form:"to"`
+	GroupBy string `form:"group_by"`
+}
+
+// GetTokenUsage godoc
+// @Summary      获取 Token 用量报表
+// @Description  按模型、知识库或日期汇总当前租户的 Token 用量和费用，并返回本月预算使用情况
+// @Tags         用量
+// @Produce      json
+// @Param        from      query     string  false  "开始日期（含），格式 YYYY-MM-DD，默认本月第一天"
+// @Param        to        query     string  false  "结束日期（含），格式 YYYY-MM-DD，默认今天"
+// @Param        group_by  query     string  false  "汇总维度：model、knowledge_base 或 day，默认 model"
+// @Success      200       {object}  map[string]interface{}  "用量报表"
+// @Failure      400       {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/tokens [get]
+func (h *UsageHandler) GetTokenUsage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query TokenUsageReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logger.Error(ctx, "Failed to parse query parameters", err)
+		c.Error(errors.NewBadRequestError("Invalid query parameters").WithDetails(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	from := types.MonthStart(now)
+	to := now
+	if query.From != "" {
+		date, err := time.ParseInLocation(time.DateOnly, query.From, time.Local)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("from must be a date in YYYY-MM-DD format"))
+			return
+		}
+		from = date
+	}
+	if query.To != "" {
+		date, err := time.ParseInLocation(time.DateOnly, query.To, time.Local)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("to must be a date in YYYY-MM-DD format"))
+			return
+		}
+		// The end date is inclusive
+		to = date.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		c.Error(errors.NewBadRequestError("to must not be earlier than from"))
+		return
+	}
+	if to.Sub(from) > maxTokenUsageReportDays*24*time.Hour {
+		c.Error(errors.NewBadRequestError("The report period must not exceed 366 days"))
+		return
+	}
+
+	groupBy := types.TokenUsageGroupBy(query.GroupBy)
+	if groupBy == "" {
+		groupBy = types.TokenUsageGroupByModel
+	}
+	if !groupBy.IsValid() {
+		c.Error(errors.NewBadRequestError("group_by must be model, knowledge_base or day"))
+		return
+	}
+
+	report, err := h.service.GetUsageReport(ctx, &types.TokenUsageQuery{From: from, To: to, GroupBy: groupBy})
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetTokenBudget godoc
+// @Summary      获取 Token 预算
+// @Description  获取当前租户本月的 Token 用量、月度预算以及是否已超出预算
+// @Tags         用量
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "预算使用情况"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/budget [get]
+func (h *UsageHandler) GetTokenBudget(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	status, err := h.service.GetBudgetStatus(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// GetQuota godoc
+// @Summary      获取资源配额
+// @Description  获取当前租户的知识库数量、文档数量、并发解析任务、并发网页抓取和存储空间的用量与上限，limit 为 0 表示不限制
+// @Tags         用量
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "配额使用情况"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/quota [get]
+func (h *UsageHandler) GetQuota(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	status, err := h.quotaService.GetQuotaStatus(ctx)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
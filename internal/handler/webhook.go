@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"net/http"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles the webhooks of the current tenant
+type WebhookHandler struct {
+	service interfaces.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(service interfaces.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// ListWebhooks godoc
+// @Summary      获取 Webhook 列表
+// @Description  列出当前租户的 Webhook，不返回签名密钥
+// @Tags         Webhook
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "Webhook 列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	webhooks, err := h.service.ListWebhooks(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhooks,
+	})
+}
+
+// CreateWebhook godoc
+// @Summary      创建 Webhook
+// @Description  注册接收事件的 HTTP 端点，事件以 HMAC-SHA256 签名的 POST 请求投递，失败后按指数退避重试。签名密钥只在创建时返回一次
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.CreateWebhookRequest  true  "Webhook 配置"
+// @Success      201      {object}  map[string]interface{}      "创建的 Webhook 及签名密钥"
+// @Failure      400      {object}  errors.AppError             "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(ctx, &req)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    webhook,
+	})
+}
+
+// GetWebhook godoc
+// @Summary      获取 Webhook 详情
+// @Description  获取当前租户的一个 Webhook，不返回签名密钥
+// @Tags         Webhook
+// @Produce      json
+// @Param        id   path      string                  true  "Webhook ID"
+// @Success      200  {object}  map[string]interface{}  "Webhook"
+// @Failure      404  {object}  errors.AppError         "Webhook 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	webhook, err := h.service.GetWebhook(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhook,
+	})
+}
+
+// UpdateWebhook godoc
+// @Summary      更新 Webhook
+// @Description  修改 Webhook 的名称、地址、订阅的事件或启用状态
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "Webhook ID"
+// @Param        request  body      types.UpdateWebhookRequest  true  "更新内容"
+// @Success      200      {object}  map[string]interface{}      "更新后的 Webhook"
+// @Failure      400      {object}  errors.AppError             "请求参数错误"
+// @Failure      404      {object}  errors.AppError             "Webhook 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(ctx, secutils.SanitizeForLog(c.Param("id")), &req)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhook,
+	})
+}
+
+// RotateWebhookSecret godoc
+// @Summary      轮换 Webhook 签名密钥
+// @Description  生成新的签名密钥，之后的投递（包括重试）立即使用新密钥签名。新密钥只返回一次
+// @Tags         Webhook
+// @Produce      json
+// @Param        id   path      string                  true  "Webhook ID"
+// @Success      200  {object}  map[string]interface{}  "Webhook 及新签名密钥"
+// @Failure      404  {object}  errors.AppError         "Webhook 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	webhook, err := h.service.RotateWebhookSecret(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhook,
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary      删除 Webhook
+// @Description  删除 Webhook，尚未投递的事件不再发送
+// @Tags         Webhook
+// @Produce      json
+// @Param        id   path      string                  true  "Webhook ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "Webhook 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.service.DeleteWebhook(ctx, secutils.SanitizeForLog(c.Param("id"))); err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// TestWebhook godoc
+// @Summary      测试 Webhook
+// @Description  立即向 Webhook 发送一次 ping 事件（不重试），返回投递结果
+// @Tags         Webhook
+// @Produce      json
+// @Param        id   path      string                  true  "Webhook ID"
+// @Success      200  {object}  map[string]interface{}  "投递记录"
+// @Failure      404  {object}  errors.AppError         "Webhook 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id}/test [post]
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	delivery, err := h.service.TestWebhook(ctx, secutils.SanitizeForLog(c.Param("id")))
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delivery,
+	})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary      获取 Webhook 投递记录
+// @Description  分页列出 Webhook 的事件投递记录及最近一次尝试的结果，按创建时间倒序
+// @Tags         Webhook
+// @Produce      json
+// @Param        id         path      string  true   "Webhook ID"
+// @Param        page       query     int     false  "页码"
+// @Param        page_size  query     int     false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "投递记录列表"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      404        {object}  errors.AppError         "Webhook 不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var pagination types.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		logger.Error(ctx, "Failed to parse pagination parameters", err)
+		c.Error(apperrors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	result, err := h.service.ListDeliveries(ctx, secutils.SanitizeForLog(c.Param("id")), &pagination)
+	if err != nil {
+		c.Error(apiKeyError(ctx, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      result.Data,
+		"total":     result.Total,
+		"page":      result.Page,
+		"page_size": result.PageSize,
+	})
+}
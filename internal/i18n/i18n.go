@@ -0,0 +1,98 @@
+// Package i18n picks the language API responses are localized to and translates their messages.
+//
+// The language of a request falls back from the Accept-Language header to the locale of the tenant, then to the
+// default language. Messages fall back from the bundle of the language to the bundle of the default language, then
+// to their key.
+package i18n
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Language is a language responses can be localized to, as a BCP 47 tag
+type Language string
+
+const (
+	// Chinese is Simplified Chinese
+	Chinese Language = "zh-CN"
+	// English is English
+	English Language = "en-US"
+	// DefaultLanguage is used when a request asks for no supported language, messages are written in it
+	DefaultLanguage = Chinese
+)
+
+// Languages are the supported languages
+var Languages = []Language{Chinese, English}
+
+// Parse matches a language tag to a supported language by its primary subtag, so en-GB gives English
+func Parse(tag string) (Language, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	switch primary {
+	case "zh":
+		return Chinese, true
+	case "en":
+		return English, true
+	}
+	return "", false
+}
+
+// ParseAcceptLanguage returns the supported language a request prefers by its Accept-Language header, false when
+// it prefers none
+func ParseAcceptLanguage(header string) (Language, bool) {
+	type candidate struct {
+		lang    Language
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{lang: lang, quality: quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	// Stable sorting keeps the order of the header among languages of the same quality
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang, true
+}
+
+// WithLanguage returns a context carrying the language a request asked for
+func WithLanguage(ctx context.Context, lang Language) context.Context {
+	return context.WithValue(ctx, types.LanguageContextKey, lang)
+}
+
+// FromContext returns the language of the request of a context: the language the request asked for, else the
+// locale of its tenant, else the default language
+func FromContext(ctx context.Context) Language {
+	if lang, ok := ctx.Value(types.LanguageContextKey).(Language); ok && lang != "" {
+		return lang
+	}
+	if tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant); ok && tenant != nil {
+		if lang, ok := Parse(tenant.Locale); ok {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
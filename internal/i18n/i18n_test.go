@@ -0,0 +1,81 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	cases := map[string]Language{
+		"":                             "",
+		"en":                           English,
+		"en-GB,en;q=0.9":               English,
+		"zh-CN,zh;q=0.9,en;q=0.8":      Chinese,
+		"fr-FR,en;q=0.5,zh;q=0.4":      English,
+		"zh;q=0.3, en-US;q=0.7":        English,
+		"en;q=0, zh-TW":                Chinese,
+		"fr-FR,de":                     "",
+		"en;q=invalid,zh-Hans-CN;q=.5": Chinese,
+	}
+	for header, want := range cases {
+		got, ok := ParseAcceptLanguage(header)
+		if got != want || ok != (want != "") {
+			t.Errorf("ParseAcceptLanguage(%q) = %q, %v, want %q", header, got, ok, want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != DefaultLanguage {
+		t.Errorf("FromContext(empty) = %q, want %q", got, DefaultLanguage)
+	}
+
+	tenantCtx := context.WithValue(ctx, types.TenantInfoContextKey, &types.Tenant{Locale: "en-US"})
+	if got := FromContext(tenantCtx); got != English {
+		t.Errorf("FromContext(tenant locale) = %q, want %q", got, English)
+	}
+	if got := FromContext(WithLanguage(tenantCtx, Chinese)); got != Chinese {
+		t.Errorf("FromContext(requested language) = %q, want %q", got, Chinese)
+	}
+
+	unsupportedCtx := context.WithValue(ctx, types.TenantInfoContextKey, &types.Tenant{Locale: "fr-FR"})
+	if got := FromContext(unsupportedCtx); got != DefaultLanguage {
+		t.Errorf("FromContext(unsupported tenant locale) = %q, want %q", got, DefaultLanguage)
+	}
+}
+
+func TestBundlesAreComplete(t *testing.T) {
+	for key := range bundles[DefaultLanguage] {
+		for _, lang := range Languages {
+			if bundles[lang][key] == "" {
+				t.Errorf("message %q has no %s translation", key, lang)
+			}
+		}
+	}
+	for _, lang := range Languages {
+		for key := range bundles[lang] {
+			if _, ok := bundles[DefaultLanguage][key]; !ok {
+				t.Errorf("message %q of %s is missing in the default language", key, lang)
+			}
+		}
+	}
+}
+
+func TestMessageFallback(t *testing.T) {
+	if got := Message(English, MsgLoginSucceeded); got != "Login successful" {
+		t.Errorf("Message(English) = %q", got)
+	}
+	if got := Message(Language("fr-FR"), MsgLoginSucceeded); got != "登录成功" {
+		t.Errorf("Message(unsupported language) = %q, want the default language", got)
+	}
+	if got := Message(English, MessageKey("unknown.key")); got != "unknown.key" {
+		t.Errorf("Message(unknown key) = %q, want the key", got)
+	}
+	ctx := WithLanguage(context.Background(), English)
+	if got := T(ctx, MsgTenantDeleted); got != "Tenant deleted successfully" {
+		t.Errorf("T(English) = %q", got)
+	}
+}
@@ -0,0 +1,212 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+)
+
+// MessageKey identifies a user-facing success or warning message of the API
+type MessageKey string
+
+// Messages of successful responses
+const (
+	MsgLoginSucceeded         MessageKey = "auth.login_succeeded"
+	MsgLoginFailed            MessageKey = "auth.login_failed"
+	MsgInvalidCredentials     MessageKey = "auth.invalid_credentials"
+	MsgInvalidEmailOrPassword MessageKey = "auth.invalid_email_or_password"
+	MsgAccountDisabled        MessageKey = "auth.account_disabled"
+	MsgRegistered             MessageKey = "auth.registered"
+	MsgLoggedOut              MessageKey = "auth.logged_out"
+	MsgTokenRefreshed         MessageKey = "auth.token_refreshed"
+	MsgTokenValid             MessageKey = "auth.token_valid"
+	MsgPasswordChanged        MessageKey = "auth.password_changed"
+
+	MsgTenantDeleted              MessageKey = "tenant.deleted"
+	MsgAgentConfigUpdated         MessageKey = "tenant.agent_config_updated"
+	MsgWebSearchConfigUpdated     MessageKey = "tenant.web_search_config_updated"
+	MsgAgentToolPolicyUpdated     MessageKey = "tenant.agent_tool_policy_updated"
+	MsgModerationConfigUpdated    MessageKey = "tenant.moderation_config_updated"
+	MsgLocalProviderConfigUpdated MessageKey = "tenant.local_provider_config_updated"
+	MsgIPPolicyUpdated            MessageKey = "tenant.ip_policy_updated"
+	MsgConversationConfigUpdated  MessageKey = "tenant.conversation_config_updated"
+	MsgLocaleUpdated              MessageKey = "tenant.locale_updated"
+
+	MsgKnowledgeBaseDeleted       MessageKey = "knowledge_base.deleted"
+	MsgKnowledgeMovedToTrash      MessageKey = "knowledge.moved_to_trash"
+	MsgKnowledgeDeleted           MessageKey = "knowledge.deleted"
+	MsgKnowledgeChunkUpdated      MessageKey = "knowledge.chunk_updated"
+	MsgKnowledgeChunkImageUpdated MessageKey = "knowledge.chunk_image_updated"
+	MsgKnowledgeReparseSubmitted  MessageKey = "knowledge.reparse_submitted"
+	MsgChunkDeleted               MessageKey = "chunk.deleted"
+	MsgKnowledgeChunksDeleted     MessageKey = "chunk.all_deleted"
+	MsgGeneratedQuestionDeleted   MessageKey = "chunk.generated_question_deleted"
+
+	MsgSessionDeleted          MessageKey = "session.deleted"
+	MsgMessageDeleted          MessageKey = "message.deleted"
+	MsgMessageAlreadyCompleted MessageKey = "message.already_completed"
+	MsgGenerationStopped       MessageKey = "message.generation_stopped"
+	MsgAgentDeleted            MessageKey = "agent.deleted"
+	MsgMCPServiceDeleted       MessageKey = "mcp_service.deleted"
+	MsgModelDeleted            MessageKey = "model.deleted"
+
+	MsgOrganizationDeleted  MessageKey = "organization.deleted"
+	MsgOrganizationLeft     MessageKey = "organization.left"
+	MsgMemberAdded          MessageKey = "organization.member_added"
+	MsgMemberRoleUpdated    MessageKey = "organization.member_role_updated"
+	MsgMemberRemoved        MessageKey = "organization.member_removed"
+	MsgJoinRequestReviewed  MessageKey = "organization.join_request_reviewed"
+	MsgSharePermissionSaved MessageKey = "organization.share_permission_updated"
+	MsgShareRemoved         MessageKey = "organization.share_removed"
+
+	MsgConfigUpdated              MessageKey = "initialization.config_updated"
+	MsgKnowledgeBaseConfigUpdated MessageKey = "initialization.knowledge_base_config_updated"
+	MsgModelAlreadyExists         MessageKey = "initialization.model_already_exists"
+	MsgModelDownloadExists        MessageKey = "initialization.model_download_exists"
+	MsgModelDownloadCreated       MessageKey = "initialization.model_download_created"
+)
+
+// Messages of warnings
+const (
+	MsgMultimodalEmbeddingUnsupported MessageKey = "initialization.multimodal_embedding_unsupported"
+)
+
+// bundles holds the messages of every key by language. Each key needs a message in the default language, other
+// languages may lag behind and fall back to it.
+var bundles = map[Language]map[MessageKey]string{
+	Chinese: {
+		MsgLoginSucceeded:         "登录成功",
+		MsgLoginFailed:            "登录失败",
+		MsgInvalidCredentials:     "用户名或密码错误",
+		MsgInvalidEmailOrPassword: "邮箱或密码错误",
+		MsgAccountDisabled:        "账号已停用",
+		MsgRegistered:             "注册成功",
+		MsgLoggedOut:              "已退出登录",
+		MsgTokenRefreshed:         "令牌刷新成功",
+		MsgTokenValid:             "令牌有效",
+		MsgPasswordChanged:        "密码修改成功",
+
+		MsgTenantDeleted:              "租户删除成功",
+		MsgAgentConfigUpdated:         "智能体配置更新成功",
+		MsgWebSearchConfigUpdated:     "网络搜索配置更新成功",
+		MsgAgentToolPolicyUpdated:     "智能体工具策略更新成功",
+		MsgModerationConfigUpdated:    "内容审核配置更新成功",
+		MsgLocalProviderConfigUpdated: "本地模型服务配置更新成功",
+		MsgIPPolicyUpdated:            "IP 访问策略更新成功",
+		MsgConversationConfigUpdated:  "对话配置更新成功",
+		MsgLocaleUpdated:              "语言设置更新成功",
+
+		MsgKnowledgeBaseDeleted:       "知识库删除成功",
+		MsgKnowledgeMovedToTrash:      "已移入回收站",
+		MsgKnowledgeDeleted:           "删除成功",
+		MsgKnowledgeChunkUpdated:      "知识分块更新成功",
+		MsgKnowledgeChunkImageUpdated: "知识分块图片更新成功",
+		MsgKnowledgeReparseSubmitted:  "重新解析任务已提交",
+		MsgChunkDeleted:               "分块已删除",
+		MsgKnowledgeChunksDeleted:     "知识下的所有分块已删除",
+		MsgGeneratedQuestionDeleted:   "生成的问题已删除",
+
+		MsgSessionDeleted:          "会话删除成功",
+		MsgMessageDeleted:          "消息删除成功",
+		MsgMessageAlreadyCompleted: "消息已生成完毕",
+		MsgGenerationStopped:       "已停止生成",
+		MsgAgentDeleted:            "智能体删除成功",
+		MsgMCPServiceDeleted:       "MCP 服务删除成功",
+		MsgModelDeleted:            "模型已删除",
+
+		MsgOrganizationDeleted:  "空间删除成功",
+		MsgOrganizationLeft:     "已退出空间",
+		MsgMemberAdded:          "成员添加成功",
+		MsgMemberRoleUpdated:    "成员角色更新成功",
+		MsgMemberRemoved:        "成员移除成功",
+		MsgJoinRequestReviewed:  "审核完成",
+		MsgSharePermissionSaved: "共享权限更新成功",
+		MsgShareRemoved:         "已取消共享",
+
+		MsgConfigUpdated:              "配置更新成功",
+		MsgKnowledgeBaseConfigUpdated: "知识库配置更新成功",
+		MsgModelAlreadyExists:         "模型已存在",
+		MsgModelDownloadExists:        "模型下载任务已存在",
+		MsgModelDownloadCreated:       "模型下载任务已创建",
+
+		MsgMultimodalEmbeddingUnsupported: "阿里云多模态 Embedding 模型暂不支持，请使用纯文本 Embedding 模型（如 text-embedding-v4）",
+	},
+	English: {
+		MsgLoginSucceeded:         "Login successful",
+		MsgLoginFailed:            "Login failed",
+		MsgInvalidCredentials:     "Invalid username or password",
+		MsgInvalidEmailOrPassword: "Invalid email or password",
+		MsgAccountDisabled:        "Account is disabled",
+		MsgRegistered:             "Registration successful",
+		MsgLoggedOut:              "Logout successful",
+		MsgTokenRefreshed:         "Token refreshed successfully",
+		MsgTokenValid:             "Token is valid",
+		MsgPasswordChanged:        "Password changed successfully",
+
+		MsgTenantDeleted:              "Tenant deleted successfully",
+		MsgAgentConfigUpdated:         "Agent configuration updated successfully",
+		MsgWebSearchConfigUpdated:     "Web search configuration updated successfully",
+		MsgAgentToolPolicyUpdated:     "Agent tool policy updated successfully",
+		MsgModerationConfigUpdated:    "Moderation config updated successfully",
+		MsgLocalProviderConfigUpdated: "Local provider config updated successfully",
+		MsgIPPolicyUpdated:            "IP policy updated successfully",
+		MsgConversationConfigUpdated:  "Conversation configuration updated successfully",
+		MsgLocaleUpdated:              "Locale updated successfully",
+
+		MsgKnowledgeBaseDeleted:       "Knowledge base deleted successfully",
+		MsgKnowledgeMovedToTrash:      "Moved to trash",
+		MsgKnowledgeDeleted:           "Deleted successfully",
+		MsgKnowledgeChunkUpdated:      "Knowledge chunk updated successfully",
+		MsgKnowledgeChunkImageUpdated: "Knowledge chunk image updated successfully",
+		MsgKnowledgeReparseSubmitted:  "Knowledge reparse task submitted",
+		MsgChunkDeleted:               "Chunk deleted",
+		MsgKnowledgeChunksDeleted:     "All chunks under knowledge deleted",
+		MsgGeneratedQuestionDeleted:   "Generated question deleted",
+
+		MsgSessionDeleted:          "Session deleted successfully",
+		MsgMessageDeleted:          "Message deleted successfully",
+		MsgMessageAlreadyCompleted: "Message already completed",
+		MsgGenerationStopped:       "Generation stopped",
+		MsgAgentDeleted:            "Agent deleted successfully",
+		MsgMCPServiceDeleted:       "MCP service deleted successfully",
+		MsgModelDeleted:            "Model deleted",
+
+		MsgOrganizationDeleted:  "Organization deleted successfully",
+		MsgOrganizationLeft:     "Left organization successfully",
+		MsgMemberAdded:          "Member added successfully",
+		MsgMemberRoleUpdated:    "Member role updated successfully",
+		MsgMemberRemoved:        "Member removed successfully",
+		MsgJoinRequestReviewed:  "Review completed",
+		MsgSharePermissionSaved: "Share permission updated successfully",
+		MsgShareRemoved:         "Share removed successfully",
+
+		MsgConfigUpdated:              "Configuration updated successfully",
+		MsgKnowledgeBaseConfigUpdated: "Knowledge base configuration updated successfully",
+		MsgModelAlreadyExists:         "The model already exists",
+		MsgModelDownloadExists:        "The model download task already exists",
+		MsgModelDownloadCreated:       "The model download task was created",
+
+		MsgMultimodalEmbeddingUnsupported: "Alibaba Cloud multimodal embedding models are not supported yet, " +
+			"use a text embedding model such as text-embedding-v4",
+	},
+}
+
+// Message returns the message of a key in a language, formatted with the arguments. Messages missing in the
+// language fall back to the default language, then to the key itself.
+func Message(lang Language, key MessageKey, args ...any) string {
+	format, ok := bundles[lang][key]
+	if !ok {
+		format, ok = bundles[DefaultLanguage][key]
+	}
+	if !ok {
+		format = string(key)
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// T returns the message of a key in the language of the request of a context
+func T(ctx context.Context, key MessageKey, args ...any) string {
+	return Message(FromContext(ctx), key, args...)
+}
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/sirupsen/logrus"
@@ -132,7 +133,7 @@ func init() {
 	logrus.SetLevel(logLevel)
 
 	// 设置日志格式而不修改全局时区
-	logrus.SetFormatter(&CustomFormatter{ForceColor: true})
+	logrus.SetFormatter(getFormatterFromEnv())
 	logrus.SetReportCaller(false)
 }
 
@@ -188,6 +189,15 @@ func getLogLevelFromEnv() logrus.Level {
 	}
 }
 
+// getFormatterFromEnv 从环境变量读取日志格式配置，LOG_FORMAT=json 时每行输出一个 JSON 对象，
+// 便于日志平台按 request_id、tenant_id、knowledge_id 等字段检索；其他值输出带颜色的文本
+func getFormatterFromEnv() logrus.Formatter {
+	if strings.ToLower(os.Getenv("LOG_FORMAT")) == "json" {
+		return &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	}
+	return &CustomFormatter{ForceColor: true}
+}
+
 // 添加调用者字段
 func addCaller(entry *logrus.Entry, skip int) *logrus.Entry {
 	pc, file, line, ok := runtime.Caller(skip)
@@ -210,6 +220,12 @@ func WithRequestID(c context.Context, requestID string) context.Context {
 	return WithField(c, "request_id", requestID)
 }
 
+// GetRequestID 获取上下文中的请求ID，没有时返回空字符串
+func GetRequestID(c context.Context) string {
+	requestID, _ := c.Value(types.RequestIDContextKey).(string)
+	return requestID
+}
+
 // WithField 向日志中添加一个字段
 func WithField(c context.Context, key string, value interface{}) context.Context {
 	logger := GetLogger(c).WithField(key, value)
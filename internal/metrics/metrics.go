@@ -0,0 +1,233 @@
+// Package metrics defines the Prometheus metrics of the server and the handler exposing them
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "weknora"
+
+// Parse pipeline stages, from fetching the document to indexing its chunks
+const (
+	StageDownload = "download"
+	StageParse    = "parse"
+	StageChunk    = "chunk"
+	StageEmbed    = "embed"
+	StageTotal    = "total"
+)
+
+// Outcomes recorded with the metrics that have a result label
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+	// ResultContended is a lock that was already held
+	ResultContended = "contended"
+	// ResultRejected is a call refused without being made, by an open circuit or a full queue
+	ResultRejected = "rejected"
+)
+
+// Outcomes of a cache lookup
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+	// CacheBypass is a lookup the cache was not used for, because it is disabled or the item is too large
+	CacheBypass = "bypass"
+)
+
+// registry holds the metrics of the server, with the Go runtime and process collectors
+var registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts HTTP requests per route template and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests per route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration is the latency of HTTP requests per route template
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests per route, streamed responses included.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"method", "route"})
+
+	// ParseStageDuration is the duration of each stage of the document parse pipeline
+	ParseStageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "parse_stage_duration_seconds",
+		Help:      "Duration of the stages of the document parse pipeline.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"stage", "result"})
+
+	// EmbeddingTexts counts the texts embedded successfully per model
+	EmbeddingTexts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "embedding_texts_total",
+		Help:      "Texts embedded successfully per model.",
+	}, []string{"model"})
+
+	// EmbeddingRequestDuration is the latency of embedding requests per model and result
+	EmbeddingRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "embedding_request_duration_seconds",
+		Help:      "Latency of embedding requests sent to providers, retries included.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60},
+	}, []string{"model", "result"})
+
+	// BrowserSessionsActive is the number of headless browser sessions open
+	BrowserSessionsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "browser_sessions_active",
+		Help:      "Headless browser sessions currently open.",
+	})
+
+	// BrowserSessions counts the headless browser sessions per result
+	BrowserSessions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "browser_sessions_total",
+		Help:      "Headless browser sessions per result.",
+	}, []string{"result"})
+
+	// RedisLockAcquisitions counts attempts to take Redis locks per lock and result
+	RedisLockAcquisitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_lock_acquisitions_total",
+		Help:      "Attempts to take Redis locks, contended when the lock was already held.",
+	}, []string{"lock", "result"})
+
+	// WebhookDeliveries counts attempts to deliver events to webhooks per event and result
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Attempts to deliver events to webhooks per event and result.",
+	}, []string{"event", "result"})
+
+	// DocReaderCalls counts DocReader calls per method and result
+	DocReaderCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "docreader_calls_total",
+		Help:      "DocReader calls per method and result, rejected when the circuit was open or the queue wait timed out.",
+	}, []string{"method", "result"})
+
+	// DocReaderInFlight is the number of DocReader calls being served
+	DocReaderInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "docreader_in_flight",
+		Help:      "DocReader calls currently in flight.",
+	})
+
+	// DocReaderQueued is the number of DocReader calls waiting for an in-flight slot
+	DocReaderQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "docreader_queued",
+		Help:      "DocReader calls waiting for an in-flight slot.",
+	})
+
+	// DocReaderQueueWait is how long DocReader calls waited for an in-flight slot
+	DocReaderQueueWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "docreader_queue_wait_seconds",
+		Help:      "Time DocReader calls waited for an in-flight slot.",
+		Buckets:   []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+	})
+
+	// DocReaderCircuitOpen is 1 while the DocReader circuit rejects calls
+	DocReaderCircuitOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "docreader_circuit_open",
+		Help:      "1 while the DocReader circuit is open or half-open, 0 when closed.",
+	})
+
+	// VectorQueryDuration is the latency of retrieval queries per engine and retriever type
+	VectorQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "vector_query_duration_seconds",
+		Help:      "Latency of retrieval queries per engine and retriever type.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"engine", "retriever", "result"})
+
+	// VectorWriteDuration is the latency of each batch saved to or deleted from a retrieval engine
+	VectorWriteDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "vector_write_duration_seconds",
+		Help:      "Latency of each batch saved to or deleted from a retrieval engine.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"engine", "op", "result"})
+
+	// KnowledgeFileCache counts the reads of knowledge files per cache outcome
+	KnowledgeFileCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "knowledge_file_cache_total",
+		Help:      "Reads of knowledge files by outcome of the file cache: hit, miss or bypass.",
+	}, []string{"result"})
+
+	// TenantScopeViolations counts the statements on tenant tables not scoped to a tenant, found by the tenant
+	// isolation audit
+	TenantScopeViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tenant_scope_violations_total",
+		Help:      "Statements on tables with a tenant_id column that are not scoped to a tenant, per table and op.",
+	}, []string{"table", "op"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		ParseStageDuration,
+		EmbeddingTexts,
+		EmbeddingRequestDuration,
+		BrowserSessionsActive,
+		BrowserSessions,
+		RedisLockAcquisitions,
+		VectorQueryDuration,
+		VectorWriteDuration,
+		KnowledgeFileCache,
+		TenantScopeViolations,
+		WebhookDeliveries,
+		DocReaderCalls,
+		DocReaderInFlight,
+		DocReaderQueued,
+		DocReaderQueueWait,
+		DocReaderCircuitOpen,
+	)
+}
+
+// Result returns the result label of an operation
+func Result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultSuccess
+}
+
+// ObserveParseStage records the duration of a parse pipeline stage started at start
+func ObserveParseStage(stage string, start time.Time, err error) {
+	ParseStageDuration.WithLabelValues(stage, Result(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveLock records an attempt to take a Redis lock
+func ObserveLock(lock string, acquired bool, err error) {
+	result := ResultSuccess
+	switch {
+	case err != nil:
+		result = ResultError
+	case !acquired:
+		result = ResultContended
+	}
+	RedisLockAcquisitions.WithLabelValues(lock, result).Inc()
+}
+
+// Handler returns the handler serving the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"log"
-	"net/http"
 	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/Tencent/WeKnora/internal/config"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/gin-gonic/gin"
@@ -18,9 +18,18 @@ import (
 // 无需认证的API列表
 var noAuthAPI = map[string][]string{
 	"/health":               {"GET"},
+	"/healthz":              {"GET"},
+	"/readyz":               {"GET"},
 	"/api/v1/auth/register": {"POST"},
 	"/api/v1/auth/login":    {"POST"},
 	"/api/v1/auth/refresh":  {"POST"},
+	// 单点登录流程在登录前完成，由签名的登录状态保护
+	"/api/v1/auth/oidc/*":     {"GET"},
+	"/api/v1/auth/ldap/login": {"POST"},
+	// 会话分享链接由签名令牌授权
+	"/api/v1/shared/sessions/*": {"GET"},
+	// 知识文件签名链接由签名令牌授权
+	"/api/v1/shared/files/*": {"GET"},
 }
 
 // 检查请求是否在无需认证的API列表中
@@ -41,7 +50,7 @@ func isNoAuthAPI(path string, method string) bool {
 // canAccessTenant checks if a user can access a target tenant
 func canAccessTenant(user *types.User, targetTenantID uint64, cfg *config.Config) bool {
 	// 1. 检查功能是否启用
-	if cfg == nil || cfg.Tenant == nil || !cfg.Tenant.EnableCrossTenantAccess {
+	if !cfg.CrossTenantAccessEnabled() {
 		return false
 	}
 	// 2. 检查用户权限
@@ -60,6 +69,7 @@ func canAccessTenant(user *types.User, targetTenantID uint64, cfg *config.Config
 func Auth(
 	tenantService interfaces.TenantService,
 	userService interfaces.UserService,
+	apiKeyService interfaces.APIKeyService,
 	cfg *config.Config,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -98,19 +108,14 @@ func Auth(
 								log.Printf("User %s switching to tenant %d", user.ID, targetTenantID)
 							} else {
 								log.Printf("Error getting target tenant by ID: %v, tenantID: %d", err, parsedTenantID)
-								c.JSON(http.StatusBadRequest, gin.H{
-									"error": "Invalid target tenant ID",
-								})
-								c.Abort()
+								abortWithError(c, apperrors.NewBadRequestError("Invalid target tenant ID"))
 								return
 							}
 						} else {
 							// 用户没有权限访问目标租户
 							log.Printf("User %s attempted to access tenant %d without permission", user.ID, parsedTenantID)
-							c.JSON(http.StatusForbidden, gin.H{
-								"error": "Forbidden: insufficient permissions to access target tenant",
-							})
-							c.Abort()
+							abortWithError(c, apperrors.NewForbiddenError(
+								"Forbidden: insufficient permissions to access target tenant"))
 							return
 						}
 					}
@@ -120,10 +125,7 @@ func Auth(
 				tenant, err := tenantService.GetTenantByID(c.Request.Context(), targetTenantID)
 				if err != nil {
 					log.Printf("Error getting tenant by ID: %v, tenantID: %d, userID: %s", err, targetTenantID, user.ID)
-					c.JSON(http.StatusUnauthorized, gin.H{
-						"error": "Unauthorized: invalid tenant",
-					})
-					c.Abort()
+					abortWithError(c, apperrors.NewUnauthorizedError("Unauthorized: invalid tenant"))
 					return
 				}
 
@@ -144,6 +146,7 @@ func Auth(
 						types.UserIDContextKey, user.ID,
 					),
 				)
+				withTenantLogger(c, targetTenantID)
 				c.Next()
 				return
 			}
@@ -151,14 +154,18 @@ func Auth(
 
 		// 尝试X-API-Key认证（兼容模式）
 		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" && strings.HasPrefix(authHeader, "Bearer ") {
+			// OpenAI 兼容客户端通过 Authorization: Bearer 传递 API Key
+			token := strings.TrimPrefix(authHeader, "Bearer ")
+			if _, err := tenantService.ExtractTenantIDFromAPIKey(token); err == nil {
+				apiKey = token
+			}
+		}
 		if apiKey != "" {
 			// Get tenant information
 			tenantID, err := tenantService.ExtractTenantIDFromAPIKey(apiKey)
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized: invalid API key format",
-				})
-				c.Abort()
+				abortWithError(c, apperrors.NewInvalidAPIKeyError("Unauthorized: invalid API key format"))
 				return
 			}
 
@@ -166,37 +173,50 @@ func Auth(
 			t, err := tenantService.GetTenantByID(c.Request.Context(), tenantID)
 			if err != nil {
 				log.Printf("Error getting tenant by ID: %v, tenantID: %d", err, tenantID)
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized: invalid API key",
-				})
-				c.Abort()
+				abortWithError(c, apperrors.NewInvalidAPIKeyError("Unauthorized: invalid API key"))
 				return
 			}
 
-			if t == nil || t.APIKey != apiKey {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized: invalid API key",
-				})
-				c.Abort()
+			if t == nil {
+				abortWithError(c, apperrors.NewInvalidAPIKeyError("Unauthorized: invalid API key"))
 				return
 			}
 
+			// Anything but the tenant API key must be a scoped API key of the tenant
+			var scopedKey *types.APIKey
+			if t.APIKey != apiKey {
+				scopedKey, err = apiKeyService.Authenticate(c.Request.Context(), tenantID, apiKey)
+				if err != nil {
+					abortWithError(c, apperrors.NewInvalidAPIKeyError("Unauthorized: invalid or expired API key"))
+					return
+				}
+				if !scopedKey.Scope.Allows(c.Request.Method, c.FullPath()) {
+					log.Printf("API key %s with scope %s denied %s %s",
+						scopedKey.ID, scopedKey.Scope, c.Request.Method, c.FullPath())
+					abortWithError(c, apperrors.NewAPIKeyScopeDeniedError())
+					return
+				}
+			}
+
 			// Store tenant ID in context
 			c.Set(types.TenantIDContextKey.String(), tenantID)
 			c.Set(types.TenantInfoContextKey.String(), t)
-			c.Request = c.Request.WithContext(
-				context.WithValue(
-					context.WithValue(c.Request.Context(), types.TenantIDContextKey, tenantID),
-					types.TenantInfoContextKey, t,
-				),
+			ctx := context.WithValue(
+				context.WithValue(c.Request.Context(), types.TenantIDContextKey, tenantID),
+				types.TenantInfoContextKey, t,
 			)
+			if scopedKey != nil {
+				c.Set(types.APIKeyContextKey.String(), scopedKey)
+				ctx = context.WithValue(ctx, types.APIKeyContextKey, scopedKey)
+			}
+			c.Request = c.Request.WithContext(ctx)
+			withTenantLogger(c, tenantID)
 			c.Next()
 			return
 		}
 
 		// 没有提供任何认证信息
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: missing authentication"})
-		c.Abort()
+		abortWithError(c, apperrors.NewUnauthorizedError("Unauthorized: missing authentication"))
 	}
 }
 
@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/Tencent/WeKnora/internal/config"
+)
+
+const (
+	encodingZstd = "zstd"
+	encodingGzip = "gzip"
+
+	defaultCompressionMinBytes = 1024
+)
+
+// defaultCompressionEncodings are the encodings offered when none are configured, in order of preference
+var defaultCompressionEncodings = []string{encodingZstd, encodingGzip}
+
+// compressor is an encoder that can be reused for another response
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressorPools keep the encoders of each encoding, building one costs more than compressing a small response
+var compressorPools = map[string]*sync.Pool{
+	encodingZstd: {New: func() any {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		return enc
+	}},
+	encodingGzip: {New: func() any {
+		enc, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		return enc
+	}},
+}
+
+// compressResponseWriter buffers the start of a response until it knows whether compressing is worth it: the
+// response is compressed once it outgrows the minimum size, and sent as it is when it ends before or is not text
+type compressResponseWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minBytes int
+	buf      []byte
+	decided  bool
+	encoder  compressor
+}
+
+// Write compresses, buffers or passes through the bytes written to the response
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if !w.compressible() {
+			w.decided = true
+		} else {
+			w.buf = append(w.buf, b...)
+			if len(w.buf) < w.minBytes {
+				return len(b), nil
+			}
+			if err := w.startEncoder(); err != nil {
+				return 0, err
+			}
+			return len(b), nil
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString writes a string to the response
+func (w *compressResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether the response has begun, buffered bytes included
+func (w *compressResponseWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Flush sends what was written so far, streamed responses decide on compression at their first flush
+func (w *compressResponseWriter) Flush() {
+	if !w.decided && len(w.buf) > 0 {
+		if err := w.startEncoder(); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// compressible reports whether the response is text worth compressing, judged by its headers at the first write
+func (w *compressResponseWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" ||
+		w.Status() == http.StatusPartialContent {
+		return false
+	}
+	return isCompressibleType(header.Get("Content-Type"))
+}
+
+// startEncoder switches the response to the encoding and writes the buffered bytes through it
+func (w *compressResponseWriter) startEncoder() error {
+	w.decided = true
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+
+	w.encoder = compressorPools[w.encoding].Get().(compressor)
+	w.encoder.Reset(w.ResponseWriter)
+	buf := w.buf
+	w.buf = nil
+	_, err := w.encoder.Write(buf)
+	return err
+}
+
+// finish sends the rest of the response: it closes the encoder, or writes a response too small to compress
+func (w *compressResponseWriter) finish() {
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		w.encoder.Reset(nil)
+		compressorPools[w.encoding].Put(w.encoder)
+		w.encoder = nil
+		return
+	}
+	if len(w.buf) > 0 {
+		buf := w.buf
+		w.buf = nil
+		w.decided = true
+		_, _ = w.ResponseWriter.Write(buf)
+	}
+}
+
+// isCompressibleType reports whether a content type is text, binary files are usually compressed already
+func isCompressibleType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	switch {
+	case mediaType == "text/event-stream":
+		// Events must reach the client as they are sent
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/xml", "application/javascript",
+		"application/yaml", "application/x-yaml":
+		return true
+	}
+	return false
+}
+
+// negotiateEncoding picks the first offered encoding the Accept-Encoding header accepts, empty when none is
+func negotiateEncoding(acceptEncoding string, offered []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	accepted := make(map[string]bool)
+	wildcard, hasWildcard := false, false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		ok := true
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				ok = false
+			}
+		}
+		if name == "*" {
+			wildcard, hasWildcard = ok, true
+			continue
+		}
+		accepted[name] = ok
+	}
+	for _, encoding := range offered {
+		if ok, listed := accepted[encoding]; listed {
+			if ok {
+				return encoding
+			}
+			continue
+		}
+		if hasWildcard && wildcard {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// Compression compresses text responses with zstd or gzip, whichever the client accepts first in the configured
+// order. Responses below the minimum size, binary files, event streams and responses already encoded or partial
+// are sent as they are.
+func Compression(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var settings *config.CompressionConfig
+		if response := cfg.Current().Response; response != nil {
+			settings = response.Compression
+		}
+		if settings == nil || !settings.Enabled || c.Request.Method == http.MethodHead ||
+			c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		offered := make([]string, 0, len(defaultCompressionEncodings))
+		for _, encoding := range settings.Encodings {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if compressorPools[encoding] != nil {
+				offered = append(offered, encoding)
+			}
+		}
+		if len(settings.Encodings) == 0 {
+			offered = defaultCompressionEncodings
+		}
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), offered)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+		minBytes := settings.MinBytes
+		if minBytes <= 0 {
+			minBytes = defaultCompressionMinBytes
+		}
+
+		original := c.Writer
+		writer := &compressResponseWriter{ResponseWriter: original, encoding: encoding, minBytes: minBytes}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			// Middleware running earlier see the response size on the wire
+			c.Writer = original
+		}()
+		c.Next()
+	}
+}
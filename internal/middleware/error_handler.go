@@ -1,11 +1,10 @@
 package middleware
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
 	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/i18n"
 )
 
 // ErrorHandler 是一个处理应用错误的中间件
@@ -22,25 +21,26 @@ func ErrorHandler() gin.HandlerFunc {
 			// 检查是否为应用错误
 			if appErr, ok := errors.IsAppError(err); ok {
 				// 返回应用错误
-				c.JSON(appErr.HTTPCode, gin.H{
-					"success": false,
-					"error": gin.H{
-						"code":    appErr.Code,
-						"message": appErr.Message,
-						"details": appErr.Details,
-					},
-				})
+				writeError(c, appErr)
 				return
 			}
 
 			// 处理其他类型的错误
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error": gin.H{
-					"code":    errors.ErrInternalServer,
-					"message": "Internal server error",
-				},
-			})
+			writeError(c, errors.NewInternalServerError(""))
 		}
 	}
 }
+
+// writeError 以统一结构返回错误，消息按请求的语言本地化
+func writeError(c *gin.Context, appErr *errors.AppError) {
+	c.JSON(appErr.HTTPCode, gin.H{
+		"success": false,
+		"error":   appErr.Localize(i18n.FromContext(c.Request.Context())),
+	})
+}
+
+// abortWithError 中止请求并返回错误，用于在处理函数之前拒绝请求的中间件
+func abortWithError(c *gin.Context, appErr *errors.AppError) {
+	writeError(c, appErr)
+	c.Abort()
+}
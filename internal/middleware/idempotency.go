@@ -0,0 +1,328 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// IdempotencyKeyHeader is the header clients send to make retries of a mutating request safe
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from an earlier request with the same key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength       = 255
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyLockTimeout = 5 * time.Minute
+	defaultIdempotencyMaxResponse = 1 << 20
+	defaultIdempotencyKeyPrefix   = "idempotency:"
+	defaultIdempotencyMaxBody     = 10 << 20
+	idempotencyMultipartMemory    = 32 << 20
+)
+
+// errIdempotentBodyTooLarge is returned when a request body is too large to fingerprint
+var errIdempotentBodyTooLarge = goerrors.New("request body too large")
+
+// idempotencyRecord is kept in Redis under an idempotency key: a request holding the key while it runs, then its
+// response
+type idempotencyRecord struct {
+	// Owner identifies the request holding the key, only it may store the response or release the key
+	Owner string `json:"owner,omitempty"`
+	// Fingerprint is the method and path of the request with a digest of its query and body, a key is only
+	// replayed for the same ones
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// completeIdempotencyScript stores the response of a request if it still holds its key
+var completeIdempotencyScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or cjson.decode(current)['owner'] ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// releaseIdempotencyScript releases the key of a request if it still holds it, so a retry runs again
+var releaseIdempotencyScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or cjson.decode(current)['owner'] ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// idempotencyResponseWriter captures the response of a request to replay it, up to a size
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body     *bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+// Write captures the bytes written to the response
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	w.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString captures the string written to the response
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// keep buffers written bytes until the response outgrows the limit
+func (w *idempotencyResponseWriter) keep(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(b) > w.limit {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
+
+// isMutatingMethod reports whether requests of a method change state and may carry an idempotency key
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// idempotencyRedisKey scopes an idempotency key to the tenant and the caller, so keys chosen by different callers
+// never collide
+func idempotencyRedisKey(ctx context.Context, prefix string, tenantID uint64, key string) string {
+	principal := "tenant"
+	if apiKey := types.APIKeyFromContext(ctx); apiKey != nil {
+		principal = "apikey:" + apiKey.ID
+	} else if user, ok := ctx.Value(types.UserContextKey).(*types.User); ok && user != nil {
+		principal = "user:" + user.ID
+	}
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("%s%d:%s:%s", prefix, tenantID, principal, hex.EncodeToString(sum[:]))
+}
+
+// requestFingerprint identifies what a request asks for: its method and path with a SHA-256 of its query and body.
+// Multipart uploads are digested by their form fields and the names and contents of their files, the parsed form
+// stays on the request for the handler. Other bodies of up to maxBody bytes are read and put back.
+func requestFingerprint(r *http.Request, maxBody int64) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(r.URL.RawQuery))
+	h.Write([]byte{0})
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(idempotencyMultipartMemory); err != nil {
+			return "", err
+		}
+		for _, name := range slices.Sorted(maps.Keys(r.MultipartForm.Value)) {
+			for _, value := range r.MultipartForm.Value[name] {
+				fmt.Fprintf(h, "field %q=%q\n", name, value)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(r.MultipartForm.File)) {
+			for _, file := range r.MultipartForm.File[name] {
+				fmt.Fprintf(h, "file %q=%q %d\n", name, file.Filename, file.Size)
+				if err := hashFormFile(h, file); err != nil {
+					return "", err
+				}
+			}
+		}
+	} else if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil {
+			return "", err
+		}
+		if int64(len(body)) > maxBody {
+			return "", errIdempotentBodyTooLarge
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+	return r.Method + " " + r.URL.Path + " " + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFormFile adds the content of an uploaded file to a digest
+func hashFormFile(h io.Writer, file *multipart.FileHeader) error {
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// Idempotency replays the response of a mutating request for retries sending the same Idempotency-Key header, so
+// network retries do not create duplicate documents. The first request holds the key while it runs; retries
+// meanwhile get a 409, and a key reused for another method, path or body gets a 422. Only successful responses are
+// kept, retries of failed or streamed requests run again. It runs after authentication, requests without a tenant or
+// key are not affected. When Redis is unavailable requests are let through.
+func Idempotency(redisClient *redis.Client, cfg *config.Config) gin.HandlerFunc {
+	if redisClient == nil {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		settings := cfg.Current().Idempotency
+		key := c.GetHeader(IdempotencyKeyHeader)
+		tenantID := c.GetUint64(types.TenantIDContextKey.String())
+		if settings == nil || !settings.Enabled || key == "" || tenantID == 0 ||
+			!isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, errors.NewBadRequestError(
+				fmt.Sprintf("Idempotency-Key 长度不能超过%d个字符", maxIdempotencyKeyLength)))
+			return
+		}
+
+		ttl := settings.TTL
+		if ttl <= 0 {
+			ttl = defaultIdempotencyTTL
+		}
+		lockTimeout := settings.LockTimeout
+		if lockTimeout <= 0 {
+			lockTimeout = defaultIdempotencyLockTimeout
+		}
+		maxResponse := settings.MaxResponseBytes
+		if maxResponse <= 0 {
+			maxResponse = defaultIdempotencyMaxResponse
+		}
+		prefix := settings.KeyPrefix
+		if prefix == "" {
+			prefix = defaultIdempotencyKeyPrefix
+		}
+		maxBody := settings.MaxBodyBytes
+		if maxBody <= 0 {
+			maxBody = defaultIdempotencyMaxBody
+		}
+
+		ctx := c.Request.Context()
+		fingerprint, err := requestFingerprint(c.Request, maxBody)
+		if goerrors.Is(err, errIdempotentBodyTooLarge) {
+			abortWithError(c, errors.NewBadRequestError(
+				fmt.Sprintf("携带 Idempotency-Key 的请求体不能超过%d字节", maxBody)))
+			return
+		}
+		if err != nil {
+			abortWithError(c, errors.NewBadRequestError("请求体读取失败").WithDetails(err.Error()))
+			return
+		}
+		redisKey := idempotencyRedisKey(ctx, prefix, tenantID, key)
+		pending := idempotencyRecord{
+			Owner:       uuid.New().String(),
+			Fingerprint: fingerprint,
+		}
+		pendingData, _ := json.Marshal(pending)
+		acquired, err := redisClient.SetNX(ctx, redisKey, pendingData, lockTimeout).Result()
+		if err != nil {
+			logger.Warnf(ctx, "Idempotency store unavailable, letting request through: %v", err)
+			c.Next()
+			return
+		}
+		if !acquired {
+			replayIdempotentResponse(c, redisClient, redisKey, pending.Fingerprint)
+			return
+		}
+
+		writer := &idempotencyResponseWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}, limit: maxResponse}
+		c.Writer = writer
+		c.Next()
+
+		// The client may have gone away, the outcome is stored regardless so its retry finds it
+		storeCtx := context.WithoutCancel(ctx)
+		status := writer.Status()
+		contentType := writer.Header().Get("Content-Type")
+		keep := len(c.Errors) == 0 && writer.Written() && !writer.overflow &&
+			status >= http.StatusOK && status < http.StatusMultipleChoices &&
+			!strings.HasPrefix(contentType, "text/event-stream")
+		if !keep {
+			err := releaseIdempotencyScript.Run(storeCtx, redisClient, []string{redisKey}, pending.Owner).Err()
+			if err != nil {
+				logger.Warnf(ctx, "Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+		done := pending
+		done.Done = true
+		done.Status = status
+		done.ContentType = contentType
+		done.Body = writer.body.Bytes()
+		doneData, _ := json.Marshal(done)
+		err = completeIdempotencyScript.Run(storeCtx, redisClient, []string{redisKey},
+			pending.Owner, doneData, ttl.Milliseconds()).Err()
+		if err != nil {
+			logger.Warnf(ctx, "Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// replayIdempotentResponse answers a request whose idempotency key is taken: with the stored response, or an error
+// while the first request runs or when the key belongs to another request
+func replayIdempotentResponse(c *gin.Context, redisClient *redis.Client, redisKey string, fingerprint string) {
+	ctx := c.Request.Context()
+	data, err := redisClient.Get(ctx, redisKey).Bytes()
+	if err == redis.Nil {
+		// The key was released or expired since, the retry may take it
+		c.Header("Retry-After", "1")
+		abortWithError(c, errors.NewIdempotencyKeyBusyError())
+		return
+	}
+	if err != nil {
+		logger.Warnf(ctx, "Idempotency store unavailable, letting request through: %v", err)
+		c.Next()
+		return
+	}
+	var record idempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		logger.Warnf(ctx, "Invalid idempotency record, letting request through: %v", err)
+		c.Next()
+		return
+	}
+	if record.Fingerprint != fingerprint {
+		abortWithError(c, errors.NewIdempotencyKeyUsedError())
+		return
+	}
+	if !record.Done {
+		c.Header("Retry-After", "1")
+		abortWithError(c, errors.NewIdempotencyKeyBusyError())
+		return
+	}
+	logger.Infof(ctx, "Replaying idempotent response, status: %d", record.Status)
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+}
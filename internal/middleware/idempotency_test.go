@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestFingerprint(t *testing.T) {
+	jsonRequest := func(path, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+	fingerprint := func(r *http.Request) string {
+		t.Helper()
+		fp, err := requestFingerprint(r, 1<<10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fp
+	}
+
+	const urlPath = "/api/v1/knowledge-bases/kb/knowledge/url"
+	r := jsonRequest(urlPath, `{"url":"https://a.example"}`)
+	first := fingerprint(r)
+	if body, _ := io.ReadAll(r.Body); string(body) != `{"url":"https://a.example"}` {
+		t.Errorf("body after fingerprint = %q", body)
+	}
+	if fp := fingerprint(jsonRequest(urlPath, `{"url":"https://a.example"}`)); fp != first {
+		t.Errorf("same request fingerprints differ: %s, %s", fp, first)
+	}
+	if fp := fingerprint(jsonRequest(urlPath, `{"url":"https://b.example"}`)); fp == first {
+		t.Error("another body has the same fingerprint")
+	}
+	otherPath := jsonRequest("/api/v1/knowledge-bases/kb2/knowledge/url", `{"url":"https://a.example"}`)
+	if fp := fingerprint(otherPath); fp == first {
+		t.Error("another path has the same fingerprint")
+	}
+
+	large := jsonRequest(urlPath, `{"url":"`+strings.Repeat("a", 1<<10)+`"}`)
+	if _, err := requestFingerprint(large, 1<<10); err != errIdempotentBodyTooLarge {
+		t.Errorf("fingerprint of a large body: err = %v", err)
+	}
+
+	upload := func(fileName, content string) *http.Request {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		_ = w.WriteField("tag_id", "t1")
+		part, _ := w.CreateFormFile("file", fileName)
+		_, _ = part.Write([]byte(content))
+		_ = w.Close()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/knowledge-bases/kb/knowledge/file", &buf)
+		r.Header.Set("Content-Type", w.FormDataContentType())
+		return r
+	}
+	r = upload("a.pdf", "content")
+	uploaded := fingerprint(r)
+	if _, header, err := r.FormFile("file"); err != nil || header.Filename != "a.pdf" {
+		t.Errorf("form file after fingerprint = %v, %v", header, err)
+	}
+	if fp := fingerprint(upload("a.pdf", "content")); fp != uploaded {
+		t.Errorf("same upload fingerprints differ: %s, %s", fp, uploaded)
+	}
+	if fp := fingerprint(upload("b.pdf", "content")); fp == uploaded {
+		t.Error("another file name has the same fingerprint")
+	}
+	if fp := fingerprint(upload("a.pdf", "changed")); fp == uploaded {
+		t.Error("another file content of the same size has the same fingerprint")
+	}
+}
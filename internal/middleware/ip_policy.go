@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// CallbackRoutePrefix is the route prefix of the callbacks of internal services such as ONLYOFFICE and DocReader,
+// they are checked against the callback policy of the tenant instead of its API policy
+const CallbackRoutePrefix = "/api/v1/callbacks/"
+
+// IPPolicy rejects requests from client addresses the IP policy of their tenant does not allow.
+// It runs after authentication, requests without a tenant are not checked. Operators accessing another tenant
+// are checked against the API policy of their own tenant, so a policy can always be fixed by them.
+func IPPolicy(tenantService interfaces.TenantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant, ok := c.Request.Context().Value(types.TenantInfoContextKey).(*types.Tenant)
+		if !ok || tenant == nil {
+			c.Next()
+			return
+		}
+		if user, ok := c.Request.Context().Value(types.UserContextKey).(*types.User); ok &&
+			user != nil && user.TenantID != tenant.ID {
+			own, err := tenantService.GetTenantByID(c.Request.Context(), user.TenantID)
+			if err != nil || own == nil {
+				log.Printf("Error getting tenant %d of user %s for IP policy: %v", user.TenantID, user.ID, err)
+				abortWithError(c, errors.NewUnauthorizedError("Unauthorized: invalid tenant"))
+				return
+			}
+			if own.IPPolicy != nil && own.IPPolicy.Enabled && !own.IPPolicy.AllowsAPI(c.ClientIP()) {
+				rejectIP(c, own)
+				return
+			}
+			c.Next()
+			return
+		}
+		if tenant.IPPolicy == nil || !tenant.IPPolicy.Enabled {
+			c.Next()
+			return
+		}
+
+		allowed := tenant.IPPolicy.AllowsAPI(c.ClientIP())
+		if strings.HasPrefix(c.Request.URL.Path, CallbackRoutePrefix) {
+			allowed = tenant.IPPolicy.AllowsCallback(c.ClientIP())
+		}
+		if !allowed {
+			rejectIP(c, tenant)
+			return
+		}
+		c.Next()
+	}
+}
+
+// rejectIP aborts a request from an address the tenant does not allow
+func rejectIP(c *gin.Context, tenant *types.Tenant) {
+	log.Printf("Rejected request to %s from %s by IP policy of tenant %d", c.Request.URL.Path, c.ClientIP(), tenant.ID)
+	abortWithError(c, errors.NewIPNotAllowedError())
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/i18n"
+)
+
+// Language 根据 Accept-Language 请求头确定响应消息的语言，并存入请求上下文；
+// 请求头未指定支持的语言时，使用租户的语言设置
+func Language() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if lang, ok := i18n.ParseAcceptLanguage(c.GetHeader("Accept-Language")); ok {
+			c.Request = c.Request.WithContext(i18n.WithLanguage(c.Request.Context(), lang))
+		}
+		c.Next()
+	}
+}
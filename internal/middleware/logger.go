@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/slowlog"
 	"github.com/Tencent/WeKnora/internal/types"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"github.com/gin-gonic/gin"
@@ -129,6 +130,34 @@ func RequestID() gin.HandlerFunc {
 	}
 }
 
+// routeKnowledgeID returns the knowledge a request acts on, from the :id of the /knowledge routes or the
+// :knowledge_id of the chunk routes
+func routeKnowledgeID(c *gin.Context) string {
+	if knowledgeID := c.Param("knowledge_id"); knowledgeID != "" {
+		return knowledgeID
+	}
+	if strings.HasPrefix(c.FullPath(), "/api/v1/knowledge/:id") {
+		return c.Param("id")
+	}
+	return ""
+}
+
+// routeKnowledgeBaseID returns the knowledge base a request acts on, from the :id of the /knowledge-bases routes
+func routeKnowledgeBaseID(c *gin.Context) string {
+	if strings.HasPrefix(c.FullPath(), "/api/v1/knowledge-bases/:id") {
+		return c.Param("id")
+	}
+	return ""
+}
+
+// withTenantLogger adds the tenant of an authenticated request to its logger, so that the logs of the services
+// it calls carry the tenant
+func withTenantLogger(c *gin.Context, tenantID uint64) {
+	ctx := logger.WithField(c.Request.Context(), "tenant_id", tenantID)
+	c.Set(types.LoggerContextKey.String(), logger.GetLogger(ctx))
+	c.Request = c.Request.WithContext(ctx)
+}
+
 // Logger middleware logs request details with request ID, input and output
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -142,6 +171,11 @@ func Logger() gin.HandlerFunc {
 			requestBody = readRequestBody(c)
 		}
 
+		// 慢操作日志按知识库归因
+		if kbID := routeKnowledgeBaseID(c); kbID != "" {
+			c.Request = c.Request.WithContext(slowlog.WithKnowledgeBase(c.Request.Context(), kbID))
+		}
+
 		// 创建响应体捕获器
 		responseBody := &bytes.Buffer{}
 		responseWriter := &loggerResponseBodyWriter{
@@ -203,8 +237,35 @@ func Logger() gin.HandlerFunc {
 			"status_code": statusCode,
 			"size":        c.Writer.Size(),
 			"latency":     latency.String(),
+			"latency_ms":  float64(latency.Microseconds()) / 1000,
 			"client_ip":   secutils.SanitizeForLog(clientIP),
 		})
+		// 路由模板（如 /api/v1/knowledge/:id）便于按接口聚合，未匹配路由时为空
+		if route := c.FullPath(); route != "" {
+			logMsg = logMsg.WithField("route", route)
+		}
+		if tenantID, ok := c.Get(types.TenantIDContextKey.String()); ok {
+			logMsg = logMsg.WithField("tenant_id", tenantID)
+		}
+		if kbID := routeKnowledgeBaseID(c); kbID != "" {
+			logMsg = logMsg.WithField("knowledge_base_id", secutils.SanitizeForLog(kbID))
+		}
+		if knowledgeID := routeKnowledgeID(c); knowledgeID != "" {
+			logMsg = logMsg.WithField("knowledge_id", secutils.SanitizeForLog(knowledgeID))
+		}
+
+		// 记录请求发起者，服务账号与用户分开记录
+		if userID := c.GetString(types.UserIDContextKey.String()); userID != "" {
+			logMsg = logMsg.WithField("user_id", userID)
+		}
+		if apiKey, ok := c.Get(types.APIKeyContextKey.String()); ok {
+			if key, ok := apiKey.(*types.APIKey); ok && key != nil {
+				logMsg = logMsg.WithField("api_key_id", key.ID)
+				if key.ServiceAccountID != "" {
+					logMsg = logMsg.WithField("service_account_id", key.ServiceAccountID)
+				}
+			}
+		}
 
 		// 添加请求体（如果有）
 		if requestBody != "" {
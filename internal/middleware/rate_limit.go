@@ -0,0 +1,291 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Route classes rate limits are configured for
+const (
+	RouteClassChat    = "chat"
+	RouteClassIngest  = "ingest"
+	RouteClassBrowser = "browser"
+	RouteClassDefault = "default"
+)
+
+// chatRoutes are the routes that run retrieval or a model
+var chatRoutes = []string{
+	"/api/v1/knowledge-chat/:session_id",
+	"/api/v1/agent-chat/:session_id",
+	"/api/v1/knowledge-search",
+	"/api/v1/knowledge/search",
+	"/api/v1/openai/chat/completions",
+	"/api/v1/sessions/:session_id/generate_title",
+	"/api/v1/knowledge-bases/:id/hybrid-search",
+	"/api/v1/knowledge-bases/:id/image-search",
+	"/api/v1/knowledge-bases/:id/faq/search",
+	"/api/v1/mcp",
+}
+
+// ingestRoutes are the routes that add documents and queue their processing
+var ingestRoutes = []string{
+	"/api/v1/knowledge-bases/:id/knowledge/file",
+	"/api/v1/knowledge-bases/:id/knowledge/enex",
+	"/api/v1/knowledge-bases/:id/knowledge/fetch",
+	"/api/v1/knowledge-bases/:id/knowledge/manual",
+	"/api/v1/knowledge/manual/:id",
+	"/api/v1/knowledge/:id/reparse",
+	"/api/v1/knowledge/:id/recapture",
+	"/api/v1/knowledge-bases/:id/faq/entries",
+	"/api/v1/knowledge-bases/:id/faq/entry",
+	"/api/v1/knowledge-bases/import",
+}
+
+// browserRoutes are the routes that make the server fetch web pages
+var browserRoutes = []string{
+	"/api/v1/knowledge-bases/:id/knowledge/url",
+	"/api/v1/knowledge/:id/recapture/preview",
+	"/api/v1/knowledge/url/analyze-batch",
+}
+
+// routeClass returns the rate limit class of a request, given its method and route template
+func routeClass(method string, route string) string {
+	switch {
+	case slices.Contains(chatRoutes, route):
+		return RouteClassChat
+	case method == http.MethodGet || method == http.MethodHead:
+		return RouteClassDefault
+	case slices.Contains(browserRoutes, route):
+		return RouteClassBrowser
+	case slices.Contains(ingestRoutes, route):
+		return RouteClassIngest
+	default:
+		return RouteClassDefault
+	}
+}
+
+// tenantRule returns the limit of a route class for a tenant: its override, else the class limit,
+// else the default class limit
+func tenantRule(cfg *config.RateLimitConfig, tenantID uint64, class string) (config.RateLimitRule, bool) {
+	if rule, ok := cfg.Tenants[tenantID][class]; ok {
+		return usableRule(rule)
+	}
+	if rule, ok := cfg.Classes[class]; ok {
+		return usableRule(rule)
+	}
+	rule, ok := cfg.Classes[RouteClassDefault]
+	if !ok {
+		return rule, false
+	}
+	return usableRule(rule)
+}
+
+// apiKeyRule returns the own limit of a scoped API key for a route class, keys without one share their tenant's
+func apiKeyRule(cfg *config.RateLimitConfig, keyID string, class string) (config.RateLimitRule, bool) {
+	rules, ok := cfg.APIKeys[keyID]
+	if !ok {
+		return config.RateLimitRule{}, false
+	}
+	rule, ok := rules[class]
+	if !ok {
+		if rule, ok = rules[RouteClassDefault]; !ok {
+			return rule, false
+		}
+	}
+	return usableRule(rule)
+}
+
+// usableRule reports whether a rule limits anything, a bucket holds at least one request
+func usableRule(rule config.RateLimitRule) (config.RateLimitRule, bool) {
+	if rule.Rate <= 0 {
+		return rule, false
+	}
+	rule.Burst = max(rule.Burst, 1)
+	return rule, true
+}
+
+// tokenBucketScript refills the buckets of a request for the time elapsed since they were last used and takes a
+// token from each if every one has a token left, so a bucket denying the request leaves the others untouched.
+// ARGV holds the current time, then the rate and burst of each bucket. It returns whether the request is allowed
+// and the tokens left in each bucket.
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local tokens = {}
+local allowed = 1
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[i * 2])
+	local burst = tonumber(ARGV[i * 2 + 1])
+	local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+	local left = tonumber(bucket[1])
+	local ts = tonumber(bucket[2])
+	if left == nil or ts == nil then
+		left = burst
+		ts = now
+	end
+	left = math.min(burst, left + math.max(0, now - ts) * rate / 1000)
+	if left < 1 then
+		allowed = 0
+	end
+	tokens[i] = left
+end
+local result = {allowed}
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[i * 2])
+	local burst = tonumber(ARGV[i * 2 + 1])
+	if allowed == 1 then
+		tokens[i] = tokens[i] - 1
+	end
+	redis.call('HSET', key, 'tokens', tostring(tokens[i]), 'ts', tostring(now))
+	redis.call('PEXPIRE', key, math.ceil(burst / rate * 1000) + 1000)
+	result[i + 1] = tostring(tokens[i])
+end
+return result
+`)
+
+// rateLimitResult is the state of a bucket after a request
+type rateLimitResult struct {
+	rule    config.RateLimitRule
+	allowed bool
+	tokens  float64
+}
+
+// remaining returns the number of requests left in the bucket
+func (r rateLimitResult) remaining() int {
+	return int(math.Floor(r.tokens))
+}
+
+// resetAfter returns how long until the bucket is full again
+func (r rateLimitResult) resetAfter() time.Duration {
+	return time.Duration((float64(r.rule.Burst) - r.tokens) / r.rule.Rate * float64(time.Second))
+}
+
+// retryAfter returns how long until the next request is allowed
+func (r rateLimitResult) retryAfter() time.Duration {
+	return time.Duration((1 - r.tokens) / r.rule.Rate * float64(time.Second))
+}
+
+// rateLimitBucket is a token bucket a request takes a token from
+type rateLimitBucket struct {
+	key  string
+	rule config.RateLimitRule
+}
+
+// takeTokens takes a token from each bucket when all of them have one left, and returns the state of the buckets.
+// A bucket is allowed when it had a token left, the request is allowed when all of them are.
+func takeTokens(ctx context.Context, client *redis.Client, buckets []rateLimitBucket) ([]rateLimitResult, error) {
+	keys := make([]string, 0, len(buckets))
+	args := []any{time.Now().UnixMilli()}
+	for _, b := range buckets {
+		keys = append(keys, b.key)
+		args = append(args, b.rule.Rate, b.rule.Burst)
+	}
+	res, err := tokenBucketScript.Run(ctx, client, keys, args...).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != len(buckets)+1 {
+		return nil, fmt.Errorf("unexpected token bucket result: %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	results := make([]rateLimitResult, 0, len(buckets))
+	for i, b := range buckets {
+		tokensText, _ := res[i+1].(string)
+		tokens, err := strconv.ParseFloat(tokensText, 64)
+		if err != nil {
+			return nil, err
+		}
+		// A denied request took no token, the buckets with one left did not deny it
+		results = append(results, rateLimitResult{rule: b.rule, allowed: allowed == 1 || tokens >= 1, tokens: tokens})
+	}
+	return results, nil
+}
+
+// seconds rounds a duration up to whole seconds for rate limit headers
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(max(d, 0).Seconds())))
+}
+
+// RateLimit limits the request rate of each tenant per route class, and of scoped API keys that have their own
+// limits. It runs after authentication, requests without a tenant are not limited. When Redis is unavailable
+// requests are let through. The limits are read on every request, so a config reload applies them at once.
+func RateLimit(redisClient *redis.Client, cfg *config.Config) gin.HandlerFunc {
+	if redisClient == nil {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		limits := cfg.Current().RateLimit
+		tenantID := c.GetUint64(types.TenantIDContextKey.String())
+		if limits == nil || !limits.Enabled || tenantID == 0 {
+			c.Next()
+			return
+		}
+		prefix := limits.KeyPrefix
+		if prefix == "" {
+			prefix = "ratelimit:"
+		}
+		ctx := c.Request.Context()
+		class := routeClass(c.Request.Method, c.FullPath())
+
+		var buckets []rateLimitBucket
+		if rule, ok := tenantRule(limits, tenantID, class); ok {
+			buckets = append(buckets, rateLimitBucket{fmt.Sprintf("%stenant:%d:%s", prefix, tenantID, class), rule})
+		}
+		if apiKey := types.APIKeyFromContext(ctx); apiKey != nil {
+			if rule, ok := apiKeyRule(limits, apiKey.ID, class); ok {
+				buckets = append(buckets, rateLimitBucket{fmt.Sprintf("%sapikey:%s:%s", prefix, apiKey.ID, class), rule})
+			}
+		}
+		if len(buckets) == 0 {
+			c.Next()
+			return
+		}
+
+		// Tokens are only taken when every bucket allows the request, so a key over its own limit does not use up
+		// the budget its tenant shares with other keys
+		results, err := takeTokens(ctx, redisClient, buckets)
+		if err != nil {
+			log.Printf("Rate limiter unavailable, letting request through: %v", err)
+			c.Next()
+			return
+		}
+		// The headers describe the bucket closest to its limit, or the one that denied the request
+		var tightest *rateLimitResult
+		for i := range results {
+			result := &results[i]
+			if !result.allowed {
+				tightest = result
+				break
+			}
+			if tightest == nil || result.remaining() < tightest.remaining() {
+				tightest = result
+			}
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(tightest.rule.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(max(tightest.remaining(), 0)))
+		c.Header("X-RateLimit-Reset", seconds(tightest.resetAfter()))
+		if !tightest.allowed {
+			c.Header("Retry-After", seconds(tightest.retryAfter()))
+			abortWithError(c, errors.NewTooManyRequestsError(
+				"Too many requests: rate limit exceeded for "+class+" requests"))
+			return
+		}
+		c.Next()
+	}
+}
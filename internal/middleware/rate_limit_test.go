@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+)
+
+func TestRouteClass(t *testing.T) {
+	tests := []struct {
+		method string
+		route  string
+		want   string
+	}{
+		{"POST", "/api/v1/knowledge-chat/:session_id", RouteClassChat},
+		{"GET", "/api/v1/knowledge-bases/:id/hybrid-search", RouteClassChat},
+		{"POST", "/api/v1/mcp", RouteClassChat},
+		{"POST", "/api/v1/knowledge-bases/:id/knowledge/file", RouteClassIngest},
+		{"GET", "/api/v1/knowledge-bases/:id/faq/entries", RouteClassDefault},
+		{"POST", "/api/v1/knowledge-bases/:id/knowledge/url", RouteClassBrowser},
+		{"DELETE", "/api/v1/knowledge/:id", RouteClassDefault},
+		{"GET", "", RouteClassDefault},
+	}
+	for _, tt := range tests {
+		if got := routeClass(tt.method, tt.route); got != tt.want {
+			t.Errorf("routeClass(%s %s) = %q, want %q", tt.method, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitRules(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Classes: map[string]config.RateLimitRule{
+			RouteClassChat:    {Rate: 2, Burst: 20},
+			RouteClassDefault: {Rate: 10, Burst: 100},
+		},
+		Tenants: map[uint64]map[string]config.RateLimitRule{
+			7: {RouteClassChat: {Rate: 5, Burst: 50}, RouteClassIngest: {Rate: 0}},
+		},
+		APIKeys: map[string]map[string]config.RateLimitRule{
+			"key": {RouteClassDefault: {Rate: 1}},
+		},
+	}
+
+	if rule, ok := tenantRule(cfg, 1, RouteClassChat); !ok || rule.Burst != 20 {
+		t.Errorf("class limit not applied: %+v %v", rule, ok)
+	}
+	if rule, ok := tenantRule(cfg, 1, RouteClassIngest); !ok || rule.Burst != 100 {
+		t.Errorf("default limit not applied: %+v %v", rule, ok)
+	}
+	if rule, ok := tenantRule(cfg, 7, RouteClassChat); !ok || rule.Burst != 50 {
+		t.Errorf("tenant override not applied: %+v %v", rule, ok)
+	}
+	if _, ok := tenantRule(cfg, 7, RouteClassIngest); ok {
+		t.Error("zero rate override should lift the limit")
+	}
+	if rule, ok := apiKeyRule(cfg, "key", RouteClassChat); !ok || rule.Burst != 1 {
+		t.Errorf("API key limit not applied: %+v %v", rule, ok)
+	}
+	if _, ok := apiKeyRule(cfg, "other", RouteClassChat); ok {
+		t.Error("keys without limits should share their tenant's")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"runtime/debug"
 
 	"github.com/sirupsen/logrus"
+	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/gin-gonic/gin"
 )
@@ -27,10 +28,7 @@ func Recovery() gin.HandlerFunc {
 				})
 
 				// 返回500错误
-				c.AbortWithStatusJSON(500, gin.H{
-					"error":   "Internal Server Error",
-					"message": fmt.Sprintf("%v", err),
-				})
+				abortWithError(c, errors.NewInternalServerError("").WithDetails(fmt.Sprintf("%v", err)))
 			}
 		}()
 
@@ -7,8 +7,11 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/Tencent/WeKnora/internal/tracing"
 )
@@ -35,10 +38,16 @@ func TracingMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Continue the trace of the caller when it sent one
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
 		// Create new span
 		spanName := fmt.Sprintf("%s %s", c.Request.Method, c.FullPath())
-		ctx, span := tracing.ContextWithSpan(c.Request.Context(), spanName)
+		ctx, span := tracing.ContextWithSpan(ctx, spanName, trace.WithSpanKind(trace.SpanKindServer))
 		defer span.End()
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			c.Header("X-Trace-ID", spanContext.TraceID().String())
+		}
 
 		// Set basic span attributes
 		span.SetAttributes(
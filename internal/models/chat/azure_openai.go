@@ -0,0 +1,37 @@
+package chat
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/tracing"
+	"github.com/sashabaranov/go-openai"
+)
+
+// NewAzureOpenAIChat 创建 Azure OpenAI 聊天实例
+// 请求发送到模型的部署（deployment），并携带配置的 api-version
+func NewAzureOpenAIChat(chatConfig *ChatConfig) (*RemoteAPIChat, error) {
+	if chatConfig.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required for Azure OpenAI")
+	}
+	extra := chatConfig.ExtraConfig()
+	deployment := provider.AzureDeployment(extra, chatConfig.ModelName)
+
+	config := openai.DefaultAzureConfig(chatConfig.APIKey, strings.TrimRight(chatConfig.BaseURL, "/"))
+	config.APIVersion = provider.AzureAPIVersion(extra)
+	config.HTTPClient = &http.Client{Transport: tracing.Transport(nil)}
+	config.AzureModelMapperFunc = func(string) string {
+		return deployment
+	}
+
+	return &RemoteAPIChat{
+		modelName: chatConfig.ModelName,
+		client:    openai.NewClientWithConfig(config),
+		modelID:   chatConfig.ModelID,
+		baseURL:   chatConfig.BaseURL,
+		apiKey:    chatConfig.APIKey,
+		provider:  provider.ProviderAzureOpenAI,
+	}, nil
+}
@@ -0,0 +1,427 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/utils/bedrock"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// BedrockChat 基于 AWS Bedrock Converse API 的聊天实现
+// Converse API 为 Claude、Titan、Llama 等模型提供统一的消息和工具调用格式
+type BedrockChat struct {
+	modelName string
+	modelID   string
+	client    *bedrock.Client
+}
+
+// NewBedrockChat 创建 Bedrock 聊天实例，模型名称为 Bedrock 模型 ID 或推理配置文件 ID
+func NewBedrockChat(config *ChatConfig) (*BedrockChat, error) {
+	extra := config.ExtraConfig()
+	client, err := bedrock.NewClient(bedrock.Config{
+		Region: provider.BedrockRegion(extra, config.BaseURL),
+		Credentials: bedrock.Credentials{
+			AccessKeyID:     extra[provider.BedrockExtraAccessKeyID],
+			SecretAccessKey: config.APIKey,
+			SessionToken:    extra[provider.BedrockExtraSessionToken],
+		},
+		BaseURL: config.BaseURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BedrockChat{
+		modelName: config.ModelName,
+		modelID:   config.ModelID,
+		client:    client,
+	}, nil
+}
+
+// converseRequest is the request body of the Converse and ConverseStream APIs
+type converseRequest struct {
+	Messages        []converseMessage        `json:"messages"`
+	System          []converseContentBlock   `json:"system,omitempty"`
+	InferenceConfig *converseInferenceConfig `json:"inferenceConfig,omitempty"`
+	ToolConfig      *converseToolConfig      `json:"toolConfig,omitempty"`
+}
+
+type converseMessage struct {
+	Role    string                 `json:"role"`
+	Content []converseContentBlock `json:"content"`
+}
+
+type converseContentBlock struct {
+	Text             string                    `json:"text,omitempty"`
+	ToolUse          *converseToolUse          `json:"toolUse,omitempty"`
+	ToolResult       *converseToolResult       `json:"toolResult,omitempty"`
+	ReasoningContent *converseReasoningContent `json:"reasoningContent,omitempty"`
+}
+
+type converseToolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+type converseToolResult struct {
+	ToolUseID string                 `json:"toolUseId"`
+	Content   []converseContentBlock `json:"content"`
+}
+
+type converseReasoningContent struct {
+	ReasoningText *struct {
+		Text string `json:"text"`
+	} `json:"reasoningText,omitempty"`
+}
+
+type converseInferenceConfig struct {
+	MaxTokens   int     `json:"maxTokens,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+	TopP        float64 `json:"topP,omitempty"`
+}
+
+type converseToolConfig struct {
+	Tools      []converseTool `json:"tools"`
+	ToolChoice map[string]any `json:"toolChoice,omitempty"`
+}
+
+type converseTool struct {
+	ToolSpec converseToolSpec `json:"toolSpec"`
+}
+
+type converseToolSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema struct {
+		JSON json.RawMessage `json:"json"`
+	} `json:"inputSchema"`
+}
+
+type converseUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+// converseResponse is the response body of the Converse API
+type converseResponse struct {
+	Output struct {
+		Message converseMessage `json:"message"`
+	} `json:"output"`
+	StopReason string        `json:"stopReason"`
+	Usage      converseUsage `json:"usage"`
+}
+
+// converseStreamEvent is the payload of a ConverseStream event, only the fields of its event type are set
+type converseStreamEvent struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Start             *struct {
+		ToolUse *struct {
+			ToolUseID string `json:"toolUseId"`
+			Name      string `json:"name"`
+		} `json:"toolUse"`
+	} `json:"start"`
+	Delta *struct {
+		Text    string `json:"text"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse"`
+		ReasoningContent *struct {
+			Text string `json:"text"`
+		} `json:"reasoningContent"`
+	} `json:"delta"`
+	StopReason string         `json:"stopReason"`
+	Usage      *converseUsage `json:"usage"`
+	Message    string         `json:"message"`
+}
+
+// buildRequest converts the messages and options to a Converse request
+func (c *BedrockChat) buildRequest(messages []Message, opts *ChatOptions) *converseRequest {
+	req := &converseRequest{}
+	for _, msg := range messages {
+		var role string
+		var blocks []converseContentBlock
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" {
+				req.System = append(req.System, converseContentBlock{Text: msg.Content})
+			}
+			continue
+		case "tool":
+			// Tool results are sent back as a user message
+			role = "user"
+			blocks = []converseContentBlock{{ToolResult: &converseToolResult{
+				ToolUseID: msg.ToolCallID,
+				Content:   []converseContentBlock{{Text: nonEmpty(msg.Content)}},
+			}}}
+		case "assistant":
+			role = "assistant"
+			if msg.Content != "" {
+				blocks = append(blocks, converseContentBlock{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				blocks = append(blocks, converseContentBlock{ToolUse: &converseToolUse{
+					ToolUseID: tc.ID,
+					Name:      tc.Function.Name,
+					Input:     toolInput(tc.Function.Arguments),
+				}})
+			}
+		default:
+			role = "user"
+			if msg.Content != "" {
+				blocks = append(blocks, converseContentBlock{Text: msg.Content})
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		// Converse expects user and assistant turns to alternate, consecutive messages of a role are merged
+		if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == role {
+			req.Messages[n-1].Content = append(req.Messages[n-1].Content, blocks...)
+			continue
+		}
+		req.Messages = append(req.Messages, converseMessage{Role: role, Content: blocks})
+	}
+
+	if opts == nil {
+		return req
+	}
+	inference := &converseInferenceConfig{Temperature: opts.Temperature, TopP: opts.TopP, MaxTokens: opts.MaxTokens}
+	if opts.MaxCompletionTokens > 0 {
+		inference.MaxTokens = opts.MaxCompletionTokens
+	}
+	if inference.MaxTokens > 0 || inference.Temperature > 0 || inference.TopP > 0 {
+		req.InferenceConfig = inference
+	}
+
+	if len(opts.Tools) > 0 {
+		toolConfig := &converseToolConfig{}
+		for _, tool := range opts.Tools {
+			spec := converseToolSpec{Name: tool.Function.Name, Description: tool.Function.Description}
+			spec.InputSchema.JSON = tool.Function.Parameters
+			if len(spec.InputSchema.JSON) == 0 {
+				spec.InputSchema.JSON = json.RawMessage(`{"type":"object","properties":{}}`)
+			}
+			toolConfig.Tools = append(toolConfig.Tools, converseTool{ToolSpec: spec})
+		}
+		// Converse has no "none" choice, the tools stay declared so earlier tool calls remain valid
+		switch opts.ToolChoice {
+		case "", "none":
+		case "auto":
+			toolConfig.ToolChoice = map[string]any{"auto": struct{}{}}
+		case "required":
+			toolConfig.ToolChoice = map[string]any{"any": struct{}{}}
+		default:
+			toolConfig.ToolChoice = map[string]any{"tool": map[string]string{"name": opts.ToolChoice}}
+		}
+		req.ToolConfig = toolConfig
+	}
+
+	if len(opts.Format) > 0 && len(req.Messages) > 0 {
+		last := &req.Messages[len(req.Messages)-1]
+		last.Content = append(last.Content, converseContentBlock{
+			Text: fmt.Sprintf("Respond in JSON. Use this JSON schema: %s", opts.Format),
+		})
+	}
+	return req
+}
+
+// nonEmpty returns a placeholder for empty text, Converse rejects empty text blocks
+func nonEmpty(text string) string {
+	if text == "" {
+		return "(empty)"
+	}
+	return text
+}
+
+// toolInput converts tool call arguments to the JSON object Converse expects
+func toolInput(arguments string) json.RawMessage {
+	if arguments == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage(`{}`)
+	}
+	return json.RawMessage(arguments)
+}
+
+// finishReason maps a Converse stop reason to the OpenAI finish reasons used elsewhere
+func finishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens":
+		return "length"
+	}
+	return stopReason
+}
+
+// Chat 进行非流式聊天
+func (c *BedrockChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	req := c.buildRequest(messages, opts)
+	logger.Infof(ctx, "[LLM Request] model=%s, provider=bedrock, stream=false, messages=%d", c.modelName, len(req.Messages))
+
+	var resp converseResponse
+	if err := c.client.Invoke(ctx, c.modelName, "converse", req, &resp); err != nil {
+		return nil, fmt.Errorf("bedrock converse: %w", err)
+	}
+
+	response := &types.ChatResponse{FinishReason: finishReason(resp.StopReason)}
+	response.Usage.PromptTokens = resp.Usage.InputTokens
+	response.Usage.CompletionTokens = resp.Usage.OutputTokens
+	response.Usage.TotalTokens = resp.Usage.TotalTokens
+	for _, block := range resp.Output.Message.Content {
+		response.Content += block.Text
+		if block.ToolUse != nil {
+			response.ToolCalls = append(response.ToolCalls, types.LLMToolCall{
+				ID:   block.ToolUse.ToolUseID,
+				Type: "function",
+				Function: types.FunctionCall{
+					Name:      block.ToolUse.Name,
+					Arguments: string(block.ToolUse.Input),
+				},
+			})
+		}
+	}
+	return response, nil
+}
+
+// ChatStream 进行流式聊天
+func (c *BedrockChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	req := c.buildRequest(messages, opts)
+	logger.Infof(ctx, "[LLM Request] model=%s, provider=bedrock, stream=true, messages=%d", c.modelName, len(req.Messages))
+
+	reader, err := c.client.Stream(ctx, c.modelName, "converse-stream", req)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock converse stream: %w", err)
+	}
+
+	streamChan := make(chan types.StreamResponse)
+	go c.processStream(ctx, reader, streamChan)
+	return streamChan, nil
+}
+
+// processStream 将 ConverseStream 事件转换为流式响应
+func (c *BedrockChat) processStream(ctx context.Context, reader *bedrock.EventReader, streamChan chan types.StreamResponse) {
+	defer close(streamChan)
+	defer reader.Close()
+
+	// Tool calls in the order the model started them, keyed by content block index
+	var toolCalls []*types.LLMToolCall
+	toolCallIndex := make(map[int]*types.LLMToolCall)
+	buildToolCalls := func() []types.LLMToolCall {
+		if len(toolCalls) == 0 {
+			return nil
+		}
+		result := make([]types.LLMToolCall, 0, len(toolCalls))
+		for _, tc := range toolCalls {
+			result = append(result, *tc)
+		}
+		return result
+	}
+	var usage *types.TokenUsage
+	hasThinking := false
+
+	for {
+		event, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				streamChan <- types.StreamResponse{
+					ResponseType: types.ResponseTypeAnswer,
+					Done:         true,
+					ToolCalls:    buildToolCalls(),
+					Usage:        usage,
+				}
+				return
+			}
+			logger.Errorf(ctx, "Bedrock stream read error: %v", err)
+			streamChan <- types.StreamResponse{ResponseType: types.ResponseTypeError, Content: err.Error(), Done: true}
+			return
+		}
+
+		var payload converseStreamEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			logger.Errorf(ctx, "Failed to parse Bedrock stream event %s: %v", event.Type, err)
+			continue
+		}
+		if event.MessageType != "event" {
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeError,
+				Content:      fmt.Sprintf("Bedrock stream %s: %s", event.ExceptionType, payload.Message),
+				Done:         true,
+			}
+			return
+		}
+
+		switch event.Type {
+		case "contentBlockStart":
+			if payload.Start == nil || payload.Start.ToolUse == nil {
+				continue
+			}
+			tc := &types.LLMToolCall{
+				ID:       payload.Start.ToolUse.ToolUseID,
+				Type:     "function",
+				Function: types.FunctionCall{Name: payload.Start.ToolUse.Name},
+			}
+			toolCalls = append(toolCalls, tc)
+			toolCallIndex[payload.ContentBlockIndex] = tc
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeToolCall,
+				Data: map[string]interface{}{
+					"tool_name":    tc.Function.Name,
+					"tool_call_id": tc.ID,
+				},
+			}
+		case "contentBlockDelta":
+			if payload.Delta == nil {
+				continue
+			}
+			if payload.Delta.ToolUse != nil {
+				if tc, ok := toolCallIndex[payload.ContentBlockIndex]; ok {
+					tc.Function.Arguments += payload.Delta.ToolUse.Input
+				}
+			}
+			if payload.Delta.ReasoningContent != nil && payload.Delta.ReasoningContent.Text != "" {
+				hasThinking = true
+				streamChan <- types.StreamResponse{
+					ResponseType: types.ResponseTypeThinking,
+					Content:      payload.Delta.ReasoningContent.Text,
+				}
+			}
+			if payload.Delta.Text != "" {
+				if hasThinking {
+					streamChan <- types.StreamResponse{ResponseType: types.ResponseTypeThinking, Done: true}
+					hasThinking = false
+				}
+				streamChan <- types.StreamResponse{
+					ResponseType: types.ResponseTypeAnswer,
+					Content:      payload.Delta.Text,
+					ToolCalls:    buildToolCalls(),
+				}
+			}
+		case "metadata":
+			if payload.Usage != nil {
+				usage = &types.TokenUsage{
+					PromptTokens:     payload.Usage.InputTokens,
+					CompletionTokens: payload.Usage.OutputTokens,
+					TotalTokens:      payload.Usage.TotalTokens,
+				}
+			}
+		}
+	}
+}
+
+// GetModelName 获取模型名称
+func (c *BedrockChat) GetModelName() string {
+	return c.modelName
+}
+
+// GetModelID 获取模型ID
+func (c *BedrockChat) GetModelID() string {
+	return c.modelID
+}
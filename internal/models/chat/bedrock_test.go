@@ -0,0 +1,50 @@
+package chat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBedrockBuildRequest(t *testing.T) {
+	c := &BedrockChat{modelName: "anthropic.claude-3-5-sonnet-20240620-v1:0"}
+	req := c.buildRequest([]Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "weather?"},
+		{Role: "assistant", ToolCalls: []ToolCall{{
+			ID: "call-1", Type: "function",
+			Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+		}}},
+		{Role: "tool", ToolCallID: "call-1", Content: "sunny"},
+		{Role: "user", Content: "thanks"},
+	}, &ChatOptions{
+		Temperature: 0.3,
+		MaxTokens:   256,
+		ToolChoice:  "required",
+		Tools: []Tool{{Type: "function", Function: FunctionDef{
+			Name: "get_weather", Parameters: json.RawMessage(`{"type":"object"}`),
+		}}},
+	})
+
+	require.Len(t, req.System, 1)
+	assert.Equal(t, "be brief", req.System[0].Text)
+
+	// The tool result and the following question are merged into one user turn
+	require.Len(t, req.Messages, 3)
+	assert.Equal(t, "user", req.Messages[0].Role)
+	assert.Equal(t, "assistant", req.Messages[1].Role)
+	require.NotNil(t, req.Messages[1].Content[0].ToolUse)
+	assert.JSONEq(t, `{"city":"Paris"}`, string(req.Messages[1].Content[0].ToolUse.Input))
+	assert.Equal(t, "user", req.Messages[2].Role)
+	require.Len(t, req.Messages[2].Content, 2)
+	assert.Equal(t, "call-1", req.Messages[2].Content[0].ToolResult.ToolUseID)
+	assert.Equal(t, "thanks", req.Messages[2].Content[1].Text)
+
+	require.NotNil(t, req.InferenceConfig)
+	assert.Equal(t, 256, req.InferenceConfig.MaxTokens)
+	require.NotNil(t, req.ToolConfig)
+	assert.Contains(t, req.ToolConfig.ToolChoice, "any")
+	assert.JSONEq(t, `{"type":"object"}`, string(req.ToolConfig.Tools[0].ToolSpec.InputSchema.JSON))
+}
@@ -86,6 +86,17 @@ type ChatConfig struct {
 	Extra     map[string]any
 }
 
+// ExtraConfig returns the string values of Extra, the provider-specific settings of the model
+func (c *ChatConfig) ExtraConfig() map[string]string {
+	extra := make(map[string]string, len(c.Extra))
+	for key, value := range c.Extra {
+		if s, ok := value.(string); ok {
+			extra[key] = s
+		}
+	}
+	return extra
+}
+
 // NewChat 创建聊天实例
 func NewChat(config *ChatConfig, ollamaService *ollama.OllamaService) (Chat, error) {
 	switch strings.ToLower(string(config.Source)) {
@@ -118,9 +129,22 @@ func NewRemoteChat(config *ChatConfig) (Chat, error) {
 	case provider.ProviderDeepSeek:
 		// DeepSeek 不支持 tool_choice
 		return NewDeepSeekChat(config)
-	case provider.ProviderGeneric:
+	case provider.ProviderGeneric, provider.ProviderVLLM:
 		// Generic provider (如 vLLM) 使用 ChatTemplateKwargs
 		return NewGenericChat(config)
+	case provider.ProviderAzureOpenAI:
+		// Azure OpenAI 按部署名称寻址，并需要 api-version 参数
+		return NewAzureOpenAIChat(config)
+	case provider.ProviderBedrock:
+		// AWS Bedrock 使用 Converse API 和 SigV4 签名
+		return NewBedrockChat(config)
+	case provider.ProviderOllama:
+		// 租户自行配置的 Ollama 服务使用原生 API
+		ollamaService, err := ollama.GetOllamaServiceForURL(config.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewOllamaChat(config, ollamaService)
 	default:
 		// 其他 provider 使用标准 OpenAI 兼容实现
 		return NewRemoteAPIChat(config)
@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/health"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// fallbackChat calls the first healthy model of a chain, failing over to the next one when a call
+// fails because the endpoint is unhealthy
+type fallbackChat struct {
+	chain    []Chat
+	registry *health.Registry
+}
+
+// NewFallbackChat chains chat models so that calls fail over from the first model to the next ones.
+// The health of each model is tracked in registry, and models whose circuit is open are skipped.
+func NewFallbackChat(chain []Chat, registry *health.Registry) Chat {
+	if len(chain) == 1 {
+		return chain[0]
+	}
+	return &fallbackChat{chain: chain, registry: registry}
+}
+
+// candidates returns the models to try in order: the healthy ones first, then the ones whose
+// circuit is open, so that a call is still attempted when every endpoint looks unhealthy
+func (c *fallbackChat) candidates() []Chat {
+	healthy := make([]Chat, 0, len(c.chain))
+	var open []Chat
+	for _, model := range c.chain {
+		if c.registry.Allow(model.GetModelID()) {
+			healthy = append(healthy, model)
+		} else {
+			open = append(open, model)
+		}
+	}
+	return append(healthy, open...)
+}
+
+// call runs fn against the chain until a model succeeds or fails with an error that is not the
+// endpoint's fault
+func call[T any](ctx context.Context, c *fallbackChat, fn func(Chat) (T, error)) (T, error) {
+	var zero T
+	var errs []error
+	for i, model := range c.candidates() {
+		result, err := fn(model)
+		if err == nil {
+			c.registry.RecordSuccess(model.GetModelID())
+			if i > 0 {
+				logger.Warnf(ctx, "Chat model %s failed over to %s", c.GetModelID(), model.GetModelID())
+			}
+			return result, nil
+		}
+		if ctx.Err() != nil || !health.IsFailoverError(err) {
+			return zero, err
+		}
+		c.registry.RecordFailure(model.GetModelID(), err)
+		logger.Warnf(ctx, "Chat model %s is unavailable: %v", model.GetModelID(), err)
+		errs = append(errs, err)
+	}
+	return zero, errors.Join(errs...)
+}
+
+// Chat runs a non-streaming chat on the first model that answers
+func (c *fallbackChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	return call(ctx, c, func(model Chat) (*types.ChatResponse, error) {
+		return model.Chat(ctx, messages, opts)
+	})
+}
+
+// ChatStream opens a streaming chat on the first model that accepts it. Once a stream has started
+// it is not moved to another model, since part of the answer may already have been sent.
+func (c *fallbackChat) ChatStream(ctx context.Context,
+	messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	return call(ctx, c, func(model Chat) (<-chan types.StreamResponse, error) {
+		return model.ChatStream(ctx, messages, opts)
+	})
+}
+
+// GetModelName returns the name of the primary model
+func (c *fallbackChat) GetModelName() string {
+	return c.chain[0].GetModelName()
+}
+
+// GetModelID returns the ID of the primary model
+func (c *fallbackChat) GetModelID() string {
+	return c.chain[0].GetModelID()
+}
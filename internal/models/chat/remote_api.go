@@ -11,6 +11,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/sashabaranov/go-openai"
 )
@@ -34,6 +35,7 @@ type RemoteAPIChat struct {
 func NewRemoteAPIChat(chatConfig *ChatConfig) (*RemoteAPIChat, error) {
 	apiKey := chatConfig.APIKey
 	config := openai.DefaultConfig(apiKey)
+	config.HTTPClient = &http.Client{Transport: tracing.Transport(nil)}
 	if baseURL := chatConfig.BaseURL; baseURL != "" {
 		config.BaseURL = baseURL
 	}
@@ -102,6 +104,10 @@ func (c *RemoteAPIChat) BuildChatCompletionRequest(messages []Message, opts *Cha
 		Messages: c.ConvertMessages(messages),
 		Stream:   isStream,
 	}
+	if isStream {
+		// Ask for the usage chunk at the end of the stream, used for token accounting
+		req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
 
 	if opts != nil {
 		if opts.Temperature > 0 {
@@ -215,7 +221,7 @@ func (c *RemoteAPIChat) chatWithRawHTTP(ctx context.Context, customReq any) (*ty
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	client := &http.Client{}
+	client := &http.Client{Transport: tracing.Transport(nil)}
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("send request: %w", err)
@@ -346,7 +352,7 @@ func (c *RemoteAPIChat) chatStreamWithRawHTTP(ctx context.Context, customReq any
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	httpReq.Header.Set("Accept", "text/event-stream")
 
-	client := &http.Client{}
+	client := &http.Client{Transport: tracing.Transport(nil)}
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("send request: %w", err)
@@ -381,6 +387,7 @@ func (c *RemoteAPIChat) processStream(ctx context.Context, stream *openai.ChatCo
 					Content:      "",
 					Done:         true,
 					ToolCalls:    state.buildOrderedToolCalls(),
+					Usage:        state.usage,
 				}
 			} else {
 				streamChan <- types.StreamResponse{
@@ -392,6 +399,9 @@ func (c *RemoteAPIChat) processStream(ctx context.Context, stream *openai.ChatCo
 			return
 		}
 
+		if response.Usage != nil {
+			state.setUsage(response.Usage)
+		}
 		if len(response.Choices) > 0 {
 			c.processStreamDelta(ctx, &response.Choices[0], state, streamChan)
 		}
@@ -430,6 +440,7 @@ func (c *RemoteAPIChat) processRawHTTPStream(ctx context.Context, resp *http.Res
 				Content:      "",
 				Done:         true,
 				ToolCalls:    state.buildOrderedToolCalls(),
+				Usage:        state.usage,
 			}
 			return
 		}
@@ -444,6 +455,9 @@ func (c *RemoteAPIChat) processRawHTTPStream(ctx context.Context, resp *http.Res
 			continue
 		}
 
+		if streamResp.Usage != nil {
+			state.setUsage(streamResp.Usage)
+		}
 		if len(streamResp.Choices) > 0 {
 			c.processStreamDelta(ctx, &streamResp.Choices[0], state, streamChan)
 		}
@@ -456,6 +470,7 @@ type streamState struct {
 	lastFunctionName map[int]string
 	nameNotified     map[int]bool
 	hasThinking      bool
+	usage            *types.TokenUsage
 }
 
 func newStreamState() *streamState {
@@ -467,6 +482,15 @@ func newStreamState() *streamState {
 	}
 }
 
+// setUsage keeps the usage reported by the provider, sent with the final response
+func (s *streamState) setUsage(usage *openai.Usage) {
+	s.usage = &types.TokenUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+}
+
 func (s *streamState) buildOrderedToolCalls() []types.LLMToolCall {
 	if len(s.toolCallMap) == 0 {
 		return nil
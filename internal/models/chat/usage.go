@@ -0,0 +1,136 @@
+package chat
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// UsageRecorder receives the token usage of every call made through a usage tracking chat model
+type UsageRecorder interface {
+	RecordTokenUsage(ctx context.Context, model *types.Model, usage types.TokenUsage)
+}
+
+// usageTrackingChat reports the token usage of the wrapped chat model, estimating it locally
+// when the provider does not return usage fields
+type usageTrackingChat struct {
+	inner    Chat
+	model    *types.Model
+	recorder UsageRecorder
+}
+
+// NewUsageTrackingChat wraps a chat model so that the usage of each call is reported to recorder
+func NewUsageTrackingChat(chatModel Chat, model *types.Model, recorder UsageRecorder) Chat {
+	if recorder == nil {
+		return chatModel
+	}
+	return &usageTrackingChat{inner: chatModel, model: model, recorder: recorder}
+}
+
+// Chat runs a non-streaming chat and records its usage
+func (c *usageTrackingChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	response, err := c.inner.Chat(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	usage := types.TokenUsage{
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+		TotalTokens:      response.Usage.TotalTokens,
+	}
+	c.record(ctx, messages, opts, completionText(response.Content, response.ToolCalls), &usage)
+	return response, nil
+}
+
+// ChatStream runs a streaming chat and records its usage once the stream ends
+func (c *usageTrackingChat) ChatStream(ctx context.Context,
+	messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	stream, err := c.inner.ChatStream(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan types.StreamResponse)
+	go func() {
+		defer close(out)
+		var content strings.Builder
+		var toolCalls []types.LLMToolCall
+		var usage *types.TokenUsage
+		for response := range stream {
+			switch response.ResponseType {
+			case types.ResponseTypeAnswer, types.ResponseTypeThinking:
+				content.WriteString(response.Content)
+			}
+			if len(response.ToolCalls) > 0 {
+				// Tool calls are re-sent whole as their arguments stream in
+				toolCalls = response.ToolCalls
+			}
+			if response.Usage != nil {
+				usage = response.Usage
+			}
+			out <- response
+		}
+		c.record(ctx, messages, opts, completionText(content.String(), toolCalls), usage)
+	}()
+	return out, nil
+}
+
+// GetModelName returns the name of the wrapped model
+func (c *usageTrackingChat) GetModelName() string {
+	return c.inner.GetModelName()
+}
+
+// GetModelID returns the ID of the wrapped model
+func (c *usageTrackingChat) GetModelID() string {
+	return c.inner.GetModelID()
+}
+
+// record reports the usage of a call, estimating it when the provider returned none
+func (c *usageTrackingChat) record(ctx context.Context,
+	messages []Message, opts *ChatOptions, completion string, usage *types.TokenUsage,
+) {
+	if usage == nil || (usage.TotalTokens <= 0 && usage.PromptTokens <= 0 && usage.CompletionTokens <= 0) {
+		usage = &types.TokenUsage{
+			PromptTokens:     types.EstimateTokens(promptText(messages, opts)),
+			CompletionTokens: types.EstimateTokens(completion),
+			Estimated:        true,
+		}
+	}
+	if usage.TotalTokens <= 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	c.recorder.RecordTokenUsage(context.WithoutCancel(ctx), c.model, *usage)
+}
+
+// promptText joins the text sent to the model, used to estimate prompt tokens
+func promptText(messages []Message, opts *ChatOptions) string {
+	var b strings.Builder
+	for _, message := range messages {
+		b.WriteString(message.Content)
+		b.WriteString("\n")
+		for _, call := range message.ToolCalls {
+			b.WriteString(call.Function.Name)
+			b.WriteString(call.Function.Arguments)
+		}
+	}
+	if opts != nil {
+		for _, tool := range opts.Tools {
+			b.WriteString(tool.Function.Name)
+			b.WriteString(tool.Function.Description)
+			b.Write(tool.Function.Parameters)
+		}
+	}
+	return b.String()
+}
+
+// completionText joins the text generated by the model, used to estimate completion tokens
+func completionText(content string, toolCalls []types.LLMToolCall) string {
+	var b strings.Builder
+	b.WriteString(content)
+	for _, call := range toolCalls {
+		b.WriteString(call.Function.Name)
+		b.WriteString(call.Function.Arguments)
+	}
+	return b.String()
+}
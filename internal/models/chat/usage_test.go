@@ -0,0 +1,70 @@
+package chat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubChat struct {
+	responses []types.StreamResponse
+}
+
+func (c *stubChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	return &types.ChatResponse{Content: "answer"}, nil
+}
+
+func (c *stubChat) ChatStream(ctx context.Context,
+	messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	stream := make(chan types.StreamResponse, len(c.responses))
+	for _, response := range c.responses {
+		stream <- response
+	}
+	close(stream)
+	return stream, nil
+}
+
+func (c *stubChat) GetModelName() string { return "stub" }
+
+func (c *stubChat) GetModelID() string { return "stub" }
+
+type usageCollector struct {
+	usages []types.TokenUsage
+}
+
+func (r *usageCollector) RecordTokenUsage(ctx context.Context, model *types.Model, usage types.TokenUsage) {
+	r.usages = append(r.usages, usage)
+}
+
+func TestUsageTrackingChatStream(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "What is WeKnora?"}}
+	drain := func(chatModel Chat) {
+		stream, err := chatModel.ChatStream(context.Background(), messages, nil)
+		require.NoError(t, err)
+		for range stream {
+		}
+	}
+
+	recorder := &usageCollector{}
+	drain(NewUsageTrackingChat(&stubChat{responses: []types.StreamResponse{
+		{ResponseType: types.ResponseTypeAnswer, Content: "A knowledge base framework."},
+		{ResponseType: types.ResponseTypeAnswer, Done: true,
+			Usage: &types.TokenUsage{PromptTokens: 12, CompletionTokens: 6}},
+	}}, &types.Model{ID: "m1"}, recorder))
+	require.Len(t, recorder.usages, 1)
+	assert.Equal(t, types.TokenUsage{PromptTokens: 12, CompletionTokens: 6, TotalTokens: 18}, recorder.usages[0])
+
+	recorder = &usageCollector{}
+	drain(NewUsageTrackingChat(&stubChat{responses: []types.StreamResponse{
+		{ResponseType: types.ResponseTypeAnswer, Content: "A knowledge base framework."},
+		{ResponseType: types.ResponseTypeAnswer, Done: true},
+	}}, &types.Model{ID: "m1"}, recorder))
+	require.Len(t, recorder.usages, 1)
+	assert.True(t, recorder.usages[0].Estimated)
+	assert.Positive(t, recorder.usages[0].PromptTokens)
+	assert.Positive(t, recorder.usages[0].CompletionTokens)
+}
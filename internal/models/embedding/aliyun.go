@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
 )
 
 const (
@@ -45,7 +46,8 @@ type AliyunEmbedInput struct {
 
 // AliyunContent represents a single content item in the input
 type AliyunContent struct {
-	Text string `json:"text,omitempty"`
+	Text  string `json:"text,omitempty"`
+	Image string `json:"image,omitempty"`
 }
 
 // AliyunEmbedResponse represents an Aliyun DashScope embedding response
@@ -95,7 +97,8 @@ func NewAliyunEmbedder(apiKey, baseURL, modelName string,
 	timeout := 60 * time.Second
 
 	client := &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: tracing.Transport(nil),
 	}
 
 	return &AliyunEmbedder{
@@ -172,7 +175,24 @@ func (e *AliyunEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]fl
 	for _, text := range texts {
 		contents = append(contents, AliyunContent{Text: text})
 	}
+	return e.embedContents(ctx, contents)
+}
+
+// EmbedImages converts images (data URIs or URLs) to vectors in the same space as text
+func (e *AliyunEmbedder) EmbedImages(ctx context.Context, images []string) ([][]float32, error) {
+	// Images are sent one per request, the response only indexes text inputs
+	embeddings := make([][]float32, len(images))
+	for i, image := range images {
+		result, err := e.embedContents(ctx, []AliyunContent{{Image: image}})
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = result[0]
+	}
+	return embeddings, nil
+}
 
+func (e *AliyunEmbedder) embedContents(ctx context.Context, contents []AliyunContent) ([][]float32, error) {
 	// Create request body
 	reqBody := AliyunEmbedRequest{
 		Model: e.modelName,
@@ -221,7 +241,7 @@ func (e *AliyunEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]fl
 	}
 
 	// Extract embedding vectors, preserving order by text_index
-	embeddings := make([][]float32, len(texts))
+	embeddings := make([][]float32, len(contents))
 	for _, emb := range response.Output.Embeddings {
 		if emb.TextIndex >= 0 && emb.TextIndex < len(embeddings) {
 			embeddings[emb.TextIndex] = emb.Embedding
@@ -0,0 +1,128 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
+)
+
+// AzureOpenAIEmbedder implements text vectorization using an Azure OpenAI embedding deployment
+type AzureOpenAIEmbedder struct {
+	apiKey     string
+	endpoint   string
+	modelName  string
+	dimensions int
+	modelID    string
+	httpClient *http.Client
+	EmbedderPooler
+}
+
+// azureEmbedRequest is the request body of the Azure OpenAI embeddings API, the model is
+// given by the deployment in the URL
+type azureEmbedRequest struct {
+	Input          []string `json:"input"`
+	EncodingFormat string   `json:"encoding_format,omitempty"`
+}
+
+// NewAzureOpenAIEmbedder creates a new Azure OpenAI embedder
+func NewAzureOpenAIEmbedder(apiKey, baseURL, modelName, deployment, apiVersion string,
+	dimensions int, modelID string, pooler EmbedderPooler,
+) (*AzureOpenAIEmbedder, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("base URL is required for Azure OpenAI")
+	}
+	if deployment == "" {
+		return nil, fmt.Errorf("deployment name is required")
+	}
+	endpoint := fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s",
+		strings.TrimRight(baseURL, "/"), url.PathEscape(deployment), url.QueryEscape(apiVersion))
+
+	return &AzureOpenAIEmbedder{
+		apiKey:         apiKey,
+		endpoint:       endpoint,
+		modelName:      modelName,
+		dimensions:     dimensions,
+		modelID:        modelID,
+		httpClient:     &http.Client{Timeout: 60 * time.Second, Transport: tracing.Transport(nil)},
+		EmbedderPooler: pooler,
+	}, nil
+}
+
+// Embed converts text to vector
+func (e *AzureOpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.BatchEmbed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+	return embeddings[0], nil
+}
+
+// BatchEmbed converts multiple texts to vectors in batch
+func (e *AzureOpenAIEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonData, err := json.Marshal(azureEmbedRequest{Input: texts, EncodingFormat: "float"})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("api-key", e.apiKey)
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("AzureOpenAIEmbedder send request error: %v", err)
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.GetLogger(ctx).Errorf("AzureOpenAIEmbedder API error: Http Status %s, body: %s", resp.Status, string(body))
+		return nil, fmt.Errorf("EmbedBatch API error: Http Status %s", resp.Status)
+	}
+
+	var response OpenAIEmbedResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, data := range response.Data {
+		if data.Index >= 0 && data.Index < len(embeddings) {
+			embeddings[data.Index] = data.Embedding
+		}
+	}
+	return embeddings, nil
+}
+
+// GetModelName returns the model name
+func (e *AzureOpenAIEmbedder) GetModelName() string {
+	return e.modelName
+}
+
+// GetDimensions returns the vector dimensions
+func (e *AzureOpenAIEmbedder) GetDimensions() int {
+	return e.dimensions
+}
+
+// GetModelID returns the model ID
+func (e *AzureOpenAIEmbedder) GetModelID() string {
+	return e.modelID
+}
@@ -2,20 +2,73 @@ package embedding
 
 import (
 	"context"
+	"math/rand/v2"
+	"net/http"
 	"os"
 	"strconv"
 	"sync"
+	"time"
 
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/health"
 	"github.com/Tencent/WeKnora/internal/models/utils"
 	"github.com/panjf2000/ants/v2"
 )
 
+const (
+	// defaultMaxConcurrency bounds the embedding requests in flight across all models, overridden by
+	// EMBEDDING_MAX_CONCURRENCY
+	defaultMaxConcurrency = 4
+	// maxRateLimitRetries is how many times a batch rejected with 429 is retried
+	maxRateLimitRetries = 4
+	// rateLimitBackoff is the base delay before the first retry, doubled on each attempt
+	rateLimitBackoff = time.Second
+	// maxRateLimitBackoff caps the delay between retries
+	maxRateLimitBackoff = 30 * time.Second
+)
+
 type batchEmbedder struct {
 	pool *ants.Pool
+	// limiter is shared by the poolers of all models so the limit is global
+	limiter chan struct{}
+	// batchSize is the number of texts sent per request, zero before a provider is applied
+	batchSize int
 }
 
 func NewBatchEmbedder(pool *ants.Pool) EmbedderPooler {
-	return &batchEmbedder{pool: pool}
+	maxConcurrency := defaultMaxConcurrency
+	if v, err := strconv.Atoi(os.Getenv("EMBEDDING_MAX_CONCURRENCY")); err == nil && v > 0 {
+		maxConcurrency = v
+	}
+	return &batchEmbedder{pool: pool, limiter: make(chan struct{}, maxConcurrency)}
+}
+
+// withBatchSize returns a pooler that sends batches of the given size, sharing the pool and the
+// concurrency limit of pooler. Other pooler implementations are returned unchanged.
+func withBatchSize(pooler EmbedderPooler, batchSize int) EmbedderPooler {
+	e, ok := pooler.(*batchEmbedder)
+	if !ok {
+		return pooler
+	}
+	return &batchEmbedder{pool: e.pool, limiter: e.limiter, batchSize: batchSize}
+}
+
+// effectiveBatchSize returns the provider's batch size, capped by BATCH_EMBED_SIZE when it is set
+func (e *batchEmbedder) effectiveBatchSize() (int, error) {
+	batchSize := e.batchSize
+	if batchSize <= 0 {
+		batchSize = defaultMaxBatchSize
+	}
+	if batchSizeStr := os.Getenv("BATCH_EMBED_SIZE"); batchSizeStr != "" {
+		limit, err := strconv.Atoi(batchSizeStr)
+		if err != nil {
+			return 0, err
+		}
+		if limit > 0 && limit < batchSize {
+			batchSize = limit
+		}
+	}
+	return batchSize, nil
 }
 
 type textEmbedding struct {
@@ -28,28 +81,31 @@ func (e *batchEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder,
 	var wg sync.WaitGroup
 	var mu sync.Mutex  // For synchronizing access to error
 	var firstErr error // Record the first error that occurs
-	batchSizeStr := os.Getenv("BATCH_EMBED_SIZE")
-	if batchSizeStr == "" {
-		batchSizeStr = "5"
-	}
-	batchSize, err := strconv.Atoi(batchSizeStr)
+	batchSize, err := e.effectiveBatchSize()
 	if err != nil {
 		return nil, err
 	}
 	textEmbeddings := utils.MapSlice(texts, func(text string) *textEmbedding {
 		return &textEmbedding{text: text}
 	})
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	stats := metricsFor(model.GetModelID())
+	start := time.Now()
 
 	// Function to process each document chunk
 	processChunk := func(texts []*textEmbedding) func() {
 		return func() {
 			defer wg.Done()
 			// If an error has already occurred, don't continue processing
-			if firstErr != nil {
+			if failed() {
 				return
 			}
 			// Embed text
-			embedding, err := model.BatchEmbed(ctx, utils.MapSlice(texts, func(text *textEmbedding) string {
+			embedding, err := e.embedWithRetry(ctx, model, stats, utils.MapSlice(texts, func(text *textEmbedding) string {
 				return text.text
 			}))
 			if err != nil {
@@ -62,7 +118,7 @@ func (e *batchEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder,
 			}
 			mu.Lock()
 			for i, text := range texts {
-				if text == nil {
+				if text == nil || i >= len(embedding) {
 					continue
 				}
 				text.results = embedding[i]
@@ -76,6 +132,8 @@ func (e *batchEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder,
 		wg.Add(1)
 		err := e.pool.Submit(processChunk(texts))
 		if err != nil {
+			wg.Done()
+			wg.Wait()
 			return nil, err
 		}
 	}
@@ -87,9 +145,45 @@ func (e *batchEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder,
 	if firstErr != nil {
 		return nil, firstErr
 	}
+	stats.recordRun(len(texts), time.Since(start))
 
 	results := utils.MapSlice(textEmbeddings, func(text *textEmbedding) []float32 {
 		return text.results
 	})
 	return results, nil
 }
+
+// embedWithRetry embeds one batch under the global concurrency limit, retrying with jittered
+// exponential backoff when the provider rate limits the request
+func (e *batchEmbedder) embedWithRetry(ctx context.Context,
+	model Embedder, stats *modelMetrics, texts []string,
+) ([][]float32, error) {
+	backoff := rateLimitBackoff
+	for attempt := 0; ; attempt++ {
+		select {
+		case e.limiter <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		start := time.Now()
+		embeddings, err := model.BatchEmbed(ctx, texts)
+		<-e.limiter
+		stats.recordRequest(len(texts), time.Since(start), err)
+
+		if err == nil || health.StatusCode(err) != http.StatusTooManyRequests || attempt >= maxRateLimitRetries {
+			return embeddings, err
+		}
+
+		// Jitter spreads the retries of concurrent batches over the second half of the backoff window
+		delay := backoff/2 + rand.N(backoff/2+1)
+		stats.recordRetry()
+		logger.GetLogger(ctx).Warnf("Embedding model %s rate limited, retrying batch of %d in %v (%d/%d)",
+			model.GetModelName(), len(texts), delay, attempt+1, maxRateLimitRetries)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff = min(backoff*2, maxRateLimitBackoff)
+	}
+}
@@ -0,0 +1,88 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/panjf2000/ants/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbedder returns the length of each text as its vector and rate limits its first call
+type fakeEmbedder struct {
+	mu         sync.Mutex
+	calls      int
+	batchSizes []int
+	limited    bool
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text))}, nil
+}
+
+func (f *fakeEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if !f.limited {
+		f.limited = true
+		return nil, fmt.Errorf("EmbedBatch API error: Http Status 429 Too Many Requests")
+	}
+	f.batchSizes = append(f.batchSizes, len(texts))
+	result := make([][]float32, len(texts))
+	for i, text := range texts {
+		result[i] = []float32{float32(len(text))}
+	}
+	return result, nil
+}
+
+func (f *fakeEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	return nil, nil
+}
+
+func (f *fakeEmbedder) GetModelName() string { return "fake" }
+func (f *fakeEmbedder) GetDimensions() int   { return 1 }
+func (f *fakeEmbedder) GetModelID() string   { return "fake-batch-test" }
+
+func TestBatchEmbedWithPoolRetriesRateLimit(t *testing.T) {
+	t.Setenv("BATCH_EMBED_SIZE", "")
+	pool, err := ants.NewPool(2)
+	require.NoError(t, err)
+	defer pool.Release()
+
+	pooler := withBatchSize(NewBatchEmbedder(pool), 3)
+	model := &fakeEmbedder{}
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff", "g"}
+
+	vectors, err := pooler.BatchEmbedWithPool(context.Background(), model, texts)
+	require.NoError(t, err)
+	require.Len(t, vectors, len(texts))
+	for i, text := range texts {
+		assert.Equal(t, float32(len(text)), vectors[i][0])
+	}
+	// Seven texts in batches of three, plus the rate limited attempt
+	assert.Equal(t, 4, model.calls)
+	assert.ElementsMatch(t, []int{3, 3, 1}, model.batchSizes)
+
+	stats := GetStats("fake-batch-test")
+	assert.Equal(t, int64(4), stats.Requests)
+	assert.Equal(t, int64(7), stats.Texts)
+	assert.Equal(t, int64(1), stats.RateLimited)
+	assert.Equal(t, int64(1), stats.Retries)
+}
+
+func TestBatchSizeCappedByEnv(t *testing.T) {
+	t.Setenv("BATCH_EMBED_SIZE", "5")
+	e := &batchEmbedder{batchSize: 128}
+	size, err := e.effectiveBatchSize()
+	require.NoError(t, err)
+	assert.Equal(t, 5, size)
+
+	e.batchSize = 3
+	size, err = e.effectiveBatchSize()
+	require.NoError(t, err)
+	assert.Equal(t, 3, size)
+}
@@ -0,0 +1,144 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/utils/bedrock"
+)
+
+// cohereBatchSize is the largest number of texts a Cohere embedding request on Bedrock accepts
+const cohereBatchSize = 96
+
+// BedrockEmbedder implements text vectorization with the Titan and Cohere embedding models on AWS Bedrock
+type BedrockEmbedder struct {
+	client     *bedrock.Client
+	modelName  string
+	dimensions int
+	modelID    string
+	EmbedderPooler
+}
+
+// titanEmbedRequest is the InvokeModel body of Titan text embedding models, which embed one text per call.
+// Only Titan v2 accepts the dimensions and normalize fields.
+type titanEmbedRequest struct {
+	InputText  string `json:"inputText"`
+	Dimensions int    `json:"dimensions,omitempty"`
+	Normalize  *bool  `json:"normalize,omitempty"`
+}
+
+type titanEmbedResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+// cohereEmbedRequest is the InvokeModel body of Cohere embedding models
+type cohereEmbedRequest struct {
+	Texts     []string `json:"texts"`
+	InputType string   `json:"input_type"`
+	Truncate  string   `json:"truncate"`
+}
+
+type cohereEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// NewBedrockEmbedder creates a new Bedrock embedder
+func NewBedrockEmbedder(config Config, pooler EmbedderPooler) (*BedrockEmbedder, error) {
+	if config.ModelName == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	client, err := bedrock.NewClient(bedrock.Config{
+		Region: provider.BedrockRegion(config.Extra, config.BaseURL),
+		Credentials: bedrock.Credentials{
+			AccessKeyID:     config.Extra[provider.BedrockExtraAccessKeyID],
+			SecretAccessKey: config.APIKey,
+			SessionToken:    config.Extra[provider.BedrockExtraSessionToken],
+		},
+		BaseURL: config.BaseURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BedrockEmbedder{
+		client:         client,
+		modelName:      config.ModelName,
+		dimensions:     config.Dimensions,
+		modelID:        config.ModelID,
+		EmbedderPooler: pooler,
+	}, nil
+}
+
+// isCohere reports whether the model is a Cohere embedding model, all other models use the Titan format
+func (e *BedrockEmbedder) isCohere() bool {
+	return strings.Contains(strings.ToLower(e.modelName), "cohere.")
+}
+
+// Embed converts text to vector
+func (e *BedrockEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.BatchEmbed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+	return embeddings[0], nil
+}
+
+// BatchEmbed converts multiple texts to vectors in batch
+func (e *BedrockEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	if e.isCohere() {
+		for start := 0; start < len(texts); start += cohereBatchSize {
+			end := min(start+cohereBatchSize, len(texts))
+			var resp cohereEmbedResponse
+			err := e.client.Invoke(ctx, e.modelName, "invoke", cohereEmbedRequest{
+				Texts:     texts[start:end],
+				InputType: "search_document",
+				Truncate:  "END",
+			}, &resp)
+			if err != nil {
+				return nil, fmt.Errorf("bedrock embed: %w", err)
+			}
+			if len(resp.Embeddings) != end-start {
+				return nil, fmt.Errorf("bedrock embed: expected %d embeddings, got %d", end-start, len(resp.Embeddings))
+			}
+			embeddings = append(embeddings, resp.Embeddings...)
+		}
+		return embeddings, nil
+	}
+
+	isV2 := strings.Contains(e.modelName, "-v2")
+	for _, text := range texts {
+		req := titanEmbedRequest{InputText: text}
+		if isV2 {
+			normalize := true
+			req.Dimensions = e.dimensions
+			req.Normalize = &normalize
+		}
+		var resp titanEmbedResponse
+		err := e.client.Invoke(ctx, e.modelName, "invoke", req, &resp)
+		if err != nil {
+			return nil, fmt.Errorf("bedrock embed: %w", err)
+		}
+		embeddings = append(embeddings, resp.Embedding)
+	}
+	return embeddings, nil
+}
+
+// GetModelName returns the model name
+func (e *BedrockEmbedder) GetModelName() string {
+	return e.modelName
+}
+
+// GetDimensions returns the vector dimensions
+func (e *BedrockEmbedder) GetDimensions() int {
+	return e.dimensions
+}
+
+// GetModelID returns the model ID
+func (e *BedrockEmbedder) GetModelID() string {
+	return e.modelID
+}
@@ -30,6 +30,13 @@ type Embedder interface {
 	EmbedderPooler
 }
 
+// ImageEmbedder is implemented by multimodal embedders that can vectorize images
+// into the same space as their text vectors
+type ImageEmbedder interface {
+	// EmbedImages converts images, given as data URIs (data:image/png;base64,...), to vectors
+	EmbedImages(ctx context.Context, images []string) ([][]float32, error)
+}
+
 type EmbedderPooler interface {
 	BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error)
 }
@@ -47,6 +54,8 @@ type Config struct {
 	Dimensions           int               `json:"dimensions"`
 	ModelID              string            `json:"model_id"`
 	Provider             string            `json:"provider"`
+	// Extra holds provider-specific settings, such as the Azure deployment or the Bedrock region
+	Extra map[string]string `json:"extra,omitempty"`
 }
 
 // NewEmbedder creates an embedder based on the configuration
@@ -55,6 +64,7 @@ func NewEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.Oll
 	var err error
 	switch strings.ToLower(string(config.Source)) {
 	case string(types.ModelSourceLocal):
+		pooler = withBatchSize(pooler, MaxBatchSize(provider.ProviderOllama, config.ModelName))
 		embedder, err = NewOllamaEmbedder(config.BaseURL,
 			config.ModelName, config.TruncatePromptTokens, config.Dimensions, config.ModelID, pooler, ollamaService)
 		return embedder, err
@@ -64,6 +74,7 @@ func NewEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.Oll
 		if providerName == "" {
 			providerName = provider.DetectProvider(config.BaseURL)
 		}
+		pooler = withBatchSize(pooler, MaxBatchSize(providerName, config.ModelName))
 
 		// Route to provider-specific embedders
 		switch providerName {
@@ -116,6 +127,30 @@ func NewEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.Oll
 				config.ModelID,
 				pooler)
 			return embedder, err
+		case provider.ProviderAzureOpenAI:
+			// Azure OpenAI 按部署名称寻址，使用 api-key 请求头认证
+			embedder, err = NewAzureOpenAIEmbedder(config.APIKey,
+				config.BaseURL,
+				config.ModelName,
+				provider.AzureDeployment(config.Extra, config.ModelName),
+				provider.AzureAPIVersion(config.Extra),
+				config.Dimensions,
+				config.ModelID,
+				pooler)
+			return embedder, err
+		case provider.ProviderBedrock:
+			// AWS Bedrock 使用 InvokeModel API 和 SigV4 签名
+			embedder, err = NewBedrockEmbedder(config, pooler)
+			return embedder, err
+		case provider.ProviderOllama:
+			// 租户自行配置的 Ollama 服务使用原生 API
+			ollamaService, err := ollama.GetOllamaServiceForURL(config.BaseURL)
+			if err != nil {
+				return nil, err
+			}
+			embedder, err = NewOllamaEmbedder(ollamaService.BaseURL(),
+				config.ModelName, config.TruncatePromptTokens, config.Dimensions, config.ModelID, pooler, ollamaService)
+			return embedder, err
 		case provider.ProviderJina:
 			// Jina AI uses different API format (truncate instead of truncate_prompt_tokens)
 			embedder, err = NewJinaEmbedder(config.APIKey,
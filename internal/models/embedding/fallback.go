@@ -0,0 +1,115 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/health"
+)
+
+// fallbackEmbedder embeds with the first healthy embedder of a chain, failing over to the next one
+// when a call fails because the endpoint is unhealthy. Every embedder of the chain must produce
+// vectors in the same space, since they are stored side by side.
+type fallbackEmbedder struct {
+	Embedder
+	chain    []Embedder
+	registry *health.Registry
+}
+
+// fallbackImageEmbedder is a fallbackEmbedder whose primary embedder also vectorizes images
+type fallbackImageEmbedder struct {
+	*fallbackEmbedder
+}
+
+// NewFallbackEmbedder chains embedders so that calls fail over from the first embedder to the next
+// ones. The health of each embedder is tracked in registry, and embedders whose circuit is open are
+// skipped.
+func NewFallbackEmbedder(chain []Embedder, registry *health.Registry) Embedder {
+	if len(chain) == 1 {
+		return chain[0]
+	}
+	embedder := &fallbackEmbedder{Embedder: chain[0], chain: chain, registry: registry}
+	if _, ok := chain[0].(ImageEmbedder); ok {
+		return &fallbackImageEmbedder{embedder}
+	}
+	return embedder
+}
+
+// candidates returns the embedders to try in order: the healthy ones first, then the ones whose
+// circuit is open, so that a call is still attempted when every endpoint looks unhealthy
+func (e *fallbackEmbedder) candidates() []Embedder {
+	healthy := make([]Embedder, 0, len(e.chain))
+	var open []Embedder
+	for _, embedder := range e.chain {
+		if e.registry.Allow(embedder.GetModelID()) {
+			healthy = append(healthy, embedder)
+		} else {
+			open = append(open, embedder)
+		}
+	}
+	return append(healthy, open...)
+}
+
+// call runs fn against the chain until an embedder succeeds or fails with an error that is not the
+// endpoint's fault
+func (e *fallbackEmbedder) call(ctx context.Context, fn func(Embedder) ([][]float32, error)) ([][]float32, error) {
+	var errs []error
+	for i, embedder := range e.candidates() {
+		vectors, err := fn(embedder)
+		if err == nil {
+			e.registry.RecordSuccess(embedder.GetModelID())
+			if i > 0 {
+				logger.Warnf(ctx, "Embedding model %s failed over to %s", e.GetModelID(), embedder.GetModelID())
+			}
+			return vectors, nil
+		}
+		if errors.Is(err, errUnsupportedImages) {
+			continue
+		}
+		if ctx.Err() != nil || !health.IsFailoverError(err) {
+			return nil, err
+		}
+		e.registry.RecordFailure(embedder.GetModelID(), err)
+		logger.Warnf(ctx, "Embedding model %s is unavailable: %v", embedder.GetModelID(), err)
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Embed converts text to a vector with the first embedder that answers
+func (e *fallbackEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.call(ctx, func(embedder Embedder) ([][]float32, error) {
+		vector, err := embedder.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		return [][]float32{vector}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// BatchEmbed converts texts to vectors with the first embedder that answers
+func (e *fallbackEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.call(ctx, func(embedder Embedder) ([][]float32, error) {
+		return embedder.BatchEmbed(ctx, texts)
+	})
+}
+
+// EmbedImages converts images to vectors with the first image embedder that answers
+func (e *fallbackImageEmbedder) EmbedImages(ctx context.Context, images []string) ([][]float32, error) {
+	return e.call(ctx, func(embedder Embedder) ([][]float32, error) {
+		imageEmbedder, ok := embedder.(ImageEmbedder)
+		if !ok {
+			return nil, errUnsupportedImages
+		}
+		return imageEmbedder.EmbedImages(ctx, images)
+	})
+}
+
+// errUnsupportedImages is returned for fallback embedders that cannot vectorize images, so that
+// the chain skips them
+var errUnsupportedImages = errors.New("embedding model does not support images")
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
 )
 
 // JinaEmbedder implements text vectorization functionality using Jina AI API
@@ -59,7 +60,8 @@ func NewJinaEmbedder(apiKey, baseURL, modelName string,
 
 	// Create HTTP client
 	client := &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: tracing.Transport(nil),
 	}
 
 	return &JinaEmbedder{
@@ -0,0 +1,45 @@
+package embedding
+
+import (
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+)
+
+// defaultMaxBatchSize is the batch size of providers without a known limit
+const defaultMaxBatchSize = 16
+
+// maxBatchSizes is the number of texts each provider accepts in one embedding request
+var maxBatchSizes = map[provider.ProviderName]int{
+	provider.ProviderOpenAI:      128,
+	provider.ProviderAzureOpenAI: 16,
+	provider.ProviderAliyun:      10,
+	provider.ProviderZhipu:       64,
+	provider.ProviderJina:        128,
+	provider.ProviderSiliconFlow: 32,
+	provider.ProviderHunyuan:     64,
+	provider.ProviderQianfan:     16,
+	provider.ProviderModelScope:  32,
+	provider.ProviderGPUStack:    32,
+	provider.ProviderTEI:         32,
+	provider.ProviderVLLM:        64,
+	provider.ProviderOllama:      32,
+	provider.ProviderGeneric:     32,
+	// The Volcengine multimodal API embeds one input per request, batches are spread over the pool instead
+	provider.ProviderVolcengine: 1,
+}
+
+// MaxBatchSize returns the number of texts sent to a provider in one embedding request
+func MaxBatchSize(providerName provider.ProviderName, modelName string) int {
+	if providerName == provider.ProviderBedrock {
+		// Cohere models take a list of texts, Titan models embed one text per call
+		if strings.Contains(strings.ToLower(modelName), "cohere.") {
+			return cohereBatchSize
+		}
+		return 1
+	}
+	if size, ok := maxBatchSizes[providerName]; ok {
+		return size
+	}
+	return defaultMaxBatchSize
+}
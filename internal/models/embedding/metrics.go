@@ -0,0 +1,130 @@
+package embedding
+
+import (
+	"sync"
+	"time"
+
+	promMetrics "github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/models/health"
+)
+
+// Stats is the embedding throughput of one model since the process started
+type Stats struct {
+	ModelID string `json:"model_id"`
+	// Requests is the number of embedding requests sent to the provider, retries included
+	Requests int64 `json:"requests"`
+	// Texts is the number of texts embedded successfully
+	Texts int64 `json:"texts"`
+	// Failures is the number of failed requests, rate limited ones included
+	Failures int64 `json:"failures"`
+	// RateLimited is the number of requests rejected with 429
+	RateLimited int64 `json:"rate_limited"`
+	Retries     int64 `json:"retries"`
+	// AvgLatencyMs is the average duration of a request
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	// TextsPerSecond is the throughput of batch embedding runs, measured over their wall time
+	TextsPerSecond float64    `json:"texts_per_second"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+}
+
+// modelMetrics accumulates the embedding counters of one model
+type modelMetrics struct {
+	mu          sync.Mutex
+	modelID     string
+	requests    int64
+	texts       int64
+	failures    int64
+	rateLimited int64
+	retries     int64
+	latency     time.Duration
+	runTexts    int64
+	runDuration time.Duration
+	lastRunAt   time.Time
+}
+
+var (
+	metricsMu sync.Mutex
+	metrics   = make(map[string]*modelMetrics)
+)
+
+// metricsFor returns the counters of a model, creating them on first use
+func metricsFor(modelID string) *modelMetrics {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	m, ok := metrics[modelID]
+	if !ok {
+		m = &modelMetrics{modelID: modelID}
+		metrics[modelID] = m
+	}
+	return m
+}
+
+func (m *modelMetrics) recordRequest(texts int, latency time.Duration, err error) {
+	promMetrics.EmbeddingRequestDuration.WithLabelValues(m.modelID, promMetrics.Result(err)).
+		Observe(latency.Seconds())
+	if err == nil {
+		promMetrics.EmbeddingTexts.WithLabelValues(m.modelID).Add(float64(texts))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	m.latency += latency
+	if err != nil {
+		m.failures++
+		if health.StatusCode(err) == 429 {
+			m.rateLimited++
+		}
+		return
+	}
+	m.texts += int64(texts)
+}
+
+func (m *modelMetrics) recordRetry() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+}
+
+// recordRun records a completed batch embedding run, the unit throughput is measured over
+func (m *modelMetrics) recordRun(texts int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runTexts += int64(texts)
+	m.runDuration += duration
+	m.lastRunAt = time.Now()
+}
+
+func (m *modelMetrics) snapshot(modelID string) Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := Stats{
+		ModelID:     modelID,
+		Requests:    m.requests,
+		Texts:       m.texts,
+		Failures:    m.failures,
+		RateLimited: m.rateLimited,
+		Retries:     m.retries,
+	}
+	if m.requests > 0 {
+		stats.AvgLatencyMs = float64(m.latency.Milliseconds()) / float64(m.requests)
+	}
+	if m.runDuration > 0 {
+		stats.TextsPerSecond = float64(m.runTexts) / m.runDuration.Seconds()
+	}
+	if !m.lastRunAt.IsZero() {
+		lastRunAt := m.lastRunAt
+		stats.LastRunAt = &lastRunAt
+	}
+	return stats
+}
+
+// GetStats returns the embedding throughput of a model, zero counters when it embedded nothing yet
+func GetStats(modelID string) Stats {
+	metricsMu.Lock()
+	m, ok := metrics[modelID]
+	metricsMu.Unlock()
+	if !ok {
+		return Stats{ModelID: modelID}
+	}
+	return m.snapshot(modelID)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
 )
 
 // OpenAIEmbedder implements text vectorization functionality using OpenAI API
@@ -62,7 +63,8 @@ func NewOpenAIEmbedder(apiKey, baseURL, modelName string,
 
 	// Create HTTP client
 	client := &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: tracing.Transport(nil),
 	}
 
 	return &OpenAIEmbedder{
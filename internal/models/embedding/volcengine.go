@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
 )
 
 const (
@@ -106,7 +107,8 @@ func NewVolcengineEmbedder(apiKey, baseURL, modelName string,
 	timeout := 60 * time.Second
 
 	client := &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: tracing.Transport(nil),
 	}
 
 	return &VolcengineEmbedder{
@@ -183,58 +185,82 @@ func (e *VolcengineEmbedder) BatchEmbed(ctx context.Context, texts []string) ([]
 	// Volcengine multimodal API returns a single combined embedding for all inputs,
 	// so we need to call the API once per text for proper batch embedding
 	for i, text := range texts {
-		input := []VolcengineInputContent{
+		embedding, err := e.embedInput(ctx, []VolcengineInputContent{
 			{
 				Type: "text",
 				Text: text,
 			},
+		})
+		if err != nil {
+			return nil, err
 		}
+		embeddings[i] = embedding
+	}
 
-		reqBody := VolcengineEmbedRequest{
-			Model: e.modelName,
-			Input: input,
-		}
+	return embeddings, nil
+}
 
-		jsonData, err := json.Marshal(reqBody)
+// EmbedImages converts images (data URIs or URLs) to vectors in the same space as text
+func (e *VolcengineEmbedder) EmbedImages(ctx context.Context, images []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(images))
+	for i, image := range images {
+		embedding, err := e.embedInput(ctx, []VolcengineInputContent{
+			{
+				Type:     "image_url",
+				ImageURL: &VolcengineImageURL{URL: image},
+			},
+		})
 		if err != nil {
-			logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed marshal request error: %v", err)
-			return nil, fmt.Errorf("marshal request: %w", err)
+			return nil, err
 		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
 
-		resp, err := e.doRequestWithRetry(ctx, jsonData)
-		if err != nil {
-			logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed send request error: %v", err)
-			return nil, fmt.Errorf("send request: %w", err)
-		}
+// embedInput sends one multimodal request and returns its combined embedding
+func (e *VolcengineEmbedder) embedInput(ctx context.Context, input []VolcengineInputContent) ([]float32, error) {
+	reqBody := VolcengineEmbedRequest{
+		Model: e.modelName,
+		Input: input,
+	}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed read response error: %v", err)
-			return nil, fmt.Errorf("read response: %w", err)
-		}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed marshal request error: %v", err)
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			var errResp VolcengineErrorResponse
-			if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
-				logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed API error: %s - %s", errResp.Error.Code, errResp.Error.Message)
-				return nil, fmt.Errorf("API error: %s - %s", errResp.Error.Code, errResp.Error.Message)
-			}
-			logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed API error: Http Status %s", resp.Status)
-			return nil, fmt.Errorf("BatchEmbed API error: Http Status %s", resp.Status)
-		}
+	resp, err := e.doRequestWithRetry(ctx, jsonData)
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed send request error: %v", err)
+		return nil, fmt.Errorf("send request: %w", err)
+	}
 
-		var response VolcengineEmbedResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed unmarshal response error: %v", err)
-			return nil, fmt.Errorf("unmarshal response: %w", err)
-		}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed read response error: %v", err)
+		return nil, fmt.Errorf("read response: %w", err)
+	}
 
-		embeddings[i] = response.Data.Embedding
+	if resp.StatusCode != http.StatusOK {
+		var errResp VolcengineErrorResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+			logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed API error: %s - %s", errResp.Error.Code, errResp.Error.Message)
+			return nil, fmt.Errorf("API error: %s - %s", errResp.Error.Code, errResp.Error.Message)
+		}
+		logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed API error: Http Status %s", resp.Status)
+		return nil, fmt.Errorf("BatchEmbed API error: Http Status %s", resp.Status)
 	}
 
-	return embeddings, nil
+	var response VolcengineEmbedResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		logger.GetLogger(ctx).Errorf("VolcengineEmbedder BatchEmbed unmarshal response error: %v", err)
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
 
+	return response.Data.Embedding, nil
 }
 
 // GetModelName returns the model name
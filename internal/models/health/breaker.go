@@ -0,0 +1,236 @@
+// Package health tracks the health of model endpoints with circuit breakers, so that calls can be
+// routed away from endpoints that keep failing and back once they recover.
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// DefaultFailureThreshold is the number of consecutive failures that opens a circuit
+	DefaultFailureThreshold = 3
+	// DefaultOpenDuration is how long an open circuit rejects calls before letting a probe through
+	DefaultOpenDuration = 30 * time.Second
+)
+
+// State is the state of a circuit
+type State string
+
+const (
+	// StateClosed lets every call through
+	StateClosed State = "closed"
+	// StateOpen rejects calls until the open duration has passed
+	StateOpen State = "open"
+	// StateHalfOpen lets a single probe call through to test whether the endpoint recovered
+	StateHalfOpen State = "half_open"
+)
+
+// Status is a snapshot of the circuit of one endpoint
+type Status struct {
+	Key                 string     `json:"model_id"`
+	State               State      `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	TotalSuccesses      int64      `json:"total_successes"`
+	TotalFailures       int64      `json:"total_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	// OpenUntil is when an open circuit lets the next probe through
+	OpenUntil *time.Time `json:"open_until,omitempty"`
+}
+
+// circuit is the breaker state of one endpoint
+type circuit struct {
+	status   Status
+	probing  bool
+	openedAt time.Time
+}
+
+// Registry holds the circuits of model endpoints, keyed by model ID
+type Registry struct {
+	mu               sync.Mutex
+	circuits         map[string]*circuit
+	failureThreshold int
+	openDuration     time.Duration
+	now              func() time.Time
+}
+
+// NewRegistry creates a registry whose circuits open after failureThreshold consecutive failures
+// and stay open for openDuration
+func NewRegistry(failureThreshold int, openDuration time.Duration) *Registry {
+	if failureThreshold <= 0 {
+		failureThreshold = DefaultFailureThreshold
+	}
+	if openDuration <= 0 {
+		openDuration = DefaultOpenDuration
+	}
+	return &Registry{
+		circuits:         make(map[string]*circuit),
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+	}
+}
+
+var defaultRegistry = NewRegistry(DefaultFailureThreshold, DefaultOpenDuration)
+
+// DefaultRegistry returns the process-wide registry shared by all model calls
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+func (r *Registry) get(key string) *circuit {
+	c, ok := r.circuits[key]
+	if !ok {
+		c = &circuit{status: Status{Key: key, State: StateClosed}}
+		r.circuits[key] = c
+	}
+	return c
+}
+
+// Allow reports whether a call to the endpoint may be made. An open circuit whose open duration
+// has passed turns half-open and lets one probe through.
+func (r *Registry) Allow(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.get(key)
+	switch c.status.State {
+	case StateOpen:
+		if r.now().Sub(c.openedAt) < r.openDuration {
+			return false
+		}
+		c.status.State = StateHalfOpen
+		c.probing = true
+		return true
+	case StateHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	}
+	return true
+}
+
+// RecordSuccess closes the circuit of the endpoint
+func (r *Registry) RecordSuccess(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.get(key)
+	now := r.now()
+	c.status.State = StateClosed
+	c.status.ConsecutiveFailures = 0
+	c.status.TotalSuccesses++
+	c.status.LastSuccessAt = &now
+	c.status.OpenUntil = nil
+	c.probing = false
+}
+
+// RecordFailure counts a failure of the endpoint, opening its circuit when the threshold is reached
+// or when a half-open probe fails
+func (r *Registry) RecordFailure(key string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.get(key)
+	now := r.now()
+	c.status.ConsecutiveFailures++
+	c.status.TotalFailures++
+	c.status.LastFailureAt = &now
+	if err != nil {
+		c.status.LastError = err.Error()
+	}
+	if c.status.State == StateHalfOpen || c.status.ConsecutiveFailures >= r.failureThreshold {
+		c.status.State = StateOpen
+		c.openedAt = now
+		openUntil := now.Add(r.openDuration)
+		c.status.OpenUntil = &openUntil
+	}
+	c.probing = false
+}
+
+// Status returns the status of the endpoint, a closed circuit when it has not been called
+func (r *Registry) Status(key string) Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.circuits[key]; ok {
+		return c.status
+	}
+	return Status{Key: key, State: StateClosed}
+}
+
+// statusPattern finds the HTTP status in the error messages of the model clients
+var statusPattern = regexp.MustCompile(`(?i)status(?: code)?:? (\d{3})`)
+
+// StatusCode returns the HTTP status of a failed model call, zero when the error carries none
+func StatusCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		return reqErr.HTTPStatusCode
+	}
+	if m := statusPattern.FindStringSubmatch(err.Error()); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status
+	}
+	return 0
+}
+
+// IsFailoverError reports whether an error means the endpoint is unhealthy, so the call should move
+// to the next endpoint of the chain: timeouts, network errors, rate limits and server errors.
+// Errors caused by the request itself, such as a prompt that is too long, are not.
+func IsFailoverError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	status := StatusCode(err)
+	switch {
+	case status == 0:
+		// Unknown failures, such as refused connections wrapped as plain errors, count against the endpoint
+		return true
+	case status == 408 || status == 429 || status >= 500:
+		return true
+	case status == 401 || status == 403:
+		// A revoked or exhausted key only affects this endpoint
+		return true
+	}
+	return false
+}
+
+// Summary returns how many endpoints have been called and how many of them have an open or half-open circuit
+func (r *Registry) Summary() (total int, open int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.circuits {
+		total++
+		if c.status.State != StateClosed {
+			open++
+		}
+	}
+	return total, open
+}
@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestRegistryOpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	r := NewRegistry(2, time.Minute)
+	r.now = func() time.Time { return now }
+
+	r.RecordFailure("m", errors.New("timeout"))
+	if !r.Allow("m") {
+		t.Fatal("circuit opened before the threshold")
+	}
+	r.RecordFailure("m", errors.New("timeout"))
+	if r.Allow("m") {
+		t.Fatal("circuit did not open at the threshold")
+	}
+	if got := r.Status("m"); got.State != StateOpen || got.LastError != "timeout" {
+		t.Fatalf("unexpected status %+v", got)
+	}
+
+	now = now.Add(time.Minute)
+	if !r.Allow("m") {
+		t.Fatal("open circuit did not let a probe through after the open duration")
+	}
+	if r.Allow("m") {
+		t.Fatal("half-open circuit let a second probe through")
+	}
+	r.RecordFailure("m", errors.New("timeout"))
+	if r.Allow("m") || r.Status("m").State != StateOpen {
+		t.Fatal("failed probe did not reopen the circuit")
+	}
+
+	now = now.Add(time.Minute)
+	r.Allow("m")
+	r.RecordSuccess("m")
+	if got := r.Status("m"); got.State != StateClosed || got.ConsecutiveFailures != 0 || !r.Allow("m") {
+		t.Fatalf("successful probe did not close the circuit: %+v", got)
+	}
+}
+
+func TestIsFailoverError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{fmt.Errorf("call: %w", context.DeadlineExceeded), true},
+		{&openai.APIError{HTTPStatusCode: 429}, true},
+		{&openai.APIError{HTTPStatusCode: 400}, false},
+		{&openai.RequestError{HTTPStatusCode: 502}, true},
+		{errors.New("API request failed with status 503"), true},
+		{errors.New("API request failed with status 413"), false},
+		{errors.New("Http Status 500 Internal Server Error"), true},
+		{errors.New("dial tcp: connection refused"), true},
+	}
+	for _, tt := range tests {
+		if got := IsFailoverError(tt.err); got != tt.want {
+			t.Errorf("IsFailoverError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
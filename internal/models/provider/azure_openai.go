@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// AzureOpenAIBaseURL Azure OpenAI 资源地址
+	AzureOpenAIBaseURL = "https://your-resource.openai.azure.com"
+	// AzureOpenAIDefaultAPIVersion 默认使用的 Azure OpenAI API 版本
+	AzureOpenAIDefaultAPIVersion = "2024-10-21"
+
+	// AzureExtraAPIVersion extra_config 中的 API 版本
+	AzureExtraAPIVersion = "api_version"
+	// AzureExtraDeployment extra_config 中的部署名称，未填写时使用模型名称
+	AzureExtraDeployment = "deployment_name"
+)
+
+// AzureOpenAIProvider 实现 Azure OpenAI 的 Provider 接口
+type AzureOpenAIProvider struct{}
+
+func init() {
+	Register(&AzureOpenAIProvider{})
+}
+
+// Info 返回 Azure OpenAI provider 的元数据
+func (p *AzureOpenAIProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderAzureOpenAI,
+		DisplayName: "Azure OpenAI",
+		Description: "OpenAI models deployed on Azure, addressed by deployment name",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeKnowledgeQA: AzureOpenAIBaseURL,
+			types.ModelTypeEmbedding:   AzureOpenAIBaseURL,
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeKnowledgeQA,
+			types.ModelTypeEmbedding,
+		},
+		RequiresAuth: true,
+		ExtraFields: []ExtraFieldConfig{
+			{
+				Key:         AzureExtraDeployment,
+				Label:       "部署名称",
+				Type:        "string",
+				Placeholder: "留空则使用模型名称",
+			},
+			{
+				Key:     AzureExtraAPIVersion,
+				Label:   "API 版本",
+				Type:    "string",
+				Default: AzureOpenAIDefaultAPIVersion,
+			},
+		},
+	}
+}
+
+// ValidateConfig 验证 Azure OpenAI provider 配置
+func (p *AzureOpenAIProvider) ValidateConfig(config *Config) error {
+	if config.BaseURL == "" {
+		return fmt.Errorf("base URL is required for Azure OpenAI provider")
+	}
+	if config.APIKey == "" {
+		return fmt.Errorf("API key is required for Azure OpenAI provider")
+	}
+	if config.ModelName == "" {
+		return fmt.Errorf("model name is required")
+	}
+	return nil
+}
+
+// AzureDeployment 返回模型的部署名称，未配置时使用模型名称
+func AzureDeployment(extra map[string]string, modelName string) string {
+	if deployment := extra[AzureExtraDeployment]; deployment != "" {
+		return deployment
+	}
+	return modelName
+}
+
+// AzureAPIVersion 返回模型的 API 版本，未配置时使用默认版本
+func AzureAPIVersion(extra map[string]string) string {
+	if version := extra[AzureExtraAPIVersion]; version != "" {
+		return version
+	}
+	return AzureOpenAIDefaultAPIVersion
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// BedrockDefaultRegion 未配置区域时使用的 AWS 区域
+	BedrockDefaultRegion = "us-east-1"
+
+	// BedrockExtraRegion extra_config 中的 AWS 区域
+	BedrockExtraRegion = "region"
+	// BedrockExtraAccessKeyID extra_config 中的 Access Key ID，Secret Access Key 填写在 api_key 中
+	BedrockExtraAccessKeyID = "access_key_id"
+	// BedrockExtraSessionToken extra_config 中的临时凭证 Session Token（可选）
+	BedrockExtraSessionToken = "session_token"
+)
+
+// BedrockProvider 实现 AWS Bedrock 的 Provider 接口
+type BedrockProvider struct{}
+
+func init() {
+	Register(&BedrockProvider{})
+}
+
+// Info 返回 AWS Bedrock provider 的元数据
+func (p *BedrockProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderBedrock,
+		DisplayName: "AWS Bedrock",
+		Description: "Claude, Titan and other models on AWS Bedrock, signed with SigV4",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeKnowledgeQA: BedrockRuntimeURL(BedrockDefaultRegion),
+			types.ModelTypeEmbedding:   BedrockRuntimeURL(BedrockDefaultRegion),
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeKnowledgeQA,
+			types.ModelTypeEmbedding,
+		},
+		RequiresAuth: true,
+		ExtraFields: []ExtraFieldConfig{
+			{
+				Key:      BedrockExtraRegion,
+				Label:    "区域",
+				Type:     "string",
+				Required: true,
+				Default:  BedrockDefaultRegion,
+			},
+			{
+				Key:      BedrockExtraAccessKeyID,
+				Label:    "Access Key ID",
+				Type:     "string",
+				Required: true,
+			},
+			{
+				Key:   BedrockExtraSessionToken,
+				Label: "Session Token",
+				Type:  "string",
+			},
+		},
+	}
+}
+
+// ValidateConfig 验证 AWS Bedrock provider 配置
+func (p *BedrockProvider) ValidateConfig(config *Config) error {
+	if config.APIKey == "" {
+		return fmt.Errorf("secret access key is required for Bedrock provider")
+	}
+	if config.ModelName == "" {
+		return fmt.Errorf("model name is required")
+	}
+	return nil
+}
+
+// BedrockRuntimeURL 返回指定区域的 Bedrock Runtime 地址
+func BedrockRuntimeURL(region string) string {
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", region)
+}
+
+// bedrockRuntimeHostPattern 匹配 Bedrock Runtime 地址中的区域
+var bedrockRuntimeHostPattern = regexp.MustCompile(`bedrock-runtime(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com`)
+
+// BedrockRegion 返回模型的区域：优先使用 extra_config 中的配置，其次从 Base URL 中解析，最后使用默认区域
+func BedrockRegion(extra map[string]string, baseURL string) string {
+	if region := extra[BedrockExtraRegion]; region != "" {
+		return region
+	}
+	if match := bedrockRuntimeHostPattern.FindStringSubmatch(baseURL); match != nil {
+		return match[1]
+	}
+	return BedrockDefaultRegion
+}
@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// OllamaBaseURL Ollama 服务默认地址（使用原生 API，而非 /v1 兼容接口）
+const OllamaBaseURL = "http://localhost:11434"
+
+// OllamaProvider 实现 Ollama 的 Provider 接口，用于按租户或按模型配置的 Ollama 服务
+type OllamaProvider struct{}
+
+func init() {
+	Register(&OllamaProvider{})
+}
+
+// Info 返回 Ollama provider 的元数据
+func (p *OllamaProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderOllama,
+		DisplayName: "Ollama",
+		Description: "Self-hosted Ollama server (native API, supports model listing and pulling)",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeKnowledgeQA: OllamaBaseURL,
+			types.ModelTypeEmbedding:   OllamaBaseURL,
+			types.ModelTypeVLLM:        OllamaBaseURL,
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeKnowledgeQA,
+			types.ModelTypeEmbedding,
+			types.ModelTypeVLLM,
+		},
+		RequiresAuth: false,
+	}
+}
+
+// ValidateConfig 验证 Ollama provider 配置
+func (p *OllamaProvider) ValidateConfig(config *Config) error {
+	if config.ModelName == "" {
+		return fmt.Errorf("model name is required")
+	}
+	return nil
+}
@@ -53,12 +53,26 @@ const (
 	ProviderLongCat ProviderName = "longcat"
 	// 腾讯云 LKEAP (知识引擎原子能力)
 	ProviderLKEAP ProviderName = "lkeap"
+	// Cohere
+	ProviderCohere ProviderName = "cohere"
+	// HuggingFace Text Embeddings Inference (本地部署 bge-reranker 等模型)
+	ProviderTEI ProviderName = "tei"
+	// Ollama (私有化部署，原生 API)
+	ProviderOllama ProviderName = "ollama"
+	// vLLM (私有化部署，OpenAI 兼容接口)
+	ProviderVLLM ProviderName = "vllm"
+	// Azure OpenAI
+	ProviderAzureOpenAI ProviderName = "azure_openai"
+	// AWS Bedrock
+	ProviderBedrock ProviderName = "bedrock"
 )
 
 // AllProviders 返回所有注册的提供者名称
 func AllProviders() []ProviderName {
 	return []ProviderName{
 		ProviderGeneric,
+		ProviderOllama,
+		ProviderVLLM,
 		ProviderAliyun,
 		ProviderZhipu,
 		ProviderVolcengine,
@@ -71,6 +85,8 @@ func AllProviders() []ProviderName {
 		ProviderQianfan,
 		ProviderQiniu,
 		ProviderOpenAI,
+		ProviderAzureOpenAI,
+		ProviderBedrock,
 		ProviderGemini,
 		ProviderOpenRouter,
 		ProviderJina,
@@ -78,6 +94,8 @@ func AllProviders() []ProviderName {
 		ProviderLongCat,
 		ProviderLKEAP,
 		ProviderGPUStack,
+		ProviderCohere,
+		ProviderTEI,
 	}
 }
 
@@ -215,6 +233,10 @@ func DetectProvider(baseURL string) ProviderName {
 		return ProviderSiliconFlow
 	case containsAny(baseURL, "api.jina.ai"):
 		return ProviderJina
+	case containsAny(baseURL, "openai.azure.com"):
+		return ProviderAzureOpenAI
+	case containsAny(baseURL, "bedrock-runtime."):
+		return ProviderBedrock
 	case containsAny(baseURL, "api.openai.com"):
 		return ProviderOpenAI
 	case containsAny(baseURL, "api.deepseek.com"):
@@ -243,6 +265,8 @@ func DetectProvider(baseURL string) ProviderName {
 		return ProviderLongCat
 	case containsAny(baseURL, "lkeap.cloud.tencent.com", "api.lkeap"):
 		return ProviderLKEAP
+	case containsAny(baseURL, "api.cohere.com", "api.cohere.ai"):
+		return ProviderCohere
 	default:
 		return ProviderGeneric
 	}
@@ -46,6 +46,8 @@ func TestDetectProvider(t *testing.T) {
 		{"https://api.minimaxi.com/v1", ProviderMiniMax},
 		{"https://api.minimax.io/v1", ProviderMiniMax},
 		{"https://api.xiaomimimo.com/v1", ProviderMimo},
+		{"https://my-resource.openai.azure.com", ProviderAzureOpenAI},
+		{"https://bedrock-runtime.eu-west-1.amazonaws.com", ProviderBedrock},
 		{"https://custom-endpoint.example.com/v1", ProviderGeneric},
 		{"http://localhost:11434/v1", ProviderGeneric},
 	}
@@ -168,3 +170,10 @@ func TestListByModelType(t *testing.T) {
 		assert.True(t, found, "Aliyun should support rerank")
 	})
 }
+
+func TestBedrockRegion(t *testing.T) {
+	assert.Equal(t, "ap-northeast-1", BedrockRegion(map[string]string{BedrockExtraRegion: "ap-northeast-1"},
+		"https://bedrock-runtime.us-west-2.amazonaws.com"))
+	assert.Equal(t, "us-west-2", BedrockRegion(nil, "https://bedrock-runtime.us-west-2.amazonaws.com"))
+	assert.Equal(t, BedrockDefaultRegion, BedrockRegion(nil, "https://bedrock.example.com"))
+}
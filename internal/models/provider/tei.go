@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// TEIBaseURL docker-compose 中 reranker sidecar 的地址
+	TEIBaseURL = "http://reranker:80"
+)
+
+// TEIProvider 实现 HuggingFace Text Embeddings Inference 的 Provider 接口
+type TEIProvider struct{}
+
+func init() {
+	Register(&TEIProvider{})
+}
+
+// Info 返回 TEI provider 的元数据
+func (p *TEIProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderTEI,
+		DisplayName: "Text Embeddings Inference",
+		Description: "Self-hosted BAAI/bge-reranker-v2-m3, BAAI/bge-reranker-base, etc.",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeRerank: TEIBaseURL,
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeRerank,
+		},
+		RequiresAuth: false,
+	}
+}
+
+// ValidateConfig 验证 TEI provider 配置
+func (p *TEIProvider) ValidateConfig(config *Config) error {
+	if config.BaseURL == "" {
+		return fmt.Errorf("base URL is required for TEI provider")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// VLLMBaseURL vLLM OpenAI 兼容服务默认地址
+const VLLMBaseURL = "http://localhost:8000/v1"
+
+// VLLMProvider 实现 vLLM 等 OpenAI 兼容本地推理服务的 Provider 接口
+type VLLMProvider struct{}
+
+func init() {
+	Register(&VLLMProvider{})
+}
+
+// Info 返回 vLLM provider 的元数据
+func (p *VLLMProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderVLLM,
+		DisplayName: "vLLM",
+		Description: "Self-hosted vLLM or other OpenAI-compatible inference server",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeKnowledgeQA: VLLMBaseURL,
+			types.ModelTypeEmbedding:   VLLMBaseURL,
+			types.ModelTypeRerank:      VLLMBaseURL,
+			types.ModelTypeVLLM:        VLLMBaseURL,
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeKnowledgeQA,
+			types.ModelTypeEmbedding,
+			types.ModelTypeRerank,
+			types.ModelTypeVLLM,
+		},
+		RequiresAuth: false, // 仅在启动时指定了 --api-key 时需要
+	}
+}
+
+// ValidateConfig 验证 vLLM provider 配置
+func (p *VLLMProvider) ValidateConfig(config *Config) error {
+	if config.BaseURL == "" {
+		return fmt.Errorf("base URL is required for vLLM provider")
+	}
+	if config.ModelName == "" {
+		return fmt.Errorf("model name is required")
+	}
+	return nil
+}
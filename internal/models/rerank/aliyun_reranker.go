@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
 )
 
 // AliyunReranker implements a reranking system based on Aliyun DashScope models
@@ -80,7 +81,7 @@ func NewAliyunReranker(config *RerankerConfig) (*AliyunReranker, error) {
 		modelID:   config.ModelID,
 		apiKey:    apiKey,
 		baseURL:   baseURL,
-		client:    &http.Client{},
+		client:    &http.Client{Transport: tracing.Transport(nil)},
 	}, nil
 }
 
@@ -0,0 +1,111 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
+)
+
+// CohereReranker implements a reranking system using the Cohere Rerank API
+type CohereReranker struct {
+	modelName string       // Name of the model used for reranking
+	modelID   string       // Unique identifier of the model
+	apiKey    string       // API key for authentication
+	baseURL   string       // Base URL for API requests
+	client    *http.Client // HTTP client for making API requests
+}
+
+// CohereRerankRequest represents a Cohere rerank request
+type CohereRerankRequest struct {
+	Model     string   `json:"model"`           // Model to use for reranking
+	Query     string   `json:"query"`           // Query text to compare documents against
+	Documents []string `json:"documents"`       // List of document texts to rerank
+	TopN      int      `json:"top_n,omitempty"` // Number of top results to return
+}
+
+// CohereRerankResponse represents the response from a Cohere reranking request
+type CohereRerankResponse struct {
+	ID      string       `json:"id"`      // Request ID
+	Results []RankResult `json:"results"` // Ranked results with relevance scores
+}
+
+// NewCohereReranker creates a new instance of Cohere reranker with the provided configuration
+func NewCohereReranker(config *RerankerConfig) (*CohereReranker, error) {
+	baseURL := "https://api.cohere.com/v2"
+	if url := config.BaseURL; url != "" {
+		baseURL = url
+	}
+
+	return &CohereReranker{
+		modelName: config.ModelName,
+		modelID:   config.ModelID,
+		apiKey:    config.APIKey,
+		baseURL:   baseURL,
+		client:    &http.Client{Transport: tracing.Transport(nil)},
+	}, nil
+}
+
+// Rerank performs document reranking based on relevance to the query
+func (r *CohereReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	requestBody := &CohereRerankRequest{
+		Model:     r.modelName,
+		Query:     query,
+		Documents: documents,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rerank", r.baseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.apiKey))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		logger.GetLogger(ctx).Errorf("CohereReranker API error: Http Status: %s, Body: %s", resp.Status, string(body))
+		return nil, fmt.Errorf("Rerank API error: Http Status: %s", resp.Status)
+	}
+
+	var response CohereRerankResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	// Cohere does not echo documents back
+	for i := range response.Results {
+		if idx := response.Results[i].Index; idx >= 0 && idx < len(documents) {
+			response.Results[i].Document.Text = documents[idx]
+		}
+	}
+	return response.Results, nil
+}
+
+// GetModelName returns the name of the reranking model
+func (r *CohereReranker) GetModelName() string {
+	return r.modelName
+}
+
+// GetModelID returns the unique identifier of the reranking model
+func (r *CohereReranker) GetModelID() string {
+	return r.modelID
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
 )
 
 // JinaReranker implements a reranking system using Jina AI API
@@ -53,7 +54,7 @@ func NewJinaReranker(config *RerankerConfig) (*JinaReranker, error) {
 		modelID:   config.ModelID,
 		apiKey:    apiKey,
 		baseURL:   baseURL,
-		client:    &http.Client{},
+		client:    &http.Client{Transport: tracing.Transport(nil)},
 	}, nil
 }
 
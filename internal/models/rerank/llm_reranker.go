@@ -0,0 +1,109 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+)
+
+// llmRerankMaxPassageRunes limits how much of each passage is shown to the judge model
+const llmRerankMaxPassageRunes = 800
+
+// llmRerankSystemPrompt asks the judge model to grade every passage on a 0-10 scale
+const llmRerankSystemPrompt = `你是一个检索结果相关性评估助手。给定用户问题和若干编号段落，` +
+	`请为每个段落与问题的相关程度打分，分数为 0 到 10 的整数，10 表示段落能直接回答问题，0 表示完全无关。
+只输出 JSON 数组，不要输出其他内容，格式如：[{"index": 0, "score": 8}, {"index": 1, "score": 2}]`
+
+// LLMReranker implements reranking by asking a chat model to judge passage relevance.
+// It is slower than a cross-encoder and is meant for small candidate sets.
+type LLMReranker struct {
+	chatModel chat.Chat // Chat model used as the relevance judge
+}
+
+// llmJudgement is one graded passage in the judge model's answer
+type llmJudgement struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+// NewLLMReranker creates a reranker that uses the given chat model as the judge
+func NewLLMReranker(chatModel chat.Chat) *LLMReranker {
+	return &LLMReranker{chatModel: chatModel}
+}
+
+// Rerank performs document reranking based on relevance to the query
+func (r *LLMReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "问题：%s\n\n", query)
+	for i, doc := range documents {
+		passage := []rune(doc)
+		if len(passage) > llmRerankMaxPassageRunes {
+			passage = passage[:llmRerankMaxPassageRunes]
+		}
+		fmt.Fprintf(&sb, "[%d] %s\n\n", i, string(passage))
+	}
+
+	thinking := false
+	resp, err := r.chatModel.Chat(ctx, []chat.Message{
+		{Role: "system", Content: llmRerankSystemPrompt},
+		{Role: "user", Content: sb.String()},
+	}, &chat.ChatOptions{Temperature: 0, Thinking: &thinking})
+	if err != nil {
+		return nil, fmt.Errorf("llm rerank: %w", err)
+	}
+	return parseLLMJudgements(resp.Content, documents)
+}
+
+// parseLLMJudgements extracts the graded passages from the judge model's answer and
+// returns them sorted by relevance with scores normalized to [0, 1]
+func parseLLMJudgements(content string, documents []string) ([]RankResult, error) {
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if start < 0 || end <= start {
+		return nil, errors.New("llm rerank: no JSON array in response")
+	}
+	var judgements []llmJudgement
+	if err := json.Unmarshal([]byte(content[start:end+1]), &judgements); err != nil {
+		return nil, fmt.Errorf("llm rerank: parse response: %w", err)
+	}
+
+	seen := make(map[int]struct{}, len(judgements))
+	results := make([]RankResult, 0, len(judgements))
+	for _, j := range judgements {
+		if j.Index < 0 || j.Index >= len(documents) {
+			continue
+		}
+		if _, ok := seen[j.Index]; ok {
+			continue
+		}
+		seen[j.Index] = struct{}{}
+		score := min(max(j.Score, 0), 10) / 10
+		results = append(results, RankResult{
+			Index:          j.Index,
+			Document:       DocumentInfo{Text: documents[j.Index]},
+			RelevanceScore: score,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+	return results, nil
+}
+
+// GetModelName returns the name of the judge model
+func (r *LLMReranker) GetModelName() string {
+	return r.chatModel.GetModelName()
+}
+
+// GetModelID returns the unique identifier of the judge model
+func (r *LLMReranker) GetModelID() string {
+	return r.chatModel.GetModelID()
+}
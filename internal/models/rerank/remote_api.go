@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
 )
 
 // OpenAIReranker implements a reranking system based on OpenAI models
@@ -55,7 +56,7 @@ func NewOpenAIReranker(config *RerankerConfig) (*OpenAIReranker, error) {
 		modelID:   config.ModelID,
 		apiKey:    apiKey,
 		baseURL:   baseURL,
-		client:    &http.Client{},
+		client:    &http.Client{Transport: tracing.Transport(nil)},
 	}, nil
 }
 
@@ -101,6 +101,10 @@ func NewReranker(config *RerankerConfig) (Reranker, error) {
 		return NewZhipuReranker(config)
 	case provider.ProviderJina:
 		return NewJinaReranker(config)
+	case provider.ProviderCohere:
+		return NewCohereReranker(config)
+	case provider.ProviderTEI:
+		return NewTEIReranker(config)
 	default:
 		return NewOpenAIReranker(config)
 	}
@@ -154,3 +154,36 @@ func TestRankResultMarshalJSON(t *testing.T) {
 		t.Errorf("Score mismatch: expected %f, got %f", result.RelevanceScore, parsed.RelevanceScore)
 	}
 }
+
+// TestParseLLMJudgements tests parsing of the LLM judge answer
+func TestParseLLMJudgements(t *testing.T) {
+	documents := []string{"doc a", "doc b", "doc c"}
+	content := "好的，评分如下：\n```json\n" +
+		`[{"index": 0, "score": 3}, {"index": 2, "score": 9}, {"index": 2, "score": 1}, {"index": 5, "score": 10}, {"index": 1, "score": 12}]` +
+		"\n```"
+
+	results, err := parseLLMJudgements(content, documents)
+	if err != nil {
+		t.Fatalf("parseLLMJudgements failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	expected := []struct {
+		index int
+		score float64
+	}{{1, 1.0}, {2, 0.9}, {0, 0.3}}
+	for i, e := range expected {
+		if results[i].Index != e.index || results[i].RelevanceScore != e.score {
+			t.Errorf("Result %d: expected index %d score %f, got index %d score %f",
+				i, e.index, e.score, results[i].Index, results[i].RelevanceScore)
+		}
+		if results[i].Document.Text != documents[e.index] {
+			t.Errorf("Result %d: unexpected document text %q", i, results[i].Document.Text)
+		}
+	}
+
+	if _, err := parseLLMJudgements("no scores", documents); err == nil {
+		t.Errorf("Expected error for response without JSON array")
+	}
+}
@@ -0,0 +1,111 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
+)
+
+// TEIReranker implements reranking with a cross-encoder served by HuggingFace
+// Text Embeddings Inference, e.g. a local BAAI/bge-reranker sidecar
+type TEIReranker struct {
+	modelName string       // Name of the model used for reranking
+	modelID   string       // Unique identifier of the model
+	apiKey    string       // Optional API key of the TEI server
+	baseURL   string       // Base URL for API requests
+	client    *http.Client // HTTP client for making API requests
+}
+
+// TEIRerankRequest represents a TEI rerank request
+type TEIRerankRequest struct {
+	Query      string   `json:"query"`       // Query text to compare documents against
+	Texts      []string `json:"texts"`       // List of document texts to rerank
+	Truncate   bool     `json:"truncate"`    // Truncate texts longer than the model's max length
+	ReturnText bool     `json:"return_text"` // Whether to return document text in response
+}
+
+// NewTEIReranker creates a new instance of TEI reranker with the provided configuration
+func NewTEIReranker(config *RerankerConfig) (*TEIReranker, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required for TEI reranker")
+	}
+	return &TEIReranker{
+		modelName: config.ModelName,
+		modelID:   config.ModelID,
+		apiKey:    config.APIKey,
+		baseURL:   strings.TrimRight(config.BaseURL, "/"),
+		client:    &http.Client{Transport: tracing.Transport(nil)},
+	}, nil
+}
+
+// Rerank performs document reranking based on relevance to the query
+func (r *TEIReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	requestBody := &TEIRerankRequest{
+		Query:    query,
+		Texts:    documents,
+		Truncate: true,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rerank", r.baseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.apiKey))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		logger.GetLogger(ctx).Errorf("TEIReranker API error: Http Status: %s, Body: %s", resp.Status, string(body))
+		return nil, fmt.Errorf("Rerank API error: Http Status: %s", resp.Status)
+	}
+
+	// TEI returns a bare array of {"index", "score"}, RankResult falls back to the score field
+	var results []RankResult
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	for i := range results {
+		if idx := results[i].Index; idx >= 0 && idx < len(documents) {
+			results[i].Document.Text = documents[idx]
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+	return results, nil
+}
+
+// GetModelName returns the name of the reranking model
+func (r *TEIReranker) GetModelName() string {
+	return r.modelName
+}
+
+// GetModelID returns the unique identifier of the reranking model
+func (r *TEIReranker) GetModelID() string {
+	return r.modelID
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing"
 )
 
 // ZhipuReranker implements a reranking system based on Zhipu AI models
@@ -64,7 +65,7 @@ func NewZhipuReranker(config *RerankerConfig) (*ZhipuReranker, error) {
 		modelID:   config.ModelID,
 		apiKey:    apiKey,
 		baseURL:   baseURL,
-		client:    &http.Client{},
+		client:    &http.Client{Transport: tracing.Transport(nil)},
 	}, nil
 }
 
@@ -0,0 +1,106 @@
+// Package bedrock is a minimal client of the Amazon Bedrock runtime API, signing requests with
+// AWS Signature Version 4 so that no AWS SDK is needed
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/tracing"
+)
+
+// signingService is the service name Bedrock runtime requests are signed for
+const signingService = "bedrock"
+
+// Config holds the region and credentials of a Bedrock client
+type Config struct {
+	Region string
+	Credentials
+	// BaseURL overrides the regional runtime endpoint, such as a VPC endpoint
+	BaseURL string
+}
+
+// Client calls the Bedrock runtime API
+type Client struct {
+	config     Config
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a Bedrock runtime client
+func NewClient(config Config) (*Client, error) {
+	if config.Region == "" {
+		return nil, fmt.Errorf("bedrock region is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("bedrock access key ID and secret access key are required")
+	}
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", config.Region)
+	}
+	return &Client{
+		config:     config,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Minute, Transport: tracing.Transport(nil)},
+	}, nil
+}
+
+// Invoke calls a non-streaming model action, such as converse or invoke, and decodes the JSON
+// response into out
+func (c *Client) Invoke(ctx context.Context, modelID, action string, body any, out any) error {
+	resp, err := c.do(ctx, modelID, action, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Stream calls a streaming model action, such as converse-stream, and returns its event stream
+func (c *Client) Stream(ctx context.Context, modelID, action string, body any) (*EventReader, error) {
+	resp, err := c.do(ctx, modelID, action, body, "application/vnd.amazon.eventstream")
+	if err != nil {
+		return nil, err
+	}
+	return NewEventReader(resp.Body), nil
+}
+
+func (c *Client) do(ctx context.Context, modelID, action string, body any, accept string) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	// Model IDs and inference profile ARNs contain ':' and '/', which must be escaped in the path
+	escapedPath := "/model/" + strings.ReplaceAll(url.PathEscape(modelID), ":", "%3A") + "/" + action
+	endpoint, err := url.Parse(c.baseURL + escapedPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bedrock endpoint: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	Sign(req, payload, c.config.Credentials, c.config.Region, signingService, time.Now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("Bedrock request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return resp, nil
+}
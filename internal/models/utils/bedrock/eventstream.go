@@ -0,0 +1,124 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// maxEventSize bounds the size of one event stream message
+const maxEventSize = 16 << 20
+
+// Event is one message of an AWS event stream
+type Event struct {
+	// Type is the :event-type header, such as contentBlockDelta
+	Type string
+	// MessageType is the :message-type header: event, exception or error
+	MessageType string
+	// ExceptionType is the :exception-type header of exceptions
+	ExceptionType string
+	Payload       []byte
+}
+
+// EventReader decodes the application/vnd.amazon.eventstream responses of streaming APIs
+type EventReader struct {
+	body io.ReadCloser
+}
+
+// NewEventReader reads events from an event stream body
+func NewEventReader(body io.ReadCloser) *EventReader {
+	return &EventReader{body: body}
+}
+
+// Next returns the next event, io.EOF at the end of the stream
+func (r *EventReader) Next() (*Event, error) {
+	var prelude [12]byte
+	if _, err := io.ReadFull(r.body, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated event stream: %w", err)
+		}
+		return nil, err
+	}
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, errors.New("event stream prelude checksum mismatch")
+	}
+	if totalLength < 16 || totalLength > maxEventSize || headersLength > totalLength-16 {
+		return nil, fmt.Errorf("invalid event stream message length %d", totalLength)
+	}
+
+	message := make([]byte, totalLength)
+	copy(message, prelude[:])
+	if _, err := io.ReadFull(r.body, message[12:]); err != nil {
+		return nil, fmt.Errorf("truncated event stream: %w", err)
+	}
+	crcOffset := totalLength - 4
+	if crc32.ChecksumIEEE(message[:crcOffset]) != binary.BigEndian.Uint32(message[crcOffset:]) {
+		return nil, errors.New("event stream message checksum mismatch")
+	}
+
+	headers, err := parseHeaders(message[12 : 12+headersLength])
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		Type:          headers[":event-type"],
+		MessageType:   headers[":message-type"],
+		ExceptionType: headers[":exception-type"],
+		Payload:       message[12+headersLength : crcOffset],
+	}, nil
+}
+
+// Close closes the underlying body
+func (r *EventReader) Close() error {
+	return r.body.Close()
+}
+
+// parseHeaders decodes the string headers of a message, skipping headers of other value types
+func parseHeaders(b []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(b) > 0 {
+		nameLength := int(b[0])
+		if len(b) < 1+nameLength+1 {
+			return nil, errors.New("invalid event stream header")
+		}
+		name := string(b[1 : 1+nameLength])
+		valueType := b[1+nameLength]
+		b = b[2+nameLength:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true, bool false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // int16
+			size = 2
+		case 4: // int32
+			size = 4
+		case 5, 8: // int64, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // byte array, string
+			if len(b) < 2 {
+				return nil, errors.New("invalid event stream header")
+			}
+			size = int(binary.BigEndian.Uint16(b[:2]))
+			b = b[2:]
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d", valueType)
+		}
+		if len(b) < size {
+			return nil, errors.New("invalid event stream header")
+		}
+		if valueType == 7 {
+			headers[name] = string(b[:size])
+		}
+		b = b[size:]
+	}
+	return headers, nil
+}
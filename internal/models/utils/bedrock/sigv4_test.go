@@ -0,0 +1,106 @@
+package bedrock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestSignGetVanilla checks the signer against the get-vanilla case of the AWS SigV4 test suite
+func TestSignGetVanilla(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	Sign(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %q", got)
+	}
+}
+
+func TestCanonicalURI(t *testing.T) {
+	tests := map[string]string{
+		"":                                     "/",
+		"/":                                    "/",
+		"/model/anthropic.claude%3A0/converse": "/model/anthropic.claude%253A0/converse",
+		"/model/titan-embed/invoke":            "/model/titan-embed/invoke",
+	}
+	for path, want := range tests {
+		if got := canonicalURI(path); got != want {
+			t.Errorf("canonicalURI(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// encodeEvent builds an event stream message with string headers
+func encodeEvent(headers map[string]string, payload []byte) []byte {
+	var h bytes.Buffer
+	for name, value := range headers {
+		h.WriteByte(byte(len(name)))
+		h.WriteString(name)
+		h.WriteByte(7)
+		binary.Write(&h, binary.BigEndian, uint16(len(value)))
+		h.WriteString(value)
+	}
+	total := uint32(12 + h.Len() + len(payload) + 4)
+	var msg bytes.Buffer
+	binary.Write(&msg, binary.BigEndian, total)
+	binary.Write(&msg, binary.BigEndian, uint32(h.Len()))
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(h.Bytes())
+	msg.Write(payload)
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func TestEventReader(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(encodeEvent(map[string]string{
+		":event-type": "contentBlockDelta", ":message-type": "event",
+	}, []byte(`{"delta":{"text":"hi"}}`)))
+	stream.Write(encodeEvent(map[string]string{
+		":exception-type": "throttlingException", ":message-type": "exception",
+	}, []byte(`{"message":"slow down"}`)))
+
+	reader := NewEventReader(io.NopCloser(&stream))
+	event, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "contentBlockDelta" || event.MessageType != "event" || string(event.Payload) != `{"delta":{"text":"hi"}}` {
+		t.Errorf("unexpected first event %+v", event)
+	}
+	event, err = reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.MessageType != "exception" || event.ExceptionType != "throttlingException" {
+		t.Errorf("unexpected second event %+v", event)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestEventReaderChecksumMismatch(t *testing.T) {
+	msg := encodeEvent(map[string]string{":event-type": "messageStop"}, []byte(`{}`))
+	msg[len(msg)-5] ^= 0xff
+	if _, err := NewEventReader(io.NopCloser(bytes.NewReader(msg))).Next(); err == nil {
+		t.Error("expected a checksum error")
+	}
+}
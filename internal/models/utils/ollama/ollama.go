@@ -12,9 +12,13 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/ollama/ollama/api"
 )
 
+// httpClient is the client of the Ollama services, it applies the outbound proxy and TLS settings
+var httpClient = &http.Client{Transport: utils.OutboundTransport()}
+
 // OllamaService manages Ollama service
 type OllamaService struct {
 	client      *api.Client
@@ -41,7 +45,7 @@ func GetOllamaService() (*OllamaService, error) {
 	}
 
 	// Create official client
-	client := api.NewClient(parsedURL, http.DefaultClient)
+	client := api.NewClient(parsedURL, httpClient)
 
 	// Check if Ollama is set as optional
 	isOptional := false
@@ -59,6 +63,52 @@ func GetOllamaService() (*OllamaService, error) {
 	return service, nil
 }
 
+// DefaultBaseURL is the address of an Ollama server running on the same host
+const DefaultBaseURL = "http://localhost:11434"
+
+var (
+	servicesMu sync.Mutex
+	services   = make(map[string]*OllamaService)
+)
+
+// NormalizeBaseURL returns the root URL of an Ollama server, dropping the trailing slash and the
+// OpenAI-compatible /v1 path that users often paste
+func NormalizeBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+	if baseURL == "" {
+		return DefaultBaseURL
+	}
+	return baseURL
+}
+
+// GetOllamaServiceForURL returns the service of the Ollama server at baseURL, used for servers
+// configured per tenant or per model rather than through OLLAMA_BASE_URL. Services are cached by URL.
+func GetOllamaServiceForURL(baseURL string) (*OllamaService, error) {
+	baseURL = NormalizeBaseURL(baseURL)
+
+	servicesMu.Lock()
+	defer servicesMu.Unlock()
+	if service, ok := services[baseURL]; ok {
+		return service, nil
+	}
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("invalid Ollama service URL: %s", baseURL)
+	}
+	service := &OllamaService{
+		client:  api.NewClient(parsedURL, httpClient),
+		baseURL: baseURL,
+	}
+	services[baseURL] = service
+	return service, nil
+}
+
+// BaseURL returns the root URL of the Ollama server
+func (s *OllamaService) BaseURL() string {
+	return s.baseURL
+}
+
 // StartService checks if Ollama service is available
 func (s *OllamaService) StartService(ctx context.Context) error {
 	s.mu.Lock()
@@ -250,10 +300,13 @@ func (s *OllamaService) GetModelInfo(ctx context.Context, modelName string) (*ap
 
 // OllamaModelInfo represents detailed information about an Ollama model
 type OllamaModelInfo struct {
-	Name       string    `json:"name"`
-	Size       int64     `json:"size"`
-	Digest     string    `json:"digest"`
-	ModifiedAt time.Time `json:"modified_at"`
+	Name              string    `json:"name"`
+	Size              int64     `json:"size"`
+	Digest            string    `json:"digest"`
+	ModifiedAt        time.Time `json:"modified_at"`
+	Family            string    `json:"family,omitempty"`
+	ParameterSize     string    `json:"parameter_size,omitempty"`
+	QuantizationLevel string    `json:"quantization_level,omitempty"`
 }
 
 // ListModels lists all available models with basic info (names only)
@@ -286,10 +339,13 @@ func (s *OllamaService) ListModelsDetailed(ctx context.Context) ([]OllamaModelIn
 	models := make([]OllamaModelInfo, len(listResp.Models))
 	for i, model := range listResp.Models {
 		models[i] = OllamaModelInfo{
-			Name:       model.Name,
-			Size:       model.Size,
-			Digest:     model.Digest,
-			ModifiedAt: model.ModifiedAt,
+			Name:              model.Name,
+			Size:              model.Size,
+			Digest:            model.Digest,
+			ModifiedAt:        model.ModifiedAt,
+			Family:            model.Details.Family,
+			ParameterSize:     model.Details.ParameterSize,
+			QuantizationLevel: model.Details.QuantizationLevel,
 		}
 	}
 
@@ -1,17 +1,24 @@
 package router
 
 import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.uber.org/dig"
 
 	"github.com/Tencent/WeKnora/internal/config"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/handler"
 	"github.com/Tencent/WeKnora/internal/handler/session"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/middleware"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 
@@ -23,6 +30,7 @@ type RouterParams struct {
 	dig.In
 
 	Config                *config.Config
+	RedisClient           *redis.Client
 	UserService           interfaces.UserService
 	KBService             interfaces.KnowledgeBaseService
 	KnowledgeService      interfaces.KnowledgeService
@@ -35,6 +43,13 @@ type RouterParams struct {
 	KnowledgeHandler      *handler.KnowledgeHandler
 	TenantHandler         *handler.TenantHandler
 	TenantService         interfaces.TenantService
+	APIKeyService         interfaces.APIKeyService
+	APIKeyHandler         *handler.APIKeyHandler
+	ServiceAccountHandler *handler.ServiceAccountHandler
+	JobHandler            *handler.JobHandler
+	TenantBackupHandler   *handler.TenantBackupHandler
+	WebhookHandler        *handler.WebhookHandler
+	SystemStatsHandler    *handler.SystemStatsHandler
 	ChunkHandler          *handler.ChunkHandler
 	SessionHandler        *session.Handler
 	MessageHandler        *handler.MessageHandler
@@ -50,32 +65,63 @@ type RouterParams struct {
 	CustomAgentHandler    *handler.CustomAgentHandler
 	SkillHandler          *handler.SkillHandler
 	OrganizationHandler   *handler.OrganizationHandler
+	UsageHandler          *handler.UsageHandler
+	ModerationHandler     *handler.ModerationHandler
+	ModelProviderHandler  *handler.ModelProviderHandler
+	HealthHandler         *handler.HealthHandler
+	MCPServerHandler      *handler.MCPServerHandler
 }
 
 // NewRouter 创建新的路由
 func NewRouter(params RouterParams) *gin.Engine {
 	r := gin.New()
 
+	// 仅信任配置的反向代理转发的客户端地址，未配置时不信任转发头，IP 访问策略依赖真实的客户端地址
+	var trustedProxies []string
+	if params.Config.Server != nil {
+		trustedProxies = params.Config.Server.TrustedProxies
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Printf("Invalid trusted proxies %v, forwarded headers are not trusted: %v", trustedProxies, err)
+		_ = r.SetTrustedProxies(nil)
+	}
+
 	// CORS 中间件应放在最前面
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID"},
-		ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID", "traceparent", "tracestate", "Idempotency-Key", "X-Knowledge-Base-IDs", "Mcp-Protocol-Version"},
+		ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "X-Trace-ID", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// 基础中间件（不需要认证）
 	r.Use(middleware.RequestID())
+	r.Use(middleware.Language())
 	r.Use(middleware.Logger())
 	r.Use(middleware.Recovery())
 	r.Use(middleware.ErrorHandler())
+	r.Use(middleware.Metrics())
+	// 响应压缩（按 Accept-Encoding 协商 zstd/gzip）
+	r.Use(middleware.Compression(params.Config))
 
 	// 健康检查（不需要认证）
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
+	// 依赖健康与就绪检查（不需要认证），探测各依赖的状态与延迟
+	r.GET("/healthz", params.HealthHandler.Healthz)
+	r.GET("/readyz", params.HealthHandler.Readyz)
+
+	// Prometheus 指标（不需要用户认证）：默认由独立端口提供，只有配置了抓取 Token 时才挂在 API 端口上
+	if m := params.Config.Metrics; m != nil && m.Enabled && m.Listen == "" {
+		if m.Token != "" {
+			r.GET("/metrics", metricsHandler(m.Token))
+		} else {
+			log.Printf("Metrics are not served: set metrics.listen, or metrics.token to serve them on the API port")
+		}
+	}
 
 	// Swagger API 文档（仅在非生产环境下启用）
 	// 通过 GIN_MODE 环境变量判断：release 模式下禁用 Swagger
@@ -89,7 +135,16 @@ func NewRouter(params RouterParams) *gin.Engine {
 	}
 
 	// 认证中间件
-	r.Use(middleware.Auth(params.TenantService, params.UserService, params.Config))
+	r.Use(middleware.Auth(params.TenantService, params.UserService, params.APIKeyService, params.Config))
+
+	// IP 访问策略中间件（依赖认证得到的租户）
+	r.Use(middleware.IPPolicy(params.TenantService))
+
+	// 限流中间件（依赖认证得到的租户与 API Key）
+	r.Use(middleware.RateLimit(params.RedisClient, params.Config))
+
+	// 幂等中间件（依赖认证得到的租户，限流拒绝的请求不占用幂等键）
+	r.Use(middleware.Idempotency(params.RedisClient, params.Config))
 
 	// 添加OpenTelemetry追踪中间件
 	r.Use(middleware.TracingMiddleware())
@@ -99,6 +154,10 @@ func NewRouter(params RouterParams) *gin.Engine {
 	{
 		RegisterAuthRoutes(v1, params.AuthHandler)
 		RegisterTenantRoutes(v1, params.TenantHandler)
+		RegisterAPIKeyRoutes(v1, params.APIKeyHandler)
+		RegisterServiceAccountRoutes(v1, params.ServiceAccountHandler)
+		RegisterJobRoutes(v1, params.JobHandler)
+		RegisterWebhookRoutes(v1, params.WebhookHandler)
 		RegisterKnowledgeBaseRoutes(v1, params.KBHandler)
 		RegisterKnowledgeTagRoutes(v1, params.TagHandler)
 		RegisterKnowledgeRoutes(v1, params.KnowledgeHandler)
@@ -106,21 +165,57 @@ func NewRouter(params RouterParams) *gin.Engine {
 		RegisterChunkRoutes(v1, params.ChunkHandler)
 		RegisterSessionRoutes(v1, params.SessionHandler)
 		RegisterChatRoutes(v1, params.SessionHandler)
+		RegisterOpenAIRoutes(v1, params.SessionHandler)
 		RegisterMessageRoutes(v1, params.MessageHandler)
 		RegisterModelRoutes(v1, params.ModelHandler)
+		RegisterModelProviderRoutes(v1, params.ModelProviderHandler)
 		RegisterEvaluationRoutes(v1, params.EvaluationHandler)
 		RegisterInitializationRoutes(v1, params.InitializationHandler)
 		RegisterSystemRoutes(v1, params.SystemHandler)
+		RegisterTenantBackupRoutes(v1, params.TenantBackupHandler)
+		RegisterSystemStatsRoutes(v1, params.SystemStatsHandler)
 		RegisterMCPServiceRoutes(v1, params.MCPServiceHandler)
+		RegisterMCPServerRoutes(v1, params.MCPServerHandler)
 		RegisterWebSearchRoutes(v1, params.WebSearchHandler)
 		RegisterCustomAgentRoutes(v1, params.CustomAgentHandler)
 		RegisterSkillRoutes(v1, params.SkillHandler)
 		RegisterOrganizationRoutes(v1, params.OrganizationHandler)
+		RegisterUsageRoutes(v1, params.UsageHandler)
+		RegisterModerationRoutes(v1, params.ModerationHandler)
 	}
 
 	return r
 }
 
+// NewMetricsServer 返回独立的指标服务，未配置 metrics.listen 时返回 nil
+func NewMetricsServer(cfg *config.Config) *http.Server {
+	m := cfg.Metrics
+	if m == nil || !m.Enabled || m.Listen == "" {
+		return nil
+	}
+	r := gin.New()
+	r.Use(middleware.Recovery())
+	r.Use(middleware.ErrorHandler())
+	r.GET("/metrics", metricsHandler(m.Token))
+	return &http.Server{Addr: m.Listen, Handler: r}
+}
+
+// metricsHandler 返回 Prometheus 指标，配置了 Token 时要求抓取方携带 Bearer Token
+func metricsHandler(token string) gin.HandlerFunc {
+	h := metrics.Handler()
+	return func(c *gin.Context) {
+		if token != "" {
+			provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				c.Error(apperrors.NewUnauthorizedError("Unauthorized: invalid metrics token"))
+				c.Abort()
+				return
+			}
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
 // RegisterChunkRoutes 注册分块相关的路由
 func RegisterChunkRoutes(r *gin.RouterGroup, handler *handler.ChunkHandler) {
 	// 分块路由组
@@ -136,6 +231,10 @@ func RegisterChunkRoutes(r *gin.RouterGroup, handler *handler.ChunkHandler) {
 		chunks.DELETE("/:knowledge_id", handler.DeleteChunksByKnowledgeID)
 		// 更新分块信息
 		chunks.PUT("/:knowledge_id/:id", handler.UpdateChunk)
+		// 置顶/取消置顶分块
+		chunks.PUT("/:knowledge_id/:id/pin", handler.PinChunk)
+		// 启用/禁用分块
+		chunks.PUT("/:knowledge_id/:id/enabled", handler.SetChunkEnabled)
 		// 删除单个生成的问题（通过问题ID）
 		chunks.DELETE("/by-id/:id/questions", handler.DeleteGeneratedQuestion)
 	}
@@ -148,12 +247,26 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 	{
 		// 从文件创建知识
 		kb.POST("/file", handler.CreateKnowledgeFromFile)
+		// 导入印象笔记导出文件，每条笔记一个知识
+		kb.POST("/enex", handler.ImportEnex)
 		// 从URL创建知识
 		kb.POST("/url", handler.CreateKnowledgeFromURL)
+		// 下载 URL 指向的文件并按上传文件导入
+		kb.POST("/fetch", handler.CreateKnowledgeFromFetchedURL)
 		// 手工 Markdown 录入
 		kb.POST("/manual", handler.CreateManualKnowledge)
 		// 获取知识库下的知识列表
 		kb.GET("", handler.ListKnowledge)
+		// 获取回收站中的知识
+		kb.GET("/trash", handler.ListTrashedKnowledge)
+		// 重复文档报告与一键去重
+		kb.GET("/duplicates", handler.ListDuplicateKnowledge)
+		kb.POST("/duplicates/dedup", handler.DedupKnowledge)
+		// 失效链接报告
+		kb.GET("/link-report", handler.GetKnowledgeLinkReport)
+		// 批量删除、改标签、重新解析知识及其进度
+		kb.POST("/bulk", handler.BulkKnowledgeOperation)
+		kb.GET("/bulk/:task_id", handler.GetKnowledgeBulkProgress)
 	}
 
 	// 知识路由组
@@ -161,6 +274,8 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 	{
 		// 批量获取知识
 		k.GET("/batch", handler.GetKnowledgeBatch)
+		// 批量分析待导入的 URL
+		k.POST("/url/analyze-batch", handler.AnalyzeURLs)
 		// 获取知识详情
 		k.GET("/:id", handler.GetKnowledge)
 		// 删除知识
@@ -171,15 +286,46 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 		k.PUT("/manual/:id", handler.UpdateManualKnowledge)
 		// 重新解析知识
 		k.POST("/:id/reparse", handler.ReparseKnowledge)
+		// 重新抓取 URL 知识：先预览差异，确认后替换
+		k.POST("/:id/recapture/preview", handler.PreviewKnowledgeRecapture)
+		k.POST("/:id/recapture", handler.ConfirmKnowledgeRecapture)
 		// 获取知识文件
 		k.GET("/:id/download", handler.DownloadKnowledgeFile)
+		// 知识解析进度（SSE）
+		k.GET("/:id/progress", handler.GetKnowledgeProgress)
 		// 更新图像分块信息
 		k.PUT("/image/:id/:chunk_id", handler.UpdateImageInfo)
 		// 批量更新知识标签
 		k.PUT("/tags", handler.UpdateKnowledgeTagBatch)
+		// 批量移动/重新打标签
+		k.POST("/tags/batch", handler.RetagKnowledgeBatch)
+		// 设置知识的多个标签
+		k.PUT("/:id/tags", handler.SetKnowledgeTags)
+		// 设置知识过期时间
+		k.PUT("/:id/expiry", handler.SetKnowledgeExpiry)
+		// 归档/取消归档知识
+		k.POST("/:id/archive", handler.ArchiveKnowledge)
+		k.POST("/:id/unarchive", handler.UnarchiveKnowledge)
+		// 文档权限
+		k.GET("/:id/permissions", handler.ListKnowledgePermissions)
+		k.PUT("/:id/permissions/:user_id", handler.SetKnowledgePermission)
+		k.DELETE("/:id/permissions/:user_id", handler.RemoveKnowledgePermission)
+		// 从回收站恢复知识
+		k.POST("/:id/restore", handler.RestoreKnowledge)
+		// 移动/复制知识到其他知识库
+		k.POST("/:id/move", handler.MoveKnowledge)
+		k.POST("/:id/copy", handler.CopyKnowledge)
+		// 文件签名链接
+		k.POST("/:id/links", handler.CreateKnowledgeFileLink)
+		k.GET("/:id/links", handler.ListKnowledgeFileLinks)
+		k.DELETE("/:id/links/:link_id", handler.RevokeKnowledgeFileLink)
 		// 搜索知识
 		k.GET("/search", handler.SearchKnowledge)
+		// 解析失败（重试耗尽）的知识
+		k.GET("/dead-letter", handler.ListDeadLetterKnowledge)
 	}
+	// 文件签名链接无需登录，由签名令牌授权
+	r.GET("/shared/files/:token", handler.GetSharedKnowledgeFile)
 }
 
 // RegisterFAQRoutes 注册 FAQ 相关路由
@@ -213,6 +359,9 @@ func RegisterFAQRoutes(r *gin.RouterGroup, handler *handler.FAQHandler) {
 
 // RegisterKnowledgeBaseRoutes 注册知识库相关的路由
 func RegisterKnowledgeBaseRoutes(r *gin.RouterGroup, handler *handler.KnowledgeBaseHandler) {
+	// 接受知识库邀请
+	r.POST("/kb-invites/accept", handler.AcceptKBInvite)
+
 	// 知识库路由组
 	kb := r.Group("/knowledge-bases")
 	{
@@ -226,12 +375,68 @@ func RegisterKnowledgeBaseRoutes(r *gin.RouterGroup, handler *handler.KnowledgeB
 		kb.PUT("/:id", handler.UpdateKnowledgeBase)
 		// 删除知识库
 		kb.DELETE("/:id", handler.DeleteKnowledgeBase)
+		// 知识库角色
+		kb.GET("/:id/my-role", handler.GetMyKBRole)
+		kb.GET("/:id/members", handler.ListKBMembers)
+		kb.PUT("/:id/members/:user_id", handler.SetKBMemberRole)
+		kb.DELETE("/:id/members/:user_id", handler.RemoveKBMember)
+		// 邀请链接
+		kb.GET("/:id/invites", handler.ListKBInvites)
+		kb.POST("/:id/invites", handler.CreateKBInvite)
+		kb.DELETE("/:id/invites/:invite_id", handler.RevokeKBInvite)
 		// 混合搜索
 		kb.GET("/:id/hybrid-search", handler.HybridSearch)
+		kb.POST("/:id/image-search", handler.ImageSearch)
+		// 预览图片理解效果
+		kb.POST("/:id/vlm/preview", handler.PreviewImageUnderstanding)
+		// 检索分析
+		kb.GET("/:id/search-analytics", handler.GetSearchAnalytics)
+		kb.POST("/:id/search-analytics/clicks", handler.RecordSearchClick)
+		kb.POST("/:id/search-analytics/feedback", handler.RecordSearchFeedback)
+		// 回答反馈审核队列
+		kb.GET("/:id/answer-feedback", handler.ListAnswerFeedback)
+		kb.PUT("/:id/answer-feedback/:feedback_id", handler.UpdateAnswerFeedback)
+		// 检索评测
+		kb.POST("/:id/eval-sets", handler.CreateEvalSet)
+		kb.GET("/:id/eval-sets", handler.ListEvalSets)
+		kb.GET("/:id/eval-sets/:set_id", handler.GetEvalSet)
+		kb.PUT("/:id/eval-sets/:set_id", handler.UpdateEvalSet)
+		kb.DELETE("/:id/eval-sets/:set_id", handler.DeleteEvalSet)
+		kb.POST("/:id/eval-sets/:set_id/runs", handler.StartEvalRun)
+		kb.GET("/:id/eval-sets/:set_id/runs", handler.ListEvalRuns)
+		kb.GET("/:id/eval-runs/:run_id", handler.GetEvalRun)
+		// A/B 实验
+		kb.POST("/:id/experiments", handler.CreateExperiment)
+		kb.GET("/:id/experiments", handler.ListExperiments)
+		kb.GET("/:id/experiments/:experiment_id", handler.GetExperiment)
+		kb.PUT("/:id/experiments/:experiment_id", handler.UpdateExperiment)
+		kb.DELETE("/:id/experiments/:experiment_id", handler.DeleteExperiment)
+		kb.POST("/:id/experiments/:experiment_id/stop", handler.StopExperiment)
+		kb.POST("/:id/experiments/:experiment_id/promote", handler.PromoteExperiment)
+		kb.GET("/:id/experiments/:experiment_id/results", handler.GetExperimentResults)
+		// 提示词模板
+		kb.GET("/:id/prompt-templates", handler.ListPromptTemplates)
+		kb.POST("/:id/prompt-templates", handler.CreatePromptTemplate)
+		kb.POST("/:id/prompt-templates/unpublish", handler.UnpublishPromptTemplate)
+		kb.GET("/:id/prompt-templates/:template_id", handler.GetPromptTemplate)
+		kb.PUT("/:id/prompt-templates/:template_id", handler.UpdatePromptTemplate)
+		kb.DELETE("/:id/prompt-templates/:template_id", handler.DeletePromptTemplate)
+		kb.POST("/:id/prompt-templates/:template_id/publish", handler.PublishPromptTemplate)
+		kb.POST("/:id/prompt-templates/:template_id/preview", handler.PreviewPromptTemplate)
 		// 拷贝知识库
 		kb.POST("/copy", handler.CopyKnowledgeBase)
 		// 获取知识库复制进度
 		kb.GET("/copy/progress/:task_id", handler.GetKBCloneProgress)
+		// 更换向量模型并重建索引
+		kb.POST("/:id/reindex", handler.ReindexKnowledgeBase)
+		// 获取重建索引进度
+		kb.GET("/reindex/progress/:task_id", handler.GetKBReindexProgress)
+		// 导出知识库
+		kb.GET("/:id/export", handler.ExportKnowledgeBase)
+		// 导入知识库
+		kb.POST("/import", handler.ImportKnowledgeBase)
+		// 获取导入进度
+		kb.GET("/import/progress/:task_id", handler.GetKBImportProgress)
 	}
 }
 
@@ -243,8 +448,10 @@ func RegisterKnowledgeTagRoutes(r *gin.RouterGroup, tagHandler *handler.TagHandl
 	kbTags := r.Group("/knowledge-bases/:id/tags")
 	{
 		kbTags.GET("", tagHandler.ListTags)
+		kbTags.GET("/tree", tagHandler.GetTagTree)
 		kbTags.POST("", tagHandler.CreateTag)
 		kbTags.PUT("/:tag_id", tagHandler.UpdateTag)
+		kbTags.PUT("/:tag_id/move", tagHandler.MoveTag)
 		kbTags.DELETE("/:tag_id", tagHandler.DeleteTag)
 	}
 }
@@ -258,6 +465,9 @@ func RegisterMessageRoutes(r *gin.RouterGroup, handler *handler.MessageHandler)
 		messages.GET("/:session_id/load", handler.LoadMessages)
 		// 删除消息
 		messages.DELETE("/:session_id/:id", handler.DeleteMessage)
+		// 对回答点赞/点踩及文字反馈
+		messages.POST("/:session_id/:id/feedback", handler.SubmitFeedback)
+		messages.GET("/:session_id/:id/feedback", handler.GetFeedback)
 	}
 }
 
@@ -272,9 +482,23 @@ func RegisterSessionRoutes(r *gin.RouterGroup, handler *session.Handler) {
 		sessions.DELETE("/:id", handler.DeleteSession)
 		sessions.POST("/:session_id/generate_title", handler.GenerateTitle)
 		sessions.POST("/:session_id/stop", handler.StopSession)
+		// 导出会话及只读分享链接
+		sessions.GET("/:id/export", handler.ExportSession)
+		sessions.POST("/:session_id/share", handler.ShareSession)
 		// 继续接收活跃流
 		sessions.GET("/continue-stream/:session_id", handler.ContinueStream)
 	}
+	// 分享链接无需登录，由签名令牌授权只读访问
+	r.GET("/shared/sessions/:token", handler.GetSharedSession)
+}
+
+// RegisterOpenAIRoutes 注册 OpenAI 兼容接口的路由
+func RegisterOpenAIRoutes(r *gin.RouterGroup, handler *session.Handler) {
+	openai := r.Group("/openai")
+	{
+		openai.POST("/chat/completions", handler.ChatCompletions)
+		openai.GET("/models", handler.ListOpenAIModels)
+	}
 }
 
 // RegisterChatRoutes 注册路由
@@ -295,6 +519,9 @@ func RegisterChatRoutes(r *gin.RouterGroup, handler *session.Handler) {
 	{
 		knowledgeSearch.POST("", handler.SearchKnowledge)
 	}
+
+	// 检索调试接口，返回检索流水线各阶段的输出
+	r.GET("/debug/retrieval", handler.DebugRetrieval)
 }
 
 // RegisterTenantRoutes 注册租户相关的路由
@@ -319,6 +546,93 @@ func RegisterTenantRoutes(r *gin.RouterGroup, handler *handler.TenantHandler) {
 	}
 }
 
+// RegisterAPIKeyRoutes 注册租户 API Key 管理相关的路由
+func RegisterAPIKeyRoutes(r *gin.RouterGroup, handler *handler.APIKeyHandler) {
+	apiKeys := r.Group("/api-keys")
+	{
+		apiKeys.GET("", handler.ListAPIKeys)
+		apiKeys.POST("", handler.CreateAPIKey)
+		apiKeys.PUT("/:id", handler.UpdateAPIKey)
+		apiKeys.DELETE("/:id", handler.RevokeAPIKey)
+		// 轮换密钥，原密钥立即失效
+		apiKeys.POST("/:id/rotate", handler.RotateAPIKey)
+	}
+}
+
+// RegisterServiceAccountRoutes 注册服务账号管理相关的路由，服务账号的 API Key 通过 /api-keys 创建
+func RegisterServiceAccountRoutes(r *gin.RouterGroup, handler *handler.ServiceAccountHandler) {
+	serviceAccounts := r.Group("/service-accounts")
+	{
+		serviceAccounts.GET("", handler.ListServiceAccounts)
+		serviceAccounts.POST("", handler.CreateServiceAccount)
+		serviceAccounts.GET("/:id", handler.GetServiceAccount)
+		serviceAccounts.PUT("/:id", handler.UpdateServiceAccount)
+		serviceAccounts.DELETE("/:id", handler.DeleteServiceAccount)
+	}
+}
+
+// RegisterJobRoutes 注册后台任务状态查询与取消相关的路由
+func RegisterJobRoutes(r *gin.RouterGroup, handler *handler.JobHandler) {
+	jobs := r.Group("/jobs")
+	{
+		jobs.GET("", handler.ListJobs)
+		jobs.GET("/:id", handler.GetJob)
+		jobs.POST("/:id/cancel", handler.CancelJob)
+	}
+}
+
+// RegisterWebhookRoutes 注册 Webhook 管理与投递记录相关的路由
+func RegisterWebhookRoutes(r *gin.RouterGroup, handler *handler.WebhookHandler) {
+	webhooks := r.Group("/webhooks")
+	{
+		webhooks.GET("", handler.ListWebhooks)
+		webhooks.POST("", handler.CreateWebhook)
+		webhooks.GET("/:id", handler.GetWebhook)
+		webhooks.PUT("/:id", handler.UpdateWebhook)
+		webhooks.DELETE("/:id", handler.DeleteWebhook)
+		// 轮换签名密钥，之后的投递使用新密钥签名
+		webhooks.POST("/:id/rotate-secret", handler.RotateWebhookSecret)
+		webhooks.POST("/:id/test", handler.TestWebhook)
+		webhooks.GET("/:id/deliveries", handler.ListWebhookDeliveries)
+	}
+}
+
+// RegisterUsageRoutes 注册当前租户 Token 用量与资源配额相关的路由
+func RegisterUsageRoutes(r *gin.RouterGroup, handler *handler.UsageHandler) {
+	usage := r.Group("/usage")
+	{
+		usage.GET("/tokens", handler.GetTokenUsage)
+		usage.GET("/budget", handler.GetTokenBudget)
+		usage.GET("/quota", handler.GetQuota)
+	}
+}
+
+// RegisterModerationRoutes 注册内容审核记录相关的路由
+func RegisterModerationRoutes(r *gin.RouterGroup, handler *handler.ModerationHandler) {
+	moderation := r.Group("/moderation")
+	{
+		moderation.GET("/events", handler.ListModerationEvents)
+		moderation.PUT("/events/:id/review", handler.ReviewModerationEvent)
+	}
+}
+
+// RegisterModelProviderRoutes 注册本地模型服务（Ollama、vLLM）相关的路由
+func RegisterModelProviderRoutes(r *gin.RouterGroup, handler *handler.ModelProviderHandler) {
+	providers := r.Group("/models/providers/:provider")
+	{
+		// 测试服务连接
+		providers.POST("/test", handler.TestProviderConnection)
+		// 获取已部署的模型
+		providers.GET("/models", handler.ListProviderModels)
+		// 拉取模型
+		providers.POST("/pulls", handler.PullProviderModel)
+		// 获取下载任务列表
+		providers.GET("/pulls", handler.ListProviderPulls)
+		// 获取下载进度
+		providers.GET("/pulls/:task_id", handler.GetProviderPull)
+	}
+}
+
 // RegisterModelRoutes 注册模型相关的路由
 func RegisterModelRoutes(r *gin.RouterGroup, handler *handler.ModelHandler) {
 	// 模型路由组
@@ -326,12 +640,20 @@ func RegisterModelRoutes(r *gin.RouterGroup, handler *handler.ModelHandler) {
 	{
 		// 获取模型厂商列表
 		models.GET("/providers", handler.ListModelProviders)
+		// 获取模型健康状态
+		models.GET("/health", handler.GetModelHealth)
+		// 获取嵌入模型吞吐统计
+		models.GET("/embedding/stats", handler.GetEmbeddingStats)
+		// 获取模型注册表
+		models.GET("/registry", handler.GetModelRegistry)
 		// 创建模型
 		models.POST("", handler.CreateModel)
 		// 获取模型列表
 		models.GET("", handler.ListModels)
 		// 获取单个模型
 		models.GET("/:id", handler.GetModel)
+		// 探测模型健康状态
+		models.POST("/:id/probe", handler.ProbeModel)
 		// 更新模型
 		models.PUT("/:id", handler.UpdateModel)
 		// 删除模型
@@ -356,6 +678,20 @@ func RegisterAuthRoutes(r *gin.RouterGroup, handler *handler.AuthHandler) {
 	r.POST("/auth/logout", handler.Logout)
 	r.GET("/auth/me", handler.GetCurrentUser)
 	r.POST("/auth/change-password", handler.ChangePassword)
+
+	// 登录会话与令牌管理
+	r.GET("/auth/sessions", handler.ListSessions)
+	r.DELETE("/auth/sessions", handler.RevokeAllSessions)
+	r.DELETE("/auth/sessions/:id", handler.RevokeSession)
+	r.DELETE("/auth/tokens/:id", handler.RevokeIssuedToken)
+
+	// OIDC 单点登录
+	r.GET("/auth/oidc/providers", handler.ListOIDCProviders)
+	r.GET("/auth/oidc/:provider/login", handler.OIDCLogin)
+	r.GET("/auth/oidc/:provider/callback", handler.OIDCCallback)
+
+	// LDAP / Active Directory 登录
+	r.POST("/auth/ldap/login", handler.LDAPLogin)
 }
 
 func RegisterInitializationRoutes(r *gin.RouterGroup, handler *handler.InitializationHandler) {
@@ -389,9 +725,40 @@ func RegisterSystemRoutes(r *gin.RouterGroup, handler *handler.SystemHandler) {
 	{
 		systemRoutes.GET("/info", handler.GetSystemInfo)
 		systemRoutes.GET("/minio/buckets", handler.ListMinioBuckets)
+		systemRoutes.GET("/config", handler.GetEffectiveConfig)
+		systemRoutes.POST("/config/reload", handler.ReloadConfig)
 	}
 }
 
+// RegisterTenantBackupRoutes 注册租户数据备份与下载相关的路由
+func RegisterTenantBackupRoutes(r *gin.RouterGroup, handler *handler.TenantBackupHandler) {
+	backups := r.Group("/system/backups")
+	{
+		backups.POST("", handler.CreateBackup)
+		backups.GET("", handler.ListBackups)
+		backups.GET("/:id", handler.GetBackup)
+		backups.GET("/:id/download", handler.DownloadBackup)
+	}
+}
+
+// RegisterSystemStatsRoutes 注册运维看板使用的跨租户统计路由
+func RegisterSystemStatsRoutes(r *gin.RouterGroup, handler *handler.SystemStatsHandler) {
+	stats := r.Group("/system/stats")
+	{
+		stats.GET("/overview", handler.GetOverview)
+		stats.GET("/tenants", handler.ListTenantStats)
+		stats.GET("/errors", handler.ListTopErrors)
+		stats.GET("/slow-ops", handler.ListSlowOps)
+	}
+}
+
+// RegisterMCPServerRoutes 注册 MCP 服务端路由，供 MCP 客户端以 Streamable HTTP 调用知识库工具
+func RegisterMCPServerRoutes(r *gin.RouterGroup, handler *handler.MCPServerHandler) {
+	r.POST("/mcp", handler.ServeMCP)
+	r.GET("/mcp", handler.RejectMCPStream)
+	r.DELETE("/mcp", handler.RejectMCPStream)
+}
+
 // RegisterMCPServiceRoutes registers MCP service routes
 func RegisterMCPServiceRoutes(r *gin.RouterGroup, handler *handler.MCPServiceHandler) {
 	mcpServices := r.Group("/mcp-services")
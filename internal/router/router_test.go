@@ -0,0 +1,18 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/config"
+)
+
+// TestNewRouter builds the full route table, gin panics on conflicting routes such as wildcards with different names
+func TestNewRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRouter(RouterParams{Config: &config.Config{}})
+	if len(r.Routes()) == 0 {
+		t.Fatal("no routes registered")
+	}
+}
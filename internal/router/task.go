@@ -1,13 +1,18 @@
 package router
 
 import (
+	"context"
 	"log"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"github.com/hibiken/asynq"
 	"go.uber.org/dig"
 )
@@ -19,6 +24,14 @@ type AsynqTaskParams struct {
 	KnowledgeService     interfaces.KnowledgeService
 	KnowledgeBaseService interfaces.KnowledgeBaseService
 	TagService           interfaces.KnowledgeTagService
+	RetrievalEvalService interfaces.RetrievalEvalService
+	ExperimentService    interfaces.ExperimentService
+	ModelService         interfaces.ModelService
+	LDAPService          interfaces.LDAPService
+	JobService           interfaces.JobService
+	TenantBackupService  interfaces.TenantBackupService
+	WebhookService       interfaces.WebhookService
+	Cleaner              interfaces.ResourceCleaner
 	ChunkExtractor       interfaces.TaskHandler `name:"chunkExtractor"`
 	DataTableSummary     interfaces.TaskHandler `name:"dataTableSummary"`
 }
@@ -51,24 +64,117 @@ func NewAsyncqClient() (*asynq.Client, error) {
 	return client, nil
 }
 
-func NewAsynqServer() *asynq.Server {
+// NewAsynqInspector creates the inspector used to take cancelled jobs off the queue
+func NewAsynqInspector() *asynq.Inspector {
+	return asynq.NewInspector(getAsynqRedisClientOpt())
+}
+
+func NewAsynqServer(cfg *config.Config) *asynq.Server {
 	opt := getAsynqRedisClientOpt()
+	// TASK_WORKER_CONCURRENCY sizes the worker pool, asynq defaults to the number of CPUs
+	concurrency := 0
+	if v := os.Getenv("TASK_WORKER_CONCURRENCY"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			concurrency = parsed
+		}
+	}
 	srv := asynq.NewServer(
 		opt,
 		asynq.Config{
+			Concurrency: concurrency,
 			Queues: map[string]int{
 				"critical": 6, // Highest priority queue
 				"default":  3, // Default priority queue
 				"low":      1, // Lowest priority queue
 			},
+			RetryDelayFunc: retryDelay,
+			// Shutdown waits this long for running tasks before requeueing them
+			ShutdownTimeout: cfg.Server.GetShutdownTimeout(),
 		},
 	)
 	return srv
 }
 
+// retryDelay backs off failed document parses exponentially and keeps the asynq default for other tasks
+func retryDelay(n int, err error, task *asynq.Task) time.Duration {
+	if task.Type() == types.TypeDocumentProcess {
+		return secutils.DocumentParseRetryDelay(n)
+	}
+	return asynq.DefaultRetryDelayFunc(n, err, task)
+}
+
+// taskInterruptGrace is how long task handlers interrupted at shutdown get to checkpoint their state
+const taskInterruptGrace = 10 * time.Second
+
+// taskDrainer keeps track of running task handlers. When its shutdown timeout is up asynq requeues the tasks
+// still running but leaves their handlers running, the drainer interrupts them so they checkpoint their state
+// before the process exits.
+type taskDrainer struct {
+	mu      sync.Mutex
+	next    uint64
+	cancels map[uint64]context.CancelCauseFunc
+	wg      sync.WaitGroup
+}
+
+func newTaskDrainer() *taskDrainer {
+	return &taskDrainer{cancels: make(map[uint64]context.CancelCauseFunc)}
+}
+
+// Middleware runs each task with a context the drainer can interrupt
+func (d *taskDrainer) Middleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		ctx, cancel := context.WithCancelCause(ctx)
+		d.mu.Lock()
+		id := d.next
+		d.next++
+		d.cancels[id] = cancel
+		d.wg.Add(1)
+		d.mu.Unlock()
+		defer func() {
+			d.mu.Lock()
+			delete(d.cancels, id)
+			d.mu.Unlock()
+			cancel(nil)
+			d.wg.Done()
+		}()
+		return next.ProcessTask(ctx, t)
+	})
+}
+
+// interrupt cancels the running handlers with types.ErrTaskInterrupted, waits up to grace for them to return
+// and returns how many were interrupted
+func (d *taskDrainer) interrupt(grace time.Duration) int {
+	d.mu.Lock()
+	running := len(d.cancels)
+	for _, cancel := range d.cancels {
+		cancel(types.ErrTaskInterrupted)
+	}
+	d.mu.Unlock()
+	if running == 0 {
+		return 0
+	}
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(grace):
+	}
+	return running
+}
+
 func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Create a new mux and register all handlers
 	mux := asynq.NewServeMux()
+	// Trace each task, continuing the trace of the request that enqueued it
+	mux.Use(tracing.TaskMiddleware)
+	// Let shutdown interrupt tasks still running when it stops waiting for them
+	drainer := newTaskDrainer()
+	mux.Use(drainer.Middleware)
+	// Record the status and history of the jobs of tenants, and skip cancelled ones
+	mux.Use(params.JobService.Track)
 
 	// Register extract handlers - router will dispatch to appropriate handler
 	mux.HandleFunc(types.TypeChunkExtract, params.ChunkExtractor.Handle)
@@ -89,20 +195,152 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Register KB clone handler
 	mux.HandleFunc(types.TypeKBClone, params.KnowledgeService.ProcessKBClone)
 
+	// Register KB embedding re-index handler
+	mux.HandleFunc(types.TypeKBEmbeddingReindex, params.KnowledgeService.ProcessKBEmbeddingReindex)
+	mux.HandleFunc(types.TypeKBImport, params.KnowledgeService.ProcessKBImport)
+
+	// Register retrieval evaluation handler
+	mux.HandleFunc(types.TypeRetrievalEval, params.RetrievalEvalService.ProcessRetrievalEval)
+
+	// Register experiment auto promotion handler
+	mux.HandleFunc(types.TypeExperimentPromotion, params.ExperimentService.ProcessExperimentPromotion)
+
 	// Register knowledge list delete handler
 	mux.HandleFunc(types.TypeKnowledgeListDelete, params.KnowledgeService.ProcessKnowledgeListDelete)
 
+	// Register knowledge bulk operation handler
+	mux.HandleFunc(types.TypeKnowledgeBulk, params.KnowledgeService.ProcessKnowledgeBulkOperation)
+
 	// Register index delete handler
 	mux.HandleFunc(types.TypeIndexDelete, params.TagService.ProcessIndexDelete)
 
 	// Register KB delete handler
 	mux.HandleFunc(types.TypeKBDelete, params.KnowledgeBaseService.ProcessKBDelete)
 
-	go func() {
-		// Start the server
-		if err := params.Server.Run(mux); err != nil {
-			log.Fatalf("could not run server: %v", err)
+	// Register knowledge lifecycle handler
+	mux.HandleFunc(types.TypeKnowledgeLifecycle, params.KnowledgeService.ProcessKnowledgeLifecycle)
+
+	// Register knowledge trash purge handler
+	mux.HandleFunc(types.TypeKnowledgeTrashPurge, params.KnowledgeService.ProcessKnowledgeTrashPurge)
+
+	// Register knowledge link check handler
+	mux.HandleFunc(types.TypeKnowledgeLinkCheck, params.KnowledgeService.ProcessKnowledgeLinkCheck)
+
+	// Register model health probe handler
+	mux.HandleFunc(types.TypeModelHealthProbe, params.ModelService.ProcessModelHealthProbe)
+	mux.HandleFunc(types.TypeLDAPSync, params.LDAPService.ProcessLDAPSync)
+	mux.HandleFunc(types.TypeJobHistoryPurge, params.JobService.ProcessJobHistoryPurge)
+	mux.HandleFunc(types.TypeTenantBackup, params.TenantBackupService.ProcessTenantBackup)
+	mux.HandleFunc(types.TypeWebhookDelivery, params.WebhookService.ProcessWebhookDelivery)
+	mux.HandleFunc(types.TypeWebhookPurge, params.WebhookService.ProcessWebhookDeliveryPurge)
+
+	// The server is started without its own signal handling, shutdown drains it through the resource cleaner
+	if err := params.Server.Start(mux); err != nil {
+		log.Fatalf("could not run server: %v", err)
+	}
+	params.Cleaner.RegisterWithName("AsynqServer", func() error {
+		// Stop pulling tasks, wait for the running ones up to the shutdown timeout and requeue the rest
+		params.Server.Shutdown()
+		if n := drainer.interrupt(taskInterruptGrace); n > 0 {
+			log.Printf("Interrupted %d background tasks still running at shutdown, they were requeued", n)
 		}
-	}()
+		return nil
+	})
 	return mux
 }
+
+const (
+	// defaultKnowledgeLifecycleInterval is the default interval of the knowledge lifecycle task
+	defaultKnowledgeLifecycleInterval = time.Hour
+	// defaultModelHealthProbeInterval is the default interval of the model health probe task
+	defaultModelHealthProbeInterval = 10 * time.Minute
+)
+
+// RunAsynqScheduler registers periodic tasks and starts the scheduler.
+// Periodic tasks are enqueued with asynq.Unique, so running several replicas does not duplicate them.
+func RunAsynqScheduler(cfg *config.Config, cleaner interfaces.ResourceCleaner) error {
+	scheduler := asynq.NewScheduler(getAsynqRedisClientOpt(), nil)
+
+	interval := defaultKnowledgeLifecycleInterval
+	if v := os.Getenv("KNOWLEDGE_LIFECYCLE_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			interval = parsed
+		}
+	}
+	task := asynq.NewTask(types.TypeKnowledgeLifecycle, nil)
+	if _, err := scheduler.Register(
+		"@every "+interval.String(), task,
+		asynq.Queue("low"), asynq.MaxRetry(1), asynq.Unique(interval),
+	); err != nil {
+		return err
+	}
+	if _, err := scheduler.Register(
+		"@every 1h", asynq.NewTask(types.TypeKnowledgeTrashPurge, nil),
+		asynq.Queue("low"), asynq.MaxRetry(1), asynq.Unique(time.Hour),
+	); err != nil {
+		return err
+	}
+	// KNOWLEDGE_LINK_CHECK_INTERVAL=0 disables the checks of source links, each run checks the links due
+	if secutils.GetKnowledgeLinkCheckInterval() > 0 {
+		if _, err := scheduler.Register(
+			"@every 1h", asynq.NewTask(types.TypeKnowledgeLinkCheck, nil),
+			asynq.Queue("low"), asynq.MaxRetry(1), asynq.Unique(time.Hour),
+		); err != nil {
+			return err
+		}
+	}
+	if _, err := scheduler.Register(
+		"@every 24h", asynq.NewTask(types.TypeJobHistoryPurge, nil),
+		asynq.Queue("low"), asynq.MaxRetry(1), asynq.Unique(24*time.Hour),
+	); err != nil {
+		return err
+	}
+	if _, err := scheduler.Register(
+		"@every 24h", asynq.NewTask(types.TypeWebhookPurge, nil),
+		asynq.Queue("low"), asynq.MaxRetry(1), asynq.Unique(24*time.Hour),
+	); err != nil {
+		return err
+	}
+	if _, err := scheduler.Register(
+		"@every 15m", asynq.NewTask(types.TypeExperimentPromotion, nil),
+		asynq.Queue("low"), asynq.MaxRetry(1), asynq.Unique(15*time.Minute),
+	); err != nil {
+		return err
+	}
+
+	// MODEL_HEALTH_PROBE_INTERVAL=0 disables the periodic model health probes
+	probeInterval := defaultModelHealthProbeInterval
+	if v := os.Getenv("MODEL_HEALTH_PROBE_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil {
+			probeInterval = parsed
+		}
+	}
+	if probeInterval > 0 {
+		if _, err := scheduler.Register(
+			"@every "+probeInterval.String(), asynq.NewTask(types.TypeModelHealthProbe, nil),
+			asynq.Queue("low"), asynq.MaxRetry(1), asynq.Unique(probeInterval),
+		); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Auth != nil && cfg.Auth.LDAP != nil && cfg.Auth.LDAP.Enabled && cfg.Auth.LDAP.SyncInterval > 0 {
+		syncInterval := cfg.Auth.LDAP.SyncInterval
+		if _, err := scheduler.Register(
+			"@every "+syncInterval.String(), asynq.NewTask(types.TypeLDAPSync, nil),
+			asynq.Queue("low"), asynq.MaxRetry(1), asynq.Unique(syncInterval),
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := scheduler.Start(); err != nil {
+		log.Printf("could not run asynq scheduler: %v", err)
+		return nil
+	}
+	cleaner.RegisterWithName("AsynqScheduler", func() error {
+		scheduler.Shutdown()
+		return nil
+	})
+	return nil
+}
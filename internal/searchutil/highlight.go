@@ -0,0 +1,404 @@
+package searchutil
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// quotedPhrasePattern matches phrases quoted in a query, which are highlighted as a whole
+var quotedPhrasePattern = regexp.MustCompile(`"([^"]+)"|“([^”]+)”`)
+
+// highlightStopWords are query words too common to be worth highlighting
+var highlightStopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {}, "by": {}, "can": {},
+	"do": {}, "does": {}, "for": {}, "from": {}, "how": {}, "in": {}, "is": {}, "it": {}, "of": {},
+	"on": {}, "or": {}, "the": {}, "to": {}, "what": {}, "when": {}, "where": {}, "which": {},
+	"who": {}, "why": {}, "with": {},
+	"什么": {}, "怎么": {}, "怎样": {}, "如何": {}, "哪些": {}, "哪个": {}, "是否": {}, "为什么": {},
+	"可以": {}, "一下": {}, "我们": {}, "你们": {},
+}
+
+// minAlignedPrefix is the shortest common prefix for a content word to count as a variant of a query word
+const minAlignedPrefix = 4
+
+// maxHighlightSpans bounds the matches considered when choosing snippets
+const maxHighlightSpans = 200
+
+// highlightSpan is a match of a term in the content, in rune offsets
+type highlightSpan struct {
+	start, end int
+	term       string
+}
+
+// HighlightTerms extracts the words and quoted phrases of a query to highlight, lowercased
+// and longest first so that longer matches win over the words they contain
+func HighlightTerms(query string) []string {
+	seen := make(map[string]struct{})
+	var terms []string
+	add := func(term string) {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if utf8.RuneCountInString(term) < 2 || strings.IndexFunc(term, isHighlightRune) == -1 {
+			return
+		}
+		if _, ok := highlightStopWords[term]; ok {
+			return
+		}
+		if _, ok := seen[term]; ok {
+			return
+		}
+		seen[term] = struct{}{}
+		terms = append(terms, term)
+	}
+
+	for _, match := range quotedPhrasePattern.FindAllStringSubmatch(query, -1) {
+		add(match[1] + match[2])
+	}
+	rest := quotedPhrasePattern.ReplaceAllString(query, " ")
+	for _, word := range types.Jieba.CutForSearch(rest, true) {
+		add(word)
+	}
+
+	sort.SliceStable(terms, func(i, j int) bool {
+		return utf8.RuneCountInString(terms[i]) > utf8.RuneCountInString(terms[j])
+	})
+	return terms
+}
+
+// HighlightResults adds highlighted snippets of the query terms to search results
+func HighlightResults(query string, results []*types.SearchResult, opts *types.HighlightOptions) {
+	if opts == nil {
+		return
+	}
+	terms := HighlightTerms(query)
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		result.Highlight = Highlight(result.Content, terms, opts.GetSnippetLength(), opts.GetMaxSnippets())
+		if opts.OmitContent {
+			result.Content = ""
+		}
+	}
+}
+
+// Highlight finds the terms in content and cuts up to maxSnippets snippets of at most
+// snippetLength characters around them. When no term occurs literally, content words sharing
+// a stem with a Latin term, or two characters with a Chinese term, are highlighted instead.
+// Without any match the snippet is the beginning of the content.
+func Highlight(content string, terms []string, snippetLength, maxSnippets int) *types.SearchHighlight {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	runes := []rune(content)
+	// Lowercase rune by rune so that offsets stay aligned with the content
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	highlight := &types.SearchHighlight{Terms: []string{}, Snippets: []types.HighlightSnippet{}}
+	spans := findTermSpans(lower, terms)
+	if len(spans) == 0 {
+		spans = alignTermSpans(lower, terms)
+		highlight.Aligned = len(spans) > 0
+	}
+	if len(spans) > maxHighlightSpans {
+		spans = spans[:maxHighlightSpans]
+	}
+
+	seen := make(map[string]struct{})
+	for _, span := range spans {
+		if _, ok := seen[span.term]; !ok {
+			seen[span.term] = struct{}{}
+			highlight.Terms = append(highlight.Terms, span.term)
+		}
+	}
+
+	for _, window := range snippetWindows(runes, spans, snippetLength, maxSnippets) {
+		highlight.Snippets = append(highlight.Snippets, buildSnippet(runes, spans, window[0], window[1]))
+	}
+	return highlight
+}
+
+// findTermSpans returns the non-overlapping occurrences of the terms, Latin words only match whole words
+func findTermSpans(lower []rune, terms []string) []highlightSpan {
+	var spans []highlightSpan
+	for _, term := range terms {
+		termRunes := []rune(term)
+		for i := 0; i+len(termRunes) <= len(lower); i++ {
+			if !hasRunesAt(lower, termRunes, i) {
+				continue
+			}
+			end := i + len(termRunes)
+			if isWordRune(termRunes[0]) && i > 0 && isWordRune(lower[i-1]) {
+				continue
+			}
+			if isWordRune(termRunes[len(termRunes)-1]) && end < len(lower) && isWordRune(lower[end]) {
+				continue
+			}
+			spans = append(spans, highlightSpan{start: i, end: end, term: term})
+		}
+	}
+	return resolveSpans(spans)
+}
+
+// alignTermSpans matches content words that are variants of the terms: Latin words sharing a
+// common prefix of at least minAlignedPrefix letters, and Chinese words sharing two characters
+func alignTermSpans(lower []rune, terms []string) []highlightSpan {
+	var latinTerms [][]rune
+	var bigrams []string
+	for _, term := range terms {
+		termRunes := []rune(term)
+		if isLatinWord(termRunes) {
+			if len(termRunes) >= minAlignedPrefix {
+				latinTerms = append(latinTerms, termRunes)
+			}
+			continue
+		}
+		for i := 0; i+2 <= len(termRunes); i++ {
+			if unicode.Is(unicode.Han, termRunes[i]) && unicode.Is(unicode.Han, termRunes[i+1]) {
+				bigrams = append(bigrams, string(termRunes[i:i+2]))
+			}
+		}
+	}
+
+	var spans []highlightSpan
+	for i := 0; i < len(lower); {
+		if !isWordRune(lower[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(lower) && isWordRune(lower[end]) {
+			end++
+		}
+		word := lower[i:end]
+		for _, term := range latinTerms {
+			if commonPrefixLength(word, term) >= minAlignedPrefix {
+				spans = append(spans, highlightSpan{start: i, end: end, term: string(word)})
+				break
+			}
+		}
+		i = end
+	}
+	for _, bigram := range bigrams {
+		spans = append(spans, findTermSpans(lower, []string{bigram})...)
+	}
+	return resolveSpans(spans)
+}
+
+// resolveSpans orders spans by position and drops those overlapping an earlier or longer one
+func resolveSpans(spans []highlightSpan) []highlightSpan {
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+	resolved := spans[:0]
+	for _, span := range spans {
+		if len(resolved) > 0 && span.start < resolved[len(resolved)-1].end {
+			continue
+		}
+		resolved = append(resolved, span)
+	}
+	return resolved
+}
+
+// snippetWindows chooses up to maxSnippets non-overlapping ranges of the content, each one
+// covering as many distinct terms not shown yet as possible, returned in content order
+func snippetWindows(runes []rune, spans []highlightSpan, length, maxSnippets int) [][2]int {
+	if len(runes) <= length {
+		return [][2]int{trimWindow(runes, 0, len(runes))}
+	}
+	if len(spans) == 0 {
+		return [][2]int{trimWindow(runes, 0, snapEnd(runes, length, length/8))}
+	}
+
+	var windows [][2]int
+	shown := make(map[string]struct{})
+	for len(windows) < maxSnippets {
+		best, bestTerms, bestSpans := [2]int{}, 0, 0
+		for _, candidate := range spans {
+			start := candidate.start - length/4
+			if start < 0 {
+				start = 0
+			}
+			if start > len(runes)-length {
+				start = len(runes) - length
+			}
+			window := [2]int{start, start + length}
+			if overlapsAny(window, windows) {
+				continue
+			}
+			terms := make(map[string]struct{})
+			covered := 0
+			for _, span := range spans {
+				if span.start >= window[0] && span.end <= window[1] {
+					covered++
+					if _, ok := shown[span.term]; !ok {
+						terms[span.term] = struct{}{}
+					}
+				}
+			}
+			if len(terms) > bestTerms || (len(terms) == bestTerms && covered > bestSpans) {
+				best, bestTerms, bestSpans = window, len(terms), covered
+			}
+		}
+		// Further snippets are only worth it when they show terms the previous ones did not
+		if bestSpans == 0 || (len(windows) > 0 && bestTerms == 0) {
+			break
+		}
+		for _, span := range spans {
+			if span.start >= best[0] && span.end <= best[1] {
+				shown[span.term] = struct{}{}
+			}
+		}
+		windows = append(windows, best)
+	}
+	if len(windows) == 0 {
+		return [][2]int{trimWindow(runes, 0, snapEnd(runes, length, length/8))}
+	}
+
+	for i, window := range windows {
+		// Snap to word boundaries without cutting off the matches the window was chosen for
+		first, last := window[1], window[0]
+		for _, span := range spans {
+			if span.start >= window[0] && span.end <= window[1] {
+				first, last = min(first, span.start), max(last, span.end)
+			}
+		}
+		start, end := window[0], window[1]
+		if start > 0 {
+			start = snapStart(runes, start, min(length/8, first-start))
+		}
+		if end < len(runes) {
+			end = snapEnd(runes, end, min(length/8, end-last))
+		}
+		windows[i] = trimWindow(runes, start, end)
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i][0] < windows[j][0] })
+	return windows
+}
+
+// buildSnippet cuts a window of the content, marks the cuts with ellipses and maps the spans
+// lying inside the window to snippet offsets
+func buildSnippet(runes []rune, spans []highlightSpan, start, end int) types.HighlightSnippet {
+	var text []rune
+	offset := start
+	if !isBlank(runes[:start]) {
+		text = append(text, '…')
+		offset--
+	}
+	for _, r := range runes[start:end] {
+		// Line breaks and tabs become spaces, one for one so that offsets are kept
+		if r == '\n' || r == '\r' || r == '\t' {
+			r = ' '
+		}
+		text = append(text, r)
+	}
+	if !isBlank(runes[end:]) {
+		text = append(text, '…')
+	}
+
+	snippet := types.HighlightSnippet{Highlights: []types.HighlightSpan{}}
+	for _, span := range spans {
+		if span.start >= start && span.end <= end {
+			snippet.Highlights = append(snippet.Highlights,
+				types.HighlightSpan{Start: span.start - offset, End: span.end - offset})
+		}
+	}
+	snippet.Text = string(text)
+	return snippet
+}
+
+// snapStart moves a window start forward past the next space within maxShift, so that the
+// snippet does not begin mid-word
+func snapStart(runes []rune, start, maxShift int) int {
+	for i := start; i < len(runes) && i <= start+maxShift; i++ {
+		if unicode.IsSpace(runes[i]) {
+			return i + 1
+		}
+	}
+	return start
+}
+
+// snapEnd moves a window end back to the previous space within maxShift
+func snapEnd(runes []rune, end, maxShift int) int {
+	for i := end; i > 0 && i >= end-maxShift; i-- {
+		if unicode.IsSpace(runes[i-1]) {
+			return i - 1
+		}
+	}
+	return end
+}
+
+// trimWindow shrinks a window to exclude leading and trailing whitespace
+func trimWindow(runes []rune, start, end int) [2]int {
+	for start < end && unicode.IsSpace(runes[start]) {
+		start++
+	}
+	for end > start && unicode.IsSpace(runes[end-1]) {
+		end--
+	}
+	return [2]int{start, end}
+}
+
+func isBlank(runes []rune) bool {
+	for _, r := range runes {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func overlapsAny(window [2]int, windows [][2]int) bool {
+	for _, other := range windows {
+		if window[0] < other[1] && other[0] < window[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRunesAt(text, sub []rune, at int) bool {
+	for j, r := range sub {
+		if text[at+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func commonPrefixLength(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// isWordRune reports whether r belongs to a space separated word, Chinese characters excluded
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !unicode.Is(unicode.Han, r)
+}
+
+// isHighlightRune reports whether r is worth highlighting, as opposed to spaces and punctuation
+func isHighlightRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isLatinWord(word []rune) bool {
+	for _, r := range word {
+		if !isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
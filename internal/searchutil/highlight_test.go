@@ -0,0 +1,101 @@
+package searchutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// highlighted returns the highlighted texts of each snippet
+func highlighted(t *testing.T, content string, terms []string, length, maxSnippets int) ([][]string, []string, bool) {
+	t.Helper()
+	highlight := Highlight(content, terms, length, maxSnippets)
+	if highlight == nil {
+		t.Fatal("Highlight returned nil")
+	}
+	var snippets [][]string
+	for _, snippet := range highlight.Snippets {
+		text := []rune(snippet.Text)
+		var words []string
+		for _, span := range snippet.Highlights {
+			words = append(words, string(text[span.Start:span.End]))
+		}
+		snippets = append(snippets, words)
+	}
+	return snippets, highlight.Terms, highlight.Aligned
+}
+
+func TestHighlightLiteral(t *testing.T) {
+	content := "The installer copies files. To configure the proxy, edit the config file and restart the service."
+	highlight := Highlight(content, []string{"proxy"}, 30, 1)
+	if len(highlight.Snippets) != 1 {
+		t.Fatalf("got %d snippets, want 1", len(highlight.Snippets))
+	}
+	snippet := highlight.Snippets[0]
+	if !strings.HasPrefix(snippet.Text, "…") || !strings.HasSuffix(snippet.Text, "…") {
+		t.Errorf("snippet %q is not marked as cut on both sides", snippet.Text)
+	}
+	if n := utf8.RuneCountInString(snippet.Text); n > 32 {
+		t.Errorf("snippet %q has %d characters, want at most 32", snippet.Text, n)
+	}
+	snippets, terms, aligned := highlighted(t, content, []string{"proxy"}, 30, 1)
+	if !reflect.DeepEqual(snippets, [][]string{{"proxy"}}) || !reflect.DeepEqual(terms, []string{"proxy"}) || aligned {
+		t.Errorf("got highlights %v, terms %v, aligned %v", snippets, terms, aligned)
+	}
+}
+
+func TestHighlightWholeWords(t *testing.T) {
+	snippets, _, _ := highlighted(t, "Proxy settings: proxyless mode disables the PROXY.", []string{"proxy"}, 160, 1)
+	if !reflect.DeepEqual(snippets, [][]string{{"Proxy", "PROXY"}}) {
+		t.Errorf("got %v", snippets)
+	}
+}
+
+func TestHighlightAligned(t *testing.T) {
+	snippets, terms, aligned := highlighted(t, "Configuring proxies is described below.",
+		[]string{"configure", "proxy"}, 160, 1)
+	if !aligned {
+		t.Error("want aligned highlights")
+	}
+	if !reflect.DeepEqual(snippets, [][]string{{"Configuring", "proxies"}}) {
+		t.Errorf("got %v", snippets)
+	}
+	if !reflect.DeepEqual(terms, []string{"configuring", "proxies"}) {
+		t.Errorf("got terms %v", terms)
+	}
+
+	snippets, _, aligned = highlighted(t, "数据库连接池的配置方法", []string{"数据库配置"}, 160, 1)
+	if !aligned || !reflect.DeepEqual(snippets, [][]string{{"数据", "配置"}}) {
+		t.Errorf("got %v, aligned %v", snippets, aligned)
+	}
+}
+
+func TestHighlightSnippets(t *testing.T) {
+	filler := strings.Repeat(" filler", 40)
+	content := "alpha" + filler + " beta" + filler
+	snippets, _, _ := highlighted(t, content, []string{"alpha", "beta"}, 40, 2)
+	if !reflect.DeepEqual(snippets, [][]string{{"alpha"}, {"beta"}}) {
+		t.Errorf("got %v", snippets)
+	}
+
+	// A second snippet is only added for terms the first one does not show
+	snippets, _, _ = highlighted(t, content+" alpha", []string{"alpha"}, 40, 2)
+	if len(snippets) != 1 {
+		t.Errorf("got %d snippets, want 1", len(snippets))
+	}
+}
+
+func TestHighlightNoMatch(t *testing.T) {
+	highlight := Highlight("\n  Nothing relevant here, "+strings.Repeat("more text ", 30), []string{"proxy"}, 40, 1)
+	if len(highlight.Terms) != 0 || highlight.Aligned || len(highlight.Snippets) != 1 {
+		t.Fatalf("got %+v", highlight)
+	}
+	text := highlight.Snippets[0].Text
+	if !strings.HasPrefix(text, "Nothing") || !strings.HasSuffix(text, "…") {
+		t.Errorf("got snippet %q, want the beginning of the content", text)
+	}
+	if Highlight("   ", []string{"proxy"}, 40, 1) != nil {
+		t.Error("want no highlight for blank content")
+	}
+}
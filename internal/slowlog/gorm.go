@@ -0,0 +1,42 @@
+package slowlog
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// gormLogger records the slow statements of gorm and leaves the other logs to the default gorm logger
+type gormLogger struct {
+	gormlogger.Interface
+}
+
+// GormLogger returns the logger of the gorm database, which logs slow statements with the db threshold instead of
+// the fixed 200 milliseconds of the default gorm logger
+func GormLogger() gormlogger.Interface {
+	return &gormLogger{Interface: gormlogger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), gormlogger.Config{
+		// Slow statements are logged by Trace
+		SlowThreshold: 0,
+		LogLevel:      gormlogger.Warn,
+		Colorful:      true,
+	})}
+}
+
+// LogMode returns the logger at another level
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &gormLogger{Interface: l.Interface.LogMode(level)}
+}
+
+// Trace records the statement when it is slow
+func (l *gormLogger) Trace(ctx context.Context,
+	begin time.Time, fc func() (sql string, rowsAffected int64), err error,
+) {
+	l.Interface.Trace(ctx, begin, fc, err)
+	if threshold := current.Load().threshold(KindDB); threshold >= 0 && time.Since(begin) >= threshold {
+		sql, _ := fc()
+		Observe(ctx, KindDB, sql, begin, err)
+	}
+}
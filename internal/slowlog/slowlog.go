@@ -0,0 +1,269 @@
+// Package slowlog logs the operations that take longer than their threshold, database queries, vector searches,
+// model calls, DocReader parses and browser commands, and keeps the recent ones for the slow operations endpoint.
+// The recent operations are kept in memory, each replica has its own.
+package slowlog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Kind is the kind of a slow operation
+type Kind string
+
+const (
+	KindDB           Kind = "db"
+	KindVectorSearch Kind = "vector_search"
+	KindModel        Kind = "model"
+	KindDocReader    Kind = "docreader"
+	KindBrowser      Kind = "browser"
+)
+
+// maxOperationLength truncates the description of an operation, SQL statements can be long
+const maxOperationLength = 500
+
+// Settings sets the thresholds of each kind of operation. A threshold of zero uses the default, a negative one
+// disables the kind.
+type Settings struct {
+	// DB is the threshold of database queries, 500 milliseconds when unset
+	DB time.Duration
+	// VectorSearch is the threshold of vector and keyword searches, 1 second when unset
+	VectorSearch time.Duration
+	// Model is the threshold of model calls, 30 seconds when unset
+	Model time.Duration
+	// DocReader is the threshold of DocReader parses, 1 minute when unset
+	DocReader time.Duration
+	// Browser is the threshold of headless browser commands, 15 seconds when unset
+	Browser time.Duration
+	// Recent is the number of slow operations kept for the endpoint, 200 when unset
+	Recent int
+}
+
+// DefaultSettings returns the settings used when the slow_log section is not set
+func DefaultSettings() Settings {
+	return Settings{
+		DB:           500 * time.Millisecond,
+		VectorSearch: time.Second,
+		Model:        30 * time.Second,
+		DocReader:    time.Minute,
+		Browser:      15 * time.Second,
+		Recent:       200,
+	}
+}
+
+// withDefaults fills the unset settings
+func (s Settings) withDefaults() Settings {
+	d := DefaultSettings()
+	if s.DB == 0 {
+		s.DB = d.DB
+	}
+	if s.VectorSearch == 0 {
+		s.VectorSearch = d.VectorSearch
+	}
+	if s.Model == 0 {
+		s.Model = d.Model
+	}
+	if s.DocReader == 0 {
+		s.DocReader = d.DocReader
+	}
+	if s.Browser == 0 {
+		s.Browser = d.Browser
+	}
+	if s.Recent <= 0 {
+		s.Recent = d.Recent
+	}
+	return s
+}
+
+// threshold returns the threshold of a kind, negative when the kind is not logged
+func (s Settings) threshold(kind Kind) time.Duration {
+	switch kind {
+	case KindDB:
+		return s.DB
+	case KindVectorSearch:
+		return s.VectorSearch
+	case KindModel:
+		return s.Model
+	case KindDocReader:
+		return s.DocReader
+	case KindBrowser:
+		return s.Browser
+	}
+	return -1
+}
+
+// Op is a slow operation
+type Op struct {
+	Kind            Kind      `json:"kind"`
+	Operation       string    `json:"operation"`
+	DurationMs      float64   `json:"duration_ms"`
+	ThresholdMs     float64   `json:"threshold_ms"`
+	TenantID        uint64    `json:"tenant_id,omitempty"`
+	KnowledgeBaseID string    `json:"knowledge_base_id,omitempty"`
+	RequestID       string    `json:"request_id,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+}
+
+// Filter selects recent slow operations, zero fields match every operation
+type Filter struct {
+	Kind     Kind
+	TenantID uint64
+	Limit    int
+}
+
+// recorder keeps the recent slow operations in a ring
+type recorder struct {
+	mu   sync.Mutex
+	ops  []Op
+	next int
+	full bool
+}
+
+var (
+	current  atomic.Pointer[Settings]
+	recorded = &recorder{}
+)
+
+func init() {
+	SetSettings(Settings{})
+}
+
+// SetSettings applies the thresholds, the recent operations are dropped when the number kept changes. It is called
+// when the config is loaded and reloaded.
+func SetSettings(settings Settings) {
+	settings = settings.withDefaults()
+	current.Store(&settings)
+	recorded.resize(settings.Recent)
+}
+
+// knowledgeBaseKey is the context key of the knowledge base slow operations are attributed to
+type knowledgeBaseKey struct{}
+
+// WithKnowledgeBase attributes the slow operations run with the returned context to a knowledge base
+func WithKnowledgeBase(ctx context.Context, knowledgeBaseID string) context.Context {
+	if knowledgeBaseID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, knowledgeBaseKey{}, knowledgeBaseID)
+}
+
+// Observe logs the operation started at start when it took longer than the threshold of its kind, with the tenant,
+// knowledge base and request of ctx
+func Observe(ctx context.Context, kind Kind, operation string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	threshold := current.Load().threshold(kind)
+	if threshold < 0 || elapsed < threshold {
+		return
+	}
+
+	if len(operation) > maxOperationLength {
+		operation = operation[:maxOperationLength] + "..."
+	}
+	op := Op{
+		Kind:        kind,
+		Operation:   operation,
+		DurationMs:  float64(elapsed.Microseconds()) / 1000,
+		ThresholdMs: float64(threshold.Microseconds()) / 1000,
+		RequestID:   logger.GetRequestID(ctx),
+		StartedAt:   start,
+	}
+	op.TenantID, _ = ctx.Value(types.TenantIDContextKey).(uint64)
+	op.KnowledgeBaseID, _ = ctx.Value(knowledgeBaseKey{}).(string)
+	if err != nil {
+		op.Error = err.Error()
+	}
+	recorded.add(op)
+
+	fields := map[string]interface{}{
+		"slow_op":      kind,
+		"duration_ms":  op.DurationMs,
+		"threshold_ms": op.ThresholdMs,
+	}
+	if op.KnowledgeBaseID != "" {
+		fields["knowledge_base_id"] = op.KnowledgeBaseID
+	}
+	if op.TenantID != 0 {
+		fields["tenant_id"] = op.TenantID
+	}
+	logger.GetLogger(ctx).WithFields(fields).Warnf("Slow %s operation took %v: %s", kind, elapsed, operation)
+}
+
+// Recent returns the recent slow operations matching the filter, the latest first
+func Recent(filter Filter) []Op {
+	return recorded.recent(filter)
+}
+
+func (r *recorder) resize(size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.ops) != size {
+		r.ops, r.next, r.full = make([]Op, size), 0, false
+	}
+}
+
+func (r *recorder) add(op Op) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops[r.next] = op
+	r.next = (r.next + 1) % len(r.ops)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *recorder) recent(filter Filter) []Op {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := r.next
+	if r.full {
+		count = len(r.ops)
+	}
+	ops := make([]Op, 0, count)
+	for i := 1; i <= count; i++ {
+		op := r.ops[(r.next-i+len(r.ops))%len(r.ops)]
+		if (filter.Kind != "" && op.Kind != filter.Kind) || (filter.TenantID != 0 && op.TenantID != filter.TenantID) {
+			continue
+		}
+		ops = append(ops, op)
+		if filter.Limit > 0 && len(ops) == filter.Limit {
+			break
+		}
+	}
+	return ops
+}
+
+// transport records the slow requests of an HTTP client
+type transport struct {
+	kind Kind
+	base http.RoundTripper
+}
+
+// Transport returns a round tripper recording the requests slower than the threshold of kind. A request lasts until
+// the response headers are received, streamed responses are not waited for.
+func Transport(kind Kind, base http.RoundTripper) http.RoundTripper {
+	return &transport{kind: kind, base: base}
+}
+
+// RoundTrip sends the request
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		Observe(req.Context(), t.kind, req.Method+" "+req.URL.Host+req.URL.Path, start, err)
+		return nil, err
+	}
+	var statusErr error
+	if resp.StatusCode >= http.StatusBadRequest {
+		statusErr = fmt.Errorf("status %d", resp.StatusCode)
+	}
+	Observe(req.Context(), t.kind, req.Method+" "+req.URL.Host+req.URL.Path, start, statusErr)
+	return resp, nil
+}
@@ -0,0 +1,38 @@
+package slowlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestObserveKeepsRecentSlowOps(t *testing.T) {
+	defer SetSettings(Settings{})
+	SetSettings(Settings{DB: time.Millisecond, Model: time.Millisecond, VectorSearch: -1, Recent: 3})
+
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(7))
+	ctx = WithKnowledgeBase(ctx, "kb-1")
+	slow := time.Now().Add(-time.Second)
+	Observe(ctx, KindDB, "fast", time.Now(), nil)
+	Observe(ctx, KindVectorSearch, "disabled", slow, nil)
+	for _, operation := range []string{"q1", "q2", "q3", "q4"} {
+		Observe(ctx, KindDB, operation, slow, nil)
+	}
+	Observe(context.Background(), KindModel, "model", slow, nil)
+
+	ops := Recent(Filter{})
+	if len(ops) != 3 || ops[0].Operation != "model" || ops[1].Operation != "q4" || ops[2].Operation != "q3" {
+		t.Fatalf("Recent() = %+v, want model, q4, q3", ops)
+	}
+	if ops[1].TenantID != 7 || ops[1].KnowledgeBaseID != "kb-1" {
+		t.Errorf("op not attributed to the tenant and knowledge base of ctx: %+v", ops[1])
+	}
+	if got := Recent(Filter{Kind: KindDB, TenantID: 7, Limit: 1}); len(got) != 1 || got[0].Operation != "q4" {
+		t.Errorf("Recent(db, tenant 7, limit 1) = %+v, want q4", got)
+	}
+	if got := Recent(Filter{TenantID: 8}); len(got) != 0 {
+		t.Errorf("Recent(tenant 8) = %+v, want none", got)
+	}
+}
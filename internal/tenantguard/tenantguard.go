@@ -0,0 +1,339 @@
+// Package tenantguard audits the database statements on tables with a tenant_id column: a statement that is not
+// scoped to a tenant is logged, or panics in tests, so that a repository method leaking data across tenants is
+// found before it ships.
+package tenantguard
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Mode is what the audit does with a statement not scoped to a tenant
+type Mode string
+
+const (
+	// ModeOff does not check the statements
+	ModeOff Mode = "off"
+	// ModeLog logs the statements and counts them in the tenant scope violations metric
+	ModeLog Mode = "log"
+	// ModePanic panics, for tests that must fail on any unscoped statement
+	ModePanic Mode = "panic"
+)
+
+// tenantColumn is the column scoping the rows of a table to a tenant
+const tenantColumn = "tenant_id"
+
+// tenantCondition matches the tenant column in a raw condition, qualified by a table or not
+var tenantCondition = regexp.MustCompile(`(^|[^\w])tenant_id([^\w]|$)`)
+
+// tenantPlaceholder matches a raw condition comparing the tenant column to a bound value, up to its placeholder
+var tenantPlaceholder = regexp.MustCompile(`(?i)(?:^|[^\w])tenant_id\s*(?:=|in)\s*\(?\s*\?`)
+
+var mode atomic.Value
+
+func init() {
+	SetMode(ModeOff)
+}
+
+// SetMode sets what the audit does, an unknown mode turns it off. It is called when the config is loaded and
+// reloaded.
+func SetMode(m Mode) {
+	switch m {
+	case ModeLog, ModePanic:
+	default:
+		m = ModeOff
+	}
+	mode.Store(m)
+}
+
+// CurrentMode returns what the audit does
+func CurrentMode() Mode {
+	return mode.Load().(Mode)
+}
+
+// unscopedKey is the context key of the statements allowed across tenants
+type unscopedKey struct{}
+
+// WithoutTenantScope allows the statements run with the returned context to read and write the rows of any tenant,
+// for the lookups that resolve the tenant of a row, such as following a share, and for cross-tenant admin work
+func WithoutTenantScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+// Register adds the audit to the callbacks of db. The audit only runs when the mode is not off.
+func Register(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenantguard:query", check("query")); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenantguard:row", check("query")); err != nil {
+		return err
+	}
+	if err := callbacks.Create().Before("gorm:create").Register("tenantguard:create", check("create")); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenantguard:update", check("update")); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenantguard:delete", check("delete"))
+}
+
+// check returns the callback auditing the statements of an op
+func check(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		m := CurrentMode()
+		if m == ModeOff || db.Error != nil {
+			return
+		}
+		stmt := db.Statement
+		if stmt.Schema == nil || stmt.Schema.LookUpField(tenantColumn) == nil {
+			return
+		}
+		if stmt.Context != nil && stmt.Context.Value(unscopedKey{}) != nil {
+			return
+		}
+		if !Scoped(stmt, op) {
+			report(stmt, op, m)
+		}
+		// Preloaded associations are selected by the keys of the rows just checked
+		if op == "query" && len(stmt.Preloads) > 0 && stmt.Context != nil {
+			stmt.Context = WithoutTenantScope(stmt.Context)
+		}
+	}
+}
+
+// Scoped reports whether a statement of an op on a tenant table is scoped to a tenant: its conditions name the
+// tenant column, or the rows it creates or saves carry a tenant ID. When the context of the statement has a tenant,
+// the tenant IDs bound in the conditions or carried by the rows must be that tenant.
+func Scoped(stmt *gorm.Statement, op string) bool {
+	current := tenantScope{}
+	if stmt.Context != nil {
+		current.id, current.known = stmt.Context.Value(types.TenantIDContextKey).(uint64)
+	}
+	if op != "query" {
+		if ids, ok := carriedTenants(stmt); ok {
+			return current.allows(ids)
+		}
+	}
+	if op == "create" {
+		return false
+	}
+	where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	if !ok {
+		return false
+	}
+	named := false
+	for _, expr := range where.Exprs {
+		names, foreign := current.check(expr)
+		if foreign {
+			return false
+		}
+		named = named || names
+	}
+	return named
+}
+
+// tenantScope is the tenant of the context of a statement, known is false when the context has none
+type tenantScope struct {
+	id    uint64
+	known bool
+}
+
+// allows reports whether the tenant IDs are all the tenant of the context, any IDs are allowed without one
+func (s tenantScope) allows(ids []uint64) bool {
+	if !s.known {
+		return true
+	}
+	for _, id := range ids {
+		if id != s.id {
+			return false
+		}
+	}
+	return true
+}
+
+// check reports whether a condition names the tenant column, and whether it binds a tenant other than the one of
+// the context. Values that cannot be read, such as a join on the tenant column, are not compared.
+func (s tenantScope) check(expr clause.Expression) (names bool, foreign bool) {
+	switch e := expr.(type) {
+	case clause.Expr:
+		for _, loc := range tenantPlaceholder.FindAllStringIndex(e.SQL, -1) {
+			index := strings.Count(e.SQL[:loc[1]-1], "?")
+			if index < len(e.Vars) {
+				if ids, ok := tenantIDs(e.Vars[index]); ok && !s.allows(ids) {
+					return true, true
+				}
+			}
+		}
+		return tenantCondition.MatchString(e.SQL), false
+	case clause.NamedExpr:
+		return tenantCondition.MatchString(e.SQL), false
+	case clause.Eq:
+		if !isTenantColumn(e.Column) {
+			return false, false
+		}
+		ids, ok := tenantIDs(e.Value)
+		return true, ok && !s.allows(ids)
+	case clause.IN:
+		if !isTenantColumn(e.Column) {
+			return false, false
+		}
+		ids, ok := tenantIDs(e.Values)
+		return true, ok && !s.allows(ids)
+	case clause.AndConditions:
+		return s.checkAll(e.Exprs)
+	case clause.Where:
+		return s.checkAll(e.Exprs)
+	}
+	return false, false
+}
+
+// checkAll checks conditions joined with AND
+func (s tenantScope) checkAll(exprs []clause.Expression) (names bool, foreign bool) {
+	for _, sub := range exprs {
+		subNames, subForeign := s.check(sub)
+		if subForeign {
+			return true, true
+		}
+		names = names || subNames
+	}
+	return names, false
+}
+
+// tenantIDs reads the tenant IDs bound to a condition, a single ID or a list of them. It reports false for values
+// that are not IDs, such as a subquery.
+func tenantIDs(value interface{}) ([]uint64, bool) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			return nil, false
+		}
+		return []uint64{uint64(v.Int())}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []uint64{v.Uint()}, true
+	case reflect.Slice, reflect.Array:
+		ids := make([]uint64, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			id, ok := tenantIDs(v.Index(i).Interface())
+			if !ok {
+				return nil, false
+			}
+			ids = append(ids, id...)
+		}
+		return ids, true
+	}
+	return nil, false
+}
+
+// isTenantColumn reports whether a column of a condition is the tenant column
+func isTenantColumn(column interface{}) bool {
+	switch c := column.(type) {
+	case string:
+		return c == tenantColumn || strings.HasSuffix(c, "."+tenantColumn)
+	case clause.Column:
+		return c.Name == tenantColumn
+	}
+	return false
+}
+
+// carriedTenants returns the tenant IDs of the rows written by the statement, it reports false unless every row
+// has one
+func carriedTenants(stmt *gorm.Statement) ([]uint64, bool) {
+	field := stmt.Schema.LookUpField(tenantColumn)
+	value := reflect.Indirect(stmt.ReflectValue)
+	switch value.Kind() {
+	case reflect.Struct:
+		return rowTenant(stmt, field, value, nil)
+	case reflect.Slice, reflect.Array:
+		if value.Len() == 0 {
+			return nil, false
+		}
+		ids := make([]uint64, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			row := reflect.Indirect(value.Index(i))
+			if row.Kind() != reflect.Struct {
+				return nil, false
+			}
+			var ok bool
+			if ids, ok = rowTenant(stmt, field, row, ids); !ok {
+				return nil, false
+			}
+		}
+		return ids, true
+	}
+	return nil, false
+}
+
+// rowTenant appends the tenant ID of a row to ids, it reports false when the row has none
+func rowTenant(stmt *gorm.Statement, field *schema.Field, row reflect.Value, ids []uint64) ([]uint64, bool) {
+	value, zero := field.ValueOf(stmt.Context, row)
+	if zero {
+		return nil, false
+	}
+	id, ok := tenantIDs(value)
+	if !ok {
+		return nil, false
+	}
+	return append(ids, id...), true
+}
+
+// report logs a statement not scoped to a tenant, or panics
+func report(stmt *gorm.Statement, op string, m Mode) {
+	table := stmt.Table
+	if table == "" {
+		table = stmt.Schema.Table
+	}
+	caller := callerOutsideGorm()
+	metrics.TenantScopeViolations.WithLabelValues(table, op).Inc()
+	if m == ModePanic {
+		panic(fmt.Sprintf("tenant isolation audit: %s on %s is not scoped to a tenant at %s", op, table, caller))
+	}
+
+	ctx := stmt.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fields := map[string]interface{}{
+		"tenant_scope": table,
+		"op":           op,
+		"caller":       caller,
+	}
+	if tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
+		fields["tenant_id"] = tenantID
+	}
+	logger.GetLogger(ctx).WithFields(fields).Warnf("Tenant isolation audit: %s on %s is not scoped to a tenant", op, table)
+}
+
+// callerOutsideGorm returns the file and line of the code running the statement
+func callerOutsideGorm() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.File, "gorm.io/") && !strings.Contains(frame.File, "/internal/tenantguard/") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package tenantguard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestAuditPanicsOnStatementsNotScopedToATenant(t *testing.T) {
+	defer SetMode(ModeOff)
+	SetMode(ModePanic)
+
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenantCtx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	cases := []struct {
+		name   string
+		run    func(db *gorm.DB) error
+		panics bool
+	}{
+		{"raw condition", func(db *gorm.DB) error {
+			return db.Where("tenant_id = ? AND id = ?", 1, "k").First(&types.Knowledge{}).Error
+		}, false},
+		{"qualified condition", func(db *gorm.DB) error {
+			return db.Where("knowledges.tenant_id = ?", 1).Find(&[]types.Knowledge{}).Error
+		}, false},
+		{"struct condition", func(db *gorm.DB) error {
+			return db.Where(&types.Knowledge{TenantID: 1}).Find(&[]types.Knowledge{}).Error
+		}, false},
+		{"map condition", func(db *gorm.DB) error {
+			return db.Where(map[string]interface{}{"tenant_id": 1}).Find(&[]types.Knowledge{}).Error
+		}, false},
+		{"query by id only", func(db *gorm.DB) error {
+			return db.Where("id = ?", "k").First(&types.Knowledge{}).Error
+		}, true},
+		{"similar column", func(db *gorm.DB) error {
+			return db.Where("source_tenant_id = ?", 1).Find(&[]types.Knowledge{}).Error
+		}, true},
+		{"allowed across tenants", func(db *gorm.DB) error {
+			return db.WithContext(WithoutTenantScope(context.Background())).
+				Where("id = ?", "k").First(&types.Knowledge{}).Error
+		}, false},
+		{"create with tenant", func(db *gorm.DB) error {
+			return db.Create(&types.Knowledge{ID: "k", TenantID: 1}).Error
+		}, false},
+		{"create without tenant", func(db *gorm.DB) error {
+			return db.Create(&types.Knowledge{ID: "k"}).Error
+		}, true},
+		{"save loaded row", func(db *gorm.DB) error {
+			return db.Save(&types.Knowledge{ID: "k", TenantID: 1}).Error
+		}, false},
+		{"update by id only", func(db *gorm.DB) error {
+			return db.Model(&types.Knowledge{}).Where("id = ?", "k").Update("title", "t").Error
+		}, true},
+		{"delete by id only", func(db *gorm.DB) error {
+			return db.Where("id = ?", "k").Delete(&types.Knowledge{}).Error
+		}, true},
+		{"table without tenant", func(db *gorm.DB) error {
+			return db.Where("id = ?", 1).First(&types.Tenant{}).Error
+		}, false},
+		{"own tenant", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Where("tenant_id = ? AND id = ?", 1, "k").First(&types.Knowledge{}).Error
+		}, false},
+		{"other tenant", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Where("tenant_id = ? AND id = ?", 2, "k").First(&types.Knowledge{}).Error
+		}, true},
+		{"other tenant after other conditions", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Where("id = ? AND knowledges.tenant_id = ?", "k", uint64(2)).
+				First(&types.Knowledge{}).Error
+		}, true},
+		{"other tenant in list", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Where("tenant_id IN ?", []uint64{1, 2}).Find(&[]types.Knowledge{}).Error
+		}, true},
+		{"other tenant struct condition", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Where(&types.Knowledge{TenantID: 2}).Find(&[]types.Knowledge{}).Error
+		}, true},
+		{"other tenant map condition", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Where(map[string]interface{}{"tenant_id": 2}).
+				Find(&[]types.Knowledge{}).Error
+		}, true},
+		{"join on tenant column", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Where("knowledges.tenant_id = knowledge_bases.tenant_id").
+				Find(&[]types.Knowledge{}).Error
+		}, false},
+		{"create for other tenant", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Create(&types.Knowledge{ID: "k", TenantID: 2}).Error
+		}, true},
+		{"create batch for other tenant", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Create([]*types.Knowledge{{ID: "a", TenantID: 1}, {ID: "b", TenantID: 2}}).Error
+		}, true},
+		{"update other tenant", func(db *gorm.DB) error {
+			return db.WithContext(tenantCtx).Model(&types.Knowledge{}).Where("tenant_id = ? AND id = ?", 2, "k").
+				Update("title", "t").Error
+		}, true},
+		{"other tenant allowed across tenants", func(db *gorm.DB) error {
+			return db.WithContext(WithoutTenantScope(tenantCtx)).Where("tenant_id = ?", 2).
+				Find(&[]types.Knowledge{}).Error
+		}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tc.panics {
+					t.Errorf("panicked = %v, want %v", r, tc.panics)
+				}
+			}()
+			_ = tc.run(db.Session(&gorm.Session{NewDB: true}))
+		})
+	}
+}
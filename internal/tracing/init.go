@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -31,9 +32,13 @@ var tracer trace.Tracer
 // InitTracer initializes OpenTelemetry tracer
 func InitTracer() (*Tracer, error) {
 	// Create resource description
+	serviceName := AppName
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		serviceName = name
+	}
 	labels := []attribute.KeyValue{
 		semconv.TelemetrySDKLanguageGo,
-		semconv.ServiceNameKey.String(AppName),
+		semconv.ServiceNameKey.String(serviceName),
 	}
 	res := resource.NewWithAttributes(semconv.SchemaURL, labels...)
 	var err error
@@ -61,7 +66,8 @@ func InitTracer() (*Tracer, error) {
 	// Create batch SpanProcessor
 	bsp := sdktrace.NewBatchSpanProcessor(traceExporter)
 
-	sampler := sdktrace.AlwaysSample()
+	// Traces started upstream keep the caller's sampling decision, others are sampled at OTEL_TRACES_SAMPLER_ARG
+	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio()))
 
 	// Create and register TracerProvider
 	tp := sdktrace.NewTracerProvider(
@@ -94,11 +100,23 @@ func InitTracer() (*Tracer, error) {
 	}, nil
 }
 
-// GetTracer gets global Tracer
+// GetTracer gets global Tracer, a no-op one when tracing was not initialized
 func GetTracer() trace.Tracer {
+	if tracer == nil {
+		return otel.Tracer(AppName)
+	}
 	return tracer
 }
 
+// samplingRatio returns the share of new traces to sample, all of them unless OTEL_TRACES_SAMPLER_ARG is set
+func samplingRatio() float64 {
+	ratio, err := strconv.ParseFloat(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return 1
+	}
+	return ratio
+}
+
 // Create context with span
 func ContextWithSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
 	return GetTracer().Start(ctx, name, opts...)
@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/slowlog"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDHeader carries the request ID to the services called and in the trace context of task payloads
+const requestIDHeader = "X-Request-ID"
+
+// requestIDCarrierKey is the key of the request ID in the trace context of task payloads
+const requestIDCarrierKey = "x-request-id"
+
+// Inject returns the trace context and the request ID of ctx as a map, to be carried in task payloads
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if requestID := logger.GetRequestID(ctx); requestID != "" {
+		carrier[requestIDCarrierKey] = requestID
+	}
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx continuing the trace context injected in carrier, with the request ID that enqueued the task
+// in its logger
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	if requestID := carrier[requestIDCarrierKey]; requestID != "" && logger.GetRequestID(ctx) == "" {
+		ctx = context.WithValue(ctx, types.RequestIDContextKey, requestID)
+		ctx = logger.WithRequestID(ctx, requestID)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// requestIDTransport sets the X-Request-ID header of requests sent on behalf of a request, so that the logs of
+// the service called can be matched with ours
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip sends the request with the request ID of its context
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestID := logger.GetRequestID(req.Context())
+	if requestID == "" || req.Header.Get(requestIDHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	req.Header.Set(requestIDHeader, requestID)
+	return t.base.RoundTrip(req)
+}
+
+// Transport returns the round tripper of the model clients. It records a client span for each request, propagates
+// the trace context and the request ID in its headers and logs the requests slower than the model threshold of the
+// slow log. base defaults to the outbound transport, which applies the outbound_http settings.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = utils.OutboundTransport()
+	}
+	return otelhttp.NewTransport(&requestIDTransport{base: slowlog.Transport(slowlog.KindModel, base)},
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Host
+		}),
+	)
+}
+
+// taskTraceContext is the trace context carried by task payloads that have one
+type taskTraceContext struct {
+	TraceContext map[string]string `json:"trace_context"`
+}
+
+// TaskMiddleware records a span for each asynq task, continuing the trace of the request that enqueued it when
+// its payload carries a trace_context. The logs of the task carry its type, ID and the request ID.
+func TaskMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		var payload taskTraceContext
+		if len(t.Payload()) > 0 {
+			_ = json.Unmarshal(t.Payload(), &payload)
+		}
+		ctx = Extract(ctx, payload.TraceContext)
+		ctx = logger.WithField(ctx, "task_type", t.Type())
+		ctx, span := ContextWithSpan(ctx, "task "+t.Type(), trace.WithSpanKind(trace.SpanKindConsumer))
+		defer span.End()
+		if taskID, ok := asynq.GetTaskID(ctx); ok {
+			span.SetAttributes(attribute.String("task.id", taskID))
+			ctx = logger.WithField(ctx, "task_id", taskID)
+		}
+		if retry, ok := asynq.GetRetryCount(ctx); ok {
+			span.SetAttributes(attribute.Int("task.retry", retry))
+		}
+
+		err := next.ProcessTask(ctx, t)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	})
+}
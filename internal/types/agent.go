@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"slices"
 	"time"
 )
 
@@ -66,6 +67,36 @@ func (c *AgentConfig) Scan(value interface{}) error {
 	return json.Unmarshal(b, c)
 }
 
+// AgentToolPolicy is the tenant-level enablement of agent tools, applied on top of each agent's allowed tools
+type AgentToolPolicy struct {
+	DisabledTools []string `json:"disabled_tools"` // Tool names no agent of the tenant may call
+}
+
+// Allows reports whether the policy lets agents call the tool, a nil policy allows every tool
+func (p *AgentToolPolicy) Allows(name string) bool {
+	if p == nil {
+		return true
+	}
+	return !slices.Contains(p.DisabledTools, name)
+}
+
+// Value implements driver.Valuer interface for AgentToolPolicy
+func (p AgentToolPolicy) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan implements sql.Scanner interface for AgentToolPolicy
+func (p *AgentToolPolicy) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, p)
+}
+
 // Value implements driver.Valuer interface for SessionAgentConfig
 func (c SessionAgentConfig) Value() (driver.Value, error) {
 	return json.Marshal(c)
@@ -0,0 +1,173 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// AnswerFeedbackRating is the thumbs-up or thumbs-down given to an answer
+type AnswerFeedbackRating string
+
+const (
+	// AnswerFeedbackUp marks a helpful answer
+	AnswerFeedbackUp AnswerFeedbackRating = "up"
+	// AnswerFeedbackDown marks a wrong or unhelpful answer, queued for curation
+	AnswerFeedbackDown AnswerFeedbackRating = "down"
+)
+
+// IsValid checks if the rating is up or down
+func (r AnswerFeedbackRating) IsValid() bool {
+	return r == AnswerFeedbackUp || r == AnswerFeedbackDown
+}
+
+// SearchFeedback returns the search log feedback value of the rating
+func (r AnswerFeedbackRating) SearchFeedback() string {
+	if r == AnswerFeedbackUp {
+		return SearchFeedbackPositive
+	}
+	return SearchFeedbackNegative
+}
+
+// AnswerFeedbackStatus is the curation status of a feedback
+type AnswerFeedbackStatus string
+
+const (
+	// AnswerFeedbackOpen is waiting for a knowledge base administrator to review it
+	AnswerFeedbackOpen AnswerFeedbackStatus = "open"
+	// AnswerFeedbackResolved has been addressed, e.g. by fixing the source chunks
+	AnswerFeedbackResolved AnswerFeedbackStatus = "resolved"
+	// AnswerFeedbackDismissed needs no change to the knowledge base
+	AnswerFeedbackDismissed AnswerFeedbackStatus = "dismissed"
+)
+
+// IsValid checks if the status is a known curation status
+func (s AnswerFeedbackStatus) IsValid() bool {
+	switch s {
+	case AnswerFeedbackOpen, AnswerFeedbackResolved, AnswerFeedbackDismissed:
+		return true
+	}
+	return false
+}
+
+// AnswerFeedback is the feedback given to an assistant message, recorded once per knowledge base
+// the answer retrieved from so each knowledge base's administrators can curate it.
+// Answers that retrieved nothing are recorded once with an empty knowledge base ID.
+type AnswerFeedback struct {
+	ID              string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	SessionID       string `json:"session_id"        gorm:"type:varchar(36)"`
+	MessageID       string `json:"message_id"        gorm:"type:varchar(36);index"`
+	// UserID is empty for feedback sent with an API key
+	UserID  string               `json:"user_id"  gorm:"type:varchar(36)"`
+	Rating  AnswerFeedbackRating `json:"rating"   gorm:"type:varchar(8)"`
+	Comment string               `json:"comment"  gorm:"type:text"`
+	// Query and Answer are copied from the conversation when the feedback is given
+	Query  string `json:"query"  gorm:"type:text"`
+	Answer string `json:"answer" gorm:"type:text"`
+	// Chunks are the chunks of the knowledge base the answer retrieved
+	Chunks         FeedbackChunks       `json:"chunks"          gorm:"type:jsonb"`
+	Status         AnswerFeedbackStatus `json:"status"          gorm:"type:varchar(16)"`
+	ResolutionNote string               `json:"resolution_note" gorm:"type:text"`
+	ResolvedBy     string               `json:"resolved_by"     gorm:"type:varchar(36)"`
+	ResolvedAt     *time.Time           `json:"resolved_at"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// TableName returns the table name of AnswerFeedback
+func (AnswerFeedback) TableName() string {
+	return "answer_feedback"
+}
+
+// FeedbackChunk is a chunk retrieved for a rated answer, locating it for curation
+type FeedbackChunk struct {
+	ChunkID        string  `json:"chunk_id"`
+	KnowledgeID    string  `json:"knowledge_id"`
+	KnowledgeTitle string  `json:"knowledge_title"`
+	ChunkIndex     int     `json:"chunk_index"`
+	Score          float64 `json:"score"`
+	// Cited is set when the answer cited the chunk with an inline marker
+	Cited bool `json:"cited"`
+	// Content is the chunk text when the answer was given, the chunk may have been edited since
+	Content string `json:"content"`
+}
+
+// FeedbackChunks is the list of chunks retrieved for a rated answer
+type FeedbackChunks []FeedbackChunk
+
+// Value implements the driver.Valuer interface, used to convert FeedbackChunks to database values
+func (c FeedbackChunks) Value() (driver.Value, error) {
+	if c == nil {
+		return json.Marshal([]FeedbackChunk{})
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database values to FeedbackChunks
+func (c *FeedbackChunks) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// feedbackChunkContentLimit caps the chunk text kept with a feedback, in runes
+const feedbackChunkContentLimit = 500
+
+// FeedbackChunksByKnowledgeBase groups the chunks an answer retrieved by knowledge base,
+// marking the ones its inline citations point to
+func FeedbackChunksByKnowledgeBase(message *Message) map[string]FeedbackChunks {
+	cited := make(map[string]bool, len(message.Citations))
+	for _, citation := range message.Citations {
+		cited[citation.ChunkID] = true
+	}
+	chunks := make(map[string]FeedbackChunks)
+	seen := make(map[string]bool)
+	for _, ref := range message.KnowledgeReferences {
+		if ref == nil || ref.KnowledgeBaseID == "" || seen[ref.ID] {
+			continue
+		}
+		seen[ref.ID] = true
+		content := []rune(ref.Content)
+		if len(content) > feedbackChunkContentLimit {
+			content = content[:feedbackChunkContentLimit]
+		}
+		chunks[ref.KnowledgeBaseID] = append(chunks[ref.KnowledgeBaseID], FeedbackChunk{
+			ChunkID:        ref.ID,
+			KnowledgeID:    ref.KnowledgeID,
+			KnowledgeTitle: ref.KnowledgeTitle,
+			ChunkIndex:     ref.ChunkIndex,
+			Score:          ref.Score,
+			Cited:          cited[ref.ID],
+			Content:        string(content),
+		})
+	}
+	return chunks
+}
+
+// AnswerFeedbackRequest rates an answer
+type AnswerFeedbackRequest struct {
+	Rating  AnswerFeedbackRating `json:"rating"  binding:"required"`
+	Comment string               `json:"comment" binding:"max=2000"`
+}
+
+// AnswerFeedbackFilter selects the feedback of a curation queue
+type AnswerFeedbackFilter struct {
+	// Status defaults to open
+	Status AnswerFeedbackStatus `form:"status"`
+	// Rating defaults to down, "all" lists both ratings
+	Rating string `form:"rating"`
+	Pagination
+}
+
+// UpdateAnswerFeedbackRequest changes the curation status of a feedback
+type UpdateAnswerFeedbackRequest struct {
+	Status         AnswerFeedbackStatus `json:"status"          binding:"required"`
+	ResolutionNote string               `json:"resolution_note" binding:"max=2000"`
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFeedbackChunksByKnowledgeBase(t *testing.T) {
+	message := &Message{
+		KnowledgeReferences: References{
+			{ID: "c1", KnowledgeBaseID: "kb1", KnowledgeID: "k1", Content: strings.Repeat("知", 600)},
+			{ID: "c2", KnowledgeBaseID: "kb2", KnowledgeID: "k2"},
+			{ID: "c1", KnowledgeBaseID: "kb1", KnowledgeID: "k1"},
+			{ID: "c3", KnowledgeBaseID: "kb1", KnowledgeID: "k3"},
+			{ID: "web", Content: "web search result"},
+		},
+		Citations: Citations{{Label: "2", ChunkID: "c3"}},
+	}
+	chunks := FeedbackChunksByKnowledgeBase(message)
+	if len(chunks) != 2 || len(chunks["kb1"]) != 2 || len(chunks["kb2"]) != 1 {
+		t.Fatalf("got %+v", chunks)
+	}
+	if got := len([]rune(chunks["kb1"][0].Content)); got != feedbackChunkContentLimit {
+		t.Errorf("got %d runes of content, want %d", got, feedbackChunkContentLimit)
+	}
+	if chunks["kb1"][0].Cited || !chunks["kb1"][1].Cited {
+		t.Errorf("got cited %v, %v", chunks["kb1"][0].Cited, chunks["kb1"][1].Cited)
+	}
+}
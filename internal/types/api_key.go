@@ -0,0 +1,216 @@
+package types
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyScope limits what a scoped API key can do
+type APIKeyScope string
+
+const (
+	// APIKeyScopeRetrieval reads knowledge bases, searches and chats, without changing anything
+	APIKeyScopeRetrieval APIKeyScope = "retrieval"
+	// APIKeyScopeIngest adds documents to knowledge bases and follows their processing
+	APIKeyScopeIngest APIKeyScope = "ingest"
+	// APIKeyScopeAdmin has the same access as the tenant API key
+	APIKeyScopeAdmin APIKeyScope = "admin"
+)
+
+// IsValid checks if the scope is supported
+func (s APIKeyScope) IsValid() bool {
+	switch s {
+	case APIKeyScopeRetrieval, APIKeyScopeIngest, APIKeyScopeAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// KBRole returns the highest role the scope allows on the knowledge bases of its tenant
+func (s APIKeyScope) KBRole() KBRole {
+	switch s {
+	case APIKeyScopeAdmin:
+		return KBRoleOwner
+	case APIKeyScopeIngest:
+		return KBRoleContributor
+	case APIKeyScopeRetrieval:
+		return KBRoleViewer
+	default:
+		return ""
+	}
+}
+
+// apiKeyRetrievalWrites are the non-GET routes that only retrieve or chat
+var apiKeyRetrievalWrites = []string{
+	"/api/v1/knowledge-chat/:session_id",
+	"/api/v1/agent-chat/:session_id",
+	"/api/v1/knowledge-search",
+	"/api/v1/openai/chat/completions",
+	"/api/v1/sessions",
+	"/api/v1/sessions/:session_id/stop",
+	"/api/v1/sessions/:session_id/generate_title",
+	"/api/v1/knowledge-bases/:id/image-search",
+	"/api/v1/knowledge-bases/:id/faq/search",
+	"/api/v1/knowledge-bases/:id/search-analytics/clicks",
+	"/api/v1/knowledge-bases/:id/search-analytics/feedback",
+	"/api/v1/messages/:session_id/:id/feedback",
+	"/api/v1/mcp",
+}
+
+// apiKeyIngestWrites are the non-GET routes that add or reprocess documents
+var apiKeyIngestWrites = []string{
+	"/api/v1/knowledge-bases/:id/knowledge/file",
+	"/api/v1/knowledge-bases/:id/knowledge/enex",
+	"/api/v1/knowledge-bases/:id/knowledge/url",
+	"/api/v1/knowledge-bases/:id/knowledge/fetch",
+	"/api/v1/knowledge-bases/:id/knowledge/manual",
+	"/api/v1/knowledge/manual/:id",
+	"/api/v1/knowledge/:id/reparse",
+	"/api/v1/knowledge/:id/recapture/preview",
+	"/api/v1/knowledge/:id/recapture",
+	"/api/v1/knowledge/url/analyze-batch",
+	"/api/v1/knowledge-bases/:id/faq/entries",
+	"/api/v1/knowledge-bases/:id/faq/entry",
+	"/api/v1/jobs/:id/cancel",
+}
+
+// apiKeyRetrievalReads are the GET routes a retrieval key can read: knowledge bases and their documents,
+// search, its own conversations and the agents it chats with. Anything else, such as exports, members,
+// jobs, usage or audit data, needs an admin key.
+var apiKeyRetrievalReads = []string{
+	"/api/v1/knowledge-bases",
+	"/api/v1/knowledge-bases/:id",
+	"/api/v1/knowledge-bases/:id/my-role",
+	"/api/v1/knowledge-bases/:id/hybrid-search",
+	"/api/v1/knowledge-bases/:id/knowledge",
+	"/api/v1/knowledge-bases/:id/tags",
+	"/api/v1/knowledge-bases/:id/tags/tree",
+	"/api/v1/knowledge-bases/:id/faq/entries",
+	"/api/v1/knowledge-bases/:id/faq/entries/:entry_id",
+	"/api/v1/shared-knowledge-bases",
+	"/api/v1/knowledge/batch",
+	"/api/v1/knowledge/search",
+	"/api/v1/knowledge/:id",
+	"/api/v1/knowledge/:id/download",
+	"/api/v1/chunks/:knowledge_id",
+	"/api/v1/chunks/by-id/:id",
+	"/api/v1/sessions",
+	"/api/v1/sessions/:id",
+	"/api/v1/sessions/continue-stream/:session_id",
+	"/api/v1/messages/:session_id/load",
+	"/api/v1/messages/:session_id/:id/feedback",
+	"/api/v1/openai/models",
+	"/api/v1/agents",
+	"/api/v1/agents/:id",
+	"/api/v1/mcp",
+}
+
+// apiKeyIngestReads are the GET routes an ingest key can read, to list knowledge bases and follow processing
+var apiKeyIngestReads = []string{
+	"/api/v1/knowledge-bases",
+	"/api/v1/knowledge-bases/:id",
+	"/api/v1/knowledge-bases/:id/my-role",
+	"/api/v1/knowledge-bases/:id/knowledge",
+	"/api/v1/knowledge-bases/:id/knowledge/bulk/:task_id",
+	"/api/v1/knowledge-bases/:id/tags",
+	"/api/v1/knowledge-bases/:id/faq/entries",
+	"/api/v1/knowledge/batch",
+	"/api/v1/knowledge/:id",
+	"/api/v1/knowledge/:id/progress",
+	"/api/v1/faq/import/progress/:task_id",
+	"/api/v1/jobs",
+	"/api/v1/jobs/:id",
+}
+
+// Allows checks if the scope permits a request, given its method and route template (e.g. /api/v1/knowledge/:id)
+func (s APIKeyScope) Allows(method string, route string) bool {
+	switch s {
+	case APIKeyScopeAdmin:
+		return true
+	case APIKeyScopeRetrieval:
+		if method == "GET" || method == "HEAD" {
+			return slices.Contains(apiKeyRetrievalReads, route)
+		}
+		return slices.Contains(apiKeyRetrievalWrites, route)
+	case APIKeyScopeIngest:
+		if method == "GET" || method == "HEAD" {
+			return slices.Contains(apiKeyIngestReads, route)
+		}
+		return slices.Contains(apiKeyIngestWrites, route)
+	default:
+		return false
+	}
+}
+
+// APIKey is a named API key of a tenant with a scope, optional knowledge base restrictions and expiry.
+// Only a hash of the key is stored, the key itself is returned once when created or rotated.
+type APIKey struct {
+	ID       string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	TenantID uint64 `json:"tenant_id" gorm:"index"`
+	Name     string `json:"name" gorm:"type:varchar(255);not null"`
+	// KeyPrefix is the beginning of the key, shown to tell keys apart
+	KeyPrefix string      `json:"key_prefix" gorm:"type:varchar(32)"`
+	KeyHash   string      `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	Scope     APIKeyScope `json:"scope" gorm:"type:varchar(32);not null"`
+	// ServiceAccountID is the service account the key authenticates as, empty for keys of the tenant
+	ServiceAccountID string `json:"service_account_id,omitempty" gorm:"type:varchar(36);index"`
+	// KnowledgeBaseIDs restricts the key to these knowledge bases, empty allows all of the tenant
+	KnowledgeBaseIDs StringArray    `json:"knowledge_base_ids" gorm:"type:jsonb"`
+	ExpiresAt        *time.Time     `json:"expires_at"`
+	LastUsedAt       *time.Time     `json:"last_used_at"`
+	CreatedBy        string         `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName returns the table name for GORM
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsExpired checks if the key has expired at the given time
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// AllowsKnowledgeBase checks if the key may access a knowledge base
+func (k *APIKey) AllowsKnowledgeBase(kbID string) bool {
+	return len(k.KnowledgeBaseIDs) == 0 || slices.Contains(k.KnowledgeBaseIDs, kbID)
+}
+
+// CreateAPIKeyRequest creates a scoped API key
+type CreateAPIKeyRequest struct {
+	Name             string      `json:"name"               binding:"required,max=255"`
+	Scope            APIKeyScope `json:"scope"              binding:"required"`
+	KnowledgeBaseIDs []string    `json:"knowledge_base_ids"`
+	ExpiresAt        *time.Time  `json:"expires_at"`
+	// ServiceAccountID creates the key for a service account, within the scopes of the account
+	ServiceAccountID string `json:"service_account_id"`
+}
+
+// UpdateAPIKeyRequest updates the name, knowledge base restrictions or expiry of a scoped API key.
+// The scope cannot be changed, create a new key instead.
+type UpdateAPIKeyRequest struct {
+	Name             *string    `json:"name"`
+	KnowledgeBaseIDs *[]string  `json:"knowledge_base_ids"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	// ClearExpiry removes the expiry so the key never expires
+	ClearExpiry bool `json:"clear_expiry"`
+}
+
+// APIKeyWithSecret is returned when a key is created or rotated, the only time the key is visible
+type APIKeyWithSecret struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyFromContext returns the scoped API key of the request, nil when not authenticated with one
+func APIKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(APIKeyContextKey).(*APIKey)
+	return key
+}
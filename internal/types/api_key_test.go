@@ -0,0 +1,83 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyScopeAllows(t *testing.T) {
+	tests := []struct {
+		scope  APIKeyScope
+		method string
+		route  string
+		want   bool
+	}{
+		{APIKeyScopeRetrieval, "GET", "/api/v1/knowledge-bases/:id/hybrid-search", true},
+		{APIKeyScopeRetrieval, "POST", "/api/v1/knowledge-chat/:session_id", true},
+		{APIKeyScopeRetrieval, "POST", "/api/v1/knowledge-bases/:id/knowledge/file", false},
+		{APIKeyScopeRetrieval, "DELETE", "/api/v1/knowledge/:id", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/api-keys", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/knowledge/:id", true},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/sessions/:id", true},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/moderation/events", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/auth/sessions", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/debug/retrieval", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/knowledge-bases/:id/export", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/knowledge-bases/:id/members", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/knowledge-bases/:id/answer-feedback", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/jobs", false},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/usage/tokens", false},
+		{APIKeyScopeRetrieval, "POST", "/api/v1/mcp", true},
+		{APIKeyScopeRetrieval, "GET", "/api/v1/mcp", true},
+		{APIKeyScopeIngest, "POST", "/api/v1/mcp", false},
+		{APIKeyScopeIngest, "POST", "/api/v1/knowledge-bases/:id/knowledge/file", true},
+		{APIKeyScopeIngest, "GET", "/api/v1/knowledge/:id/progress", true},
+		{APIKeyScopeIngest, "POST", "/api/v1/knowledge-chat/:session_id", false},
+		{APIKeyScopeIngest, "GET", "/api/v1/sessions", false},
+		{APIKeyScopeIngest, "GET", "/api/v1/knowledge-bases/:id/export", false},
+		{APIKeyScopeAdmin, "DELETE", "/api/v1/knowledge-bases/:id", true},
+		{"", "GET", "/api/v1/knowledge-bases", false},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.Allows(tt.method, tt.route); got != tt.want {
+			t.Errorf("%q.Allows(%s %s) = %v, want %v", tt.scope, tt.method, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestServiceAccountScopes(t *testing.T) {
+	account := &ServiceAccount{ID: "sa-0123", Scopes: StringArray{string(APIKeyScopeIngest)}}
+	if !account.AllowsScope(APIKeyScopeIngest) || account.AllowsScope(APIKeyScopeRetrieval) {
+		t.Error("service account should only allow its own scopes")
+	}
+	if APIKeyScopeAdmin.IsValidForServiceAccount() || !APIKeyScopeRetrieval.IsValidForServiceAccount() {
+		t.Error("service accounts should not be granted the admin scope")
+	}
+	if !IsServiceAccountID(account.ID) || IsServiceAccountID("9a8b7c6d-5e4f-3a2b-1c0d-e9f8a7b6c5d4") {
+		t.Error("service account IDs should be told apart from user IDs")
+	}
+
+	ctx := context.WithValue(context.Background(), APIKeyContextKey, &APIKey{ServiceAccountID: account.ID})
+	if got := ActorIDFromContext(ctx); got != account.ID {
+		t.Errorf("ActorIDFromContext() = %q, want the service account", got)
+	}
+	if got := ActorIDFromContext(context.WithValue(ctx, UserIDContextKey, "user")); got != "user" {
+		t.Errorf("ActorIDFromContext() = %q, want the user", got)
+	}
+}
+
+func TestAPIKeyRestrictions(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	key := &APIKey{KnowledgeBaseIDs: StringArray{"kb1"}, ExpiresAt: &past}
+	if !key.IsExpired(now) {
+		t.Error("key should be expired")
+	}
+	if !key.AllowsKnowledgeBase("kb1") || key.AllowsKnowledgeBase("kb2") {
+		t.Error("key should only allow kb1")
+	}
+	if unrestricted := (&APIKey{}); unrestricted.IsExpired(now) || !unrestricted.AllowsKnowledgeBase("kb2") {
+		t.Error("key without restrictions should allow everything")
+	}
+}
@@ -60,6 +60,10 @@ const (
 	ResponseTypeAgentQuery ResponseType = "agent_query"
 	// Complete response type (agent complete)
 	ResponseTypeComplete ResponseType = "complete"
+	// Citations response type (passages cited by inline markers of the answer)
+	ResponseTypeCitations ResponseType = "citations"
+	// Follow-up questions response type (questions suggested after the answer)
+	ResponseTypeFollowUps ResponseType = "follow_up_questions"
 )
 
 // StreamResponse stream response
@@ -82,6 +86,8 @@ type StreamResponse struct {
 	ToolCalls []LLMToolCall `json:"tool_calls,omitempty"`
 	// Additional metadata for enhanced display
 	Data map[string]interface{} `json:"data,omitempty"`
+	// Token usage reported by the provider, set on the final response of a stream
+	Usage *TokenUsage `json:"usage,omitempty"`
 }
 
 // References references
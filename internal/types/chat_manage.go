@@ -7,9 +7,14 @@ type ChatManage struct {
 	Query        string     `json:"query,omitempty"`         // Original user query
 	RewriteQuery string     `json:"rewrite_query,omitempty"` // Query after rewriting for better retrieval
 	History      []*History `json:"history,omitempty"`       // Chat history for context
+	// ExternalHistory marks History as supplied by the caller (e.g. OpenAI-compatible requests)
+	// instead of being loaded from the session's stored messages
+	ExternalHistory bool `json:"-"`
 
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`      // IDs of knowledge bases to search (multi-KB support)
 	KnowledgeIDs     []string `json:"knowledge_ids,omitempty"` // IDs of specific files to search (optional)
+	// SearchFilter restricts retrieval to knowledge matching structured conditions (optional)
+	SearchFilter *SearchFilter `json:"search_filter,omitempty"`
 	// SearchTargets is the pre-computed unified search targets
 	// Computed once at request entry point, used throughout the pipeline
 	SearchTargets    SearchTargets `json:"-"`
@@ -34,6 +39,12 @@ type ChatManage struct {
 	EnableQueryExpansion bool   `json:"enable_query_expansion"` // Whether to enable query expansion with LLM
 	RewritePromptSystem  string `json:"rewrite_prompt_system"`  // Custom system prompt for rewrite stage
 	RewritePromptUser    string `json:"rewrite_prompt_user"`    // Custom user prompt for rewrite stage
+	// EnableLLMExpansion asks the chat model for keyword expansions and synonyms before retrieval
+	EnableLLMExpansion bool `json:"enable_llm_expansion"`
+	// EnableHyDE asks the chat model for a hypothetical answer that is searched by vector similarity
+	EnableHyDE bool `json:"enable_hyde"`
+	// EnableFollowUps suggests follow-up questions grounded in the retrieved passages after the answer
+	EnableFollowUps bool `json:"enable_follow_ups"`
 
 	// Internal fields for pipeline data processing
 	SearchResult    []*SearchResult   `json:"-"` // Results from search phase
@@ -43,13 +54,22 @@ type ChatManage struct {
 	EntityKBIDs     []string          `json:"-"` // Knowledge base IDs with ExtractConfig enabled
 	EntityKnowledge map[string]string `json:"-"` // KnowledgeID -> KnowledgeBaseID mapping for graph-enabled files
 	GraphResult     *GraphData        `json:"-"` // Graph data from search phase
+	QueryExpansions []string          `json:"-"` // Keyword expansions generated by the LLM
+	HyDEDocument    string            `json:"-"` // Hypothetical document generated by the LLM
 	UserContent     string            `json:"-"` // Processed user content
 	ChatResponse    *ChatResponse     `json:"-"` // Final response from chat model
+	Citations       Citations         `json:"-"` // Citations of the passages labeled in the prompt
+
+	// CitationStyle is how the answer marks the passages it uses, inline markers when empty
+	CitationStyle CitationStyle `json:"-"`
 
 	// Event system for streaming responses
 	EventBus  EventBusInterface `json:"-"` // EventBus for emitting streaming events
 	MessageID string            `json:"-"` // Assistant message ID for event emission
 
+	// Experiment is the A/B experiment variant the request was routed to, nil outside experiments
+	Experiment *ExperimentAssignment `json:"-"`
+
 	// Web search configuration (internal use)
 	TenantID         uint64 `json:"-"` // Tenant ID for retrieving web search config
 	WebSearchEnabled bool   `json:"-"` // Whether web search is enabled for this request
@@ -76,10 +96,13 @@ func (c *ChatManage) Clone() *ChatManage {
 		if t != nil {
 			kidsCopy := make([]string, len(t.KnowledgeIDs))
 			copy(kidsCopy, t.KnowledgeIDs)
+			hiddenCopy := make([]string, len(t.HiddenKnowledgeIDs))
+			copy(hiddenCopy, t.HiddenKnowledgeIDs)
 			searchTargets[i] = &SearchTarget{
-				Type:            t.Type,
-				KnowledgeBaseID: t.KnowledgeBaseID,
-				KnowledgeIDs:    kidsCopy,
+				Type:               t.Type,
+				KnowledgeBaseID:    t.KnowledgeBaseID,
+				KnowledgeIDs:       kidsCopy,
+				HiddenKnowledgeIDs: hiddenCopy,
 			}
 		}
 	}
@@ -91,6 +114,7 @@ func (c *ChatManage) Clone() *ChatManage {
 		KnowledgeBaseIDs: knowledgeBaseIDs,
 		KnowledgeIDs:     knowledgeIDs,
 		SearchTargets:    searchTargets,
+		SearchFilter:     c.SearchFilter,
 		VectorThreshold:  c.VectorThreshold,
 		KeywordThreshold: c.KeywordThreshold,
 		EmbeddingTopK:    c.EmbeddingTopK,
@@ -122,7 +146,10 @@ func (c *ChatManage) Clone() *ChatManage {
 		RewritePromptUser:    c.RewritePromptUser,
 		EnableRewrite:        c.EnableRewrite,
 		EnableQueryExpansion: c.EnableQueryExpansion,
+		EnableLLMExpansion:   c.EnableLLMExpansion,
+		EnableHyDE:           c.EnableHyDE,
 		TenantID:             c.TenantID,
+		Experiment:           c.Experiment,
 		// FAQ Strategy Settings
 		FAQPriorityEnabled:       c.FAQPriorityEnabled,
 		FAQDirectAnswerThreshold: c.FAQDirectAnswerThreshold,
@@ -32,6 +32,8 @@ const (
 	ChunkTypeTableSummary ChunkType = "table_summary"
 	// ChunkTypeTableColumn 表示数据表列描述的 Chunk
 	ChunkTypeTableColumn ChunkType = "table_column"
+	// ChunkTypeTable 表示从文档中抽取的结构化表格 Chunk
+	ChunkTypeTable ChunkType = "table"
 )
 
 // ChunkStatus 定义了不同状态的 Chunk
@@ -52,11 +54,27 @@ const (
 	// ChunkFlagRecommended 表示可推荐状态（1 << 0 = 1）
 	// 当设置此标志时，该 Chunk 可以被推荐给用户
 	ChunkFlagRecommended ChunkFlags = 1 << 0
+	// ChunkFlagPinned 表示置顶状态（1 << 1 = 2）
+	// 当设置此标志时，该 Chunk 在检索排序时获得加权
+	ChunkFlagPinned ChunkFlags = 1 << 1
 	// 未来可扩展更多标志位：
-	// ChunkFlagPinned ChunkFlags = 1 << 1  // 置顶
 	// ChunkFlagHot    ChunkFlags = 1 << 2  // 热门
 )
 
+// ChunkEmbeddingStatus 描述 Chunk 在向量索引中的状态
+type ChunkEmbeddingStatus string
+
+const (
+	// ChunkEmbeddingStatusPending 表示尚未完成向量化
+	ChunkEmbeddingStatusPending ChunkEmbeddingStatus = "pending"
+	// ChunkEmbeddingStatusIndexed 表示已写入向量索引，可被检索
+	ChunkEmbeddingStatusIndexed ChunkEmbeddingStatus = "indexed"
+	// ChunkEmbeddingStatusFailed 表示所属文档解析失败，向量化未完成
+	ChunkEmbeddingStatusFailed ChunkEmbeddingStatus = "failed"
+	// ChunkEmbeddingStatusDisabled 表示已索引但被禁用，不参与检索
+	ChunkEmbeddingStatusDisabled ChunkEmbeddingStatus = "disabled"
+)
+
 // HasFlag 检查是否设置了指定标志
 func (f ChunkFlags) HasFlag(flag ChunkFlags) bool {
 	return f&flag != 0
@@ -91,6 +109,8 @@ type ImageInfo struct {
 	Caption string `json:"caption"`
 	// 图片OCR文本
 	OCRText string `json:"ocr_text"`
+	// 生成OCR文本的引擎
+	OCREngine string `json:"ocr_engine,omitempty"`
 }
 
 // Chunk represents a document chunk
@@ -152,3 +172,33 @@ type Chunk struct {
 	// Soft delete marker, supports data recovery
 	DeletedAt gorm.DeletedAt `json:"deleted_at"               gorm:"index"`
 }
+
+// IsPinned reports whether the chunk is pinned for ranking boost
+func (c *Chunk) IsPinned() bool {
+	return c.Flags.HasFlag(ChunkFlagPinned)
+}
+
+// EmbeddingStatus derives the chunk's embedding status. Document chunks created by the
+// parse pipeline keep the default status, so the parent knowledge's parse status decides.
+func (c *Chunk) EmbeddingStatus(knowledgeParseStatus string) ChunkEmbeddingStatus {
+	var status ChunkEmbeddingStatus
+	switch ChunkStatus(c.Status) {
+	case ChunkStatusIndexed:
+		status = ChunkEmbeddingStatusIndexed
+	case ChunkStatusStored:
+		status = ChunkEmbeddingStatusPending
+	default:
+		switch knowledgeParseStatus {
+		case ParseStatusCompleted:
+			status = ChunkEmbeddingStatusIndexed
+		case ParseStatusFailed, ParseStatusFailedPermanent:
+			status = ChunkEmbeddingStatusFailed
+		default:
+			status = ChunkEmbeddingStatusPending
+		}
+	}
+	if status == ChunkEmbeddingStatusIndexed && !c.IsEnabled {
+		return ChunkEmbeddingStatusDisabled
+	}
+	return status
+}
@@ -0,0 +1,197 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Citation maps an inline citation marker of an answer to the chunk it cites
+type Citation struct {
+	// Label numbers the passage in the prompt, e.g. "1", or "FAQ-1" and "DOC-1" with FAQ priority
+	Label string `json:"label"`
+	// Marker is the inline marker of the passage in the answer, e.g. "[1]"
+	Marker          string `json:"marker"`
+	ChunkID         string `json:"chunk_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+	KnowledgeTitle  string `json:"knowledge_title"`
+	FileName        string `json:"file_name,omitempty"`
+	// PageStart and PageEnd are the pages of the chunk in paged documents such as PDFs
+	PageStart int `json:"page_start,omitempty"`
+	PageEnd   int `json:"page_end,omitempty"`
+	// HeadingPath is the heading hierarchy the chunk starts under, e.g. ["Install", "Docker"]
+	HeadingPath []string `json:"heading_path,omitempty"`
+	// LineStart and LineEnd are the lines of the chunk in source code files
+	LineStart int `json:"line_start,omitempty"`
+	LineEnd   int `json:"line_end,omitempty"`
+	// SourceURL is the address of web pages and web search results
+	SourceURL string `json:"source_url,omitempty"`
+	// URLFragment is the id of the section of the web page the chunk is in, without "#"
+	URLFragment string `json:"url_fragment,omitempty"`
+}
+
+// NewCitation describes the passage of a search result labeled in the prompt
+func NewCitation(label string, result *SearchResult) *Citation {
+	citation := &Citation{
+		Label:           label,
+		Marker:          "[" + label + "]",
+		ChunkID:         result.ID,
+		KnowledgeID:     result.KnowledgeID,
+		KnowledgeBaseID: result.KnowledgeBaseID,
+		KnowledgeTitle:  result.KnowledgeTitle,
+		FileName:        result.KnowledgeFilename,
+	}
+	switch {
+	case strings.HasPrefix(result.KnowledgeSource, "http://"), strings.HasPrefix(result.KnowledgeSource, "https://"):
+		citation.SourceURL = result.KnowledgeSource
+	case result.Metadata["url"] != "":
+		citation.SourceURL = result.Metadata["url"]
+	}
+	if len(result.ChunkMetadata) > 0 {
+		var meta DocumentChunkMetadata
+		if err := json.Unmarshal(result.ChunkMetadata, &meta); err == nil {
+			citation.PageStart = meta.PageStart
+			citation.PageEnd = meta.PageEnd
+			citation.HeadingPath = meta.HeadingPath
+			citation.LineStart = meta.LineStart
+			citation.LineEnd = meta.LineEnd
+			if citation.SourceURL != "" {
+				citation.URLFragment = meta.URLFragment
+			}
+		}
+	}
+	return citation
+}
+
+// Link returns the source URL with the fragment of the cited section, "" without a source URL
+func (c *Citation) Link() string {
+	if c.SourceURL == "" || c.URLFragment == "" {
+		return c.SourceURL
+	}
+	return strings.SplitN(c.SourceURL, "#", 2)[0] + "#" + c.URLFragment
+}
+
+// Source describes where the cited chunk comes from, e.g. "guide.pdf · 第 3 页 · Install > Docker"
+func (c *Citation) Source() string {
+	name := c.KnowledgeTitle
+	if name == "" {
+		name = c.FileName
+	}
+	parts := []string{name}
+	if location := sourceLocation(c.PageStart, c.PageEnd, c.LineStart, c.LineEnd, c.HeadingPath); location != "" {
+		parts = append(parts, location)
+	}
+	if link := c.Link(); link != "" {
+		parts = append(parts, link)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// sourceLocation describes where a chunk is in its document, e.g. "第 3 页 · Install > Docker",
+// "" when nothing is known
+func sourceLocation(pageStart, pageEnd, lineStart, lineEnd int, headingPath []string) string {
+	var parts []string
+	switch {
+	case pageStart > 0 && pageEnd > pageStart:
+		parts = append(parts, fmt.Sprintf("第 %d-%d 页", pageStart, pageEnd))
+	case pageStart > 0:
+		parts = append(parts, fmt.Sprintf("第 %d 页", pageStart))
+	}
+	switch {
+	case lineStart > 0 && lineEnd > lineStart:
+		parts = append(parts, fmt.Sprintf("第 %d-%d 行", lineStart, lineEnd))
+	case lineStart > 0:
+		parts = append(parts, fmt.Sprintf("第 %d 行", lineStart))
+	}
+	if len(headingPath) > 0 {
+		parts = append(parts, strings.Join(headingPath, " > "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// citationMarkerPattern matches inline markers such as [1], [FAQ-2] and [1, 3]
+var citationMarkerPattern = regexp.MustCompile(
+	`\[((?:FAQ-|DOC-)?\d+(?:\s*[,，、]\s*(?:FAQ-|DOC-)?\d+)*)\]`)
+
+// citationLabelSeparator splits the labels of a marker citing several passages
+var citationLabelSeparator = regexp.MustCompile(`\s*[,，、]\s*`)
+
+// Citations is the list of citations of an answer
+type Citations []*Citation
+
+// citationMarkerMaxLen bounds the unfinished marker kept between two parts of a streamed answer
+const citationMarkerMaxLen = 64
+
+// Cited returns the citations referenced by markers in the answer, in order of first appearance
+func (c Citations) Cited(answer string) Citations {
+	scanner := c.Scanner()
+	scanner.Write(answer)
+	return scanner.Cited()
+}
+
+// Scanner returns a scanner finding the citations of an answer streamed in parts
+func (c Citations) Scanner() *CitationScanner {
+	byLabel := make(map[string]*Citation, len(c))
+	for _, citation := range c {
+		byLabel[citation.Label] = citation
+	}
+	return &CitationScanner{byLabel: byLabel, seen: make(map[string]bool)}
+}
+
+// CitationScanner finds the citations of a streamed answer. Each part is scanned once, only a marker split
+// between two parts is carried over to the next one.
+type CitationScanner struct {
+	byLabel map[string]*Citation
+	seen    map[string]bool
+	cited   Citations
+	// pending is the end of the answer from an opening bracket not yet closed
+	pending string
+}
+
+// Write scans the next part of the answer and returns the citations it cites for the first time
+func (s *CitationScanner) Write(part string) Citations {
+	text := s.pending + part
+	s.pending = ""
+	if open := strings.LastIndexByte(text, '['); open >= 0 && !strings.Contains(text[open:], "]") &&
+		len(text)-open <= citationMarkerMaxLen {
+		s.pending = text[open:]
+	}
+	count := len(s.cited)
+	for _, match := range citationMarkerPattern.FindAllStringSubmatch(text, -1) {
+		for _, label := range citationLabelSeparator.Split(match[1], -1) {
+			if citation, ok := s.byLabel[label]; ok && !s.seen[label] {
+				s.seen[label] = true
+				s.cited = append(s.cited, citation)
+			}
+		}
+	}
+	return s.cited[count:]
+}
+
+// Cited returns the citations found so far, in order of first appearance
+func (s *CitationScanner) Cited() Citations {
+	return s.cited
+}
+
+// Value implements the driver.Valuer interface, used to convert Citations to database values
+func (c Citations) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database values to Citations
+func (c *Citations) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCitationsCited(t *testing.T) {
+	citations := Citations{
+		{Label: "1", ChunkID: "c1"},
+		{Label: "2", ChunkID: "c2"},
+		{Label: "3", ChunkID: "c3"},
+		{Label: "FAQ-1", ChunkID: "f1"},
+	}
+	answer := "Deploy with Docker [3]. It needs PostgreSQL [1，3] and Redis [2, 9]; see also [FAQ-1] and [x]."
+	cited := citations.Cited(answer)
+	var ids []string
+	for _, citation := range cited {
+		ids = append(ids, citation.ChunkID)
+	}
+	want := []string{"c3", "c1", "c2", "f1"}
+	if len(ids) != len(want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("got %v, want %v", ids, want)
+		}
+	}
+	if len(citations.Cited("no markers here")) != 0 {
+		t.Error("want no citations without markers")
+	}
+}
+
+func TestCitationScannerStream(t *testing.T) {
+	citations := Citations{{Label: "1", ChunkID: "c1"}, {Label: "12", ChunkID: "c12"}, {Label: "FAQ-2", ChunkID: "f2"}}
+	scanner := citations.Scanner()
+	var got [][]string
+	// Markers split between parts are found once the part closing them arrives
+	for _, part := range []string{"Docker [", "1", "2] and [FAQ", "-2, 1] again [1]", " and [12"} {
+		var ids []string
+		for _, citation := range scanner.Write(part) {
+			ids = append(ids, citation.ChunkID)
+		}
+		got = append(got, ids)
+	}
+	want := [][]string{nil, nil, {"c12"}, {"f2", "c1"}, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if len(scanner.Cited()) != 3 {
+		t.Errorf("got %d citations, want 3", len(scanner.Cited()))
+	}
+}
+
+func TestNewCitation(t *testing.T) {
+	meta, _ := json.Marshal(DocumentChunkMetadata{PageStart: 3, PageEnd: 4, HeadingPath: []string{"Install", "Docker"}})
+	citation := NewCitation("DOC-2", &SearchResult{
+		ID:                "chunk",
+		KnowledgeID:       "knowledge",
+		KnowledgeFilename: "guide.pdf",
+		KnowledgeSource:   "https://example.com/guide.pdf",
+		ChunkMetadata:     meta,
+	})
+	if citation.Marker != "[DOC-2]" || citation.FileName != "guide.pdf" {
+		t.Errorf("got %+v", citation)
+	}
+	if citation.PageStart != 3 || citation.PageEnd != 4 || len(citation.HeadingPath) != 2 {
+		t.Errorf("got anchors %d-%d %v", citation.PageStart, citation.PageEnd, citation.HeadingPath)
+	}
+	if citation.SourceURL != "https://example.com/guide.pdf" {
+		t.Errorf("got source url %q", citation.SourceURL)
+	}
+
+	citation = NewCitation("1", &SearchResult{KnowledgeSource: "file", Metadata: map[string]string{"url": "https://example.com"}})
+	if citation.SourceURL != "https://example.com" || citation.PageStart != 0 {
+		t.Errorf("got %+v", citation)
+	}
+}
+
+func TestCitationURLFragment(t *testing.T) {
+	meta, _ := json.Marshal(DocumentChunkMetadata{HeadingPath: []string{"Guide", "Install"}, URLFragment: "install"})
+	citation := NewCitation("1", &SearchResult{KnowledgeTitle: "Guide", KnowledgeSource: "https://example.com/guide#top", ChunkMetadata: meta})
+	if got := citation.Link(); got != "https://example.com/guide#install" {
+		t.Errorf("got link %q", got)
+	}
+	if got := citation.Source(); got != "Guide · Guide > Install · https://example.com/guide#install" {
+		t.Errorf("got source %q", got)
+	}
+
+	// Files have no page to link to
+	citation = NewCitation("2", &SearchResult{KnowledgeSource: "file", ChunkMetadata: meta})
+	if citation.URLFragment != "" || citation.Link() != "" {
+		t.Errorf("got %+v", citation)
+	}
+
+	var docMeta DocumentChunkMetadata
+	if docMeta.SourceLocation() != "" {
+		t.Error("want no location without anchors")
+	}
+	docMeta = DocumentChunkMetadata{PageStart: 2, LineStart: 5, LineEnd: 9}
+	if got := docMeta.SourceLocation(); got != "第 2 页 · 第 5-9 行" {
+		t.Errorf("got location %q", got)
+	}
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// codeFileLanguages maps source code file extensions to the language they are indexed as,
+// keep in sync with CODE_LANGUAGES of the docreader code parser
+var codeFileLanguages = map[string]string{
+	"go":    "go",
+	"py":    "python",
+	"js":    "javascript",
+	"jsx":   "javascript",
+	"ts":    "typescript",
+	"tsx":   "typescript",
+	"java":  "java",
+	"kt":    "kotlin",
+	"scala": "scala",
+	"c":     "c",
+	"h":     "c",
+	"cpp":   "cpp",
+	"cc":    "cpp",
+	"hpp":   "cpp",
+	"cs":    "csharp",
+	"rb":    "ruby",
+	"php":   "php",
+	"rs":    "rust",
+	"swift": "swift",
+	"sh":    "shell",
+	"sql":   "sql",
+}
+
+// CodeFileLanguage returns the language of a source code file type, or "" for other files
+func CodeFileLanguage(fileType string) string {
+	return codeFileLanguages[strings.ToLower(strings.TrimPrefix(fileType, "."))]
+}
+
+// IsCodeFileType reports whether a file type is a supported source code file
+func IsCodeFileType(fileType string) bool {
+	return CodeFileLanguage(fileType) != ""
+}
+
+// CodeFileTypes returns the file types of all supported source code files in sorted order
+func CodeFileTypes() []string {
+	fileTypes := make([]string, 0, len(codeFileLanguages))
+	for ext := range codeFileLanguages {
+		fileTypes = append(fileTypes, ext)
+	}
+	sort.Strings(fileTypes)
+	return fileTypes
+}
+
+// CodeLanguageFileTypes returns the file types of a language in sorted order, nil if unknown
+func CodeLanguageFileTypes(language string) []string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	var fileTypes []string
+	for ext, lang := range codeFileLanguages {
+		if lang == language {
+			fileTypes = append(fileTypes, ext)
+		}
+	}
+	sort.Strings(fileTypes)
+	return fileTypes
+}
+
+// CodeSymbol is a function, class or other definition found in a source code chunk
+type CodeSymbol struct {
+	// Symbol name, methods are not qualified with their receiver or class
+	Name string `json:"name"`
+	// Kind such as function, method, class, type or interface
+	Kind string `json:"kind"`
+	// Line range of the definition in the file, 1-based and inclusive
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
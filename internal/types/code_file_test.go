@@ -0,0 +1,46 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCodeFileLanguage(t *testing.T) {
+	if got := CodeFileLanguage(".TSX"); got != "typescript" {
+		t.Errorf("got %q", got)
+	}
+	if IsCodeFileType("pdf") || !IsCodeFileType("go") {
+		t.Error("unexpected code file types")
+	}
+	if got := CodeLanguageFileTypes(" TypeScript "); !reflect.DeepEqual(got, []string{"ts", "tsx"}) {
+		t.Errorf("got %v", got)
+	}
+	if got := CodeLanguageFileTypes("cobol"); got != nil {
+		t.Errorf("got %v", got)
+	}
+	if got := CodeFileTypes(); len(got) != len(codeFileLanguages) || got[0] != "c" || !IsCodeFileType(got[len(got)-1]) {
+		t.Errorf("got %v", got)
+	}
+
+	filter := &SearchFilter{Languages: []string{"go"}}
+	if err := filter.Validate(); err != nil || !filter.HasKnowledgeConditions() {
+		t.Errorf("got %v", err)
+	}
+	if err := (&SearchFilter{Languages: []string{"cobol"}}).Validate(); err == nil {
+		t.Error("expected unsupported language error")
+	}
+}
+
+func TestCodeCitationSource(t *testing.T) {
+	meta, _ := json.Marshal(DocumentChunkMetadata{
+		Language:  "go",
+		Symbols:   []CodeSymbol{{Name: "Run", Kind: "function", StartLine: 12, EndLine: 30}},
+		LineStart: 10,
+		LineEnd:   30,
+	})
+	citation := NewCitation("1", &SearchResult{KnowledgeTitle: "main.go", ChunkMetadata: meta})
+	if got := citation.Source(); got != "main.go · 第 10-30 行" {
+		t.Errorf("got %q", got)
+	}
+}
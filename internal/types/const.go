@@ -19,6 +19,20 @@ const (
 	// SessionTenantIDContextKey is the context key for session owner's tenant ID.
 	// When set (e.g. in pipeline with shared agent), session/message lookups use this instead of TenantIDContextKey.
 	SessionTenantIDContextKey ContextKey = "SessionTenantID"
+	// RetrievalTraceContextKey is the context key for the retrieval trace collected by debug requests
+	RetrievalTraceContextKey ContextKey = "RetrievalTrace"
+	// FollowUpsContextKey is the context key for a request's choice to suggest follow-up questions,
+	// overriding the configuration of the agent
+	FollowUpsContextKey ContextKey = "FollowUps"
+	// TokenUsageScopeContextKey is the context key for the session and knowledge base LLM token usage is attributed to
+	TokenUsageScopeContextKey ContextKey = "TokenUsageScope"
+	// APIKeyContextKey is the context key for the scoped API key a request authenticated with,
+	// not set for user tokens and the tenant API key
+	APIKeyContextKey ContextKey = "APIKey"
+	// ClientInfoContextKey is the context key for the IP address and user agent of a login request
+	ClientInfoContextKey ContextKey = "ClientInfo"
+	// LanguageContextKey is the context key for the language responses of a request are localized to
+	LanguageContextKey ContextKey = "Language"
 )
 
 // String returns the string representation of the context key
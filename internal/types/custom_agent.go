@@ -150,6 +150,12 @@ type CustomAgentConfig struct {
 	// ===== Advanced Settings (mainly for normal mode) =====
 	// Whether to enable query expansion
 	EnableQueryExpansion bool `yaml:"enable_query_expansion" json:"enable_query_expansion"`
+	// Whether to generate keyword expansions and synonyms with the LLM before retrieval
+	EnableLLMExpansion bool `yaml:"enable_llm_expansion" json:"enable_llm_expansion"`
+	// Whether to search with a hypothetical answer generated by the LLM (HyDE)
+	EnableHyDE bool `yaml:"enable_hyde" json:"enable_hyde"`
+	// Whether to suggest follow-up questions grounded in the retrieved passages after each answer
+	EnableFollowUps bool `yaml:"enable_follow_ups" json:"enable_follow_ups"`
 	// Whether to enable query rewrite for multi-turn conversations
 	EnableRewrite bool `yaml:"enable_rewrite" json:"enable_rewrite"`
 	// Rewrite prompt system message
@@ -296,7 +302,7 @@ func GetBuiltinSmartReasoningAgent(tenantID uint64) *CustomAgent {
 			MaxIterations:               50,
 			KBSelectionMode:             "all",
 			RetrieveKBOnlyWhenMentioned: false, // Default: retrieve KB based on KBSelectionMode
-			AllowedTools:                []string{"thinking", "todo_write", "knowledge_search", "grep_chunks", "list_knowledge_chunks", "query_knowledge_graph", "get_document_info"},
+			AllowedTools:                []string{"thinking", "todo_write", "knowledge_search", "grep_chunks", "list_knowledge_chunks", "query_knowledge_graph", "get_document_info", "calculator", "datetime"},
 			WebSearchEnabled:            true,
 			WebSearchMaxResults:         5,
 			ReflectionEnabled:           false,
@@ -0,0 +1,114 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// Duplicate kinds of a knowledge entry
+const (
+	// KnowledgeDuplicateExact means the extracted text is identical after normalization
+	KnowledgeDuplicateExact = "exact"
+	// KnowledgeDuplicateNear means the SimHash fingerprints are within NearDuplicateMaxDistance bits
+	KnowledgeDuplicateNear = "near"
+)
+
+// NearDuplicateMaxDistance is the largest Hamming distance between two SimHash
+// fingerprints that still counts as a near-duplicate
+const NearDuplicateMaxDistance = 6
+
+// nearDuplicateMinTokens is the minimum text length for a SimHash fingerprint;
+// a handful of tokens cannot tell near-duplicates from merely similar texts
+const nearDuplicateMinTokens = 50
+
+// DuplicateCluster groups the knowledge entries detected as duplicates of one original
+type DuplicateCluster struct {
+	Original   *Knowledge   `json:"original"`
+	Duplicates []*Knowledge `json:"duplicates"`
+}
+
+// DuplicateWarning returns the warning reported for a knowledge detected as a duplicate, empty when it is not one
+func (k *Knowledge) DuplicateWarning() string {
+	if k.DuplicateOf == "" {
+		return ""
+	}
+	return fmt.Sprintf("duplicate of %s (%s)", k.DuplicateOf, k.DuplicateType)
+}
+
+// contentTokens splits text into lowercase words; CJK characters are tokens on their own
+// since those scripts do not separate words by spaces
+func contentTokens(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// ContentHash returns the SHA-256 of the text with case, whitespace and punctuation normalized away
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.Join(contentTokens(text), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SimHash returns the 64-bit SimHash fingerprint of the text over token bigrams.
+// Texts that differ in a few places get fingerprints that differ in a few bits.
+// Texts too short to fingerprint reliably get 0.
+func SimHash(text string) uint64 {
+	tokens := contentTokens(text)
+	if len(tokens) < nearDuplicateMinTokens {
+		return 0
+	}
+	features := make([]string, 0, len(tokens)-1)
+	for i := 0; i+1 < len(tokens); i++ {
+		features = append(features, tokens[i]+" "+tokens[i+1])
+	}
+
+	var weights [64]int
+	h := fnv.New64a()
+	for _, feature := range features {
+		h.Reset()
+		_, _ = h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	var fingerprint uint64
+	for i, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+	return fingerprint
+}
+
+// HammingDistance returns the number of differing bits between two fingerprints
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestContentHashNormalizesFormatting(t *testing.T) {
+	a := ContentHash("Hello,  World!\n知识库 使用指南")
+	b := ContentHash("hello world 知识库使用指南")
+	if a != b {
+		t.Fatalf("expected equal hashes for texts differing only in formatting")
+	}
+	if a == ContentHash("hello there 知识库使用指南") {
+		t.Fatalf("expected different hashes for different texts")
+	}
+}
+
+func TestSimHashNearDuplicates(t *testing.T) {
+	var words []string
+	for i := 0; i < 400; i++ {
+		words = append(words, fmt.Sprintf("term%d", i*7%101+i))
+	}
+	base := strings.Join(words, " ")
+	words[200] = "changed"
+	edited := strings.Join(words, " ")
+	var others []string
+	for i := 0; i < 400; i++ {
+		others = append(others, fmt.Sprintf("word%d", i*13%97+i))
+	}
+	other := strings.Join(others, " ")
+
+	if d := HammingDistance(SimHash(base), SimHash(edited)); d > NearDuplicateMaxDistance {
+		t.Errorf("expected near-duplicate, got distance %d", d)
+	}
+	if d := HammingDistance(SimHash(base), SimHash(other)); d <= NearDuplicateMaxDistance {
+		t.Errorf("expected distinct texts, got distance %d", d)
+	}
+}
+
+func TestSimHashShortText(t *testing.T) {
+	if SimHash("  \n ") != 0 || SimHash("just a few words") != 0 {
+		t.Errorf("expected zero fingerprint for short text")
+	}
+}
+
+func TestKnowledgeDuplicateWarning(t *testing.T) {
+	if got := (&Knowledge{}).DuplicateWarning(); got != "" {
+		t.Errorf("got %q for a knowledge that is no duplicate", got)
+	}
+	k := &Knowledge{DuplicateOf: "k1", DuplicateType: KnowledgeDuplicateNear}
+	if got := k.DuplicateWarning(); got != "duplicate of k1 (near)" {
+		t.Errorf("got %q", got)
+	}
+}
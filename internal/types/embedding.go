@@ -7,6 +7,7 @@ const (
 	ChunkSourceType   SourceType = iota // Source is a text chunk
 	PassageSourceType                   // Source is a passage
 	SummarySourceType                   // Source is a summary
+	ImageSourceType                     // Source is the image of an image knowledge
 )
 
 // MatchType represents the type of matching algorithm
@@ -0,0 +1,29 @@
+package types
+
+// Metadata keys of the knowledge imported from the notes of an Evernote export
+const (
+	EnexMetadataNotebook    = "notebook"
+	EnexMetadataNoteCreated = "note_created"
+	EnexMetadataNoteUpdated = "note_updated"
+	EnexMetadataAuthor      = "author"
+	EnexMetadataSourceURL   = "source_url"
+	EnexMetadataTags        = "evernote_tags"
+)
+
+// EnexImportResult is the outcome of importing an Evernote export (.enex), one knowledge per note
+type EnexImportResult struct {
+	// Notebook is the notebook the notes were imported from, also the name of the tag they were given
+	Notebook string `json:"notebook"`
+	// Knowledge is the knowledge created for the notes
+	Knowledge []*Knowledge `json:"knowledge"`
+	// Duplicates is the knowledge of notes imported before, left as they were
+	Duplicates []*Knowledge `json:"duplicates"`
+	// Failed lists the notes that could not be imported
+	Failed []EnexNoteError `json:"failed"`
+}
+
+// EnexNoteError is a note of an Evernote export that could not be imported
+type EnexNoteError struct {
+	Title string `json:"title"`
+	Error string `json:"error"`
+}
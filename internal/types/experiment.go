@@ -0,0 +1,308 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+// ExperimentStatus is the lifecycle status of a knowledge base experiment
+type ExperimentStatus string
+
+const (
+	// ExperimentStatusRunning splits chat traffic between both variants
+	ExperimentStatusRunning ExperimentStatus = "running"
+	// ExperimentStatusStopped no longer affects chat traffic
+	ExperimentStatusStopped ExperimentStatus = "stopped"
+	// ExperimentStatusPromoted routes all chat traffic to the winning variant
+	ExperimentStatusPromoted ExperimentStatus = "promoted"
+)
+
+// ExperimentVariant names one of the two configurations of an experiment
+type ExperimentVariant string
+
+const (
+	ExperimentVariantA ExperimentVariant = "a"
+	ExperimentVariantB ExperimentVariant = "b"
+)
+
+// ExperimentMetric is the metric automatic promotion compares variants by
+type ExperimentMetric string
+
+const (
+	// ExperimentMetricFeedback compares the share of positive feedback among rated answers
+	ExperimentMetricFeedback ExperimentMetric = "feedback"
+	// ExperimentMetricClicks compares the share of answers whose citations were clicked
+	ExperimentMetricClicks ExperimentMetric = "clicks"
+	// ExperimentMetricLatency compares the mean latency until the answer starts streaming, lower wins
+	ExperimentMetricLatency ExperimentMetric = "latency"
+)
+
+// Automatic promotion defaults
+const (
+	DefaultExperimentMinSamples = 100
+	DefaultExperimentMinLift    = 0.05
+)
+
+// ExperimentVariantConfig overrides the retrieval and generation settings of chat requests
+// routed to a variant, zero values and nil flags keep the settings the request would use
+type ExperimentVariantConfig struct {
+	VectorThreshold    float64 `json:"vector_threshold,omitempty"`
+	KeywordThreshold   float64 `json:"keyword_threshold,omitempty"`
+	EmbeddingTopK      int     `json:"embedding_top_k,omitempty"`
+	RerankModelID      string  `json:"rerank_model_id,omitempty"`
+	RerankTopK         int     `json:"rerank_top_k,omitempty"`
+	RerankThreshold    float64 `json:"rerank_threshold,omitempty"`
+	EnableLLMExpansion *bool   `json:"enable_llm_expansion,omitempty"`
+	EnableHyDE         *bool   `json:"enable_hyde,omitempty"`
+	ChatModelID        string  `json:"chat_model_id,omitempty"`
+	// Prompt replaces the system prompt of answer generation
+	Prompt string `json:"prompt,omitempty"`
+	// ContextTemplate replaces the template the retrieved context is rendered with
+	ContextTemplate string   `json:"context_template,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+}
+
+// Validate checks the bounds of a variant configuration
+func (c *ExperimentVariantConfig) Validate() error {
+	if c.VectorThreshold < 0 || c.VectorThreshold > 1 || c.KeywordThreshold < 0 || c.KeywordThreshold > 1 ||
+		c.RerankThreshold < 0 || c.RerankThreshold > 1 {
+		return errors.New("thresholds must be between 0 and 1")
+	}
+	if c.EmbeddingTopK < 0 || c.RerankTopK < 0 {
+		return errors.New("top k must not be negative")
+	}
+	if c.Temperature != nil && (*c.Temperature < 0 || *c.Temperature > 2) {
+		return errors.New("temperature must be between 0 and 2")
+	}
+	return nil
+}
+
+// Apply overrides the settings of a chat request with the variant configuration
+func (c *ExperimentVariantConfig) Apply(chatManage *ChatManage) {
+	if c.VectorThreshold > 0 {
+		chatManage.VectorThreshold = c.VectorThreshold
+	}
+	if c.KeywordThreshold > 0 {
+		chatManage.KeywordThreshold = c.KeywordThreshold
+	}
+	if c.EmbeddingTopK > 0 {
+		chatManage.EmbeddingTopK = c.EmbeddingTopK
+	}
+	if c.RerankModelID != "" {
+		chatManage.RerankModelID = c.RerankModelID
+	}
+	if c.RerankTopK > 0 {
+		chatManage.RerankTopK = c.RerankTopK
+	}
+	if c.RerankThreshold > 0 {
+		chatManage.RerankThreshold = c.RerankThreshold
+	}
+	if c.EnableLLMExpansion != nil {
+		chatManage.EnableLLMExpansion = *c.EnableLLMExpansion
+	}
+	if c.EnableHyDE != nil {
+		chatManage.EnableHyDE = *c.EnableHyDE
+	}
+	if c.ChatModelID != "" {
+		chatManage.ChatModelID = c.ChatModelID
+	}
+	if c.Prompt != "" {
+		chatManage.SummaryConfig.Prompt = c.Prompt
+	}
+	if c.ContextTemplate != "" {
+		chatManage.SummaryConfig.ContextTemplate = c.ContextTemplate
+	}
+	if c.Temperature != nil {
+		chatManage.SummaryConfig.Temperature = *c.Temperature
+	}
+}
+
+// Value implements the driver.Valuer interface
+func (c ExperimentVariantConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *ExperimentVariantConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// ExperimentAutoPromote promotes the better variant once both have enough samples
+type ExperimentAutoPromote struct {
+	Enabled bool             `json:"enabled"`
+	Metric  ExperimentMetric `json:"metric"`
+	// MinSamples is the number of samples each variant needs, rated answers for the
+	// feedback metric and chat requests otherwise
+	MinSamples int `json:"min_samples"`
+	// MinLift is the relative improvement the winner needs over the other variant
+	MinLift float64 `json:"min_lift"`
+}
+
+// Validate checks the promotion metric and bounds
+func (c *ExperimentAutoPromote) Validate() error {
+	switch c.Metric {
+	case "", ExperimentMetricFeedback, ExperimentMetricClicks, ExperimentMetricLatency:
+	default:
+		return errors.New("metric must be feedback, clicks or latency")
+	}
+	if c.MinSamples < 0 || c.MinLift < 0 {
+		return errors.New("min_samples and min_lift must not be negative")
+	}
+	return nil
+}
+
+// GetMetric returns the promotion metric, feedback when not set
+func (c *ExperimentAutoPromote) GetMetric() ExperimentMetric {
+	if c.Metric == "" {
+		return ExperimentMetricFeedback
+	}
+	return c.Metric
+}
+
+// GetMinSamples returns the samples each variant needs, DefaultExperimentMinSamples when not set
+func (c *ExperimentAutoPromote) GetMinSamples() int {
+	if c.MinSamples <= 0 {
+		return DefaultExperimentMinSamples
+	}
+	return c.MinSamples
+}
+
+// GetMinLift returns the relative improvement the winner needs, DefaultExperimentMinLift when not set
+func (c *ExperimentAutoPromote) GetMinLift() float64 {
+	if c.MinLift <= 0 {
+		return DefaultExperimentMinLift
+	}
+	return c.MinLift
+}
+
+// Value implements the driver.Valuer interface
+func (c ExperimentAutoPromote) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *ExperimentAutoPromote) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// KBExperiment is an A/B experiment comparing two chat configurations of a knowledge base
+type KBExperiment struct {
+	ID              string           `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64           `json:"tenant_id"`
+	KnowledgeBaseID string           `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	Name            string           `json:"name"              gorm:"type:varchar(255);not null"`
+	Description     string           `json:"description"       gorm:"type:text"`
+	Status          ExperimentStatus `json:"status"            gorm:"type:varchar(16)"`
+	// TrafficPercent is the share of chat sessions routed to variant B, the rest use variant A
+	TrafficPercent int                     `json:"traffic_percent"`
+	VariantA       ExperimentVariantConfig `json:"variant_a"    gorm:"type:json"`
+	VariantB       ExperimentVariantConfig `json:"variant_b"    gorm:"type:json"`
+	AutoPromote    ExperimentAutoPromote   `json:"auto_promote" gorm:"type:json"`
+	// Winner is the promoted variant, empty until the experiment is promoted
+	Winner     ExperimentVariant `json:"winner,omitempty" gorm:"type:varchar(8)"`
+	PromotedAt *time.Time        `json:"promoted_at,omitempty"`
+	StoppedAt  *time.Time        `json:"stopped_at,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// TableName returns the table name of KBExperiment
+func (KBExperiment) TableName() string {
+	return "kb_experiments"
+}
+
+// Validate checks the traffic split, variants and promotion settings
+func (e *KBExperiment) Validate() error {
+	if strings.TrimSpace(e.Name) == "" {
+		return errors.New("name is required")
+	}
+	if e.TrafficPercent < 1 || e.TrafficPercent > 99 {
+		return errors.New("traffic_percent must be between 1 and 99")
+	}
+	if err := e.VariantA.Validate(); err != nil {
+		return errors.New("variant_a: " + err.Error())
+	}
+	if err := e.VariantB.Validate(); err != nil {
+		return errors.New("variant_b: " + err.Error())
+	}
+	return e.AutoPromote.Validate()
+}
+
+// IsLive reports whether the experiment affects chat traffic
+func (e *KBExperiment) IsLive() bool {
+	return e.Status == ExperimentStatusRunning || e.Status == ExperimentStatusPromoted
+}
+
+// VariantConfig returns the configuration of a variant
+func (e *KBExperiment) VariantConfig(variant ExperimentVariant) *ExperimentVariantConfig {
+	if variant == ExperimentVariantB {
+		return &e.VariantB
+	}
+	return &e.VariantA
+}
+
+// AssignVariant returns the variant a chat session is routed to. Sessions are hashed so
+// every question of a conversation sees the same variant; promoted experiments route all
+// sessions to the winner.
+func (e *KBExperiment) AssignVariant(sessionID string) ExperimentVariant {
+	if e.Status == ExperimentStatusPromoted {
+		return e.Winner
+	}
+	h := fnv.New32a()
+	h.Write([]byte(e.ID + ":" + sessionID))
+	if int(h.Sum32()%100) < e.TrafficPercent {
+		return ExperimentVariantB
+	}
+	return ExperimentVariantA
+}
+
+// ExperimentAssignment records the experiment variant a chat request was routed to
+type ExperimentAssignment struct {
+	ExperimentID string            `json:"experiment_id"`
+	Variant      ExperimentVariant `json:"variant"`
+}
+
+// ExperimentVariantStats aggregates the chat requests routed to one variant
+type ExperimentVariantStats struct {
+	Variant            ExperimentVariant `json:"variant"`
+	Searches           int64             `json:"searches"`
+	ZeroResultSearches int64             `json:"zero_result_searches"`
+	// AvgLatencyMs is the mean time until the answer started streaming
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+	Clicks           int64   `json:"clicks"`
+	ClickedSearches  int64   `json:"clicked_searches"`
+	PositiveFeedback int64   `json:"positive_feedback"`
+	NegativeFeedback int64   `json:"negative_feedback"`
+	// ClickThroughRate is the share of answers with at least one clicked citation
+	ClickThroughRate float64 `json:"click_through_rate" gorm:"-"`
+	// SatisfactionRate is the share of positive feedback among rated answers
+	SatisfactionRate float64 `json:"satisfaction_rate"  gorm:"-"`
+}
+
+// ExperimentResults compares the variants of an experiment
+type ExperimentResults struct {
+	Experiment *KBExperiment             `json:"experiment"`
+	Variants   []*ExperimentVariantStats `json:"variants"`
+	// Leader is the variant ahead on the promotion metric, empty while either lacks samples
+	Leader ExperimentVariant `json:"leader,omitempty"`
+	// Lift is the relative improvement of the leader over the other variant
+	Lift float64 `json:"lift"`
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 const (
 	TypeChunkExtract        = "chunk:extract"
 	TypeDocumentProcess     = "document:process"      // 文档处理任务
@@ -10,7 +12,21 @@ const (
 	TypeIndexDelete         = "index:delete"          // 索引删除任务
 	TypeKBDelete            = "kb:delete"             // 知识库删除任务
 	TypeKnowledgeListDelete = "knowledge:list_delete" // 批量删除知识任务
+	TypeKnowledgeBulk       = "knowledge:bulk"        // 知识批量操作（删除、改标签、重新解析）任务
 	TypeDataTableSummary    = "datatable:summary"     // 表格摘要任务
+	TypeKnowledgeLifecycle  = "knowledge:lifecycle"   // 知识生命周期巡检任务
+	TypeKnowledgeTrashPurge = "knowledge:trash_purge" // 回收站过期知识清理任务
+	TypeKnowledgeLinkCheck  = "knowledge:link_check"  // URL 知识失效链接巡检任务
+	TypeKBEmbeddingReindex  = "kb:embedding_reindex"  // 知识库向量模型迁移（全量重建索引）任务
+	TypeKBImport            = "kb:import"             // 知识库导入任务
+	TypeRetrievalEval       = "retrieval:eval"        // 检索评测任务
+	TypeExperimentPromotion = "experiment:promotion"  // A/B 实验自动晋升巡检任务
+	TypeModelHealthProbe    = "model:health_probe"    // 模型健康探测任务
+	TypeLDAPSync            = "ldap:sync"             // LDAP 用户与用户组同步任务
+	TypeJobHistoryPurge     = "job:history_purge"     // 过期任务记录清理任务
+	TypeTenantBackup        = "tenant:backup"         // 租户备份任务
+	TypeWebhookDelivery     = "webhook:delivery"      // Webhook 事件投递任务
+	TypeWebhookPurge        = "webhook:purge"         // 过期 Webhook 投递记录清理任务
 )
 
 // ExtractChunkPayload represents the extract chunk task payload
@@ -34,6 +50,13 @@ type DocumentProcessPayload struct {
 	EnableMultimodel         bool     `json:"enable_multimodel"`
 	EnableQuestionGeneration bool     `json:"enable_question_generation"` // 是否启用问题生成
 	QuestionCount            int      `json:"question_count,omitempty"`   // 每个chunk生成的问题数量
+	// ExtractionMode overrides the web extraction mode of the knowledge base for URL imports
+	ExtractionMode string `json:"extraction_mode,omitempty"`
+	// RecapturePreviewID names a confirmed re-capture of the URL, whose previewed content is indexed instead of
+	// fetching the page again
+	RecapturePreviewID string `json:"recapture_preview_id,omitempty"`
+	// TraceContext carries the trace of the request that enqueued the task
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 // FAQImportPayload represents the FAQ import task payload (including dry run mode)
@@ -88,6 +111,8 @@ type KBDeletePayload struct {
 	TenantID         uint64                  `json:"tenant_id"`
 	KnowledgeBaseID  string                  `json:"knowledge_base_id"`
 	EffectiveEngines []RetrieverEngineParams `json:"effective_engines"`
+	// ImageEmbeddingModelID is the model of the visual vectors to delete, empty when image embedding was never configured
+	ImageEmbeddingModelID string `json:"image_embedding_model_id,omitempty"`
 }
 
 // KnowledgeListDeletePayload represents the batch knowledge delete task payload
@@ -96,6 +121,22 @@ type KnowledgeListDeletePayload struct {
 	KnowledgeIDs []string `json:"knowledge_ids"`
 }
 
+// KnowledgeLifecyclePayload represents the periodic knowledge lifecycle task payload
+type KnowledgeLifecyclePayload struct {
+	// Optional: only enforce policies of this knowledge base
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+}
+
+// KnowledgeExpiryNotification is emitted before knowledge expires
+type KnowledgeExpiryNotification struct {
+	TenantID        uint64    `json:"tenant_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	KnowledgeID     string    `json:"knowledge_id"`
+	Title           string    `json:"title"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	Action          string    `json:"action"`
+}
+
 // KBCloneTaskStatus represents the status of a knowledge base clone task
 type KBCloneTaskStatus string
 
@@ -121,6 +162,87 @@ type KBCloneProgress struct {
 	UpdatedAt int64             `json:"updated_at"` // 最后更新时间
 }
 
+// KBReindexPayload represents the knowledge base embedding re-index task payload
+type KBReindexPayload struct {
+	TenantID  uint64 `json:"tenant_id"`
+	TaskID    string `json:"task_id"`
+	KBID      string `json:"kb_id"`
+	ToModelID string `json:"to_model_id"`
+	// Number of chunks embedded per batch
+	BatchSize int `json:"batch_size"`
+	// Maximum number of batches per minute, 0 means unlimited
+	BatchesPerMinute int `json:"batches_per_minute"`
+}
+
+// KBReindexTaskStatus represents the status of a knowledge base re-index task
+type KBReindexTaskStatus string
+
+const (
+	KBReindexStatusPending    KBReindexTaskStatus = "pending"
+	KBReindexStatusProcessing KBReindexTaskStatus = "processing"
+	KBReindexStatusSwitching  KBReindexTaskStatus = "switching" // 新向量已就绪，正在切换
+	KBReindexStatusCompleted  KBReindexTaskStatus = "completed"
+	KBReindexStatusFailed     KBReindexTaskStatus = "failed"
+)
+
+// KBReindexProgress represents the progress of a knowledge base re-index task
+type KBReindexProgress struct {
+	TaskID      string              `json:"task_id"`
+	KBID        string              `json:"kb_id"`
+	FromModelID string              `json:"from_model_id"`
+	ToModelID   string              `json:"to_model_id"`
+	Status      KBReindexTaskStatus `json:"status"`
+	Progress    int                 `json:"progress"`   // 0-100
+	Total       int                 `json:"total"`      // 需要重建的分块数
+	Processed   int                 `json:"processed"`  // 已重建的分块数
+	Message     string              `json:"message"`    // 状态消息
+	Error       string              `json:"error"`      // 错误信息
+	CreatedAt   int64               `json:"created_at"` // 任务创建时间
+	UpdatedAt   int64               `json:"updated_at"` // 最后更新时间
+}
+
+// KBReindexRequest is the request to re-index a knowledge base with another embedding model
+type KBReindexRequest struct {
+	EmbeddingModelID string `json:"embedding_model_id" binding:"required"`
+	BatchSize        int    `json:"batch_size"`
+	BatchesPerMinute int    `json:"batches_per_minute"`
+}
+
+// KBImportPayload represents the knowledge base bundle import task payload
+type KBImportPayload struct {
+	TenantID uint64 `json:"tenant_id"`
+	TaskID   string `json:"task_id"`
+	KBID     string `json:"kb_id"`
+	// Storage path of the uploaded bundle, deleted once the import has finished
+	BundlePath string `json:"bundle_path"`
+}
+
+// KBImportTaskStatus represents the status of a knowledge base import task
+type KBImportTaskStatus string
+
+const (
+	KBImportStatusPending    KBImportTaskStatus = "pending"
+	KBImportStatusProcessing KBImportTaskStatus = "processing"
+	KBImportStatusCompleted  KBImportTaskStatus = "completed"
+	KBImportStatusFailed     KBImportTaskStatus = "failed"
+)
+
+// KBImportProgress represents the progress of a knowledge base import task
+type KBImportProgress struct {
+	TaskID    string             `json:"task_id"`
+	KBID      string             `json:"kb_id"`
+	Status    KBImportTaskStatus `json:"status"`
+	Progress  int                `json:"progress"`  // 0-100
+	Total     int                `json:"total"`     // 总知识数
+	Processed int                `json:"processed"` // 已导入知识数
+	// Number of chunks whose vectors came from the bundle instead of the embedding model
+	ReusedEmbeddings int    `json:"reused_embeddings"`
+	Message          string `json:"message"`    // 状态消息
+	Error            string `json:"error"`      // 错误信息
+	CreatedAt        int64  `json:"created_at"` // 任务创建时间
+	UpdatedAt        int64  `json:"updated_at"` // 最后更新时间
+}
+
 // ChunkContext represents chunk content with surrounding context
 type ChunkContext struct {
 	ChunkID     string `json:"chunk_id"`
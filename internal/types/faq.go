@@ -22,8 +22,9 @@ type FAQChunkMetadata struct {
 
 // GeneratedQuestion 表示AI生成的单个问题
 type GeneratedQuestion struct {
-	ID       string `json:"id"`       // 唯一标识，用于构造 source_id
-	Question string `json:"question"` // 问题内容
+	ID       string `json:"id"`               // 唯一标识，用于构造 source_id
+	Question string `json:"question"`         // 问题内容
+	Answer   string `json:"answer,omitempty"` // 基于 Chunk 内容生成的答案（开启问答对生成时）
 }
 
 // DocumentChunkMetadata 定义文档 Chunk 的元数据结构
@@ -32,6 +33,32 @@ type DocumentChunkMetadata struct {
 	// GeneratedQuestions 存储AI为该Chunk生成的相关问题
 	// 这些问题会被独立索引以提高召回率
 	GeneratedQuestions []GeneratedQuestion `json:"generated_questions,omitempty"`
+	// NotebookCells 记录该Chunk覆盖的笔记本单元格（按单元格顺序，仅 ipynb 文档）
+	NotebookCells []NotebookCell `json:"notebook_cells,omitempty"`
+	// PageStart 和 PageEnd 记录该Chunk所在的页码范围（从1开始，仅分页文档如 PDF）
+	PageStart int `json:"page_start,omitempty"`
+	PageEnd   int `json:"page_end,omitempty"`
+	// HeadingPath 记录该Chunk起始位置所在的标题层级（Markdown 等带标题的文档）
+	HeadingPath []string `json:"heading_path,omitempty"`
+	// Language 记录源代码文件的语言，如 go、python（仅代码文件）
+	Language string `json:"language,omitempty"`
+	// Symbols 记录该Chunk包含的函数、类等定义（仅代码文件）
+	Symbols []CodeSymbol `json:"symbols,omitempty"`
+	// LineStart 和 LineEnd 记录该Chunk在文件中的行号范围（从1开始，仅代码文件）
+	LineStart int `json:"line_start,omitempty"`
+	LineEnd   int `json:"line_end,omitempty"`
+	// URLFragment 记录该Chunk所在网页章节的锚点（标题元素的 id，不含 #，仅网页）
+	URLFragment string `json:"url_fragment,omitempty"`
+	// Table 记录该Chunk包含的表格行及表头、列类型（仅 CSV、Excel 文档）
+	Table *StructuredTable `json:"table,omitempty"`
+}
+
+// NotebookCell 标识 Jupyter 笔记本中的一个单元格
+type NotebookCell struct {
+	// 单元格在笔记本中的序号（从0开始）
+	Index int `json:"index"`
+	// 单元格类型：code、markdown 或 raw
+	Type string `json:"type"`
 }
 
 // GetQuestionStrings 返回问题内容字符串列表（兼容旧代码）
@@ -46,6 +73,28 @@ func (m *DocumentChunkMetadata) GetQuestionStrings() []string {
 	return result
 }
 
+// QuestionBySourceID 根据索引 source_id（格式：{chunk_id}-{question_id}）查找生成的问题
+func (m *DocumentChunkMetadata) QuestionBySourceID(chunkID, sourceID string) *GeneratedQuestion {
+	if m == nil {
+		return nil
+	}
+	questionID, ok := strings.CutPrefix(sourceID, chunkID+"-")
+	if !ok {
+		return nil
+	}
+	for i := range m.GeneratedQuestions {
+		if m.GeneratedQuestions[i].ID == questionID {
+			return &m.GeneratedQuestions[i]
+		}
+	}
+	return nil
+}
+
+// SourceLocation 描述该Chunk在文档中的位置，如 "第 3 页 · Install > Docker"，无位置信息时为空
+func (m *DocumentChunkMetadata) SourceLocation() string {
+	return sourceLocation(m.PageStart, m.PageEnd, m.LineStart, m.LineEnd, m.HeadingPath)
+}
+
 // DocumentMetadata 解析 Chunk 中的文档元数据
 func (c *Chunk) DocumentMetadata() (*DocumentChunkMetadata, error) {
 	if c == nil || len(c.Metadata) == 0 {
@@ -0,0 +1,59 @@
+package types
+
+import "time"
+
+// HealthStatus is the health of a dependency or of the whole service
+type HealthStatus string
+
+const (
+	// HealthStatusUp dependencies answered their probe
+	HealthStatusUp HealthStatus = "up"
+	// HealthStatusDegraded dependencies work but some of what they front is failing, such as model endpoints
+	HealthStatusDegraded HealthStatus = "degraded"
+	// HealthStatusDown dependencies failed their probe
+	HealthStatusDown HealthStatus = "down"
+)
+
+// DependencyHealth is the result of probing one dependency
+type DependencyHealth struct {
+	// Name is the dependency, such as postgres, redis or vector_store:qdrant
+	Name   string       `json:"name"`
+	Status HealthStatus `json:"status"`
+	// Required dependencies being down make the service not ready
+	Required bool   `json:"required"`
+	Latency  int64  `json:"latency_ms"`
+	Error    string `json:"error,omitempty"`
+	// Details add counts such as the model endpoints whose circuit is open
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// HealthReport is the health of the service and each of its dependencies
+type HealthReport struct {
+	Status HealthStatus `json:"status"`
+	// Ready is false when a required dependency is down or the service is shutting down
+	Ready        bool                `json:"ready"`
+	ShuttingDown bool                `json:"shutting_down,omitempty"`
+	Dependencies []*DependencyHealth `json:"dependencies"`
+	CheckedAt    time.Time           `json:"checked_at"`
+}
+
+// NewHealthReport sums up the probes of the dependencies. The service is down when a required dependency
+// is down, and degraded when an optional one is down or any is degraded.
+func NewHealthReport(deps []*DependencyHealth, shuttingDown bool, checkedAt time.Time) *HealthReport {
+	status := HealthStatusUp
+	for _, dep := range deps {
+		switch {
+		case dep.Status == HealthStatusDown && dep.Required:
+			status = HealthStatusDown
+		case dep.Status != HealthStatusUp && status == HealthStatusUp:
+			status = HealthStatusDegraded
+		}
+	}
+	return &HealthReport{
+		Status:       status,
+		Ready:        status != HealthStatusDown && !shuttingDown,
+		ShuttingDown: shuttingDown,
+		Dependencies: deps,
+		CheckedAt:    checkedAt,
+	}
+}
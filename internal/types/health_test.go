@@ -0,0 +1,36 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewHealthReport(t *testing.T) {
+	now := time.Now()
+	up := func(name string, required bool) *DependencyHealth {
+		return &DependencyHealth{Name: name, Status: HealthStatusUp, Required: required}
+	}
+	down := func(name string, required bool) *DependencyHealth {
+		return &DependencyHealth{Name: name, Status: HealthStatusDown, Required: required}
+	}
+
+	report := NewHealthReport([]*DependencyHealth{up("postgres", true), up("neo4j", false)}, false, now)
+	if report.Status != HealthStatusUp || !report.Ready {
+		t.Errorf("all up should be up and ready: %+v", report)
+	}
+
+	report = NewHealthReport([]*DependencyHealth{up("postgres", true), down("neo4j", false)}, false, now)
+	if report.Status != HealthStatusDegraded || !report.Ready {
+		t.Errorf("optional dependency down should be degraded and ready: %+v", report)
+	}
+
+	report = NewHealthReport([]*DependencyHealth{down("postgres", true), down("neo4j", false)}, false, now)
+	if report.Status != HealthStatusDown || report.Ready {
+		t.Errorf("required dependency down should be down and not ready: %+v", report)
+	}
+
+	report = NewHealthReport([]*DependencyHealth{up("postgres", true)}, true, now)
+	if report.Status != HealthStatusUp || report.Ready || !report.ShuttingDown {
+		t.Errorf("shutting down should not be ready: %+v", report)
+	}
+}
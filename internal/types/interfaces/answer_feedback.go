@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// AnswerFeedbackService records feedback on answers and serves the curation queue of knowledge bases
+type AnswerFeedbackService interface {
+	// SubmitFeedback rates an assistant message of the current tenant, replacing earlier feedback on it
+	SubmitFeedback(ctx context.Context, sessionID, messageID string,
+		req *types.AnswerFeedbackRequest) (*types.AnswerFeedback, error)
+	// GetMessageFeedback returns the feedback on an assistant message, nil when it has not been rated
+	GetMessageFeedback(ctx context.Context, sessionID, messageID string) (*types.AnswerFeedback, error)
+	// ListCurationQueue lists the feedback on answers retrieved from a knowledge base, newest first
+	ListCurationQueue(ctx context.Context, knowledgeBaseID string,
+		filter *types.AnswerFeedbackFilter) (*types.PageResult, error)
+	// UpdateFeedbackStatus changes the curation status of a feedback of a knowledge base
+	UpdateFeedbackStatus(ctx context.Context, knowledgeBaseID, id string,
+		req *types.UpdateAnswerFeedbackRequest) (*types.AnswerFeedback, error)
+}
+
+// AnswerFeedbackRepository stores answer feedback
+type AnswerFeedbackRepository interface {
+	// ListByMessage returns the feedback rows of a message, one per knowledge base
+	ListByMessage(ctx context.Context, messageID string) ([]*types.AnswerFeedback, error)
+	// SaveMessageFeedback replaces the feedback rows of a message
+	SaveMessageFeedback(ctx context.Context, messageID string, feedback []*types.AnswerFeedback) error
+	// Get returns a feedback of a knowledge base
+	Get(ctx context.Context, knowledgeBaseID, id string) (*types.AnswerFeedback, error)
+	// Update saves a feedback
+	Update(ctx context.Context, feedback *types.AnswerFeedback) error
+	// List returns a page of the feedback of a knowledge base, newest first
+	List(ctx context.Context, knowledgeBaseID string,
+		filter *types.AnswerFeedbackFilter) ([]*types.AnswerFeedback, int64, error)
+}
@@ -0,0 +1,46 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// APIKeyService manages the scoped API keys of tenants
+type APIKeyService interface {
+	// CreateAPIKey creates a scoped API key for the tenant in the context
+	CreateAPIKey(ctx context.Context, req *types.CreateAPIKeyRequest) (*types.APIKeyWithSecret, error)
+	// ListAPIKeys lists the scoped API keys of the tenant in the context
+	ListAPIKeys(ctx context.Context) ([]*types.APIKey, error)
+	// UpdateAPIKey updates the name, knowledge base restrictions or expiry of a key
+	UpdateAPIKey(ctx context.Context, id string, req *types.UpdateAPIKeyRequest) (*types.APIKey, error)
+	// RotateAPIKey replaces the key of a scoped API key, the previous key stops working immediately
+	RotateAPIKey(ctx context.Context, id string) (*types.APIKeyWithSecret, error)
+	// RevokeAPIKey deletes a scoped API key
+	RevokeAPIKey(ctx context.Context, id string) error
+	// Authenticate returns the scoped API key of a tenant matching the key, if it has not expired,
+	// and records its use
+	Authenticate(ctx context.Context, tenantID uint64, key string) (*types.APIKey, error)
+}
+
+// APIKeyRepository stores scoped API keys
+type APIKeyRepository interface {
+	// CreateAPIKey creates a key
+	CreateAPIKey(ctx context.Context, key *types.APIKey) error
+	// GetAPIKeyByID gets a key of a tenant by ID
+	GetAPIKeyByID(ctx context.Context, tenantID uint64, id string) (*types.APIKey, error)
+	// GetAPIKeyByHash gets a key by the hash of its secret
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*types.APIKey, error)
+	// ListAPIKeys lists the keys of a tenant
+	ListAPIKeys(ctx context.Context, tenantID uint64) ([]*types.APIKey, error)
+	// UpdateAPIKey updates a key
+	UpdateAPIKey(ctx context.Context, key *types.APIKey) error
+	// TouchAPIKey records the last time a key was used
+	TouchAPIKey(ctx context.Context, id string) error
+	// DeleteAPIKey deletes a key of a tenant
+	DeleteAPIKey(ctx context.Context, tenantID uint64, id string) error
+	// ListAPIKeysByServiceAccount lists the keys of a service account
+	ListAPIKeysByServiceAccount(ctx context.Context, tenantID uint64, serviceAccountID string) ([]*types.APIKey, error)
+	// DeleteAPIKeysByServiceAccount deletes the keys of a service account
+	DeleteAPIKeysByServiceAccount(ctx context.Context, tenantID uint64, serviceAccountID string) error
+}
@@ -54,6 +54,13 @@ type ChunkRepository interface {
 	DeleteChunks(ctx context.Context, tenantID uint64, ids []string) error
 	// DeleteChunksByKnowledgeID deletes chunks by knowledge id
 	DeleteChunksByKnowledgeID(ctx context.Context, tenantID uint64, knowledgeID string) error
+	// UpdateChunkEnabledByKnowledgeID sets is_enabled for all chunks of a knowledge.
+	// Returns the IDs of chunks whose status changed.
+	UpdateChunkEnabledByKnowledgeID(ctx context.Context, tenantID uint64, knowledgeID string, isEnabled bool) ([]string, error)
+	// UpdateChunkEnabledByIDs sets is_enabled for the chunks of a knowledge among ids.
+	// Returns the IDs of chunks whose status changed.
+	UpdateChunkEnabledByIDs(ctx context.Context,
+		tenantID uint64, knowledgeID string, ids []string, isEnabled bool) ([]string, error)
 	// DeleteByKnowledgeList deletes all chunks for a knowledge list
 	DeleteByKnowledgeList(ctx context.Context, tenantID uint64, knowledgeIDs []string) error
 	// DeleteChunksByTagID deletes all chunks with the specified tag ID
@@ -120,4 +127,10 @@ type ChunkService interface {
 	// DeleteGeneratedQuestion deletes a single generated question from a chunk by question ID
 	// This updates the chunk metadata and removes the corresponding vector index
 	DeleteGeneratedQuestion(ctx context.Context, chunkID string, questionID string) error
+	// UpdateChunkContent replaces the chunk text and re-embeds only that chunk
+	UpdateChunkContent(ctx context.Context, chunk *types.Chunk, content string) error
+	// SetChunkEnabled enables or disables a chunk for retrieval
+	SetChunkEnabled(ctx context.Context, chunk *types.Chunk, enabled bool) error
+	// SetChunkPinned pins or unpins a chunk for ranking boost
+	SetChunkPinned(ctx context.Context, chunk *types.Chunk, pinned bool) error
 }
@@ -0,0 +1,52 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// ExperimentService manages A/B experiments of knowledge base chat configurations
+type ExperimentService interface {
+	// CreateExperiment creates and starts an experiment, a knowledge base runs at most one at a time
+	CreateExperiment(ctx context.Context, experiment *types.KBExperiment) (*types.KBExperiment, error)
+	// GetExperiment returns an experiment of a knowledge base
+	GetExperiment(ctx context.Context, knowledgeBaseID, id string) (*types.KBExperiment, error)
+	// ListExperiments lists the experiments of a knowledge base, newest first
+	ListExperiments(ctx context.Context, knowledgeBaseID string) ([]*types.KBExperiment, error)
+	// UpdateExperiment updates the name, description, traffic split and promotion settings of an experiment
+	UpdateExperiment(ctx context.Context, experiment *types.KBExperiment) (*types.KBExperiment, error)
+	// DeleteExperiment deletes an experiment, its search logs are kept
+	DeleteExperiment(ctx context.Context, knowledgeBaseID, id string) error
+	// StopExperiment stops routing chat traffic to an experiment
+	StopExperiment(ctx context.Context, knowledgeBaseID, id string) (*types.KBExperiment, error)
+	// PromoteExperiment routes all chat traffic of the knowledge base to a variant
+	PromoteExperiment(ctx context.Context, knowledgeBaseID, id string,
+		variant types.ExperimentVariant) (*types.KBExperiment, error)
+	// GetExperimentResults compares the variants of an experiment
+	GetExperimentResults(ctx context.Context, knowledgeBaseID, id string) (*types.ExperimentResults, error)
+	// AssignVariant returns the variant of the live experiment of a knowledge base a chat session
+	// is routed to and its configuration, both nil when the knowledge base has no live experiment.
+	// Promoted experiments only return the winner's configuration.
+	AssignVariant(ctx context.Context, knowledgeBaseID, sessionID string) (
+		*types.ExperimentAssignment, *types.ExperimentVariantConfig, error)
+	// ProcessExperimentPromotion promotes the winners of running experiments with automatic promotion
+	ProcessExperimentPromotion(ctx context.Context, t *asynq.Task) error
+}
+
+// ExperimentRepository stores knowledge base experiments
+type ExperimentRepository interface {
+	CreateExperiment(ctx context.Context, experiment *types.KBExperiment) error
+	GetExperiment(ctx context.Context, tenantID uint64, id string) (*types.KBExperiment, error)
+	ListExperiments(ctx context.Context, tenantID uint64, knowledgeBaseID string) ([]*types.KBExperiment, error)
+	UpdateExperiment(ctx context.Context, experiment *types.KBExperiment) error
+	DeleteExperiment(ctx context.Context, tenantID uint64, id string) error
+	// GetLiveExperiment returns the running experiment of a knowledge base, or else its latest
+	// promoted one, nil when there is neither
+	GetLiveExperiment(ctx context.Context, knowledgeBaseID string) (*types.KBExperiment, error)
+	// ListRunningExperiments lists the running experiments of all tenants
+	ListRunningExperiments(ctx context.Context) ([]*types.KBExperiment, error)
+	// PromoteExperiment saves a promoted experiment and stops the other promoted experiments of its knowledge base
+	PromoteExperiment(ctx context.Context, experiment *types.KBExperiment) error
+}
@@ -21,3 +21,9 @@ type FileService interface {
 	// DeleteFile deletes a file.
 	DeleteFile(ctx context.Context, filePath string) error
 }
+
+// FileStreamSaver is implemented by file services that store a file from a reader without holding it in memory
+type FileStreamSaver interface {
+	// SaveReader saves the size bytes read from r and returns the file path, like SaveBytes with temp false.
+	SaveReader(ctx context.Context, r io.Reader, size int64, tenantID uint64, fileName string) (string, error)
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// HealthChecker is implemented by clients of backends that can be probed, such as vector stores
+type HealthChecker interface {
+	// Ping checks the backend answers
+	Ping(ctx context.Context) error
+}
+
+// HealthService probes the dependencies of the service for the health and readiness endpoints
+type HealthService interface {
+	// Check probes the dependencies, results are reused for a few seconds
+	Check(ctx context.Context) *types.HealthReport
+	// SetShuttingDown marks the service as not ready, so orchestrators stop routing to it while it drains
+	SetShuttingDown()
+}
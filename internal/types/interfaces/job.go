@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// TaskEnqueuer puts tasks on the background queue. The container provides one that records the tasks
+// of tenants as jobs, services enqueue through it rather than through the asynq client directly.
+type TaskEnqueuer interface {
+	// Enqueue enqueues a task, with options as for asynq.Client.Enqueue
+	Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+}
+
+// JobService tracks the background jobs of tenants
+type JobService interface {
+	// ListJobs lists the jobs of the tenant in the context, newest first
+	ListJobs(ctx context.Context, filter *types.JobFilter, page *types.Pagination) (*types.PageResult, error)
+	// GetJob gets a job of the tenant in the context
+	GetJob(ctx context.Context, id string) (*types.Job, error)
+	// CancelJob cancels a job of the tenant in the context that has not finished
+	CancelJob(ctx context.Context, id string) (*types.Job, error)
+	// Track is task middleware recording when jobs start, retry and finish, and skipping cancelled jobs
+	Track(next asynq.Handler) asynq.Handler
+	// ProcessJobHistoryPurge handles the periodic purge of jobs finished longer than the retention window
+	ProcessJobHistoryPurge(ctx context.Context, t *asynq.Task) error
+}
+
+// JobRepository stores background jobs
+type JobRepository interface {
+	// CreateJob records a queued job, jobs already recorded are kept
+	CreateJob(ctx context.Context, job *types.Job) error
+	// SaveJobState saves the status, attempts, error and timestamps of a job, recording it if needed
+	SaveJobState(ctx context.Context, job *types.Job) error
+	// GetJob gets a job of a tenant by ID
+	GetJob(ctx context.Context, tenantID uint64, id string) (*types.Job, error)
+	// GetJobByID gets a job of any tenant by ID
+	GetJobByID(ctx context.Context, id string) (*types.Job, error)
+	// ListJobs lists the jobs of a tenant matching a filter
+	ListJobs(ctx context.Context,
+		tenantID uint64, filter *types.JobFilter, page *types.Pagination) ([]*types.Job, int64, error)
+	// DeleteFinishedJobs deletes the jobs that finished before a time and returns how many were deleted
+	DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,84 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KBPermissionService resolves and manages the roles of users on knowledge bases and their documents
+type KBPermissionService interface {
+	// ResolveKBRole returns the role of a user on a knowledge base and the tenant the knowledge base data
+	// is read with; an empty role means no access. A role granted on the knowledge base takes precedence
+	// over the owner role of its tenant members and over organization shares.
+	ResolveKBRole(ctx context.Context, kb *types.KnowledgeBase, tenantID uint64, userID string) (types.KBRole, uint64, error)
+	// ResolveKnowledgeRole applies the document permission and authorship of a user to its role on the knowledge base
+	ResolveKnowledgeRole(ctx context.Context, knowledge *types.Knowledge,
+		kbRole types.KBRole, userID string) (types.KBRole, error)
+
+	// ListMembers lists the roles granted on a knowledge base
+	ListMembers(ctx context.Context, kbID string) ([]*types.KBMember, error)
+	// SetMemberRole grants a role on a knowledge base to a user
+	SetMemberRole(ctx context.Context, kbID string, userID string, role types.KBRole) (*types.KBMember, error)
+	// RemoveMember revokes the role granted to a user, who falls back to its tenant or share role
+	RemoveMember(ctx context.Context, kbID string, userID string) error
+
+	// ListKnowledgePermissions lists the permissions set on a document
+	ListKnowledgePermissions(ctx context.Context, knowledgeID string) ([]*types.KnowledgePermission, error)
+	// SetKnowledgePermission overrides the role of a user on a document
+	SetKnowledgePermission(ctx context.Context, knowledge *types.Knowledge,
+		userID string, role types.KBRole) (*types.KnowledgePermission, error)
+	// RemoveKnowledgePermission removes the document permission of a user
+	RemoveKnowledgePermission(ctx context.Context, knowledgeID string, userID string) error
+	// ListHiddenKnowledgeIDs returns the documents of a knowledge base hidden from a user
+	ListHiddenKnowledgeIDs(ctx context.Context, kbID string, userID string) ([]string, error)
+
+	// ListGrantedKnowledgeBases lists the knowledge bases of other tenants a user was granted a role on,
+	// directly or by accepting an invite
+	ListGrantedKnowledgeBases(ctx context.Context, userID string, currentTenantID uint64) ([]*types.SharedKnowledgeBaseInfo, error)
+
+	// CreateInvite creates an expiring invite link granting a role on a knowledge base
+	CreateInvite(ctx context.Context, kbID string, req *types.CreateKBInviteRequest) (*types.KBInviteLink, error)
+	// ListInvites lists the invites of a knowledge base that have not been revoked
+	ListInvites(ctx context.Context, kbID string) ([]*types.KBInvite, error)
+	// RevokeInvite revokes an invite, the roles already granted through it are kept
+	RevokeInvite(ctx context.Context, kbID string, id string) error
+	// AcceptInvite grants the role of an invite link to the user in ctx and returns the resulting role
+	AcceptInvite(ctx context.Context, token string) (*types.KBMember, error)
+}
+
+// KBPermissionRepository stores roles granted on knowledge bases and document permissions
+type KBPermissionRepository interface {
+	// GetMember returns the role granted to a user on a knowledge base
+	GetMember(ctx context.Context, kbID string, userID string) (*types.KBMember, error)
+	// ListMembers lists the roles granted on a knowledge base with their users
+	ListMembers(ctx context.Context, kbID string) ([]*types.KBMember, error)
+	// SaveMember creates or updates the role granted to a user on a knowledge base
+	SaveMember(ctx context.Context, member *types.KBMember) error
+	// ListMembershipsByUser lists the roles granted to a user on knowledge bases
+	ListMembershipsByUser(ctx context.Context, userID string) ([]*types.KBMember, error)
+	// DeleteMember removes the role granted to a user on a knowledge base
+	DeleteMember(ctx context.Context, kbID string, userID string) error
+
+	// GetKnowledgePermission returns the permission of a user on a document
+	GetKnowledgePermission(ctx context.Context, knowledgeID string, userID string) (*types.KnowledgePermission, error)
+	// ListKnowledgePermissions lists the permissions set on a document with their users
+	ListKnowledgePermissions(ctx context.Context, knowledgeID string) ([]*types.KnowledgePermission, error)
+	// SaveKnowledgePermission creates or updates the permission of a user on a document
+	SaveKnowledgePermission(ctx context.Context, permission *types.KnowledgePermission) error
+	// DeleteKnowledgePermission removes the permission of a user on a document
+	DeleteKnowledgePermission(ctx context.Context, knowledgeID string, userID string) error
+	// ListHiddenKnowledgeIDs returns the documents of a knowledge base hidden from a user
+	ListHiddenKnowledgeIDs(ctx context.Context, kbID string, userID string) ([]string, error)
+
+	// CreateInvite creates an invite link to a knowledge base
+	CreateInvite(ctx context.Context, invite *types.KBInvite) error
+	// GetInvite returns an invite that has not been revoked
+	GetInvite(ctx context.Context, id string) (*types.KBInvite, error)
+	// ListInvites lists the invites of a knowledge base that have not been revoked
+	ListInvites(ctx context.Context, kbID string) ([]*types.KBInvite, error)
+	// DeleteInvite revokes an invite of a knowledge base
+	DeleteInvite(ctx context.Context, kbID string, id string) error
+	// UseInvite counts one use of an invite, failing when it has no uses left
+	UseInvite(ctx context.Context, id string) error
+}
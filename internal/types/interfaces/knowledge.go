@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"mime/multipart"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/hibiken/asynq"
@@ -24,6 +25,7 @@ type KnowledgeService interface {
 	) (*types.Knowledge, error)
 	// CreateKnowledgeFromURL creates knowledge from a URL.
 	// tagID is optional - when provided, the knowledge will be assigned to the specified tag/category.
+	// extractionMode is optional - when provided, it overrides the web extraction mode of the knowledge base.
 	CreateKnowledgeFromURL(
 		ctx context.Context,
 		kbID string,
@@ -31,7 +33,29 @@ type KnowledgeService interface {
 		enableMultimodel *bool,
 		title string,
 		tagID string,
+		extractionMode string,
 	) (*types.Knowledge, error)
+	// CreateKnowledgeFromFetchedURL downloads the document file served at a URL and imports it as a file.
+	CreateKnowledgeFromFetchedURL(
+		ctx context.Context,
+		kbID string,
+		fileURL string,
+		enableMultimodel *bool,
+		title string,
+		tagID string,
+	) (*types.Knowledge, error)
+	// ImportEnex imports an Evernote export, one knowledge per note, tagged with the notebook and the note tags.
+	ImportEnex(
+		ctx context.Context,
+		kbID string,
+		file *multipart.FileHeader,
+		metadata map[string]string,
+		enableMultimodel *bool,
+		notebook string,
+		tagID string,
+	) (*types.EnexImportResult, error)
+	// AnalyzeURLs tells for each URL of a list whether it serves a web page or a document file to import.
+	AnalyzeURLs(ctx context.Context, urls []string) []*types.AnalyzeURLResult
 	// CreateKnowledgeFromPassage creates knowledge from text passages.
 	CreateKnowledgeFromPassage(ctx context.Context, kbID string, passage []string) (*types.Knowledge, error)
 	// CreateKnowledgeFromPassageSync creates knowledge from text passages and waits until chunks are indexed.
@@ -64,6 +88,16 @@ type KnowledgeService interface {
 		keyword string,
 		fileType string,
 	) (*types.PageResult, error)
+	// ListKnowledgePageByKnowledgeBaseID lists a page of the knowledge under a knowledge base by page number or
+	// cursor, sorted by the field of the query. Filters are those of ListPagedKnowledgeByKnowledgeBaseID.
+	ListKnowledgePageByKnowledgeBaseID(
+		ctx context.Context,
+		kbID string,
+		query *types.ListQuery,
+		tagID string,
+		keyword string,
+		fileType string,
+	) (*types.CursorPageResult, error)
 	// DeleteKnowledge deletes knowledge by ID.
 	DeleteKnowledge(ctx context.Context, id string) error
 	// DeleteKnowledgeList deletes multiple knowledge entries by IDs.
@@ -80,6 +114,12 @@ type KnowledgeService interface {
 	) (*types.Knowledge, error)
 	// ReparseKnowledge deletes existing document content and re-parses the knowledge asynchronously.
 	ReparseKnowledge(ctx context.Context, knowledgeID string) (*types.Knowledge, error)
+	// PreviewKnowledgeRecapture captures the page of a URL knowledge again and diffs it against the current content.
+	// extractionMode is optional - when provided, it replaces the web extraction mode of the last capture.
+	PreviewKnowledgeRecapture(ctx context.Context,
+		knowledgeID string, extractionMode string) (*types.KnowledgeRecapturePreview, error)
+	// ConfirmKnowledgeRecapture replaces the content of a URL knowledge with a previewed capture.
+	ConfirmKnowledgeRecapture(ctx context.Context, knowledgeID string, previewID string) (*types.Knowledge, error)
 	// CloneKnowledgeBase clones knowledge to another knowledge base.
 	CloneKnowledgeBase(ctx context.Context, srcID, dstID string) error
 	// UpdateImageInfo updates image information for a knowledge chunk.
@@ -120,6 +160,48 @@ type KnowledgeService interface {
 	ExportFAQEntries(ctx context.Context, kbID string) ([]byte, error)
 	// UpdateKnowledgeTagBatch updates tag for document knowledge items in batch.
 	UpdateKnowledgeTagBatch(ctx context.Context, updates map[string]*string) error
+	// SetKnowledgeTags replaces all tags of a knowledge entry; the first tag becomes the primary tag.
+	SetKnowledgeTags(ctx context.Context, knowledgeID string, tagIDs []string) (*types.Knowledge, error)
+	// SetKnowledgeExpiry sets (or clears with nil) the explicit expiration time of a knowledge entry.
+	SetKnowledgeExpiry(ctx context.Context, id string, expiresAt *time.Time) (*types.Knowledge, error)
+	// ArchiveKnowledge archives a knowledge entry, excluding it from retrieval.
+	ArchiveKnowledge(ctx context.Context, id string) (*types.Knowledge, error)
+	// UnarchiveKnowledge restores an archived knowledge entry to retrieval.
+	UnarchiveKnowledge(ctx context.Context, id string) (*types.Knowledge, error)
+	// ProcessKnowledgeLifecycle handles the periodic retention policy enforcement task
+	ProcessKnowledgeLifecycle(ctx context.Context, t *asynq.Task) error
+	// ListDeadLetterKnowledge lists knowledge whose parsing failed after all automatic retries.
+	ListDeadLetterKnowledge(ctx context.Context, kbID string, page *types.Pagination) (*types.PageResult, error)
+	// GetKnowledgeParseProgress returns the stage-level parse progress of a knowledge entry.
+	GetKnowledgeParseProgress(ctx context.Context, id string) (*types.KnowledgeParseProgress, error)
+	// CopyKnowledgeToKB copies a knowledge entry to another knowledge base of the same tenant.
+	CopyKnowledgeToKB(ctx context.Context, id string, req *types.KnowledgeTransferRequest) (*types.Knowledge, error)
+	// MoveKnowledgeToKB moves a knowledge entry to another knowledge base of the same tenant.
+	MoveKnowledgeToKB(ctx context.Context, id string, req *types.KnowledgeTransferRequest) (*types.Knowledge, error)
+	// TrashKnowledge moves a knowledge entry to trash, excluding it from listing and retrieval.
+	TrashKnowledge(ctx context.Context, id string) error
+	// RestoreKnowledge restores a trashed knowledge entry.
+	RestoreKnowledge(ctx context.Context, id string) (*types.Knowledge, error)
+	// ListDuplicateClusters lists the duplicate clusters of a knowledge base.
+	ListDuplicateClusters(ctx context.Context, kbID string) ([]*types.DuplicateCluster, error)
+	// DedupKnowledge moves the detected duplicates of a knowledge base to trash, keeping the originals.
+	// When knowledgeIDs is non-empty only those duplicates are handled. Returns the number trashed.
+	DedupKnowledge(ctx context.Context, kbID string, knowledgeIDs []string) (int, error)
+	// ListTrashedKnowledge lists trashed knowledge of a knowledge base.
+	ListTrashedKnowledge(ctx context.Context, kbID string, page *types.Pagination) (*types.PageResult, error)
+	// ProcessKnowledgeTrashPurge handles the periodic purge of knowledge trashed longer than the retention window
+	ProcessKnowledgeTrashPurge(ctx context.Context, t *asynq.Task) error
+	// ProcessKnowledgeLinkCheck handles the periodic check of the source URLs of knowledge imported from the web
+	ProcessKnowledgeLinkCheck(ctx context.Context, t *asynq.Task) error
+	// GetKnowledgeLinkReport counts the knowledge of a knowledge base imported from the web by the status of their
+	// source and lists those with the given statuses.
+	GetKnowledgeLinkReport(ctx context.Context, kbID string,
+		statuses []types.KnowledgeLinkStatus) (*types.KnowledgeLinkReport, error)
+	// PreviewImageUnderstanding runs the VLM settings of a knowledge base on one image without creating knowledge.
+	PreviewImageUnderstanding(ctx context.Context,
+		kb *types.KnowledgeBase, image []byte, fileName string, prompt string) (*types.VLMPreviewResult, error)
+	// RetagKnowledgeBatch moves (replace) or adds/removes tags for multiple knowledge entries.
+	RetagKnowledgeBatch(ctx context.Context, req *types.KnowledgeRetagRequest) error
 	// UpdateFAQEntryTagBatch updates tag for FAQ entries in batch.
 	// Key: entry seq_id, Value: tag seq_id (nil to remove tag)
 	UpdateFAQEntryTagBatch(ctx context.Context, kbID string, updates map[int64]*int64) error
@@ -141,6 +223,40 @@ type KnowledgeService interface {
 	GetKBCloneProgress(ctx context.Context, taskID string) (*types.KBCloneProgress, error)
 	// SaveKBCloneProgress saves the progress of a knowledge base clone task
 	SaveKBCloneProgress(ctx context.Context, progress *types.KBCloneProgress) error
+	// StartKBEmbeddingReindex enqueues a full re-index of a knowledge base with another embedding model
+	StartKBEmbeddingReindex(
+		ctx context.Context, kb *types.KnowledgeBase, req *types.KBReindexRequest,
+	) (*types.KBReindexProgress, error)
+	// ProcessKBEmbeddingReindex handles Asynq knowledge base embedding re-index tasks
+	ProcessKBEmbeddingReindex(ctx context.Context, t *asynq.Task) error
+	// GetKBReindexProgress retrieves the progress of a knowledge base re-index task
+	GetKBReindexProgress(ctx context.Context, taskID string) (*types.KBReindexProgress, error)
+	// StartKnowledgeBulkOperation enqueues a bulk operation on the knowledge of a knowledge base for a caller with
+	// the given role on it
+	StartKnowledgeBulkOperation(
+		ctx context.Context, kb *types.KnowledgeBase, role types.KBRole, req *types.KnowledgeBulkRequest,
+	) (*types.KnowledgeBulkProgress, error)
+	// ProcessKnowledgeBulkOperation handles Asynq knowledge bulk operation tasks
+	ProcessKnowledgeBulkOperation(ctx context.Context, t *asynq.Task) error
+	// GetKnowledgeBulkProgress retrieves the progress of a knowledge bulk operation task
+	GetKnowledgeBulkProgress(ctx context.Context, taskID string) (*types.KnowledgeBulkProgress, error)
+	// ExportKnowledgeBase writes a document knowledge base as a zip bundle
+	ExportKnowledgeBase(ctx context.Context, kbID string, includeEmbeddings bool, w io.Writer) error
+	// StartKBImport creates a knowledge base from a bundle and enqueues the import of its content
+	StartKBImport(
+		ctx context.Context, file *multipart.FileHeader, req *types.KBImportRequest,
+	) (*types.KnowledgeBase, *types.KBImportProgress, error)
+	// ProcessKBImport handles Asynq knowledge base import tasks
+	ProcessKBImport(ctx context.Context, t *asynq.Task) error
+	// GetKBImportProgress retrieves the progress of a knowledge base import task
+	GetKBImportProgress(ctx context.Context, taskID string) (*types.KBImportProgress, error)
+	// ExportKnowledgeVectors returns the vectors of a knowledge keyed by source ID, for tenant backups
+	ExportKnowledgeVectors(ctx context.Context, knowledge *types.Knowledge) (map[string][]float32, error)
+	// RestoreKnowledgeVectors indexes the chunks of a restored knowledge, reusing the vectors of the backup.
+	// It returns how many vectors were reused.
+	RestoreKnowledgeVectors(
+		ctx context.Context, knowledge *types.Knowledge, vectors map[string][]float32,
+	) (int, error)
 	// GetFAQImportProgress retrieves the progress of an FAQ import task
 	GetFAQImportProgress(ctx context.Context, taskID string) (*types.FAQImportProgress, error)
 	// UpdateLastFAQImportResultDisplayStatus updates the display status of FAQ import result
@@ -166,6 +282,16 @@ type KnowledgeRepository interface {
 	ListPagedKnowledgeByKnowledgeBaseID(ctx context.Context,
 		tenantID uint64, kbID string, page *types.Pagination, tagID string, keyword string, fileType string,
 	) ([]*types.Knowledge, int64, error)
+	// ListKnowledgePageByKnowledgeBaseID lists a page of the knowledge in a knowledge base sorted by the field of the
+	// query, with one more row than the limit when more follow. The total is nil when the query does not count it.
+	ListKnowledgePageByKnowledgeBaseID(ctx context.Context,
+		tenantID uint64, kbID string, query *types.ListQuery, tagID string, keyword string, fileType string,
+	) ([]*types.Knowledge, *int64, error)
+	// ListKnowledgeIDsByFilter lists the IDs of the knowledge in a knowledge base matched by a bulk filter, oldest
+	// first, up to limit.
+	ListKnowledgeIDsByFilter(ctx context.Context,
+		tenantID uint64, kbID string, filter *types.KnowledgeBulkFilter, limit int,
+	) ([]string, error)
 	UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error
 	// UpdateKnowledgeBatch updates knowledge items in batch
 	UpdateKnowledgeBatch(ctx context.Context, knowledgeList []*types.Knowledge) error
@@ -189,6 +315,9 @@ type KnowledgeRepository interface {
 	CountKnowledgeByKnowledgeBaseID(ctx context.Context, tenantID uint64, kbID string) (int64, error)
 	// CountKnowledgeByStatus counts the number of knowledge items with the specified parse status.
 	CountKnowledgeByStatus(ctx context.Context, tenantID uint64, kbID string, parseStatuses []string) (int64, error)
+	// CountKnowledgeByTenant counts the knowledge items of a tenant across knowledge bases,
+	// optionally only those of a type and with one of the parse statuses.
+	CountKnowledgeByTenant(ctx context.Context, tenantID uint64, knowledgeType string, parseStatuses []string) (int64, error)
 	// SearchKnowledge searches knowledge items by keyword across the tenant.
 	// fileTypes: optional list of file extensions to filter by (e.g., ["csv", "xlsx"])
 	SearchKnowledge(ctx context.Context, tenantID uint64, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, error)
@@ -196,4 +325,57 @@ type KnowledgeRepository interface {
 	SearchKnowledgeInScopes(ctx context.Context, scopes []types.KnowledgeSearchScope, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, error)
 	// ListIDsByTagID returns all knowledge IDs that have the specified tag ID.
 	ListIDsByTagID(ctx context.Context, tenantID uint64, kbID, tagID string) ([]string, error)
+	// ListKnowledgeBaseIDsWithExpiry returns IDs of knowledge bases having unarchived knowledge with expires_at set.
+	ListKnowledgeBaseIDsWithExpiry(ctx context.Context) ([]string, error)
+	// ListDueKnowledge lists unarchived knowledge due for expiry, see the repository for details.
+	ListDueKnowledge(
+		ctx context.Context,
+		kbID string,
+		dueBefore time.Time,
+		createdBefore *time.Time,
+		unnotifiedOnly bool,
+		limit int,
+	) ([]*types.Knowledge, error)
+	// ListDeadLetterKnowledge lists knowledge whose parsing failed permanently. kbID is optional.
+	ListDeadLetterKnowledge(
+		ctx context.Context,
+		tenantID uint64,
+		kbID string,
+		page *types.Pagination,
+	) ([]*types.Knowledge, int64, error)
+	// ListTrashedKnowledge lists trashed knowledge in a knowledge base with pagination.
+	ListTrashedKnowledge(
+		ctx context.Context,
+		tenantID uint64,
+		kbID string,
+		page *types.Pagination,
+	) ([]*types.Knowledge, int64, error)
+	// ListTrashedBefore lists knowledge of all tenants trashed before the given time.
+	ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*types.Knowledge, error)
+	// ListFingerprintedKnowledge lists content fingerprints of live knowledge in a knowledge base, oldest first.
+	ListFingerprintedKnowledge(ctx context.Context, tenantID uint64, kbID string, excludeID string) ([]*types.Knowledge, error)
+	// ListDuplicateKnowledge lists live knowledge in a knowledge base detected as a duplicate.
+	ListDuplicateKnowledge(ctx context.Context, tenantID uint64, kbID string) ([]*types.Knowledge, error)
+	// ListKnowledgeBaseIDsWithSourceURL lists the knowledge bases of all tenants holding knowledge imported from a URL.
+	ListKnowledgeBaseIDsWithSourceURL(ctx context.Context) ([]string, error)
+	// ListLinkCheckDueKnowledge lists knowledge imported from a URL whose source was not checked since checkedBefore.
+	ListLinkCheckDueKnowledge(ctx context.Context, kbID string, checkedBefore time.Time, limit int) ([]*types.Knowledge, error)
+	// UpdateKnowledgeLinkCheck records the result of a check of the source URL of a knowledge.
+	UpdateKnowledgeLinkCheck(ctx context.Context, id string, check *types.KnowledgeLinkCheck) error
+	// CountKnowledgeByLinkStatus counts knowledge imported from a URL by the status of their source.
+	CountKnowledgeByLinkStatus(ctx context.Context, tenantID uint64, kbID string) (map[types.KnowledgeLinkStatus]int64, error)
+	// ListKnowledgeByLinkStatus lists knowledge imported from a URL whose source had one of the statuses.
+	ListKnowledgeByLinkStatus(
+		ctx context.Context,
+		tenantID uint64,
+		kbID string,
+		statuses []types.KnowledgeLinkStatus,
+		limit int,
+	) ([]*types.Knowledge, error)
+	// ListIDsByTagTree returns knowledge IDs tagged with any of the given tags or their descendants,
+	// including tags assigned through knowledge_tag_relations.
+	ListIDsByTagTree(ctx context.Context, tenantID uint64, kbID string, tagIDs []string) ([]string, error)
+	// ListIDsByFilter returns knowledge IDs matching the file type, date, metadata and source domain
+	// conditions of a search filter. Tag conditions are not applied.
+	ListIDsByFilter(ctx context.Context, tenantID uint64, kbID string, filter *types.SearchFilter) ([]string, error)
 }
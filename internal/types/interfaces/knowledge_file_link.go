@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeFileLinkService mints and serves signed links to knowledge files. The methods taking a knowledge ID
+// expect the context to carry the tenant of the knowledge, which the caller has checked access to.
+type KnowledgeFileLinkService interface {
+	// CreateLink signs a link to the file of a knowledge
+	CreateLink(ctx context.Context,
+		knowledgeID string, req *types.CreateKnowledgeFileLinkRequest) (*types.KnowledgeFileLinkWithURL, error)
+	// ListLinks lists the links to the file of a knowledge, without their tokens
+	ListLinks(ctx context.Context, knowledgeID string) ([]*types.KnowledgeFileLink, error)
+	// RevokeLink revokes a link to the file of a knowledge before it expires
+	RevokeLink(ctx context.Context, knowledgeID string, id string) error
+	// OpenLink checks a link token, counts the fetch and opens the file, no tenant context is needed
+	OpenLink(ctx context.Context, token string) (*types.KnowledgeFileLink, io.ReadCloser, string, error)
+}
+
+// KnowledgeFileLinkRepository stores the signed links to knowledge files
+type KnowledgeFileLinkRepository interface {
+	// CreateLink creates a link
+	CreateLink(ctx context.Context, link *types.KnowledgeFileLink) error
+	// GetLink gets a link of a tenant by ID
+	GetLink(ctx context.Context, tenantID uint64, id string) (*types.KnowledgeFileLink, error)
+	// ListLinks lists the links to a knowledge of a tenant
+	ListLinks(ctx context.Context, tenantID uint64, knowledgeID string) ([]*types.KnowledgeFileLink, error)
+	// RevokeLink revokes a link to a knowledge of a tenant
+	RevokeLink(ctx context.Context, tenantID uint64, knowledgeID string, id string) error
+	// ConsumeDownload counts a fetch through a link, false when the link is no longer usable
+	ConsumeDownload(ctx context.Context, tenantID uint64, id string) (bool, error)
+}
@@ -90,6 +90,18 @@ type KnowledgeBaseService interface {
 	//   - Possible errors such as not existing, insufficient permissions, search engine errors, etc.
 	HybridSearch(ctx context.Context, id string, params types.SearchParams) ([]*types.SearchResult, error)
 
+	// ImageSearch finds knowledge whose image is visually similar to the query image
+	// Parameters:
+	//   - ctx: Context information
+	//   - id: Unique identifier of the knowledge base
+	//   - image: Content of the query image
+	//   - params: Search parameters, including top K and threshold
+	// Returns:
+	//   - Similar image knowledge, sorted by similarity
+	//   - Possible errors such as image embedding not enabled, embedding model errors, etc.
+	ImageSearch(ctx context.Context, id string,
+		image []byte, params types.ImageSearchParams) ([]*types.ImageSearchResult, error)
+
 	// CopyKnowledgeBase copies a knowledge base
 	// Parameters:
 	//   - ctx: Context information
@@ -115,6 +127,16 @@ type KnowledgeBaseService interface {
 	// Returns:
 	//   - Possible errors during deletion
 	ProcessKBDelete(ctx context.Context, t *asynq.Task) error
+
+	// CheckEmbeddingDimension checks that embeddings of the given dimension fit the vectors already
+	// stored for a knowledge base
+	// Parameters:
+	//   - ctx: Context information
+	//   - kb: Knowledge base to bind the embedding model to
+	//   - dimension: Embedding dimension of the model to bind
+	// Returns:
+	//   - ErrEmbeddingDimensionMismatch when the knowledge base holds vectors of another dimension
+	CheckEmbeddingDimension(ctx context.Context, kb *types.KnowledgeBase, dimension int) error
 }
 
 // KnowledgeBaseRepository defines the knowledge base repository interface
@@ -175,6 +197,15 @@ type KnowledgeBaseRepository interface {
 	//   - Possible errors such as database errors, etc.
 	ListKnowledgeBasesByTenantID(ctx context.Context, tenantID uint64) ([]*types.KnowledgeBase, error)
 
+	// CountKnowledgeBasesByTenantID counts the knowledge bases of a tenant, excluding temporary ones
+	// Parameters:
+	//   - ctx: Context information
+	//   - tenantID: Tenant ID
+	// Returns:
+	//   - Number of knowledge bases
+	//   - Possible errors such as database errors, etc.
+	CountKnowledgeBasesByTenantID(ctx context.Context, tenantID uint64) (int64, error)
+
 	// UpdateKnowledgeBase updates a knowledge base record
 	// Parameters:
 	//   - ctx: Context information
@@ -183,6 +214,16 @@ type KnowledgeBaseRepository interface {
 	//   - Possible errors such as record not existing, database errors, etc.
 	UpdateKnowledgeBase(ctx context.Context, kb *types.KnowledgeBase) error
 
+	// SwitchEmbeddingModel atomically updates the embedding model of a knowledge base and its knowledge
+	// Parameters:
+	//   - ctx: Context information
+	//   - tenantID: Tenant ID
+	//   - kbID: Knowledge base ID
+	//   - modelID: New embedding model ID
+	// Returns:
+	//   - Possible errors such as record not existing, database errors, etc.
+	SwitchEmbeddingModel(ctx context.Context, tenantID uint64, kbID string, modelID string) error
+
 	// DeleteKnowledgeBase deletes a knowledge base record
 	// Parameters:
 	//   - ctx: Context information
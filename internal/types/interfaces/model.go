@@ -5,8 +5,10 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/models/health"
 	"github.com/Tencent/WeKnora/internal/models/rerank"
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
 )
 
 // ModelService defines the model service interface
@@ -29,6 +31,16 @@ type ModelService interface {
 	GetRerankModel(ctx context.Context, modelId string) (rerank.Reranker, error)
 	// GetChatModel gets a chat model
 	GetChatModel(ctx context.Context, modelId string) (chat.Chat, error)
+	// GetModelHealth gets the circuit breaker status of the tenant's models
+	GetModelHealth(ctx context.Context) ([]health.Status, error)
+	// GetEmbeddingStats gets the embedding throughput of the tenant's embedding models
+	GetEmbeddingStats(ctx context.Context) ([]embedding.Stats, error)
+	// GetModelRegistry lists the tenant's models with their capabilities and health
+	GetModelRegistry(ctx context.Context) ([]*types.ModelRegistryEntry, error)
+	// ProbeModel runs a health probe of a model now
+	ProbeModel(ctx context.Context, id string) (*types.ModelProbeResult, error)
+	// ProcessModelHealthProbe probes the active models of all tenants
+	ProcessModelHealthProbe(ctx context.Context, t *asynq.Task) error
 }
 
 // ModelRepository defines the model repository interface
@@ -51,4 +63,8 @@ type ModelRepository interface {
 	// ClearDefaultByType clears the default flag for all models of a specific type
 	// optionally excluding a specific model ID.
 	ClearDefaultByType(ctx context.Context, tenantID uint, modelType types.ModelType, excludeID string) error
+	// ListActive lists the active models of all tenants, used by the health probe task
+	ListActive(ctx context.Context) ([]*types.Model, error)
+	// UpdateProbeResult saves the result of a model's health probe
+	UpdateProbeResult(ctx context.Context, id string, result *types.ModelProbeResult) error
 }
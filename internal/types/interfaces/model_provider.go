@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ModelProviderService talks to the self-hosted Ollama and vLLM servers of tenants
+type ModelProviderService interface {
+	// ResolveEndpoint returns the server to use for a provider, falling back to the tenant's
+	// configured server and then to the provider's default address
+	ResolveEndpoint(ctx context.Context, provider string, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error)
+	// TestConnection checks that a server answers, and that modelName is served when it is set
+	TestConnection(ctx context.Context,
+		provider string, endpoint *types.ProviderEndpoint, modelName string) (*types.ProviderConnectionStatus, error)
+	// ListModels lists the models served by a server
+	ListModels(ctx context.Context, provider string, endpoint *types.ProviderEndpoint) ([]types.ProviderModel, error)
+	// PullModel starts downloading a model on an Ollama server
+	PullModel(ctx context.Context, endpoint *types.ProviderEndpoint, modelName string) (*types.ModelPullTask, error)
+	// GetPullTask gets a model download of the tenant
+	GetPullTask(ctx context.Context, id string) (*types.ModelPullTask, error)
+	// ListPullTasks lists the model downloads of the tenant
+	ListPullTasks(ctx context.Context) ([]*types.ModelPullTask, error)
+}
@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ModerationService applies the tenant's content moderation to questions and answers
+// and keeps the audit trail of moderated events
+type ModerationService interface {
+	// ModerateQuery checks a question, returning it with masked text replaced,
+	// or a content blocked error when a block rule matched
+	ModerateQuery(ctx context.Context, sessionID, query string) (string, error)
+	// GuardAnswerStream moderates the answer chunks emitted on the event bus before its handlers see them
+	GuardAnswerStream(ctx context.Context, sessionID, messageID string, eventBus *event.EventBus)
+	// ListEvents lists the moderated events of the tenant, newest first
+	ListEvents(ctx context.Context, filter *types.ModerationEventFilter) (*types.PageResult, error)
+	// ReviewEvent records the review of a flagged event
+	ReviewEvent(ctx context.Context, id string,
+		req *types.ReviewModerationEventRequest) (*types.ModerationEvent, error)
+}
+
+// ModerationRepository stores moderated events
+type ModerationRepository interface {
+	// Create inserts a moderated event
+	Create(ctx context.Context, moderationEvent *types.ModerationEvent) error
+	// Get returns a moderated event of a tenant
+	Get(ctx context.Context, tenantID uint64, id string) (*types.ModerationEvent, error)
+	// Update saves a moderated event
+	Update(ctx context.Context, moderationEvent *types.ModerationEvent) error
+	// List returns a page of the moderated events of a tenant, newest first
+	List(ctx context.Context, tenantID uint64,
+		filter *types.ModerationEventFilter) ([]*types.ModerationEvent, int64, error)
+}
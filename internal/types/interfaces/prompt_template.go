@@ -0,0 +1,45 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// PromptTemplateService manages the versioned prompt templates answers of knowledge bases are generated with
+type PromptTemplateService interface {
+	// ListTemplates lists the template versions of a knowledge base, newest first
+	ListTemplates(ctx context.Context, knowledgeBaseID string) ([]*types.KBPromptTemplate, error)
+	// GetTemplate returns a template version of a knowledge base
+	GetTemplate(ctx context.Context, knowledgeBaseID, id string) (*types.KBPromptTemplate, error)
+	// CreateTemplate creates a draft as the next version of the knowledge base
+	CreateTemplate(ctx context.Context, knowledgeBaseID string,
+		req *types.PromptTemplateRequest) (*types.KBPromptTemplate, error)
+	// UpdateTemplate edits a draft, published and archived versions are immutable
+	UpdateTemplate(ctx context.Context, knowledgeBaseID, id string,
+		req *types.PromptTemplateRequest) (*types.KBPromptTemplate, error)
+	// DeleteTemplate deletes a draft
+	DeleteTemplate(ctx context.Context, knowledgeBaseID, id string) error
+	// PublishTemplate applies a version to answers of the knowledge base, archiving the published one
+	PublishTemplate(ctx context.Context, knowledgeBaseID, id string) (*types.KBPromptTemplate, error)
+	// UnpublishTemplate archives the published version, answers fall back to the agent's prompt
+	UnpublishTemplate(ctx context.Context, knowledgeBaseID string) error
+	// PreviewTemplate answers sample questions with a template version without publishing it
+	PreviewTemplate(ctx context.Context, knowledgeBaseID, id string,
+		req *types.PromptPreviewRequest) ([]*types.PromptPreviewResult, error)
+}
+
+// PromptTemplateRepository stores knowledge base prompt templates
+type PromptTemplateRepository interface {
+	Create(ctx context.Context, template *types.KBPromptTemplate) error
+	Get(ctx context.Context, tenantID uint64, id string) (*types.KBPromptTemplate, error)
+	List(ctx context.Context, tenantID uint64, knowledgeBaseID string) ([]*types.KBPromptTemplate, error)
+	Update(ctx context.Context, template *types.KBPromptTemplate) error
+	Delete(ctx context.Context, tenantID uint64, id string) error
+	// GetPublished returns the published version of a knowledge base, nil when there is none
+	GetPublished(ctx context.Context, knowledgeBaseID string) (*types.KBPromptTemplate, error)
+	// Publish publishes a version and archives the other published versions of its knowledge base
+	Publish(ctx context.Context, template *types.KBPromptTemplate) error
+	// ArchivePublished archives the published version of a knowledge base
+	ArchivePublished(ctx context.Context, knowledgeBaseID string) error
+}
@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// QuotaService enforces the resource quotas of tenants
+type QuotaService interface {
+	// GetQuotaStatus returns the usage of the tenant in ctx against its quotas
+	GetQuotaStatus(ctx context.Context) (*types.TenantQuotaStatus, error)
+	// CheckKnowledgeBaseQuota returns an error when the tenant cannot create another knowledge base
+	CheckKnowledgeBaseQuota(ctx context.Context) error
+	// CheckKnowledgeQuota returns an error when the tenant cannot add another document, by count or storage
+	CheckKnowledgeQuota(ctx context.Context) error
+	// CheckKnowledgeCountQuota returns an error when the tenant cannot add count more documents, by count or storage
+	CheckKnowledgeCountQuota(ctx context.Context, count int64) error
+	// CheckParseJobQuota returns an error when the tenant cannot start parsing another document of the type,
+	// URL imports are also capped by the concurrent browser sessions
+	CheckParseJobQuota(ctx context.Context, knowledgeType string) error
+}
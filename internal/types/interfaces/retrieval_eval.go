@@ -0,0 +1,46 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// RetrievalEvalService manages golden question sets of knowledge bases and evaluates retrieval against them
+type RetrievalEvalService interface {
+	// CreateEvalSet creates a golden question set
+	CreateEvalSet(ctx context.Context, set *types.RetrievalEvalSet) (*types.RetrievalEvalSet, error)
+	// GetEvalSet returns a golden question set of a knowledge base
+	GetEvalSet(ctx context.Context, knowledgeBaseID, id string) (*types.RetrievalEvalSet, error)
+	// ListEvalSets lists the golden question sets of a knowledge base
+	ListEvalSets(ctx context.Context, knowledgeBaseID string) ([]*types.RetrievalEvalSet, error)
+	// UpdateEvalSet replaces the name, description and cases of a golden question set
+	UpdateEvalSet(ctx context.Context, set *types.RetrievalEvalSet) (*types.RetrievalEvalSet, error)
+	// DeleteEvalSet deletes a golden question set and its runs
+	DeleteEvalSet(ctx context.Context, knowledgeBaseID, id string) error
+	// StartEvalRun enqueues a run of a golden question set, zero config values use the current configuration
+	StartEvalRun(ctx context.Context, knowledgeBaseID, setID string,
+		config *types.RetrievalEvalConfig) (*types.RetrievalEvalRun, error)
+	// GetEvalRun returns a run with its per case results
+	GetEvalRun(ctx context.Context, knowledgeBaseID, runID string) (*types.RetrievalEvalRun, error)
+	// ListEvalRuns lists the runs of a golden question set without per case results, newest first
+	ListEvalRuns(ctx context.Context, knowledgeBaseID, setID string) ([]*types.RetrievalEvalRun, error)
+	// ProcessRetrievalEval handles the asynq task of a run
+	ProcessRetrievalEval(ctx context.Context, t *asynq.Task) error
+}
+
+// RetrievalEvalRepository stores golden question sets and their runs
+type RetrievalEvalRepository interface {
+	CreateEvalSet(ctx context.Context, set *types.RetrievalEvalSet) error
+	GetEvalSet(ctx context.Context, tenantID uint64, id string) (*types.RetrievalEvalSet, error)
+	ListEvalSets(ctx context.Context, tenantID uint64, knowledgeBaseID string) ([]*types.RetrievalEvalSet, error)
+	UpdateEvalSet(ctx context.Context, set *types.RetrievalEvalSet) error
+	// DeleteEvalSet deletes a set together with its runs
+	DeleteEvalSet(ctx context.Context, tenantID uint64, id string) error
+	CreateEvalRun(ctx context.Context, run *types.RetrievalEvalRun) error
+	GetEvalRun(ctx context.Context, tenantID uint64, id string) (*types.RetrievalEvalRun, error)
+	// ListEvalRuns lists runs newest first, without per case results
+	ListEvalRuns(ctx context.Context, tenantID uint64, setID string) ([]*types.RetrievalEvalRun, error)
+	UpdateEvalRun(ctx context.Context, run *types.RetrievalEvalRun) error
+}
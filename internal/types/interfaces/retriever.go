@@ -130,3 +130,19 @@ type RetrieveEngineService interface {
 	// RetrieveEngine retrieves the engine
 	RetrieveEngine
 }
+
+// EmbeddingReader is implemented by retrieve engines that can read stored vectors back.
+// Engines without it cannot export embeddings.
+type EmbeddingReader interface {
+	// GetEmbeddingsByKnowledgeID returns the vectors of a knowledge keyed by source ID
+	GetEmbeddingsByKnowledgeID(ctx context.Context, knowledgeID string) (map[string][]float32, error)
+}
+
+// DimensionDeleter is implemented by retrieve engines that store vectors of different dimensions apart,
+// so the vectors of one embedding model can be dropped while those of another stay searchable.
+type DimensionDeleter interface {
+	// SeparatesDimensions reports whether vectors of different dimensions are stored apart
+	SeparatesDimensions() bool
+	// DeleteByKnowledgeIDListInDimension deletes the index info of the knowledge stored with the given dimension
+	DeleteByKnowledgeIDListInDimension(ctx context.Context, knowledgeIDList []string, dimension int) error
+}
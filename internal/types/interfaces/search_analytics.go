@@ -0,0 +1,38 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SearchAnalyticsService records searches and reports search analytics per knowledge base
+type SearchAnalyticsService interface {
+	// RecordSearch logs a search once per knowledge base it targeted, failures are only logged
+	RecordSearch(ctx context.Context, record *types.SearchRecord)
+	// RecordClick records a click on a citation of the answer to messageID
+	RecordClick(ctx context.Context, knowledgeBaseID, messageID, chunkID string) error
+	// RecordFeedback records the feedback given to the answer to messageID
+	RecordFeedback(ctx context.Context, knowledgeBaseID, messageID, feedback string) error
+	// GetSearchAnalytics reports the searches of the last days, listing up to limit queries per kind
+	GetSearchAnalytics(ctx context.Context, knowledgeBaseID string, days, limit int) (*types.SearchAnalytics, error)
+}
+
+// SearchLogRepository stores search logs
+type SearchLogRepository interface {
+	// CreateSearchLogs inserts search logs
+	CreateSearchLogs(ctx context.Context, logs []*types.SearchLog) error
+	// GetSearchLogByMessage returns the search log of a knowledge base answered by messageID
+	GetSearchLogByMessage(ctx context.Context, knowledgeBaseID, messageID string) (*types.SearchLog, error)
+	// UpdateSearchLog saves a search log
+	UpdateSearchLog(ctx context.Context, log *types.SearchLog) error
+	// GetSearchSummary aggregates the searches of a knowledge base since the given time
+	GetSearchSummary(ctx context.Context, knowledgeBaseID string, since time.Time,
+		lowConfidenceThreshold float64) (*types.SearchSummary, error)
+	// ListQueryStats aggregates the searches of a knowledge base by query, most frequent first
+	ListQueryStats(ctx context.Context, knowledgeBaseID string, since time.Time, kind types.SearchQueryKind,
+		lowConfidenceThreshold float64, limit int) ([]*types.SearchQueryStat, error)
+	// GetExperimentStats aggregates the searches attributed to an experiment by variant
+	GetExperimentStats(ctx context.Context, knowledgeBaseID, experimentID string) ([]*types.ExperimentVariantStats, error)
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SemanticCache stores answers to past questions per knowledge base and finds them by query similarity
+type SemanticCache interface {
+	// Lookup returns the most similar unexpired entry scoring at least threshold, nil when there is none
+	Lookup(ctx context.Context, knowledgeBaseID string, embeddingModelID string,
+		embedding []float32, threshold float64) (*types.SemanticCacheEntry, float64, error)
+	// Store caches an answer for ttl
+	Store(ctx context.Context, knowledgeBaseID string, entry *types.SemanticCacheEntry, ttl time.Duration) error
+	// InvalidateKnowledge drops cached answers built from any of the given knowledge
+	InvalidateKnowledge(ctx context.Context, knowledgeBaseID string, knowledgeIDs []string) error
+	// InvalidateKnowledgeBase drops all cached answers of a knowledge base
+	InvalidateKnowledgeBase(ctx context.Context, knowledgeBaseID string) error
+}
@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ServiceAccountService manages the service accounts of tenants
+type ServiceAccountService interface {
+	// CreateServiceAccount creates a service account for the tenant in the context
+	CreateServiceAccount(ctx context.Context, req *types.CreateServiceAccountRequest) (*types.ServiceAccount, error)
+	// ListServiceAccounts lists the service accounts of the tenant in the context
+	ListServiceAccounts(ctx context.Context) ([]*types.ServiceAccount, error)
+	// GetServiceAccount gets a service account with its API keys
+	GetServiceAccount(ctx context.Context, id string) (*types.ServiceAccount, error)
+	// UpdateServiceAccount updates the name, description, scopes or status of a service account
+	UpdateServiceAccount(ctx context.Context,
+		id string, req *types.UpdateServiceAccountRequest) (*types.ServiceAccount, error)
+	// DeleteServiceAccount deletes a service account and revokes its API keys
+	DeleteServiceAccount(ctx context.Context, id string) error
+}
+
+// ServiceAccountRepository stores service accounts
+type ServiceAccountRepository interface {
+	// CreateServiceAccount creates a service account
+	CreateServiceAccount(ctx context.Context, account *types.ServiceAccount) error
+	// GetServiceAccountByID gets a service account of a tenant by ID
+	GetServiceAccountByID(ctx context.Context, tenantID uint64, id string) (*types.ServiceAccount, error)
+	// ListServiceAccounts lists the service accounts of a tenant
+	ListServiceAccounts(ctx context.Context, tenantID uint64) ([]*types.ServiceAccount, error)
+	// UpdateServiceAccount updates a service account
+	UpdateServiceAccount(ctx context.Context, account *types.ServiceAccount) error
+	// DeleteServiceAccount deletes a service account of a tenant
+	DeleteServiceAccount(ctx context.Context, tenantID uint64, id string) error
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -17,6 +18,9 @@ type SessionService interface {
 	GetSessionsByTenant(ctx context.Context) ([]*types.Session, error)
 	// GetPagedSessionsByTenant gets paged sessions of a tenant
 	GetPagedSessionsByTenant(ctx context.Context, page *types.Pagination) (*types.PageResult, error)
+	// ListSessionPageByTenant lists a page of the sessions of a tenant by page number or cursor, sorted by the field
+	// of the query
+	ListSessionPageByTenant(ctx context.Context, query *types.ListQuery) (*types.CursorPageResult, error)
 	// UpdateSession updates a session
 	UpdateSession(ctx context.Context, session *types.Session) error
 	// DeleteSession deletes a session
@@ -31,21 +35,34 @@ type SessionService interface {
 	// KnowledgeQA performs knowledge-based question answering
 	// knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 	// knowledgeIDs: list of specific knowledge (file) IDs to search
+	// searchFilter: optional structured filter (tags, file type, dates, metadata, source domain)
 	// summaryModelID: optional summary model ID override (if empty, uses session/KB default)
 	// webSearchEnabled: whether to enable web search to supplement knowledge base results
 	// customAgent: optional custom agent for config override (multiTurnEnabled, historyTurns)
 	// Events are emitted through eventBus (references, answer chunks, completion)
 	KnowledgeQA(ctx context.Context,
 		session *types.Session, query string, knowledgeBaseIDs []string, knowledgeIDs []string,
-		assistantMessageID string, summaryModelID string, webSearchEnabled bool, eventBus *event.EventBus,
+		searchFilter *types.SearchFilter, assistantMessageID string, summaryModelID string, webSearchEnabled bool, eventBus *event.EventBus,
 		customAgent *types.CustomAgent,
 	) error
+	// KnowledgeQAWithHistory performs knowledge-based question answering for a conversation kept by the caller,
+	// such as OpenAI-compatible chat completions; history replaces the session's stored messages
+	KnowledgeQAWithHistory(ctx context.Context,
+		session *types.Session, query string, history []*types.History, knowledgeBaseIDs []string,
+		assistantMessageID string, eventBus *event.EventBus, customAgent *types.CustomAgent,
+	) error
 	// KnowledgeQAByEvent performs knowledge-based question answering by event
 	KnowledgeQAByEvent(ctx context.Context, chatManage *types.ChatManage, eventList []types.EventType) error
 	// SearchKnowledge performs knowledge-based search, without summarization
 	// knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 	// knowledgeIDs: list of specific knowledge (file) IDs to search
-	SearchKnowledge(ctx context.Context, knowledgeBaseIDs []string, knowledgeIDs []string, query string) ([]*types.SearchResult, error)
+	// searchFilter: optional structured filter applied to every knowledge base
+	SearchKnowledge(ctx context.Context, knowledgeBaseIDs []string, knowledgeIDs []string,
+		searchFilter *types.SearchFilter, query string) ([]*types.SearchResult, error)
+	// DebugRetrieval runs the retrieval stages for a query against a knowledge base and reports each stage's output
+	// sessionID is optional and enables query rewriting with the session history
+	// modelID is optional and selects the chat model used for rewriting and LLM query expansion
+	DebugRetrieval(ctx context.Context, knowledgeBaseID, query, sessionID, modelID string) (*types.RetrievalDebugResult, error)
 	// AgentQA performs agent-based question answering with conversation history and streaming support
 	// eventBus is optional - if nil, uses service's default EventBus
 	// customAgent is optional - if provided, uses custom agent configuration instead of tenant defaults
@@ -63,6 +80,12 @@ type SessionService interface {
 	) error
 	// ClearContext clears the LLM context for a session
 	ClearContext(ctx context.Context, sessionID string) error
+	// ExportSession exports a session with the citations of its answers
+	ExportSession(ctx context.Context, id string) (*types.SessionExport, error)
+	// ShareSession signs a read-only link to a session, valid for ttl (a default expiry when zero)
+	ShareSession(ctx context.Context, id string, ttl time.Duration) (*types.SessionShareLink, error)
+	// GetSharedSession exports the session a share link token points to
+	GetSharedSession(ctx context.Context, token string) (*types.SessionExport, error)
 }
 
 // SessionRepository defines the session repository interface
@@ -75,6 +98,9 @@ type SessionRepository interface {
 	GetByTenantID(ctx context.Context, tenantID uint64) ([]*types.Session, error)
 	// GetPagedByTenantID gets paged sessions of a tenant
 	GetPagedByTenantID(ctx context.Context, tenantID uint64, page *types.Pagination) ([]*types.Session, int64, error)
+	// ListPageByTenantID lists a page of the sessions of a tenant sorted by the field of the query, with one more row
+	// than the limit when more follow. The total is nil when the query does not count it.
+	ListPageByTenantID(ctx context.Context, tenantID uint64, query *types.ListQuery) ([]*types.Session, *int64, error)
 	// Update updates a session
 	Update(ctx context.Context, session *types.Session) error
 	// Delete deletes a session
@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SystemStatsService aggregates the statistics of every tenant for operators
+type SystemStatsService interface {
+	// GetOverview aggregates the statistics of every tenant over a period
+	GetOverview(ctx context.Context, query *types.SystemStatsQuery) (*types.SystemStatsOverview, error)
+	// ListTenantStats lists the statistics of each tenant over a period, ranked by a column in descending order
+	ListTenantStats(ctx context.Context, query *types.SystemStatsQuery,
+		sort types.TenantStatsSort, page *types.Pagination) (*types.PageResult, error)
+	// ListTopErrors lists the most frequent errors of background jobs over a period, of one tenant when
+	// tenantID is not 0
+	ListTopErrors(ctx context.Context, query *types.SystemStatsQuery,
+		tenantID uint64, limit int) ([]*types.ErrorStat, error)
+}
+
+// SystemStatsRepository runs the aggregate queries of system statistics
+type SystemStatsRepository interface {
+	GetOverview(ctx context.Context, query *types.SystemStatsQuery) (*types.SystemStatsOverview, error)
+	ListTenantStats(ctx context.Context, query *types.SystemStatsQuery,
+		sort types.TenantStatsSort, page *types.Pagination) ([]*types.TenantStats, int64, error)
+	// ListJobErrors counts the failures of background jobs by task type and raw error message
+	ListJobErrors(ctx context.Context, query *types.SystemStatsQuery,
+		tenantID uint64, limit int) ([]*types.ErrorStat, error)
+}
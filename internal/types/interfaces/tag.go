@@ -11,11 +11,22 @@ import (
 type KnowledgeTagService interface {
 	// ListTags lists all tags under a knowledge base with associated statistics.
 	ListTags(ctx context.Context, kbID string, page *types.Pagination, keyword string) (*types.PageResult, error)
-	// CreateTag creates a new tag under a knowledge base.
-	CreateTag(ctx context.Context, kbID string, name string, color string, sortOrder int) (*types.KnowledgeTag, error)
+	// GetTagTree returns all tags under a knowledge base organized as a tree.
+	GetTagTree(ctx context.Context, kbID string) ([]*types.KnowledgeTagNode, error)
+	// CreateTag creates a new tag under a knowledge base. parentID is optional.
+	CreateTag(
+		ctx context.Context,
+		kbID string,
+		parentID string,
+		name string,
+		color string,
+		sortOrder int,
+	) (*types.KnowledgeTag, error)
 	// UpdateTag updates tag basic information.
 	UpdateTag(ctx context.Context, id string, name *string, color *string, sortOrder *int) (*types.KnowledgeTag, error)
-	// DeleteTag deletes a tag.
+	// MoveTag moves a tag under a new parent. An empty parentID moves it to the root.
+	MoveTag(ctx context.Context, id string, parentID string) (*types.KnowledgeTag, error)
+	// DeleteTag deletes a tag. Children of the deleted tag are moved to its parent.
 	// When contentOnly=true, only deletes the content under the tag but keeps the tag itself.
 	// excludeIDs: IDs of chunks to exclude from deletion (only valid when deleting chunks)
 	DeleteTag(ctx context.Context, id string, force bool, contentOnly bool, excludeIDs []string) error
@@ -44,7 +55,17 @@ type KnowledgeTagRepository interface {
 		page *types.Pagination,
 		keyword string,
 	) ([]*types.KnowledgeTag, int64, error)
+	// ListAllByKB lists all tags of a knowledge base, used to build the tag tree.
+	ListAllByKB(ctx context.Context, tenantID uint64, kbID string) ([]*types.KnowledgeTag, error)
+	// ListDescendantIDs returns the given tag IDs together with the IDs of all their descendants.
+	ListDescendantIDs(ctx context.Context, tenantID uint64, tagIDs []string) ([]string, error)
+	// ReparentChildren moves all direct children of a tag under a new parent.
+	ReparentChildren(ctx context.Context, tenantID uint64, tagID string, newParentID string) error
 	Delete(ctx context.Context, tenantID uint64, id string) error
+	// SetKnowledgeTags replaces all tags assigned to a knowledge entry.
+	SetKnowledgeTags(ctx context.Context, tenantID uint64, kbID string, knowledgeID string, tagIDs []string) error
+	// ListKnowledgeTagIDs returns knowledgeID -> assigned tag IDs.
+	ListKnowledgeTagIDs(ctx context.Context, tenantID uint64, knowledgeIDs []string) (map[string][]string, error)
 	// CountReferences returns number of knowledges and chunks that reference the tag.
 	CountReferences(
 		ctx context.Context,
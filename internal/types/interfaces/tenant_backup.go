@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// TenantBackupService backs up tenants to object storage and restores them from backup archives
+type TenantBackupService interface {
+	// CreateBackup records a backup of a tenant and enqueues it
+	CreateBackup(ctx context.Context, tenantID uint64) (*types.TenantBackup, error)
+	// ListBackups lists the backups of a tenant, or of every tenant when tenantID is 0, newest first
+	ListBackups(ctx context.Context, tenantID uint64, page *types.Pagination) (*types.PageResult, error)
+	// GetBackup gets a backup by ID
+	GetBackup(ctx context.Context, id string) (*types.TenantBackup, error)
+	// OpenBackup opens the archive of a completed backup
+	OpenBackup(ctx context.Context, id string) (*types.TenantBackup, io.ReadCloser, error)
+	// ProcessTenantBackup handles Asynq tenant backup tasks
+	ProcessTenantBackup(ctx context.Context, t *asynq.Task) error
+	// RestoreBackup recreates the tenant of a backup archive on this deployment, after verifying the archive
+	RestoreBackup(ctx context.Context, archivePath string, opts types.TenantRestoreOptions) (*types.TenantRestoreReport, error)
+}
+
+// TenantBackupRepository stores the records of tenant backups
+type TenantBackupRepository interface {
+	CreateBackup(ctx context.Context, backup *types.TenantBackup) error
+	UpdateBackup(ctx context.Context, backup *types.TenantBackup) error
+	GetBackup(ctx context.Context, id string) (*types.TenantBackup, error)
+	ListBackups(ctx context.Context, tenantID uint64, page *types.Pagination) ([]*types.TenantBackup, int64, error)
+}
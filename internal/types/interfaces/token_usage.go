@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// TokenUsageService records the LLM token usage of tenants and enforces their monthly budgets
+type TokenUsageService interface {
+	// RecordTokenUsage records the usage of one LLM call, attributed to the tenant and usage scope of ctx
+	RecordTokenUsage(ctx context.Context, model *types.Model, usage types.TokenUsage)
+	// GetBudgetStatus returns the current month's usage of the tenant against its budget
+	GetBudgetStatus(ctx context.Context) (*types.TokenBudgetStatus, error)
+	// CheckBudget returns an error when the tenant has used up its monthly budget
+	CheckBudget(ctx context.Context) error
+	// GetUsageReport aggregates the usage of the tenant over a period
+	GetUsageReport(ctx context.Context, query *types.TokenUsageQuery) (*types.TokenUsageReport, error)
+}
+
+// TokenUsageRepository stores token usage records
+type TokenUsageRepository interface {
+	// Create inserts a usage record
+	Create(ctx context.Context, record *types.TokenUsageRecord) error
+	// SumTotalTokens sums the tokens used by a tenant since the given time
+	SumTotalTokens(ctx context.Context, tenantID uint64, since time.Time) (int64, error)
+	// GetTotals aggregates the usage of a tenant over a period
+	GetTotals(ctx context.Context, tenantID uint64, from, to time.Time) (*types.TokenUsageStat, error)
+	// ListStats aggregates the usage of a tenant over a period by the dimension of the query
+	ListStats(ctx context.Context, tenantID uint64, query *types.TokenUsageQuery) ([]*types.TokenUsageStat, error)
+}
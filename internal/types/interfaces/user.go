@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
 )
 
 // UserService defines the user service interface
@@ -32,14 +33,43 @@ type UserService interface {
 	ValidateToken(ctx context.Context, token string) (*types.User, error)
 	// RefreshToken refreshes access token using refresh token
 	RefreshToken(ctx context.Context, refreshToken string) (accessToken, newRefreshToken string, err error)
-	// RevokeToken revokes a token
+	// RevokeToken revokes a token and the login session it belongs to
 	RevokeToken(ctx context.Context, token string) error
+	// ListSessions lists the active login sessions of a user with their usable tokens,
+	// marking the session of currentToken
+	ListSessions(ctx context.Context, userID string, currentToken string) ([]*types.LoginSession, error)
+	// RevokeSession revokes a login session of a user and all of its tokens
+	RevokeSession(ctx context.Context, userID string, sessionID string) error
+	// RevokeAllSessions revokes all login sessions and tokens of a user, except the session of keepToken if given
+	RevokeAllSessions(ctx context.Context, userID string, keepToken string) error
+	// RevokeIssuedToken revokes a single token of a user
+	RevokeIssuedToken(ctx context.Context, userID string, tokenID string) error
 	// GetCurrentUser gets current user from context
 	GetCurrentUser(ctx context.Context) (*types.User, error)
 	// SearchUsers searches users by username or email
 	SearchUsers(ctx context.Context, query string, limit int) ([]*types.User, error)
 }
 
+// OIDCService signs users in with OpenID Connect single sign-on providers
+type OIDCService interface {
+	// ListProviders lists the configured providers
+	ListProviders() []*types.OIDCProviderInfo
+	// BeginLogin returns the authorization URL of a provider and the signed state the callback must present
+	BeginLogin(ctx context.Context, provider string) (authURL string, signedState string, err error)
+	// CompleteLogin exchanges the authorization code, signs in or provisions the user and issues login tokens
+	CompleteLogin(ctx context.Context, provider, code, state, signedState string) (*types.LoginResponse, error)
+}
+
+// LDAPService authenticates users against an LDAP or Active Directory server and syncs their groups
+type LDAPService interface {
+	// Enabled reports whether LDAP login is configured
+	Enabled() bool
+	// Login authenticates a directory user by login name and password and issues login tokens
+	Login(ctx context.Context, username, password string) (*types.LoginResponse, error)
+	// ProcessLDAPSync synchronizes the status and groups of all directory users
+	ProcessLDAPSync(ctx context.Context, t *asynq.Task) error
+}
+
 // UserRepository defines the user repository interface
 type UserRepository interface {
 	// CreateUser creates a user
@@ -50,6 +80,10 @@ type UserRepository interface {
 	GetUserByEmail(ctx context.Context, email string) (*types.User, error)
 	// GetUserByUsername gets a user by username
 	GetUserByUsername(ctx context.Context, username string) (*types.User, error)
+	// GetUserByExternalID gets a user by the subject it has at an identity provider
+	GetUserByExternalID(ctx context.Context, provider, externalID string) (*types.User, error)
+	// ListUsersByAuthProvider lists the users signing in with an identity provider
+	ListUsersByAuthProvider(ctx context.Context, provider string) ([]*types.User, error)
 	// UpdateUser updates a user
 	UpdateUser(ctx context.Context, user *types.User) error
 	// DeleteUser deletes a user
@@ -76,4 +110,22 @@ type AuthTokenRepository interface {
 	DeleteExpiredTokens(ctx context.Context) error
 	// RevokeTokensByUserID revokes all tokens for a user
 	RevokeTokensByUserID(ctx context.Context, userID string) error
+	// GetTokenByID gets a token by ID
+	GetTokenByID(ctx context.Context, id string) (*types.AuthToken, error)
+	// ListActiveTokens lists the tokens of a user that are neither revoked nor expired
+	ListActiveTokens(ctx context.Context, userID string) ([]*types.AuthToken, error)
+	// TouchToken records the use of a token and the activity of its session
+	TouchToken(ctx context.Context, token *types.AuthToken) error
+	// CreateSession creates a login session
+	CreateSession(ctx context.Context, session *types.LoginSession) error
+	// GetSession gets a login session by ID
+	GetSession(ctx context.Context, id string) (*types.LoginSession, error)
+	// UpdateSession updates a login session
+	UpdateSession(ctx context.Context, session *types.LoginSession) error
+	// ListActiveSessions lists the login sessions of a user that are neither revoked nor expired
+	ListActiveSessions(ctx context.Context, userID string) ([]*types.LoginSession, error)
+	// RevokeSession revokes a login session and its tokens
+	RevokeSession(ctx context.Context, id string) error
+	// RevokeSessionsByUserID revokes the login sessions and tokens of a user, except the session to keep if any
+	RevokeSessionsByUserID(ctx context.Context, userID string, keepSessionID string) error
 }
@@ -0,0 +1,63 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// WebhookService manages the webhooks of tenants and delivers events to them
+type WebhookService interface {
+	// CreateWebhook registers a webhook for the tenant in the context
+	CreateWebhook(ctx context.Context, req *types.CreateWebhookRequest) (*types.WebhookWithSecret, error)
+	// ListWebhooks lists the webhooks of the tenant in the context
+	ListWebhooks(ctx context.Context) ([]*types.Webhook, error)
+	// GetWebhook gets a webhook of the tenant in the context
+	GetWebhook(ctx context.Context, id string) (*types.Webhook, error)
+	// UpdateWebhook updates the name, URL, events or state of a webhook
+	UpdateWebhook(ctx context.Context, id string, req *types.UpdateWebhookRequest) (*types.Webhook, error)
+	// RotateWebhookSecret replaces the secret of a webhook, payloads are signed with the new secret immediately
+	RotateWebhookSecret(ctx context.Context, id string) (*types.WebhookWithSecret, error)
+	// DeleteWebhook deletes a webhook
+	DeleteWebhook(ctx context.Context, id string) error
+	// TestWebhook sends a ping event to a webhook
+	TestWebhook(ctx context.Context, id string) (*types.WebhookDelivery, error)
+	// ListDeliveries lists the deliveries of a webhook, newest first
+	ListDeliveries(ctx context.Context, id string, page *types.Pagination) (*types.PageResult, error)
+	// Publish delivers an event to the webhooks of a tenant subscribed to it. Failures are logged, publishing
+	// never fails the operation that raised the event.
+	Publish(ctx context.Context, tenantID uint64, event types.WebhookEvent, data any)
+	// ProcessWebhookDelivery handles the task sending a delivery, failed attempts are retried with backoff
+	ProcessWebhookDelivery(ctx context.Context, t *asynq.Task) error
+	// ProcessWebhookDeliveryPurge handles the periodic purge of deliveries older than the retention window
+	ProcessWebhookDeliveryPurge(ctx context.Context, t *asynq.Task) error
+}
+
+// WebhookRepository stores webhooks and their deliveries
+type WebhookRepository interface {
+	// CreateWebhook creates a webhook
+	CreateWebhook(ctx context.Context, webhook *types.Webhook) error
+	// GetWebhook gets a webhook of a tenant by ID
+	GetWebhook(ctx context.Context, tenantID uint64, id string) (*types.Webhook, error)
+	// ListWebhooks lists the webhooks of a tenant
+	ListWebhooks(ctx context.Context, tenantID uint64) ([]*types.Webhook, error)
+	// ListEnabledWebhooks lists the enabled webhooks of a tenant
+	ListEnabledWebhooks(ctx context.Context, tenantID uint64) ([]*types.Webhook, error)
+	// UpdateWebhook updates a webhook
+	UpdateWebhook(ctx context.Context, webhook *types.Webhook) error
+	// DeleteWebhook deletes a webhook of a tenant
+	DeleteWebhook(ctx context.Context, tenantID uint64, id string) error
+	// CreateDelivery records a delivery
+	CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error
+	// GetDelivery gets a delivery by ID
+	GetDelivery(ctx context.Context, id string) (*types.WebhookDelivery, error)
+	// UpdateDelivery saves the status and outcome of a delivery
+	UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error
+	// ListDeliveries lists the deliveries of a webhook of a tenant
+	ListDeliveries(ctx context.Context,
+		tenantID uint64, webhookID string, page *types.Pagination) ([]*types.WebhookDelivery, int64, error)
+	// DeleteDeliveriesBefore deletes the deliveries created before a time and returns how many were deleted
+	DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// IPPolicy restricts the client addresses that can call an API surface
+type IPPolicy struct {
+	// Allow lists the CIDRs or addresses allowed, empty allows every address that is not denied
+	Allow []string `json:"allow"`
+	// Deny lists the CIDRs or addresses denied, it takes precedence over Allow
+	Deny []string `json:"deny"`
+}
+
+// TenantIPPolicy restricts the client addresses of a tenant, separately for its public API and for
+// callbacks of internal services such as ONLYOFFICE and DocReader
+type TenantIPPolicy struct {
+	Enabled bool `json:"enabled"`
+	// API applies to requests authenticated as the tenant, its users and API keys
+	API IPPolicy `json:"api"`
+	// Callbacks applies to internal service callbacks about the documents of the tenant
+	Callbacks IPPolicy `json:"callbacks"`
+}
+
+// Value implements the driver.Valuer interface, used to convert TenantIPPolicy to database value
+func (p TenantIPPolicy) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database values to TenantIPPolicy
+func (p *TenantIPPolicy) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, p)
+}
+
+// Validate checks that every entry is a CIDR or an address
+func (p *TenantIPPolicy) Validate() error {
+	for _, entries := range [][]string{p.API.Allow, p.API.Deny, p.Callbacks.Allow, p.Callbacks.Deny} {
+		for _, entry := range entries {
+			if _, err := parseIPPrefix(entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AllowsAPI checks if an address may call the public API of the tenant
+func (p *TenantIPPolicy) AllowsAPI(addr string) bool {
+	return p == nil || !p.Enabled || p.API.Allows(addr)
+}
+
+// AllowsCallback checks if an address may call back about the documents of the tenant
+func (p *TenantIPPolicy) AllowsCallback(addr string) bool {
+	return p == nil || !p.Enabled || p.Callbacks.Allows(addr)
+}
+
+// Allows checks an address against the policy. Unparsable addresses are only allowed by an empty policy.
+func (p IPPolicy) Allows(addr string) bool {
+	if len(p.Allow) == 0 && len(p.Deny) == 0 {
+		return true
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(addr))
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	if matchesAnyPrefix(p.Deny, ip) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchesAnyPrefix(p.Allow, ip)
+}
+
+// matchesAnyPrefix checks if an address is in any of the CIDRs or addresses, skipping invalid entries
+func matchesAnyPrefix(entries []string, ip netip.Addr) bool {
+	for _, entry := range entries {
+		if prefix, err := parseIPPrefix(entry); err == nil && prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPPrefix parses a CIDR, or a single address as the prefix holding only it
+func parseIPPrefix(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+		}
+		return prefix.Masked(), nil
+	}
+	ip, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q: %w", entry, err)
+	}
+	ip = ip.Unmap()
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
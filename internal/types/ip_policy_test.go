@@ -0,0 +1,50 @@
+package types
+
+import "testing"
+
+func TestIPPolicyAllows(t *testing.T) {
+	policy := IPPolicy{
+		Allow: []string{"10.0.0.0/8", "203.0.113.7", "2001:db8::/32"},
+		Deny:  []string{"10.66.0.0/16"},
+	}
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"10.66.1.1", false},
+		{"203.0.113.7", true},
+		{"203.0.113.8", false},
+		{"::ffff:10.1.2.3", true},
+		{"2001:db8::1", true},
+		{"not-an-ip", false},
+	}
+	for _, tt := range tests {
+		if got := policy.Allows(tt.addr); got != tt.want {
+			t.Errorf("Allows(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+	if !(IPPolicy{}).Allows("not-an-ip") {
+		t.Error("empty policy should allow every address")
+	}
+	if !(IPPolicy{Deny: []string{"192.0.2.0/24"}}).Allows("198.51.100.1") {
+		t.Error("deny-only policy should allow addresses not denied")
+	}
+}
+
+func TestTenantIPPolicy(t *testing.T) {
+	policy := &TenantIPPolicy{
+		API:       IPPolicy{Allow: []string{"10.0.0.0/8"}},
+		Callbacks: IPPolicy{Allow: []string{"172.16.0.10"}},
+	}
+	if !policy.AllowsAPI("198.51.100.1") {
+		t.Error("disabled policy should allow every address")
+	}
+	policy.Enabled = true
+	if policy.AllowsAPI("172.16.0.10") || !policy.AllowsCallback("172.16.0.10") {
+		t.Error("API and callback policies should apply separately")
+	}
+	if err := (&TenantIPPolicy{API: IPPolicy{Allow: []string{"10.0.0.0/33"}}}).Validate(); err == nil {
+		t.Error("invalid CIDR should be rejected")
+	}
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrTaskInterrupted is the cause a task context is cancelled with when shutdown interrupts it.
+// The task is requeued, handlers checkpoint their state so it resumes after restart.
+var ErrTaskInterrupted = errors.New("task interrupted by shutdown")
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+const (
+	// JobStatusQueued jobs wait in their queue for a worker
+	JobStatusQueued JobStatus = "queued"
+	// JobStatusRunning jobs are being processed by a worker
+	JobStatusRunning JobStatus = "running"
+	// JobStatusRetrying jobs failed and are scheduled to run again
+	JobStatusRetrying JobStatus = "retrying"
+	// JobStatusSucceeded jobs completed
+	JobStatusSucceeded JobStatus = "succeeded"
+	// JobStatusFailed jobs failed and will not be retried
+	JobStatusFailed JobStatus = "failed"
+	// JobStatusCancelled jobs were cancelled before completing
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+// IsFinished checks if a job in the status will not run again
+func (s JobStatus) IsFinished() bool {
+	return s == JobStatusSucceeded || s == JobStatusFailed || s == JobStatusCancelled
+}
+
+// Job is a background task of a tenant, such as parsing a document, re-indexing a knowledge base or
+// fetching a web page. Jobs run on the task queue, the record keeps their status and history.
+type Job struct {
+	// ID is the ID of the task on the queue
+	ID       string `json:"id"        gorm:"type:varchar(64);primaryKey"`
+	TenantID uint64 `json:"tenant_id" gorm:"index"`
+	// Type is the task type, such as document:process
+	Type string `json:"type" gorm:"type:varchar(64);index"`
+	// Queue is the priority queue the job runs on: critical, default or low
+	Queue           string    `json:"queue"             gorm:"type:varchar(32)"`
+	Status          JobStatus `json:"status"            gorm:"type:varchar(16);index"`
+	KnowledgeBaseID string    `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	KnowledgeID     string    `json:"knowledge_id"      gorm:"type:varchar(36)"`
+	// Attempts is how many times the job has been started
+	Attempts int `json:"attempts"`
+	// MaxRetry is how many times the job is retried after failing
+	MaxRetry   int        `json:"max_retry"`
+	LastError  string     `json:"last_error"  gorm:"type:text"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for GORM
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// jobPayload holds the fields task payloads share to tell whose job it is and what it works on
+type jobPayload struct {
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	KBID            string `json:"kb_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+}
+
+// NewJob creates the record of a queued task from its payload. It returns nil for tasks of no tenant,
+// such as periodic maintenance, which are not recorded.
+func NewJob(id string, taskType string, queue string, maxRetry int, payload []byte) *Job {
+	var p jobPayload
+	if len(payload) == 0 || json.Unmarshal(payload, &p) != nil || p.TenantID == 0 {
+		return nil
+	}
+	kbID := p.KnowledgeBaseID
+	if kbID == "" {
+		kbID = p.KBID
+	}
+	return &Job{
+		ID:              id,
+		TenantID:        p.TenantID,
+		Type:            taskType,
+		Queue:           queue,
+		Status:          JobStatusQueued,
+		KnowledgeBaseID: kbID,
+		KnowledgeID:     p.KnowledgeID,
+		MaxRetry:        maxRetry,
+	}
+}
+
+// JobFilter filters the jobs of a tenant, empty fields match all jobs
+type JobFilter struct {
+	Status          JobStatus `form:"status"`
+	Type            string    `form:"type"`
+	KnowledgeBaseID string    `form:"kb_id"`
+	KnowledgeID     string    `form:"knowledge_id"`
+}
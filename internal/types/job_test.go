@@ -0,0 +1,41 @@
+package types
+
+import "testing"
+
+func TestNewJob(t *testing.T) {
+	job := NewJob("t1", TypeKBEmbeddingReindex, "low", 2, []byte(`{"tenant_id":7,"kb_id":"kb1"}`))
+	if job == nil {
+		t.Fatal("expected a job for a tenant task")
+	}
+	if job.TenantID != 7 || job.KnowledgeBaseID != "kb1" || job.Status != JobStatusQueued || job.MaxRetry != 2 {
+		t.Errorf("unexpected job: %+v", job)
+	}
+
+	job = NewJob("t2", TypeDocumentProcess, "default", 3,
+		[]byte(`{"tenant_id":7,"knowledge_base_id":"kb1","knowledge_id":"k1"}`))
+	if job == nil || job.KnowledgeBaseID != "kb1" || job.KnowledgeID != "k1" {
+		t.Errorf("unexpected job: %+v", job)
+	}
+
+	if job := NewJob("t3", TypeKnowledgeLifecycle, "low", 1, nil); job != nil {
+		t.Errorf("periodic tasks of no tenant should not be recorded: %+v", job)
+	}
+	if job := NewJob("t4", TypeLDAPSync, "low", 1, []byte(`not json`)); job != nil {
+		t.Errorf("unreadable payloads should not be recorded: %+v", job)
+	}
+}
+
+func TestJobStatusIsFinished(t *testing.T) {
+	for status, want := range map[JobStatus]bool{
+		JobStatusQueued:    false,
+		JobStatusRunning:   false,
+		JobStatusRetrying:  false,
+		JobStatusSucceeded: true,
+		JobStatusFailed:    true,
+		JobStatusCancelled: true,
+	} {
+		if got := status.IsFinished(); got != want {
+			t.Errorf("%s.IsFinished() = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"path"
+	"time"
+)
+
+// KBBundleFormatVersion is the layout version of knowledge base bundles written by this build
+const KBBundleFormatVersion = 1
+
+// KBBundleMaxSize is the largest knowledge base bundle accepted for import
+const KBBundleMaxSize = 1 << 30
+
+// KBBundleManifestPath is the path of the manifest inside a knowledge base bundle.
+//
+// A bundle is a zip archive laid out as:
+//
+//	manifest.json                    knowledge base settings, tags and knowledge metadata
+//	files/<knowledge_id>/<file_name> original uploaded files
+//	markdown/<knowledge_id>.md       extracted text
+//	chunks/<knowledge_id>.json       chunks with their metadata
+//	embeddings/<knowledge_id>.json   vectors keyed by source ID, only when exported with embeddings
+const KBBundleManifestPath = "manifest.json"
+
+// KBBundleFilePath returns the bundle path of the original file of a knowledge
+func KBBundleFilePath(knowledgeID, fileName string) string {
+	return path.Join("files", knowledgeID, path.Base(fileName))
+}
+
+// KBBundleMarkdownPath returns the bundle path of the extracted text of a knowledge
+func KBBundleMarkdownPath(knowledgeID string) string {
+	return path.Join("markdown", knowledgeID+".md")
+}
+
+// KBBundleChunksPath returns the bundle path of the chunks of a knowledge
+func KBBundleChunksPath(knowledgeID string) string {
+	return path.Join("chunks", knowledgeID+".json")
+}
+
+// KBBundleEmbeddingsPath returns the bundle path of the vectors of a knowledge
+func KBBundleEmbeddingsPath(knowledgeID string) string {
+	return path.Join("embeddings", knowledgeID+".json")
+}
+
+// KBBundleManifest describes the content of a knowledge base bundle
+type KBBundleManifest struct {
+	FormatVersion int                   `json:"format_version"`
+	ExportedAt    time.Time             `json:"exported_at"`
+	KnowledgeBase KBBundleKnowledgeBase `json:"knowledge_base"`
+	Tags          []*KnowledgeTag       `json:"tags"`
+	// Knowledge entries with TagIDs filled in; IDs are only meaningful inside the bundle
+	Knowledge []*Knowledge `json:"knowledge"`
+	// Set only when the bundle carries vectors
+	Embedding *KBBundleEmbedding `json:"embedding,omitempty"`
+}
+
+// KBBundleKnowledgeBase holds the portable settings of an exported knowledge base.
+// Models, storage and VLM settings are deployment specific and chosen again on import.
+type KBBundleKnowledgeBase struct {
+	Name                     string                    `json:"name"`
+	Description              string                    `json:"description"`
+	Type                     string                    `json:"type"`
+	ChunkingConfig           ChunkingConfig            `json:"chunking_config"`
+	ImageProcessingConfig    ImageProcessingConfig     `json:"image_processing_config"`
+	QuestionGenerationConfig *QuestionGenerationConfig `json:"question_generation_config,omitempty"`
+	RetentionConfig          *RetentionConfig          `json:"retention_config,omitempty"`
+	OCRConfig                *OCRConfig                `json:"ocr_config,omitempty"`
+	WebExtractionConfig      *WebExtractionConfig      `json:"web_extraction_config,omitempty"`
+	URLFetchConfig           *URLFetchConfig           `json:"url_fetch_config,omitempty"`
+}
+
+// KBBundleEmbedding identifies the model the bundled vectors were computed with.
+// Vectors are reused on import only when the target model has the same name and dimensions.
+type KBBundleEmbedding struct {
+	ModelName  string `json:"model_name"`
+	Dimensions int    `json:"dimensions"`
+}
+
+// KBImportRequest holds the form fields of a knowledge base import besides the bundle file
+type KBImportRequest struct {
+	// Overrides the name stored in the bundle
+	Name             string `form:"name"`
+	EmbeddingModelID string `form:"embedding_model_id" binding:"required"`
+	SummaryModelID   string `form:"summary_model_id"`
+}
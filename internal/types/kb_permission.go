@@ -0,0 +1,190 @@
+package types
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KBRole is the role of a user on a knowledge base, or on a single document of it
+type KBRole string
+
+const (
+	// KBRoleOwner manages the knowledge base settings, its members and document permissions
+	KBRoleOwner KBRole = "owner"
+	// KBRoleEditor adds, edits and deletes any document
+	KBRoleEditor KBRole = "editor"
+	// KBRoleContributor adds documents and edits or deletes the ones it added
+	KBRoleContributor KBRole = "contributor"
+	// KBRoleViewer reads and searches documents
+	KBRoleViewer KBRole = "viewer"
+	// KBRoleNone is only used by document permissions, it hides the document from the user
+	KBRoleNone KBRole = "none"
+)
+
+var kbRoleLevels = map[KBRole]int{
+	KBRoleOwner:       4,
+	KBRoleEditor:      3,
+	KBRoleContributor: 2,
+	KBRoleViewer:      1,
+}
+
+// IsValid checks if the role can be granted on a knowledge base
+func (r KBRole) IsValid() bool {
+	_, ok := kbRoleLevels[r]
+	return ok
+}
+
+// IsValidForKnowledge checks if the role can be set as a document permission
+func (r KBRole) IsValidForKnowledge() bool {
+	switch r {
+	case KBRoleEditor, KBRoleViewer, KBRoleNone:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasPermission checks if this role has at least the required permission level
+func (r KBRole) HasPermission(required KBRole) bool {
+	level, ok := kbRoleLevels[r]
+	return ok && level >= kbRoleLevels[required]
+}
+
+// KBRoleFromOrgRole maps the permission of a knowledge base shared to an organization to a knowledge base role
+func KBRoleFromOrgRole(role OrgMemberRole) KBRole {
+	switch role {
+	case OrgRoleAdmin:
+		return KBRoleOwner
+	case OrgRoleEditor:
+		return KBRoleEditor
+	case OrgRoleViewer:
+		return KBRoleViewer
+	default:
+		return ""
+	}
+}
+
+// OrgRole maps the role to the closest permission of organization shares, which clients display
+func (r KBRole) OrgRole() OrgMemberRole {
+	switch r {
+	case KBRoleOwner:
+		return OrgRoleAdmin
+	case KBRoleEditor, KBRoleContributor:
+		return OrgRoleEditor
+	case KBRoleViewer:
+		return OrgRoleViewer
+	default:
+		return ""
+	}
+}
+
+// EffectiveKnowledgeRole returns the role of a user on a document from its role on the knowledge base,
+// the permission set on the document for the user (empty when none) and whether the user added the document
+func EffectiveKnowledgeRole(kbRole KBRole, override KBRole, isCreator bool) KBRole {
+	if kbRole == "" {
+		return ""
+	}
+	if override != "" {
+		return override
+	}
+	if kbRole == KBRoleContributor && isCreator {
+		return KBRoleEditor
+	}
+	return kbRole
+}
+
+// KBMember grants a user a role on a knowledge base. It takes precedence over the role
+// the user gets from its tenant or from organization shares, so it can also restrict access.
+type KBMember struct {
+	ID              string    `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	KnowledgeBaseID string    `json:"knowledge_base_id" gorm:"type:varchar(36);not null;index"`
+	UserID          string    `json:"user_id"           gorm:"type:varchar(36);not null;index"`
+	Role            KBRole    `json:"role"              gorm:"type:varchar(32);not null"`
+	GrantedBy       string    `json:"granted_by"        gorm:"type:varchar(36)"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Associations (not stored in database)
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName returns the table name for GORM
+func (KBMember) TableName() string {
+	return "kb_members"
+}
+
+// KnowledgePermission overrides the role of a user on one document of a knowledge base
+type KnowledgePermission struct {
+	ID              string    `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	KnowledgeID     string    `json:"knowledge_id"      gorm:"type:varchar(36);not null;index"`
+	KnowledgeBaseID string    `json:"knowledge_base_id" gorm:"type:varchar(36);not null;index"`
+	UserID          string    `json:"user_id"           gorm:"type:varchar(36);not null;index"`
+	Role            KBRole    `json:"role"              gorm:"type:varchar(32);not null"`
+	GrantedBy       string    `json:"granted_by"        gorm:"type:varchar(36)"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Associations (not stored in database)
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName returns the table name for GORM
+func (KnowledgePermission) TableName() string {
+	return "knowledge_permissions"
+}
+
+// SetKBRoleRequest sets the role of a user on a knowledge base or a document
+type SetKBRoleRequest struct {
+	Role KBRole `json:"role" binding:"required"`
+}
+
+// KBInvite is an invite link granting a role on a knowledge base to the users who accept it,
+// until it expires, runs out of uses or is revoked
+type KBInvite struct {
+	ID              string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);not null;index"`
+	Role            KBRole `json:"role"              gorm:"type:varchar(32);not null"`
+	// MaxUses limits how many users can accept the invite, zero is unlimited
+	MaxUses   int            `json:"max_uses"   gorm:"not null;default:0"`
+	Uses      int            `json:"uses"       gorm:"not null;default:0"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	CreatedBy string         `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-"          gorm:"index"`
+}
+
+// TableName returns the table name for GORM
+func (KBInvite) TableName() string {
+	return "kb_invites"
+}
+
+// IsUsable checks if the invite can still be accepted at the given time
+func (i *KBInvite) IsUsable(now time.Time) bool {
+	return now.Before(i.ExpiresAt) && (i.MaxUses <= 0 || i.Uses < i.MaxUses)
+}
+
+// IsValidForInvite checks if the role can be granted by an invite link, ownership is only granted directly
+func (r KBRole) IsValidForInvite() bool {
+	return r.IsValid() && r != KBRoleOwner
+}
+
+// CreateKBInviteRequest creates an invite link to a knowledge base
+type CreateKBInviteRequest struct {
+	Role KBRole `json:"role" binding:"required"`
+	// ExpiresInHours defaults to 72 hours, at most 30 days
+	ExpiresInHours int `json:"expires_in_hours"`
+	MaxUses        int `json:"max_uses"`
+}
+
+// KBInviteLink is returned when an invite is created, the only time its token is visible
+type KBInviteLink struct {
+	*KBInvite
+	Token string `json:"token"`
+}
+
+// AcceptKBInviteRequest accepts an invite link
+type AcceptKBInviteRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEffectiveKnowledgeRole(t *testing.T) {
+	tests := []struct {
+		kbRole    KBRole
+		override  KBRole
+		isCreator bool
+		want      KBRole
+	}{
+		{KBRoleEditor, "", false, KBRoleEditor},
+		{KBRoleContributor, "", false, KBRoleContributor},
+		{KBRoleContributor, "", true, KBRoleEditor},
+		{KBRoleViewer, KBRoleEditor, false, KBRoleEditor},
+		{KBRoleOwner, KBRoleNone, false, KBRoleNone},
+		{"", KBRoleEditor, true, ""},
+	}
+	for _, tt := range tests {
+		if got := EffectiveKnowledgeRole(tt.kbRole, tt.override, tt.isCreator); got != tt.want {
+			t.Errorf("EffectiveKnowledgeRole(%q, %q, %v) = %q, want %q",
+				tt.kbRole, tt.override, tt.isCreator, got, tt.want)
+		}
+	}
+}
+
+func TestKBRoleHasPermission(t *testing.T) {
+	if !KBRoleOwner.HasPermission(KBRoleEditor) || !KBRoleContributor.HasPermission(KBRoleViewer) {
+		t.Error("higher roles should include lower ones")
+	}
+	if KBRoleContributor.HasPermission(KBRoleEditor) || KBRoleNone.HasPermission(KBRoleViewer) {
+		t.Error("lower roles should not include higher ones")
+	}
+	if KBRole("").HasPermission(KBRoleViewer) {
+		t.Error("empty role should have no permission")
+	}
+	if KBRoleFromOrgRole(OrgRoleAdmin) != KBRoleOwner || KBRoleContributor.OrgRole() != OrgRoleEditor {
+		t.Error("unexpected mapping between organization and knowledge base roles")
+	}
+}
+
+func TestKBInviteIsUsable(t *testing.T) {
+	now := time.Now()
+	invite := &KBInvite{ExpiresAt: now.Add(time.Hour)}
+	if !invite.IsUsable(now) {
+		t.Error("invite without use limit should be usable before it expires")
+	}
+	if invite.IsUsable(now.Add(2 * time.Hour)) {
+		t.Error("expired invite should not be usable")
+	}
+	invite.MaxUses, invite.Uses = 2, 2
+	if invite.IsUsable(now) {
+		t.Error("invite with no uses left should not be usable")
+	}
+	if KBRoleOwner.IsValidForInvite() || !KBRoleViewer.IsValidForInvite() || KBRoleNone.IsValidForInvite() {
+		t.Error("invites should grant editor, contributor or viewer only")
+	}
+}
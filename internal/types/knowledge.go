@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,8 @@ const (
 	KnowledgeTypeManual = "manual"
 	// KnowledgeTypeFAQ represents the FAQ knowledge type
 	KnowledgeTypeFAQ = "faq"
+	// KnowledgeTypeURL represents knowledge imported from a web page
+	KnowledgeTypeURL = "url"
 )
 
 // Knowledge parse status constants
@@ -26,6 +29,8 @@ const (
 	ParseStatusCompleted = "completed"
 	// ParseStatusFailed indicates the knowledge processing failed
 	ParseStatusFailed = "failed"
+	// ParseStatusFailedPermanent indicates processing failed and all automatic retries are exhausted
+	ParseStatusFailedPermanent = "failed_permanent"
 	// ParseStatusDeleting indicates the knowledge is being deleted (used to prevent async task conflicts)
 	ParseStatusDeleting = "deleting"
 )
@@ -63,6 +68,9 @@ type Knowledge struct {
 	KnowledgeBaseID string `json:"knowledge_base_id"`
 	// Optional tag ID for categorization within a knowledge base
 	TagID string `json:"tag_id"             gorm:"type:varchar(36);index"`
+	// ID of the user or service account who added the knowledge, empty when added with a tenant API key
+	// or by a background task
+	CreatedBy string `json:"created_by,omitempty" gorm:"type:varchar(36)"`
 	// Type of the knowledge
 	Type string `json:"type"`
 	// Title of the knowledge
@@ -87,12 +95,29 @@ type Knowledge struct {
 	FileSize int64 `json:"file_size"`
 	// File hash of the knowledge
 	FileHash string `json:"file_hash"`
+	// Hash of the normalized extracted text, used for duplicate detection
+	ContentHash string `json:"content_hash,omitempty" gorm:"type:varchar(64)"`
+	// SimHash fingerprint of the extracted text, 0 when the text is too short to fingerprint
+	SimHash int64 `json:"-"`
+	// ID of the knowledge this entry duplicates, detected at upload for text files and manual knowledge, and after
+	// parsing for all knowledge
+	DuplicateOf string `json:"duplicate_of,omitempty" gorm:"type:varchar(36)"`
+	// Kind of duplicate: exact or near
+	DuplicateType string `json:"duplicate_type,omitempty" gorm:"type:varchar(16)"`
 	// File path of the knowledge
 	FilePath string `json:"file_path"`
 	// Storage size of the knowledge
 	StorageSize int64 `json:"storage_size"`
 	// Metadata of the knowledge
 	Metadata JSON `json:"metadata"           gorm:"type:json"`
+	// Provenance of knowledge captured from a URL, nil for other knowledge
+	Capture *KnowledgeCapture `json:"capture,omitempty" gorm:"type:jsonb"`
+	// Status of the source URL at its last check, empty for knowledge without a source URL or not checked yet
+	LinkStatus KnowledgeLinkStatus `json:"link_status,omitempty" gorm:"type:varchar(16)"`
+	// Time the source URL was last checked
+	LinkCheckedAt *time.Time `json:"link_checked_at,omitempty"`
+	// Result of the last check of the source URL
+	LinkCheck *KnowledgeLinkCheck `json:"link_check,omitempty" gorm:"type:jsonb"`
 	// Last FAQ import result (for FAQ type knowledge only)
 	LastFAQImportResult JSON `json:"last_faq_import_result" gorm:"type:json"`
 	// Creation time of the knowledge
@@ -103,10 +128,23 @@ type Knowledge struct {
 	ProcessedAt *time.Time `json:"processed_at"`
 	// Error message of the knowledge
 	ErrorMessage string `json:"error_message"`
+	// Explicit expiration time, after which the knowledge base retention action applies
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Archive time; archived knowledge is excluded from retrieval
+	ArchivedAt *time.Time `json:"archived_at"`
+	// Time the expiring notification was sent
+	ExpiryNotifiedAt *time.Time `json:"expiry_notified_at,omitempty"`
+	// Time the knowledge was moved to trash; trashed knowledge is purged after the retention window
+	TrashedAt *time.Time `json:"trashed_at"`
+	// Chunks that were enabled when the knowledge was archived or trashed, enabled again once it is neither.
+	// Nil when no chunks were recorded.
+	SuspendedChunkIDs StringArray `json:"-" gorm:"type:jsonb"`
 	// Deletion time of the knowledge
 	DeletedAt gorm.DeletedAt `json:"deleted_at"         gorm:"index"`
 	// Knowledge base name (not stored in database, populated on query)
 	KnowledgeBaseName string `json:"knowledge_base_name" gorm:"-"`
+	// All tags assigned to the knowledge (not stored in this table, populated on query)
+	TagIDs []string `json:"tag_ids,omitempty"    gorm:"-"`
 }
 
 // GetMetadata returns the metadata as a map[string]string.
@@ -123,6 +161,25 @@ func (k *Knowledge) GetMetadata() map[string]string {
 }
 
 // BeforeCreate hook generates a UUID for new Knowledge entities before they are created.
+// ListID returns the id knowledge listings are ordered by after their sort field
+func (k *Knowledge) ListID() string {
+	return k.ID
+}
+
+// SortValue returns the value of a sort column of the knowledge as text, for cursors
+func (k *Knowledge) SortValue(column string) string {
+	switch column {
+	case "updated_at":
+		return FormatSortTime(k.UpdatedAt)
+	case "file_size":
+		return strconv.FormatInt(k.FileSize, 10)
+	case "parse_status":
+		return k.ParseStatus
+	default:
+		return FormatSortTime(k.CreatedAt)
+	}
+}
+
 func (k *Knowledge) BeforeCreate(tx *gorm.DB) (err error) {
 	if k.ID == "" {
 		k.ID = uuid.New().String()
@@ -249,6 +306,11 @@ func (k *Knowledge) GetLastFAQImportResult() (*FAQImportResult, error) {
 	return &result, nil
 }
 
+// IsParseFailed returns true if parsing failed, whether or not it will be retried.
+func (k *Knowledge) IsParseFailed() bool {
+	return k.ParseStatus == ParseStatusFailed || k.ParseStatus == ParseStatusFailedPermanent
+}
+
 // IsManual returns true if the knowledge item is manual Markdown knowledge.
 func (k *Knowledge) IsManual() bool {
 	return k != nil && k.Type == KnowledgeTypeManual
@@ -288,3 +350,58 @@ type KnowledgeCheckParams struct {
 	// Knowledge type
 	Type string
 }
+
+// KnowledgeTransferRequest defines a request to move or copy a knowledge entry to another knowledge base.
+type KnowledgeTransferRequest struct {
+	// Target knowledge base, must belong to the same tenant
+	TargetKBID string `json:"target_kb_id" binding:"required"`
+	// Reuse existing chunks and embeddings when both knowledge bases use the same embedding model,
+	// otherwise the knowledge is re-parsed in the target knowledge base. Defaults to true.
+	ReuseChunks *bool `json:"reuse_chunks"`
+}
+
+// ShouldReuseChunks returns whether existing chunks should be reused
+func (r *KnowledgeTransferRequest) ShouldReuseChunks() bool {
+	return r.ReuseChunks == nil || *r.ReuseChunks
+}
+
+// KnowledgeParseStage is a stage of the document parsing pipeline
+type KnowledgeParseStage string
+
+const (
+	// KnowledgeParseStageQueued waits for the parse task to start
+	KnowledgeParseStageQueued KnowledgeParseStage = "queued"
+	// KnowledgeParseStageDownloaded has the source file loaded from storage
+	KnowledgeParseStageDownloaded KnowledgeParseStage = "downloaded"
+	// KnowledgeParseStageConverted has the document converted by docreader to a format it parses, e.g. DOC to DOCX
+	KnowledgeParseStageConverted KnowledgeParseStage = "converted"
+	// KnowledgeParseStageParsing parses the document in docreader, counting pages when docreader reports them
+	KnowledgeParseStageParsing KnowledgeParseStage = "parsing"
+	// KnowledgeParseStageParsed has the document parsed into chunks
+	KnowledgeParseStageParsed KnowledgeParseStage = "parsed"
+	// KnowledgeParseStageChunked has the chunks saved
+	KnowledgeParseStageChunked KnowledgeParseStage = "chunked"
+	// KnowledgeParseStageEmbedding embeds and indexes the chunks
+	KnowledgeParseStageEmbedding KnowledgeParseStage = "embedding"
+	// KnowledgeParseStageCompleted finished parsing
+	KnowledgeParseStageCompleted KnowledgeParseStage = "completed"
+	// KnowledgeParseStageFailed failed parsing
+	KnowledgeParseStageFailed KnowledgeParseStage = "failed"
+)
+
+// KnowledgeParseProgress is the stage-level progress of parsing a knowledge entry
+type KnowledgeParseProgress struct {
+	KnowledgeID string              `json:"knowledge_id"`
+	Stage       KnowledgeParseStage `json:"stage"`
+	// Current and Total count the items of the stage, e.g. embedded chunks; 0 when not applicable
+	Current   int    `json:"current"`
+	Total     int    `json:"total"`
+	Message   string `json:"message,omitempty"`
+	Error     string `json:"error,omitempty"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// Done returns whether parsing has finished, successfully or not
+func (p *KnowledgeParseProgress) Done() bool {
+	return p.Stage == KnowledgeParseStageCompleted || p.Stage == KnowledgeParseStageFailed
+}
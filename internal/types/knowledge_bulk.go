@@ -0,0 +1,159 @@
+package types
+
+import "fmt"
+
+// KnowledgeBulkOperation is an operation applied to many knowledge entries of a knowledge base at once
+type KnowledgeBulkOperation string
+
+const (
+	// KnowledgeBulkDelete moves knowledge to the trash, or deletes it permanently
+	KnowledgeBulkDelete KnowledgeBulkOperation = "delete"
+	// KnowledgeBulkRetag replaces, adds or removes tags of knowledge
+	KnowledgeBulkRetag KnowledgeBulkOperation = "retag"
+	// KnowledgeBulkReparse parses knowledge again
+	KnowledgeBulkReparse KnowledgeBulkOperation = "reparse"
+)
+
+const (
+	// MaxKnowledgeBulkItems is the largest number of knowledge entries one bulk operation applies to
+	MaxKnowledgeBulkItems = 1000
+	// MaxKnowledgeBulkFailures is the number of failed entries kept in the progress of a bulk operation
+	MaxKnowledgeBulkFailures = 100
+)
+
+// KnowledgeBulkFilter selects the knowledge of a knowledge base a bulk operation applies to, like the filters of
+// the knowledge listing
+type KnowledgeBulkFilter struct {
+	TagID       string `json:"tag_id"`
+	Keyword     string `json:"keyword"`
+	FileType    string `json:"file_type"`
+	ParseStatus string `json:"parse_status"`
+}
+
+// KnowledgeBulkRequest is a bulk operation on the knowledge given by IDs or matched by a filter
+type KnowledgeBulkRequest struct {
+	Operation    KnowledgeBulkOperation `json:"operation" binding:"required"`
+	KnowledgeIDs []string               `json:"knowledge_ids"`
+	Filter       *KnowledgeBulkFilter   `json:"filter"`
+	// Permanent deletes the knowledge instead of moving it to the trash, for delete
+	Permanent bool `json:"permanent"`
+	// TagIDs and Mode are the tag change of retag, as for the batch retag endpoint
+	TagIDs []string           `json:"tag_ids"`
+	Mode   KnowledgeRetagMode `json:"mode"`
+}
+
+// Validate checks the request and removes duplicate knowledge IDs
+func (r *KnowledgeBulkRequest) Validate() error {
+	switch r.Operation {
+	case KnowledgeBulkDelete, KnowledgeBulkReparse:
+	case KnowledgeBulkRetag:
+		if r.Mode == "" {
+			r.Mode = KnowledgeRetagModeReplace
+		}
+		switch r.Mode {
+		case KnowledgeRetagModeReplace:
+		case KnowledgeRetagModeAdd, KnowledgeRetagModeRemove:
+			if len(r.TagIDs) == 0 {
+				return fmt.Errorf("tag_ids is required in %s mode", r.Mode)
+			}
+		default:
+			return fmt.Errorf("unsupported retag mode %q", r.Mode)
+		}
+	default:
+		return fmt.Errorf("unsupported operation %q", r.Operation)
+	}
+
+	if (len(r.KnowledgeIDs) == 0) == (r.Filter == nil) {
+		return fmt.Errorf("exactly one of knowledge_ids and filter is required")
+	}
+	if len(r.KnowledgeIDs) > MaxKnowledgeBulkItems {
+		return fmt.Errorf("at most %d knowledge IDs are allowed", MaxKnowledgeBulkItems)
+	}
+	seen := make(map[string]bool, len(r.KnowledgeIDs))
+	ids := r.KnowledgeIDs[:0]
+	for _, id := range r.KnowledgeIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	r.KnowledgeIDs = ids
+	return nil
+}
+
+// KnowledgeBulkPayload represents the knowledge bulk operation task payload, the filter of a request is resolved to
+// knowledge IDs when the task is submitted
+type KnowledgeBulkPayload struct {
+	TenantID uint64 `json:"tenant_id"`
+	TaskID   string `json:"task_id"`
+	KBID     string `json:"kb_id"`
+	// UserID and KBRole are the caller and its role on the knowledge base, document permissions are checked against
+	// them when the task runs
+	UserID       string                 `json:"user_id"`
+	KBRole       KBRole                 `json:"kb_role"`
+	Operation    KnowledgeBulkOperation `json:"operation"`
+	KnowledgeIDs []string               `json:"knowledge_ids"`
+	Permanent    bool                   `json:"permanent"`
+	TagIDs       []string               `json:"tag_ids"`
+	Mode         KnowledgeRetagMode     `json:"mode"`
+}
+
+// KnowledgeBulkTaskStatus represents the status of a knowledge bulk operation task
+type KnowledgeBulkTaskStatus string
+
+const (
+	KnowledgeBulkStatusPending    KnowledgeBulkTaskStatus = "pending"
+	KnowledgeBulkStatusProcessing KnowledgeBulkTaskStatus = "processing"
+	KnowledgeBulkStatusCompleted  KnowledgeBulkTaskStatus = "completed"
+	KnowledgeBulkStatusFailed     KnowledgeBulkTaskStatus = "failed"
+)
+
+// KnowledgeBulkFailure is a knowledge entry a bulk operation failed on
+type KnowledgeBulkFailure struct {
+	KnowledgeID string `json:"knowledge_id"`
+	Error       string `json:"error"`
+}
+
+// KnowledgeBulkProgress represents the progress of a knowledge bulk operation task
+type KnowledgeBulkProgress struct {
+	TaskID    string                  `json:"task_id"`
+	KBID      string                  `json:"kb_id"`
+	Operation KnowledgeBulkOperation  `json:"operation"`
+	Status    KnowledgeBulkTaskStatus `json:"status"`
+	Progress  int                     `json:"progress"`  // 0-100
+	Total     int                     `json:"total"`     // 需要处理的知识数
+	Processed int                     `json:"processed"` // 已处理的知识数
+	Succeeded int                     `json:"succeeded"` // 处理成功的知识数
+	Failed    int                     `json:"failed"`    // 处理失败的知识数
+	// Failures are the first failed entries, up to MaxKnowledgeBulkFailures
+	Failures  []KnowledgeBulkFailure `json:"failures"`
+	Message   string                 `json:"message"`    // 状态消息
+	Error     string                 `json:"error"`      // 错误信息
+	CreatedAt int64                  `json:"created_at"` // 任务创建时间
+	UpdatedAt int64                  `json:"updated_at"` // 最后更新时间
+}
+
+// RecordSuccess counts entries the operation succeeded on
+func (p *KnowledgeBulkProgress) RecordSuccess(n int) {
+	p.Processed += n
+	p.Succeeded += n
+	p.updatePercent()
+}
+
+// RecordFailure counts an entry the operation failed on
+func (p *KnowledgeBulkProgress) RecordFailure(knowledgeID string, err error) {
+	p.Processed++
+	p.Failed++
+	if len(p.Failures) < MaxKnowledgeBulkFailures {
+		p.Failures = append(p.Failures, KnowledgeBulkFailure{KnowledgeID: knowledgeID, Error: err.Error()})
+	}
+	p.updatePercent()
+}
+
+// updatePercent derives the percentage from the processed count
+func (p *KnowledgeBulkProgress) updatePercent() {
+	if p.Total > 0 {
+		p.Progress = p.Processed * 100 / p.Total
+	}
+}
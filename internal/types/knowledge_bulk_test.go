@@ -0,0 +1,59 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKnowledgeBulkRequestValidate(t *testing.T) {
+	req := KnowledgeBulkRequest{Operation: KnowledgeBulkRetag, KnowledgeIDs: []string{"a", "b", "a", ""}}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if req.Mode != KnowledgeRetagModeReplace {
+		t.Errorf("Validate() mode = %q, want replace", req.Mode)
+	}
+	if len(req.KnowledgeIDs) != 2 || req.KnowledgeIDs[0] != "a" || req.KnowledgeIDs[1] != "b" {
+		t.Errorf("Validate() knowledge IDs = %v, want [a b]", req.KnowledgeIDs)
+	}
+
+	tooMany := make([]string, MaxKnowledgeBulkItems+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("k%d", i)
+	}
+	for _, invalid := range []KnowledgeBulkRequest{
+		{Operation: "archive", KnowledgeIDs: []string{"a"}},
+		{Operation: KnowledgeBulkDelete},
+		{Operation: KnowledgeBulkDelete, KnowledgeIDs: []string{"a"}, Filter: &KnowledgeBulkFilter{}},
+		{Operation: KnowledgeBulkReparse, KnowledgeIDs: tooMany},
+		{Operation: KnowledgeBulkRetag, KnowledgeIDs: []string{"a"}, Mode: KnowledgeRetagModeAdd},
+		{Operation: KnowledgeBulkRetag, KnowledgeIDs: []string{"a"}, Mode: "merge", TagIDs: []string{"t"}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", invalid.Operation)
+		}
+	}
+
+	filtered := KnowledgeBulkRequest{Operation: KnowledgeBulkDelete, Filter: &KnowledgeBulkFilter{TagID: "t"}}
+	if err := filtered.Validate(); err != nil {
+		t.Errorf("Validate() with a filter error = %v", err)
+	}
+}
+
+func TestKnowledgeBulkProgressRecord(t *testing.T) {
+	progress := KnowledgeBulkProgress{Total: MaxKnowledgeBulkFailures + 10}
+	progress.RecordSuccess(5)
+	for i := 0; i < MaxKnowledgeBulkFailures+5; i++ {
+		progress.RecordFailure(fmt.Sprintf("k%d", i), errors.New("failed"))
+	}
+	if progress.Processed != progress.Total || progress.Succeeded != 5 || progress.Failed != MaxKnowledgeBulkFailures+5 {
+		t.Errorf("progress = %+v, want every entry processed", progress)
+	}
+	if len(progress.Failures) != MaxKnowledgeBulkFailures {
+		t.Errorf("failures kept = %d, want %d", len(progress.Failures), MaxKnowledgeBulkFailures)
+	}
+	if progress.Progress != 100 {
+		t.Errorf("progress percent = %d, want 100", progress.Progress)
+	}
+}
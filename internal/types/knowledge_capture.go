@@ -0,0 +1,72 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// KnowledgeCaptureMethod is how the content of a web page was captured
+type KnowledgeCaptureMethod string
+
+const (
+	// KnowledgeCaptureMethodText captures the extracted text of the rendered page
+	KnowledgeCaptureMethodText KnowledgeCaptureMethod = "text"
+	// KnowledgeCaptureMethodScreenshot captures an image of the rendered page
+	KnowledgeCaptureMethodScreenshot KnowledgeCaptureMethod = "screenshot"
+	// KnowledgeCaptureMethodPDF captures a PDF document served at the URL
+	KnowledgeCaptureMethodPDF KnowledgeCaptureMethod = "pdf"
+	// KnowledgeCaptureMethodFile captures another document file served at the URL, such as a Word or Excel file
+	KnowledgeCaptureMethodFile KnowledgeCaptureMethod = "file"
+)
+
+// KnowledgeCaptureEngineDocReader names the docreader web parser, which renders pages in a headless browser and
+// extracts their main content
+const KnowledgeCaptureEngineDocReader = "docreader"
+
+// KnowledgeCapture records where and how the content of a knowledge captured from the web came from, for tracing
+// it back to its source and capturing it again
+type KnowledgeCapture struct {
+	// URL the content was captured from
+	SourceURL string `json:"source_url"`
+	// Time the content was captured
+	CapturedAt time.Time `json:"captured_at"`
+	// How the content was captured
+	Method KnowledgeCaptureMethod `json:"capture_method"`
+	// ID of the user or service account who requested the capture, empty for a tenant API key
+	CapturedBy string `json:"captured_by,omitempty"`
+	// Engine that fetched and extracted the content
+	ExtractionEngine string `json:"extraction_engine"`
+	// Web extraction mode of the engine: full, readability or llm
+	ExtractionMode string `json:"extraction_mode,omitempty"`
+	// Hash of the normalized extracted text, empty until the content is parsed
+	ContentHash string `json:"content_hash,omitempty"`
+}
+
+// NewKnowledgeCapture records a capture of a URL made now
+func NewKnowledgeCapture(sourceURL string, method KnowledgeCaptureMethod, capturedBy string) *KnowledgeCapture {
+	return &KnowledgeCapture{
+		SourceURL:        sourceURL,
+		CapturedAt:       time.Now().UTC(),
+		Method:           method,
+		CapturedBy:       capturedBy,
+		ExtractionEngine: KnowledgeCaptureEngineDocReader,
+	}
+}
+
+// Value implements the driver.Valuer interface, used to convert KnowledgeCapture to database value
+func (c KnowledgeCapture) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to KnowledgeCapture
+func (c *KnowledgeCapture) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
@@ -0,0 +1,22 @@
+package types
+
+import "testing"
+
+func TestKnowledgeCaptureValueScan(t *testing.T) {
+	capture := NewKnowledgeCapture("https://example.com/page", KnowledgeCaptureMethodText, "user-1")
+	capture.ContentHash = "abc"
+	value, err := capture.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+
+	var scanned KnowledgeCapture
+	if err := scanned.Scan(value); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if scanned.SourceURL != capture.SourceURL || scanned.Method != KnowledgeCaptureMethodText ||
+		scanned.CapturedBy != "user-1" || scanned.ExtractionEngine != KnowledgeCaptureEngineDocReader ||
+		scanned.ContentHash != "abc" || !scanned.CapturedAt.Equal(capture.CapturedAt) {
+		t.Errorf("Scan() = %+v, want %+v", scanned, *capture)
+	}
+}
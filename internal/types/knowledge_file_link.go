@@ -0,0 +1,70 @@
+package types
+
+import (
+	"time"
+)
+
+// KnowledgeFileLinkScope is what a signed link to a knowledge file lets its holder do
+type KnowledgeFileLinkScope string
+
+const (
+	// KnowledgeFileLinkScopeView serves the file inline, for embedding in viewers and other tools
+	KnowledgeFileLinkScopeView KnowledgeFileLinkScope = "view"
+	// KnowledgeFileLinkScopeDownload serves the file as an attachment
+	KnowledgeFileLinkScopeDownload KnowledgeFileLinkScope = "download"
+)
+
+// IsValid checks if the scope is supported
+func (s KnowledgeFileLinkScope) IsValid() bool {
+	return s == KnowledgeFileLinkScopeView || s == KnowledgeFileLinkScopeDownload
+}
+
+// KnowledgeFileLink is an expiring signed URL to the file of a knowledge, usable without an API key. The token of
+// the URL only names the link, so a link can be limited in downloads and revoked before it expires.
+type KnowledgeFileLink struct {
+	ID string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	// TenantID is the tenant of the knowledge, which differs from the creator's for shared knowledge bases
+	TenantID    uint64                 `json:"tenant_id" gorm:"index"`
+	KnowledgeID string                 `json:"knowledge_id" gorm:"type:varchar(36);index"`
+	Scope       KnowledgeFileLinkScope `json:"scope" gorm:"type:varchar(16);not null"`
+	// MaxDownloads is the number of times the file can be fetched through the link, 0 for no limit
+	MaxDownloads  int        `json:"max_downloads"`
+	DownloadCount int        `json:"download_count"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	CreatedBy     string     `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for GORM
+func (KnowledgeFileLink) TableName() string {
+	return "knowledge_file_links"
+}
+
+// IsUsable checks if the link can still serve the file at the given time
+func (l *KnowledgeFileLink) IsUsable(now time.Time) bool {
+	if l.RevokedAt != nil || !now.Before(l.ExpiresAt) {
+		return false
+	}
+	return l.MaxDownloads == 0 || l.DownloadCount < l.MaxDownloads
+}
+
+// CreateKnowledgeFileLinkRequest creates a signed link to the file of a knowledge
+type CreateKnowledgeFileLinkRequest struct {
+	// Scope is view or download, download when empty
+	Scope KnowledgeFileLinkScope `json:"scope"`
+	// ExpiresInHours is how long the link stays valid, 24 when zero and at most 720
+	ExpiresInHours int `json:"expires_in_hours"`
+	// MaxDownloads limits the number of fetches through the link, 0 for no limit
+	MaxDownloads int `json:"max_downloads"`
+}
+
+// KnowledgeFileLinkWithURL is returned when a link is created, the only time its token is visible
+type KnowledgeFileLinkWithURL struct {
+	*KnowledgeFileLink
+	// Token signs the link ID and expiry
+	Token string `json:"token"`
+	// Path is the API path serving the file
+	Path string `json:"path"`
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKnowledgeFileLinkIsUsable(t *testing.T) {
+	now := time.Now()
+	revoked := now.Add(-time.Minute)
+	tests := []struct {
+		name string
+		link KnowledgeFileLink
+		want bool
+	}{
+		{"valid", KnowledgeFileLink{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", KnowledgeFileLink{ExpiresAt: now}, false},
+		{"revoked", KnowledgeFileLink{ExpiresAt: now.Add(time.Hour), RevokedAt: &revoked}, false},
+		{"downloads left", KnowledgeFileLink{ExpiresAt: now.Add(time.Hour), MaxDownloads: 2, DownloadCount: 1}, true},
+		{"used up", KnowledgeFileLink{ExpiresAt: now.Add(time.Hour), MaxDownloads: 2, DownloadCount: 2}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.link.IsUsable(now); got != tt.want {
+			t.Errorf("%s: IsUsable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !KnowledgeFileLinkScopeView.IsValid() || KnowledgeFileLinkScope("edit").IsValid() {
+		t.Error("only view and download scopes should be valid")
+	}
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// KnowledgeLinkStatus is the state of the source URL of a knowledge at its last check
+type KnowledgeLinkStatus string
+
+const (
+	// KnowledgeLinkOK is a source still serving what was imported
+	KnowledgeLinkOK KnowledgeLinkStatus = "ok"
+	// KnowledgeLinkDead is a source answering 404 Not Found or 410 Gone
+	KnowledgeLinkDead KnowledgeLinkStatus = "dead"
+	// KnowledgeLinkChanged is a source serving something else than what was imported, see the reasons
+	KnowledgeLinkChanged KnowledgeLinkStatus = "changed"
+	// KnowledgeLinkUnreachable is a source that failed otherwise, such as a timeout or a server error, which may
+	// be transient
+	KnowledgeLinkUnreachable KnowledgeLinkStatus = "unreachable"
+)
+
+// KnowledgeLinkCheck is the result of the last check of the source URL of a knowledge
+type KnowledgeLinkCheck struct {
+	Status    KnowledgeLinkStatus `json:"status"`
+	CheckedAt time.Time           `json:"checked_at"`
+	// StatusCode is the HTTP status of the source, 0 when it did not answer
+	StatusCode int `json:"status_code,omitempty"`
+	// FinalURL is the source URL after redirects
+	FinalURL string `json:"final_url,omitempty"`
+	// Reasons explain a changed or unreachable source
+	Reasons []string `json:"reasons,omitempty"`
+	// Title is the page title served at the last check
+	Title string `json:"title,omitempty"`
+	// BaselineTitle is the page title of the first successful check, later titles are compared to it
+	BaselineTitle string `json:"baseline_title,omitempty"`
+	// FailedChecks counts the checks in a row the source was unreachable
+	FailedChecks int `json:"failed_checks,omitempty"`
+}
+
+// Value implements the driver.Valuer interface
+func (c KnowledgeLinkCheck) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *KnowledgeLinkCheck) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// KnowledgeLinkReport lists the URL knowledge of a knowledge base whose source needs attention
+type KnowledgeLinkReport struct {
+	// Counts is the number of URL knowledge by status of their source, unchecked ones under "unchecked"
+	Counts map[string]int64 `json:"counts"`
+	// Items are the knowledge with the requested statuses, the most recently checked first
+	Items []*Knowledge `json:"items"`
+}
+
+// KnowledgeLinkCheckPayload is the payload of a link check run, empty to check every knowledge base
+type KnowledgeLinkCheckPayload struct {
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+}
@@ -0,0 +1,153 @@
+package types
+
+import "time"
+
+// KnowledgeDiffOp is the change a line of a content diff makes
+type KnowledgeDiffOp string
+
+const (
+	// KnowledgeDiffEqual is a line kept as is, shown around changes for context
+	KnowledgeDiffEqual KnowledgeDiffOp = "equal"
+	// KnowledgeDiffInsert is a line only in the new content
+	KnowledgeDiffInsert KnowledgeDiffOp = "insert"
+	// KnowledgeDiffDelete is a line only in the current content
+	KnowledgeDiffDelete KnowledgeDiffOp = "delete"
+)
+
+// maxKnowledgeDiffCells bounds the memory of a line diff, changed regions larger than this many line pairs are
+// shown as deleted and inserted whole
+const maxKnowledgeDiffCells = 4 << 20
+
+// KnowledgeDiffLine is a line of a content diff, numbered in the content it appears in
+type KnowledgeDiffLine struct {
+	Op KnowledgeDiffOp `json:"op"`
+	// OldLine is the line number in the current content, 0 for inserted lines
+	OldLine int `json:"old_line,omitempty"`
+	// NewLine is the line number in the new content, 0 for deleted lines
+	NewLine int    `json:"new_line,omitempty"`
+	Text    string `json:"text"`
+}
+
+// KnowledgeRecapturePreview is the difference between the content of a URL knowledge and a new capture of its page,
+// which replaces the content once confirmed
+type KnowledgeRecapturePreview struct {
+	// PreviewID confirms this capture, and no later one, replaces the content
+	PreviewID   string `json:"preview_id"`
+	KnowledgeID string `json:"knowledge_id"`
+	// Capture is the provenance the knowledge gets once the replacement is confirmed
+	Capture *KnowledgeCapture `json:"capture"`
+	// Changed is false when the captured content is identical to the current content
+	Changed      bool `json:"changed"`
+	AddedLines   int  `json:"added_lines"`
+	RemovedLines int  `json:"removed_lines"`
+	// Warnings flag captures that look like error pages rather than the page content
+	Warnings []string `json:"warnings"`
+	// Diff holds the changed lines with a few lines of context, lines far from any change are left out
+	Diff []KnowledgeDiffLine `json:"diff"`
+	// ExpiresAt is the time after which the preview can no longer be confirmed
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PreviewKnowledgeRecaptureRequest captures the page of a URL knowledge again
+type PreviewKnowledgeRecaptureRequest struct {
+	// ExtractionMode replaces the web extraction mode of the last capture: full, readability or llm
+	ExtractionMode string `json:"extraction_mode"`
+}
+
+// ConfirmKnowledgeRecaptureRequest replaces the content of a URL knowledge with a previewed capture
+type ConfirmKnowledgeRecaptureRequest struct {
+	PreviewID string `json:"preview_id" binding:"required"`
+}
+
+// DiffLines computes the line diff turning oldLines into newLines, keeping context equal lines around each change.
+// It also returns the number of inserted and deleted lines.
+func DiffLines(oldLines, newLines []string, context int) (diff []KnowledgeDiffLine, added, removed int) {
+	ops := diffLineOps(oldLines, newLines)
+	keep := make([]bool, len(ops))
+	for i, op := range ops {
+		switch op.Op {
+		case KnowledgeDiffEqual:
+			continue
+		case KnowledgeDiffInsert:
+			added++
+		case KnowledgeDiffDelete:
+			removed++
+		}
+		for j := max(0, i-context); j <= min(len(ops)-1, i+context); j++ {
+			keep[j] = true
+		}
+	}
+	diff = []KnowledgeDiffLine{}
+	for i, op := range ops {
+		if keep[i] {
+			diff = append(diff, op)
+		}
+	}
+	return diff, added, removed
+}
+
+// diffLineOps returns every line of both contents as kept, inserted or deleted, following their longest common
+// subsequence
+func diffLineOps(a, b []string) []KnowledgeDiffLine {
+	// Pages usually change in a few places, the common head and tail are matched without the quadratic table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]KnowledgeDiffLine, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, KnowledgeDiffLine{Op: KnowledgeDiffEqual, OldLine: i + 1, NewLine: i + 1, Text: a[i]})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(midA), len(midB)
+	// lcs[i*(m+1)+j] is the length of the longest common subsequence of midA[i:] and midB[j:]
+	var lcs []int32
+	if n*m <= maxKnowledgeDiffCells {
+		lcs = make([]int32, (n+1)*(m+1))
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+				} else {
+					lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+				}
+			}
+		}
+	}
+	i, j := 0, 0
+	for lcs != nil && i < n && j < m {
+		switch {
+		case midA[i] == midB[j]:
+			ops = append(ops, KnowledgeDiffLine{
+				Op: KnowledgeDiffEqual, OldLine: prefix + i + 1, NewLine: prefix + j + 1, Text: midA[i],
+			})
+			i, j = i+1, j+1
+		case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+			ops = append(ops, KnowledgeDiffLine{Op: KnowledgeDiffDelete, OldLine: prefix + i + 1, Text: midA[i]})
+			i++
+		default:
+			ops = append(ops, KnowledgeDiffLine{Op: KnowledgeDiffInsert, NewLine: prefix + j + 1, Text: midB[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, KnowledgeDiffLine{Op: KnowledgeDiffDelete, OldLine: prefix + i + 1, Text: midA[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, KnowledgeDiffLine{Op: KnowledgeDiffInsert, NewLine: prefix + j + 1, Text: midB[j]})
+	}
+
+	for k := 0; k < suffix; k++ {
+		oldIndex, newIndex := len(a)-suffix+k, len(b)-suffix+k
+		ops = append(ops, KnowledgeDiffLine{
+			Op: KnowledgeDiffEqual, OldLine: oldIndex + 1, NewLine: newIndex + 1, Text: a[oldIndex],
+		})
+	}
+	return ops
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	oldLines := []string{"title", "a", "b", "c", "d", "e", "footer"}
+	newLines := []string{"title", "a", "B", "c", "d", "e", "new", "footer"}
+
+	diff, added, removed := DiffLines(oldLines, newLines, 1)
+	want := []KnowledgeDiffLine{
+		{Op: KnowledgeDiffEqual, OldLine: 2, NewLine: 2, Text: "a"},
+		{Op: KnowledgeDiffDelete, OldLine: 3, Text: "b"},
+		{Op: KnowledgeDiffInsert, NewLine: 3, Text: "B"},
+		{Op: KnowledgeDiffEqual, OldLine: 4, NewLine: 4, Text: "c"},
+		{Op: KnowledgeDiffEqual, OldLine: 6, NewLine: 6, Text: "e"},
+		{Op: KnowledgeDiffInsert, NewLine: 7, Text: "new"},
+		{Op: KnowledgeDiffEqual, OldLine: 7, NewLine: 8, Text: "footer"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("DiffLines() = %+v, want %+v", diff, want)
+	}
+	if added != 2 || removed != 1 {
+		t.Errorf("DiffLines() added, removed = %d, %d, want 2, 1", added, removed)
+	}
+}
+
+func TestDiffLinesUnchanged(t *testing.T) {
+	lines := []string{"a", "b"}
+	diff, added, removed := DiffLines(lines, lines, 3)
+	if len(diff) != 0 || added != 0 || removed != 0 {
+		t.Errorf("DiffLines() of identical content = %+v, %d, %d, want no changes", diff, added, removed)
+	}
+}
+
+func TestDiffLinesReplaced(t *testing.T) {
+	diff, added, removed := DiffLines([]string{"page"}, []string{"404", "Not Found"}, 3)
+	if added != 2 || removed != 1 || len(diff) != 3 {
+		t.Errorf("DiffLines() = %+v, %d, %d, want the page replaced", diff, added, removed)
+	}
+}
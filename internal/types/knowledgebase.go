@@ -3,8 +3,13 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -67,6 +72,22 @@ type KnowledgeBase struct {
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"              gorm:"column:faq_config;type:json"`
 	// QuestionGenerationConfig stores question generation configuration for document knowledge bases
 	QuestionGenerationConfig *QuestionGenerationConfig `yaml:"question_generation_config" json:"question_generation_config" gorm:"column:question_generation_config;type:json"`
+	// RetentionConfig stores the lifecycle policy applied to knowledge in this knowledge base
+	RetentionConfig *RetentionConfig `yaml:"retention_config"        json:"retention_config"        gorm:"column:retention_config;type:json"`
+	// OCRConfig selects the OCR engine and languages used by DocReader, nil uses the service default
+	OCRConfig *OCRConfig `yaml:"ocr_config"              json:"ocr_config"              gorm:"column:ocr_config;type:json"`
+	// WebExtractionConfig selects how web pages are extracted for URL knowledge, nil uses readability
+	WebExtractionConfig *WebExtractionConfig `yaml:"web_extraction_config"   json:"web_extraction_config"   gorm:"column:web_extraction_config;type:json"`
+	// URLFetchConfig sets the User-Agent, headers and cookies URL knowledge is fetched with, nil uses the defaults
+	URLFetchConfig *URLFetchConfig `yaml:"url_fetch_config"        json:"url_fetch_config"        gorm:"column:url_fetch_config;type:json"`
+	// ImageEmbeddingConfig enables visual vectors for image knowledge, nil disables them
+	ImageEmbeddingConfig *ImageEmbeddingConfig `yaml:"image_embedding_config"  json:"image_embedding_config"  gorm:"column:image_embedding_config;type:json"`
+	// RerankConfig overrides how retrieval results of this knowledge base are reranked, nil uses the session settings
+	RerankConfig *RerankConfig `yaml:"rerank_config"           json:"rerank_config"           gorm:"column:rerank_config;type:json"`
+	// SemanticCacheConfig enables answering near-identical questions from cached answers, nil disables it
+	SemanticCacheConfig *SemanticCacheConfig `yaml:"semantic_cache_config"   json:"semantic_cache_config"   gorm:"column:semantic_cache_config;type:json"`
+	// KeywordSearchConfig selects the keyword index analyzer and the fusion weights of hybrid search, nil uses the defaults
+	KeywordSearchConfig *KeywordSearchConfig `yaml:"keyword_search_config"   json:"keyword_search_config"   gorm:"column:keyword_search_config;type:json"`
 	// Creation time of the knowledge base
 	CreatedAt time.Time `yaml:"created_at"              json:"created_at"`
 	// Last updated time of the knowledge base
@@ -93,6 +114,24 @@ type KnowledgeBaseConfig struct {
 	ImageProcessingConfig ImageProcessingConfig `yaml:"image_processing_config" json:"image_processing_config"`
 	// FAQ configuration (only for FAQ type knowledge bases)
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"`
+	// Retention (lifecycle) configuration
+	RetentionConfig *RetentionConfig `yaml:"retention_config"        json:"retention_config"`
+	// OCR engine and language configuration
+	OCRConfig *OCRConfig `yaml:"ocr_config"              json:"ocr_config"`
+	// Vision model, prompt and image limit of image understanding
+	VLMConfig *VLMConfig `yaml:"vlm_config"              json:"vlm_config"`
+	// Web page extraction mode of URL knowledge
+	WebExtractionConfig *WebExtractionConfig `yaml:"web_extraction_config"   json:"web_extraction_config"`
+	// User-Agent, headers and cookies of URL fetching
+	URLFetchConfig *URLFetchConfig `yaml:"url_fetch_config"        json:"url_fetch_config"`
+	// Image embedding configuration
+	ImageEmbeddingConfig *ImageEmbeddingConfig `yaml:"image_embedding_config"  json:"image_embedding_config"`
+	// Rerank configuration
+	RerankConfig *RerankConfig `yaml:"rerank_config"           json:"rerank_config"`
+	// Semantic answer cache configuration
+	SemanticCacheConfig *SemanticCacheConfig `yaml:"semantic_cache_config"   json:"semantic_cache_config"`
+	// Keyword analyzer and fusion weight configuration
+	KeywordSearchConfig *KeywordSearchConfig `yaml:"keyword_search_config"   json:"keyword_search_config"`
 }
 
 // ChunkingConfig represents the document splitting configuration
@@ -194,6 +233,39 @@ type VLMConfig struct {
 	APIKey string `yaml:"api_key" json:"api_key"`
 	// Interface Type: "ollama" or "openai"
 	InterfaceType string `yaml:"interface_type" json:"interface_type"`
+
+	// Prompt replaces the default image description prompt of DocReader, empty uses the default
+	Prompt string `yaml:"prompt"                  json:"prompt,omitempty"`
+	// MaxImagesPerDocument caps the images of a document sent to the VLM, zero means no limit.
+	// Images beyond the limit are still stored and OCRed, but get no description.
+	MaxImagesPerDocument int `yaml:"max_images_per_document" json:"max_images_per_document,omitempty"`
+}
+
+// MaxVLMPromptLength is the maximum length in characters of a custom VLM prompt
+const MaxVLMPromptLength = 2000
+
+// Validate checks the prompt and image limit of the VLM config
+func (c *VLMConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if len([]rune(c.Prompt)) > MaxVLMPromptLength {
+		return fmt.Errorf("VLM prompt cannot exceed %d characters", MaxVLMPromptLength)
+	}
+	if c.MaxImagesPerDocument < 0 {
+		return errors.New("max images per document cannot be negative")
+	}
+	return nil
+}
+
+// VLMPreviewResult is the image understanding output of a knowledge base's VLM settings for one image
+type VLMPreviewResult struct {
+	Model   string `json:"model"`
+	Prompt  string `json:"prompt,omitempty"`
+	Caption string `json:"caption"`
+	OCRText string `json:"ocr_text"`
+	// ProcessingTime is the DocReader round trip in milliseconds
+	ProcessingTime int64 `json:"processing_time"`
 }
 
 // IsEnabled 判断多模态是否启用（兼容新老版本）
@@ -218,6 +290,8 @@ type QuestionGenerationConfig struct {
 	Enabled bool `yaml:"enabled"  json:"enabled"`
 	// Number of questions to generate per chunk (default: 3, max: 10)
 	QuestionCount int `yaml:"question_count" json:"question_count"`
+	// GenerateAnswers generates a grounded answer for each question, producing Q&A pairs
+	GenerateAnswers bool `yaml:"generate_answers" json:"generate_answers"`
 }
 
 // Value implements the driver.Valuer interface
@@ -237,6 +311,715 @@ func (c *QuestionGenerationConfig) Scan(value interface{}) error {
 	return json.Unmarshal(b, c)
 }
 
+// Retention actions applied to expired knowledge
+const (
+	// RetentionActionArchive excludes expired knowledge from retrieval but keeps its data
+	RetentionActionArchive = "archive"
+	// RetentionActionDelete deletes expired knowledge
+	RetentionActionDelete = "delete"
+)
+
+// RetentionConfig represents the lifecycle policy of a knowledge base.
+// Knowledge expires at its explicit ExpiresAt, or MaxAgeDays after creation when MaxAgeDays > 0.
+type RetentionConfig struct {
+	Enabled bool `yaml:"enabled"            json:"enabled"`
+	// Maximum age in days after creation, 0 means only explicit expiration dates apply
+	MaxAgeDays int `yaml:"max_age_days"       json:"max_age_days"`
+	// Action applied on expiry: archive or delete (default: archive)
+	Action string `yaml:"action"             json:"action"`
+	// Days before expiry at which an expiring notification is emitted, 0 disables notifications
+	NotifyBeforeDays int `yaml:"notify_before_days" json:"notify_before_days"`
+}
+
+// GetAction returns the configured action, defaulting to archive
+func (c *RetentionConfig) GetAction() string {
+	if c == nil || c.Action != RetentionActionDelete {
+		return RetentionActionArchive
+	}
+	return RetentionActionDelete
+}
+
+// Value implements the driver.Valuer interface
+func (c RetentionConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *RetentionConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// OCR engines supported by DocReader
+const (
+	// OCREnginePaddle uses PaddleOCR, which recognizes a single language per model
+	OCREnginePaddle = "paddle"
+	// OCREngineTesseract uses Tesseract, which combines all configured languages
+	OCREngineTesseract = "tesseract"
+	// OCREngineVLM transcribes images with a vision language model only
+	OCREngineVLM = "vlm"
+)
+
+// OCRConfig represents the OCR settings of a knowledge base
+type OCRConfig struct {
+	// OCR engine: paddle, tesseract or vlm; empty uses the DocReader default
+	Engine string `yaml:"engine"    json:"engine"`
+	// ISO 639-1 codes of the document languages, e.g. zh, en, ja; empty uses the engine default
+	Languages []string `yaml:"languages" json:"languages"`
+}
+
+// Validate checks the engine and languages of the OCR config
+func (c *OCRConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Engine {
+	case "", OCREnginePaddle, OCREngineTesseract, OCREngineVLM:
+	default:
+		return fmt.Errorf("unsupported OCR engine: %s", c.Engine)
+	}
+	for _, lang := range c.Languages {
+		if strings.TrimSpace(lang) == "" {
+			return errors.New("OCR language cannot be empty")
+		}
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (c OCRConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *OCRConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+const (
+	// WebExtractionModeFull converts the whole rendered page, navigation and footers included
+	WebExtractionModeFull = "full"
+	// WebExtractionModeReadability keeps only the main article of the page
+	WebExtractionModeReadability = "readability"
+	// WebExtractionModeLLM keeps the main article, then has the summary model remove boilerplate and normalize
+	// headings
+	WebExtractionModeLLM = "llm"
+)
+
+// ValidWebExtractionMode reports whether mode is a web extraction mode, empty meaning the default
+func ValidWebExtractionMode(mode string) bool {
+	switch mode {
+	case "", WebExtractionModeFull, WebExtractionModeReadability, WebExtractionModeLLM:
+		return true
+	default:
+		return false
+	}
+}
+
+// WebExtractionConfig represents how the web pages of URL knowledge are extracted
+type WebExtractionConfig struct {
+	// Extraction mode: full, readability or llm; empty uses readability
+	Mode string `yaml:"mode" json:"mode"`
+}
+
+// Validate checks the mode of the web extraction config
+func (c *WebExtractionConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if !ValidWebExtractionMode(c.Mode) {
+		return fmt.Errorf("unsupported web extraction mode: %s", c.Mode)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (c WebExtractionConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *WebExtractionConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// WebExtractionMode returns the mode web pages are extracted with, override taking precedence over the knowledge
+// base setting
+func (kb *KnowledgeBase) WebExtractionMode(override string) string {
+	if override != "" {
+		return override
+	}
+	if kb.WebExtractionConfig != nil && kb.WebExtractionConfig.Mode != "" {
+		return kb.WebExtractionConfig.Mode
+	}
+	return WebExtractionModeReadability
+}
+
+// urlFetchMaskedValue replaces credential values in API responses, an update sending it keeps the stored value
+const urlFetchMaskedValue = "******"
+
+const (
+	maxURLFetchUserAgentLength = 512
+	maxURLFetchValueLength     = 4096
+	maxURLFetchHeaders         = 20
+	maxURLFetchCookies         = 20
+	maxURLFetchHosts           = 20
+)
+
+// urlFetchHostPattern matches a host name, or a domain with a leading dot standing for its subdomains
+var urlFetchHostPattern = regexp.MustCompile(`^\.?[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// urlFetchHeaderName matches the token allowed as an HTTP header name
+var urlFetchHeaderName = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// urlFetchReservedHeaders are set by the fetcher itself or through the other fields of URLFetchConfig
+var urlFetchReservedHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"transfer-encoding": true,
+	"cookie":            true,
+	"user-agent":        true,
+}
+
+// URLFetchConfig represents the User-Agent, headers and cookies sent when fetching the pages and files of URL
+// knowledge, for sites that block the default client or sit behind a login. Headers and cookies are only sent to
+// the hosts listed in Hosts. Credential headers and cookies are stored encrypted and masked in API responses.
+type URLFetchConfig struct {
+	// UserAgent replaces the default User-Agent, empty keeps it
+	UserAgent string `yaml:"user_agent" json:"user_agent"`
+	// Headers are added to the requests made to the site of the URL
+	Headers map[string]string `yaml:"headers"    json:"headers"`
+	// Cookies are sent to the site of the URL, by name
+	Cookies map[string]string `yaml:"cookies"    json:"cookies"`
+	// Hosts are the hosts headers and cookies are sent to, an entry starting with a dot matches the subdomains
+	// of the domain. Required when there are headers or cookies.
+	Hosts []string `yaml:"hosts"      json:"hosts"`
+}
+
+// Validate checks the User-Agent, header names and values of the URL fetch config, and that credentials can be
+// encrypted
+func (c *URLFetchConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if len(c.UserAgent) > maxURLFetchUserAgentLength || strings.ContainsAny(c.UserAgent, "\r\n") {
+		return fmt.Errorf("user_agent must be at most %d characters on a single line", maxURLFetchUserAgentLength)
+	}
+	if len(c.Headers) > maxURLFetchHeaders {
+		return fmt.Errorf("at most %d headers are allowed", maxURLFetchHeaders)
+	}
+	for name, value := range c.Headers {
+		if !urlFetchHeaderName.MatchString(name) {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+		if urlFetchReservedHeaders[strings.ToLower(name)] {
+			return fmt.Errorf("header %s cannot be set, use user_agent or cookies", name)
+		}
+		if len(value) > maxURLFetchValueLength || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("value of header %s must be at most %d characters on a single line",
+				name, maxURLFetchValueLength)
+		}
+	}
+	if len(c.Cookies) > maxURLFetchCookies {
+		return fmt.Errorf("at most %d cookies are allowed", maxURLFetchCookies)
+	}
+	for name, value := range c.Cookies {
+		if !urlFetchHeaderName.MatchString(name) {
+			return fmt.Errorf("invalid cookie name: %q", name)
+		}
+		if len(value) > maxURLFetchValueLength || strings.ContainsAny(value, ";\r\n") {
+			return fmt.Errorf("value of cookie %s must be at most %d characters without ';'",
+				name, maxURLFetchValueLength)
+		}
+	}
+	if len(c.Hosts) > maxURLFetchHosts {
+		return fmt.Errorf("at most %d hosts are allowed", maxURLFetchHosts)
+	}
+	for i, host := range c.Hosts {
+		c.Hosts[i] = strings.ToLower(strings.TrimSpace(host))
+		if !urlFetchHostPattern.MatchString(c.Hosts[i]) {
+			return fmt.Errorf("invalid host: %q, use a host name such as wiki.example.com or .example.com", host)
+		}
+	}
+	if (len(c.Headers) > 0 || len(c.Cookies) > 0) && len(c.Hosts) == 0 {
+		return errors.New("hosts must list the hosts headers and cookies are sent to")
+	}
+	if c.HasCredentials() && !utils.CanEncryptSecrets() {
+		return errors.New("cookies and credential headers need TENANT_AES_KEY to be set to be stored encrypted")
+	}
+	return nil
+}
+
+// SendsTo reports whether the headers and cookies of the config are sent to a host
+func (c *URLFetchConfig) SendsTo(host string) bool {
+	if c == nil {
+		return false
+	}
+	host = strings.ToLower(host)
+	for _, entry := range c.Hosts {
+		if host == entry || (strings.HasPrefix(entry, ".") && strings.HasSuffix(host, entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCredentialHeader reports whether a header likely carries a credential, by its name
+func isCredentialHeader(name string) bool {
+	name = strings.ToLower(name)
+	if name == "authorization" || name == "proxy-authorization" {
+		return true
+	}
+	for _, word := range []string{"token", "key", "secret", "auth", "session", "password"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCredentials reports whether the config holds cookies or credential headers, which need an encryption key
+func (c *URLFetchConfig) HasCredentials() bool {
+	if c == nil {
+		return false
+	}
+	if len(c.Cookies) > 0 {
+		return true
+	}
+	for name := range c.Headers {
+		if isCredentialHeader(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeMasked replaces the masked values of an update with the values stored in existing, so reading a config and
+// sending it back does not overwrite credentials with the mask
+func (c *URLFetchConfig) MergeMasked(existing *URLFetchConfig) {
+	if c == nil {
+		return
+	}
+	for name, value := range c.Headers {
+		if value != urlFetchMaskedValue {
+			continue
+		}
+		if existing != nil && existing.Headers[name] != "" {
+			c.Headers[name] = existing.Headers[name]
+		} else {
+			delete(c.Headers, name)
+		}
+	}
+	for name, value := range c.Cookies {
+		if value != urlFetchMaskedValue {
+			continue
+		}
+		if existing != nil && existing.Cookies[name] != "" {
+			c.Cookies[name] = existing.Cookies[name]
+		} else {
+			delete(c.Cookies, name)
+		}
+	}
+}
+
+// DropMasked removes the masked values, which an exported config holds in place of its credentials
+func (c *URLFetchConfig) DropMasked() {
+	c.MergeMasked(nil)
+}
+
+// urlFetchConfigJSON has the fields of URLFetchConfig without its methods
+type urlFetchConfigJSON URLFetchConfig
+
+// MarshalJSON masks cookies and credential headers
+func (c URLFetchConfig) MarshalJSON() ([]byte, error) {
+	masked := urlFetchConfigJSON{UserAgent: c.UserAgent, Hosts: c.Hosts}
+	if c.Headers != nil {
+		masked.Headers = make(map[string]string, len(c.Headers))
+		for name, value := range c.Headers {
+			if isCredentialHeader(name) {
+				value = urlFetchMaskedValue
+			}
+			masked.Headers[name] = value
+		}
+	}
+	if c.Cookies != nil {
+		masked.Cookies = make(map[string]string, len(c.Cookies))
+		for name := range c.Cookies {
+			masked.Cookies[name] = urlFetchMaskedValue
+		}
+	}
+	return json.Marshal(masked)
+}
+
+// Value implements the driver.Valuer interface, encrypting cookies and credential headers
+func (c URLFetchConfig) Value() (driver.Value, error) {
+	stored := urlFetchConfigJSON{UserAgent: c.UserAgent, Hosts: c.Hosts}
+	if c.Headers != nil {
+		stored.Headers = make(map[string]string, len(c.Headers))
+		for name, value := range c.Headers {
+			if isCredentialHeader(name) {
+				encrypted, err := utils.EncryptSecret(value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encrypt header %s: %w", name, err)
+				}
+				value = encrypted
+			}
+			stored.Headers[name] = value
+		}
+	}
+	if c.Cookies != nil {
+		stored.Cookies = make(map[string]string, len(c.Cookies))
+		for name, value := range c.Cookies {
+			encrypted, err := utils.EncryptSecret(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt cookie %s: %w", name, err)
+			}
+			stored.Cookies[name] = encrypted
+		}
+	}
+	return json.Marshal(stored)
+}
+
+// Scan implements the sql.Scanner interface, decrypting cookies and credential headers. Values that no longer
+// decrypt, after the key changed, are dropped rather than sent in their encrypted form.
+func (c *URLFetchConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	var stored urlFetchConfigJSON
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return err
+	}
+	for name, value := range stored.Headers {
+		if plaintext, err := utils.DecryptSecret(value); err == nil {
+			stored.Headers[name] = plaintext
+		} else {
+			delete(stored.Headers, name)
+		}
+	}
+	for name, value := range stored.Cookies {
+		if plaintext, err := utils.DecryptSecret(value); err == nil {
+			stored.Cookies[name] = plaintext
+		} else {
+			delete(stored.Cookies, name)
+		}
+	}
+	*c = URLFetchConfig(stored)
+	return nil
+}
+
+// ImageEmbeddingConfig represents the visual embedding settings of a knowledge base.
+// Image knowledge is embedded with a multimodal embedding model in addition to its
+// OCR and caption text, and can then be found by searching with an image.
+type ImageEmbeddingConfig struct {
+	// Enabled turns on image embedding for newly parsed image knowledge
+	Enabled bool `yaml:"enabled"  json:"enabled"`
+	// ModelID is a multimodal embedding model that accepts images, e.g. multimodal-embedding-v1
+	ModelID string `yaml:"model_id" json:"model_id"`
+}
+
+// IsEnabled reports whether image knowledge should be embedded
+func (c *ImageEmbeddingConfig) IsEnabled() bool {
+	return c != nil && c.Enabled && c.ModelID != ""
+}
+
+// Validate checks that an enabled config names an embedding model
+func (c *ImageEmbeddingConfig) Validate() error {
+	if c != nil && c.Enabled && strings.TrimSpace(c.ModelID) == "" {
+		return errors.New("image embedding model is required when image embedding is enabled")
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (c ImageEmbeddingConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *ImageEmbeddingConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// Rerank types supported by RerankConfig
+const (
+	// RerankTypeModel reranks with a rerank model, e.g. a local bge-reranker, Cohere Rerank or Jina
+	RerankTypeModel = "model"
+	// RerankTypeLLM reranks by asking a chat model to judge passage relevance
+	RerankTypeLLM = "llm"
+	// RerankTypeNone keeps the retrieval scores of the knowledge base
+	RerankTypeNone = "none"
+)
+
+// RerankConfig represents the per knowledge base rerank stage applied to hybrid search
+// results before they reach the generator
+type RerankConfig struct {
+	// Type selects the reranker: model, llm or none
+	Type string `yaml:"type"       json:"type"`
+	// ModelID is a rerank model for the model type, or a chat model for the llm type
+	ModelID string `yaml:"model_id"   json:"model_id"`
+	// Threshold drops results scored below it, 0 uses the session threshold
+	Threshold float64 `yaml:"threshold"  json:"threshold"`
+	// TimeoutMs is the latency budget of the rerank call, on timeout the retrieval scores are kept
+	TimeoutMs int `yaml:"timeout_ms" json:"timeout_ms"`
+}
+
+// MaxRerankTimeoutMs bounds the latency budget of a knowledge base rerank call
+const MaxRerankTimeoutMs = 60000
+
+// Validate checks the rerank type, model and bounds
+func (c *RerankConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Type {
+	case RerankTypeModel, RerankTypeLLM:
+		if strings.TrimSpace(c.ModelID) == "" {
+			return fmt.Errorf("rerank model is required for rerank type %s", c.Type)
+		}
+	case RerankTypeNone:
+	default:
+		return fmt.Errorf("unsupported rerank type: %s", c.Type)
+	}
+	if c.Threshold < 0 || c.Threshold > 1 {
+		return errors.New("rerank threshold must be between 0 and 1")
+	}
+	if c.TimeoutMs < 0 || c.TimeoutMs > MaxRerankTimeoutMs {
+		return fmt.Errorf("rerank timeout must be between 0 and %d ms", MaxRerankTimeoutMs)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (c RerankConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *RerankConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// SemanticCacheConfig represents the semantic answer cache of a knowledge base
+// Questions whose embedding is close enough to a cached question are answered from the cache
+type SemanticCacheConfig struct {
+	Enabled bool `yaml:"enabled"     json:"enabled"`
+	// Threshold is the minimum cosine similarity of a cache hit, 0 uses DefaultSemanticCacheThreshold
+	Threshold float64 `yaml:"threshold"   json:"threshold"`
+	// TTLSeconds is how long a cached answer is kept, 0 uses DefaultSemanticCacheTTLSeconds
+	TTLSeconds int `yaml:"ttl_seconds" json:"ttl_seconds"`
+}
+
+// Semantic cache defaults and bounds
+const (
+	DefaultSemanticCacheThreshold  = 0.95
+	DefaultSemanticCacheTTLSeconds = 24 * 60 * 60
+	MaxSemanticCacheTTLSeconds     = 30 * 24 * 60 * 60
+)
+
+// IsEnabled reports whether the semantic cache is turned on
+func (c *SemanticCacheConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// GetThreshold returns the similarity threshold, falling back to the default
+func (c *SemanticCacheConfig) GetThreshold() float64 {
+	if c == nil || c.Threshold <= 0 {
+		return DefaultSemanticCacheThreshold
+	}
+	return c.Threshold
+}
+
+// GetTTL returns how long cached answers are kept, falling back to the default
+func (c *SemanticCacheConfig) GetTTL() time.Duration {
+	if c == nil || c.TTLSeconds <= 0 {
+		return DefaultSemanticCacheTTLSeconds * time.Second
+	}
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+// Validate checks the threshold and TTL bounds
+func (c *SemanticCacheConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Threshold < 0 || c.Threshold > 1 {
+		return errors.New("semantic cache threshold must be between 0 and 1")
+	}
+	if c.TTLSeconds < 0 || c.TTLSeconds > MaxSemanticCacheTTLSeconds {
+		return fmt.Errorf("semantic cache ttl must be between 0 and %d seconds", MaxSemanticCacheTTLSeconds)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (c SemanticCacheConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *SemanticCacheConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// KeywordAnalyzer selects how the keyword (BM25) index tokenizes chunk content and queries
+type KeywordAnalyzer string
+
+const (
+	// KeywordAnalyzerDefault segments Chinese with the index default tokenizer
+	KeywordAnalyzerDefault KeywordAnalyzer = ""
+	// KeywordAnalyzerChinese segments Chinese with jieba
+	KeywordAnalyzerChinese KeywordAnalyzer = "chinese"
+	// KeywordAnalyzerEnglish lowercases and stems English words
+	KeywordAnalyzerEnglish KeywordAnalyzer = "english"
+	// KeywordAnalyzerCode splits source code identifiers on camelCase, snake_case and punctuation
+	KeywordAnalyzerCode KeywordAnalyzer = "code"
+)
+
+// MaxFusionWeight bounds the retriever weights of hybrid search fusion
+const MaxFusionWeight = 10
+
+// KeywordSearchConfig represents the keyword retrieval settings of a knowledge base and how
+// its results are fused with vector retrieval
+type KeywordSearchConfig struct {
+	// Analyzer of the keyword index, only the Postgres (ParadeDB) retrieval engine supports one.
+	// Chunks of source code files are always matched with the code analyzer.
+	Analyzer KeywordAnalyzer `yaml:"analyzer"       json:"analyzer"`
+	// VectorWeight scales the RRF contribution of vector hits, 0 uses 1
+	VectorWeight float64 `yaml:"vector_weight"  json:"vector_weight"`
+	// KeywordWeight scales the RRF contribution of keyword hits, 0 uses 1
+	KeywordWeight float64 `yaml:"keyword_weight" json:"keyword_weight"`
+}
+
+// GetAnalyzer returns the keyword analyzer, falling back to the default
+func (c *KeywordSearchConfig) GetAnalyzer() KeywordAnalyzer {
+	if c == nil {
+		return KeywordAnalyzerDefault
+	}
+	return c.Analyzer
+}
+
+// GetVectorWeight returns the fusion weight of vector hits, falling back to 1
+func (c *KeywordSearchConfig) GetVectorWeight() float64 {
+	if c == nil || c.VectorWeight <= 0 {
+		return 1
+	}
+	return c.VectorWeight
+}
+
+// GetKeywordWeight returns the fusion weight of keyword hits, falling back to 1
+func (c *KeywordSearchConfig) GetKeywordWeight() float64 {
+	if c == nil || c.KeywordWeight <= 0 {
+		return 1
+	}
+	return c.KeywordWeight
+}
+
+// Validate checks the analyzer and weight bounds
+func (c *KeywordSearchConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Analyzer {
+	case KeywordAnalyzerDefault, KeywordAnalyzerChinese, KeywordAnalyzerEnglish, KeywordAnalyzerCode:
+	default:
+		return fmt.Errorf("unsupported keyword analyzer: %s", c.Analyzer)
+	}
+	if c.VectorWeight < 0 || c.VectorWeight > MaxFusionWeight ||
+		c.KeywordWeight < 0 || c.KeywordWeight > MaxFusionWeight {
+		return fmt.Errorf("fusion weights must be between 0 and %d", MaxFusionWeight)
+	}
+	return nil
+}
+
+// ValidateEngines checks the keyword retrieval engines apply the analyzer, other engines would ignore it
+func (c *KeywordSearchConfig) ValidateEngines(engines []RetrieverEngineParams) error {
+	if c.GetAnalyzer() == KeywordAnalyzerDefault || SupportsKeywordAnalyzers(engines) {
+		return nil
+	}
+	return fmt.Errorf("keyword analyzer %s requires the %s keyword retrieval engine",
+		c.Analyzer, PostgresRetrieverEngineType)
+}
+
+// SupportsKeywordAnalyzers reports whether keyword retrieval runs on engines that apply analyzers,
+// only the Postgres engine indexes content once per analyzer
+func SupportsKeywordAnalyzers(engines []RetrieverEngineParams) bool {
+	supported := false
+	for _, engine := range engines {
+		if engine.RetrieverType != KeywordsRetrieverType {
+			continue
+		}
+		if engine.RetrieverEngineType != PostgresRetrieverEngineType {
+			return false
+		}
+		supported = true
+	}
+	return supported
+}
+
+// Value implements the driver.Valuer interface
+func (c KeywordSearchConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *KeywordSearchConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
 // Value implements the driver.Valuer interface, used to convert VLMConfig to database value
 func (c VLMConfig) Value() (driver.Value, error) {
 	return json.Marshal(c)
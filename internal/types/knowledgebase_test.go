@@ -0,0 +1,155 @@
+package types
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestURLFetchConfigValueScan(t *testing.T) {
+	t.Setenv("TENANT_AES_KEY", "0123456789abcdef0123456789abcdef")
+	config := URLFetchConfig{
+		UserAgent: "WikiImporter/1.0",
+		Headers:   map[string]string{"X-Api-Key": "key-1", "Accept-Language": "zh-CN"},
+		Cookies:   map[string]string{"sessionid": "session-1"},
+		Hosts:     []string{"wiki.example.com"},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	value, err := config.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	stored := string(value.([]byte))
+	if strings.Contains(stored, "key-1") || strings.Contains(stored, "session-1") {
+		t.Errorf("Value() = %s, credentials stored in plaintext", stored)
+	}
+	if !strings.Contains(stored, "zh-CN") {
+		t.Errorf("Value() = %s, plain header encrypted", stored)
+	}
+
+	var scanned URLFetchConfig
+	if err := scanned.Scan(value); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if scanned.UserAgent != config.UserAgent || scanned.Headers["X-Api-Key"] != "key-1" ||
+		scanned.Headers["Accept-Language"] != "zh-CN" || scanned.Cookies["sessionid"] != "session-1" ||
+		len(scanned.Hosts) != 1 {
+		t.Errorf("Scan() = %+v, want %+v", scanned, config)
+	}
+}
+
+func TestURLFetchConfigMasking(t *testing.T) {
+	config := URLFetchConfig{
+		Headers: map[string]string{"Authorization": "Bearer secret", "Accept-Language": "zh-CN"},
+		Cookies: map[string]string{"sessionid": "session-1"},
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(b), "secret") || strings.Contains(string(b), "session-1") {
+		t.Errorf("Marshal() = %s, credentials not masked", b)
+	}
+
+	var update URLFetchConfig
+	if err := json.Unmarshal(b, &update); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	update.Headers["Accept-Language"] = "en-US"
+	update.MergeMasked(&config)
+	if update.Headers["Authorization"] != "Bearer secret" || update.Cookies["sessionid"] != "session-1" ||
+		update.Headers["Accept-Language"] != "en-US" {
+		t.Errorf("MergeMasked() = %+v, stored credentials not kept", update)
+	}
+
+	var imported URLFetchConfig
+	_ = json.Unmarshal(b, &imported)
+	imported.DropMasked()
+	if _, ok := imported.Headers["Authorization"]; ok || len(imported.Cookies) != 0 {
+		t.Errorf("DropMasked() = %+v, masked values kept", imported)
+	}
+}
+
+func TestURLFetchConfigValidate(t *testing.T) {
+	t.Setenv("TENANT_AES_KEY", "")
+	tests := []struct {
+		name    string
+		config  URLFetchConfig
+		wantErr bool
+	}{
+		{"plain header", URLFetchConfig{
+			Headers: map[string]string{"Accept-Language": "zh-CN"}, Hosts: []string{"wiki.example.com"},
+		}, false},
+		{"header without hosts", URLFetchConfig{Headers: map[string]string{"Accept-Language": "zh-CN"}}, true},
+		{"user agent without hosts", URLFetchConfig{UserAgent: "WikiImporter/1.0"}, false},
+		{"host with scheme", URLFetchConfig{
+			Headers: map[string]string{"Accept-Language": "zh-CN"}, Hosts: []string{"https://wiki.example.com"},
+		}, true},
+		{"header injection", URLFetchConfig{Headers: map[string]string{"X-A": "a\r\nHost: b"}}, true},
+		{"reserved header", URLFetchConfig{Headers: map[string]string{"host": "example.com"}}, true},
+		{"invalid header name", URLFetchConfig{Headers: map[string]string{"X A": "a"}}, true},
+		{"cookie separator", URLFetchConfig{Cookies: map[string]string{"a": "b; c=d"}}, true},
+		{"credential without key", URLFetchConfig{Cookies: map[string]string{"sessionid": "s"}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestURLFetchConfigSendsTo(t *testing.T) {
+	config := &URLFetchConfig{Hosts: []string{"wiki.example.com", ".corp.example.com"}}
+	tests := map[string]bool{
+		"wiki.example.com":      true,
+		"WIKI.example.com":      true,
+		"docs.corp.example.com": true,
+		"corp.example.com":      false,
+		"example.com":           false,
+		"evil.com":              false,
+		"wiki.example.com.evil": false,
+	}
+	for host, want := range tests {
+		if got := config.SendsTo(host); got != want {
+			t.Errorf("SendsTo(%q) = %v, want %v", host, got, want)
+		}
+	}
+	if (*URLFetchConfig)(nil).SendsTo("wiki.example.com") {
+		t.Error("nil config sends to a host")
+	}
+}
+
+func TestKeywordSearchConfigValidateEngines(t *testing.T) {
+	postgres := []RetrieverEngineParams{
+		{RetrieverEngineType: PostgresRetrieverEngineType, RetrieverType: KeywordsRetrieverType},
+		{RetrieverEngineType: PostgresRetrieverEngineType, RetrieverType: VectorRetrieverType},
+	}
+	elasticsearch := []RetrieverEngineParams{
+		{RetrieverEngineType: ElasticsearchRetrieverEngineType, RetrieverType: KeywordsRetrieverType},
+		{RetrieverEngineType: PostgresRetrieverEngineType, RetrieverType: VectorRetrieverType},
+	}
+	mixed := append(slices.Clone(postgres), elasticsearch[0])
+	vectorOnly := postgres[1:]
+
+	tests := []struct {
+		name    string
+		config  *KeywordSearchConfig
+		engines []RetrieverEngineParams
+		wantErr bool
+	}{
+		{"no config", nil, elasticsearch, false},
+		{"default analyzer", &KeywordSearchConfig{KeywordWeight: 2}, elasticsearch, false},
+		{"postgres", &KeywordSearchConfig{Analyzer: KeywordAnalyzerEnglish}, postgres, false},
+		{"elasticsearch", &KeywordSearchConfig{Analyzer: KeywordAnalyzerEnglish}, elasticsearch, true},
+		{"postgres and elasticsearch", &KeywordSearchConfig{Analyzer: KeywordAnalyzerCode}, mixed, true},
+		{"no keyword engine", &KeywordSearchConfig{Analyzer: KeywordAnalyzerChinese}, vectorOnly, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.ValidateEngines(tt.engines); (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateEngines() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
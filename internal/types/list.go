@@ -0,0 +1,238 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SortOrder is the direction a listing is sorted in
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// SortFieldKind is the type of the column a listing is sorted by, cursors carry its values as text
+type SortFieldKind int
+
+const (
+	SortFieldTime SortFieldKind = iota
+	SortFieldInt
+	SortFieldString
+)
+
+// SortField is a field a listing can be sorted by
+type SortField struct {
+	// Column is the column of the field, rows are further sorted by id to keep the order stable
+	Column string
+	// Expr is the SQL expression rows are sorted by when the column is nullable, the column when empty
+	Expr string
+	Kind SortFieldKind
+}
+
+// SQL returns the expression rows are sorted by
+func (f SortField) SQL() string {
+	if f.Expr != "" {
+		return f.Expr
+	}
+	return f.Column
+}
+
+// DefaultSortField is the field listings are sorted by when none is given, newest first
+const DefaultSortField = "created_at"
+
+// KnowledgeSortFields are the fields knowledge listings can be sorted by
+var KnowledgeSortFields = map[string]SortField{
+	"created_at":   {Column: "created_at", Kind: SortFieldTime},
+	"updated_at":   {Column: "updated_at", Kind: SortFieldTime},
+	"size":         {Column: "file_size", Expr: "COALESCE(file_size, 0)", Kind: SortFieldInt},
+	"parse_status": {Column: "parse_status", Kind: SortFieldString},
+}
+
+// SessionSortFields are the fields session listings can be sorted by
+var SessionSortFields = map[string]SortField{
+	"created_at": {Column: "created_at", Kind: SortFieldTime},
+	"updated_at": {Column: "updated_at", Kind: SortFieldTime},
+}
+
+// ListOptions are the pagination and sorting query parameters of listings. Pages are either numbered with page, or
+// follow the opaque cursor returned with the previous page; cursors stay consistent while rows are added and are
+// cheap at any depth, page numbers are kept for compatibility.
+type ListOptions struct {
+	Pagination
+	// Cursor is the next_cursor of the previous page, it cannot be combined with page
+	Cursor string `form:"cursor"`
+	// Sort is the field to sort by, created_at when empty
+	Sort string `form:"sort"`
+	// Order is asc or desc, desc when empty
+	Order SortOrder `form:"order"`
+	// SkipTotal skips counting the rows, which is slow for large knowledge bases
+	SkipTotal bool `form:"skip_total"`
+}
+
+// ListCursor is the position after the last row of a page
+type ListCursor struct {
+	Sort  string    `json:"s"`
+	Order SortOrder `json:"o"`
+	Value string    `json:"v"`
+	ID    string    `json:"id"`
+}
+
+// Encode returns the opaque text of the cursor
+func (c ListCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeListCursor parses the text of a cursor
+func DecodeListCursor(s string) (*ListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var cursor ListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// ListQuery is the resolved pagination and sorting of a listing
+type ListQuery struct {
+	// Sort is the name of the sort field
+	Sort  string
+	Field SortField
+	Desc  bool
+	// After is the position to continue after, rows are skipped by Offset instead when nil
+	After *ListCursor
+	// Page is the number of the page when rows are skipped by Offset
+	Page   int
+	Offset int
+	Limit  int
+	// CountTotal tells whether to count the rows
+	CountTotal bool
+}
+
+// Resolve validates the options against the fields the listing can be sorted by
+func (o *ListOptions) Resolve(fields map[string]SortField) (*ListQuery, error) {
+	sort := o.Sort
+	if sort == "" {
+		sort = DefaultSortField
+	}
+	field, ok := fields[sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", sort)
+	}
+	order := o.Order
+	if order == "" {
+		order = SortDesc
+	}
+	if order != SortAsc && order != SortDesc {
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+	query := &ListQuery{
+		Sort:       sort,
+		Field:      field,
+		Desc:       order == SortDesc,
+		Limit:      o.GetPageSize(),
+		CountTotal: !o.SkipTotal,
+	}
+	if o.Cursor == "" {
+		query.Page = o.GetPage()
+		query.Offset = o.Offset()
+		return query, nil
+	}
+	if o.Page > 1 {
+		return nil, fmt.Errorf("cursor cannot be combined with page")
+	}
+	cursor, err := DecodeListCursor(o.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	// A cursor is a position in one order, it means nothing in another
+	if cursor.Sort != sort || cursor.Order != order {
+		return nil, fmt.Errorf("cursor was issued for another sort order")
+	}
+	if _, err := query.CursorValue(cursor); err != nil {
+		return nil, err
+	}
+	query.After = cursor
+	return query, nil
+}
+
+// Order returns the order of the query
+func (q *ListQuery) Order() SortOrder {
+	if q.Desc {
+		return SortDesc
+	}
+	return SortAsc
+}
+
+// CursorValue returns the sort value of a cursor typed for its column
+func (q *ListQuery) CursorValue(cursor *ListCursor) (any, error) {
+	switch q.Field.Kind {
+	case SortFieldTime:
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		return t, nil
+	case SortFieldInt:
+		n, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		return n, nil
+	default:
+		return cursor.Value, nil
+	}
+}
+
+// Listable is a row of a listing with a cursor
+type Listable interface {
+	// ListID returns the id of the row, the tie-breaker of the order
+	ListID() string
+	// SortValue returns the value of a sort column of the row as text
+	SortValue(column string) string
+}
+
+// FormatSortTime formats a time sort value of a cursor, keeping the microseconds the database stores
+func FormatSortTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// CursorPageResult is a page of a listing with the cursor of the next page
+type CursorPageResult struct {
+	Data     any    `json:"data"`
+	Total    *int64 `json:"total,omitempty"`
+	Page     int    `json:"page,omitempty"`
+	PageSize int    `json:"page_size"`
+	HasMore  bool   `json:"has_more"`
+	// NextCursor continues the listing after this page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewCursorPageResult builds a page from rows queried with one more row than the limit, which tells whether more
+// rows follow. The total is nil when it was not counted.
+func NewCursorPageResult[T Listable](rows []T, total *int64, query *ListQuery) *CursorPageResult {
+	result := &CursorPageResult{Total: total, Page: query.Page, PageSize: query.Limit}
+	if len(rows) > query.Limit {
+		rows = rows[:query.Limit]
+		result.HasMore = true
+	}
+	if result.HasMore && len(rows) > 0 {
+		last := rows[len(rows)-1]
+		result.NextCursor = ListCursor{
+			Sort:  query.Sort,
+			Order: query.Order(),
+			Value: last.SortValue(query.Field.Column),
+			ID:    last.ListID(),
+		}.Encode()
+	}
+	result.Data = rows
+	return result
+}
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListOptionsResolveDefaults(t *testing.T) {
+	options := ListOptions{Pagination: Pagination{Page: 3, PageSize: 10}}
+	query, err := options.Resolve(KnowledgeSortFields)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if query.Sort != DefaultSortField || !query.Desc || query.Offset != 20 || query.Limit != 10 || query.Page != 3 {
+		t.Errorf("Resolve() = %+v, want created_at desc from offset 20", query)
+	}
+	if !query.CountTotal {
+		t.Error("Resolve() skips the total without skip_total")
+	}
+
+	for _, invalid := range []ListOptions{
+		{Sort: "title"},
+		{Order: "up"},
+		{Cursor: "not a cursor"},
+	} {
+		if _, err := invalid.Resolve(KnowledgeSortFields); err == nil {
+			t.Errorf("Resolve(%+v) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestListCursorRoundTrip(t *testing.T) {
+	created := time.Date(2026, 3, 1, 8, 30, 0, 123456000, time.UTC)
+	rows := []*Knowledge{
+		{ID: "a", CreatedAt: created.Add(2 * time.Second)},
+		{ID: "b", CreatedAt: created.Add(time.Second)},
+		{ID: "c", CreatedAt: created},
+	}
+	query, err := (&ListOptions{Pagination: Pagination{PageSize: 2}}).Resolve(KnowledgeSortFields)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	page := NewCursorPageResult(rows, nil, query)
+	if !page.HasMore || page.NextCursor == "" || len(page.Data.([]*Knowledge)) != 2 {
+		t.Fatalf("NewCursorPageResult() = %+v, want 2 rows and a cursor", page)
+	}
+
+	next, err := (&ListOptions{Cursor: page.NextCursor}).Resolve(KnowledgeSortFields)
+	if err != nil {
+		t.Fatalf("Resolve(next cursor) error = %v", err)
+	}
+	if next.After == nil || next.After.ID != "b" || next.Page != 0 {
+		t.Fatalf("Resolve(next cursor) = %+v, want to continue after b", next)
+	}
+	value, err := next.CursorValue(next.After)
+	if err != nil || !value.(time.Time).Equal(created.Add(time.Second)) {
+		t.Errorf("CursorValue() = %v, %v, want the creation time of b", value, err)
+	}
+
+	last := NewCursorPageResult(rows[2:], nil, next)
+	if last.HasMore || last.NextCursor != "" {
+		t.Errorf("NewCursorPageResult(last page) = %+v, want no more rows", last)
+	}
+}
+
+func TestListCursorRejectsOtherOrders(t *testing.T) {
+	cursor := ListCursor{Sort: "size", Order: SortDesc, Value: "1024", ID: "a"}.Encode()
+	cases := []ListOptions{
+		{Cursor: cursor, Sort: "created_at"},
+		{Cursor: cursor, Sort: "size", Order: SortAsc},
+		{Cursor: cursor, Sort: "size", Pagination: Pagination{Page: 2}},
+	}
+	for _, options := range cases {
+		if _, err := options.Resolve(KnowledgeSortFields); err == nil {
+			t.Errorf("Resolve(%+v) succeeded, want an error", options)
+		}
+	}
+	if _, err := (&ListOptions{Cursor: cursor, Sort: "size"}).Resolve(KnowledgeSortFields); err != nil {
+		t.Errorf("Resolve(matching cursor) error = %v", err)
+	}
+	if _, err := (&ListOptions{Sort: "size"}).Resolve(SessionSortFields); err == nil {
+		t.Error("Resolve() accepted a knowledge sort field for sessions")
+	}
+}
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Self-hosted model servers a tenant can configure
+const (
+	LocalProviderOllama = "ollama"
+	LocalProviderVLLM   = "vllm"
+)
+
+// ProviderEndpoint is the address of a model server
+type ProviderEndpoint struct {
+	BaseURL string `json:"base_url"`
+	APIKey  string `json:"api_key,omitempty"`
+}
+
+// Validate checks that the base URL is an absolute HTTP URL
+func (e *ProviderEndpoint) Validate() error {
+	u, err := url.Parse(e.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base_url must be an http or https URL")
+	}
+	return nil
+}
+
+// LocalProviderConfig holds the tenant's self-hosted model servers. Models created with the ollama or
+// vllm provider and no base URL use these servers.
+type LocalProviderConfig struct {
+	Ollama *ProviderEndpoint `json:"ollama,omitempty"`
+	VLLM   *ProviderEndpoint `json:"vllm,omitempty"`
+}
+
+// Endpoint returns the configured server of a provider, nil when there is none
+func (c *LocalProviderConfig) Endpoint(provider string) *ProviderEndpoint {
+	if c == nil {
+		return nil
+	}
+	switch provider {
+	case LocalProviderOllama:
+		return c.Ollama
+	case LocalProviderVLLM:
+		return c.VLLM
+	}
+	return nil
+}
+
+// Validate checks the configured servers
+func (c *LocalProviderConfig) Validate() error {
+	for name, endpoint := range map[string]*ProviderEndpoint{
+		LocalProviderOllama: c.Ollama,
+		LocalProviderVLLM:   c.VLLM,
+	} {
+		if endpoint == nil {
+			continue
+		}
+		if err := endpoint.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (c LocalProviderConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *LocalProviderConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// ProviderModel is a model served by a self-hosted model server
+type ProviderModel struct {
+	Name              string     `json:"name"`
+	Size              int64      `json:"size,omitempty"`
+	ModifiedAt        *time.Time `json:"modified_at,omitempty"`
+	Family            string     `json:"family,omitempty"`
+	ParameterSize     string     `json:"parameter_size,omitempty"`
+	QuantizationLevel string     `json:"quantization_level,omitempty"`
+	// MaxModelLen is the context length reported by vLLM
+	MaxModelLen int `json:"max_model_len,omitempty"`
+}
+
+// ProviderConnectionStatus is the result of testing the connection to a model server
+type ProviderConnectionStatus struct {
+	Provider  string          `json:"provider"`
+	BaseURL   string          `json:"base_url"`
+	Available bool            `json:"available"`
+	Version   string          `json:"version,omitempty"`
+	LatencyMs int64           `json:"latency_ms"`
+	Models    []ProviderModel `json:"models"`
+	// ModelAvailable reports whether the requested model is served, nil when none was requested
+	ModelAvailable *bool  `json:"model_available,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// ModelPullStatus is the state of a model download on an Ollama server
+type ModelPullStatus string
+
+const (
+	ModelPullPending     ModelPullStatus = "pending"
+	ModelPullDownloading ModelPullStatus = "downloading"
+	ModelPullCompleted   ModelPullStatus = "completed"
+	ModelPullFailed      ModelPullStatus = "failed"
+)
+
+// ModelPullTask tracks a model download on a tenant's Ollama server
+type ModelPullTask struct {
+	ID         string          `json:"id"`
+	TenantID   uint64          `json:"-"`
+	BaseURL    string          `json:"base_url"`
+	ModelName  string          `json:"model_name"`
+	Status     ModelPullStatus `json:"status"`
+	Progress   float64         `json:"progress"`
+	Message    string          `json:"message"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// IsRunning reports whether the download has not finished yet
+func (t *ModelPullTask) IsRunning() bool {
+	return t.Status == ModelPullPending || t.Status == ModelPullDownloading
+}
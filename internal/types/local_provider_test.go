@@ -0,0 +1,27 @@
+package types
+
+import "testing"
+
+func TestLocalProviderConfig(t *testing.T) {
+	cfg := &LocalProviderConfig{
+		Ollama: &ProviderEndpoint{BaseURL: "http://ollama:11434"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+	if got := cfg.Endpoint(LocalProviderOllama); got == nil || got.BaseURL != "http://ollama:11434" {
+		t.Errorf("Endpoint(ollama) = %+v", got)
+	}
+	if got := cfg.Endpoint(LocalProviderVLLM); got != nil {
+		t.Errorf("Endpoint(vllm) = %+v, want nil", got)
+	}
+	var empty *LocalProviderConfig
+	if got := empty.Endpoint(LocalProviderOllama); got != nil {
+		t.Errorf("nil config returned %+v", got)
+	}
+
+	cfg.VLLM = &ProviderEndpoint{BaseURL: "vllm:8000/v1"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("base URL without scheme accepted")
+	}
+}
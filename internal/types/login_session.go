@@ -0,0 +1,70 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// LoginSession is a login of a user on a device. It lasts across token refreshes until the user logs out,
+// it is revoked or its refresh token expires.
+type LoginSession struct {
+	ID     string `json:"id"      gorm:"type:varchar(36);primaryKey"`
+	UserID string `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	// UserAgent and IPAddress describe the device the user logged in from
+	UserAgent    string     `json:"user_agent"     gorm:"type:text"`
+	IPAddress    string     `json:"ip_address"     gorm:"type:varchar(64)"`
+	LastActiveAt time.Time  `json:"last_active_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Current marks the session of the request listing sessions
+	Current bool `json:"current" gorm:"-"`
+	// Tokens are the tokens of the session that can still be used
+	Tokens []*IssuedToken `json:"tokens,omitempty" gorm:"-"`
+}
+
+// TableName returns the table name for GORM
+func (LoginSession) TableName() string {
+	return "login_sessions"
+}
+
+// IsActive checks if the session can still be used at the given time
+func (s *LoginSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// IssuedToken describes an issued token without its value
+type IssuedToken struct {
+	ID         string     `json:"id"`
+	SessionID  string     `json:"session_id"`
+	TokenType  string     `json:"token_type"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Issued describes the token without its value
+func (t *AuthToken) Issued() *IssuedToken {
+	return &IssuedToken{
+		ID:         t.ID,
+		SessionID:  t.SessionID,
+		TokenType:  t.TokenType,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+// ClientInfo describes the client making a request, recorded on the sessions it logs in
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// ClientInfoFromContext returns the client of the request, nil when not recorded
+func ClientInfoFromContext(ctx context.Context) *ClientInfo {
+	client, _ := ctx.Value(ClientInfoContextKey).(*ClientInfo)
+	return client
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginSessionIsActive(t *testing.T) {
+	now := time.Now()
+	session := &LoginSession{ExpiresAt: now.Add(time.Hour)}
+	if !session.IsActive(now) {
+		t.Error("session should be active before it expires")
+	}
+	if session.IsActive(now.Add(2 * time.Hour)) {
+		t.Error("expired session should not be active")
+	}
+	session.RevokedAt = &now
+	if session.IsActive(now) {
+		t.Error("revoked session should not be active")
+	}
+}
+
+func TestAuthTokenIssuedHidesValue(t *testing.T) {
+	token := &AuthToken{ID: "t1", SessionID: "s1", Token: "secret", TokenType: "access_token"}
+	issued := token.Issued()
+	if issued.ID != "t1" || issued.SessionID != "s1" || issued.TokenType != "access_token" {
+		t.Errorf("unexpected issued token %+v", issued)
+	}
+}
@@ -69,6 +69,10 @@ type Message struct {
 	Role string `json:"role"`
 	// References to knowledge chunks used in the response
 	KnowledgeReferences References `json:"knowledge_references"  gorm:"type:json,column:knowledge_references"`
+	// Chunks cited by the inline markers of the answer, e.g. [1]
+	Citations Citations `json:"citations,omitempty"   gorm:"type:jsonb,column:citations"`
+	// Follow-up questions suggested after the answer, rendered as quick replies
+	FollowUpQuestions StringArray `json:"follow_up_questions,omitempty" gorm:"type:jsonb,column:follow_up_questions"`
 	// Agent execution steps (only for assistant messages generated by agent)
 	// This contains the detailed reasoning process and tool calls made by the agent
 	// Stored for user history display, but NOT included in LLM context to avoid redundancy
@@ -3,6 +3,8 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -59,9 +61,14 @@ type ModelParameters struct {
 	APIKey              string              `yaml:"api_key"              json:"api_key"`
 	InterfaceType       string              `yaml:"interface_type"       json:"interface_type"`
 	EmbeddingParameters EmbeddingParameters `yaml:"embedding_parameters" json:"embedding_parameters"`
-	ParameterSize       string              `yaml:"parameter_size"       json:"parameter_size"` // Ollama model parameter size (e.g., "7B", "13B", "70B")
-	Provider            string              `yaml:"provider"             json:"provider"`       // Provider identifier: openai, aliyun, zhipu, generic
-	ExtraConfig         map[string]string   `yaml:"extra_config"         json:"extra_config"`   // Provider-specific configuration
+	ParameterSize       string              `yaml:"parameter_size"       json:"parameter_size"`    // Ollama model parameter size (e.g., "7B", "13B", "70B")
+	Provider            string              `yaml:"provider"             json:"provider"`          // Provider identifier: openai, aliyun, zhipu, generic
+	ExtraConfig         map[string]string   `yaml:"extra_config"         json:"extra_config"`      // Provider-specific configuration
+	Pricing             *ModelPricing       `yaml:"pricing"              json:"pricing,omitempty"` // Price per million tokens, used for cost tracking
+	// FallbackModelIDs are the models to fail over to, in order, when this model's endpoint is unhealthy
+	FallbackModelIDs []string `yaml:"fallback_model_ids" json:"fallback_model_ids,omitempty"`
+	// Capabilities describes the context window and supported inputs of the model
+	Capabilities *ModelCapabilities `yaml:"capabilities" json:"capabilities,omitempty"`
 }
 
 // Model represents the AI model
@@ -92,6 +99,8 @@ type Model struct {
 	UpdatedAt time.Time `yaml:"updated_at"  json:"updated_at"`
 	// Deletion time of the model
 	DeletedAt gorm.DeletedAt `yaml:"deleted_at"  json:"deleted_at"  gorm:"index"`
+	// Result of the last health probe, written by the probe task only
+	LastProbe *ModelProbeResult `yaml:"-"           json:"last_probe,omitempty" gorm:"type:jsonb"`
 }
 
 // Value implements the driver.Valuer interface, used to convert ModelParameters to database value
@@ -122,3 +131,33 @@ func (m *Model) BeforeCreate(tx *gorm.DB) (err error) {
 	m.ID = uuid.New().String()
 	return nil
 }
+
+// MaxModelFallbacks bounds the number of fallback models of a model
+const MaxModelFallbacks = 3
+
+// SupportsFallback reports whether calls to models of this type can fail over to other models
+func (t ModelType) SupportsFallback() bool {
+	return t == ModelTypeKnowledgeQA || t == ModelTypeEmbedding
+}
+
+// CheckFallback returns why calls to the model cannot fail over to fallback, nil when they can.
+// An embedding fallback must serve the same model with the same dimension, since its vectors are
+// stored and searched alongside the ones of the primary model.
+func (m *Model) CheckFallback(fallback *Model) error {
+	if fallback.ID == m.ID {
+		return errors.New("a model cannot fall back to itself")
+	}
+	if fallback.Type != m.Type {
+		return fmt.Errorf("fallback model %s is a %s model, expected %s", fallback.ID, fallback.Type, m.Type)
+	}
+	if m.Type == ModelTypeEmbedding {
+		if fallback.Name != m.Name {
+			return fmt.Errorf("fallback embedding model %s serves %s, expected %s", fallback.ID, fallback.Name, m.Name)
+		}
+		if fallback.Parameters.EmbeddingParameters.Dimension != m.Parameters.EmbeddingParameters.Dimension {
+			return fmt.Errorf("fallback embedding model %s has dimension %d, expected %d", fallback.ID,
+				fallback.Parameters.EmbeddingParameters.Dimension, m.Parameters.EmbeddingParameters.Dimension)
+		}
+	}
+	return nil
+}
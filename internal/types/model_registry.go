@@ -0,0 +1,84 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ModelCapabilities describes what a configured model can do, used to pick models for features
+// such as image understanding or long-context summarization
+type ModelCapabilities struct {
+	// MaxContextTokens is the context window of the model, zero when unknown
+	MaxContextTokens int `yaml:"max_context_tokens" json:"max_context_tokens,omitempty"`
+	// SupportsVision is set for models that accept images, always true for VLLM models
+	SupportsVision bool `yaml:"supports_vision"    json:"supports_vision"`
+	// SupportsTools is set for chat models that accept tool definitions
+	SupportsTools bool `yaml:"supports_tools"     json:"supports_tools"`
+}
+
+// Validate checks the capability values
+func (c *ModelCapabilities) Validate() error {
+	if c != nil && c.MaxContextTokens < 0 {
+		return errors.New("max_context_tokens cannot be negative")
+	}
+	return nil
+}
+
+// GetCapabilities returns the capabilities of the model, filling in what its type implies
+func (m *Model) GetCapabilities() ModelCapabilities {
+	var capabilities ModelCapabilities
+	if m.Parameters.Capabilities != nil {
+		capabilities = *m.Parameters.Capabilities
+	}
+	if m.Type == ModelTypeVLLM {
+		capabilities.SupportsVision = true
+	}
+	return capabilities
+}
+
+// ModelProbeResult is the outcome of the last health probe of a model
+type ModelProbeResult struct {
+	Healthy   bool      `json:"healthy"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	// DetectedDimension is the length of the vector an embedding model returned
+	DetectedDimension int `json:"detected_dimension,omitempty"`
+}
+
+// Value implements the driver.Valuer interface, used to convert ModelProbeResult to database value
+func (r ModelProbeResult) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to ModelProbeResult
+func (r *ModelProbeResult) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, r)
+}
+
+// ModelRegistryEntry is a configured model with its capabilities and health, as listed by the model registry
+type ModelRegistryEntry struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Type         ModelType         `json:"type"`
+	Source       ModelSource       `json:"source"`
+	Provider     string            `json:"provider,omitempty"`
+	Status       ModelStatus       `json:"status"`
+	IsBuiltin    bool              `json:"is_builtin"`
+	Capabilities ModelCapabilities `json:"capabilities"`
+	// EmbeddingDimension is the configured vector dimension of embedding models
+	EmbeddingDimension int `json:"embedding_dimension,omitempty"`
+	// LastProbe is nil until the model is probed
+	LastProbe *ModelProbeResult `json:"last_probe,omitempty"`
+	// CircuitState is the circuit breaker state of the model endpoint: closed, open or half_open
+	CircuitState string `json:"circuit_state"`
+}
@@ -0,0 +1,56 @@
+package types
+
+import "testing"
+
+func TestModelCheckFallback(t *testing.T) {
+	embedding := func(id, name string, dimension int) *Model {
+		return &Model{ID: id, Name: name, Type: ModelTypeEmbedding, Parameters: ModelParameters{
+			EmbeddingParameters: EmbeddingParameters{Dimension: dimension},
+		}}
+	}
+	primary := embedding("a", "bge-m3", 1024)
+
+	if err := primary.CheckFallback(embedding("b", "bge-m3", 1024)); err != nil {
+		t.Errorf("same embedding model on another endpoint rejected: %v", err)
+	}
+	if err := primary.CheckFallback(embedding("b", "bge-large", 1024)); err == nil {
+		t.Error("different embedding model accepted")
+	}
+	if err := primary.CheckFallback(embedding("b", "bge-m3", 768)); err == nil {
+		t.Error("different dimension accepted")
+	}
+	if err := primary.CheckFallback(primary); err == nil {
+		t.Error("self fallback accepted")
+	}
+
+	chat := &Model{ID: "c", Name: "qwen", Type: ModelTypeKnowledgeQA}
+	if err := chat.CheckFallback(&Model{ID: "d", Name: "gpt-4o", Type: ModelTypeKnowledgeQA}); err != nil {
+		t.Errorf("chat fallback to another chat model rejected: %v", err)
+	}
+	if err := chat.CheckFallback(primary); err == nil {
+		t.Error("chat fallback to an embedding model accepted")
+	}
+}
+
+func TestModelGetCapabilities(t *testing.T) {
+	vlm := &Model{Type: ModelTypeVLLM}
+	if !vlm.GetCapabilities().SupportsVision {
+		t.Error("VLLM model does not support vision")
+	}
+
+	chat := &Model{Type: ModelTypeKnowledgeQA, Parameters: ModelParameters{
+		Capabilities: &ModelCapabilities{MaxContextTokens: 128000, SupportsTools: true},
+	}}
+	capabilities := chat.GetCapabilities()
+	if capabilities.MaxContextTokens != 128000 || !capabilities.SupportsTools || capabilities.SupportsVision {
+		t.Errorf("unexpected capabilities: %+v", capabilities)
+	}
+
+	if err := (&ModelCapabilities{MaxContextTokens: -1}).Validate(); err == nil {
+		t.Error("negative context window accepted")
+	}
+	var unset *ModelCapabilities
+	if err := unset.Validate(); err != nil {
+		t.Errorf("unset capabilities rejected: %v", err)
+	}
+}
@@ -0,0 +1,422 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ModerationAction is what happens to text that matches a moderation rule
+type ModerationAction string
+
+const (
+	// ModerationBlock rejects a question, or stops an answer and replaces the rest with the block message
+	ModerationBlock ModerationAction = "block"
+	// ModerationMask replaces the matched text with asterisks
+	ModerationMask ModerationAction = "mask"
+	// ModerationFlag lets the text through and queues the event for review
+	ModerationFlag ModerationAction = "flag"
+)
+
+// IsValid checks if the action is block, mask or flag
+func (a ModerationAction) IsValid() bool {
+	return a == ModerationBlock || a == ModerationMask || a == ModerationFlag
+}
+
+// severity orders actions so the strictest one of several matches wins
+func (a ModerationAction) severity() int {
+	switch a {
+	case ModerationBlock:
+		return 3
+	case ModerationMask:
+		return 2
+	case ModerationFlag:
+		return 1
+	}
+	return 0
+}
+
+// ModerationStage is the side of the conversation a rule applies to
+type ModerationStage string
+
+const (
+	// ModerationStageQuery checks the user's question before it is answered
+	ModerationStageQuery ModerationStage = "query"
+	// ModerationStageAnswer checks the model's answer as it streams
+	ModerationStageAnswer ModerationStage = "answer"
+)
+
+// IsValid checks if the stage is query or answer
+func (s ModerationStage) IsValid() bool {
+	return s == ModerationStageQuery || s == ModerationStageAnswer
+}
+
+// ModerationRuleType is how the patterns of a rule are matched
+type ModerationRuleType string
+
+const (
+	// ModerationRuleKeyword matches the patterns as case-insensitive substrings
+	ModerationRuleKeyword ModerationRuleType = "keyword"
+	// ModerationRuleRegex matches the patterns as regular expressions
+	ModerationRuleRegex ModerationRuleType = "regex"
+)
+
+// ModerationRule is a list of keywords or regular expressions and the action taken on a match
+type ModerationRule struct {
+	Name     string             `json:"name"`
+	Type     ModerationRuleType `json:"type"`
+	Patterns []string           `json:"patterns"`
+	Action   ModerationAction   `json:"action"`
+	// Stages defaults to both query and answer
+	Stages []ModerationStage `json:"stages,omitempty"`
+}
+
+// appliesTo checks if the rule applies to a stage
+func (r *ModerationRule) appliesTo(stage ModerationStage) bool {
+	return len(r.Stages) == 0 || slices.Contains(r.Stages, stage)
+}
+
+// ModerationModelConfig uses a chat model as a classifier for content the rules cannot describe
+type ModerationModelConfig struct {
+	ModelID string `json:"model_id"`
+	// Action is block or flag, the model cannot locate text to mask
+	Action ModerationAction `json:"action"`
+	// Categories lists what the model should look for, a default list is used when empty
+	Categories []string `json:"categories,omitempty"`
+	// Stages defaults to the query only. Answers are classified once complete and can only be flagged.
+	Stages []ModerationStage `json:"stages,omitempty"`
+}
+
+// AppliesTo checks if the model classifies a stage
+func (m *ModerationModelConfig) AppliesTo(stage ModerationStage) bool {
+	if m == nil || m.ModelID == "" {
+		return false
+	}
+	if len(m.Stages) == 0 {
+		return stage == ModerationStageQuery
+	}
+	return slices.Contains(m.Stages, stage)
+}
+
+// ModerationConfig is the content moderation configuration of a tenant
+type ModerationConfig struct {
+	Enabled bool                   `json:"enabled"`
+	Rules   []ModerationRule       `json:"rules"`
+	Model   *ModerationModelConfig `json:"model,omitempty"`
+	// BlockMessage is shown in place of blocked content, a default message is used when empty
+	BlockMessage string `json:"block_message,omitempty"`
+}
+
+// DefaultModerationBlockMessage is shown in place of blocked content
+const DefaultModerationBlockMessage = "抱歉，该内容涉及不当信息，无法提供回答。"
+
+// GetBlockMessage returns the message shown in place of blocked content
+func (c *ModerationConfig) GetBlockMessage() string {
+	if c == nil || strings.TrimSpace(c.BlockMessage) == "" {
+		return DefaultModerationBlockMessage
+	}
+	return c.BlockMessage
+}
+
+// IsActiveFor checks if any rule or the model moderates a stage
+func (c *ModerationConfig) IsActiveFor(stage ModerationStage) bool {
+	if c == nil || !c.Enabled {
+		return false
+	}
+	if c.Model.AppliesTo(stage) {
+		return true
+	}
+	for i := range c.Rules {
+		if c.Rules[i].appliesTo(stage) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the rules and model configuration
+func (c *ModerationConfig) Validate() error {
+	for i, rule := range c.Rules {
+		if rule.Type != ModerationRuleKeyword && rule.Type != ModerationRuleRegex {
+			return fmt.Errorf("rule %d: type must be keyword or regex", i+1)
+		}
+		if !rule.Action.IsValid() {
+			return fmt.Errorf("rule %d: action must be block, mask or flag", i+1)
+		}
+		for _, stage := range rule.Stages {
+			if !stage.IsValid() {
+				return fmt.Errorf("rule %d: stage must be query or answer", i+1)
+			}
+		}
+		if len(rule.Patterns) == 0 {
+			return fmt.Errorf("rule %d: patterns cannot be empty", i+1)
+		}
+		for _, pattern := range rule.Patterns {
+			if strings.TrimSpace(pattern) == "" {
+				return fmt.Errorf("rule %d: patterns cannot be blank", i+1)
+			}
+			if rule.Type == ModerationRuleRegex {
+				if _, err := regexp.Compile(pattern); err != nil {
+					return fmt.Errorf("rule %d: invalid regex %q: %v", i+1, pattern, err)
+				}
+			}
+		}
+	}
+	if c.Model != nil && c.Model.ModelID != "" {
+		if c.Model.Action != ModerationBlock && c.Model.Action != ModerationFlag {
+			return fmt.Errorf("model action must be block or flag")
+		}
+		for _, stage := range c.Model.Stages {
+			if !stage.IsValid() {
+				return fmt.Errorf("model stage must be query or answer")
+			}
+		}
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface, used to convert ModerationConfig to database values
+func (c ModerationConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database values to ModerationConfig
+func (c *ModerationConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// ModerationSource is what detected a moderated event
+type ModerationSource string
+
+const (
+	ModerationSourceKeyword ModerationSource = "keyword"
+	ModerationSourceRegex   ModerationSource = "regex"
+	ModerationSourceModel   ModerationSource = "model"
+)
+
+// ModerationMatch is a rule or model verdict that matched some text
+type ModerationMatch struct {
+	Rule   string           `json:"rule"`
+	Source ModerationSource `json:"source"`
+	Action ModerationAction `json:"action"`
+	// Text is the matched text, or the category reported by the model
+	Text string `json:"text"`
+}
+
+// ModerationMatches is the list of matches of a moderated event
+type ModerationMatches []ModerationMatch
+
+// Value implements the driver.Valuer interface, used to convert ModerationMatches to database values
+func (m ModerationMatches) Value() (driver.Value, error) {
+	if m == nil {
+		return json.Marshal([]ModerationMatch{})
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database values to ModerationMatches
+func (m *ModerationMatches) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, m)
+}
+
+// ModerationResult is the outcome of checking a text
+type ModerationResult struct {
+	// Action is the strictest action of the matches, empty when nothing matched
+	Action ModerationAction
+	// Text is the checked text with masked matches replaced
+	Text    string
+	Matches ModerationMatches
+}
+
+// Add records a match, keeping the strictest action
+func (r *ModerationResult) Add(match ModerationMatch) {
+	r.Matches = append(r.Matches, match)
+	if match.Action.severity() > r.Action.severity() {
+		r.Action = match.Action
+	}
+}
+
+// compiledModerationRule is a rule with its patterns ready to match
+type compiledModerationRule struct {
+	rule     *ModerationRule
+	keywords []string
+	regexes  []*regexp.Regexp
+}
+
+// ModerationMatcher checks text against the rules of one stage
+type ModerationMatcher struct {
+	rules []compiledModerationRule
+}
+
+// NewModerationMatcher compiles the rules of a configuration that apply to a stage
+func NewModerationMatcher(config *ModerationConfig, stage ModerationStage) (*ModerationMatcher, error) {
+	matcher := &ModerationMatcher{}
+	if config == nil || !config.Enabled {
+		return matcher, nil
+	}
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+		if !rule.appliesTo(stage) {
+			continue
+		}
+		compiled := compiledModerationRule{rule: rule}
+		for _, pattern := range rule.Patterns {
+			if strings.TrimSpace(pattern) == "" {
+				continue
+			}
+			if rule.Type == ModerationRuleRegex {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return nil, fmt.Errorf("rule %s: invalid regex %q: %w", rule.Name, pattern, err)
+				}
+				compiled.regexes = append(compiled.regexes, re)
+			} else {
+				compiled.keywords = append(compiled.keywords, strings.ToLower(pattern))
+			}
+		}
+		matcher.rules = append(matcher.rules, compiled)
+	}
+	return matcher, nil
+}
+
+// Empty reports whether no rule applies
+func (m *ModerationMatcher) Empty() bool {
+	return len(m.rules) == 0
+}
+
+// Check matches text against the rules, masking the matches of mask rules
+func (m *ModerationMatcher) Check(text string) *ModerationResult {
+	result := &ModerationResult{Text: text}
+	runes := []rune(text)
+	masked := make([]bool, len(runes))
+	lower := []rune(strings.ToLower(text))
+	// Lowercasing may change the length of some runes, keyword offsets are only reliable when it does not
+	sameLength := len(lower) == len(runes)
+	for _, rule := range m.rules {
+		var spans [][2]int
+		for _, keyword := range rule.keywords {
+			k := []rune(keyword)
+			if !sameLength {
+				if strings.Contains(strings.ToLower(text), keyword) {
+					result.Add(ModerationMatch{
+						Rule: rule.rule.Name, Source: ModerationSourceKeyword,
+						Action: rule.rule.Action, Text: keyword,
+					})
+				}
+				continue
+			}
+			for start := 0; start+len(k) <= len(lower); start++ {
+				if slices.Equal(lower[start:start+len(k)], k) {
+					spans = append(spans, [2]int{start, start + len(k)})
+					result.Add(ModerationMatch{
+						Rule: rule.rule.Name, Source: ModerationSourceKeyword,
+						Action: rule.rule.Action, Text: string(runes[start : start+len(k)]),
+					})
+					start += len(k) - 1
+				}
+			}
+		}
+		for _, re := range rule.regexes {
+			for _, loc := range re.FindAllStringIndex(text, -1) {
+				if loc[0] == loc[1] {
+					continue
+				}
+				start := len([]rune(text[:loc[0]]))
+				end := start + len([]rune(text[loc[0]:loc[1]]))
+				spans = append(spans, [2]int{start, end})
+				result.Add(ModerationMatch{
+					Rule: rule.rule.Name, Source: ModerationSourceRegex,
+					Action: rule.rule.Action, Text: text[loc[0]:loc[1]],
+				})
+			}
+		}
+		if rule.rule.Action == ModerationMask {
+			for _, span := range spans {
+				for i := span[0]; i < span[1]; i++ {
+					masked[i] = true
+				}
+			}
+		}
+	}
+	if result.Action == ModerationMask {
+		for i := range runes {
+			if masked[i] {
+				runes[i] = '*'
+			}
+		}
+		result.Text = string(runes)
+	}
+	return result
+}
+
+// ModerationReviewStatus is the review status of a moderated event
+type ModerationReviewStatus string
+
+const (
+	// ModerationReviewNone is the status of blocked and masked events, which need no review
+	ModerationReviewNone ModerationReviewStatus = "none"
+	// ModerationReviewPending is the status of flagged events waiting for review
+	ModerationReviewPending ModerationReviewStatus = "pending"
+	// ModerationReviewApproved marks flagged content as acceptable
+	ModerationReviewApproved ModerationReviewStatus = "approved"
+	// ModerationReviewRejected marks flagged content as a violation
+	ModerationReviewRejected ModerationReviewStatus = "rejected"
+)
+
+// ModerationEvent is the audit record of a question or answer that matched a moderation rule
+type ModerationEvent struct {
+	ID        string `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	TenantID  uint64 `json:"tenant_id"  gorm:"index"`
+	SessionID string `json:"session_id" gorm:"type:varchar(36)"`
+	// MessageID is the assistant message of a moderated answer
+	MessageID string `json:"message_id" gorm:"type:varchar(36)"`
+	// UserID is the user or service account, empty for requests made with other API keys
+	UserID  string            `json:"user_id"  gorm:"type:varchar(36)"`
+	Stage   ModerationStage   `json:"stage"    gorm:"type:varchar(16)"`
+	Action  ModerationAction  `json:"action"   gorm:"type:varchar(16)"`
+	Matches ModerationMatches `json:"matches"  gorm:"type:jsonb"`
+	// Content is the original text before masking
+	Content      string                 `json:"content"       gorm:"type:text"`
+	ReviewStatus ModerationReviewStatus `json:"review_status" gorm:"type:varchar(16)"`
+	ReviewNote   string                 `json:"review_note"   gorm:"type:text"`
+	ReviewedBy   string                 `json:"reviewed_by"   gorm:"type:varchar(36)"`
+	ReviewedAt   *time.Time             `json:"reviewed_at"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// TableName returns the table name of ModerationEvent
+func (ModerationEvent) TableName() string {
+	return "moderation_events"
+}
+
+// ModerationEventFilter selects the moderated events of a tenant
+type ModerationEventFilter struct {
+	Stage        ModerationStage        `form:"stage"`
+	Action       ModerationAction       `form:"action"`
+	ReviewStatus ModerationReviewStatus `form:"review_status"`
+	Pagination
+}
+
+// ReviewModerationEventRequest records the review of a flagged event
+type ReviewModerationEventRequest struct {
+	Status ModerationReviewStatus `json:"status"      binding:"required"`
+	Note   string                 `json:"review_note" binding:"max=2000"`
+}
@@ -0,0 +1,66 @@
+package types
+
+import "testing"
+
+func TestModerationMatcherCheck(t *testing.T) {
+	config := &ModerationConfig{
+		Enabled: true,
+		Rules: []ModerationRule{
+			{Name: "phone", Type: ModerationRuleRegex, Patterns: []string{`1[3-9]\d{9}`}, Action: ModerationMask},
+			{Name: "secret", Type: ModerationRuleKeyword, Patterns: []string{"Project X"}, Action: ModerationFlag},
+			{Name: "banned", Type: ModerationRuleKeyword, Patterns: []string{"炸药"}, Action: ModerationBlock,
+				Stages: []ModerationStage{ModerationStageQuery}},
+		},
+	}
+	matcher, err := NewModerationMatcher(config, ModerationStageAnswer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := matcher.Check("联系 13812345678 了解 project x 和炸药")
+	if result.Action != ModerationMask {
+		t.Errorf("got action %q, want mask", result.Action)
+	}
+	if want := "联系 *********** 了解 project x 和炸药"; result.Text != want {
+		t.Errorf("got text %q, want %q", result.Text, want)
+	}
+	if len(result.Matches) != 2 || result.Matches[1].Text != "project x" {
+		t.Errorf("got matches %+v", result.Matches)
+	}
+
+	matcher, err = NewModerationMatcher(config, ModerationStageQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := matcher.Check("如何制作炸药"); result.Action != ModerationBlock {
+		t.Errorf("got action %q, want block", result.Action)
+	}
+	if result := matcher.Check("hello"); result.Action != "" || len(result.Matches) != 0 {
+		t.Errorf("got %+v for clean text", result)
+	}
+}
+
+func TestModerationConfigValidate(t *testing.T) {
+	invalid := []ModerationConfig{
+		{Rules: []ModerationRule{{Type: "glob", Patterns: []string{"a"}, Action: ModerationBlock}}},
+		{Rules: []ModerationRule{{Type: ModerationRuleRegex, Patterns: []string{"("}, Action: ModerationBlock}}},
+		{Rules: []ModerationRule{{Type: ModerationRuleKeyword, Patterns: []string{" "}, Action: ModerationFlag}}},
+		{Model: &ModerationModelConfig{ModelID: "m", Action: ModerationMask}},
+	}
+	for i, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("config %d: expected an error", i)
+		}
+	}
+	valid := ModerationConfig{
+		Enabled: true,
+		Rules:   []ModerationRule{{Type: ModerationRuleKeyword, Patterns: []string{"a"}, Action: ModerationMask}},
+		Model:   &ModerationModelConfig{ModelID: "m", Action: ModerationFlag},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !valid.Model.AppliesTo(ModerationStageQuery) || valid.Model.AppliesTo(ModerationStageAnswer) {
+		t.Error("the model should classify questions only by default")
+	}
+}
@@ -0,0 +1,240 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Model name prefixes of the OpenAI-compatible chat completions gateway
+const (
+	// OpenAIModelPrefixKnowledgeBase selects a knowledge base with the default retrieval configuration, e.g. "kb:<id>"
+	OpenAIModelPrefixKnowledgeBase = "kb:"
+	// OpenAIModelPrefixAgent selects a custom agent with its knowledge bases and retrieval configuration, e.g. "agent:<id>"
+	OpenAIModelPrefixAgent = "agent:"
+)
+
+// OpenAI object types
+const (
+	OpenAIObjectChatCompletion      = "chat.completion"
+	OpenAIObjectChatCompletionChunk = "chat.completion.chunk"
+	OpenAIObjectModel               = "model"
+	OpenAIObjectList                = "list"
+)
+
+// OpenAIFinishReasonStop is the finish reason of a completed answer
+const OpenAIFinishReasonStop = "stop"
+
+// OpenAIChatCompletionRequest is the body of an OpenAI-compatible chat completions request
+// Sampling parameters are accepted for compatibility but the model settings of the knowledge base or agent apply
+type OpenAIChatCompletionRequest struct {
+	Model         string               `json:"model"    binding:"required"`
+	Messages      []OpenAIChatMessage  `json:"messages" binding:"required"`
+	Stream        bool                 `json:"stream"`
+	StreamOptions *OpenAIStreamOptions `json:"stream_options,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	MaxTokens     *int                 `json:"max_tokens,omitempty"`
+	User          string               `json:"user,omitempty"`
+	// SuggestFollowUps asks for follow-up questions after the answer, overriding the agent configuration
+	SuggestFollowUps *bool `json:"suggest_follow_ups,omitempty"`
+}
+
+// OpenAIStreamOptions configures streamed chat completions
+type OpenAIStreamOptions struct {
+	// IncludeUsage sends a final chunk with the token usage of the request
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// OpenAIChatMessage is a message of an OpenAI-compatible conversation
+// Content is either a string or an array of content parts
+type OpenAIChatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// openAIContentPart is an element of an array message content
+type openAIContentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Text returns the text content of the message, joining text content parts
+func (m OpenAIChatMessage) Text() (string, error) {
+	if len(m.Content) == 0 || string(m.Content) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(m.Content, &text); err == nil {
+		return text, nil
+	}
+	var parts []openAIContentPart
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return "", errors.New("message content must be a string or an array of content parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type != "text" {
+			return "", fmt.Errorf("unsupported content part type: %s", part.Type)
+		}
+		texts = append(texts, part.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// Conversation splits the messages into the current query and the completed rounds before it
+// System and developer messages are ignored: the prompts of the knowledge base or agent apply
+func (r *OpenAIChatCompletionRequest) Conversation() (string, []*History, error) {
+	var history []*History
+	var pending []string
+	for i, message := range r.Messages {
+		text, err := message.Text()
+		if err != nil {
+			return "", nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		switch message.Role {
+		case "system", "developer":
+		case "user":
+			if strings.TrimSpace(text) != "" {
+				pending = append(pending, text)
+			}
+		case "assistant":
+			// Answers without a question before them cannot form a round
+			if len(pending) > 0 {
+				history = append(history, &History{Query: strings.Join(pending, "\n"), Answer: text})
+				pending = nil
+			}
+		default:
+			return "", nil, fmt.Errorf("messages[%d]: unsupported role: %s", i, message.Role)
+		}
+	}
+	if len(pending) == 0 {
+		return "", nil, errors.New("the conversation must end with a user message")
+	}
+	return strings.Join(pending, "\n"), history, nil
+}
+
+// OpenAIChatCompletion is an OpenAI-compatible chat completion or streamed chunk
+type OpenAIChatCompletion struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []OpenAIChoice `json:"choices"`
+	Usage   *OpenAIUsage   `json:"usage,omitempty"`
+	// Citations maps the inline markers of the answer to the cited chunks, set on the completion and the last chunk
+	Citations Citations `json:"citations,omitempty"`
+	// FollowUpQuestions are suggested after the answer, set on the completion and the last chunk
+	FollowUpQuestions []string `json:"follow_up_questions,omitempty"`
+}
+
+// OpenAIChoice is a generated answer, Message is set on completions and Delta on streamed chunks
+type OpenAIChoice struct {
+	Index        int                    `json:"index"`
+	Message      *OpenAIResponseMessage `json:"message,omitempty"`
+	Delta        *OpenAIResponseMessage `json:"delta,omitempty"`
+	FinishReason *string                `json:"finish_reason"`
+}
+
+// OpenAIResponseMessage is the answer, or the part of it carried by a streamed chunk
+type OpenAIResponseMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+	// ReasoningContent carries the thinking of reasoning models apart from the answer
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+}
+
+// OpenAIUsage reports the tokens of a request
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// NewOpenAIUsage estimates the usage of a request from its prompt and completion texts
+func NewOpenAIUsage(prompt, completion string) *OpenAIUsage {
+	promptTokens := EstimateTokens(prompt)
+	completionTokens := EstimateTokens(completion)
+	return &OpenAIUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// EstimateTokens approximates the token count of a text without a tokenizer:
+// one token per Han character and one per four other non-space characters
+func EstimateTokens(text string) int {
+	han, other := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case !unicode.IsSpace(r):
+			other++
+		}
+	}
+	return han + (other+3)/4
+}
+
+// OpenAIModel describes a model of the gateway, i.e. a knowledge base or custom agent
+type OpenAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+	// Name is the display name of the knowledge base or agent
+	Name string `json:"name,omitempty"`
+}
+
+// OpenAIModelList is the response of the models endpoint
+type OpenAIModelList struct {
+	Object string         `json:"object"`
+	Data   []*OpenAIModel `json:"data"`
+}
+
+// OpenAIErrorResponse is the error body expected by OpenAI clients
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+// OpenAIError describes a failed request
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+// OpenAIThinkingSplitter separates the <think> blocks embedded in streamed answers
+// into reasoning content, keeping its state across chunks
+type OpenAIThinkingSplitter struct {
+	thinking bool
+}
+
+// Split returns the reasoning and answer parts of a streamed chunk
+func (s *OpenAIThinkingSplitter) Split(chunk string) (string, string) {
+	var reasoning, content strings.Builder
+	for chunk != "" {
+		if s.thinking {
+			end := strings.Index(chunk, "</think>")
+			if end < 0 {
+				reasoning.WriteString(chunk)
+				break
+			}
+			reasoning.WriteString(chunk[:end])
+			chunk = chunk[end+len("</think>"):]
+			s.thinking = false
+			continue
+		}
+		start := strings.Index(chunk, "<think>")
+		if start < 0 {
+			content.WriteString(chunk)
+			break
+		}
+		content.WriteString(chunk[:start])
+		chunk = chunk[start+len("<think>"):]
+		s.thinking = true
+	}
+	return reasoning.String(), content.String()
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOpenAIConversation(t *testing.T) {
+	var request OpenAIChatCompletionRequest
+	body := `{"model": "kb:1", "messages": [
+		{"role": "system", "content": "You are helpful"},
+		{"role": "user", "content": "What is WeKnora?"},
+		{"role": "assistant", "content": "A RAG framework."},
+		{"role": "user", "content": [{"type": "text", "text": "How do I"}, {"type": "text", "text": "deploy it?"}]}
+	]}`
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		t.Fatal(err)
+	}
+	query, history, err := request.Conversation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "How do I\ndeploy it?" {
+		t.Errorf("got query %q", query)
+	}
+	if len(history) != 1 || history[0].Query != "What is WeKnora?" || history[0].Answer != "A RAG framework." {
+		t.Errorf("got history %+v", history)
+	}
+}
+
+func TestOpenAIConversationErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"ends with assistant": `{"messages": [{"role": "user", "content": "hi"}, {"role": "assistant", "content": "hello"}]}`,
+		"unsupported role":    `{"messages": [{"role": "tool", "content": "{}"}, {"role": "user", "content": "hi"}]}`,
+		"image content":       `{"messages": [{"role": "user", "content": [{"type": "image_url", "image_url": {}}]}]}`,
+	} {
+		var request OpenAIChatCompletionRequest
+		if err := json.Unmarshal([]byte(body), &request); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := request.Conversation(); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if n := EstimateTokens("知识库 search"); n != 5 {
+		t.Errorf("got %d tokens, want 5", n)
+	}
+	usage := NewOpenAIUsage("abcd", "")
+	if usage.PromptTokens != 1 || usage.CompletionTokens != 0 || usage.TotalTokens != 1 {
+		t.Errorf("got %+v", usage)
+	}
+}
+
+func TestOpenAIThinkingSplitter(t *testing.T) {
+	var splitter OpenAIThinkingSplitter
+	var reasoning, content string
+	for _, chunk := range []string{"<think>Let me", " check", "</think>", "The answer", " is 42"} {
+		r, c := splitter.Split(chunk)
+		reasoning += r
+		content += c
+	}
+	if reasoning != "Let me check" || content != "The answer is 42" {
+		t.Errorf("got reasoning %q, content %q", reasoning, content)
+	}
+}
@@ -0,0 +1,48 @@
+package types
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// parserPluginFileTypes are the file types DocReader parses with external parser plugins,
+// as reported by DocReader
+var (
+	parserPluginFileTypes   = map[string]struct{}{}
+	parserPluginFileTypesMu sync.RWMutex
+)
+
+// SetParserPluginFileTypes replaces the file types accepted for DocReader parser plugins
+func SetParserPluginFileTypes(fileTypes []string) {
+	set := make(map[string]struct{}, len(fileTypes))
+	for _, fileType := range fileTypes {
+		fileType = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(fileType), "."))
+		if fileType != "" {
+			set[fileType] = struct{}{}
+		}
+	}
+	parserPluginFileTypesMu.Lock()
+	parserPluginFileTypes = set
+	parserPluginFileTypesMu.Unlock()
+}
+
+// IsParserPluginFileType reports whether a file type is parsed by a DocReader parser plugin
+func IsParserPluginFileType(fileType string) bool {
+	parserPluginFileTypesMu.RLock()
+	defer parserPluginFileTypesMu.RUnlock()
+	_, ok := parserPluginFileTypes[strings.ToLower(strings.TrimPrefix(fileType, "."))]
+	return ok
+}
+
+// ParserPluginFileTypes returns the file types of DocReader parser plugins in sorted order
+func ParserPluginFileTypes() []string {
+	parserPluginFileTypesMu.RLock()
+	defer parserPluginFileTypesMu.RUnlock()
+	fileTypes := make([]string, 0, len(parserPluginFileTypes))
+	for fileType := range parserPluginFileTypes {
+		fileTypes = append(fileTypes, fileType)
+	}
+	sort.Strings(fileTypes)
+	return fileTypes
+}
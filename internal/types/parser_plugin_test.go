@@ -0,0 +1,26 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParserPluginFileTypes(t *testing.T) {
+	defer SetParserPluginFileTypes(nil)
+
+	SetParserPluginFileTypes([]string{" .AXML ", "acme", "", "axml"})
+	if !IsParserPluginFileType("axml") || !IsParserPluginFileType(".ACME") {
+		t.Error("configured plugin file types should be accepted")
+	}
+	if IsParserPluginFileType("pdf") {
+		t.Error("built-in file types are not plugin file types")
+	}
+	if got := ParserPluginFileTypes(); !reflect.DeepEqual(got, []string{"acme", "axml"}) {
+		t.Errorf("got %v", got)
+	}
+
+	SetParserPluginFileTypes(nil)
+	if IsParserPluginFileType("axml") || len(ParserPluginFileTypes()) != 0 {
+		t.Error("plugin file types should be replaced")
+	}
+}
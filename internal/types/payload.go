@@ -0,0 +1,65 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultMaxPayloadBytes bounds the text of one response when the config sets no cap
+const DefaultMaxPayloadBytes = 4 << 20
+
+// PayloadCursor continues a response that was cut at the payload cap, or a listing paged with such cursors
+type PayloadCursor struct {
+	// Scope ties the cursor to the listing it was issued for, such as a knowledge ID or a hash of a search
+	Scope string `json:"s"`
+	// Offset is the position of the first item of the next response
+	Offset int `json:"o"`
+}
+
+// Encode returns the opaque text of the cursor
+func (c PayloadCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePayloadCursor parses the text of a cursor issued for scope
+func DecodePayloadCursor(s string, scope string) (*PayloadCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var cursor PayloadCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Offset < 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	if cursor.Scope != scope {
+		return nil, fmt.Errorf("cursor was issued for another listing")
+	}
+	return &cursor, nil
+}
+
+// PayloadScope hashes the parameters a listing depends on into the scope of its cursors
+func PayloadScope(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// FitPayload returns how many of n items fit in maxBytes, given the size of each. The first item is always kept,
+// so a response cut at the cap still makes progress; truncated tells whether items were left out.
+func FitPayload(n int, maxBytes int, size func(i int) int) (count int, truncated bool) {
+	total := 0
+	for i := 0; i < n; i++ {
+		total += size(i)
+		if total > maxBytes && i > 0 {
+			return i, true
+		}
+	}
+	return n, false
+}
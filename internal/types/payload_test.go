@@ -0,0 +1,45 @@
+package types
+
+import "testing"
+
+func TestPayloadCursorRoundTrip(t *testing.T) {
+	text := PayloadCursor{Scope: "knowledge-1", Offset: 42}.Encode()
+	cursor, err := DecodePayloadCursor(text, "knowledge-1")
+	if err != nil {
+		t.Fatalf("DecodePayloadCursor() error = %v", err)
+	}
+	if cursor.Offset != 42 {
+		t.Errorf("Offset = %d, want 42", cursor.Offset)
+	}
+	if _, err := DecodePayloadCursor(text, "knowledge-2"); err == nil {
+		t.Error("DecodePayloadCursor() accepted a cursor of another listing")
+	}
+	if _, err := DecodePayloadCursor("not a cursor", "knowledge-1"); err == nil {
+		t.Error("DecodePayloadCursor() accepted an invalid cursor")
+	}
+	if PayloadScope("a", "bc") == PayloadScope("ab", "c") {
+		t.Error("PayloadScope() does not separate its parts")
+	}
+}
+
+func TestFitPayload(t *testing.T) {
+	sizes := []int{40, 30, 50, 10}
+	size := func(i int) int { return sizes[i] }
+	tests := []struct {
+		maxBytes      int
+		wantCount     int
+		wantTruncated bool
+	}{
+		{maxBytes: 200, wantCount: 4},
+		{maxBytes: 130, wantCount: 4},
+		{maxBytes: 100, wantCount: 2, wantTruncated: true},
+		{maxBytes: 10, wantCount: 1, wantTruncated: true},
+	}
+	for _, tt := range tests {
+		count, truncated := FitPayload(len(sizes), tt.maxBytes, size)
+		if count != tt.wantCount || truncated != tt.wantTruncated {
+			t.Errorf("FitPayload(max %d) = %d, %v, want %d, %v",
+				tt.maxBytes, count, truncated, tt.wantCount, tt.wantTruncated)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package types
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PromptTemplateStatus is the lifecycle status of a prompt template version
+type PromptTemplateStatus string
+
+const (
+	// PromptTemplateDraft can still be edited and previewed, it does not affect chat answers
+	PromptTemplateDraft PromptTemplateStatus = "draft"
+	// PromptTemplatePublished is applied to answers of the knowledge base, at most one version at a time
+	PromptTemplatePublished PromptTemplateStatus = "published"
+	// PromptTemplateArchived was published before, publishing it again rolls back to it
+	PromptTemplateArchived PromptTemplateStatus = "archived"
+)
+
+// CitationStyle is how answers mark the passages they use
+type CitationStyle string
+
+const (
+	// CitationStyleInline asks for inline markers such as [1], the default
+	CitationStyleInline CitationStyle = "inline"
+	// CitationStyleNone asks for no citation markers
+	CitationStyleNone CitationStyle = "none"
+)
+
+// RefusalPolicy is how answers deal with questions the knowledge base does not cover
+type RefusalPolicy string
+
+const (
+	// RefusalPolicyStrict answers from the retrieved passages only and refuses otherwise
+	RefusalPolicyStrict RefusalPolicy = "strict"
+	// RefusalPolicyLenient may fill gaps with general knowledge, saying so
+	RefusalPolicyLenient RefusalPolicy = "lenient"
+)
+
+// Prompt template limits
+const (
+	maxPromptTemplateLength = 20000
+	maxPromptStyleLength    = 200
+	// MaxPromptPreviewQuestions caps the sample questions of one preview
+	MaxPromptPreviewQuestions = 5
+)
+
+// KBPromptTemplate is a version of the prompt answers of a knowledge base are generated with.
+// Versions are drafted, previewed against sample questions and then published, the published
+// version overrides the system prompt of chats against the knowledge base alone.
+type KBPromptTemplate struct {
+	ID              string               `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64               `json:"tenant_id"`
+	KnowledgeBaseID string               `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	Version         int                  `json:"version"`
+	Status          PromptTemplateStatus `json:"status"            gorm:"type:varchar(16)"`
+	// SystemPrompt replaces the system prompt of answer generation, empty keeps the agent's prompt
+	SystemPrompt string `json:"system_prompt"    gorm:"type:text"`
+	// ContextTemplate replaces the template the retrieved context is rendered with,
+	// it supports the {{query}} and {{contexts}} placeholders
+	ContextTemplate string `json:"context_template" gorm:"type:text"`
+	// Language is the language answers are written in, e.g. 简体中文 or English
+	Language string `json:"language"         gorm:"type:varchar(64)"`
+	// Tone describes the voice of answers, e.g. 简洁专业
+	Tone          string        `json:"tone"           gorm:"type:varchar(255)"`
+	CitationStyle CitationStyle `json:"citation_style" gorm:"type:varchar(16)"`
+	RefusalPolicy RefusalPolicy `json:"refusal_policy" gorm:"type:varchar(16)"`
+	// RefusalMessage is the reply of the strict policy when nothing relevant is retrieved
+	RefusalMessage string     `json:"refusal_message" gorm:"type:text"`
+	Note           string     `json:"note"            gorm:"type:text"`
+	CreatedBy      string     `json:"created_by"      gorm:"type:varchar(36)"`
+	PublishedAt    *time.Time `json:"published_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name of KBPromptTemplate
+func (KBPromptTemplate) TableName() string {
+	return "kb_prompt_templates"
+}
+
+// Validate checks the styles and lengths of a template
+func (t *KBPromptTemplate) Validate() error {
+	if utf8.RuneCountInString(t.SystemPrompt) > maxPromptTemplateLength ||
+		utf8.RuneCountInString(t.ContextTemplate) > maxPromptTemplateLength {
+		return errors.New("system_prompt and context_template must not exceed 20000 characters")
+	}
+	if t.ContextTemplate != "" && !strings.Contains(t.ContextTemplate, "{{contexts}}") {
+		return errors.New("context_template must contain the {{contexts}} placeholder")
+	}
+	if utf8.RuneCountInString(t.Language) > maxPromptStyleLength ||
+		utf8.RuneCountInString(t.Tone) > maxPromptStyleLength {
+		return errors.New("language and tone must not exceed 200 characters")
+	}
+	switch t.CitationStyle {
+	case "", CitationStyleInline, CitationStyleNone:
+	default:
+		return errors.New("citation_style must be inline or none")
+	}
+	switch t.RefusalPolicy {
+	case "", RefusalPolicyStrict, RefusalPolicyLenient:
+	default:
+		return errors.New("refusal_policy must be strict or lenient")
+	}
+	return nil
+}
+
+// Apply overrides the generation settings of a chat request with the template
+func (t *KBPromptTemplate) Apply(chatManage *ChatManage) {
+	if t.SystemPrompt != "" {
+		chatManage.SummaryConfig.Prompt = t.SystemPrompt
+	}
+	if instructions := t.styleInstructions(); instructions != "" {
+		chatManage.SummaryConfig.Prompt = strings.TrimRight(chatManage.SummaryConfig.Prompt, "\n") +
+			"\n\n" + instructions
+	}
+	if t.ContextTemplate != "" {
+		chatManage.SummaryConfig.ContextTemplate = t.ContextTemplate
+	}
+	if t.CitationStyle != "" {
+		chatManage.CitationStyle = t.CitationStyle
+	}
+	// A strict template answers questions without relevant passages with its refusal message
+	if t.RefusalPolicy == RefusalPolicyStrict && t.RefusalMessage != "" {
+		chatManage.FallbackStrategy = FallbackStrategyFixed
+		chatManage.FallbackResponse = t.RefusalMessage
+	}
+}
+
+// styleInstructions renders the answer language, tone and refusal policy as prompt instructions
+func (t *KBPromptTemplate) styleInstructions() string {
+	var lines []string
+	if language := strings.TrimSpace(t.Language); language != "" {
+		lines = append(lines, "- 请始终使用"+language+"回答，无论提问使用何种语言。")
+	}
+	if tone := strings.TrimSpace(t.Tone); tone != "" {
+		lines = append(lines, "- 回答的语气与风格："+tone+"。")
+	}
+	switch t.RefusalPolicy {
+	case RefusalPolicyStrict:
+		refusal := "- 只能依据参考资料回答；参考资料中没有相关内容时不要猜测或使用常识补充，直接说明无法回答"
+		if t.RefusalMessage != "" {
+			refusal += "，并回复：" + t.RefusalMessage
+		} else {
+			refusal += "。"
+		}
+		lines = append(lines, refusal)
+	case RefusalPolicyLenient:
+		lines = append(lines, "- 参考资料不足时可以结合通用知识回答，但要说明哪些内容并非来自参考资料。")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "回答要求：\n" + strings.Join(lines, "\n")
+}
+
+// PromptTemplateRequest creates or edits a draft template
+type PromptTemplateRequest struct {
+	SystemPrompt    string        `json:"system_prompt"`
+	ContextTemplate string        `json:"context_template"`
+	Language        string        `json:"language"`
+	Tone            string        `json:"tone"`
+	CitationStyle   CitationStyle `json:"citation_style"`
+	RefusalPolicy   RefusalPolicy `json:"refusal_policy"`
+	RefusalMessage  string        `json:"refusal_message" binding:"max=2000"`
+	Note            string        `json:"note"            binding:"max=2000"`
+}
+
+// PromptPreviewRequest tests a template against sample questions
+type PromptPreviewRequest struct {
+	Questions []string `json:"questions" binding:"required,min=1"`
+	// Generate asks the chat model for the answers, otherwise only the prompts are rendered
+	Generate bool `json:"generate"`
+	// ChatModelID defaults to the summary model of the knowledge base
+	ChatModelID string `json:"chat_model_id"`
+}
+
+// PromptPreviewResult is a sample question answered with a template
+type PromptPreviewResult struct {
+	Question     string `json:"question"`
+	SystemPrompt string `json:"system_prompt"`
+	UserContent  string `json:"user_content"`
+	// References are the passages retrieved for the question
+	References []*SearchResult `json:"references"`
+	// Answer is empty unless answers were generated
+	Answer string `json:"answer,omitempty"`
+	// Fallback is set when nothing relevant was retrieved and the fallback reply was used
+	Fallback bool   `json:"fallback,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKBPromptTemplateApply(t *testing.T) {
+	chatManage := &ChatManage{
+		SummaryConfig:    SummaryConfig{Prompt: "agent prompt\n", ContextTemplate: "{{query}} {{contexts}}"},
+		FallbackStrategy: FallbackStrategyModel,
+		FallbackResponse: "agent fallback",
+	}
+	template := &KBPromptTemplate{
+		Language:       "English",
+		Tone:           "简洁专业",
+		CitationStyle:  CitationStyleNone,
+		RefusalPolicy:  RefusalPolicyStrict,
+		RefusalMessage: "Sorry, the handbook does not cover this.",
+	}
+	template.Apply(chatManage)
+
+	prompt := chatManage.SummaryConfig.Prompt
+	if !strings.HasPrefix(prompt, "agent prompt\n\n回答要求：") {
+		t.Errorf("agent prompt not kept: %q", prompt)
+	}
+	for _, want := range []string{"English", "简洁专业", template.RefusalMessage} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt misses %q: %q", want, prompt)
+		}
+	}
+	if chatManage.SummaryConfig.ContextTemplate != "{{query}} {{contexts}}" {
+		t.Errorf("context template changed: %q", chatManage.SummaryConfig.ContextTemplate)
+	}
+	if chatManage.CitationStyle != CitationStyleNone {
+		t.Errorf("got citation style %q", chatManage.CitationStyle)
+	}
+	if chatManage.FallbackStrategy != FallbackStrategyFixed || chatManage.FallbackResponse != template.RefusalMessage {
+		t.Errorf("got fallback %q %q", chatManage.FallbackStrategy, chatManage.FallbackResponse)
+	}
+}
+
+func TestKBPromptTemplateApplySystemPrompt(t *testing.T) {
+	chatManage := &ChatManage{SummaryConfig: SummaryConfig{Prompt: "agent prompt"}}
+	template := &KBPromptTemplate{SystemPrompt: "kb prompt", ContextTemplate: "{{contexts}}"}
+	template.Apply(chatManage)
+	if chatManage.SummaryConfig.Prompt != "kb prompt" {
+		t.Errorf("got prompt %q", chatManage.SummaryConfig.Prompt)
+	}
+	if chatManage.SummaryConfig.ContextTemplate != "{{contexts}}" {
+		t.Errorf("got context template %q", chatManage.SummaryConfig.ContextTemplate)
+	}
+	if chatManage.CitationStyle != "" {
+		t.Errorf("got citation style %q", chatManage.CitationStyle)
+	}
+}
+
+func TestKBPromptTemplateValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template KBPromptTemplate
+		wantErr  bool
+	}{
+		{"empty", KBPromptTemplate{}, false},
+		{"styles", KBPromptTemplate{CitationStyle: CitationStyleInline, RefusalPolicy: RefusalPolicyLenient}, false},
+		{"citation style", KBPromptTemplate{CitationStyle: "footnote"}, true},
+		{"refusal policy", KBPromptTemplate{RefusalPolicy: "never"}, true},
+		{"context placeholder", KBPromptTemplate{ContextTemplate: "{{query}}"}, true},
+		{"tone length", KBPromptTemplate{Tone: strings.Repeat("简", maxPromptStyleLength+1)}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.template.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// TenantQuota caps the resources of a tenant. For each cap, zero falls back to the deployment default
+// and a negative value lifts the cap.
+type TenantQuota struct {
+	// MaxKnowledgeBases caps the number of knowledge bases
+	MaxKnowledgeBases int64 `yaml:"max_knowledge_bases"       json:"max_knowledge_bases"`
+	// MaxKnowledge caps the number of documents across all knowledge bases
+	MaxKnowledge int64 `yaml:"max_knowledge"             json:"max_knowledge"`
+	// MaxConcurrentParseJobs caps the number of documents waiting for or being parsed at once
+	MaxConcurrentParseJobs int64 `yaml:"max_concurrent_parse_jobs" json:"max_concurrent_parse_jobs"`
+	// MaxBrowserSessions caps the number of web pages being fetched for URL imports at once
+	MaxBrowserSessions int64 `yaml:"max_browser_sessions"      json:"max_browser_sessions"`
+}
+
+// Value implements the driver.Valuer interface, used to convert TenantQuota to database value
+func (q TenantQuota) Value() (driver.Value, error) {
+	return json.Marshal(q)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database values to TenantQuota
+func (q *TenantQuota) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, q)
+}
+
+// Effective returns the caps that apply given the deployment defaults, where zero means unlimited
+func (q *TenantQuota) Effective(defaults *TenantQuota) TenantQuota {
+	var own, fallback TenantQuota
+	if q != nil {
+		own = *q
+	}
+	if defaults != nil {
+		fallback = *defaults
+	}
+	pick := func(own, fallback int64) int64 {
+		if own == 0 {
+			own = fallback
+		}
+		return max(own, 0)
+	}
+	return TenantQuota{
+		MaxKnowledgeBases:      pick(own.MaxKnowledgeBases, fallback.MaxKnowledgeBases),
+		MaxKnowledge:           pick(own.MaxKnowledge, fallback.MaxKnowledge),
+		MaxConcurrentParseJobs: pick(own.MaxConcurrentParseJobs, fallback.MaxConcurrentParseJobs),
+		MaxBrowserSessions:     pick(own.MaxBrowserSessions, fallback.MaxBrowserSessions),
+	}
+}
+
+// QuotaUsage is the usage of one resource against its cap
+type QuotaUsage struct {
+	// Limit is zero when the resource is not capped
+	Limit int64 `json:"limit"`
+	Used  int64 `json:"used"`
+	// Exceeded is set when the cap is reached and nothing more can be added
+	Exceeded bool `json:"exceeded"`
+}
+
+// NewQuotaUsage returns the usage of a resource against its cap
+func NewQuotaUsage(limit int64, used int64) QuotaUsage {
+	return QuotaUsage{Limit: limit, Used: used, Exceeded: limit > 0 && used >= limit}
+}
+
+// TenantQuotaStatus is the usage of a tenant against its quotas
+type TenantQuotaStatus struct {
+	KnowledgeBases  QuotaUsage `json:"knowledge_bases"`
+	Knowledge       QuotaUsage `json:"knowledge"`
+	ParseJobs       QuotaUsage `json:"parse_jobs"`
+	BrowserSessions QuotaUsage `json:"browser_sessions"`
+	// Storage is in bytes
+	Storage QuotaUsage `json:"storage"`
+}
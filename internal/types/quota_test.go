@@ -0,0 +1,35 @@
+package types
+
+import "testing"
+
+func TestTenantQuotaEffective(t *testing.T) {
+	defaults := &TenantQuota{MaxKnowledgeBases: 10, MaxKnowledge: 1000, MaxConcurrentParseJobs: 5}
+	own := &TenantQuota{MaxKnowledgeBases: 20, MaxKnowledge: -1}
+
+	got := own.Effective(defaults)
+	want := TenantQuota{MaxKnowledgeBases: 20, MaxKnowledge: 0, MaxConcurrentParseJobs: 5}
+	if got != want {
+		t.Errorf("Effective() = %+v, want %+v", got, want)
+	}
+
+	var unset *TenantQuota
+	if got := unset.Effective(nil); got != (TenantQuota{}) {
+		t.Errorf("Effective() without caps = %+v, want no caps", got)
+	}
+}
+
+func TestNewQuotaUsage(t *testing.T) {
+	tests := []struct {
+		limit, used int64
+		exceeded    bool
+	}{
+		{0, 100, false},
+		{10, 9, false},
+		{10, 10, true},
+	}
+	for _, tt := range tests {
+		if got := NewQuotaUsage(tt.limit, tt.used); got.Exceeded != tt.exceeded {
+			t.Errorf("NewQuotaUsage(%d, %d).Exceeded = %v, want %v", tt.limit, tt.used, got.Exceeded, tt.exceeded)
+		}
+	}
+}
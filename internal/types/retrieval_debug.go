@@ -0,0 +1,86 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+// FusionMethodRRF and FusionMethodVectorOnly describe how HybridSearch merged retriever results
+const (
+	FusionMethodRRF        = "rrf"
+	FusionMethodVectorOnly = "vector_only"
+)
+
+// RetrievalHit is a single retriever hit recorded for retrieval debugging
+type RetrievalHit struct {
+	ChunkID     string    `json:"chunk_id"`
+	KnowledgeID string    `json:"knowledge_id"`
+	Content     string    `json:"content"`
+	Score       float64   `json:"score"`
+	MatchType   MatchType `json:"match_type"`
+	// VectorRank and KeywordRank are 1-based ranks used by RRF fusion, 0 when absent
+	VectorRank  int `json:"vector_rank,omitempty"`
+	KeywordRank int `json:"keyword_rank,omitempty"`
+}
+
+// FusionInfo describes the fusion of vector and keyword hits
+type FusionInfo struct {
+	Method        string  `json:"method"`
+	RRFK          int     `json:"rrf_k,omitempty"`
+	VectorWeight  float64 `json:"vector_weight"`
+	KeywordWeight float64 `json:"keyword_weight"`
+}
+
+// HybridSearchTrace records the retriever outputs of one HybridSearch call
+type HybridSearchTrace struct {
+	KnowledgeBaseID string          `json:"knowledge_base_id"`
+	QueryText       string          `json:"query_text"`
+	KeywordAnalyzer KeywordAnalyzer `json:"keyword_analyzer,omitempty"`
+	VectorHits      []*RetrievalHit `json:"vector_hits"`
+	KeywordHits     []*RetrievalHit `json:"keyword_hits"`
+	Fusion          *FusionInfo     `json:"fusion"`
+	FusedHits       []*RetrievalHit `json:"fused_hits"`
+}
+
+// RetrievalTrace collects HybridSearch traces of a request when stored in its context
+type RetrievalTrace struct {
+	mu       sync.Mutex
+	searches []*HybridSearchTrace
+}
+
+// AddSearch records a HybridSearch trace, safe for concurrent searches
+func (t *RetrievalTrace) AddSearch(search *HybridSearchTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.searches = append(t.searches, search)
+}
+
+// Searches returns the recorded HybridSearch traces
+func (t *RetrievalTrace) Searches() []*HybridSearchTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*HybridSearchTrace(nil), t.searches...)
+}
+
+// RetrievalTraceFromContext returns the retrieval trace of the context, nil when tracing is off
+func RetrievalTraceFromContext(ctx context.Context) *RetrievalTrace {
+	trace, _ := ctx.Value(RetrievalTraceContextKey).(*RetrievalTrace)
+	return trace
+}
+
+// RetrievalDebugResult is the output of each retrieval pipeline stage for a query
+type RetrievalDebugResult struct {
+	Query           string               `json:"query"`
+	RewriteQuery    string               `json:"rewrite_query"`
+	QueryExpansions []string             `json:"query_expansions"`
+	HyDEDocument    string               `json:"hyde_document,omitempty"`
+	Searches        []*HybridSearchTrace `json:"searches"`
+	SearchResults   []*SearchResult      `json:"search_results"`
+	RerankModelID   string               `json:"rerank_model_id"`
+	RerankResults   []*SearchResult      `json:"rerank_results"`
+	MergeResults    []*SearchResult      `json:"merge_results"`
+	// Context is the user message assembled from the final results for the chat model
+	Context string `json:"context"`
+	// StoppedAt is the stage that ended the pipeline early, e.g. when nothing was found
+	StoppedAt EventType `json:"stopped_at,omitempty"`
+}
@@ -0,0 +1,265 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Retrieval evaluation limits
+const (
+	MaxRetrievalEvalCases = 500
+	MaxRetrievalEvalK     = 100
+	MaxRetrievalEvalKs    = 10
+)
+
+// DefaultRetrievalEvalKs are the cutoffs recall is reported at when a run does not choose them
+var DefaultRetrievalEvalKs = []int{1, 3, 5, 10}
+
+// RetrievalEvalStatus is the status of a retrieval evaluation run
+type RetrievalEvalStatus string
+
+const (
+	RetrievalEvalStatusPending   RetrievalEvalStatus = "pending"
+	RetrievalEvalStatusRunning   RetrievalEvalStatus = "running"
+	RetrievalEvalStatusCompleted RetrievalEvalStatus = "completed"
+	RetrievalEvalStatusFailed    RetrievalEvalStatus = "failed"
+)
+
+// RetrievalEvalCase is a golden question with the sources expected to answer it
+type RetrievalEvalCase struct {
+	Question string `json:"question"`
+	// ExpectedKnowledgeIDs are the documents expected among the retrieved results
+	ExpectedKnowledgeIDs []string `json:"expected_knowledge_ids,omitempty"`
+	// ExpectedChunkIDs are the chunks expected among the retrieved results, they take
+	// precedence over ExpectedKnowledgeIDs when both are given
+	ExpectedChunkIDs []string `json:"expected_chunk_ids,omitempty"`
+	// ExpectedAnswer is an optional reference answer shown next to the generated one
+	ExpectedAnswer string `json:"expected_answer,omitempty"`
+}
+
+// ExpectedSourceIDs returns the IDs retrieved results are matched against and whether they are chunk IDs
+func (c *RetrievalEvalCase) ExpectedSourceIDs() ([]string, bool) {
+	if len(c.ExpectedChunkIDs) > 0 {
+		return c.ExpectedChunkIDs, true
+	}
+	return c.ExpectedKnowledgeIDs, false
+}
+
+// RetrievalEvalCases is the list of golden cases of an evaluation set
+type RetrievalEvalCases []RetrievalEvalCase
+
+// Validate checks that every case has a question and at least one expected source
+func (c RetrievalEvalCases) Validate() error {
+	if len(c) == 0 {
+		return errors.New("at least one case is required")
+	}
+	if len(c) > MaxRetrievalEvalCases {
+		return fmt.Errorf("at most %d cases are allowed", MaxRetrievalEvalCases)
+	}
+	for i := range c {
+		if strings.TrimSpace(c[i].Question) == "" {
+			return fmt.Errorf("case %d: question is required", i+1)
+		}
+		if len(c[i].ExpectedKnowledgeIDs) == 0 && len(c[i].ExpectedChunkIDs) == 0 {
+			return fmt.Errorf("case %d: expected_knowledge_ids or expected_chunk_ids is required", i+1)
+		}
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface
+func (c RetrievalEvalCases) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *RetrievalEvalCases) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// RetrievalEvalSet is a golden question set of a knowledge base
+type RetrievalEvalSet struct {
+	ID              string             `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64             `json:"tenant_id"`
+	KnowledgeBaseID string             `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	Name            string             `json:"name"              gorm:"type:varchar(255);not null"`
+	Description     string             `json:"description"       gorm:"type:text"`
+	Cases           RetrievalEvalCases `json:"cases"             gorm:"type:json"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt     `json:"-"                 gorm:"index"`
+}
+
+// RetrievalEvalConfig is the retrieval configuration an evaluation run used.
+// In a run request zero values and nil flags fall back to the current configuration.
+type RetrievalEvalConfig struct {
+	VectorThreshold    float64 `json:"vector_threshold"`
+	KeywordThreshold   float64 `json:"keyword_threshold"`
+	EmbeddingTopK      int     `json:"embedding_top_k"`
+	RerankModelID      string  `json:"rerank_model_id"`
+	RerankTopK         int     `json:"rerank_top_k"`
+	RerankThreshold    float64 `json:"rerank_threshold"`
+	EnableLLMExpansion *bool   `json:"enable_llm_expansion"`
+	EnableHyDE         *bool   `json:"enable_hyde"`
+	// ChatModelID generates the answers judged for faithfulness
+	ChatModelID string `json:"chat_model_id"`
+	// JudgeModelID scores faithfulness, ChatModelID is used when empty
+	JudgeModelID string `json:"judge_model_id"`
+	// Judge enables answer generation and LLM-judged faithfulness
+	Judge *bool `json:"judge"`
+	// Ks are the cutoffs recall is reported at
+	Ks []int `json:"ks"`
+}
+
+// Validate checks the bounds of a run request
+func (c *RetrievalEvalConfig) Validate() error {
+	if c.VectorThreshold < 0 || c.VectorThreshold > 1 || c.KeywordThreshold < 0 || c.KeywordThreshold > 1 ||
+		c.RerankThreshold < 0 || c.RerankThreshold > 1 {
+		return errors.New("thresholds must be between 0 and 1")
+	}
+	if c.EmbeddingTopK < 0 || c.RerankTopK < 0 {
+		return errors.New("top k must not be negative")
+	}
+	if len(c.Ks) > MaxRetrievalEvalKs {
+		return fmt.Errorf("at most %d ks are allowed", MaxRetrievalEvalKs)
+	}
+	for _, k := range c.Ks {
+		if k < 1 || k > MaxRetrievalEvalK {
+			return fmt.Errorf("k must be between 1 and %d", MaxRetrievalEvalK)
+		}
+	}
+	return nil
+}
+
+// JudgeEnabled reports whether answers are generated and judged
+func (c *RetrievalEvalConfig) JudgeEnabled() bool {
+	return c.Judge == nil || *c.Judge
+}
+
+// Value implements the driver.Valuer interface
+func (c RetrievalEvalConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *RetrievalEvalConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// RetrievalEvalMetrics are the aggregated metrics of an evaluation run
+type RetrievalEvalMetrics struct {
+	// RecallAtK maps each cutoff to the mean share of expected sources retrieved within it
+	RecallAtK map[int]float64 `json:"recall_at_k"`
+	// MRR is the mean reciprocal rank of the first expected source
+	MRR float64 `json:"mrr"`
+	// Faithfulness is the mean judged faithfulness of the answers to their retrieved context
+	Faithfulness   *float64 `json:"faithfulness,omitempty"`
+	EvaluatedCases int      `json:"evaluated_cases"`
+	JudgedCases    int      `json:"judged_cases"`
+	FailedCases    int      `json:"failed_cases"`
+}
+
+// Value implements the driver.Valuer interface
+func (m RetrievalEvalMetrics) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface
+func (m *RetrievalEvalMetrics) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, m)
+}
+
+// RetrievalEvalHit is a retrieved result of an evaluated case
+type RetrievalEvalHit struct {
+	ChunkID     string  `json:"chunk_id"`
+	KnowledgeID string  `json:"knowledge_id"`
+	Score       float64 `json:"score"`
+	// Relevant marks results matching an expected source
+	Relevant bool `json:"relevant"`
+}
+
+// RetrievalEvalCaseResult is the outcome of one golden case
+type RetrievalEvalCaseResult struct {
+	Question  string              `json:"question"`
+	Retrieved []*RetrievalEvalHit `json:"retrieved"`
+	RecallAtK map[int]float64     `json:"recall_at_k"`
+	// FirstRelevantRank is the 1-based rank of the first expected source, 0 when none was retrieved
+	FirstRelevantRank int      `json:"first_relevant_rank"`
+	ReciprocalRank    float64  `json:"reciprocal_rank"`
+	Answer            string   `json:"answer,omitempty"`
+	ExpectedAnswer    string   `json:"expected_answer,omitempty"`
+	Faithfulness      *float64 `json:"faithfulness,omitempty"`
+	JudgeReason       string   `json:"judge_reason,omitempty"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// RetrievalEvalCaseResults are the per case outcomes of an evaluation run
+type RetrievalEvalCaseResults []*RetrievalEvalCaseResult
+
+// Value implements the driver.Valuer interface
+func (r RetrievalEvalCaseResults) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan implements the sql.Scanner interface
+func (r *RetrievalEvalCaseResults) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, r)
+}
+
+// RetrievalEvalRun is one run of an evaluation set against a retrieval configuration
+type RetrievalEvalRun struct {
+	ID              string                   `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64                   `json:"tenant_id"`
+	EvalSetID       string                   `json:"eval_set_id"       gorm:"type:varchar(36);index"`
+	KnowledgeBaseID string                   `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	Status          RetrievalEvalStatus      `json:"status"            gorm:"type:varchar(16)"`
+	Config          RetrievalEvalConfig      `json:"config"            gorm:"type:json"`
+	Metrics         *RetrievalEvalMetrics    `json:"metrics,omitempty" gorm:"type:json"`
+	Results         RetrievalEvalCaseResults `json:"results,omitempty" gorm:"type:json"`
+	Total           int                      `json:"total"`
+	Finished        int                      `json:"finished"`
+	Error           string                   `json:"error,omitempty"   gorm:"type:text"`
+	CreatedAt       time.Time                `json:"created_at"`
+	UpdatedAt       time.Time                `json:"updated_at"`
+	FinishedAt      *time.Time               `json:"finished_at,omitempty"`
+}
+
+// RetrievalEvalPayload is the asynq payload of a retrieval evaluation run
+type RetrievalEvalPayload struct {
+	TenantID uint64 `json:"tenant_id"`
+	RunID    string `json:"run_id"`
+}
@@ -44,6 +44,8 @@ type RetrieveParams struct {
 	Threshold float64
 	// Knowledge type (e.g., "faq", "manual") - determines which index to use
 	KnowledgeType string
+	// Analyzer of the keyword index to match the query against (used for keyword retrieval)
+	KeywordAnalyzer KeywordAnalyzer
 	// Additional parameters, different retrievers may require different parameters
 	AdditionalParams map[string]interface{}
 	// Retriever type
@@ -3,6 +3,12 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 )
 
 // SearchTargetType represents the type of search target
@@ -28,6 +34,8 @@ type SearchTarget struct {
 	// KnowledgeIDs is the list of specific knowledge IDs to search within the knowledge base
 	// Only used when Type is SearchTargetTypeKnowledge
 	KnowledgeIDs []string `json:"knowledge_ids,omitempty"`
+	// HiddenKnowledgeIDs are the documents of the knowledge base a document permission hides from the user
+	HiddenKnowledgeIDs []string `json:"hidden_knowledge_ids,omitempty"`
 }
 
 // SearchTargets is a list of search targets, pre-computed at request entry point
@@ -78,6 +86,16 @@ func (st SearchTargets) ContainsKB(kbID string) bool {
 	return false
 }
 
+// IsHidden checks if a document permission hides the knowledge of a knowledge base from the user
+func (st SearchTargets) IsHidden(kbID string, knowledgeID string) bool {
+	for _, t := range st {
+		if t.KnowledgeBaseID == kbID && slices.Contains(t.HiddenKnowledgeIDs, knowledgeID) {
+			return true
+		}
+	}
+	return false
+}
+
 // SearchResult represents the search result
 type SearchResult struct {
 	// ID
@@ -86,6 +104,8 @@ type SearchResult struct {
 	Content string `gorm:"column:content"         json:"content"`
 	// Knowledge ID
 	KnowledgeID string `gorm:"column:knowledge_id"    json:"knowledge_id"`
+	// Knowledge base ID, used to apply per knowledge base rerank settings
+	KnowledgeBaseID string `                              json:"knowledge_base_id,omitempty"`
 	// Chunk index
 	ChunkIndex int `gorm:"column:chunk_index"     json:"chunk_index"`
 	// Knowledge title
@@ -126,6 +146,15 @@ type SearchResult struct {
 	// MatchedContent is the actual content that was matched in vector search
 	// For FAQ: this is the matched question text (standard or similar question)
 	MatchedContent string `json:"matched_content,omitempty"`
+
+	// Pinned indicates the chunk was pinned by a curator and gets a ranking boost
+	Pinned bool `json:"pinned,omitempty"`
+
+	// MatchedQuestion is the generated question (and answer, if any) whose vector matched the query
+	MatchedQuestion *GeneratedQuestion `json:"matched_question,omitempty"`
+
+	// Highlight holds snippets of the content with the matched query terms, set when requested
+	Highlight *SearchHighlight `json:"highlight,omitempty"`
 }
 
 // SearchParams represents the search parameters
@@ -139,6 +168,162 @@ type SearchParams struct {
 	KnowledgeIDs         []string `json:"knowledge_ids"`
 	TagIDs               []string `json:"tag_ids"` // Tag IDs for filtering (used for FAQ priority filtering)
 	OnlyRecommended      bool     `json:"only_recommended"`
+	// Structured filter resolved to knowledge IDs before retrieval
+	Filter *SearchFilter `json:"filter,omitempty"`
+	// Highlighted snippets to add to the results
+	Highlight *HighlightOptions `json:"highlight,omitempty"`
+	// Cursor continues results cut at the payload cap, the next_cursor of the previous response
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// metadataFilterKeyPattern restricts metadata filter keys, they are used as JSON paths in SQL
+var metadataFilterKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,64}$`)
+
+// SearchFilter represents structured conditions on the knowledge a search may return.
+// The conditions are resolved against the knowledge table and passed to the retrieval
+// engines as a knowledge ID filter, so the vector store and keyword index only score
+// matching chunks instead of filtering results afterwards.
+type SearchFilter struct {
+	// Tag IDs, sub-tags are included
+	TagIDs []string `json:"tag_ids,omitempty"`
+	// File types such as pdf or docx; "url" and "manual" match knowledge of that type
+	FileTypes []string `json:"file_types,omitempty"`
+	// Only knowledge created at or after this time
+	CreatedAfter *time.Time `json:"created_after,omitempty"`
+	// Only knowledge created at or before this time
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	// Knowledge metadata fields that must equal the given values
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Source URL domains, subdomains are included
+	SourceDomains []string `json:"source_domains,omitempty"`
+	// Source code languages such as go or python, matched by file type
+	Languages []string `json:"languages,omitempty"`
+}
+
+// IsEmpty reports whether the filter has no conditions
+func (f *SearchFilter) IsEmpty() bool {
+	return f == nil || (len(f.TagIDs) == 0 && !f.HasKnowledgeConditions())
+}
+
+// HasKnowledgeConditions reports whether the filter has conditions other than tags
+func (f *SearchFilter) HasKnowledgeConditions() bool {
+	return f != nil && (len(f.FileTypes) > 0 || f.CreatedAfter != nil || f.CreatedBefore != nil ||
+		len(f.Metadata) > 0 || len(f.SourceDomains) > 0 || len(f.Languages) > 0)
+}
+
+// Validate checks the date range, metadata keys, source domains and languages
+func (f *SearchFilter) Validate() error {
+	if f == nil {
+		return nil
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && f.CreatedAfter.After(*f.CreatedBefore) {
+		return errors.New("created_after must not be later than created_before")
+	}
+	for key := range f.Metadata {
+		if !metadataFilterKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid metadata filter key: %s", key)
+		}
+	}
+	for _, domain := range f.SourceDomains {
+		if strings.TrimSpace(domain) == "" || strings.ContainsAny(domain, "/%_ ") {
+			return fmt.Errorf("invalid source domain: %s", domain)
+		}
+	}
+	for _, language := range f.Languages {
+		if len(CodeLanguageFileTypes(language)) == 0 {
+			return fmt.Errorf("unsupported language: %s", language)
+		}
+	}
+	return nil
+}
+
+// Default and maximum snippet settings of search result highlighting
+const (
+	DefaultHighlightSnippetLength = 160
+	MaxHighlightSnippetLength     = 1000
+	MinHighlightSnippetLength     = 20
+	DefaultHighlightMaxSnippets   = 1
+	MaxHighlightSnippets          = 5
+)
+
+// HighlightOptions requests highlighted snippets in search results
+type HighlightOptions struct {
+	// Maximum length of a snippet in characters, ellipses excluded
+	SnippetLength int `json:"snippet_length,omitempty"`
+	// Maximum number of snippets per result
+	MaxSnippets int `json:"max_snippets,omitempty"`
+	// Return only the snippets and leave the chunk content out of the results
+	OmitContent bool `json:"omit_content,omitempty"`
+}
+
+// Validate checks the snippet bounds
+func (o *HighlightOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.SnippetLength != 0 &&
+		(o.SnippetLength < MinHighlightSnippetLength || o.SnippetLength > MaxHighlightSnippetLength) {
+		return fmt.Errorf("snippet_length must be between %d and %d",
+			MinHighlightSnippetLength, MaxHighlightSnippetLength)
+	}
+	if o.MaxSnippets < 0 || o.MaxSnippets > MaxHighlightSnippets {
+		return fmt.Errorf("max_snippets must be between 1 and %d", MaxHighlightSnippets)
+	}
+	return nil
+}
+
+// GetSnippetLength returns the snippet length, default is 160
+func (o *HighlightOptions) GetSnippetLength() int {
+	if o == nil || o.SnippetLength <= 0 {
+		return DefaultHighlightSnippetLength
+	}
+	return o.SnippetLength
+}
+
+// GetMaxSnippets returns the maximum number of snippets, default is 1
+func (o *HighlightOptions) GetMaxSnippets() int {
+	if o == nil || o.MaxSnippets <= 0 {
+		return DefaultHighlightMaxSnippets
+	}
+	return o.MaxSnippets
+}
+
+// SearchHighlight holds the highlighted snippets of a search result
+type SearchHighlight struct {
+	// Words of the content that were highlighted, lowercased, in order of appearance
+	Terms []string `json:"terms"`
+	// Aligned reports that no query term occurs literally in the content, so the highlighted words
+	// are variants of the query terms, e.g. for results found by vector similarity
+	Aligned bool `json:"aligned,omitempty"`
+	// Snippets in content order
+	Snippets []HighlightSnippet `json:"snippets"`
+}
+
+// HighlightSnippet is a bounded excerpt of the content, with ellipses where it was cut
+type HighlightSnippet struct {
+	Text string `json:"text"`
+	// Highlighted ranges of Text
+	Highlights []HighlightSpan `json:"highlights"`
+}
+
+// HighlightSpan is a highlighted range [Start, End) of a snippet, in characters (Unicode code points)
+type HighlightSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// ImageSearchParams represents the parameters of a visual similarity search
+type ImageSearchParams struct {
+	// Number of knowledge entries to return
+	TopK int `json:"top_k"     form:"top_k"`
+	// Minimum similarity score
+	Threshold float64 `json:"threshold" form:"threshold"`
+}
+
+// ImageSearchResult is a knowledge entry whose image is visually similar to the query image
+type ImageSearchResult struct {
+	Knowledge *Knowledge `json:"knowledge"`
+	Score     float64    `json:"score"`
 }
 
 // Value implements the driver.Valuer interface, used to convert SearchResult to database value
@@ -164,6 +349,8 @@ type Pagination struct {
 	Page int `form:"page"      json:"page"      binding:"omitempty,min=1"`
 	// Page size
 	PageSize int `form:"page_size" json:"page_size" binding:"omitempty,min=1,max=100"`
+	// Start is the row to start at instead of the page, set from continuation cursors
+	Start int `form:"-" json:"-"`
 }
 
 // GetPage gets the page number, default is 1
@@ -187,6 +374,9 @@ func (p *Pagination) GetPageSize() int {
 
 // Offset gets the offset for database query
 func (p *Pagination) Offset() int {
+	if p.Start > 0 {
+		return p.Start
+	}
 	return (p.GetPage() - 1) * p.GetPageSize()
 }
 
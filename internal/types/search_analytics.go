@@ -0,0 +1,110 @@
+package types
+
+import "time"
+
+// SearchLogSource is the entry point a logged search came from
+type SearchLogSource string
+
+const (
+	// SearchLogSourceChat is a retrieval made while answering a chat question
+	SearchLogSourceChat SearchLogSource = "chat"
+	// SearchLogSourceSearch is a knowledge search without answer generation
+	SearchLogSourceSearch SearchLogSource = "search"
+)
+
+// Feedback values of a logged search
+const (
+	SearchFeedbackPositive = "positive"
+	SearchFeedbackNegative = "negative"
+)
+
+// SearchQueryKind selects which queries a search analytics listing reports
+type SearchQueryKind string
+
+const (
+	// SearchQueryKindTop lists the most frequent queries
+	SearchQueryKindTop SearchQueryKind = "top"
+	// SearchQueryKindZeroResult lists queries that retrieved nothing
+	SearchQueryKindZeroResult SearchQueryKind = "zero_result"
+	// SearchQueryKindLowConfidence lists queries whose best result scored below the low confidence threshold
+	SearchQueryKindLowConfidence SearchQueryKind = "low_confidence"
+)
+
+// SearchLog records one search against a knowledge base for search analytics
+type SearchLog struct {
+	ID              string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	SessionID       string `json:"session_id"        gorm:"type:varchar(36)"`
+	// MessageID is the assistant message answered from this search, used to attribute clicks and feedback
+	MessageID string          `json:"message_id" gorm:"type:varchar(36)"`
+	Source    SearchLogSource `json:"source"     gorm:"type:varchar(16)"`
+	// Query is empty when query text recording is disabled, QueryHash still groups repeated queries
+	Query     string `json:"query"      gorm:"type:text"`
+	QueryHash string `json:"query_hash" gorm:"type:varchar(64)"`
+	HitCount  int    `json:"hit_count"`
+	// TopScore is the score of the best result after reranking
+	TopScore        float64     `json:"top_score"`
+	ClickCount      int         `json:"click_count"`
+	ClickedChunkIDs StringArray `json:"clicked_chunk_ids" gorm:"type:json"`
+	Feedback        string      `json:"feedback"          gorm:"type:varchar(16)"`
+	// LatencyMs is the time until the answer started streaming, or until results were returned for searches
+	LatencyMs int64 `json:"latency_ms"`
+	// ExperimentID and Variant attribute the search to an A/B experiment variant, empty outside experiments
+	ExperimentID string            `json:"experiment_id" gorm:"type:varchar(36)"`
+	Variant      ExperimentVariant `json:"variant"       gorm:"type:varchar(8)"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// TableName returns the table name of SearchLog
+func (SearchLog) TableName() string {
+	return "search_logs"
+}
+
+// SearchRecord describes a search to be logged
+type SearchRecord struct {
+	Source           SearchLogSource
+	SessionID        string
+	MessageID        string
+	Query            string
+	KnowledgeBaseIDs []string
+	Results          []*SearchResult
+	Latency          time.Duration
+	// Experiment is the experiment variant the search was routed to, nil outside experiments
+	Experiment *ExperimentAssignment
+}
+
+// SearchSummary aggregates the searches of a knowledge base over a period
+type SearchSummary struct {
+	TotalSearches         int64 `json:"total_searches"`
+	ZeroResultSearches    int64 `json:"zero_result_searches"`
+	LowConfidenceSearches int64 `json:"low_confidence_searches"`
+	Clicks                int64 `json:"clicks"`
+	PositiveFeedback      int64 `json:"positive_feedback"`
+	NegativeFeedback      int64 `json:"negative_feedback"`
+}
+
+// SearchQueryStat aggregates the searches of one query
+type SearchQueryStat struct {
+	Query            string    `json:"query"`
+	QueryHash        string    `json:"query_hash"`
+	Count            int64     `json:"count"`
+	AvgHitCount      float64   `json:"avg_hit_count"`
+	AvgTopScore      float64   `json:"avg_top_score"`
+	Clicks           int64     `json:"clicks"`
+	PositiveFeedback int64     `json:"positive_feedback"`
+	NegativeFeedback int64     `json:"negative_feedback"`
+	LastSearchedAt   time.Time `json:"last_searched_at"`
+}
+
+// SearchAnalytics reports how a knowledge base was searched so curators can find missing content
+type SearchAnalytics struct {
+	KnowledgeBaseID        string  `json:"knowledge_base_id"`
+	Days                   int     `json:"days"`
+	LowConfidenceThreshold float64 `json:"low_confidence_threshold"`
+	SearchSummary
+	TopQueries           []*SearchQueryStat `json:"top_queries"`
+	ZeroResultQueries    []*SearchQueryStat `json:"zero_result_queries"`
+	LowConfidenceQueries []*SearchQueryStat `json:"low_confidence_queries"`
+}
@@ -0,0 +1,24 @@
+package types
+
+import "testing"
+
+func TestSearchTargetsIsHidden(t *testing.T) {
+	targets := SearchTargets{
+		{Type: SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb1", HiddenKnowledgeIDs: []string{"k1"}},
+		{Type: SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb2"},
+	}
+	cases := []struct {
+		kbID, knowledgeID string
+		want              bool
+	}{
+		{"kb1", "k1", true},
+		{"kb1", "k2", false},
+		{"kb2", "k1", false},
+		{"kb3", "k1", false},
+	}
+	for _, c := range cases {
+		if got := targets.IsHidden(c.kbID, c.knowledgeID); got != c.want {
+			t.Errorf("IsHidden(%q, %q) = %v, want %v", c.kbID, c.knowledgeID, got, c.want)
+		}
+	}
+}
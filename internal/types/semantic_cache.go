@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+// SemanticCacheEntry is a cached answer to a question asked against a knowledge base
+type SemanticCacheEntry struct {
+	ID    string `json:"id"`
+	Query string `json:"query"`
+	// Embedding is the query vector, only comparable with vectors of the same EmbeddingModelID
+	Embedding        []float32       `json:"embedding"`
+	EmbeddingModelID string          `json:"embedding_model_id"`
+	Answer           string          `json:"answer"`
+	References       []*SearchResult `json:"references"`
+	// KnowledgeIDs are the knowledge the answer was built from, used for invalidation
+	KnowledgeIDs []string  `json:"knowledge_ids"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
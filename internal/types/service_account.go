@@ -0,0 +1,79 @@
+package types
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ServiceAccountIDPrefix starts the IDs of service accounts, so what they add or change can be told apart from users
+const ServiceAccountIDPrefix = "sa-"
+
+// IsServiceAccountID checks if a recorded creator or actor ID is a service account
+func IsServiceAccountID(id string) bool {
+	return strings.HasPrefix(id, ServiceAccountIDPrefix)
+}
+
+// IsValidForServiceAccount checks if the scope can be granted to a service account, admin is kept for people
+func (s APIKeyScope) IsValidForServiceAccount() bool {
+	return s == APIKeyScopeRetrieval || s == APIKeyScopeIngest
+}
+
+// ServiceAccount is a non-human identity of a tenant, such as a CI pipeline or crawler.
+// It cannot log in and authenticates with scoped API keys of its own.
+type ServiceAccount struct {
+	ID          string `json:"id"          gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64 `json:"tenant_id"   gorm:"index"`
+	Name        string `json:"name"        gorm:"type:varchar(255);not null"`
+	Description string `json:"description" gorm:"type:text"`
+	// Scopes are the scopes the keys of the account may have
+	Scopes StringArray `json:"scopes" gorm:"type:jsonb"`
+	// Disabled accounts cannot authenticate, their keys are kept
+	Disabled  bool           `json:"disabled"`
+	CreatedBy string         `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-"          gorm:"index"`
+	// Keys are the API keys of the account, filled in when getting a single account
+	Keys []*APIKey `json:"keys,omitempty" gorm:"-"`
+}
+
+// TableName returns the table name for GORM
+func (ServiceAccount) TableName() string {
+	return "service_accounts"
+}
+
+// AllowsScope checks if the account may have keys with the scope
+func (a *ServiceAccount) AllowsScope(scope APIKeyScope) bool {
+	return slices.Contains(a.Scopes, string(scope))
+}
+
+// CreateServiceAccountRequest creates a service account
+type CreateServiceAccountRequest struct {
+	Name        string        `json:"name"        binding:"required,max=255"`
+	Description string        `json:"description"`
+	Scopes      []APIKeyScope `json:"scopes"      binding:"required,min=1"`
+}
+
+// UpdateServiceAccountRequest updates a service account, narrowing its scopes also restricts its existing keys
+type UpdateServiceAccountRequest struct {
+	Name        *string        `json:"name"`
+	Description *string        `json:"description"`
+	Scopes      *[]APIKeyScope `json:"scopes"`
+	Disabled    *bool          `json:"disabled"`
+}
+
+// ActorIDFromContext returns who a request is attributed to: the user, else the service account of the API key.
+// It is empty for the tenant API key and scoped keys of no service account.
+func ActorIDFromContext(ctx context.Context) string {
+	if userID, _ := ctx.Value(UserIDContextKey).(string); userID != "" {
+		return userID
+	}
+	if apiKey := APIKeyFromContext(ctx); apiKey != nil {
+		return apiKey.ServiceAccountID
+	}
+	return ""
+}
@@ -107,6 +107,19 @@ type Session struct {
 	Messages []Message `json:"-" gorm:"foreignKey:SessionID"`
 }
 
+// ListID returns the id session listings are ordered by after their sort field
+func (s *Session) ListID() string {
+	return s.ID
+}
+
+// SortValue returns the value of a sort column of the session as text, for cursors
+func (s *Session) SortValue(column string) string {
+	if column == "updated_at" {
+		return FormatSortTime(s.UpdatedAt)
+	}
+	return FormatSortTime(s.CreatedAt)
+}
+
 func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
 	s.ID = uuid.New().String()
 	return nil
@@ -0,0 +1,113 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SessionExportFormat is the file format of an exported conversation
+type SessionExportFormat string
+
+const (
+	// SessionExportFormatMarkdown exports the conversation as a Markdown document
+	SessionExportFormatMarkdown SessionExportFormat = "markdown"
+	// SessionExportFormatJSON exports the conversation as JSON
+	SessionExportFormatJSON SessionExportFormat = "json"
+)
+
+// SessionExport is a conversation exported with the citations of its answers
+type SessionExport struct {
+	SessionID   string                  `json:"session_id"`
+	Title       string                  `json:"title"`
+	Description string                  `json:"description,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	ExportedAt  time.Time               `json:"exported_at"`
+	Messages    []*SessionExportMessage `json:"messages"`
+}
+
+// SessionExportMessage is a message of an exported conversation
+type SessionExportMessage struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Citations Citations `json:"citations,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// exportThinkPattern matches the thinking process embedded in answers
+var exportThinkPattern = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
+// NewSessionExport exports the completed messages of a session, thinking processes are left out
+func NewSessionExport(session *Session, messages []*Message) *SessionExport {
+	export := &SessionExport{
+		SessionID:   session.ID,
+		Title:       session.Title,
+		Description: session.Description,
+		CreatedAt:   session.CreatedAt,
+		ExportedAt:  time.Now(),
+		Messages:    make([]*SessionExportMessage, 0, len(messages)),
+	}
+	for _, message := range messages {
+		if message.Role != "user" && message.Role != "assistant" {
+			continue
+		}
+		if message.Role == "assistant" && !message.IsCompleted {
+			continue
+		}
+		export.Messages = append(export.Messages, &SessionExportMessage{
+			ID:        message.ID,
+			Role:      message.Role,
+			Content:   StripThinking(message.Content),
+			Citations: message.Citations,
+			CreatedAt: message.CreatedAt,
+		})
+	}
+	return export
+}
+
+// StripThinking removes the thinking process embedded in an answer
+func StripThinking(content string) string {
+	return strings.TrimSpace(exportThinkPattern.ReplaceAllString(content, ""))
+}
+
+// Markdown renders the conversation as a Markdown document, citations are listed after each answer
+func (e *SessionExport) Markdown() string {
+	var b strings.Builder
+	title := e.Title
+	if title == "" {
+		title = "未命名对话"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if e.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", e.Description)
+	}
+	fmt.Fprintf(&b, "> 创建于 %s，导出于 %s\n",
+		e.CreatedAt.Format("2006-01-02 15:04:05"), e.ExportedAt.Format("2006-01-02 15:04:05"))
+
+	for _, message := range e.Messages {
+		role := "用户"
+		if message.Role == "assistant" {
+			role = "助手"
+		}
+		fmt.Fprintf(&b, "\n## %s · %s\n\n%s\n", role, message.CreatedAt.Format("2006-01-02 15:04:05"), message.Content)
+		if len(message.Citations) == 0 {
+			continue
+		}
+		b.WriteString("\n**引用**\n\n")
+		for _, citation := range message.Citations {
+			fmt.Fprintf(&b, "- %s %s\n", citation.Marker, citation.Source())
+		}
+	}
+	return b.String()
+}
+
+// SessionShareLink is a signed read-only link to a conversation
+type SessionShareLink struct {
+	// Token signs the session and expiry of the link
+	Token string `json:"token"`
+	// Path is the API path reading the shared conversation
+	Path      string    `json:"path"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSessionExportMarkdown(t *testing.T) {
+	session := &Session{ID: "s1", Title: "部署问题"}
+	messages := []*Message{
+		{ID: "m1", Role: "user", Content: "How do I deploy?"},
+		{ID: "m2", Role: "assistant", IsCompleted: true,
+			Content: "<think>check the guide</think>Use Docker [1].",
+			Citations: Citations{{Label: "1", Marker: "[1]", FileName: "guide.pdf", PageStart: 3, PageEnd: 4,
+				HeadingPath: []string{"Install", "Docker"}}}},
+		{ID: "m3", Role: "assistant", Content: "still generating"},
+	}
+	export := NewSessionExport(session, messages)
+	if len(export.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(export.Messages))
+	}
+	if export.Messages[1].Content != "Use Docker [1]." {
+		t.Errorf("got answer %q", export.Messages[1].Content)
+	}
+
+	markdown := export.Markdown()
+	for _, want := range []string{"# 部署问题", "How do I deploy?", "- [1] guide.pdf · 第 3-4 页 · Install > Docker"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown misses %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Contains(markdown, "check the guide") {
+		t.Error("markdown should leave out the thinking process")
+	}
+}
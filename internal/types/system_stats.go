@@ -0,0 +1,153 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSystemStatsWindow is the window of system statistics when none is given
+	DefaultSystemStatsWindow = 24 * time.Hour
+	// MaxSystemStatsWindow bounds the window of system statistics
+	MaxSystemStatsWindow = 90 * 24 * time.Hour
+	// maxStatsErrorLength is how much of an error message is kept when grouping errors
+	maxStatsErrorLength = 200
+)
+
+// ParseSystemStatsWindow parses a statistics window such as 1h, 24h, 7d or 30d, the default window for an empty string
+func ParseSystemStatsWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return DefaultSystemStatsWindow, nil
+	}
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid window %q, use hours or days such as 24h or 7d", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid window %q, use hours or days such as 24h or 7d", s)
+	}
+	var window time.Duration
+	switch s[len(s)-1] {
+	case 'h':
+		window = time.Duration(n) * time.Hour
+	case 'd':
+		window = time.Duration(n) * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("invalid window %q, use hours or days such as 24h or 7d", s)
+	}
+	if window > MaxSystemStatsWindow {
+		return 0, fmt.Errorf("window %q is longer than %d days", s, int(MaxSystemStatsWindow.Hours()/24))
+	}
+	return window, nil
+}
+
+// SystemStatsQuery selects the period of system statistics
+type SystemStatsQuery struct {
+	From time.Time
+	To   time.Time
+}
+
+// TenantStatsSort is the column tenants are ranked by in system statistics
+type TenantStatsSort string
+
+const (
+	TenantStatsSortDocuments      TenantStatsSort = "documents"
+	TenantStatsSortStorage        TenantStatsSort = "storage"
+	TenantStatsSortTokens         TenantStatsSort = "tokens"
+	TenantStatsSortActiveSessions TenantStatsSort = "active_sessions"
+	TenantStatsSortParseFailed    TenantStatsSort = "parse_failed"
+)
+
+// IsValid reports whether tenants can be ranked by the column
+func (s TenantStatsSort) IsValid() bool {
+	switch s {
+	case TenantStatsSortDocuments, TenantStatsSortStorage, TenantStatsSortTokens,
+		TenantStatsSortActiveSessions, TenantStatsSortParseFailed:
+		return true
+	}
+	return false
+}
+
+// ParseStats counts the documents whose parsing finished in a period
+type ParseStats struct {
+	ParseCompleted int64 `json:"parse_completed"`
+	ParseFailed    int64 `json:"parse_failed"`
+	// ParseFailureRate is the share of finished parses that failed, from 0 to 1
+	ParseFailureRate float64 `json:"parse_failure_rate"`
+}
+
+// ComputeFailureRate sets the failure rate from the completed and failed counts
+func (p *ParseStats) ComputeFailureRate() {
+	p.ParseFailureRate = 0
+	if finished := p.ParseCompleted + p.ParseFailed; finished > 0 {
+		p.ParseFailureRate = float64(p.ParseFailed) / float64(finished)
+	}
+}
+
+// TenantStats aggregates the documents, storage and activity of a tenant. Documents and storage are current,
+// usage, sessions and parsing are counted over the period of the query.
+type TenantStats struct {
+	TenantID     uint64 `json:"tenant_id"`
+	Name         string `json:"name"`
+	Status       string `json:"status"`
+	Documents    int64  `json:"documents"`
+	StorageUsed  int64  `json:"storage_used"`
+	StorageQuota int64  `json:"storage_quota"`
+	// Requests, TotalTokens and Cost are the LLM usage of the tenant
+	Requests    int64   `json:"requests"`
+	TotalTokens int64   `json:"total_tokens"`
+	Cost        float64 `json:"cost"`
+	// ActiveSessions are the sessions with at least one message in the period
+	ActiveSessions int64 `json:"active_sessions"`
+	ParseStats
+}
+
+// SystemStatsOverview aggregates the statistics of every tenant
+type SystemStatsOverview struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Tenants     int64     `json:"tenants"`
+	Documents   int64     `json:"documents"`
+	StorageUsed int64     `json:"storage_used"`
+	Requests    int64     `json:"requests"`
+	TotalTokens int64     `json:"total_tokens"`
+	Cost        float64   `json:"cost"`
+	// ActiveSessions are the sessions with at least one message in the period
+	ActiveSessions int64 `json:"active_sessions"`
+	// FailedJobs are the background jobs that failed for good in the period
+	FailedJobs int64 `json:"failed_jobs"`
+	ParseStats
+}
+
+// ErrorStat counts the occurrences of an error of a background task type
+type ErrorStat struct {
+	TaskType string `json:"task_type"`
+	// Error is the normalized error message, attempt counters and IDs removed
+	Error      string    `json:"error"`
+	Count      int64     `json:"count"`
+	Tenants    int64     `json:"tenants"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+var (
+	// statsErrorAttemptSuffix matches the attempt counters appended to parse errors
+	statsErrorAttemptSuffix = regexp.MustCompile(`\s*\((attempt \d+/\d+, retrying|gave up after \d+ attempts)\)$`)
+	// statsErrorUUID matches the IDs of knowledges, chunks and other records in error messages
+	statsErrorUUID = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+)
+
+// NormalizeErrorMessage reduces an error message to its cause so occurrences on different records and attempts
+// are counted together
+func NormalizeErrorMessage(msg string) string {
+	msg = strings.TrimSpace(msg)
+	msg = statsErrorAttemptSuffix.ReplaceAllString(msg, "")
+	msg = statsErrorUUID.ReplaceAllString(msg, "<id>")
+	if runes := []rune(msg); len(runes) > maxStatsErrorLength {
+		msg = string(runes[:maxStatsErrorLength]) + "..."
+	}
+	return msg
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSystemStatsWindow(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":    DefaultSystemStatsWindow,
+		"1h":  time.Hour,
+		"24h": 24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+		"90d": MaxSystemStatsWindow,
+	}
+	for input, want := range cases {
+		got, err := ParseSystemStatsWindow(input)
+		if err != nil || got != want {
+			t.Errorf("ParseSystemStatsWindow(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"d", "0h", "-1d", "7w", "1.5h", "91d"} {
+		if _, err := ParseSystemStatsWindow(input); err == nil {
+			t.Errorf("ParseSystemStatsWindow(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestParseStatsFailureRate(t *testing.T) {
+	stats := ParseStats{ParseCompleted: 3, ParseFailed: 1}
+	stats.ComputeFailureRate()
+	if stats.ParseFailureRate != 0.25 {
+		t.Errorf("got failure rate %v, want 0.25", stats.ParseFailureRate)
+	}
+	empty := ParseStats{}
+	empty.ComputeFailureRate()
+	if empty.ParseFailureRate != 0 {
+		t.Errorf("got failure rate %v without parses, want 0", empty.ParseFailureRate)
+	}
+}
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	cases := map[string]string{
+		"docreader timeout (attempt 2/4, retrying)":            "docreader timeout",
+		"docreader timeout (gave up after 4 attempts)":         "docreader timeout",
+		"chunk 3f2b8c1e-9a4d-4e6f-8b2a-1c3d5e7f9a0b not found": "chunk <id> not found",
+	}
+	for input, want := range cases {
+		if got := NormalizeErrorMessage(input); got != want {
+			t.Errorf("NormalizeErrorMessage(%q) = %q, want %q", input, got, want)
+		}
+	}
+	long := NormalizeErrorMessage(strings.Repeat("错", 300))
+	if got := len([]rune(long)); got != maxStatsErrorLength+3 {
+		t.Errorf("got %d runes for a long message, want %d", got, maxStatsErrorLength+3)
+	}
+}
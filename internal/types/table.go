@@ -0,0 +1,241 @@
+package types
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StructuredTable is a table extracted from a document, kept as header + rows
+// so that cell values can be looked up without re-parsing flattened text
+type StructuredTable struct {
+	Header []string   `json:"header"`
+	Rows   [][]string `json:"rows"`
+	// Sheet is the worksheet of Excel workbooks the rows come from
+	Sheet string `json:"sheet,omitempty"`
+	// HeaderRow, RowStart and RowEnd are 1-based row numbers in the CSV or Excel sheet;
+	// HeaderRow is 0 when the sheet has no header row and column names were generated
+	HeaderRow int `json:"header_row,omitempty"`
+	RowStart  int `json:"row_start,omitempty"`
+	RowEnd    int `json:"row_end,omitempty"`
+	// ColumnTypes are the detected types of the columns: integer, number, date, boolean or text
+	ColumnTypes []string `json:"column_types,omitempty"`
+}
+
+// TableChunkMetadata is the machine-readable sidecar stored in Chunk.Metadata of table chunks
+type TableChunkMetadata struct {
+	Table *StructuredTable `json:"table"`
+}
+
+// markdownTableSeparator matches the separator line below a Markdown table header, e.g. |---|:--:|
+var markdownTableSeparator = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+
+// ParseMarkdownTables extracts all pipe tables from Markdown content.
+// Tables without any data row are skipped.
+func ParseMarkdownTables(content string) []*StructuredTable {
+	lines := strings.Split(content, "\n")
+	var tables []*StructuredTable
+	for i := 0; i+1 < len(lines); i++ {
+		headerLine, separatorLine := strings.TrimSpace(lines[i]), strings.TrimSpace(lines[i+1])
+		if !strings.Contains(headerLine, "|") || !strings.Contains(separatorLine, "|") ||
+			!markdownTableSeparator.MatchString(separatorLine) {
+			continue
+		}
+		header := splitMarkdownTableRow(headerLine)
+		table := &StructuredTable{Header: header}
+		j := i + 2
+		for ; j < len(lines); j++ {
+			rowLine := strings.TrimSpace(lines[j])
+			if rowLine == "" || !strings.Contains(rowLine, "|") {
+				break
+			}
+			table.Rows = append(table.Rows, normalizeTableRow(splitMarkdownTableRow(rowLine), len(header)))
+		}
+		if len(table.Rows) > 0 {
+			tables = append(tables, table)
+		}
+		i = j - 1
+	}
+	return tables
+}
+
+// splitMarkdownTableRow splits a Markdown table line into trimmed cells, honoring escaped pipes
+func splitMarkdownTableRow(line string) []string {
+	line = strings.TrimPrefix(strings.TrimSuffix(line, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// normalizeTableRow pads or truncates a row to the header width
+func normalizeTableRow(row []string, width int) []string {
+	if len(row) >= width {
+		return row[:width]
+	}
+	return append(row, make([]string, width-len(row))...)
+}
+
+// Markdown serializes the table back into a Markdown pipe table
+func (t *StructuredTable) Markdown() string {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			b.WriteString(" ")
+			b.WriteString(strings.ReplaceAll(cell, "|", "\\|"))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(t.Header)
+	b.WriteString("|")
+	for range t.Header {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// RowText renders a row as "header: value" pairs so each cell keeps its column name
+func (t *StructuredTable) RowText(row []string) string {
+	pairs := make([]string, 0, len(row))
+	for i, cell := range row {
+		if cell == "" {
+			continue
+		}
+		if i < len(t.Header) && t.Header[i] != "" {
+			pairs = append(pairs, t.Header[i]+": "+cell)
+		} else {
+			pairs = append(pairs, cell)
+		}
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// LookupRows returns the rows whose cell values appear in the query, best matches first.
+// It answers cell-lookup questions such as "what is the price of X" by locating the row for X.
+func (t *StructuredTable) LookupRows(query string, limit int) [][]string {
+	query = strings.ToLower(query)
+	type scoredRow struct {
+		row   []string
+		score int
+	}
+	var matches []scoredRow
+	for _, row := range t.Rows {
+		score := 0
+		for _, cell := range row {
+			cell = strings.ToLower(strings.TrimSpace(cell))
+			// Single characters match almost any query and carry no signal
+			if len([]rune(cell)) < 2 {
+				continue
+			}
+			if strings.Contains(query, cell) {
+				score += len([]rune(cell))
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scoredRow{row: row, score: score})
+		}
+	}
+	// Stable sort keeps document order among equal scores
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	rows := make([][]string, len(matches))
+	for i, m := range matches {
+		rows[i] = m.row
+	}
+	return rows
+}
+
+// TableCell is a cell value found by LookupCells
+type TableCell struct {
+	// Key is the cells of the row that appear in the query, identifying the row
+	Key    string
+	Column string
+	Value  string
+}
+
+// LookupCells answers "what is the X of Y" questions: in the rows LookupRows finds by the values
+// in the query (Y), it returns the cells of the columns whose names appear in the query (X).
+func (t *StructuredTable) LookupCells(query string, limit int) []TableCell {
+	lowerQuery := strings.ToLower(query)
+	var columns []int
+	for i, name := range t.Header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && strings.Contains(lowerQuery, name) {
+			columns = append(columns, i)
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	var cells []TableCell
+	for _, row := range t.LookupRows(query, limit) {
+		var keys []string
+		isKey := make(map[int]bool)
+		for i, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if len([]rune(cell)) >= 2 && strings.Contains(lowerQuery, strings.ToLower(cell)) {
+				keys = append(keys, cell)
+				isKey[i] = true
+			}
+		}
+		for _, i := range columns {
+			if i >= len(row) || isKey[i] || strings.TrimSpace(row[i]) == "" {
+				continue
+			}
+			cells = append(cells, TableCell{Key: strings.Join(keys, " / "), Column: t.Header[i], Value: row[i]})
+		}
+	}
+	return cells
+}
+
+// TableMetadata parses the structured table sidecar of a table chunk
+func (c *Chunk) TableMetadata() (*TableChunkMetadata, error) {
+	if c == nil || len(c.Metadata) == 0 {
+		return nil, nil
+	}
+	var meta TableChunkMetadata
+	if err := json.Unmarshal(c.Metadata, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// SetTableMetadata stores the structured table sidecar of a table chunk
+func (c *Chunk) SetTableMetadata(meta *TableChunkMetadata) error {
+	if c == nil {
+		return nil
+	}
+	if meta == nil {
+		c.Metadata = nil
+		return nil
+	}
+	bytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	c.Metadata = JSON(bytes)
+	return nil
+}
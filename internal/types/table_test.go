@@ -0,0 +1,89 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdownTables(t *testing.T) {
+	content := "产品价格如下：\n\n| 产品 | 价格 | 库存 |\n| --- | :---: | ---: |\n| 苹果 | 5 | 100 |\n| 香蕉 | 3 |\n| 管道\\|接头 | 12 | 7 |\n\n结束"
+	tables := ParseMarkdownTables(content)
+	if len(tables) != 1 {
+		t.Fatalf("expected 1 table, got %d", len(tables))
+	}
+	want := &StructuredTable{
+		Header: []string{"产品", "价格", "库存"},
+		Rows: [][]string{
+			{"苹果", "5", "100"},
+			{"香蕉", "3", ""},
+			{"管道|接头", "12", "7"},
+		},
+	}
+	if !reflect.DeepEqual(tables[0], want) {
+		t.Errorf("got %+v, want %+v", tables[0], want)
+	}
+}
+
+func TestParseMarkdownTablesIgnoresNonTables(t *testing.T) {
+	content := "a | b\n---\n\n| only | header |\n| --- | --- |\n"
+	if tables := ParseMarkdownTables(content); len(tables) != 0 {
+		t.Errorf("expected no tables, got %+v", tables)
+	}
+}
+
+func TestStructuredTableMarkdownRoundTrip(t *testing.T) {
+	table := &StructuredTable{
+		Header: []string{"名称", "说明"},
+		Rows:   [][]string{{"a|b", "x"}},
+	}
+	parsed := ParseMarkdownTables(table.Markdown())
+	if len(parsed) != 1 || !reflect.DeepEqual(parsed[0], table) {
+		t.Errorf("round trip mismatch: %+v", parsed)
+	}
+}
+
+func TestStructuredTableLookupRows(t *testing.T) {
+	table := &StructuredTable{
+		Header: []string{"城市", "人口", "省份"},
+		Rows: [][]string{
+			{"广州", "1880万", "广东"},
+			{"深圳", "1770万", "广东"},
+			{"杭州", "1230万", "浙江"},
+		},
+	}
+	rows := table.LookupRows("深圳的人口是多少", 5)
+	if len(rows) != 1 || rows[0][0] != "深圳" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	if got := table.RowText(rows[0]); got != "城市: 深圳; 人口: 1770万; 省份: 广东" {
+		t.Errorf("unexpected row text: %s", got)
+	}
+	if rows := table.LookupRows("广东有哪些城市", 1); len(rows) != 1 || rows[0][0] != "广州" {
+		t.Errorf("expected limit to keep first match in document order, got %v", rows)
+	}
+	if rows := table.LookupRows("无关问题", 5); len(rows) != 0 {
+		t.Errorf("expected no rows, got %v", rows)
+	}
+}
+
+func TestStructuredTableLookupCells(t *testing.T) {
+	table := &StructuredTable{
+		Header: []string{"产品", "单价", "产地"},
+		Rows: [][]string{
+			{"苹果", "3.5", "山东"},
+			{"香蕉", "2", "海南"},
+		},
+	}
+	cells := table.LookupCells("香蕉的单价是多少", 5)
+	if len(cells) != 1 || cells[0] != (TableCell{Key: "香蕉", Column: "单价", Value: "2"}) {
+		t.Fatalf("unexpected cells: %+v", cells)
+	}
+	if cells := table.LookupCells("香蕉怎么样", 5); cells != nil {
+		t.Errorf("expected no cells without a column name, got %+v", cells)
+	}
+	// The column holding the key is skipped, the other mentioned column answers
+	cells = table.LookupCells("哪个产品产地是山东", 5)
+	if len(cells) != 1 || cells[0] != (TableCell{Key: "山东", Column: "产品", Value: "苹果"}) {
+		t.Errorf("unexpected cells: %+v", cells)
+	}
+}
@@ -14,6 +14,8 @@ type KnowledgeTag struct {
 	TenantID uint64 `json:"tenant_id"`
 	// Knowledge base ID that this tag belongs to
 	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	// Parent tag ID, empty for root tags
+	ParentID string `json:"parent_id"         gorm:"type:varchar(36);index;default:''"`
 	// Tag name, unique within the same knowledge base
 	Name string `json:"name"              gorm:"type:varchar(128);not null"`
 	// Optional display color
@@ -33,6 +35,50 @@ type KnowledgeTagWithStats struct {
 	ChunkCount     int64 `json:"chunk_count"`
 }
 
+// KnowledgeTagNode is a node of the tag tree of a knowledge base.
+type KnowledgeTagNode struct {
+	KnowledgeTagWithStats
+	Children []*KnowledgeTagNode `json:"children"`
+}
+
+// KnowledgeTagRelation assigns an additional tag to a knowledge entry.
+// Knowledge.TagID keeps the primary tag; all assigned tags (including the primary one)
+// are recorded in this table.
+type KnowledgeTagRelation struct {
+	KnowledgeID     string    `json:"knowledge_id"      gorm:"type:varchar(36);primaryKey"`
+	TagID           string    `json:"tag_id"            gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64    `json:"tenant_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TableName returns the table name of KnowledgeTagRelation
+func (KnowledgeTagRelation) TableName() string {
+	return "knowledge_tag_relations"
+}
+
+// KnowledgeRetagMode defines how a bulk retag request changes existing tags.
+type KnowledgeRetagMode string
+
+const (
+	// KnowledgeRetagModeReplace replaces all tags (moves knowledge to the given tags)
+	KnowledgeRetagModeReplace KnowledgeRetagMode = "replace"
+	// KnowledgeRetagModeAdd adds the given tags, keeping existing ones
+	KnowledgeRetagModeAdd KnowledgeRetagMode = "add"
+	// KnowledgeRetagModeRemove removes the given tags
+	KnowledgeRetagModeRemove KnowledgeRetagMode = "remove"
+)
+
+// KnowledgeRetagRequest is a bulk move/retag request for knowledge entries.
+type KnowledgeRetagRequest struct {
+	KnowledgeIDs []string           `json:"knowledge_ids" binding:"required"`
+	TagIDs       []string           `json:"tag_ids"`
+	Mode         KnowledgeRetagMode `json:"mode"`
+}
+
+// MaxTagDepth is the maximum nesting depth of the tag tree.
+const MaxTagDepth = 8
+
 // TagReferenceCounts holds the reference counts for a tag.
 type TagReferenceCounts struct {
 	KnowledgeCount int64
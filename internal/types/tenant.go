@@ -75,6 +75,10 @@ type Tenant struct {
 	StorageQuota int64 `yaml:"storage_quota"       json:"storage_quota"       gorm:"default:10737418240"`
 	// Storage used (Bytes)
 	StorageUsed int64 `yaml:"storage_used"        json:"storage_used"        gorm:"default:0"`
+	// Monthly LLM token budget, zero or negative means unlimited
+	MonthlyTokenBudget int64 `yaml:"monthly_token_budget" json:"monthly_token_budget" gorm:"default:0"`
+	// Caps on knowledge bases, documents and concurrent parse jobs, unset caps use the deployment defaults
+	Quota *TenantQuota `yaml:"quota"               json:"quota"               gorm:"type:jsonb"`
 	// Deprecated: AgentConfig is deprecated, use CustomAgent (builtin-smart-reasoning) config instead.
 	// This field is kept for backward compatibility and will be removed in future versions.
 	AgentConfig *AgentConfig `yaml:"agent_config"        json:"agent_config"        gorm:"type:jsonb"`
@@ -82,6 +86,16 @@ type Tenant struct {
 	ContextConfig *ContextConfig `yaml:"context_config"      json:"context_config"      gorm:"type:jsonb"`
 	// Global WebSearch configuration for this tenant
 	WebSearchConfig *WebSearchConfig `yaml:"web_search_config"   json:"web_search_config"   gorm:"type:jsonb"`
+	// Agent tools disabled for every agent of this tenant
+	AgentToolPolicy *AgentToolPolicy `yaml:"agent_tool_policy"   json:"agent_tool_policy"   gorm:"type:jsonb"`
+	// Content moderation of questions and answers
+	ModerationConfig *ModerationConfig `yaml:"moderation_config"   json:"moderation_config"   gorm:"type:jsonb"`
+	// Self-hosted Ollama and vLLM servers of this tenant
+	LocalProviderConfig *LocalProviderConfig `yaml:"local_provider_config" json:"local_provider_config" gorm:"type:jsonb"`
+	// Client addresses allowed to call the API and the internal service callbacks of this tenant
+	IPPolicy *TenantIPPolicy `yaml:"ip_policy"           json:"ip_policy"           gorm:"type:jsonb"`
+	// Language of API messages when requests ask for none, such as en-US, empty for the deployment default
+	Locale string `yaml:"locale"              json:"locale"              gorm:"type:varchar(16);default:''"`
 	// Deprecated: ConversationConfig is deprecated, use CustomAgent (builtin-quick-answer) config instead.
 	// This field is kept for backward compatibility and will be removed in future versions.
 	ConversationConfig *ConversationConfig `yaml:"conversation_config" json:"conversation_config" gorm:"type:jsonb"`
@@ -151,6 +165,8 @@ type ConversationConfig struct {
 	RerankThreshold      float64 `json:"rerank_threshold"`
 	EnableRewrite        bool    `json:"enable_rewrite"`
 	EnableQueryExpansion bool    `json:"enable_query_expansion"`
+	EnableLLMExpansion   bool    `json:"enable_llm_expansion"`
+	EnableHyDE           bool    `json:"enable_hyde"`
 
 	// Model configuration
 	SummaryModelID string `json:"summary_model_id"`
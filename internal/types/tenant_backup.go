@@ -0,0 +1,176 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// TenantBackupFormatVersion is the layout version of tenant backups written by this build
+const TenantBackupFormatVersion = 1
+
+// TenantBackupManifestPath is the path of the manifest inside a tenant backup.
+//
+// A backup is a zip archive laid out as:
+//
+//	manifest.json                    tenant, schema version, row counts and the checksum of every other entry
+//	tables/<table>.jsonl             database rows of the tenant, one JSON object per line
+//	files/<knowledge_id>/<file_name> original uploaded files
+//	vectors/<knowledge_id>.json      vectors keyed by source ID
+const TenantBackupManifestPath = "manifest.json"
+
+// TenantBackupTablePath returns the backup path of the rows of a table
+func TenantBackupTablePath(table string) string {
+	return path.Join("tables", table+".jsonl")
+}
+
+// TenantBackupFilePath returns the backup path of the original file of a knowledge
+func TenantBackupFilePath(knowledgeID, fileName string) string {
+	return path.Join("files", knowledgeID, path.Base(fileName))
+}
+
+// TenantBackupVectorsPath returns the backup path of the vectors of a knowledge
+func TenantBackupVectorsPath(knowledgeID string) string {
+	return path.Join("vectors", knowledgeID+".json")
+}
+
+// TenantBackupStatus is the state of a tenant backup
+type TenantBackupStatus string
+
+const (
+	// TenantBackupStatusPending backups wait for a worker
+	TenantBackupStatusPending TenantBackupStatus = "pending"
+	// TenantBackupStatusRunning backups are being written
+	TenantBackupStatusRunning TenantBackupStatus = "running"
+	// TenantBackupStatusCompleted backups are stored in object storage
+	TenantBackupStatusCompleted TenantBackupStatus = "completed"
+	// TenantBackupStatusFailed backups stopped with an error
+	TenantBackupStatusFailed TenantBackupStatus = "failed"
+)
+
+// TenantBackup is an archive of the database rows, files and vectors of a tenant kept in object storage
+type TenantBackup struct {
+	ID       string             `json:"id"        gorm:"type:varchar(36);primaryKey"`
+	TenantID uint64             `json:"tenant_id" gorm:"index"`
+	Status   TenantBackupStatus `json:"status"    gorm:"type:varchar(16)"`
+	// FilePath is the path of the archive in object storage
+	FilePath      string `json:"-"              gorm:"type:varchar(512)"`
+	FormatVersion int    `json:"format_version"`
+	// SchemaVersion is the database migration version the rows were exported from
+	SchemaVersion uint  `json:"schema_version"`
+	Size          int64 `json:"size"`
+	// SHA256 is the checksum of the whole archive, passed to the restore command to verify the download
+	SHA256      string     `json:"sha256"         gorm:"column:sha256;type:varchar(64)"`
+	Rows        int64      `json:"rows"`
+	Files       int        `json:"files"`
+	Error       string     `json:"error"          gorm:"type:text"`
+	CreatedBy   string     `json:"created_by"     gorm:"type:varchar(36)"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// TableName returns the table name for GORM
+func (TenantBackup) TableName() string {
+	return "tenant_backups"
+}
+
+// TenantBackupTable is the row count of a table in a backup
+type TenantBackupTable struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+// TenantBackupFile is an original file in a backup
+type TenantBackupFile struct {
+	KnowledgeID string `json:"knowledge_id"`
+	// SourcePath is the storage path of the file on the deployment the backup was taken from
+	SourcePath string `json:"source_path"`
+	Entry      string `json:"entry"`
+}
+
+// TenantBackupEntry is the size and checksum of an entry of a backup
+type TenantBackupEntry struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// TenantBackupManifest describes the content of a tenant backup
+type TenantBackupManifest struct {
+	FormatVersion int       `json:"format_version"`
+	SchemaVersion uint      `json:"schema_version"`
+	TenantID      uint64    `json:"tenant_id"`
+	TenantName    string    `json:"tenant_name"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Tables are listed in restore order
+	Tables []TenantBackupTable `json:"tables"`
+	Files  []TenantBackupFile  `json:"files"`
+	// MissingFiles are knowledge whose original file could not be read when backing up
+	MissingFiles []string `json:"missing_files,omitempty"`
+	// Entries are the size and checksum of every entry besides the manifest, keyed by path
+	Entries map[string]TenantBackupEntry `json:"entries"`
+}
+
+// Verify checks an entry read from the archive matches the size and checksum recorded in the manifest
+func (m *TenantBackupManifest) Verify(name string, r io.Reader) error {
+	expected, ok := m.Entries[name]
+	if !ok {
+		return fmt.Errorf("entry %s is not listed in the manifest", name)
+	}
+	entry, err := NewTenantBackupEntry(r)
+	if err != nil {
+		return fmt.Errorf("read entry %s: %w", name, err)
+	}
+	if entry != expected {
+		return fmt.Errorf("entry %s is corrupted: expected %d bytes with sha256 %s, got %d bytes with sha256 %s",
+			name, expected.Size, expected.SHA256, entry.Size, entry.SHA256)
+	}
+	return nil
+}
+
+// NewTenantBackupEntry computes the size and checksum of an entry
+func NewTenantBackupEntry(r io.Reader) (TenantBackupEntry, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
+	if err != nil {
+		return TenantBackupEntry{}, err
+	}
+	return TenantBackupEntry{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// TenantBackupRequest starts a backup of a tenant
+type TenantBackupRequest struct {
+	TenantID uint64 `json:"tenant_id" binding:"required"`
+}
+
+// TenantBackupPayload is the task payload of a tenant backup
+type TenantBackupPayload struct {
+	TenantID uint64 `json:"tenant_id"`
+	BackupID string `json:"backup_id"`
+}
+
+// TenantRestoreOptions controls a restore from a backup archive
+type TenantRestoreOptions struct {
+	// SHA256 is the expected checksum of the whole archive, checked when set
+	SHA256 string
+	// VerifyOnly checks the archive without restoring it
+	VerifyOnly bool
+}
+
+// TenantRestoreReport is the outcome of a restore
+type TenantRestoreReport struct {
+	TenantID      uint64              `json:"tenant_id"`
+	TenantName    string              `json:"tenant_name"`
+	SchemaVersion uint                `json:"schema_version"`
+	Tables        []TenantBackupTable `json:"tables"`
+	Files         int                 `json:"files"`
+	// Indexed is the number of knowledge whose chunks were indexed again
+	Indexed int `json:"indexed"`
+	// ReusedVectors is the number of vectors restored from the archive instead of embedded again
+	ReusedVectors int `json:"reused_vectors"`
+	// Failures are the knowledge that could not be indexed, they are marked failed to be parsed again
+	Failures []string `json:"failures,omitempty"`
+	Verified bool     `json:"verified"`
+}
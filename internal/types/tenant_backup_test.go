@@ -0,0 +1,37 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTenantBackupManifestVerify(t *testing.T) {
+	entry, err := NewTenantBackupEntry(strings.NewReader("{\"id\":1}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Size != 9 || len(entry.SHA256) != 64 {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	name := TenantBackupTablePath("tenants")
+	if name != "tables/tenants.jsonl" {
+		t.Fatalf("unexpected table path: %s", name)
+	}
+	manifest := &TenantBackupManifest{Entries: map[string]TenantBackupEntry{name: entry}}
+
+	if err := manifest.Verify(name, strings.NewReader("{\"id\":1}\n")); err != nil {
+		t.Errorf("matching entry should verify: %v", err)
+	}
+	if err := manifest.Verify(name, strings.NewReader("{\"id\":2}\n")); err == nil {
+		t.Error("corrupted entry should not verify")
+	}
+	if err := manifest.Verify(TenantBackupTablePath("users"), strings.NewReader("")); err == nil {
+		t.Error("entry missing from the manifest should not verify")
+	}
+}
+
+func TestTenantBackupFilePath(t *testing.T) {
+	if got := TenantBackupFilePath("k1", "10000/k1/../../report.pdf"); got != "files/k1/report.pdf" {
+		t.Errorf("file path should keep only the base name, got %s", got)
+	}
+}
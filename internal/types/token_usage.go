@@ -0,0 +1,116 @@
+package types
+
+import "time"
+
+// TokenUsage is the token count of one LLM call
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	// Estimated is set when the provider reported no usage and the tokens were counted locally
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// ModelPricing is the price of a chat model per million tokens, in the currency the tenant bills in
+type ModelPricing struct {
+	PromptPrice     float64 `yaml:"prompt_price"     json:"prompt_price"`
+	CompletionPrice float64 `yaml:"completion_price" json:"completion_price"`
+}
+
+// Cost returns the cost of a usage at this pricing, zero for a nil pricing
+func (p *ModelPricing) Cost(usage TokenUsage) float64 {
+	if p == nil {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*p.PromptPrice + float64(usage.CompletionTokens)*p.CompletionPrice) / 1e6
+}
+
+// TokenUsageRecord records the tokens used by one LLM call
+type TokenUsageRecord struct {
+	ID       string `json:"id"        gorm:"type:varchar(36);primaryKey"`
+	TenantID uint64 `json:"tenant_id" gorm:"index"`
+	// KnowledgeBaseID is set when the call answered from a single knowledge base
+	KnowledgeBaseID  string    `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	SessionID        string    `json:"session_id"        gorm:"type:varchar(36)"`
+	ModelID          string    `json:"model_id"          gorm:"type:varchar(64)"`
+	ModelName        string    `json:"model_name"        gorm:"type:varchar(255)"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Estimated        bool      `json:"estimated"`
+	Cost             float64   `json:"cost"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// TableName returns the table name of TokenUsageRecord
+func (TokenUsageRecord) TableName() string {
+	return "token_usage_records"
+}
+
+// TokenUsageScope attributes the LLM calls of a request to a session and knowledge base
+type TokenUsageScope struct {
+	SessionID       string
+	KnowledgeBaseID string
+}
+
+// TokenUsageGroupBy is the dimension a usage report aggregates by
+type TokenUsageGroupBy string
+
+const (
+	TokenUsageGroupByModel         TokenUsageGroupBy = "model"
+	TokenUsageGroupByKnowledgeBase TokenUsageGroupBy = "knowledge_base"
+	TokenUsageGroupByDay           TokenUsageGroupBy = "day"
+)
+
+// IsValid reports whether the dimension is supported
+func (g TokenUsageGroupBy) IsValid() bool {
+	switch g {
+	case TokenUsageGroupByModel, TokenUsageGroupByKnowledgeBase, TokenUsageGroupByDay:
+		return true
+	}
+	return false
+}
+
+// TokenUsageStat aggregates the usage records of one group
+type TokenUsageStat struct {
+	// Key is the model ID, knowledge base ID or day (YYYY-MM-DD) of the group, empty for the totals
+	Key               string  `json:"key"`
+	Name              string  `json:"name,omitempty"`
+	Requests          int64   `json:"requests"`
+	PromptTokens      int64   `json:"prompt_tokens"`
+	CompletionTokens  int64   `json:"completion_tokens"`
+	TotalTokens       int64   `json:"total_tokens"`
+	EstimatedRequests int64   `json:"estimated_requests"`
+	Cost              float64 `json:"cost"`
+}
+
+// TokenUsageQuery selects the usage records of a report
+type TokenUsageQuery struct {
+	From    time.Time
+	To      time.Time
+	GroupBy TokenUsageGroupBy
+}
+
+// TokenBudgetStatus is the tenant's token usage of the current month against its budget
+type TokenBudgetStatus struct {
+	// MonthlyBudget is zero when the tenant has no budget
+	MonthlyBudget int64     `json:"monthly_budget"`
+	Used          int64     `json:"used"`
+	PeriodStart   time.Time `json:"period_start"`
+	Exceeded      bool      `json:"exceeded"`
+}
+
+// TokenUsageReport is the usage of a tenant over a period
+type TokenUsageReport struct {
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	GroupBy TokenUsageGroupBy `json:"group_by"`
+	Totals  *TokenUsageStat   `json:"totals"`
+	Items   []*TokenUsageStat `json:"items"`
+	Budget  TokenBudgetStatus `json:"budget"`
+}
+
+// MonthStart returns the first instant of the month of t, in t's location
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
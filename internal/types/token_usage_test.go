@@ -0,0 +1,27 @@
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestModelPricingCost(t *testing.T) {
+	usage := TokenUsage{PromptTokens: 2000, CompletionTokens: 500, TotalTokens: 2500}
+	pricing := &ModelPricing{PromptPrice: 0.8, CompletionPrice: 2}
+	if got, want := pricing.Cost(usage), 0.0026; math.Abs(got-want) > 1e-12 {
+		t.Errorf("got cost %v, want %v", got, want)
+	}
+	var unpriced *ModelPricing
+	if got := unpriced.Cost(usage); got != 0 {
+		t.Errorf("got cost %v for a model without pricing, want 0", got)
+	}
+}
+
+func TestMonthStart(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*3600)
+	got := MonthStart(time.Date(2025, 3, 31, 23, 59, 0, 0, location))
+	if want := time.Date(2025, 3, 1, 0, 0, 0, 0, location); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package types
+
+// MaxAnalyzeURLBatch is the number of URLs a batch analysis accepts
+const MaxAnalyzeURLBatch = 50
+
+// URLKind is how the content served at a URL is imported
+type URLKind string
+
+const (
+	// URLKindPage is a web page, imported with POST /knowledge-bases/:id/knowledge/url
+	URLKindPage URLKind = "page"
+	// URLKindFile is a document file, imported with POST /knowledge-bases/:id/knowledge/fetch
+	URLKindFile URLKind = "file"
+	// URLKindUnsupported is content of a file type that cannot be imported
+	URLKindUnsupported URLKind = "unsupported"
+)
+
+// AnalyzeURLsRequest analyzes a list of URLs before importing them
+type AnalyzeURLsRequest struct {
+	URLs []string `json:"urls" binding:"required"`
+}
+
+// AnalyzeURLResult is what a URL serves and how it can be imported, Error is set when it could not be fetched
+type AnalyzeURLResult struct {
+	URL string `json:"url"`
+	// FinalURL is the URL after redirects
+	FinalURL string `json:"final_url,omitempty"`
+	// Reachable is true when the URL answered with a 2xx status
+	Reachable  bool    `json:"reachable"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Kind       URLKind `json:"kind,omitempty"`
+	// ContentType is the media type the URL is served with
+	ContentType string `json:"content_type,omitempty"`
+	// ContentLength is the size announced by the server, -1 when unknown
+	ContentLength int64 `json:"content_length,omitempty"`
+	// Title is the title of a web page
+	Title string `json:"title,omitempty"`
+	// FileName and FileType name a document file as it would be imported
+	FileName string `json:"file_name,omitempty"`
+	FileType string `json:"file_type,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
@@ -24,6 +24,10 @@ type User struct {
 	IsActive bool `json:"is_active"  gorm:"default:true"`
 	// Whether the user can access all tenants (cross-tenant access)
 	CanAccessAllTenants bool `json:"can_access_all_tenants" gorm:"default:false"`
+	// Identity provider the user signs in with, empty for local password accounts
+	AuthProvider string `json:"auth_provider,omitempty" gorm:"type:varchar(100)"`
+	// Subject of the user at the identity provider
+	ExternalID string `json:"-"          gorm:"type:varchar(255)"`
 	// Creation time of the user
 	CreatedAt time.Time `json:"created_at"`
 	// Last updated time of the user
@@ -49,6 +53,10 @@ type AuthToken struct {
 	ExpiresAt time.Time `json:"expires_at"`
 	// Whether the token is revoked
 	IsRevoked bool `json:"is_revoked" gorm:"default:false"`
+	// Login session the token was issued to, kept across refreshes
+	SessionID string `json:"session_id" gorm:"type:varchar(36);index"`
+	// Last time the token authenticated a request, recorded at most once a minute
+	LastUsedAt *time.Time `json:"last_used_at"`
 	// Creation time of the token
 	CreatedAt time.Time `json:"created_at"`
 	// Last updated time of the token
@@ -64,6 +72,12 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+// LDAPLoginRequest represents a login request against the LDAP directory
+type LDAPLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
 // RegisterRequest represents a registration request
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
@@ -89,6 +103,14 @@ type RegisterResponse struct {
 	Tenant  *Tenant `json:"tenant,omitempty"`
 }
 
+// OIDCProviderInfo describes a single sign-on provider offered on the login page
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	// LoginURL starts the login at the provider
+	LoginURL string `json:"login_url"`
+}
+
 // UserInfo represents user information for API responses
 type UserInfo struct {
 	ID                  string    `json:"id"`
@@ -98,6 +120,7 @@ type UserInfo struct {
 	TenantID            uint64    `json:"tenant_id"`
 	IsActive            bool      `json:"is_active"`
 	CanAccessAllTenants bool      `json:"can_access_all_tenants"`
+	AuthProvider        string    `json:"auth_provider,omitempty"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
 }
@@ -112,6 +135,7 @@ func (u *User) ToUserInfo() *UserInfo {
 		TenantID:            u.TenantID,
 		IsActive:            u.IsActive,
 		CanAccessAllTenants: u.CanAccessAllTenants,
+		AuthProvider:        u.AuthProvider,
 		CreatedAt:           u.CreatedAt,
 		UpdatedAt:           u.UpdatedAt,
 	}
@@ -0,0 +1,212 @@
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent is the type of an event delivered to webhooks
+type WebhookEvent string
+
+const (
+	// WebhookEventKnowledgeCreated is sent when a document, URL or manual knowledge is added
+	WebhookEventKnowledgeCreated WebhookEvent = "knowledge.created"
+	// WebhookEventParseCompleted is sent when a knowledge is parsed and indexed
+	WebhookEventParseCompleted WebhookEvent = "parse.completed"
+	// WebhookEventParseFailed is sent when parsing a knowledge fails, on every attempt
+	WebhookEventParseFailed WebhookEvent = "parse.failed"
+	// WebhookEventCrawlCompleted is sent when a web page added by URL is fetched and indexed
+	WebhookEventCrawlCompleted WebhookEvent = "crawl.completed"
+	// WebhookEventKnowledgeExpiring is sent once when a knowledge nears the expiration of its retention policy
+	WebhookEventKnowledgeExpiring WebhookEvent = "knowledge.expiring"
+	// WebhookEventKnowledgeExpired is sent when an expired knowledge is archived or deleted
+	WebhookEventKnowledgeExpired WebhookEvent = "knowledge.expired"
+	// WebhookEventChatFeedback is sent when a user rates an answer
+	WebhookEventChatFeedback WebhookEvent = "chat.feedback"
+	// WebhookEventPing is sent when testing a webhook, webhooks receive it without subscribing
+	WebhookEventPing WebhookEvent = "ping"
+)
+
+// WebhookEvents are the events webhooks can subscribe to
+var WebhookEvents = []WebhookEvent{
+	WebhookEventKnowledgeCreated,
+	WebhookEventParseCompleted,
+	WebhookEventParseFailed,
+	WebhookEventCrawlCompleted,
+	WebhookEventKnowledgeExpiring,
+	WebhookEventKnowledgeExpired,
+	WebhookEventChatFeedback,
+}
+
+// IsValid checks if webhooks can subscribe to the event
+func (e WebhookEvent) IsValid() bool {
+	return slices.Contains(WebhookEvents, e)
+}
+
+// Webhook is an endpoint of a tenant receiving events as signed HTTP POST requests
+type Webhook struct {
+	ID       string `json:"id"        gorm:"type:varchar(36);primaryKey"`
+	TenantID uint64 `json:"tenant_id" gorm:"index"`
+	Name     string `json:"name"      gorm:"type:varchar(255);not null"`
+	URL      string `json:"url"       gorm:"type:varchar(2048);not null"`
+	// Secret signs the payloads, it is returned once when the webhook is created or its secret rotated
+	Secret string `json:"-" gorm:"type:varchar(128)"`
+	// Events are the events the webhook subscribes to
+	Events    StringArray    `json:"events"     gorm:"type:jsonb"`
+	Enabled   bool           `json:"enabled"`
+	CreatedBy string         `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-"          gorm:"index"`
+}
+
+// TableName returns the table name for GORM
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Subscribes checks if the webhook receives an event
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	return w.Enabled && (event == WebhookEventPing || slices.Contains(w.Events, string(event)))
+}
+
+// WebhookWithSecret is returned when a webhook is created or its secret rotated, the only time the secret is visible
+type WebhookWithSecret struct {
+	*Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookRequest registers a webhook
+type CreateWebhookRequest struct {
+	Name   string   `json:"name"   binding:"required,max=255"`
+	URL    string   `json:"url"    binding:"required,max=2048"`
+	Events []string `json:"events" binding:"required,min=1"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled"`
+}
+
+// UpdateWebhookRequest updates the name, URL, events or state of a webhook
+type UpdateWebhookRequest struct {
+	Name    *string   `json:"name"`
+	URL     *string   `json:"url"`
+	Events  *[]string `json:"events"`
+	Enabled *bool     `json:"enabled"`
+}
+
+// WebhookDeliveryStatus is the state of the delivery of an event to a webhook
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending deliveries wait for their first attempt
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryRetrying deliveries failed and are scheduled to be sent again
+	WebhookDeliveryRetrying WebhookDeliveryStatus = "retrying"
+	// WebhookDeliverySucceeded deliveries were answered with a 2xx status
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed deliveries failed every attempt
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is the delivery of an event to a webhook, with the outcome of its last attempt
+type WebhookDelivery struct {
+	ID        string       `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	WebhookID string       `json:"webhook_id" gorm:"type:varchar(36);index"`
+	TenantID  uint64       `json:"tenant_id"  gorm:"index"`
+	Event     WebhookEvent `json:"event"      gorm:"type:varchar(64)"`
+	// Payload is the JSON body sent
+	Payload JSON                  `json:"payload" gorm:"type:jsonb"`
+	Status  WebhookDeliveryStatus `json:"status"  gorm:"type:varchar(16)"`
+	// Attempts is how many times the event has been sent
+	Attempts int `json:"attempts"`
+	// ResponseStatus is the HTTP status of the last attempt, 0 when no response was received
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error"   gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for GORM
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryPayload is the task payload of a webhook delivery
+type WebhookDeliveryPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// WebhookEventBody is the JSON body posted to webhooks
+type WebhookEventBody struct {
+	// ID is the ID of the delivery, the same across retries so receivers can deduplicate
+	ID        string       `json:"id"`
+	Event     WebhookEvent `json:"event"`
+	TenantID  uint64       `json:"tenant_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	Data      any          `json:"data"`
+}
+
+// WebhookKnowledgeData is the data of knowledge and parsing events
+type WebhookKnowledgeData struct {
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	Type            string `json:"type"`
+	Title           string `json:"title"`
+	Source          string `json:"source,omitempty"`
+	FileName        string `json:"file_name,omitempty"`
+	ParseStatus     string `json:"parse_status"`
+	ErrorMessage    string `json:"error_message,omitempty"`
+}
+
+// NewWebhookKnowledgeData returns the event data of a knowledge
+func NewWebhookKnowledgeData(knowledge *Knowledge) *WebhookKnowledgeData {
+	return &WebhookKnowledgeData{
+		KnowledgeID:     knowledge.ID,
+		KnowledgeBaseID: knowledge.KnowledgeBaseID,
+		Type:            knowledge.Type,
+		Title:           knowledge.Title,
+		Source:          knowledge.Source,
+		FileName:        knowledge.FileName,
+		ParseStatus:     knowledge.ParseStatus,
+		ErrorMessage:    knowledge.ErrorMessage,
+	}
+}
+
+// WebhookFeedbackData is the data of chat feedback events
+type WebhookFeedbackData struct {
+	SessionID string               `json:"session_id"`
+	MessageID string               `json:"message_id"`
+	UserID    string               `json:"user_id,omitempty"`
+	Rating    AnswerFeedbackRating `json:"rating"`
+	Comment   string               `json:"comment,omitempty"`
+	Query     string               `json:"query"`
+	// KnowledgeBaseIDs are the knowledge bases the rated answer retrieved from
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`
+}
+
+const (
+	// WebhookSignatureHeader carries the timestamp and signature of a webhook payload
+	WebhookSignatureHeader = "X-WeKnora-Signature"
+	// WebhookEventHeader carries the event of a webhook payload
+	WebhookEventHeader = "X-WeKnora-Event"
+	// WebhookDeliveryHeader carries the delivery ID of a webhook payload
+	WebhookDeliveryHeader = "X-WeKnora-Delivery"
+)
+
+// SignWebhookPayload returns the signature header of a payload sent at timestamp, in the form t=<unix>,v1=<hex>.
+// v1 is the HMAC-SHA256 of "<unix>.<body>" keyed by the webhook secret.
+func SignWebhookPayload(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"event":"parse.completed"}`)
+	ts := time.Unix(1700000000, 0)
+
+	signature := SignWebhookPayload("whsec_test", ts, body)
+	if !strings.HasPrefix(signature, "t=1700000000,v1=") {
+		t.Fatalf("unexpected signature header: %s", signature)
+	}
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	if expected := hex.EncodeToString(mac.Sum(nil)); !strings.HasSuffix(signature, "v1="+expected) {
+		t.Errorf("signature should be the HMAC of the timestamp and body, got %s", signature)
+	}
+	if SignWebhookPayload("whsec_other", ts, body) == signature {
+		t.Error("signatures with different secrets should differ")
+	}
+	if SignWebhookPayload("whsec_test", ts.Add(time.Second), body) == signature {
+		t.Error("signatures at different times should differ")
+	}
+}
+
+func TestWebhookSubscribes(t *testing.T) {
+	webhook := &Webhook{Enabled: true, Events: StringArray{string(WebhookEventParseFailed)}}
+	if !webhook.Subscribes(WebhookEventParseFailed) {
+		t.Error("webhook should receive the events it subscribes to")
+	}
+	if webhook.Subscribes(WebhookEventParseCompleted) {
+		t.Error("webhook should not receive other events")
+	}
+	if !webhook.Subscribes(WebhookEventPing) {
+		t.Error("webhook should receive pings without subscribing")
+	}
+	webhook.Enabled = false
+	if webhook.Subscribes(WebhookEventParseFailed) {
+		t.Error("disabled webhook should not receive events")
+	}
+	if WebhookEventPing.IsValid() || !WebhookEventChatFeedback.IsValid() {
+		t.Error("only lifecycle events can be subscribed to")
+	}
+}
@@ -3,21 +3,29 @@ package utils
 import (
 	"os"
 	"strconv"
+	"sync/atomic"
 )
 
+// maxFileSizeMB is the size limit set from the config file, it takes precedence over MAX_FILE_SIZE_MB
+var maxFileSizeMB atomic.Int64
+
+// SetMaxFileSizeMB sets the maximum file upload size, 0 falls back to MAX_FILE_SIZE_MB.
+// It is called when the config is loaded and reloaded.
+func SetMaxFileSizeMB(size int64) {
+	maxFileSizeMB.Store(max(size, 0))
+}
+
 // GetMaxFileSize returns the maximum file upload size in bytes.
-// Default is 50MB, can be configured via MAX_FILE_SIZE_MB environment variable.
+// Default is 50MB, can be configured via knowledge_base.max_file_size_mb or MAX_FILE_SIZE_MB environment variable.
 func GetMaxFileSize() int64 {
-	if sizeStr := os.Getenv("MAX_FILE_SIZE_MB"); sizeStr != "" {
-		if size, err := strconv.ParseInt(sizeStr, 10, 64); err == nil && size > 0 {
-			return size * 1024 * 1024
-		}
-	}
-	return 50 * 1024 * 1024 // default 50MB
+	return GetMaxFileSizeMB() * 1024 * 1024
 }
 
 // GetMaxFileSizeMB returns the maximum file upload size in MB.
 func GetMaxFileSizeMB() int64 {
+	if size := maxFileSizeMB.Load(); size > 0 {
+		return size
+	}
 	if sizeStr := os.Getenv("MAX_FILE_SIZE_MB"); sizeStr != "" {
 		if size, err := strconv.ParseInt(sizeStr, 10, 64); err == nil && size > 0 {
 			return size
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"os"
+	"time"
+)
+
+const defaultKnowledgeLinkCheckInterval = 7 * 24 * time.Hour
+
+// GetKnowledgeLinkCheckInterval returns how often the source URL of a knowledge is checked, 0 disables the checks.
+// Default is 7 days, can be configured via KNOWLEDGE_LINK_CHECK_INTERVAL (Go duration string).
+func GetKnowledgeLinkCheckInterval() time.Duration {
+	if v := os.Getenv("KNOWLEDGE_LINK_CHECK_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return defaultKnowledgeLinkCheckInterval
+}
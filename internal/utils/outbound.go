@@ -0,0 +1,286 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// OutboundHTTPSettings configures the connections of the clients calling services outside the server: models,
+// web search, web fetch, webhooks, OIDC and MCP servers
+type OutboundHTTPSettings struct {
+	// DialTimeout bounds connecting to a host, 30 seconds when unset
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake, 10 seconds when unset
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds the wait for the response headers once a request is sent, no limit when unset
+	ResponseHeaderTimeout time.Duration
+	// IdleConnTimeout closes connections idle for longer, 90 seconds when unset
+	IdleConnTimeout time.Duration
+	// ProxyURL is the proxy of all outbound requests, HTTP_PROXY and HTTPS_PROXY are used when unset
+	ProxyURL string
+	// NoProxy lists the hosts reached without the proxy in the NO_PROXY format, NO_PROXY is used when unset
+	NoProxy string
+	// ProxySSRFSafeClients sends the requests of SSRF-safe clients, such as web fetch and webhooks, through the
+	// proxy too. The proxy resolves their targets, so the targets are checked before each request is sent; off,
+	// they connect directly and each resolved address is checked.
+	ProxySSRFSafeClients bool
+	// CAFile is a PEM bundle of certificate authorities trusted besides the system ones
+	CAFile string
+	// InsecureSkipVerifyHosts are the hosts whose certificates are not verified. An entry starting with a dot
+	// matches the subdomains of the domain.
+	InsecureSkipVerifyHosts []string
+}
+
+// outboundTransportKind tells apart the transports the clients need from the same settings
+type outboundTransportKind struct {
+	ssrfSafe           bool
+	disableKeepAlives  bool
+	disableCompression bool
+	// skipVerify does not verify certificates, for the hosts listed in InsecureSkipVerifyHosts
+	skipVerify bool
+}
+
+// outboundState holds the settings in use and the transports built from them
+type outboundState struct {
+	settings  OutboundHTTPSettings
+	proxy     func(*url.URL) (*url.URL, error)
+	proxyAddr map[string]bool
+	tlsConfig *tls.Config
+	skipHosts []string
+
+	mu         sync.Mutex
+	transports map[outboundTransportKind]*http.Transport
+}
+
+var outbound atomic.Pointer[outboundState]
+
+func init() {
+	state, _ := newOutboundState(OutboundHTTPSettings{})
+	outbound.Store(state)
+}
+
+// CheckOutboundHTTPSettings reports whether the settings can be applied, the proxy URL parses and the CA file
+// holds certificates
+func CheckOutboundHTTPSettings(settings OutboundHTTPSettings) error {
+	_, err := newOutboundState(settings)
+	return err
+}
+
+// SetOutboundHTTPSettings applies the settings to the outbound clients. Requests already sent keep their
+// connections, the next ones use the new settings. It is called when the config is loaded and reloaded.
+func SetOutboundHTTPSettings(settings OutboundHTTPSettings) error {
+	state, err := newOutboundState(settings)
+	if err != nil {
+		return err
+	}
+	previous := outbound.Swap(state)
+	if previous != nil {
+		previous.closeIdleConnections()
+	}
+	return nil
+}
+
+// OutboundTransport returns the transport of the clients calling services outside the server
+func OutboundTransport() http.RoundTripper {
+	return &outboundRoundTripper{}
+}
+
+// outboundRoundTripper sends each request through the transport built from the current settings, so clients
+// created once pick up reloaded settings
+type outboundRoundTripper struct {
+	kind outboundTransportKind
+}
+
+// RoundTrip sends the request
+func (t *outboundRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	state := outbound.Load()
+	kind := t.kind
+	kind.skipVerify = matchHost(state.skipHosts, strings.ToLower(req.URL.Hostname()))
+	return state.transport(kind).RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transports
+func (t *outboundRoundTripper) CloseIdleConnections() {
+	outbound.Load().closeIdleConnections()
+}
+
+func newOutboundState(settings OutboundHTTPSettings) (*outboundState, error) {
+	proxyConfig := httpproxy.FromEnvironment()
+	if settings.ProxyURL != "" {
+		proxyURL, err := url.Parse(settings.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid outbound proxy URL %q", settings.ProxyURL)
+		}
+		proxyConfig.HTTPProxy, proxyConfig.HTTPSProxy = settings.ProxyURL, settings.ProxyURL
+	}
+	if settings.NoProxy != "" {
+		proxyConfig.NoProxy = settings.NoProxy
+	}
+
+	tlsConfig, err := outboundTLSConfig(settings.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	skipHosts := make([]string, 0, len(settings.InsecureSkipVerifyHosts))
+	for _, host := range settings.InsecureSkipVerifyHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			skipHosts = append(skipHosts, host)
+		}
+	}
+	return &outboundState{
+		settings:   settings,
+		proxy:      proxyConfig.ProxyFunc(),
+		proxyAddr:  proxyAddresses(proxyConfig.HTTPProxy, proxyConfig.HTTPSProxy),
+		tlsConfig:  tlsConfig,
+		skipHosts:  skipHosts,
+		transports: make(map[outboundTransportKind]*http.Transport),
+	}, nil
+}
+
+// proxyAddresses returns the host:port of the proxies, which proxied SSRF-safe clients may dial although they are
+// usually on a private network
+func proxyAddresses(proxies ...string) map[string]bool {
+	addrs := make(map[string]bool)
+	for _, proxy := range proxies {
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "://") {
+			proxy = "http://" + proxy
+		}
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			continue
+		}
+		port := proxyURL.Port()
+		if port == "" {
+			switch proxyURL.Scheme {
+			case "https":
+				port = "443"
+			case "socks5", "socks5h":
+				port = "1080"
+			default:
+				port = "80"
+			}
+		}
+		addrs[net.JoinHostPort(proxyURL.Hostname(), port)] = true
+	}
+	return addrs
+}
+
+// outboundTLSConfig trusts the CA bundle besides the system authorities, it is nil when there is none
+func outboundTLSConfig(caFile string) (*tls.Config, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read outbound CA file: %w", err)
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in outbound CA file %s", caFile)
+	}
+	return &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}, nil
+}
+
+// matchHost reports whether host is one of the entries, an entry starting with a dot matching subdomains
+func matchHost(entries []string, host string) bool {
+	for _, entry := range entries {
+		if host == entry || (strings.HasPrefix(entry, ".") && strings.HasSuffix(host, entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// transport returns the transport of a kind, built on first use
+func (s *outboundState) transport(kind outboundTransportKind) *http.Transport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := s.transports[kind]; t != nil {
+		return t
+	}
+
+	dialer := &net.Dialer{Timeout: durationOr(s.settings.DialTimeout, 30*time.Second), KeepAlive: 30 * time.Second}
+	dial := dialer.DialContext
+	proxy := func(req *http.Request) (*url.URL, error) {
+		return s.proxy(req.URL)
+	}
+	switch {
+	case kind.ssrfSafe && s.settings.ProxySSRFSafeClients:
+		proxy = func(req *http.Request) (*url.URL, error) {
+			proxyURL, err := s.proxy(req.URL)
+			if err != nil || proxyURL == nil {
+				return proxyURL, err
+			}
+			// The proxy resolves the target, so it is checked here instead of when dialing
+			if err := checkSSRFTarget(req.Context(), req.URL.Hostname()); err != nil {
+				return nil, err
+			}
+			return proxyURL, nil
+		}
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if s.proxyAddr[addr] {
+				return dialer.DialContext(ctx, network, addr)
+			}
+			return ssrfSafeDialContext(ctx, dialer, network, addr)
+		}
+	case kind.ssrfSafe:
+		// Without the opt-in SSRF-safe clients connect directly, so every resolved address is checked
+		proxy = nil
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return ssrfSafeDialContext(ctx, dialer, network, addr)
+		}
+	}
+	tlsConfig := s.tlsConfig
+	if kind.skipVerify {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: true}
+	}
+	t := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       durationOr(s.settings.IdleConnTimeout, 90*time.Second),
+		TLSHandshakeTimeout:   durationOr(s.settings.TLSHandshakeTimeout, 10*time.Second),
+		ResponseHeaderTimeout: s.settings.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		DisableKeepAlives:     kind.disableKeepAlives,
+		DisableCompression:    kind.disableCompression,
+	}
+	s.transports[kind] = t
+	return t
+}
+
+// closeIdleConnections closes the idle connections of the transports, once they are replaced
+func (s *outboundState) closeIdleConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.transports {
+		t.CloseIdleConnections()
+	}
+}
+
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
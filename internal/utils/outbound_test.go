@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutboundTransportTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	defer SetOutboundHTTPSettings(OutboundHTTPSettings{})
+
+	client := &http.Client{Transport: OutboundTransport()}
+	get := func() error {
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(); err == nil {
+		t.Fatal("self-signed certificate accepted without settings")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetOutboundHTTPSettings(OutboundHTTPSettings{CAFile: caFile}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err != nil {
+		t.Errorf("certificate signed by the CA file rejected: %v", err)
+	}
+
+	if err := SetOutboundHTTPSettings(OutboundHTTPSettings{InsecureSkipVerifyHosts: []string{"other.example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err == nil {
+		t.Error("certificate accepted for a host that is not skipped")
+	}
+	if err := SetOutboundHTTPSettings(OutboundHTTPSettings{InsecureSkipVerifyHosts: []string{"127.0.0.1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err != nil {
+		t.Errorf("certificate of a skipped host rejected: %v", err)
+	}
+}
+
+func TestCheckOutboundHTTPSettings(t *testing.T) {
+	emptyCA := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(emptyCA, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, settings := range map[string]OutboundHTTPSettings{
+		"missing CA file":              {CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"CA file without certificates": {CAFile: emptyCA},
+		"proxy URL without host":       {ProxyURL: "proxy:3128"},
+	} {
+		if err := CheckOutboundHTTPSettings(settings); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if err := CheckOutboundHTTPSettings(OutboundHTTPSettings{ProxyURL: "http://proxy.corp:3128"}); err != nil {
+		t.Errorf("valid proxy URL rejected: %v", err)
+	}
+}
+
+func TestProxyAddresses(t *testing.T) {
+	addrs := proxyAddresses("http://proxy.corp:3128", "https://secure.corp", "10.0.0.1", "")
+	for _, want := range []string{"proxy.corp:3128", "secure.corp:443", "10.0.0.1:80"} {
+		if !addrs[want] {
+			t.Errorf("proxyAddresses missing %s, got %v", want, addrs)
+		}
+	}
+}
+
+func TestSSRFSafeTransportProxy(t *testing.T) {
+	defer SetOutboundHTTPSettings(OutboundHTTPSettings{})
+	req := httptest.NewRequest(http.MethodGet, "http://169.254.169.254/", nil)
+	publicReq := httptest.NewRequest(http.MethodGet, "http://93.184.216.34/", nil)
+
+	if err := SetOutboundHTTPSettings(OutboundHTTPSettings{ProxyURL: "http://proxy.corp:3128"}); err != nil {
+		t.Fatal(err)
+	}
+	state := outbound.Load()
+	if proxy := state.transport(outboundTransportKind{}).Proxy; proxy == nil {
+		t.Fatal("outbound transport does not use the proxy")
+	} else if proxyURL, err := proxy(publicReq); err != nil || proxyURL == nil {
+		t.Errorf("outbound request not proxied: %v, %v", proxyURL, err)
+	}
+	if state.transport(outboundTransportKind{ssrfSafe: true}).Proxy != nil {
+		t.Error("SSRF-safe transport uses the proxy without the opt-in")
+	}
+
+	if err := SetOutboundHTTPSettings(OutboundHTTPSettings{
+		ProxyURL: "http://proxy.corp:3128", ProxySSRFSafeClients: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	proxy := outbound.Load().transport(outboundTransportKind{ssrfSafe: true}).Proxy
+	if proxy == nil {
+		t.Fatal("SSRF-safe transport does not use the proxy with the opt-in")
+	}
+	if proxyURL, err := proxy(publicReq); err != nil || proxyURL == nil {
+		t.Errorf("public target not proxied: %v, %v", proxyURL, err)
+	}
+	if _, err := proxy(req); err == nil {
+		t.Error("proxied request to a restricted address allowed")
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultDocumentParseMaxRetry  = 5
+	defaultDocumentParseBaseDelay = 30 * time.Second
+	defaultDocumentParseMaxDelay  = 30 * time.Minute
+)
+
+// GetDocumentParseMaxRetry returns how many times a failed document parse is retried.
+// Default is 5, can be configured via DOCUMENT_PARSE_MAX_RETRY environment variable.
+func GetDocumentParseMaxRetry() int {
+	if v := os.Getenv("DOCUMENT_PARSE_MAX_RETRY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultDocumentParseMaxRetry
+}
+
+// DocumentParseRetryDelay returns the exponential backoff delay before the n-th retry (starting at 0).
+// The base delay (default 30s) and the cap (default 30m) can be configured via
+// DOCUMENT_PARSE_RETRY_BASE_DELAY and DOCUMENT_PARSE_RETRY_MAX_DELAY (Go duration strings).
+func DocumentParseRetryDelay(n int) time.Duration {
+	base := durationFromEnv("DOCUMENT_PARSE_RETRY_BASE_DELAY", defaultDocumentParseBaseDelay)
+	maxDelay := durationFromEnv("DOCUMENT_PARSE_RETRY_MAX_DELAY", defaultDocumentParseMaxDelay)
+	return ExponentialBackoff(n, base, maxDelay)
+}
+
+// ExponentialBackoff returns base * 2^n, capped at maxDelay.
+func ExponentialBackoff(n int, base, maxDelay time.Duration) time.Duration {
+	if n < 0 {
+		n = 0
+	}
+	delay := base
+	for i := 0; i < n; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return min(delay, maxDelay)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
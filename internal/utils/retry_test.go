@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want time.Duration
+	}{
+		{name: "first retry uses base delay", n: 0, want: 30 * time.Second},
+		{name: "negative attempt uses base delay", n: -1, want: 30 * time.Second},
+		{name: "doubles each retry", n: 3, want: 240 * time.Second},
+		{name: "capped at max delay", n: 10, want: 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExponentialBackoff(tt.n, 30*time.Second, 30*time.Minute); got != tt.want {
+				t.Errorf("ExponentialBackoff(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
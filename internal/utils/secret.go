@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// encryptedSecretPrefix marks a value encrypted by EncryptSecret, so plaintext stored before encryption still reads
+const encryptedSecretPrefix = "enc:v1:"
+
+// secretKey returns the AES key secrets are encrypted with, the one tenant API keys are encrypted with
+var secretKey = func() []byte {
+	return []byte(os.Getenv("TENANT_AES_KEY"))
+}
+
+// newSecretCipher returns the AES-GCM cipher of the secret key
+func newSecretCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secretKey())
+	if err != nil {
+		return nil, fmt.Errorf("invalid TENANT_AES_KEY: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// CanEncryptSecrets reports whether TENANT_AES_KEY is a valid AES key, secrets cannot be stored otherwise
+func CanEncryptSecrets() bool {
+	_, err := newSecretCipher()
+	return err == nil
+}
+
+// IsEncryptedSecret reports whether a value was encrypted by EncryptSecret
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, encryptedSecretPrefix)
+}
+
+// EncryptSecret encrypts a credential to store it, with AES-GCM under TENANT_AES_KEY. Encrypted values are left as
+// they are.
+func EncryptSecret(plaintext string) (string, error) {
+	if plaintext == "" || IsEncryptedSecret(plaintext) {
+		return plaintext, nil
+	}
+	aead, err := newSecretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret decrypts a value encrypted by EncryptSecret, other values are returned as they are
+func DecryptSecret(value string) (string, error) {
+	if !IsEncryptedSecret(value) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedSecretPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret: %w", err)
+	}
+	aead, err := newSecretCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted secret: too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 )
@@ -156,6 +157,36 @@ var restrictedHostSuffixes = []string{
 	".pod.cluster.local",
 }
 
+// ssrfAllowedHosts are the hosts the operator allows despite the SSRF checks, set from the config file
+var ssrfAllowedHosts atomic.Pointer[[]string]
+
+// SetSSRFAllowedHosts sets the hosts that may be reached although they resolve to private addresses.
+// An entry starting with a dot matches the subdomains of the domain. It is called when the config is
+// loaded and reloaded.
+func SetSSRFAllowedHosts(hosts []string) {
+	normalized := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			normalized = append(normalized, host)
+		}
+	}
+	ssrfAllowedHosts.Store(&normalized)
+}
+
+// isSSRFAllowedHost reports whether the lowercased host is allowed by the operator
+func isSSRFAllowedHost(host string) bool {
+	allowed := ssrfAllowedHosts.Load()
+	if allowed == nil {
+		return false
+	}
+	for _, entry := range *allowed {
+		if host == entry || (strings.HasPrefix(entry, ".") && strings.HasSuffix(host, entry)) {
+			return true
+		}
+	}
+	return false
+}
+
 // restrictedIPv4Ranges contains CIDR ranges that should be blocked
 // These are additional ranges not covered by Go's IsPrivate(), IsLoopback(), etc.
 var restrictedIPv4Ranges = []*net.IPNet{
@@ -331,6 +362,43 @@ func IsSSRFSafeURL(rawURL string) (bool, string) {
 	}
 	hostnameLower := strings.ToLower(hostname)
 
+	// Hosts allowed by the operator skip the address checks, the port checks still apply
+	if !isSSRFAllowedHost(hostnameLower) {
+		if safe, reason := checkSSRFHost(hostname, hostnameLower); !safe {
+			return false, reason
+		}
+	}
+
+	// Check for suspicious port numbers
+	port := parsed.Port()
+	if port != "" {
+		// Block common internal service ports
+		blockedPorts := map[string]bool{
+			"22":    true, // SSH
+			"23":    true, // Telnet
+			"25":    true, // SMTP
+			"445":   true, // SMB
+			"3389":  true, // RDP
+			"5432":  true, // PostgreSQL
+			"3306":  true, // MySQL
+			"6379":  true, // Redis
+			"27017": true, // MongoDB
+			"9200":  true, // Elasticsearch
+			"2379":  true, // etcd
+			"2380":  true, // etcd
+			"8500":  true, // Consul
+			"4001":  true, // etcd (old)
+		}
+		if blockedPorts[port] {
+			return false, fmt.Sprintf("port %s is blocked for security reasons", port)
+		}
+	}
+
+	return true, ""
+}
+
+// checkSSRFHost checks the hostname of a URL is not restricted and does not resolve to a restricted address
+func checkSSRFHost(hostname, hostnameLower string) (bool, string) {
 	// Check against restricted hostnames
 	for _, restricted := range restrictedHostnames {
 		if hostnameLower == restricted {
@@ -377,31 +445,6 @@ func IsSSRFSafeURL(rawURL string) (bool, string) {
 		}
 	}
 
-	// Check for suspicious port numbers
-	port := parsed.Port()
-	if port != "" {
-		// Block common internal service ports
-		blockedPorts := map[string]bool{
-			"22":    true, // SSH
-			"23":    true, // Telnet
-			"25":    true, // SMTP
-			"445":   true, // SMB
-			"3389":  true, // RDP
-			"5432":  true, // PostgreSQL
-			"3306":  true, // MySQL
-			"6379":  true, // Redis
-			"27017": true, // MongoDB
-			"9200":  true, // Elasticsearch
-			"2379":  true, // etcd
-			"2380":  true, // etcd
-			"8500":  true, // Consul
-			"4001":  true, // etcd (old)
-		}
-		if blockedPorts[port] {
-			return false, fmt.Sprintf("port %s is blocked for security reasons", port)
-		}
-	}
-
 	return true, ""
 }
 
@@ -680,13 +723,15 @@ var ErrSSRFRedirectBlocked = fmt.Errorf("redirect blocked: target URL failed SSR
 
 // NewSSRFSafeHTTPClient creates an HTTP client that validates redirect targets against SSRF protections.
 // This prevents SSRF attacks via HTTP redirects where an attacker's server redirects to internal services.
+// It uses the outbound TLS settings like the other outbound clients, and the outbound proxy only when
+// proxy_ssrf_safe_clients is set.
 func NewSSRFSafeHTTPClient(config SSRFSafeHTTPClientConfig) *http.Client {
-	transport := &http.Transport{
-		DisableKeepAlives:  config.DisableKeepAlives,
-		DisableCompression: config.DisableCompression,
-		// Dial with SSRF protection - validates resolved IPs before connecting
-		DialContext: ssrfSafeDialContext,
-	}
+	// Dial with SSRF protection - validates resolved IPs before connecting
+	transport := &outboundRoundTripper{kind: outboundTransportKind{
+		ssrfSafe:           true,
+		disableKeepAlives:  config.DisableKeepAlives,
+		disableCompression: config.DisableCompression,
+	}}
 
 	return &http.Client{
 		Timeout:   config.Timeout,
@@ -711,44 +756,52 @@ func NewSSRFSafeHTTPClient(config SSRFSafeHTTPClientConfig) *http.Client {
 // ssrfSafeDialContext is a custom dial function that validates the resolved IP addresses
 // before establishing a connection. This provides an additional layer of SSRF protection
 // against DNS rebinding attacks during the connection phase.
-func ssrfSafeDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+func ssrfSafeDialContext(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
 	// Parse host and port
 	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid address %s: %w", addr, err)
 	}
+	if err := checkSSRFTarget(ctx, host); err != nil {
+		return nil, err
+	}
 
-	// Check if the host is a restricted hostname
+	// If we get here, all IPs are safe. Connect using the standard dialer.
+	// We dial the original address so that proper connection routing happens.
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// checkSSRFTarget resolves a host and rejects it when it is restricted or resolves to a restricted IP
+func checkSSRFTarget(ctx context.Context, host string) error {
+	// Hosts allowed by the operator are reached without checking their addresses
 	hostLower := strings.ToLower(host)
+	if isSSRFAllowedHost(hostLower) {
+		return nil
+	}
+
+	// Check if the host is a restricted hostname
 	for _, restricted := range restrictedHostnames {
 		if hostLower == restricted {
-			return nil, fmt.Errorf("connection blocked: hostname %s is restricted", host)
+			return fmt.Errorf("connection blocked: hostname %s is restricted", host)
 		}
 	}
 	for _, suffix := range restrictedHostSuffixes {
 		if strings.HasSuffix(hostLower, suffix) {
-			return nil, fmt.Errorf("connection blocked: hostname suffix %s is restricted", suffix)
+			return fmt.Errorf("connection blocked: hostname suffix %s is restricted", suffix)
 		}
 	}
 
 	// Resolve the hostname to IP addresses
 	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
 	if err != nil {
-		return nil, fmt.Errorf("DNS resolution failed for %s: %w", host, err)
+		return fmt.Errorf("DNS resolution failed for %s: %w", host, err)
 	}
 
 	// Validate all resolved IPs
 	for _, ipAddr := range ips {
 		if restricted, reason := isRestrictedIP(ipAddr.IP); restricted {
-			return nil, fmt.Errorf("connection blocked: %s resolves to restricted IP %s (%s)", host, ipAddr.IP.String(), reason)
+			return fmt.Errorf("connection blocked: %s resolves to restricted IP %s (%s)", host, ipAddr.IP.String(), reason)
 		}
 	}
-
-	// If we get here, all IPs are safe. Connect using the standard dialer.
-	// We dial the original address so that proper connection routing happens.
-	dialer := &net.Dialer{
-		Timeout:   30 * time.Second,
-		KeepAlive: 30 * time.Second,
-	}
-	return dialer.DialContext(ctx, network, addr)
+	return nil
 }
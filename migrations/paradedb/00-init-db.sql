@@ -208,6 +208,18 @@ WITH (
     text_fields = '{
         "content": {
           "tokenizer": {"type": "chinese_lindera"}
+        },
+        "content_jieba": {
+          "column": "content",
+          "tokenizer": {"type": "jieba"}
+        },
+        "content_english": {
+          "column": "content",
+          "tokenizer": {"type": "default", "stemmer": "English"}
+        },
+        "content_code": {
+          "column": "content",
+          "tokenizer": {"type": "source_code"}
         }
     }'
 );
@@ -0,0 +1,10 @@
+-- Migration: 000013_hierarchical_tags (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Rolling back hierarchical tags...'; END $$;
+
+DROP INDEX IF EXISTS idx_knowledge_tag_relations_tag;
+DROP TABLE IF EXISTS knowledge_tag_relations;
+
+DROP INDEX IF EXISTS idx_knowledge_tags_parent_id;
+ALTER TABLE knowledge_tags DROP COLUMN IF EXISTS parent_id;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Rollback completed successfully!'; END $$;
@@ -0,0 +1,29 @@
+-- Migration: 000013_hierarchical_tags
+-- Description: Tag tree (parent/child) and multi-tag assignment for knowledge
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Adding parent_id to knowledge_tags...'; END $$;
+
+ALTER TABLE knowledge_tags ADD COLUMN IF NOT EXISTS parent_id VARCHAR(36) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_knowledge_tags_parent_id ON knowledge_tags(tenant_id, knowledge_base_id, parent_id);
+COMMENT ON COLUMN knowledge_tags.parent_id IS 'Parent tag ID; empty string means a root tag';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Creating table: knowledge_tag_relations'; END $$;
+CREATE TABLE IF NOT EXISTS knowledge_tag_relations (
+    knowledge_id VARCHAR(36) NOT NULL,
+    tag_id VARCHAR(36) NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (knowledge_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_knowledge_tag_relations_tag ON knowledge_tag_relations(tenant_id, knowledge_base_id, tag_id);
+
+COMMENT ON TABLE knowledge_tag_relations IS 'Additional tags assigned to knowledge entries (knowledges.tag_id keeps the primary tag)';
+
+-- Backfill relations from the existing single tag column
+INSERT INTO knowledge_tag_relations (knowledge_id, tag_id, tenant_id, knowledge_base_id)
+SELECT id, tag_id, tenant_id, knowledge_base_id
+FROM knowledges
+WHERE tag_id IS NOT NULL AND tag_id != '' AND deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Hierarchical tags setup completed successfully!'; END $$;
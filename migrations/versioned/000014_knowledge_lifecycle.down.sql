@@ -0,0 +1,11 @@
+-- Migration: 000014_knowledge_lifecycle (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000014] Rolling back knowledge lifecycle columns...'; END $$;
+
+DROP INDEX IF EXISTS idx_knowledges_archived_at;
+DROP INDEX IF EXISTS idx_knowledges_expires_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS expiry_notified_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS retention_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000014] Rollback completed successfully!'; END $$;
@@ -0,0 +1,19 @@
+-- Migration: 000014_knowledge_lifecycle
+-- Description: Knowledge expiry, archive state and per-KB retention policies
+DO $$ BEGIN RAISE NOTICE '[Migration 000014] Adding knowledge lifecycle columns...'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS retention_config JSON DEFAULT NULL;
+COMMENT ON COLUMN knowledge_bases.retention_config IS 'Retention policy: enabled, max_age_days, action (archive/delete), notify_before_days';
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS expiry_notified_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_knowledges_expires_at ON knowledges(expires_at) WHERE expires_at IS NOT NULL AND archived_at IS NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_knowledges_archived_at ON knowledges(archived_at) WHERE archived_at IS NOT NULL;
+
+COMMENT ON COLUMN knowledges.expires_at IS 'Explicit expiration time of the knowledge';
+COMMENT ON COLUMN knowledges.archived_at IS 'Archive time; archived knowledge is excluded from retrieval';
+COMMENT ON COLUMN knowledges.expiry_notified_at IS 'Time the expiring notification was sent';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000014] Knowledge lifecycle setup completed successfully!'; END $$;
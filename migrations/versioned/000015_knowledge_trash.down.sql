@@ -0,0 +1,7 @@
+-- Migration: 000015_knowledge_trash (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000015] Rolling back knowledge trash column...'; END $$;
+
+DROP INDEX IF EXISTS idx_knowledges_trashed_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS trashed_at;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000015] Rollback completed successfully!'; END $$;
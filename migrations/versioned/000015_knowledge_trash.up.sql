@@ -0,0 +1,11 @@
+-- Migration: 000015_knowledge_trash
+-- Description: Trash state for soft-deleted knowledge
+DO $$ BEGIN RAISE NOTICE '[Migration 000015] Adding knowledge trash column...'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS trashed_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_knowledges_trashed_at ON knowledges(trashed_at) WHERE trashed_at IS NOT NULL AND deleted_at IS NULL;
+
+COMMENT ON COLUMN knowledges.trashed_at IS 'Time the knowledge was moved to trash; trashed knowledge is excluded from listing and retrieval';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000015] Knowledge trash setup completed successfully!'; END $$;
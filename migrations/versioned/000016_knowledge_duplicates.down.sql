@@ -0,0 +1,11 @@
+-- Migration: 000016_knowledge_duplicates (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000016] Rolling back knowledge duplicate detection columns...'; END $$;
+
+DROP INDEX IF EXISTS idx_knowledges_kb_duplicate_of;
+DROP INDEX IF EXISTS idx_knowledges_kb_content_hash;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS duplicate_type;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS duplicate_of;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS sim_hash;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS content_hash;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000016] Rollback completed successfully!'; END $$;
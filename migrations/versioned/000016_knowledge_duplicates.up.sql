@@ -0,0 +1,18 @@
+-- Migration: 000016_knowledge_duplicates
+-- Description: Content fingerprints for duplicate-document detection
+DO $$ BEGIN RAISE NOTICE '[Migration 000016] Adding knowledge duplicate detection columns...'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS sim_hash BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS duplicate_of VARCHAR(36) NOT NULL DEFAULT '';
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS duplicate_type VARCHAR(16) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_knowledges_kb_content_hash ON knowledges(knowledge_base_id, content_hash) WHERE content_hash <> '' AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_knowledges_kb_duplicate_of ON knowledges(knowledge_base_id, duplicate_of) WHERE duplicate_of <> '' AND deleted_at IS NULL;
+
+COMMENT ON COLUMN knowledges.content_hash IS 'SHA-256 of the normalized extracted text';
+COMMENT ON COLUMN knowledges.sim_hash IS '64-bit SimHash fingerprint of the extracted text, 0 when too short';
+COMMENT ON COLUMN knowledges.duplicate_of IS 'ID of the knowledge this entry duplicates';
+COMMENT ON COLUMN knowledges.duplicate_type IS 'Duplicate kind: exact or near';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000016] Knowledge duplicate detection setup completed successfully!'; END $$;
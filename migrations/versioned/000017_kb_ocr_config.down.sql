@@ -0,0 +1,6 @@
+-- Migration: 000017_kb_ocr_config (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000017] Rolling back knowledge base OCR config...'; END $$;
+
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS ocr_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000017] Rollback completed successfully!'; END $$;
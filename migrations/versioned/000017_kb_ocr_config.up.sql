@@ -0,0 +1,8 @@
+-- Migration: 000017_kb_ocr_config
+-- Description: Per knowledge base OCR engine and language selection
+DO $$ BEGIN RAISE NOTICE '[Migration 000017] Adding knowledge base OCR config...'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS ocr_config JSON DEFAULT NULL;
+COMMENT ON COLUMN knowledge_bases.ocr_config IS 'OCR settings: engine (paddle/tesseract/vlm, empty for the DocReader default), languages (ISO 639-1 codes)';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000017] Knowledge base OCR config setup completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000018_kb_image_embedding (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000018] Rolling back knowledge base image embedding config...'; END $$;
+
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS image_embedding_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000018] Rollback completed successfully!'; END $$;
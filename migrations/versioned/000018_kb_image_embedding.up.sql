@@ -0,0 +1,8 @@
+-- Migration: 000018_kb_image_embedding
+-- Description: Per knowledge base image embedding for visual similarity search
+DO $$ BEGIN RAISE NOTICE '[Migration 000018] Adding knowledge base image embedding config...'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS image_embedding_config JSON DEFAULT NULL;
+COMMENT ON COLUMN knowledge_bases.image_embedding_config IS 'Image embedding settings: enabled, model_id (multimodal embedding model used for image knowledge and image queries)';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000018] Knowledge base image embedding config setup completed successfully!'; END $$;
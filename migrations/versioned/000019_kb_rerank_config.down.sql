@@ -0,0 +1,6 @@
+-- Migration: 000019_kb_rerank_config (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000019] Rolling back knowledge base rerank config...'; END $$;
+
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS rerank_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000019] Rollback completed successfully!'; END $$;
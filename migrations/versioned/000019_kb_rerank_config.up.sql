@@ -0,0 +1,8 @@
+-- Migration: 000019_kb_rerank_config
+-- Description: Per knowledge base rerank stage with score threshold and latency budget
+DO $$ BEGIN RAISE NOTICE '[Migration 000019] Adding knowledge base rerank config...'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS rerank_config JSON DEFAULT NULL;
+COMMENT ON COLUMN knowledge_bases.rerank_config IS 'Rerank settings: type (model, llm, none), model_id, threshold, timeout_ms';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000019] Knowledge base rerank config setup completed successfully!'; END $$;
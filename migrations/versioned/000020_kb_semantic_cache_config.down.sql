@@ -0,0 +1,6 @@
+-- Migration: 000020_kb_semantic_cache_config (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000020] Rolling back knowledge base semantic cache config...'; END $$;
+
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS semantic_cache_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000020] Rollback completed successfully!'; END $$;
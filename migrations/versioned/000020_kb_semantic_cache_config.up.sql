@@ -0,0 +1,8 @@
+-- Migration: 000020_kb_semantic_cache_config
+-- Description: Per knowledge base semantic answer cache settings
+DO $$ BEGIN RAISE NOTICE '[Migration 000020] Adding knowledge base semantic cache config...'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS semantic_cache_config JSON DEFAULT NULL;
+COMMENT ON COLUMN knowledge_bases.semantic_cache_config IS 'Semantic answer cache settings: enabled, threshold, ttl_seconds';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000020] Knowledge base semantic cache config setup completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000021_search_logs (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000021] Rolling back search logs...'; END $$;
+
+DROP TABLE IF EXISTS search_logs;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000021] Rollback completed successfully!'; END $$;
@@ -0,0 +1,34 @@
+-- Migration: 000021_search_logs
+-- Description: Search logs behind knowledge base search analytics
+DO $$ BEGIN RAISE NOTICE '[Migration 000021] Creating table: search_logs'; END $$;
+
+CREATE TABLE IF NOT EXISTS search_logs (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    source VARCHAR(16) NOT NULL DEFAULT 'chat',
+    query TEXT NOT NULL DEFAULT '',
+    query_hash VARCHAR(64) NOT NULL,
+    hit_count INTEGER NOT NULL DEFAULT 0,
+    top_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    click_count INTEGER NOT NULL DEFAULT 0,
+    clicked_chunk_ids JSON,
+    feedback VARCHAR(16) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_logs_kb_created ON search_logs(knowledge_base_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_search_logs_kb_query_hash ON search_logs(knowledge_base_id, query_hash);
+CREATE INDEX IF NOT EXISTS idx_search_logs_kb_message ON search_logs(knowledge_base_id, message_id);
+
+COMMENT ON TABLE search_logs IS 'One row per search against a knowledge base, used for search analytics';
+COMMENT ON COLUMN search_logs.source IS 'Search entry point: chat or search';
+COMMENT ON COLUMN search_logs.query IS 'Query text; empty when query text recording is disabled';
+COMMENT ON COLUMN search_logs.query_hash IS 'SHA-256 of the normalized query, groups repeated queries';
+COMMENT ON COLUMN search_logs.top_score IS 'Score of the best result after reranking';
+COMMENT ON COLUMN search_logs.feedback IS 'Answer feedback: positive, negative or empty';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000021] Search logs setup completed successfully!'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000022_retrieval_eval (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000022] Rolling back retrieval evaluation...'; END $$;
+
+DROP TABLE IF EXISTS retrieval_eval_runs;
+DROP TABLE IF EXISTS retrieval_eval_sets;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000022] Rollback completed successfully!'; END $$;
@@ -0,0 +1,45 @@
+-- Migration: 000022_retrieval_eval
+-- Description: Golden question sets and runs of the retrieval evaluation harness
+DO $$ BEGIN RAISE NOTICE '[Migration 000022] Creating tables: retrieval_eval_sets, retrieval_eval_runs'; END $$;
+
+CREATE TABLE IF NOT EXISTS retrieval_eval_sets (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cases JSON,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_retrieval_eval_sets_knowledge_base_id ON retrieval_eval_sets(knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_retrieval_eval_sets_deleted_at ON retrieval_eval_sets(deleted_at);
+
+CREATE TABLE IF NOT EXISTS retrieval_eval_runs (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    eval_set_id VARCHAR(36) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    config JSON,
+    metrics JSON,
+    results JSON,
+    total INTEGER NOT NULL DEFAULT 0,
+    finished INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_retrieval_eval_runs_eval_set_id ON retrieval_eval_runs(eval_set_id, created_at);
+
+COMMENT ON TABLE retrieval_eval_sets IS 'Golden question sets with the sources expected to answer each question';
+COMMENT ON TABLE retrieval_eval_runs IS 'Runs of a golden question set against a retrieval configuration';
+COMMENT ON COLUMN retrieval_eval_runs.config IS 'Effective retrieval configuration the run used';
+COMMENT ON COLUMN retrieval_eval_runs.metrics IS 'Aggregated recall@k, MRR and faithfulness';
+COMMENT ON COLUMN retrieval_eval_runs.results IS 'Per case retrieved results, ranks and judgements';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000022] Retrieval evaluation setup completed successfully!'; END $$;
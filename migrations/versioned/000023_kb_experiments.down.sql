@@ -0,0 +1,11 @@
+-- Migration: 000023_kb_experiments (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000023] Rolling back experiments...'; END $$;
+
+DROP INDEX IF EXISTS idx_search_logs_experiment;
+ALTER TABLE search_logs DROP COLUMN IF EXISTS variant;
+ALTER TABLE search_logs DROP COLUMN IF EXISTS experiment_id;
+ALTER TABLE search_logs DROP COLUMN IF EXISTS latency_ms;
+
+DROP TABLE IF EXISTS kb_experiments;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000023] Rollback completed successfully!'; END $$;
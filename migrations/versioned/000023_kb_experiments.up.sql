@@ -0,0 +1,42 @@
+-- Migration: 000023_kb_experiments
+-- Description: A/B experiments of knowledge base chat configurations, attributed through search logs
+DO $$ BEGIN RAISE NOTICE '[Migration 000023] Creating table: kb_experiments'; END $$;
+
+CREATE TABLE IF NOT EXISTS kb_experiments (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    traffic_percent INTEGER NOT NULL DEFAULT 50,
+    variant_a JSON,
+    variant_b JSON,
+    auto_promote JSON,
+    winner VARCHAR(8) NOT NULL DEFAULT '',
+    promoted_at TIMESTAMP WITH TIME ZONE,
+    stopped_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_experiments_knowledge_base_id ON kb_experiments(knowledge_base_id, status);
+-- A knowledge base runs at most one experiment at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kb_experiments_running ON kb_experiments(knowledge_base_id) WHERE status = 'running';
+
+COMMENT ON TABLE kb_experiments IS 'A/B experiments splitting chat traffic of a knowledge base between two configurations';
+COMMENT ON COLUMN kb_experiments.traffic_percent IS 'Share of chat sessions routed to variant B';
+COMMENT ON COLUMN kb_experiments.winner IS 'Promoted variant serving all chat traffic: a, b or empty';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000023] Adding experiment columns to search_logs'; END $$;
+
+ALTER TABLE search_logs ADD COLUMN IF NOT EXISTS latency_ms BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE search_logs ADD COLUMN IF NOT EXISTS experiment_id VARCHAR(36) NOT NULL DEFAULT '';
+ALTER TABLE search_logs ADD COLUMN IF NOT EXISTS variant VARCHAR(8) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_search_logs_experiment ON search_logs(experiment_id, variant) WHERE experiment_id <> '';
+
+COMMENT ON COLUMN search_logs.latency_ms IS 'Time until the answer started streaming, or until search results were returned';
+COMMENT ON COLUMN search_logs.experiment_id IS 'Experiment the search was routed through, empty outside experiments';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000023] Experiments setup completed successfully!'; END $$;
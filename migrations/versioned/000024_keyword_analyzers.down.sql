@@ -0,0 +1,25 @@
+-- Migration: 000024_keyword_analyzers (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000024] Rolling back keyword analyzers...'; END $$;
+
+DO $$
+BEGIN
+    IF current_setting('app.skip_embedding', true) = 'true' OR to_regclass('embeddings') IS NULL THEN
+        RETURN;
+    END IF;
+
+    DROP INDEX IF EXISTS embeddings_search_idx;
+    CREATE INDEX embeddings_search_idx ON embeddings
+    USING bm25 (id, knowledge_base_id, content, knowledge_id, chunk_id)
+    WITH (
+        key_field = 'id',
+        text_fields = '{
+            "content": {
+              "tokenizer": {"type": "chinese_lindera"}
+            }
+        }'
+    );
+END $$;
+
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS keyword_search_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000024] Rollback completed successfully!'; END $$;
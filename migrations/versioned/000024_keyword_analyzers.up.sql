@@ -0,0 +1,47 @@
+-- Migration: 000024_keyword_analyzers
+-- Description: Per knowledge base keyword analyzer and fusion weights, BM25 index fields per analyzer
+DO $$ BEGIN RAISE NOTICE '[Migration 000024] Adding knowledge base keyword search config...'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS keyword_search_config JSON DEFAULT NULL;
+COMMENT ON COLUMN knowledge_bases.keyword_search_config IS 'Keyword search settings: analyzer, vector_weight, keyword_weight';
+
+DO $$
+BEGIN
+    IF current_setting('app.skip_embedding', true) = 'true' THEN
+        RAISE NOTICE '[Migration 000024] Skipping BM25 index update (app.skip_embedding=true)';
+        RETURN;
+    END IF;
+    IF to_regclass('embeddings') IS NULL THEN
+        RAISE NOTICE '[Migration 000024] Skipping BM25 index update, embeddings table does not exist';
+        RETURN;
+    END IF;
+
+    -- The content column is indexed once per analyzer, a knowledge base queries the field of its analyzer
+    RAISE NOTICE '[Migration 000024] Rebuilding BM25 index embeddings_search_idx (this may take a while)...';
+    DROP INDEX IF EXISTS embeddings_search_idx;
+    CREATE INDEX embeddings_search_idx ON embeddings
+    USING bm25 (id, knowledge_base_id, content, knowledge_id, chunk_id)
+    WITH (
+        key_field = 'id',
+        text_fields = '{
+            "content": {
+              "tokenizer": {"type": "chinese_lindera"}
+            },
+            "content_jieba": {
+              "column": "content",
+              "tokenizer": {"type": "jieba"}
+            },
+            "content_english": {
+              "column": "content",
+              "tokenizer": {"type": "default", "stemmer": "English"}
+            },
+            "content_code": {
+              "column": "content",
+              "tokenizer": {"type": "source_code"}
+            }
+        }'
+    );
+    RAISE NOTICE '[Migration 000024] Rebuilt BM25 index embeddings_search_idx';
+END $$;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000024] Keyword analyzers setup completed successfully!'; END $$;
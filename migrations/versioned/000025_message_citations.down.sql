@@ -0,0 +1,6 @@
+-- Migration: 000025_message_citations (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000025] Rolling back message citations...'; END $$;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS citations;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000025] Rollback completed successfully!'; END $$;
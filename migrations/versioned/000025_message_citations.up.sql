@@ -0,0 +1,8 @@
+-- Migration: 000025_message_citations
+-- Description: Citations of assistant answers, mapping inline markers to the cited chunks
+DO $$ BEGIN RAISE NOTICE '[Migration 000025] Adding message citations...'; END $$;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS citations JSONB DEFAULT NULL;
+COMMENT ON COLUMN messages.citations IS 'Chunks cited by inline markers of the answer: label, chunk, file name, pages, heading path, source URL';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000025] Message citations setup completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000026_answer_feedback (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000026] Rolling back answer feedback...'; END $$;
+
+DROP TABLE IF EXISTS answer_feedback;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000026] Rollback completed successfully!'; END $$;
@@ -0,0 +1,34 @@
+-- Migration: 000026_answer_feedback
+-- Description: Feedback on answers tied to the retrieved chunks, curated per knowledge base
+DO $$ BEGIN RAISE NOTICE '[Migration 000026] Creating table: answer_feedback'; END $$;
+
+CREATE TABLE IF NOT EXISTS answer_feedback (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    rating VARCHAR(8) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    query TEXT NOT NULL DEFAULT '',
+    answer TEXT NOT NULL DEFAULT '',
+    chunks JSONB,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    resolution_note TEXT NOT NULL DEFAULT '',
+    resolved_by VARCHAR(36) NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_answer_feedback_message ON answer_feedback(message_id);
+CREATE INDEX IF NOT EXISTS idx_answer_feedback_kb_status ON answer_feedback(knowledge_base_id, status, rating, created_at);
+
+COMMENT ON TABLE answer_feedback IS 'Feedback on assistant messages, one row per knowledge base the answer retrieved from';
+COMMENT ON COLUMN answer_feedback.knowledge_base_id IS 'Knowledge base whose administrators curate the feedback; empty when the answer retrieved nothing';
+COMMENT ON COLUMN answer_feedback.rating IS 'up or down';
+COMMENT ON COLUMN answer_feedback.chunks IS 'Chunks of the knowledge base the answer retrieved: chunk_id, knowledge_id, title, score, cited, content';
+COMMENT ON COLUMN answer_feedback.status IS 'Curation status: open, resolved or dismissed';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000026] Answer feedback setup completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000027_kb_prompt_templates (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000027] Rolling back prompt templates...'; END $$;
+
+DROP TABLE IF EXISTS kb_prompt_templates;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000027] Rollback completed successfully!'; END $$;
@@ -0,0 +1,33 @@
+-- Migration: 000027_kb_prompt_templates
+-- Description: Versioned prompt templates applied to answers of a knowledge base
+DO $$ BEGIN RAISE NOTICE '[Migration 000027] Creating table: kb_prompt_templates'; END $$;
+
+CREATE TABLE IF NOT EXISTS kb_prompt_templates (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    version INTEGER NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'draft',
+    system_prompt TEXT NOT NULL DEFAULT '',
+    context_template TEXT NOT NULL DEFAULT '',
+    language VARCHAR(64) NOT NULL DEFAULT '',
+    tone VARCHAR(255) NOT NULL DEFAULT '',
+    citation_style VARCHAR(16) NOT NULL DEFAULT '',
+    refusal_policy VARCHAR(16) NOT NULL DEFAULT '',
+    refusal_message TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kb_prompt_templates_version ON kb_prompt_templates(knowledge_base_id, version);
+CREATE INDEX IF NOT EXISTS idx_kb_prompt_templates_status ON kb_prompt_templates(knowledge_base_id, status);
+
+COMMENT ON TABLE kb_prompt_templates IS 'Versioned prompt templates of knowledge bases, at most one published version per knowledge base';
+COMMENT ON COLUMN kb_prompt_templates.status IS 'draft, published or archived; publishing an archived version rolls back to it';
+COMMENT ON COLUMN kb_prompt_templates.citation_style IS 'inline or none, empty keeps inline citations';
+COMMENT ON COLUMN kb_prompt_templates.refusal_policy IS 'strict or lenient, empty adds no refusal instruction';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000027] Prompt templates setup completed successfully!'; END $$;
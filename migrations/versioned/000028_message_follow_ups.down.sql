@@ -0,0 +1,6 @@
+-- Migration: 000028_message_follow_ups (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000028] Rolling back message follow-up questions...'; END $$;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS follow_up_questions;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000028] Rollback completed successfully!'; END $$;
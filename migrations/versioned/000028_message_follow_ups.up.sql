@@ -0,0 +1,9 @@
+-- Migration: 000028_message_follow_ups
+-- Description: Store follow-up questions suggested after assistant answers
+DO $$ BEGIN RAISE NOTICE '[Migration 000028] Adding column: messages.follow_up_questions'; END $$;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS follow_up_questions JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN messages.follow_up_questions IS 'Follow-up questions suggested after the answer';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000028] Migration completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000029_tenant_agent_tool_policy (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000029] Rolling back tenant agent tool policy...'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS agent_tool_policy;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000029] Rollback completed successfully!'; END $$;
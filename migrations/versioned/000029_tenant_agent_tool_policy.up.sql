@@ -0,0 +1,9 @@
+-- Migration: 000029_tenant_agent_tool_policy
+-- Description: Tenant-level enablement of agent tools
+DO $$ BEGIN RAISE NOTICE '[Migration 000029] Adding column: tenants.agent_tool_policy'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS agent_tool_policy JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN tenants.agent_tool_policy IS 'Agent tools disabled for every agent of the tenant';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000029] Migration completed successfully!'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000030_token_usage (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000030] Rolling back token usage tracking...'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS monthly_token_budget;
+DROP TABLE IF EXISTS token_usage_records;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000030] Rollback completed successfully!'; END $$;
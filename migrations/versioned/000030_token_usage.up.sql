@@ -0,0 +1,28 @@
+-- Migration: 000030_token_usage
+-- Description: Per-call LLM token usage records and tenant monthly token budgets
+DO $$ BEGIN RAISE NOTICE '[Migration 000030] Creating table: token_usage_records'; END $$;
+
+CREATE TABLE IF NOT EXISTS token_usage_records (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    model_id VARCHAR(64) NOT NULL DEFAULT '',
+    model_name VARCHAR(255) NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    estimated BOOLEAN NOT NULL DEFAULT FALSE,
+    cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_usage_records_tenant_created ON token_usage_records(tenant_id, created_at);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000030] Adding column: tenants.monthly_token_budget'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS monthly_token_budget BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN tenants.monthly_token_budget IS 'Monthly LLM token budget of the tenant, 0 means unlimited';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000030] Migration completed successfully!'; END $$;
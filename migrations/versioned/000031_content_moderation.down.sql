@@ -0,0 +1,7 @@
+-- Migration: 000031_content_moderation (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000031] Rolling back content moderation...'; END $$;
+
+DROP TABLE IF EXISTS moderation_events;
+ALTER TABLE tenants DROP COLUMN IF EXISTS moderation_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000031] Rollback completed successfully!'; END $$;
@@ -0,0 +1,31 @@
+-- Migration: 000031_content_moderation
+-- Description: Tenant content moderation rules and the audit trail of moderated questions and answers
+DO $$ BEGIN RAISE NOTICE '[Migration 000031] Adding column: tenants.moderation_config'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS moderation_config JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN tenants.moderation_config IS 'Content moderation rules applied to questions and answers';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000031] Creating table: moderation_events'; END $$;
+
+CREATE TABLE IF NOT EXISTS moderation_events (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    stage VARCHAR(16) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    matches JSONB NOT NULL DEFAULT '[]',
+    content TEXT NOT NULL DEFAULT '',
+    review_status VARCHAR(16) NOT NULL DEFAULT 'none',
+    review_note TEXT NOT NULL DEFAULT '',
+    reviewed_by VARCHAR(36) NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_events_tenant_created ON moderation_events(tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_moderation_events_tenant_review ON moderation_events(tenant_id, review_status);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000031] Migration completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000032_local_providers (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000032] Rolling back local provider config...'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS local_provider_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000032] Rollback completed successfully!'; END $$;
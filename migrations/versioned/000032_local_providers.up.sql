@@ -0,0 +1,9 @@
+-- Migration: 000032_local_providers
+-- Description: Tenant configuration of self-hosted Ollama and vLLM servers
+DO $$ BEGIN RAISE NOTICE '[Migration 000032] Adding column: tenants.local_provider_config'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS local_provider_config JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN tenants.local_provider_config IS 'Self-hosted Ollama and vLLM servers used by models without a base URL';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000032] Migration completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000033_model_health_probes (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000033] Rolling back model health probes...'; END $$;
+
+ALTER TABLE models DROP COLUMN IF EXISTS last_probe;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000033] Rollback completed successfully!'; END $$;
@@ -0,0 +1,9 @@
+-- Migration: 000033_model_health_probes
+-- Description: Result of the periodic health probe of each model
+DO $$ BEGIN RAISE NOTICE '[Migration 000033] Adding column: models.last_probe'; END $$;
+
+ALTER TABLE models ADD COLUMN IF NOT EXISTS last_probe JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN models.last_probe IS 'Latency, error and detected embedding dimension of the last health probe';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000033] Migration completed successfully!'; END $$;
@@ -0,0 +1,8 @@
+-- Migration: 000034_user_external_identity (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000034] Rolling back user external identity...'; END $$;
+
+DROP INDEX IF EXISTS idx_users_external_identity;
+ALTER TABLE users DROP COLUMN IF EXISTS external_id;
+ALTER TABLE users DROP COLUMN IF EXISTS auth_provider;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000034] Rollback completed successfully!'; END $$;
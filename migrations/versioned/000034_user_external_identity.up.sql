@@ -0,0 +1,15 @@
+-- Migration: 000034_user_external_identity
+-- Description: Identity provider and subject of users signing in with single sign-on
+DO $$ BEGIN RAISE NOTICE '[Migration 000034] Adding columns: users.auth_provider, users.external_id'; END $$;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_provider VARCHAR(100) DEFAULT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id VARCHAR(255) DEFAULT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_identity
+    ON users (auth_provider, external_id)
+    WHERE external_id IS NOT NULL AND external_id <> '' AND deleted_at IS NULL;
+
+COMMENT ON COLUMN users.auth_provider IS 'Identity provider the user signs in with, empty for local password accounts';
+COMMENT ON COLUMN users.external_id IS 'Subject of the user at the identity provider';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000034] Migration completed successfully!'; END $$;
@@ -0,0 +1,8 @@
+-- Migration: 000035_kb_permissions (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000035] Rolling back knowledge base permissions...'; END $$;
+
+ALTER TABLE knowledges DROP COLUMN IF EXISTS created_by;
+DROP TABLE IF EXISTS knowledge_permissions;
+DROP TABLE IF EXISTS kb_members;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000035] Rollback completed successfully!'; END $$;
@@ -0,0 +1,46 @@
+-- Migration: 000035_kb_permissions
+-- Description: Roles granted on knowledge bases, per-document permissions and document authorship
+DO $$ BEGIN RAISE NOTICE '[Migration 000035] Creating table: kb_members'; END $$;
+
+CREATE TABLE IF NOT EXISTS kb_members (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    granted_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kb_members_kb_user ON kb_members (knowledge_base_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_kb_members_user_id ON kb_members (user_id);
+
+COMMENT ON TABLE kb_members IS 'Roles granted to users on knowledge bases, taking precedence over tenant and share roles';
+COMMENT ON COLUMN kb_members.role IS 'owner, editor, contributor or viewer';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000035] Creating table: knowledge_permissions'; END $$;
+
+CREATE TABLE IF NOT EXISTS knowledge_permissions (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    knowledge_id VARCHAR(36) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    granted_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_permissions_knowledge_user ON knowledge_permissions (knowledge_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_permissions_kb_user_role ON knowledge_permissions (knowledge_base_id, user_id, role);
+
+COMMENT ON TABLE knowledge_permissions IS 'Per-document overrides of the knowledge base role of users';
+COMMENT ON COLUMN knowledge_permissions.role IS 'editor, viewer or none (hidden from the user)';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000035] Adding column: knowledges.created_by'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS created_by VARCHAR(36) DEFAULT NULL;
+
+COMMENT ON COLUMN knowledges.created_by IS 'User who added the document, contributors may edit their own documents';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000035] Migration completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000036_api_keys (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000036] Rolling back scoped API keys...'; END $$;
+
+DROP TABLE IF EXISTS api_keys;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000036] Rollback completed successfully!'; END $$;
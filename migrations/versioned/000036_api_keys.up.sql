@@ -0,0 +1,30 @@
+-- Migration: 000036_api_keys
+-- Description: Scoped API keys of tenants with knowledge base restrictions, expiry and last use
+DO $$ BEGIN RAISE NOTICE '[Migration 000036] Creating table: api_keys'; END $$;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    key_prefix VARCHAR(32),
+    key_hash VARCHAR(64) NOT NULL,
+    scope VARCHAR(32) NOT NULL,
+    knowledge_base_ids JSONB,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+COMMENT ON TABLE api_keys IS 'Scoped API keys of tenants, in addition to the tenant API key';
+COMMENT ON COLUMN api_keys.key_hash IS 'SHA-256 of the key, the key itself is only returned when created or rotated';
+COMMENT ON COLUMN api_keys.scope IS 'retrieval, ingest or admin';
+COMMENT ON COLUMN api_keys.knowledge_base_ids IS 'Knowledge bases the key is restricted to, empty for all of the tenant';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000036] Migration completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000037_tenant_quota (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000037] Rolling back tenant quotas...'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS quota;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000037] Rollback completed successfully!'; END $$;
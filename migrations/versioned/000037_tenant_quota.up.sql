@@ -0,0 +1,9 @@
+-- Migration: 000037_tenant_quota
+-- Description: Add per tenant caps on knowledge bases, documents, concurrent parse jobs and browser sessions
+DO $$ BEGIN RAISE NOTICE '[Migration 000037] Adding column: tenants.quota'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS quota JSONB;
+
+COMMENT ON COLUMN tenants.quota IS 'Resource caps of the tenant, unset caps use the deployment defaults and negative caps are unlimited';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000037] Migration completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000038_kb_invites (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000038] Rolling back knowledge base invites...'; END $$;
+
+DROP TABLE IF EXISTS kb_invites;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000038] Rollback completed successfully!'; END $$;
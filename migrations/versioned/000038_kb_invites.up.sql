@@ -0,0 +1,24 @@
+-- Migration: 000038_kb_invites
+-- Description: Expiring invite links granting roles on knowledge bases
+DO $$ BEGIN RAISE NOTICE '[Migration 000038] Creating table: kb_invites'; END $$;
+
+CREATE TABLE IF NOT EXISTS kb_invites (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    max_uses INTEGER NOT NULL DEFAULT 0,
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_invites_knowledge_base_id ON kb_invites (knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_kb_invites_deleted_at ON kb_invites (deleted_at);
+
+COMMENT ON TABLE kb_invites IS 'Invite links granting a role on a knowledge base to the users who accept them, revoked invites are soft deleted';
+COMMENT ON COLUMN kb_invites.max_uses IS 'How many users can accept the invite, 0 is unlimited';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000038] Migration completed successfully!'; END $$;
@@ -0,0 +1,8 @@
+-- Migration: 000039_service_accounts (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000039] Rolling back service accounts...'; END $$;
+
+DROP INDEX IF EXISTS idx_api_keys_service_account_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS service_account_id;
+DROP TABLE IF EXISTS service_accounts;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000039] Rollback completed successfully!'; END $$;
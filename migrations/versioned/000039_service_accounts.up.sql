@@ -0,0 +1,32 @@
+-- Migration: 000039_service_accounts
+-- Description: Service accounts of tenants authenticating with their own scoped API keys
+DO $$ BEGIN RAISE NOTICE '[Migration 000039] Creating table: service_accounts'; END $$;
+
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    scopes JSONB,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_accounts_tenant_id ON service_accounts (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_service_accounts_deleted_at ON service_accounts (deleted_at);
+
+COMMENT ON TABLE service_accounts IS 'Non-human identities of tenants, they cannot log in and authenticate with their own API keys';
+COMMENT ON COLUMN service_accounts.id IS 'Prefixed with sa- so records created by service accounts can be told apart from users';
+COMMENT ON COLUMN service_accounts.scopes IS 'Scopes the keys of the account may have: retrieval, ingest';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000039] Adding column: api_keys.service_account_id'; END $$;
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS service_account_id VARCHAR(36);
+CREATE INDEX IF NOT EXISTS idx_api_keys_service_account_id ON api_keys (service_account_id);
+
+COMMENT ON COLUMN api_keys.service_account_id IS 'Service account the key authenticates as, empty for keys of the tenant';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000039] Migration completed successfully!'; END $$;
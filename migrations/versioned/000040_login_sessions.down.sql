@@ -0,0 +1,9 @@
+-- Migration: 000040_login_sessions (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000040] Rolling back login sessions...'; END $$;
+
+DROP INDEX IF EXISTS idx_auth_tokens_session_id;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE auth_tokens DROP COLUMN IF EXISTS session_id;
+DROP TABLE IF EXISTS login_sessions;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000040] Rollback completed successfully!'; END $$;
@@ -0,0 +1,29 @@
+-- Migration: 000040_login_sessions
+-- Description: Login sessions grouping the tokens issued to a device, with device, IP and last activity
+DO $$ BEGIN RAISE NOTICE '[Migration 000040] Creating table: login_sessions'; END $$;
+
+CREATE TABLE IF NOT EXISTS login_sessions (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(36) NOT NULL,
+    user_agent TEXT,
+    ip_address VARCHAR(64),
+    last_active_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_sessions_user_id ON login_sessions (user_id);
+
+COMMENT ON TABLE login_sessions IS 'Logins of users on a device, kept across token refreshes until logout, revocation or expiry';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000040] Adding columns: auth_tokens.session_id, auth_tokens.last_used_at'; END $$;
+
+ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS session_id VARCHAR(36);
+ALTER TABLE auth_tokens ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_session_id ON auth_tokens (session_id);
+
+COMMENT ON COLUMN auth_tokens.session_id IS 'Login session the token was issued to, empty for tokens issued before sessions were recorded';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000040] Migration completed successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000041_tenant_ip_policy (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000041] Rolling back tenant IP policy...'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS ip_policy;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000041] Rollback completed successfully!'; END $$;
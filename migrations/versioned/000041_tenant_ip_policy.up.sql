@@ -0,0 +1,9 @@
+-- Migration: 000041_tenant_ip_policy
+-- Description: Per-tenant IP allow and deny lists for the API and internal service callbacks
+DO $$ BEGIN RAISE NOTICE '[Migration 000041] Adding column: tenants.ip_policy'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS ip_policy JSONB;
+
+COMMENT ON COLUMN tenants.ip_policy IS 'IP allow and deny lists of the tenant, separately for the API and internal service callbacks';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000041] Tenant IP policy added successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000042_jobs (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000042] Rolling back jobs...'; END $$;
+
+DROP TABLE IF EXISTS jobs;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000042] Rollback completed successfully!'; END $$;
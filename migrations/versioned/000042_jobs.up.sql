@@ -0,0 +1,31 @@
+-- Migration: 000042_jobs
+-- Description: Status and history of the background jobs of tenants run on the task queue
+DO $$ BEGIN RAISE NOTICE '[Migration 000042] Creating table: jobs'; END $$;
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id VARCHAR(64) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    queue VARCHAR(32) NOT NULL DEFAULT 'default',
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',
+    knowledge_base_id VARCHAR(36),
+    knowledge_id VARCHAR(36),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_retry INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_id ON jobs (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs (type);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at ON jobs (finished_at);
+
+COMMENT ON TABLE jobs IS 'Background jobs of tenants, such as document parsing and re-indexing, with their status, attempts and last error';
+COMMENT ON COLUMN jobs.id IS 'ID of the task on the queue';
+COMMENT ON COLUMN jobs.queue IS 'Priority queue the job runs on: critical, default or low';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000042] Jobs table created successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000043_tenant_backups (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000043] Rolling back tenant_backups...'; END $$;
+
+DROP TABLE IF EXISTS tenant_backups;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000043] Rollback completed successfully!'; END $$;
@@ -0,0 +1,29 @@
+-- Migration: 000043_tenant_backups
+-- Description: Backups of the rows, files and vectors of tenants stored in object storage
+DO $$ BEGIN RAISE NOTICE '[Migration 000043] Creating table: tenant_backups'; END $$;
+
+CREATE TABLE IF NOT EXISTS tenant_backups (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    file_path VARCHAR(512),
+    format_version INTEGER NOT NULL DEFAULT 1,
+    schema_version INTEGER NOT NULL DEFAULT 0,
+    size BIGINT NOT NULL DEFAULT 0,
+    sha256 VARCHAR(64),
+    rows BIGINT NOT NULL DEFAULT 0,
+    files INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_backups_tenant_id ON tenant_backups (tenant_id, created_at DESC);
+
+COMMENT ON TABLE tenant_backups IS 'Backups of tenants: database rows, original files and vectors archived in object storage';
+COMMENT ON COLUMN tenant_backups.file_path IS 'Path of the backup archive in object storage';
+COMMENT ON COLUMN tenant_backups.schema_version IS 'Migration version the rows were exported from';
+COMMENT ON COLUMN tenant_backups.sha256 IS 'SHA-256 checksum of the whole archive';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000043] Tenant backups table created successfully!'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000044_webhooks (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000044] Rolling back webhooks...'; END $$;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000044] Rollback completed successfully!'; END $$;
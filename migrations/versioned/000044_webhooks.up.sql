@@ -0,0 +1,48 @@
+-- Migration: 000044_webhooks
+-- Description: Webhook endpoints of tenants and the deliveries of events to them
+DO $$ BEGIN RAISE NOTICE '[Migration 000044] Creating tables: webhooks, webhook_deliveries'; END $$;
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    events JSONB NOT NULL DEFAULT '[]',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_tenant_id ON webhooks (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_webhooks_deleted_at ON webhooks (deleted_at);
+
+COMMENT ON TABLE webhooks IS 'HTTP endpoints of tenants receiving signed knowledge lifecycle events';
+COMMENT ON COLUMN webhooks.secret IS 'Secret the HMAC-SHA256 signature of payloads is keyed by';
+COMMENT ON COLUMN webhooks.events IS 'Events the webhook subscribes to, such as parse.completed';
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id VARCHAR(36) PRIMARY KEY,
+    webhook_id VARCHAR(36) NOT NULL,
+    tenant_id BIGINT NOT NULL,
+    event VARCHAR(64) NOT NULL,
+    payload JSONB,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant_id ON webhook_deliveries (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries (created_at);
+
+COMMENT ON TABLE webhook_deliveries IS 'Deliveries of events to webhooks with the outcome of their last attempt';
+COMMENT ON COLUMN webhook_deliveries.response_status IS 'HTTP status of the last attempt, 0 when no response was received';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000044] Webhook tables created successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000045_tenant_locale (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000045] Rolling back tenant locale...'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS locale;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000045] Rollback completed successfully!'; END $$;
@@ -0,0 +1,9 @@
+-- Migration: 000045_tenant_locale
+-- Description: Language of API messages of tenants whose requests ask for none
+DO $$ BEGIN RAISE NOTICE '[Migration 000045] Adding column: tenants.locale'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS locale VARCHAR(16) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN tenants.locale IS 'Language of API messages when requests send no supported Accept-Language, such as en-US; empty for the deployment default';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000045] Tenant locale added successfully!'; END $$;
@@ -0,0 +1,9 @@
+-- Migration: 000046_list_sort_indexes (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000046] Rolling back listing sort indexes...'; END $$;
+
+DROP INDEX IF EXISTS idx_knowledges_kb_created_at_id;
+DROP INDEX IF EXISTS idx_knowledges_kb_updated_at_id;
+DROP INDEX IF EXISTS idx_sessions_tenant_created_at_id;
+DROP INDEX IF EXISTS idx_sessions_tenant_updated_at_id;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000046] Rollback completed successfully!'; END $$;
@@ -0,0 +1,10 @@
+-- Migration: 000046_list_sort_indexes
+-- Description: Indexes serving cursor pagination of knowledge and session listings by their sort fields
+DO $$ BEGIN RAISE NOTICE '[Migration 000046] Creating listing sort indexes on knowledges and sessions'; END $$;
+
+CREATE INDEX IF NOT EXISTS idx_knowledges_kb_created_at_id ON knowledges (knowledge_base_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_knowledges_kb_updated_at_id ON knowledges (knowledge_base_id, updated_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_sessions_tenant_created_at_id ON sessions (tenant_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_sessions_tenant_updated_at_id ON sessions (tenant_id, updated_at, id) WHERE deleted_at IS NULL;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000046] Listing sort indexes created successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000047_knowledge_file_links (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000047] Rolling back knowledge_file_links...'; END $$;
+
+DROP TABLE IF EXISTS knowledge_file_links;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000047] Rollback completed successfully!'; END $$;
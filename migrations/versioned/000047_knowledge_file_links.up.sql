@@ -0,0 +1,26 @@
+-- Migration: 000047_knowledge_file_links
+-- Description: Expiring signed links to knowledge files, with download limits and revocation
+DO $$ BEGIN RAISE NOTICE '[Migration 000047] Creating table: knowledge_file_links'; END $$;
+
+CREATE TABLE IF NOT EXISTS knowledge_file_links (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    scope VARCHAR(16) NOT NULL,
+    max_downloads INTEGER NOT NULL DEFAULT 0,
+    download_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_file_links_tenant_id ON knowledge_file_links (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_file_links_knowledge_id ON knowledge_file_links (knowledge_id);
+
+COMMENT ON TABLE knowledge_file_links IS 'Signed URLs serving knowledge files without an API key';
+COMMENT ON COLUMN knowledge_file_links.scope IS 'view serves the file inline, download as an attachment';
+COMMENT ON COLUMN knowledge_file_links.max_downloads IS 'Fetches allowed through the link, 0 for no limit';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000047] Knowledge file links table created successfully!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000048_knowledge_capture (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000048] Rolling back knowledges.capture...'; END $$;
+
+ALTER TABLE knowledges DROP COLUMN IF EXISTS capture;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000048] Rollback completed successfully!'; END $$;
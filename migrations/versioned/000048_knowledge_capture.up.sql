@@ -0,0 +1,9 @@
+-- Migration: 000048_knowledge_capture
+-- Description: Provenance of knowledge captured from URLs
+DO $$ BEGIN RAISE NOTICE '[Migration 000048] Adding column: knowledges.capture'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS capture JSONB;
+
+COMMENT ON COLUMN knowledges.capture IS 'Source URL, time, method, user, extraction engine and content hash of a URL capture';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000048] Knowledge capture column added successfully!'; END $$;
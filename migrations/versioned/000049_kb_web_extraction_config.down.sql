@@ -0,0 +1,6 @@
+-- Migration: 000049_kb_web_extraction_config (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000049] Rolling back knowledge base web extraction config...'; END $$;
+
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS web_extraction_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000049] Rollback completed successfully!'; END $$;
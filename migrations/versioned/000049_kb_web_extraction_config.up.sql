@@ -0,0 +1,8 @@
+-- Migration: 000049_kb_web_extraction_config
+-- Description: Per knowledge base extraction mode of web pages imported from a URL
+DO $$ BEGIN RAISE NOTICE '[Migration 000049] Adding knowledge base web extraction config...'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS web_extraction_config JSON DEFAULT NULL;
+COMMENT ON COLUMN knowledge_bases.web_extraction_config IS 'Web extraction settings: mode (full/readability/llm, empty for readability)';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000049] Knowledge base web extraction config setup completed successfully!'; END $$;
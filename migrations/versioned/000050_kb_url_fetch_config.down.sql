@@ -0,0 +1,6 @@
+-- Migration: 000050_kb_url_fetch_config (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000050] Rolling back knowledge base URL fetch config...'; END $$;
+
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS url_fetch_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000050] Rollback completed successfully!'; END $$;
@@ -0,0 +1,8 @@
+-- Migration: 000050_kb_url_fetch_config
+-- Description: Per knowledge base User-Agent, headers and cookies of URL knowledge fetching
+DO $$ BEGIN RAISE NOTICE '[Migration 000050] Adding knowledge base URL fetch config...'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS url_fetch_config JSON DEFAULT NULL;
+COMMENT ON COLUMN knowledge_bases.url_fetch_config IS 'URL fetch settings: user_agent, headers, cookies (cookies and credential headers encrypted)';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000050] Knowledge base URL fetch config setup completed successfully!'; END $$;
@@ -0,0 +1,9 @@
+-- Migration: 000051_knowledge_link_check (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000051] Rolling back knowledge link check columns...'; END $$;
+
+DROP INDEX IF EXISTS idx_knowledges_link_status;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS link_check;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS link_checked_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS link_status;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000051] Rollback completed successfully!'; END $$;
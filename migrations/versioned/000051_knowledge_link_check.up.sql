@@ -0,0 +1,15 @@
+-- Migration: 000051_knowledge_link_check
+-- Description: Status of the source URL of knowledge imported from the web, checked periodically
+DO $$ BEGIN RAISE NOTICE '[Migration 000051] Adding knowledge link check columns...'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS link_status VARCHAR(16) DEFAULT NULL;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS link_checked_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS link_check JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_knowledges_link_status ON knowledges(knowledge_base_id, link_status) WHERE link_status IS NOT NULL AND deleted_at IS NULL;
+
+COMMENT ON COLUMN knowledges.link_status IS 'Status of the source URL at its last check: ok, dead, changed or unreachable';
+COMMENT ON COLUMN knowledges.link_checked_at IS 'Time the source URL was last checked';
+COMMENT ON COLUMN knowledges.link_check IS 'Status code, final URL, reasons and page title of the last source URL check';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000051] Knowledge link check setup completed successfully!'; END $$;
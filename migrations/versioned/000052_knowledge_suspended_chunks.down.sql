@@ -0,0 +1,6 @@
+-- Migration: 000052_knowledge_suspended_chunks (down)
+DO $$ BEGIN RAISE NOTICE '[Migration 000052] Rolling back knowledge suspended chunks column...'; END $$;
+
+ALTER TABLE knowledges DROP COLUMN IF EXISTS suspended_chunk_ids;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000052] Rollback completed successfully!'; END $$;
@@ -0,0 +1,9 @@
+-- Migration: 000052_knowledge_suspended_chunks
+-- Description: Chunks disabled by archiving or trashing a knowledge, enabled again when it is restored
+DO $$ BEGIN RAISE NOTICE '[Migration 000052] Adding knowledge suspended chunks column...'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS suspended_chunk_ids JSONB;
+
+COMMENT ON COLUMN knowledges.suspended_chunk_ids IS 'IDs of the chunks that were enabled when the knowledge was archived or trashed';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000052] Knowledge suspended chunks setup completed successfully!'; END $$;